@@ -32,13 +32,17 @@ data class Message(
     val timestamp: Long? = null,
 
     @SerialName("data")
-    val data: JsonElement? = null
+    val data: JsonElement? = null,
+
+    @SerialName("token")
+    val token: String? = null
 ) {
     companion object {
-        fun hello(deviceId: String, sdkVersion: String): Message = Message(
+        fun hello(deviceId: String, sdkVersion: String, token: String? = null): Message = Message(
             type = "hello",
             deviceId = deviceId,
-            sdkVersion = sdkVersion
+            sdkVersion = sdkVersion,
+            token = token
         )
 
         fun state(droneState: DroneState): Message = Message(
@@ -30,7 +30,8 @@ enum class ConnectionState {
  */
 class TcpClient(
     private val deviceId: String,
-    private val sdkVersion: String = "5.7.1"
+    private val sdkVersion: String = "5.7.1",
+    private val token: String? = null // Pre-shared token, when the gateway sets dji.token
 ) {
     companion object {
         private const val TAG = "TcpClient"
@@ -87,7 +88,7 @@ class TcpClient(
                 inputStream = DataInputStream(socket!!.getInputStream())
 
                 // Send hello message
-                sendMessageDirect(Message.hello(deviceId, sdkVersion))
+                sendMessageDirect(Message.hello(deviceId, sdkVersion, token))
 
                 // Wait for ACK
                 val response = receiveMessage()
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/api"
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/backup"
+	"github.com/open-uav/telemetry-bridge/internal/core/geofence"
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
+	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
+)
+
+// Snapshots of in-memory state stored in backups
+const (
+	snapshotGeofences      = "geofences.json"
+	snapshotGeofenceGroups = "geofence_groups.json"
+	snapshotAlertRules     = "alert_rules.json"
+	snapshotRoutingRules   = "routing_rules.json"
+	snapshotTracks         = "tracks.json"
+)
+
+// newBackupTarget creates the archive store selected by backup.target
+func newBackupTarget(cfg config.BackupConfig) (backup.Target, error) {
+	return newTarget(cfg.Target, cfg.Path, cfg.S3)
+}
+
+// newTarget creates a local or S3 target
+func newTarget(kind, path string, s3 config.BackupS3Config) (backup.Target, error) {
+	switch kind {
+	case "local":
+		return &backup.LocalTarget{Dir: path}, nil
+	case "s3":
+		return backup.NewS3Target(backup.S3Config{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			Prefix:    s3.Prefix,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			PathStyle: s3.PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown target %q (want local or s3)", kind)
+	}
+}
+
+// backupFile is a file included in backups, by its name in the archive
+type backupFile struct{ name, path string }
+
+// backupFiles lists the files backed up, at their paths in cfg
+func backupFiles(configPath string, cfg *config.Config) []backupFile {
+	return []backupFile{
+		{"files/config.yaml", configPath},
+		{"files/apikeys.json", cfg.HTTP.Auth.APIKeysFile},
+		{"files/devices.json", cfg.Devices.RegistryFile},
+		{"files/alerts.db", cfg.Alerts.File},
+		{"files/mavlink_signing.json", cfg.MAVLink.Signing.TimestampFile},
+	}
+}
+
+// readBackupFile reads a file to back up. The alert store is copied from
+// the running alerter, as the file may be mid-write.
+func readBackupFile(f backupFile, httpServer *api.Server) ([]byte, error) {
+	if f.name == "files/alerts.db" && httpServer != nil {
+		var buf bytes.Buffer
+		err := httpServer.GetAlerter().Backup(&buf)
+		if err == nil {
+			return buf.Bytes(), nil
+		}
+		if !errors.Is(err, alerter.ErrNotOpen) {
+			return nil, err
+		}
+	}
+	return os.ReadFile(f.path)
+}
+
+// restoreTargets returns where a restore writes backed up files and
+// snapshots: the paths of the current configuration, never those recorded
+// in the archive
+func restoreTargets(configPath string, cfg *config.Config) backup.Targets {
+	files := make(map[string]string)
+	for _, f := range backupFiles(configPath, cfg) {
+		files[f.name] = f.path
+	}
+	return backup.Targets{Files: files, SnapshotDir: cfg.Backup.RestoreDir}
+}
+
+// backupCollector snapshots the config file, the persisted stores and the
+// in-memory geofences, rules and recent tracks. httpServer may be nil.
+func backupCollector(configPath string, cfg *config.Config, engine *core.Engine, httpServer *api.Server, trackWindow time.Duration) backup.Collector {
+	return func() (*backup.Manifest, error) {
+		m := &backup.Manifest{Version: version, RestoreDir: cfg.Backup.RestoreDir}
+
+		for _, f := range backupFiles(configPath, cfg) {
+			if f.name == "files/mavlink_signing.json" && !cfg.MAVLink.Signing.Enabled {
+				continue
+			}
+			data, err := readBackupFile(f, httpServer)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			m.Entries = append(m.Entries, backup.Entry{Name: f.name, Kind: backup.KindFile, Path: f.path, Data: data})
+		}
+
+		snapshots := map[string]interface{}{
+			snapshotRoutingRules: engine.Routing().List(),
+		}
+		if httpServer != nil {
+			snapshots[snapshotGeofences] = httpServer.GetGeofenceEngine().GetGeofences()
+			snapshots[snapshotGeofenceGroups] = httpServer.GetGeofenceEngine().GetGroups()
+			snapshots[snapshotAlertRules] = httpServer.GetAlerter().GetRules()
+		}
+		if trackWindow > 0 {
+			since := time.Now().Add(-trackWindow).UnixMilli()
+			tracks := make(map[string][]trackstore.TrackPoint)
+			for _, id := range engine.GetTrackDeviceIDs() {
+				if points := engine.GetTrack(id, 0, since); len(points) > 0 {
+					tracks[id] = points
+				}
+			}
+			snapshots[snapshotTracks] = tracks
+		}
+		for _, name := range []string{snapshotGeofences, snapshotGeofenceGroups, snapshotAlertRules, snapshotRoutingRules, snapshotTracks} {
+			v, ok := snapshots[name]
+			if !ok {
+				continue
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("encoding %s: %w", name, err)
+			}
+			m.Entries = append(m.Entries, backup.Entry{Name: "snapshots/" + name, Kind: backup.KindSnapshot, Data: data})
+		}
+		return m, nil
+	}
+}
+
+// importRestoredSnapshots loads the state written by "outb restore" into
+// the stores, replacing their contents, and removes the imported snapshots.
+// Geofence and alert rule snapshots are kept until the HTTP API is enabled.
+func importRestoredSnapshots(dir string, engine *core.Engine, httpServer *api.Server) {
+	snapshots, err := backup.LoadSnapshots(dir)
+	if err != nil {
+		log.Printf("[Backup] Failed to read restored state from %s: %v", dir, err)
+		return
+	}
+
+	imported := func(name string, err error) {
+		if err != nil {
+			log.Printf("[Backup] Failed to import restored %s: %v", name, err)
+			return
+		}
+		os.Remove(filepath.Join(dir, name))
+	}
+
+	if data, ok := snapshots[snapshotRoutingRules]; ok {
+		var rules []routing.Rule
+		err := json.Unmarshal(data, &rules)
+		if err == nil {
+			router := engine.Routing()
+			for _, r := range router.List() {
+				router.Delete(r.ID)
+			}
+			for i := range rules {
+				router.Create(&rules[i])
+			}
+			log.Printf("[Backup] Restored %d routing rules", len(rules))
+		}
+		imported(snapshotRoutingRules, err)
+	}
+
+	if data, ok := snapshots[snapshotTracks]; ok {
+		var tracks map[string][]trackstore.TrackPoint
+		err := json.Unmarshal(data, &tracks)
+		if err == nil {
+			added := 0
+			for id, points := range tracks {
+				added += engine.ImportTrack(id, points)
+			}
+			log.Printf("[Backup] Restored %d track points for %d devices", added, len(tracks))
+		}
+		imported(snapshotTracks, err)
+	}
+
+	if httpServer == nil {
+		return
+	}
+
+	if data, ok := snapshots[snapshotGeofences]; ok {
+		var geofences []*geofence.Geofence
+		var groups []*geofence.Group
+		err := json.Unmarshal(data, &geofences)
+		if groupData, ok := snapshots[snapshotGeofenceGroups]; ok && err == nil {
+			err = json.Unmarshal(groupData, &groups)
+		}
+		if err == nil {
+			gfe := httpServer.GetGeofenceEngine()
+			for _, gf := range gfe.GetGeofences() {
+				gfe.DeleteGeofence(gf.ID)
+			}
+			restoreGeofenceGroups(gfe, groups)
+			for _, gf := range geofences {
+				gfe.AddGeofence(gf)
+			}
+			log.Printf("[Backup] Restored %d geofences in %d groups", len(geofences), len(groups))
+		}
+		imported(snapshotGeofences, err)
+		if _, ok := snapshots[snapshotGeofenceGroups]; ok {
+			imported(snapshotGeofenceGroups, err)
+		}
+	}
+
+	if data, ok := snapshots[snapshotAlertRules]; ok {
+		var rules []*alerter.Rule
+		err := json.Unmarshal(data, &rules)
+		if err == nil {
+			a := httpServer.GetAlerter()
+			for _, r := range a.GetRules() {
+				a.DeleteRule(r.ID)
+			}
+			for _, r := range rules {
+				a.CreateRule(r)
+			}
+			log.Printf("[Backup] Restored %d alert rules", len(rules))
+		}
+		imported(snapshotAlertRules, err)
+	}
+}
+
+// restoreGeofenceGroups replaces the geofence groups of an engine without
+// geofences. Groups are removed innermost first and added outermost first,
+// since a group cannot be deleted before its subgroups nor added before its
+// parent.
+func restoreGeofenceGroups(gfe *geofence.Engine, groups []*geofence.Group) {
+	for existing := gfe.GetGroups(); len(existing) > 0; {
+		var left []*geofence.Group
+		for _, g := range existing {
+			if gfe.DeleteGroup(g.ID) != nil {
+				left = append(left, g)
+			}
+		}
+		if len(left) == len(existing) {
+			break
+		}
+		existing = left
+	}
+	for len(groups) > 0 {
+		var left []*geofence.Group
+		for _, g := range groups {
+			if gfe.AddGroup(g) != nil {
+				left = append(left, g)
+			}
+		}
+		if len(left) == len(groups) {
+			log.Printf("[Backup] Skipped %d geofence groups with unknown parents", len(left))
+			break
+		}
+		groups = left
+	}
+}
+
+// restoreCommand restores a backup archive given as a local file, an
+// archive name on the configured target, or "latest". Files are restored
+// to the paths of the config, or the default paths on a unit without one.
+func restoreCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("outb restore", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", defaultConfigPath, "config file with the backup target and restore paths (defaults are used for a local archive file if it does not exist)")
+	force := fs.Bool("force", false, "overwrite existing files")
+	list := fs.Bool("list", false, "list the archives on the backup target")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: outb restore [-config path] [-force] <archive file | archive name | latest>")
+		fmt.Fprintln(stderr, "       outb restore [-config path] -list")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return flagExitCode(err)
+	}
+	if (*list && fs.NArg() != 0) || (!*list && fs.NArg() != 1) {
+		fs.Usage()
+		return 2
+	}
+
+	var data []byte
+	if !*list {
+		data, _ = os.ReadFile(fs.Arg(0))
+	}
+	cfg, err := config.Load(*configPath)
+	if errors.Is(err, os.ErrNotExist) && data != nil {
+		cfg, err = config.Parse([]byte("{}"))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", *configPath, err)
+		return 1
+	}
+
+	if data == nil {
+		target, err := newBackupTarget(cfg.Backup)
+		if err != nil {
+			fmt.Fprintf(stderr, "backup: %v\n", err)
+			return 1
+		}
+		names, err := target.List()
+		if err != nil {
+			fmt.Fprintf(stderr, "listing %s: %v\n", target, err)
+			return 1
+		}
+		if *list {
+			for _, name := range names {
+				fmt.Fprintln(stdout, name)
+			}
+			return 0
+		}
+
+		name := fs.Arg(0)
+		if name == "latest" {
+			if len(names) == 0 {
+				fmt.Fprintf(stderr, "no backups in %s\n", target)
+				return 1
+			}
+			name = names[len(names)-1]
+		}
+		if data, err = target.Get(name); err != nil {
+			fmt.Fprintf(stderr, "fetching %s: %v\n", name, err)
+			return 1
+		}
+	}
+
+	m, err := backup.ReadArchive(bytes.NewReader(data))
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	paths, err := backup.Restore(m, restoreTargets(*configPath, cfg), *force)
+	if errors.Is(err, backup.ErrFileExists) {
+		fmt.Fprintf(stderr, "restore: %v (use -force to overwrite)\n", err)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "restore: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Restored backup from %s (outb %s):\n", time.UnixMilli(m.CreatedAt).Format(time.RFC3339), m.Version)
+	for _, p := range paths {
+		fmt.Fprintf(stdout, "  %s\n", p)
+	}
+	fmt.Fprintf(stdout, "State in %s is imported on the next start.\n", cfg.Backup.RestoreDir)
+	return 0
+}
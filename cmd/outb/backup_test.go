@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core"
+	"github.com/open-uav/telemetry-bridge/internal/core/backup"
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
+	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir) // Restores without a config use the default, relative paths
+	configPath := filepath.Join(dir, "config.yaml")
+	os.WriteFile(configPath, []byte("backup:\n  enabled: true\n  path: "+filepath.Join(dir, "backups")+"\n"), 0600)
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := core.NewEngine(core.EngineConfig{RateHz: 1, TrackEnabled: true, TrackMaxPoints: 100})
+	engine.Routing().Create(&routing.Rule{ID: "mqtt-only", Name: "MQTT only", Enabled: true, Publishers: []string{"mqtt"}})
+	now := time.Now().UnixMilli()
+	engine.ImportTrack("mavlink-1", []trackstore.TrackPoint{
+		{Timestamp: now - 2*time.Hour.Milliseconds(), Lat: 22.4, Lon: 114.0}, // Outside the track window
+		{Timestamp: now - 60000, Lat: 22.5, Lon: 114.0},
+		{Timestamp: now - 30000, Lat: 22.6, Lon: 114.0},
+	})
+
+	target, _ := newBackupTarget(cfg.Backup)
+	name, err := backup.NewRunner(target, 7, backupCollector(configPath, cfg, engine, nil, time.Hour)).Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Rebuild: the config is gone, restore the newest archive from the target
+	archive := filepath.Join(cfg.Backup.Path, name)
+	var stdout, stderr bytes.Buffer
+	if code := restoreCommand([]string{"-config", configPath, archive}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "-force") {
+		t.Errorf("restore over existing config: code %d, stderr %q", code, stderr.String())
+	}
+	os.Remove(configPath)
+	stdout.Reset()
+	if code := restoreCommand([]string{"-config", configPath, archive}, &stdout, &stderr); code != 0 {
+		t.Fatalf("restore: code %d, stderr %q", code, stderr.String())
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		t.Errorf("Config not restored: %v", err)
+	}
+	stdout.Reset()
+	if code := restoreCommand([]string{"-config", configPath, "-list"}, &stdout, &stderr); code != 0 || strings.TrimSpace(stdout.String()) != name {
+		t.Errorf("restore -list: code %d, stdout %q", code, stdout.String())
+	}
+
+	fresh := core.NewEngine(core.EngineConfig{RateHz: 1, TrackEnabled: true, TrackMaxPoints: 100})
+	importRestoredSnapshots(cfg.Backup.RestoreDir, fresh, nil)
+	if rules := fresh.Routing().List(); len(rules) != 1 || rules[0].ID != "mqtt-only" {
+		t.Errorf("Restored routing rules = %+v", rules)
+	}
+	if n := fresh.GetTrackSize("mavlink-1"); n != 2 {
+		t.Errorf("Restored track size = %d, want 2", n)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Backup.RestoreDir, snapshotTracks)); !os.IsNotExist(err) {
+		t.Error("Imported snapshots should be removed")
+	}
+}
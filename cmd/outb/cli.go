@@ -0,0 +1,509 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-uav/telemetry-bridge/internal/adapters/dji"
+	"github.com/open-uav/telemetry-bridge/internal/adapters/mavlink"
+	"github.com/open-uav/telemetry-bridge/internal/adapters/poll"
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core"
+	"github.com/open-uav/telemetry-bridge/internal/core/archiver"
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/internal/core/enrich"
+	"github.com/open-uav/telemetry-bridge/internal/core/identity"
+	"github.com/open-uav/telemetry-bridge/internal/core/logger"
+	"github.com/open-uav/telemetry-bridge/internal/core/retention"
+	"github.com/open-uav/telemetry-bridge/internal/core/tenant"
+	"github.com/open-uav/telemetry-bridge/internal/core/timefmt"
+	"github.com/open-uav/telemetry-bridge/internal/plugin"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/amqp"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/mqtt"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/postgres"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/stanag4586"
+)
+
+// defaultConfigPath is used when no config file is given
+const defaultConfigPath = "configs/config.yaml"
+
+const usage = `Usage: outb [command] [flags] [config]
+
+Commands:
+  run              Run the gateway (default)
+  selftest         Check every adapter and publisher, then exit
+  validate-config  Load and check a config file without starting
+  hash-password    Print a bcrypt hash for http.auth users
+  restore          Restore a backup archive (see backup in the config)
+  version          Print the version
+
+Run "outb <command> -h" for the flags of a command.
+`
+
+// runOptions are the flags shared by run, selftest and validate-config
+type runOptions struct {
+	configPath string
+	logLevel   string // Overrides server.log_level when set
+	httpAddr   string // Overrides http.address when set
+}
+
+// parseRunFlags parses the flags of a config-loading command. A positional
+// config path is still accepted for compatibility with "outb config.yaml".
+func parseRunFlags(name string, args []string, stderr io.Writer) (runOptions, error) {
+	var opts runOptions
+	fs := flag.NewFlagSet("outb "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.configPath, "config", defaultConfigPath, "path to the config file")
+	fs.StringVar(&opts.logLevel, "log-level", "", "override server.log_level (debug, info, warn, error)")
+	fs.StringVar(&opts.httpAddr, "http-addr", "", "override http.address, e.g. :9090")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		opts.configPath = fs.Arg(0)
+	default:
+		return opts, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args()[1:], " "))
+	}
+	return opts, nil
+}
+
+// loadConfig loads the config file and applies the command-line overrides
+func (o runOptions) loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(o.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if o.logLevel != "" {
+		cfg.Server.LogLevel = o.logLevel
+	}
+	if o.httpAddr != "" {
+		cfg.HTTP.Address = o.httpAddr
+	}
+	return cfg, nil
+}
+
+// validateConfig returns every problem in cfg that would stop the gateway
+// from starting
+func validateConfig(cfg *config.Config) []error {
+	errs := config.Validate(cfg)
+	if _, err := logger.ParseLevel(cfg.Server.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("server.log_level: %w", err))
+	}
+	if _, err := logger.ParseSourceLevels(cfg.Server.LogLevels); err != nil {
+		errs = append(errs, fmt.Errorf("server.log_levels: %w", err))
+	}
+	if _, err := timefmt.LoadLocation(cfg.Server.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("server.timezone: %w", err))
+	}
+	if err := timefmt.ValidateFormat(cfg.Server.TimeFormat); err != nil {
+		errs = append(errs, fmt.Errorf("server.time_format: %w", err))
+	}
+	for _, r := range []struct{ name, value string }{
+		{"interval", cfg.Retention.Interval},
+		{"tracks", cfg.Retention.Tracks},
+		{"alerts", cfg.Retention.Alerts},
+		{"logs", cfg.Retention.Logs},
+		{"archives", cfg.Retention.Archives},
+		{"coverage", cfg.Retention.Coverage},
+	} {
+		if _, err := retention.ParseAge(r.value); err != nil {
+			errs = append(errs, fmt.Errorf("retention.%s: %w", r.name, err))
+		}
+	}
+	if _, err := retention.ParseAge(cfg.Coverage.Bucket); err != nil {
+		errs = append(errs, fmt.Errorf("coverage.bucket: %w", err))
+	}
+	if _, err := retention.ParseAge(cfg.Incidents.Window); err != nil {
+		errs = append(errs, fmt.Errorf("incidents.window: %w", err))
+	}
+	if _, err := retention.ParseAge(cfg.State.ExpireAfter); err != nil {
+		errs = append(errs, fmt.Errorf("state.expire_after: %w", err))
+	}
+	switch cfg.Coordinate.AccuracyMode {
+	case "", coordinator.AccuracyAnalytic, coordinator.AccuracyGrid:
+	default:
+		errs = append(errs, fmt.Errorf("coordinate.accuracy_mode: must be analytic or grid"))
+	}
+	if !coordinator.ValidDatum(cfg.Geofence.DefaultDatum) {
+		errs = append(errs, fmt.Errorf("geofence.default_datum: must be wgs84, gcj02 or bd09"))
+	}
+	if cfg.State.MaxDevices < 0 {
+		errs = append(errs, fmt.Errorf("state.max_devices: must not be negative"))
+	}
+	if cfg.State.GeohashPrecision < 0 || cfg.State.GeohashPrecision > 12 {
+		errs = append(errs, fmt.Errorf("state.geohash_precision: must be 0 to 12"))
+	}
+	if cfg.Tracing.Enabled {
+		if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+			errs = append(errs, fmt.Errorf("tracing.sample_ratio: must be between 0 and 1"))
+		}
+		if _, err := newTracingExporter(cfg.Tracing, ""); err != nil {
+			errs = append(errs, fmt.Errorf("tracing: %w", err))
+		}
+	}
+	if cfg.Batch.MaxSize < 0 {
+		errs = append(errs, fmt.Errorf("batch.max_size: must not be negative"))
+	}
+	if cfg.Batch.MaxLatencyMs < 0 {
+		errs = append(errs, fmt.Errorf("batch.max_latency_ms: must not be negative"))
+	}
+	if cfg.Queue.Size < 0 {
+		errs = append(errs, fmt.Errorf("queue.size: must not be negative"))
+	}
+	if err := core.ValidDropPolicy(cfg.Queue.DropPolicy); err != nil {
+		errs = append(errs, fmt.Errorf("queue.drop_policy: %w", err))
+	}
+	if cfg.Dedup.PositionM < 0 {
+		errs = append(errs, fmt.Errorf("dedup.position_m: must not be negative"))
+	}
+	if cfg.MQTT.Enabled {
+		if _, err := mqtt.ParseTopicTemplate(cfg.MQTT.TopicTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("mqtt.topic_template: %w", err))
+		}
+	}
+	if l := cfg.HTTP.Compress.Level; cfg.HTTP.Compress.Enabled && (l < 1 || l > 9) {
+		errs = append(errs, fmt.Errorf("http.compress.level: must be between 1 and 9"))
+	}
+	if ws := cfg.HTTP.WebSocket; ws.RequireAuth && !cfg.HTTP.Auth.Enabled {
+		errs = append(errs, fmt.Errorf("http.websocket.require_auth: needs http.auth.enabled"))
+	}
+	if ws := cfg.HTTP.WebSocket; ws.AuthTimeoutSec < 0 || ws.MessagesPerSec < 0 || ws.MessageBurst < 0 {
+		errs = append(errs, fmt.Errorf("http.websocket: auth_timeout_sec, messages_per_sec and message_burst must not be negative"))
+	}
+	for _, origin := range cfg.HTTP.WebSocket.AllowedOrigins {
+		if u, err := url.Parse(origin); origin != "*" && (err != nil || u.Scheme == "" || u.Host == "") {
+			errs = append(errs, fmt.Errorf("http.websocket.allowed_origins: %q must be scheme://host[:port] or *", origin))
+		}
+	}
+	if cfg.Audit.MaxEntries < 0 {
+		errs = append(errs, fmt.Errorf("audit.max_entries: must not be negative"))
+	}
+	if cfg.Backup.Enabled {
+		if _, err := retention.ParseAge(cfg.Backup.TrackWindow); err != nil {
+			errs = append(errs, fmt.Errorf("backup.track_window: %w", err))
+		}
+		if _, err := newBackupTarget(cfg.Backup); err != nil {
+			errs = append(errs, fmt.Errorf("backup: %w", err))
+		}
+	}
+	if cfg.Archive.Enabled {
+		if _, err := archiver.New(archiver.Config{Format: cfg.Archive.Format}, nil); err != nil {
+			errs = append(errs, fmt.Errorf("archive.format: %w", err))
+		}
+		if _, err := retention.ParseAge(cfg.Archive.Rotate); err != nil {
+			errs = append(errs, fmt.Errorf("archive.rotate: %w", err))
+		}
+		if cfg.Archive.MaxRows < 0 {
+			errs = append(errs, fmt.Errorf("archive.max_rows: must not be negative"))
+		}
+		if _, err := newTarget(cfg.Archive.Target, cfg.Archive.Path, cfg.Archive.S3); err != nil {
+			errs = append(errs, fmt.Errorf("archive: %w", err))
+		}
+	}
+	if cfg.PublicFeed.Enabled {
+		for _, d := range []struct{ name, value string }{
+			{"delay", cfg.PublicFeed.Delay},
+			{"stale_after", cfg.PublicFeed.StaleAfter},
+		} {
+			if _, err := retention.ParseAge(d.value); err != nil {
+				errs = append(errs, fmt.Errorf("public_feed.%s: %w", d.name, err))
+			}
+		}
+	}
+	if _, err := newNotifier(cfg.Notifications); err != nil {
+		errs = append(errs, fmt.Errorf("notifications: %w", err))
+	}
+	if _, err := escalationPolicies(cfg.Notifications); err != nil {
+		errs = append(errs, fmt.Errorf("notifications: %w", err))
+	}
+	if cfg.Notifications.CheckIntervalSec < 0 {
+		errs = append(errs, fmt.Errorf("notifications.check_interval_sec: must be positive"))
+	}
+	if _, err := jobSchedules(cfg); err != nil {
+		errs = append(errs, fmt.Errorf("jobs: %w", err))
+	}
+	pollNames := make(map[string]bool)
+	for i, pc := range cfg.Poll {
+		if _, err := poll.New(pc); err != nil {
+			errs = append(errs, fmt.Errorf("poll[%d]: %w", i, err))
+		}
+		if pollNames[pc.Name] {
+			errs = append(errs, fmt.Errorf("poll[%d]: duplicate name %q", i, pc.Name))
+		}
+		pollNames[pc.Name] = true
+	}
+	if cfg.MAVLink.Enabled && cfg.MAVLink.Signing.Enabled {
+		if _, err := mavlink.ParseSigningKey(cfg.MAVLink.Signing); err != nil {
+			errs = append(errs, fmt.Errorf("mavlink.signing: %w", err))
+		}
+	}
+	if cfg.MAVLink.Enabled {
+		for i, address := range cfg.MAVLink.Forward {
+			if _, _, err := net.SplitHostPort(address); err != nil {
+				errs = append(errs, fmt.Errorf("mavlink.forward[%d]: %w", i, err))
+			}
+		}
+		if err := mavlink.ValidateRawMessages(cfg.MAVLink.Raw); err != nil {
+			errs = append(errs, fmt.Errorf("mavlink.raw: %w", err))
+		}
+		if cfg.MAVLink.ConnectionType == "serial" {
+			if _, err := filepath.Match(cfg.MAVLink.SerialPort, ""); err != nil {
+				errs = append(errs, fmt.Errorf("mavlink.serial_port: invalid pattern %q", cfg.MAVLink.SerialPort))
+			}
+			if cfg.MAVLink.SerialBaud < 0 {
+				errs = append(errs, fmt.Errorf("mavlink.serial_baud: must be a baud rate, or 0 to detect it"))
+			}
+		}
+	}
+	if cfg.DJI.Enabled && cfg.DJI.TLS.Enabled {
+		if _, err := dji.LoadTLSConfig(cfg.DJI.TLS); err != nil {
+			errs = append(errs, fmt.Errorf("dji.tls: %w", err))
+		}
+	}
+	if cfg.AMQP.Enabled {
+		if _, err := amqp.New(cfg.AMQP); err != nil {
+			errs = append(errs, fmt.Errorf("amqp: %w", err))
+		}
+	}
+	if cfg.Postgres.Enabled {
+		if _, err := postgres.New(cfg.Postgres); err != nil {
+			errs = append(errs, fmt.Errorf("postgres: %w", err))
+		}
+	}
+	if cfg.AWSIoT.Enabled {
+		if _, err := mqtt.NewAWSIoT(cfg.AWSIoT); err != nil {
+			errs = append(errs, fmt.Errorf("aws_iot: %w", err))
+		}
+	}
+	if cfg.AzureIoT.Enabled {
+		if _, err := mqtt.NewAzureIoT(cfg.AzureIoT); err != nil {
+			errs = append(errs, fmt.Errorf("azure_iot: %w", err))
+		}
+	}
+	if cfg.STANAG4586.Enabled {
+		if _, err := stanag4586.New(cfg.STANAG4586); err != nil {
+			errs = append(errs, fmt.Errorf("stanag4586: %w", err))
+		}
+	}
+	if cfg.UDP.Enabled {
+		if _, err := net.ResolveUDPAddr("udp", cfg.UDP.ListenAddress); err != nil {
+			errs = append(errs, fmt.Errorf("udp.listen_address: %w", err))
+		}
+	}
+	if cfg.NMEA.Enabled {
+		switch cfg.NMEA.ConnectionType {
+		case "serial":
+			if cfg.NMEA.SerialPort == "" {
+				errs = append(errs, fmt.Errorf("nmea.serial_port: required for connection_type serial"))
+			}
+		case "tcp":
+			if _, _, err := net.SplitHostPort(cfg.NMEA.Address); err != nil {
+				errs = append(errs, fmt.Errorf("nmea.address: %w", err))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("nmea.connection_type: unknown type %q, want serial or tcp", cfg.NMEA.ConnectionType))
+		}
+	}
+	tenants, err := newTenantRegistry(cfg)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("tenants: %w", err))
+	}
+	if _, err := newIdentityMapper(cfg); err != nil {
+		errs = append(errs, fmt.Errorf("devices.aliases: %w", err))
+	}
+	if _, err := newEnrichmentChain(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	usernames := map[string]bool{cfg.HTTP.Auth.Username: true}
+	for _, u := range cfg.HTTP.Auth.Users {
+		if usernames[u.Username] {
+			errs = append(errs, fmt.Errorf("http.auth.users: duplicate username %q", u.Username))
+		}
+		usernames[u.Username] = true
+		if _, ok := tenants.Get(u.Tenant); !ok && tenants != nil {
+			errs = append(errs, fmt.Errorf("http.auth.users: %s has unknown tenant %q", u.Username, u.Tenant))
+		}
+	}
+	if l := cfg.HTTP.Auth.Lockout; l.MaxFailures > 0 && (l.WindowSec <= 0 || l.DurationSec <= 0) {
+		errs = append(errs, fmt.Errorf("http.auth.lockout: window_sec and duration_sec must be positive"))
+	}
+	for _, pc := range cfg.Plugins {
+		if pc.Kind != plugin.KindAdapter && pc.Kind != plugin.KindPublisher {
+			errs = append(errs, fmt.Errorf("plugin %s: unknown kind %q (want adapter or publisher)", pc.Name, pc.Kind))
+		}
+	}
+	return errs
+}
+
+// newTenantRegistry builds the device ownership registry from the tenants
+// section
+func newTenantRegistry(cfg *config.Config) (*tenant.Registry, error) {
+	tenants := make([]tenant.Tenant, len(cfg.Tenants))
+	for i, t := range cfg.Tenants {
+		tenants[i] = tenant.Tenant{
+			ID:             t.ID,
+			Name:           t.Name,
+			Devices:        t.Devices,
+			DevicePrefixes: t.DevicePrefixes,
+		}
+	}
+	return tenant.New(tenants)
+}
+
+// newIdentityMapper builds the device aliases from the devices section
+func newIdentityMapper(cfg *config.Config) (*identity.Mapper, error) {
+	aliases := make([]identity.Alias, len(cfg.Devices.Aliases))
+	for i, a := range cfg.Devices.Aliases {
+		aliases[i] = identity.Alias{ID: a.ID, Canonical: a.Canonical, Source: a.Source}
+	}
+	return identity.New(aliases)
+}
+
+// newEnrichmentChain builds the enabled state enrichment processors, nil
+// if there are none
+func newEnrichmentChain(cfg *config.Config) (*enrich.Chain, error) {
+	chain := &enrich.Chain{}
+	for i, ec := range cfg.Enrichment {
+		if !ec.Enabled {
+			continue
+		}
+		places := make([]enrich.Place, len(ec.Places))
+		for j, p := range ec.Places {
+			places[j] = enrich.Place{Name: p.Name, Lat: p.Lat, Lon: p.Lon, RadiusM: p.RadiusM}
+		}
+		err := chain.Add(enrich.Spec{
+			Type:    ec.Type,
+			Sources: ec.Sources,
+			Convert: ec.Convert,
+			Tags:    ec.Tags,
+			Rename:  ec.Rename,
+			Field:   ec.Field,
+			Places:  places,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("enrichment[%d]: %w", i, err)
+		}
+	}
+	if chain.Len() == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// runCLI dispatches a command and returns the process exit code
+func runCLI(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	// Flags and config paths without a command mean "run"
+	command := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") && !strings.ContainsAny(args[0], "./") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "run", "selftest":
+		opts, err := parseRunFlags(command, args, stderr)
+		if err != nil {
+			return flagExitCode(err)
+		}
+		serve(opts, command == "selftest")
+		return 0
+	case "validate-config":
+		return validateConfigCommand(args, stdout, stderr)
+	case "hash-password":
+		return hashPasswordCommand(args, stdin, stdout, stderr)
+	case "restore":
+		return restoreCommand(args, stdout, stderr)
+	case "version":
+		fmt.Fprintf(stdout, "outb %s\n", version)
+		return 0
+	case "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "outb: unknown command %q\n\n%s", command, usage)
+		return 2
+	}
+}
+
+// flagExitCode returns 0 for -h and 2 for other flag errors
+func flagExitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 2
+}
+
+// validateConfigCommand loads a config file and reports every problem
+func validateConfigCommand(args []string, stdout, stderr io.Writer) int {
+	opts, err := parseRunFlags("validate-config", args, stderr)
+	if err != nil {
+		return flagExitCode(err)
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", opts.configPath, err)
+		return 1
+	}
+	if errs := validateConfig(cfg); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(stderr, "%s: %v\n", opts.configPath, err)
+		}
+		return 1
+	}
+	fmt.Fprintf(stdout, "%s: OK\n", opts.configPath)
+	return 0
+}
+
+// hashPasswordCommand prints the bcrypt hash of a password given as an
+// argument or, to keep it out of the shell history, on the first line of
+// stdin
+func hashPasswordCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("outb hash-password", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: outb hash-password [password]")
+		fmt.Fprintln(stderr, "Reads the password from stdin when no argument is given.")
+	}
+	if err := fs.Parse(args); err != nil {
+		return flagExitCode(err)
+	}
+
+	var password string
+	switch fs.NArg() {
+	case 0:
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			fmt.Fprintf(stderr, "reading password: %v\n", err)
+			return 1
+		}
+		password = strings.TrimRight(line, "\r\n")
+	case 1:
+		password = fs.Arg(0)
+	default:
+		fs.Usage()
+		return 2
+	}
+	if password == "" {
+		fmt.Fprintln(stderr, "password must not be empty")
+		return 1
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashing password: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, hash)
+	return 0
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/config"
+)
+
+func TestParseRunFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want runOptions
+	}{
+		{"defaults", nil, runOptions{configPath: defaultConfigPath}},
+		{"positional config", []string{"my.yaml"}, runOptions{configPath: "my.yaml"}},
+		{"flags", []string{"--config", "a.yaml", "--log-level", "debug", "--http-addr", ":9090"},
+			runOptions{configPath: "a.yaml", logLevel: "debug", httpAddr: ":9090"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRunFlags("run", tt.args, &bytes.Buffer{})
+			if err != nil {
+				t.Fatalf("parseRunFlags() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseRunFlags() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseRunFlags("run", []string{"a.yaml", "b.yaml"}, &bytes.Buffer{}); err == nil {
+		t.Error("parseRunFlags() should reject two config paths")
+	}
+}
+
+func TestLoadConfigOverrides(t *testing.T) {
+	opts := runOptions{configPath: "../../configs/config.example.yaml", logLevel: "debug", httpAddr: ":9090"}
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.Server.LogLevel != "debug" || cfg.HTTP.Address != ":9090" {
+		t.Errorf("overrides not applied: log_level=%s http.address=%s", cfg.Server.LogLevel, cfg.HTTP.Address)
+	}
+}
+
+func TestValidateConfigCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"validate-config", "--config", "../../configs/config.example.yaml"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code = %d, stderr = %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "OK") {
+		t.Errorf("stdout = %q, want OK", stdout.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	bad := "server:\n  log_level: loud\nretention:\n  tracks: forever\n"
+	if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := runCLI([]string{"validate-config", path}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	for _, want := range []string{"server.log_level", "retention.tracks"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr = %q, want it to mention %s", stderr.String(), want)
+		}
+	}
+}
+
+func TestHashPasswordCommand(t *testing.T) {
+	for _, args := range [][]string{{"hash-password", "secret"}, {"hash-password"}} {
+		var stdout, stderr bytes.Buffer
+		code := runCLI(args, strings.NewReader("secret\n"), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("%v: exit code = %d, stderr = %s", args, code, stderr.String())
+		}
+		hash := strings.TrimSpace(stdout.String())
+		if err := auth.NewManager("admin", hash, "jwt", 1).ValidateCredentials("admin", "secret"); err != nil {
+			t.Errorf("%v: hash %q does not match the password", args, hash)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"hash-password"}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Errorf("empty password exit code = %d, want 1", code)
+	}
+}
+
+func TestRunCLICommands(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"version"}, nil, &stdout, &stderr); code != 0 || !strings.Contains(stdout.String(), version) {
+		t.Errorf("version: code = %d, stdout = %q", code, stdout.String())
+	}
+	if code := runCLI([]string{"frobnicate"}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("unknown command exit code = %d, want 2", code)
+	}
+}
+
+func TestValidateConfigTenants(t *testing.T) {
+	cfg := &config.Config{Tenants: []config.TenantConfig{{ID: "acme", DevicePrefixes: []string{"acme-"}}}}
+	cfg.HTTP.Auth.Username = "admin"
+	cfg.HTTP.Auth.Users = []config.UserConfig{
+		{Username: "acme-ops", Tenant: "acme"},
+		{Username: "admin", Tenant: "acme"},
+		{Username: "gx-ops", Tenant: "globex"},
+	}
+
+	var msgs []string
+	for _, err := range validateConfig(cfg) {
+		msgs = append(msgs, err.Error())
+	}
+	got := strings.Join(msgs, "\n")
+	for _, want := range []string{`duplicate username "admin"`, `unknown tenant "globex"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("validateConfig() = %q, want it to mention %s", got, want)
+		}
+	}
+	if strings.Contains(got, "acme-ops") {
+		t.Errorf("validateConfig() = %q, acme-ops is valid", got)
+	}
+}
+
+func TestValidateConfigPublicFeed(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.HTTP.Enabled, cfg.HTTP.Address = true, ":8080"
+	cfg.PublicFeed = config.PublicFeedConfig{Enabled: true, Address: ":8080", Delay: "soon", StaleAfter: "5m"}
+
+	var msgs []string
+	for _, err := range validateConfig(cfg) {
+		msgs = append(msgs, err.Error())
+	}
+	got := strings.Join(msgs, "\n")
+	for _, want := range []string{"public_feed.delay", "public_feed.address"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("validateConfig() = %q, want it to mention %s", got, want)
+		}
+	}
+	if strings.Contains(got, "public_feed.stale_after") {
+		t.Errorf("validateConfig() = %q, stale_after is valid", got)
+	}
+}
+
+func TestValidateConfigEnrichment(t *testing.T) {
+	cfg := &config.Config{Enrichment: []config.EnrichConfig{
+		{Enabled: true, Type: "tags", Tags: map[string]string{"site": "north"}},
+		{Enabled: false, Type: "lookup"},
+		{Enabled: true, Type: "units", Convert: map[string]string{"location.alt_baro": "furlongs"}},
+	}}
+
+	var msgs []string
+	for _, err := range validateConfig(cfg) {
+		msgs = append(msgs, err.Error())
+	}
+	got := strings.Join(msgs, "\n")
+	if !strings.Contains(got, "enrichment[2]: ") || !strings.Contains(got, "furlongs") {
+		t.Errorf("validateConfig() = %q, want the units processor reported", got)
+	}
+	if strings.Contains(got, "lookup") {
+		t.Errorf("validateConfig() = %q, disabled processors are not checked", got)
+	}
+}
+
+func TestValidateConfigSerial(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.MAVLink = config.MAVLinkConfig{Enabled: true, ConnectionType: "serial", SerialPort: "/dev/ttyUSB[", SerialBaud: -1}
+
+	var msgs []string
+	for _, err := range validateConfig(cfg) {
+		msgs = append(msgs, err.Error())
+	}
+	got := strings.Join(msgs, "\n")
+	for _, want := range []string{"mavlink.serial_port", "mavlink.serial_baud"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("validateConfig() = %q, want it to mention %s", got, want)
+		}
+	}
+
+	// A pattern and baud detection are valid
+	cfg.MAVLink.SerialPort, cfg.MAVLink.SerialBaud = "/dev/ttyUSB*", 0
+	for _, err := range validateConfig(cfg) {
+		if strings.Contains(err.Error(), "serial") {
+			t.Errorf("validateConfig() error = %v", err)
+		}
+	}
+}
+
+func TestValidateConfigAccuracyMode(t *testing.T) {
+	for mode, valid := range map[string]bool{"analytic": true, "grid": true, "precise": false, "Grid": false} {
+		cfg := &config.Config{}
+		cfg.Coordinate.AccuracyMode = mode
+
+		var reported bool
+		for _, err := range validateConfig(cfg) {
+			reported = reported || strings.Contains(err.Error(), "coordinate.accuracy_mode")
+		}
+		if reported == valid {
+			t.Errorf("validateConfig() with accuracy_mode %q reported = %v, want %v", mode, reported, !valid)
+		}
+	}
+}
+
+func TestValidateConfigWebSocket(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.HTTP.WebSocket = config.WebSocketConfig{RequireAuth: true, MessagesPerSec: -1, AllowedOrigins: []string{"ops.example.com"}}
+
+	var msgs []string
+	for _, err := range validateConfig(cfg) {
+		msgs = append(msgs, err.Error())
+	}
+	got := strings.Join(msgs, "\n")
+	for _, want := range []string{"http.websocket.require_auth", "http.websocket: ", "http.websocket.allowed_origins"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("validateConfig() = %q, want it to mention %s", got, want)
+		}
+	}
+
+	cfg.HTTP.Auth.Enabled = true
+	cfg.HTTP.WebSocket = config.WebSocketConfig{RequireAuth: true, AllowedOrigins: []string{"https://ops.example.com", "*"}}
+	for _, err := range validateConfig(cfg) {
+		if strings.Contains(err.Error(), "websocket") {
+			t.Errorf("validateConfig() error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/retention"
+	"github.com/open-uav/telemetry-bridge/internal/core/scheduler"
+)
+
+// Built-in scheduled jobs
+const (
+	jobRetention   = "retention"
+	jobBackup      = "backup"
+	jobEscalations = "escalations"
+)
+
+// jobSchedules returns the schedule of every built-in job with the jobs
+// section applied. Defaults come from retention.interval, backup.schedule
+// and notifications.check_interval_sec.
+func jobSchedules(cfg *config.Config) (map[string]config.JobConfig, error) {
+	interval, _ := retention.ParseAge(cfg.Retention.Interval)
+	schedules := map[string]config.JobConfig{
+		jobRetention:   {Name: jobRetention, Schedule: "@every " + interval.String()},
+		jobBackup:      {Name: jobBackup, Schedule: cfg.Backup.Schedule},
+		jobEscalations: {Name: jobEscalations, Schedule: fmt.Sprintf("@every %ds", cfg.Notifications.CheckIntervalSec)},
+	}
+
+	seen := make(map[string]bool)
+	for _, j := range cfg.Jobs {
+		job, ok := schedules[j.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown job %q", j.Name)
+		}
+		if seen[j.Name] {
+			return nil, fmt.Errorf("duplicate job %q", j.Name)
+		}
+		seen[j.Name] = true
+		if j.Schedule != "" {
+			job.Schedule = j.Schedule
+		}
+		job.Disabled = j.Disabled
+		schedules[j.Name] = job
+	}
+
+	for name, job := range schedules {
+		if name == jobBackup && !cfg.Backup.Enabled {
+			continue
+		}
+		if _, err := scheduler.Parse(job.Schedule, nil); err != nil {
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+	}
+	return schedules, nil
+}
+
+// retentionTask prunes every store of the janitor
+func retentionTask(janitor *retention.Janitor) scheduler.Task {
+	return func(ctx context.Context) (string, error) {
+		removed := janitor.RunOnce(time.Now())
+		names := make([]string, 0, len(removed))
+		total := 0
+		for name, n := range removed {
+			total += n
+			if n > 0 {
+				names = append(names, fmt.Sprintf("%s: %d", name, n))
+			}
+		}
+		if total == 0 {
+			return "Nothing to prune", nil
+		}
+		sort.Strings(names)
+		return fmt.Sprintf("Pruned %d item(s) (%s)", total, strings.Join(names, ", ")), nil
+	}
+}
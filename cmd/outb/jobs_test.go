@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/retention"
+)
+
+func TestJobSchedules(t *testing.T) {
+	cfg := &config.Config{
+		Retention:     config.RetentionConfig{Interval: "1h"},
+		Backup:        config.BackupConfig{Enabled: true, Schedule: "0 3 * * *"},
+		Notifications: config.NotificationsConfig{CheckIntervalSec: 30},
+		Jobs: []config.JobConfig{
+			{Name: "backup", Schedule: "30 2 * * 1-5"},
+			{Name: "escalations", Disabled: true},
+		},
+	}
+
+	schedules, err := jobSchedules(cfg)
+	if err != nil {
+		t.Fatalf("jobSchedules() error = %v", err)
+	}
+	if got := schedules["retention"]; got.Schedule != "@every 1h0m0s" || got.Disabled {
+		t.Errorf("retention = %+v, want the retention interval", got)
+	}
+	if got := schedules["backup"].Schedule; got != "30 2 * * 1-5" {
+		t.Errorf("backup schedule = %q, want the override", got)
+	}
+	if got := schedules["escalations"]; got.Schedule != "@every 30s" || !got.Disabled {
+		t.Errorf("escalations = %+v, want the default schedule disabled", got)
+	}
+
+	for _, tt := range []struct {
+		jobs []config.JobConfig
+		want string
+	}{
+		{[]config.JobConfig{{Name: "reports"}}, "unknown job"},
+		{[]config.JobConfig{{Name: "backup"}, {Name: "backup"}}, "duplicate"},
+		{[]config.JobConfig{{Name: "retention", Schedule: "hourly"}}, "job retention"},
+	} {
+		cfg.Jobs = tt.jobs
+		if _, err := jobSchedules(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("jobSchedules(%+v) error = %v, want %q", tt.jobs, err, tt.want)
+		}
+	}
+}
+
+func TestRetentionTask(t *testing.T) {
+	janitor := retention.NewJanitor()
+	janitor.Add("track points", time.Hour, func(time.Time) int { return 10 })
+	janitor.Add("alerts", time.Hour, func(time.Time) int { return 2 })
+	janitor.Add("logs", time.Hour, func(time.Time) int { return 0 })
+
+	result, err := retentionTask(janitor)(context.Background())
+	if err != nil || result != "Pruned 12 item(s) (alerts: 2, track points: 10)" {
+		t.Errorf("retentionTask() = %q, %v", result, err)
+	}
+}
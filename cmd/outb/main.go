@@ -6,53 +6,234 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/open-uav/telemetry-bridge/internal/adapters/dji"
+	"github.com/open-uav/telemetry-bridge/internal/adapters/external"
 	"github.com/open-uav/telemetry-bridge/internal/adapters/mavlink"
+	"github.com/open-uav/telemetry-bridge/internal/adapters/nmea"
+	"github.com/open-uav/telemetry-bridge/internal/adapters/poll"
+	"github.com/open-uav/telemetry-bridge/internal/adapters/sim"
+	"github.com/open-uav/telemetry-bridge/internal/adapters/udp"
 	"github.com/open-uav/telemetry-bridge/internal/api"
-	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/api/public"
 	"github.com/open-uav/telemetry-bridge/internal/core"
+	"github.com/open-uav/telemetry-bridge/internal/core/anonymize"
+	"github.com/open-uav/telemetry-bridge/internal/core/archiver"
+	"github.com/open-uav/telemetry-bridge/internal/core/audit"
+	"github.com/open-uav/telemetry-bridge/internal/core/backup"
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/logger"
+	"github.com/open-uav/telemetry-bridge/internal/core/publicfeed"
+	"github.com/open-uav/telemetry-bridge/internal/core/registry"
+	"github.com/open-uav/telemetry-bridge/internal/core/retention"
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
+	"github.com/open-uav/telemetry-bridge/internal/core/scheduler"
+	"github.com/open-uav/telemetry-bridge/internal/core/timefmt"
+	"github.com/open-uav/telemetry-bridge/internal/core/tracing"
+	"github.com/open-uav/telemetry-bridge/internal/plugin"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/amqp"
 	"github.com/open-uav/telemetry-bridge/internal/publishers/gb28181"
 	"github.com/open-uav/telemetry-bridge/internal/publishers/mqtt"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/postgres"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/redis"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/stanag4586"
 )
 
 const version = "0.4.0-dev"
 
 func main() {
+	os.Exit(runCLI(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// serve runs the gateway until it receives SIGINT/SIGTERM. In self-test
+// mode it validates the pipeline and exits instead.
+func serve(opts runOptions, selfTest bool) {
 	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
 
 	fmt.Printf("Open-UAV-Telemetry-Bridge v%s\n", version)
 	fmt.Println("Protocol-agnostic UAV telemetry gateway")
 	fmt.Println()
 
-	// Determine config path
-	configPath := "configs/config.yaml"
-	if len(os.Args) > 1 {
-		configPath = os.Args[1]
+	// Load configuration and apply command-line overrides
+	configPath := opts.configPath
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to load config from %s: %v", configPath, err)
+	}
+	if errs := validateConfig(cfg); len(errs) > 0 {
+		for _, err := range errs {
+			log.Printf("Invalid configuration: %v", err)
+		}
+		log.Fatalf("[ERROR] Configuration in %s has %d error(s)", configPath, len(errs))
 	}
 
-	// Load configuration
-	cfg, err := config.Load(configPath)
+	// Configure logging: level filter, stdout format and optional rotating file
+	logLevel, err := logger.ParseLevel(cfg.Server.LogLevel)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid log level: %v", err)
+	}
+	sourceLevels, err := logger.ParseSourceLevels(cfg.Server.LogLevels)
 	if err != nil {
-		log.Fatalf("Failed to load config from %s: %v", configPath, err)
+		log.Fatalf("[ERROR] Invalid log level: %v", err)
 	}
+	logBuffer := logger.New(cfg.Server.LogBufferSize)
+	logBuffer.SetLevel(logLevel)
+	logBuffer.SetSourceLevels(sourceLevels)
+	logOutput := logger.SetupGlobalLogger(logBuffer, os.Stdout)
+	logOutput.SetJSON(cfg.Server.LogFormat == "json")
+	var logFile *logger.RotatingFile
+	if cfg.Server.LogFile.Enabled {
+		logFile, err = logger.NewRotatingFile(logger.RotateConfig{
+			Path:       cfg.Server.LogFile.Path,
+			MaxSizeMB:  cfg.Server.LogFile.MaxSizeMB,
+			MaxAgeDays: cfg.Server.LogFile.MaxAgeDays,
+			MaxBackups: cfg.Server.LogFile.MaxBackups,
+		})
+		if err != nil {
+			log.Fatalf("[ERROR] Failed to open log file: %v", err)
+		}
+		defer logFile.Close()
+		logOutput.SetFile(logFile)
+	}
+
 	log.Printf("Configuration loaded from %s", configPath)
 
+	// Resolve gateway timezone
+	timeFormatter, err := timefmt.Parse(cfg.Server.Timezone, cfg.Server.TimeFormat)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid server timezone or time format: %v", err)
+	}
+	log.Printf("Gateway timezone: %s (format: %s)", timeFormatter.Location(), timeFormatter.FormatName())
+
+	// Parse retention policies up front so a typo fails fast
+	retentionAges := make(map[string]time.Duration)
+	for name, value := range map[string]string{
+		"interval": cfg.Retention.Interval,
+		"tracks":   cfg.Retention.Tracks,
+		"alerts":   cfg.Retention.Alerts,
+		"logs":     cfg.Retention.Logs,
+		"archives": cfg.Retention.Archives,
+		"coverage": cfg.Retention.Coverage,
+	} {
+		age, err := retention.ParseAge(value)
+		if err != nil {
+			log.Fatalf("[ERROR] Invalid retention.%s: %v", name, err)
+		}
+		retentionAges[name] = age
+	}
+
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Load GCJ02 correction grid if requested
+	var coordGrid *coordinator.Grid
+	if cfg.Coordinate.AccuracyMode == coordinator.AccuracyGrid {
+		coordGrid, err = coordinator.LoadGrid(cfg.Coordinate.GridPath)
+		if err != nil {
+			log.Fatalf("[ERROR] Failed to load coordinate grid from %s: %v", cfg.Coordinate.GridPath, err)
+		}
+		log.Printf("Coordinate correction grid loaded (%d tiles)", coordGrid.TileCount())
+	}
+
 	// Create core engine with coordinate conversion and track storage
 	engineCfg := core.EngineConfig{
-		RateHz:                cfg.Throttle.DefaultRateHz,
-		ConvertGCJ02:          cfg.Coordinate.ConvertGCJ02,
-		ConvertBD09:           cfg.Coordinate.ConvertBD09,
-		TrackEnabled:          cfg.Track.Enabled,
-		TrackMaxPoints:        cfg.Track.MaxPointsPerDrone,
-		TrackSampleIntervalMs: cfg.Track.SampleIntervalMs,
+		RateHz:                 cfg.Throttle.DefaultRateHz,
+		MaxRateHz:              cfg.Throttle.MaxRateHz,
+		ConvertGCJ02:           cfg.Coordinate.ConvertGCJ02,
+		ConvertBD09:            cfg.Coordinate.ConvertBD09,
+		TrackEnabled:           cfg.Track.Enabled,
+		TrackMaxPoints:         cfg.Track.MaxPointsPerDrone,
+		TrackSampleIntervalMs:  cfg.Track.SampleIntervalMs,
+		TrackMaxFlights:        cfg.Track.MaxFlightsPerDrone,
+		TrackFlightIdleMs:      int64(cfg.Track.FlightIdleSec) * 1000,
+		TrackMaxBytes:          int64(max(cfg.Track.MaxMemoryMB, 0)) << 20,
+		TrackDownsampleAfterMs: int64(cfg.Track.DownsampleAfterSec) * 1000,
+		TrackDownsampleEvery:   cfg.Track.DownsampleEvery,
+		CoordinateGrid:         coordGrid,
+		CoordinateReverseIters: cfg.Coordinate.ReverseIterations,
+
+		PublisherDegradedErrors: cfg.Health.DegradedAfterErrors,
+		PublisherStaleAfterMs:   int64(cfg.Health.StaleAfterSec) * 1000,
+		DeviceOfflineAfterMs:    int64(cfg.Health.DeviceOfflineSec) * 1000,
+
+		QuarantineMaxEntries: cfg.Quarantine.MaxEntries,
+
+		MaxDevices:       cfg.State.MaxDevices,
+		GeohashPrecision: cfg.State.GeohashPrecision,
+
+		CoverageCellSizeM: cfg.Coverage.CellSizeM,
+
+		Batching:        cfg.Batch.Enabled,
+		BatchMaxSize:    cfg.Batch.MaxSize,
+		BatchMaxLatency: time.Duration(cfg.Batch.MaxLatencyMs) * time.Millisecond,
+
+		QueueSize:  cfg.Queue.Size,
+		DropPolicy: cfg.Queue.DropPolicy,
+
+		Dedup:          cfg.Dedup.Enabled,
+		DedupSources:   cfg.Dedup.Sources,
+		DedupPositionM: cfg.Dedup.PositionM,
+	}
+	engineCfg.CoverageBucket, err = retention.ParseAge(cfg.Coverage.Bucket)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid coverage.bucket: %v", err)
+	}
+	engineCfg.StateTTL, _ = retention.ParseAge(cfg.State.ExpireAfter)
+	engineCfg.Devices, err = registry.New(cfg.Devices.RegistryFile)
+	if err != nil {
+		log.Printf("Failed to load device registry, using in-memory registry: %v", err)
+	}
+	engineCfg.Tenants, err = newTenantRegistry(cfg)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid tenants: %v", err)
+	}
+	engineCfg.Identity, err = newIdentityMapper(cfg)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid devices.aliases: %v", err)
+	}
+	engineCfg.Enrichment, err = newEnrichmentChain(cfg)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid config: %v", err)
+	}
+	if engineCfg.Enrichment != nil {
+		log.Printf("State enrichment enabled (%s)", strings.Join(engineCfg.Enrichment.Types(), " -> "))
+	}
+	if len(cfg.Tenants) > 0 {
+		log.Printf("Multi-tenancy enabled (%d tenants, %d tenant users)", len(cfg.Tenants), len(cfg.HTTP.Auth.Users))
+	}
+	for _, route := range cfg.Routing {
+		engineCfg.RoutingRules = append(engineCfg.RoutingRules, routing.Rule{
+			Name:         route.Name,
+			Enabled:      true,
+			Publishers:   route.Publishers,
+			DeviceIDs:    route.Devices,
+			DevicePrefix: route.DevicePrefix,
+			Sources:      route.Sources,
+			Topic:        route.Topic,
+		})
+	}
+	var tracer *tracing.Tracer
+	tracerCtx, stopTracer := context.WithCancel(context.Background())
+	defer stopTracer()
+	if cfg.Tracing.Enabled {
+		exporter, _ := newTracingExporter(cfg.Tracing, version)
+		tracer = tracing.New(tracing.Config{SampleRatio: cfg.Tracing.SampleRatio}, exporter)
+		tracer.Start(tracerCtx)
+		engineCfg.Tracer = tracer
+		log.Printf("Tracing enabled (OTLP %s, sampling %g)", cfg.Tracing.Endpoint, cfg.Tracing.SampleRatio)
 	}
 	engine := core.NewEngine(engineCfg)
+	if len(cfg.Routing) > 0 {
+		log.Printf("Publisher routing enabled (%d rules)", len(cfg.Routing))
+	}
+	if cfg.Batch.Enabled {
+		log.Printf("Batch publishing enabled (up to %d states, %d ms)", cfg.Batch.MaxSize, cfg.Batch.MaxLatencyMs)
+	}
 	log.Printf("Core engine created (throttle: %.1f Hz, GCJ02: %v, BD09: %v, track: %v)",
 		cfg.Throttle.DefaultRateHz, cfg.Coordinate.ConvertGCJ02, cfg.Coordinate.ConvertBD09, cfg.Track.Enabled)
 
@@ -71,6 +252,36 @@ func main() {
 			cfg.DJI.ListenAddress, cfg.DJI.MaxClients)
 	}
 
+	if cfg.External.Enabled {
+		engine.RegisterAdapter(external.New(cfg.External))
+		log.Printf("External adapter host registered (socket: %s, max adapters: %d)",
+			cfg.External.SocketPath, cfg.External.MaxAdapters)
+	}
+
+	if cfg.UDP.Enabled {
+		engine.RegisterAdapter(udp.New(cfg.UDP))
+		log.Printf("UDP JSON adapter registered (listen: %s, signed: %v)",
+			cfg.UDP.ListenAddress, cfg.UDP.Secret != "")
+	}
+
+	if cfg.NMEA.Enabled {
+		engine.RegisterAdapter(nmea.New(cfg.NMEA))
+		log.Printf("NMEA adapter registered (%s, device: %s)", cfg.NMEA.ConnectionType, cfg.NMEA.DeviceID)
+	}
+
+	var simAdapter *sim.Adapter
+	if cfg.Sim.Enabled {
+		simAdapter = sim.New(cfg.Sim)
+		engine.RegisterAdapter(simAdapter)
+		log.Printf("Simulator adapter registered (%d drones, %.1f Hz)", len(simAdapter.Drones()), cfg.Sim.RateHz)
+	}
+
+	for _, pc := range cfg.Poll {
+		pollAdapter, _ := poll.New(pc)
+		engine.RegisterAdapter(pollAdapter)
+		log.Printf("Polling adapter registered (%s: every %ds)", pc.Name, pc.IntervalSec)
+	}
+
 	// Register publishers
 	if cfg.MQTT.Enabled {
 		mqttPublisher := mqtt.New(cfg.MQTT)
@@ -78,30 +289,267 @@ func main() {
 		log.Printf("MQTT publisher registered (broker: %s)", cfg.MQTT.Broker)
 	}
 
+	if cfg.AWSIoT.Enabled {
+		awsPublisher, _ := mqtt.NewAWSIoT(cfg.AWSIoT)
+		engine.RegisterPublisher(awsPublisher)
+		log.Printf("AWS IoT publisher registered (endpoint: %s, thing: %s)", cfg.AWSIoT.Endpoint, cfg.AWSIoT.ThingName)
+	}
+
+	if cfg.AzureIoT.Enabled {
+		azurePublisher, _ := mqtt.NewAzureIoT(cfg.AzureIoT)
+		engine.RegisterPublisher(azurePublisher)
+		log.Printf("Azure IoT Hub publisher registered (twin updates: %v)", cfg.AzureIoT.Twin.Enabled)
+	}
+
+	if cfg.Redis.Enabled {
+		engine.RegisterPublisher(redis.New(cfg.Redis))
+		log.Printf("Redis publisher registered (address: %s, key prefix: %s)", cfg.Redis.Address, cfg.Redis.KeyPrefix)
+	}
+
+	if cfg.AMQP.Enabled {
+		amqpPublisher, _ := amqp.New(cfg.AMQP)
+		engine.RegisterPublisher(amqpPublisher)
+		log.Printf("AMQP publisher registered (exchange: %s, routing key: %s)", cfg.AMQP.Exchange, cfg.AMQP.RoutingKey)
+	}
+
+	if cfg.Postgres.Enabled {
+		postgresPublisher, _ := postgres.New(cfg.Postgres)
+		engine.RegisterPublisher(postgresPublisher)
+		log.Printf("PostgreSQL publisher registered (address: %s, database: %s)", cfg.Postgres.Address, cfg.Postgres.Database)
+	}
+
+	if cfg.STANAG4586.Enabled {
+		stanagPublisher, _ := stanag4586.New(cfg.STANAG4586)
+		engine.RegisterPublisher(stanagPublisher)
+		log.Printf("STANAG 4586 publisher registered (address: %s, VSM ID: %d)", cfg.STANAG4586.Address, cfg.STANAG4586.VSMID)
+	}
+
 	if cfg.GB28181.Enabled {
 		gb28181Publisher := gb28181.New(cfg.GB28181)
+		gb28181Publisher.SetLocation(timeFormatter.Location())
 		engine.RegisterPublisher(gb28181Publisher)
+		// Report alerts and geofence breaches to the platform as alarms
+		engine.Events().Subscribe("gb28181", func(ev events.Event) {
+			go func() {
+				if err := gb28181Publisher.PublishAlert(ev.Alert); err != nil {
+					log.Printf("[GB28181] Failed to send alarm for alert %s: %v", ev.Alert.ID, err)
+				}
+			}()
+		}, events.AlertRaised)
 		log.Printf("GB28181 publisher registered (server: %s:%d, device: %s)",
 			cfg.GB28181.ServerIP, cfg.GB28181.ServerPort, cfg.GB28181.DeviceID)
 	}
 
+	// Register external plugins
+	for _, pc := range cfg.Plugins {
+		switch pc.Kind {
+		case plugin.KindAdapter:
+			engine.RegisterAdapter(plugin.NewAdapter(pc))
+		case plugin.KindPublisher:
+			engine.RegisterPublisher(plugin.NewPublisher(pc))
+		default:
+			log.Fatalf("[ERROR] Plugin %s has unknown kind %q (want adapter or publisher)", pc.Name, pc.Kind)
+		}
+		log.Printf("Plugin %s registered (%s: %s)", pc.Name, pc.Kind, pc.Command)
+	}
+
 	// Start engine
 	if err := engine.Start(ctx); err != nil {
-		log.Fatalf("Failed to start engine: %v", err)
+		log.Fatalf("[ERROR] Failed to start engine: %v", err)
 	}
 
+	// Self-test mode: validate the pipeline and exit
+	if selfTest {
+		report := engine.RunSelfTest()
+		printSelfTestReport(report)
+		cancel()
+		engine.Stop()
+		if !report.Passed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Pseudonyms are shared by anonymized exports and the public feed
+	anonymizer := newAnonymizer(cfg.Export.AnonymizeKey)
+
 	// Start HTTP API server
 	var httpServer *api.Server
 	if cfg.HTTP.Enabled {
 		httpServer = api.New(cfg.HTTP, engine, version)
+		httpServer.SetTimeFormatter(timeFormatter)
+		httpServer.SetLogBuffer(logBuffer)
+		httpServer.SetAnonymizer(anonymizer)
+		incidentWindow, _ := retention.ParseAge(cfg.Incidents.Window)
+		httpServer.GetIncidents().SetWindow(incidentWindow)
+		httpServer.GetGeofenceEngine().SetPredictHorizon(time.Duration(cfg.Geofence.PredictSec) * time.Second)
+		httpServer.GetGeofenceEngine().SetDefaultDatum(cfg.Geofence.DefaultDatum)
+		httpServer.SetConverter(engine.Converter())
+		httpServer.SetConfigValidator(validateConfig)
+		if simAdapter != nil {
+			httpServer.SetSimulator(simAdapter)
+		}
+		if cfg.Audit.Enabled {
+			auditLog, err := audit.Open(audit.Config{Path: cfg.Audit.Path, MaxEntries: cfg.Audit.MaxEntries})
+			if err != nil {
+				log.Fatalf("[ERROR] Failed to open audit log: %v", err)
+			}
+			defer auditLog.Close()
+			httpServer.SetAuditLog(auditLog)
+			log.Printf("Audit log enabled (%s)", cfg.Audit.Path)
+		}
 		if err := httpServer.Start(ctx); err != nil {
-			log.Fatalf("Failed to start HTTP server: %v", err)
+			log.Fatalf("[ERROR] Failed to start HTTP server: %v", err)
+		}
+		// Raise alerts when a publisher degrades or recovers
+		engine.SetPublisherHealthCallback(httpServer.HandlePublisherHealth)
+
+		// Re-notify about alerts that stay unacknowledged
+		notifier, _ := newNotifier(cfg.Notifications)
+		httpServer.SetNotifier(notifier)
+		policies, _ := escalationPolicies(cfg.Notifications)
+		// Policies restored from the alert store that were dropped from the config
+		for _, p := range httpServer.GetAlerter().GetEscalationPolicies() {
+			if strings.HasPrefix(p.ID, configPolicyPrefix) {
+				httpServer.GetAlerter().DeleteEscalationPolicy(p.ID)
+			}
+		}
+		for _, p := range policies {
+			httpServer.GetAlerter().CreateEscalationPolicy(p)
+		}
+		if len(cfg.Notifications.Channels) > 0 {
+			log.Printf("Alert escalation enabled (channels: %s, policies: %d)",
+				strings.Join(notifier.Names(), ", "), len(policies))
 		}
-		// Connect WebSocket broadcast to engine state updates
-		engine.SetStateCallback(httpServer.BroadcastState)
 		log.Printf("HTTP API server started (address: %s, WebSocket: /api/v1/ws)", cfg.HTTP.Address)
 	}
 
+	// Import state written by "outb restore"
+	importRestoredSnapshots(cfg.Backup.RestoreDir, engine, httpServer)
+
+	// Start public feed on its own listener
+	var publicServer *public.Server
+	if cfg.PublicFeed.Enabled {
+		delay, _ := retention.ParseAge(cfg.PublicFeed.Delay)
+		staleAfter, _ := retention.ParseAge(cfg.PublicFeed.StaleAfter)
+		feed := publicfeed.New(publicfeed.Config{
+			Delay:      delay,
+			PrecisionM: cfg.PublicFeed.PrecisionM,
+			StaleAfter: staleAfter,
+			Anonymizer: anonymizer,
+		})
+		engine.Events().Subscribe("publicfeed", func(ev events.Event) {
+			feed.Observe(ev.State, time.Now())
+		}, events.StateUpdated)
+
+		publicServer = public.New(public.Config{
+			Address:   cfg.PublicFeed.Address,
+			RateLimit: cfg.PublicFeed.RateLimit,
+		}, feed)
+		if err := publicServer.Start(); err != nil {
+			log.Fatalf("[ERROR] Failed to start public feed: %v", err)
+		}
+		log.Printf("Public feed started (address: %s, delay: %s, precision: %.0f m)",
+			cfg.PublicFeed.Address, retention.FormatAge(delay), cfg.PublicFeed.PrecisionM)
+	}
+
+	// Telemetry archive
+	var archive *archiver.Archiver
+	if cfg.Archive.Enabled {
+		store, _ := newTarget(cfg.Archive.Target, cfg.Archive.Path, cfg.Archive.S3)
+		rotate, _ := retention.ParseAge(cfg.Archive.Rotate)
+		archive, _ = archiver.New(archiver.Config{
+			Format:  cfg.Archive.Format,
+			Rotate:  rotate,
+			MaxRows: cfg.Archive.MaxRows,
+		}, store)
+		engine.Events().Subscribe("archiver", func(ev events.Event) {
+			archive.Observe(ev.State)
+		}, events.StateUpdated)
+		go archive.Run(ctx)
+		log.Printf("Telemetry archive configured (format: %s, target: %s, rotate: %s)",
+			cfg.Archive.Format, store, retention.FormatAge(rotate))
+	}
+
+	// Recurring tasks run as scheduler jobs
+	schedules, _ := jobSchedules(cfg)
+	jobs := scheduler.New(timeFormatter.Location())
+	addJob := func(def scheduler.Definition) {
+		def.Schedule, def.Disabled = schedules[def.Name].Schedule, schedules[def.Name].Disabled
+		if err := jobs.Add(def); err != nil {
+			log.Printf("Failed to add job: %v", err)
+		}
+	}
+
+	// Data retention janitor
+	janitor := retention.NewJanitor()
+	janitor.Add("track points", retentionAges["tracks"], engine.PruneTracks)
+	janitor.Add("log entries", retentionAges["logs"], logBuffer.Prune)
+	janitor.Add("coverage periods", retentionAges["coverage"], engine.PruneCoverage)
+	if logFile != nil {
+		janitor.Add("log archives", retentionAges["archives"], logFile.PruneBefore)
+	}
+	if httpServer != nil {
+		janitor.Add("alerts", retentionAges["alerts"], httpServer.GetAlerter().Prune)
+		janitor.Add("incidents", retentionAges["alerts"], httpServer.GetIncidents().Prune)
+	}
+	addJob(scheduler.Definition{
+		Name:        jobRetention,
+		Description: "Prune data older than its retention period",
+		RunAtStart:  true,
+		Task:        retentionTask(janitor),
+	})
+	log.Printf("Retention janitor configured (tracks: %s, alerts: %s, logs: %s, archives: %s, coverage: %s)",
+		retention.FormatAge(retentionAges["tracks"]), retention.FormatAge(retentionAges["alerts"]),
+		retention.FormatAge(retentionAges["logs"]), retention.FormatAge(retentionAges["archives"]),
+		retention.FormatAge(retentionAges["coverage"]))
+
+	// Scheduled backups
+	if cfg.Backup.Enabled {
+		target, _ := newBackupTarget(cfg.Backup)
+		trackWindow, _ := retention.ParseAge(cfg.Backup.TrackWindow)
+		runner := backup.NewRunner(target, cfg.Backup.Keep,
+			backupCollector(configPath, cfg, engine, httpServer, trackWindow))
+		addJob(scheduler.Definition{
+			Name:        jobBackup,
+			Description: "Back up configuration and state to " + target.String(),
+			Task: func(ctx context.Context) (string, error) {
+				name, err := runner.Run()
+				if err != nil {
+					return "", err
+				}
+				return "Stored " + name, nil
+			},
+		})
+		log.Printf("Backups configured (target: %s, keep: %d)", target, cfg.Backup.Keep)
+	}
+
+	// Alert escalation checks
+	if httpServer != nil {
+		alerts := httpServer.GetAlerter()
+		addJob(scheduler.Definition{
+			Name:        jobEscalations,
+			Description: "Escalate alerts that stay unacknowledged",
+			Task: func(ctx context.Context) (string, error) {
+				return fmt.Sprintf("%d alert(s) escalated", alerts.CheckEscalations(time.Now())), nil
+			},
+		})
+	}
+
+	jobs.Start(ctx)
+	if httpServer != nil {
+		httpServer.SetScheduler(jobs)
+	}
+	var jobSummary []string
+	for _, job := range jobs.Jobs() {
+		if job.Enabled {
+			jobSummary = append(jobSummary, job.Name+": "+job.Schedule)
+		} else {
+			jobSummary = append(jobSummary, job.Name+": disabled")
+		}
+	}
+	log.Printf("Job scheduler started (%s)", strings.Join(jobSummary, ", "))
+
 	log.Println("Gateway is running. Press Ctrl+C to stop.")
 	fmt.Println()
 
@@ -116,17 +564,65 @@ func main() {
 	// Cancel context to stop all goroutines
 	cancel()
 
-	// Stop HTTP server first
+	// Stop HTTP servers first
 	if httpServer != nil {
 		if err := httpServer.Stop(); err != nil {
 			log.Printf("Error stopping HTTP server: %v", err)
 		}
 	}
+	if publicServer != nil {
+		if err := publicServer.Stop(); err != nil {
+			log.Printf("Error stopping public feed: %v", err)
+		}
+	}
 
 	// Stop engine gracefully
 	if err := engine.Stop(); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
 
+	// Write the telemetry received since the last archive file
+	if archive != nil {
+		if _, err := archive.Flush(); err != nil {
+			log.Printf("Error writing archive: %v", err)
+		}
+	}
+
+	// Export the spans still queued
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	tracer.Flush(flushCtx)
+	cancelFlush()
+
 	log.Println("Shutdown complete")
 }
+
+// newAnonymizer creates the anonymizer for pseudonymized exports
+func newAnonymizer(key string) anonymize.Anonymizer {
+	if key != "" {
+		return anonymize.NewHMAC([]byte(key))
+	}
+	a, err := anonymize.NewRandomHMAC()
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to create export anonymizer: %v", err)
+	}
+	log.Printf("No export.anonymize_key configured, anonymized IDs will change on restart")
+	return a
+}
+
+// printSelfTestReport prints a self-test report as a table
+func printSelfTestReport(report core.SelfTestReport) {
+	fmt.Println()
+	fmt.Println("Self-test results:")
+	for _, st := range report.Stages {
+		line := fmt.Sprintf("  %-4s  %-9s  %-20s  %4dms", strings.ToUpper(st.Result), st.Stage, st.Component, st.DurationMs)
+		if st.Error != "" {
+			line += "  " + st.Error
+		}
+		fmt.Println(line)
+	}
+	if report.Passed {
+		fmt.Println("Self-test PASSED")
+	} else {
+		fmt.Println("Self-test FAILED")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/notify"
+	"github.com/open-uav/telemetry-bridge/internal/core/retention"
+)
+
+// newNotifier builds the notification channels from the notifications section
+func newNotifier(cfg config.NotificationsConfig) (*notify.Dispatcher, error) {
+	channels := make([]notify.Channel, 0, len(cfg.Channels))
+	seen := make(map[string]bool)
+	for _, c := range cfg.Channels {
+		if c.Name == "" {
+			return nil, fmt.Errorf("channel name is required")
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("duplicate channel %q", c.Name)
+		}
+		seen[c.Name] = true
+
+		var channel notify.Channel
+		switch c.Type {
+		case "webhook":
+			if c.URL == "" {
+				return nil, fmt.Errorf("channel %s: url is required", c.Name)
+			}
+			channel = notify.NewWebhook(c.Name, c.URL, c.Headers)
+		case "email":
+			if c.SMTPAddress == "" || c.From == "" || len(c.To) == 0 {
+				return nil, fmt.Errorf("channel %s: smtp_address, from and to are required", c.Name)
+			}
+			channel = notify.NewEmail(notify.EmailConfig{
+				Name:     c.Name,
+				Address:  c.SMTPAddress,
+				Username: c.Username,
+				Password: c.Password,
+				From:     c.From,
+				To:       c.To,
+			})
+		case "sms":
+			if c.Username == "" || c.Password == "" || c.From == "" || len(c.To) == 0 {
+				return nil, fmt.Errorf("channel %s: username, password, from and to are required", c.Name)
+			}
+			channel = notify.NewSMS(notify.SMSConfig{
+				Name:       c.Name,
+				URL:        c.URL,
+				AccountSID: c.Username,
+				AuthToken:  c.Password,
+				From:       c.From,
+				To:         c.To,
+			})
+		default:
+			return nil, fmt.Errorf("channel %s: unknown type %q (want webhook, email or sms)", c.Name, c.Type)
+		}
+		if c.RateLimit < 0 {
+			return nil, fmt.Errorf("channel %s: rate_limit must not be negative", c.Name)
+		}
+		channels = append(channels, notify.WithRateLimit(channel, c.RateLimit))
+	}
+	return notify.NewDispatcher(channels...), nil
+}
+
+// configPolicyPrefix starts the IDs of the escalation policies created from
+// the configuration
+const configPolicyPrefix = "config-"
+
+// escalationPolicies converts the configured escalation policies, checking
+// that they only name configured channels. IDs follow the configuration
+// order, so they stay the same across restarts.
+func escalationPolicies(cfg config.NotificationsConfig) ([]*alerter.EscalationPolicy, error) {
+	channels := make(map[string]bool)
+	for _, c := range cfg.Channels {
+		channels[c.Name] = true
+	}
+
+	policies := make([]*alerter.EscalationPolicy, 0, len(cfg.Escalations))
+	for i, e := range cfg.Escalations {
+		after, err := retention.ParseAge(e.After)
+		if err != nil {
+			return nil, fmt.Errorf("escalation %s: after: %w", e.Name, err)
+		}
+		severity := alerter.AlertSeverity(e.MinSeverity)
+		switch severity {
+		case "", alerter.SeverityInfo, alerter.SeverityWarning, alerter.SeverityCritical:
+		default:
+			return nil, fmt.Errorf("escalation %s: unknown min_severity %q", e.Name, e.MinSeverity)
+		}
+		if len(e.Channels) == 0 {
+			return nil, fmt.Errorf("escalation %s: at least one channel is required", e.Name)
+		}
+		for _, name := range e.Channels {
+			if !channels[name] {
+				return nil, fmt.Errorf("escalation %s: unknown channel %q", e.Name, name)
+			}
+		}
+
+		types := make([]alerter.AlertType, len(e.Types))
+		for i, t := range e.Types {
+			types[i] = alerter.AlertType(t)
+		}
+		policies = append(policies, &alerter.EscalationPolicy{
+			ID:           fmt.Sprintf("%s%d", configPolicyPrefix, i+1),
+			Name:         e.Name,
+			Enabled:      true,
+			MinSeverity:  severity,
+			Types:        types,
+			AfterMs:      after.Milliseconds(),
+			Channels:     e.Channels,
+			BumpSeverity: e.BumpSeverity,
+		})
+	}
+	return policies, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+)
+
+func TestNotificationsConfig(t *testing.T) {
+	cfg := config.NotificationsConfig{
+		Channels: []config.NotificationChannelConfig{
+			{Name: "ops", Type: "webhook", URL: "https://hooks.example.com/outb"},
+			{Name: "oncall", Type: "email", SMTPAddress: "smtp.example.com:587", From: "outb@example.com", To: []string{"oncall@example.com"}},
+			{Name: "pager", Type: "sms", Username: "AC123", Password: "token", From: "+15551234", To: []string{"+15559876"}, RateLimit: 5},
+		},
+		Escalations: []config.EscalationConfig{
+			{Name: "Page", After: "10m", Channels: []string{"oncall"}},
+			{Name: "Bump", MinSeverity: "warning", Types: []string{"battery_low"}, After: "5m", Channels: []string{"ops"}, BumpSeverity: true},
+		},
+	}
+
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		t.Fatalf("newNotifier() error = %v", err)
+	}
+	if names := notifier.Names(); len(names) != 3 {
+		t.Errorf("Names() = %v, want all channels", names)
+	}
+
+	policies, err := escalationPolicies(cfg)
+	if err != nil {
+		t.Fatalf("escalationPolicies() error = %v", err)
+	}
+	if p := policies[0]; p.ID != "config-1" || p.AfterMs != 600000 || !p.Enabled || p.MinSeverity != "" {
+		t.Errorf("Policy 0 = %+v", p)
+	}
+	if p := policies[1]; p.MinSeverity != alerter.SeverityWarning || p.Types[0] != alerter.AlertTypeBatteryLow || !p.BumpSeverity {
+		t.Errorf("Policy 1 = %+v", p)
+	}
+
+	invalid := []struct {
+		name string
+		cfg  config.NotificationsConfig
+		want string
+	}{
+		{"unknown type", config.NotificationsConfig{Channels: []config.NotificationChannelConfig{{Name: "x", Type: "pager"}}}, "unknown type"},
+		{"duplicate", config.NotificationsConfig{Channels: []config.NotificationChannelConfig{cfg.Channels[0], cfg.Channels[0]}}, "duplicate"},
+		{"sms without credentials", config.NotificationsConfig{Channels: []config.NotificationChannelConfig{{Name: "x", Type: "sms", From: "+1555", To: []string{"+1555"}}}}, "required"},
+		{"negative rate limit", config.NotificationsConfig{Channels: []config.NotificationChannelConfig{{Name: "x", Type: "webhook", URL: "http://x", RateLimit: -1}}}, "rate_limit"},
+		{"email without recipients", config.NotificationsConfig{Channels: []config.NotificationChannelConfig{{Name: "x", Type: "email", SMTPAddress: "smtp:25"}}}, "required"},
+		{"unknown channel", config.NotificationsConfig{Escalations: []config.EscalationConfig{{Name: "x", After: "1m", Channels: []string{"phone"}}}}, "unknown channel"},
+		{"bad delay", config.NotificationsConfig{Channels: cfg.Channels, Escalations: []config.EscalationConfig{{Name: "x", After: "later", Channels: []string{"ops"}}}}, "after"},
+	}
+	for _, tt := range invalid {
+		_, err1 := newNotifier(tt.cfg)
+		_, err2 := escalationPolicies(tt.cfg)
+		if (err1 == nil || !strings.Contains(err1.Error(), tt.want)) && (err2 == nil || !strings.Contains(err2.Error(), tt.want)) {
+			t.Errorf("%s: errors %v / %v, want %q", tt.name, err1, err2, tt.want)
+		}
+	}
+}
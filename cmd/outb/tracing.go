@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/tracing"
+)
+
+// newTracingExporter builds the OTLP exporter from the tracing section
+func newTracingExporter(cfg config.TracingConfig, version string) (*tracing.OTLPExporter, error) {
+	return tracing.NewOTLPExporter(tracing.OTLPConfig{
+		Endpoint:       cfg.Endpoint,
+		Headers:        cfg.Headers,
+		ServiceName:    cfg.ServiceName,
+		ServiceVersion: version,
+	})
+}
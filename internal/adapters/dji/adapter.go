@@ -3,17 +3,26 @@ package dji
 import (
 	"bufio"
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/internal/core/adapterstats"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
 )
 
 // MessageType defines the type of message from DJI forwarder
@@ -32,23 +41,47 @@ type Message struct {
 	SDKVersion string         `json:"sdk_version,omitempty"`
 	Timestamp int64           `json:"timestamp,omitempty"`
 	Data      json.RawMessage `json:"data,omitempty"`
+
+	Token    string `json:"token,omitempty"`    // Pre-shared token, sent in the hello message
+	Encoding string `json:"encoding,omitempty"` // Encoding requested in the hello and confirmed in its ack
+
+	State *models.DroneState `json:"-"` // State of a Protobuf STATE message, instead of Data
 }
 
+// clientSweepInterval is how often clients are checked against the client
+// timeout
+const clientSweepInterval = time.Second
+
+// errHelloRejected is returned for hello messages failing the allow list
+// or the token check
+var errHelloRejected = errors.New("hello rejected")
+
+// errStateRejected is returned for states claiming another device than the
+// one that said hello
+var errStateRejected = errors.New("state rejected")
+
 // Client represents a connected DJI forwarder client
 type Client struct {
 	conn       net.Conn
 	deviceID   string
 	sdkVersion string
-	lastSeen   time.Time
+	lastSeen   time.Time // Guarded by the adapter's mu once registered
+	protobuf   bool // Replies are sent in Protobuf, negotiated in the hello
 }
 
 // Adapter implements the core.Adapter interface for DJI forwarder protocol
 type Adapter struct {
-	cfg      config.DJIConfig
-	listener net.Listener
-	clients  map[string]*Client
-	mu       sync.RWMutex
-	wg       sync.WaitGroup
+	cfg        config.DJIConfig
+	listener   net.Listener
+	clients    map[string]*Client
+	quarantine *quarantine.Store
+	mu         sync.RWMutex
+	wg         sync.WaitGroup
+
+	tlsConfig *tls.Config // nil without TLS
+	rejected  atomic.Uint64
+	stats     *adapterstats.Counter
+	offline   func(deviceID string) // Called for evicted clients, may be nil
 }
 
 // New creates a new DJI adapter
@@ -56,6 +89,7 @@ func New(cfg config.DJIConfig) *Adapter {
 	return &Adapter{
 		cfg:     cfg,
 		clients: make(map[string]*Client),
+		stats:   adapterstats.New(),
 	}
 }
 
@@ -66,21 +100,86 @@ func (a *Adapter) Name() string {
 
 // Start begins listening for DJI forwarder connections
 func (a *Adapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	if a.cfg.TLS.Enabled {
+		tlsConfig, err := LoadTLSConfig(a.cfg.TLS)
+		if err != nil {
+			return err
+		}
+		a.tlsConfig = tlsConfig
+	}
+
 	listener, err := net.Listen("tcp", a.cfg.ListenAddress)
 	if err != nil {
 		return fmt.Errorf("failed to listen on %s: %w", a.cfg.ListenAddress, err)
 	}
 	a.listener = listener
 
-	log.Printf("[DJI] TCP server listening on %s", a.cfg.ListenAddress)
+	log.Printf("[DJI] TCP server listening on %s (TLS: %v, client certificates: %v, token: %v)",
+		a.cfg.ListenAddress, a.tlsConfig != nil, a.cfg.TLS.Enabled && a.cfg.TLS.ClientCAFile != "", a.cfg.Token != "")
 
 	// Accept connections in a goroutine
 	a.wg.Add(1)
 	go a.acceptLoop(ctx, events)
 
+	if a.cfg.ClientTimeoutSec > 0 {
+		a.wg.Add(1)
+		go a.sweepLoop(ctx)
+	}
+
 	return nil
 }
 
+// SetOfflineCallback sets the function called with the device ID of each
+// evicted client
+func (a *Adapter) SetOfflineCallback(fn func(deviceID string)) {
+	a.offline = fn
+}
+
+// sweepLoop periodically evicts silent clients
+func (a *Adapter) sweepLoop(ctx context.Context) {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(clientSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.evictSilent(now)
+		}
+	}
+}
+
+// evictSilent removes the clients not heard from within the client timeout,
+// closes their connections and reports their devices offline. Forwarders
+// that vanish without closing the connection would otherwise stay
+// registered until a read fails.
+func (a *Adapter) evictSilent(now time.Time) {
+	timeout := time.Duration(a.cfg.ClientTimeoutSec) * time.Second
+
+	var evicted []*Client
+	a.mu.Lock()
+	for id, client := range a.clients {
+		if now.Sub(client.lastSeen) > timeout {
+			delete(a.clients, id)
+			evicted = append(evicted, client)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, client := range evicted {
+		log.Printf("[DJI] Client %s silent for %s, evicting", client.deviceID, timeout)
+		if client.conn != nil {
+			client.conn.Close()
+		}
+		if a.offline != nil {
+			a.offline(client.deviceID)
+		}
+	}
+}
+
 // Stop gracefully stops the adapter
 func (a *Adapter) Stop() error {
 	if a.listener != nil {
@@ -141,6 +240,11 @@ func (a *Adapter) acceptLoop(ctx context.Context, events chan<- *models.DroneSta
 
 		log.Printf("[DJI] New connection from %s", conn.RemoteAddr())
 
+		// The handshake runs on the first read, in the client's goroutine
+		if a.tlsConfig != nil {
+			conn = tls.Server(conn, a.tlsConfig)
+		}
+
 		a.wg.Add(1)
 		go a.handleClient(ctx, conn, events)
 	}
@@ -150,6 +254,8 @@ func (a *Adapter) acceptLoop(ctx context.Context, events chan<- *models.DroneSta
 func (a *Adapter) handleClient(ctx context.Context, conn net.Conn, events chan<- *models.DroneState) {
 	defer a.wg.Done()
 	defer conn.Close()
+	a.stats.PeerConnected()
+	defer a.stats.PeerDisconnected()
 
 	client := &Client{
 		conn:     conn,
@@ -194,34 +300,57 @@ func (a *Adapter) handleClient(ctx context.Context, conn net.Conn, events chan<-
 			a.removeClient(client)
 			return
 		}
+		a.stats.Received(len(lengthBuf) + len(msgBuf))
 
 		// Parse message
-		var msg Message
-		if err := json.Unmarshal(msgBuf, &msg); err != nil {
-			log.Printf("[DJI] JSON parse error from %s: %v", conn.RemoteAddr(), err)
+		msg, err := parseMessage(msgBuf)
+		if err != nil {
+			log.Printf("[DJI] Parse error from %s: %v", conn.RemoteAddr(), err)
+			a.stats.ParseError()
+			a.quarantinePayload(client, msgBuf, err)
 			continue
 		}
 
+		a.mu.Lock()
 		client.lastSeen = time.Now()
+		a.mu.Unlock()
 
 		// Handle message by type
 		switch msg.Type {
 		case MessageTypeHello:
-			a.handleHello(client, &msg)
+			if err := a.handleHello(client, msg); err != nil {
+				a.rejected.Add(1)
+				log.Printf("[DJI] Closing connection from %s: %v", conn.RemoteAddr(), err)
+				a.removeClient(client)
+				return
+			}
 		case MessageTypeState:
-			a.handleState(client, &msg, events)
+			if err := a.handleState(ctx, client, msg, msgBuf, events); err != nil {
+				a.rejected.Add(1)
+				log.Printf("[DJI] Closing connection from %s: %v", conn.RemoteAddr(), err)
+				a.removeClient(client)
+				return
+			}
 		case MessageTypeHeartbeat:
 			// Send ACK for heartbeat
 			ack := Message{Type: "ack"}
-			a.sendMessage(client.conn, &ack)
+			a.reply(client, &ack)
 		default:
 			log.Printf("[DJI] Unknown message type from %s: %s", conn.RemoteAddr(), msg.Type)
 		}
 	}
 }
 
-// handleHello processes HELLO message
-func (a *Adapter) handleHello(client *Client, msg *Message) {
+// handleHello processes HELLO message. It returns an error, and the
+// connection is closed, if the device isn't allowed or the token is wrong.
+func (a *Adapter) handleHello(client *Client, msg *Message) error {
+	if err := a.checkHello(msg); err != nil {
+		return err
+	}
+	if client.deviceID != "" && client.deviceID != msg.DeviceID {
+		a.removeClient(client)
+	}
+
 	client.deviceID = msg.DeviceID
 	client.sdkVersion = msg.SDKVersion
 
@@ -229,38 +358,216 @@ func (a *Adapter) handleHello(client *Client, msg *Message) {
 	a.clients[client.deviceID] = client
 	a.mu.Unlock()
 
-	log.Printf("[DJI] Client registered: %s (SDK %s)", client.deviceID, client.sdkVersion)
-
-	// Send ACK
+	// The ack is sent in the encoding of the previous messages; the
+	// confirmed encoding applies from then on
 	ack := Message{Type: "ack"}
-	a.sendMessage(client.conn, &ack)
+	if msg.Encoding == EncodingProtobuf {
+		ack.Encoding = EncodingProtobuf
+	}
+	a.reply(client, &ack)
+	client.protobuf = ack.Encoding == EncodingProtobuf
+
+	log.Printf("[DJI] Client registered: %s (SDK %s, protobuf: %v)", client.deviceID, client.sdkVersion, client.protobuf)
+	return nil
+}
+
+// checkHello verifies the device ID of a hello message against the allow
+// list and its token against the pre-shared token
+func (a *Adapter) checkHello(msg *Message) error {
+	if msg.DeviceID == "" {
+		return fmt.Errorf("%w: missing device_id", errHelloRejected)
+	}
+	if len(a.cfg.AllowedIDs) > 0 {
+		allowed := false
+		for _, id := range a.cfg.AllowedIDs {
+			if id == msg.DeviceID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: device %s is not in dji.allowed_ids", errHelloRejected, msg.DeviceID)
+		}
+	}
+	if a.cfg.Token != "" && subtle.ConstantTimeCompare([]byte(msg.Token), []byte(a.cfg.Token)) != 1 {
+		return fmt.Errorf("%w: invalid token from device %s", errHelloRejected, msg.DeviceID)
+	}
+	return nil
+}
+
+// Stats returns the messages, parse errors, connections and bytes received
+func (a *Adapter) Stats() sdk.AdapterStats {
+	return a.stats.Stats()
+}
+
+// Rejected returns the number of connections closed for a rejected hello
+// or a state of another device
+func (a *Adapter) Rejected() uint64 {
+	return a.rejected.Load()
 }
 
-// handleState processes STATE message
-func (a *Adapter) handleState(client *Client, msg *Message, events chan<- *models.DroneState) {
+// LoadTLSConfig builds the listener TLS configuration. With a client CA
+// file, clients must present a certificate signed by it.
+func LoadTLSConfig(cfg config.DJITLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates in client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// handleState processes STATE message. It returns an error, and the
+// connection is closed, if the state claims another device than the client's.
+func (a *Adapter) handleState(ctx context.Context, client *Client, msg *Message, raw []byte, events chan<- *models.DroneState) error {
 	if client.deviceID == "" {
 		log.Printf("[DJI] State received before hello from %s", client.conn.RemoteAddr())
-		return
+		return nil
 	}
 
-	// Parse DroneState from data
-	var state models.DroneState
-	if err := json.Unmarshal(msg.Data, &state); err != nil {
+	state, err := decodeState(client.deviceID, msg)
+	if err != nil {
 		log.Printf("[DJI] Failed to parse state from %s: %v", client.deviceID, err)
-		return
+		a.stats.ParseError()
+		a.quarantinePayload(client, raw, err)
+		return nil
+	}
+	if state.DeviceID != client.deviceID {
+		return fmt.Errorf("%w: state for device %s from device %s", errStateRejected, state.DeviceID, client.deviceID)
+	}
+
+	// Send to events channel; blocks while the engine applies back-pressure
+	select {
+	case events <- state:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// decodeState parses the DroneState carried by a STATE message
+func decodeState(deviceID string, msg *Message) (*models.DroneState, error) {
+	var state models.DroneState
+	if msg.State != nil {
+		state = *msg.State
+	} else if err := json.Unmarshal(msg.Data, &state); err != nil {
+		return nil, err
 	}
 
 	// Ensure device ID and protocol source are set
 	if state.DeviceID == "" {
-		state.DeviceID = client.deviceID
+		state.DeviceID = deviceID
 	}
 	state.ProtocolSource = "dji"
 
-	// Send to events channel
+	// The bridge sends complete states
+	if state.Freshness.IsZero() {
+		state.Freshness = models.FreshnessAt(state.Timestamp)
+	}
+	return &state, nil
+}
+
+// SetQuarantine sets the store for frames that fail to parse
+func (a *Adapter) SetQuarantine(q *quarantine.Store) {
+	a.quarantine = q
+}
+
+// quarantinePayload stores a frame that failed to parse
+func (a *Adapter) quarantinePayload(client *Client, raw []byte, err error) {
+	if a.quarantine == nil {
+		return
+	}
+	source := ""
+	if client.conn != nil {
+		source = client.conn.RemoteAddr().String()
+	}
+	a.quarantine.Add(a.Name(), client.deviceID, source, raw, err)
+}
+
+// Replay re-parses a quarantined STATE frame
+func (a *Adapter) Replay(entry quarantine.Entry) (*models.DroneState, error) {
+	msg, err := parseMessage(entry.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding message: %w", err)
+	}
+	if msg.Type != MessageTypeState {
+		return nil, fmt.Errorf("not a state message: %q", msg.Type)
+	}
+
+	deviceID := entry.DeviceID
+	if deviceID == "" {
+		deviceID = msg.DeviceID
+	}
+	state, err := decodeState(deviceID, msg)
+	if err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+	if state.DeviceID == "" {
+		return nil, fmt.Errorf("state without device_id")
+	}
+	if entry.DeviceID != "" && state.DeviceID != entry.DeviceID {
+		return nil, fmt.Errorf("state for device %s from device %s", state.DeviceID, entry.DeviceID)
+	}
+	return state, nil
+}
+
+// SelfTest checks that the listener accepts connections and decodes a
+// synthetic state message in loopback without emitting it
+func (a *Adapter) SelfTest() (*models.DroneState, error) {
+	if a.listener == nil {
+		return nil, fmt.Errorf("listener not started")
+	}
+
+	// The accept loop treats an immediate close as a normal disconnect
+	conn, err := net.DialTimeout("tcp", a.listener.Addr().String(), 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to listener: %w", err)
+	}
+	conn.Close()
+
+	synthetic := models.NewDroneState("dji-selftest", "dji")
+	synthetic.Timestamp = time.Now().UnixMilli()
+	synthetic.Location.Lat = 39.9087
+	synthetic.Location.Lon = 116.3975
+	data, err := json.Marshal(synthetic)
+	if err != nil {
+		return nil, fmt.Errorf("encoding state: %w", err)
+	}
+	raw, err := json.Marshal(Message{Type: MessageTypeState, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("encoding message: %w", err)
+	}
+
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("decoding message: %w", err)
+	}
+
+	events := make(chan *models.DroneState, 1)
+	if err := a.handleState(context.Background(), &Client{deviceID: synthetic.DeviceID}, &msg, raw, events); err != nil {
+		return nil, err
+	}
+
 	select {
-	case events <- &state:
+	case state := <-events:
+		return state, nil
 	default:
-		// Channel full, skip
+		return nil, fmt.Errorf("state message was not decoded")
 	}
 }
 
@@ -274,12 +581,25 @@ func (a *Adapter) removeClient(client *Client) {
 	}
 }
 
-// sendMessage sends a message to a connection
+// reply sends a message to a client in its negotiated encoding
+func (a *Adapter) reply(client *Client, msg *Message) error {
+	if client.protobuf {
+		return sendFrame(client.conn, encodeProtoMessage(msg))
+	}
+	return a.sendMessage(client.conn, msg)
+}
+
+// sendMessage sends a JSON message to a connection
 func (a *Adapter) sendMessage(conn net.Conn, msg *Message) error {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
+	return sendFrame(conn, data)
+}
+
+// sendFrame writes a length-prefixed frame
+func sendFrame(conn net.Conn, data []byte) error {
 
 	// Write length prefix
 	lengthBuf := make([]byte, 4)
@@ -1,14 +1,17 @@
 package dji
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
+	"io"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestNew(t *testing.T) {
@@ -330,7 +333,7 @@ func TestAdapter_handleState(t *testing.T) {
 	events := make(chan *models.DroneState, 1)
 
 	// Handle state
-	a.handleState(client, msg, events)
+	a.handleState(context.Background(), client, msg, nil, events)
 
 	// Check event was sent
 	select {
@@ -371,7 +374,7 @@ func TestAdapter_handleState_NoHello(t *testing.T) {
 	events := make(chan *models.DroneState, 1)
 
 	// Handle state - should be rejected
-	a.handleState(client, msg, events)
+	a.handleState(context.Background(), client, msg, nil, events)
 
 	// Check no event was sent
 	select {
@@ -404,7 +407,7 @@ func TestAdapter_handleState_SetDeviceID(t *testing.T) {
 	events := make(chan *models.DroneState, 1)
 
 	// Handle state
-	a.handleState(client, msg, events)
+	a.handleState(context.Background(), client, msg, nil, events)
 
 	// Check event has deviceID set from client
 	select {
@@ -416,3 +419,110 @@ func TestAdapter_handleState_SetDeviceID(t *testing.T) {
 		t.Error("State event should be sent")
 	}
 }
+
+func TestAdapter_handleState_Quarantine(t *testing.T) {
+	a := New(config.DJIConfig{})
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+
+	client := &Client{deviceID: "test-drone"}
+
+	// Latitude sent as a string by a buggy forwarder
+	raw := []byte(`{"type":"state","data":{"location":{"lat":"39.9087","lon":116.3975}}}`)
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	events := make(chan *models.DroneState, 1)
+	a.handleState(context.Background(), client, &msg, raw, events)
+
+	select {
+	case <-events:
+		t.Fatal("Malformed state should not be emitted")
+	default:
+	}
+
+	entries := q.List("dji", 0)
+	if len(entries) != 1 {
+		t.Fatalf("Quarantined entries = %d, want 1", len(entries))
+	}
+	if entries[0].DeviceID != "test-drone" || string(entries[0].Payload) != string(raw) {
+		t.Errorf("Unexpected entry: %+v", entries[0])
+	}
+
+	// Replay still fails with the same payload
+	if _, err := a.Replay(entries[0]); err == nil {
+		t.Error("Replay of malformed payload should fail")
+	}
+
+	// A fixed payload replays with the stored device ID
+	entries[0].Payload = []byte(`{"type":"state","data":{"location":{"lat":39.9087,"lon":116.3975}}}`)
+	state, err := a.Replay(entries[0])
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if state.DeviceID != "test-drone" || state.Location.Lat != 39.9087 {
+		t.Errorf("Unexpected replayed state: %+v", state)
+	}
+}
+
+func TestAdapter_SelfTest(t *testing.T) {
+	a := New(config.DJIConfig{ListenAddress: "127.0.0.1:0", MaxClients: 1})
+
+	if _, err := a.SelfTest(); err == nil {
+		t.Error("SelfTest should fail before Start")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := a.Start(ctx, make(chan *models.DroneState, 1)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		// Cancel before Stop so the accept loop exits
+		cancel()
+		a.Stop()
+	}()
+
+	state, err := a.SelfTest()
+	if err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+	if state.ProtocolSource != "dji" {
+		t.Errorf("ProtocolSource = %s, want 'dji'", state.ProtocolSource)
+	}
+	if a.GetClientCount() != 0 {
+		t.Error("SelfTest must not register a client")
+	}
+}
+
+func TestAdapter_evictSilent(t *testing.T) {
+	a := New(config.DJIConfig{ClientTimeoutSec: 60})
+	var offline []string
+	a.SetOfflineCallback(func(deviceID string) { offline = append(offline, deviceID) })
+
+	silentConn, silentPeer := net.Pipe()
+	defer silentPeer.Close()
+	activeConn, activePeer := net.Pipe()
+	defer activeConn.Close()
+	defer activePeer.Close()
+
+	now := time.Now()
+	a.mu.Lock()
+	a.clients["drone-1"] = &Client{conn: silentConn, deviceID: "drone-1", lastSeen: now.Add(-61 * time.Second)}
+	a.clients["drone-2"] = &Client{conn: activeConn, deviceID: "drone-2", lastSeen: now.Add(-10 * time.Second)}
+	a.mu.Unlock()
+
+	a.evictSilent(now)
+
+	if clients := a.GetClients(); len(clients) != 1 || clients[0] != "drone-2" {
+		t.Errorf("Clients after eviction = %v, want [drone-2]", clients)
+	}
+	if len(offline) != 1 || offline[0] != "drone-1" {
+		t.Errorf("Offline = %v, want [drone-1]", offline)
+	}
+	silentPeer.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := silentPeer.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read from evicted connection = %v, want EOF after close", err)
+	}
+}
@@ -0,0 +1,268 @@
+package dji
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/protowire"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Encodings negotiated in the hello exchange
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+)
+
+// Protobuf field numbers from proto/dji_forwarder.proto
+const (
+	fieldMessageType       = 1
+	fieldMessageDeviceID   = 2
+	fieldMessageSDKVersion = 3
+	fieldMessageTimestamp  = 4
+	fieldMessageState      = 5
+	fieldMessageToken      = 6
+	fieldMessageEncoding   = 7
+
+	fieldStateDeviceID  = 1
+	fieldStateTimestamp = 2
+	fieldStateLocation  = 3
+	fieldStateAttitude  = 4
+	fieldStateStatus    = 5
+	fieldStateVelocity  = 6
+
+	fieldLocationLat              = 1
+	fieldLocationLon              = 2
+	fieldLocationAltBaro          = 3
+	fieldLocationAltGNSS          = 4
+	fieldLocationCoordinateSystem = 5
+
+	fieldAttitudeRoll  = 1
+	fieldAttitudePitch = 2
+	fieldAttitudeYaw   = 3
+
+	fieldStatusBatteryPercent = 1
+	fieldStatusFlightMode     = 2
+	fieldStatusArmed          = 3
+	fieldStatusSignalQuality  = 4
+	fieldStatusBatterySerial  = 5
+	fieldStatusPayloadID      = 6
+
+	fieldVelocityVx = 1
+	fieldVelocityVy = 2
+	fieldVelocityVz = 3
+)
+
+// parseMessage decodes a frame. JSON frames start with '{', which as a
+// Protobuf tag would be field 15 with the unused group wire type, so any
+// other frame is decoded as Protobuf.
+func parseMessage(data []byte) (*Message, error) {
+	var msg Message
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+	}
+	if err := decodeProtoMessage(data, &msg); err != nil {
+		return nil, fmt.Errorf("protobuf: %w", err)
+	}
+	return &msg, nil
+}
+
+// encodeProtoMessage serializes a message in Protobuf wire format. States
+// are taken from State, not Data.
+func encodeProtoMessage(msg *Message) []byte {
+	var buf []byte
+	buf = appendString(buf, fieldMessageType, string(msg.Type))
+	buf = appendString(buf, fieldMessageDeviceID, msg.DeviceID)
+	buf = appendString(buf, fieldMessageSDKVersion, msg.SDKVersion)
+	if msg.Timestamp != 0 {
+		buf = protowire.AppendVarint(buf, fieldMessageTimestamp, uint64(msg.Timestamp))
+	}
+	if msg.State != nil {
+		buf = protowire.AppendBytes(buf, fieldMessageState, encodeProtoState(msg.State))
+	}
+	buf = appendString(buf, fieldMessageToken, msg.Token)
+	buf = appendString(buf, fieldMessageEncoding, msg.Encoding)
+	return buf
+}
+
+// decodeProtoMessage parses a Protobuf message into msg
+func decodeProtoMessage(data []byte, msg *Message) error {
+	return protowire.Walk(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case fieldMessageType:
+			msg.Type = MessageType(b)
+		case fieldMessageDeviceID:
+			msg.DeviceID = string(b)
+		case fieldMessageSDKVersion:
+			msg.SDKVersion = string(b)
+		case fieldMessageTimestamp:
+			msg.Timestamp = int64(v)
+		case fieldMessageState:
+			state, err := decodeProtoState(b)
+			if err != nil {
+				return err
+			}
+			msg.State = state
+		case fieldMessageToken:
+			msg.Token = string(b)
+		case fieldMessageEncoding:
+			msg.Encoding = string(b)
+		}
+		return nil
+	})
+}
+
+// encodeProtoState serializes the fields of a state the forwarder sends
+func encodeProtoState(s *models.DroneState) []byte {
+	var buf []byte
+	buf = appendString(buf, fieldStateDeviceID, s.DeviceID)
+	if s.Timestamp != 0 {
+		buf = protowire.AppendVarint(buf, fieldStateTimestamp, uint64(s.Timestamp))
+	}
+
+	var loc []byte
+	loc = appendDouble(loc, fieldLocationLat, s.Location.Lat)
+	loc = appendDouble(loc, fieldLocationLon, s.Location.Lon)
+	loc = appendFloat(loc, fieldLocationAltBaro, s.Location.AltBaro)
+	loc = appendFloat(loc, fieldLocationAltGNSS, s.Location.AltGNSS)
+	loc = appendString(loc, fieldLocationCoordinateSystem, s.Location.CoordinateSystem)
+	buf = protowire.AppendBytes(buf, fieldStateLocation, loc)
+
+	var att []byte
+	att = appendFloat(att, fieldAttitudeRoll, s.Attitude.Roll)
+	att = appendFloat(att, fieldAttitudePitch, s.Attitude.Pitch)
+	att = appendFloat(att, fieldAttitudeYaw, s.Attitude.Yaw)
+	buf = protowire.AppendBytes(buf, fieldStateAttitude, att)
+
+	var status []byte
+	status = appendInt32(status, fieldStatusBatteryPercent, s.Status.BatteryPercent)
+	status = appendString(status, fieldStatusFlightMode, string(s.Status.FlightMode))
+	if s.Status.Armed {
+		status = protowire.AppendVarint(status, fieldStatusArmed, 1)
+	}
+	status = appendInt32(status, fieldStatusSignalQuality, s.Status.SignalQuality)
+	status = appendString(status, fieldStatusBatterySerial, s.Status.BatterySerial)
+	status = appendString(status, fieldStatusPayloadID, s.Status.PayloadID)
+	buf = protowire.AppendBytes(buf, fieldStateStatus, status)
+
+	var vel []byte
+	vel = appendFloat(vel, fieldVelocityVx, s.Velocity.Vx)
+	vel = appendFloat(vel, fieldVelocityVy, s.Velocity.Vy)
+	vel = appendFloat(vel, fieldVelocityVz, s.Velocity.Vz)
+	buf = protowire.AppendBytes(buf, fieldStateVelocity, vel)
+	return buf
+}
+
+// decodeProtoState parses a Protobuf DroneState
+func decodeProtoState(data []byte) (*models.DroneState, error) {
+	var s models.DroneState
+	err := protowire.Walk(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case fieldStateDeviceID:
+			s.DeviceID = string(b)
+		case fieldStateTimestamp:
+			s.Timestamp = int64(v)
+		case fieldStateLocation:
+			return protowire.Walk(b, func(field, wire int, v uint64, b []byte) error {
+				switch field {
+				case fieldLocationLat:
+					s.Location.Lat = math.Float64frombits(v)
+				case fieldLocationLon:
+					s.Location.Lon = math.Float64frombits(v)
+				case fieldLocationAltBaro:
+					s.Location.AltBaro = float32Value(v)
+				case fieldLocationAltGNSS:
+					s.Location.AltGNSS = float32Value(v)
+				case fieldLocationCoordinateSystem:
+					s.Location.CoordinateSystem = string(b)
+				}
+				return nil
+			})
+		case fieldStateAttitude:
+			return protowire.Walk(b, func(field, wire int, v uint64, b []byte) error {
+				switch field {
+				case fieldAttitudeRoll:
+					s.Attitude.Roll = float32Value(v)
+				case fieldAttitudePitch:
+					s.Attitude.Pitch = float32Value(v)
+				case fieldAttitudeYaw:
+					s.Attitude.Yaw = float32Value(v)
+				}
+				return nil
+			})
+		case fieldStateStatus:
+			return protowire.Walk(b, func(field, wire int, v uint64, b []byte) error {
+				switch field {
+				case fieldStatusBatteryPercent:
+					s.Status.BatteryPercent = int(int32(v))
+				case fieldStatusFlightMode:
+					s.Status.FlightMode = models.FlightMode(b)
+				case fieldStatusArmed:
+					s.Status.Armed = v != 0
+				case fieldStatusSignalQuality:
+					s.Status.SignalQuality = int(int32(v))
+				case fieldStatusBatterySerial:
+					s.Status.BatterySerial = string(b)
+				case fieldStatusPayloadID:
+					s.Status.PayloadID = string(b)
+				}
+				return nil
+			})
+		case fieldStateVelocity:
+			return protowire.Walk(b, func(field, wire int, v uint64, b []byte) error {
+				switch field {
+				case fieldVelocityVx:
+					s.Velocity.Vx = float32Value(v)
+				case fieldVelocityVy:
+					s.Velocity.Vy = float32Value(v)
+				case fieldVelocityVz:
+					s.Velocity.Vz = float32Value(v)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Proto3 leaves out fields with the default value
+
+func appendString(buf []byte, field int, s string) []byte {
+	if s == "" {
+		return buf
+	}
+	return protowire.AppendBytes(buf, field, []byte(s))
+}
+
+func appendInt32(buf []byte, field int, v int) []byte {
+	if v == 0 {
+		return buf
+	}
+	return protowire.AppendVarint(buf, field, uint64(int32(v)))
+}
+
+func appendFloat(buf []byte, field int, v float64) []byte {
+	if v == 0 {
+		return buf
+	}
+	return protowire.AppendFloat(buf, field, float32(v))
+}
+
+func appendDouble(buf []byte, field int, v float64) []byte {
+	if v == 0 {
+		return buf
+	}
+	return protowire.AppendDouble(buf, field, v)
+}
+
+func float32Value(v uint64) float64 {
+	return float64(math.Float32frombits(uint32(v)))
+}
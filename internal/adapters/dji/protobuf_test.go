@@ -0,0 +1,126 @@
+package dji
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestProtoMessage_RoundTrip(t *testing.T) {
+	state := &models.DroneState{
+		DeviceID:  "dji-m300-01",
+		Timestamp: 1700000000123,
+		Location:  models.Location{Lat: 39.9087123, Lon: 116.3975456, AltBaro: 120.5, AltGNSS: 150.25, CoordinateSystem: "WGS84"},
+		Attitude:  models.Attitude{Roll: -0.25, Pitch: 0.5, Yaw: 270},
+		Status:    models.Status{BatteryPercent: 85, FlightMode: models.FlightModeAuto, Armed: true, SignalQuality: 90, PayloadID: "H20T"},
+		Velocity:  models.Velocity{Vx: 5.5, Vy: -1.5, Vz: 0.25},
+	}
+	data := encodeProtoMessage(&Message{Type: MessageTypeState, State: state})
+	if data[0] == '{' {
+		t.Fatal("Protobuf frame must not start with '{'")
+	}
+
+	msg, err := parseMessage(data)
+	if err != nil {
+		t.Fatalf("parseMessage failed: %v", err)
+	}
+	if msg.Type != MessageTypeState || msg.State == nil {
+		t.Fatalf("Decoded %+v, want a state message", msg)
+	}
+	if !reflect.DeepEqual(msg.State, state) {
+		t.Errorf("State = %+v, want %+v", *msg.State, *state)
+	}
+
+	if _, err := parseMessage(data[:len(data)-3]); err == nil {
+		t.Error("Truncated frame should fail to parse")
+	}
+}
+
+func TestAdapter_ProtobufNegotiation(t *testing.T) {
+	a := New(config.DJIConfig{ListenAddress: "127.0.0.1:0", MaxClients: 4})
+	events := make(chan *models.DroneState, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		cancel()
+		a.Stop()
+	}()
+
+	conn, err := net.Dial("tcp", a.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The hello and its ack are JSON
+	writeFrame(t, conn, Message{Type: MessageTypeHello, DeviceID: "drone-1", Encoding: EncodingProtobuf})
+	ack, err := readFrame(conn)
+	if err != nil || ack.Type != "ack" || ack.Encoding != EncodingProtobuf {
+		t.Fatalf("Hello = %+v, %v, want ack with protobuf encoding", ack, err)
+	}
+
+	writeProtoFrame := func(msg *Message) {
+		data := encodeProtoMessage(msg)
+		if _, err := conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(data))), data...)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	writeProtoFrame(&Message{Type: MessageTypeState, State: &models.DroneState{
+		Timestamp: 1000,
+		Location:  models.Location{Lat: 39.9, Lon: 116.4},
+		Status:    models.Status{BatteryPercent: 60},
+	}})
+	select {
+	case state := <-events:
+		if state.DeviceID != "drone-1" || state.ProtocolSource != "dji" || state.Location.Lat != 39.9 || state.Status.BatteryPercent != 60 {
+			t.Errorf("State = %+v", state)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for state")
+	}
+
+	// Replies are Protobuf from now on
+	writeProtoFrame(&Message{Type: MessageTypeHeartbeat, Timestamp: 2000})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	lengthBuf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, lengthBuf); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(lengthBuf))
+	if _, err := io.ReadFull(conn, data); err != nil {
+		t.Fatal(err)
+	}
+	if data[0] == '{' {
+		t.Fatalf("Heartbeat ack is JSON: %s", data)
+	}
+	if msg, err := parseMessage(data); err != nil || msg.Type != "ack" {
+		t.Errorf("Heartbeat ack = %+v, %v", msg, err)
+	}
+}
+
+func TestAdapter_HelloWithoutEncoding(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	a := New(config.DJIConfig{})
+	c := &Client{conn: server}
+	go a.handleHello(c, &Message{Type: MessageTypeHello, DeviceID: "drone-1"})
+
+	ack, err := readFrame(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ack.Encoding != "" {
+		t.Errorf("Encoding = %q, want none for JSON clients", ack.Encoding)
+	}
+}
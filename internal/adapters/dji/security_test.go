@@ -0,0 +1,306 @@
+package dji
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAdapter_checkHello(t *testing.T) {
+	a := New(config.DJIConfig{AllowedIDs: []string{"drone-1", "drone-2"}, Token: "s3cret"})
+
+	tests := []struct {
+		name string
+		msg  Message
+		ok   bool
+	}{
+		{"allowed with token", Message{DeviceID: "drone-1", Token: "s3cret"}, true},
+		{"wrong token", Message{DeviceID: "drone-1", Token: "guess"}, false},
+		{"missing token", Message{DeviceID: "drone-2"}, false},
+		{"not allowed", Message{DeviceID: "rogue", Token: "s3cret"}, false},
+		{"missing device id", Message{Token: "s3cret"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := a.checkHello(&tt.msg)
+			if (err == nil) != tt.ok {
+				t.Errorf("checkHello() error = %v, want ok %v", err, tt.ok)
+			}
+			if err != nil && !errors.Is(err, errHelloRejected) {
+				t.Errorf("checkHello() error = %v, want errHelloRejected", err)
+			}
+		})
+	}
+
+	if err := New(config.DJIConfig{}).checkHello(&Message{DeviceID: "anyone"}); err != nil {
+		t.Errorf("Without allow list and token any device should be accepted: %v", err)
+	}
+}
+
+// writeCert writes a PEM certificate and key signed by parent (self-signed
+// when parent is nil) and returns the certificate
+func writeCert(t *testing.T, dir, name string, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// writeFrame sends a length-prefixed message
+func writeFrame(t *testing.T, conn net.Conn, msg Message) {
+	t.Helper()
+	data, _ := json.Marshal(msg)
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	if _, err := conn.Write(append(frame, data...)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+}
+
+// readFrame reads a length-prefixed JSON or Protobuf message
+func readFrame(conn net.Conn) (*Message, error) {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	lengthBuf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, lengthBuf); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(lengthBuf))
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return nil, err
+	}
+	return parseMessage(data)
+}
+
+func TestAdapter_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	caCert, caKey := writeCert(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "OUTB Test CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	writeCert(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "outb"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caCert, caKey)
+	writeCert(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "drone-1"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, caKey)
+
+	a := New(config.DJIConfig{
+		ListenAddress: "127.0.0.1:0",
+		MaxClients:    4,
+		AllowedIDs:    []string{"drone-1"},
+		Token:         "s3cret",
+		TLS: config.DJITLSConfig{
+			Enabled:      true,
+			CertFile:     filepath.Join(dir, "server.crt"),
+			KeyFile:      filepath.Join(dir, "server.key"),
+			ClientCAFile: filepath.Join(dir, "ca.crt"),
+		},
+	})
+	events := make(chan *models.DroneState, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		cancel()
+		a.Stop()
+	}()
+	addr := a.listener.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	clientCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dial := func(certs []tls.Certificate) *tls.Conn {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, Certificates: certs})
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		return conn
+	}
+
+	// Without a client certificate the server aborts the handshake
+	conn := dial(nil)
+	writeFrame(t, conn, Message{Type: MessageTypeHello, DeviceID: "drone-1", Token: "s3cret"})
+	if _, err := readFrame(conn); err == nil {
+		t.Error("Connection without a client certificate should be refused")
+	}
+	conn.Close()
+
+	// A wrong token closes the connection
+	conn = dial([]tls.Certificate{clientCert})
+	writeFrame(t, conn, Message{Type: MessageTypeHello, DeviceID: "drone-1", Token: "guess"})
+	if _, err := readFrame(conn); err == nil {
+		t.Error("Hello with a wrong token should not be acknowledged")
+	}
+	conn.Close()
+	if a.Rejected() != 1 {
+		t.Errorf("Rejected() = %d, want 1", a.Rejected())
+	}
+
+	// A client certificate and the token are accepted
+	conn = dial([]tls.Certificate{clientCert})
+	defer conn.Close()
+	writeFrame(t, conn, Message{Type: MessageTypeHello, DeviceID: "drone-1", Token: "s3cret"})
+	if ack, err := readFrame(conn); err != nil || ack.Type != "ack" {
+		t.Fatalf("Hello = %+v, %v, want ack", ack, err)
+	}
+	data, _ := json.Marshal(models.NewDroneState("drone-1", "dji"))
+	writeFrame(t, conn, Message{Type: MessageTypeState, Data: data})
+	select {
+	case state := <-events:
+		if state.DeviceID != "drone-1" {
+			t.Errorf("DeviceID = %s, want drone-1", state.DeviceID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for state")
+	}
+}
+
+func TestAdapter_SpoofedDeviceID(t *testing.T) {
+	a := New(config.DJIConfig{ListenAddress: "127.0.0.1:0", MaxClients: 4})
+	events := make(chan *models.DroneState, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		cancel()
+		a.Stop()
+	}()
+
+	conn, err := net.Dial("tcp", a.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	writeFrame(t, conn, Message{Type: MessageTypeHello, DeviceID: "drone-1"})
+	if ack, err := readFrame(conn); err != nil || ack.Type != "ack" {
+		t.Fatalf("Hello = %+v, %v, want ack", ack, err)
+	}
+
+	// A state of another device is dropped and closes the connection
+	data, _ := json.Marshal(models.NewDroneState("drone-2", "dji"))
+	writeFrame(t, conn, Message{Type: MessageTypeState, Data: data})
+	if _, err := readFrame(conn); err == nil {
+		t.Error("Connection sending a state of another device should be closed")
+	}
+	select {
+	case state := <-events:
+		t.Errorf("Spoofed state for %s was emitted", state.DeviceID)
+	default:
+	}
+	if a.Rejected() != 1 {
+		t.Errorf("Rejected() = %d, want 1", a.Rejected())
+	}
+}
+
+func TestAdapter_RejectedRehello(t *testing.T) {
+	a := New(config.DJIConfig{ListenAddress: "127.0.0.1:0", MaxClients: 4, Token: "s3cret"})
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := a.Start(ctx, make(chan *models.DroneState, 1)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		cancel()
+		a.Stop()
+	}()
+
+	conn, err := net.Dial("tcp", a.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	writeFrame(t, conn, Message{Type: MessageTypeHello, DeviceID: "drone-1", Token: "s3cret"})
+	if ack, err := readFrame(conn); err != nil || ack.Type != "ack" {
+		t.Fatalf("Hello = %+v, %v, want ack", ack, err)
+	}
+
+	// A rejected second hello closes the connection and unregisters it
+	writeFrame(t, conn, Message{Type: MessageTypeHello, DeviceID: "drone-1", Token: "guess"})
+	if _, err := readFrame(conn); err == nil {
+		t.Error("Hello with a wrong token should close the connection")
+	}
+	if clients := a.GetClients(); len(clients) != 0 {
+		t.Errorf("GetClients() = %v after the rejected hello, want none", clients)
+	}
+	if a.Rejected() != 1 {
+		t.Errorf("Rejected() = %d, want 1", a.Rejected())
+	}
+}
+
+func TestLoadTLSConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadTLSConfig(config.DJITLSConfig{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: filepath.Join(dir, "missing.key")}); err == nil {
+		t.Error("Missing certificate should fail")
+	}
+
+	writeCert(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, nil, nil)
+	os.WriteFile(filepath.Join(dir, "empty.pem"), []byte("not a certificate"), 0600)
+	_, err := LoadTLSConfig(config.DJITLSConfig{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "empty.pem"),
+	})
+	if err == nil {
+		t.Error("Client CA file without certificates should fail")
+	}
+}
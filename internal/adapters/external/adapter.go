@@ -0,0 +1,401 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/adapterstats"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// namePrefix is prepended to registered adapter names in status listings
+const namePrefix = "external:"
+
+// client is a connected out-of-process adapter
+type client struct {
+	conn         net.Conn
+	name         string // Registered name, empty until registered
+	version      string
+	capabilities map[string]bool
+	writeMu      sync.Mutex // Serializes frames written to conn
+}
+
+// send writes a message to the adapter
+func (c *client) send(msg *Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writeMessage(c.conn, msg)
+}
+
+// Adapter implements the core.Adapter interface for out-of-process adapters
+// connecting over a UNIX domain socket
+type Adapter struct {
+	cfg        config.ExternalConfig
+	listener   net.Listener
+	quarantine *quarantine.Store
+	mu         sync.RWMutex
+	conns      map[net.Conn]*client
+	registered map[string]*client // By registered name
+	wg         sync.WaitGroup
+	stats      *adapterstats.Counter // Traffic of all hosted adapters
+}
+
+// New creates a new external adapter host
+func New(cfg config.ExternalConfig) *Adapter {
+	return &Adapter{
+		cfg:        cfg,
+		conns:      make(map[net.Conn]*client),
+		registered: make(map[string]*client),
+		stats:      adapterstats.New(),
+	}
+}
+
+// Name returns the adapter name
+func (a *Adapter) Name() string {
+	return "external"
+}
+
+// Start listens on the socket for adapter connections
+func (a *Adapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	if err := removeStaleSocket(a.cfg.SocketPath); err != nil {
+		return err
+	}
+	listener, err := net.Listen("unix", a.cfg.SocketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", a.cfg.SocketPath, err)
+	}
+	if err := os.Chmod(a.cfg.SocketPath, 0660); err != nil {
+		listener.Close()
+		return fmt.Errorf("setting socket permissions: %w", err)
+	}
+	a.listener = listener
+
+	log.Printf("[External] Listening for adapters on %s", a.cfg.SocketPath)
+
+	a.wg.Add(1)
+	go a.acceptLoop(ctx, events)
+
+	// Unblock Accept when the context is cancelled
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	return nil
+}
+
+// removeStaleSocket removes a socket file left behind by a previous run. It
+// fails if another process is still listening on it.
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use by another process", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("removing stale socket %s: %w", path, err)
+	}
+	return nil
+}
+
+// Stop tells connected adapters to shut down and closes the socket
+func (a *Adapter) Stop() error {
+	if a.listener != nil {
+		a.listener.Close()
+	}
+
+	a.mu.Lock()
+	for conn, c := range a.conns {
+		if c.name != "" {
+			c.send(&Message{Type: MessageTypeShutdown})
+		}
+		conn.Close()
+	}
+	a.mu.Unlock()
+
+	a.wg.Wait()
+	log.Printf("[External] Adapter host stopped")
+	return nil
+}
+
+// acceptLoop accepts new connections
+func (a *Adapter) acceptLoop(ctx context.Context, events chan<- *models.DroneState) {
+	defer a.wg.Done()
+
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("[External] Accept error: %v", err)
+			continue
+		}
+
+		a.mu.Lock()
+		a.conns[conn] = &client{conn: conn}
+		a.mu.Unlock()
+
+		a.wg.Add(1)
+		go a.handleConn(ctx, conn, events)
+	}
+}
+
+// handleConn runs the handshake and then reads state frames until the
+// adapter disconnects
+func (a *Adapter) handleConn(ctx context.Context, conn net.Conn, events chan<- *models.DroneState) {
+	defer a.wg.Done()
+	defer a.removeConn(conn)
+	a.stats.PeerConnected()
+	defer a.stats.PeerDisconnected()
+
+	reader := bufio.NewReader(conn)
+
+	conn.SetReadDeadline(time.Now().Add(ReadTimeout))
+	c, err := a.register(conn, reader)
+	if err != nil {
+		log.Printf("[External] Registration rejected: %v", err)
+		writeMessage(conn, &Message{Type: MessageTypeReject, Error: err.Error()})
+		return
+	}
+
+	log.Printf("[External] Adapter registered: %s (version %s, capabilities %v)",
+		c.name, c.version, sortedCapabilities(c.capabilities))
+	defer log.Printf("[External] Adapter disconnected: %s", c.name)
+
+	for ctx.Err() == nil {
+		conn.SetReadDeadline(time.Now().Add(ReadTimeout))
+		raw, err := readFrame(reader)
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				log.Printf("[External] Read error from %s: %v", c.name, err)
+			}
+			return
+		}
+		a.stats.Received(frameHeaderSize + len(raw))
+
+		var msg Message
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			log.Printf("[External] JSON parse error from %s: %v", c.name, err)
+			a.stats.ParseError()
+			a.quarantinePayload(c.name, raw, err)
+			continue
+		}
+		a.handleMessage(ctx, c, &msg, raw, events)
+	}
+}
+
+// register reads the register frame and adds the adapter, or returns why
+// it was rejected
+func (a *Adapter) register(conn net.Conn, reader io.Reader) (*client, error) {
+	raw, err := readFrame(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading register frame: %w", err)
+	}
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("decoding register frame: %w", err)
+	}
+
+	switch {
+	case msg.Type != MessageTypeRegister:
+		return nil, fmt.Errorf("first frame must be register, got %q", msg.Type)
+	case !validName.MatchString(msg.Name):
+		return nil, fmt.Errorf("invalid adapter name %q", msg.Name)
+	case msg.Protocol != ProtocolVersion:
+		return nil, fmt.Errorf("%s: unsupported protocol version %d (want %d)", msg.Name, msg.Protocol, ProtocolVersion)
+	}
+
+	caps := negotiate(msg.Capabilities)
+	c := &client{
+		conn:         conn,
+		name:         msg.Name,
+		version:      msg.Version,
+		capabilities: make(map[string]bool, len(caps)),
+	}
+	for _, name := range caps {
+		c.capabilities[name] = true
+	}
+	if !c.capabilities[CapabilityState] {
+		return nil, fmt.Errorf("%s: the %q capability is required", msg.Name, CapabilityState)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, taken := a.registered[c.name]; taken {
+		return nil, fmt.Errorf("%s: an adapter with this name is already registered", c.name)
+	}
+	if len(a.registered) >= a.cfg.MaxAdapters {
+		return nil, fmt.Errorf("%s: max adapters reached (%d)", c.name, a.cfg.MaxAdapters)
+	}
+
+	welcome := &Message{Type: MessageTypeWelcome, Protocol: ProtocolVersion, Capabilities: caps}
+	if err := writeMessage(conn, welcome); err != nil {
+		return nil, fmt.Errorf("%s: sending welcome: %w", c.name, err)
+	}
+	a.registered[c.name] = c
+	a.conns[conn] = c
+	return c, nil
+}
+
+// handleMessage processes a frame from a registered adapter
+func (a *Adapter) handleMessage(ctx context.Context, c *client, msg *Message, raw []byte, events chan<- *models.DroneState) {
+	switch {
+	case msg.Type == MessageTypeState:
+		a.emit(ctx, c.name, msg.Data, raw, events)
+	case msg.Type == MessageTypeBatch && c.capabilities[CapabilityBatch]:
+		for _, data := range msg.States {
+			a.emit(ctx, c.name, data, data, events)
+		}
+	case msg.Type == MessageTypeHeartbeat && c.capabilities[CapabilityHeartbeat]:
+		c.send(&Message{Type: MessageTypeHeartbeat})
+	default:
+		log.Printf("[External] Unexpected message type from %s: %s", c.name, msg.Type)
+	}
+}
+
+// emit decodes a state and sends it to the events channel
+func (a *Adapter) emit(ctx context.Context, name string, data json.RawMessage, raw []byte, events chan<- *models.DroneState) {
+	state, err := decodeState(name, data)
+	if err != nil {
+		log.Printf("[External] Failed to parse state from %s: %v", name, err)
+		a.stats.ParseError()
+		a.quarantinePayload(name, raw, err)
+		return
+	}
+
+	// Blocks while the engine applies back-pressure
+	select {
+	case events <- state:
+	case <-ctx.Done():
+	}
+}
+
+// decodeState parses a DroneState sent by the named adapter
+func decodeState(name string, data json.RawMessage) (*models.DroneState, error) {
+	var state models.DroneState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.DeviceID == "" {
+		return nil, fmt.Errorf("state without device_id")
+	}
+	if state.ProtocolSource == "" {
+		state.ProtocolSource = name
+	}
+	// States without freshness are taken as complete updates
+	if state.Freshness.IsZero() {
+		state.Freshness = models.FreshnessAt(state.Timestamp)
+	}
+	return &state, nil
+}
+
+// removeConn forgets a connection and its registration
+func (a *Adapter) removeConn(conn net.Conn) {
+	conn.Close()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if c, ok := a.conns[conn]; ok && c.name != "" && a.registered[c.name] == c {
+		delete(a.registered, c.name)
+	}
+	delete(a.conns, conn)
+}
+
+// Stats returns the frames, parse errors, connections and bytes received
+// from all hosted adapters
+func (a *Adapter) Stats() sdk.AdapterStats {
+	return a.stats.Stats()
+}
+
+// HostedAdapters returns the names of the registered adapters, sorted
+func (a *Adapter) HostedAdapters() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	names := make([]string, 0, len(a.registered))
+	for name := range a.registered {
+		names = append(names, namePrefix+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetQuarantine sets the store for frames that fail to parse
+func (a *Adapter) SetQuarantine(q *quarantine.Store) {
+	a.quarantine = q
+}
+
+// quarantinePayload stores a frame that failed to parse. The registered
+// adapter name is kept as the source.
+func (a *Adapter) quarantinePayload(name string, raw []byte, err error) {
+	if a.quarantine != nil {
+		a.quarantine.Add(a.Name(), "", name, raw, err)
+	}
+}
+
+// Replay re-parses a quarantined state frame
+func (a *Adapter) Replay(entry quarantine.Entry) (*models.DroneState, error) {
+	var msg Message
+	if err := json.Unmarshal(entry.Payload, &msg); err != nil {
+		return nil, fmt.Errorf("decoding message: %w", err)
+	}
+	data := json.RawMessage(entry.Payload)
+	if msg.Type != "" {
+		if msg.Type != MessageTypeState {
+			return nil, fmt.Errorf("not a state message: %q", msg.Type)
+		}
+		data = msg.Data
+	}
+	return decodeState(entry.Source, data)
+}
+
+// SelfTest checks that the socket accepts connections and decodes a
+// synthetic state in loopback without emitting it
+func (a *Adapter) SelfTest() (*models.DroneState, error) {
+	if a.listener == nil {
+		return nil, fmt.Errorf("listener not started")
+	}
+
+	// An immediate close is a rejected registration, logged but harmless
+	conn, err := net.DialTimeout("unix", a.cfg.SocketPath, 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to socket: %w", err)
+	}
+	conn.Close()
+
+	synthetic := models.NewDroneState("external-selftest", "")
+	synthetic.Timestamp = time.Now().UnixMilli()
+	synthetic.Location.Lat = 39.9087
+	synthetic.Location.Lon = 116.3975
+	data, err := json.Marshal(synthetic)
+	if err != nil {
+		return nil, fmt.Errorf("encoding state: %w", err)
+	}
+	return decodeState("selftest", data)
+}
+
+// sortedCapabilities returns the capability names, sorted
+func sortedCapabilities(caps map[string]bool) []string {
+	result := make([]string, 0, len(caps))
+	for c := range caps {
+		result = append(result, c)
+	}
+	sort.Strings(result)
+	return result
+}
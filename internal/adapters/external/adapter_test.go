@@ -0,0 +1,307 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// startAdapter starts an adapter host on a socket in a temp directory
+func startAdapter(t *testing.T, maxAdapters int) (*Adapter, chan *models.DroneState) {
+	t.Helper()
+
+	a := New(config.ExternalConfig{
+		Enabled:     true,
+		SocketPath:  filepath.Join(t.TempDir(), "adapters.sock"),
+		MaxAdapters: maxAdapters,
+	})
+	events := make(chan *models.DroneState, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		a.Stop()
+	})
+	return a, events
+}
+
+// testConn is the adapter side of a connection
+type testConn struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(t *testing.T, a *Adapter) *testConn {
+	t.Helper()
+	conn, err := net.Dial("unix", a.cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testConn{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *testConn) send(msg *Message) {
+	c.t.Helper()
+	if err := writeMessage(c.conn, msg); err != nil {
+		c.t.Fatalf("send error = %v", err)
+	}
+}
+
+func (c *testConn) recv() *Message {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	raw, err := readFrame(c.reader)
+	if err != nil {
+		c.t.Fatalf("recv error = %v", err)
+	}
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		c.t.Fatalf("recv decode error = %v", err)
+	}
+	return &msg
+}
+
+// register connects and completes the handshake
+func register(t *testing.T, a *Adapter, name string, caps ...string) *testConn {
+	t.Helper()
+	c := dial(t, a)
+	c.send(&Message{Type: MessageTypeRegister, Name: name, Version: "1.0", Protocol: ProtocolVersion, Capabilities: caps})
+	if msg := c.recv(); msg.Type != MessageTypeWelcome {
+		t.Fatalf("handshake reply = %+v, want welcome", msg)
+	}
+	return c
+}
+
+func stateData(t *testing.T, deviceID string) json.RawMessage {
+	t.Helper()
+	state := models.NewDroneState(deviceID, "")
+	state.Location.Lat = 22.5
+	state.Location.Lon = 114.0
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func receive(t *testing.T, events chan *models.DroneState) *models.DroneState {
+	t.Helper()
+	select {
+	case s := <-events:
+		return s
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for state")
+		return nil
+	}
+}
+
+// waitFor polls until cond holds
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAdapter_RegisterAndStream(t *testing.T) {
+	a, events := startAdapter(t, 4)
+
+	c := dial(t, a)
+	c.send(&Message{
+		Type:         MessageTypeRegister,
+		Name:         "lidar",
+		Version:      "1.2",
+		Protocol:     ProtocolVersion,
+		Capabilities: []string{"heartbeat", "state", "video"},
+	})
+	welcome := c.recv()
+	if welcome.Type != MessageTypeWelcome || welcome.Protocol != ProtocolVersion {
+		t.Fatalf("welcome = %+v", welcome)
+	}
+	if got := strings.Join(welcome.Capabilities, ","); got != "state,heartbeat" {
+		t.Errorf("negotiated capabilities = %s, want state,heartbeat", got)
+	}
+
+	names := a.HostedAdapters()
+	if len(names) != 1 || names[0] != "external:lidar" {
+		t.Errorf("HostedAdapters() = %v, want [external:lidar]", names)
+	}
+
+	c.send(&Message{Type: MessageTypeState, Data: stateData(t, "lidar-001")})
+	state := receive(t, events)
+	if state.DeviceID != "lidar-001" || state.ProtocolSource != "lidar" {
+		t.Errorf("state = %s from %s, want lidar-001 from lidar", state.DeviceID, state.ProtocolSource)
+	}
+
+	c.send(&Message{Type: MessageTypeHeartbeat})
+	if msg := c.recv(); msg.Type != MessageTypeHeartbeat {
+		t.Errorf("heartbeat reply = %+v", msg)
+	}
+
+	// Batches need the batch capability
+	c.send(&Message{Type: MessageTypeBatch, States: []json.RawMessage{stateData(t, "lidar-002")}})
+	c.send(&Message{Type: MessageTypeState, Data: stateData(t, "lidar-003")})
+	if state := receive(t, events); state.DeviceID != "lidar-003" {
+		t.Errorf("state = %s, want lidar-003 (batch without capability ignored)", state.DeviceID)
+	}
+	if stats := a.Stats(); stats.MessagesReceived != 4 || stats.ConnectedPeers != 1 || stats.LastMessageAt == 0 {
+		t.Errorf("Stats = %+v, want 4 frames from 1 peer", stats)
+	}
+
+	c.conn.Close()
+	waitFor(t, func() bool { return len(a.HostedAdapters()) == 0 && a.Stats().ConnectedPeers == 0 })
+}
+
+func TestAdapter_Batch(t *testing.T) {
+	a, events := startAdapter(t, 4)
+	c := register(t, a, "radar", CapabilityState, CapabilityBatch)
+
+	c.send(&Message{Type: MessageTypeBatch, States: []json.RawMessage{
+		stateData(t, "r1"),
+		stateData(t, "r2"),
+	}})
+	if s := receive(t, events); s.DeviceID != "r1" {
+		t.Errorf("first state = %s, want r1", s.DeviceID)
+	}
+	if s := receive(t, events); s.DeviceID != "r2" {
+		t.Errorf("second state = %s, want r2", s.DeviceID)
+	}
+}
+
+func TestAdapter_Reject(t *testing.T) {
+	a, _ := startAdapter(t, 1)
+	register(t, a, "first", CapabilityState)
+
+	tests := []struct {
+		name string
+		msg  *Message
+		want string
+	}{
+		{"not register", &Message{Type: MessageTypeState}, "first frame must be register"},
+		{"bad name", &Message{Type: MessageTypeRegister, Name: "a/b", Protocol: 1, Capabilities: []string{"state"}}, "invalid adapter name"},
+		{"bad protocol", &Message{Type: MessageTypeRegister, Name: "x", Protocol: 99, Capabilities: []string{"state"}}, "unsupported protocol version"},
+		{"no state capability", &Message{Type: MessageTypeRegister, Name: "x", Protocol: 1, Capabilities: []string{"batch"}}, "capability is required"},
+		{"duplicate", &Message{Type: MessageTypeRegister, Name: "first", Protocol: 1, Capabilities: []string{"state"}}, "already registered"},
+		{"max adapters", &Message{Type: MessageTypeRegister, Name: "second", Protocol: 1, Capabilities: []string{"state"}}, "max adapters reached"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, a)
+			c.send(tt.msg)
+			msg := c.recv()
+			if msg.Type != MessageTypeReject || !strings.Contains(msg.Error, tt.want) {
+				t.Errorf("reply = %+v, want reject containing %q", msg, tt.want)
+			}
+		})
+	}
+
+	if names := a.HostedAdapters(); len(names) != 1 {
+		t.Errorf("HostedAdapters() = %v, want only the first adapter", names)
+	}
+}
+
+func TestAdapter_Shutdown(t *testing.T) {
+	a, _ := startAdapter(t, 4)
+	c := register(t, a, "lidar", CapabilityState)
+
+	go a.Stop()
+	if msg := c.recv(); msg.Type != MessageTypeShutdown {
+		t.Errorf("message on stop = %+v, want shutdown", msg)
+	}
+	waitFor(t, func() bool {
+		_, err := os.Stat(a.cfg.SocketPath)
+		return err != nil
+	})
+}
+
+func TestAdapter_StaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adapters.sock")
+
+	// A socket file nobody listens on is removed
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	if err := removeStaleSocket(path); err != nil {
+		t.Fatalf("removeStaleSocket() error = %v", err)
+	}
+
+	// A socket in use is left alone
+	l, err = net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := removeStaleSocket(path); err == nil {
+		t.Error("removeStaleSocket() should fail for a socket in use")
+	}
+}
+
+func TestAdapter_QuarantineAndReplay(t *testing.T) {
+	a, _ := startAdapter(t, 4)
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+
+	c := register(t, a, "lidar", CapabilityState)
+	c.send(&Message{Type: MessageTypeState, Data: json.RawMessage(`{"location":{"lat":1}}`)})
+
+	var entries []quarantine.Entry
+	waitFor(t, func() bool {
+		entries = q.List("", 0)
+		return len(entries) == 1
+	})
+	entry := entries[0]
+	if entry.Adapter != "external" || entry.Source != "lidar" {
+		t.Errorf("entry adapter/source = %s/%s, want external/lidar", entry.Adapter, entry.Source)
+	}
+
+	// The payload is still missing a device ID
+	if _, err := a.Replay(entry); err == nil {
+		t.Error("Replay() should fail for a state without device_id")
+	}
+
+	entry.Payload = []byte(`{"type":"state","data":{"device_id":"fixed"}}`)
+	state, err := a.Replay(entry)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if state.DeviceID != "fixed" || state.ProtocolSource != "lidar" {
+		t.Errorf("replayed state = %s from %s", state.DeviceID, state.ProtocolSource)
+	}
+}
+
+func TestAdapter_SelfTest(t *testing.T) {
+	a := New(config.ExternalConfig{SocketPath: filepath.Join(t.TempDir(), "adapters.sock"), MaxAdapters: 1})
+	if _, err := a.SelfTest(); err == nil {
+		t.Error("SelfTest() should fail before Start")
+	}
+
+	a, _ = startAdapter(t, 1)
+	state, err := a.SelfTest()
+	if err != nil {
+		t.Fatalf("SelfTest() error = %v", err)
+	}
+	if state.DeviceID != "external-selftest" {
+		t.Errorf("DeviceID = %s, want external-selftest", state.DeviceID)
+	}
+}
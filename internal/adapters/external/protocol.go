@@ -0,0 +1,131 @@
+// Package external accepts out-of-process adapters over a UNIX domain
+// socket. Adapters written in any language connect, register with a
+// capability handshake and stream DroneStates; each registered adapter is
+// listed as "external:<name>" next to the built-in adapters.
+//
+// Every frame is a 4-byte big-endian length followed by a JSON message, as
+// in the DJI forwarder protocol:
+//
+//	adapter -> bridge:
+//	  {"type":"register","name":"lidar","version":"1.2","protocol":1,"capabilities":["state","batch"]}
+//	  {"type":"state","data":{...DroneState...}}
+//	  {"type":"batch","states":[{...DroneState...}, ...]}   ("batch" capability)
+//	  {"type":"heartbeat"}                                   ("heartbeat" capability)
+//
+//	bridge -> adapter:
+//	  {"type":"welcome","protocol":1,"capabilities":["state","batch"]}
+//	  {"type":"reject","error":"..."}                        (then the bridge closes)
+//	  {"type":"heartbeat"}                                   (reply to a heartbeat)
+//	  {"type":"shutdown"}                                    (the bridge is stopping)
+//
+// The first frame must be register. The welcome lists the capabilities both
+// sides support; "state" is required. A connection that sends nothing for
+// ReadTimeout is closed, so idle adapters should send heartbeats.
+package external
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"time"
+)
+
+// ProtocolVersion is the protocol version spoken by the bridge
+const ProtocolVersion = 1
+
+// Protocol limits
+const (
+	MaxFrameSize    = 1 << 20 // Largest accepted frame in bytes
+	ReadTimeout     = 60 * time.Second
+	writeTimeout    = 5 * time.Second
+	frameHeaderSize = 4 // Big-endian frame length
+)
+
+// MessageType defines the type of a protocol message
+type MessageType string
+
+const (
+	MessageTypeRegister  MessageType = "register"
+	MessageTypeWelcome   MessageType = "welcome"
+	MessageTypeReject    MessageType = "reject"
+	MessageTypeState     MessageType = "state"
+	MessageTypeBatch     MessageType = "batch"
+	MessageTypeHeartbeat MessageType = "heartbeat"
+	MessageTypeShutdown  MessageType = "shutdown"
+)
+
+// Capabilities
+const (
+	CapabilityState     = "state"     // Single state frames (required)
+	CapabilityBatch     = "batch"     // Several states per frame
+	CapabilityHeartbeat = "heartbeat" // Heartbeat frames answered by the bridge
+)
+
+// supportedCapabilities are the capabilities the bridge implements
+var supportedCapabilities = []string{CapabilityState, CapabilityBatch, CapabilityHeartbeat}
+
+// validName matches adapter names accepted in register frames
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Message is a single protocol frame
+type Message struct {
+	Type         MessageType       `json:"type"`
+	Name         string            `json:"name,omitempty"`
+	Version      string            `json:"version,omitempty"`
+	Protocol     int               `json:"protocol,omitempty"`
+	Capabilities []string          `json:"capabilities,omitempty"`
+	Data         json.RawMessage   `json:"data,omitempty"`
+	States       []json.RawMessage `json:"states,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// negotiate returns the requested capabilities the bridge supports, in the
+// bridge's order
+func negotiate(requested []string) []string {
+	want := make(map[string]bool, len(requested))
+	for _, c := range requested {
+		want[c] = true
+	}
+	var result []string
+	for _, c := range supportedCapabilities {
+		if want[c] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// readFrame reads one length-prefixed frame
+func readFrame(r io.Reader) ([]byte, error) {
+	var lengthBuf [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, lengthBuf[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(lengthBuf[:])
+	if length > MaxFrameSize {
+		return nil, fmt.Errorf("frame too large: %d bytes", length)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// writeMessage writes one message as a length-prefixed frame
+func writeMessage(conn net.Conn, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err = conn.Write(frame)
+	return err
+}
@@ -3,31 +3,73 @@ package mavlink
 import (
 	"context"
 	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/bluenviron/gomavlib/v3"
 	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
 	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/internal/core/adapterstats"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // Adapter implements the core.Adapter interface for MAVLink protocol
 type Adapter struct {
-	cfg    config.MAVLinkConfig
-	node   *gomavlib.Node
-	mu     sync.RWMutex
-	states map[uint8]*models.DroneState // keyed by system ID
+	cfg         config.MAVLinkConfig
+	node        *gomavlib.Node
+	quarantine  *quarantine.Store
+	signing     *timestampStore // nil unless signing is enabled
+	serial      *serialLink     // nil unless connection_type is serial
+	onLink      func(status *models.LinkStatus)
+	mu          sync.RWMutex
+	states      map[uint8]*models.DroneState // keyed by system ID
+	metadata    map[uint8]*autopilotMeta     // keyed by system ID
+	missions    map[uint8]*missionState      // keyed by system ID
+	onMission   func(deviceID string, mission *models.Mission)
+	raw         map[string]bool // Message names passed through
+	onRaw       func(msg *models.RawMessage)
+	statusTexts map[uint8][]models.StatusText // Recent STATUSTEXT messages, keyed by system ID
+	links       map[*gomavlib.Channel]bool    // Open channels, true for downstream GCS; only used by the receive loop
+	streams     map[uint8]time.Time           // Last stream rate request, keyed by system ID
+
+	stats       *adapterstats.Counter
+	readWriters map[uint32]*message.ReadWriter // Payload encoders for frameSize, by message ID
 }
 
+// gcsSystemID is the system ID the adapter uses on the link
+const gcsSystemID = 255
+
+// signingSaveInterval is how often accepted signature timestamps are persisted
+const signingSaveInterval = 30 * time.Second
+
 // New creates a new MAVLink adapter
 func New(cfg config.MAVLinkConfig) *Adapter {
-	return &Adapter{
-		cfg:    cfg,
-		states: make(map[uint8]*models.DroneState),
+	a := &Adapter{
+		cfg:         cfg,
+		states:      make(map[uint8]*models.DroneState),
+		metadata:    make(map[uint8]*autopilotMeta),
+		missions:    make(map[uint8]*missionState),
+		raw:         make(map[string]bool),
+		statusTexts: make(map[uint8][]models.StatusText),
+		links:       make(map[*gomavlib.Channel]bool),
+		streams:     make(map[uint8]time.Time),
+
+		stats:       adapterstats.New(),
+		readWriters: make(map[uint32]*message.ReadWriter),
+	}
+	for _, name := range cfg.Raw {
+		a.raw[strings.ToUpper(name)] = true
+	}
+	if cfg.ConnectionType == "serial" {
+		a.serial = newSerialLink(cfg.SerialPort, cfg.SerialBaud)
 	}
+	return a
 }
 
 // Name returns the adapter name
@@ -42,18 +84,40 @@ func (a *Adapter) Start(ctx context.Context, events chan<- *models.DroneState) e
 		return fmt.Errorf("building endpoints: %w", err)
 	}
 
-	node, err := gomavlib.NewNode(gomavlib.NodeConf{
+	nodeConf := gomavlib.NodeConf{
 		Endpoints:   endpoints,
 		Dialect:     ardupilotmega.Dialect,
 		OutVersion:  gomavlib.V2,
-		OutSystemID: 255, // GCS system ID
-	})
+		OutSystemID: gcsSystemID,
+	}
+
+	// With signing, the node drops unsigned and badly signed frames (they
+	// surface as parse errors) and signs outgoing frames
+	if a.cfg.Signing.Enabled {
+		key, err := ParseSigningKey(a.cfg.Signing)
+		if err != nil {
+			return err
+		}
+		signing := newTimestampStore(a.cfg.Signing.TimestampFile)
+		if err := signing.load(); err != nil {
+			return err
+		}
+		nodeConf.InKey = key
+		nodeConf.OutKey = key
+		a.signing = signing
+	}
+
+	node, err := gomavlib.NewNode(nodeConf)
 	if err != nil {
 		return fmt.Errorf("creating mavlink node: %w", err)
 	}
 	a.node = node
 
 	go a.receiveLoop(ctx, events)
+	if a.signing != nil {
+		go a.saveSigningTimestamps(ctx)
+		log.Printf("[MAVLink] Message signing enabled, unsigned frames are rejected")
+	}
 
 	return nil
 }
@@ -63,30 +127,47 @@ func (a *Adapter) Stop() error {
 	if a.node != nil {
 		a.node.Close()
 	}
+	a.stats.ResetPeers()
+	if a.signing != nil {
+		if err := a.signing.save(); err != nil {
+			log.Printf("[MAVLink] Failed to save signing timestamps: %v", err)
+		}
+	}
 	return nil
 }
 
-// buildEndpoints creates the appropriate endpoint configuration
+// saveSigningTimestamps periodically persists the signature timestamps
+func (a *Adapter) saveSigningTimestamps(ctx context.Context) {
+	ticker := time.NewTicker(signingSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.signing.save(); err != nil {
+				log.Printf("[MAVLink] Failed to save signing timestamps: %v", err)
+			}
+		}
+	}
+}
+
+// buildEndpoints creates the appropriate endpoint configuration, followed
+// by the downstream GCS endpoints
 func (a *Adapter) buildEndpoints() ([]gomavlib.EndpointConf, error) {
+	var endpoint gomavlib.EndpointConf
 	switch a.cfg.ConnectionType {
 	case "udp":
-		return []gomavlib.EndpointConf{
-			gomavlib.EndpointUDPServer{Address: a.cfg.Address},
-		}, nil
+		endpoint = gomavlib.EndpointUDPServer{Address: a.cfg.Address}
 	case "tcp":
-		return []gomavlib.EndpointConf{
-			gomavlib.EndpointTCPServer{Address: a.cfg.Address},
-		}, nil
+		endpoint = gomavlib.EndpointTCPServer{Address: a.cfg.Address}
 	case "serial":
-		return []gomavlib.EndpointConf{
-			gomavlib.EndpointSerial{
-				Device: a.cfg.SerialPort,
-				Baud:   a.cfg.SerialBaud,
-			},
-		}, nil
+		endpoint = a.serial.endpoint()
 	default:
 		return nil, fmt.Errorf("unknown connection type: %s", a.cfg.ConnectionType)
 	}
+	return append([]gomavlib.EndpointConf{endpoint}, a.forwardEndpoints()...), nil
 }
 
 // receiveLoop processes incoming MAVLink messages
@@ -96,16 +177,113 @@ func (a *Adapter) receiveLoop(ctx context.Context, events chan<- *models.DroneSt
 		case <-ctx.Done():
 			return
 		case evt := <-a.node.Events():
-			if frm, ok := evt.(*gomavlib.EventFrame); ok {
-				a.handleFrame(frm.Frame, events)
+			switch e := evt.(type) {
+			case *gomavlib.EventFrame:
+				// Frames from a downstream GCS are not telemetry
+				if a.links[e.Channel] {
+					a.forwardToDrones(e)
+					continue
+				}
+				a.stats.Received(a.frameSize(e.Frame))
+				if err := a.checkSignature(e); err != nil {
+					a.rejectFrame(e, err)
+					continue
+				}
+				a.handleFrame(ctx, e.Frame, events)
+				a.requestMetadata(e)
+				a.requestStreams(e)
+				a.requestMission(e)
+				a.forwardToGCS(e)
+			case *gomavlib.EventParseError:
+				if a.links[e.Channel] {
+					continue
+				}
+				a.stats.Received(0) // The size of the bad frame is unknown
+				a.stats.ParseError()
+				a.handleParseError(e)
+			case *gomavlib.EventChannelOpen:
+				gcs := isForward(e.Channel)
+				a.links[e.Channel] = gcs
+				if gcs {
+					log.Printf("[MAVLink] Forwarding frames to GCS %s", strings.TrimPrefix(e.Channel.String(), forwardLabel))
+				} else {
+					a.stats.PeerConnected()
+				}
+				if isSerial(e.Channel) {
+					a.linkChanged(true, nil)
+				}
+			case *gomavlib.EventChannelClose:
+				if !a.links[e.Channel] {
+					a.stats.PeerDisconnected()
+				}
+				delete(a.links, e.Channel)
+				if isSerial(e.Channel) && ctx.Err() == nil {
+					a.linkChanged(false, e.Error)
+				}
 			}
 		}
 	}
 }
 
+// SetQuarantine sets the store for frames that fail to parse
+func (a *Adapter) SetQuarantine(q *quarantine.Store) {
+	a.quarantine = q
+}
+
+// handleParseError records a frame the node could not decode.
+// gomavlib does not expose the raw bytes, so entries are for diagnosis only
+// and cannot be replayed.
+func (a *Adapter) handleParseError(evt *gomavlib.EventParseError) {
+	if a.quarantine == nil {
+		return
+	}
+	source := ""
+	if evt.Channel != nil {
+		source = evt.Channel.String()
+	}
+	a.quarantine.Add(a.Name(), "", source, nil, evt.Error)
+}
+
+// checkSignature rejects replayed signed frames. The node has already
+// verified the signature itself.
+func (a *Adapter) checkSignature(evt *gomavlib.EventFrame) error {
+	if a.signing == nil {
+		return nil
+	}
+	f, ok := evt.Frame.(*frame.V2Frame)
+	if !ok || f.Signature == nil {
+		return fmt.Errorf("unsigned frame")
+	}
+	return a.signing.check(f)
+}
+
+// rejectFrame records a frame dropped by signature checks
+func (a *Adapter) rejectFrame(evt *gomavlib.EventFrame, err error) {
+	if a.quarantine == nil {
+		return
+	}
+	source := ""
+	if evt.Channel != nil {
+		source = evt.Channel.String()
+	}
+	deviceID := fmt.Sprintf("mavlink-%d", evt.Frame.GetSystemID())
+	a.quarantine.Add(a.Name(), deviceID, source, nil, err)
+}
+
 // handleFrame processes a single MAVLink frame
-func (a *Adapter) handleFrame(frm frame.Frame, events chan<- *models.DroneState) {
+func (a *Adapter) handleFrame(ctx context.Context, frm frame.Frame, events chan<- *models.DroneState) {
 	sysID := frm.GetSystemID()
+	a.applyRaw(sysID, frm.GetMessage())
+
+	// Firmware details and parameters do not change the state
+	if a.applyMetadata(sysID, frm.GetMessage()) {
+		return
+	}
+
+	// Neither do mission transfers
+	if a.applyMission(sysID, frm.GetMessage()) {
+		return
+	}
 
 	a.mu.Lock()
 	state, exists := a.states[sysID]
@@ -121,8 +299,22 @@ func (a *Adapter) handleFrame(frm frame.Frame, events chan<- *models.DroneState)
 	// Update timestamp
 	state.Timestamp = time.Now().UnixMilli()
 
-	// Process message based on type
-	switch msg := frm.GetMessage().(type) {
+	// Process message based on type; ignore other message types
+	if !a.applyMessage(state, frm.GetMessage()) {
+		return
+	}
+
+	// Send state update; blocks while the engine applies back-pressure
+	select {
+	case events <- state:
+	case <-ctx.Done():
+	}
+}
+
+// applyMessage updates the state from a supported message.
+// Returns false for message types the adapter ignores.
+func (a *Adapter) applyMessage(state *models.DroneState, msg message.Message) bool {
+	switch msg := msg.(type) {
 	case *ardupilotmega.MessageHeartbeat:
 		a.handleHeartbeat(state, msg)
 	case *ardupilotmega.MessageGlobalPositionInt:
@@ -131,23 +323,67 @@ func (a *Adapter) handleFrame(frm frame.Frame, events chan<- *models.DroneState)
 		a.handleAttitude(state, msg)
 	case *ardupilotmega.MessageSysStatus:
 		a.handleSysStatus(state, msg)
+	case *ardupilotmega.MessageSmartBatteryInfo:
+		a.handleSmartBatteryInfo(state, msg)
+	case *ardupilotmega.MessageCameraInformation:
+		a.handleCameraInformation(state, msg)
+	case *ardupilotmega.MessageRadioStatus:
+		a.handleRadioStatus(state, msg)
+	case *ardupilotmega.MessageGpsRawInt:
+		a.handleGPSRawInt(state, msg)
+	case *ardupilotmega.MessageHomePosition:
+		a.handleHomePosition(state, msg)
 	default:
-		// Ignore other message types
-		return
+		return false
 	}
+	return true
+}
 
-	// Send state update
-	select {
-	case events <- state:
-	default:
-		// Channel full, skip this update
+// SelfTest encodes synthetic MAVLink messages, decodes them in loopback
+// and returns the resulting state without emitting it
+func (a *Adapter) SelfTest() (*models.DroneState, error) {
+	if a.node == nil {
+		return nil, fmt.Errorf("mavlink node not started")
+	}
+
+	msgs := []message.Message{
+		&ardupilotmega.MessageHeartbeat{
+			Type:     ardupilotmega.MAV_TYPE_QUADROTOR,
+			BaseMode: ardupilotmega.MAV_MODE_FLAG_SAFETY_ARMED,
+		},
+		&ardupilotmega.MessageGlobalPositionInt{
+			Lat:         399087000,
+			Lon:         1163975000,
+			Alt:         150000,
+			RelativeAlt: 100000,
+		},
+		&ardupilotmega.MessageSysStatus{BatteryRemaining: 80},
+	}
+
+	state := models.NewDroneState("mavlink-selftest", "mavlink")
+	state.Timestamp = time.Now().UnixMilli()
+	for _, msg := range msgs {
+		rw, err := message.NewReadWriter(msg)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %T: %w", msg, err)
+		}
+		decoded, err := rw.Read(rw.Write(msg, true), true)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %T: %w", msg, err)
+		}
+		a.applyMessage(state, decoded)
+	}
+
+	if state.Location.Lat != 39.9087 || state.Status.BatteryPercent != 80 || !state.Status.Armed {
+		return nil, fmt.Errorf("loopback decode mismatch")
 	}
+	return state, nil
 }
 
 // handleHeartbeat processes HEARTBEAT message
 func (a *Adapter) handleHeartbeat(state *models.DroneState, msg *ardupilotmega.MessageHeartbeat) {
 	state.Status.Armed = (msg.BaseMode & ardupilotmega.MAV_MODE_FLAG_SAFETY_ARMED) != 0
-	state.Status.FlightMode = mapFlightMode(msg.CustomMode, msg.Type)
+	state.Status.FlightMode = mapFlightMode(msg.CustomMode, msg.Type, msg.Autopilot)
 }
 
 // handleGlobalPositionInt processes GLOBAL_POSITION_INT message
@@ -162,6 +398,7 @@ func (a *Adapter) handleGlobalPositionInt(state *models.DroneState, msg *ardupil
 	state.Velocity.Vx = float64(msg.Vx) / 100.0
 	state.Velocity.Vy = float64(msg.Vy) / 100.0
 	state.Velocity.Vz = float64(msg.Vz) / 100.0
+	state.Freshness.PositionAt = state.Timestamp
 }
 
 // handleAttitude processes ATTITUDE message
@@ -174,11 +411,58 @@ func (a *Adapter) handleAttitude(state *models.DroneState, msg *ardupilotmega.Me
 		yawDeg += 360.0
 	}
 	state.Attitude.Yaw = yawDeg
+	state.Freshness.AttitudeAt = state.Timestamp
 }
 
 // handleSysStatus processes SYS_STATUS message
 func (a *Adapter) handleSysStatus(state *models.DroneState, msg *ardupilotmega.MessageSysStatus) {
 	if msg.BatteryRemaining >= 0 && msg.BatteryRemaining <= 100 {
 		state.Status.BatteryPercent = int(msg.BatteryRemaining)
+		state.Freshness.BatteryAt = state.Timestamp
+	}
+}
+
+// handleSmartBatteryInfo processes SMART_BATTERY_INFO message. Only the
+// first battery is tracked.
+func (a *Adapter) handleSmartBatteryInfo(state *models.DroneState, msg *ardupilotmega.MessageSmartBatteryInfo) {
+	if msg.Id == 0 && msg.SerialNumber != "" {
+		state.Status.BatterySerial = msg.SerialNumber
+	}
+}
+
+// handleCameraInformation processes CAMERA_INFORMATION message, using the
+// camera's vendor and model as the payload ID
+func (a *Adapter) handleCameraInformation(state *models.DroneState, msg *ardupilotmega.MessageCameraInformation) {
+	vendor := strings.TrimRight(string(msg.VendorName[:]), "\x00")
+	model := strings.TrimRight(string(msg.ModelName[:]), "\x00")
+	if id := strings.TrimSpace(vendor + " " + model); id != "" {
+		state.Status.PayloadID = id
+	}
+}
+
+// handleHomePosition processes HOME_POSITION message, sent by the autopilot
+// when home is set (usually on arming) and on request
+func (a *Adapter) handleHomePosition(state *models.DroneState, msg *ardupilotmega.MessageHomePosition) {
+	state.Home = &models.HomePosition{
+		Lat:    float64(msg.Latitude) / 1e7,
+		Lon:    float64(msg.Longitude) / 1e7,
+		Alt:    float64(msg.Altitude) / 1000.0,
+		Source: models.HomeSourceAutopilot,
+	}
+}
+
+// handleRadioStatus processes RADIO_STATUS message. RSSI is reported in
+// radio-specific units from 0 to 254 and scaled to a 0-100 signal quality.
+func (a *Adapter) handleRadioStatus(state *models.DroneState, msg *ardupilotmega.MessageRadioStatus) {
+	if msg.Rssi != 255 {
+		state.Status.SignalQuality = int(msg.Rssi) * 100 / 254
+	}
+}
+
+// handleGPSRawInt processes GPS_RAW_INT message
+func (a *Adapter) handleGPSRawInt(state *models.DroneState, msg *ardupilotmega.MessageGpsRawInt) {
+	if msg.SatellitesVisible != 255 {
+		sats := int(msg.SatellitesVisible)
+		state.Status.SatellitesVisible = &sats
 	}
 }
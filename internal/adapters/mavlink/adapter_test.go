@@ -1,12 +1,18 @@
 package mavlink
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/bluenviron/gomavlib/v3"
 	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestNew(t *testing.T) {
@@ -191,7 +197,7 @@ func TestMapFlightMode_Copter(t *testing.T) {
 
 	for _, vType := range copterTypes {
 		t.Run(vType.String(), func(t *testing.T) {
-			got := mapFlightMode(copterModeAuto, vType)
+			got := mapFlightMode(copterModeAuto, vType, ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA)
 			if got != models.FlightModeAuto {
 				t.Errorf("mapFlightMode(auto, %s) = %s, want AUTO", vType.String(), got)
 			}
@@ -211,7 +217,7 @@ func TestMapFlightMode_Plane(t *testing.T) {
 
 	for _, vType := range planeTypes {
 		t.Run(vType.String(), func(t *testing.T) {
-			got := mapFlightMode(planeModeAuto, vType)
+			got := mapFlightMode(planeModeAuto, vType, ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA)
 			if got != models.FlightModeAuto {
 				t.Errorf("mapFlightMode(auto, %s) = %s, want AUTO", vType.String(), got)
 			}
@@ -221,12 +227,42 @@ func TestMapFlightMode_Plane(t *testing.T) {
 
 func TestMapFlightMode_Unknown(t *testing.T) {
 	// Unknown vehicle types should default to copter mapping
-	got := mapFlightMode(copterModeAuto, ardupilotmega.MAV_TYPE_GENERIC)
+	got := mapFlightMode(copterModeAuto, ardupilotmega.MAV_TYPE_GENERIC, ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA)
 	if got != models.FlightModeAuto {
 		t.Errorf("mapFlightMode for unknown type = %s, want AUTO (copter default)", got)
 	}
 }
 
+func TestMapFlightMode_PX4(t *testing.T) {
+	px4Mode := func(main, sub uint32) uint32 { return main<<16 | sub<<24 }
+	tests := []struct {
+		customMode uint32
+		want       models.FlightMode
+	}{
+		{px4Mode(px4MainModeManual, 0), models.FlightModeManual},
+		{px4Mode(px4MainModeStabilized, 0), models.FlightModeStabilize},
+		{px4Mode(px4MainModeAltCtl, 0), models.FlightModeAltHold},
+		{px4Mode(px4MainModePosCtl, 0), models.FlightModeLoiter},
+		{px4Mode(px4MainModeOffboard, 0), models.FlightModeGuided},
+		{px4Mode(px4MainModeTermination, 0), models.FlightModeEmergency},
+		{px4Mode(px4MainModeAuto, px4AutoModeMission), models.FlightModeAuto},
+		{px4Mode(px4MainModeAuto, px4AutoModeTakeoff), models.FlightModeTakeoff},
+		{px4Mode(px4MainModeAuto, px4AutoModeLoiter), models.FlightModeLoiter},
+		{px4Mode(px4MainModeAuto, px4AutoModeRTL), models.FlightModeRTL},
+		{px4Mode(px4MainModeAuto, px4AutoModePrecLand), models.FlightModeLand},
+		{px4Mode(px4MainModeAuto, px4AutoModeReady), models.FlightModeUnknown},
+		{0, models.FlightModeUnknown},
+	}
+	for _, tt := range tests {
+		// PX4 modes do not depend on the vehicle type
+		for _, vType := range []ardupilotmega.MAV_TYPE{ardupilotmega.MAV_TYPE_QUADROTOR, ardupilotmega.MAV_TYPE_FIXED_WING} {
+			if got := mapFlightMode(tt.customMode, vType, ardupilotmega.MAV_AUTOPILOT_PX4); got != tt.want {
+				t.Errorf("mapFlightMode(%#x, %s, PX4) = %s, want %s", tt.customMode, vType, got, tt.want)
+			}
+		}
+	}
+}
+
 func TestAdapter_ConfigWithSerial(t *testing.T) {
 	cfg := config.MAVLinkConfig{
 		Enabled:        true,
@@ -244,3 +280,152 @@ func TestAdapter_ConfigWithSerial(t *testing.T) {
 		t.Errorf("SerialBaud = %d, want 57600", a.cfg.SerialBaud)
 	}
 }
+
+func TestAdapter_SelfTest_NotStarted(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+
+	if _, err := a.SelfTest(); err == nil {
+		t.Error("SelfTest should fail before Start")
+	}
+}
+
+func TestAdapter_SelfTest(t *testing.T) {
+	a := New(config.MAVLinkConfig{ConnectionType: "udp", Address: "127.0.0.1:0"})
+	if err := a.Start(context.Background(), make(chan *models.DroneState, 1)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer a.Stop()
+
+	state, err := a.SelfTest()
+	if err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+	if state.Location.Lat != 39.9087 || state.Location.Lon != 116.3975 {
+		t.Errorf("Location = %f,%f, want 39.9087,116.3975", state.Location.Lat, state.Location.Lon)
+	}
+	if len(a.states) != 0 {
+		t.Error("SelfTest must not register a vehicle")
+	}
+}
+
+func TestAdapter_handleParseError(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+
+	a.handleParseError(&gomavlib.EventParseError{Error: errors.New("invalid checksum")})
+
+	entries := q.List("mavlink", 0)
+	if len(entries) != 1 {
+		t.Fatalf("Quarantined entries = %d, want 1", len(entries))
+	}
+	if entries[0].Error != "invalid checksum" {
+		t.Errorf("Error = %s, want 'invalid checksum'", entries[0].Error)
+	}
+}
+
+func TestAdapter_applyMessage_Equipment(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	state := models.NewDroneState("mavlink-1", "mavlink")
+
+	a.applyMessage(state, &ardupilotmega.MessageSmartBatteryInfo{Id: 1, SerialNumber: "SECOND"})
+	a.applyMessage(state, &ardupilotmega.MessageSmartBatteryInfo{Id: 0, SerialNumber: "SN-0042"})
+	if state.Status.BatterySerial != "SN-0042" {
+		t.Errorf("BatterySerial = %q, want SN-0042 from the first battery", state.Status.BatterySerial)
+	}
+
+	camera := &ardupilotmega.MessageCameraInformation{}
+	copy(camera.VendorName[:], "Sony")
+	copy(camera.ModelName[:], "RX0")
+	a.applyMessage(state, camera)
+	if state.Status.PayloadID != "Sony RX0" {
+		t.Errorf("PayloadID = %q, want 'Sony RX0'", state.Status.PayloadID)
+	}
+}
+
+func TestAdapter_applyMessage_RadioStatus(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	state := models.NewDroneState("mavlink-1", "mavlink")
+
+	a.applyMessage(state, &ardupilotmega.MessageRadioStatus{Rssi: 127})
+	if state.Status.SignalQuality != 50 {
+		t.Errorf("SignalQuality = %d, want 50", state.Status.SignalQuality)
+	}
+	a.applyMessage(state, &ardupilotmega.MessageRadioStatus{Rssi: 255})
+	if state.Status.SignalQuality != 50 {
+		t.Errorf("SignalQuality = %d, invalid RSSI should be ignored", state.Status.SignalQuality)
+	}
+}
+
+func TestAdapter_applyMessage_GPSRawInt(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	state := models.NewDroneState("mavlink-1", "mavlink")
+
+	a.applyMessage(state, &ardupilotmega.MessageGpsRawInt{SatellitesVisible: 255})
+	if state.Status.SatellitesVisible != nil {
+		t.Errorf("SatellitesVisible = %d, unknown count should be ignored", *state.Status.SatellitesVisible)
+	}
+	a.applyMessage(state, &ardupilotmega.MessageGpsRawInt{SatellitesVisible: 9})
+	if state.Status.SatellitesVisible == nil || *state.Status.SatellitesVisible != 9 {
+		t.Errorf("SatellitesVisible = %v, want 9", state.Status.SatellitesVisible)
+	}
+}
+
+func TestAdapter_applyMessage_Freshness(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	state := models.NewDroneState("mavlink-1", "mavlink")
+
+	state.Timestamp = 1000
+	a.applyMessage(state, &ardupilotmega.MessageGlobalPositionInt{Lat: 399087000})
+	a.applyMessage(state, &ardupilotmega.MessageSysStatus{BatteryRemaining: 80})
+	state.Timestamp = 2000
+	a.applyMessage(state, &ardupilotmega.MessageAttitude{Yaw: 1})
+	a.applyMessage(state, &ardupilotmega.MessageSysStatus{BatteryRemaining: -1})
+
+	want := models.Freshness{PositionAt: 1000, AttitudeAt: 2000, BatteryAt: 1000}
+	if state.Freshness != want {
+		t.Errorf("Freshness = %+v, want %+v", state.Freshness, want)
+	}
+}
+
+func TestAdapter_frameSize(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	heartbeat := &ardupilotmega.MessageHeartbeat{Type: ardupilotmega.MAV_TYPE_QUADROTOR, MavlinkVersion: 3}
+
+	tests := []struct {
+		name  string
+		frame frame.Frame
+		want  int
+	}{
+		{"v1", &frame.V1Frame{Message: heartbeat}, 6 + 9 + 2},
+		{"v2", &frame.V2Frame{Message: heartbeat}, 10 + 9 + 2},
+		{"v2 signed", &frame.V2Frame{Message: heartbeat, Signature: &frame.V2Signature{}}, 10 + 9 + 2 + 13},
+		{"v2 truncated", &frame.V2Frame{Message: &ardupilotmega.MessageSysStatus{}}, 10 + 1 + 2},
+		{"unknown message", &frame.V2Frame{Message: &message.MessageRaw{ID: 9999, Payload: make([]byte, 5)}}, 10 + 5 + 2},
+	}
+	for _, tt := range tests {
+		if got := a.frameSize(tt.frame); got != tt.want {
+			t.Errorf("frameSize(%s) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAdapter_handleFrame_HomePosition(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	events := make(chan *models.DroneState, 1)
+
+	a.handleFrame(context.Background(), &frame.V2Frame{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageHomePosition{
+		Latitude:  225400000,
+		Longitude: 1139400000,
+		Altitude:  35500,
+	}}, events)
+
+	state := <-events
+	want := models.HomePosition{Lat: 22.54, Lon: 113.94, Alt: 35.5, Source: models.HomeSourceAutopilot}
+	if state.Home == nil || *state.Home != want {
+		t.Errorf("Home = %+v, want %+v", state.Home, want)
+	}
+	if !a.metadata[3].hasHome {
+		t.Error("HOME_POSITION should stop home requests")
+	}
+}
@@ -3,7 +3,7 @@ package mavlink
 import (
 	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // ArduPilot Copter flight modes
@@ -64,8 +64,41 @@ const (
 	planeModeThermal      = 24
 )
 
-// mapFlightMode converts MAVLink custom_mode to unified FlightMode
-func mapFlightMode(customMode uint32, vehicleType ardupilotmega.MAV_TYPE) models.FlightMode {
+// PX4 main modes, in bits 16-23 of custom_mode
+// https://github.com/PX4/PX4-Autopilot/blob/main/src/modules/commander/px4_custom_mode.h
+const (
+	px4MainModeManual      = 1
+	px4MainModeAltCtl      = 2
+	px4MainModePosCtl      = 3
+	px4MainModeAuto        = 4
+	px4MainModeAcro        = 5
+	px4MainModeOffboard    = 6
+	px4MainModeStabilized  = 7
+	px4MainModeRattitude   = 8
+	px4MainModeTermination = 9
+)
+
+// PX4 auto sub modes, in bits 24-31 of custom_mode
+const (
+	px4AutoModeReady        = 1
+	px4AutoModeTakeoff      = 2
+	px4AutoModeLoiter       = 3
+	px4AutoModeMission      = 4
+	px4AutoModeRTL          = 5
+	px4AutoModeLand         = 6
+	px4AutoModeFollowTarget = 8
+	px4AutoModePrecLand     = 9
+	px4AutoModeVTOLTakeoff  = 10
+)
+
+// mapFlightMode converts MAVLink custom_mode to unified FlightMode. PX4
+// encodes the mode the same way for all vehicle types; ArduPilot and other
+// autopilots are mapped by vehicle type.
+func mapFlightMode(customMode uint32, vehicleType ardupilotmega.MAV_TYPE, autopilot ardupilotmega.MAV_AUTOPILOT) models.FlightMode {
+	if autopilot == ardupilotmega.MAV_AUTOPILOT_PX4 {
+		return mapPX4Mode(customMode)
+	}
+
 	switch vehicleType {
 	case ardupilotmega.MAV_TYPE_QUADROTOR,
 		ardupilotmega.MAV_TYPE_HEXAROTOR,
@@ -137,3 +170,40 @@ func mapPlaneMode(customMode uint32) models.FlightMode {
 		return models.FlightModeUnknown
 	}
 }
+
+// mapPX4Mode maps PX4 main and sub modes to unified FlightMode
+func mapPX4Mode(customMode uint32) models.FlightMode {
+	mainMode := (customMode >> 16) & 0xff
+	subMode := (customMode >> 24) & 0xff
+
+	switch mainMode {
+	case px4MainModeManual, px4MainModeAcro:
+		return models.FlightModeManual
+	case px4MainModeStabilized, px4MainModeRattitude:
+		return models.FlightModeStabilize
+	case px4MainModeAltCtl:
+		return models.FlightModeAltHold
+	case px4MainModePosCtl:
+		return models.FlightModeLoiter // Position control, orbit included
+	case px4MainModeOffboard:
+		return models.FlightModeGuided
+	case px4MainModeTermination:
+		return models.FlightModeEmergency
+	case px4MainModeAuto:
+		switch subMode {
+		case px4AutoModeMission:
+			return models.FlightModeAuto
+		case px4AutoModeTakeoff, px4AutoModeVTOLTakeoff:
+			return models.FlightModeTakeoff
+		case px4AutoModeLoiter:
+			return models.FlightModeLoiter
+		case px4AutoModeRTL:
+			return models.FlightModeRTL
+		case px4AutoModeLand, px4AutoModePrecLand:
+			return models.FlightModeLand
+		case px4AutoModeFollowTarget:
+			return models.FlightModeGuided
+		}
+	}
+	return models.FlightModeUnknown
+}
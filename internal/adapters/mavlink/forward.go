@@ -0,0 +1,77 @@
+package mavlink
+
+import (
+	"context"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+)
+
+// forwardLabel prefixes the label of downstream GCS endpoints, which is how
+// their channels are told apart from the drone links
+const forwardLabel = "forward:"
+
+// forwardEndpoints returns a UDP client endpoint per downstream GCS
+func (a *Adapter) forwardEndpoints() []gomavlib.EndpointConf {
+	endpoints := make([]gomavlib.EndpointConf, 0, len(a.cfg.Forward))
+	for _, address := range a.cfg.Forward {
+		endpoints = append(endpoints, gomavlib.EndpointCustomClient{
+			Connect: func(ctx context.Context) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "udp", address)
+			},
+			Label: forwardLabel + address,
+		})
+	}
+	return endpoints
+}
+
+// isForward reports whether a channel leads to a downstream GCS
+func isForward(ch *gomavlib.Channel) bool {
+	conf, ok := ch.Endpoint().Conf().(gomavlib.EndpointCustomClient)
+	return ok && strings.HasPrefix(conf.Label, forwardLabel)
+}
+
+// forwardToGCS copies a frame received from a drone to every downstream GCS
+func (a *Adapter) forwardToGCS(evt *gomavlib.EventFrame) {
+	for ch, gcs := range a.links {
+		if gcs {
+			a.forwardFrame(ch, evt.Frame)
+		}
+	}
+}
+
+// forwardToDrones copies a frame received from a downstream GCS to every
+// drone link, unless the adapter is passive
+func (a *Adapter) forwardToDrones(evt *gomavlib.EventFrame) {
+	if a.cfg.Passive {
+		return
+	}
+	for ch, gcs := range a.links {
+		if !gcs {
+			a.forwardFrame(ch, evt.Frame)
+		}
+	}
+}
+
+// forwardFrame writes a received frame unchanged, keeping its sequence
+// number and signature. The node encodes the message of a written frame in
+// place, so a copy is written and the received frame stays decoded.
+func (a *Adapter) forwardFrame(ch *gomavlib.Channel, f frame.Frame) {
+	var out frame.Frame
+	switch f := f.(type) {
+	case *frame.V1Frame:
+		c := *f
+		out = &c
+	case *frame.V2Frame:
+		c := *f
+		out = &c
+	default:
+		return
+	}
+	if err := a.node.WriteFrameTo(ch, out); err != nil {
+		log.Printf("[MAVLink] Failed to forward frame to %s: %v", ch, err)
+	}
+}
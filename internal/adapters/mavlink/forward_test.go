@@ -0,0 +1,106 @@
+package mavlink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// testNode creates a node on one endpoint that does not send heartbeats
+func testNode(t *testing.T, endpoint gomavlib.EndpointConf, sysID byte) *gomavlib.Node {
+	t.Helper()
+	node, err := gomavlib.NewNode(gomavlib.NodeConf{
+		Endpoints:        []gomavlib.EndpointConf{endpoint},
+		Dialect:          ardupilotmega.Dialect,
+		OutVersion:       gomavlib.V2,
+		OutSystemID:      sysID,
+		HeartbeatDisable: true,
+	})
+	if err != nil {
+		t.Fatalf("NewNode() error = %v", err)
+	}
+	t.Cleanup(node.Close)
+	return node
+}
+
+// waitFrame returns the first frame a node receives from a system
+func waitFrame(t *testing.T, node *gomavlib.Node, sysID byte) *gomavlib.EventFrame {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case evt := <-node.Events():
+			if f, ok := evt.(*gomavlib.EventFrame); ok && f.Frame.GetSystemID() == sysID {
+				return f
+			}
+		case <-timeout:
+			t.Fatalf("no frame from system %d", sysID)
+		}
+	}
+}
+
+func TestAdapter_Forward(t *testing.T) {
+	address, gcsAddress := freeUDPAddress(t), freeUDPAddress(t)
+	gcs := testNode(t, gomavlib.EndpointUDPServer{Address: gcsAddress}, 250)
+
+	a := New(config.MAVLinkConfig{
+		ConnectionType: "udp",
+		Address:        address,
+		Forward:        []string{gcsAddress},
+	})
+	events := make(chan *models.DroneState, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer a.Stop()
+
+	drone := testNode(t, gomavlib.EndpointUDPClient{Address: address}, 7)
+	timeout := time.After(2 * time.Second)
+	for open := false; !open; {
+		select {
+		case evt := <-drone.Events():
+			_, open = evt.(*gomavlib.EventChannelOpen)
+		case <-timeout:
+			t.Fatal("drone channel did not open")
+		}
+	}
+
+	// Telemetry is consumed and copied to the GCS with its checksum intact
+	drone.WriteMessageAll(&ardupilotmega.MessageGlobalPositionInt{Lat: 225000000, Lon: 1140000000})
+	f := waitFrame(t, gcs, 7)
+	if msg, ok := f.Frame.GetMessage().(*ardupilotmega.MessageGlobalPositionInt); !ok || msg.Lat != 225000000 {
+		t.Errorf("GCS received %+v, want the GLOBAL_POSITION_INT", f.Frame.GetMessage())
+	}
+	select {
+	case state := <-events:
+		if state.DeviceID != "mavlink-7" {
+			t.Errorf("State from %s, want mavlink-7", state.DeviceID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Forwarded frame was not consumed")
+	}
+
+	// Commands from the GCS are forwarded to the drone and never become telemetry
+	gcs.WriteMessageAll(&ardupilotmega.MessageCommandLong{TargetSystem: 7, Command: common.MAV_CMD_COMPONENT_ARM_DISARM})
+	f = waitFrame(t, drone, 250)
+	if msg, ok := f.Frame.GetMessage().(*ardupilotmega.MessageCommandLong); !ok || msg.TargetSystem != 7 {
+		t.Errorf("Drone received %+v, want the COMMAND_LONG", f.Frame.GetMessage())
+	}
+	select {
+	case state := <-events:
+		t.Errorf("Unexpected state from %s", state.DeviceID)
+	default:
+	}
+	if peers := a.Stats().ConnectedPeers; peers != 1 {
+		t.Errorf("ConnectedPeers = %d, the GCS should not be counted", peers)
+	}
+}
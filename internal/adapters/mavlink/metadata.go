@@ -0,0 +1,241 @@
+package mavlink
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// metadataRetry is how long to wait before requesting missing metadata again
+const metadataRetry = 30 * time.Second
+
+// Message IDs requested with MAV_CMD_REQUEST_MESSAGE
+const (
+	autopilotVersionID = 148 // AUTOPILOT_VERSION
+	homePositionID     = 242 // HOME_POSITION
+)
+
+// autopilotMeta is the metadata captured for one system
+type autopilotMeta struct {
+	info       models.AutopilotInfo
+	hasVersion bool
+	hasHome    bool // HOME_POSITION received
+	requested  time.Time
+}
+
+// AutopilotInfo returns the firmware and hardware details captured for a
+// device
+func (a *Adapter) AutopilotInfo(deviceID string) (*models.AutopilotInfo, bool) {
+	sysID, ok := systemID(deviceID)
+	if !ok {
+		return nil, false
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	meta, ok := a.metadata[sysID]
+	if !ok {
+		return nil, false
+	}
+	info := meta.info
+	info.Params = maps.Clone(meta.info.Params)
+	return &info, true
+}
+
+// systemID parses a device ID of the form mavlink-<system ID>
+func systemID(deviceID string) (uint8, bool) {
+	rest, ok := strings.CutPrefix(deviceID, "mavlink-")
+	if !ok {
+		return 0, false
+	}
+	sysID, err := strconv.ParseUint(rest, 10, 8)
+	if err != nil {
+		return 0, false
+	}
+	return uint8(sysID), true
+}
+
+// meta returns the metadata of a system. Caller must hold the lock.
+func (a *Adapter) meta(sysID uint8) *autopilotMeta {
+	meta, ok := a.metadata[sysID]
+	if !ok {
+		meta = &autopilotMeta{}
+		a.metadata[sysID] = meta
+	}
+	return meta
+}
+
+// applyMetadata captures autopilot details from a message. Returns true for
+// messages that carry only metadata and do not update the state.
+func (a *Adapter) applyMetadata(sysID uint8, msg message.Message) bool {
+	now := time.Now().UnixMilli()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch msg := msg.(type) {
+	case *ardupilotmega.MessageHeartbeat:
+		// Gimbals, cameras and other components report no autopilot
+		if msg.Autopilot != ardupilotmega.MAV_AUTOPILOT_INVALID {
+			meta := a.meta(sysID)
+			if name := autopilotName(msg.Autopilot); meta.info.Autopilot != name {
+				meta.info.Autopilot = name
+				meta.info.UpdatedAt = now
+			}
+		}
+		return false
+	case *ardupilotmega.MessageAutopilotVersion:
+		meta := a.meta(sysID)
+		meta.hasVersion = true
+		meta.info.FirmwareVersion = formatVersion(msg.FlightSwVersion)
+		meta.info.FirmwareGitHash = formatCustomVersion(msg.FlightCustomVersion)
+		meta.info.MiddlewareVersion = formatVersion(msg.MiddlewareSwVersion)
+		meta.info.OSVersion = formatVersion(msg.OsSwVersion)
+		meta.info.BoardVersion = msg.BoardVersion
+		meta.info.VendorID = msg.VendorId
+		meta.info.ProductID = msg.ProductId
+		meta.info.HardwareUID = formatUID(msg.Uid, msg.Uid2)
+		meta.info.UpdatedAt = now
+		return true
+	case *ardupilotmega.MessageHomePosition:
+		// Also updates the state
+		a.meta(sysID).hasHome = true
+		return false
+	case *ardupilotmega.MessageParamValue:
+		if slices.Contains(a.cfg.MetadataParams, msg.ParamId) {
+			meta := a.meta(sysID)
+			if meta.info.Params == nil {
+				meta.info.Params = make(map[string]float64)
+			}
+			meta.info.Params[msg.ParamId] = float64(msg.ParamValue)
+			meta.info.UpdatedAt = now
+		}
+		return true
+	}
+	return false
+}
+
+// requestMetadata asks an autopilot for the version, home position and
+// parameters not yet captured, at most once per metadataRetry
+func (a *Adapter) requestMetadata(evt *gomavlib.EventFrame) {
+	hb, ok := evt.Frame.GetMessage().(*ardupilotmega.MessageHeartbeat)
+	if !ok || hb.Autopilot == ardupilotmega.MAV_AUTOPILOT_INVALID || a.cfg.Passive || a.node == nil {
+		return
+	}
+	sysID, compID := evt.Frame.GetSystemID(), evt.Frame.GetComponentID()
+
+	a.mu.Lock()
+	meta := a.meta(sysID)
+	var missing []string
+	for _, p := range a.cfg.MetadataParams {
+		if _, ok := meta.info.Params[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	due := (!meta.hasVersion || !meta.hasHome || len(missing) > 0) && time.Since(meta.requested) >= metadataRetry
+	if due {
+		meta.requested = time.Now()
+	}
+	needVersion, needHome := !meta.hasVersion, !meta.hasHome
+	a.mu.Unlock()
+	if !due {
+		return
+	}
+
+	var msgs []message.Message
+	if needVersion {
+		msgs = append(msgs, &ardupilotmega.MessageCommandLong{
+			TargetSystem:    sysID,
+			TargetComponent: compID,
+			Command:         common.MAV_CMD_REQUEST_MESSAGE,
+			Param1:          autopilotVersionID,
+		})
+	}
+	if needHome {
+		msgs = append(msgs, &ardupilotmega.MessageCommandLong{
+			TargetSystem:    sysID,
+			TargetComponent: compID,
+			Command:         common.MAV_CMD_REQUEST_MESSAGE,
+			Param1:          homePositionID,
+		})
+	}
+	for _, p := range missing {
+		msgs = append(msgs, &ardupilotmega.MessageParamRequestRead{
+			TargetSystem:    sysID,
+			TargetComponent: compID,
+			ParamId:         p,
+			ParamIndex:      -1,
+		})
+	}
+	for _, msg := range msgs {
+		if err := a.node.WriteMessageTo(evt.Channel, msg); err != nil {
+			log.Printf("[MAVLink] Failed to request metadata from system %d: %v", sysID, err)
+			return
+		}
+	}
+}
+
+// autopilotName returns the flight stack name, e.g. "ardupilot" or "px4"
+func autopilotName(ap ardupilotmega.MAV_AUTOPILOT) string {
+	if ap == ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA {
+		return "ardupilot"
+	}
+	return strings.ToLower(strings.TrimPrefix(ap.String(), "MAV_AUTOPILOT_"))
+}
+
+// formatVersion formats a version encoded as major, minor, patch and
+// FIRMWARE_VERSION_TYPE bytes, e.g. "4.5.1" or "1.15.0-rc"
+func formatVersion(v uint32) string {
+	if v == 0 {
+		return ""
+	}
+	version := fmt.Sprintf("%d.%d.%d", v>>24, v>>16&0xff, v>>8&0xff)
+	switch t := v & 0xff; {
+	case t < 64:
+		return version + "-dev"
+	case t < 128:
+		return version + "-alpha"
+	case t < 192:
+		return version + "-beta"
+	case t < 255:
+		return version + "-rc"
+	}
+	return version
+}
+
+// formatCustomVersion formats a custom version field, which ArduPilot fills
+// with the first characters of the git hash and PX4 with its first bytes
+func formatCustomVersion(b [8]uint8) string {
+	if b == [8]uint8{} {
+		return ""
+	}
+	text := strings.TrimRight(string(b[:]), "\x00")
+	if strings.Trim(text, "0123456789abcdef") == "" {
+		return text
+	}
+	reversed := b
+	slices.Reverse(reversed[:])
+	return hex.EncodeToString(reversed[:])
+}
+
+// formatUID formats the flight controller UID, preferring the longer uid2
+func formatUID(uid uint64, uid2 [18]uint8) string {
+	if uid2 != [18]uint8{} {
+		return hex.EncodeToString(uid2[:])
+	}
+	if uid != 0 {
+		return fmt.Sprintf("%016x", uid)
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package mavlink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAdapter_AutopilotInfo(t *testing.T) {
+	a := New(config.MAVLinkConfig{MetadataParams: []string{"FRAME_CLASS"}})
+	events := make(chan *models.DroneState, 10)
+
+	for _, f := range []*frame.V2Frame{
+		{SystemID: 7, ComponentID: 154, Message: &ardupilotmega.MessageHeartbeat{Autopilot: ardupilotmega.MAV_AUTOPILOT_INVALID}},
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageHeartbeat{Autopilot: ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA}},
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageAutopilotVersion{
+			FlightSwVersion:     4<<24 | 5<<16 | 1<<8 | 255,
+			FlightCustomVersion: [8]uint8{'1', '2', 'a', 'b', 'c', 'd', 'e', 'f'},
+			BoardVersion:        140 << 16,
+			VendorId:            0x1209,
+			ProductId:           0x5741,
+			Uid:                 0x00360027_31395104,
+		}},
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageParamValue{ParamId: "FRAME_CLASS", ParamValue: 1}},
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageParamValue{ParamId: "BATT_CAPACITY", ParamValue: 5200}},
+	} {
+		a.handleFrame(context.Background(), f, events)
+	}
+
+	if len(events) != 2 {
+		t.Errorf("Emitted %d states, want only the heartbeats", len(events))
+	}
+	info, ok := a.AutopilotInfo("mavlink-7")
+	if !ok {
+		t.Fatal("AutopilotInfo() should report the captured system")
+	}
+	if info.Autopilot != "ardupilot" || info.FirmwareVersion != "4.5.1" || info.FirmwareGitHash != "12abcdef" {
+		t.Errorf("Firmware = %+v", info)
+	}
+	if info.VendorID != 0x1209 || info.HardwareUID != "0036002731395104" || info.UpdatedAt == 0 {
+		t.Errorf("Hardware = %+v", info)
+	}
+	if len(info.Params) != 1 || info.Params["FRAME_CLASS"] != 1 {
+		t.Errorf("Params = %v, want only the selected FRAME_CLASS", info.Params)
+	}
+
+	info.Params["FRAME_CLASS"] = 2
+	if again, _ := a.AutopilotInfo("mavlink-7"); again.Params["FRAME_CLASS"] != 1 {
+		t.Error("AutopilotInfo() should return a copy")
+	}
+	for _, id := range []string{"mavlink-8", "mavlink-300", "dji-7"} {
+		if _, ok := a.AutopilotInfo(id); ok {
+			t.Errorf("AutopilotInfo(%q) should not be found", id)
+		}
+	}
+}
+
+func TestFormatVersion(t *testing.T) {
+	tests := map[uint32]string{
+		0:                           "",
+		4<<24 | 5<<16 | 1<<8 | 255:  "4.5.1",
+		1<<24 | 15<<16 | 0<<8 | 192: "1.15.0-rc",
+		1<<24 | 16<<16 | 0<<8 | 128: "1.16.0-beta",
+		4<<24 | 6<<16 | 0<<8 | 0:    "4.6.0-dev",
+	}
+	for in, want := range tests {
+		if got := formatVersion(in); got != want {
+			t.Errorf("formatVersion(%#x) = %q, want %q", in, got, want)
+		}
+	}
+
+	// PX4 sends the git hash as bytes, least significant first
+	if got := formatCustomVersion([8]uint8{0xef, 0xcd, 0xab, 0x89, 0x67, 0x45, 0x23, 0x01}); got != "0123456789abcdef" {
+		t.Errorf("formatCustomVersion() = %q", got)
+	}
+}
@@ -0,0 +1,289 @@
+package mavlink
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// missionState is the mission captured for one system
+type missionState struct {
+	mission   *models.Mission       // Last complete plan, nil until one is captured
+	pending   []*models.MissionItem // Slots of a transfer in progress, nil when idle
+	pendingID uint32                // Plan ID announced by the transfer, if any
+	current   int                   // Item being executed, -1 if unknown
+	missionID uint32                // Latest plan ID the autopilot reported, 0 if unsupported
+	requested time.Time
+}
+
+// Mission returns the last complete mission captured for a device
+func (a *Adapter) Mission(deviceID string) (*models.Mission, bool) {
+	sysID, ok := systemID(deviceID)
+	if !ok {
+		return nil, false
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	st, ok := a.missions[sysID]
+	if !ok || st.mission == nil {
+		return nil, false
+	}
+	return st.snapshot(), true
+}
+
+// SetMissionCallback sets the function called when a device's captured
+// mission changes
+func (a *Adapter) SetMissionCallback(fn func(deviceID string, mission *models.Mission)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onMission = fn
+}
+
+// snapshot returns a copy of the captured plan. Caller must hold the lock.
+func (st *missionState) snapshot() *models.Mission {
+	m := *st.mission
+	m.Items = slices.Clone(st.mission.Items)
+	m.Current = st.current
+	return &m
+}
+
+// missionOf returns the mission state of a system. Caller must hold the lock.
+func (a *Adapter) missionOf(sysID uint8) *missionState {
+	st, ok := a.missions[sysID]
+	if !ok {
+		st = &missionState{current: -1}
+		a.missions[sysID] = st
+	}
+	return st
+}
+
+// missionVehicle returns the system a mission message is about: the sender
+// if it is an autopilot, otherwise the target, e.g. when a ground station
+// uploads a plan. Caller must hold the lock.
+func (a *Adapter) missionVehicle(sender, target uint8) uint8 {
+	if meta, ok := a.metadata[sender]; ok && meta.info.Autopilot != "" {
+		return sender
+	}
+	return target
+}
+
+// applyMission follows mission transfers in either direction on the link,
+// so plans are captured whether the bridge or a ground station downloads
+// them, or a ground station uploads them. Returns true for mission protocol
+// messages, which do not update the state.
+func (a *Adapter) applyMission(sysID uint8, msg message.Message) bool {
+	vehicle, changed, ok := a.trackMission(sysID, msg)
+	if !ok {
+		return false
+	}
+
+	a.mu.RLock()
+	fn := a.onMission
+	a.mu.RUnlock()
+	if changed != nil && fn != nil {
+		fn(fmt.Sprintf("mavlink-%d", vehicle), changed)
+	}
+	return true
+}
+
+// trackMission updates the mission state from a message and returns the
+// vehicle's new plan if a transfer completed with a different one
+func (a *Adapter) trackMission(sysID uint8, msg message.Message) (uint8, *models.Mission, bool) {
+	now := time.Now().UnixMilli()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch msg := msg.(type) {
+	case *ardupilotmega.MessageMissionCount:
+		if msg.MissionType != ardupilotmega.MAV_MISSION_TYPE_MISSION {
+			return 0, nil, true
+		}
+		vehicle := a.missionVehicle(sysID, msg.TargetSystem)
+		st := a.missionOf(vehicle)
+		st.pending = make([]*models.MissionItem, msg.Count)
+		st.pendingID = msg.OpaqueId
+		return vehicle, st.commit(now), true
+	case *ardupilotmega.MessageMissionItemInt:
+		if msg.MissionType != ardupilotmega.MAV_MISSION_TYPE_MISSION {
+			return 0, nil, true
+		}
+		vehicle := a.missionVehicle(sysID, msg.TargetSystem)
+		st := a.missionOf(vehicle)
+		if int(msg.Seq) >= len(st.pending) {
+			return vehicle, nil, true
+		}
+		item := missionItem(msg)
+		st.pending[msg.Seq] = &item
+		return vehicle, st.commit(now), true
+	case *ardupilotmega.MessageMissionClearAll:
+		if msg.MissionType != ardupilotmega.MAV_MISSION_TYPE_MISSION && msg.MissionType != ardupilotmega.MAV_MISSION_TYPE_ALL {
+			return 0, nil, true
+		}
+		st := a.missionOf(msg.TargetSystem)
+		st.pending = []*models.MissionItem{}
+		st.pendingID = 0
+		return msg.TargetSystem, st.commit(now), true
+	case *ardupilotmega.MessageMissionAck:
+		// The vehicle acknowledges an upload with the new plan ID
+		if msg.MissionType == ardupilotmega.MAV_MISSION_TYPE_MISSION && msg.Type == ardupilotmega.MAV_MISSION_ACCEPTED &&
+			msg.OpaqueId != 0 && a.missionVehicle(sysID, msg.TargetSystem) == sysID {
+			st := a.missionOf(sysID)
+			st.missionID = msg.OpaqueId
+			if st.mission != nil && st.pending == nil {
+				st.mission.MissionID = msg.OpaqueId
+			}
+		}
+		return sysID, nil, true
+	case *ardupilotmega.MessageMissionCurrent:
+		st := a.missionOf(sysID)
+		st.current = int(msg.Seq)
+		if msg.MissionId != 0 {
+			st.missionID = msg.MissionId
+		}
+		return sysID, nil, true
+	case *ardupilotmega.MessageMissionRequestList, *ardupilotmega.MessageMissionRequestInt,
+		*ardupilotmega.MessageMissionRequest:
+		return sysID, nil, true
+	}
+	return 0, nil, false
+}
+
+// commit makes a finished transfer the captured plan. Returns a copy of the
+// plan if it differs from the previous one, nil otherwise.
+func (st *missionState) commit(now int64) *models.Mission {
+	items := make([]models.MissionItem, 0, len(st.pending))
+	for _, item := range st.pending {
+		if item == nil {
+			return nil
+		}
+		items = append(items, *item)
+	}
+
+	changed := st.mission == nil || !slices.Equal(st.mission.Items, items)
+	id := st.pendingID
+	if id == 0 {
+		id = st.missionID
+	}
+	st.mission = &models.Mission{Items: items, MissionID: id, UpdatedAt: now}
+	st.pending = nil
+	st.pendingID = 0
+	if !changed {
+		return nil
+	}
+	return st.snapshot()
+}
+
+// outdated reports whether the plan should be downloaded: none is
+// captured, a transfer stalled, or the autopilot reports a different plan ID
+func (st *missionState) outdated() bool {
+	return st.mission == nil || st.pending != nil || (st.missionID != 0 && st.mission.MissionID != st.missionID)
+}
+
+// requestMission downloads the plan of an autopilot whose plan is
+// outdated, at most once per metadataRetry, and drives the downloads it
+// started: each item is requested in turn and the last one acknowledged
+func (a *Adapter) requestMission(evt *gomavlib.EventFrame) {
+	if a.cfg.Passive || a.node == nil {
+		return
+	}
+	sysID, compID := evt.Frame.GetSystemID(), evt.Frame.GetComponentID()
+
+	var reply message.Message
+	a.mu.Lock()
+	switch msg := evt.Frame.GetMessage().(type) {
+	case *ardupilotmega.MessageHeartbeat:
+		if msg.Autopilot == ardupilotmega.MAV_AUTOPILOT_INVALID {
+			break
+		}
+		if st := a.missionOf(sysID); st.outdated() && time.Since(st.requested) >= metadataRetry {
+			st.requested = time.Now()
+			reply = &ardupilotmega.MessageMissionRequestList{
+				TargetSystem:    sysID,
+				TargetComponent: compID,
+				MissionType:     ardupilotmega.MAV_MISSION_TYPE_MISSION,
+			}
+		}
+	case *ardupilotmega.MessageMissionCount:
+		if msg.TargetSystem == gcsSystemID && msg.MissionType == ardupilotmega.MAV_MISSION_TYPE_MISSION {
+			reply = a.nextMissionRequest(sysID, compID)
+		}
+	case *ardupilotmega.MessageMissionItemInt:
+		if msg.TargetSystem == gcsSystemID && msg.MissionType == ardupilotmega.MAV_MISSION_TYPE_MISSION {
+			reply = a.nextMissionRequest(sysID, compID)
+		}
+	}
+	a.mu.Unlock()
+	if reply == nil {
+		return
+	}
+
+	if err := a.node.WriteMessageTo(evt.Channel, reply); err != nil {
+		log.Printf("[MAVLink] Failed to request mission from system %d: %v", sysID, err)
+	}
+}
+
+// nextMissionRequest returns the request for the first missing item of a
+// download, or its acknowledgement once complete. Caller must hold the lock.
+func (a *Adapter) nextMissionRequest(sysID, compID uint8) message.Message {
+	st, ok := a.missions[sysID]
+	if !ok {
+		return nil
+	}
+	for seq, item := range st.pending {
+		if item == nil {
+			return &ardupilotmega.MessageMissionRequestInt{
+				TargetSystem:    sysID,
+				TargetComponent: compID,
+				Seq:             uint16(seq),
+				MissionType:     ardupilotmega.MAV_MISSION_TYPE_MISSION,
+			}
+		}
+	}
+	return &ardupilotmega.MessageMissionAck{
+		TargetSystem:    sysID,
+		TargetComponent: compID,
+		Type:            ardupilotmega.MAV_MISSION_ACCEPTED,
+		MissionType:     ardupilotmega.MAV_MISSION_TYPE_MISSION,
+	}
+}
+
+// missionItem converts a MISSION_ITEM_INT, naming ArduPilot-specific
+// commands too. Global frames carry degrees * 1e7, local frames meters * 1e4.
+func missionItem(msg *ardupilotmega.MessageMissionItemInt) models.MissionItem {
+	frame := strings.TrimPrefix(msg.Frame.String(), "MAV_FRAME_")
+	scale := 1e4
+	if strings.HasPrefix(frame, "GLOBAL") {
+		scale = 1e7
+	}
+	return models.MissionItem{
+		Seq:          int(msg.Seq),
+		Command:      strings.TrimPrefix(ardupilotmega.MAV_CMD(msg.Command).String(), "MAV_CMD_"),
+		CommandID:    int(msg.Command),
+		Frame:        frame,
+		Lat:          float64(msg.X) / scale,
+		Lon:          float64(msg.Y) / scale,
+		Alt:          finite(msg.Z),
+		Params:       [4]float64{finite(msg.Param1), finite(msg.Param2), finite(msg.Param3), finite(msg.Param4)},
+		Autocontinue: msg.Autocontinue != 0,
+	}
+}
+
+// finite converts a float parameter, mapping the NaN used for unset
+// parameters to 0 so the item can be encoded as JSON
+func finite(v float32) float64 {
+	f := float64(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return f
+}
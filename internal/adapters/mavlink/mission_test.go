@@ -0,0 +1,129 @@
+package mavlink
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAdapter_Mission(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	events := make(chan *models.DroneState, 10)
+
+	var changes []string
+	var last *models.Mission
+	a.SetMissionCallback(func(deviceID string, mission *models.Mission) {
+		changes = append(changes, deviceID)
+		last = mission
+	})
+
+	item := func(sender, target uint8, seq uint16, cmd common.MAV_CMD) *frame.V2Frame {
+		return &frame.V2Frame{SystemID: sender, ComponentID: 1, Message: &ardupilotmega.MessageMissionItemInt{
+			TargetSystem: target,
+			Seq:          seq,
+			Frame:        ardupilotmega.MAV_FRAME_GLOBAL_RELATIVE_ALT_INT,
+			Command:      cmd,
+			Param1:       float32(math.NaN()),
+			X:            399087000 + int32(seq),
+			Y:            1163975000,
+			Z:            30,
+			Autocontinue: 1,
+		}}
+	}
+
+	// A ground station uploads a plan to the autopilot
+	upload := []*frame.V2Frame{
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageHeartbeat{Autopilot: ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA}},
+		{SystemID: 255, ComponentID: 190, Message: &ardupilotmega.MessageMissionCount{TargetSystem: 7, Count: 2}},
+		item(255, 7, 0, common.MAV_CMD_NAV_TAKEOFF),
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageMissionRequestInt{TargetSystem: 255, Seq: 1}},
+		item(255, 7, 1, common.MAV_CMD_NAV_WAYPOINT),
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageMissionAck{TargetSystem: 255, OpaqueId: 42}},
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageMissionCurrent{Seq: 1, MissionId: 42}},
+	}
+	for _, f := range upload {
+		a.handleFrame(context.Background(), f, events)
+	}
+
+	if len(events) != 1 {
+		t.Errorf("Emitted %d states, want only the heartbeat", len(events))
+	}
+	if len(changes) != 1 || changes[0] != "mavlink-7" || len(last.Items) != 2 {
+		t.Fatalf("Mission changes = %v, last %+v", changes, last)
+	}
+
+	m, ok := a.Mission("mavlink-7")
+	if !ok {
+		t.Fatal("Mission() should report the uploaded plan")
+	}
+	first := m.Items[0]
+	if first.Command != "NAV_TAKEOFF" || first.Frame != "GLOBAL_RELATIVE_ALT_INT" || first.Lat != 39.9087 || first.Alt != 30 {
+		t.Errorf("First item = %+v", first)
+	}
+	if first.Params[0] != 0 || !first.Autocontinue {
+		t.Errorf("Unset parameters should be 0: %+v", first)
+	}
+	if m.Current != 1 || m.MissionID != 42 {
+		t.Errorf("Current = %d, mission ID = %d", m.Current, m.MissionID)
+	}
+	if _, ok := a.Mission("mavlink-255"); ok {
+		t.Error("The ground station should not own a mission")
+	}
+
+	// Downloading the same plan again is not a change
+	for _, f := range []*frame.V2Frame{
+		{SystemID: 7, ComponentID: 1, Message: &ardupilotmega.MessageMissionCount{TargetSystem: 255, Count: 2, OpaqueId: 42}},
+		item(7, 255, 0, common.MAV_CMD_NAV_TAKEOFF),
+		item(7, 255, 1, common.MAV_CMD_NAV_WAYPOINT),
+	} {
+		a.handleFrame(context.Background(), f, events)
+	}
+	if len(changes) != 1 {
+		t.Errorf("Unchanged download reported as change: %v", changes)
+	}
+
+	a.handleFrame(context.Background(), &frame.V2Frame{SystemID: 255, Message: &ardupilotmega.MessageMissionClearAll{TargetSystem: 7}}, events)
+	if m, _ := a.Mission("mavlink-7"); len(changes) != 2 || len(m.Items) != 0 {
+		t.Errorf("After clear: changes %v, mission %+v", changes, m)
+	}
+}
+
+func TestAdapter_MissionDownload(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	events := make(chan *models.DroneState, 10)
+	a.handleFrame(context.Background(), &frame.V2Frame{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageHeartbeat{Autopilot: ardupilotmega.MAV_AUTOPILOT_PX4}}, events)
+	if st := a.missionOf(3); !st.outdated() {
+		t.Error("A system without a captured plan should be outdated")
+	}
+
+	a.handleFrame(context.Background(), &frame.V2Frame{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageMissionCount{TargetSystem: 255, Count: 2}}, events)
+	if req, ok := a.nextMissionRequest(3, 1).(*ardupilotmega.MessageMissionRequestInt); !ok || req.Seq != 0 {
+		t.Fatalf("After MISSION_COUNT: %+v, want a request for item 0", req)
+	}
+
+	a.handleFrame(context.Background(), &frame.V2Frame{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageMissionItemInt{TargetSystem: 255, Seq: 0}}, events)
+	if req, ok := a.nextMissionRequest(3, 1).(*ardupilotmega.MessageMissionRequestInt); !ok || req.Seq != 1 {
+		t.Fatalf("After item 0: %+v, want a request for item 1", req)
+	}
+
+	a.handleFrame(context.Background(), &frame.V2Frame{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageMissionItemInt{TargetSystem: 255, Seq: 1}}, events)
+	if _, ok := a.nextMissionRequest(3, 1).(*ardupilotmega.MessageMissionAck); !ok {
+		t.Fatal("A complete download should be acknowledged")
+	}
+	if a.missionOf(3).outdated() {
+		t.Error("Plan should be up to date after the download")
+	}
+
+	// A new plan ID makes the plan outdated again
+	a.handleFrame(context.Background(), &frame.V2Frame{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageMissionCurrent{MissionId: 9}}, events)
+	if !a.missionOf(3).outdated() {
+		t.Error("Plan should be outdated after the plan ID changed")
+	}
+}
@@ -0,0 +1,121 @@
+package mavlink
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// statusTextHistory is how many STATUSTEXT messages are kept per drone
+const statusTextHistory = 50
+
+// ValidateRawMessages checks that the message names configured for
+// passthrough exist in the dialect
+func ValidateRawMessages(names []string) error {
+	for _, name := range names {
+		if !slices.ContainsFunc(ardupilotmega.Dialect.Messages, func(msg message.Message) bool {
+			return messageName(msg) == strings.ToUpper(name)
+		}) {
+			return fmt.Errorf("unknown message %q", name)
+		}
+	}
+	return nil
+}
+
+// StatusTexts returns the recent STATUSTEXT messages of a device, oldest
+// first
+func (a *Adapter) StatusTexts(deviceID string) ([]models.StatusText, bool) {
+	sysID, ok := systemID(deviceID)
+	if !ok {
+		return nil, false
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	texts, ok := a.statusTexts[sysID]
+	return slices.Clone(texts), ok
+}
+
+// SetRawCallback sets the function called with each message selected for
+// passthrough
+func (a *Adapter) SetRawCallback(fn func(msg *models.RawMessage)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onRaw = fn
+}
+
+// applyRaw records STATUSTEXT messages and passes the configured raw
+// messages to the callback
+func (a *Adapter) applyRaw(sysID uint8, msg message.Message) {
+	now := time.Now().UnixMilli()
+	if st, ok := msg.(*ardupilotmega.MessageStatustext); ok {
+		a.mu.Lock()
+		texts := append(a.statusTexts[sysID], models.StatusText{
+			Severity:  strings.ToLower(strings.TrimPrefix(st.Severity.String(), "MAV_SEVERITY_")),
+			Text:      st.Text,
+			Timestamp: now,
+		})
+		if len(texts) > statusTextHistory {
+			texts = texts[len(texts)-statusTextHistory:]
+		}
+		a.statusTexts[sysID] = texts
+		a.mu.Unlock()
+	}
+
+	name := messageName(msg)
+	if !a.raw[name] {
+		return
+	}
+	a.mu.RLock()
+	fn := a.onRaw
+	a.mu.RUnlock()
+	if fn != nil {
+		fn(&models.RawMessage{
+			DeviceID:  fmt.Sprintf("mavlink-%d", sysID),
+			Name:      name,
+			Timestamp: now,
+			Fields:    messageFields(msg),
+		})
+	}
+}
+
+// messageName returns the MAVLink name of a message, e.g. SYS_STATUS for
+// *MessageSysStatus
+func messageName(msg message.Message) string {
+	return strings.ToUpper(snakeCase(strings.TrimPrefix(reflect.TypeOf(msg).Elem().Name(), "Message")))
+}
+
+// messageFields returns the fields of a message by MAVLink field name.
+// Enum values keep their type, so they are encoded by label.
+func messageFields(msg message.Message) map[string]any {
+	v := reflect.ValueOf(msg).Elem()
+	fields := make(map[string]any, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		fields[snakeCase(v.Type().Field(i).Name)] = v.Field(i).Interface()
+	}
+	return fields
+}
+
+// snakeCase converts a Go identifier generated from a MAVLink name back,
+// e.g. VoltageBattery to voltage_battery
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package mavlink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAdapter_Raw(t *testing.T) {
+	a := New(config.MAVLinkConfig{Raw: []string{"sys_status", "STATUSTEXT"}})
+	events := make(chan *models.DroneState, 10)
+
+	var raw []*models.RawMessage
+	a.SetRawCallback(func(msg *models.RawMessage) {
+		raw = append(raw, msg)
+	})
+
+	for _, f := range []*frame.V2Frame{
+		{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageHeartbeat{}},
+		{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageSysStatus{VoltageBattery: 12600, BatteryRemaining: 80}},
+		{SystemID: 3, ComponentID: 1, Message: &ardupilotmega.MessageStatustext{Severity: ardupilotmega.MAV_SEVERITY_CRITICAL, Text: "PreArm: Compass not calibrated"}},
+	} {
+		a.handleFrame(context.Background(), f, events)
+	}
+
+	if len(raw) != 2 || raw[0].Name != "SYS_STATUS" || raw[1].Name != "STATUSTEXT" || raw[0].DeviceID != "mavlink-3" {
+		t.Fatalf("Raw messages = %+v", raw)
+	}
+	data, _ := json.Marshal(raw[1].Fields)
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	if fields["severity"] != "MAV_SEVERITY_CRITICAL" || fields["text"] != "PreArm: Compass not calibrated" {
+		t.Errorf("STATUSTEXT fields = %s", data)
+	}
+	if raw[0].Fields["voltage_battery"] != uint16(12600) {
+		t.Errorf("SYS_STATUS fields = %+v", raw[0].Fields)
+	}
+
+	texts, ok := a.StatusTexts("mavlink-3")
+	if !ok || len(texts) != 1 || texts[0].Severity != "critical" || texts[0].Text != "PreArm: Compass not calibrated" {
+		t.Errorf("StatusTexts() = %+v, %v", texts, ok)
+	}
+	if _, ok := a.StatusTexts("mavlink-4"); ok {
+		t.Error("StatusTexts() should report drones without messages as missing")
+	}
+}
+
+func TestAdapter_StatusTextHistory(t *testing.T) {
+	a := New(config.MAVLinkConfig{})
+	for i := range statusTextHistory + 5 {
+		a.applyRaw(1, &ardupilotmega.MessageStatustext{Severity: ardupilotmega.MAV_SEVERITY_INFO, Text: fmt.Sprintf("msg %d", i)})
+	}
+
+	texts, _ := a.StatusTexts("mavlink-1")
+	if len(texts) != statusTextHistory || texts[0].Text != "msg 5" {
+		t.Errorf("Kept %d messages starting at %q", len(texts), texts[0].Text)
+	}
+}
+
+func TestValidateRawMessages(t *testing.T) {
+	if err := ValidateRawMessages([]string{"STATUSTEXT", "sys_status", "EKF_STATUS_REPORT"}); err != nil {
+		t.Errorf("ValidateRawMessages() error = %v", err)
+	}
+	if err := ValidateRawMessages([]string{"NOT_A_MESSAGE"}); err == nil {
+		t.Error("ValidateRawMessages() should reject unknown messages")
+	}
+}
@@ -0,0 +1,291 @@
+package mavlink
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialect"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+	"go.bug.st/serial"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// serialLabel is the label of the serial endpoint
+const serialLabel = "serial"
+
+// defaultSerialPorts are scanned when serial_port is empty: USB serial
+// adapters and USB-connected flight controllers on Linux and macOS
+var defaultSerialPorts = []string{"/dev/ttyUSB*", "/dev/ttyACM*", "/dev/tty.usbserial*", "/dev/tty.usbmodem*"}
+
+// autoBauds are the baud rates tried, in order, when serial_baud is 0
+var autoBauds = []int{57600, 115200, 921600, 460800, 230400, 38400}
+
+// serialProbeTimeout is how long a port is listened to for MAVLink while
+// scanning. Autopilots send a heartbeat every second.
+const serialProbeTimeout = 1500 * time.Millisecond
+
+// errNoSerialPort is returned while no port matches serial_port
+var errNoSerialPort = errors.New("no serial port found")
+
+// serialTarget is a port opened at a baud rate
+type serialTarget struct {
+	device string
+	baud   int
+}
+
+func (t serialTarget) String() string {
+	return fmt.Sprintf("%s@%d", t.device, t.baud)
+}
+
+// serialLink opens the serial port of the adapter. With a glob pattern or
+// no serial_port it scans the matching ports, and without serial_baud it
+// tries common baud rates, keeping the first port and rate carrying valid
+// MAVLink frames. gomavlib connects again whenever the port is lost, so an
+// unplugged radio or flight controller is picked up when it returns, even
+// under another device name.
+type serialLink struct {
+	port string
+	baud int
+
+	open         func(device string, baud int) (io.ReadWriteCloser, error)
+	glob         func(pattern string) ([]string, error)
+	probeTimeout time.Duration
+
+	mu      sync.Mutex
+	current serialTarget // Port and rate of the open link, tried first after a loss
+	next    int          // Candidate to resume a scan interrupted by the connect timeout
+	failing bool         // The last connect failed; logged once until one succeeds
+}
+
+// newSerialLink creates the link for the configured port and baud rate
+func newSerialLink(port string, baud int) *serialLink {
+	return &serialLink{
+		port:         port,
+		baud:         baud,
+		open:         openSerial,
+		glob:         filepath.Glob,
+		probeTimeout: serialProbeTimeout,
+	}
+}
+
+// openSerial opens a port in the 8N1 mode autopilots use
+func openSerial(device string, baud int) (io.ReadWriteCloser, error) {
+	port, err := serial.Open(device, &serial.Mode{
+		BaudRate: baud,
+		Parity:   serial.NoParity,
+		DataBits: 8,
+		StopBits: serial.OneStopBit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	port.SetDTR(true)
+	port.SetRTS(true)
+	return port, nil
+}
+
+// endpoint returns the gomavlib endpoint opening the link
+func (l *serialLink) endpoint() gomavlib.EndpointConf {
+	return gomavlib.EndpointCustomClient{Connect: l.connect, Label: serialLabel}
+}
+
+// isSerial reports whether a channel is the serial link
+func isSerial(ch *gomavlib.Channel) bool {
+	conf, ok := ch.Endpoint().Conf().(gomavlib.EndpointCustomClient)
+	return ok && conf.Label == serialLabel
+}
+
+// candidates lists the ports and rates to try
+func (l *serialLink) candidates() []serialTarget {
+	patterns := defaultSerialPorts
+	if l.port != "" {
+		patterns = []string{l.port}
+	}
+	var devices []string
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			devices = append(devices, pattern)
+			continue
+		}
+		matches, _ := l.glob(pattern)
+		devices = append(devices, matches...)
+	}
+	slices.Sort(devices)
+	devices = slices.Compact(devices)
+
+	bauds := autoBauds
+	if l.baud > 0 {
+		bauds = []int{l.baud}
+	}
+	targets := make([]serialTarget, 0, len(devices)*len(bauds))
+	for _, device := range devices {
+		for _, baud := range bauds {
+			targets = append(targets, serialTarget{device: device, baud: baud})
+		}
+	}
+	return targets
+}
+
+// connect opens the first candidate carrying MAVLink. A single candidate
+// is opened without listening to it first.
+func (l *serialLink) connect(ctx context.Context) (net.Conn, error) {
+	targets := l.candidates()
+	if len(targets) == 0 {
+		return nil, l.fail(errNoSerialPort)
+	}
+
+	l.mu.Lock()
+	start := slices.Index(targets, l.current)
+	if start < 0 {
+		start = l.next % len(targets)
+	}
+	l.mu.Unlock()
+
+	var lastErr error
+	for i := range targets {
+		// The scan resumes here on the next attempt
+		if ctx.Err() != nil {
+			l.mu.Lock()
+			l.current, l.next = serialTarget{}, start+i
+			l.mu.Unlock()
+			return nil, l.fail(fmt.Errorf("scanning %d port/baud combinations: %w", len(targets), ctx.Err()))
+		}
+		target := targets[(start+i)%len(targets)]
+		rwc, err := l.open(target.device, target.baud)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(targets) > 1 && !l.probe(rwc) {
+			lastErr = fmt.Errorf("no MAVLink on %s", target)
+			continue
+		}
+
+		l.mu.Lock()
+		l.current, l.failing = target, false
+		l.mu.Unlock()
+		return &serialConn{rwc}, nil
+	}
+	if len(targets) > 1 {
+		lastErr = fmt.Errorf("no MAVLink found on %d port/baud combinations: %w", len(targets), lastErr)
+	}
+	return nil, l.fail(lastErr)
+}
+
+// fail logs the first of consecutive connect failures
+func (l *serialLink) fail(err error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.failing {
+		log.Printf("[MAVLink] Serial link unavailable, retrying: %v", err)
+		l.failing = true
+	}
+	return err
+}
+
+// probe listens to a port for a valid frame of a known message, which a
+// wrong baud rate or another device practically never produces. The port
+// is closed if none arrives in time.
+func (l *serialLink) probe(rwc io.ReadWriteCloser) bool {
+	found := make(chan bool, 1)
+	go func() {
+		found <- readsMAVLink(rwc)
+	}()
+
+	select {
+	case ok := <-found:
+		if !ok {
+			rwc.Close()
+		}
+		return ok
+	case <-time.After(l.probeTimeout):
+		rwc.Close()
+		<-found
+		return false
+	}
+}
+
+// readsMAVLink reads until a frame decodes or the reader fails
+func readsMAVLink(r io.Reader) bool {
+	rw := &dialect.ReadWriter{Dialect: ardupilotmega.Dialect}
+	if err := rw.Initialize(); err != nil {
+		return false
+	}
+	reader := &frame.Reader{BufByteReader: bufio.NewReader(r), DialectRW: rw}
+	if err := reader.Initialize(); err != nil {
+		return false
+	}
+	for {
+		f, err := reader.Read()
+		if err != nil {
+			var readErr frame.ReadError
+			if errors.As(err, &readErr) {
+				continue
+			}
+			return false
+		}
+		if _, raw := f.GetMessage().(*message.MessageRaw); !raw {
+			return true
+		}
+	}
+}
+
+// linkStatus returns the status of the serial link
+func (l *serialLink) linkStatus(connected bool, err error) *models.LinkStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	status := &models.LinkStatus{
+		Connected: connected,
+		Endpoint:  l.current.String(),
+		Timestamp: time.Now().UnixMilli(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// serialConn adapts a serial port to net.Conn. Deadlines are not
+// supported; a lost port fails reads instead.
+type serialConn struct {
+	io.ReadWriteCloser
+}
+
+func (*serialConn) LocalAddr() net.Addr                { return nil }
+func (*serialConn) RemoteAddr() net.Addr               { return nil }
+func (*serialConn) SetDeadline(_ time.Time) error      { return nil }
+func (*serialConn) SetReadDeadline(_ time.Time) error  { return nil }
+func (*serialConn) SetWriteDeadline(_ time.Time) error { return nil }
+
+// SetLinkCallback sets the function called when the serial link is opened
+// or lost
+func (a *Adapter) SetLinkCallback(fn func(status *models.LinkStatus)) {
+	a.onLink = fn
+}
+
+// linkChanged logs and reports a change of the serial link
+func (a *Adapter) linkChanged(connected bool, err error) {
+	status := a.serial.linkStatus(connected, err)
+	if connected {
+		log.Printf("[MAVLink] Serial link open on %s", status.Endpoint)
+	} else {
+		log.Printf("[MAVLink] Serial link on %s lost, waiting for it to return: %v", status.Endpoint, err)
+	}
+	if a.onLink != nil {
+		a.onLink(status)
+	}
+}
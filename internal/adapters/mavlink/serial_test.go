@@ -0,0 +1,185 @@
+package mavlink
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// fakePorts simulates serial ports. A device opened at its MAVLink baud
+// rate is piped to the drone node; other rates are silent.
+type fakePorts struct {
+	mu      sync.Mutex
+	devices map[string]int // Present devices and the rate carrying MAVLink
+	drone   net.Conn       // Drone end of the last pipe
+	drones  chan net.Conn
+}
+
+// unplug switches the present devices and cuts the drone off
+func (p *fakePorts) unplug(devices map[string]int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.devices = devices
+	p.drone.Close()
+}
+
+func (p *fakePorts) glob(pattern string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var matches []string
+	for device := range p.devices {
+		if strings.HasPrefix(device, strings.TrimSuffix(pattern, "*")) {
+			matches = append(matches, device)
+		}
+	}
+	return matches, nil
+}
+
+func (p *fakePorts) open(device string, baud int) (io.ReadWriteCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	mavBaud, ok := p.devices[device]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	if baud != mavBaud {
+		return &silentPort{closed: make(chan struct{})}, nil
+	}
+	ours, theirs := net.Pipe()
+	p.drone = theirs
+	p.drones <- theirs
+	return ours, nil
+}
+
+// silentPort is a port receiving nothing
+type silentPort struct {
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (s *silentPort) Read([]byte) (int, error) {
+	<-s.closed
+	return 0, io.EOF
+}
+
+func (s *silentPort) Write(p []byte) (int, error) { return len(p), nil }
+
+func (s *silentPort) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+// droneNode is an autopilot sending heartbeats on the piped ports
+func droneNode(t *testing.T, ports *fakePorts) *gomavlib.Node {
+	t.Helper()
+	node, err := gomavlib.NewNode(gomavlib.NodeConf{
+		Endpoints: []gomavlib.EndpointConf{gomavlib.EndpointCustomClient{
+			Connect: func(ctx context.Context) (net.Conn, error) {
+				select {
+				case conn := <-ports.drones:
+					return conn, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			},
+		}},
+		Dialect:         ardupilotmega.Dialect,
+		OutVersion:      gomavlib.V2,
+		OutSystemID:     1,
+		HeartbeatPeriod: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewNode() error = %v", err)
+	}
+	go func() {
+		for range node.Events() {
+		}
+	}()
+	return node
+}
+
+func TestSerialLink_Candidates(t *testing.T) {
+	ports := &fakePorts{devices: map[string]int{"/dev/ttyUSB1": 0, "/dev/ttyUSB0": 0, "/dev/ttyACM0": 0}}
+	l := newSerialLink("/dev/ttyUSB*", 115200)
+	l.glob = ports.glob
+	got := l.candidates()
+	if len(got) != 2 || got[0].String() != "/dev/ttyUSB0@115200" || got[1].String() != "/dev/ttyUSB1@115200" {
+		t.Errorf("candidates() = %v", got)
+	}
+
+	// Without a port every default pattern is scanned, at every rate
+	l = newSerialLink("", 0)
+	l.glob = ports.glob
+	if got := l.candidates(); len(got) != 3*len(autoBauds) || got[0].String() != "/dev/ttyACM0@57600" {
+		t.Errorf("candidates() = %v", got)
+	}
+
+	// A plain device name is tried even when it is missing
+	l = newSerialLink("/dev/ttyS0", 57600)
+	l.glob = ports.glob
+	if got := l.candidates(); len(got) != 1 || got[0].String() != "/dev/ttyS0@57600" {
+		t.Errorf("candidates() = %v", got)
+	}
+}
+
+func TestAdapter_SerialHotPlug(t *testing.T) {
+	ports := &fakePorts{
+		devices: map[string]int{"/dev/ttyUSB0": 0, "/dev/ttyUSB1": 115200},
+		drones:  make(chan net.Conn, 4),
+	}
+	drone := droneNode(t, ports)
+	defer drone.Close()
+
+	a := New(config.MAVLinkConfig{ConnectionType: "serial", SerialPort: "/dev/ttyUSB*", Passive: true, StreamRateHz: -1})
+	a.serial.open, a.serial.glob = ports.open, ports.glob
+	a.serial.probeTimeout = 300 * time.Millisecond
+	links := make(chan *models.LinkStatus, 4)
+	a.SetLinkCallback(func(status *models.LinkStatus) { links <- status })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan *models.DroneState, 100)
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer a.Stop()
+
+	waitLink := func(connected bool, endpoint string) {
+		t.Helper()
+		select {
+		case status := <-links:
+			if status.Connected != connected || status.Endpoint != endpoint {
+				t.Fatalf("Link status = %+v, want connected %v on %s", status, connected, endpoint)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("No link status, want connected %v on %s", connected, endpoint)
+		}
+	}
+
+	// The silent port and the wrong rates are skipped
+	waitLink(true, "/dev/ttyUSB1@115200")
+	select {
+	case state := <-events:
+		if state.DeviceID != "mavlink-1" {
+			t.Errorf("State from %s, want mavlink-1", state.DeviceID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("No state from the drone")
+	}
+
+	// Unplugged, then back under another name
+	ports.unplug(map[string]int{"/dev/ttyUSB2": 115200})
+	waitLink(false, "/dev/ttyUSB1@115200")
+	waitLink(true, "/dev/ttyUSB2@115200")
+}
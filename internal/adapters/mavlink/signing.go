@@ -0,0 +1,162 @@
+package mavlink
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+)
+
+// signatureEpoch is the start of MAVLink 2 signature timestamps, which count
+// 10 µs units since then
+var signatureEpoch = time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// maxNewStreamLag is how far a new stream's first timestamp may lag the
+// local timestamp: one minute in 10 µs units
+const maxNewStreamLag = 60 * 100000
+
+var (
+	// ErrInvalidSigningKey is returned for a missing or malformed signing key
+	ErrInvalidSigningKey = errors.New("invalid mavlink signing key")
+	// ErrStaleSignature is returned for signed frames whose timestamp was
+	// already accepted or is too old, e.g. replayed packets
+	ErrStaleSignature = errors.New("stale mavlink signature timestamp")
+)
+
+// ParseSigningKey returns the secret key of a signing config: either the
+// hex key or the SHA-256 of the passphrase, as Mission Planner and
+// QGroundControl derive it
+func ParseSigningKey(cfg config.MAVLinkSigningConfig) (*frame.V2Key, error) {
+	switch {
+	case cfg.Key != "" && cfg.Passphrase != "":
+		return nil, fmt.Errorf("%w: set key or passphrase, not both", ErrInvalidSigningKey)
+	case cfg.Key != "":
+		key, err := hex.DecodeString(cfg.Key)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%w: key must be 64 hex characters", ErrInvalidSigningKey)
+		}
+		return frame.NewV2Key(key), nil
+	case cfg.Passphrase != "":
+		sum := sha256.Sum256([]byte(cfg.Passphrase))
+		return frame.NewV2Key(sum[:]), nil
+	default:
+		return nil, fmt.Errorf("%w: key or passphrase is required", ErrInvalidSigningKey)
+	}
+}
+
+// timestampStore keeps the last accepted signature timestamp of every
+// signed stream (system, component, link) and persists them, so packets
+// captured before a restart cannot be replayed after it
+type timestampStore struct {
+	path string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	streams map[string]uint64
+	dirty   bool
+}
+
+// timestampFile is the on-disk format of the timestamp store
+type timestampFile struct {
+	Streams map[string]uint64 `json:"streams"`
+}
+
+func newTimestampStore(path string) *timestampStore {
+	return &timestampStore{
+		path:    path,
+		now:     time.Now,
+		streams: make(map[string]uint64),
+	}
+}
+
+// load reads the stored timestamps. A missing file is not an error.
+func (s *timestampStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading signing timestamps: %w", err)
+	}
+
+	var f timestampFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parsing signing timestamps %s: %w", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range f.Streams {
+		s.streams[k] = v
+	}
+	return nil
+}
+
+// save writes the timestamps if they changed since the last save
+func (s *timestampStore) save() error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	data, err := json.MarshalIndent(timestampFile{Streams: s.streams}, "", "  ")
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("creating signing timestamp directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing signing timestamps: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// check accepts a signed frame if its timestamp is newer than the last one
+// accepted on its stream. The first frame of a stream may lag the local
+// timestamp by at most a minute.
+func (s *timestampStore) check(f *frame.V2Frame) error {
+	stream := fmt.Sprintf("%d/%d/%d", f.SystemID, f.ComponentID, f.SignatureLinkID)
+	ts := f.SignatureTimestamp
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.streams[stream]; ok {
+		if ts <= last {
+			return fmt.Errorf("%w: stream %s sent %d, last accepted %d", ErrStaleSignature, stream, ts, last)
+		}
+	} else if local := s.localTimestamp(); ts+maxNewStreamLag < local {
+		return fmt.Errorf("%w: new stream %s is more than a minute behind", ErrStaleSignature, stream)
+	}
+
+	s.streams[stream] = ts
+	s.dirty = true
+	return nil
+}
+
+// localTimestamp returns the current time as a signature timestamp, or the
+// newest accepted timestamp if the clock is behind it. Caller must hold
+// the lock.
+func (s *timestampStore) localTimestamp() uint64 {
+	local := uint64(s.now().Sub(signatureEpoch) / (10 * time.Microsecond))
+	for _, ts := range s.streams {
+		if ts > local {
+			local = ts
+		}
+	}
+	return local
+}
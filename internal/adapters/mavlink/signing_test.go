@@ -0,0 +1,222 @@
+package mavlink
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+const testSigningKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestParseSigningKey(t *testing.T) {
+	key, err := ParseSigningKey(config.MAVLinkSigningConfig{Key: testSigningKey})
+	if err != nil {
+		t.Fatalf("ParseSigningKey(key) error = %v", err)
+	}
+	if key[0] != 0x00 || key[31] != 0x1f {
+		t.Errorf("key = %x", key[:])
+	}
+
+	fromPass, err := ParseSigningKey(config.MAVLinkSigningConfig{Passphrase: "secret"})
+	if err != nil {
+		t.Fatalf("ParseSigningKey(passphrase) error = %v", err)
+	}
+	// SHA-256("secret")
+	if got := hex.EncodeToString(fromPass[:]); !strings.HasPrefix(got, "2bb80d53") {
+		t.Errorf("passphrase key = %s, want SHA-256 of the passphrase", got)
+	}
+
+	for _, cfg := range []config.MAVLinkSigningConfig{
+		{},
+		{Key: "abcd"},
+		{Key: strings.Repeat("zz", 32)},
+		{Key: testSigningKey, Passphrase: "secret"},
+	} {
+		if _, err := ParseSigningKey(cfg); !errors.Is(err, ErrInvalidSigningKey) {
+			t.Errorf("ParseSigningKey(%+v) error = %v, want ErrInvalidSigningKey", cfg, err)
+		}
+	}
+}
+
+// signedFrame returns a frame with the given stream and timestamp
+func signedFrame(sysID, linkID byte, ts uint64) *frame.V2Frame {
+	return &frame.V2Frame{
+		SystemID:           sysID,
+		ComponentID:        1,
+		SignatureLinkID:    linkID,
+		SignatureTimestamp: ts,
+		Signature:          &frame.V2Signature{},
+	}
+}
+
+func TestTimestampStore_Check(t *testing.T) {
+	s := newTimestampStore(filepath.Join(t.TempDir(), "ts.json"))
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	local := uint64(now.Sub(signatureEpoch) / (10 * time.Microsecond))
+
+	if err := s.check(signedFrame(1, 0, local)); err != nil {
+		t.Fatalf("first frame error = %v", err)
+	}
+	if err := s.check(signedFrame(1, 0, local+1)); err != nil {
+		t.Errorf("newer frame error = %v", err)
+	}
+
+	// Replays and older frames on the same stream are rejected
+	for _, ts := range []uint64{local + 1, local} {
+		if err := s.check(signedFrame(1, 0, ts)); !errors.Is(err, ErrStaleSignature) {
+			t.Errorf("check(%d) error = %v, want ErrStaleSignature", ts, err)
+		}
+	}
+
+	// Streams are independent, but new ones must be recent
+	if err := s.check(signedFrame(1, 1, local-maxNewStreamLag/2)); err != nil {
+		t.Errorf("new link within a minute error = %v", err)
+	}
+	if err := s.check(signedFrame(2, 0, local-2*maxNewStreamLag)); !errors.Is(err, ErrStaleSignature) {
+		t.Errorf("new stream two minutes old error = %v, want ErrStaleSignature", err)
+	}
+}
+
+func TestTimestampStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "ts.json")
+	now := time.Now()
+	ts := uint64(now.Sub(signatureEpoch) / (10 * time.Microsecond))
+
+	s := newTimestampStore(path)
+	if err := s.load(); err != nil {
+		t.Fatalf("load() of a missing file error = %v", err)
+	}
+	if err := s.check(signedFrame(1, 0, ts)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.save(); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	// After a restart the captured frame is still a replay
+	restarted := newTimestampStore(path)
+	if err := restarted.load(); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if err := restarted.check(signedFrame(1, 0, ts)); !errors.Is(err, ErrStaleSignature) {
+		t.Errorf("replay after restart error = %v, want ErrStaleSignature", err)
+	}
+	if err := restarted.check(signedFrame(1, 0, ts+1)); err != nil {
+		t.Errorf("newer frame after restart error = %v", err)
+	}
+}
+
+// freeUDPAddress returns a local UDP address that is currently unused
+func freeUDPAddress(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().String()
+}
+
+// sendPosition sends one GLOBAL_POSITION_INT from a vehicle node
+func sendPosition(t *testing.T, address string, sysID byte, key *frame.V2Key) {
+	t.Helper()
+	node, err := gomavlib.NewNode(gomavlib.NodeConf{
+		Endpoints:        []gomavlib.EndpointConf{gomavlib.EndpointUDPClient{Address: address}},
+		Dialect:          ardupilotmega.Dialect,
+		OutVersion:       gomavlib.V2,
+		OutSystemID:      sysID,
+		OutKey:           key,
+		HeartbeatDisable: true,
+	})
+	if err != nil {
+		t.Fatalf("NewNode() error = %v", err)
+	}
+	defer node.Close()
+
+	// Writes are dropped until the channel is open
+	timeout := time.After(2 * time.Second)
+	for open := false; !open; {
+		select {
+		case evt := <-node.Events():
+			_, open = evt.(*gomavlib.EventChannelOpen)
+		case <-timeout:
+			t.Fatal("vehicle channel did not open")
+		}
+	}
+
+	node.WriteMessageAll(&ardupilotmega.MessageGlobalPositionInt{Lat: 225000000, Lon: 1140000000})
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestAdapter_Signing(t *testing.T) {
+	address := freeUDPAddress(t)
+	a := New(config.MAVLinkConfig{
+		ConnectionType: "udp",
+		Address:        address,
+		Signing: config.MAVLinkSigningConfig{
+			Enabled:       true,
+			Key:           testSigningKey,
+			TimestampFile: filepath.Join(t.TempDir(), "ts.json"),
+		},
+	})
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+
+	events := make(chan *models.DroneState, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer a.Stop()
+
+	key, _ := ParseSigningKey(a.cfg.Signing)
+	wrongKey, _ := ParseSigningKey(config.MAVLinkSigningConfig{Passphrase: "wrong"})
+
+	sendPosition(t, address, 7, nil)
+	sendPosition(t, address, 8, wrongKey)
+	sendPosition(t, address, 9, key)
+
+	select {
+	case state := <-events:
+		if state.DeviceID != "mavlink-9" {
+			t.Errorf("accepted state from %s, want only the signed mavlink-9", state.DeviceID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("signed frame was not accepted")
+	}
+	select {
+	case state := <-events:
+		t.Errorf("unexpected state from %s", state.DeviceID)
+	default:
+	}
+
+	if got := len(q.List("mavlink", 0)); got < 2 {
+		t.Errorf("quarantined rejections = %d, want at least 2", got)
+	}
+}
+
+func TestAdapter_SigningInvalidKey(t *testing.T) {
+	a := New(config.MAVLinkConfig{
+		ConnectionType: "udp",
+		Address:        "127.0.0.1:0",
+		Signing:        config.MAVLinkSigningConfig{Enabled: true, Key: "short"},
+	})
+	if err := a.Start(context.Background(), make(chan *models.DroneState, 1)); !errors.Is(err, ErrInvalidSigningKey) {
+		t.Errorf("Start() error = %v, want ErrInvalidSigningKey", err)
+	}
+}
@@ -0,0 +1,51 @@
+package mavlink
+
+import (
+	"github.com/bluenviron/gomavlib/v3/pkg/frame"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// MAVLink frame overhead in bytes, without the payload
+const (
+	v1FrameOverhead = 6 + 2     // Header and checksum
+	v2FrameOverhead = 10 + 2    // Header and checksum
+	v2SignatureSize = 1 + 6 + 6 // Link ID, timestamp and signature
+)
+
+// Stats returns the frames, parse errors, open channels and bytes received
+func (a *Adapter) Stats() sdk.AdapterStats {
+	return a.stats.Stats()
+}
+
+// frameSize returns the size of a received frame on the wire. gomavlib does
+// not expose the raw bytes, so the payload is encoded again. Only called
+// from the receive loop.
+func (a *Adapter) frameSize(f frame.Frame) int {
+	_, isV2 := f.(*frame.V2Frame)
+	payload := 0
+	switch msg := f.GetMessage().(type) {
+	case *message.MessageRaw:
+		payload = len(msg.Payload)
+	default:
+		rw, ok := a.readWriters[msg.GetID()]
+		if !ok {
+			var err error
+			if rw, err = message.NewReadWriter(msg); err != nil {
+				return 0
+			}
+			a.readWriters[msg.GetID()] = rw
+		}
+		payload = len(rw.Write(msg, isV2).Payload)
+	}
+
+	if !isV2 {
+		return v1FrameOverhead + payload
+	}
+	size := v2FrameOverhead + payload
+	if f.(*frame.V2Frame).Signature != nil {
+		size += v2SignatureSize
+	}
+	return size
+}
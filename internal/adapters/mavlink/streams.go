@@ -0,0 +1,94 @@
+package mavlink
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/bluenviron/gomavlib/v3"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+	"github.com/bluenviron/gomavlib/v3/pkg/message"
+)
+
+// streamRefresh is how often stream rates are requested again, since
+// autopilots restore their own rates after a reboot
+const streamRefresh = time.Minute
+
+// streamMessageIDs are the telemetry messages whose rate is requested with
+// MAV_CMD_SET_MESSAGE_INTERVAL
+var streamMessageIDs = []uint32{
+	1,  // SYS_STATUS
+	30, // ATTITUDE
+	33, // GLOBAL_POSITION_INT
+}
+
+// dataStreams are the ArduPilot streams carrying the same messages,
+// requested with REQUEST_DATA_STREAM
+var dataStreams = []ardupilotmega.MAV_DATA_STREAM{
+	ardupilotmega.MAV_DATA_STREAM_EXTENDED_STATUS,
+	ardupilotmega.MAV_DATA_STREAM_EXTRA1,
+	ardupilotmega.MAV_DATA_STREAM_POSITION,
+}
+
+// requestStreams asks an autopilot to send its telemetry at the configured
+// stream rate, so the link does not carry messages the engine throttles
+// away. Requests are repeated every streamRefresh. Nothing is requested
+// while forwarding to a GCS, which then manages the rates itself.
+func (a *Adapter) requestStreams(evt *gomavlib.EventFrame) {
+	hb, ok := evt.Frame.GetMessage().(*ardupilotmega.MessageHeartbeat)
+	if !ok || hb.Autopilot == ardupilotmega.MAV_AUTOPILOT_INVALID || a.cfg.StreamRateHz <= 0 ||
+		a.cfg.Passive || len(a.cfg.Forward) > 0 || a.node == nil {
+		return
+	}
+	sysID, compID := evt.Frame.GetSystemID(), evt.Frame.GetComponentID()
+
+	a.mu.Lock()
+	due := time.Since(a.streams[sysID]) >= streamRefresh
+	if due {
+		a.streams[sysID] = time.Now()
+	}
+	a.mu.Unlock()
+	if !due {
+		return
+	}
+
+	for _, msg := range streamRequests(sysID, compID, hb.Autopilot, a.cfg.StreamRateHz) {
+		if err := a.node.WriteMessageTo(evt.Channel, msg); err != nil {
+			log.Printf("[MAVLink] Failed to request stream rates from system %d: %v", sysID, err)
+			return
+		}
+	}
+}
+
+// streamRequests returns the messages requesting telemetry at hz from an
+// autopilot: REQUEST_DATA_STREAM for ArduPilot, whose streams take whole Hz,
+// and MAV_CMD_SET_MESSAGE_INTERVAL for PX4 and the others
+func streamRequests(sysID, compID uint8, autopilot ardupilotmega.MAV_AUTOPILOT, hz float64) []message.Message {
+	var msgs []message.Message
+	if autopilot == ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA {
+		rate := uint16(max(1, math.Ceil(hz)))
+		for _, stream := range dataStreams {
+			msgs = append(msgs, &ardupilotmega.MessageRequestDataStream{
+				TargetSystem:    sysID,
+				TargetComponent: compID,
+				ReqStreamId:     uint8(stream),
+				ReqMessageRate:  rate,
+				StartStop:       1,
+			})
+		}
+		return msgs
+	}
+
+	interval := float32(1e6 / hz) // Microseconds
+	for _, id := range streamMessageIDs {
+		msgs = append(msgs, &ardupilotmega.MessageCommandLong{
+			TargetSystem:    sysID,
+			TargetComponent: compID,
+			Command:         common.MAV_CMD_SET_MESSAGE_INTERVAL,
+			Param1:          float32(id),
+			Param2:          interval,
+		})
+	}
+	return msgs
+}
@@ -0,0 +1,38 @@
+package mavlink
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/ardupilotmega"
+	"github.com/bluenviron/gomavlib/v3/pkg/dialects/common"
+)
+
+func TestStreamRequests(t *testing.T) {
+	// ArduPilot streams take whole Hz, rounded up
+	msgs := streamRequests(1, 1, ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA, 2.5)
+	if len(msgs) != len(dataStreams) {
+		t.Fatalf("ArduPilot: %d requests, want %d", len(msgs), len(dataStreams))
+	}
+	for i, msg := range msgs {
+		req, ok := msg.(*ardupilotmega.MessageRequestDataStream)
+		if !ok || req.TargetSystem != 1 || req.ReqStreamId != uint8(dataStreams[i]) || req.ReqMessageRate != 3 || req.StartStop != 1 {
+			t.Errorf("ArduPilot request %d = %+v", i, msg)
+		}
+	}
+	if req := streamRequests(1, 1, ardupilotmega.MAV_AUTOPILOT_ARDUPILOTMEGA, 0.5)[0].(*ardupilotmega.MessageRequestDataStream); req.ReqMessageRate != 1 {
+		t.Errorf("Rate below 1 Hz = %d, want 1", req.ReqMessageRate)
+	}
+
+	// PX4 and other autopilots get message intervals
+	msgs = streamRequests(2, 1, ardupilotmega.MAV_AUTOPILOT_PX4, 4)
+	if len(msgs) != len(streamMessageIDs) {
+		t.Fatalf("PX4: %d requests, want %d", len(msgs), len(streamMessageIDs))
+	}
+	for i, msg := range msgs {
+		cmd, ok := msg.(*ardupilotmega.MessageCommandLong)
+		if !ok || cmd.TargetSystem != 2 || cmd.Command != common.MAV_CMD_SET_MESSAGE_INTERVAL ||
+			cmd.Param1 != float32(streamMessageIDs[i]) || cmd.Param2 != 250000 {
+			t.Errorf("PX4 request %d = %+v", i, msg)
+		}
+	}
+}
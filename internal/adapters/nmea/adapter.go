@@ -0,0 +1,270 @@
+// Package nmea implements an adapter for NMEA 0183 GNSS receivers. It reads
+// GGA, RMC and VTG sentences from a serial port or a TCP connection, so
+// ground vehicles and balloon payloads carrying a plain GPS tracker can be
+// followed through the same pipeline as drones.
+package nmea
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/adapterstats"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// dialTimeout limits connecting to a TCP receiver
+const dialTimeout = 10 * time.Second
+
+// maxSentence is the longest line accepted. NMEA allows 82 characters,
+// longer lines are noise, e.g. from a wrong baud rate.
+const maxSentence = 1024
+
+// Adapter implements the core.Adapter interface for one NMEA receiver.
+// NMEA carries no device identity, so every fix is reported as the
+// configured device ID.
+type Adapter struct {
+	cfg        config.NMEAConfig
+	open       func(ctx context.Context) (io.ReadCloser, error)
+	quarantine *quarantine.Store
+	stats      *adapterstats.Counter
+	now        func() time.Time
+
+	mu   sync.Mutex
+	conn io.ReadCloser // Open port or connection, nil while reconnecting
+	fix  fix
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a new NMEA adapter
+func New(cfg config.NMEAConfig) *Adapter {
+	a := &Adapter{
+		cfg:   cfg,
+		stats: adapterstats.New(),
+		now:   time.Now,
+	}
+	a.open = a.openSource
+	return a
+}
+
+// Name returns the adapter name
+func (a *Adapter) Name() string {
+	return "nmea"
+}
+
+// SetQuarantine sets the store for sentences that fail to parse
+func (a *Adapter) SetQuarantine(q *quarantine.Store) {
+	a.quarantine = q
+}
+
+// Start opens the receiver in the background. A missing port or an
+// unreachable receiver is retried every reconnect_sec.
+func (a *Adapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	if a.cfg.ConnectionType != "serial" && a.cfg.ConnectionType != "tcp" {
+		return fmt.Errorf("unknown connection type: %s", a.cfg.ConnectionType)
+	}
+
+	ctx, a.cancel = context.WithCancel(ctx)
+	log.Printf("[NMEA] Reading %s (device: %s)", a.source(), a.cfg.DeviceID)
+
+	a.wg.Add(1)
+	go a.readLoop(ctx, events)
+	return nil
+}
+
+// Stop closes the receiver and waits for the read loop to exit
+func (a *Adapter) Stop() error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.mu.Lock()
+	if a.conn != nil {
+		a.conn.Close()
+	}
+	a.mu.Unlock()
+
+	a.wg.Wait()
+	log.Printf("[NMEA] Adapter stopped")
+	return nil
+}
+
+// Stats returns the sentences, parse errors and bytes received. The
+// receiver counts as the only peer while it is open.
+func (a *Adapter) Stats() sdk.AdapterStats {
+	return a.stats.Stats()
+}
+
+// source describes the configured port or address for logs
+func (a *Adapter) source() string {
+	if a.cfg.ConnectionType == "tcp" {
+		return "tcp://" + a.cfg.Address
+	}
+	return fmt.Sprintf("%s at %d baud", a.cfg.SerialPort, a.cfg.SerialBaud)
+}
+
+// openSource opens the configured serial port or TCP connection
+func (a *Adapter) openSource(ctx context.Context) (io.ReadCloser, error) {
+	if a.cfg.ConnectionType == "tcp" {
+		return (&net.Dialer{Timeout: dialTimeout}).DialContext(ctx, "tcp", a.cfg.Address)
+	}
+	return serial.Open(a.cfg.SerialPort, &serial.Mode{BaudRate: a.cfg.SerialBaud})
+}
+
+// readLoop reads sentences until the context is cancelled, reopening the
+// receiver whenever it fails
+func (a *Adapter) readLoop(ctx context.Context, events chan<- *models.DroneState) {
+	defer a.wg.Done()
+
+	retry := time.Duration(a.cfg.ReconnectSec) * time.Second
+	for ctx.Err() == nil {
+		conn, err := a.open(ctx)
+		if err != nil {
+			log.Printf("[NMEA] Cannot open %s: %v (retrying in %s)", a.source(), err, retry)
+		} else {
+			err = a.readSentences(ctx, conn, events)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[NMEA] Lost %s: %v (reopening in %s)", a.source(), err, retry)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// readSentences emits the fixes read from an open receiver until it
+// fails. Returns the read error, io.EOF if the receiver closed.
+func (a *Adapter) readSentences(ctx context.Context, conn io.ReadCloser, events chan<- *models.DroneState) error {
+	a.mu.Lock()
+	a.conn = conn
+	a.mu.Unlock()
+	a.stats.PeerConnected()
+	defer func() {
+		a.mu.Lock()
+		a.conn = nil
+		a.mu.Unlock()
+		conn.Close()
+		a.stats.PeerDisconnected()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, maxSentence), maxSentence)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		a.stats.Received(len(line))
+
+		state, err := a.handleSentence(line)
+		if err != nil {
+			log.Printf("[NMEA] Failed to parse sentence %q: %v", line, err)
+			a.stats.ParseError()
+			if a.quarantine != nil && strings.HasPrefix(line, "$") {
+				a.quarantine.Add(a.Name(), a.cfg.DeviceID, a.source(), []byte(line), err)
+			}
+			continue
+		}
+		if state == nil {
+			continue
+		}
+
+		// Blocks while the engine applies back-pressure
+		select {
+		case events <- state:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// handleSentence applies a sentence to the receiver's fix. Returns the
+// state to emit after position sentences, nil after others.
+func (a *Adapter) handleSentence(line string) (*models.DroneState, error) {
+	s, err := parseSentence(line)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	position, err := a.fix.update(s)
+	if err != nil || !position {
+		return nil, err
+	}
+	return a.state(a.fix), nil
+}
+
+// state converts a fix to a DroneState stamped with the receive time. Only
+// the position, and the heading if the course is known, count as updated.
+func (a *Adapter) state(f fix) *models.DroneState {
+	state := models.NewDroneState(a.cfg.DeviceID, a.Name())
+	state.Timestamp = a.now().UnixMilli()
+	state.Location.Lat = f.lat
+	state.Location.Lon = f.lon
+	state.Location.AltGNSS = f.alt
+	state.Freshness.PositionAt = state.Timestamp
+	if f.hasCourse {
+		rad := f.course * math.Pi / 180
+		state.Velocity.Vx = f.speed * math.Cos(rad)
+		state.Velocity.Vy = f.speed * math.Sin(rad)
+		state.Attitude.Yaw = f.course
+		state.Freshness.AttitudeAt = state.Timestamp
+	}
+	return state
+}
+
+// Replay re-parses a quarantined sentence on its own. Only position
+// sentences can be replayed, without the motion of earlier sentences.
+func (a *Adapter) Replay(entry quarantine.Entry) (*models.DroneState, error) {
+	s, err := parseSentence(string(entry.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("parsing sentence: %w", err)
+	}
+	var f fix
+	position, err := f.update(s)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sentence: %w", err)
+	}
+	if !position {
+		return nil, errors.New("sentence carries no position")
+	}
+	return a.state(f), nil
+}
+
+// SelfTest checks that the receiver is open and parses a synthetic GGA
+// sentence without emitting it or changing the fix
+func (a *Adapter) SelfTest() (*models.DroneState, error) {
+	a.mu.Lock()
+	open := a.conn != nil
+	a.mu.Unlock()
+	if !open {
+		return nil, fmt.Errorf("%s not open", a.source())
+	}
+	return a.Replay(quarantine.Entry{
+		Payload: []byte("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47"),
+	})
+}
@@ -0,0 +1,116 @@
+package nmea
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAdapter_Name(t *testing.T) {
+	if name := New(config.NMEAConfig{}).Name(); name != "nmea" {
+		t.Errorf("Name() = %s, want 'nmea'", name)
+	}
+}
+
+func TestAdapter_Stop_NotStarted(t *testing.T) {
+	if err := New(config.NMEAConfig{}).Stop(); err != nil {
+		t.Errorf("Stop should not error before Start: %v", err)
+	}
+}
+
+func TestAdapter_StartUnknownType(t *testing.T) {
+	if err := New(config.NMEAConfig{ConnectionType: "usb"}).Start(context.Background(), nil); err == nil {
+		t.Error("Start() with an unknown connection type should fail")
+	}
+}
+
+func TestAdapter_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	a := New(config.NMEAConfig{ConnectionType: "tcp", Address: l.Addr().String(), DeviceID: "rover-1", ReconnectSec: 1})
+	a.now = func() time.Time { return time.UnixMilli(1700000000000) }
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+	events := make(chan *models.DroneState, 10)
+	if err := a.Start(context.Background(), events); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer a.Stop()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48\r\n" +
+		"$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74\r\n" +
+		"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48\r\n" + // Corrupted
+		"\x8f\x02noise\r\n" +
+		"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47\r\n"))
+
+	var state *models.DroneState
+	select {
+	case state = <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("No state received")
+	}
+	if state.DeviceID != "rover-1" || state.ProtocolSource != "nmea" || state.Timestamp != 1700000000000 {
+		t.Errorf("State = %s %s %d", state.DeviceID, state.ProtocolSource, state.Timestamp)
+	}
+	if state.Location.AltGNSS != 545.4 || state.Attitude.Yaw != 54.7 || state.Velocity.Vx <= 0 || state.Velocity.Vy <= 0 {
+		t.Errorf("State = %+v %+v %+v", state.Location, state.Attitude, state.Velocity)
+	}
+	if state.Freshness.PositionAt == 0 || state.Freshness.BatteryAt != 0 {
+		t.Errorf("Freshness = %+v, want only position and attitude", state.Freshness)
+	}
+
+	if _, err := a.SelfTest(); err != nil {
+		t.Errorf("SelfTest() error = %v", err)
+	}
+	entries := q.List("", 0)
+	if len(entries) != 1 || entries[0].DeviceID != "rover-1" {
+		t.Fatalf("Quarantined %+v, want only the corrupted sentence", entries)
+	}
+	if _, err := a.Replay(entries[0]); err == nil {
+		t.Error("Replay() of the corrupted sentence should fail")
+	}
+	if stats := a.Stats(); stats.MessagesReceived != 5 || stats.ParseErrors != 2 || stats.ConnectedPeers != 1 {
+		t.Errorf("Stats = %+v, want 5 lines, 2 parse errors and the receiver", stats)
+	}
+
+	// The adapter reconnects after the receiver drops the connection
+	conn.Close()
+	conn, err = l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("$GPRMC,123520,A,4807.038,S,01131.000,W,022.4,084.4,230394,003.1,W*6F\r\n"))
+	select {
+	case state = <-events:
+		if state.Location.Lat >= 0 {
+			t.Errorf("Lat = %f after reconnect, want the new fix", state.Location.Lat)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No state received after reconnecting")
+	}
+}
+
+func TestAdapter_Replay(t *testing.T) {
+	a := New(config.NMEAConfig{DeviceID: "balloon"})
+	state, err := a.Replay(quarantine.Entry{Payload: []byte("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,")})
+	if err != nil || state.DeviceID != "balloon" || state.Location.Lat == 0 {
+		t.Errorf("Replay() = %+v, %v", state, err)
+	}
+	if _, err := a.Replay(quarantine.Entry{Payload: []byte("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48")}); err == nil {
+		t.Error("Replay() of a sentence without position should fail")
+	}
+}
@@ -0,0 +1,185 @@
+package nmea
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// knotsToMs converts knots to meters per second
+const knotsToMs = 1852.0 / 3600.0
+
+// errChecksum is returned for sentences whose checksum does not match
+var errChecksum = errors.New("checksum mismatch")
+
+// sentence is one parsed NMEA 0183 sentence, e.g. "$GNGGA,..." has talker
+// GN and kind GGA
+type sentence struct {
+	talker string
+	kind   string
+	fields []string // Data fields after the address field
+}
+
+// parseSentence splits a sentence and verifies its checksum. The checksum
+// is optional, as some simple trackers omit it, but must match if present.
+func parseSentence(line string) (sentence, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "$") {
+		return sentence{}, fmt.Errorf("sentence does not start with $")
+	}
+	body := line[1:]
+	if data, sum, ok := strings.Cut(body, "*"); ok {
+		want, err := strconv.ParseUint(sum, 16, 8)
+		if err != nil || len(sum) != 2 {
+			return sentence{}, fmt.Errorf("invalid checksum %q", sum)
+		}
+		var got byte
+		for i := 0; i < len(data); i++ {
+			got ^= data[i]
+		}
+		if got != byte(want) {
+			return sentence{}, fmt.Errorf("%w: got %02X, want %02X", errChecksum, got, want)
+		}
+		body = data
+	}
+
+	fields := strings.Split(body, ",")
+	address := fields[0]
+	if len(address) != 5 || address[0] == 'P' {
+		// Proprietary sentences ($P...) carry vendor formats
+		return sentence{talker: address, fields: fields[1:]}, nil
+	}
+	return sentence{talker: address[:2], kind: address[2:], fields: fields[1:]}, nil
+}
+
+// field returns the i-th data field, or "" if the sentence is shorter
+func (s sentence) field(i int) string {
+	if i < len(s.fields) {
+		return s.fields[i]
+	}
+	return ""
+}
+
+// float parses the i-th data field. Empty fields are not an error, they
+// mean the receiver has no value.
+func (s sentence) float(i int) (float64, bool, error) {
+	f := s.field(i)
+	if f == "" {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseFloat(f, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s field %d: invalid number %q", s.kind, i+1, f)
+	}
+	return v, true, nil
+}
+
+// coordinate parses a ddmm.mmmm (latitude) or dddmm.mmmm (longitude) field
+// and its hemisphere into degrees
+func (s sentence) coordinate(i int) (float64, bool, error) {
+	v, ok, err := s.float(i)
+	if !ok || err != nil {
+		return 0, false, err
+	}
+	deg := math.Floor(v / 100)
+	deg += (v - deg*100) / 60
+	switch s.field(i + 1) {
+	case "N", "E":
+	case "S", "W":
+		deg = -deg
+	default:
+		return 0, false, fmt.Errorf("%s field %d: invalid hemisphere %q", s.kind, i+2, s.field(i+1))
+	}
+	return deg, true, nil
+}
+
+// fix is the latest position and motion of a receiver, assembled from its
+// GGA, RMC and VTG sentences
+type fix struct {
+	lat, lon    float64
+	alt         float64 // Above mean sea level in meters
+	hasPosition bool
+	speed       float64 // Ground speed in m/s
+	course      float64 // Course over ground in degrees from true north
+	hasCourse   bool
+}
+
+// update applies a sentence to the fix. Returns true if the sentence
+// carried a valid position, which is when a state is emitted. Other
+// sentence types are ignored.
+func (f *fix) update(s sentence) (bool, error) {
+	switch s.kind {
+	case "GGA":
+		quality, _, err := s.float(5)
+		if err != nil || quality == 0 {
+			return false, err // No fix yet
+		}
+		if err := f.position(s, 1); err != nil {
+			return false, err
+		}
+		if alt, ok, err := s.float(8); err != nil {
+			return false, err
+		} else if ok {
+			f.alt = alt
+		}
+		return f.hasPosition, nil
+	case "RMC":
+		if s.field(1) != "A" {
+			return false, nil // Void: the receiver has no fix
+		}
+		if err := f.position(s, 2); err != nil {
+			return false, err
+		}
+		if err := f.motion(s, 6, 7, knotsToMs); err != nil {
+			return false, err
+		}
+		return f.hasPosition, nil
+	case "VTG":
+		if s.field(8) == "N" {
+			return false, nil // Mode indicator: data not valid
+		}
+		return false, f.motion(s, 6, 0, 1/3.6)
+	}
+	return false, nil
+}
+
+// position reads latitude and longitude starting at field i
+func (f *fix) position(s sentence, i int) error {
+	lat, latOK, err := s.coordinate(i)
+	if err != nil {
+		return err
+	}
+	lon, lonOK, err := s.coordinate(i + 2)
+	if err != nil {
+		return err
+	}
+	if latOK && lonOK {
+		f.lat, f.lon, f.hasPosition = lat, lon, true
+	}
+	return nil
+}
+
+// motion reads the speed field, converted to m/s with scale, and the
+// course field
+func (f *fix) motion(s sentence, speedField, courseField int, scale float64) error {
+	speed, ok, err := s.float(speedField)
+	if err != nil {
+		return err
+	}
+	if ok {
+		f.speed = speed * scale
+	}
+	course, ok, err := s.float(courseField)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if course < 0 || course >= 360 {
+			course = math.Mod(math.Mod(course, 360)+360, 360)
+		}
+		f.course, f.hasCourse = course, true
+	}
+	return nil
+}
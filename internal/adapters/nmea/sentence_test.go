@@ -0,0 +1,69 @@
+package nmea
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseSentence(t *testing.T) {
+	s, err := parseSentence("$GNRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*74\r\n")
+	if err != nil {
+		t.Fatalf("parseSentence() error = %v", err)
+	}
+	if s.talker != "GN" || s.kind != "RMC" || s.field(1) != "A" || s.field(20) != "" {
+		t.Errorf("Sentence = %+v", s)
+	}
+
+	if _, err := parseSentence("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,"); err != nil {
+		t.Errorf("Sentence without checksum: %v", err)
+	}
+	if _, err := parseSentence("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48"); !errors.Is(err, errChecksum) {
+		t.Errorf("Wrong checksum: error = %v, want errChecksum", err)
+	}
+	if _, err := parseSentence("GPGGA,123519"); err == nil {
+		t.Error("Line without $ should fail")
+	}
+	if s, err := parseSentence("$PUBX,00,081350.00"); err != nil || s.kind != "" {
+		t.Errorf("Proprietary sentence = %+v, %v, want ignored", s, err)
+	}
+}
+
+func TestFix_update(t *testing.T) {
+	var f fix
+	for _, tc := range []struct {
+		line     string
+		position bool
+	}{
+		{"$GPGGA,123518,,,,,0,00,,,M,,M,,*6A", false}, // No fix yet
+		{"$GPRMC,123518,V,,,,,,,230394,,*32", false},
+		{"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", true},
+		{"$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48", false},
+	} {
+		s, err := parseSentence(tc.line)
+		if err != nil {
+			t.Fatalf("parseSentence(%s) error = %v", tc.line, err)
+		}
+		if position, err := f.update(s); err != nil || position != tc.position {
+			t.Errorf("update(%s) = %v, %v, want %v", tc.line, position, err, tc.position)
+		}
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+	if !near(f.lat, 48.1173) || !near(f.lon, 11.516666667) || f.alt != 545.4 {
+		t.Errorf("Position = %f, %f, %f", f.lat, f.lon, f.alt)
+	}
+	if !f.hasCourse || f.course != 54.7 || !near(f.speed, 10.2/3.6) {
+		t.Errorf("Motion = %f m/s at %f", f.speed, f.course)
+	}
+
+	s, _ := parseSentence("$GPRMC,123520,A,4807.038,S,01131.000,W,022.4,084.4,230394,003.1,W*6F")
+	if position, _ := f.update(s); !position || f.lat > 0 || f.lon > 0 || !near(f.speed, 22.4*knotsToMs) {
+		t.Errorf("RMC fix = %+v, want southern and western hemisphere", f)
+	}
+
+	s, _ = parseSentence("$GPGGA,123521,48x7.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,")
+	if _, err := f.update(s); err == nil {
+		t.Error("update() with an invalid latitude should fail")
+	}
+}
@@ -0,0 +1,349 @@
+// Package poll implements a generic HTTP polling adapter. It fetches JSON
+// from a tracking API such as OpenSky or FlightAware at a fixed interval and
+// maps each aircraft onto a DroneState using configured paths, so such
+// services can be integrated without writing a dedicated adapter.
+package poll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/adapterstats"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// namePrefix is prepended to the configured name in status listings
+const namePrefix = "poll:"
+
+// maxResponseSize limits the size of a polled response
+const maxResponseSize = 32 << 20
+
+// setters holds the mapping fields copied directly onto a state
+var setters = map[string]func(s *models.DroneState, v float64){
+	"lat":             func(s *models.DroneState, v float64) { s.Location.Lat = v },
+	"lon":             func(s *models.DroneState, v float64) { s.Location.Lon = v },
+	"alt_baro":        func(s *models.DroneState, v float64) { s.Location.AltBaro = v },
+	"alt_gnss":        func(s *models.DroneState, v float64) { s.Location.AltGNSS = v },
+	"roll":            func(s *models.DroneState, v float64) { s.Attitude.Roll = v },
+	"pitch":           func(s *models.DroneState, v float64) { s.Attitude.Pitch = v },
+	"yaw":             func(s *models.DroneState, v float64) { s.Attitude.Yaw = v },
+	"vx":              func(s *models.DroneState, v float64) { s.Velocity.Vx = v },
+	"vy":              func(s *models.DroneState, v float64) { s.Velocity.Vy = v },
+	"vz":              func(s *models.DroneState, v float64) { s.Velocity.Vz = v },
+	"climb_rate":      func(s *models.DroneState, v float64) { s.Velocity.Vz = -v },
+	"battery_percent": func(s *models.DroneState, v float64) { s.Status.BatteryPercent = int(math.Round(v)) },
+	"signal_quality":  func(s *models.DroneState, v float64) { s.Status.SignalQuality = int(math.Round(v)) },
+	"armed":           func(s *models.DroneState, v float64) { s.Status.Armed = v != 0 },
+}
+
+// Fields returns the DroneState fields a mapping may set. Besides the
+// direct fields, device_id and timestamp identify the sample, and speed
+// (ground speed, m/s) with track (course, degrees) set vx and vy, and yaw
+// when it is not mapped.
+func Fields() []string {
+	fields := []string{"device_id", "timestamp", "speed", "track"}
+	for f := range setters {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Adapter implements the core.Adapter interface for one polled API
+type Adapter struct {
+	cfg        config.PollConfig
+	items      path
+	fields     map[string]path
+	interval   time.Duration
+	client     *http.Client
+	quarantine *quarantine.Store
+	stats      *adapterstats.Counter
+
+	mu       sync.Mutex
+	lastSeen map[string]int64 // Timestamp per device in the previous response
+	failing  bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a polling adapter, validating its mapping
+func New(cfg config.PollConfig) (*Adapter, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, errors.New("url must be an http or https URL")
+	}
+	if cfg.TimestampUnit != "" && cfg.TimestampUnit != "s" && cfg.TimestampUnit != "ms" {
+		return nil, fmt.Errorf("unknown timestamp_unit %q", cfg.TimestampUnit)
+	}
+	for _, f := range []string{"device_id", "lat", "lon"} {
+		if cfg.Mapping[f] == "" {
+			return nil, fmt.Errorf("mapping.%s is required", f)
+		}
+	}
+
+	items, err := parsePath(cfg.Items)
+	if err != nil {
+		return nil, fmt.Errorf("items: %w", err)
+	}
+	known := Fields()
+	fields := make(map[string]path, len(cfg.Mapping))
+	for f, expr := range cfg.Mapping {
+		if i := sort.SearchStrings(known, f); i == len(known) || known[i] != f {
+			return nil, fmt.Errorf("mapping: unknown field %q", f)
+		}
+		if fields[f], err = parsePath(expr); err != nil {
+			return nil, fmt.Errorf("mapping.%s: %w", f, err)
+		}
+	}
+	for f := range cfg.Scale {
+		if _, ok := fields[f]; !ok {
+			return nil, fmt.Errorf("scale: field %q is not mapped", f)
+		}
+	}
+
+	interval := time.Duration(cfg.IntervalSec) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &Adapter{
+		cfg:      cfg,
+		items:    items,
+		fields:   fields,
+		interval: interval,
+		client:   &http.Client{Timeout: max(interval, 5*time.Second)},
+		lastSeen: make(map[string]int64),
+		stats:    adapterstats.New(),
+	}, nil
+}
+
+// Name returns the adapter name
+func (a *Adapter) Name() string {
+	return namePrefix + a.cfg.Name
+}
+
+// Stats returns the responses, undecodable responses and bytes received
+func (a *Adapter) Stats() sdk.AdapterStats {
+	return a.stats.Stats()
+}
+
+// SetQuarantine stores responses that cannot be decoded in q
+func (a *Adapter) SetQuarantine(q *quarantine.Store) {
+	a.quarantine = q
+}
+
+// Start begins polling
+func (a *Adapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	ctx, cancel := context.WithCancel(ctx)
+	a.cancel = cancel
+
+	a.wg.Add(1)
+	go a.run(ctx, events)
+
+	log.Printf("[Poll] %s: polling %s every %s", a.cfg.Name, a.cfg.URL, a.interval)
+	return nil
+}
+
+// Stop stops polling
+func (a *Adapter) Stop() error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+	log.Printf("[Poll] %s: stopped", a.cfg.Name)
+	return nil
+}
+
+// run polls immediately and then on every tick
+func (a *Adapter) run(ctx context.Context, events chan<- *models.DroneState) {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		a.poll(ctx, events)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the API once and emits the states that changed. Failures
+// are logged once until a poll succeeds again.
+func (a *Adapter) poll(ctx context.Context, events chan<- *models.DroneState) {
+	states, err := a.fetch(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	a.mu.Lock()
+	wasFailing := a.failing
+	a.failing = err != nil
+	a.mu.Unlock()
+	switch {
+	case err != nil && !wasFailing:
+		log.Printf("[Poll] %s: %v", a.cfg.Name, err)
+	case err == nil && wasFailing:
+		log.Printf("[Poll] %s: polling recovered", a.cfg.Name)
+	}
+
+	for _, state := range states {
+		select {
+		case events <- state:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fetch requests the API and maps the response onto states, skipping
+// aircraft whose timestamp has not changed since the previous poll
+func (a *Adapter) fetch(ctx context.Context) ([]*models.DroneState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range a.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	a.stats.Received(len(body))
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		err = fmt.Errorf("decode response: %w", err)
+		a.stats.ParseError()
+		if a.quarantine != nil {
+			a.quarantine.Add(a.Name(), "", a.cfg.URL, body, err)
+		}
+		return nil, err
+	}
+
+	var items []any
+	switch v := a.items.lookup(doc).(type) {
+	case []any:
+		items = v
+	case map[string]any:
+		items = []any{v}
+	case nil:
+		// No aircraft, e.g. OpenSky returns "states": null
+	default:
+		a.stats.ParseError()
+		return nil, fmt.Errorf("items is neither an array nor an object")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now().UnixMilli()
+	seen := make(map[string]int64, len(items))
+	var states []*models.DroneState
+	for _, item := range items {
+		state := a.mapItem(item, now)
+		if state == nil {
+			continue
+		}
+		seen[state.DeviceID] = state.Timestamp
+		if last, ok := a.lastSeen[state.DeviceID]; ok && last == state.Timestamp {
+			continue
+		}
+		states = append(states, state)
+	}
+	// Aircraft no longer in the response are forgotten
+	a.lastSeen = seen
+	return states, nil
+}
+
+// mapItem maps one aircraft onto a state. Returns nil if the device ID or
+// position is missing.
+func (a *Adapter) mapItem(item any, now int64) *models.DroneState {
+	id := text(a.fields["device_id"].lookup(item))
+	if id == "" {
+		return nil
+	}
+
+	values := make(map[string]float64, len(a.fields))
+	for f, p := range a.fields {
+		if f == "device_id" {
+			continue
+		}
+		if v, ok := number(p.lookup(item)); ok {
+			if scale, ok := a.cfg.Scale[f]; ok {
+				v *= scale
+			}
+			values[f] = v
+		}
+	}
+	if _, ok := values["lat"]; !ok {
+		return nil
+	}
+	if _, ok := values["lon"]; !ok {
+		return nil
+	}
+
+	state := models.NewDroneState(a.cfg.DevicePrefix+id, a.cfg.Name)
+	state.Timestamp = now
+	if ts, ok := values["timestamp"]; ok {
+		if a.cfg.TimestampUnit == "s" {
+			ts *= 1000
+		}
+		state.Timestamp = int64(ts)
+	}
+	for f, v := range values {
+		if set, ok := setters[f]; ok {
+			set(state, v)
+		}
+	}
+	if track, ok := values["track"]; ok {
+		if _, ok := values["yaw"]; !ok {
+			state.Attitude.Yaw = math.Mod(track+360, 360)
+		}
+		if speed, ok := values["speed"]; ok {
+			rad := track * math.Pi / 180
+			state.Velocity.Vx = speed * math.Cos(rad)
+			state.Velocity.Vy = speed * math.Sin(rad)
+		}
+	}
+
+	// Only parts present in the response count as updated
+	state.Freshness.PositionAt = state.Timestamp
+	for _, f := range []string{"roll", "pitch", "yaw", "track"} {
+		if _, ok := values[f]; ok {
+			state.Freshness.AttitudeAt = state.Timestamp
+		}
+	}
+	if _, ok := values["battery_percent"]; ok {
+		state.Freshness.BatteryAt = state.Timestamp
+	}
+	return state
+}
@@ -0,0 +1,194 @@
+package poll
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// openSky is a response in the OpenSky /states/all format
+const openSky = `{"time":1700000000,"states":[
+	["3c6444","DLH9LF  ","Germany",1700000000,1700000000,6.1,50.1,1000.5,false,70.0,90.0,-2.5,null,1100.2,"1000",false,0],
+	["4b1814","","Switzerland",null,1699999990,null,null,null,true,0,0,null,null,null,null,false,0]
+]}`
+
+// openSkyConfig maps the OpenSky state vector indices
+func openSkyConfig(url string) config.PollConfig {
+	return config.PollConfig{
+		Name:          "opensky",
+		URL:           url,
+		Items:         "states",
+		DevicePrefix:  "icao-",
+		TimestampUnit: "s",
+		Mapping: map[string]string{
+			"device_id":  "[0]",
+			"timestamp":  "[3]",
+			"lon":        "[5]",
+			"lat":        "[6]",
+			"alt_baro":   "[7]",
+			"speed":      "[9]",
+			"track":      "[10]",
+			"climb_rate": "[11]",
+			"alt_gnss":   "[13]",
+		},
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	doc := map[string]any{"ac": []any{map[string]any{"pos": map[string]any{"lat": 1.5}}}}
+	for expr, want := range map[string]any{
+		"ac[0].pos.lat":   1.5,
+		"$.ac[0].pos.lat": 1.5,
+		"ac[1].pos.lat":   nil,
+		"ac.pos":          nil,
+		"missing":         nil,
+	} {
+		p, err := parsePath(expr)
+		if err != nil {
+			t.Fatalf("parsePath(%q): %v", expr, err)
+		}
+		if got := p.lookup(doc); got != want {
+			t.Errorf("lookup(%q) = %v, want %v", expr, got, want)
+		}
+	}
+	for _, expr := range []string{"ac[", "ac[x]", "ac[-1]", "ac..pos"} {
+		if _, err := parsePath(expr); err == nil {
+			t.Errorf("parsePath(%q) should fail", expr)
+		}
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	valid := openSkyConfig("http://localhost")
+	if _, err := New(valid); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for name, mutate := range map[string]func(c *config.PollConfig){
+		"no name":        func(c *config.PollConfig) { c.Name = "" },
+		"bad url":        func(c *config.PollConfig) { c.URL = "localhost" },
+		"bad unit":       func(c *config.PollConfig) { c.TimestampUnit = "us" },
+		"no device_id":   func(c *config.PollConfig) { delete(c.Mapping, "device_id") },
+		"unknown field":  func(c *config.PollConfig) { c.Mapping["heading"] = "[10]" },
+		"bad path":       func(c *config.PollConfig) { c.Mapping["lat"] = "[6" },
+		"unmapped scale": func(c *config.PollConfig) { c.Scale = map[string]float64{"vx": 2} },
+	} {
+		c := openSkyConfig("http://localhost")
+		mutate(&c)
+		if _, err := New(c); err == nil {
+			t.Errorf("%s: New should fail", name)
+		}
+	}
+}
+
+func TestAdapter_PollOpenSky(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(openSky))
+	}))
+	defer srv.Close()
+
+	cfg := openSkyConfig(srv.URL)
+	cfg.Headers = map[string]string{"Authorization": "Basic abc"}
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if a.Name() != "poll:opensky" {
+		t.Errorf("Name = %s", a.Name())
+	}
+
+	events := make(chan *models.DroneState, 10)
+	a.poll(context.Background(), events)
+	if auth != "Basic abc" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	// The second aircraft has no position
+	if len(events) != 1 {
+		t.Fatalf("Got %d states, want 1", len(events))
+	}
+	s := <-events
+	if s.DeviceID != "icao-3c6444" || s.ProtocolSource != "opensky" || s.Timestamp != 1700000000000 {
+		t.Errorf("State = %s/%s at %d", s.DeviceID, s.ProtocolSource, s.Timestamp)
+	}
+	if s.Location.Lat != 50.1 || s.Location.Lon != 6.1 || s.Location.AltBaro != 1000.5 || s.Location.AltGNSS != 1100.2 {
+		t.Errorf("Location = %+v", s.Location)
+	}
+	if s.Attitude.Yaw != 90 || s.Velocity.Vz != 2.5 {
+		t.Errorf("Yaw = %v, Vz = %v, want 90 and 2.5 (descending)", s.Attitude.Yaw, s.Velocity.Vz)
+	}
+	if math.Abs(s.Velocity.Vx) > 1e-9 || math.Abs(s.Velocity.Vy-70) > 1e-9 {
+		t.Errorf("Velocity = %+v, want 70 m/s east", s.Velocity)
+	}
+
+	// Unchanged timestamps are not sent again
+	a.poll(context.Background(), events)
+	if len(events) != 0 {
+		t.Errorf("Repeated poll sent %d states, want 0", len(events))
+	}
+}
+
+func TestAdapter_PollObject(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"flight":{"ident":"UAL1","position":{"latitude":"37.6","longitude":"-122.4","altitude":350}}}`))
+	}))
+	defer srv.Close()
+
+	a, err := New(config.PollConfig{
+		Name:  "flightaware",
+		URL:   srv.URL,
+		Items: "flight",
+		Mapping: map[string]string{
+			"device_id": "ident",
+			"lat":       "position.latitude",
+			"lon":       "position.longitude",
+			"alt_baro":  "position.altitude",
+		},
+		Scale: map[string]float64{"alt_baro": 30.48}, // Hundreds of feet
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	events := make(chan *models.DroneState, 10)
+	a.poll(context.Background(), events)
+	if len(events) != 1 {
+		t.Fatalf("Got %d states, want 1", len(events))
+	}
+	s := <-events
+	if s.DeviceID != "UAL1" || s.Location.Lat != 37.6 || s.Location.Lon != -122.4 || math.Abs(s.Location.AltBaro-10668) > 1e-6 {
+		t.Errorf("State = %s %+v", s.DeviceID, s.Location)
+	}
+	if time.Since(time.UnixMilli(s.Timestamp)) > time.Minute {
+		t.Errorf("Timestamp = %d, want now when unmapped", s.Timestamp)
+	}
+}
+
+func TestAdapter_PollInvalidJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>maintenance</html>"))
+	}))
+	defer srv.Close()
+
+	a, _ := New(openSkyConfig(srv.URL))
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+	if _, err := a.fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "decode response") {
+		t.Errorf("fetch error = %v, want decode error", err)
+	}
+	if entries := q.List("poll:opensky", 0); len(entries) != 1 {
+		t.Errorf("Quarantined %d responses, want 1", len(entries))
+	}
+	if stats := a.Stats(); stats.MessagesReceived != 1 || stats.ParseErrors != 1 || stats.BytesReceived != 24 {
+		t.Errorf("Stats = %+v, want one bad 24-byte response", stats)
+	}
+}
@@ -0,0 +1,101 @@
+package poll
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is one element of a path: an object key or an array index
+type step struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// path locates a value within decoded JSON
+type path []step
+
+// parsePath parses a JSONPath-like expression of dotted keys and array
+// indices, e.g. "ac[0].lat", "[6]" or "$.position.alt". An empty
+// expression is the value itself.
+func parsePath(expr string) (path, error) {
+	expr = strings.TrimPrefix(strings.TrimPrefix(expr, "$"), ".")
+	var p path
+	for expr != "" {
+		switch {
+		case expr[0] == '[':
+			end := strings.IndexByte(expr, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", expr)
+			}
+			n, err := strconv.Atoi(expr[1:end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index %q", expr[1:end])
+			}
+			p = append(p, step{index: n, isIdx: true})
+			expr = expr[end+1:]
+		case expr[0] == '.':
+			expr = expr[1:]
+			if expr == "" || expr[0] == '.' || expr[0] == '[' {
+				return nil, fmt.Errorf("empty key")
+			}
+		default:
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			p = append(p, step{key: expr[:end]})
+			expr = expr[end:]
+		}
+	}
+	return p, nil
+}
+
+// lookup returns the value at the path, or nil if it does not exist
+func (p path) lookup(v any) any {
+	for _, s := range p {
+		if s.isIdx {
+			arr, ok := v.([]any)
+			if !ok || s.index >= len(arr) {
+				return nil
+			}
+			v = arr[s.index]
+		} else {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil
+			}
+			v = obj[s.key]
+		}
+	}
+	return v
+}
+
+// number converts a JSON number, numeric string or boolean to a float
+func number(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// text converts a JSON string or number to a string
+func text(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,223 @@
+// Package sim implements a built-in telemetry simulator adapter. It flies
+// fake drones on orbits or waypoint loops with battery drain and GNSS
+// jitter, for UI development and load testing without SITL or hardware.
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// MaxDrones is the maximum number of simulated drones
+const MaxDrones = 1000
+
+var (
+	// ErrInvalidSpec is returned for an invalid drone spec
+	ErrInvalidSpec = errors.New("invalid drone spec")
+	// ErrDroneExists is returned when a drone ID is already in use
+	ErrDroneExists = errors.New("drone already exists")
+	// ErrDroneNotFound is returned for an unknown drone ID
+	ErrDroneNotFound = errors.New("drone not found")
+	// ErrTooManyDrones is returned when MaxDrones would be exceeded
+	ErrTooManyDrones = errors.New("too many simulated drones")
+)
+
+// Adapter implements the core.Adapter interface for simulated drones
+type Adapter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	drones   map[string]*drone
+	nextID   int
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// New creates a simulator with the drones from the configuration. Invalid
+// drones are logged and skipped.
+func New(cfg config.SimConfig) *Adapter {
+	rateHz := cfg.RateHz
+	if rateHz <= 0 {
+		rateHz = 5
+	}
+	a := &Adapter{
+		interval: time.Duration(float64(time.Second) / rateHz),
+		drones:   make(map[string]*drone),
+	}
+	for _, dc := range cfg.Drones {
+		spec := DroneSpec{
+			ID:          dc.ID,
+			Pattern:     dc.Pattern,
+			Lat:         dc.Lat,
+			Lon:         dc.Lon,
+			Alt:         dc.Alt,
+			RadiusM:     dc.RadiusM,
+			SpeedMS:     dc.SpeedMS,
+			Waypoints:   dc.Waypoints,
+			Battery:     dc.Battery,
+			DrainPerMin: dc.DrainPerMin,
+			JitterM:     dc.JitterM,
+		}
+		if _, err := a.AddDrones(spec, 1); err != nil {
+			log.Printf("[Sim] Skipping drone %q: %v", dc.ID, err)
+		}
+	}
+	return a
+}
+
+// Name returns the adapter name
+func (a *Adapter) Name() string {
+	return "sim"
+}
+
+// Start begins emitting simulated states
+func (a *Adapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	ctx, cancel := context.WithCancel(ctx)
+	a.cancel = cancel
+
+	a.wg.Add(1)
+	go a.run(ctx, events)
+
+	log.Printf("[Sim] Simulator started (%d drones, %.1f Hz)", len(a.Drones()), float64(time.Second)/float64(a.interval))
+	return nil
+}
+
+// Stop stops the simulation
+func (a *Adapter) Stop() error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+	log.Printf("[Sim] Simulator stopped")
+	return nil
+}
+
+// run advances all drones on every tick
+func (a *Adapter) run(ctx context.Context, events chan<- *models.DroneState) {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, state := range a.step(now.Sub(last).Seconds(), now) {
+				// Blocks while the engine applies back-pressure
+				select {
+				case events <- state:
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = now
+		}
+	}
+}
+
+// step advances all drones by dt seconds and returns their states
+func (a *Adapter) step(dt float64, now time.Time) []*models.DroneState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	states := make([]*models.DroneState, 0, len(a.drones))
+	for _, d := range a.drones {
+		d.step(dt)
+		states = append(states, d.state(now))
+	}
+	return states
+}
+
+// AddDrones adds count drones built from spec. With count > 1 the drones
+// get IDs "<id>-N" (or generated IDs), and orbit drones are spread around
+// the circle with slightly varied radii.
+func (a *Adapter) AddDrones(spec DroneSpec, count int) ([]DroneInfo, error) {
+	if count <= 0 {
+		count = 1
+	}
+	spec, err := spec.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.drones)+count > MaxDrones {
+		return nil, fmt.Errorf("%w: limit is %d", ErrTooManyDrones, MaxDrones)
+	}
+
+	specs := make([]DroneSpec, count)
+	for i := range specs {
+		s := spec
+		switch {
+		case count > 1 && spec.ID != "":
+			s.ID = fmt.Sprintf("%s-%d", spec.ID, i+1)
+		case spec.ID == "":
+			s.ID = a.generateID()
+		}
+		if _, exists := a.drones[s.ID]; exists {
+			return nil, fmt.Errorf("%w: %s", ErrDroneExists, s.ID)
+		}
+		if count > 1 && s.Pattern == PatternOrbit {
+			s.RadiusM = spec.RadiusM * (0.8 + 0.4*float64(i)/float64(count))
+		}
+		specs[i] = s
+	}
+
+	infos := make([]DroneInfo, 0, count)
+	for i, s := range specs {
+		phase := 2 * math.Pi * float64(i) / float64(count)
+		d := newDrone(s, phase, time.Now().UnixNano()+int64(i))
+		a.drones[s.ID] = d
+		infos = append(infos, d.info())
+	}
+	return infos, nil
+}
+
+// generateID returns an unused sim-NNN ID. Caller must hold the lock.
+func (a *Adapter) generateID() string {
+	for {
+		a.nextID++
+		id := fmt.Sprintf("sim-%03d", a.nextID)
+		if _, exists := a.drones[id]; !exists {
+			return id
+		}
+	}
+}
+
+// RemoveDrone removes a simulated drone
+func (a *Adapter) RemoveDrone(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.drones[id]; !ok {
+		return ErrDroneNotFound
+	}
+	delete(a.drones, id)
+	return nil
+}
+
+// Drones returns all simulated drones sorted by ID
+func (a *Adapter) Drones() []DroneInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	infos := make([]DroneInfo, 0, len(a.drones))
+	for _, d := range a.drones {
+		infos = append(infos, d.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
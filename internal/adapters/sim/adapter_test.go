@@ -0,0 +1,176 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// distance returns the approximate distance in meters between two positions
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	north := (lat2 - lat1) * coordinator.MetersPerDegLat
+	east := (lon2 - lon1) * coordinator.MetersPerDegLat * math.Cos(lat1*math.Pi/180)
+	return math.Hypot(north, east)
+}
+
+func TestNew_ConfiguredDrones(t *testing.T) {
+	a := New(config.SimConfig{
+		RateHz: 10,
+		Drones: []config.SimDroneConfig{
+			{ID: "orbit-1", Lat: 22.5, Lon: 113.9},
+			{ID: "bad", Pattern: "spiral"},
+		},
+	})
+
+	if a.Name() != "sim" {
+		t.Errorf("Name = %s, want sim", a.Name())
+	}
+	if a.interval != 100*time.Millisecond {
+		t.Errorf("Interval = %s, want 100ms", a.interval)
+	}
+	drones := a.Drones()
+	if len(drones) != 1 || drones[0].ID != "orbit-1" || drones[0].Pattern != PatternOrbit {
+		t.Errorf("Drones = %+v, want only orbit-1", drones)
+	}
+}
+
+func TestAdapter_AddDrones(t *testing.T) {
+	a := New(config.SimConfig{})
+
+	infos, err := a.AddDrones(DroneSpec{Lat: 22.5, Lon: 113.9}, 3)
+	if err != nil {
+		t.Fatalf("AddDrones failed: %v", err)
+	}
+	if len(infos) != 3 || infos[0].ID != "sim-001" || infos[2].ID != "sim-003" {
+		t.Errorf("Generated drones = %+v", infos)
+	}
+
+	infos, err = a.AddDrones(DroneSpec{ID: "fleet", Lat: 22.5, Lon: 113.9}, 2)
+	if err != nil || len(infos) != 2 || infos[1].ID != "fleet-2" {
+		t.Errorf("Named batch = %+v, %v", infos, err)
+	}
+
+	if _, err := a.AddDrones(DroneSpec{ID: "fleet-1"}, 1); !errors.Is(err, ErrDroneExists) {
+		t.Errorf("Duplicate ID error = %v, want ErrDroneExists", err)
+	}
+	if _, err := a.AddDrones(DroneSpec{Pattern: PatternWaypoints, Waypoints: [][]float64{{22.5, 113.9}}}, 1); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("Single waypoint error = %v, want ErrInvalidSpec", err)
+	}
+	if _, err := a.AddDrones(DroneSpec{}, MaxDrones); !errors.Is(err, ErrTooManyDrones) {
+		t.Errorf("Too many drones error = %v, want ErrTooManyDrones", err)
+	}
+
+	if err := a.RemoveDrone("sim-002"); err != nil {
+		t.Errorf("RemoveDrone failed: %v", err)
+	}
+	if err := a.RemoveDrone("sim-002"); !errors.Is(err, ErrDroneNotFound) {
+		t.Errorf("RemoveDrone twice error = %v, want ErrDroneNotFound", err)
+	}
+	if len(a.Drones()) != 4 {
+		t.Errorf("Drones = %d, want 4", len(a.Drones()))
+	}
+}
+
+func TestDrone_Orbit(t *testing.T) {
+	spec, _ := DroneSpec{ID: "o", Lat: 22.5, Lon: 113.9, RadiusM: 100, SpeedMS: 10, JitterM: 0.001}.withDefaults()
+	d := newDrone(spec, 0, 1)
+
+	start := d.info()
+	for i := 0; i < 50; i++ {
+		d.step(0.2)
+	}
+	info := d.info()
+
+	if r := distance(spec.Lat, spec.Lon, info.Lat, info.Lon); math.Abs(r-100) > 0.5 {
+		t.Errorf("Orbit radius = %.1f m, want 100", r)
+	}
+	if moved := distance(start.Lat, start.Lon, info.Lat, info.Lon); moved < 50 {
+		t.Errorf("Drone moved %.1f m in 10 s, want about 100 along the arc", moved)
+	}
+
+	state := d.state(time.Now())
+	if state.ProtocolSource != "sim" || state.Status.FlightMode != models.FlightModeAuto || !state.Status.Armed {
+		t.Errorf("State = %+v", state.Status)
+	}
+	if speed := math.Hypot(state.Velocity.Vx, state.Velocity.Vy); math.Abs(speed-10) > 0.01 {
+		t.Errorf("Speed = %.2f, want 10", speed)
+	}
+	if state.Status.BatteryPercent != 100 || info.Battery >= 100 {
+		t.Errorf("Battery = %d%% (%.3f), want slight drain", state.Status.BatteryPercent, info.Battery)
+	}
+}
+
+func TestDrone_Waypoints(t *testing.T) {
+	spec, err := DroneSpec{
+		ID: "w", Pattern: PatternWaypoints, Lat: 22.5, Lon: 113.9, SpeedMS: 20,
+		Waypoints: [][]float64{{22.5, 113.9}, {22.501, 113.9, 120}},
+	}.withDefaults()
+	if err != nil {
+		t.Fatalf("withDefaults failed: %v", err)
+	}
+	d := newDrone(spec, 0, 1)
+
+	// 111 m north at 20 m/s with a 20 m climb at 3 m/s
+	for i := 0; i < 80 && d.waypoint == 1; i++ {
+		d.step(0.1)
+	}
+	if d.waypoint != 0 {
+		t.Fatalf("Drone did not reach the second waypoint: %+v", d.info())
+	}
+	if d.alt != 120 || d.heading > 1 {
+		t.Errorf("At waypoint: alt %.1f heading %.1f, want 120 heading north", d.alt, d.heading)
+	}
+}
+
+func TestDrone_BatteryRTLAndLand(t *testing.T) {
+	spec, _ := DroneSpec{ID: "b", Lat: 22.5, Lon: 113.9, Alt: 10, RadiusM: 50, Battery: 21, DrainPerMin: 60}.withDefaults()
+	d := newDrone(spec, 0, 1)
+
+	var sawRTL bool
+	for i := 0; i < 600 && d.armed; i++ {
+		d.step(0.1)
+		sawRTL = sawRTL || d.mode == models.FlightModeRTL
+	}
+
+	if !sawRTL {
+		t.Error("Drone should return to launch on low battery")
+	}
+	info := d.info()
+	if info.Armed || info.FlightMode != models.FlightModeLand || info.Alt != 0 {
+		t.Errorf("Drone after landing = %+v", info)
+	}
+	if r := distance(spec.Lat, spec.Lon, info.Lat, info.Lon); r > 1 {
+		t.Errorf("Landed %.1f m from home, want at home", r)
+	}
+}
+
+func TestAdapter_StartStop(t *testing.T) {
+	a := New(config.SimConfig{RateHz: 50})
+	a.AddDrones(DroneSpec{Lat: 22.5, Lon: 113.9}, 2)
+
+	events := make(chan *models.DroneState, 100)
+	if err := a.Start(context.Background(), events); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	timeout := time.After(2 * time.Second)
+	for len(seen) < 2 {
+		select {
+		case state := <-events:
+			seen[state.DeviceID] = true
+		case <-timeout:
+			t.Fatalf("Received states from %v, want both drones", seen)
+		}
+	}
+
+	if err := a.Stop(); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Flight patterns
+const (
+	PatternOrbit     = "orbit"
+	PatternWaypoints = "waypoints"
+)
+
+// Simulation constants
+const (
+	gravity    = 9.81
+	rtlBattery = 20.0 // Return home below this battery percent
+	climbRate  = 3.0  // m/s
+	landRate   = 1.5  // m/s
+)
+
+// DroneSpec describes a simulated drone. Zero values use defaults.
+type DroneSpec struct {
+	ID          string      `json:"id,omitempty"`
+	Pattern     string      `json:"pattern,omitempty"`       // orbit | waypoints (default orbit)
+	Lat         float64     `json:"lat"`                     // Orbit center / home latitude
+	Lon         float64     `json:"lon"`                     // Orbit center / home longitude
+	Alt         float64     `json:"alt,omitempty"`           // Cruise altitude in meters (default 100)
+	RadiusM     float64     `json:"radius_m,omitempty"`      // Orbit radius (default 200)
+	SpeedMS     float64     `json:"speed_ms,omitempty"`      // Ground speed (default 10)
+	Waypoints   [][]float64 `json:"waypoints,omitempty"`     // [[lat, lon], [lat, lon, alt], ...]
+	Battery     float64     `json:"battery,omitempty"`       // Starting battery percent (default 100)
+	DrainPerMin float64     `json:"drain_per_min,omitempty"` // Battery percent per minute at cruise (default 1)
+	JitterM     float64     `json:"jitter_m,omitempty"`      // GNSS noise standard deviation (default 1)
+}
+
+// withDefaults fills in defaults and validates the spec
+func (s DroneSpec) withDefaults() (DroneSpec, error) {
+	if s.Pattern == "" {
+		s.Pattern = PatternOrbit
+	}
+	if s.Alt == 0 {
+		s.Alt = 100
+	}
+	if s.RadiusM == 0 {
+		s.RadiusM = 200
+	}
+	if s.SpeedMS == 0 {
+		s.SpeedMS = 10
+	}
+	if s.Battery == 0 {
+		s.Battery = 100
+	}
+	if s.DrainPerMin == 0 {
+		s.DrainPerMin = 1
+	}
+	if s.JitterM == 0 {
+		s.JitterM = 1
+	}
+
+	switch {
+	case s.Pattern != PatternOrbit && s.Pattern != PatternWaypoints:
+		return s, fmt.Errorf("%w: pattern must be orbit or waypoints", ErrInvalidSpec)
+	case s.Lat < -90 || s.Lat > 90 || s.Lon < -180 || s.Lon > 180:
+		return s, fmt.Errorf("%w: lat/lon out of range", ErrInvalidSpec)
+	case s.RadiusM < 0 || s.SpeedMS < 0 || s.Alt < 0 || s.DrainPerMin < 0 || s.JitterM < 0:
+		return s, fmt.Errorf("%w: radius, speed, alt, drain and jitter must not be negative", ErrInvalidSpec)
+	case s.Battery < 0 || s.Battery > 100:
+		return s, fmt.Errorf("%w: battery must be between 0 and 100", ErrInvalidSpec)
+	}
+	if s.Pattern == PatternWaypoints {
+		if len(s.Waypoints) < 2 {
+			return s, fmt.Errorf("%w: waypoints pattern needs at least 2 waypoints", ErrInvalidSpec)
+		}
+		for i, wp := range s.Waypoints {
+			if len(wp) < 2 || len(wp) > 3 {
+				return s, fmt.Errorf("%w: waypoint %d must be [lat, lon] or [lat, lon, alt]", ErrInvalidSpec, i)
+			}
+		}
+	}
+	return s, nil
+}
+
+// DroneInfo is the current state of a simulated drone
+type DroneInfo struct {
+	ID         string            `json:"id"`
+	Pattern    string            `json:"pattern"`
+	Lat        float64           `json:"lat"`
+	Lon        float64           `json:"lon"`
+	Alt        float64           `json:"alt"`
+	Battery    float64           `json:"battery"`
+	FlightMode models.FlightMode `json:"flight_mode"`
+	Armed      bool              `json:"armed"`
+}
+
+// drone is a simulated drone. Positions are true positions; jitter is only
+// applied to reported states.
+type drone struct {
+	spec DroneSpec
+	rng  *rand.Rand
+
+	lat, lon, alt float64
+	angle         float64 // Orbit phase in radians
+	waypoint      int     // Index of the waypoint being flown to
+	battery       float64
+	mode          models.FlightMode
+	armed         bool
+	vn, ve, vd    float64 // Velocity north/east/down in m/s
+	roll, pitch   float64 // Radians
+	heading       float64 // Degrees
+}
+
+// newDrone places a drone at the start of its pattern. phase is the initial
+// orbit angle in radians.
+func newDrone(spec DroneSpec, phase float64, seed int64) *drone {
+	d := &drone{
+		spec:    spec,
+		rng:     rand.New(rand.NewSource(seed)),
+		alt:     spec.Alt,
+		angle:   phase,
+		battery: spec.Battery,
+		mode:    models.FlightModeAuto,
+		armed:   true,
+	}
+	if spec.Pattern == PatternOrbit {
+		d.lat, d.lon = offset(spec.Lat, spec.Lon, spec.RadiusM*math.Cos(phase), spec.RadiusM*math.Sin(phase))
+	} else {
+		d.lat, d.lon = spec.Waypoints[0][0], spec.Waypoints[0][1]
+		d.alt = d.waypointAlt(0)
+		d.waypoint = 1
+	}
+	return d
+}
+
+// step advances the simulation by dt seconds
+func (d *drone) step(dt float64) {
+	if !d.armed || dt <= 0 {
+		return
+	}
+
+	// Drain grows with speed: hovering uses 70% of the cruise rate
+	speed := math.Hypot(d.vn, d.ve)
+	load := 0.7 + 0.3*speed/math.Max(d.spec.SpeedMS, 1)
+	d.battery = math.Max(0, d.battery-d.spec.DrainPerMin*load*dt/60)
+
+	if d.battery <= rtlBattery && d.mode == models.FlightModeAuto {
+		d.mode = models.FlightModeRTL
+	}
+	if d.battery == 0 && d.mode != models.FlightModeLand {
+		d.mode = models.FlightModeLand
+	}
+
+	switch d.mode {
+	case models.FlightModeLand:
+		d.land(dt)
+	case models.FlightModeRTL:
+		if d.flyTo(d.spec.Lat, d.spec.Lon, d.spec.Alt, dt) {
+			d.mode = models.FlightModeLand
+		}
+	default:
+		if d.spec.Pattern == PatternOrbit {
+			d.orbit(dt)
+		} else if d.flyTo(d.spec.Waypoints[d.waypoint][0], d.spec.Waypoints[d.waypoint][1], d.waypointAlt(d.waypoint), dt) {
+			d.waypoint = (d.waypoint + 1) % len(d.spec.Waypoints)
+		}
+	}
+}
+
+// orbit moves along the circle around the spec's center
+func (d *drone) orbit(dt float64) {
+	r := math.Max(d.spec.RadiusM, 1)
+	d.angle += d.spec.SpeedMS * dt / r
+	d.lat, d.lon = offset(d.spec.Lat, d.spec.Lon, r*math.Cos(d.angle), r*math.Sin(d.angle))
+	d.vn = -math.Sin(d.angle) * d.spec.SpeedMS
+	d.ve = math.Cos(d.angle) * d.spec.SpeedMS
+	d.vd = 0
+	d.heading = headingOf(d.vn, d.ve)
+	d.roll = math.Atan(d.spec.SpeedMS * d.spec.SpeedMS / (r * gravity))
+	d.pitch = d.cruisePitch()
+}
+
+// flyTo moves towards a target at cruise speed and reports whether it was reached
+func (d *drone) flyTo(lat, lon, alt, dt float64) bool {
+	north := (lat - d.lat) * coordinator.MetersPerDegLat
+	east := (lon - d.lon) * coordinator.MetersPerDegLat * math.Cos(d.lat*math.Pi/180)
+	dist := math.Hypot(north, east)
+	stepLen := d.spec.SpeedMS * dt
+
+	climb := math.Max(-climbRate*dt, math.Min(climbRate*dt, alt-d.alt))
+	d.alt += climb
+	d.vd = -climb / dt
+
+	d.roll = 0
+	if dist <= stepLen {
+		d.lat, d.lon = lat, lon
+		d.vn, d.ve, d.pitch = 0, 0, 0
+		return d.alt == alt
+	}
+
+	d.vn = north / dist * d.spec.SpeedMS
+	d.ve = east / dist * d.spec.SpeedMS
+	d.lat, d.lon = offset(d.lat, d.lon, d.vn*dt, d.ve*dt)
+	d.heading = headingOf(d.vn, d.ve)
+	d.pitch = d.cruisePitch()
+	return false
+}
+
+// cruisePitch is the nose-down pitch (radians) needed to hold cruise speed
+func (d *drone) cruisePitch() float64 {
+	return -0.01 * d.spec.SpeedMS
+}
+
+// land descends in place and disarms on touchdown
+func (d *drone) land(dt float64) {
+	d.vn, d.ve, d.roll, d.pitch = 0, 0, 0, 0
+	d.vd = landRate
+	d.alt -= landRate * dt
+	if d.alt <= 0 {
+		d.alt, d.vd = 0, 0
+		d.armed = false
+	}
+}
+
+// waypointAlt returns the altitude of a waypoint (cruise altitude if unset)
+func (d *drone) waypointAlt(i int) float64 {
+	if wp := d.spec.Waypoints[i]; len(wp) == 3 {
+		return wp[2]
+	}
+	return d.spec.Alt
+}
+
+// state returns the telemetry a real drone would report, with GNSS noise
+func (d *drone) state(now time.Time) *models.DroneState {
+	s := models.NewDroneState(d.spec.ID, "sim")
+	s.Timestamp = now.UnixMilli()
+
+	j := d.spec.JitterM
+	s.Location.Lat, s.Location.Lon = offset(d.lat, d.lon, d.rng.NormFloat64()*j, d.rng.NormFloat64()*j)
+	s.Location.AltGNSS = d.alt + d.rng.NormFloat64()*j*1.5
+	s.Location.AltBaro = d.alt + d.rng.NormFloat64()*0.3
+
+	s.Attitude.Roll = d.roll + d.rng.NormFloat64()*0.01
+	s.Attitude.Pitch = d.pitch + d.rng.NormFloat64()*0.01
+	s.Attitude.Yaw = d.heading
+
+	s.Velocity.Vx = d.vn
+	s.Velocity.Vy = d.ve
+	s.Velocity.Vz = d.vd
+
+	s.Status.BatteryPercent = int(math.Ceil(d.battery))
+	s.Status.FlightMode = d.mode
+	s.Status.Armed = d.armed
+	s.Status.SignalQuality = 80 + d.rng.Intn(21)
+	s.Freshness = models.FreshnessAt(s.Timestamp)
+	return s
+}
+
+// info returns the drone's true state
+func (d *drone) info() DroneInfo {
+	return DroneInfo{
+		ID:         d.spec.ID,
+		Pattern:    d.spec.Pattern,
+		Lat:        d.lat,
+		Lon:        d.lon,
+		Alt:        d.alt,
+		Battery:    d.battery,
+		FlightMode: d.mode,
+		Armed:      d.armed,
+	}
+}
+
+// offset moves a position by north/east meters
+func offset(lat, lon, north, east float64) (float64, float64) {
+	return lat + north/coordinator.MetersPerDegLat,
+		lon + east/(coordinator.MetersPerDegLat*math.Cos(lat*math.Pi/180))
+}
+
+// headingOf returns the compass heading (0-360) of a velocity
+func headingOf(vn, ve float64) float64 {
+	h := math.Atan2(ve, vn) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
@@ -0,0 +1,253 @@
+package udp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/adapterstats"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// maxDatagram is the largest UDP payload accepted
+const maxDatagram = 65535
+
+// errSignature is returned for lines whose HMAC is missing or wrong
+var errSignature = errors.New("invalid signature")
+
+// Adapter implements the core.Adapter interface for newline-delimited
+// DroneState JSON sent over UDP. Each datagram carries one or more lines.
+// With a shared secret configured, every line is prefixed with the hex
+// HMAC-SHA256 of its JSON and a space:
+//
+//	<hmac> {"device_id":"drone-1","location":{"lat":22.54,"lon":113.94}}
+type Adapter struct {
+	cfg        config.UDPConfig
+	conn       net.PacketConn
+	quarantine *quarantine.Store
+	rejected   uint64
+	stats      *adapterstats.Counter
+	mu         sync.Mutex
+	wg         sync.WaitGroup
+	now        func() time.Time
+}
+
+// New creates a new UDP JSON adapter
+func New(cfg config.UDPConfig) *Adapter {
+	return &Adapter{
+		cfg:   cfg,
+		stats: adapterstats.New(),
+		now:   time.Now,
+	}
+}
+
+// Name returns the adapter name
+func (a *Adapter) Name() string {
+	return "udp"
+}
+
+// SetQuarantine sets the store for lines that fail to parse
+func (a *Adapter) SetQuarantine(q *quarantine.Store) {
+	a.quarantine = q
+}
+
+// Start begins receiving datagrams
+func (a *Adapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	conn, err := net.ListenPacket("udp", a.cfg.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", a.cfg.ListenAddress, err)
+	}
+	a.conn = conn
+
+	log.Printf("[UDP] Listening on %s (signed: %v)", conn.LocalAddr(), a.cfg.Secret != "")
+
+	a.wg.Add(1)
+	go a.receiveLoop(ctx, events)
+
+	return nil
+}
+
+// Stop closes the socket and waits for the receive loop to exit
+func (a *Adapter) Stop() error {
+	if a.conn != nil {
+		a.conn.Close()
+	}
+	a.wg.Wait()
+	log.Printf("[UDP] Adapter stopped")
+	return nil
+}
+
+// Rejected returns the number of lines dropped for a bad signature
+func (a *Adapter) Rejected() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rejected
+}
+
+// Stats returns the lines, parse errors and bytes received. UDP has no
+// connections, so no peers are reported.
+func (a *Adapter) Stats() sdk.AdapterStats {
+	return a.stats.Stats()
+}
+
+// receiveLoop reads datagrams until the socket is closed
+func (a *Adapter) receiveLoop(ctx context.Context, events chan<- *models.DroneState) {
+	defer a.wg.Done()
+
+	buf := make([]byte, maxDatagram)
+	for {
+		n, addr, err := a.conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("[UDP] Read error: %v", err)
+			continue
+		}
+		a.handleDatagram(ctx, buf[:n], addr.String(), events)
+	}
+}
+
+// handleDatagram emits the states of each line in a datagram
+func (a *Adapter) handleDatagram(ctx context.Context, data []byte, source string, events chan<- *models.DroneState) {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		a.stats.Received(len(line))
+
+		payload, err := a.verifyLine(line)
+		if err != nil {
+			a.mu.Lock()
+			a.rejected++
+			a.mu.Unlock()
+			log.Printf("[UDP] Rejected line from %s: %v", source, err)
+			continue
+		}
+		state, err := a.decodeState(payload)
+		if err != nil {
+			log.Printf("[UDP] Failed to parse state from %s: %v", source, err)
+			a.stats.ParseError()
+			if a.quarantine != nil {
+				a.quarantine.Add(a.Name(), "", source, payload, err)
+			}
+			continue
+		}
+
+		// Blocks while the engine applies back-pressure
+		select {
+		case events <- state:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// verifyLine checks a line's signature when a secret is configured and
+// returns the JSON payload
+func (a *Adapter) verifyLine(line []byte) ([]byte, error) {
+	if a.cfg.Secret == "" {
+		return line, nil
+	}
+	sig, payload, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return nil, fmt.Errorf("%w: missing", errSignature)
+	}
+	if !a.verify(sig, payload) {
+		return nil, errSignature
+	}
+	return payload, nil
+}
+
+// verify reports whether sig is the hex HMAC-SHA256 of payload
+func (a *Adapter) verify(sig, payload []byte) bool {
+	got := make([]byte, hex.DecodedLen(len(sig)))
+	if _, err := hex.Decode(got, sig); err != nil {
+		return false
+	}
+	return hmac.Equal(got, Sign([]byte(a.cfg.Secret), payload))
+}
+
+// Sign returns the HMAC-SHA256 of a payload, as expected by an adapter
+// configured with the secret
+func Sign(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// decodeState parses a DroneState, filling in the protocol source and a
+// missing timestamp and freshness
+func (a *Adapter) decodeState(payload []byte) (*models.DroneState, error) {
+	var state models.DroneState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return nil, err
+	}
+	if state.DeviceID == "" {
+		return nil, fmt.Errorf("state without device_id")
+	}
+	if state.ProtocolSource == "" {
+		state.ProtocolSource = a.Name()
+	}
+	if state.Timestamp == 0 {
+		state.Timestamp = a.now().UnixMilli()
+	}
+	if state.Freshness.IsZero() {
+		state.Freshness = models.FreshnessAt(state.Timestamp)
+	}
+	return &state, nil
+}
+
+// Replay re-parses a quarantined line. Lines are quarantined without their
+// signature, and only after it was verified.
+func (a *Adapter) Replay(entry quarantine.Entry) (*models.DroneState, error) {
+	state, err := a.decodeState(entry.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+	return state, nil
+}
+
+// SelfTest checks that the socket is open and verifies and decodes a
+// synthetic line, signed if a secret is configured, without emitting it
+func (a *Adapter) SelfTest() (*models.DroneState, error) {
+	if a.conn == nil {
+		return nil, fmt.Errorf("socket not open")
+	}
+
+	synthetic := models.NewDroneState("udp-selftest", a.Name())
+	synthetic.Timestamp = a.now().UnixMilli()
+	synthetic.Location.Lat = 39.9087
+	synthetic.Location.Lon = 116.3975
+	line, err := json.Marshal(synthetic)
+	if err != nil {
+		return nil, fmt.Errorf("encoding state: %w", err)
+	}
+	if a.cfg.Secret != "" {
+		sig := hex.EncodeToString(Sign([]byte(a.cfg.Secret), line))
+		line = append([]byte(sig+" "), line...)
+	}
+
+	payload, err := a.verifyLine(line)
+	if err != nil {
+		return nil, fmt.Errorf("verifying line: %w", err)
+	}
+	state, err := a.decodeState(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+	return state, nil
+}
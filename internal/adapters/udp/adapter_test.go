@@ -0,0 +1,156 @@
+package udp
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAdapter_Name(t *testing.T) {
+	if name := New(config.UDPConfig{}).Name(); name != "udp" {
+		t.Errorf("Name() = %s, want 'udp'", name)
+	}
+}
+
+func TestAdapter_Stop_NotStarted(t *testing.T) {
+	if err := New(config.UDPConfig{}).Stop(); err != nil {
+		t.Errorf("Stop should not error before Start: %v", err)
+	}
+}
+
+func TestAdapter_handleDatagram(t *testing.T) {
+	a := New(config.UDPConfig{})
+	a.now = func() time.Time { return time.UnixMilli(1700000000000) }
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+	events := make(chan *models.DroneState, 10)
+
+	a.handleDatagram(context.Background(), []byte(`{"device_id":"drone-1","timestamp":1000,"location":{"lat":22.5,"lon":113.9}}
+{"device_id":"drone-2","protocol_source":"companion"}
+
+not json
+{"location":{"lat":1,"lon":2}}
+`), "10.0.0.5:40000", events)
+
+	if len(events) != 2 {
+		t.Fatalf("Emitted %d states, want 2", len(events))
+	}
+	first, second := <-events, <-events
+	if first.DeviceID != "drone-1" || first.Timestamp != 1000 || first.Location.Lat != 22.5 || first.ProtocolSource != "udp" {
+		t.Errorf("First state = %+v", first)
+	}
+	if second.ProtocolSource != "companion" || second.Timestamp != 1700000000000 {
+		t.Errorf("Second state: source %q, timestamp %d", second.ProtocolSource, second.Timestamp)
+	}
+
+	entries := q.List("", 0)
+	if len(entries) != 2 {
+		t.Fatalf("Quarantined %d lines, want 2", len(entries))
+	}
+	if entries[0].Adapter != "udp" || entries[0].Source != "10.0.0.5:40000" {
+		t.Errorf("Entry = %+v", entries[0])
+	}
+	if stats := a.Stats(); stats.MessagesReceived != 4 || stats.ParseErrors != 2 || stats.BytesReceived == 0 {
+		t.Errorf("Stats = %+v, want 4 lines with 2 parse errors", stats)
+	}
+}
+
+func TestAdapter_Signed(t *testing.T) {
+	a := New(config.UDPConfig{Secret: "s3cret"})
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+	events := make(chan *models.DroneState, 10)
+
+	payload := []byte(`{"device_id":"drone-1","timestamp":1000}`)
+	sig := hex.EncodeToString(Sign([]byte("s3cret"), payload))
+	wrong := hex.EncodeToString(Sign([]byte("other"), payload))
+
+	a.handleDatagram(context.Background(), []byte(sig+" "+string(payload)), "test", events)
+	a.handleDatagram(context.Background(), []byte(wrong+" "+string(payload)), "test", events)
+	a.handleDatagram(context.Background(), payload, "test", events)
+	a.handleDatagram(context.Background(), []byte("zz "+string(payload)), "test", events)
+
+	if len(events) != 1 {
+		t.Fatalf("Emitted %d states, want only the signed one", len(events))
+	}
+	if a.Rejected() != 3 {
+		t.Errorf("Rejected() = %d, want 3", a.Rejected())
+	}
+	if len(q.List("", 0)) != 0 {
+		t.Error("Lines with a bad signature should not be quarantined")
+	}
+}
+
+func TestAdapter_Replay(t *testing.T) {
+	a := New(config.UDPConfig{Secret: "s3cret"})
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+
+	payload := []byte(`{"device_id":"drone-1","location":{"lat":"x"}}`)
+	sig := hex.EncodeToString(Sign([]byte("s3cret"), payload))
+	a.handleDatagram(context.Background(), []byte(sig+" "+string(payload)), "test", make(chan *models.DroneState, 1))
+
+	entries := q.List("", 0)
+	if len(entries) != 1 {
+		t.Fatalf("Quarantined %d lines, want 1", len(entries))
+	}
+	if string(entries[0].Payload) != string(payload) {
+		t.Errorf("Payload = %s, want the line without its signature", entries[0].Payload)
+	}
+	if _, err := a.Replay(entries[0]); err == nil {
+		t.Error("Replay of an invalid state should fail")
+	}
+
+	entries[0].Payload = []byte(`{"device_id":"drone-1","location":{"lat":1,"lon":2}}`)
+	state, err := a.Replay(entries[0])
+	if err != nil {
+		t.Fatalf("Replay() error: %v", err)
+	}
+	if state.DeviceID != "drone-1" || state.Location.Lon != 2 {
+		t.Errorf("Replayed state = %+v", state)
+	}
+}
+
+func TestAdapter_StartReceive(t *testing.T) {
+	a := New(config.UDPConfig{ListenAddress: "127.0.0.1:0", Secret: "s3cret"})
+	events := make(chan *models.DroneState, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer a.Stop()
+
+	if state, err := a.SelfTest(); err != nil || state.DeviceID != "udp-selftest" {
+		t.Fatalf("SelfTest() = %v, %v", state, err)
+	}
+	if len(events) != 0 {
+		t.Error("SelfTest should not emit a state")
+	}
+
+	conn, err := net.Dial("udp", a.conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	payload := []byte(`{"device_id":"companion-7","location":{"lat":22.5,"lon":113.9}}`)
+	if _, err := conn.Write([]byte(hex.EncodeToString(Sign([]byte("s3cret"), payload)) + " " + string(payload) + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case state := <-events:
+		if state.DeviceID != "companion-7" {
+			t.Errorf("DeviceID = %s, want companion-7", state.DeviceID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("No state received")
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core"
+)
+
+// AdapterStatsProvider is optionally implemented by a StateProvider to
+// report the traffic counters of adapters
+type AdapterStatsProvider interface {
+	AdapterStats(name string) (core.AdapterStats, error)
+}
+
+// AdapterStatsResponse is the response for GET /api/v1/adapters/{name}/stats
+type AdapterStatsResponse struct {
+	Adapter string `json:"adapter"`
+	core.AdapterStats
+}
+
+// handleGetAdapterStats returns the messages, parse errors, peers and byte
+// rate of an adapter
+// GET /api/v1/adapters/{name}/stats
+func (s *Server) handleGetAdapterStats(w http.ResponseWriter, r *http.Request) {
+	sp, ok := s.provider.(AdapterStatsProvider)
+	if !ok {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "adapter statistics not supported",
+		})
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	stats, err := sp.AdapterStats(name)
+	switch {
+	case errors.Is(err, core.ErrAdapterNotFound):
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "adapter not found"})
+		return
+	case err != nil:
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, AdapterStatsResponse{Adapter: name, AdapterStats: stats})
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/identity"
+)
+
+// IdentityProvider is optionally implemented by a StateProvider to expose
+// the device aliases merging sources into canonical devices
+type IdentityProvider interface {
+	Identity() *identity.Mapper
+}
+
+// AliasesResponse is the response for GET /api/v1/aliases
+type AliasesResponse struct {
+	Count   int              `json:"count"`
+	Aliases []identity.Alias `json:"aliases"`
+}
+
+// identityMapper returns the mapper or writes 501 if unsupported
+func (s *Server) identityMapper(w http.ResponseWriter) (*identity.Mapper, bool) {
+	ip, ok := s.provider.(IdentityProvider)
+	if !ok || ip.Identity() == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "device aliases not supported",
+		})
+		return nil, false
+	}
+	return ip.Identity(), true
+}
+
+// handleGetAliases lists device aliases
+// GET /api/v1/aliases
+func (s *Server) handleGetAliases(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.identityMapper(w)
+	if !ok {
+		return
+	}
+	aliases := m.List()
+	s.writeJSON(w, http.StatusOK, AliasesResponse{Count: len(aliases), Aliases: aliases})
+}
+
+// handleGetAlias returns the alias of a device ID
+// GET /api/v1/aliases/{id}
+func (s *Server) handleGetAlias(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.identityMapper(w)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	for _, a := range m.List() {
+		if a.ID == id {
+			s.writeJSON(w, http.StatusOK, a)
+			return
+		}
+	}
+	s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: identity.ErrNotFound.Error(), DeviceID: id})
+}
+
+// handleSetAlias merges the states of a device ID into a canonical device
+// PUT /api/v1/aliases/{id}
+func (s *Server) handleSetAlias(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.identityMapper(w)
+	if !ok {
+		return
+	}
+
+	var a identity.Alias
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	a.ID = chi.URLParam(r, "id")
+	a, err := m.Set(a)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, identity.ErrInvalidAlias) {
+			status = http.StatusBadRequest
+		}
+		s.writeJSON(w, status, ErrorResponse{Error: err.Error(), DeviceID: chi.URLParam(r, "id")})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, a)
+}
+
+// handleDeleteAlias removes the alias of a device ID
+// DELETE /api/v1/aliases/{id}
+func (s *Server) handleDeleteAlias(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.identityMapper(w)
+	if !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := m.Delete(id); err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error(), DeviceID: id})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
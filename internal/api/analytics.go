@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/coverage"
+)
+
+// Cell sizes accepted by the heatmap endpoint, in meters
+const (
+	defaultHeatmapCellM = 100.0
+	minHeatmapCellM     = 1.0
+	maxHeatmapCellM     = 100000.0
+)
+
+// HeatmapResponse is the response for GET /api/v1/analytics/heatmap
+type HeatmapResponse struct {
+	CellSizeM float64                  `json:"cell_size_m"`
+	Points    int                      `json:"points"` // Track points counted over all cells
+	Count     int                      `json:"count"`
+	Cells     []coverage.OccupancyCell `json:"cells"` // Busiest first
+}
+
+// handleGetHeatmap counts the stored track points of all visible drones
+// per grid cell
+// GET /api/v1/analytics/heatmap?bbox=minLat,minLon,maxLat,maxLon&cell=&from=&to=
+func (s *Server) handleGetHeatmap(w http.ResponseWriter, r *http.Request) {
+	if !s.provider.IsTrackEnabled() {
+		s.writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{
+			Error: "track storage is disabled",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	var q coverage.Query
+	for name, dst := range map[string]*int64{"from": &q.Since, "to": &q.Until} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+					Error: "invalid " + name + " parameter",
+				})
+				return
+			}
+			*dst = n
+		}
+	}
+	if v := query.Get("bbox"); v != "" {
+		b, ok := parseBBox(v)
+		if !ok {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "bbox must be minLat,minLon,maxLat,maxLon",
+			})
+			return
+		}
+		q.Bounds = b
+	}
+	cell := defaultHeatmapCellM
+	if v := query.Get("cell"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < minHeatmapCellM || n > maxHeatmapCellM {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "cell must be between 1 and 100000 meters",
+			})
+			return
+		}
+		cell = n
+	}
+
+	tenantID := auth.TenantFromContext(r.Context())
+	occupancy := coverage.NewOccupancy(cell, q)
+	for _, d := range s.provider.GetAllStates() {
+		if !s.tenants().Visible(tenantID, d.DeviceID) {
+			continue
+		}
+		for _, p := range s.provider.GetTrack(d.DeviceID, 0, q.Since) {
+			occupancy.Add(d.DeviceID, p.Lat, p.Lon, p.Timestamp)
+		}
+	}
+
+	cells := occupancy.Cells()
+	points := 0
+	for _, c := range cells {
+		points += c.Points
+	}
+	s.writeJSON(w, http.StatusOK, HeatmapResponse{
+		CellSizeM: cell,
+		Points:    points,
+		Count:     len(cells),
+		Cells:     cells,
+	})
+}
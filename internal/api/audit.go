@@ -0,0 +1,216 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/audit"
+)
+
+// AuditResponse is the response for GET /api/v1/audit
+type AuditResponse struct {
+	Count   int           `json:"count"`
+	Entries []audit.Entry `json:"entries"` // Newest first
+}
+
+// SetAuditLog records config, device, rule, geofence and API key changes
+// in l and enables GET /api/v1/audit
+func (s *Server) SetAuditLog(l *audit.Log) {
+	s.auditLog = l
+}
+
+// audited records the changes made by a mutating route. get returns the
+// current state of the resource the request targets, or nil if it does not
+// exist; it is called before and after the handler to diff the change.
+// Creates are diffed against the response body instead. Only successful
+// requests are recorded.
+func (s *Server) audited(resource string, action audit.Action, get func(*http.Request) any) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.auditLog == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var before any
+			if action != audit.ActionCreate && get != nil {
+				before = get(r)
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			var body bytes.Buffer
+			if action == audit.ActionCreate {
+				ww.Tee(&body)
+			}
+			next.ServeHTTP(ww, r)
+			if status := ww.Status(); status < 200 || status >= 300 {
+				return
+			}
+
+			var after any
+			switch {
+			case action == audit.ActionCreate:
+				json.Unmarshal(body.Bytes(), &after)
+			case action != audit.ActionDelete && get != nil:
+				after = get(r)
+			}
+
+			entry := audit.Entry{
+				Actor:      "anonymous",
+				RemoteAddr: r.RemoteAddr,
+				Action:     action,
+				Resource:   resource,
+				ResourceID: chi.URLParam(r, "id"),
+				Changes:    audit.Diff(before, after),
+			}
+			if user, ok := auth.GetUserFromContext(r.Context()); ok {
+				entry.Actor = user.Username
+				entry.Role = user.Role
+				entry.Tenant = user.Tenant
+			}
+			if entry.ResourceID == "" {
+				entry.ResourceID = resourceID(after)
+			}
+			if _, err := s.auditLog.Record(entry); err != nil {
+				log.Printf("[Audit] Failed to record %s %s: %v", action, resource, err)
+			}
+		})
+	}
+}
+
+// snapshot returns a get function for audited that calls a GET handler
+// with the request's URL parameters and decodes its response
+func (s *Server) snapshot(h http.HandlerFunc) func(*http.Request) any {
+	return func(r *http.Request) any {
+		req := r.Clone(r.Context())
+		req.Method = http.MethodGet
+		req.Body = http.NoBody
+		rec := &snapshotWriter{header: make(http.Header), status: http.StatusOK}
+		h(rec, req)
+		if rec.status != http.StatusOK {
+			return nil
+		}
+		var v any
+		json.Unmarshal(rec.body.Bytes(), &v)
+		return v
+	}
+}
+
+// snapshotWriter collects the response of a handler called by snapshot
+type snapshotWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *snapshotWriter) Header() http.Header         { return w.header }
+func (w *snapshotWriter) Write(b []byte) (int, error) { return w.body.Write(b) }
+func (w *snapshotWriter) WriteHeader(status int)      { w.status = status }
+
+// apiKeySnapshot returns the API key the request targets, without its hash
+func (s *Server) apiKeySnapshot(r *http.Request) any {
+	id := chi.URLParam(r, "id")
+	for _, key := range s.apiKeys.List() {
+		if key.ID == id {
+			data, _ := json.Marshal(key)
+			var v any
+			json.Unmarshal(data, &v)
+			return v
+		}
+	}
+	return nil
+}
+
+// resourceID returns the id of a created resource from its response, which
+// is either the resource itself or wraps it in one field, such as api_key
+func resourceID(v any) string {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return ""
+	}
+	if id, ok := obj["id"].(string); ok {
+		return id
+	}
+	for _, field := range obj {
+		if nested, ok := field.(map[string]any); ok {
+			if id, ok := nested["id"].(string); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// recordLockout logs and audits a lockout after repeated failed logins.
+// key is the locked username or client IP, e.g. "ip:192.0.2.7".
+func (s *Server) recordLockout(username, ip, key string) {
+	log.Printf("[HTTP] Logins locked out for %s after repeated failures (last username %q from %s)", key, username, ip)
+	if s.auditLog == nil {
+		return
+	}
+	entry := audit.Entry{
+		Actor:      username,
+		RemoteAddr: ip,
+		Action:     audit.ActionLockout,
+		Resource:   "login",
+		ResourceID: key,
+	}
+	if _, err := s.auditLog.Record(entry); err != nil {
+		log.Printf("[Audit] Failed to record lockout of %s: %v", key, err)
+	}
+}
+
+// clientIP returns the IP of the client, as set by the realIP middleware
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// handleGetAudit lists audit log entries
+// GET /api/v1/audit?actor=&resource=&resource_id=&since=&until=&limit=
+func (s *Server) handleGetAudit(w http.ResponseWriter, r *http.Request) {
+	if s.auditLog == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "audit log not enabled",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	q := audit.Query{
+		Actor:      query.Get("actor"),
+		Resource:   query.Get("resource"),
+		ResourceID: query.Get("resource_id"),
+		Limit:      100,
+	}
+	for name, dst := range map[string]*int64{"since": &q.Since, "until": &q.Until} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+					Error: "invalid " + name + " parameter",
+				})
+				return
+			}
+			*dst = n
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 1000 {
+			q.Limit = l
+		}
+	}
+
+	entries := s.auditLog.List(q)
+	s.writeJSON(w, http.StatusOK, AuditResponse{Count: len(entries), Entries: entries})
+}
@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API key scopes
+const (
+	ScopeRead  = "read"  // GET requests
+	ScopeWrite = "write" // Mutating requests
+	ScopeAdmin = "admin" // Everything, including API key management
+)
+
+// APIKeyPrefix is prepended to every generated key so keys are easy to
+// recognise in configs and logs
+const APIKeyPrefix = "outb_"
+
+var (
+	// ErrInvalidAPIKey is returned when an API key is unknown
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrAPIKeyExpired is returned when an API key has expired
+	ErrAPIKeyExpired = errors.New("api key has expired")
+	// ErrAPIKeyNotFound is returned when deleting an unknown key
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidScope is returned when creating a key with an unknown scope
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// APIKey is a long-lived credential for machine clients.
+// Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"` // First characters of the key, for identification
+	Hash       string   `json:"hash,omitempty"`
+	Scopes     []string `json:"scopes"`
+	Tenant     string   `json:"tenant,omitempty"`       // Tenant the key is limited to, empty = global
+	CreatedAt  int64    `json:"created_at"`             // Unix ms
+	ExpiresAt  int64    `json:"expires_at,omitempty"`   // Unix ms, 0 = never
+	LastUsedAt int64    `json:"last_used_at,omitempty"` // Unix ms, not persisted across restarts
+}
+
+// HasScope reports whether the key grants the given scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// public returns a copy of the key without its hash
+func (k *APIKey) public() APIKey {
+	c := *k
+	c.Hash = ""
+	c.Scopes = append([]string(nil), k.Scopes...)
+	return c
+}
+
+// KeyStore holds API keys in memory and optionally persists them to a
+// JSON file
+type KeyStore struct {
+	path string
+
+	mu     sync.RWMutex
+	keys   map[string]*APIKey // by ID
+	byHash map[string]*APIKey
+}
+
+// NewKeyStore creates a key store, loading existing keys from path.
+// An empty path keeps keys in memory only.
+func NewKeyStore(path string) (*KeyStore, error) {
+	s := &KeyStore{
+		path:   path,
+		keys:   make(map[string]*APIKey),
+		byHash: make(map[string]*APIKey),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("reading api keys: %w", err)
+	}
+
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parsing api keys: %w", err)
+	}
+	for _, k := range keys {
+		s.keys[k.ID] = k
+		s.byHash[k.Hash] = k
+	}
+	return s, nil
+}
+
+// Create generates a new key. The raw key is returned only once.
+func (s *KeyStore) Create(name string, scopes []string, expiresAt time.Time) (APIKey, string, error) {
+	return s.CreateForTenant(name, scopes, expiresAt, "")
+}
+
+// CreateForTenant generates a key limited to a tenant's devices. Tenant
+// keys may not have the admin scope.
+func (s *KeyStore) CreateForTenant(name string, scopes []string, expiresAt time.Time, tenant string) (APIKey, string, error) {
+	if len(scopes) == 0 {
+		scopes = []string{ScopeRead}
+	}
+	for _, scope := range scopes {
+		if scope != ScopeRead && scope != ScopeWrite && scope != ScopeAdmin {
+			return APIKey{}, "", fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if scope == ScopeAdmin && tenant != "" {
+			return APIKey{}, "", fmt.Errorf("%w: tenant keys cannot have the admin scope", ErrInvalidScope)
+		}
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("generating api key: %w", err)
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("generating api key id: %w", err)
+	}
+	raw := APIKeyPrefix + secret
+
+	key := &APIKey{
+		ID:        id,
+		Name:      name,
+		Prefix:    raw[:len(APIKeyPrefix)+8],
+		Hash:      hashAPIKey(raw),
+		Scopes:    append([]string(nil), scopes...),
+		Tenant:    tenant,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	if !expiresAt.IsZero() {
+		key.ExpiresAt = expiresAt.UnixMilli()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+	s.byHash[key.Hash] = key
+	if err := s.save(); err != nil {
+		delete(s.keys, key.ID)
+		delete(s.byHash, key.Hash)
+		return APIKey{}, "", err
+	}
+	return key.public(), raw, nil
+}
+
+// List returns all keys without hashes, oldest first
+func (s *KeyStore) List() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		result = append(result, k.public())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt == result[j].CreatedAt {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].CreatedAt < result[j].CreatedAt
+	})
+	return result
+}
+
+// Delete revokes a key
+func (s *KeyStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	delete(s.keys, id)
+	delete(s.byHash, key.Hash)
+	if err := s.save(); err != nil {
+		s.keys[id] = key
+		s.byHash[key.Hash] = key
+		return err
+	}
+	return nil
+}
+
+// Validate looks up a raw key and returns it if valid
+func (s *KeyStore) Validate(raw string) (APIKey, error) {
+	if !strings.HasPrefix(raw, APIKeyPrefix) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.byHash[hashAPIKey(raw)]
+	if !ok {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	now := time.Now().UnixMilli()
+	if key.ExpiresAt > 0 && now >= key.ExpiresAt {
+		return APIKey{}, ErrAPIKeyExpired
+	}
+	key.LastUsedAt = now
+	return key.public(), nil
+}
+
+// save writes all keys to the store file. Must be called with s.mu held.
+func (s *KeyStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	keys := make([]*APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt < keys[j].CreatedAt })
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding api keys: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("creating api key directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing api keys: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing api keys: %w", err)
+	}
+	return nil
+}
+
+// hashAPIKey returns the hex SHA-256 of a raw key
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes hex-encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
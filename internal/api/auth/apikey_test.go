@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyStore_CreateAndValidate(t *testing.T) {
+	s, err := NewKeyStore("")
+	if err != nil {
+		t.Fatalf("NewKeyStore failed: %v", err)
+	}
+
+	key, raw, err := s.Create("ingest", []string{ScopeRead, ScopeWrite}, time.Time{})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !strings.HasPrefix(raw, APIKeyPrefix) {
+		t.Errorf("Raw key %q should start with %q", raw, APIKeyPrefix)
+	}
+	if key.Hash != "" {
+		t.Error("Returned key should not expose its hash")
+	}
+	if !strings.HasPrefix(raw, key.Prefix) {
+		t.Errorf("Prefix %q should match raw key", key.Prefix)
+	}
+
+	got, err := s.Validate(raw)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if got.ID != key.ID || got.LastUsedAt == 0 {
+		t.Errorf("Unexpected validated key: %+v", got)
+	}
+
+	if _, err := s.Validate(raw + "x"); err != ErrInvalidAPIKey {
+		t.Errorf("Validate(wrong) error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+	if _, err := s.Validate("random"); err != ErrInvalidAPIKey {
+		t.Errorf("Validate(no prefix) error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+}
+
+func TestKeyStore_DefaultScopeAndInvalidScope(t *testing.T) {
+	s, _ := NewKeyStore("")
+
+	key, _, err := s.Create("viewer", nil, time.Time{})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(key.Scopes) != 1 || key.Scopes[0] != ScopeRead {
+		t.Errorf("Default scopes = %v, want [read]", key.Scopes)
+	}
+
+	if _, _, err := s.Create("bad", []string{"root"}, time.Time{}); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Create with invalid scope error = %v, want %v", err, ErrInvalidScope)
+	}
+}
+
+func TestKeyStore_Expired(t *testing.T) {
+	s, _ := NewKeyStore("")
+	_, raw, _ := s.Create("old", nil, time.Now().Add(-time.Minute))
+
+	if _, err := s.Validate(raw); err != ErrAPIKeyExpired {
+		t.Errorf("Validate error = %v, want %v", err, ErrAPIKeyExpired)
+	}
+}
+
+func TestKeyStore_Delete(t *testing.T) {
+	s, _ := NewKeyStore("")
+	key, raw, _ := s.Create("temp", nil, time.Time{})
+
+	if err := s.Delete(key.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.Validate(raw); err != ErrInvalidAPIKey {
+		t.Errorf("Validate after delete error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+	if err := s.Delete(key.ID); err != ErrAPIKeyNotFound {
+		t.Errorf("Delete twice error = %v, want %v", err, ErrAPIKeyNotFound)
+	}
+}
+
+func TestKeyStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "apikeys.json")
+
+	s1, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewKeyStore failed: %v", err)
+	}
+	_, raw, err := s1.Create("persisted", []string{ScopeAdmin}, time.Time{})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Key file not written: %v", err)
+	}
+	if strings.Contains(string(data), raw) {
+		t.Error("Key file must not contain the raw key")
+	}
+
+	s2, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(s2.List()) != 1 {
+		t.Fatalf("Reloaded keys = %d, want 1", len(s2.List()))
+	}
+	if _, err := s2.Validate(raw); err != nil {
+		t.Errorf("Reloaded key should validate: %v", err)
+	}
+}
+
+func TestMiddlewareWithAPIKeys(t *testing.T) {
+	m := NewManager("admin", "hash", "secret", 24)
+	keys, _ := NewKeyStore("")
+	_, readKey, _ := keys.Create("reader", []string{ScopeRead}, time.Time{})
+	_, writeKey, _ := keys.Create("writer", []string{ScopeRead, ScopeWrite}, time.Time{})
+	token, _, _ := m.GenerateToken("admin")
+
+	var gotUser User
+	handler := MiddlewareWithAPIKeys(m, keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _ = GetUserFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		method string
+		apiKey string
+		bearer string
+		want   int
+	}{
+		{"read key GET", "GET", readKey, "", http.StatusOK},
+		{"read key POST", "POST", readKey, "", http.StatusForbidden},
+		{"write key POST", "POST", writeKey, "", http.StatusOK},
+		{"invalid key", "GET", "outb_invalid", "", http.StatusUnauthorized},
+		{"bearer token", "POST", "", token, http.StatusOK},
+		{"no credentials", "GET", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("Status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set(APIKeyHeader, readKey)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if gotUser.Username != "apikey:reader" || gotUser.Role != "apikey" {
+		t.Errorf("Unexpected API key user: %+v", gotUser)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	m := NewManager("admin", "hash", "secret", 24)
+	keys, _ := NewKeyStore("")
+	_, readKey, _ := keys.Create("reader", []string{ScopeRead}, time.Time{})
+	_, writeOnly, _ := keys.Create("writer", []string{ScopeWrite}, time.Time{})
+	token, _, _ := m.GenerateToken("admin")
+
+	if user, err := Authenticate(m, keys, token, ""); err != nil || user.Username != "admin" {
+		t.Errorf("Token: user %+v, error %v", user, err)
+	}
+	if user, err := Authenticate(m, keys, "", readKey); err != nil || user.Username != "apikey:reader" {
+		t.Errorf("API key: user %+v, error %v", user, err)
+	}
+	tests := []struct {
+		name          string
+		token, apiKey string
+		want          error
+	}{
+		{"no credentials", "", "", ErrMissingCredentials},
+		{"invalid token", "not-a-jwt", "", ErrInvalidToken},
+		{"invalid key", "", "outb_invalid", ErrInvalidAPIKey},
+		{"key without read scope", "", writeOnly, ErrMissingScope},
+	}
+	for _, tt := range tests {
+		if _, err := Authenticate(m, keys, tt.token, tt.apiKey); err != tt.want {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	m := NewManager("admin", "hash", "secret", 24)
+	keys, _ := NewKeyStore("")
+	_, readKey, _ := keys.Create("reader", []string{ScopeRead}, time.Time{})
+
+	handler := ReadOnly(MiddlewareWithAPIKeys(m, keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest("POST", "/test", nil)
+	req.Header.Set(APIKeyHeader, readKey)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Status = %d, want %d for a read-only POST with a read key", rr.Code, http.StatusOK)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := RequireScope(ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name string
+		user *User
+		want int
+	}{
+		{"no user", nil, http.StatusForbidden},
+		{"jwt admin", &User{Username: "admin", Role: "admin"}, http.StatusOK},
+		{"write key", &User{Role: "apikey", Scopes: []string{ScopeWrite}}, http.StatusForbidden},
+		{"admin key", &User{Role: "apikey", Scopes: []string{ScopeAdmin}}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.user != nil {
+				req = req.WithContext(context.WithValue(req.Context(), UserContextKey, *tt.user))
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("Status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireScopeForWrites(t *testing.T) {
+	handler := RequireScopeForWrites(ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	writeKey := User{Role: "apikey", Scopes: []string{ScopeRead, ScopeWrite}}
+
+	tests := []struct {
+		name   string
+		method string
+		user   User
+		want   int
+	}{
+		{"read with write key", "GET", writeKey, http.StatusOK},
+		{"write with write key", "PUT", writeKey, http.StatusForbidden},
+		{"write with admin key", "PUT", User{Role: "apikey", Scopes: []string{ScopeAdmin}}, http.StatusOK},
+		{"write as admin", "POST", User{Username: "admin", Role: "admin"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			req = req.WithContext(context.WithValue(req.Context(), UserContextKey, tt.user))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("Status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyStore_CreateForTenant(t *testing.T) {
+	s, _ := NewKeyStore("")
+	key, raw, err := s.CreateForTenant("acme-ingest", []string{ScopeRead, ScopeWrite}, time.Time{}, "acme")
+	if err != nil {
+		t.Fatalf("CreateForTenant() error = %v", err)
+	}
+	if key.Tenant != "acme" || apiKeyUser(mustValidate(t, s, raw)).Tenant != "acme" {
+		t.Errorf("tenant not carried by key %+v", key)
+	}
+	if _, _, err := s.CreateForTenant("acme-admin", []string{ScopeAdmin}, time.Time{}, "acme"); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("admin scope for tenant key error = %v, want ErrInvalidScope", err)
+	}
+}
+
+func mustValidate(t *testing.T, s *KeyStore, raw string) APIKey {
+	t.Helper()
+	key, err := s.Validate(raw)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	return key
+}
@@ -15,21 +15,51 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 	// ErrTokenExpired is returned when token has expired
 	ErrTokenExpired = errors.New("token has expired")
+	// ErrTokenRevoked is returned when the token or its session was revoked
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrTokenReused is returned when a refresh token is presented again;
+	// the session is revoked, as the token may have been stolen
+	ErrTokenReused = errors.New("refresh token already used")
 )
 
-// JWTClaims represents the claims in the JWT token
+// Token types
+const (
+	tokenTypeAccess  = "access"  // Authorizes API requests for a few minutes
+	tokenTypeRefresh = "refresh" // Exchanged once for a new token pair
+)
+
+// defaultAccessTokenExpiry is the lifetime of access tokens
+const defaultAccessTokenExpiry = 15 * time.Minute
+
+// JWTClaims represents the claims in the JWT token. The registered ID
+// (jti) identifies the token; SessionID is shared by all tokens issued
+// from one login.
 type JWTClaims struct {
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	Tenant    string `json:"tenant,omitempty"`
+	TokenType string `json:"typ"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// Manager handles authentication operations
+// Manager handles authentication operations. A login starts a session
+// lasting tokenExpiryHrs, during which short-lived access tokens are
+// renewed with single-use refresh tokens.
 type Manager struct {
 	username       string
 	passwordHash   string
 	jwtSecret      []byte
 	tokenExpiryHrs int
+	accessExpiry   time.Duration
+	users          map[string]tenantUser
+	revoked        *RevocationList
+}
+
+// tenantUser is an additional login limited to one tenant
+type tenantUser struct {
+	passwordHash string
+	tenant       string
 }
 
 // NewManager creates a new auth manager
@@ -42,55 +72,177 @@ func NewManager(username, passwordHash, jwtSecret string, tokenExpiryHrs int) *M
 		passwordHash:   passwordHash,
 		jwtSecret:      []byte(jwtSecret),
 		tokenExpiryHrs: tokenExpiryHrs,
+		accessExpiry:   defaultAccessTokenExpiry,
+		users:          make(map[string]tenantUser),
+		revoked:        NewRevocationList(),
+	}
+}
+
+// SetAccessTokenExpiry sets the lifetime of access tokens. Access tokens
+// never outlive their session.
+func (m *Manager) SetAccessTokenExpiry(d time.Duration) {
+	if d > 0 {
+		m.accessExpiry = d
 	}
 }
 
-// ValidateCredentials checks if the provided credentials are valid
+// AddUser registers a user whose tokens are limited to the given tenant.
+// Tenant users get read and write access but never the admin scope.
+func (m *Manager) AddUser(username, passwordHash, tenant string) {
+	m.users[username] = tenantUser{passwordHash: passwordHash, tenant: tenant}
+}
+
+// ValidateCredentials checks if the provided credentials are valid. Unknown
+// usernames are checked against the admin hash too, so that the response
+// time does not reveal which usernames exist.
 func (m *Manager) ValidateCredentials(username, password string) error {
+	hash, known := m.passwordHash, true
 	if username != m.username {
-		return ErrInvalidCredentials
+		u, ok := m.users[username]
+		if ok {
+			hash = u.passwordHash
+		}
+		known = ok
 	}
 
 	// Check password against bcrypt hash
-	if err := bcrypt.CompareHashAndPassword([]byte(m.passwordHash), []byte(password)); err != nil {
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil || !known {
 		return ErrInvalidCredentials
 	}
 
 	return nil
 }
 
-// GenerateToken creates a new JWT token for the user
+// GenerateToken creates an access token for the user in a new session
 func (m *Manager) GenerateToken(username string) (string, int64, error) {
-	expiresAt := time.Now().Add(time.Duration(m.tokenExpiryHrs) * time.Hour)
+	pair, err := m.NewSession(username)
+	if err != nil {
+		return "", 0, err
+	}
+	return pair.AccessToken, pair.ExpiresAt, nil
+}
 
-	claims := &JWTClaims{
-		Username: username,
-		Role:     "admin", // Single-user mode, always admin
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "outb",
-		},
+// NewSession starts a session for the user, returning its first access
+// and refresh tokens
+func (m *Manager) NewSession(username string) (*TokenPair, error) {
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	user := m.LookupUser(username)
+	base := JWTClaims{
+		Username:  username,
+		Role:      user.Role,
+		Tenant:    user.Tenant,
+		SessionID: sessionID,
 	}
+	return m.issue(base, time.Now().Add(time.Duration(m.tokenExpiryHrs)*time.Hour))
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(m.jwtSecret)
+// Refresh exchanges a refresh token for a new token pair of the same
+// session. Each refresh token is accepted once: presenting it again
+// revokes the whole session.
+func (m *Manager) Refresh(refreshToken string) (*TokenPair, error) {
+	claims, err := m.parse(refreshToken, tokenTypeRefresh)
 	if err != nil {
-		return "", 0, err
+		return nil, err
+	}
+	if m.revoked.IsRevoked(claims.SessionID) {
+		return nil, ErrTokenRevoked
+	}
+	if !m.Revoke(claims.ID) {
+		m.Revoke(claims.SessionID)
+		return nil, ErrTokenReused
+	}
+
+	// The role and tenant are carried over rather than looked up, so the
+	// session keeps the rights it was granted at login
+	base := JWTClaims{
+		Username:  claims.Username,
+		Role:      claims.Role,
+		Tenant:    claims.Tenant,
+		SessionID: claims.SessionID,
 	}
+	return m.issue(base, claims.ExpiresAt.Time)
+}
 
-	return tokenString, expiresAt.Unix(), nil
+// Revoke adds a token or session ID to the revocation list. It returns
+// false if the ID was already revoked.
+func (m *Manager) Revoke(id string) bool {
+	// No token lives longer than a session, so the ID can be forgotten
+	// after one
+	return m.revoked.Revoke(id, time.Now().Add(time.Duration(m.tokenExpiryHrs)*time.Hour))
 }
 
-// ValidateToken validates a JWT token and returns the token info
-func (m *Manager) ValidateToken(tokenString string) (*TokenInfo, error) {
+// RevokeSession revokes the session of an access or refresh token, even
+// an expired one, and returns the session ID
+func (m *Manager) RevokeSession(tokenString string) (string, error) {
+	claims, err := m.parse(tokenString, "", jwt.WithoutClaimsValidation())
+	if err != nil {
+		return "", err
+	}
+	m.Revoke(claims.SessionID)
+	return claims.SessionID, nil
+}
+
+// Revocations returns the revocation list
+func (m *Manager) Revocations() *RevocationList {
+	return m.revoked
+}
+
+// issue signs an access and a refresh token for a session
+func (m *Manager) issue(base JWTClaims, sessionExpiresAt time.Time) (*TokenPair, error) {
+	accessExpiresAt := time.Now().Add(m.accessExpiry)
+	if accessExpiresAt.After(sessionExpiresAt) {
+		accessExpiresAt = sessionExpiresAt
+	}
+
+	access, err := m.sign(base, tokenTypeAccess, accessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := m.sign(base, tokenTypeRefresh, sessionExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		ExpiresAt:        accessExpiresAt.Unix(),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: sessionExpiresAt.Unix(),
+		SessionID:        base.SessionID,
+		User:             User{Username: base.Username, Role: base.Role, Tenant: base.Tenant},
+	}, nil
+}
+
+// sign creates a token of the given type with a new token ID
+func (m *Manager) sign(claims JWTClaims, tokenType string, expiresAt time.Time) (string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	claims.TokenType = tokenType
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        id,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		Issuer:    "outb",
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
+	return token.SignedString(m.jwtSecret)
+}
+
+// parse verifies a token of the given type ("" = any type)
+func (m *Manager) parse(tokenString, tokenType string, opts ...jwt.ParserOption) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Validate signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
 		return m.jwtSecret, nil
-	})
+	}, opts...)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -100,13 +252,31 @@ func (m *Manager) ValidateToken(tokenString string) (*TokenInfo, error) {
 	}
 
 	claims, ok := token.Claims.(*JWTClaims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.ID == "" || claims.SessionID == "" {
 		return nil, ErrInvalidToken
 	}
+	if tokenType != "" && claims.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ValidateToken validates an access token and returns the token info
+func (m *Manager) ValidateToken(tokenString string) (*TokenInfo, error) {
+	claims, err := m.parse(tokenString, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	if m.revoked.IsRevoked(claims.ID) || m.revoked.IsRevoked(claims.SessionID) {
+		return nil, ErrTokenRevoked
+	}
 
 	return &TokenInfo{
 		Username:  claims.Username,
 		Role:      claims.Role,
+		Tenant:    claims.Tenant,
+		ID:        claims.ID,
+		SessionID: claims.SessionID,
 		ExpiresAt: claims.ExpiresAt.Time,
 	}, nil
 }
@@ -128,3 +298,12 @@ func (m *Manager) GetUser() User {
 		Role:     "admin",
 	}
 }
+
+// LookupUser returns the user for a username: a tenant user if one is
+// registered under that name, the admin otherwise
+func (m *Manager) LookupUser(username string) User {
+	if u, ok := m.users[username]; ok && username != m.username {
+		return User{Username: username, Role: "tenant", Tenant: u.tenant}
+	}
+	return User{Username: username, Role: "admin"}
+}
@@ -94,10 +94,106 @@ func TestManager_GenerateToken(t *testing.T) {
 		t.Error("ExpiresAt should not be zero")
 	}
 
-	// ExpiresAt should be approximately 24 hours from now
-	expectedExpiry := time.Now().Add(24 * time.Hour).Unix()
+	// Access tokens are short-lived: ExpiresAt should be approximately
+	// 15 minutes from now
+	expectedExpiry := time.Now().Add(15 * time.Minute).Unix()
 	if expiresAt < expectedExpiry-60 || expiresAt > expectedExpiry+60 {
-		t.Errorf("ExpiresAt should be ~24h from now, got %d, want ~%d", expiresAt, expectedExpiry)
+		t.Errorf("ExpiresAt should be ~15m from now, got %d, want ~%d", expiresAt, expectedExpiry)
+	}
+}
+
+func TestManager_Refresh(t *testing.T) {
+	m := NewManager("admin", "hash", "secret", 24)
+	m.SetAccessTokenExpiry(5 * time.Minute)
+
+	pair, err := m.NewSession("admin")
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	if want := time.Now().Add(5 * time.Minute).Unix(); pair.ExpiresAt < want-60 || pair.ExpiresAt > want+60 {
+		t.Errorf("Access token ExpiresAt = %d, want ~%d", pair.ExpiresAt, want)
+	}
+	if want := time.Now().Add(24 * time.Hour).Unix(); pair.RefreshExpiresAt < want-60 || pair.RefreshExpiresAt > want+60 {
+		t.Errorf("RefreshExpiresAt = %d, want ~%d", pair.RefreshExpiresAt, want)
+	}
+
+	// Refresh tokens do not authorize requests and access tokens do not refresh
+	if _, err := m.ValidateToken(pair.RefreshToken); err != ErrInvalidToken {
+		t.Errorf("ValidateToken(refresh token) error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := m.Refresh(pair.AccessToken); err != ErrInvalidToken {
+		t.Errorf("Refresh(access token) error = %v, want ErrInvalidToken", err)
+	}
+
+	next, err := m.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if next.SessionID != pair.SessionID || next.RefreshExpiresAt != pair.RefreshExpiresAt || next.User.Role != "admin" {
+		t.Errorf("Refreshed pair = %+v, want the same session", next)
+	}
+	info, err := m.ValidateToken(next.AccessToken)
+	if err != nil || info.SessionID != pair.SessionID || info.ID == "" {
+		t.Fatalf("ValidateToken(refreshed) = %+v, %v", info, err)
+	}
+
+	// Replaying a spent refresh token revokes the whole session
+	if _, err := m.Refresh(pair.RefreshToken); err != ErrTokenReused {
+		t.Errorf("Refresh(spent token) error = %v, want ErrTokenReused", err)
+	}
+	if _, err := m.ValidateToken(next.AccessToken); err != ErrTokenRevoked {
+		t.Errorf("ValidateToken after reuse error = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := m.Refresh(next.RefreshToken); err != ErrTokenRevoked {
+		t.Errorf("Refresh after reuse error = %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestManager_Revoke(t *testing.T) {
+	m := NewManager("admin", "hash", "secret", 24)
+	a, _ := m.NewSession("admin")
+	b, _ := m.NewSession("admin")
+
+	// Revoking a token ID kills that token only
+	info, _ := m.ValidateToken(a.AccessToken)
+	if !m.Revoke(info.ID) || m.Revoke(info.ID) {
+		t.Error("Revoke() should report only the first revocation")
+	}
+	if _, err := m.ValidateToken(a.AccessToken); err != ErrTokenRevoked {
+		t.Errorf("ValidateToken(revoked) error = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := m.Refresh(a.RefreshToken); err != nil {
+		t.Errorf("Refresh() of the session error = %v", err)
+	}
+
+	// Logging out with the refresh token kills the whole session
+	sessionID, err := m.RevokeSession(b.RefreshToken)
+	if err != nil || sessionID != b.SessionID {
+		t.Fatalf("RevokeSession() = %s, %v", sessionID, err)
+	}
+	if _, err := m.ValidateToken(b.AccessToken); err != ErrTokenRevoked {
+		t.Errorf("ValidateToken after logout error = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := m.RevokeSession("not-a-token"); err != ErrInvalidToken {
+		t.Errorf("RevokeSession(garbage) error = %v, want ErrInvalidToken", err)
+	}
+	if n := m.Revocations().Len(); n != 3 {
+		t.Errorf("Revocations = %d, want the token, the spent refresh token and the session", n)
+	}
+}
+
+func TestRevocationList_Prune(t *testing.T) {
+	l := NewRevocationList()
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	l.Revoke("old", now.Add(time.Minute))
+	l.Revoke("new", now.Add(time.Hour))
+	now = now.Add(2 * time.Minute)
+	l.Revoke("newer", now.Add(time.Hour))
+
+	if l.IsRevoked("old") || !l.IsRevoked("new") || l.Len() != 2 {
+		t.Errorf("Expired IDs should be forgotten, len = %d", l.Len())
 	}
 }
 
@@ -449,3 +545,46 @@ func TestMiddleware_BearerCaseInsensitive(t *testing.T) {
 		})
 	}
 }
+
+func TestManager_TenantUser(t *testing.T) {
+	hash, _ := HashPassword("tenantpass")
+	m := NewManager("admin", "hash", "secret", 24)
+	m.AddUser("acme-ops", hash, "acme")
+
+	if err := m.ValidateCredentials("acme-ops", "tenantpass"); err != nil {
+		t.Fatalf("ValidateCredentials() error = %v", err)
+	}
+	if err := m.ValidateCredentials("acme-ops", "wrong"); err != ErrInvalidCredentials {
+		t.Errorf("ValidateCredentials() with wrong password error = %v", err)
+	}
+
+	token, _, _ := m.GenerateToken("acme-ops")
+	info, err := m.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if info.Role != "tenant" || info.Tenant != "acme" {
+		t.Errorf("TokenInfo = %+v, want tenant role for acme", info)
+	}
+
+	user := m.LookupUser("acme-ops")
+	if !user.HasScope(ScopeWrite) || user.HasScope(ScopeAdmin) {
+		t.Error("Tenant users should have read/write but not admin scope")
+	}
+}
+
+func TestRequireGlobal(t *testing.T) {
+	handler := RequireGlobal(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for tenant, want := range map[string]int{"": http.StatusOK, "acme": http.StatusForbidden} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey, User{Username: "u", Tenant: tenant}))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Errorf("tenant %q: status = %d, want %d", tenant, rr.Code, want)
+		}
+	}
+}
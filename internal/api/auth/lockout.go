@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTrackedKeys bounds the number of usernames and IPs with recorded
+// failures; expired ones are pruned beyond it
+const maxTrackedKeys = 10000
+
+// Lockout limits password guessing at the login endpoint. Failed logins are
+// counted per username and per client IP; once either reaches the maximum
+// within the window, its logins are refused until the lockout ends, even
+// with the right password. A nil Lockout never locks out.
+type Lockout struct {
+	max      int
+	window   time.Duration
+	duration time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]*failures
+}
+
+// failures are the recent failed logins of one username or IP
+type failures struct {
+	count       int
+	since       time.Time // First failure in the window
+	lockedUntil time.Time
+}
+
+// NewLockout creates a lockout after maxFailures failures within window,
+// lasting duration
+func NewLockout(maxFailures int, window, duration time.Duration) *Lockout {
+	return &Lockout{
+		max:      maxFailures,
+		window:   window,
+		duration: duration,
+		now:      time.Now,
+		keys:     make(map[string]*failures),
+	}
+}
+
+// Lockout keys
+func userKey(username string) string { return "user:" + username }
+func ipKey(ip string) string         { return "ip:" + ip }
+
+// Check returns how long logins for the username or from the IP stay
+// refused, 0 if they are allowed
+func (l *Lockout) Check(username, ip string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		if f, ok := l.keys[key]; ok {
+			wait = max(wait, f.lockedUntil.Sub(now))
+		}
+	}
+	return wait
+}
+
+// Fail records a failed login and returns the keys it locked out, e.g.
+// "user:admin" or "ip:192.0.2.7"
+func (l *Lockout) Fail(username, ip string) []string {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.keys) >= maxTrackedKeys {
+		l.prune(now)
+	}
+
+	var locked []string
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		f, ok := l.keys[key]
+		if !ok {
+			f = &failures{since: now}
+			l.keys[key] = f
+		}
+		if now.Sub(f.since) > l.window {
+			f.count, f.since = 0, now
+		}
+		f.count++
+		if f.count >= l.max && now.After(f.lockedUntil) {
+			f.lockedUntil = now.Add(l.duration)
+			f.count, f.since = 0, now
+			locked = append(locked, key)
+		}
+	}
+	return locked
+}
+
+// Succeed clears the failures of a username after a successful login. The
+// IP's failures are kept, so that one valid account cannot reset the count
+// of guesses against others.
+func (l *Lockout) Succeed(username string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.keys, userKey(username))
+}
+
+// prune drops the keys that are neither locked nor have failures within the
+// window. Caller must hold the lock.
+func (l *Lockout) prune(now time.Time) {
+	for key, f := range l.keys {
+		if now.After(f.lockedUntil) && now.Sub(f.since) > l.window {
+			delete(l.keys, key)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewLockout(3, time.Minute, 5*time.Minute)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if locked := l.Fail("admin", "192.0.2.7"); len(locked) != 0 {
+			t.Fatalf("Failure %d locked %v", i+1, locked)
+		}
+	}
+	locked := l.Fail("admin", "192.0.2.7")
+	if len(locked) != 2 || locked[0] != "user:admin" || locked[1] != "ip:192.0.2.7" {
+		t.Fatalf("Third failure locked %v", locked)
+	}
+
+	// Both the username and the IP are refused, from anywhere and for anyone
+	if wait := l.Check("admin", "198.51.100.1"); wait != 5*time.Minute {
+		t.Errorf("Check(admin) = %v", wait)
+	}
+	if wait := l.Check("ops", "192.0.2.7"); wait != 5*time.Minute {
+		t.Errorf("Check(ip) = %v", wait)
+	}
+	if wait := l.Check("ops", "198.51.100.1"); wait != 0 {
+		t.Errorf("Check(other) = %v", wait)
+	}
+
+	now = now.Add(5*time.Minute + time.Second)
+	if wait := l.Check("admin", "192.0.2.7"); wait != 0 {
+		t.Errorf("Check after the lockout = %v", wait)
+	}
+}
+
+func TestLockout_Window(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewLockout(2, time.Minute, time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.Fail("admin", "192.0.2.7")
+	now = now.Add(2 * time.Minute)
+	if locked := l.Fail("admin", "192.0.2.7"); len(locked) != 0 {
+		t.Errorf("Failures outside the window locked %v", locked)
+	}
+}
+
+func TestLockout_Succeed(t *testing.T) {
+	l := NewLockout(2, time.Minute, time.Minute)
+	l.Fail("admin", "192.0.2.7")
+	l.Succeed("admin")
+
+	// The username count restarts, the IP count does not
+	locked := l.Fail("admin", "192.0.2.7")
+	if len(locked) != 1 || locked[0] != "ip:192.0.2.7" {
+		t.Errorf("Locked %v, want only the IP", locked)
+	}
+}
+
+func TestLockout_Nil(t *testing.T) {
+	var l *Lockout
+	if locked := l.Fail("admin", "192.0.2.7"); locked != nil {
+		t.Errorf("Fail() = %v", locked)
+	}
+	l.Succeed("admin")
+	if wait := l.Check("admin", "192.0.2.7"); wait != 0 {
+		t.Errorf("Check() = %v", wait)
+	}
+}
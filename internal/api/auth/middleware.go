@@ -2,14 +2,55 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 )
 
+// APIKeyHeader is the request header carrying an API key
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrMissingCredentials is returned by Authenticate without a token or API key
+	ErrMissingCredentials = errors.New("missing credentials")
+	// ErrMissingScope is returned by Authenticate for an API key without the read scope
+	ErrMissingScope = errors.New("api key lacks required scope")
+)
+
 // Middleware creates an authentication middleware for chi router
 func Middleware(manager *Manager) func(http.Handler) http.Handler {
+	return MiddlewareWithAPIKeys(manager, nil)
+}
+
+// MiddlewareWithAPIKeys creates an authentication middleware that accepts
+// either a Bearer JWT or an X-API-Key header. API keys need the read scope
+// for GET/HEAD requests and the write scope for everything else.
+func MiddlewareWithAPIKeys(manager *Manager, keys *KeyStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// API key takes precedence when present
+			if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" && keys != nil {
+				key, err := keys.Validate(apiKey)
+				if err != nil {
+					switch err {
+					case ErrAPIKeyExpired:
+						http.Error(w, `{"error": "api key has expired"}`, http.StatusUnauthorized)
+					default:
+						http.Error(w, `{"error": "invalid api key"}`, http.StatusUnauthorized)
+					}
+					return
+				}
+
+				if !key.HasScope(requiredScope(r)) {
+					http.Error(w, `{"error": "api key lacks required scope"}`, http.StatusForbidden)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserContextKey, apiKeyUser(key))
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			// Extract token from Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
@@ -32,6 +73,8 @@ func Middleware(manager *Manager) func(http.Handler) http.Handler {
 				switch err {
 				case ErrTokenExpired:
 					http.Error(w, `{"error": "token has expired"}`, http.StatusUnauthorized)
+				case ErrTokenRevoked:
+					http.Error(w, `{"error": "token has been revoked"}`, http.StatusUnauthorized)
 				default:
 					http.Error(w, `{"error": "invalid token"}`, http.StatusUnauthorized)
 				}
@@ -42,6 +85,7 @@ func Middleware(manager *Manager) func(http.Handler) http.Handler {
 			user := User{
 				Username: tokenInfo.Username,
 				Role:     tokenInfo.Role,
+				Tenant:   tokenInfo.Tenant,
 			}
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
 
@@ -51,17 +95,146 @@ func Middleware(manager *Manager) func(http.Handler) http.Handler {
 	}
 }
 
+// RequireScope rejects requests whose user lacks the given scope.
+// It must be used after Middleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r.Context())
+			if !ok || !user.HasScope(scope) {
+				http.Error(w, `{"error": "insufficient scope"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScopeForWrites rejects requests other than GET/HEAD/OPTIONS whose
+// user lacks the given scope, leaving reads to the authentication
+// middleware. It must be used after Middleware.
+func RequireScopeForWrites(scope string) func(http.Handler) http.Handler {
+	requireScope := RequireScope(scope)
+	return func(next http.Handler) http.Handler {
+		guarded := requireScope(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requiredScope(r) == ScopeRead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			guarded.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireGlobal rejects requests from users limited to a tenant, for
+// endpoints exposing gateway-wide state. It must be used after Middleware.
+func RequireGlobal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if TenantFromContext(r.Context()) != "" {
+			http.Error(w, `{"error": "not available to tenant users"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ReadOnly marks requests as reads, so that API keys with the read scope
+// may POST to endpoints that only query, such as GraphQL. It must be used
+// before Middleware.
+func ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), readOnlyContextKey, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requiredScope returns the scope an API key needs for the request method
+func requiredScope(r *http.Request) string {
+	if readOnly, _ := r.Context().Value(readOnlyContextKey).(bool); readOnly {
+		return ScopeRead
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	default:
+		return ScopeWrite
+	}
+}
+
+// Authenticate returns the user of a bearer token or, if keys is set, of an
+// API key with the read scope. It serves clients that cannot send headers,
+// such as browser WebSockets passing credentials in the URL or a message.
+func Authenticate(manager *Manager, keys *KeyStore, token, apiKey string) (User, error) {
+	if apiKey != "" && keys != nil {
+		key, err := keys.Validate(apiKey)
+		if err != nil {
+			return User{}, err
+		}
+		if !key.HasScope(ScopeRead) {
+			return User{}, ErrMissingScope
+		}
+		return apiKeyUser(key), nil
+	}
+	if token == "" {
+		return User{}, ErrMissingCredentials
+	}
+	if manager == nil {
+		return User{}, ErrInvalidToken
+	}
+	tokenInfo, err := manager.ValidateToken(token)
+	if err != nil {
+		return User{}, err
+	}
+	return User{
+		Username: tokenInfo.Username,
+		Role:     tokenInfo.Role,
+		Tenant:   tokenInfo.Tenant,
+	}, nil
+}
+
+// apiKeyUser returns the context user for an API key
+func apiKeyUser(key APIKey) User {
+	return User{
+		Username: "apikey:" + key.Name,
+		Role:     "apikey",
+		Scopes:   key.Scopes,
+		Tenant:   key.Tenant,
+	}
+}
+
 // GetUserFromContext extracts user information from request context
 func GetUserFromContext(ctx context.Context) (User, bool) {
 	user, ok := ctx.Value(UserContextKey).(User)
 	return user, ok
 }
 
+// TenantFromContext returns the tenant of the request user, or "" for
+// global users and unauthenticated requests
+func TenantFromContext(ctx context.Context) string {
+	user, _ := GetUserFromContext(ctx)
+	return user.Tenant
+}
+
 // OptionalMiddleware creates a middleware that extracts user info if token is present
 // but doesn't require authentication
 func OptionalMiddleware(manager *Manager) func(http.Handler) http.Handler {
+	return OptionalMiddlewareWithAPIKeys(manager, nil)
+}
+
+// OptionalMiddlewareWithAPIKeys is OptionalMiddleware that also accepts an
+// X-API-Key header
+func OptionalMiddlewareWithAPIKeys(manager *Manager, keys *KeyStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" && keys != nil {
+				if key, err := keys.Validate(apiKey); err == nil && key.HasScope(ScopeRead) {
+					ctx := context.WithValue(r.Context(), UserContextKey, apiKeyUser(key))
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				next.ServeHTTP(w, r)
@@ -83,6 +256,7 @@ func OptionalMiddleware(manager *Manager) func(http.Handler) http.Handler {
 			user := User{
 				Username: tokenInfo.Username,
 				Role:     tokenInfo.Role,
+				Tenant:   tokenInfo.Tenant,
 			}
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationList holds the IDs of revoked tokens and sessions. An ID is
+// kept until every token carrying it would have expired anyway, so the
+// list stays bounded by the number of revocations per session lifetime.
+// It lives in memory: a restart forgets revocations, and rotating the JWT
+// secret is the way to kill every outstanding token.
+type RevocationList struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	revoked map[string]time.Time // ID -> when it can be forgotten
+}
+
+// NewRevocationList creates an empty revocation list
+func NewRevocationList() *RevocationList {
+	return &RevocationList{
+		now:     time.Now,
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// Revoke adds an ID until the given time. It returns false if the ID was
+// already revoked, so that a token can be spent exactly once.
+func (l *RevocationList) Revoke(id string, until time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for k, t := range l.revoked {
+		if now.After(t) {
+			delete(l.revoked, k)
+		}
+	}
+	if _, ok := l.revoked[id]; ok {
+		return false
+	}
+	l.revoked[id] = until
+	return true
+}
+
+// IsRevoked reports whether an ID has been revoked
+func (l *RevocationList) IsRevoked(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.revoked[id]
+	return ok
+}
+
+// Len returns the number of revoked IDs
+func (l *RevocationList) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.revoked)
+}
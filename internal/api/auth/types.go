@@ -5,8 +5,26 @@ import "time"
 
 // User represents an authenticated user
 type User struct {
-	Username string `json:"username"`
-	Role     string `json:"role"` // "admin" for single-user mode
+	Username string   `json:"username"`
+	Role     string   `json:"role"`             // "admin" for the configured admin, "tenant" for tenant users, "apikey" for API keys
+	Scopes   []string `json:"scopes,omitempty"` // API key scopes (admin users have all scopes)
+	Tenant   string   `json:"tenant,omitempty"` // Tenant the user is limited to, empty for global users
+}
+
+// HasScope reports whether the user is allowed the given scope
+func (u User) HasScope(scope string) bool {
+	if u.Role == "admin" {
+		return true
+	}
+	if u.Role == "tenant" {
+		return scope == ScopeRead || scope == ScopeWrite
+	}
+	for _, s := range u.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
 }
 
 // LoginRequest is the request body for login
@@ -15,11 +33,29 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-// LoginResponse is the response for successful login
+// LoginResponse is the response for successful login and refresh
 type LoginResponse struct {
-	Token     string `json:"token"`
-	ExpiresAt int64  `json:"expires_at"`
-	User      User   `json:"user"`
+	Token            string `json:"token"`
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"` // End of the session
+	SessionID        string `json:"session_id"`
+	User             User   `json:"user"`
+}
+
+// RefreshRequest is the request body for refresh, and optionally logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenPair is an access token with the refresh token renewing it
+type TokenPair struct {
+	AccessToken      string
+	ExpiresAt        int64 // Unix seconds
+	RefreshToken     string
+	RefreshExpiresAt int64 // Unix seconds
+	SessionID        string
+	User             User
 }
 
 // Claims represents JWT claims
@@ -32,6 +68,9 @@ type Claims struct {
 type TokenInfo struct {
 	Username  string
 	Role      string
+	Tenant    string
+	ID        string // Token ID (jti)
+	SessionID string
 	ExpiresAt time.Time
 }
 
@@ -41,4 +80,7 @@ type ContextKey string
 const (
 	// UserContextKey is the context key for storing user information
 	UserContextKey ContextKey = "user"
+
+	// readOnlyContextKey marks requests that only read, whatever their method
+	readOnlyContextKey ContextKey = "read_only"
 )
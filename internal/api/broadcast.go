@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/broadcast"
+)
+
+// BroadcastForwarder is optionally implemented by a StateProvider to forward
+// operator broadcasts through a northbound publisher
+type BroadcastForwarder interface {
+	PublishBroadcast(publisher string, msg *broadcast.Message) error
+}
+
+// BroadcastRequest is the request body for POST /api/v1/broadcast
+type BroadcastRequest struct {
+	Message  string                `json:"message"`
+	Severity alerter.AlertSeverity `json:"severity,omitempty"` // info | warning | critical (default info)
+	TTLSec   int                   `json:"ttl_sec,omitempty"`  // Seconds until the message expires (default 3600)
+	MQTT     bool                  `json:"mqtt,omitempty"`     // Also publish to {prefix}/broadcast
+}
+
+// BroadcastResponse is the response for POST /api/v1/broadcast
+type BroadcastResponse struct {
+	Broadcast     *broadcast.Message `json:"broadcast"`
+	Clients       int                `json:"clients"` // WebSocket clients the message was sent to
+	MQTTPublished bool               `json:"mqtt_published,omitempty"`
+	MQTTError     string             `json:"mqtt_error,omitempty"`
+}
+
+// BroadcastsResponse lists the active broadcasts
+type BroadcastsResponse struct {
+	Count      int                  `json:"count"`
+	Broadcasts []*broadcast.Message `json:"broadcasts"`
+}
+
+// handleCreateBroadcast pushes an operator message to all UIs
+// POST /api/v1/broadcast
+func (s *Server) handleCreateBroadcast(w http.ResponseWriter, r *http.Request) {
+	var req BroadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	forwarder, canForward := s.provider.(BroadcastForwarder)
+	if req.MQTT && !canForward {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "broadcast forwarding not supported",
+		})
+		return
+	}
+
+	author := ""
+	if user, ok := auth.GetUserFromContext(r.Context()); ok {
+		author = user.Username
+	}
+
+	msg, err := s.broadcasts.Add(req.Message, req.Severity, author, time.Duration(req.TTLSec)*time.Second)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, broadcast.ErrInvalidMessage) {
+			status = http.StatusBadRequest
+		}
+		s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	s.hub.BroadcastOperatorMessage(msg)
+	resp := BroadcastResponse{Broadcast: msg, Clients: s.hub.ClientCount()}
+	log.Printf("[HTTP] Broadcast %s (%s): %q", msg.ID, msg.Severity, msg.Text)
+
+	// The message is already on screen; a failed MQTT publish is reported
+	// but does not fail the request
+	if req.MQTT {
+		if err := forwarder.PublishBroadcast("mqtt", msg); err != nil {
+			log.Printf("[HTTP] Failed to publish broadcast %s to MQTT: %v", msg.ID, err)
+			resp.MQTTError = err.Error()
+		} else {
+			resp.MQTTPublished = true
+		}
+	}
+
+	s.writeJSON(w, http.StatusCreated, resp)
+}
+
+// handleGetBroadcasts lists the unexpired broadcasts
+// GET /api/v1/broadcast
+func (s *Server) handleGetBroadcasts(w http.ResponseWriter, r *http.Request) {
+	active := s.broadcasts.Active()
+	s.writeJSON(w, http.StatusOK, BroadcastsResponse{Count: len(active), Broadcasts: active})
+}
+
+// handleDeleteBroadcast withdraws a broadcast before it expires
+// DELETE /api/v1/broadcast/{id}
+func (s *Server) handleDeleteBroadcast(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := s.broadcasts.Delete(id); err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.hub.BroadcastCleared(id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core"
+	"github.com/open-uav/telemetry-bridge/internal/core/chaos"
+)
+
+// ChaosProvider is optionally implemented by a StateProvider to expose
+// fault injection. Chaos returns nil unless built with the "chaos" tag.
+type ChaosProvider interface {
+	Chaos() *chaos.Injector
+	ReconnectAdapter(name string) error
+}
+
+// ChaosResponse is the response for the chaos admin endpoints
+type ChaosResponse struct {
+	Settings chaos.Settings `json:"settings"`
+	Stats    chaos.Stats    `json:"stats"`
+}
+
+// chaosInjector returns the provider's fault injector, or nil
+func (s *Server) chaosInjector() (ChaosProvider, *chaos.Injector) {
+	cp, ok := s.provider.(ChaosProvider)
+	if !ok {
+		return nil, nil
+	}
+	return cp, cp.Chaos()
+}
+
+// setupChaosRoutes registers the admin fault injection endpoints when
+// fault injection is compiled in
+func (s *Server) setupChaosRoutes(r chi.Router) {
+	if _, injector := s.chaosInjector(); injector == nil {
+		return
+	}
+
+	r.Route("/admin/chaos", func(r chi.Router) {
+		if s.authEnabled {
+			r.Use(auth.RequireScope(auth.ScopeAdmin))
+		}
+		r.Get("/", s.handleGetChaos)
+		r.Put("/", s.handleSetChaos)
+		r.Delete("/", s.handleClearChaos)
+		r.Post("/adapters/{name}/reconnect", s.handleChaosReconnect)
+	})
+}
+
+// handleGetChaos returns the active faults and counters
+// GET /api/v1/admin/chaos
+func (s *Server) handleGetChaos(w http.ResponseWriter, r *http.Request) {
+	_, injector := s.chaosInjector()
+	s.writeJSON(w, http.StatusOK, ChaosResponse{Settings: injector.Settings(), Stats: injector.Stats()})
+}
+
+// handleSetChaos replaces the active faults
+// PUT /api/v1/admin/chaos
+func (s *Server) handleSetChaos(w http.ResponseWriter, r *http.Request) {
+	_, injector := s.chaosInjector()
+
+	var settings chaos.Settings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+	if err := injector.SetSettings(settings); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	log.Printf("[Chaos] Faults updated: drop %.1f%%, publisher delays %v", settings.DropPercent, settings.PublisherDelayMs)
+	s.writeJSON(w, http.StatusOK, ChaosResponse{Settings: injector.Settings(), Stats: injector.Stats()})
+}
+
+// handleClearChaos removes all faults
+// DELETE /api/v1/admin/chaos
+func (s *Server) handleClearChaos(w http.ResponseWriter, r *http.Request) {
+	_, injector := s.chaosInjector()
+	injector.SetSettings(chaos.Settings{})
+	log.Printf("[Chaos] Faults cleared")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleChaosReconnect forces an adapter to reconnect
+// POST /api/v1/admin/chaos/adapters/{name}/reconnect
+func (s *Server) handleChaosReconnect(w http.ResponseWriter, r *http.Request) {
+	cp, _ := s.chaosInjector()
+	name := chi.URLParam(r, "name")
+
+	if err := cp.ReconnectAdapter(name); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrAdapterNotFound) {
+			status = http.StatusNotFound
+		}
+		s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Adapter reconnected",
+		"adapter": name,
+	})
+}
@@ -0,0 +1,84 @@
+//go:build chaos
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core"
+	"github.com/open-uav/telemetry-bridge/internal/core/chaos"
+)
+
+// chaosProvider adds fault injection to the mock provider
+type chaosProvider struct {
+	*mockProvider
+	injector   *chaos.Injector
+	reconnects []string
+}
+
+func (p *chaosProvider) Chaos() *chaos.Injector { return p.injector }
+
+func (p *chaosProvider) ReconnectAdapter(name string) error {
+	if name != "mavlink" {
+		return core.ErrAdapterNotFound
+	}
+	p.reconnects = append(p.reconnects, name)
+	return nil
+}
+
+func TestHandleChaos(t *testing.T) {
+	provider := &chaosProvider{mockProvider: newMockProvider(), injector: chaos.New()}
+	server := New(config.HTTPConfig{Enabled: true}, provider, "test-version")
+
+	body := `{"drop_percent": 25, "publisher_delay_ms": {"mqtt": 500}}`
+	req := httptest.NewRequest("PUT", "/api/v1/admin/chaos", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/admin/chaos", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	var resp ChaosResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Settings.DropPercent != 25 || resp.Settings.PublisherDelayMs["mqtt"] != 500 {
+		t.Errorf("Unexpected settings: %+v", resp.Settings)
+	}
+
+	req = httptest.NewRequest("PUT", "/api/v1/admin/chaos", strings.NewReader(`{"drop_percent": 150}`))
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Invalid PUT: expected status 400, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/admin/chaos/adapters/mavlink/reconnect", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || len(provider.reconnects) != 1 {
+		t.Errorf("Reconnect: status %d, reconnects %v", w.Code, provider.reconnects)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/admin/chaos/adapters/missing/reconnect", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Reconnect missing: expected status 404, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/v1/admin/chaos", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent || provider.injector.Settings().DropPercent != 0 {
+		t.Errorf("Clear: status %d, settings %+v", w.Code, provider.injector.Settings())
+	}
+}
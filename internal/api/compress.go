@@ -0,0 +1,121 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// compressedTypes are the response types gzipped when compression is enabled
+var compressedTypes = []string{
+	"application/json",
+	"application/geo+json",
+	"application/gpx+xml",
+	"application/vnd.google-earth.kml+xml",
+	"text/csv",
+}
+
+// compressResponses gzips responses of the compressed types for clients
+// that accept it
+func compressResponses(level int) func(http.Handler) http.Handler {
+	if level < 1 || level > 9 {
+		level = gzip.DefaultCompression
+	}
+	return middleware.Compress(level, compressedTypes...)
+}
+
+// maxDecompressedBody caps gzip request bodies once inflated, so a small
+// compressed request cannot expand into a huge body
+const maxDecompressedBody = 16 << 20
+
+// decompressRequests transparently decodes gzip-encoded request bodies,
+// failing reads past maxDecompressedBody
+func decompressRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "invalid gzip request body", http.StatusBadRequest)
+			return
+		}
+		defer zr.Close()
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		r.ContentLength = -1
+		r.Body = http.MaxBytesReader(w, zr, maxDecompressedBody)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// etagWriter buffers a response to compute its ETag
+type etagWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *etagWriter) Header() http.Header { return w.header }
+
+func (w *etagWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// etagged sets a weak ETag on successful GET responses and answers 304 Not
+// Modified when it matches the request's If-None-Match, so polling clients
+// only download data that changed. Weak tags stay valid across compression.
+func etagged(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &etagWriter{header: w.Header()}
+		next.ServeHTTP(buf, r)
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+
+		if buf.status == http.StatusOK {
+			sum := sha256.Sum256(buf.body.Bytes())
+			tag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", tag)
+			if etagMatches(r.Header.Get("If-None-Match"), tag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.WriteHeader(buf.status)
+		w.Write(buf.body.Bytes())
+	})
+}
+
+// etagMatches reports whether an If-None-Match header lists the tag, using
+// weak comparison
+func etagMatches(header, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
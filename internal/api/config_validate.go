@@ -0,0 +1,65 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+)
+
+// maxConfigBytes limits the size of a submitted config
+const maxConfigBytes = 1 << 20
+
+// ConfigValidator checks a complete configuration like the gateway does at
+// startup, returning every problem
+type ConfigValidator func(cfg *config.Config) []error
+
+// ConfigValidationResponse is the response for POST /api/v1/config/validate
+type ConfigValidationResponse struct {
+	Valid    bool                `json:"valid"`
+	Errors   []config.FieldError `json:"errors"`
+	Warnings []config.FieldError `json:"warnings,omitempty"` // Unreachable endpoints, with probe=true
+}
+
+// SetConfigValidator sets the checks run on submitted configs. Without one,
+// only config.Validate runs.
+func (s *Server) SetConfigValidator(v ConfigValidator) {
+	s.configValidator = v
+}
+
+// handleValidateConfig checks a submitted YAML or JSON config without
+// applying it. With probe=true the enabled brokers are also dialed, and
+// unreachable ones reported as warnings.
+// POST /api/v1/config/validate?probe=true
+func (s *Server) handleValidateConfig(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxConfigBytes+1))
+	if err != nil || len(data) > maxConfigBytes {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "config must be at most 1 MiB",
+		})
+		return
+	}
+	cfg, err := config.Parse(data)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	validate := s.configValidator
+	if validate == nil {
+		validate = config.Validate
+	}
+	resp := ConfigValidationResponse{Errors: []config.FieldError{}}
+	for _, err := range validate(cfg) {
+		resp.Errors = append(resp.Errors, config.AsFieldError(err))
+	}
+	resp.Valid = len(resp.Errors) == 0
+	if r.URL.Query().Get("probe") == "true" {
+		for _, err := range config.Probe(r.Context(), cfg) {
+			resp.Warnings = append(resp.Warnings, config.AsFieldError(err))
+		}
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/conflict"
+)
+
+// ConflictProvider is optionally implemented by a StateProvider to expose
+// device IDs claimed by more than one protocol source
+type ConflictProvider interface {
+	Conflicts() *conflict.Detector
+}
+
+// ConflictsResponse is the response for GET /api/v1/conflicts
+type ConflictsResponse struct {
+	Count     int                  `json:"count"`
+	Conflicts []*conflict.Conflict `json:"conflicts"`
+}
+
+// conflictDetector returns the detector or writes 501 if unsupported
+func (s *Server) conflictDetector(w http.ResponseWriter) (*conflict.Detector, bool) {
+	cp, ok := s.provider.(ConflictProvider)
+	if !ok || cp.Conflicts() == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "conflict detection not supported",
+		})
+		return nil, false
+	}
+	return cp.Conflicts(), true
+}
+
+// handleGetConflicts lists device ID conflicts
+// GET /api/v1/conflicts
+func (s *Server) handleGetConflicts(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.conflictDetector(w)
+	if !ok {
+		return
+	}
+	conflicts := d.List()
+	s.writeJSON(w, http.StatusOK, ConflictsResponse{Count: len(conflicts), Conflicts: conflicts})
+}
+
+// handleResolveConflict sets how states for a conflicting device ID are handled
+// PUT /api/v1/conflicts/{deviceID}/resolution
+func (s *Server) handleResolveConflict(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.conflictDetector(w)
+	if !ok {
+		return
+	}
+
+	var res conflict.Resolution
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	deviceID := chi.URLParam(r, "deviceID")
+	res, err := d.SetResolution(deviceID, res)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, conflict.ErrInvalidResolution) {
+			status = http.StatusBadRequest
+		}
+		s.writeJSON(w, status, ErrorResponse{Error: err.Error(), DeviceID: deviceID})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, res)
+}
+
+// handleDismissConflict removes a conflict and its resolution
+// DELETE /api/v1/conflicts/{deviceID}
+func (s *Server) handleDismissConflict(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.conflictDetector(w)
+	if !ok {
+		return
+	}
+
+	deviceID := chi.URLParam(r, "deviceID")
+	if err := d.Dismiss(deviceID); err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error(), DeviceID: deviceID})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
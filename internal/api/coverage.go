@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/coverage"
+)
+
+// CoverageProvider is optionally implemented by a StateProvider to expose
+// the link quality heatmap at /api/v1/coverage
+type CoverageProvider interface {
+	Coverage() *coverage.Map
+}
+
+// CoverageResponse is the response for GET /api/v1/coverage
+type CoverageResponse struct {
+	CellSizeM float64         `json:"cell_size_m"`
+	Count     int             `json:"count"`
+	Cells     []coverage.Cell `json:"cells"` // Weakest signal first
+}
+
+// handleGetCoverage returns link quality aggregated per grid cell
+// GET /api/v1/coverage?since=&until=&bbox=minLat,minLon,maxLat,maxLon&format=json|geojson
+func (s *Server) handleGetCoverage(w http.ResponseWriter, r *http.Request) {
+	cp, ok := s.provider.(CoverageProvider)
+	if !ok || cp.Coverage() == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "coverage map not supported",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	q := coverage.Query{Tenant: auth.TenantFromContext(r.Context())}
+	for name, dst := range map[string]*int64{"since": &q.Since, "until": &q.Until} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+					Error: "invalid " + name + " parameter",
+				})
+				return
+			}
+			*dst = n
+		}
+	}
+	if v := query.Get("bbox"); v != "" {
+		b, ok := parseBBox(v)
+		if !ok {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "bbox must be minLat,minLon,maxLat,maxLon",
+			})
+			return
+		}
+		q.Bounds = b
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "geojson" {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "format must be json or geojson",
+		})
+		return
+	}
+
+	m := cp.Coverage()
+	cells := m.Cells(q)
+	if format == "geojson" {
+		writeCoverageGeoJSON(w, cells)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, CoverageResponse{
+		CellSizeM: m.CellSize(),
+		Count:     len(cells),
+		Cells:     cells,
+	})
+}
+
+// parseBBox parses "minLat,minLon,maxLat,maxLon"
+func parseBBox(v string) (*coverage.Bounds, bool) {
+	parts := strings.Split(v, ",")
+	if len(parts) != 4 {
+		return nil, false
+	}
+	var f [4]float64
+	for i, p := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, false
+		}
+		f[i] = n
+	}
+	b := &coverage.Bounds{MinLat: f[0], MinLon: f[1], MaxLat: f[2], MaxLon: f[3]}
+	if b.MinLat > b.MaxLat || b.MinLon > b.MaxLon {
+		return nil, false
+	}
+	return b, true
+}
+
+// writeCoverageGeoJSON writes one Polygon feature per cell, for rendering
+// the heatmap directly in GIS tools and map libraries
+func writeCoverageGeoJSON(w http.ResponseWriter, cells []coverage.Cell) {
+	fc := GeoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]GeoJSONFeature, 0, len(cells))}
+	for _, c := range cells {
+		b := c.Bounds
+		ring := [][2]float64{
+			{b.MinLon, b.MinLat}, {b.MaxLon, b.MinLat}, {b.MaxLon, b.MaxLat},
+			{b.MinLon, b.MaxLat}, {b.MinLon, b.MinLat},
+		}
+		fc.Features = append(fc.Features, GeoJSONFeature{
+			Type:     "Feature",
+			Geometry: GeoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{ring}},
+			Properties: map[string]interface{}{
+				"samples":     c.Samples,
+				"avg_quality": c.AvgQuality,
+				"min_quality": c.MinQuality,
+				"max_quality": c.MaxQuality,
+				"first_seen":  c.FirstSeen,
+				"last_seen":   c.LastSeen,
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fc)
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
+)
+
+// SetConverter sets the coordinate converter used for ?datum= on tracks and
+// for geofences drawn in GCJ02 or BD09
+func (s *Server) SetConverter(c *coordinator.Converter) {
+	if c == nil {
+		return
+	}
+	s.converter = c
+	s.geofenceEngine.SetConverter(c)
+}
+
+// trackDatum returns the datum track points are requested in (default
+// wgs84). Writes an error and returns false if it is unknown.
+func (s *Server) trackDatum(w http.ResponseWriter, r *http.Request) (string, bool) {
+	datum := r.URL.Query().Get("datum")
+	if datum == "" {
+		return coordinator.DatumWGS84, true
+	}
+	if !coordinator.ValidDatum(datum) {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid datum parameter (must be wgs84, gcj02 or bd09)",
+		})
+		return "", false
+	}
+	return datum, true
+}
+
+// convertTrack returns the points with Lat/Lon in the datum. The stored
+// GCJ02 annotations are left as they are.
+func (s *Server) convertTrack(points []trackstore.TrackPoint, datum string) []trackstore.TrackPoint {
+	if datum == coordinator.DatumWGS84 {
+		return points
+	}
+	converted := make([]trackstore.TrackPoint, len(points))
+	for i, p := range points {
+		p.Lat, p.Lon = s.converter.ToDatum(p.Lat, p.Lon, datum)
+		converted[i] = p
+	}
+	return converted
+}
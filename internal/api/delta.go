@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// WSSubscribeOptions are the optional encoding settings of a subscribe
+// message. Omitted settings keep their current value.
+type WSSubscribeOptions struct {
+	Delta    *bool `json:"delta,omitempty"`    // Send state_delta frames with only the changed fields
+	Compress *bool `json:"compress,omitempty"` // Compress frames with permessage-deflate
+}
+
+// WSSubscribeAck confirms the encoding in effect after a subscribe message
+// with options. Compress is false when the client did not offer
+// permessage-deflate in the handshake.
+type WSSubscribeAck struct {
+	Delta    bool `json:"delta"`
+	Compress bool `json:"compress"`
+}
+
+// setOptions applies subscribe options and returns the resulting encoding.
+// Switching delta encoding on or off resets the per-device baselines, so the
+// next update of every device is a full state_update.
+func (c *WSClient) setOptions(opts WSSubscribeOptions) WSSubscribeAck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if opts.Delta != nil && *opts.Delta != c.delta {
+		c.delta = *opts.Delta
+		c.lastState = nil
+	}
+	if opts.Compress != nil {
+		c.compress.Store(*opts.Compress && c.deflate)
+	}
+	return WSSubscribeAck{Delta: c.delta, Compress: c.compress.Load()}
+}
+
+// queueState queues a state for the client: the full message, or for delta
+// clients a state_delta against the last state queued for the device. The
+// baseline only advances when the frame is queued, so a dropped frame is
+// covered by the next delta.
+func (c *WSClient) queueState(deviceID string, full []byte, fields map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg := full
+	if c.delta {
+		if prev, ok := c.lastState[deviceID]; ok {
+			changed := stateDelta(prev, fields)
+			if len(changed) == 0 {
+				return
+			}
+			data, _ := json.Marshal(changed)
+			msg, _ = json.Marshal(WSMessage{Type: WSMessageTypeStateDelta, DeviceID: deviceID, Data: data})
+		}
+	}
+
+	select {
+	case c.send <- msg:
+		if c.delta {
+			if c.lastState == nil {
+				c.lastState = make(map[string]map[string]any)
+			}
+			c.lastState[deviceID] = fields
+		}
+	default:
+		// Skip if buffer is full
+	}
+}
+
+// forgetState drops the delta baselines of devices, so their next update is
+// sent in full
+func (c *WSClient) forgetState(deviceIDs ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range deviceIDs {
+		delete(c.lastState, id)
+	}
+}
+
+// wantsDelta reports whether the client receives delta frames
+func (c *WSClient) wantsDelta() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.delta
+}
+
+// stateDelta returns the fields of cur that differ from prev. Nested objects
+// are compared field by field and fields missing from cur are set to null,
+// so merging the delta into prev yields cur.
+func stateDelta(prev, cur map[string]any) map[string]any {
+	delta := make(map[string]any)
+	for k, v := range cur {
+		old, ok := prev[k]
+		if !ok {
+			delta[k] = v
+			continue
+		}
+		oldObj, oldIsObj := old.(map[string]any)
+		obj, isObj := v.(map[string]any)
+		if oldIsObj && isObj {
+			if nested := stateDelta(oldObj, obj); len(nested) > 0 {
+				delta[k] = nested
+			}
+			continue
+		}
+		if !reflect.DeepEqual(old, v) {
+			delta[k] = v
+		}
+	}
+	for k := range prev {
+		if _, ok := cur[k]; !ok {
+			delta[k] = nil
+		}
+	}
+	return delta
+}
+
+// offersDeflate reports whether a WebSocket handshake offers the
+// permessage-deflate extension
+func offersDeflate(r *http.Request) bool {
+	for _, header := range r.Header.Values("Sec-WebSocket-Extensions") {
+		for _, ext := range strings.Split(header, ",") {
+			name, _, _ := strings.Cut(ext, ";")
+			if strings.TrimSpace(name) == "permessage-deflate" {
+				return true
+			}
+		}
+	}
+	return false
+}
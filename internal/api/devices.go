@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/registry"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// DeviceRegistryProvider is optionally implemented by a StateProvider to
+// manage registered device details at /api/v1/devices
+type DeviceRegistryProvider interface {
+	Devices() *registry.Registry
+}
+
+// DevicesResponse is the response for GET /api/v1/devices
+type DevicesResponse struct {
+	Count   int               `json:"count"`
+	Devices []registry.Device `json:"devices"`
+}
+
+// deviceRegistry returns the registry, or nil if unsupported
+func (s *Server) deviceRegistry() *registry.Registry {
+	if dp, ok := s.provider.(DeviceRegistryProvider); ok {
+		return dp.Devices()
+	}
+	return nil
+}
+
+// requireRegistry returns the registry or writes 501 if unsupported
+func (s *Server) requireRegistry(w http.ResponseWriter) (*registry.Registry, bool) {
+	reg := s.deviceRegistry()
+	if reg == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "device registry not supported",
+		})
+		return nil, false
+	}
+	return reg, true
+}
+
+// forResponse returns a copy of the state for an API response. It carries
+// the current registered details, so edits show up before the device reports
+// again, and its age, so clients can tell a frozen state from a live one.
+func (s *Server) forResponse(state *models.DroneState) *models.DroneState {
+	out := *state
+	if reg := s.deviceRegistry(); reg != nil {
+		out.Metadata = reg.Metadata(state.DeviceID)
+	}
+	if state.Timestamp > 0 {
+		age := max(time.Now().UnixMilli()-state.Timestamp, 0)
+		out.AgeMs = &age
+	}
+	return &out
+}
+
+// handleGetDevices lists registered devices
+// GET /api/v1/devices
+func (s *Server) handleGetDevices(w http.ResponseWriter, r *http.Request) {
+	reg, ok := s.requireRegistry(w)
+	if !ok {
+		return
+	}
+
+	devices := make([]registry.Device, 0)
+	for _, d := range reg.List() {
+		if s.deviceVisible(r, d.ID) {
+			devices = append(devices, d)
+		}
+	}
+	s.writeJSON(w, http.StatusOK, DevicesResponse{Count: len(devices), Devices: devices})
+}
+
+// handleGetDevice returns a registered device
+// GET /api/v1/devices/{id}
+func (s *Server) handleGetDevice(w http.ResponseWriter, r *http.Request) {
+	reg, ok := s.requireRegistry(w)
+	if !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	d, err := reg.Get(id)
+	if err != nil || !s.deviceVisible(r, id) {
+		s.writeDeviceError(w, registry.ErrDeviceNotFound)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, d)
+}
+
+// handleCreateDevice registers a device
+// POST /api/v1/devices
+func (s *Server) handleCreateDevice(w http.ResponseWriter, r *http.Request) {
+	reg, ok := s.requireRegistry(w)
+	if !ok {
+		return
+	}
+
+	var d registry.Device
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+	if d.ID != "" && !s.requireDevice(w, r, d.ID) {
+		return
+	}
+
+	if err := reg.Create(&d); err != nil {
+		s.writeDeviceError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, d)
+}
+
+// handleUpdateDevice replaces the details of a registered device
+// PUT /api/v1/devices/{id}
+func (s *Server) handleUpdateDevice(w http.ResponseWriter, r *http.Request) {
+	reg, ok := s.requireRegistry(w)
+	if !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if !s.deviceVisible(r, id) {
+		s.writeDeviceError(w, registry.ErrDeviceNotFound)
+		return
+	}
+
+	var d registry.Device
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+	d.ID = id
+
+	if err := reg.Update(&d); err != nil {
+		s.writeDeviceError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, d)
+}
+
+// handleDeleteDevice unregisters a device
+// DELETE /api/v1/devices/{id}
+func (s *Server) handleDeleteDevice(w http.ResponseWriter, r *http.Request) {
+	reg, ok := s.requireRegistry(w)
+	if !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	err := registry.ErrDeviceNotFound
+	if s.deviceVisible(r, id) {
+		err = reg.Delete(id)
+	}
+	if err != nil {
+		s.writeDeviceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeDeviceError maps registry errors to HTTP status codes
+func (s *Server) writeDeviceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, registry.ErrDeviceNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, registry.ErrDeviceExists):
+		status = http.StatusConflict
+	case errors.Is(err, registry.ErrInvalidDevice):
+		status = http.StatusBadRequest
+	}
+	s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/internal/core/coverage"
+	"github.com/open-uav/telemetry-bridge/internal/core/statestore"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// SpatialProvider is optionally implemented by a StateProvider to answer
+// bbox and radius queries from a spatial index instead of a scan of every
+// drone
+type SpatialProvider interface {
+	GetStatesInBounds(b statestore.Bounds) []*models.DroneState
+	GetStatesWithinRadius(lat, lon, radiusM float64) []*models.DroneState
+}
+
+// DronesFieldsResponse is the response for /api/v1/drones with fields=,
+// holding only the selected fields of each drone
+type DronesFieldsResponse struct {
+	Count  int              `json:"count"`
+	Drones []map[string]any `json:"drones"`
+}
+
+// droneQuery holds the filters and field selection of /api/v1/drones
+type droneQuery struct {
+	protocolSource string
+	armed          *bool
+	bbox           *coverage.Bounds
+	radius         *radiusFilter
+	fields         [][]string // Dotted paths split on "."; nil returns whole states
+}
+
+// radiusFilter keeps the drones within radiusM meters of a point
+type radiusFilter struct {
+	lat, lon, radiusM float64
+}
+
+// parseDroneQuery parses ?protocol_source=&armed=true|false
+// &bbox=minLat,minLon,maxLat,maxLon&within_radius=lat,lon,meters&fields=a,b.c
+func parseDroneQuery(query url.Values) (droneQuery, error) {
+	q := droneQuery{protocolSource: query.Get("protocol_source")}
+	if v := query.Get("armed"); v != "" {
+		armed, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, fmt.Errorf("armed must be true or false")
+		}
+		q.armed = &armed
+	}
+	if v := query.Get("bbox"); v != "" {
+		b, ok := parseBBox(v)
+		if !ok {
+			return q, fmt.Errorf("bbox must be minLat,minLon,maxLat,maxLon")
+		}
+		q.bbox = b
+	}
+	if v := query.Get("within_radius"); v != "" {
+		r, ok := parseRadius(v)
+		if !ok {
+			return q, fmt.Errorf("within_radius must be lat,lon,meters")
+		}
+		q.radius = r
+	}
+	if v := query.Get("fields"); v != "" {
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			path := strings.Split(f, ".")
+			for _, p := range path {
+				if p == "" {
+					return q, fmt.Errorf("invalid field %q", f)
+				}
+			}
+			q.fields = append(q.fields, path)
+		}
+	}
+	return q, nil
+}
+
+// matches reports whether a drone passes the filters
+func (q droneQuery) matches(d *models.DroneState) bool {
+	if q.protocolSource != "" && d.ProtocolSource != q.protocolSource {
+		return false
+	}
+	if q.armed != nil && d.Status.Armed != *q.armed {
+		return false
+	}
+	if b := q.bbox; b != nil {
+		lat, lon := d.Location.Lat, d.Location.Lon
+		if lat < b.MinLat || lat > b.MaxLat || lon < b.MinLon || lon > b.MaxLon {
+			return false
+		}
+	}
+	if r := q.radius; r != nil {
+		if coordinator.HaversineDistance(r.lat, r.lon, d.Location.Lat, d.Location.Lon) > r.radiusM {
+			return false
+		}
+	}
+	return true
+}
+
+// candidates returns the drones the filters are applied to: those near the
+// radius or bbox when the provider has a spatial index, otherwise all
+func (q droneQuery) candidates(provider StateProvider) []*models.DroneState {
+	if sp, ok := provider.(SpatialProvider); ok {
+		switch {
+		case q.radius != nil:
+			return sp.GetStatesWithinRadius(q.radius.lat, q.radius.lon, q.radius.radiusM)
+		case q.bbox != nil:
+			return sp.GetStatesInBounds(statestore.Bounds{
+				MinLat: q.bbox.MinLat, MinLon: q.bbox.MinLon, MaxLat: q.bbox.MaxLat, MaxLon: q.bbox.MaxLon,
+			})
+		}
+	}
+	return provider.GetAllStates()
+}
+
+// parseRadius parses "lat,lon,meters"
+func parseRadius(v string) (*radiusFilter, bool) {
+	parts := strings.Split(v, ",")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	var f [3]float64
+	for i, p := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, false
+		}
+		f[i] = n
+	}
+	r := &radiusFilter{lat: f[0], lon: f[1], radiusM: f[2]}
+	if math.Abs(r.lat) > 90 || math.Abs(r.lon) > 180 || !(r.radiusM > 0) {
+		return nil, false
+	}
+	return r, true
+}
+
+// selectFields returns only the given fields of a drone, nested as in the
+// full state. Fields the state does not have are left out.
+func selectFields(d *models.DroneState, fields [][]string) (map[string]any, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var full map[string]any
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]any)
+	for _, path := range fields {
+		src, dst := full, out
+		for i, key := range path {
+			v, ok := src[key]
+			if !ok {
+				break
+			}
+			if i == len(path)-1 {
+				dst[key] = v
+				break
+			}
+			next, ok := v.(map[string]any)
+			if !ok {
+				break
+			}
+			sub, ok := dst[key].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				dst[key] = sub
+			}
+			src, dst = next, sub
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/equipment"
+)
+
+// EquipmentProvider is optionally implemented by a StateProvider to expose
+// battery swaps, payload changes and battery statistics
+type EquipmentProvider interface {
+	Equipment() *equipment.Tracker
+}
+
+// EquipmentChangesResponse is the response for GET /api/v1/equipment/changes
+type EquipmentChangesResponse struct {
+	Count   int                `json:"count"`
+	Changes []equipment.Change `json:"changes"`
+}
+
+// BatteriesResponse is the response for GET /api/v1/equipment/batteries
+type BatteriesResponse struct {
+	Count     int                 `json:"count"`
+	Batteries []equipment.Battery `json:"batteries"`
+}
+
+// equipmentTracker returns the tracker or writes 501 if unsupported
+func (s *Server) equipmentTracker(w http.ResponseWriter) (*equipment.Tracker, bool) {
+	ep, ok := s.provider.(EquipmentProvider)
+	if !ok || ep.Equipment() == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "equipment tracking not supported",
+		})
+		return nil, false
+	}
+	return ep.Equipment(), true
+}
+
+// handleGetEquipmentChanges lists battery swaps and payload changes, newest first
+// GET /api/v1/equipment/changes?device_id=xxx&limit=50
+func (s *Server) handleGetEquipmentChanges(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.equipmentTracker(w)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid limit parameter",
+			})
+			return
+		}
+	}
+
+	changes := t.Changes(r.URL.Query().Get("device_id"), 0)
+	visible := make([]equipment.Change, 0, len(changes))
+	for _, c := range changes {
+		if !s.deviceVisible(r, c.DeviceID) {
+			continue
+		}
+		visible = append(visible, c)
+		if limit > 0 && len(visible) >= limit {
+			break
+		}
+	}
+	changes = visible
+	s.writeJSON(w, http.StatusOK, EquipmentChangesResponse{Count: len(changes), Changes: changes})
+}
+
+// handleGetBatteries lists the statistics of every battery pack seen
+// GET /api/v1/equipment/batteries
+func (s *Server) handleGetBatteries(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.equipmentTracker(w)
+	if !ok {
+		return
+	}
+	batteries := make([]equipment.Battery, 0)
+	for _, b := range t.Batteries() {
+		if s.deviceVisible(r, b.DeviceID) {
+			batteries = append(batteries, b)
+		}
+	}
+	s.writeJSON(w, http.StatusOK, BatteriesResponse{Count: len(batteries), Batteries: batteries})
+}
+
+// handleGetBattery returns the statistics of one battery pack
+// GET /api/v1/equipment/batteries/{serial}
+func (s *Server) handleGetBattery(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.equipmentTracker(w)
+	if !ok {
+		return
+	}
+	serial := chi.URLParam(r, "serial")
+	b, found := t.Battery(serial)
+	if !found || !s.deviceVisible(r, b.DeviceID) {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: "battery not found: " + serial,
+		})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, b)
+}
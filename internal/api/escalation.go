@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/notify"
+)
+
+// SetNotifier sets the channels raised and escalated alerts are sent to.
+// Alert rules, geofences and escalation policies may only name these
+// channels.
+func (s *Server) SetNotifier(n *notify.Dispatcher) {
+	s.notifier = n
+	s.alertsHandler.SetChannels(n.Names)
+	s.geofencesHandler.SetChannels(n.Names)
+}
+
+// notifyAlert sends a raised alert to the channels of its rule or geofence
+func (s *Server) notifyAlert(alert alerter.Alert) {
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(string(alert.Severity)), alert.Message)
+	text := fmt.Sprintf("%s\n\nType: %s\nSeverity: %s\nDevice: %s\nRaised: %s\n",
+		alert.Message, alert.Type, alert.Severity, alert.DeviceID, s.timeFormatter.Format(alert.Timestamp))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.notifier.Send(ctx, alert.Channels, notify.Message{Subject: subject, Text: text, Data: alert}); err != nil {
+		log.Printf("[Alerts] Notification of alert %s failed: %v", alert.ID, err)
+	}
+}
+
+// escalateAlert sends an escalated alert to the policy's channels and
+// publishes an AlertEscalated event
+func (s *Server) escalateAlert(alert alerter.Alert, policy alerter.EscalationPolicy) {
+	log.Printf("[Alerts] Escalating %s alert %s via %s (policy %s)",
+		alert.Severity, alert.ID, strings.Join(policy.Channels, ", "), policy.Name)
+	s.publishEvent(events.Event{
+		Type:     events.AlertEscalated,
+		DeviceID: alert.DeviceID,
+		Source:   alert.Source,
+		Alert:    &alert,
+	})
+
+	subject := fmt.Sprintf("[%s] Unacknowledged alert: %s", strings.ToUpper(string(alert.Severity)), alert.Message)
+	text := fmt.Sprintf("%s\n\nType: %s\nSeverity: %s\nDevice: %s\nRaised: %s\nEscalation policy: %s\n",
+		alert.Message, alert.Type, alert.Severity, alert.DeviceID,
+		s.timeFormatter.Format(alert.Timestamp), policy.Name)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.notifier.Send(ctx, policy.Channels, notify.Message{Subject: subject, Text: text, Data: alert}); err != nil {
+		log.Printf("[Alerts] Escalation of alert %s failed: %v", alert.ID, err)
+	}
+}
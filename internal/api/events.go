@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/geofence"
+)
+
+// EventSource is optionally implemented by a StateProvider to expose its
+// event bus. The server subscribes its subsystems to it on Start.
+type EventSource interface {
+	Events() *events.Bus
+}
+
+// attachEvents subscribes the geofence engine, alerter, incident correlator
+// and WebSocket hub to the bus. For each state the order is: geofence
+// evaluation (which may raise breach alerts), alert rules, then the WebSocket
+// broadcast. Every alert raised along the way is correlated into incidents.
+func (s *Server) attachEvents(bus *events.Bus) {
+	s.events = bus
+	s.unsubscribe = append(s.unsubscribe,
+		bus.Subscribe("geofence", s.evaluateGeofencesEvent, events.StateUpdated),
+		bus.Subscribe("geofence", s.forgetGeofenceDevice, events.DeviceEvicted),
+		bus.Subscribe("alerter", s.evaluateAlertsEvent, events.StateUpdated),
+		bus.Subscribe("alerter", s.forgetAlerterDevice, events.DeviceEvicted),
+		bus.Subscribe("alerter", s.raiseBreachAlert, events.BreachDetected, events.PredictedBreach),
+		bus.Subscribe("alerter", s.raiseConflictAlert, events.DeviceConflict),
+		bus.Subscribe("incidents", s.correlateEvent, events.AlertRaised, events.DeviceOffline, events.DeviceOnline),
+		bus.Subscribe("websocket", s.broadcastEvent, events.StateUpdated, events.DeviceOnline, events.DeviceOffline),
+		bus.Subscribe("websocket", s.broadcastMission, events.MissionChanged),
+		bus.Subscribe("websocket", s.broadcastFlightEvent, events.FlightEvent),
+		bus.Subscribe("websocket", s.broadcastAdapterStatus, events.AdapterStatus),
+	)
+}
+
+// detachEvents removes the server's subscriptions
+func (s *Server) detachEvents() {
+	for _, unsubscribe := range s.unsubscribe {
+		unsubscribe()
+	}
+	s.unsubscribe = nil
+}
+
+// publishEvent publishes to the attached bus, if any
+func (s *Server) publishEvent(ev events.Event) {
+	if s.events != nil {
+		s.events.Publish(ev)
+	}
+}
+
+// evaluateGeofencesEvent checks a state against geofences and publishes
+// actual and predicted breaches
+func (s *Server) evaluateGeofencesEvent(ev events.Event) {
+	if s.geofenceEngine == nil || ev.State == nil {
+		return
+	}
+	for _, breach := range s.geofenceEngine.Evaluate(ev.State) {
+		eventType := events.BreachDetected
+		if breach.Predicted {
+			eventType = events.PredictedBreach
+		}
+		s.publishEvent(events.Event{
+			Type:     eventType,
+			DeviceID: breach.DeviceID,
+			Source:   "geofence:" + breach.GeofenceID,
+			Breach:   breach,
+		})
+	}
+}
+
+// forgetGeofenceDevice drops the geofence state of an evicted device
+func (s *Server) forgetGeofenceDevice(ev events.Event) {
+	if s.geofenceEngine != nil {
+		s.geofenceEngine.ForgetDevice(ev.DeviceID)
+	}
+}
+
+// forgetAlerterDevice drops the home and fix history of an evicted device
+func (s *Server) forgetAlerterDevice(ev events.Event) {
+	if s.alerter != nil {
+		s.alerter.ForgetDevice(ev.DeviceID)
+	}
+}
+
+// evaluateAlertsEvent checks a state against alert rules and publishes alerts
+func (s *Server) evaluateAlertsEvent(ev events.Event) {
+	if s.alerter == nil || ev.State == nil {
+		return
+	}
+	for _, alert := range s.alerter.Evaluate(ev.State) {
+		s.publishAlert(alert)
+	}
+}
+
+// raiseBreachAlert turns an actual, predicted or dwell geofence breach into
+// an alert with the geofence's severity and notification channels
+func (s *Server) raiseBreachAlert(ev events.Event) {
+	if s.alerter == nil || ev.Breach == nil {
+		return
+	}
+	name := ev.Breach.GeofenceName
+	if name == "" {
+		name = ev.Breach.GeofenceID
+	}
+	severity := ev.Breach.Severity
+	if severity == "" {
+		severity = geofence.DefaultSeverity
+	}
+	exit := ev.Breach.Type == geofence.BreachTypeExit
+
+	alertType := alerter.AlertTypeGeofenceBreach
+	var msg string
+	switch {
+	case ev.Breach.Type == geofence.BreachTypeDwell:
+		alertType = alerter.AlertTypeGeofenceDwell
+		side := "outside"
+		if ev.Breach.Inside {
+			side = "inside"
+		}
+		msg = fmt.Sprintf("Drone %s stayed %s geofence %s for %.0f s", ev.Breach.DeviceID, side, name, ev.Breach.DwellSec)
+	case ev.Breach.Predicted && exit:
+		alertType = alerter.AlertTypeGeofencePredicted
+		msg = fmt.Sprintf("Drone %s projected to leave geofence %s in %.0f s", ev.Breach.DeviceID, name, ev.Breach.ETASec)
+	case ev.Breach.Predicted:
+		alertType = alerter.AlertTypeGeofencePredicted
+		msg = fmt.Sprintf("Drone %s projected to enter geofence %s in %.0f s", ev.Breach.DeviceID, name, ev.Breach.ETASec)
+	case exit:
+		msg = fmt.Sprintf("Drone %s left geofence %s", ev.Breach.DeviceID, name)
+	default:
+		msg = fmt.Sprintf("Drone %s entered geofence %s", ev.Breach.DeviceID, name)
+	}
+	alert := s.alerter.RaiseForDevice(alertType, severity, ev.Breach.DeviceID, ev.Source, msg, ev.Breach.Channels...)
+	s.publishAlert(alert)
+}
+
+// raiseConflictAlert alerts on a device ID claimed by several sources
+func (s *Server) raiseConflictAlert(ev events.Event) {
+	if s.alerter == nil || ev.Conflict == nil {
+		return
+	}
+	alert := s.alerter.RaiseForDevice(alerter.AlertTypeDeviceConflict, alerter.SeverityWarning,
+		ev.Conflict.DeviceID, "conflict", fmt.Sprintf("Device ID %s is reported by multiple sources: %s",
+			ev.Conflict.DeviceID, strings.Join(ev.Conflict.Sources, ", ")))
+	s.publishAlert(alert)
+}
+
+// publishAlert publishes an AlertRaised event and sends the alert to its
+// notification channels. Silenced alerts (nil) are skipped.
+func (s *Server) publishAlert(alert *alerter.Alert) {
+	if alert == nil {
+		return
+	}
+	s.publishEvent(events.Event{
+		Type:     events.AlertRaised,
+		DeviceID: alert.DeviceID,
+		Source:   alert.Source,
+		Alert:    alert,
+	})
+	if len(alert.Channels) > 0 && s.notifier != nil {
+		go s.notifyAlert(*alert)
+	}
+}
+
+// broadcastEvent forwards state and presence events to WebSocket clients
+func (s *Server) broadcastEvent(ev events.Event) {
+	switch ev.Type {
+	case events.StateUpdated:
+		s.BroadcastState(ev.State)
+		s.relay(fanoutMessage{Type: WSMessageTypeStateUpdate, DeviceID: ev.DeviceID, State: ev.State})
+	case events.DeviceOnline:
+		tenant := s.tenants().Resolve(ev.DeviceID)
+		s.hub.BroadcastDroneOnline(ev.DeviceID, tenant)
+		s.relay(fanoutMessage{Type: WSMessageTypeDroneOnline, DeviceID: ev.DeviceID, Tenant: tenant})
+	case events.DeviceOffline:
+		tenant := s.tenants().Resolve(ev.DeviceID)
+		s.hub.BroadcastDroneOffline(ev.DeviceID, tenant)
+		s.relay(fanoutMessage{Type: WSMessageTypeDroneOffline, DeviceID: ev.DeviceID, Tenant: tenant})
+	}
+}
+
+// broadcastAdapterStatus forwards adapter link changes to WebSocket clients
+func (s *Server) broadcastAdapterStatus(ev events.Event) {
+	if ev.Link != nil {
+		s.hub.BroadcastAdapterStatus(AdapterLink{Adapter: ev.Source, LinkStatus: *ev.Link})
+	}
+}
+
+// broadcastAlertsAcked tells WebSocket clients which alerts were
+// acknowledged, each tenant only about the alerts of its devices
+func (s *Server) broadcastAlertsAcked(alerts []alerter.Alert) {
+	byTenant := make(map[string]*AlertsAcked)
+	var order []string
+	for _, alert := range alerts {
+		tenant := s.tenants().Resolve(alert.DeviceID)
+		acked, ok := byTenant[tenant]
+		if !ok {
+			acked = &AlertsAcked{AckedBy: alert.AckedBy, AckedAt: alert.AckedAt}
+			byTenant[tenant] = acked
+			order = append(order, tenant)
+		}
+		acked.IDs = append(acked.IDs, alert.ID)
+	}
+	for _, tenant := range order {
+		s.hub.BroadcastAlertsAcked(*byTenant[tenant], tenant)
+	}
+}
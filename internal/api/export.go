@@ -0,0 +1,344 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/anonymize"
+	"github.com/open-uav/telemetry-bridge/internal/core/timefmt"
+	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
+)
+
+// ExportTrackPoint is a track point with a formatted time for exports
+type ExportTrackPoint struct {
+	Time string `json:"time"`
+	trackstore.TrackPoint
+}
+
+// TrackExportResponse is the JSON response for track exports
+type TrackExportResponse struct {
+	DeviceID   string             `json:"device_id"`
+	Timezone   string             `json:"timezone"`
+	TimeFormat string             `json:"time_format"`
+	Anonymized bool               `json:"anonymized,omitempty"`
+	Datum      string             `json:"datum"` // Datum of the point positions
+	Count      int                `json:"count"`
+	Points     []ExportTrackPoint `json:"points"`
+	Events     []TrackEvent       `json:"events,omitempty"`
+}
+
+// ExportAlert is an alert with a formatted time for exports
+type ExportAlert struct {
+	Time         string                `json:"time"`
+	ID           string                `json:"id"`
+	Type         alerter.AlertType     `json:"type"`
+	Severity     alerter.AlertSeverity `json:"severity"`
+	DeviceID     string                `json:"device_id"`
+	Source       string                `json:"source,omitempty"`
+	Message      string                `json:"message"`
+	Value        float64               `json:"value,omitempty"`
+	Threshold    float64               `json:"threshold,omitempty"`
+	Timestamp    int64                 `json:"timestamp"`
+	Acknowledged bool                  `json:"acknowledged"`
+	AckedAt      int64                 `json:"acked_at,omitempty"`
+	AckedBy      string                `json:"acked_by,omitempty"`
+}
+
+// AlertExportResponse is the JSON response for alert exports
+type AlertExportResponse struct {
+	Timezone   string        `json:"timezone"`
+	TimeFormat string        `json:"time_format"`
+	Anonymized bool          `json:"anonymized,omitempty"`
+	Count      int           `json:"count"`
+	Alerts     []ExportAlert `json:"alerts"`
+}
+
+// SetTimeFormatter sets the gateway timezone and timestamp format used for exports
+func (s *Server) SetTimeFormatter(f *timefmt.Formatter) {
+	if f != nil {
+		s.timeFormatter = f
+	}
+}
+
+// SetAnonymizer sets the anonymizer used for exports with anonymize=true
+func (s *Server) SetAnonymizer(a anonymize.Anonymizer) {
+	s.anonymizer = a
+}
+
+// exportAnonymizer returns the anonymizer if the request asks for an
+// anonymized export, nil otherwise. Writes an error and returns false if
+// the request is invalid or anonymization is not configured.
+func (s *Server) exportAnonymizer(w http.ResponseWriter, r *http.Request) (anonymize.Anonymizer, bool) {
+	value := r.URL.Query().Get("anonymize")
+	if value == "" {
+		return nil, true
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid anonymize parameter",
+		})
+		return nil, false
+	}
+	if !enabled {
+		return nil, true
+	}
+	if s.anonymizer == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "anonymized exports not configured",
+		})
+		return nil, false
+	}
+	return s.anonymizer, true
+}
+
+// handleExportTrack exports a drone track as CSV, JSON, GeoJSON or KML.
+// With anonymize=true the device ID is replaced by its pseudonym. With
+// events=true the device's alerts and geofence breaches during the track
+// are embedded at their positions (not supported for CSV).
+// GET /api/v1/drones/{deviceID}/track/export?format=kml&events=true&tz=Asia/Shanghai&time_format=datetime&anonymize=true
+func (s *Server) handleExportTrack(w http.ResponseWriter, r *http.Request) {
+	deviceID := chi.URLParam(r, "deviceID")
+
+	if !s.provider.IsTrackEnabled() {
+		s.writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{
+			Error:    "track storage is disabled",
+			DeviceID: deviceID,
+		})
+		return
+	}
+	if !s.requireDevice(w, r, deviceID) {
+		return
+	}
+
+	query := r.URL.Query()
+
+	// Per-export timezone/format override
+	formatter, err := s.timeFormatter.WithOverrides(query.Get("tz"), query.Get("time_format"))
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var since int64
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err = strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || since < 0 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid since parameter",
+			})
+			return
+		}
+	}
+
+	withEvents := false
+	if eventsStr := query.Get("events"); eventsStr != "" {
+		withEvents, err = strconv.ParseBool(eventsStr)
+		if err != nil {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid events parameter",
+			})
+			return
+		}
+	}
+
+	format := query.Get("format")
+	switch format {
+	case "", "csv":
+		if withEvents {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "events require json, geojson or kml format",
+			})
+			return
+		}
+	case "json", "geojson", "kml":
+	default:
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "format must be csv, json, geojson or kml",
+		})
+		return
+	}
+
+	datum, ok := s.trackDatum(w, r)
+	if !ok {
+		return
+	}
+
+	anon, ok := s.exportAnonymizer(w, r)
+	if !ok {
+		return
+	}
+
+	points := s.provider.GetTrack(deviceID, 0, since)
+
+	exportID := deviceID
+	if anon != nil {
+		exportID = anon.DeviceID(deviceID)
+	}
+
+	var events []TrackEvent
+	if withEvents {
+		events = s.trackEvents(deviceID, points, formatter, anon)
+		for i := range events {
+			events[i].Lat, events[i].Lon = s.converter.ToDatum(events[i].Lat, events[i].Lon, datum)
+		}
+	}
+	points = s.convertTrack(points, datum)
+
+	switch format {
+	case "", "csv":
+		s.writeTrackCSV(w, exportID, points, formatter)
+	case "geojson":
+		s.writeTrackGeoJSON(w, exportID, points, events, formatter)
+	case "kml":
+		s.writeTrackKML(w, exportID, points, events, formatter)
+	case "json":
+		exported := make([]ExportTrackPoint, len(points))
+		for i, p := range points {
+			exported[i] = ExportTrackPoint{Time: formatter.Format(p.Timestamp), TrackPoint: p}
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=track-%s.json", exportID))
+		s.writeJSON(w, http.StatusOK, TrackExportResponse{
+			DeviceID:   exportID,
+			Timezone:   formatter.Location().String(),
+			TimeFormat: formatter.FormatName(),
+			Anonymized: anon != nil,
+			Datum:      datum,
+			Count:      len(exported),
+			Points:     exported,
+			Events:     events,
+		})
+	}
+}
+
+// writeTrackCSV writes track points as a CSV attachment
+func (s *Server) writeTrackCSV(w http.ResponseWriter, deviceID string, points []trackstore.TrackPoint, formatter *timefmt.Formatter) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=track-%s.csv", deviceID))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"timestamp", "time", "lat", "lon", "alt", "heading", "speed", "lat_gcj02", "lon_gcj02"})
+	for _, p := range points {
+		cw.Write([]string{
+			strconv.FormatInt(p.Timestamp, 10),
+			formatter.Format(p.Timestamp),
+			strconv.FormatFloat(p.Lat, 'f', 7, 64),
+			strconv.FormatFloat(p.Lon, 'f', 7, 64),
+			strconv.FormatFloat(p.Alt, 'f', 2, 64),
+			strconv.FormatFloat(p.Heading, 'f', 1, 64),
+			strconv.FormatFloat(p.Speed, 'f', 2, 64),
+			strconv.FormatFloat(p.LatGCJ02, 'f', 7, 64),
+			strconv.FormatFloat(p.LonGCJ02, 'f', 7, 64),
+		})
+	}
+	cw.Flush()
+}
+
+// handleExportAlerts exports alerts as CSV or JSON. With anonymize=true
+// device IDs (including those in messages) and operator names are replaced
+// by their pseudonyms.
+// GET /api/v1/alerts/export?format=csv&device_id=xxx&since=1700000000000&anonymize=true
+func (s *Server) handleExportAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	formatter, err := s.timeFormatter.WithOverrides(query.Get("tz"), query.Get("time_format"))
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var since int64
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err = strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || since < 0 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid since parameter",
+			})
+			return
+		}
+	}
+
+	anon, ok := s.exportAnonymizer(w, r)
+	if !ok {
+		return
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "csv" && format != "json" {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "format must be csv or json",
+		})
+		return
+	}
+
+	alerts := s.alerter.GetAlerts(query.Get("device_id"), nil, 0)
+	exported := make([]ExportAlert, 0, len(alerts))
+	for i := len(alerts) - 1; i >= 0; i-- { // Oldest first
+		a := alerts[i]
+		if a.Timestamp < since || !s.deviceVisible(r, a.DeviceID) {
+			continue
+		}
+		e := ExportAlert{
+			Time:         formatter.Format(a.Timestamp),
+			ID:           a.ID,
+			Type:         a.Type,
+			Severity:     a.Severity,
+			DeviceID:     a.DeviceID,
+			Source:       a.Source,
+			Message:      a.Message,
+			Value:        a.Value,
+			Threshold:    a.Threshold,
+			Timestamp:    a.Timestamp,
+			Acknowledged: a.Acknowledged,
+			AckedAt:      a.AckedAt,
+			AckedBy:      a.AckedBy,
+		}
+		if anon != nil {
+			e.DeviceID = anon.DeviceID(a.DeviceID)
+			e.Message = anonymize.Text(anon, a.Message, a.DeviceID)
+			e.AckedBy = anon.Operator(a.AckedBy)
+		}
+		exported = append(exported, e)
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Disposition", "attachment; filename=alerts.json")
+		s.writeJSON(w, http.StatusOK, AlertExportResponse{
+			Timezone:   formatter.Location().String(),
+			TimeFormat: formatter.FormatName(),
+			Anonymized: anon != nil,
+			Count:      len(exported),
+			Alerts:     exported,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=alerts.csv")
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "timestamp", "time", "type", "severity", "device_id", "source", "message", "value", "threshold", "acknowledged", "acked_by"})
+	for _, a := range exported {
+		cw.Write([]string{
+			a.ID,
+			strconv.FormatInt(a.Timestamp, 10),
+			a.Time,
+			string(a.Type),
+			string(a.Severity),
+			a.DeviceID,
+			a.Source,
+			a.Message,
+			strconv.FormatFloat(a.Value, 'f', -1, 64),
+			strconv.FormatFloat(a.Threshold, 'f', -1, 64),
+			strconv.FormatBool(a.Acknowledged),
+			a.AckedBy,
+		})
+	}
+	cw.Flush()
+}
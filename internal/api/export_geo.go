@@ -0,0 +1,273 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/anonymize"
+	"github.com/open-uav/telemetry-bridge/internal/core/timefmt"
+	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
+)
+
+// TrackEvent is an alert or geofence breach placed on an exported track
+type TrackEvent struct {
+	Kind         string                `json:"kind"` // "alert" or "breach"
+	ID           string                `json:"id"`
+	Type         string                `json:"type"`
+	Severity     alerter.AlertSeverity `json:"severity,omitempty"`
+	Message      string                `json:"message,omitempty"`
+	GeofenceID   string                `json:"geofence_id,omitempty"`
+	GeofenceName string                `json:"geofence_name,omitempty"`
+	Timestamp    int64                 `json:"timestamp"`
+	Time         string                `json:"time"`
+	Lat          float64               `json:"-"`
+	Lon          float64               `json:"-"`
+	Alt          float64               `json:"-"`
+}
+
+// trackEvents returns the alerts and geofence breaches of a device within
+// the time span of its track, oldest first. Breaches carry their own
+// position; alerts are placed at the track point closest in time. Geofence
+// breach alerts are skipped since the breach itself is included.
+func (s *Server) trackEvents(deviceID string, points []trackstore.TrackPoint, formatter *timefmt.Formatter, anon anonymize.Anonymizer) []TrackEvent {
+	if len(points) == 0 {
+		return nil
+	}
+	start, end := points[0].Timestamp, points[len(points)-1].Timestamp
+
+	var events []TrackEvent
+	for _, b := range s.geofenceEngine.GetBreaches(deviceID, "", 0) {
+		if b.Timestamp < start || b.Timestamp > end {
+			continue
+		}
+		events = append(events, TrackEvent{
+			Kind:         "breach",
+			ID:           b.ID,
+			Type:         string(b.Type),
+			Severity:     b.Severity,
+			GeofenceID:   b.GeofenceID,
+			GeofenceName: b.GeofenceName,
+			Timestamp:    b.Timestamp,
+			Lat:          b.Lat,
+			Lon:          b.Lon,
+			Alt:          b.Alt,
+		})
+	}
+
+	for _, a := range s.alerter.GetAlerts(deviceID, nil, 0) {
+		if a.Type == alerter.AlertTypeGeofenceBreach || a.Type == alerter.AlertTypeGeofenceDwell || a.Timestamp < start || a.Timestamp > end {
+			continue
+		}
+		p := nearestTrackPoint(points, a.Timestamp)
+		message := a.Message
+		if anon != nil {
+			message = anonymize.Text(anon, a.Message, deviceID)
+		}
+		events = append(events, TrackEvent{
+			Kind:      "alert",
+			ID:        a.ID,
+			Type:      string(a.Type),
+			Severity:  a.Severity,
+			Message:   message,
+			Timestamp: a.Timestamp,
+			Lat:       p.Lat,
+			Lon:       p.Lon,
+			Alt:       p.Alt,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+	for i := range events {
+		events[i].Time = formatter.Format(events[i].Timestamp)
+	}
+	return events
+}
+
+// nearestTrackPoint returns the point closest in time to ts. Points must
+// be sorted by timestamp and not empty.
+func nearestTrackPoint(points []trackstore.TrackPoint, ts int64) trackstore.TrackPoint {
+	i := sort.Search(len(points), func(i int) bool { return points[i].Timestamp >= ts })
+	if i == len(points) {
+		return points[i-1]
+	}
+	if i > 0 && ts-points[i-1].Timestamp < points[i].Timestamp-ts {
+		return points[i-1]
+	}
+	return points[i]
+}
+
+// GeoJSONFeatureCollection is a GeoJSON track or coverage export
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature is a single GeoJSON feature
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONGeometry is a Point, LineString or Polygon geometry
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// writeTrackGeoJSON writes the track as a LineString feature followed by a
+// Point feature per event
+func (s *Server) writeTrackGeoJSON(w http.ResponseWriter, deviceID string, points []trackstore.TrackPoint, events []TrackEvent, formatter *timefmt.Formatter) {
+	coords := make([][3]float64, len(points))
+	for i, p := range points {
+		coords[i] = [3]float64{p.Lon, p.Lat, p.Alt}
+	}
+	props := map[string]interface{}{
+		"device_id": deviceID,
+		"count":     len(points),
+	}
+	if len(points) > 0 {
+		props["start_time"] = formatter.Format(points[0].Timestamp)
+		props["end_time"] = formatter.Format(points[len(points)-1].Timestamp)
+	}
+
+	fc := GeoJSONFeatureCollection{Type: "FeatureCollection"}
+	fc.Features = append(fc.Features, GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   GeoJSONGeometry{Type: "LineString", Coordinates: coords},
+		Properties: props,
+	})
+	for _, e := range events {
+		props := map[string]interface{}{
+			"kind":      e.Kind,
+			"id":        e.ID,
+			"type":      e.Type,
+			"timestamp": e.Timestamp,
+			"time":      e.Time,
+		}
+		if e.Severity != "" {
+			props["severity"] = e.Severity
+		}
+		if e.Message != "" {
+			props["message"] = e.Message
+		}
+		if e.GeofenceID != "" {
+			props["geofence_id"] = e.GeofenceID
+			props["geofence_name"] = e.GeofenceName
+		}
+		fc.Features = append(fc.Features, GeoJSONFeature{
+			Type:       "Feature",
+			Geometry:   GeoJSONGeometry{Type: "Point", Coordinates: [3]float64{e.Lon, e.Lat, e.Alt}},
+			Properties: props,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=track-%s.geojson", deviceID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fc)
+}
+
+// kmlDocument is the subset of KML used by track exports
+type kmlDocument struct {
+	XMLName xml.Name     `xml:"kml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Name    string       `xml:"Document>name"`
+	Track   kmlPlacemark `xml:"Document>Placemark"`
+	Folders []kmlFolder  `xml:"Document>Folder,omitempty"`
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name        string          `xml:"name"`
+	Description string          `xml:"description,omitempty"`
+	TimeStamp   string          `xml:"TimeStamp>when,omitempty"` // Always RFC 3339, as KML requires
+	Data        []kmlData       `xml:"ExtendedData>Data,omitempty"`
+	Point       *kmlCoordinates `xml:"Point,omitempty"`
+	LineString  *kmlCoordinates `xml:"LineString,omitempty"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlCoordinates struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+// kmlCoord formats a position as a KML lon,lat,alt tuple
+func kmlCoord(lat, lon, alt float64) string {
+	return strconv.FormatFloat(lon, 'f', 7, 64) + "," +
+		strconv.FormatFloat(lat, 'f', 7, 64) + "," +
+		strconv.FormatFloat(alt, 'f', 2, 64)
+}
+
+// writeTrackKML writes the track as a LineString placemark and the events
+// as point placemarks in an "Events" folder. Event timestamps are RFC 3339
+// in the formatter's zone; the configured format is kept in a "time" field.
+func (s *Server) writeTrackKML(w http.ResponseWriter, deviceID string, points []trackstore.TrackPoint, events []TrackEvent, formatter *timefmt.Formatter) {
+	when := timefmt.New(formatter.Location(), timefmt.FormatRFC3339)
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = kmlCoord(p.Lat, p.Lon, p.Alt)
+	}
+	doc := kmlDocument{
+		Xmlns: "http://www.opengis.net/kml/2.2",
+		Name:  "Track " + deviceID,
+		Track: kmlPlacemark{
+			Name:       deviceID,
+			LineString: &kmlCoordinates{AltitudeMode: "absolute", Coordinates: strings.Join(coords, " ")},
+		},
+	}
+
+	if len(events) > 0 {
+		folder := kmlFolder{Name: "Events"}
+		for _, e := range events {
+			name := e.Type
+			if e.Kind == "breach" {
+				name = fmt.Sprintf("Geofence %s: %s", e.Type, e.GeofenceName)
+			}
+			pm := kmlPlacemark{
+				Name:        name,
+				Description: e.Message,
+				TimeStamp:   when.Format(e.Timestamp),
+				Data: []kmlData{
+					{Name: "kind", Value: e.Kind},
+					{Name: "id", Value: e.ID},
+					{Name: "severity", Value: string(e.Severity)},
+					{Name: "timestamp", Value: strconv.FormatInt(e.Timestamp, 10)},
+					{Name: "time", Value: e.Time},
+				},
+				Point: &kmlCoordinates{AltitudeMode: "absolute", Coordinates: kmlCoord(e.Lat, e.Lon, e.Alt)},
+			}
+			if e.GeofenceID != "" {
+				pm.Data = append(pm.Data, kmlData{Name: "geofence_id", Value: e.GeofenceID})
+			}
+			folder.Placemarks = append(folder.Placemarks, pm)
+		}
+		doc.Folders = append(doc.Folders, folder)
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.google-earth.kml+xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=track-%s.kml", deviceID))
+	w.WriteHeader(http.StatusOK)
+
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(doc)
+}
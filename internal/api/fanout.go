@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/timeline"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// fanoutMessage is a hub event relayed to the WebSocket clients of the other
+// gateway instances
+type fanoutMessage struct {
+	Type     WSMessageType      `json:"type"` // state_update, drone_online, drone_offline, mission_changed or flight_event
+	DeviceID string             `json:"device_id,omitempty"`
+	Tenant   string             `json:"tenant,omitempty"`
+	State    *models.DroneState `json:"state,omitempty"`
+	Mission  *models.Mission    `json:"mission,omitempty"`
+	Flight   *timeline.Event    `json:"flight,omitempty"`
+}
+
+// relay publishes a local hub event to the other instances, if fan-out is
+// enabled
+func (s *Server) relay(msg fanoutMessage) {
+	if s.fanout == nil {
+		return
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("[Fanout] Failed to marshal %s: %v", msg.Type, err)
+		return
+	}
+	s.fanout.Publish(data)
+}
+
+// receiveFanout broadcasts an event of another instance to the local
+// WebSocket clients. It is not relayed again, nor seen by the alerter or
+// geofences, which run on the instance that received the state.
+func (s *Server) receiveFanout(data []byte) {
+	var msg fanoutMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log.Printf("[Fanout] Ignoring malformed event: %v", err)
+		return
+	}
+	switch msg.Type {
+	case WSMessageTypeStateUpdate:
+		if msg.State != nil {
+			s.hub.BroadcastState(msg.State)
+		}
+	case WSMessageTypeDroneOnline:
+		s.hub.BroadcastDroneOnline(msg.DeviceID, msg.Tenant)
+	case WSMessageTypeDroneOffline:
+		s.hub.BroadcastDroneOffline(msg.DeviceID, msg.Tenant)
+	case WSMessageTypeMission:
+		if msg.Mission != nil {
+			s.hub.BroadcastMission(msg.DeviceID, msg.Tenant, msg.Mission)
+		}
+	case WSMessageTypeFlightEvent:
+		if msg.Flight != nil {
+			s.hub.BroadcastFlightEvent(msg.DeviceID, msg.Tenant, msg.Flight)
+		}
+	}
+}
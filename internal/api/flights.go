@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
+)
+
+// FlightProvider is optionally implemented by a StateProvider to expose
+// the flights segmented from each drone's track
+type FlightProvider interface {
+	GetFlights(deviceID string) []trackstore.Flight
+}
+
+// DroneFlightsResponse is the response for GET /api/v1/drones/{deviceID}/flights
+type DroneFlightsResponse struct {
+	DeviceID string              `json:"device_id"`
+	Count    int                 `json:"count"`
+	Flights  []trackstore.Flight `json:"flights"` // Oldest first
+}
+
+// handleGetDroneFlights returns a drone's flights with their statistics
+// GET /api/v1/drones/{deviceID}/flights
+func (s *Server) handleGetDroneFlights(w http.ResponseWriter, r *http.Request) {
+	deviceID := chi.URLParam(r, "deviceID")
+
+	fp, ok := s.provider.(FlightProvider)
+	if !ok || !s.provider.IsTrackEnabled() {
+		s.writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{
+			Error:    "track storage is disabled",
+			DeviceID: deviceID,
+		})
+		return
+	}
+	if !s.requireDevice(w, r, deviceID) {
+		return
+	}
+
+	flights := fp.GetFlights(deviceID)
+	s.writeJSON(w, http.StatusOK, DroneFlightsResponse{
+		DeviceID: deviceID,
+		Count:    len(flights),
+		Flights:  flights,
+	})
+}
@@ -0,0 +1,521 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/api/graphql"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/geofence"
+	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// graphqlSubprotocol is the WebSocket subprotocol of GraphQL subscriptions
+const graphqlSubprotocol = "graphql-transport-ws"
+
+// graphqlEventBuffer is the number of events buffered per subscription;
+// events are dropped while a slow client's buffer is full
+const graphqlEventBuffer = 64
+
+var graphqlUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{graphqlSubprotocol},
+}
+
+// newGraphQLSchema builds the GraphQL schema over the same data as the REST
+// API. Resolvers apply the tenant of the request user like the REST handlers.
+func (s *Server) newGraphQLSchema() *graphql.Schema {
+	deviceArg := graphql.Arg{Name: "device_id", Type: graphql.String, Required: true}
+
+	query := map[string]*graphql.Field{
+		"drones": {
+			Description: "Current state of every drone",
+			Type:        reflect.TypeFor[[]*models.DroneState](),
+			Args:        []graphql.Arg{{Name: "protocol_source", Type: graphql.String}},
+			Resolve:     s.resolveDrones,
+		},
+		"drone": {
+			Description: "Current state of one drone, null if unknown",
+			Type:        reflect.TypeFor[*models.DroneState](),
+			Args:        []graphql.Arg{deviceArg},
+			Resolve:     s.resolveDrone,
+		},
+		"track": {
+			Description: "Track points of a drone, oldest first",
+			Type:        reflect.TypeFor[[]trackstore.TrackPoint](),
+			Args: []graphql.Arg{
+				deviceArg,
+				{Name: "limit", Type: graphql.Int},
+				{Name: "since", Type: graphql.Long, Description: "Unix milliseconds"},
+				{Name: "max_points", Type: graphql.Int},
+			},
+			Resolve: s.resolveTrack,
+		},
+		"alerts": {
+			Description: "Alerts, newest first",
+			Type:        reflect.TypeFor[[]alerter.Alert](),
+			Args: []graphql.Arg{
+				{Name: "device_id", Type: graphql.String},
+				{Name: "acknowledged", Type: graphql.Boolean},
+				{Name: "limit", Type: graphql.Int},
+			},
+			Resolve: s.resolveAlerts,
+		},
+		"geofences": {
+			Description: "Geofences",
+			Type:        reflect.TypeFor[[]*geofence.Geofence](),
+			Resolve:     s.resolveGeofences,
+		},
+	}
+	if _, ok := s.provider.(FlightProvider); ok {
+		query["flights"] = &graphql.Field{
+			Description: "Flights segmented from a drone's track, oldest first",
+			Type:        reflect.TypeFor[[]trackstore.Flight](),
+			Args:        []graphql.Arg{deviceArg},
+			Resolve:     s.resolveFlights,
+		}
+	}
+
+	subscription := map[string]*graphql.Field{
+		"drone_updated": {
+			Description: "States as they are processed",
+			Type:        reflect.TypeFor[*models.DroneState](),
+			Args:        []graphql.Arg{{Name: "device_id", Type: graphql.String}},
+			Subscribe:   s.subscribeDroneUpdated,
+		},
+		"alert_raised": {
+			Description: "Alerts as they are raised",
+			Type:        reflect.TypeFor[*alerter.Alert](),
+			Args:        []graphql.Arg{{Name: "device_id", Type: graphql.String}},
+			Subscribe:   s.subscribeAlertRaised,
+		},
+	}
+	return graphql.NewSchema(query, subscription)
+}
+
+func (s *Server) resolveDrones(ctx context.Context, args graphql.Args) (any, error) {
+	tenantID := auth.TenantFromContext(ctx)
+	source := args.String("protocol_source")
+
+	drones := make([]*models.DroneState, 0)
+	for _, d := range s.provider.GetAllStates() {
+		if tenantID != "" && d.Tenant != tenantID {
+			continue
+		}
+		if source != "" && d.ProtocolSource != source {
+			continue
+		}
+		drones = append(drones, s.forResponse(d))
+	}
+	return drones, nil
+}
+
+func (s *Server) resolveDrone(ctx context.Context, args graphql.Args) (any, error) {
+	deviceID := args.String("device_id")
+	state := s.provider.GetState(deviceID)
+	if state == nil || !s.tenants().Visible(auth.TenantFromContext(ctx), deviceID) {
+		return nil, nil
+	}
+	return s.forResponse(state), nil
+}
+
+func (s *Server) resolveTrack(ctx context.Context, args graphql.Args) (any, error) {
+	deviceID := args.String("device_id")
+	if !s.provider.IsTrackEnabled() {
+		return nil, errors.New("track storage is disabled")
+	}
+	if !s.tenants().Visible(auth.TenantFromContext(ctx), deviceID) {
+		return nil, errors.New("drone not found")
+	}
+
+	limit, _ := args.Int("limit")
+	since, _ := args.Int("since")
+	if limit < 0 || since < 0 {
+		return nil, errors.New("limit and since must not be negative")
+	}
+	points := s.provider.GetTrack(deviceID, int(limit), since)
+	if maxPoints, ok := args.Int("max_points"); ok {
+		if maxPoints < 2 {
+			return nil, errors.New("max_points must be at least 2")
+		}
+		points = trackstore.Decimate(points, int(maxPoints))
+	}
+	return points, nil
+}
+
+func (s *Server) resolveFlights(ctx context.Context, args graphql.Args) (any, error) {
+	deviceID := args.String("device_id")
+	if !s.provider.IsTrackEnabled() {
+		return nil, errors.New("track storage is disabled")
+	}
+	if !s.tenants().Visible(auth.TenantFromContext(ctx), deviceID) {
+		return nil, errors.New("drone not found")
+	}
+	return s.provider.(FlightProvider).GetFlights(deviceID), nil
+}
+
+func (s *Server) resolveAlerts(ctx context.Context, args graphql.Args) (any, error) {
+	if s.alerter == nil {
+		return nil, errors.New("alerts are not available")
+	}
+
+	limit := int64(100)
+	if l, ok := args.Int("limit"); ok {
+		if l <= 0 || l > 1000 {
+			return nil, errors.New("limit must be between 1 and 1000")
+		}
+		limit = l
+	}
+
+	deviceID := args.String("device_id")
+	acknowledged := args.Bool("acknowledged")
+	tenantID := auth.TenantFromContext(ctx)
+	if tenantID == "" {
+		return s.alerter.GetAlerts(deviceID, acknowledged, int(limit)), nil
+	}
+
+	alerts := make([]alerter.Alert, 0)
+	for _, a := range s.alerter.GetAlerts(deviceID, acknowledged, 0) {
+		if !s.tenants().Visible(tenantID, a.DeviceID) {
+			continue
+		}
+		alerts = append(alerts, a)
+		if int64(len(alerts)) >= limit {
+			break
+		}
+	}
+	return alerts, nil
+}
+
+func (s *Server) resolveGeofences(ctx context.Context, args graphql.Args) (any, error) {
+	if s.geofenceEngine == nil {
+		return nil, errors.New("geofences are not available")
+	}
+	geofences := s.geofenceEngine.GetGeofences()
+	if tenantID := auth.TenantFromContext(ctx); tenantID != "" {
+		visible := make([]*geofence.Geofence, 0, len(geofences))
+		for _, gf := range geofences {
+			if gf.Tenant == "" || gf.Tenant == tenantID {
+				visible = append(visible, gf)
+			}
+		}
+		geofences = visible
+	}
+	return geofences, nil
+}
+
+func (s *Server) subscribeDroneUpdated(ctx context.Context, args graphql.Args) (<-chan any, error) {
+	deviceID := args.String("device_id")
+	tenantID := auth.TenantFromContext(ctx)
+	return s.subscribeEvents(ctx, func(ev events.Event) any {
+		if ev.State == nil || deviceID != "" && ev.State.DeviceID != deviceID ||
+			!s.tenants().Visible(tenantID, ev.State.DeviceID) {
+			return nil
+		}
+		// Copy, since the state is reused once the handlers have run
+		state := *ev.State
+		return s.forResponse(&state)
+	}, events.StateUpdated)
+}
+
+func (s *Server) subscribeAlertRaised(ctx context.Context, args graphql.Args) (<-chan any, error) {
+	deviceID := args.String("device_id")
+	tenantID := auth.TenantFromContext(ctx)
+	return s.subscribeEvents(ctx, func(ev events.Event) any {
+		if ev.Alert == nil || deviceID != "" && ev.Alert.DeviceID != deviceID ||
+			!s.tenants().Visible(tenantID, ev.Alert.DeviceID) {
+			return nil
+		}
+		alert := *ev.Alert
+		return &alert
+	}, events.AlertRaised)
+}
+
+// subscribeEvents forwards the non-nil results of project for bus events
+// until ctx is done. Bus handlers must not block, so events are dropped
+// while the buffer is full.
+func (s *Server) subscribeEvents(ctx context.Context, project func(events.Event) any, types ...events.Type) (<-chan any, error) {
+	if s.events == nil {
+		return nil, errors.New("events are not available")
+	}
+
+	ch := make(chan any, graphqlEventBuffer)
+	unsubscribe := s.events.Subscribe("graphql", func(ev events.Event) {
+		v := project(ev)
+		if v == nil {
+			return
+		}
+		select {
+		case ch <- v:
+		default:
+		}
+	}, types...)
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+	return ch, nil
+}
+
+// handleGraphQL runs a GraphQL query from a JSON body (POST) or from the
+// query, operationName and variables parameters (GET). Field errors are
+// reported in the response with status 200.
+// POST /api/v1/graphql
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid variables parameter"})
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+		return
+	}
+	if req.Query == "" {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "query is required"})
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, s.graphql.Execute(r.Context(), req))
+}
+
+// handleGraphQLSchema returns the schema in the GraphQL schema language
+// GET /api/v1/graphql/schema
+func (s *Server) handleGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(s.graphql.SDL()))
+}
+
+// graphqlWSMessage is a graphql-transport-ws protocol message
+type graphqlWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// graphqlConn is a GraphQL WebSocket connection with its operations
+type graphqlConn struct {
+	conn *websocket.Conn
+	ctx  context.Context
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	ops     map[string]context.CancelFunc
+}
+
+// serveGraphQLWs serves queries and subscriptions over WebSocket with the
+// graphql-transport-ws protocol. With authentication enabled, clients not
+// authenticated in the handshake must authenticate in the connection_init
+// payload, as /graphql requires a user.
+// GET /api/v1/graphql/ws
+func (s *Server) serveGraphQLWs(w http.ResponseWriter, r *http.Request) {
+	r, ok := s.wsHandshakeAuth(w, r)
+	if !ok {
+		return
+	}
+	u := graphqlUpgrader
+	u.CheckOrigin = s.checkOrigin
+	conn, err := u.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("[GraphQL] Upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != graphqlSubprotocol {
+		closeGraphQLWs(conn, 4406, "Subprotocol not acceptable")
+		return
+	}
+
+	// Subscriptions outlive the request timeout, so only keep the
+	// request's values (the authenticated user)
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+	c := &graphqlConn{conn: conn, ctx: ctx, ops: make(map[string]context.CancelFunc)}
+
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+	go c.keepAlive(ctx)
+
+	// Clients that must authenticate in connection_init get the auth
+	// timeout to send it
+	mustInit := s.authEnabled && !wsAuthenticated(r)
+	if mustInit {
+		conn.SetReadDeadline(time.Now().Add(s.wsAuthTimeout()))
+	}
+
+	initialized := false
+	for {
+		var msg graphqlWSMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			var netErr net.Error
+			if mustInit && !initialized && errors.As(err, &netErr) && netErr.Timeout() {
+				closeGraphQLWs(conn, 4408, "Connection initialisation timeout")
+				return
+			}
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("[GraphQL] Read error: %v", err)
+			}
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if initialized {
+				closeGraphQLWs(conn, 4429, "Too many initialisation requests")
+				return
+			}
+			var creds WSAuth
+			json.Unmarshal(msg.Payload, &creds)
+			if s.authEnabled && (creds.Token != "" || creds.APIKey != "") {
+				user, err := s.wsAuthenticate(creds)
+				if err != nil {
+					closeGraphQLWs(conn, 4403, "Forbidden")
+					return
+				}
+				c.ctx = context.WithValue(c.ctx, auth.UserContextKey, user)
+			} else if mustInit {
+				closeGraphQLWs(conn, wsCloseUnauthorized, "Unauthorized")
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+			initialized = true
+			c.send(graphqlWSMessage{Type: "connection_ack"})
+		case "ping":
+			c.send(graphqlWSMessage{Type: "pong"})
+		case "pong":
+		case "subscribe":
+			if !initialized {
+				closeGraphQLWs(conn, 4401, "Unauthorized")
+				return
+			}
+			var req graphql.Request
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil {
+				closeGraphQLWs(conn, 4400, "Invalid subscribe message")
+				return
+			}
+			if !c.start(s.graphql, msg.ID, req) {
+				closeGraphQLWs(conn, 4409, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+				return
+			}
+		case "complete":
+			c.stop(msg.ID)
+		default:
+			closeGraphQLWs(conn, 4400, fmt.Sprintf("Unknown message type %q", msg.Type))
+			return
+		}
+	}
+}
+
+// start runs an operation, returning false if the ID is in use
+func (c *graphqlConn) start(schema *graphql.Schema, id string, req graphql.Request) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.ops[id]; ok {
+		return false
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.ops[id] = cancel
+
+	go func() {
+		defer c.stop(id)
+
+		results, err := schema.Subscribe(ctx, req)
+		if errors.Is(err, graphql.ErrNotSubscription) {
+			c.next(id, schema.Execute(ctx, req))
+			c.send(graphqlWSMessage{ID: id, Type: "complete"})
+			return
+		}
+		if err != nil {
+			payload, _ := json.Marshal([]*graphql.Error{{Message: err.Error()}})
+			c.send(graphqlWSMessage{ID: id, Type: "error", Payload: payload})
+			return
+		}
+
+		for resp := range results {
+			c.next(id, resp)
+		}
+		// Results end when the client completes the operation or the
+		// connection closes, in which case no complete is due
+		if ctx.Err() == nil {
+			c.send(graphqlWSMessage{ID: id, Type: "complete"})
+		}
+	}()
+	return true
+}
+
+// stop cancels an operation
+func (c *graphqlConn) stop(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cancel, ok := c.ops[id]; ok {
+		cancel()
+		delete(c.ops, id)
+	}
+}
+
+// next sends a result of an operation
+func (c *graphqlConn) next(id string, resp *graphql.Response) {
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("[GraphQL] Failed to encode result: %v", err)
+		return
+	}
+	c.send(graphqlWSMessage{ID: id, Type: "next", Payload: payload})
+}
+
+// send writes a message; writes are serialized across operations
+func (c *graphqlConn) send(msg graphqlWSMessage) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	c.conn.WriteJSON(msg)
+}
+
+// keepAlive pings the client until the connection is done
+func (c *graphqlConn) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+			c.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// closeGraphQLWs closes a connection with a protocol close code
+func closeGraphQLWs(conn *websocket.Conn, code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+}
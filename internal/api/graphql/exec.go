@@ -0,0 +1,559 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrNotSubscription is returned by Subscribe for queries, which should be
+// run with Execute instead
+var ErrNotSubscription = errors.New("operation is not a subscription")
+
+// Request is a GraphQL request as sent over HTTP or WebSocket
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data is omitted when the request
+// failed before execution.
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is a request or field error
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"` // Response keys leading to the failed field
+}
+
+// errorResponse returns a response for a request error
+func errorResponse(err error) *Response {
+	return &Response{Errors: []*Error{{Message: err.Error()}}}
+}
+
+// object is a JSON object keeping the order of its members, which follows
+// the order of the selection set
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// execution is a validated operation ready to run
+type execution struct {
+	schema *Schema
+	doc    *document
+	op     *operation
+	root   map[string]*Field
+	vars   map[string]any
+	errors []*Error
+}
+
+// group is the fields selected under one response key
+type group struct {
+	key    string
+	fields []*field
+}
+
+// Execute runs a query
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	e, err := s.prepare(req)
+	if err != nil {
+		return errorResponse(err)
+	}
+	switch e.op.kind {
+	case "subscription":
+		return errorResponse(fmt.Errorf("subscriptions are only supported over WebSocket"))
+	case "mutation":
+		return errorResponse(fmt.Errorf("mutations are not supported"))
+	}
+
+	data := object{}
+	for _, g := range e.collect(e.op.selections) {
+		f := g.fields[0]
+		if f.name == "__typename" {
+			data = append(data, member{g.key, "Query"})
+			continue
+		}
+		def := e.root[f.name]
+		args, err := e.args(def, f)
+		var value any
+		if err == nil {
+			value, err = def.Resolve(ctx, args)
+		}
+		if err != nil {
+			e.errors = append(e.errors, &Error{Message: err.Error(), Path: []any{g.key}})
+			data = append(data, member{g.key, nil})
+			continue
+		}
+		data = append(data, member{g.key, e.complete(def.Type, reflect.ValueOf(value), e.subselections(g))})
+	}
+	return &Response{Data: data, Errors: e.errors}
+}
+
+// Subscribe starts a subscription and returns a response per event. The
+// channel is closed when ctx is done or the event source ends.
+func (s *Schema) Subscribe(ctx context.Context, req Request) (<-chan *Response, error) {
+	e, err := s.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	if e.op.kind != "subscription" {
+		return nil, ErrNotSubscription
+	}
+	groups := e.collect(e.op.selections)
+	if len(groups) != 1 || groups[0].fields[0].name == "__typename" {
+		return nil, fmt.Errorf("a subscription must select exactly one field")
+	}
+
+	g := groups[0]
+	def := e.root[g.fields[0].name]
+	args, err := e.args(def, g.fields[0])
+	if err != nil {
+		return nil, err
+	}
+	events, err := def.Subscribe(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	sels := e.subselections(g)
+	out := make(chan *Response)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				resp := &Response{Data: object{{g.key, e.complete(def.Type, reflect.ValueOf(ev), sels)}}}
+				select {
+				case out <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// prepare parses and validates a request
+func (s *Schema) prepare(req Request) (*execution, error) {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var op *operation
+	for _, o := range doc.operations {
+		if req.OperationName == "" || o.name == req.OperationName {
+			if op != nil {
+				return nil, fmt.Errorf("operationName is required for documents with several operations")
+			}
+			op = o
+		}
+	}
+	if op == nil {
+		return nil, fmt.Errorf("unknown operation %q", req.OperationName)
+	}
+
+	e := &execution{schema: s, doc: doc, op: op, root: s.query, vars: make(map[string]any)}
+	if op.kind == "subscription" {
+		e.root = s.subscription
+	}
+	for _, v := range op.vars {
+		value, ok := req.Variables[v.name]
+		switch {
+		case !ok && v.def != nil:
+			value = v.def
+		case (!ok || value == nil) && v.nonNull:
+			return nil, fmt.Errorf("variable $%s of type %s! was not provided", v.name, v.typeName)
+		case !ok:
+			continue
+		}
+		e.vars[v.name] = value
+	}
+	if err := e.validate(op.selections); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// validate checks the root selections of the operation
+func (e *execution) validate(sels []selection) error {
+	if err := e.checkFragments(sels, map[string]bool{}); err != nil {
+		return err
+	}
+	for _, g := range e.collect(sels) {
+		f := g.fields[0]
+		switch {
+		case f.name == "__typename":
+			continue
+		case f.name == "__schema" || f.name == "__type":
+			return fmt.Errorf("line %d: introspection is not supported", f.line)
+		}
+		def, ok := e.root[f.name]
+		if !ok {
+			return fmt.Errorf("line %d: cannot query field %q on type %s", f.line, f.name, e.rootName())
+		}
+		if _, err := e.args(def, f); err != nil {
+			return fmt.Errorf("line %d: %v", f.line, err)
+		}
+		if err := e.validateType(def.Type, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFragments rejects unknown and cyclic fragment spreads
+func (e *execution) checkFragments(sels []selection, visiting map[string]bool) error {
+	for _, sel := range sels {
+		switch {
+		case sel.field != nil:
+			if err := e.checkFragments(sel.field.selections, visiting); err != nil {
+				return err
+			}
+		case sel.inline != nil:
+			if err := e.checkFragments(sel.inline.selections, visiting); err != nil {
+				return err
+			}
+		default:
+			frag, ok := e.doc.fragments[sel.spread]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", sel.spread)
+			}
+			if visiting[sel.spread] {
+				return fmt.Errorf("fragment %q spreads itself", sel.spread)
+			}
+			visiting[sel.spread] = true
+			if err := e.checkFragments(frag.selections, visiting); err != nil {
+				return err
+			}
+			delete(visiting, sel.spread)
+		}
+	}
+	return nil
+}
+
+func (e *execution) rootName() string {
+	if e.op.kind == "subscription" {
+		return "Subscription"
+	}
+	return "Query"
+}
+
+// validateType checks the selections of a field against its type
+func (e *execution) validateType(t reflect.Type, g group) error {
+	f := g.fields[0]
+	for _, other := range g.fields[1:] {
+		if other.name != f.name {
+			return fmt.Errorf("line %d: fields %q and %q conflict under the response key %q", other.line, f.name, other.name, g.key)
+		}
+	}
+
+	t = unwrap(t)
+	for classify(t) == kindList {
+		t = unwrap(t.Elem())
+	}
+	sels := e.subselections(g)
+	if classify(t) == kindScalar {
+		if len(sels) > 0 {
+			return fmt.Errorf("line %d: field %q of type %s must not have a selection set", f.line, f.name, scalarName(t))
+		}
+		return nil
+	}
+
+	obj := e.schema.types[t]
+	if len(sels) == 0 {
+		return fmt.Errorf("line %d: field %q of type %s must have a selection set", f.line, f.name, obj.name)
+	}
+	if err := e.checkTypeConditions(sels, obj.name); err != nil {
+		return err
+	}
+	for _, sub := range e.collect(sels) {
+		sf := sub.fields[0]
+		if sf.name == "__typename" {
+			continue
+		}
+		field, ok := obj.fields[sf.name]
+		if !ok {
+			return fmt.Errorf("line %d: cannot query field %q on type %s", sf.line, sf.name, obj.name)
+		}
+		if len(sf.args) > 0 {
+			return fmt.Errorf("line %d: field %q does not take arguments", sf.line, sf.name)
+		}
+		if err := e.validateType(field.typ, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTypeConditions rejects fragments on other types than the selected one
+func (e *execution) checkTypeConditions(sels []selection, typeName string) error {
+	for _, sel := range sels {
+		frag := sel.inline
+		if sel.spread != "" {
+			frag = e.doc.fragments[sel.spread]
+		}
+		if frag == nil {
+			continue
+		}
+		if frag.typeCond != "" && frag.typeCond != typeName {
+			return fmt.Errorf("fragment on %s cannot be spread on type %s", frag.typeCond, typeName)
+		}
+		if err := e.checkTypeConditions(frag.selections, typeName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect groups selected fields by response key, in selection order,
+// expanding fragments and applying @skip and @include
+func (e *execution) collect(sels []selection) []group {
+	var groups []group
+	index := make(map[string]int)
+
+	var walk func([]selection)
+	walk = func(sels []selection) {
+		for _, sel := range sels {
+			switch {
+			case sel.field != nil:
+				if !e.included(sel.field.directives) {
+					continue
+				}
+				key := sel.field.key()
+				if i, ok := index[key]; ok {
+					groups[i].fields = append(groups[i].fields, sel.field)
+					continue
+				}
+				index[key] = len(groups)
+				groups = append(groups, group{key: key, fields: []*field{sel.field}})
+			case sel.inline != nil:
+				if e.included(sel.directives) {
+					walk(sel.inline.selections)
+				}
+			default:
+				if frag, ok := e.doc.fragments[sel.spread]; ok && e.included(sel.directives) {
+					walk(frag.selections)
+				}
+			}
+		}
+	}
+	walk(sels)
+	return groups
+}
+
+// subselections merges the selection sets of a group's fields
+func (e *execution) subselections(g group) []selection {
+	if len(g.fields) == 1 {
+		return g.fields[0].selections
+	}
+	var sels []selection
+	for _, f := range g.fields {
+		sels = append(sels, f.selections...)
+	}
+	return sels
+}
+
+// included evaluates @skip and @include
+func (e *execution) included(dirs []directive) bool {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		var cond bool
+		for _, a := range d.args {
+			if a.name == "if" {
+				cond, _ = e.resolve(a.value).(bool)
+			}
+		}
+		if d.name == "skip" && cond || d.name == "include" && !cond {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve replaces variables in a value
+func (e *execution) resolve(v any) any {
+	switch v := v.(type) {
+	case variable:
+		return e.vars[string(v)]
+	case listVal:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = e.resolve(item)
+		}
+		return list
+	}
+	return v
+}
+
+// args coerces the arguments of a root field
+func (e *execution) args(def *Field, f *field) (Args, error) {
+	args := Args{}
+	given := make(map[string]any)
+	for _, a := range f.args {
+		if v, ok := a.value.(variable); ok {
+			if _, declared := e.varDeclared(string(v)); !declared {
+				return nil, fmt.Errorf("variable $%s is not defined", v)
+			}
+			if _, ok := e.vars[string(v)]; !ok {
+				continue // Absent variable: as if the argument was not given
+			}
+		}
+		given[a.name] = e.resolve(a.value)
+	}
+
+	for _, spec := range def.Args {
+		v, ok := given[spec.Name]
+		delete(given, spec.Name)
+		if !ok || v == nil {
+			if spec.Required {
+				return nil, fmt.Errorf("argument %q of field %q is required", spec.Name, f.name)
+			}
+			continue
+		}
+		coerced, err := coerce(spec.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q of field %q: %v", spec.Name, f.name, err)
+		}
+		args[spec.Name] = coerced
+	}
+	for name := range given {
+		return nil, fmt.Errorf("unknown argument %q on field %q", name, f.name)
+	}
+	return args, nil
+}
+
+func (e *execution) varDeclared(name string) (varDef, bool) {
+	for _, v := range e.op.vars {
+		if v.name == name {
+			return v, true
+		}
+	}
+	return varDef{}, false
+}
+
+// coerce converts a literal or variable value to an argument type
+func coerce(typ string, v any) (any, error) {
+	switch typ {
+	case String:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case Int, Long:
+		var n int64
+		switch v := v.(type) {
+		case int64:
+			n = v
+		case float64: // Numbers in JSON variables
+			if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+				return nil, fmt.Errorf("expected %s, got %v", typ, v)
+			}
+			n = int64(v)
+		case json.Number:
+			var err error
+			if n, err = v.Int64(); err != nil {
+				return nil, fmt.Errorf("expected %s, got %v", typ, v)
+			}
+		default:
+			return nil, fmt.Errorf("expected %s, got %v", typ, v)
+		}
+		if typ == Int && (n < math.MinInt32 || n > math.MaxInt32) {
+			return nil, fmt.Errorf("%d does not fit in Int", n)
+		}
+		return n, nil
+	case Float:
+		switch v := v.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case json.Number:
+			return v.Float64()
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %v", typ, v)
+}
+
+// complete projects a value onto the selected fields
+func (e *execution) complete(t reflect.Type, v reflect.Value, sels []selection) any {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	t = unwrap(t)
+
+	switch classify(t) {
+	case kindScalar:
+		return v.Interface()
+	case kindList:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = e.complete(t.Elem(), v.Index(i), sels)
+		}
+		return list
+	}
+
+	obj := e.schema.types[t]
+	result := object{}
+	for _, g := range e.collect(sels) {
+		name := g.fields[0].name
+		if name == "__typename" {
+			result = append(result, member{g.key, obj.name})
+			continue
+		}
+		f := obj.fields[name]
+		result = append(result, member{g.key, e.complete(f.typ, v.FieldByIndex(f.index), e.subselections(g))})
+	}
+	return result
+}
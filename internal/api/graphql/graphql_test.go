@@ -0,0 +1,240 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type position struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type status struct {
+	Battery int `json:"battery"`
+}
+
+type drone struct {
+	ID       string     `json:"device_id"`
+	Position position   `json:"position"`
+	Path     []position `json:"path,omitempty"`
+	Seen     int64      `json:"seen"`
+	Secret   string     `json:"-"`
+	Payload  *string    `json:"payload"`
+	status
+}
+
+func testSchema(events chan any) *Schema {
+	drones := []*drone{
+		{ID: "uav-1", Position: position{22.5, 113.9}, Path: []position{{1, 2}}, Seen: 1700000000000, status: status{80}},
+		{ID: "uav-2", Position: position{31.2, 121.5}, status: status{40}},
+	}
+	query := map[string]*Field{
+		"drones": {
+			Type: reflect.TypeFor[[]*drone](),
+			Args: []Arg{{Name: "min_battery", Type: Int}},
+			Resolve: func(ctx context.Context, args Args) (any, error) {
+				min, _ := args.Int("min_battery")
+				var out []*drone
+				for _, d := range drones {
+					if int64(d.Battery) >= min {
+						out = append(out, d)
+					}
+				}
+				return out, nil
+			},
+		},
+		"drone": {
+			Type: reflect.TypeFor[*drone](),
+			Args: []Arg{{Name: "device_id", Type: String, Required: true}},
+			Resolve: func(ctx context.Context, args Args) (any, error) {
+				for _, d := range drones {
+					if d.ID == args.String("device_id") {
+						return d, nil
+					}
+				}
+				return nil, nil
+			},
+		},
+		"failing": {
+			Type: reflect.TypeFor[string](),
+			Resolve: func(ctx context.Context, args Args) (any, error) {
+				return nil, errors.New("boom")
+			},
+		},
+	}
+	subscription := map[string]*Field{
+		"drone_updated": {
+			Type: reflect.TypeFor[*drone](),
+			Subscribe: func(ctx context.Context, args Args) (<-chan any, error) {
+				return events, nil
+			},
+		},
+	}
+	return NewSchema(query, subscription)
+}
+
+// execute runs a query and returns its JSON response
+func execute(t *testing.T, s *Schema, req Request) string {
+	t.Helper()
+	data, err := json.Marshal(s.Execute(context.Background(), req))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return string(data)
+}
+
+func TestParse(t *testing.T) {
+	doc, err := parse(`
+		# Dashboard query
+		query Dashboard($id: String!, $n: Int = 10) {
+			a: drone(device_id: $id) { ...Fields }
+			drones { ... on drone { device_id } }
+		}
+		fragment Fields on drone { device_id, position { lat } }
+	`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	op := doc.operations[0]
+	if op.kind != "query" || op.name != "Dashboard" || len(op.vars) != 2 || !op.vars[0].nonNull || op.vars[1].def != int64(10) {
+		t.Errorf("Operation = %+v", op)
+	}
+	if f := op.selections[0].field; f.alias != "a" || f.name != "drone" || f.args[0].value != variable("id") {
+		t.Errorf("First field = %+v", f)
+	}
+	if op.selections[1].field.selections[0].inline == nil {
+		t.Error("Inline fragment not parsed")
+	}
+	if frag := doc.fragments["Fields"]; frag == nil || frag.typeCond != "drone" || len(frag.selections) != 2 {
+		t.Errorf("Fragment = %+v", frag)
+	}
+
+	for _, src := range []string{`{ drones `, `query { drone(device_id: ) }`, `{ "x" }`, `mutation`} {
+		if _, err := parse(src); err == nil {
+			t.Errorf("parse(%q) should fail", src)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+	s := testSchema(nil)
+
+	got := execute(t, s, Request{Query: `{ drones(min_battery: 50) { device_id battery position { lat } path { lon } __typename } }`})
+	want := `{"data":{"drones":[{"device_id":"uav-1","battery":80,"position":{"lat":22.5},"path":[{"lon":2}],"__typename":"drone"}]}}`
+	if got != want {
+		t.Errorf("Execute =\n%s\nwant\n%s", got, want)
+	}
+
+	// Aliases, variables, fragments, directives and nulls
+	got = execute(t, s, Request{
+		Query: `query Q($id: String!, $all: Boolean!) {
+			first: drone(device_id: $id) { ...F payload path { lat } }
+			missing: drone(device_id: "none") { device_id }
+		}
+		fragment F on drone { device_id seen @include(if: $all) battery @skip(if: true) }`,
+		Variables: map[string]any{"id": "uav-2", "all": false},
+	})
+	want = `{"data":{"first":{"device_id":"uav-2","payload":null,"path":null},"missing":null}}`
+	if got != want {
+		t.Errorf("Execute =\n%s\nwant\n%s", got, want)
+	}
+
+	// Field errors null the field and keep the others
+	got = execute(t, s, Request{Query: `{ failing drone(device_id: "uav-1") { seen } }`})
+	want = `{"data":{"failing":null,"drone":{"seen":1700000000000}},"errors":[{"message":"boom","path":["failing"]}]}`
+	if got != want {
+		t.Errorf("Execute =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExecute_Errors(t *testing.T) {
+	s := testSchema(nil)
+	tests := []struct {
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{`{ unknown }`, nil, `cannot query field "unknown"`},
+		{`{ drones { secret } }`, nil, `cannot query field "secret" on type drone`},
+		{`{ drones }`, nil, "must have a selection set"},
+		{`{ drones { device_id { x } } }`, nil, "must not have a selection set"},
+		{`{ drone { device_id } }`, nil, `argument "device_id" of field "drone" is required`},
+		{`{ drones(color: "red") { device_id } }`, nil, `unknown argument "color"`},
+		{`{ drones(min_battery: "high") { device_id } }`, nil, "expected Int"},
+		{`query($id: String!) { drone(device_id: $id) { device_id } }`, nil, "was not provided"},
+		{`{ drone(device_id: $id) { device_id } }`, nil, "variable $id is not defined"},
+		{`{ drones { ...Missing } }`, nil, `unknown fragment "Missing"`},
+		{`{ drones { ... on position { lat } } }`, nil, "cannot be spread on type drone"},
+		{`{ __schema { types { name } } }`, nil, "introspection is not supported"},
+		{`subscription { drone_updated { device_id } }`, nil, "only supported over WebSocket"},
+		{`query($n: Int) { drones(min_battery: $n) { device_id } }`, map[string]any{"n": 1.5}, "expected Int"},
+	}
+	for _, tt := range tests {
+		resp := s.Execute(context.Background(), Request{Query: tt.query, Variables: tt.vars})
+		if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, tt.want) {
+			data, _ := json.Marshal(resp)
+			t.Errorf("Execute(%q) = %s, want error containing %q", tt.query, data, tt.want)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	events := make(chan any, 1)
+	s := testSchema(events)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := s.Subscribe(ctx, Request{Query: `{ drones { device_id } }`}); !errors.Is(err, ErrNotSubscription) {
+		t.Errorf("Subscribe(query) error = %v, want ErrNotSubscription", err)
+	}
+
+	results, err := s.Subscribe(ctx, Request{Query: `subscription { update: drone_updated { device_id battery } }`})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	events <- &drone{ID: "uav-3", status: status{55}}
+	select {
+	case resp := <-results:
+		data, _ := json.Marshal(resp)
+		if want := `{"data":{"update":{"device_id":"uav-3","battery":55}}}`; string(data) != want {
+			t.Errorf("Event = %s, want %s", data, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-results:
+		if ok {
+			t.Error("Results should close once the context is done")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Results not closed after cancel")
+	}
+}
+
+func TestSDL(t *testing.T) {
+	sdl := testSchema(nil).SDL()
+	for _, want := range []string{
+		"  drone(device_id: String!): drone\n",
+		"  drones(min_battery: Int): [drone]\n",
+		"type Subscription {\n  drone_updated: drone\n}",
+		"  seen: Long\n",
+		"  battery: Int\n",
+		"  path: [position]\n",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL missing %q:\n%s", want, sdl)
+		}
+	}
+	if strings.Contains(sdl, "Secret") {
+		t.Error("Fields excluded from JSON should not be in the schema")
+	}
+}
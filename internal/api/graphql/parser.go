@@ -0,0 +1,622 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// document is a parsed GraphQL request document
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is a query, mutation or subscription
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	vars       []varDef
+	selections []selection
+}
+
+// varDef declares a variable of an operation
+type varDef struct {
+	name     string
+	typeName string // Named type, e.g. String
+	list     bool
+	nonNull  bool
+	def      any // Default value, nil if none
+}
+
+// selection is a field, a fragment spread or an inline fragment
+type selection struct {
+	field      *field
+	spread     string      // Name of a spread fragment
+	inline     *fragment   // Inline fragment
+	directives []directive // Of the spread or inline fragment
+}
+
+// field is a selected field
+type field struct {
+	alias      string
+	name       string
+	args       []argument
+	directives []directive
+	selections []selection
+	line       int
+}
+
+// key is the name of the field in the response
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// fragment is a named or inline fragment
+type fragment struct {
+	name       string
+	typeCond   string // Type condition, empty for inline fragments without one
+	selections []selection
+}
+
+type argument struct {
+	name  string
+	value any
+}
+
+type directive struct {
+	name string
+	args []argument
+}
+
+// Values of the document besides strings, numbers, booleans and nil
+type (
+	variable  string     // $name
+	enumValue string     // Unquoted name
+	objectVal []argument // {name: value}
+	listVal   []any      // [value]
+)
+
+// token kinds
+const (
+	tokEOF = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind int
+	text string
+	line int
+}
+
+// lexer splits a document into tokens
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *lexer) next() (token, error) {
+	// Skip whitespace, commas and comments
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			goto scan
+		}
+	}
+	return token{kind: tokEOF, line: l.line}, nil
+
+scan:
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, text: string(c), line: l.line}, nil
+	case c == '.':
+		if strings.HasPrefix(l.src[l.pos:], "...") {
+			l.pos += 3
+			return token{kind: tokPunct, text: "...", line: l.line}, nil
+		}
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokName, text: l.src[start:l.pos], line: l.line}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	}
+	return token{}, fmt.Errorf("line %d: unexpected character %q", l.line, c)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case isDigit(c):
+		case c == '.' || c == 'e' || c == 'E':
+			kind = tokFloat
+		case (c == '+' || c == '-') && kind == tokFloat:
+		default:
+			return token{kind: kind, text: l.src[start:l.pos], line: l.line}, nil
+		}
+		l.pos++
+	}
+	return token{kind: kind, text: l.src[start:l.pos], line: l.line}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	line := l.line
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, fmt.Errorf("line %d: unterminated block string", line)
+		}
+		text := l.src[l.pos+3 : l.pos+3+end]
+		l.line += strings.Count(text, "\n")
+		l.pos += end + 6
+		return token{kind: tokString, text: strings.TrimSpace(text), line: line}, nil
+	}
+
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '\n':
+			return token{}, fmt.Errorf("line %d: unterminated string", line)
+		case '"':
+			l.pos++
+			text, err := strconv.Unquote(l.src[start:l.pos])
+			if err != nil {
+				return token{}, fmt.Errorf("line %d: invalid string: %v", line, err)
+			}
+			return token{kind: tokString, text: text, line: line}, nil
+		}
+		l.pos++
+	}
+	return token{}, fmt.Errorf("line %d: unterminated string", line)
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// parser builds a document from tokens
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// parse parses a request document
+func parse(src string) (*document, error) {
+	p := &parser{lex: &lexer{src: src, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.is(tokPunct, "{"):
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sels})
+		case p.is(tokName, "query"), p.is(tokName, "mutation"), p.is(tokName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.is(tokName, "fragment"):
+			frag, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, fmt.Errorf("fragment %q is defined more than once", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document contains no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) is(kind int, text string) bool {
+	return p.tok.kind == kind && p.tok.text == text
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("line %d: unexpected end of document", p.tok.line)
+	}
+	return fmt.Errorf("line %d: unexpected %q", p.tok.line, p.tok.text)
+}
+
+// expect consumes a punctuator
+func (p *parser) expect(punct string) error {
+	if !p.is(tokPunct, punct) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// name consumes a name
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	name := p.tok.text
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.text}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokName {
+		op.name = p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is(tokPunct, "(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.is(tokPunct, ")") {
+			v, err := p.varDef()
+			if err != nil {
+				return nil, err
+			}
+			op.vars = append(op.vars, v)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+func (p *parser) varDef() (varDef, error) {
+	var v varDef
+	if err := p.expect("$"); err != nil {
+		return v, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return v, err
+	}
+	v.name = name
+	if err := p.expect(":"); err != nil {
+		return v, err
+	}
+
+	if p.is(tokPunct, "[") {
+		v.list = true
+		if err := p.advance(); err != nil {
+			return v, err
+		}
+	}
+	if v.typeName, err = p.name(); err != nil {
+		return v, err
+	}
+	if v.list {
+		if p.is(tokPunct, "!") {
+			if err := p.advance(); err != nil {
+				return v, err
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return v, err
+		}
+	}
+	if p.is(tokPunct, "!") {
+		v.nonNull = true
+		if err := p.advance(); err != nil {
+			return v, err
+		}
+	}
+
+	if p.is(tokPunct, "=") {
+		if err := p.advance(); err != nil {
+			return v, err
+		}
+		if v.def, err = p.value(true); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+func (p *parser) fragmentDefinition() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("line %d: fragment cannot be named \"on\"", p.tok.line)
+	}
+	if !p.is(tokName, "on") {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	typeCond, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeCond: typeCond, selections: sels}, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for !p.is(tokPunct, "}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("line %d: empty selection set", p.tok.line)
+	}
+	return sels, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	if !p.is(tokPunct, "...") {
+		f, err := p.field()
+		return selection{field: f}, err
+	}
+
+	if err := p.advance(); err != nil {
+		return selection{}, err
+	}
+	if p.tok.kind == tokName && p.tok.text != "on" {
+		name := p.tok.text
+		if err := p.advance(); err != nil {
+			return selection{}, err
+		}
+		dirs, err := p.directives()
+		return selection{spread: name, directives: dirs}, err
+	}
+
+	inline := &fragment{}
+	if p.is(tokName, "on") {
+		if err := p.advance(); err != nil {
+			return selection{}, err
+		}
+		typeCond, err := p.name()
+		if err != nil {
+			return selection{}, err
+		}
+		inline.typeCond = typeCond
+	}
+	dirs, err := p.directives()
+	if err != nil {
+		return selection{}, err
+	}
+	if inline.selections, err = p.selectionSet(); err != nil {
+		return selection{}, err
+	}
+	return selection{inline: inline, directives: dirs}, nil
+}
+
+func (p *parser) field() (*field, error) {
+	f := &field{line: p.tok.line}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.name = name
+	if p.is(tokPunct, ":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.args, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.is(tokPunct, "{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments() ([]argument, error) {
+	if !p.is(tokPunct, "(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var args []argument
+	for !p.is(tokPunct, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, argument{name: name, value: value})
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var dirs []directive
+	for p.is(tokPunct, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, directive{name: name, args: args})
+	}
+	return dirs, nil
+}
+
+// value parses a value; constant values may not contain variables
+func (p *parser) value(constant bool) (any, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokPunct:
+		switch tok.text {
+		case "$":
+			if constant {
+				return nil, fmt.Errorf("line %d: variable not allowed here", tok.line)
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			return variable(name), err
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			list := listVal{}
+			for !p.is(tokPunct, "]") {
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			obj := objectVal{}
+			for !p.is(tokPunct, "}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, argument{name: name, value: v})
+			}
+			return obj, p.advance()
+		}
+	case tokInt:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid integer %s", tok.line, tok.text)
+		}
+		return n, p.advance()
+	case tokFloat:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid number %s", tok.line, tok.text)
+		}
+		return f, p.advance()
+	case tokString:
+		return tok.text, p.advance()
+	case tokName:
+		var v any
+		switch tok.text {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enumValue(tok.text)
+		}
+		return v, p.advance()
+	}
+	return nil, p.unexpected()
+}
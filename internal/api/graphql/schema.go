@@ -0,0 +1,320 @@
+package graphql
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Scalar type names of arguments and fields
+const (
+	String  = "String"
+	Int     = "Int"
+	Long    = "Long" // 64-bit integer, e.g. Unix millisecond timestamps
+	Float   = "Float"
+	Boolean = "Boolean"
+	JSON    = "JSON" // Any JSON value, e.g. maps
+)
+
+// Field is a root field of the query or subscription type
+type Field struct {
+	Description string
+	Type        reflect.Type // Type of the resolved value, or of each subscription event
+	Args        []Arg
+
+	// Resolve returns the value of a query field
+	Resolve func(ctx context.Context, args Args) (any, error)
+
+	// Subscribe returns the events of a subscription field. The channel is
+	// closed, or ctx done, when the subscription ends.
+	Subscribe func(ctx context.Context, args Args) (<-chan any, error)
+}
+
+// Arg is an argument of a root field
+type Arg struct {
+	Name        string
+	Type        string // String, Int, Long, Float or Boolean
+	Required    bool
+	Description string
+}
+
+// Args are the coerced argument values of a field; absent optional
+// arguments are missing from the map
+type Args map[string]any
+
+// String returns a string argument, or "" if absent
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns an Int or Long argument and whether it was given
+func (a Args) Int(name string) (int64, bool) {
+	n, ok := a[name].(int64)
+	return n, ok
+}
+
+// Bool returns a Boolean argument, or nil if absent
+func (a Args) Bool(name string) *bool {
+	b, ok := a[name].(bool)
+	if !ok {
+		return nil
+	}
+	return &b
+}
+
+// Schema is a set of query and subscription root fields. Field types are
+// derived from the resolved Go types: structs become object types with a
+// field per JSON-encoded struct field, named as in JSON.
+type Schema struct {
+	query        map[string]*Field
+	subscription map[string]*Field
+
+	types map[reflect.Type]*objectType
+	names map[string]reflect.Type
+}
+
+// objectType is a Go struct exposed as a GraphQL object type
+type objectType struct {
+	name   string
+	fields map[string]*objectField
+	order  []string // JSON field order
+}
+
+// objectField is a field of an object type
+type objectField struct {
+	index []int // reflect.Value.FieldByIndex path
+	typ   reflect.Type
+}
+
+// NewSchema creates a schema from its root fields
+func NewSchema(query, subscription map[string]*Field) *Schema {
+	s := &Schema{
+		query:        query,
+		subscription: subscription,
+		types:        make(map[reflect.Type]*objectType),
+		names:        make(map[string]reflect.Type),
+	}
+	for _, root := range []map[string]*Field{query, subscription} {
+		for _, f := range root {
+			s.register(f.Type)
+		}
+	}
+	return s
+}
+
+// kind classifies a type
+type kind int
+
+const (
+	kindScalar kind = iota
+	kindList
+	kindObject
+)
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+	textType      = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// unwrap removes pointers
+func unwrap(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// classify returns the kind of a type with its pointers removed. Structs
+// are objects even if they implement json.Marshaler, which they only do to
+// tweak their encoding, but not if they encode as text like time.Time.
+func classify(t reflect.Type) kind {
+	t = unwrap(t)
+	if t == timeType || t.Implements(textType) || reflect.PointerTo(t).Implements(textType) {
+		return kindScalar
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return kindObject
+	case reflect.Slice, reflect.Array:
+		if t.Implements(marshalerType) {
+			return kindScalar
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return kindScalar // []byte is encoded as base64
+		}
+		return kindList
+	}
+	return kindScalar
+}
+
+// scalarName returns the GraphQL name of a scalar type
+func scalarName(t reflect.Type) string {
+	t = unwrap(t)
+	if t == timeType {
+		return String
+	}
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return JSON
+	}
+	if t.Implements(textType) || reflect.PointerTo(t).Implements(textType) {
+		return String
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return String
+	case reflect.Bool:
+		return Boolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return Int
+	case reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return Long
+	case reflect.Float32, reflect.Float64:
+		return Float
+	}
+	return JSON
+}
+
+// register adds the object types reachable from t
+func (s *Schema) register(t reflect.Type) {
+	t = unwrap(t)
+	switch classify(t) {
+	case kindList:
+		s.register(t.Elem())
+		return
+	case kindScalar:
+		return
+	}
+	if _, ok := s.types[t]; ok {
+		return
+	}
+
+	obj := &objectType{name: s.typeName(t), fields: make(map[string]*objectField)}
+	s.types[t] = obj
+	s.addFields(obj, t, nil)
+	for _, name := range obj.order {
+		s.register(obj.fields[name].typ)
+	}
+}
+
+// typeName names an object type after its Go type, qualified with the
+// package when two packages use the same name
+func (s *Schema) typeName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		name = "Object"
+	}
+	if other, ok := s.names[name]; ok && other != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	s.names[name] = t
+	return name
+}
+
+// addFields adds the JSON-encoded fields of a struct, including those
+// promoted from embedded structs
+func (s *Schema) addFields(obj *objectType, t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		idx := append(append([]int(nil), index...), i)
+
+		if sf.Anonymous && name == "" && unwrap(sf.Type).Kind() == reflect.Struct && sf.Type.Kind() != reflect.Pointer {
+			s.addFields(obj, sf.Type, idx)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if _, ok := obj.fields[name]; ok {
+			continue
+		}
+		obj.fields[name] = &objectField{index: idx, typ: sf.Type}
+		obj.order = append(obj.order, name)
+	}
+}
+
+// typeRef returns the GraphQL type of a Go type as used in a field
+// definition, e.g. [DroneState]
+func (s *Schema) typeRef(t reflect.Type) string {
+	t = unwrap(t)
+	switch classify(t) {
+	case kindList:
+		return "[" + s.typeRef(t.Elem()) + "]"
+	case kindObject:
+		return s.types[t].name
+	}
+	return scalarName(t)
+}
+
+// SDL returns the schema in the GraphQL schema definition language
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	b.WriteString("scalar Long\nscalar JSON\n")
+
+	writeRoot := func(name string, fields map[string]*Field) {
+		if len(fields) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\ntype %s {\n", name)
+		for _, fname := range sortedKeys(fields) {
+			f := fields[fname]
+			if f.Description != "" {
+				fmt.Fprintf(&b, "  %q\n", f.Description)
+			}
+			fmt.Fprintf(&b, "  %s", fname)
+			if len(f.Args) > 0 {
+				args := make([]string, len(f.Args))
+				for i, a := range f.Args {
+					args[i] = a.Name + ": " + a.Type
+					if a.Required {
+						args[i] += "!"
+					}
+				}
+				fmt.Fprintf(&b, "(%s)", strings.Join(args, ", "))
+			}
+			fmt.Fprintf(&b, ": %s\n", s.typeRef(f.Type))
+		}
+		b.WriteString("}\n")
+	}
+	writeRoot("Query", s.query)
+	writeRoot("Subscription", s.subscription)
+
+	objects := make([]*objectType, 0, len(s.types))
+	for _, obj := range s.types {
+		objects = append(objects, obj)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].name < objects[j].name })
+	for _, obj := range objects {
+		fmt.Fprintf(&b, "\ntype %s {\n", obj.name)
+		for _, name := range obj.order {
+			fmt.Fprintf(&b, "  %s: %s\n", name, s.typeRef(obj.fields[name].typ))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,16 +2,22 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
 	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/tenant"
 )
 
 // AlertsHandler handles alert-related API endpoints
 type AlertsHandler struct {
-	alerter *alerter.Alerter
+	alerter  *alerter.Alerter
+	tenants  *tenant.Registry
+	channels func() []string
 }
 
 // NewAlertsHandler creates a new alerts handler
@@ -21,6 +27,22 @@ func NewAlertsHandler(a *alerter.Alerter) *AlertsHandler {
 	}
 }
 
+// SetTenants limits tenant users to the alerts of their own devices
+func (h *AlertsHandler) SetTenants(tenants *tenant.Registry) {
+	h.tenants = tenants
+}
+
+// SetChannels sets the source of notification channel names rules and
+// escalation policies may use
+func (h *AlertsHandler) SetChannels(channels func() []string) {
+	h.channels = channels
+}
+
+// visible reports whether the request user may see an alert
+func (h *AlertsHandler) visible(r *http.Request, alert alerter.Alert) bool {
+	return h.tenants.Visible(auth.TenantFromContext(r.Context()), alert.DeviceID)
+}
+
 // GetAlerts returns all alerts with optional filtering
 // GET /api/v1/alerts?device_id=xxx&acknowledged=false&limit=100
 func (h *AlertsHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
@@ -41,14 +63,28 @@ func (h *AlertsHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	alerts := h.alerter.GetAlerts(deviceID, acknowledged, limit)
+	resp := map[string]interface{}{}
+	var alerts []alerter.Alert
+	if auth.TenantFromContext(r.Context()) == "" {
+		alerts = h.alerter.GetAlerts(deviceID, acknowledged, limit)
+		resp["stats"] = h.alerter.GetStats()
+	} else {
+		// Stats cover all tenants, so tenant users only get their alerts
+		for _, a := range h.alerter.GetAlerts(deviceID, acknowledged, 0) {
+			if !h.visible(r, a) {
+				continue
+			}
+			alerts = append(alerts, a)
+			if len(alerts) >= limit {
+				break
+			}
+		}
+	}
+	resp["alerts"] = alerts
+	resp["count"] = len(alerts)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"alerts": alerts,
-		"count":  len(alerts),
-		"stats":  h.alerter.GetStats(),
-	})
+	json.NewEncoder(w).Encode(resp)
 }
 
 // GetAlert returns a single alert by ID
@@ -57,6 +93,9 @@ func (h *AlertsHandler) GetAlert(w http.ResponseWriter, r *http.Request) {
 	alertID := chi.URLParam(r, "id")
 
 	alert, err := h.alerter.GetAlert(alertID)
+	if err == nil && !h.visible(r, *alert) {
+		err = alerter.ErrAlertNotFound
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -66,17 +105,25 @@ func (h *AlertsHandler) GetAlert(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(alert)
 }
 
+// ackedBy returns the user acknowledging alerts, "system" if unknown
+func ackedBy(r *http.Request) string {
+	if user := r.Context().Value("user"); user != nil {
+		if u, ok := user.(string); ok {
+			return u
+		}
+	}
+	return "system"
+}
+
 // AcknowledgeAlert marks an alert as acknowledged
 // POST /api/v1/alerts/{id}/ack
 func (h *AlertsHandler) AcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
 	alertID := chi.URLParam(r, "id")
+	ackedBy := ackedBy(r)
 
-	// Get user from context if available
-	ackedBy := "system"
-	if user := r.Context().Value("user"); user != nil {
-		if u, ok := user.(string); ok {
-			ackedBy = u
-		}
+	if alert, err := h.alerter.GetAlert(alertID); err == nil && !h.visible(r, *alert) {
+		http.Error(w, alerter.ErrAlertNotFound.Error(), http.StatusNotFound)
+		return
 	}
 
 	if err := h.alerter.AcknowledgeAlert(alertID, ackedBy); err != nil {
@@ -90,6 +137,66 @@ func (h *AlertsHandler) AcknowledgeAlert(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// BulkAckRequest selects the alerts to acknowledge: the listed IDs, or the
+// alerts matching a filter. Filter fields combine.
+type BulkAckRequest struct {
+	IDs      []string `json:"ids,omitempty"`
+	DeviceID string   `json:"device_id,omitempty"`
+	Before   int64    `json:"before,omitempty"` // Alerts raised before this Unix ms
+}
+
+// BulkAckResponse lists the alerts acknowledged by a bulk request
+type BulkAckResponse struct {
+	Acknowledged int      `json:"acknowledged"`
+	IDs          []string `json:"ids"`
+	NotFound     []string `json:"not_found,omitempty"` // Requested IDs that do not exist
+}
+
+// AcknowledgeAlerts marks several alerts as acknowledged. Alerts that are
+// already acknowledged are left as they are.
+// POST /api/v1/alerts/ack
+func (h *AlertsHandler) AcknowledgeAlerts(w http.ResponseWriter, r *http.Request) {
+	var req BulkAckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > 0 && (req.DeviceID != "" || req.Before != 0) {
+		http.Error(w, "Give either ids or a device_id/before filter", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 && req.DeviceID == "" && req.Before == 0 {
+		http.Error(w, "ids, device_id or before is required", http.StatusBadRequest)
+		return
+	}
+
+	resp := BulkAckResponse{IDs: []string{}}
+	ids := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		ids[id] = true
+		if alert, err := h.alerter.GetAlert(id); err != nil || !h.visible(r, *alert) {
+			resp.NotFound = append(resp.NotFound, id)
+		}
+	}
+	match := func(alert alerter.Alert) bool {
+		if !h.visible(r, alert) {
+			return false
+		}
+		if len(ids) > 0 {
+			return ids[alert.ID]
+		}
+		return (req.DeviceID == "" || alert.DeviceID == req.DeviceID) &&
+			(req.Before == 0 || alert.Timestamp < req.Before)
+	}
+	for _, alert := range h.alerter.AcknowledgeAlerts(match, ackedBy(r)) {
+		resp.IDs = append(resp.IDs, alert.ID)
+	}
+	resp.Acknowledged = len(resp.IDs)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // ClearAlerts removes all alerts
 // DELETE /api/v1/alerts
 func (h *AlertsHandler) ClearAlerts(w http.ResponseWriter, r *http.Request) {
@@ -115,6 +222,18 @@ func (h *AlertsHandler) GetRules(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetFields returns the fields rule conditions can compare
+// GET /api/v1/alerts/fields
+func (h *AlertsHandler) GetFields(w http.ResponseWriter, r *http.Request) {
+	fields := h.alerter.Fields()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"fields": fields,
+		"count":  len(fields),
+	})
+}
+
 // GetRule returns a single rule by ID
 // GET /api/v1/alerts/rules/{id}
 func (h *AlertsHandler) GetRule(w http.ResponseWriter, r *http.Request) {
@@ -139,24 +258,163 @@ func (h *AlertsHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if rule.Name == "" {
-		http.Error(w, "Rule name is required", http.StatusBadRequest)
+	h.createRule(w, &rule)
+}
+
+// createRule validates and creates a rule
+func (h *AlertsHandler) createRule(w http.ResponseWriter, rule *alerter.Rule) {
+	if msg := h.validateRule(rule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
 
+	if err := h.alerter.CreateRule(rule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// validateRule returns why a new rule is invalid, or ""
+func (h *AlertsHandler) validateRule(rule *alerter.Rule) string {
+	if rule.Name == "" {
+		return "Rule name is required"
+	}
 	if rule.Condition.Field == "" {
-		http.Error(w, "Condition field is required", http.StatusBadRequest)
+		return "Condition field is required"
+	}
+	if !h.alerter.HasField(rule.Condition.Field) {
+		return "Unknown condition field: " + rule.Condition.Field
+	}
+	if c := unknownChannel(h.channelNames(), rule.Channels); c != "" {
+		return "Unknown notification channel: " + c
+	}
+	if err := rule.Validate(); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// GetRuleTemplates returns the built-in rule templates
+// GET /api/v1/alerts/rules/templates
+func (h *AlertsHandler) GetRuleTemplates(w http.ResponseWriter, r *http.Request) {
+	templates := alerter.Templates()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// TemplateRuleRequest creates a rule from a template. Set fields override
+// the template's.
+type TemplateRuleRequest struct {
+	Template       string                `json:"template"`
+	Name           string                `json:"name,omitempty"`
+	Severity       alerter.AlertSeverity `json:"severity,omitempty"`
+	Threshold      *float64              `json:"threshold,omitempty"`
+	CooldownMs     *int64                `json:"cooldown_ms,omitempty"`
+	Channels       []string              `json:"channels,omitempty"`
+	DeviceIDs      []string              `json:"device_ids,omitempty"`
+	DevicePattern  string                `json:"device_pattern,omitempty"`
+	ProtocolSource string                `json:"protocol_source,omitempty"`
+}
+
+// CreateRuleFromTemplate creates an alert rule from a template
+// POST /api/v1/alerts/rules/from-template
+func (h *AlertsHandler) CreateRuleFromTemplate(w http.ResponseWriter, r *http.Request) {
+	var req TemplateRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := alerter.RuleFromTemplate(req.Template)
+	if err != nil {
+		http.Error(w, err.Error()+": "+req.Template, http.StatusNotFound)
 		return
 	}
+	if req.Name != "" {
+		rule.Name = req.Name
+	}
+	switch req.Severity {
+	case "":
+	case alerter.SeverityInfo, alerter.SeverityWarning, alerter.SeverityCritical:
+		rule.Severity = req.Severity
+	default:
+		http.Error(w, "severity must be info, warning or critical", http.StatusBadRequest)
+		return
+	}
+	if req.Threshold != nil {
+		rule.Condition.Threshold = *req.Threshold
+	}
+	if req.CooldownMs != nil {
+		rule.CooldownMs = *req.CooldownMs
+	}
+	rule.Channels = req.Channels
+	rule.DeviceIDs = req.DeviceIDs
+	rule.DevicePattern = req.DevicePattern
+	rule.ProtocolSource = req.ProtocolSource
 
-	if err := h.alerter.CreateRule(&rule); err != nil {
+	h.createRule(w, rule)
+}
+
+// ExportRules returns all alert rules as YAML, to import on other gateways
+// GET /api/v1/alerts/rules/export
+func (h *AlertsHandler) ExportRules(w http.ResponseWriter, r *http.Request) {
+	data, err := h.alerter.ExportRules()
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Header().Set("Content-Disposition", "attachment; filename=alert-rules.yaml")
+	w.Write(data)
+}
+
+// maxRulesImport is the largest rules document accepted
+const maxRulesImport = 1 << 20
+
+// ImportRules creates or replaces the alert rules of an exported YAML
+// document. Nothing changes unless every rule is valid. With replace=true,
+// rules missing from the document are deleted.
+// POST /api/v1/alerts/rules/import?replace=true
+func (h *AlertsHandler) ImportRules(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRulesImport))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	rules, err := alerter.ParseRules(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		if msg := h.validateRule(rule); msg != "" {
+			http.Error(w, "Rule "+strconv.Itoa(i)+": "+msg, http.StatusBadRequest)
+			return
+		}
+		if rule.ID != "" && ids[rule.ID] {
+			http.Error(w, "Rule "+strconv.Itoa(i)+": duplicate id "+rule.ID, http.StatusBadRequest)
+			return
+		}
+		ids[rule.ID] = true
+	}
+
+	res := h.alerter.ImportRules(rules, r.URL.Query().Get("replace") == "true")
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(rule)
+	json.NewEncoder(w).Encode(struct {
+		alerter.ImportResult
+		Count int `json:"count"`
+	}{res, len(rules)})
 }
 
 // UpdateRule updates an existing alert rule
@@ -172,6 +430,21 @@ func (h *AlertsHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
 
 	rule.ID = ruleID
 
+	if !h.alerter.HasField(rule.Condition.Field) {
+		http.Error(w, "Unknown condition field: "+rule.Condition.Field, http.StatusBadRequest)
+		return
+	}
+
+	if c := unknownChannel(h.channelNames(), rule.Channels); c != "" {
+		http.Error(w, "Unknown notification channel: "+c, http.StatusBadRequest)
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if err := h.alerter.UpdateRule(&rule); err != nil {
 		if err == alerter.ErrRuleNotFound {
 			http.Error(w, err.Error(), http.StatusNotFound)
@@ -202,6 +475,220 @@ func (h *AlertsHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetEscalations returns all escalation policies
+// GET /api/v1/alerts/escalations
+func (h *AlertsHandler) GetEscalations(w http.ResponseWriter, r *http.Request) {
+	policies := h.alerter.GetEscalationPolicies()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"policies": policies,
+		"channels": h.channelNames(),
+		"count":    len(policies),
+	})
+}
+
+// GetEscalation returns a single escalation policy by ID
+// GET /api/v1/alerts/escalations/{id}
+func (h *AlertsHandler) GetEscalation(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.alerter.GetEscalationPolicy(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// CreateEscalation creates a new escalation policy
+// POST /api/v1/alerts/escalations
+func (h *AlertsHandler) CreateEscalation(w http.ResponseWriter, r *http.Request) {
+	var policy alerter.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if msg := h.validateEscalation(&policy); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.alerter.CreateEscalationPolicy(&policy); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// UpdateEscalation updates an existing escalation policy
+// PUT /api/v1/alerts/escalations/{id}
+func (h *AlertsHandler) UpdateEscalation(w http.ResponseWriter, r *http.Request) {
+	var policy alerter.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	policy.ID = chi.URLParam(r, "id")
+	if msg := h.validateEscalation(&policy); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.alerter.UpdateEscalationPolicy(&policy); err != nil {
+		if err == alerter.ErrPolicyNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// DeleteEscalation removes an escalation policy
+// DELETE /api/v1/alerts/escalations/{id}
+func (h *AlertsHandler) DeleteEscalation(w http.ResponseWriter, r *http.Request) {
+	if err := h.alerter.DeleteEscalationPolicy(chi.URLParam(r, "id")); err != nil {
+		if err == alerter.ErrPolicyNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSilences returns the silences that have not ended
+// GET /api/v1/alerts/silences
+func (h *AlertsHandler) GetSilences(w http.ResponseWriter, r *http.Request) {
+	silences := h.alerter.GetSilences()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"silences": silences,
+		"count":    len(silences),
+	})
+}
+
+// GetSilence returns a single silence by ID
+// GET /api/v1/alerts/silences/{id}
+func (h *AlertsHandler) GetSilence(w http.ResponseWriter, r *http.Request) {
+	silence, err := h.alerter.GetSilence(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(silence)
+}
+
+// CreateSilence creates a new silence
+// POST /api/v1/alerts/silences
+func (h *AlertsHandler) CreateSilence(w http.ResponseWriter, r *http.Request) {
+	var silence alerter.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	silence.ID = ""
+	if user, ok := auth.GetUserFromContext(r.Context()); ok {
+		silence.CreatedBy = user.Username
+	}
+
+	if err := h.alerter.CreateSilence(&silence); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(silence)
+}
+
+// UpdateSilence updates an existing silence, e.g. to end it early
+// PUT /api/v1/alerts/silences/{id}
+func (h *AlertsHandler) UpdateSilence(w http.ResponseWriter, r *http.Request) {
+	var silence alerter.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	silence.ID = chi.URLParam(r, "id")
+
+	if err := h.alerter.UpdateSilence(&silence); err != nil {
+		if err == alerter.ErrSilenceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(silence)
+}
+
+// DeleteSilence removes a silence
+// DELETE /api/v1/alerts/silences/{id}
+func (h *AlertsHandler) DeleteSilence(w http.ResponseWriter, r *http.Request) {
+	if err := h.alerter.DeleteSilence(chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// channelNames returns the configured notification channels
+func (h *AlertsHandler) channelNames() []string {
+	if h.channels == nil {
+		return []string{}
+	}
+	return h.channels()
+}
+
+// validateEscalation returns a message describing what is wrong with a
+// policy, or "" if it is valid
+func (h *AlertsHandler) validateEscalation(p *alerter.EscalationPolicy) string {
+	if p.Name == "" {
+		return "Policy name is required"
+	}
+	if p.AfterMs < 0 {
+		return "after_ms must not be negative"
+	}
+	switch p.MinSeverity {
+	case "", alerter.SeverityInfo, alerter.SeverityWarning, alerter.SeverityCritical:
+	default:
+		return "min_severity must be info, warning or critical"
+	}
+	if len(p.Channels) == 0 {
+		return "At least one channel is required"
+	}
+	if c := unknownChannel(h.channelNames(), p.Channels); c != "" {
+		return "Unknown notification channel: " + c
+	}
+	return ""
+}
+
+// unknownChannel returns the first of channels that is not known, or ""
+func unknownChannel(known, channels []string) string {
+	for _, c := range channels {
+		if !slices.Contains(known, c) {
+			return c
+		}
+	}
+	return ""
+}
+
 // GetStats returns alerter statistics
 // GET /api/v1/alerts/stats
 func (h *AlertsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
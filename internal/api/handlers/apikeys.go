@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/tenant"
+)
+
+// APIKeysHandler handles API key management endpoints
+type APIKeysHandler struct {
+	keys    *auth.KeyStore
+	tenants *tenant.Registry
+}
+
+// NewAPIKeysHandler creates a new API keys handler
+func NewAPIKeysHandler(keys *auth.KeyStore) *APIKeysHandler {
+	return &APIKeysHandler{
+		keys: keys,
+	}
+}
+
+// SetTenants sets the registry used to validate the tenant of new keys
+func (h *APIKeysHandler) SetTenants(tenants *tenant.Registry) {
+	h.tenants = tenants
+}
+
+// CreateAPIKeyRequest is the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`          // read | write | admin (default read)
+	ExpiresInDays int      `json:"expires_in_days"` // 0 = never expires
+	Tenant        string   `json:"tenant"`          // Limit the key to a tenant's devices (optional)
+}
+
+// CreateAPIKeyResponse is returned once when a key is created
+type CreateAPIKeyResponse struct {
+	Key    string      `json:"key"` // Raw key, only shown once
+	APIKey auth.APIKey `json:"api_key"`
+}
+
+// GetAPIKeys returns all API keys (without secrets)
+// GET /api/v1/apikeys
+func (h *APIKeysHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys := h.keys.List()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"api_keys": keys,
+		"count":    len(keys),
+	})
+}
+
+// CreateAPIKey creates a new API key
+// POST /api/v1/apikeys
+func (h *APIKeysHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "API key name is required", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		http.Error(w, "expires_in_days must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if req.Tenant != "" {
+		if _, ok := h.tenants.Get(req.Tenant); !ok {
+			http.Error(w, "unknown tenant: "+req.Tenant, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresInDays > 0 {
+		expiresAt = time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+	}
+
+	key, raw, err := h.keys.CreateForTenant(req.Name, req.Scopes, expiresAt, req.Tenant)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidScope) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateAPIKeyResponse{Key: raw, APIKey: key})
+}
+
+// DeleteAPIKey revokes an API key
+// DELETE /api/v1/apikeys/{id}
+func (h *APIKeysHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := h.keys.Delete(id); err != nil {
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
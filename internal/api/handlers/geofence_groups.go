@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/geofence"
+)
+
+// GroupRequest represents a create or update geofence group request
+type GroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parent      string `json:"parent,omitempty"` // ID of the enclosing group
+	Enabled     bool   `json:"enabled"`
+}
+
+// getOwnedGroup returns a group the request user may access. Like
+// geofences, tenant users can read global groups but only change their own.
+func (h *GeofencesHandler) getOwnedGroup(r *http.Request, id string, write bool) (*geofence.Group, error) {
+	g, err := h.engine.GetGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	tenantID := auth.TenantFromContext(r.Context())
+	if tenantID != "" && g.Tenant != tenantID && (write || g.Tenant != "") {
+		return nil, geofence.ErrGroupNotFound
+	}
+	return g, nil
+}
+
+// checkGroup reports whether the request user may put geofences or
+// subgroups into the group; "" is always allowed
+func (h *GeofencesHandler) checkGroup(w http.ResponseWriter, r *http.Request, id string) bool {
+	if id == "" {
+		return true
+	}
+	if _, err := h.getOwnedGroup(r, id, false); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown group: " + id})
+		return false
+	}
+	return true
+}
+
+// writeGroupError writes the response for a geofence group error
+func writeGroupError(w http.ResponseWriter, err error) {
+	switch err {
+	case geofence.ErrGroupNotFound:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case geofence.ErrParentNotFound, geofence.ErrGroupCycle:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case geofence.ErrGroupNotEmpty:
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+// GetGroups returns all geofence groups
+func (h *GeofencesHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
+	groups := h.engine.GetGroups()
+	if tenantID := auth.TenantFromContext(r.Context()); tenantID != "" {
+		visible := make([]*geofence.Group, 0, len(groups))
+		for _, g := range groups {
+			if g.Tenant == "" || g.Tenant == tenantID {
+				visible = append(visible, g)
+			}
+		}
+		groups = visible
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"groups": groups,
+		"count":  len(groups),
+	})
+}
+
+// GetGroup returns a single geofence group by ID
+func (h *GeofencesHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	g, err := h.getOwnedGroup(r, chi.URLParam(r, "id"), false)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+// CreateGroup creates a new geofence group
+func (h *GeofencesHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+	if !h.checkGroup(w, r, req.Parent) {
+		return
+	}
+
+	g := &geofence.Group{
+		Name:        req.Name,
+		Description: req.Description,
+		Parent:      req.Parent,
+		Enabled:     req.Enabled,
+		Tenant:      auth.TenantFromContext(r.Context()),
+	}
+	if err := h.engine.AddGroup(g); err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, g)
+}
+
+// UpdateGroup updates a geofence group. Disabling a group deactivates all
+// geofences in it and its subgroups.
+func (h *GeofencesHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	existing, err := h.getOwnedGroup(r, chi.URLParam(r, "id"), true)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	var req GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if !h.checkGroup(w, r, req.Parent) {
+		return
+	}
+
+	// Update a copy, so a rejected parent leaves the group unchanged
+	g := *existing
+	if req.Name != "" {
+		g.Name = req.Name
+	}
+	g.Description = req.Description
+	g.Parent = req.Parent
+	g.Enabled = req.Enabled
+
+	if err := h.engine.UpdateGroup(&g); err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &g)
+}
+
+// DeleteGroup removes an empty geofence group
+func (h *GeofencesHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	_, err := h.getOwnedGroup(r, id, true)
+	if err == nil {
+		err = h.engine.DeleteGroup(id)
+	}
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetGroupStats returns the statistics of a geofence group and its
+// subgroups. Tenant users only get those of their own groups, since global
+// groups count the breaches of every tenant.
+func (h *GeofencesHandler) GetGroupStats(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	_, err := h.getOwnedGroup(r, id, true)
+	var stats *geofence.GroupStats
+	if err == nil {
+		stats, err = h.engine.GroupStats(id)
+	}
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -7,12 +7,18 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
 	"github.com/open-uav/telemetry-bridge/internal/core/geofence"
+	"github.com/open-uav/telemetry-bridge/internal/core/tenant"
 )
 
 // GeofencesHandler handles geofence-related API requests
 type GeofencesHandler struct {
-	engine *geofence.Engine
+	engine   *geofence.Engine
+	tenants  *tenant.Registry
+	channels func() []string
 }
 
 // NewGeofencesHandler creates a new geofences handler
@@ -22,9 +28,60 @@ func NewGeofencesHandler(engine *geofence.Engine) *GeofencesHandler {
 	}
 }
 
-// GetGeofences returns all geofences
+// SetTenants limits tenant users to the breaches of their own devices
+func (h *GeofencesHandler) SetTenants(tenants *tenant.Registry) {
+	h.tenants = tenants
+}
+
+// SetChannels sets the source of notification channel names geofences may
+// send breach alerts to
+func (h *GeofencesHandler) SetChannels(channels func() []string) {
+	h.channels = channels
+}
+
+// unknownChannel returns the first of channels that is not configured, or ""
+func (h *GeofencesHandler) unknownChannel(channels []string) string {
+	var known []string
+	if h.channels != nil {
+		known = h.channels()
+	}
+	return unknownChannel(known, channels)
+}
+
+// getOwned returns a geofence the request user may access. Tenant users
+// can read geofences that apply to every device but only change their own.
+func (h *GeofencesHandler) getOwned(r *http.Request, id string, write bool) (*geofence.Geofence, error) {
+	gf, err := h.engine.GetGeofence(id)
+	if err != nil {
+		return nil, err
+	}
+	tenantID := auth.TenantFromContext(r.Context())
+	if tenantID != "" && gf.Tenant != tenantID && (write || gf.Tenant != "") {
+		return nil, geofence.ErrGeofenceNotFound
+	}
+	return gf, nil
+}
+
+// GetGeofences returns all geofences, or those of a group and its
+// subgroups
+// GET /geofences?group=
 func (h *GeofencesHandler) GetGeofences(w http.ResponseWriter, r *http.Request) {
 	geofences := h.engine.GetGeofences()
+	tenantID := auth.TenantFromContext(r.Context())
+	group := r.URL.Query().Get("group")
+	if tenantID != "" || group != "" {
+		visible := make([]*geofence.Geofence, 0, len(geofences))
+		for _, gf := range geofences {
+			if tenantID != "" && gf.Tenant != "" && gf.Tenant != tenantID {
+				continue
+			}
+			if group != "" && !h.engine.InGroup(gf, group) {
+				continue
+			}
+			visible = append(visible, gf)
+		}
+		geofences = visible
+	}
 
 	resp := map[string]interface{}{
 		"geofences": geofences,
@@ -38,7 +95,7 @@ func (h *GeofencesHandler) GetGeofences(w http.ResponseWriter, r *http.Request)
 func (h *GeofencesHandler) GetGeofence(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	gf, err := h.engine.GetGeofence(id)
+	gf, err := h.getOwned(r, id, false)
 	if err != nil {
 		if err == geofence.ErrGeofenceNotFound {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": "geofence not found"})
@@ -63,6 +120,13 @@ type CreateGeofenceRequest struct {
 	AlertOnEnter bool                  `json:"alert_on_enter"`
 	AlertOnExit  bool                  `json:"alert_on_exit"`
 	Enabled      bool                  `json:"enabled"`
+	Severity     alerter.AlertSeverity `json:"severity,omitempty"` // Breach alert severity (default warning)
+	Channels     []string              `json:"channels,omitempty"` // Notification channels breach alerts are sent to
+	Datum        string                `json:"datum,omitempty"`    // Datum of the coordinates (default geofence.default_datum)
+	Group        *string               `json:"group,omitempty"`    // Group ID; on update, "" removes the fence from its group
+
+	DwellInsideSec  int `json:"dwell_inside_sec,omitempty"`  // Alert when a drone stays inside longer (0 = off)
+	DwellOutsideSec int `json:"dwell_outside_sec,omitempty"` // Alert when a drone stays outside longer (0 = off)
 }
 
 // CreateGeofence creates a new geofence
@@ -89,6 +153,34 @@ func (h *GeofencesHandler) CreateGeofence(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if req.Severity != "" && !geofence.ValidSeverity(req.Severity) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "severity must be 'info', 'warning' or 'critical'"})
+		return
+	}
+
+	if c := h.unknownChannel(req.Channels); c != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown notification channel: " + c})
+		return
+	}
+
+	if req.DwellInsideSec < 0 || req.DwellOutsideSec < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "dwell times must not be negative"})
+		return
+	}
+
+	if req.Datum != "" && !coordinator.ValidDatum(req.Datum) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "datum must be 'wgs84', 'gcj02' or 'bd09'"})
+		return
+	}
+
+	var group string
+	if req.Group != nil {
+		group = *req.Group
+	}
+	if !h.checkGroup(w, r, group) {
+		return
+	}
+
 	if req.Type == geofence.GeofenceTypeCircle {
 		if len(req.Center) < 2 {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "circle requires center [lat, lon]"})
@@ -100,6 +192,11 @@ func (h *GeofencesHandler) CreateGeofence(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	datum := req.Datum
+	if datum == "" {
+		datum = h.engine.DefaultDatum()
+	}
+
 	gf := &geofence.Geofence{
 		Name:         req.Name,
 		Type:         req.Type,
@@ -111,6 +208,14 @@ func (h *GeofencesHandler) CreateGeofence(w http.ResponseWriter, r *http.Request
 		AlertOnEnter: req.AlertOnEnter,
 		AlertOnExit:  req.AlertOnExit,
 		Enabled:      req.Enabled,
+		Severity:     req.Severity,
+		Channels:     req.Channels,
+		Tenant:       auth.TenantFromContext(r.Context()),
+		Datum:        datum,
+		Group:        group,
+
+		DwellInsideSec:  req.DwellInsideSec,
+		DwellOutsideSec: req.DwellOutsideSec,
 	}
 
 	if err := h.engine.AddGeofence(gf); err != nil {
@@ -126,7 +231,7 @@ func (h *GeofencesHandler) UpdateGeofence(w http.ResponseWriter, r *http.Request
 	id := chi.URLParam(r, "id")
 
 	// Get existing geofence
-	existing, err := h.engine.GetGeofence(id)
+	existing, err := h.getOwned(r, id, true)
 	if err != nil {
 		if err == geofence.ErrGeofenceNotFound {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": "geofence not found"})
@@ -142,6 +247,30 @@ func (h *GeofencesHandler) UpdateGeofence(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if req.Severity != "" && !geofence.ValidSeverity(req.Severity) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "severity must be 'info', 'warning' or 'critical'"})
+		return
+	}
+
+	if c := h.unknownChannel(req.Channels); c != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown notification channel: " + c})
+		return
+	}
+
+	if req.DwellInsideSec < 0 || req.DwellOutsideSec < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "dwell times must not be negative"})
+		return
+	}
+
+	if req.Datum != "" && !coordinator.ValidDatum(req.Datum) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "datum must be 'wgs84', 'gcj02' or 'bd09'"})
+		return
+	}
+
+	if req.Group != nil && !h.checkGroup(w, r, *req.Group) {
+		return
+	}
+
 	// Update fields
 	if req.Name != "" {
 		existing.Name = req.Name
@@ -163,6 +292,20 @@ func (h *GeofencesHandler) UpdateGeofence(w http.ResponseWriter, r *http.Request
 	existing.AlertOnEnter = req.AlertOnEnter
 	existing.AlertOnExit = req.AlertOnExit
 	existing.Enabled = req.Enabled
+	existing.DwellInsideSec = req.DwellInsideSec
+	existing.DwellOutsideSec = req.DwellOutsideSec
+	if req.Severity != "" {
+		existing.Severity = req.Severity
+	}
+	if req.Channels != nil {
+		existing.Channels = req.Channels
+	}
+	if req.Datum != "" {
+		existing.Datum = req.Datum
+	}
+	if req.Group != nil {
+		existing.Group = *req.Group
+	}
 
 	if err := h.engine.UpdateGeofence(existing); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -176,7 +319,11 @@ func (h *GeofencesHandler) UpdateGeofence(w http.ResponseWriter, r *http.Request
 func (h *GeofencesHandler) DeleteGeofence(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	if err := h.engine.DeleteGeofence(id); err != nil {
+	_, err := h.getOwned(r, id, true)
+	if err == nil {
+		err = h.engine.DeleteGeofence(id)
+	}
+	if err != nil {
 		if err == geofence.ErrGeofenceNotFound {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": "geofence not found"})
 			return
@@ -189,9 +336,13 @@ func (h *GeofencesHandler) DeleteGeofence(w http.ResponseWriter, r *http.Request
 }
 
 // GetBreaches returns breach history
+// GET /geofences/breaches?device_id=&geofence_id=&group_id=&limit=
 func (h *GeofencesHandler) GetBreaches(w http.ResponseWriter, r *http.Request) {
-	deviceID := r.URL.Query().Get("device_id")
-	geofenceID := r.URL.Query().Get("geofence_id")
+	q := geofence.BreachQuery{
+		DeviceID:   r.URL.Query().Get("device_id"),
+		GeofenceID: r.URL.Query().Get("geofence_id"),
+		GroupID:    r.URL.Query().Get("group_id"),
+	}
 
 	limit := 100
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
@@ -200,7 +351,21 @@ func (h *GeofencesHandler) GetBreaches(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	breaches := h.engine.GetBreaches(deviceID, geofenceID, limit)
+	var breaches []geofence.Breach
+	if tenantID := auth.TenantFromContext(r.Context()); tenantID == "" {
+		q.Limit = limit
+		breaches = h.engine.QueryBreaches(q)
+	} else {
+		for _, b := range h.engine.QueryBreaches(q) {
+			if !h.tenants.Visible(tenantID, b.DeviceID) {
+				continue
+			}
+			breaches = append(breaches, b)
+			if len(breaches) >= limit {
+				break
+			}
+		}
+	}
 
 	resp := map[string]interface{}{
 		"breaches": breaches,
@@ -24,6 +24,11 @@ func NewLogsHandler(buffer *logger.Buffer) *LogsHandler {
 	}
 }
 
+// SetBuffer replaces the log buffer served by the handler
+func (h *LogsHandler) SetBuffer(buffer *logger.Buffer) {
+	h.buffer = buffer
+}
+
 // GetLogs returns historical log entries with optional filtering
 // GET /api/v1/logs?level=info&source=Engine&limit=100&since_id=123
 func (h *LogsHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
@@ -169,6 +174,96 @@ func (h *LogsHandler) ClearLogs(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// LogLevels is the default log level and its per-source overrides
+type LogLevels struct {
+	Default logger.Level            `json:"default"`
+	Sources map[string]logger.Level `json:"sources"`
+}
+
+// GetLevels returns the log levels
+// GET /api/v1/logs/levels
+func (h *LogsHandler) GetLevels(w http.ResponseWriter, r *http.Request) {
+	if h.buffer == nil {
+		http.Error(w, "Log buffer not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, LogLevels{
+		Default: h.buffer.Level(),
+		Sources: h.buffer.SourceLevels(),
+	})
+}
+
+// UpdateLevels changes the log levels at runtime. An empty default keeps
+// the current one; sources, when present, replace all overrides.
+// PUT /api/v1/logs/levels
+func (h *LogsHandler) UpdateLevels(w http.ResponseWriter, r *http.Request) {
+	if h.buffer == nil {
+		http.Error(w, "Log buffer not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req LogLevels
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	var level logger.Level
+	if req.Default != "" {
+		var err error
+		if level, err = logger.ParseLevel(string(req.Default)); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "default: " + err.Error()})
+			return
+		}
+	}
+	names := make(map[string]string, len(req.Sources))
+	for source, l := range req.Sources {
+		names[source] = string(l)
+	}
+	sources, err := logger.ParseSourceLevels(names)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if level != "" {
+		h.buffer.SetLevel(level)
+	}
+	if req.Sources != nil {
+		h.buffer.SetSourceLevels(sources)
+	}
+	h.GetLevels(w, r)
+}
+
+// LogStats counts log entries by level and source
+type LogStats struct {
+	Levels     map[logger.Level]int64   `json:"levels"`     // Entries logged per level, all sources
+	Suppressed int64                    `json:"suppressed"` // Entries dropped by the level filters
+	Sources    map[string]logger.Counts `json:"sources"`
+}
+
+// GetStats returns log counters by level and source since startup
+// GET /api/v1/logs/stats
+func (h *LogsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	if h.buffer == nil {
+		http.Error(w, "Log buffer not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	stats := LogStats{
+		Levels:  make(map[logger.Level]int64),
+		Sources: h.buffer.Counts(),
+	}
+	for _, c := range stats.Sources {
+		for level, n := range c.Levels {
+			stats.Levels[level] += n
+		}
+		stats.Suppressed += c.Suppressed
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
 // shouldSendLog checks if an entry with given level should be included based on filter
 func shouldSendLog(entryLevel, filterLevel logger.Level) bool {
 	levels := map[logger.Level]int{
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
+)
+
+// RoutingHandler handles publisher routing rule endpoints
+type RoutingHandler struct {
+	router     *routing.Router
+	publishers func() []string
+}
+
+// NewRoutingHandler creates a new routing handler. publishers returns the
+// names of the registered publishers, used to validate rules.
+func NewRoutingHandler(router *routing.Router, publishers func() []string) *RoutingHandler {
+	return &RoutingHandler{
+		router:     router,
+		publishers: publishers,
+	}
+}
+
+// GetRules returns all routing rules in evaluation order
+// GET /api/v1/routing/rules
+func (h *RoutingHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	rules := h.router.List()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// GetRule returns a single routing rule
+// GET /api/v1/routing/rules/{id}
+func (h *RoutingHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.router.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// CreateRule appends a routing rule
+// POST /api/v1/routing/rules
+func (h *RoutingHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.decodeRule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.router.Create(&rule); err != nil {
+		h.writeRuleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateRule replaces a routing rule
+// PUT /api/v1/routing/rules/{id}
+func (h *RoutingHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.decodeRule(w, r)
+	if !ok {
+		return
+	}
+	rule.ID = chi.URLParam(r, "id")
+
+	if err := h.router.Update(&rule); err != nil {
+		h.writeRuleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRule removes a routing rule
+// DELETE /api/v1/routing/rules/{id}
+func (h *RoutingHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.router.Delete(chi.URLParam(r, "id")); err != nil {
+		h.writeRuleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRule reads a rule from the request body and checks that it only
+// names registered publishers. New rules are enabled unless the body says
+// otherwise.
+func (h *RoutingHandler) decodeRule(w http.ResponseWriter, r *http.Request) (routing.Rule, bool) {
+	rule := routing.Rule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return rule, false
+	}
+
+	if rule.Name == "" {
+		http.Error(w, "Rule name is required", http.StatusBadRequest)
+		return rule, false
+	}
+
+	known := make(map[string]bool)
+	for _, name := range h.publishers() {
+		known[name] = true
+	}
+	for _, name := range rule.Publishers {
+		if !known[name] {
+			http.Error(w, fmt.Sprintf("Unknown publisher: %s", name), http.StatusBadRequest)
+			return rule, false
+		}
+	}
+	return rule, true
+}
+
+// writeRuleError maps router errors to HTTP status codes
+func (h *RoutingHandler) writeRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, routing.ErrRuleNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, routing.ErrInvalidRule):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
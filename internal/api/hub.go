@@ -4,9 +4,15 @@ import (
 	"encoding/json"
 	"log"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gorilla/websocket"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"golang.org/x/time/rate"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/broadcast"
+	"github.com/open-uav/telemetry-bridge/internal/core/timeline"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // WSMessageType defines WebSocket message types
@@ -14,11 +20,24 @@ type WSMessageType string
 
 const (
 	WSMessageTypeStateUpdate  WSMessageType = "state_update"
+	WSMessageTypeStateDelta   WSMessageType = "state_delta"
 	WSMessageTypeDroneOnline  WSMessageType = "drone_online"
 	WSMessageTypeDroneOffline WSMessageType = "drone_offline"
 	WSMessageTypeSubscribe    WSMessageType = "subscribe"
 	WSMessageTypeUnsubscribe  WSMessageType = "unsubscribe"
+	WSMessageTypeSubscribeAck WSMessageType = "subscribe_ack"
 	WSMessageTypeError        WSMessageType = "error"
+	WSMessageTypeBoostRate    WSMessageType = "boost_rate"
+	WSMessageTypeClearBoost   WSMessageType = "clear_boost"
+	WSMessageTypeBoostAck     WSMessageType = "boost_ack"
+	WSMessageTypeBroadcast    WSMessageType = "broadcast"
+	WSMessageTypeBroadcastEnd WSMessageType = "broadcast_cleared"
+	WSMessageTypeMission      WSMessageType = "mission_changed"
+	WSMessageTypeAlertsAcked  WSMessageType = "alerts_acknowledged"
+	WSMessageTypeAdapter      WSMessageType = "adapter_status"
+	WSMessageTypeFlightEvent  WSMessageType = "flight_event"
+	WSMessageTypeAuth         WSMessageType = "auth"
+	WSMessageTypeAuthAck      WSMessageType = "auth_ack"
 )
 
 // WSMessage represents a WebSocket message
@@ -35,6 +54,17 @@ type WSClient struct {
 	send        chan []byte
 	subscribed  map[string]bool // subscribed device IDs, empty means all
 	mu          sync.RWMutex
+	throttle    ThrottleProvider // nil if rate boosts are unsupported
+	canControl  bool             // client may send control messages
+	tenant      string           // tenant the client is limited to, empty for global users
+
+	authenticate func(WSAuth) (auth.User, error) // validates auth messages, nil if auth is disabled
+	limiter      *rate.Limiter                   // limits client messages, nil if unlimited
+
+	delta     bool                      // send state_delta frames
+	lastState map[string]map[string]any // last state queued per device, the delta baseline
+	deflate   bool                      // permessage-deflate was negotiated
+	compress  atomic.Bool               // compress outgoing frames
 }
 
 // Hub maintains the set of active clients and broadcasts messages
@@ -111,38 +141,200 @@ func (h *Hub) BroadcastState(state *models.DroneState) {
 		return
 	}
 
+	// Delta clients share one decoded copy of the state
+	var fields map[string]any
+
 	h.mu.RLock()
 	for client := range h.clients {
-		if client.isSubscribed(state.DeviceID) {
-			select {
-			case client.send <- msgBytes:
-			default:
-				// Skip if buffer is full
+		if client.seesTenant(state.Tenant) && client.isSubscribed(state.DeviceID) {
+			if fields == nil && client.wantsDelta() {
+				json.Unmarshal(data, &fields)
 			}
+			client.queueState(state.DeviceID, msgBytes, fields)
 		}
 	}
 	h.mu.RUnlock()
 }
 
-// BroadcastDroneOnline notifies clients that a drone is online
-func (h *Hub) BroadcastDroneOnline(deviceID string) {
+// BroadcastDroneOnline notifies the clients allowed to see the tenant's
+// devices that a drone is online
+func (h *Hub) BroadcastDroneOnline(deviceID, tenant string) {
 	msg := WSMessage{
 		Type:     WSMessageTypeDroneOnline,
 		DeviceID: deviceID,
 	}
 
 	msgBytes, _ := json.Marshal(msg)
-	h.broadcast <- msgBytes
+	h.broadcastTenant(msgBytes, tenant)
 }
 
-// BroadcastDroneOffline notifies clients that a drone is offline
-func (h *Hub) BroadcastDroneOffline(deviceID string) {
+// BroadcastDroneOffline notifies the clients allowed to see the tenant's
+// devices that a drone is offline
+func (h *Hub) BroadcastDroneOffline(deviceID, tenant string) {
 	msg := WSMessage{
 		Type:     WSMessageTypeDroneOffline,
 		DeviceID: deviceID,
 	}
 
 	msgBytes, _ := json.Marshal(msg)
+	h.broadcastTenant(msgBytes, tenant)
+
+	// A drone coming back is sent in full again
+	h.mu.RLock()
+	for client := range h.clients {
+		client.forgetState(deviceID)
+	}
+	h.mu.RUnlock()
+}
+
+// BroadcastMission sends a drone's new mission plan to the subscribed
+// clients allowed to see the tenant's devices
+func (h *Hub) BroadcastMission(deviceID, tenant string, mission *models.Mission) {
+	data, err := json.Marshal(mission)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to marshal mission: %v", err)
+		return
+	}
+	msgBytes, _ := json.Marshal(WSMessage{
+		Type:     WSMessageTypeMission,
+		DeviceID: deviceID,
+		Data:     data,
+	})
+
+	h.mu.RLock()
+	for client := range h.clients {
+		if client.seesTenant(tenant) && client.isSubscribed(deviceID) {
+			select {
+			case client.send <- msgBytes:
+			default:
+				// Skip if buffer is full
+			}
+		}
+	}
+	h.mu.RUnlock()
+}
+
+// BroadcastFlightEvent sends a flight event of a device to the clients
+// subscribed to it
+func (h *Hub) BroadcastFlightEvent(deviceID, tenant string, ev *timeline.Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to marshal flight event: %v", err)
+		return
+	}
+	msgBytes, _ := json.Marshal(WSMessage{
+		Type:     WSMessageTypeFlightEvent,
+		DeviceID: deviceID,
+		Data:     data,
+	})
+
+	h.mu.RLock()
+	for client := range h.clients {
+		if client.seesTenant(tenant) && client.isSubscribed(deviceID) {
+			select {
+			case client.send <- msgBytes:
+			default:
+				// Skip if buffer is full
+			}
+		}
+	}
+	h.mu.RUnlock()
+}
+
+// AlertsAcked is the payload of an alerts_acknowledged message
+type AlertsAcked struct {
+	IDs     []string `json:"ids"`
+	AckedBy string   `json:"acked_by"`
+	AckedAt int64    `json:"acked_at"` // Unix ms
+}
+
+// BroadcastAlertsAcked tells the clients allowed to see the tenant's
+// devices that alerts were acknowledged, so every open dashboard clears
+// them at once
+func (h *Hub) BroadcastAlertsAcked(acked AlertsAcked, tenant string) {
+	data, err := json.Marshal(acked)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to marshal acknowledgement: %v", err)
+		return
+	}
+	msgBytes, _ := json.Marshal(WSMessage{
+		Type: WSMessageTypeAlertsAcked,
+		Data: data,
+	})
+	h.broadcastTenant(msgBytes, tenant)
+}
+
+// AdapterLink is the payload of an adapter_status message
+type AdapterLink struct {
+	Adapter string `json:"adapter"`
+	models.LinkStatus
+}
+
+// BroadcastAdapterStatus tells global clients that an adapter's link
+// opened or was lost. Tenant users do not see gateway-wide state.
+func (h *Hub) BroadcastAdapterStatus(link AdapterLink) {
+	data, err := json.Marshal(link)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to marshal adapter status: %v", err)
+		return
+	}
+	msgBytes, _ := json.Marshal(WSMessage{
+		Type: WSMessageTypeAdapter,
+		Data: data,
+	})
+
+	h.mu.RLock()
+	for client := range h.clients {
+		if client.isGlobal() {
+			select {
+			case client.send <- msgBytes:
+			default:
+				// Skip if buffer is full
+			}
+		}
+	}
+	h.mu.RUnlock()
+}
+
+// broadcastTenant sends a message to global clients and to the clients of
+// the given tenant
+func (h *Hub) broadcastTenant(msgBytes []byte, tenant string) {
+	h.mu.RLock()
+	for client := range h.clients {
+		if client.seesTenant(tenant) {
+			select {
+			case client.send <- msgBytes:
+			default:
+				// Skip if buffer is full
+			}
+		}
+	}
+	h.mu.RUnlock()
+}
+
+// BroadcastOperatorMessage sends an operator broadcast to all clients,
+// regardless of their device subscriptions
+func (h *Hub) BroadcastOperatorMessage(msg *broadcast.Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("[WebSocket] Failed to marshal broadcast: %v", err)
+		return
+	}
+
+	msgBytes, _ := json.Marshal(WSMessage{
+		Type: WSMessageTypeBroadcast,
+		Data: data,
+	})
+	h.broadcast <- msgBytes
+}
+
+// BroadcastCleared notifies clients that an operator broadcast was withdrawn
+func (h *Hub) BroadcastCleared(id string) {
+	data, _ := json.Marshal(map[string]string{"id": id})
+	msgBytes, _ := json.Marshal(WSMessage{
+		Type: WSMessageTypeBroadcastEnd,
+		Data: data,
+	})
 	h.broadcast <- msgBytes
 }
 
@@ -153,6 +345,21 @@ func (h *Hub) ClientCount() int {
 	return len(h.clients)
 }
 
+// seesTenant reports whether the client may receive messages about the
+// devices of a tenant. Global clients see every tenant.
+func (c *WSClient) seesTenant(tenant string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tenant == "" || c.tenant == tenant
+}
+
+// isGlobal reports whether the client is not limited to a tenant
+func (c *WSClient) isGlobal() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tenant == ""
+}
+
 // isSubscribed checks if the client is subscribed to a device
 func (c *WSClient) isSubscribed(deviceID string) bool {
 	c.mu.RLock()
@@ -185,5 +392,6 @@ func (c *WSClient) unsubscribe(deviceIDs []string) {
 
 	for _, id := range deviceIDs {
 		delete(c.subscribed, id)
+		delete(c.lastState, id)
 	}
 }
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/incident"
+)
+
+// IncidentsResponse is the response for GET /api/v1/incidents
+type IncidentsResponse struct {
+	Count     int                 `json:"count"`
+	Incidents []incident.Incident `json:"incidents"` // Most recently updated first
+}
+
+// GetIncidents returns the incident correlator
+func (s *Server) GetIncidents() *incident.Correlator {
+	return s.incidents
+}
+
+// correlateEvent feeds alerts and link events to the incident correlator
+func (s *Server) correlateEvent(ev events.Event) {
+	if s.incidents == nil {
+		return
+	}
+	switch ev.Type {
+	case events.AlertRaised:
+		s.incidents.AddAlert(ev.Alert)
+	case events.DeviceOffline:
+		s.incidents.LinkLost(ev.DeviceID, ev.Timestamp)
+	case events.DeviceOnline:
+		s.incidents.LinkRestored(ev.DeviceID, ev.Timestamp)
+	}
+}
+
+// handleGetIncidents lists incidents
+// GET /api/v1/incidents?device_id=&status=open|resolved&limit=
+func (s *Server) handleGetIncidents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := incident.Query{DeviceID: query.Get("device_id"), Status: incident.Status(query.Get("status"))}
+	if q.Status != "" && q.Status != incident.StatusOpen && q.Status != incident.StatusResolved {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "status must be open or resolved",
+		})
+		return
+	}
+
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 1000 {
+			limit = l
+		}
+	}
+
+	incidents := make([]incident.Incident, 0)
+	for _, inc := range s.incidents.List(q) {
+		if !s.deviceVisible(r, inc.DeviceID) {
+			continue
+		}
+		incidents = append(incidents, inc)
+		if len(incidents) >= limit {
+			break
+		}
+	}
+	s.writeJSON(w, http.StatusOK, IncidentsResponse{Count: len(incidents), Incidents: incidents})
+}
+
+// handleGetIncident returns an incident with its events
+// GET /api/v1/incidents/{id}
+func (s *Server) handleGetIncident(w http.ResponseWriter, r *http.Request) {
+	inc, err := s.incidents.Get(chi.URLParam(r, "id"))
+	if err != nil || !s.deviceVisible(r, inc.DeviceID) {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: incident.ErrIncidentNotFound.Error(),
+		})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, inc)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/scheduler"
+)
+
+// JobsResponse is the response for GET /api/v1/jobs
+type JobsResponse struct {
+	Count int             `json:"count"`
+	Jobs  []scheduler.Job `json:"jobs"`
+}
+
+// JobResponse is the response for GET /api/v1/jobs/{name}
+type JobResponse struct {
+	scheduler.Job
+	History []scheduler.Run `json:"history"` // Newest first
+}
+
+// SetScheduler enables the /api/v1/jobs endpoints
+func (s *Server) SetScheduler(sched *scheduler.Scheduler) {
+	s.scheduler = sched
+}
+
+// requireScheduler writes 501 if the scheduler is not set
+func (s *Server) requireScheduler(w http.ResponseWriter) bool {
+	if s.scheduler == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "job scheduler not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// handleGetJobs lists scheduled jobs with their last run
+// GET /api/v1/jobs
+func (s *Server) handleGetJobs(w http.ResponseWriter, r *http.Request) {
+	if !s.requireScheduler(w) {
+		return
+	}
+	jobs := s.scheduler.Jobs()
+	s.writeJSON(w, http.StatusOK, JobsResponse{Count: len(jobs), Jobs: jobs})
+}
+
+// handleGetJob returns a job with its run history
+// GET /api/v1/jobs/{name}
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	if !s.requireScheduler(w) {
+		return
+	}
+	job, runs, err := s.scheduler.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, JobResponse{Job: job, History: runs})
+}
+
+// handleRunJob runs a job now and returns the run. A failed run is
+// reported in the run's error field.
+// POST /api/v1/jobs/{name}/run
+func (s *Server) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	if !s.requireScheduler(w) {
+		return
+	}
+	run, err := s.scheduler.Trigger(chi.URLParam(r, "name"))
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	case errors.Is(err, scheduler.ErrJobRunning):
+		s.writeJSON(w, http.StatusConflict, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, run)
+}
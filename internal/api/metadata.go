@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// AutopilotProvider is optionally implemented by a StateProvider to expose
+// the firmware and hardware details drones report about themselves
+type AutopilotProvider interface {
+	AutopilotInfo(deviceID string) (*models.AutopilotInfo, bool)
+}
+
+// DroneMetadataResponse is the response for GET /api/v1/drones/{deviceID}/metadata
+type DroneMetadataResponse struct {
+	DeviceID   string                 `json:"device_id"`
+	Registered *models.DeviceMetadata `json:"registered,omitempty"` // Operator-maintained details
+	Autopilot  *models.AutopilotInfo  `json:"autopilot,omitempty"`  // Reported by the flight controller
+}
+
+// handleGetDroneMetadata returns the registered and autopilot-reported
+// details of a drone
+// GET /api/v1/drones/{deviceID}/metadata
+func (s *Server) handleGetDroneMetadata(w http.ResponseWriter, r *http.Request) {
+	deviceID := chi.URLParam(r, "deviceID")
+
+	resp := DroneMetadataResponse{DeviceID: deviceID}
+	if reg := s.deviceRegistry(); reg != nil {
+		resp.Registered = reg.Metadata(deviceID)
+	}
+	if ap, ok := s.provider.(AutopilotProvider); ok {
+		resp.Autopilot, _ = ap.AutopilotInfo(deviceID)
+	}
+
+	known := s.provider.GetState(deviceID) != nil || resp.Registered != nil || resp.Autopilot != nil
+	if !known || !s.deviceVisible(r, deviceID) {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:    "drone not found",
+			DeviceID: deviceID,
+		})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
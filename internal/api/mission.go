@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// MissionProvider is optionally implemented by a StateProvider to expose
+// the mission plans captured from drones
+type MissionProvider interface {
+	Mission(deviceID string) (*models.Mission, bool)
+}
+
+// DroneMissionResponse is the response for GET /api/v1/drones/{deviceID}/mission
+type DroneMissionResponse struct {
+	DeviceID string          `json:"device_id"`
+	Mission  *models.Mission `json:"mission"`
+}
+
+// handleGetDroneMission returns the mission plan loaded on a drone
+// GET /api/v1/drones/{deviceID}/mission
+func (s *Server) handleGetDroneMission(w http.ResponseWriter, r *http.Request) {
+	deviceID := chi.URLParam(r, "deviceID")
+
+	var mission *models.Mission
+	if mp, ok := s.provider.(MissionProvider); ok {
+		mission, _ = mp.Mission(deviceID)
+	}
+	if mission == nil || !s.deviceVisible(r, deviceID) {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:    "mission not found",
+			DeviceID: deviceID,
+		})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, DroneMissionResponse{DeviceID: deviceID, Mission: mission})
+}
+
+// broadcastMission forwards a mission change to WebSocket clients
+func (s *Server) broadcastMission(ev events.Event) {
+	if ev.Mission != nil {
+		tenant := s.tenants().Resolve(ev.DeviceID)
+		s.hub.BroadcastMission(ev.DeviceID, tenant, ev.Mission)
+		s.relay(fanoutMessage{Type: WSMessageTypeMission, DeviceID: ev.DeviceID, Tenant: tenant, Mission: ev.Mission})
+	}
+}
@@ -0,0 +1,148 @@
+// Package public serves the community transparency feed on its own
+// listener. It has no access to the operational API: the only data it can
+// return is what the publicfeed package has already delayed, coarsened and
+// pseudonymized, and it accepts no writes and no credentials.
+package public
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/ratelimit"
+	"github.com/open-uav/telemetry-bridge/internal/core/publicfeed"
+)
+
+// DefaultRateLimit is the requests per second allowed per client when
+// Config.RateLimit is 0
+const DefaultRateLimit = 2.0
+
+// Config holds public feed server settings
+type Config struct {
+	Address   string  // Listen address
+	RateLimit float64 // Requests per second per client (0 = DefaultRateLimit)
+}
+
+// FeedResponse is the response for GET /v1/feed
+type FeedResponse struct {
+	DelaySeconds int64                 `json:"delay_seconds"`
+	PrecisionM   float64               `json:"precision_m"`
+	GeneratedAt  int64                 `json:"generated_at"` // Unix ms
+	Count        int                   `json:"count"`
+	Drones       []publicfeed.Position `json:"drones"`
+}
+
+// Server serves the public feed
+type Server struct {
+	cfg    Config
+	feed   *publicfeed.Feed
+	router chi.Router
+	server *http.Server
+}
+
+// New creates a public feed server
+func New(cfg Config, feed *publicfeed.Feed) *Server {
+	if cfg.RateLimit <= 0 {
+		cfg.RateLimit = DefaultRateLimit
+	}
+	s := &Server{cfg: cfg, feed: feed}
+
+	r := chi.NewRouter()
+	r.Use(middleware.Recoverer)
+	r.Use(ratelimit.Middleware(ratelimit.NewIPRateLimiter(cfg.RateLimit, int(cfg.RateLimit*5)+1)))
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	r.Get("/v1/feed", s.handleFeed)
+	s.router = r
+	return s
+}
+
+// handleFeed returns the delayed, coarsened drone positions
+// GET /v1/feed?format=json|geojson
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "geojson" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "format must be json or geojson"})
+		return
+	}
+
+	now := time.Now()
+	positions := s.feed.Positions(now)
+	w.Header().Set("Cache-Control", "public, max-age=5")
+
+	if format == "geojson" {
+		features := make([]map[string]interface{}, len(positions))
+		for i, p := range positions {
+			features[i] = map[string]interface{}{
+				"type": "Feature",
+				"geometry": map[string]interface{}{
+					"type":        "Point",
+					"coordinates": [3]float64{p.Lon, p.Lat, p.Alt},
+				},
+				"properties": map[string]interface{}{
+					"id":        p.ID,
+					"timestamp": p.Timestamp,
+				},
+			}
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"type":     "FeatureCollection",
+			"features": features,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FeedResponse{
+		DelaySeconds: int64(s.feed.Delay() / time.Second),
+		PrecisionM:   s.feed.PrecisionM(),
+		GeneratedAt:  now.UnixMilli(),
+		Count:        len(positions),
+		Drones:       positions,
+	})
+}
+
+// Start starts the listener
+func (s *Server) Start() error {
+	s.server = &http.Server{
+		Addr:         s.cfg.Address,
+		Handler:      s.router,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	go func() {
+		log.Printf("[PublicFeed] Server listening on %s", s.cfg.Address)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("[PublicFeed] Server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop gracefully shuts down the listener
+func (s *Server) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
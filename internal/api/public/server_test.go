@@ -0,0 +1,61 @@
+package public
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/anonymize"
+	"github.com/open-uav/telemetry-bridge/internal/core/publicfeed"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestServer_Feed(t *testing.T) {
+	feed := publicfeed.New(publicfeed.Config{Delay: time.Minute, Anonymizer: anonymize.NewHMAC([]byte("key"))})
+	s := models.NewDroneState("mavlink-1", "mavlink")
+	s.Location.Lat, s.Location.Lon = 22.5, 114.0
+	feed.Observe(s, time.Now().Add(-2*time.Minute))
+	feed.Observe(s, time.Now()) // Not yet old enough
+
+	server := New(Config{RateLimit: 100}, feed)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/v1/feed")
+	var resp FeedResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Count != 1 || resp.DelaySeconds != 60 {
+		t.Fatalf("Feed: status %d, body %s", w.Code, w.Body.String())
+	}
+	if resp.Drones[0].ID == "mavlink-1" {
+		t.Error("Feed should not expose device IDs")
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("Feed should allow cross-origin requests")
+	}
+
+	if w := get("/v1/feed?format=geojson"); w.Header().Get("Content-Type") != "application/geo+json" {
+		t.Errorf("GeoJSON feed: content type %q", w.Header().Get("Content-Type"))
+	}
+	if w := get("/v1/feed?format=csv"); w.Code != http.StatusBadRequest {
+		t.Errorf("Unknown format: expected status 400, got %d", w.Code)
+	}
+
+	// Nothing but the feed is served
+	for _, path := range []string{"/api/v1/drones", "/api/v1/status"} {
+		if w := get(path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, w.Code)
+		}
+	}
+	req := httptest.NewRequest("POST", "/v1/feed", nil)
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST feed: expected status 405, got %d", w.Code)
+	}
+}
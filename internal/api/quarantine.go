@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// QuarantineProvider is optionally implemented by a StateProvider to expose
+// payloads that adapters failed to parse
+type QuarantineProvider interface {
+	Quarantine() *quarantine.Store
+	ReplayQuarantined(id string) (*models.DroneState, error)
+}
+
+// QuarantineResponse is the response for GET /api/v1/quarantine
+type QuarantineResponse struct {
+	Count   int                `json:"count"`
+	Entries []quarantine.Entry `json:"entries"`
+	Stats   quarantine.Stats   `json:"stats"`
+}
+
+// ReplayResponse is the response for a successful replay
+type ReplayResponse struct {
+	Replayed bool               `json:"replayed"`
+	State    *models.DroneState `json:"state"`
+}
+
+// quarantineProvider returns the provider or writes 501 if unsupported
+func (s *Server) quarantineProvider(w http.ResponseWriter) (QuarantineProvider, bool) {
+	qp, ok := s.provider.(QuarantineProvider)
+	if !ok || qp.Quarantine() == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "quarantine not supported",
+		})
+		return nil, false
+	}
+	return qp, true
+}
+
+// handleGetQuarantine lists quarantined payloads
+// GET /api/v1/quarantine?adapter=dji&limit=100
+func (s *Server) handleGetQuarantine(w http.ResponseWriter, r *http.Request) {
+	qp, ok := s.quarantineProvider(w)
+	if !ok {
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid limit parameter",
+			})
+			return
+		}
+		limit = l
+	}
+
+	q := qp.Quarantine()
+	entries := q.List(r.URL.Query().Get("adapter"), limit)
+	if entries == nil {
+		entries = []quarantine.Entry{}
+	}
+	s.writeJSON(w, http.StatusOK, QuarantineResponse{
+		Count:   len(entries),
+		Entries: entries,
+		Stats:   q.Stats(),
+	})
+}
+
+// handleGetQuarantineEntry returns a single quarantined payload
+// GET /api/v1/quarantine/{id}
+func (s *Server) handleGetQuarantineEntry(w http.ResponseWriter, r *http.Request) {
+	qp, ok := s.quarantineProvider(w)
+	if !ok {
+		return
+	}
+
+	entry, err := qp.Quarantine().Get(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, entry)
+}
+
+// handleReplayQuarantineEntry re-parses a payload with the current adapter code
+// POST /api/v1/quarantine/{id}/replay
+func (s *Server) handleReplayQuarantineEntry(w http.ResponseWriter, r *http.Request) {
+	qp, ok := s.quarantineProvider(w)
+	if !ok {
+		return
+	}
+
+	state, err := qp.ReplayQuarantined(chi.URLParam(r, "id"))
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, quarantine.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, ReplayResponse{Replayed: true, State: state})
+}
+
+// handleDeleteQuarantineEntry discards a quarantined payload
+// DELETE /api/v1/quarantine/{id}
+func (s *Server) handleDeleteQuarantineEntry(w http.ResponseWriter, r *http.Request) {
+	qp, ok := s.quarantineProvider(w)
+	if !ok {
+		return
+	}
+
+	if err := qp.Quarantine().Delete(chi.URLParam(r, "id")); err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleClearQuarantine discards all payloads, optionally for one adapter
+// DELETE /api/v1/quarantine?adapter=dji
+func (s *Server) handleClearQuarantine(w http.ResponseWriter, r *http.Request) {
+	qp, ok := s.quarantineProvider(w)
+	if !ok {
+		return
+	}
+
+	qp.Quarantine().Clear(r.URL.Query().Get("adapter"))
+	w.WriteHeader(http.StatusNoContent)
+}
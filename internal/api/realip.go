@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// realIP sets the request's RemoteAddr to the client address forwarded by
+// a trusted proxy (http.trusted_proxies). Forwarded headers from other
+// peers are ignored, so clients cannot choose the address that login
+// lockouts, rate limits and the audit log see.
+func (s *Server) realIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := s.forwardedIP(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedIP returns the client IP given by a trusted proxy: the last
+// X-Forwarded-For hop that is not a trusted proxy itself, as earlier hops
+// are set by the client, or X-Real-IP without X-Forwarded-For. It returns
+// "" for requests not from a trusted proxy.
+func (s *Server) forwardedIP(r *http.Request) string {
+	if !s.isTrustedProxy(clientIP(r)) {
+		return ""
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if !s.isTrustedProxy(hop) {
+				return validIP(hop)
+			}
+		}
+		return ""
+	}
+	return validIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+}
+
+// isTrustedProxy reports whether ip is in http.trusted_proxies
+func (s *Server) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range s.trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// validIP returns ip if it is an IP address, or ""
+func validIP(ip string) string {
+	if _, err := netip.ParseAddr(ip); err != nil {
+		return ""
+	}
+	return ip
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// handleDroneStateSchema returns the JSON Schema of the drone states served
+// and published by the gateway, so consumers can validate them. The schema
+// version is also sent in the X-Schema-Version header.
+// GET /api/v1/schema/drone-state
+func (s *Server) handleDroneStateSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Header().Set("X-Schema-Version", models.SchemaVersion)
+	w.Write(models.DroneStateSchema())
+}
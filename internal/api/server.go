@@ -4,24 +4,41 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"net/netip"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/api/graphql"
 	"github.com/open-uav/telemetry-bridge/internal/api/handlers"
 	"github.com/open-uav/telemetry-bridge/internal/api/ratelimit"
 	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core"
 	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/anonymize"
+	"github.com/open-uav/telemetry-bridge/internal/core/audit"
+	"github.com/open-uav/telemetry-bridge/internal/core/broadcast"
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/fanout"
 	"github.com/open-uav/telemetry-bridge/internal/core/geofence"
+	"github.com/open-uav/telemetry-bridge/internal/core/incident"
 	"github.com/open-uav/telemetry-bridge/internal/core/logger"
+	"github.com/open-uav/telemetry-bridge/internal/core/notify"
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
+	"github.com/open-uav/telemetry-bridge/internal/core/scheduler"
+	"github.com/open-uav/telemetry-bridge/internal/core/timefmt"
 	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
-	"github.com/open-uav/telemetry-bridge/internal/models"
 	"github.com/open-uav/telemetry-bridge/internal/web"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // StateProvider is an interface for getting drone states
@@ -37,6 +54,43 @@ type StateProvider interface {
 	GetPublisherNames() []string
 }
 
+// SelfTestRunner is optionally implemented by a StateProvider to run the
+// pipeline self-test from POST /api/v1/selftest
+type SelfTestRunner interface {
+	RunSelfTest() core.SelfTestReport
+}
+
+// RoutingProvider is optionally implemented by a StateProvider to manage
+// publisher routing rules at /api/v1/routing/rules
+type RoutingProvider interface {
+	Routing() *routing.Router
+}
+
+// PublisherHealthProvider is optionally implemented by a StateProvider
+// to report per-publisher health in /api/v1/status
+type PublisherHealthProvider interface {
+	GetPublisherHealth() []core.PublisherHealth
+}
+
+// QueueStatsProvider is optionally implemented by a StateProvider to report
+// per-stage queue depths and drops in /api/v1/status
+type QueueStatsProvider interface {
+	GetQueueStats() []core.QueueStats
+}
+
+// DedupStatsProvider is optionally implemented by a StateProvider to report
+// the repeated states dropped in /api/v1/status
+type DedupStatsProvider interface {
+	GetDedupStats() []core.DedupStats
+}
+
+// TrackStatsProvider is optionally implemented by a StateProvider to report
+// track store memory in /api/v1/status
+type TrackStatsProvider interface {
+	IsTrackEnabled() bool
+	GetTrackStats() trackstore.Stats
+}
+
 // Server is the HTTP API server
 type Server struct {
 	cfg               config.HTTPConfig
@@ -51,13 +105,32 @@ type Server struct {
 	webUIEnabled      bool
 	authEnabled       bool
 	authManager       *auth.Manager
+	apiKeys           *auth.KeyStore
+	apiKeysHandler    *handlers.APIKeysHandler
 	configHandler     *handlers.ConfigHandler
+	configValidator   ConfigValidator
 	logBuffer         *logger.Buffer
 	logsHandler       *handlers.LogsHandler
 	alerter           *alerter.Alerter
 	alertsHandler     *handlers.AlertsHandler
 	geofenceEngine    *geofence.Engine
+	converter         *coordinator.Converter
 	geofencesHandler  *handlers.GeofencesHandler
+	incidents         *incident.Correlator
+	notifier          *notify.Dispatcher
+	routingHandler    *handlers.RoutingHandler
+	timeFormatter     *timefmt.Formatter
+	anonymizer        anonymize.Anonymizer
+	simulator         Simulator
+	scheduler         *scheduler.Scheduler
+	broadcasts        *broadcast.Store
+	events            *events.Bus
+	auditLog          *audit.Log
+	loginLockout      *auth.Lockout // nil if lockout is off
+	trustedProxies    []netip.Prefix
+	graphql           *graphql.Schema
+	fanout            *fanout.Bus // nil unless WebSocket fan-out is enabled
+	unsubscribe       []func()
 }
 
 // New creates a new HTTP API server
@@ -78,6 +151,22 @@ func NewWithConfig(cfg config.HTTPConfig, fullConfig *config.Config, configPath
 		authEnabled:  cfg.Auth.Enabled,
 	}
 
+	proxies, err := config.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Printf("[HTTP] Ignoring trusted proxies: %v", err)
+	}
+	s.trustedProxies = proxies
+
+	// Timezone and timestamp format for exports
+	s.timeFormatter = timefmt.New(time.Local, timefmt.FormatRFC3339)
+	if fullConfig != nil {
+		if f, err := timefmt.Parse(fullConfig.Server.Timezone, fullConfig.Server.TimeFormat); err == nil {
+			s.timeFormatter = f
+		} else {
+			log.Printf("[HTTP] Invalid timezone, using local time: %v", err)
+		}
+	}
+
 	// Initialize auth manager if authentication is enabled
 	if cfg.Auth.Enabled {
 		s.authManager = auth.NewManager(
@@ -86,7 +175,27 @@ func NewWithConfig(cfg config.HTTPConfig, fullConfig *config.Config, configPath
 			cfg.Auth.JWTSecret,
 			cfg.Auth.TokenExpiryHours,
 		)
+		s.authManager.SetAccessTokenExpiry(time.Duration(cfg.Auth.AccessTokenMinutes) * time.Minute)
 		log.Printf("[HTTP] Authentication enabled for user: %s", cfg.Auth.Username)
+		for _, u := range cfg.Auth.Users {
+			s.authManager.AddUser(u.Username, u.PasswordHash, u.Tenant)
+		}
+		if len(cfg.Auth.Users) > 0 {
+			log.Printf("[HTTP] %d tenant users configured", len(cfg.Auth.Users))
+		}
+		if l := cfg.Auth.Lockout; l.MaxFailures > 0 {
+			s.loginLockout = auth.NewLockout(l.MaxFailures,
+				time.Duration(l.WindowSec)*time.Second, time.Duration(l.DurationSec)*time.Second)
+		}
+
+		keys, err := auth.NewKeyStore(cfg.Auth.APIKeysFile)
+		if err != nil {
+			log.Printf("[HTTP] Failed to load API keys, using in-memory store: %v", err)
+			keys, _ = auth.NewKeyStore("")
+		}
+		s.apiKeys = keys
+		s.apiKeysHandler = handlers.NewAPIKeysHandler(keys)
+		log.Printf("[HTTP] API key authentication enabled (%d keys)", len(keys.List()))
 	}
 
 	// Initialize config handler if full config is provided
@@ -105,15 +214,58 @@ func NewWithConfig(cfg config.HTTPConfig, fullConfig *config.Config, configPath
 	log.Printf("[HTTP] Log buffer enabled (capacity: %d)", logBufferSize)
 
 	// Initialize alerter (always enabled)
-	s.alerter = alerter.New(alerter.Config{MaxAlerts: 1000})
+	maxAlerts := 1000
+	if fullConfig != nil && fullConfig.Alerts.MaxAlerts > 0 {
+		maxAlerts = fullConfig.Alerts.MaxAlerts
+	}
+	s.alerter = alerter.New(alerter.Config{MaxAlerts: maxAlerts})
+	if fullConfig != nil && fullConfig.Alerts.File != "" {
+		if err := s.alerter.Open(fullConfig.Alerts.File); err != nil {
+			log.Printf("[HTTP] Failed to restore alerts, keeping them in memory only: %v", err)
+		} else {
+			log.Printf("[HTTP] Alerts persisted to %s", fullConfig.Alerts.File)
+		}
+	}
 	s.alertsHandler = handlers.NewAlertsHandler(s.alerter)
+	s.alerter.SetEscalationCallback(s.escalateAlert)
+	s.alerter.SetAckCallback(s.broadcastAlertsAcked)
 	log.Printf("[HTTP] Alert system enabled")
 
 	// Initialize geofence engine (always enabled)
 	s.geofenceEngine = geofence.NewEngine(geofence.Config{MaxBreaches: 500})
+	s.converter = coordinator.New(false, false)
 	s.geofencesHandler = handlers.NewGeofencesHandler(s.geofenceEngine)
 	log.Printf("[HTTP] Geofence system enabled")
 
+	// Group related alerts and link events into incidents (always enabled)
+	s.incidents = incident.New(incident.Config{})
+
+	// Operator broadcasts (always enabled)
+	s.broadcasts = broadcast.NewStore()
+
+	// Scope alerts, geofences and API keys to tenants
+	if tenants := s.tenants(); tenants != nil {
+		s.alertsHandler.SetTenants(tenants)
+		s.geofencesHandler.SetTenants(tenants)
+		if s.apiKeysHandler != nil {
+			s.apiKeysHandler.SetTenants(tenants)
+		}
+	}
+
+	if rp, ok := provider.(RoutingProvider); ok && rp.Routing() != nil {
+		s.routingHandler = handlers.NewRoutingHandler(rp.Routing(), provider.GetPublisherNames)
+	}
+
+	if cfg.GraphQL.Enabled {
+		s.graphql = s.newGraphQLSchema()
+		log.Printf("[HTTP] GraphQL endpoint enabled")
+	}
+
+	if cfg.Fanout.Enabled {
+		s.fanout = fanout.New(cfg.Fanout)
+		log.Printf("[HTTP] WebSocket fan-out enabled (Redis %s, channel %s)", cfg.Fanout.Address, cfg.Fanout.Channel)
+	}
+
 	s.setupRouter()
 	return s
 }
@@ -124,10 +276,15 @@ func (s *Server) setupRouter() {
 
 	// Middleware
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(s.realIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(decompressRequests)
+	if s.cfg.Compress.Enabled {
+		r.Use(compressResponses(s.cfg.Compress.Level))
+		log.Printf("[HTTP] Response compression enabled (gzip level %d)", s.cfg.Compress.Level)
+	}
 
 	// Rate Limiting
 	if s.cfg.RateLimit.Enabled {
@@ -153,7 +310,7 @@ func (s *Server) setupRouter() {
 		r.Use(cors.Handler(cors.Options{
 			AllowedOrigins:   origins,
 			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", auth.APIKeyHeader},
 			ExposedHeaders:   []string{"Link"},
 			AllowCredentials: false,
 			MaxAge:           300,
@@ -165,43 +322,177 @@ func (s *Server) setupRouter() {
 		// Public auth routes (always available, even when auth is disabled)
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/login", s.handleLogin)
+			r.Post("/refresh", s.handleRefresh)
 			r.Post("/logout", s.handleLogout)
 			r.Get("/me", s.handleGetMe)
+
+			// Revoking tokens of other users is for admins
+			if s.authEnabled {
+				r.Group(func(r chi.Router) {
+					r.Use(auth.MiddlewareWithAPIKeys(s.authManager, s.apiKeys))
+					r.Use(auth.RequireGlobal)
+					r.Use(auth.RequireScope(auth.ScopeAdmin))
+					r.With(s.audited("token", audit.ActionDelete, nil)).Delete("/tokens/{id}", s.handleRevokeToken)
+				})
+			}
 		})
 
-		// Protected routes (conditionally apply auth middleware)
+		// Schemas of the published payloads are public
+		r.Get("/schema/drone-state", s.handleDroneStateSchema)
+
+		// Protected routes (conditionally apply auth middleware). Routes
+		// exposing gateway-wide state are closed to tenant users.
 		r.Group(func(r chi.Router) {
 			if s.authEnabled {
-				r.Use(auth.Middleware(s.authManager))
+				r.Use(auth.MiddlewareWithAPIKeys(s.authManager, s.apiKeys))
 			}
-			r.Get("/status", s.handleStatus)
-			r.Get("/drones", s.handleGetDrones)
+			r.With(auth.RequireGlobal).Get("/status", s.handleStatus)
+			r.With(auth.RequireGlobal).Post("/selftest", s.handleSelfTest)
+			r.With(etagged).Get("/drones", s.handleGetDrones)
 			r.Get("/drones/{deviceID}", s.handleGetDrone)
-			r.Get("/drones/{deviceID}/track", s.handleGetTrack)
+			r.Get("/drones/{deviceID}/metadata", s.handleGetDroneMetadata)
+			r.Get("/drones/{deviceID}/mission", s.handleGetDroneMission)
+			r.Get("/drones/{deviceID}/events", s.handleGetDroneEvents)
+			r.Get("/drones/{deviceID}/statustext", s.handleGetDroneStatusText)
+			r.Get("/drones/{deviceID}/flights", s.handleGetDroneFlights)
+			r.With(etagged).Get("/drones/{deviceID}/track", s.handleGetTrack)
 			r.Delete("/drones/{deviceID}/track", s.handleDeleteTrack)
+			r.With(etagged).Get("/drones/{deviceID}/track/export", s.handleExportTrack)
+			r.With(auth.RequireGlobal).Get("/throttle/status", s.handleThrottleStatus)
+			r.With(auth.RequireGlobal).Get("/adapters/{name}/stats", s.handleGetAdapterStats)
+			r.Get("/coverage", s.handleGetCoverage)
+			r.Get("/analytics/heatmap", s.handleGetHeatmap)
+
+			// Registered device names, airframes and operators
+			r.Route("/devices", func(r chi.Router) {
+				device := s.snapshot(s.handleGetDevice)
+				r.Get("/", s.handleGetDevices)
+				r.With(s.audited("device", audit.ActionCreate, nil)).Post("/", s.handleCreateDevice)
+				r.Get("/{id}", s.handleGetDevice)
+				r.With(s.audited("device", audit.ActionUpdate, device)).Put("/{id}", s.handleUpdateDevice)
+				r.With(s.audited("device", audit.ActionDelete, device)).Delete("/{id}", s.handleDeleteDevice)
+			})
+
+			// Device IDs of other sources merged into canonical devices
+			r.Route("/aliases", func(r chi.Router) {
+				r.Use(auth.RequireGlobal)
+				alias := s.snapshot(s.handleGetAlias)
+				r.Get("/", s.handleGetAliases)
+				r.Get("/{id}", s.handleGetAlias)
+				r.With(s.audited("alias", audit.ActionUpdate, alias)).Put("/{id}", s.handleSetAlias)
+				r.With(s.audited("alias", audit.ActionDelete, alias)).Delete("/{id}", s.handleDeleteAlias)
+			})
+
+			// Duplicate device IDs across adapters
+			r.Route("/conflicts", func(r chi.Router) {
+				r.Use(auth.RequireGlobal)
+				r.Get("/", s.handleGetConflicts)
+				r.Put("/{deviceID}/resolution", s.handleResolveConflict)
+				r.Delete("/{deviceID}", s.handleDismissConflict)
+			})
+
+			// Battery swaps, payload changes and battery statistics
+			r.Route("/equipment", func(r chi.Router) {
+				r.Get("/changes", s.handleGetEquipmentChanges)
+				r.Get("/batteries", s.handleGetBatteries)
+				r.Get("/batteries/{serial}", s.handleGetBattery)
+			})
+
+			// Operator broadcasts to all UIs
+			r.Route("/broadcast", func(r chi.Router) {
+				r.Get("/", s.handleGetBroadcasts)
+				r.With(auth.RequireGlobal).Post("/", s.handleCreateBroadcast)
+				r.With(auth.RequireGlobal).Delete("/{id}", s.handleDeleteBroadcast)
+			})
+
+			// Fault injection (only in builds with the chaos tag)
+			s.setupChaosRoutes(r)
+
+			// Built-in simulator (501 unless enabled)
+			r.Route("/sim/drones", func(r chi.Router) {
+				r.Use(auth.RequireGlobal)
+				r.Get("/", s.handleGetSimDrones)
+				r.Post("/", s.handleCreateSimDrones)
+				r.Delete("/{id}", s.handleDeleteSimDrone)
+			})
+
+			// Parse error quarantine
+			r.Route("/quarantine", func(r chi.Router) {
+				r.Use(auth.RequireGlobal)
+				r.Get("/", s.handleGetQuarantine)
+				r.Delete("/", s.handleClearQuarantine)
+				r.Get("/{id}", s.handleGetQuarantineEntry)
+				r.Delete("/{id}", s.handleDeleteQuarantineEntry)
+				r.Post("/{id}/replay", s.handleReplayQuarantineEntry)
+			})
+
+			// Configuration validation and, if the config handler is
+			// available, management routes
+			r.Route("/config", func(r chi.Router) {
+				r.Use(auth.RequireGlobal)
+				if s.authEnabled {
+					// Changing the gateway configuration is reserved to admins
+					r.Use(auth.RequireScopeForWrites(auth.ScopeAdmin))
+				}
+				r.Post("/validate", s.handleValidateConfig)
+				if s.configHandler == nil {
+					return
+				}
+
+				cfg := s.snapshot(s.configHandler.GetConfig)
+				update := s.audited("config", audit.ActionUpdate, cfg)
+				r.Get("/", s.configHandler.GetConfig)
+				r.With(update).Put("/adapters/mavlink", s.configHandler.UpdateMAVLinkConfig)
+				r.With(update).Put("/adapters/dji", s.configHandler.UpdateDJIConfig)
+				r.With(update).Put("/publishers/mqtt", s.configHandler.UpdateMQTTConfig)
+				r.With(update).Put("/publishers/gb28181", s.configHandler.UpdateGB28181Config)
+				r.With(update).Put("/throttle", s.configHandler.UpdateThrottleConfig)
+				r.With(update).Put("/coordinate", s.configHandler.UpdateCoordinateConfig)
+				r.With(update).Put("/track", s.configHandler.UpdateTrackConfig)
+				r.With(s.audited("config", audit.ActionApply, cfg)).Post("/apply", s.configHandler.ApplyConfig)
+				r.Post("/export", s.configHandler.ExportConfig)
+			})
+
+			// API key management (only when authentication is enabled)
+			if s.apiKeysHandler != nil {
+				r.Route("/apikeys", func(r chi.Router) {
+					r.Use(auth.RequireScope(auth.ScopeAdmin))
+					r.Get("/", s.apiKeysHandler.GetAPIKeys)
+					r.With(s.audited("api_key", audit.ActionCreate, nil)).Post("/", s.apiKeysHandler.CreateAPIKey)
+					r.With(s.audited("api_key", audit.ActionDelete, s.apiKeySnapshot)).Delete("/{id}", s.apiKeysHandler.DeleteAPIKey)
+				})
+			}
 
-			// Configuration management routes (only if config handler is available)
-			if s.configHandler != nil {
-				r.Route("/config", func(r chi.Router) {
-					r.Get("/", s.configHandler.GetConfig)
-					r.Put("/adapters/mavlink", s.configHandler.UpdateMAVLinkConfig)
-					r.Put("/adapters/dji", s.configHandler.UpdateDJIConfig)
-					r.Put("/publishers/mqtt", s.configHandler.UpdateMQTTConfig)
-					r.Put("/publishers/gb28181", s.configHandler.UpdateGB28181Config)
-					r.Put("/throttle", s.configHandler.UpdateThrottleConfig)
-					r.Put("/coordinate", s.configHandler.UpdateCoordinateConfig)
-					r.Put("/track", s.configHandler.UpdateTrackConfig)
-					r.Post("/apply", s.configHandler.ApplyConfig)
-					r.Post("/export", s.configHandler.ExportConfig)
+			// Publisher routing rules (only if the provider supports routing)
+			if s.routingHandler != nil {
+				r.Route("/routing/rules", func(r chi.Router) {
+					r.Use(auth.RequireGlobal)
+					rule := s.snapshot(s.routingHandler.GetRule)
+					r.Get("/", s.routingHandler.GetRules)
+					r.With(s.audited("routing_rule", audit.ActionCreate, nil)).Post("/", s.routingHandler.CreateRule)
+					r.Get("/{id}", s.routingHandler.GetRule)
+					r.With(s.audited("routing_rule", audit.ActionUpdate, rule)).Put("/{id}", s.routingHandler.UpdateRule)
+					r.With(s.audited("routing_rule", audit.ActionDelete, rule)).Delete("/{id}", s.routingHandler.DeleteRule)
 				})
 			}
 
 			// Logs routes (always enabled)
 			if s.logsHandler != nil {
 				r.Route("/logs", func(r chi.Router) {
+					r.Use(auth.RequireGlobal)
 					r.Get("/", s.logsHandler.GetLogs)
 					r.Get("/stream", s.logsHandler.StreamLogs)
 					r.Delete("/", s.logsHandler.ClearLogs)
+					r.Get("/stats", s.logsHandler.GetStats)
+					r.Get("/levels", s.logsHandler.GetLevels)
+					r.Group(func(r chi.Router) {
+						if s.authEnabled {
+							// Log levels are gateway-wide settings, like the configuration
+							r.Use(auth.RequireScope(auth.ScopeAdmin))
+						}
+						levels := s.snapshot(s.logsHandler.GetLevels)
+						r.With(s.audited("log_levels", audit.ActionUpdate, levels)).Put("/levels", s.logsHandler.UpdateLevels)
+					})
 				})
 			}
 
@@ -209,43 +500,123 @@ func (s *Server) setupRouter() {
 			if s.alertsHandler != nil {
 				r.Route("/alerts", func(r chi.Router) {
 					r.Get("/", s.alertsHandler.GetAlerts)
-					r.Delete("/", s.alertsHandler.ClearAlerts)
-					r.Get("/stats", s.alertsHandler.GetStats)
+					r.With(auth.RequireGlobal).Delete("/", s.alertsHandler.ClearAlerts)
+					r.With(auth.RequireGlobal).Get("/stats", s.alertsHandler.GetStats)
+					r.Get("/fields", s.alertsHandler.GetFields)
+					r.Get("/export", s.handleExportAlerts)
+					r.Post("/ack", s.alertsHandler.AcknowledgeAlerts)
 					r.Get("/{id}", s.alertsHandler.GetAlert)
 					r.Post("/{id}/ack", s.alertsHandler.AcknowledgeAlert)
 
 					// Rules sub-routes
 					r.Route("/rules", func(r chi.Router) {
+						r.Use(auth.RequireGlobal)
+						rule := s.snapshot(s.alertsHandler.GetRule)
 						r.Get("/", s.alertsHandler.GetRules)
-						r.Post("/", s.alertsHandler.CreateRule)
+						r.With(s.audited("alert_rule", audit.ActionCreate, nil)).Post("/", s.alertsHandler.CreateRule)
+						r.Get("/templates", s.alertsHandler.GetRuleTemplates)
+						r.With(s.audited("alert_rule", audit.ActionCreate, nil)).Post("/from-template", s.alertsHandler.CreateRuleFromTemplate)
+						r.Get("/export", s.alertsHandler.ExportRules)
+						r.With(s.audited("alert_rules", audit.ActionUpdate, s.snapshot(s.alertsHandler.GetRules))).Post("/import", s.alertsHandler.ImportRules)
 						r.Get("/{id}", s.alertsHandler.GetRule)
-						r.Put("/{id}", s.alertsHandler.UpdateRule)
-						r.Delete("/{id}", s.alertsHandler.DeleteRule)
+						r.With(s.audited("alert_rule", audit.ActionUpdate, rule)).Put("/{id}", s.alertsHandler.UpdateRule)
+						r.With(s.audited("alert_rule", audit.ActionDelete, rule)).Delete("/{id}", s.alertsHandler.DeleteRule)
+					})
+
+					// Escalation policies for unacknowledged alerts
+					r.Route("/escalations", func(r chi.Router) {
+						r.Use(auth.RequireGlobal)
+						policy := s.snapshot(s.alertsHandler.GetEscalation)
+						r.Get("/", s.alertsHandler.GetEscalations)
+						r.With(s.audited("escalation", audit.ActionCreate, nil)).Post("/", s.alertsHandler.CreateEscalation)
+						r.Get("/{id}", s.alertsHandler.GetEscalation)
+						r.With(s.audited("escalation", audit.ActionUpdate, policy)).Put("/{id}", s.alertsHandler.UpdateEscalation)
+						r.With(s.audited("escalation", audit.ActionDelete, policy)).Delete("/{id}", s.alertsHandler.DeleteEscalation)
+					})
+
+					// Maintenance windows suppressing alerts
+					r.Route("/silences", func(r chi.Router) {
+						r.Use(auth.RequireGlobal)
+						silence := s.snapshot(s.alertsHandler.GetSilence)
+						r.Get("/", s.alertsHandler.GetSilences)
+						r.With(s.audited("alert_silence", audit.ActionCreate, nil)).Post("/", s.alertsHandler.CreateSilence)
+						r.Get("/{id}", s.alertsHandler.GetSilence)
+						r.With(s.audited("alert_silence", audit.ActionUpdate, silence)).Put("/{id}", s.alertsHandler.UpdateSilence)
+						r.With(s.audited("alert_silence", audit.ActionDelete, silence)).Delete("/{id}", s.alertsHandler.DeleteSilence)
 					})
 				})
 			}
 
+			// Scheduled jobs (501 until the scheduler is set)
+			r.Route("/jobs", func(r chi.Router) {
+				r.Use(auth.RequireGlobal)
+				r.Get("/", s.handleGetJobs)
+				r.Get("/{name}", s.handleGetJob)
+				r.Post("/{name}/run", s.handleRunJob)
+			})
+
+			// Audit log of API changes (501 until the log is set)
+			r.Route("/audit", func(r chi.Router) {
+				r.Use(auth.RequireGlobal)
+				if s.authEnabled {
+					r.Use(auth.RequireScope(auth.ScopeAdmin))
+				}
+				r.Get("/", s.handleGetAudit)
+			})
+
+			// Correlated alert incidents (always enabled)
+			r.Route("/incidents", func(r chi.Router) {
+				r.Get("/", s.handleGetIncidents)
+				r.Get("/{id}", s.handleGetIncident)
+			})
+
 			// Geofences routes (always enabled)
 			if s.geofencesHandler != nil {
 				r.Route("/geofences", func(r chi.Router) {
-					r.Get("/", s.geofencesHandler.GetGeofences)
-					r.Post("/", s.geofencesHandler.CreateGeofence)
-					r.Get("/stats", s.geofencesHandler.GetStats)
+					fence := s.snapshot(s.geofencesHandler.GetGeofence)
+					r.With(etagged).Get("/", s.geofencesHandler.GetGeofences)
+					r.With(s.audited("geofence", audit.ActionCreate, nil)).Post("/", s.geofencesHandler.CreateGeofence)
+					r.With(auth.RequireGlobal).Get("/stats", s.geofencesHandler.GetStats)
 					r.Get("/breaches", s.geofencesHandler.GetBreaches)
-					r.Delete("/breaches", s.geofencesHandler.ClearBreaches)
+					r.With(auth.RequireGlobal).Delete("/breaches", s.geofencesHandler.ClearBreaches)
+					r.Route("/groups", func(r chi.Router) {
+						group := s.snapshot(s.geofencesHandler.GetGroup)
+						r.Get("/", s.geofencesHandler.GetGroups)
+						r.With(s.audited("geofence_group", audit.ActionCreate, nil)).Post("/", s.geofencesHandler.CreateGroup)
+						r.Get("/{id}", s.geofencesHandler.GetGroup)
+						r.With(s.audited("geofence_group", audit.ActionUpdate, group)).Put("/{id}", s.geofencesHandler.UpdateGroup)
+						r.With(s.audited("geofence_group", audit.ActionDelete, group)).Delete("/{id}", s.geofencesHandler.DeleteGroup)
+						r.Get("/{id}/stats", s.geofencesHandler.GetGroupStats)
+					})
 					r.Get("/{id}", s.geofencesHandler.GetGeofence)
-					r.Put("/{id}", s.geofencesHandler.UpdateGeofence)
-					r.Delete("/{id}", s.geofencesHandler.DeleteGeofence)
+					r.With(s.audited("geofence", audit.ActionUpdate, fence)).Put("/{id}", s.geofencesHandler.UpdateGeofence)
+					r.With(s.audited("geofence", audit.ActionDelete, fence)).Delete("/{id}", s.geofencesHandler.DeleteGeofence)
 				})
 			}
 		})
 
-		// WebSocket endpoint (with optional auth)
+		// GraphQL queries only read, so read-only API keys may POST them
+		if s.graphql != nil {
+			r.Group(func(r chi.Router) {
+				r.Use(auth.ReadOnly)
+				if s.authEnabled {
+					r.Use(auth.MiddlewareWithAPIKeys(s.authManager, s.apiKeys))
+				}
+				r.Get("/graphql", s.handleGraphQL)
+				r.Post("/graphql", s.handleGraphQL)
+				r.Get("/graphql/schema", s.handleGraphQLSchema)
+			})
+		}
+
+		// WebSocket endpoints (with optional auth)
 		r.Group(func(r chi.Router) {
 			if s.authEnabled {
-				r.Use(auth.OptionalMiddleware(s.authManager))
+				r.Use(auth.OptionalMiddlewareWithAPIKeys(s.authManager, s.apiKeys))
 			}
 			r.Get("/ws", s.serveWs)
+			if s.graphql != nil {
+				r.Get("/graphql/ws", s.serveGraphQLWs)
+			}
 		})
 	})
 
@@ -280,6 +651,14 @@ func (s *Server) Start(ctx context.Context) error {
 
 	// Start WebSocket hub
 	go s.hub.Run()
+	if s.fanout != nil {
+		s.fanout.Start(ctx, s.receiveFanout)
+	}
+
+	// Subscribe the hub, alerter and geofence engine to engine events
+	if es, ok := s.provider.(EventSource); ok && es.Events() != nil {
+		s.attachEvents(es.Events())
+	}
 
 	go func() {
 		if s.cfg.TLS.Enabled {
@@ -300,6 +679,10 @@ func (s *Server) Start(ctx context.Context) error {
 
 // Stop gracefully shuts down the server
 func (s *Server) Stop() error {
+	s.detachEvents()
+	if err := s.alerter.Close(); err != nil {
+		log.Printf("[HTTP] Failed to save alerts: %v", err)
+	}
 	if s.server == nil {
 		return nil
 	}
@@ -315,11 +698,15 @@ func (s *Server) Stop() error {
 
 // StatusResponse is the response for /api/v1/status
 type StatusResponse struct {
-	Version       string          `json:"version"`
-	UptimeSeconds int64           `json:"uptime_seconds"`
-	Adapters      []AdapterStatus `json:"adapters"`
-	Publishers    []string        `json:"publishers"`
-	Stats         Stats           `json:"stats"`
+	Version         string                 `json:"version"`
+	UptimeSeconds   int64                  `json:"uptime_seconds"`
+	Adapters        []AdapterStatus        `json:"adapters"`
+	Publishers      []string               `json:"publishers"`
+	PublisherHealth []core.PublisherHealth `json:"publisher_health,omitempty"`
+	Queues          []core.QueueStats      `json:"queues,omitempty"`
+	Dedup           []core.DedupStats      `json:"dedup,omitempty"`
+	Tracks          *trackstore.Stats      `json:"tracks,omitempty"`
+	Stats           Stats                  `json:"stats"`
 }
 
 // AdapterStatus represents adapter status in the response
@@ -374,12 +761,74 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 			WebSocketClients: s.hub.ClientCount(),
 		},
 	}
+	if hp, ok := s.provider.(PublisherHealthProvider); ok {
+		resp.PublisherHealth = hp.GetPublisherHealth()
+	}
+	if qp, ok := s.provider.(QueueStatsProvider); ok {
+		resp.Queues = qp.GetQueueStats()
+	}
+	if dp, ok := s.provider.(DedupStatsProvider); ok {
+		resp.Dedup = dp.GetDedupStats()
+	}
+	if tp, ok := s.provider.(TrackStatsProvider); ok && tp.IsTrackEnabled() {
+		stats := tp.GetTrackStats()
+		resp.Tracks = &stats
+	}
 
 	s.writeJSON(w, http.StatusOK, resp)
 }
 
+// handleSelfTest runs the pipeline self-test and returns the per-stage report
+func (s *Server) handleSelfTest(w http.ResponseWriter, r *http.Request) {
+	runner, ok := s.provider.(SelfTestRunner)
+	if !ok {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "self-test not supported",
+		})
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, runner.RunSelfTest())
+}
+
+// handleGetDrones lists the current drone states
+// GET /api/v1/drones?protocol_source=&armed=&bbox=&within_radius=&fields=
 func (s *Server) handleGetDrones(w http.ResponseWriter, r *http.Request) {
-	drones := s.provider.GetAllStates()
+	q, err := parseDroneQuery(r.URL.Query())
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tenantID := auth.TenantFromContext(r.Context())
+	all := q.candidates(s.provider)
+	drones := make([]*models.DroneState, 0, len(all))
+	for _, d := range all {
+		if tenantID != "" && d.Tenant != tenantID {
+			continue
+		}
+		if q.matches(d) {
+			drones = append(drones, s.forResponse(d))
+		}
+	}
+
+	if q.fields != nil {
+		selected := make([]map[string]any, 0, len(drones))
+		for _, d := range drones {
+			m, err := selectFields(d, q.fields)
+			if err != nil {
+				s.writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+				return
+			}
+			selected = append(selected, m)
+		}
+		s.writeJSON(w, http.StatusOK, DronesFieldsResponse{
+			Count:  len(selected),
+			Drones: selected,
+		})
+		return
+	}
+
 	resp := DronesResponse{
 		Count:  len(drones),
 		Drones: drones,
@@ -392,7 +841,7 @@ func (s *Server) handleGetDrone(w http.ResponseWriter, r *http.Request) {
 	deviceID := chi.URLParam(r, "deviceID")
 
 	state := s.provider.GetState(deviceID)
-	if state == nil {
+	if state == nil || !s.deviceVisible(r, deviceID) {
 		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
 			Error:    "drone not found",
 			DeviceID: deviceID,
@@ -400,7 +849,7 @@ func (s *Server) handleGetDrone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, state)
+	s.writeJSON(w, http.StatusOK, s.forResponse(state))
 }
 
 // TrackResponse is the response for /api/v1/drones/{deviceID}/track
@@ -409,6 +858,8 @@ type TrackResponse struct {
 	Count      int                     `json:"count"`
 	Points     []trackstore.TrackPoint `json:"points"`
 	TotalSize  int                     `json:"total_size"`
+	Decimated  bool                    `json:"decimated,omitempty"` // Points were reduced to max_points
+	Datum      string                  `json:"datum"`               // Datum of the point positions
 }
 
 func (s *Server) handleGetTrack(w http.ResponseWriter, r *http.Request) {
@@ -422,12 +873,16 @@ func (s *Server) handleGetTrack(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if !s.requireDevice(w, r, deviceID) {
+		return
+	}
 
 	// Parse query parameters
 	limitStr := r.URL.Query().Get("limit")
 	sinceStr := r.URL.Query().Get("since")
+	maxPointsStr := r.URL.Query().Get("max_points")
 
-	var limit int
+	var limit, maxPoints int
 	var since int64
 
 	if limitStr != "" {
@@ -452,14 +907,36 @@ func (s *Server) handleGetTrack(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if maxPointsStr != "" {
+		var err error
+		maxPoints, err = strconv.Atoi(maxPointsStr)
+		if err != nil || maxPoints < 2 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid max_points parameter (must be at least 2)",
+			})
+			return
+		}
+	}
+
+	datum, ok := s.trackDatum(w, r)
+	if !ok {
+		return
+	}
+
 	points := s.provider.GetTrack(deviceID, limit, since)
 	totalSize := s.provider.GetTrackSize(deviceID)
 
+	// Decimate server-side so long flights stay renderable
+	raw := len(points)
+	points = trackstore.Decimate(points, maxPoints)
+
 	s.writeJSON(w, http.StatusOK, TrackResponse{
 		DeviceID:  deviceID,
 		Count:     len(points),
-		Points:    points,
+		Points:    s.convertTrack(points, datum),
 		TotalSize: totalSize,
+		Decimated: len(points) < raw,
+		Datum:     datum,
 	})
 }
 
@@ -474,6 +951,9 @@ func (s *Server) handleDeleteTrack(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if !s.requireDevice(w, r, deviceID) {
+		return
+	}
 
 	s.provider.ClearTrack(deviceID)
 
@@ -500,16 +980,30 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Refuse locked out usernames and clients without checking the password
+	ip := clientIP(r)
+	if wait := s.loginLockout.Check(req.Username, ip); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		s.writeJSON(w, http.StatusTooManyRequests, ErrorResponse{
+			Error: "too many failed logins, try again later",
+		})
+		return
+	}
+
 	// Validate credentials
 	if err := s.authManager.ValidateCredentials(req.Username, req.Password); err != nil {
+		for _, key := range s.loginLockout.Fail(req.Username, ip) {
+			s.recordLockout(req.Username, ip, key)
+		}
 		s.writeJSON(w, http.StatusUnauthorized, ErrorResponse{
 			Error: "invalid username or password",
 		})
 		return
 	}
+	s.loginLockout.Succeed(req.Username)
 
-	// Generate JWT token
-	token, expiresAt, err := s.authManager.GenerateToken(req.Username)
+	// Start a session with an access and a refresh token
+	pair, err := s.authManager.NewSession(req.Username)
 	if err != nil {
 		log.Printf("[HTTP] Failed to generate token: %v", err)
 		s.writeJSON(w, http.StatusInternalServerError, ErrorResponse{
@@ -518,22 +1012,88 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := s.authManager.GetUser()
-	s.writeJSON(w, http.StatusOK, auth.LoginResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		User:      user,
-	})
+	s.writeJSON(w, http.StatusOK, loginResponse(pair))
+}
+
+// loginResponse returns the response carrying a token pair
+func loginResponse(pair *auth.TokenPair) auth.LoginResponse {
+	return auth.LoginResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.ExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+		SessionID:        pair.SessionID,
+		User:             pair.User,
+	}
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if !s.authEnabled {
+		s.writeJSON(w, http.StatusOK, map[string]interface{}{
+			"auth_enabled": false,
+			"message":      "authentication is disabled",
+		})
+		return
+	}
+
+	var req auth.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	pair, err := s.authManager.Refresh(req.RefreshToken)
+	if err != nil {
+		switch err {
+		case auth.ErrTokenReused:
+			log.Printf("[HTTP] Refresh token reused from %s, session revoked", clientIP(r))
+			s.writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "token has been revoked"})
+		case auth.ErrTokenExpired, auth.ErrTokenRevoked:
+			s.writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		default:
+			s.writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "invalid token"})
+		}
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, loginResponse(pair))
 }
 
 func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
-	// JWT is stateless, so logout is handled client-side by removing the token
-	// This endpoint exists for API completeness
+	// Revoke the session of the bearer token, or of the refresh token in
+	// the body when the access token has expired, so that neither can be
+	// used again. Logging out always succeeds for the client.
+	if s.authEnabled {
+		var token string
+		if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+			token = h[len("Bearer "):]
+		} else {
+			var req auth.RefreshRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			token = req.RefreshToken
+		}
+		if token != "" {
+			if sessionID, err := s.authManager.RevokeSession(token); err == nil {
+				log.Printf("[HTTP] Session %s logged out", sessionID)
+			}
+		}
+	}
+
 	s.writeJSON(w, http.StatusOK, map[string]string{
 		"message": "logged out successfully",
 	})
 }
 
+func (s *Server) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if s.authManager.Revoke(id) {
+		log.Printf("[HTTP] Token %s revoked", id)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
 	// If auth is not enabled, return anonymous user
 	if !s.authEnabled {
@@ -570,9 +1130,9 @@ func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
 
 	tokenInfo, err := s.authManager.ValidateToken(parts[1])
 	if err != nil {
-		if err == auth.ErrTokenExpired {
+		if err == auth.ErrTokenExpired || err == auth.ErrTokenRevoked {
 			s.writeJSON(w, http.StatusUnauthorized, ErrorResponse{
-				Error: "token has expired",
+				Error: err.Error(),
 			})
 		} else {
 			s.writeJSON(w, http.StatusUnauthorized, ErrorResponse{
@@ -584,9 +1144,11 @@ func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
 
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
 		"auth_enabled": true,
+		"session_id":   tokenInfo.SessionID,
 		"user": auth.User{
 			Username: tokenInfo.Username,
 			Role:     tokenInfo.Role,
+			Tenant:   tokenInfo.Tenant,
 		},
 	})
 }
@@ -610,6 +1172,16 @@ func (s *Server) GetHub() *Hub {
 	return s.hub
 }
 
+// SetLogBuffer replaces the log buffer, e.g. with one shared with the global
+// logger. Must be called before Start.
+func (s *Server) SetLogBuffer(buffer *logger.Buffer) {
+	if buffer == nil {
+		return
+	}
+	s.logBuffer = buffer
+	s.logsHandler.SetBuffer(buffer)
+}
+
 // GetLogBuffer returns the log buffer for integration with the global logger
 func (s *Server) GetLogBuffer() *logger.Buffer {
 	return s.logBuffer
@@ -627,6 +1199,26 @@ func (s *Server) EvaluateAlerts(state *models.DroneState) {
 	}
 }
 
+// HandlePublisherHealth raises an alert when a publisher becomes degraded
+// or recovers. Intended for core.Engine.SetPublisherHealthCallback.
+func (s *Server) HandlePublisherHealth(h core.PublisherHealth) {
+	if s.alerter == nil {
+		return
+	}
+	source := "publisher:" + h.Name
+	switch h.Status {
+	case core.PublisherStatusDegraded:
+		msg := fmt.Sprintf("Publisher %s degraded (%s)", h.Name, h.Reason)
+		if h.LastError != "" {
+			msg += ": " + h.LastError
+		}
+		s.publishAlert(s.alerter.Raise(alerter.AlertTypePublisherDegraded, alerter.SeverityCritical, source, msg))
+	case core.PublisherStatusHealthy:
+		s.publishAlert(s.alerter.Raise(alerter.AlertTypePublisherDegraded, alerter.SeverityInfo, source,
+			fmt.Sprintf("Publisher %s recovered", h.Name)))
+	}
+}
+
 // GetGeofenceEngine returns the geofence engine for integration
 func (s *Server) GetGeofenceEngine() *geofence.Engine {
 	return s.geofenceEngine
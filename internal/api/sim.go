@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/adapters/sim"
+)
+
+// Simulator controls the built-in telemetry simulator
+type Simulator interface {
+	AddDrones(spec sim.DroneSpec, count int) ([]sim.DroneInfo, error)
+	RemoveDrone(id string) error
+	Drones() []sim.DroneInfo
+}
+
+// SimDronesRequest is the request body for POST /api/v1/sim/drones
+type SimDronesRequest struct {
+	sim.DroneSpec
+	Count int `json:"count,omitempty"` // Number of drones to create (default 1)
+}
+
+// SimDronesResponse lists simulated drones
+type SimDronesResponse struct {
+	Count  int             `json:"count"`
+	Drones []sim.DroneInfo `json:"drones"`
+}
+
+// SetSimulator enables the /api/v1/sim endpoints
+func (s *Server) SetSimulator(sim Simulator) {
+	s.simulator = sim
+}
+
+// requireSimulator writes 501 if the simulator is not enabled
+func (s *Server) requireSimulator(w http.ResponseWriter) bool {
+	if s.simulator == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "simulator not enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// handleGetSimDrones lists simulated drones
+// GET /api/v1/sim/drones
+func (s *Server) handleGetSimDrones(w http.ResponseWriter, r *http.Request) {
+	if !s.requireSimulator(w) {
+		return
+	}
+	drones := s.simulator.Drones()
+	s.writeJSON(w, http.StatusOK, SimDronesResponse{Count: len(drones), Drones: drones})
+}
+
+// handleCreateSimDrones adds simulated drones
+// POST /api/v1/sim/drones
+func (s *Server) handleCreateSimDrones(w http.ResponseWriter, r *http.Request) {
+	if !s.requireSimulator(w) {
+		return
+	}
+
+	var req SimDronesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+	if req.Count < 0 {
+		s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: "count must not be negative",
+		})
+		return
+	}
+
+	drones, err := s.simulator.AddDrones(req.DroneSpec, req.Count)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, sim.ErrInvalidSpec), errors.Is(err, sim.ErrTooManyDrones):
+			status = http.StatusBadRequest
+		case errors.Is(err, sim.ErrDroneExists):
+			status = http.StatusConflict
+		}
+		s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, SimDronesResponse{Count: len(drones), Drones: drones})
+}
+
+// handleDeleteSimDrone removes a simulated drone
+// DELETE /api/v1/sim/drones/{id}
+func (s *Server) handleDeleteSimDrone(w http.ResponseWriter, r *http.Request) {
+	if !s.requireSimulator(w) {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := s.simulator.RemoveDrone(id); err != nil {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:    err.Error(),
+			DeviceID: id,
+		})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
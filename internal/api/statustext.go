@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// StatusTextProvider is optionally implemented by a StateProvider to expose
+// the recent text messages of drone autopilots
+type StatusTextProvider interface {
+	StatusTexts(deviceID string) ([]models.StatusText, bool)
+}
+
+// DroneStatusTextResponse is the response for GET /api/v1/drones/{deviceID}/statustext
+type DroneStatusTextResponse struct {
+	DeviceID string              `json:"device_id"`
+	Count    int                 `json:"count"`
+	Messages []models.StatusText `json:"messages"` // Oldest first
+}
+
+// handleGetDroneStatusText returns the recent autopilot text messages of a
+// drone, e.g. pre-arm check failures. Known drones without any return an
+// empty list.
+// GET /api/v1/drones/{deviceID}/statustext
+func (s *Server) handleGetDroneStatusText(w http.ResponseWriter, r *http.Request) {
+	deviceID := chi.URLParam(r, "deviceID")
+
+	var texts []models.StatusText
+	found := false
+	if sp, ok := s.provider.(StatusTextProvider); ok {
+		texts, found = sp.StatusTexts(deviceID)
+	}
+	known := found || s.provider.GetState(deviceID) != nil
+	if !known || !s.deviceVisible(r, deviceID) {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:    "drone not found",
+			DeviceID: deviceID,
+		})
+		return
+	}
+	if texts == nil {
+		texts = []models.StatusText{}
+	}
+	s.writeJSON(w, http.StatusOK, DroneStatusTextResponse{DeviceID: deviceID, Count: len(texts), Messages: texts})
+}
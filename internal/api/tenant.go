@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+	"github.com/open-uav/telemetry-bridge/internal/core/tenant"
+)
+
+// TenantProvider is optionally implemented by a StateProvider to scope
+// devices to tenants. Users whose token carries a tenant only see that
+// tenant's devices, alerts, geofences and tracks.
+type TenantProvider interface {
+	Tenants() *tenant.Registry
+}
+
+// tenants returns the tenant registry, or nil if tenants are not configured
+func (s *Server) tenants() *tenant.Registry {
+	if tp, ok := s.provider.(TenantProvider); ok {
+		return tp.Tenants()
+	}
+	return nil
+}
+
+// deviceVisible reports whether the request user may see a device
+func (s *Server) deviceVisible(r *http.Request, deviceID string) bool {
+	return s.tenants().Visible(auth.TenantFromContext(r.Context()), deviceID)
+}
+
+// requireDevice writes a 404 and returns false if the request user may not
+// see the device, so other tenants' devices look like unknown ones
+func (s *Server) requireDevice(w http.ResponseWriter, r *http.Request, deviceID string) bool {
+	if s.deviceVisible(r, deviceID) {
+		return true
+	}
+	s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+		Error:    "drone not found",
+		DeviceID: deviceID,
+	})
+	return false
+}
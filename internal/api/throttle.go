@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/throttler"
+)
+
+// ThrottleProvider is optionally implemented by a StateProvider to expose
+// runtime throttle state and per-device rate boosts
+type ThrottleProvider interface {
+	GetThrottleStatus() throttler.Status
+	BoostThrottle(deviceID string, rateHz float64, d time.Duration) (time.Time, error)
+	ClearThrottleBoost(deviceID string)
+}
+
+// BoostRequest is the data of a boost_rate WebSocket message
+type BoostRequest struct {
+	DeviceID    string  `json:"device_id"`
+	RateHz      float64 `json:"rate_hz"`
+	DurationSec int     `json:"duration_sec,omitempty"` // 0 = default
+}
+
+// BoostResponse is the data of a boost_ack WebSocket message
+type BoostResponse struct {
+	DeviceID  string  `json:"device_id"`
+	RateHz    float64 `json:"rate_hz"`              // 0 after clear_boost
+	ExpiresAt int64   `json:"expires_at,omitempty"` // Unix milliseconds
+}
+
+// handleThrottleStatus returns effective rates and per-device counters
+// GET /api/v1/throttle/status
+func (s *Server) handleThrottleStatus(w http.ResponseWriter, r *http.Request) {
+	tp, ok := s.provider.(ThrottleProvider)
+	if !ok {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "throttle inspection not supported",
+		})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, tp.GetThrottleStatus())
+}
+
+// handleBoost processes boost_rate and clear_boost messages
+func (c *WSClient) handleBoost(msg *WSMessage) {
+	if c.throttle == nil {
+		c.sendError("throttle boost not supported")
+		return
+	}
+	if !c.canControl {
+		c.sendError("write access required to boost rates")
+		return
+	}
+
+	var req BoostRequest
+	if err := json.Unmarshal(msg.Data, &req); err != nil || req.DeviceID == "" {
+		c.sendError("device_id is required")
+		return
+	}
+
+	resp := BoostResponse{DeviceID: req.DeviceID}
+	if msg.Type == WSMessageTypeClearBoost {
+		c.throttle.ClearThrottleBoost(req.DeviceID)
+	} else {
+		expires, err := c.throttle.BoostThrottle(req.DeviceID, req.RateHz, time.Duration(req.DurationSec)*time.Second)
+		if err != nil {
+			if errors.Is(err, throttler.ErrInvalidRate) || errors.Is(err, throttler.ErrInvalidDuration) {
+				c.sendError(err.Error())
+			} else {
+				log.Printf("[WebSocket] Boost failed: %v", err)
+				c.sendError("boost failed")
+			}
+			return
+		}
+		resp.RateHz = req.RateHz
+		resp.ExpiresAt = expires.UnixMilli()
+	}
+
+	data, _ := json.Marshal(resp)
+	c.sendMessage(WSMessage{Type: WSMessageTypeBoostAck, DeviceID: req.DeviceID, Data: data})
+}
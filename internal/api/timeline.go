@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/timeline"
+)
+
+// TimelineProvider is optionally implemented by a StateProvider to expose
+// the flight events derived from drone states
+type TimelineProvider interface {
+	Timeline() *timeline.Tracker
+}
+
+// DroneEventsResponse is the response for GET /api/v1/drones/{deviceID}/events
+type DroneEventsResponse struct {
+	DeviceID string           `json:"device_id"`
+	Count    int              `json:"count"`
+	Events   []timeline.Event `json:"events"` // Newest first
+}
+
+// handleGetDroneEvents returns the flight events of a drone (arming,
+// takeoff, landing, mode changes, RTL, GPS loss), newest first
+// GET /api/v1/drones/{deviceID}/events?limit=50
+func (s *Server) handleGetDroneEvents(w http.ResponseWriter, r *http.Request) {
+	tp, ok := s.provider.(TimelineProvider)
+	if !ok || tp.Timeline() == nil {
+		s.writeJSON(w, http.StatusNotImplemented, ErrorResponse{
+			Error: "flight events not supported",
+		})
+		return
+	}
+	deviceID := chi.URLParam(r, "deviceID")
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			s.writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error: "invalid limit parameter",
+			})
+			return
+		}
+	}
+
+	evs := tp.Timeline().Events(deviceID, limit)
+	known := len(evs) > 0 || s.provider.GetState(deviceID) != nil
+	if !known || !s.deviceVisible(r, deviceID) {
+		s.writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:    "drone not found",
+			DeviceID: deviceID,
+		})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, DroneEventsResponse{DeviceID: deviceID, Count: len(evs), Events: evs})
+}
+
+// broadcastFlightEvent forwards a flight event to WebSocket clients
+func (s *Server) broadcastFlightEvent(ev events.Event) {
+	if ev.Flight != nil {
+		tenant := s.tenants().Resolve(ev.DeviceID)
+		s.hub.BroadcastFlightEvent(ev.DeviceID, tenant, ev.Flight)
+		s.relay(fanoutMessage{Type: WSMessageTypeFlightEvent, DeviceID: ev.DeviceID, Tenant: tenant, Flight: ev.Flight})
+	}
+}
@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
 )
 
 const (
@@ -24,26 +27,65 @@ const (
 )
 
 var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for now
-	},
+	ReadBufferSize:    1024,
+	WriteBufferSize:   1024,
+	EnableCompression: true, // Used once a client asks for it in a subscribe message
 }
 
-// serveWs handles WebSocket requests from clients
+// serveWs handles WebSocket requests from clients. With
+// http.websocket.require_auth, clients not authenticated in the handshake
+// must send an auth message first and get nothing before.
 func (s *Server) serveWs(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	r, ok := s.wsHandshakeAuth(w, r)
+	if !ok {
+		return
+	}
+	u := upgrader
+	u.CheckOrigin = s.checkOrigin
+	conn, err := u.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("[WebSocket] Upgrade error: %v", err)
 		return
 	}
+	conn.EnableWriteCompression(false)
+
+	var ack *WSAuthAck
+	if s.wsRequireAuth() && !wsAuthenticated(r) {
+		user, err := s.awaitWSAuth(conn)
+		if err != nil {
+			log.Printf("[WebSocket] Rejected client %s: %v", r.RemoteAddr, err)
+			closeUnauthorized(conn, err)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), auth.UserContextKey, user))
+		ack = &WSAuthAck{Username: user.Username, Role: user.Role, Tenant: user.Tenant}
+	}
 
 	client := &WSClient{
 		hub:        s.hub,
 		conn:       conn,
 		send:       make(chan []byte, 256),
 		subscribed: make(map[string]bool),
+		canControl: s.canControl(r),
+		tenant:     auth.TenantFromContext(r.Context()),
+		deflate:    offersDeflate(r),
+		limiter:    s.wsLimiter(),
+	}
+	if s.authEnabled {
+		client.authenticate = s.wsAuthenticate
+	}
+	if tp, ok := s.provider.(ThrottleProvider); ok {
+		client.throttle = tp
+	}
+	if ack != nil {
+		data, _ := json.Marshal(ack)
+		client.sendMessage(WSMessage{Type: WSMessageTypeAuthAck, Data: data})
+	}
+
+	// Show new clients the broadcasts that are still active
+	for _, msg := range s.broadcasts.Active() {
+		data, _ := json.Marshal(msg)
+		client.sendMessage(WSMessage{Type: WSMessageTypeBroadcast, Data: data})
 	}
 
 	client.hub.register <- client
@@ -83,6 +125,11 @@ func (c *WSClient) readPump() {
 			continue
 		}
 
+		if c.limiter != nil && !c.limiter.Allow() {
+			c.sendError("rate limit exceeded, message dropped")
+			continue
+		}
+
 		// Handle client commands
 		c.handleMessage(&msg)
 	}
@@ -106,6 +153,7 @@ func (c *WSClient) writePump() {
 				return
 			}
 
+			c.conn.EnableWriteCompression(c.compress.Load())
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				return
@@ -138,10 +186,15 @@ func (c *WSClient) handleMessage(msg *WSMessage) {
 	case WSMessageTypeSubscribe:
 		var payload struct {
 			DeviceIDs []string `json:"device_ids"`
+			WSSubscribeOptions
 		}
 		if err := json.Unmarshal(msg.Data, &payload); err == nil {
 			c.subscribe(payload.DeviceIDs)
 			log.Printf("[WebSocket] Client subscribed to: %v", payload.DeviceIDs)
+			if payload.Delta != nil || payload.Compress != nil {
+				ack, _ := json.Marshal(c.setOptions(payload.WSSubscribeOptions))
+				c.sendMessage(WSMessage{Type: WSMessageTypeSubscribeAck, Data: ack})
+			}
 		}
 
 	case WSMessageTypeUnsubscribe:
@@ -153,11 +206,54 @@ func (c *WSClient) handleMessage(msg *WSMessage) {
 			log.Printf("[WebSocket] Client unsubscribed from: %v", payload.DeviceIDs)
 		}
 
+	case WSMessageTypeBoostRate, WSMessageTypeClearBoost:
+		c.handleBoost(msg)
+
+	case WSMessageTypeAuth:
+		c.handleAuth(msg)
+
 	default:
 		log.Printf("[WebSocket] Unknown message type: %s", msg.Type)
 	}
 }
 
+// handleAuth authenticates the connection as the user of an auth message,
+// limiting it to the user's tenant and scopes
+func (c *WSClient) handleAuth(msg *WSMessage) {
+	if c.authenticate == nil {
+		c.sendError("authentication is not enabled")
+		return
+	}
+	var creds WSAuth
+	if err := json.Unmarshal(msg.Data, &creds); err != nil {
+		c.sendError("invalid auth message")
+		return
+	}
+	user, err := c.authenticate(creds)
+	if err != nil {
+		c.sendError(err.Error())
+		return
+	}
+
+	c.mu.Lock()
+	c.tenant = user.Tenant
+	c.canControl = user.HasScope(auth.ScopeWrite)
+	c.mu.Unlock()
+
+	data, _ := json.Marshal(WSAuthAck{Username: user.Username, Role: user.Role, Tenant: user.Tenant})
+	c.sendMessage(WSMessage{Type: WSMessageTypeAuthAck, Data: data})
+}
+
+// canControl reports whether a WebSocket request may send control messages:
+// always when auth is disabled, otherwise only with write access
+func (s *Server) canControl(r *http.Request) bool {
+	if !s.authEnabled {
+		return true
+	}
+	user, ok := auth.GetUserFromContext(r.Context())
+	return ok && user.HasScope(auth.ScopeWrite)
+}
+
 // sendError sends an error message to the client
 func (c *WSClient) sendError(message string) {
 	msg := WSMessage{
@@ -165,7 +261,11 @@ func (c *WSClient) sendError(message string) {
 	}
 	data, _ := json.Marshal(map[string]string{"message": message})
 	msg.Data = data
+	c.sendMessage(msg)
+}
 
+// sendMessage queues a message for the client, dropping it if the buffer is full
+func (c *WSClient) sendMessage(msg WSMessage) {
 	msgBytes, _ := json.Marshal(msg)
 	select {
 	case c.send <- msgBytes:
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+
+	"github.com/open-uav/telemetry-bridge/internal/api/auth"
+)
+
+// WSAuth is the payload of an auth message, and of the connection_init
+// message of GraphQL subscriptions
+type WSAuth struct {
+	Token  string `json:"token,omitempty"`   // Access token from /auth/login
+	APIKey string `json:"api_key,omitempty"` // API key with the read scope
+}
+
+// WSAuthAck is the payload of an auth_ack message
+type WSAuthAck struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Tenant   string `json:"tenant,omitempty"`
+}
+
+// wsCloseUnauthorized is the close code of connections failing to
+// authenticate, as in graphql-transport-ws
+const wsCloseUnauthorized = 4401
+
+// defaultWSAuthTimeout is the time to send the auth message when
+// http.websocket.auth_timeout_sec is unset
+const defaultWSAuthTimeout = 10 * time.Second
+
+// errWSAuthRequired is returned for a first message other than auth
+var errWSAuthRequired = errors.New("authentication required")
+
+// wsRequireAuth reports whether WebSocket clients must authenticate
+func (s *Server) wsRequireAuth() bool {
+	return s.authEnabled && s.cfg.WebSocket.RequireAuth
+}
+
+// wsAuthTimeout returns the time clients get to authenticate after the
+// handshake
+func (s *Server) wsAuthTimeout() time.Duration {
+	if s.cfg.WebSocket.AuthTimeoutSec > 0 {
+		return time.Duration(s.cfg.WebSocket.AuthTimeoutSec) * time.Second
+	}
+	return defaultWSAuthTimeout
+}
+
+// wsAuthenticated reports whether the request carries a user
+func wsAuthenticated(r *http.Request) bool {
+	_, ok := auth.GetUserFromContext(r.Context())
+	return ok
+}
+
+// wsHandshakeAuth authenticates a WebSocket handshake with the token or
+// api_key query parameter, for browsers that cannot send headers. A user
+// from the auth headers is kept. Invalid credentials are rejected with 401
+// and false.
+func (s *Server) wsHandshakeAuth(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if !s.authEnabled || wsAuthenticated(r) {
+		return r, true
+	}
+	query := r.URL.Query()
+	creds := WSAuth{Token: query.Get("token"), APIKey: query.Get("api_key")}
+	if creds.Token == "" && creds.APIKey == "" {
+		return r, true
+	}
+	user, err := auth.Authenticate(s.authManager, s.apiKeys, creds.Token, creds.APIKey)
+	if err != nil {
+		s.writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		return r, false
+	}
+	return r.WithContext(context.WithValue(r.Context(), auth.UserContextKey, user)), true
+}
+
+// wsAuthenticate returns the user of the credentials of an auth message
+func (s *Server) wsAuthenticate(creds WSAuth) (auth.User, error) {
+	return auth.Authenticate(s.authManager, s.apiKeys, creds.Token, creds.APIKey)
+}
+
+// awaitWSAuth reads the first message of a connection, which must be an
+// auth message with valid credentials, within the auth timeout
+func (s *Server) awaitWSAuth(conn *websocket.Conn) (auth.User, error) {
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(s.wsAuthTimeout()))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var msg WSMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		return auth.User{}, errWSAuthRequired
+	}
+	var creds WSAuth
+	if msg.Type != WSMessageTypeAuth || json.Unmarshal(msg.Data, &creds) != nil {
+		return auth.User{}, errWSAuthRequired
+	}
+	return s.wsAuthenticate(creds)
+}
+
+// closeUnauthorized tells a client why it is disconnected and closes the
+// connection
+func closeUnauthorized(conn *websocket.Conn, err error) {
+	data, _ := json.Marshal(map[string]string{"message": err.Error()})
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	conn.WriteJSON(WSMessage{Type: WSMessageTypeError, Data: data})
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(wsCloseUnauthorized, "Unauthorized"))
+	conn.Close()
+}
+
+// checkOrigin allows WebSocket handshakes from the allowed origins, or any
+// origin if none are configured. Clients sending no Origin header are not
+// browsers and are left to authentication.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	allowed := s.cfg.WebSocket.AllowedOrigins
+	origin := r.Header.Get("Origin")
+	if len(allowed) == 0 || origin == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(a, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// wsLimiter returns the rate limiter of a new connection's messages, nil
+// if unlimited
+func (s *Server) wsLimiter() *rate.Limiter {
+	if s.cfg.WebSocket.MessagesPerSec <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(s.cfg.WebSocket.MessagesPerSec), max(s.cfg.WebSocket.MessageBurst, 1))
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts environment variables that override config values.
+// Nesting levels are separated by a double underscore, e.g.
+// OUTB_MQTT__PASSWORD sets mqtt.password and OUTB_POLL__0__URL sets the url
+// of the first poll entry.
+const EnvPrefix = "OUTB_"
+
+// fileSuffix marks a key whose value is read from a file, e.g.
+// password_file: /run/secrets/mqtt_password sets password
+const fileSuffix = "_file"
+
+// interpolation matches ${VAR} and ${VAR:-default}
+var interpolation = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// resolve applies ${VAR} interpolation, OUTB_ environment overrides and
+// *_file secrets to a parsed config document, in that order
+func resolve(doc *yaml.Node, environ []string) error {
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+
+	env := make(map[string]string)
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+
+	if err := interpolate(root, env); err != nil {
+		return err
+	}
+	if err := applyEnv(root, env); err != nil {
+		return err
+	}
+	return readFiles(root, reflect.TypeOf(Config{}), "")
+}
+
+// interpolate replaces ${VAR} references in scalar values. Comments are not
+// part of the tree, so commented-out references are ignored.
+func interpolate(node *yaml.Node, env map[string]string) error {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "${") {
+		var missing []string
+		node.Value = interpolation.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			m := interpolation.FindStringSubmatch(ref)
+			if v, ok := env[m[1]]; ok && (v != "" || m[2] == "") {
+				return v
+			}
+			if m[2] != "" {
+				return m[3]
+			}
+			missing = append(missing, m[1])
+			return ""
+		})
+		if len(missing) > 0 {
+			return fmt.Errorf("line %d: environment variable %s is not set", node.Line, strings.Join(missing, ", "))
+		}
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := interpolate(child, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEnv sets the values of OUTB_ environment variables, sorted so a
+// variable for a nested key is applied after one replacing its parent
+func applyEnv(root *yaml.Node, env map[string]string) error {
+	var names []string
+	for name := range env {
+		if strings.HasPrefix(name, EnvPrefix) && strings.Contains(name, "__") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "__")
+		if err := setPath(root, reflect.TypeOf(Config{}), path, env[name]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setPath sets the value at a key path, creating the mappings and sequence
+// entries along the way. String fields take the value as is; other fields
+// parse it as YAML, so lists can be given as [a, b].
+func setPath(node *yaml.Node, t reflect.Type, path []string, value string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if len(path) == 0 {
+		return setValue(node, t, value)
+	}
+	key := path[0]
+
+	switch t.Kind() {
+	case reflect.Struct:
+		field, ok := fieldType(t, key)
+		if !ok {
+			// key_file of a string field
+			base, isFile := strings.CutSuffix(key, fileSuffix)
+			field, ok = fieldType(t, base)
+			if !isFile || !ok || field.Kind() != reflect.String {
+				return fmt.Errorf("unknown config key %q", key)
+			}
+		}
+		return setPath(mappingValue(node, key), field, path[1:], value)
+	case reflect.Map:
+		return setPath(mappingValue(node, key), t.Elem(), path[1:], value)
+	case reflect.Slice:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 {
+			return fmt.Errorf("invalid list index %q", key)
+		}
+		if node.Kind != yaml.SequenceNode {
+			*node = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		}
+		if i > len(node.Content) {
+			return fmt.Errorf("list index %d skips entries, the list has %d", i, len(node.Content))
+		}
+		if i == len(node.Content) {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+		}
+		return setPath(node.Content[i], t.Elem(), path[1:], value)
+	}
+	return fmt.Errorf("%q is not a section", key)
+}
+
+// setValue replaces a node with an environment value
+func setValue(node *yaml.Node, t reflect.Type, value string) error {
+	if t.Kind() == reflect.String {
+		*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		return nil
+	}
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return fmt.Errorf("parsing value: %w", err)
+	}
+	if len(parsed.Content) == 0 {
+		*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+		return nil
+	}
+	*node = *parsed.Content[0]
+	return nil
+}
+
+// readFiles replaces each key_file entry of a string field with the field
+// set to the file's content, without the trailing newline. Keys that are
+// fields themselves, like tls.cert_file, are left alone.
+func readFiles(node *yaml.Node, t reflect.Type, prefix string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if field, ok := fieldType(t, key.Value); ok {
+				if err := readFiles(value, field, prefix+key.Value+"."); err != nil {
+					return err
+				}
+				continue
+			}
+			base, isFile := strings.CutSuffix(key.Value, fileSuffix)
+			if field, ok := fieldType(t, base); !isFile || !ok || field.Kind() != reflect.String || value.Value == "" {
+				continue
+			}
+			data, err := os.ReadFile(value.Value)
+			if err != nil {
+				return fmt.Errorf("%s%s: %w", prefix, key.Value, err)
+			}
+			*mappingValue(node, base) = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.TrimRight(string(data), "\r\n")}
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := readFiles(node.Content[i+1], t.Elem(), prefix+node.Content[i].Value+"."); err != nil {
+				return err
+			}
+		}
+	case node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, item := range node.Content {
+			if err := readFiles(item, t.Elem(), fmt.Sprintf("%s%d.", prefix, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldType returns the type of the struct field with the given yaml name
+func fieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if tag == "" {
+			tag = strings.ToLower(f.Name)
+		}
+		if tag == name && tag != "-" {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+// mappingValue returns the value node of a key, adding the key if missing.
+// A node that is not a mapping is replaced by an empty one.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	)
+	return value
+}
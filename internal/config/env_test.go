@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_Environment(t *testing.T) {
+	tmpDir := t.TempDir()
+	secretPath := filepath.Join(tmpDir, "jwt_secret")
+	if err := os.WriteFile(secretPath, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	configContent := `
+mqtt:
+  enabled: true
+  broker: "tcp://${MQTT_HOST}:1883"
+  client_id: "${CLIENT_ID:-outb-default}"
+  password: "baked-in"
+  # username: ${NOT_SET}
+
+http:
+  enabled: true
+  tls:
+    cert_file: /etc/outb/cert.pem
+  auth:
+    password_hash: "$2a$10$abcdefghijklmnopqrstuv"
+    jwt_secret_file: ` + secretPath + `
+
+poll:
+  - name: opensky
+    url: https://opensky-network.org/api/states/all
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("MQTT_HOST", "broker.local")
+	t.Setenv("OUTB_MQTT__PASSWORD", "from-env")
+	t.Setenv("OUTB_MQTT__QOS", "2")
+	t.Setenv("OUTB_HTTP__CORS_ORIGINS", "[https://a.example, https://b.example]")
+	t.Setenv("OUTB_POLL__0__INTERVAL_SEC", "30")
+	t.Setenv("OUTB_PLUGIN_HELPER", "ignored without a double underscore")
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	if cfg.MQTT.Broker != "tcp://broker.local:1883" || cfg.MQTT.ClientID != "outb-default" {
+		t.Errorf("Interpolated: broker %q, client ID %q", cfg.MQTT.Broker, cfg.MQTT.ClientID)
+	}
+	if cfg.MQTT.Password != "from-env" || cfg.MQTT.QoS != 2 {
+		t.Errorf("Overrides: password %q, qos %d", cfg.MQTT.Password, cfg.MQTT.QoS)
+	}
+	if len(cfg.HTTP.CORSOrigins) != 2 || cfg.HTTP.CORSOrigins[1] != "https://b.example" {
+		t.Errorf("CORSOrigins = %v", cfg.HTTP.CORSOrigins)
+	}
+	if cfg.HTTP.Auth.JWTSecret != "from-file" {
+		t.Errorf("JWTSecret = %q, want the file content", cfg.HTTP.Auth.JWTSecret)
+	}
+	if cfg.HTTP.Auth.PasswordHash != "$2a$10$abcdefghijklmnopqrstuv" || cfg.HTTP.TLS.CertFile != "/etc/outb/cert.pem" {
+		t.Errorf("Plain values changed: hash %q, cert %q", cfg.HTTP.Auth.PasswordHash, cfg.HTTP.TLS.CertFile)
+	}
+	if len(cfg.Poll) != 1 || cfg.Poll[0].IntervalSec != 30 || cfg.Poll[0].Name != "opensky" {
+		t.Errorf("Poll = %+v", cfg.Poll)
+	}
+}
+
+func TestLoadConfig_EnvironmentErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    string
+	}{
+		{"unset variable", "mqtt:\n  broker: ${OUTB_TEST_UNSET}\n", nil, "OUTB_TEST_UNSET is not set"},
+		{"unknown key", "", map[string]string{"OUTB_MQTT__PASWORD": "x"}, `unknown config key "pasword"`},
+		{"missing file", "mqtt:\n  password_file: " + filepath.Join(tmpDir, "missing") + "\n", nil, "mqtt.password_file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to mention %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// probeTimeout is how long Probe waits for each endpoint
+const probeTimeout = 3 * time.Second
+
+// Probe dials the brokers and servers of the enabled publishers, and the
+// receivers adapters connect to, and returns a FieldError for each one that
+// does not accept a TCP connection in time. It only checks reachability,
+// not credentials. UDP endpoints are skipped.
+func Probe(ctx context.Context, cfg *Config) []error {
+	type endpoint struct{ field, address string }
+	var endpoints []endpoint
+	if cfg.MQTT.Enabled {
+		endpoints = append(endpoints, endpoint{"mqtt.broker", urlAddress(cfg.MQTT.Broker, map[string]string{
+			"tcp": "1883", "mqtt": "1883", "ssl": "8883", "tls": "8883", "mqtts": "8883", "ws": "80", "wss": "443",
+		})})
+	}
+	if cfg.Redis.Enabled {
+		endpoints = append(endpoints, endpoint{"redis.address", cfg.Redis.Address})
+	}
+	if cfg.AMQP.Enabled {
+		endpoints = append(endpoints, endpoint{"amqp.url", urlAddress(cfg.AMQP.URL, map[string]string{"amqp": "5672", "amqps": "5671"})})
+	}
+	if cfg.AWSIoT.Enabled {
+		endpoints = append(endpoints, endpoint{"aws_iot.endpoint", net.JoinHostPort(cfg.AWSIoT.Endpoint, strconv.Itoa(cfg.AWSIoT.Port))})
+	}
+	if cfg.AzureIoT.Enabled {
+		for _, part := range strings.Split(cfg.AzureIoT.ConnectionString, ";") {
+			if host, ok := strings.CutPrefix(part, "HostName="); ok {
+				endpoints = append(endpoints, endpoint{"azure_iot.connection_string", net.JoinHostPort(host, "8883")})
+			}
+		}
+	}
+	if cfg.NMEA.Enabled && cfg.NMEA.ConnectionType == "tcp" {
+		endpoints = append(endpoints, endpoint{"nmea.address", cfg.NMEA.Address})
+	}
+	if cfg.GB28181.Enabled && cfg.GB28181.Transport == "tcp" {
+		endpoints = append(endpoints, endpoint{"gb28181.server_ip", net.JoinHostPort(cfg.GB28181.ServerIP, strconv.Itoa(cfg.GB28181.ServerPort))})
+	}
+
+	results := make([]error, len(endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ep.address == "" {
+				results[i] = fieldErrorf(ep.field, "no host to probe")
+				return
+			}
+			conn, err := (&net.Dialer{Timeout: probeTimeout}).DialContext(ctx, "tcp", ep.address)
+			if err != nil {
+				results[i] = fieldErrorf(ep.field, "%s is not reachable: %v; check the address and firewall", ep.address, err)
+				return
+			}
+			conn.Close()
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// urlAddress returns host:port of a broker URL, with the default port of
+// its scheme. Returns "" for URLs that do not parse.
+func urlAddress(raw string, defaultPorts map[string]string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPorts[u.Scheme]
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
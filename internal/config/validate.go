@@ -0,0 +1,175 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// FieldError is a problem with one config key
+type FieldError struct {
+	Field   string `json:"field"`   // Dotted key, e.g. mqtt.topic_template
+	Message string `json:"message"` // What is wrong and how to fix it
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func fieldErrorf(field, format string, args ...any) error {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// AsFieldError returns the FieldError in err. Other errors are split into
+// key and message at the first ": ", like "mqtt.broker: invalid URL".
+func AsFieldError(err error) FieldError {
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		return *fe
+	}
+	if field, msg, ok := strings.Cut(err.Error(), ": "); ok && !strings.Contains(field, " ") {
+		return FieldError{Field: field, Message: msg}
+	}
+	return FieldError{Message: err.Error()}
+}
+
+// Validate checks what the loader accepts but would stop the gateway or
+// make it misbehave: listeners sharing a port, certificates that cannot be
+// loaded and rates out of range. Sections owned by other packages, like
+// publisher URLs, are checked by those packages.
+func Validate(cfg *Config) []error {
+	errs := validateListeners(cfg)
+
+	if cfg.HTTP.Enabled && cfg.HTTP.TLS.Enabled {
+		if _, err := tls.LoadX509KeyPair(cfg.HTTP.TLS.CertFile, cfg.HTTP.TLS.KeyFile); err != nil {
+			errs = append(errs, fieldErrorf("http.tls", "cannot load certificate: %v; check cert_file and key_file", err))
+		}
+	}
+
+	t := cfg.Throttle
+	switch {
+	case t.MinRateHz <= 0:
+		errs = append(errs, fieldErrorf("throttle.min_rate_hz", "must be positive"))
+	case t.MaxRateHz < t.MinRateHz:
+		errs = append(errs, fieldErrorf("throttle.max_rate_hz", "%g is below min_rate_hz (%g)", t.MaxRateHz, t.MinRateHz))
+	case t.DefaultRateHz < t.MinRateHz || t.DefaultRateHz > t.MaxRateHz:
+		errs = append(errs, fieldErrorf("throttle.default_rate_hz", "%g is outside min_rate_hz..max_rate_hz (%g-%g)",
+			t.DefaultRateHz, t.MinRateHz, t.MaxRateHz))
+	}
+	if _, err := ParseTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		errs = append(errs, fieldErrorf("http.trusted_proxies", "%v", err))
+	}
+	if r := cfg.MAVLink.StreamRateHz; cfg.MAVLink.Enabled && r <= 0 && r != -1 {
+		errs = append(errs, fieldErrorf("mavlink.stream_rate_hz", "must be positive, or -1 to keep the autopilot's rates"))
+	}
+	if cfg.Sim.Enabled && cfg.Sim.RateHz <= 0 {
+		errs = append(errs, fieldErrorf("sim.rate_hz", "must be positive"))
+	}
+	if cfg.GB28181.Enabled {
+		for _, iv := range []struct {
+			name  string
+			value int
+		}{
+			{"heartbeat_interval", cfg.GB28181.HeartbeatInterval},
+			{"position_interval", cfg.GB28181.PositionInterval},
+		} {
+			if iv.value <= 0 {
+				errs = append(errs, fieldErrorf("gb28181."+iv.name, "must be a positive number of seconds"))
+			}
+		}
+	}
+	return errs
+}
+
+// listener is a local port range the gateway binds
+type listener struct {
+	field    string
+	network  string // tcp | udp
+	host     string
+	min, max int
+}
+
+// validateListeners reports listeners of the same network whose ports
+// overlap on the same or a wildcard host, which would fail at startup with
+// "address already in use"
+func validateListeners(cfg *Config) []error {
+	var errs []error
+	var listeners []listener
+	add := func(field, network, address string) {
+		host, port, err := net.SplitHostPort(address)
+		n, perr := strconv.Atoi(port)
+		if err != nil || perr != nil || n < 0 || n > 65535 {
+			errs = append(errs, fieldErrorf(field, "invalid address %q: want host:port", address))
+			return
+		}
+		if n > 0 { // Port 0 picks a free port
+			listeners = append(listeners, listener{field: field, network: network, host: host, min: n, max: n})
+		}
+	}
+
+	if cfg.MAVLink.Enabled && (cfg.MAVLink.ConnectionType == "udp" || cfg.MAVLink.ConnectionType == "tcp") {
+		add("mavlink.address", cfg.MAVLink.ConnectionType, cfg.MAVLink.Address)
+	}
+	if cfg.DJI.Enabled {
+		add("dji.listen_address", "tcp", cfg.DJI.ListenAddress)
+	}
+	if cfg.UDP.Enabled {
+		add("udp.listen_address", "udp", cfg.UDP.ListenAddress)
+	}
+	if cfg.HTTP.Enabled {
+		add("http.address", "tcp", cfg.HTTP.Address)
+	}
+	if cfg.PublicFeed.Enabled {
+		add("public_feed.address", "tcp", cfg.PublicFeed.Address)
+	}
+	if cfg.GB28181.Enabled {
+		network := cfg.GB28181.Transport
+		if network != "tcp" {
+			network = "udp"
+		}
+		add("gb28181.local_port", network, net.JoinHostPort(cfg.GB28181.LocalIP, strconv.Itoa(cfg.GB28181.LocalPort)))
+		if v := cfg.GB28181.Video; v.Enabled && v.MediaPortMin > 0 {
+			listeners = append(listeners, listener{field: "gb28181.video.media_port_min", network: "udp",
+				host: cfg.GB28181.LocalIP, min: v.MediaPortMin, max: max(v.MediaPortMin, v.MediaPortMax)})
+		}
+	}
+
+	for i, l := range listeners {
+		for _, other := range listeners[:i] {
+			if l.network != other.network || l.min > other.max || other.min > l.max || !sameHost(l.host, other.host) {
+				continue
+			}
+			errs = append(errs, fieldErrorf(l.field, "%s port %d is already used by %s; choose another port",
+				l.network, max(l.min, other.min), other.field))
+			break
+		}
+	}
+	return errs
+}
+
+// sameHost reports whether listeners on two hosts can collide
+func sameHost(a, b string) bool {
+	wildcard := func(h string) bool { return h == "" || h == "0.0.0.0" || h == "::" }
+	return a == b || wildcard(a) || wildcard(b)
+}
+
+// ParseTrustedProxies parses http.trusted_proxies, IP addresses or CIDRs
+func ParseTrustedProxies(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		if addr, err := netip.ParseAddr(s); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: want an IP or CIDR", s)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
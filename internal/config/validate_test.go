@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cfg := &Config{
+		Throttle: ThrottleConfig{DefaultRateHz: 1, MinRateHz: 0.5, MaxRateHz: 10},
+		MAVLink:  MAVLinkConfig{Enabled: true, ConnectionType: "udp", Address: "0.0.0.0:14550", StreamRateHz: -1},
+		DJI:      DJIConfig{Enabled: true, ListenAddress: "0.0.0.0:14560"},
+		UDP:      UDPConfig{Enabled: true, ListenAddress: "127.0.0.1:14560"}, // Same port, other network
+		HTTP:     HTTPConfig{Enabled: true, Address: "127.0.0.1:8080", TrustedProxies: []string{"10.0.0.1", "fd00::/8"}},
+	}
+	if errs := Validate(cfg); len(errs) != 0 {
+		t.Fatalf("Validate() = %v, want no errors", errs)
+	}
+
+	cfg.PublicFeed = PublicFeedConfig{Enabled: true, Address: ":8080"}
+	cfg.GB28181 = GB28181Config{
+		Enabled: true, LocalPort: 5060, HeartbeatInterval: 60,
+		Video: GB28181VideoConfig{Enabled: true, MediaPortMin: 14500, MediaPortMax: 14600},
+	}
+	cfg.HTTP.TLS = TLSConfig{Enabled: true, CertFile: "missing.crt", KeyFile: "missing.key"}
+	cfg.Throttle.DefaultRateHz = 20
+	cfg.MAVLink.StreamRateHz = -2
+	cfg.HTTP.TrustedProxies = append(cfg.HTTP.TrustedProxies, "proxy.local")
+
+	got := make(map[string]string)
+	for _, err := range Validate(cfg) {
+		var fe *FieldError
+		if !errors.As(err, &fe) {
+			t.Fatalf("Validate() returned %T, want *FieldError", err)
+		}
+		got[fe.Field] = fe.Message
+	}
+	for field, want := range map[string]string{
+		"public_feed.address":          "tcp port 8080 is already used by http.address",
+		"gb28181.video.media_port_min": "udp port 14550 is already used by mavlink.address",
+		"gb28181.position_interval":    "positive",
+		"http.tls":                     "cannot load certificate",
+		"throttle.default_rate_hz":     "outside",
+		"mavlink.stream_rate_hz":       "-1",
+		"http.trusted_proxies":         "proxy.local",
+	} {
+		if !strings.Contains(got[field], want) {
+			t.Errorf("%s: %q, want it to mention %q", field, got[field], want)
+		}
+	}
+	if len(got) != 7 {
+		t.Errorf("Validate() = %v, want 7 errors", got)
+	}
+}
+
+func TestAsFieldError(t *testing.T) {
+	if fe := AsFieldError(fmt.Errorf("wrapped: %w", fieldErrorf("a.b", "bad"))); fe != (FieldError{Field: "a.b", Message: "bad"}) {
+		t.Errorf("AsFieldError(wrapped) = %+v", fe)
+	}
+	if fe := AsFieldError(fmt.Errorf("poll[0]: duplicate name %q", "x")); fe.Field != "poll[0]" || fe.Message != `duplicate name "x"` {
+		t.Errorf("AsFieldError(prefixed) = %+v", fe)
+	}
+	if fe := AsFieldError(errors.New("no key here: at all")); fe.Field != "" {
+		t.Errorf("AsFieldError(plain) = %+v, want no field", fe)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	open, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer open.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := l.Addr().String()
+	l.Close()
+
+	cfg := &Config{
+		MQTT:  MQTTConfig{Enabled: true, Broker: "tcp://" + open.Addr().String()},
+		Redis: RedisConfig{Enabled: true, Address: closed},
+		AMQP:  AMQPConfig{Enabled: false, URL: "amqp://" + closed},
+	}
+	errs := Probe(context.Background(), cfg)
+	if len(errs) != 1 || AsFieldError(errs[0]).Field != "redis.address" {
+		t.Errorf("Probe() = %v, want only redis.address unreachable", errs)
+	}
+
+	if got := urlAddress("ssl://broker.example.com", map[string]string{"ssl": "8883"}); got != "broker.example.com:8883" {
+		t.Errorf("urlAddress() = %s", got)
+	}
+}
@@ -0,0 +1,112 @@
+// Package adapterstats counts the traffic an adapter receives, for adapters
+// implementing sdk.StatsReporter
+package adapterstats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// rateWindow is the number of whole seconds the byte rate is averaged over
+const rateWindow = 10
+
+// bucket holds the bytes received in one second
+type bucket struct {
+	sec   int64
+	bytes uint64
+}
+
+// Counter counts messages, parse errors, bytes and connected peers. It is
+// safe for concurrent use.
+type Counter struct {
+	mu          sync.Mutex
+	messages    uint64
+	parseErrors uint64
+	bytes       uint64
+	peers       int
+	lastMessage time.Time
+	buckets     [rateWindow + 1]bucket // The current second and the window before it
+
+	now func() time.Time
+}
+
+// New creates an empty counter
+func New() *Counter {
+	return &Counter{now: time.Now}
+}
+
+// Received counts a message of n bytes
+func (c *Counter) Received(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.messages++
+	c.bytes += uint64(n)
+	c.lastMessage = now
+
+	sec := now.Unix()
+	b := &c.buckets[sec%int64(len(c.buckets))]
+	if b.sec != sec {
+		*b = bucket{sec: sec}
+	}
+	b.bytes += uint64(n)
+}
+
+// ParseError counts a received message that could not be decoded
+func (c *Counter) ParseError() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parseErrors++
+}
+
+// PeerConnected counts a new connection
+func (c *Counter) PeerConnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.peers++
+}
+
+// PeerDisconnected counts a closed connection
+func (c *Counter) PeerDisconnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.peers > 0 {
+		c.peers--
+	}
+}
+
+// ResetPeers forgets all connections, for adapters that are stopped without
+// being told each connection closed
+func (c *Counter) ResetPeers() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.peers = 0
+}
+
+// Stats returns the current counters. The byte rate is the average over the
+// last rateWindow whole seconds.
+func (c *Counter) Stats() sdk.AdapterStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := sdk.AdapterStats{
+		MessagesReceived: c.messages,
+		ParseErrors:      c.parseErrors,
+		ConnectedPeers:   c.peers,
+		BytesReceived:    c.bytes,
+	}
+	if !c.lastMessage.IsZero() {
+		stats.LastMessageAt = c.lastMessage.UnixMilli()
+	}
+
+	now := c.now().Unix()
+	var windowBytes uint64
+	for _, b := range c.buckets {
+		if b.sec >= now-rateWindow && b.sec < now {
+			windowBytes += b.bytes
+		}
+	}
+	stats.BytesPerSec = float64(windowBytes) / rateWindow
+	return stats
+}
@@ -0,0 +1,54 @@
+package adapterstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := New()
+	c.now = func() time.Time { return now }
+
+	if stats := c.Stats(); stats.MessagesReceived != 0 || stats.LastMessageAt != 0 || stats.BytesPerSec != 0 {
+		t.Errorf("Empty counter = %+v", stats)
+	}
+
+	// 100 bytes per second for 12 seconds
+	for range 12 {
+		c.Received(60)
+		c.Received(40)
+		now = now.Add(time.Second)
+	}
+	c.ParseError()
+	c.PeerConnected()
+	c.PeerConnected()
+	c.PeerDisconnected()
+
+	stats := c.Stats()
+	if stats.MessagesReceived != 24 || stats.BytesReceived != 1200 || stats.ParseErrors != 1 || stats.ConnectedPeers != 1 {
+		t.Errorf("Stats = %+v", stats)
+	}
+	if stats.BytesPerSec != 100 {
+		t.Errorf("BytesPerSec = %v, want 100", stats.BytesPerSec)
+	}
+	if want := now.Add(-time.Second).UnixMilli(); stats.LastMessageAt != want {
+		t.Errorf("LastMessageAt = %d, want %d", stats.LastMessageAt, want)
+	}
+
+	// The rate decays once traffic stops
+	now = now.Add(5 * time.Second)
+	if got := c.Stats().BytesPerSec; got != 50 {
+		t.Errorf("BytesPerSec after 5s of silence = %v, want 50", got)
+	}
+	now = now.Add(time.Minute)
+	if got := c.Stats().BytesPerSec; got != 0 {
+		t.Errorf("BytesPerSec after a minute of silence = %v, want 0", got)
+	}
+
+	c.PeerDisconnected()
+	c.PeerDisconnected()
+	if got := c.Stats().ConnectedPeers; got != 0 {
+		t.Errorf("ConnectedPeers = %d, should not go negative", got)
+	}
+}
@@ -3,11 +3,15 @@ package alerter
 
 import (
 	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // AlertType represents the type of alert
@@ -18,6 +22,10 @@ const (
 	AlertTypeConnectionLost  AlertType = "connection_lost"
 	AlertTypeSignalWeak      AlertType = "signal_weak"
 	AlertTypeGeofenceBreach  AlertType = "geofence_breach"
+	AlertTypeGeofencePredicted AlertType = "geofence_predicted"
+	AlertTypeGeofenceDwell     AlertType = "geofence_dwell"
+	AlertTypePublisherDegraded AlertType = "publisher_degraded"
+	AlertTypeDeviceConflict  AlertType = "device_conflict"
 	AlertTypeCustom          AlertType = "custom"
 )
 
@@ -37,6 +45,7 @@ type Alert struct {
 	Type        AlertType     `json:"type"`
 	Severity    AlertSeverity `json:"severity"`
 	DeviceID    string        `json:"device_id"`
+	Source      string        `json:"source,omitempty"` // Gateway component for system alerts
 	Message     string        `json:"message"`
 	Value       float64       `json:"value,omitempty"`
 	Threshold   float64       `json:"threshold,omitempty"`
@@ -44,26 +53,65 @@ type Alert struct {
 	Acknowledged bool         `json:"acknowledged"`
 	AckedAt     int64         `json:"acked_at,omitempty"`
 	AckedBy     string        `json:"acked_by,omitempty"`
+	Escalations []string      `json:"escalations,omitempty"` // IDs of the escalation policies that fired
+	EscalatedAt int64         `json:"escalated_at,omitempty"`
+	Channels    []string      `json:"channels,omitempty"` // Notification channels the alert was sent to
 }
 
-// Rule represents an alert rule
+// Rule represents an alert rule. Its YAML form, used to export and import
+// rules, leaves out the timestamps.
 type Rule struct {
-	ID          string        `json:"id"`
-	Name        string        `json:"name"`
-	Type        AlertType     `json:"type"`
-	Severity    AlertSeverity `json:"severity"`
-	Enabled     bool          `json:"enabled"`
-	Condition   Condition     `json:"condition"`
-	CooldownMs  int64         `json:"cooldown_ms"` // Minimum time between alerts
-	CreatedAt   int64         `json:"created_at"`
-	UpdatedAt   int64         `json:"updated_at"`
+	ID          string        `json:"id" yaml:"id"`
+	Name        string        `json:"name" yaml:"name"`
+	Type        AlertType     `json:"type" yaml:"type"`
+	Severity    AlertSeverity `json:"severity" yaml:"severity"`
+	Enabled     bool          `json:"enabled" yaml:"enabled"`
+	Condition   Condition     `json:"condition" yaml:"condition"`
+	CooldownMs  int64         `json:"cooldown_ms" yaml:"cooldown_ms"` // Minimum time between alerts
+	Channels    []string      `json:"channels,omitempty" yaml:"channels,omitempty"` // Notification channels alerts are sent to
+	CreatedAt   int64         `json:"created_at" yaml:"-"`
+	UpdatedAt   int64         `json:"updated_at" yaml:"-"`
+
+	// Scope limits the rule to some devices, e.g. a higher low-battery
+	// threshold for fixed-wings. A device is in scope if it is listed in
+	// DeviceIDs or matches DevicePattern, when either is set, and its states
+	// come from ProtocolSource, when set.
+	DeviceIDs      []string `json:"device_ids,omitempty" yaml:"device_ids,omitempty"`
+	DevicePattern  string   `json:"device_pattern,omitempty" yaml:"device_pattern,omitempty"`   // Glob of device IDs, e.g. "fw-*"
+	ProtocolSource string   `json:"protocol_source,omitempty" yaml:"protocol_source,omitempty"` // e.g. mavlink, dji
+}
+
+// Validate checks the device pattern of the rule's scope
+func (r *Rule) Validate() error {
+	if _, err := path.Match(r.DevicePattern, ""); err != nil {
+		return fmt.Errorf("invalid device_pattern %q", r.DevicePattern)
+	}
+	return nil
+}
+
+// AppliesTo reports whether a state is in the rule's scope
+func (r *Rule) AppliesTo(state *models.DroneState) bool {
+	if r.ProtocolSource != "" && r.ProtocolSource != state.ProtocolSource {
+		return false
+	}
+	if len(r.DeviceIDs) == 0 && r.DevicePattern == "" {
+		return true
+	}
+	if slices.Contains(r.DeviceIDs, state.DeviceID) {
+		return true
+	}
+	if r.DevicePattern == "" {
+		return false
+	}
+	ok, _ := path.Match(r.DevicePattern, state.DeviceID)
+	return ok
 }
 
 // Condition defines when an alert should trigger
 type Condition struct {
-	Field     string  `json:"field"`     // e.g., "battery_percent", "satellites_visible"
-	Operator  string  `json:"operator"`  // "<", ">", "<=", ">=", "==", "!="
-	Threshold float64 `json:"threshold"` // Value to compare against
+	Field     string  `json:"field" yaml:"field"`         // e.g., "battery_percent", "satellites_visible"
+	Operator  string  `json:"operator" yaml:"operator"`   // "<", ">", "<=", ">=", "==", "!="
+	Threshold float64 `json:"threshold" yaml:"threshold"` // Value to compare against
 }
 
 // Alerter is the alert engine that evaluates rules and generates alerts
@@ -74,7 +122,19 @@ type Alerter struct {
 	lastAlertTime   map[string]int64    // rule_id:device_id -> last alert timestamp
 	maxAlerts       int
 	onAlert         func(*Alert)
+	onAck           func([]Alert)
 	mu              sync.RWMutex
+
+	policies   map[string]*EscalationPolicy
+	onEscalate func(Alert, EscalationPolicy)
+
+	silences map[string]*Silence
+
+	fields  map[string]Field
+	history map[string]*deviceHistory // device_id -> home and last fix
+	now     func() time.Time
+
+	persist *persister // nil unless opened on a file
 }
 
 // Config holds alerter configuration
@@ -95,6 +155,14 @@ func New(cfg Config) *Alerter {
 		alertsByDevice: make(map[string][]string),
 		lastAlertTime:  make(map[string]int64),
 		maxAlerts:      maxAlerts,
+		policies:       make(map[string]*EscalationPolicy),
+		silences:       make(map[string]*Silence),
+		fields:         make(map[string]Field),
+		history:        make(map[string]*deviceHistory),
+		now:            time.Now,
+	}
+	for _, f := range builtinFields {
+		a.fields[f.Name] = f
 	}
 
 	// Add default rules
@@ -167,20 +235,66 @@ func (a *Alerter) SetAlertCallback(cb func(*Alert)) {
 	a.onAlert = cb
 }
 
+// SetAckCallback sets the function called with the alerts acknowledged by
+// each call to AcknowledgeAlert or AcknowledgeAlerts
+func (a *Alerter) SetAckCallback(cb func([]Alert)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onAck = cb
+}
+
+// Raise records a system alert that is not tied to a rule, e.g. a degraded
+// publisher. Returns the stored alert.
+func (a *Alerter) Raise(alertType AlertType, severity AlertSeverity, source, message string) *Alert {
+	return a.RaiseForDevice(alertType, severity, "", source, message)
+}
+
+// RaiseForDevice records an alert for a device that is not tied to a rule,
+// e.g. a geofence breach, and sends it to the given notification channels.
+// Returns the stored alert, or nil if a silence suppressed it.
+func (a *Alerter) RaiseForDevice(alertType AlertType, severity AlertSeverity, deviceID, source, message string, channels ...string) *Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now().UnixMilli()
+	if a.silenced("", deviceID, now) {
+		return nil
+	}
+
+	alert := &Alert{
+		ID:        uuid.New().String(),
+		Type:      alertType,
+		Severity:  severity,
+		DeviceID:  deviceID,
+		Source:    source,
+		Message:   message,
+		Timestamp: now,
+		Channels:  channels,
+	}
+	a.addAlert(alert)
+
+	if a.onAlert != nil {
+		go a.onAlert(alert)
+	}
+
+	return alert
+}
+
 // Evaluate checks the drone state against all rules and generates alerts
 func (a *Alerter) Evaluate(state *models.DroneState) []*Alert {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	var generated []*Alert
-	now := time.Now().UnixMilli()
+	ctx := a.observe(state, a.now())
+	now := ctx.Now.UnixMilli()
 
 	for _, rule := range a.rules {
-		if !rule.Enabled {
+		if !rule.Enabled || !rule.AppliesTo(state) {
 			continue
 		}
 
-		value, ok := a.getFieldValue(state, rule.Condition.Field)
+		value, ok := a.getFieldValue(state, rule.Condition.Field, ctx)
 		if !ok {
 			continue
 		}
@@ -196,6 +310,9 @@ func (a *Alerter) Evaluate(state *models.DroneState) []*Alert {
 				continue
 			}
 		}
+		if a.silenced(rule.ID, state.DeviceID, now) {
+			continue
+		}
 
 		// Generate alert
 		alert := &Alert{
@@ -208,6 +325,7 @@ func (a *Alerter) Evaluate(state *models.DroneState) []*Alert {
 			Value:     value,
 			Threshold: rule.Condition.Threshold,
 			Timestamp: now,
+			Channels:  rule.Channels,
 		}
 
 		a.addAlert(alert)
@@ -223,25 +341,13 @@ func (a *Alerter) Evaluate(state *models.DroneState) []*Alert {
 	return generated
 }
 
-// getFieldValue extracts a field value from the drone state
-func (a *Alerter) getFieldValue(state *models.DroneState, field string) (float64, bool) {
-	switch field {
-	case "battery_percent":
-		return float64(state.Status.BatteryPercent), true
-	case "signal_quality":
-		return float64(state.Status.SignalQuality), true
-	case "altitude":
-		return state.Location.AltGNSS, true
-	case "altitude_baro":
-		return state.Location.AltBaro, true
-	case "speed":
-		// Calculate ground speed
-		vx := state.Velocity.Vx
-		vy := state.Velocity.Vy
-		return vx*vx + vy*vy, true // squared for comparison
-	default:
+// getFieldValue computes a registered field from the drone state
+func (a *Alerter) getFieldValue(state *models.DroneState, field string, ctx FieldContext) (float64, bool) {
+	f, ok := a.fields[field]
+	if !ok {
 		return 0, false
 	}
+	return f.Value(state, ctx)
 }
 
 // evaluateCondition checks if a value satisfies the condition
@@ -346,6 +452,7 @@ func intToString(n int) string {
 // addAlert adds an alert to the list, maintaining max size
 func (a *Alerter) addAlert(alert *Alert) {
 	a.alerts = append(a.alerts, *alert)
+	a.changed()
 
 	// Track by device
 	a.alertsByDevice[alert.DeviceID] = append(a.alertsByDevice[alert.DeviceID], alert.ID)
@@ -399,18 +506,47 @@ func (a *Alerter) GetAlerts(deviceID string, acknowledged *bool, limit int) []Al
 // AcknowledgeAlert marks an alert as acknowledged
 func (a *Alerter) AcknowledgeAlert(alertID, ackedBy string) error {
 	a.mu.Lock()
-	defer a.mu.Unlock()
-
+	found := false
 	for i := range a.alerts {
 		if a.alerts[i].ID == alertID {
-			a.alerts[i].Acknowledged = true
-			a.alerts[i].AckedAt = time.Now().UnixMilli()
-			a.alerts[i].AckedBy = ackedBy
-			return nil
+			found = true
+			break
 		}
 	}
+	a.mu.Unlock()
+	if !found {
+		return ErrAlertNotFound
+	}
 
-	return ErrAlertNotFound
+	a.AcknowledgeAlerts(func(alert Alert) bool { return alert.ID == alertID }, ackedBy)
+	return nil
+}
+
+// AcknowledgeAlerts marks the unacknowledged alerts for which match returns
+// true as acknowledged. Returns them, oldest first.
+func (a *Alerter) AcknowledgeAlerts(match func(Alert) bool, ackedBy string) []Alert {
+	a.mu.Lock()
+	now := a.now().UnixMilli()
+	var acked []Alert
+	for i := range a.alerts {
+		if a.alerts[i].Acknowledged || !match(a.alerts[i]) {
+			continue
+		}
+		a.alerts[i].Acknowledged = true
+		a.alerts[i].AckedAt = now
+		a.alerts[i].AckedBy = ackedBy
+		acked = append(acked, a.alerts[i])
+	}
+	if len(acked) > 0 {
+		a.changed()
+	}
+	cb := a.onAck
+	a.mu.Unlock()
+
+	if cb != nil && len(acked) > 0 {
+		cb(acked)
+	}
+	return acked
 }
 
 // GetAlert returns a single alert by ID
@@ -429,7 +565,7 @@ func (a *Alerter) GetAlert(alertID string) (*Alert, error) {
 
 // Rule management
 
-// GetRules returns all rules
+// GetRules returns all rules, ordered by ID
 func (a *Alerter) GetRules() []*Rule {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -438,6 +574,7 @@ func (a *Alerter) GetRules() []*Rule {
 	for _, rule := range a.rules {
 		rules = append(rules, rule)
 	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
 	return rules
 }
 
@@ -466,6 +603,7 @@ func (a *Alerter) CreateRule(rule *Rule) error {
 	rule.UpdatedAt = now
 
 	a.rules[rule.ID] = rule
+	a.changed()
 	return nil
 }
 
@@ -480,6 +618,7 @@ func (a *Alerter) UpdateRule(rule *Rule) error {
 
 	rule.UpdatedAt = time.Now().UnixMilli()
 	a.rules[rule.ID] = rule
+	a.changed()
 	return nil
 }
 
@@ -493,6 +632,7 @@ func (a *Alerter) DeleteRule(ruleID string) error {
 	}
 
 	delete(a.rules, ruleID)
+	a.changed()
 	return nil
 }
 
@@ -503,6 +643,33 @@ func (a *Alerter) ClearAlerts() {
 
 	a.alerts = make([]Alert, 0)
 	a.alertsByDevice = make(map[string][]string)
+	a.changed()
+}
+
+// Prune removes alerts raised before the given time.
+// Returns the number of alerts removed.
+func (a *Alerter) Prune(before time.Time) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cutoff := before.UnixMilli()
+	kept := make([]Alert, 0, len(a.alerts))
+	byDevice := make(map[string][]string)
+	for _, alert := range a.alerts {
+		if alert.Timestamp < cutoff {
+			continue
+		}
+		kept = append(kept, alert)
+		byDevice[alert.DeviceID] = append(byDevice[alert.DeviceID], alert.ID)
+	}
+
+	removed := len(a.alerts) - len(kept)
+	a.alerts = kept
+	a.alertsByDevice = byDevice
+	if removed > 0 {
+		a.changed()
+	}
+	return removed
 }
 
 // GetStats returns alerter statistics
@@ -535,6 +702,8 @@ func (a *Alert) MarshalJSON() ([]byte, error) {
 var (
 	ErrAlertNotFound = &AlertError{"alert not found"}
 	ErrRuleNotFound  = &AlertError{"rule not found"}
+	ErrPolicyNotFound = &AlertError{"escalation policy not found"}
+	ErrSilenceNotFound = &AlertError{"silence not found"}
 )
 
 type AlertError struct {
@@ -5,7 +5,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestNew(t *testing.T) {
@@ -260,6 +260,35 @@ func TestAlerter_AcknowledgeAlert_NotFound(t *testing.T) {
 	}
 }
 
+func TestAlerter_AcknowledgeAlerts(t *testing.T) {
+	a := New(Config{})
+	var notified [][]Alert
+	a.SetAckCallback(func(alerts []Alert) { notified = append(notified, alerts) })
+
+	first := a.RaiseForDevice(AlertTypeCustom, SeverityWarning, "drone-1", "test", "first")
+	second := a.RaiseForDevice(AlertTypeCustom, SeverityWarning, "drone-1", "test", "second")
+	other := a.RaiseForDevice(AlertTypeCustom, SeverityWarning, "drone-2", "test", "other")
+	a.AcknowledgeAlert(first.ID, "admin")
+
+	acked := a.AcknowledgeAlerts(func(alert Alert) bool { return alert.DeviceID == "drone-1" }, "ops")
+	if len(acked) != 1 || acked[0].ID != second.ID || acked[0].AckedBy != "ops" {
+		t.Fatalf("AcknowledgeAlerts() = %+v, want only the unacknowledged alert of drone-1", acked)
+	}
+	if alert, _ := a.GetAlert(first.ID); alert.AckedBy != "admin" {
+		t.Errorf("Already acknowledged alert: AckedBy = %s, want admin", alert.AckedBy)
+	}
+	if alert, _ := a.GetAlert(other.ID); alert.Acknowledged {
+		t.Error("Alert of drone-2 should not be acknowledged")
+	}
+	if len(notified) != 2 || notified[1][0].ID != second.ID {
+		t.Errorf("Ack callback got %d calls, want one per acknowledgement", len(notified))
+	}
+
+	if acked := a.AcknowledgeAlerts(func(Alert) bool { return false }, "ops"); len(acked) != 0 || len(notified) != 2 {
+		t.Error("Acknowledging nothing should not call back")
+	}
+}
+
 func TestAlerter_GetAlert(t *testing.T) {
 	a := New(Config{})
 
@@ -383,6 +412,26 @@ func TestAlerter_ClearAlerts(t *testing.T) {
 	}
 }
 
+func TestAlerter_Prune(t *testing.T) {
+	a := New(Config{})
+	old := a.Raise(AlertTypePublisherDegraded, SeverityWarning, "publisher:mqtt", "old")
+	recent := a.Raise(AlertTypePublisherDegraded, SeverityWarning, "publisher:mqtt", "recent")
+
+	a.mu.Lock()
+	a.alerts[0].Timestamp = time.Now().Add(-48 * time.Hour).UnixMilli()
+	a.mu.Unlock()
+
+	if removed := a.Prune(time.Now().Add(-24 * time.Hour)); removed != 1 {
+		t.Errorf("Prune() = %d, want 1", removed)
+	}
+	if _, err := a.GetAlert(old.ID); err == nil {
+		t.Error("Old alert should be pruned")
+	}
+	if _, err := a.GetAlert(recent.ID); err != nil {
+		t.Error("Recent alert should be kept")
+	}
+}
+
 func TestAlerter_GetStats(t *testing.T) {
 	a := New(Config{})
 
@@ -456,6 +505,26 @@ func TestAlerter_SetAlertCallback(t *testing.T) {
 	}
 }
 
+func TestAlerter_Raise(t *testing.T) {
+	a := New(Config{})
+
+	alert := a.Raise(AlertTypePublisherDegraded, SeverityCritical, "publisher:mqtt", "mqtt degraded")
+	if alert.ID == "" {
+		t.Error("Raised alert should have an ID")
+	}
+	if alert.Source != "publisher:mqtt" {
+		t.Errorf("Source = %s, want publisher:mqtt", alert.Source)
+	}
+
+	alerts := a.GetAlerts("", nil, 0)
+	if len(alerts) != 1 {
+		t.Fatalf("GetAlerts = %d, want 1", len(alerts))
+	}
+	if alerts[0].Type != AlertTypePublisherDegraded {
+		t.Errorf("Type = %s, want %s", alerts[0].Type, AlertTypePublisherDegraded)
+	}
+}
+
 func TestAlerter_EvaluateCondition(t *testing.T) {
 	a := New(Config{})
 
@@ -517,12 +586,12 @@ func TestAlerter_GetFieldValue(t *testing.T) {
 		{"signal_quality", 90, true},
 		{"altitude", 100.5, true},
 		{"altitude_baro", 99.0, true},
-		{"speed", 25, true}, // 3^2 + 4^2 = 25
+		{"speed", 5, true}, // sqrt(3^2 + 4^2)
 		{"unknown", 0, false},
 	}
 
 	for _, tt := range tests {
-		val, ok := a.getFieldValue(state, tt.field)
+		val, ok := a.getFieldValue(state, tt.field, FieldContext{})
 		if ok != tt.wantOk {
 			t.Errorf("getFieldValue(%s) ok = %v, want %v", tt.field, ok, tt.wantOk)
 		}
@@ -547,3 +616,46 @@ func TestAlerter_DisabledRule(t *testing.T) {
 		t.Error("Disabled rules should not generate alerts")
 	}
 }
+
+func TestAlerter_RuleScope(t *testing.T) {
+	a := New(Config{})
+	for _, rule := range a.rules {
+		rule.Enabled = false
+	}
+	a.CreateRule(&Rule{
+		Name: "Fixed-wing low battery", Type: AlertTypeBatteryLow, Severity: SeverityWarning, Enabled: true,
+		Condition:     Condition{Field: "battery_percent", Operator: "<", Threshold: 30},
+		DevicePattern: "fw-*",
+	})
+	a.CreateRule(&Rule{
+		Name: "Multirotor low battery", Type: AlertTypeBatteryLow, Severity: SeverityWarning, Enabled: true,
+		Condition: Condition{Field: "battery_percent", Operator: "<", Threshold: 15},
+		DeviceIDs: []string{"mr-1", "mr-2"}, ProtocolSource: "mavlink",
+	})
+
+	tests := []struct {
+		deviceID string
+		source   string
+		battery  int
+		want     int
+	}{
+		{"fw-1", "mavlink", 25, 1},
+		{"fw-1", "mavlink", 35, 0},
+		{"mr-1", "mavlink", 25, 0},
+		{"mr-1", "mavlink", 10, 1},
+		{"mr-2", "dji", 10, 0},
+		{"other", "mavlink", 10, 0},
+	}
+	for _, tt := range tests {
+		state := &models.DroneState{DeviceID: tt.deviceID, ProtocolSource: tt.source, Status: models.Status{BatteryPercent: tt.battery}}
+		if got := len(a.Evaluate(state)); got != tt.want {
+			t.Errorf("%s (%s) at %d%%: %d alerts, want %d", tt.deviceID, tt.source, tt.battery, got, tt.want)
+		}
+		a.ClearAlerts()
+		a.lastAlertTime = make(map[string]int64)
+	}
+
+	if err := (&Rule{DevicePattern: "fw-["}).Validate(); err == nil {
+		t.Error("Validate should reject a malformed device_pattern")
+	}
+}
@@ -0,0 +1,199 @@
+package alerter
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EscalationPolicy re-notifies about alerts that stay unacknowledged
+type EscalationPolicy struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Enabled      bool          `json:"enabled"`
+	MinSeverity  AlertSeverity `json:"min_severity"`    // Alerts at or above this severity (default critical)
+	Types        []AlertType   `json:"types,omitempty"` // Alert types the policy applies to (empty = all)
+	AfterMs      int64         `json:"after_ms"`        // Time an alert must stay unacknowledged
+	Channels     []string      `json:"channels"`        // Notification channels to send to
+	BumpSeverity bool          `json:"bump_severity"`   // Raise the alert one severity level
+	CreatedAt    int64         `json:"created_at"`
+	UpdatedAt    int64         `json:"updated_at"`
+}
+
+// matches reports whether the policy applies to an alert at the given time
+func (p *EscalationPolicy) matches(alert *Alert, now int64) bool {
+	if !p.Enabled || alert.Acknowledged || now-alert.Timestamp < p.AfterMs {
+		return false
+	}
+	if severityLevel(alert.Severity) < severityLevel(p.MinSeverity) {
+		return false
+	}
+	if len(p.Types) > 0 && !slices.Contains(p.Types, alert.Type) {
+		return false
+	}
+	return !slices.Contains(alert.Escalations, p.ID)
+}
+
+// severityLevel orders severities from least to most severe
+func severityLevel(s AlertSeverity) int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityCritical:
+		return 3
+	}
+	return 0
+}
+
+// bumpSeverity returns the next higher severity
+func bumpSeverity(s AlertSeverity) AlertSeverity {
+	if s == SeverityInfo {
+		return SeverityWarning
+	}
+	return SeverityCritical
+}
+
+// SetEscalationCallback sets the function called for every escalation with
+// the escalated alert and the policy that fired
+func (a *Alerter) SetEscalationCallback(cb func(Alert, EscalationPolicy)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onEscalate = cb
+}
+
+// CheckEscalations escalates the alerts that have stayed unacknowledged
+// longer than a matching policy allows. Each policy fires at most once per
+// alert. Returns the number of escalations.
+func (a *Alerter) CheckEscalations(now time.Time) int {
+	type escalation struct {
+		alert  Alert
+		policy EscalationPolicy
+	}
+	var fired []escalation
+
+	a.mu.Lock()
+	policies := a.sortedPolicies()
+	nowMs := now.UnixMilli()
+	for i := range a.alerts {
+		alert := &a.alerts[i]
+		for _, p := range policies {
+			if !p.matches(alert, nowMs) {
+				continue
+			}
+			alert.Escalations = append(alert.Escalations, p.ID)
+			alert.EscalatedAt = nowMs
+			if p.BumpSeverity {
+				alert.Severity = bumpSeverity(alert.Severity)
+			}
+			cp := *alert
+			cp.Escalations = slices.Clone(alert.Escalations)
+			fired = append(fired, escalation{alert: cp, policy: *p})
+		}
+	}
+	if len(fired) > 0 {
+		a.changed()
+	}
+	cb := a.onEscalate
+	a.mu.Unlock()
+
+	if cb != nil {
+		for _, e := range fired {
+			cb(e.alert, e.policy)
+		}
+	}
+	return len(fired)
+}
+
+// sortedPolicies returns the policies by delay, then ID, so shorter
+// escalations fire first. Caller must hold the lock.
+func (a *Alerter) sortedPolicies() []*EscalationPolicy {
+	policies := make([]*EscalationPolicy, 0, len(a.policies))
+	for _, p := range a.policies {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].AfterMs != policies[j].AfterMs {
+			return policies[i].AfterMs < policies[j].AfterMs
+		}
+		return policies[i].ID < policies[j].ID
+	})
+	return policies
+}
+
+// Escalation policy management
+
+// GetEscalationPolicies returns all escalation policies, shortest delay first
+func (a *Alerter) GetEscalationPolicies() []*EscalationPolicy {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.sortedPolicies()
+}
+
+// GetEscalationPolicy returns a single escalation policy by ID
+func (a *Alerter) GetEscalationPolicy(id string) (*EscalationPolicy, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if p, ok := a.policies[id]; ok {
+		return p, nil
+	}
+	return nil, ErrPolicyNotFound
+}
+
+// CreateEscalationPolicy creates a new escalation policy
+func (a *Alerter) CreateEscalationPolicy(p *EscalationPolicy) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	if p.MinSeverity == "" {
+		p.MinSeverity = SeverityCritical
+	}
+
+	now := time.Now().UnixMilli()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	a.policies[p.ID] = p
+	a.changed()
+	return nil
+}
+
+// UpdateEscalationPolicy updates an existing escalation policy
+func (a *Alerter) UpdateEscalationPolicy(p *EscalationPolicy) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	existing, ok := a.policies[p.ID]
+	if !ok {
+		return ErrPolicyNotFound
+	}
+	if p.MinSeverity == "" {
+		p.MinSeverity = SeverityCritical
+	}
+
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = time.Now().UnixMilli()
+	a.policies[p.ID] = p
+	a.changed()
+	return nil
+}
+
+// DeleteEscalationPolicy removes an escalation policy
+func (a *Alerter) DeleteEscalationPolicy(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.policies[id]; !ok {
+		return ErrPolicyNotFound
+	}
+	delete(a.policies, id)
+	a.changed()
+	return nil
+}
@@ -0,0 +1,76 @@
+package alerter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlerter_CheckEscalations(t *testing.T) {
+	a := New(Config{})
+	a.CreateEscalationPolicy(&EscalationPolicy{ID: "page", Name: "Page on-call", Enabled: true, AfterMs: 600000, Channels: []string{"email"}})
+	a.CreateEscalationPolicy(&EscalationPolicy{ID: "bump", Name: "Bump warnings", Enabled: true, MinSeverity: SeverityWarning,
+		Types: []AlertType{AlertTypeBatteryLow}, AfterMs: 300000, Channels: []string{"webhook"}, BumpSeverity: true})
+	a.CreateEscalationPolicy(&EscalationPolicy{ID: "off", AfterMs: 0, Channels: []string{"webhook"}})
+
+	if p, _ := a.GetEscalationPolicy("page"); p.MinSeverity != SeverityCritical {
+		t.Errorf("Default MinSeverity = %s, want critical", p.MinSeverity)
+	}
+
+	var fired []string
+	a.SetEscalationCallback(func(alert Alert, p EscalationPolicy) {
+		fired = append(fired, p.ID+":"+alert.DeviceID+":"+string(alert.Severity))
+	})
+
+	battery := a.RaiseForDevice(AlertTypeBatteryLow, SeverityWarning, "drone-1", "", "Battery low")
+	signal := a.RaiseForDevice(AlertTypeSignalWeak, SeverityWarning, "drone-2", "", "Weak signal")
+	acked := a.RaiseForDevice(AlertTypeBatteryLow, SeverityCritical, "drone-3", "", "Battery critical")
+	a.AcknowledgeAlert(acked.ID, "operator")
+	raised := time.UnixMilli(battery.Timestamp)
+
+	if n := a.CheckEscalations(raised.Add(time.Minute)); n != 0 {
+		t.Errorf("CheckEscalations() before any delay = %d, want 0", n)
+	}
+
+	// After 5 minutes the warning is bumped to critical, after 10 the critical policy pages
+	a.CheckEscalations(raised.Add(5 * time.Minute))
+	a.CheckEscalations(raised.Add(10 * time.Minute))
+	a.CheckEscalations(raised.Add(20 * time.Minute))
+	want := []string{"bump:drone-1:critical", "page:drone-1:critical"}
+	if len(fired) != len(want) || fired[0] != want[0] || fired[1] != want[1] {
+		t.Errorf("Escalations = %v, want %v", fired, want)
+	}
+
+	got, _ := a.GetAlert(battery.ID)
+	if got.Severity != SeverityCritical || len(got.Escalations) != 2 || got.EscalatedAt == 0 {
+		t.Errorf("Escalated alert = %+v", got)
+	}
+	if got, _ := a.GetAlert(signal.ID); len(got.Escalations) != 0 {
+		t.Errorf("Alert of another type escalated: %+v", got)
+	}
+}
+
+func TestAlerter_EscalationPolicyCRUD(t *testing.T) {
+	a := New(Config{})
+	p := &EscalationPolicy{Name: "Page", Enabled: true, AfterMs: 60000, Channels: []string{"email"}}
+	a.CreateEscalationPolicy(p)
+	if p.ID == "" || p.CreatedAt == 0 {
+		t.Fatalf("CreateEscalationPolicy() = %+v, want ID and CreatedAt set", p)
+	}
+
+	if err := a.UpdateEscalationPolicy(&EscalationPolicy{ID: p.ID, Name: "Page on-call", AfterMs: 120000}); err != nil {
+		t.Fatalf("UpdateEscalationPolicy() error = %v", err)
+	}
+	if got, _ := a.GetEscalationPolicy(p.ID); got.Name != "Page on-call" || got.CreatedAt != p.CreatedAt {
+		t.Errorf("Updated policy = %+v", got)
+	}
+	if err := a.UpdateEscalationPolicy(&EscalationPolicy{ID: "missing"}); err != ErrPolicyNotFound {
+		t.Errorf("UpdateEscalationPolicy() unknown error = %v", err)
+	}
+
+	if err := a.DeleteEscalationPolicy(p.ID); err != nil || len(a.GetEscalationPolicies()) != 0 {
+		t.Errorf("DeleteEscalationPolicy() error = %v", err)
+	}
+	if err := a.DeleteEscalationPolicy(p.ID); err != ErrPolicyNotFound {
+		t.Errorf("DeleteEscalationPolicy() twice error = %v", err)
+	}
+}
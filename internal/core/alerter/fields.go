@@ -0,0 +1,193 @@
+package alerter
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// ErrFieldExists is returned when registering a field name twice
+var ErrFieldExists = errors.New("field already registered")
+
+// FieldContext is the per-device history available to computed fields
+type FieldContext struct {
+	Now     time.Time
+	HomeLat float64 // Where the drone armed, or its first fix if it never reports arming
+	HomeLon float64
+	HasHome bool
+	LastFix time.Time // When a position was last received; zero if never
+}
+
+// FieldFunc computes a field from a state. ok is false when the state or
+// history does not have the data, in which case the rule is skipped.
+type FieldFunc func(state *models.DroneState, ctx FieldContext) (value float64, ok bool)
+
+// Field is a value alert rule conditions can compare
+type Field struct {
+	Name        string    `json:"name"`
+	Unit        string    `json:"unit,omitempty"`
+	Description string    `json:"description"`
+	Value       FieldFunc `json:"-"`
+}
+
+// deviceHistory tracks what computed fields need beyond the current state
+type deviceHistory struct {
+	armed   bool
+	homeLat float64
+	homeLon float64
+	hasHome bool
+	lastFix time.Time
+}
+
+// builtinFields are the fields every alerter knows
+var builtinFields = []Field{
+	{Name: "battery_percent", Unit: "%", Description: "Battery level", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		return float64(s.Status.BatteryPercent), true
+	}},
+	{Name: "signal_quality", Unit: "%", Description: "Link signal quality", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		return float64(s.Status.SignalQuality), true
+	}},
+	{Name: "altitude", Unit: "m", Description: "GNSS altitude", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		return s.Location.AltGNSS, true
+	}},
+	{Name: "altitude_baro", Unit: "m", Description: "Barometric altitude", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		return s.Location.AltBaro, true
+	}},
+	{Name: "ground_speed", Unit: "m/s", Description: "Horizontal speed", Value: groundSpeed},
+	{Name: "speed", Unit: "m/s", Description: "Horizontal speed (same as ground_speed)", Value: groundSpeed},
+	{Name: "vertical_speed", Unit: "m/s", Description: "Climb rate, negative when descending", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		return -s.Velocity.Vz, true
+	}},
+	{Name: "heading", Unit: "deg", Description: "Heading from north, 0-360", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		heading := math.Mod(s.Attitude.Yaw, 360)
+		if heading < 0 {
+			heading += 360
+		}
+		return heading, true
+	}},
+	{Name: "distance_from_home", Unit: "m", Description: "Distance from the home position", Value: func(s *models.DroneState, ctx FieldContext) (float64, bool) {
+		if !hasFix(s) {
+			return 0, false
+		}
+		if s.Home != nil {
+			return s.Home.DistanceM, true
+		}
+		if !ctx.HasHome {
+			return 0, false
+		}
+		return coordinator.HaversineDistance(ctx.HomeLat, ctx.HomeLon, s.Location.Lat, s.Location.Lon), true
+	}},
+	{Name: "bearing_to_home", Unit: "deg", Description: "Direction from the drone to home, 0-360 from north", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		if s.Home == nil || !hasFix(s) {
+			return 0, false
+		}
+		return s.Home.BearingDeg, true
+	}},
+	{Name: "estimated_endurance_min", Unit: "min", Description: "Estimated flight time left on the battery at the recent discharge rate", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		if s.Status.EstimatedEnduranceMin == nil {
+			return 0, false
+		}
+		return *s.Status.EstimatedEnduranceMin, true
+	}},
+	{Name: "satellites_visible", Description: "GNSS satellites in view", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		if s.Status.SatellitesVisible == nil {
+			return 0, false
+		}
+		return float64(*s.Status.SatellitesVisible), true
+	}},
+	{Name: "age_of_last_fix", Unit: "s", Description: "Seconds since a position was last received", Value: func(_ *models.DroneState, ctx FieldContext) (float64, bool) {
+		if ctx.LastFix.IsZero() {
+			return 0, false
+		}
+		return ctx.Now.Sub(ctx.LastFix).Seconds(), true
+	}},
+}
+
+// groundSpeed is the horizontal speed in m/s
+func groundSpeed(s *models.DroneState, _ FieldContext) (float64, bool) {
+	return math.Hypot(s.Velocity.Vx, s.Velocity.Vy), true
+}
+
+// hasFix reports whether a state carries a position
+func hasFix(s *models.DroneState) bool {
+	return s.Location.Lat != 0 || s.Location.Lon != 0
+}
+
+// RegisterField adds a field rules can use, e.g. one derived from a custom
+// adapter's data
+func (a *Alerter) RegisterField(f Field) error {
+	if f.Name == "" || f.Value == nil {
+		return errors.New("field needs a name and a value function")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.fields[f.Name]; ok {
+		return ErrFieldExists
+	}
+	a.fields[f.Name] = f
+	return nil
+}
+
+// Fields returns the fields rules can use, sorted by name
+func (a *Alerter) Fields() []Field {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	fields := make([]Field, 0, len(a.fields))
+	for _, f := range a.fields {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// HasField reports whether rules can use a field
+func (a *Alerter) HasField(name string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	_, ok := a.fields[name]
+	return ok
+}
+
+// ForgetDevice drops the home position and fix history of a device
+func (a *Alerter) ForgetDevice(deviceID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.history, deviceID)
+}
+
+// observe updates a device's history with a state and returns the context
+// for computing its fields. Home is reset when the drone arms.
+func (a *Alerter) observe(state *models.DroneState, now time.Time) FieldContext {
+	h, ok := a.history[state.DeviceID]
+	if !ok {
+		h = &deviceHistory{}
+		a.history[state.DeviceID] = h
+	}
+
+	if state.Status.Armed && !h.armed {
+		h.hasHome = false
+	}
+	h.armed = state.Status.Armed
+
+	if hasFix(state) {
+		h.lastFix = now
+		if !h.hasHome {
+			h.homeLat, h.homeLon, h.hasHome = state.Location.Lat, state.Location.Lon, true
+		}
+	}
+
+	return FieldContext{
+		Now:     now,
+		HomeLat: h.homeLat,
+		HomeLon: h.homeLon,
+		HasHome: h.hasHome,
+		LastFix: h.lastFix,
+	}
+}
@@ -0,0 +1,147 @@
+package alerter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAlerter_ComputedFields(t *testing.T) {
+	a := New(Config{})
+	now := time.UnixMilli(1700000000000)
+	a.now = func() time.Time { return now }
+
+	state := &models.DroneState{
+		DeviceID: "drone-1",
+		Location: models.Location{Lat: 22.5, Lon: 113.9},
+		Attitude: models.Attitude{Yaw: -90},
+		Velocity: models.Velocity{Vx: 6, Vy: 8, Vz: -2},
+		Status:   models.Status{Armed: true, EstimatedEnduranceMin: new(float64)},
+	}
+	*state.Status.EstimatedEnduranceMin = 12.5
+	ctx := a.observe(state, now)
+
+	tests := []struct {
+		field string
+		want  float64
+	}{
+		{"ground_speed", 10},
+		{"speed", 10},
+		{"vertical_speed", 2},
+		{"heading", 270},
+		{"distance_from_home", 0},
+		{"age_of_last_fix", 0},
+		{"estimated_endurance_min", 12.5},
+	}
+	for _, tt := range tests {
+		got, ok := a.getFieldValue(state, tt.field, ctx)
+		if !ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v (ok %v), want %v", tt.field, got, ok, tt.want)
+		}
+	}
+
+	// Roughly 111 m north of home, then a state without a fix 5 s later
+	moved := *state
+	moved.Location.Lat = 22.501
+	ctx = a.observe(&moved, now.Add(time.Second))
+	if d, _ := a.getFieldValue(&moved, "distance_from_home", ctx); math.Abs(d-111.2) > 0.5 {
+		t.Errorf("distance_from_home = %.1f, want about 111.2", d)
+	}
+
+	lost := moved
+	lost.Location = models.Location{}
+	ctx = a.observe(&lost, now.Add(6*time.Second))
+	if age, _ := a.getFieldValue(&lost, "age_of_last_fix", ctx); age != 5 {
+		t.Errorf("age_of_last_fix = %v, want 5", age)
+	}
+	if _, ok := a.getFieldValue(&lost, "distance_from_home", ctx); ok {
+		t.Error("distance_from_home needs a position")
+	}
+}
+
+func TestAlerter_HomeResetOnArming(t *testing.T) {
+	a := New(Config{})
+	now := time.Now()
+
+	state := &models.DroneState{DeviceID: "drone-1", Location: models.Location{Lat: 22.5, Lon: 113.9}}
+	a.observe(state, now)
+
+	// Carried to a new launch site while disarmed, then armed there
+	state.Location.Lat = 22.6
+	a.observe(state, now)
+	state.Status.Armed = true
+	ctx := a.observe(state, now)
+	if ctx.HomeLat != 22.6 {
+		t.Errorf("HomeLat = %v, want the position where the drone armed", ctx.HomeLat)
+	}
+
+	a.ForgetDevice("drone-1")
+	if _, ok := a.history["drone-1"]; ok {
+		t.Error("ForgetDevice should drop the device history")
+	}
+}
+
+func TestAlerter_HomeFromState(t *testing.T) {
+	a := New(Config{})
+	now := time.Now()
+
+	// The gateway's home position takes precedence over the alerter's own
+	state := &models.DroneState{
+		DeviceID: "drone-1",
+		Location: models.Location{Lat: 22.5, Lon: 113.9},
+		Home:     &models.HomePosition{Lat: 22.52, Lon: 113.9, DistanceM: 2224, BearingDeg: 0},
+	}
+	ctx := a.observe(state, now)
+	if d, ok := a.getFieldValue(state, "distance_from_home", ctx); !ok || d != 2224 {
+		t.Errorf("distance_from_home = %v (ok %v), want 2224", d, ok)
+	}
+	if b, ok := a.getFieldValue(state, "bearing_to_home", ctx); !ok || b != 0 {
+		t.Errorf("bearing_to_home = %v (ok %v), want 0", b, ok)
+	}
+
+	state.Home = nil
+	if _, ok := a.getFieldValue(state, "bearing_to_home", ctx); ok {
+		t.Error("bearing_to_home needs a home position")
+	}
+}
+
+func TestAlerter_RegisterField(t *testing.T) {
+	a := New(Config{})
+
+	custom := Field{Name: "roll_deg", Unit: "deg", Value: func(s *models.DroneState, _ FieldContext) (float64, bool) {
+		return s.Attitude.Roll * 180 / math.Pi, true
+	}}
+	if err := a.RegisterField(custom); err != nil {
+		t.Fatalf("RegisterField() error: %v", err)
+	}
+	if err := a.RegisterField(custom); err != ErrFieldExists {
+		t.Errorf("Registering twice: error = %v, want ErrFieldExists", err)
+	}
+	if err := a.RegisterField(Field{Name: "no_value"}); err == nil {
+		t.Error("A field without a value function should be rejected")
+	}
+	if !a.HasField("roll_deg") || a.HasField("no_value") {
+		t.Error("HasField does not match the registered fields")
+	}
+
+	fields := a.Fields()
+	for i := 1; i < len(fields); i++ {
+		if fields[i-1].Name >= fields[i].Name {
+			t.Fatalf("Fields() not sorted: %s before %s", fields[i-1].Name, fields[i].Name)
+		}
+	}
+
+	a.CreateRule(&Rule{
+		ID:        "steep-bank",
+		Type:      AlertTypeCustom,
+		Severity:  SeverityWarning,
+		Enabled:   true,
+		Condition: Condition{Field: "roll_deg", Operator: ">", Threshold: 45},
+	})
+	alerts := a.Evaluate(&models.DroneState{DeviceID: "drone-1", Attitude: models.Attitude{Roll: math.Pi / 3}, Status: models.Status{BatteryPercent: 90, SignalQuality: 90}})
+	if len(alerts) != 1 || alerts[0].RuleID != "steep-bank" || math.Abs(alerts[0].Value-60) > 1e-9 {
+		t.Errorf("Alerts = %+v, want one steep-bank alert at 60 deg", alerts)
+	}
+}
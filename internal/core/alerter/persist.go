@@ -0,0 +1,334 @@
+package alerter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// saveDelay batches the changes written to the alert store
+const saveDelay = 2 * time.Second
+
+// ErrNotOpen is returned when the alerter is not opened on a store
+var ErrNotOpen = errors.New("alert store not open")
+
+// Buckets of the alert store. Values are JSON.
+var (
+	bucketRules    = []byte("rules")
+	bucketAlerts   = []byte("alerts") // Keyed by timestamp then ID, so oldest first
+	bucketSilences = []byte("silences")
+	bucketPolicies = []byte("escalation_policies")
+)
+
+// persister writes the alerter to its store in the background
+type persister struct {
+	db      *bolt.DB
+	changed chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// Open restores the rules, alerts, silences and escalation policies saved in
+// the bbolt store at path, replacing the default rules, and saves every later
+// change there until Close. A missing store is created with the defaults.
+func (a *Alerter) Open(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating alerts directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("opening alerts: %w", err)
+	}
+
+	var stored bool
+	err = db.Update(func(tx *bolt.Tx) error {
+		stored = tx.Bucket(bucketRules) != nil
+		for _, name := range [][]byte{bucketRules, bucketAlerts, bucketSilences, bucketPolicies} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if stored {
+			return a.restore(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("reading alerts: %w", err)
+	}
+
+	p := &persister{
+		db:      db,
+		changed: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if !stored {
+		// Save the default rules, so deleting one later sticks
+		if err := a.sync(db); err != nil {
+			db.Close()
+			return err
+		}
+	}
+	a.mu.Lock()
+	a.persist = p
+	a.mu.Unlock()
+	go a.saveLoop(p)
+	return nil
+}
+
+// restore replaces the rules, alerts, silences and escalation policies with
+// those of the store. Rule cooldowns resume from the restored alerts, so a
+// restart does not raise them again.
+func (a *Alerter) restore(tx *bolt.Tx) error {
+	rules := make(map[string]*Rule)
+	err := tx.Bucket(bucketRules).ForEach(func(k, v []byte) error {
+		var rule Rule
+		if err := json.Unmarshal(v, &rule); err != nil {
+			return fmt.Errorf("rule %s: %w", k, err)
+		}
+		rules[rule.ID] = &rule
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var alerts []Alert
+	err = tx.Bucket(bucketAlerts).ForEach(func(k, v []byte) error {
+		var alert Alert
+		if err := json.Unmarshal(v, &alert); err != nil {
+			return fmt.Errorf("alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	silences := make(map[string]*Silence)
+	err = tx.Bucket(bucketSilences).ForEach(func(k, v []byte) error {
+		var s Silence
+		if err := json.Unmarshal(v, &s); err != nil {
+			return fmt.Errorf("silence %s: %w", k, err)
+		}
+		silences[s.ID] = &s
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	policies := make(map[string]*EscalationPolicy)
+	err = tx.Bucket(bucketPolicies).ForEach(func(k, v []byte) error {
+		var p EscalationPolicy
+		if err := json.Unmarshal(v, &p); err != nil {
+			return fmt.Errorf("escalation policy %s: %w", k, err)
+		}
+		policies[p.ID] = &p
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rules = rules
+	a.silences = silences
+	a.policies = policies
+	if len(alerts) > a.maxAlerts {
+		alerts = alerts[len(alerts)-a.maxAlerts:]
+	}
+	a.alerts = make([]Alert, 0, len(alerts))
+	a.alertsByDevice = make(map[string][]string)
+	for i := range alerts {
+		alert := alerts[i]
+		a.addAlert(&alert)
+		if alert.RuleID == "" {
+			continue
+		}
+		key := alert.RuleID + ":" + alert.DeviceID
+		if alert.Timestamp > a.lastAlertTime[key] {
+			a.lastAlertTime[key] = alert.Timestamp
+		}
+	}
+	return nil
+}
+
+// Close stops saving in the background, writes any pending changes and
+// closes the store
+func (a *Alerter) Close() error {
+	a.mu.Lock()
+	p := a.persist
+	a.persist = nil
+	a.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	close(p.done)
+	<-p.stopped
+	err := a.sync(p.db)
+	if cerr := p.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Backup writes a consistent copy of the store, pending changes included,
+// to w
+func (a *Alerter) Backup(w io.Writer) error {
+	a.mu.RLock()
+	p := a.persist
+	a.mu.RUnlock()
+	if p == nil {
+		return ErrNotOpen
+	}
+
+	if err := a.sync(p.db); err != nil {
+		return err
+	}
+	return p.db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return err
+	})
+}
+
+// changed schedules a save after a change. Caller must hold the lock.
+func (a *Alerter) changed() {
+	if a.persist == nil {
+		return
+	}
+	select {
+	case a.persist.changed <- struct{}{}:
+	default:
+	}
+}
+
+// saveLoop writes to the store at most once per saveDelay while changes
+// keep coming
+func (a *Alerter) saveLoop(p *persister) {
+	defer close(p.stopped)
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-p.changed:
+		}
+
+		select {
+		case <-p.done:
+			return // Close saves
+		case <-time.After(saveDelay):
+		}
+		if err := a.sync(p.db); err != nil {
+			log.Printf("[Alerter] Failed to save alerts: %v", err)
+		}
+	}
+}
+
+// sync brings the store in line with memory in a single transaction,
+// writing only the values that changed and deleting the removed ones
+func (a *Alerter) sync(db *bolt.DB) error {
+	a.mu.RLock()
+	buckets, err := a.encode()
+	a.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encoding alerts: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for name, want := range buckets {
+			b := tx.Bucket([]byte(name))
+			var stale [][]byte
+			err := b.ForEach(func(k, v []byte) error {
+				data, ok := want[string(k)]
+				if !ok {
+					stale = append(stale, bytes.Clone(k))
+				} else if bytes.Equal(data, v) {
+					delete(want, string(k))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, k := range stale {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			for k, data := range want {
+				if err := b.Put([]byte(k), data); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("writing alerts: %w", err)
+	}
+	return nil
+}
+
+// encode returns the JSON values of every bucket by key. Caller must hold
+// the lock.
+func (a *Alerter) encode() (map[string]map[string][]byte, error) {
+	buckets := map[string]map[string][]byte{
+		string(bucketRules):    make(map[string][]byte, len(a.rules)),
+		string(bucketAlerts):   make(map[string][]byte, len(a.alerts)),
+		string(bucketSilences): make(map[string][]byte, len(a.silences)),
+		string(bucketPolicies): make(map[string][]byte, len(a.policies)),
+	}
+	put := func(bucket []byte, key string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buckets[string(bucket)][key] = data
+		return nil
+	}
+
+	for id, rule := range a.rules {
+		if err := put(bucketRules, id, rule); err != nil {
+			return nil, err
+		}
+	}
+	for i := range a.alerts {
+		if err := put(bucketAlerts, alertKey(&a.alerts[i]), &a.alerts[i]); err != nil {
+			return nil, err
+		}
+	}
+	for id, s := range a.silences {
+		if err := put(bucketSilences, id, s); err != nil {
+			return nil, err
+		}
+	}
+	for id, p := range a.policies {
+		if err := put(bucketPolicies, id, p); err != nil {
+			return nil, err
+		}
+	}
+	return buckets, nil
+}
+
+// alertKey orders alerts by time in the store
+func alertKey(alert *Alert) string {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(alert.Timestamp))
+	return string(ts[:]) + alert.ID
+}
@@ -0,0 +1,117 @@
+package alerter
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAlerter_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.db")
+
+	a := New(Config{})
+	if err := a.Open(path); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	a.DeleteRule("default-weak-signal")
+	a.CreateRule(&Rule{ID: "battery-half", Name: "Battery below half", Enabled: true, CooldownMs: 3600000,
+		Condition: Condition{Field: "battery_percent", Operator: "<", Threshold: 50}})
+	state := models.NewDroneState("uav-1", "mavlink")
+	state.Status.BatteryPercent = 40
+	generated := a.Evaluate(state)
+	if len(generated) != 1 {
+		t.Fatalf("Evaluate() raised %d alerts, want 1", len(generated))
+	}
+	a.AcknowledgeAlert(generated[0].ID, "ops")
+	a.CreateEscalationPolicy(&EscalationPolicy{ID: "page", Name: "Page", Enabled: true, AfterMs: 60000, Channels: []string{"ops"}})
+	a.CreateEscalationPolicy(&EscalationPolicy{ID: "gone", Name: "Gone", Enabled: true, Channels: []string{"ops"}})
+	a.DeleteEscalationPolicy("gone")
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	b := New(Config{})
+	if err := b.Open(path); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer b.Close()
+	if _, err := b.GetRule("default-weak-signal"); err != ErrRuleNotFound {
+		t.Error("Deleted default rule should stay deleted")
+	}
+	if _, err := b.GetRule("battery-half"); err != nil {
+		t.Errorf("Created rule was not restored: %v", err)
+	}
+	alert, err := b.GetAlert(generated[0].ID)
+	if err != nil {
+		t.Fatalf("Alert was not restored: %v", err)
+	}
+	if !alert.Acknowledged || alert.AckedBy != "ops" {
+		t.Errorf("Restored alert = %+v, want acknowledged by ops", alert)
+	}
+	if p, err := b.GetEscalationPolicy("page"); err != nil || p.AfterMs != 60000 {
+		t.Errorf("Escalation policy was not restored: %+v, %v", p, err)
+	}
+	if _, err := b.GetEscalationPolicy("gone"); err != ErrPolicyNotFound {
+		t.Error("Deleted escalation policy should stay deleted")
+	}
+	if got := len(b.GetAlerts("uav-1", nil, 0)); got != 1 {
+		t.Errorf("Alerts of uav-1 = %d, want 1", got)
+	}
+
+	// The rule's cooldown carries over the restart
+	if again := b.Evaluate(state); len(again) != 0 {
+		t.Errorf("Restored alerter raised %d alerts during the cooldown", len(again))
+	}
+}
+
+func TestAlerter_OpenMissingFile(t *testing.T) {
+	a := New(Config{})
+	if err := a.Open(filepath.Join(t.TempDir(), "alerts.db")); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer a.Close()
+	if got := len(a.GetRules()); got != 3 {
+		t.Errorf("Rules = %d, want the 3 defaults", got)
+	}
+}
+
+func TestAlerter_OpenInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.db")
+	os.WriteFile(path, []byte("{\"rules\": []}"), 0644)
+	if err := New(Config{}).Open(path); err == nil {
+		t.Error("Open() should fail on a corrupt file")
+	}
+}
+
+func TestAlerter_Backup(t *testing.T) {
+	dir := t.TempDir()
+	a := New(Config{})
+	if err := a.Backup(io.Discard); err != ErrNotOpen {
+		t.Errorf("Backup() error = %v, want ErrNotOpen", err)
+	}
+	if err := a.Open(filepath.Join(dir, "alerts.db")); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer a.Close()
+	a.DeleteRule("default-weak-signal")
+
+	// The copy holds the change before the background save
+	var buf bytes.Buffer
+	if err := a.Backup(&buf); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	path := filepath.Join(dir, "restored.db")
+	os.WriteFile(path, buf.Bytes(), 0644)
+	b := New(Config{})
+	if err := b.Open(path); err != nil {
+		t.Fatalf("Open(backup) error = %v", err)
+	}
+	defer b.Close()
+	if got := len(b.GetRules()); got != 2 {
+		t.Errorf("Restored rules = %d, want 2", got)
+	}
+}
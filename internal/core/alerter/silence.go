@@ -0,0 +1,178 @@
+package alerter
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Silence suppresses alerts during a maintenance window, e.g. a planned
+// test flight. Alerts it matches are not raised at all. Expired silences
+// are removed.
+type Silence struct {
+	ID            string   `json:"id"`
+	DevicePattern string   `json:"device_pattern"`     // Glob of device IDs, e.g. "test-*" (empty = all devices)
+	RuleIDs       []string `json:"rule_ids,omitempty"` // Rules to silence (empty = all alerts, including geofence and conflict alerts)
+	StartsAt      int64    `json:"starts_at"`          // Unix ms (default now)
+	EndsAt        int64    `json:"ends_at"`            // Unix ms
+	Comment       string   `json:"comment,omitempty"`
+	CreatedBy     string   `json:"created_by,omitempty"`
+	CreatedAt     int64    `json:"created_at"`
+}
+
+// Validate checks the pattern and time range
+func (s *Silence) Validate() error {
+	if _, err := path.Match(s.DevicePattern, ""); err != nil {
+		return fmt.Errorf("invalid device_pattern %q", s.DevicePattern)
+	}
+	if s.EndsAt <= s.StartsAt {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// Active reports whether the silence is in effect at the given time
+func (s *Silence) Active(nowMs int64) bool {
+	return nowMs >= s.StartsAt && nowMs < s.EndsAt
+}
+
+// matches reports whether the silence suppresses an alert of a rule (""
+// for alerts not raised by a rule) for a device at the given time. System
+// alerts, which have no device, are never silenced.
+func (s *Silence) matches(ruleID, deviceID string, nowMs int64) bool {
+	if deviceID == "" || !s.Active(nowMs) {
+		return false
+	}
+	if len(s.RuleIDs) > 0 && !slices.Contains(s.RuleIDs, ruleID) {
+		return false
+	}
+	if s.DevicePattern == "" {
+		return true
+	}
+	ok, _ := path.Match(s.DevicePattern, deviceID)
+	return ok
+}
+
+// silenced reports whether an active silence suppresses an alert and drops
+// expired silences. Caller must hold the write lock.
+func (a *Alerter) silenced(ruleID, deviceID string, nowMs int64) bool {
+	a.expireSilences(nowMs)
+	for _, s := range a.silences {
+		if s.matches(ruleID, deviceID, nowMs) {
+			return true
+		}
+	}
+	return false
+}
+
+// expireSilences removes the silences that have ended. Caller must hold the
+// write lock.
+func (a *Alerter) expireSilences(nowMs int64) {
+	for id, s := range a.silences {
+		if s.EndsAt <= nowMs {
+			delete(a.silences, id)
+			a.changed()
+		}
+	}
+}
+
+// Silence management
+
+// GetSilences returns the silences that have not ended, earliest start
+// first
+func (a *Alerter) GetSilences() []*Silence {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.expireSilences(a.now().UnixMilli())
+	silences := make([]*Silence, 0, len(a.silences))
+	for _, s := range a.silences {
+		silences = append(silences, s)
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		if silences[i].StartsAt != silences[j].StartsAt {
+			return silences[i].StartsAt < silences[j].StartsAt
+		}
+		return silences[i].ID < silences[j].ID
+	})
+	return silences
+}
+
+// GetSilence returns a single silence by ID
+func (a *Alerter) GetSilence(id string) (*Silence, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.expireSilences(a.now().UnixMilli())
+	if s, ok := a.silences[id]; ok {
+		return s, nil
+	}
+	return nil, ErrSilenceNotFound
+}
+
+// CreateSilence creates a new silence, starting now unless StartsAt is set
+func (a *Alerter) CreateSilence(s *Silence) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now().UnixMilli()
+	if s.StartsAt == 0 {
+		s.StartsAt = now
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if s.EndsAt <= now {
+		return fmt.Errorf("ends_at must be in the future")
+	}
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	s.CreatedAt = now
+
+	a.silences[s.ID] = s
+	a.changed()
+	return nil
+}
+
+// UpdateSilence updates an existing silence, e.g. to end it early or extend
+// it
+func (a *Alerter) UpdateSilence(s *Silence) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now().UnixMilli()
+	a.expireSilences(now)
+	existing, ok := a.silences[s.ID]
+	if !ok {
+		return ErrSilenceNotFound
+	}
+	if s.StartsAt == 0 {
+		s.StartsAt = existing.StartsAt
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	s.CreatedBy = existing.CreatedBy
+	s.CreatedAt = existing.CreatedAt
+	a.silences[s.ID] = s
+	a.changed()
+	return nil
+}
+
+// DeleteSilence removes a silence
+func (a *Alerter) DeleteSilence(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.silences[id]; !ok {
+		return ErrSilenceNotFound
+	}
+	delete(a.silences, id)
+	a.changed()
+	return nil
+}
@@ -0,0 +1,119 @@
+package alerter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestAlerter_Silences(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	a := New(Config{})
+	a.now = func() time.Time { return now }
+
+	lowBattery := func(deviceID string) *models.DroneState {
+		state := models.NewDroneState(deviceID, "mavlink")
+		state.Status.BatteryPercent = 15
+		state.Status.SignalQuality = 100
+		return state
+	}
+
+	if err := a.CreateSilence(&Silence{
+		DevicePattern: "test-*",
+		RuleIDs:       []string{"default-battery-low"},
+		EndsAt:        now.Add(time.Hour).UnixMilli(),
+		Comment:       "Bench test",
+	}); err != nil {
+		t.Fatalf("CreateSilence() error = %v", err)
+	}
+	if err := a.CreateSilence(&Silence{
+		DevicePattern: "maint-1",
+		EndsAt:        now.Add(2 * time.Hour).UnixMilli(),
+	}); err != nil {
+		t.Fatalf("CreateSilence() error = %v", err)
+	}
+
+	if got := a.Evaluate(lowBattery("test-7")); len(got) != 0 {
+		t.Errorf("Silenced rule raised %d alerts", len(got))
+	}
+	if got := a.Evaluate(lowBattery("prod-1")); len(got) != 1 {
+		t.Errorf("Device outside the pattern raised %d alerts, want 1", len(got))
+	}
+	if got := a.Evaluate(lowBattery("maint-1")); len(got) != 0 {
+		t.Errorf("Device with all alerts silenced raised %d alerts", len(got))
+	}
+	if alert := a.RaiseForDevice(AlertTypeGeofenceBreach, SeverityWarning, "maint-1", "geofence", "left"); alert != nil {
+		t.Error("Geofence alert of a silenced device should be suppressed")
+	}
+	if alert := a.RaiseForDevice(AlertTypeGeofenceBreach, SeverityWarning, "test-7", "geofence", "left"); alert == nil {
+		t.Error("Silence of one rule should not suppress geofence alerts")
+	}
+	if alert := a.Raise(AlertTypePublisherDegraded, SeverityCritical, "mqtt", "down"); alert == nil {
+		t.Error("System alerts should never be silenced")
+	}
+
+	// Silences expire on their own
+	now = now.Add(90 * time.Minute)
+	if got := a.GetSilences(); len(got) != 1 || got[0].DevicePattern != "maint-1" {
+		t.Errorf("GetSilences() = %+v, want only the maint-1 silence", got)
+	}
+	if got := a.Evaluate(lowBattery("test-7")); len(got) != 1 {
+		t.Errorf("Expired silence still suppressed %d alerts", 1-len(got))
+	}
+}
+
+func TestAlerter_SilenceLifecycle(t *testing.T) {
+	a := New(Config{})
+	now := time.Now()
+
+	for _, s := range []*Silence{
+		{DevicePattern: "[", EndsAt: now.Add(time.Hour).UnixMilli()},
+		{StartsAt: now.Add(time.Hour).UnixMilli(), EndsAt: now.UnixMilli()},
+		{StartsAt: now.Add(-2 * time.Hour).UnixMilli(), EndsAt: now.Add(-time.Hour).UnixMilli()},
+	} {
+		if err := a.CreateSilence(s); err == nil {
+			t.Errorf("CreateSilence(%+v) should fail", s)
+		}
+	}
+
+	// A scheduled window does not apply before it starts
+	s := &Silence{StartsAt: now.Add(time.Hour).UnixMilli(), EndsAt: now.Add(2 * time.Hour).UnixMilli(), CreatedBy: "ops"}
+	if err := a.CreateSilence(s); err != nil {
+		t.Fatalf("CreateSilence() error = %v", err)
+	}
+	if a.silenced("", "uav-1", now.UnixMilli()) {
+		t.Error("Silence applied before its start")
+	}
+
+	update := &Silence{ID: s.ID, EndsAt: now.Add(3 * time.Hour).UnixMilli()}
+	if err := a.UpdateSilence(update); err != nil {
+		t.Fatalf("UpdateSilence() error = %v", err)
+	}
+	got, _ := a.GetSilence(s.ID)
+	if got.StartsAt != s.StartsAt || got.CreatedBy != "ops" || got.EndsAt != update.EndsAt {
+		t.Errorf("Updated silence = %+v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "alerts.db")
+	a.Open(path)
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := New(Config{})
+	if err := b.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if _, err := b.GetSilence(s.ID); err != nil {
+		t.Errorf("Silence was not restored: %v", err)
+	}
+
+	if err := b.DeleteSilence(s.ID); err != nil {
+		t.Errorf("DeleteSilence() error = %v", err)
+	}
+	if err := b.DeleteSilence(s.ID); err != ErrSilenceNotFound {
+		t.Errorf("DeleteSilence() twice = %v, want ErrSilenceNotFound", err)
+	}
+}
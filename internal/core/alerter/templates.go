@@ -0,0 +1,153 @@
+package alerter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrTemplateNotFound is returned for an unknown rule template
+var ErrTemplateNotFound = errors.New("rule template not found")
+
+// Template is a preset rule for a common check. Creating a rule from it
+// may override the threshold and the other settings.
+type Template struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Rule        Rule   `json:"rule"`
+}
+
+// templates are the built-in rule templates
+var templates = []Template{
+	{
+		ID:          "battery-low",
+		Description: "Battery below 25%",
+		Rule: Rule{Name: "Low Battery", Type: AlertTypeBatteryLow, Severity: SeverityWarning, CooldownMs: 60000,
+			Condition: Condition{Field: "battery_percent", Operator: "<", Threshold: 25}},
+	},
+	{
+		ID:          "battery-critical",
+		Description: "Battery below 10%, land now",
+		Rule: Rule{Name: "Critical Battery", Type: AlertTypeBatteryLow, Severity: SeverityCritical, CooldownMs: 30000,
+			Condition: Condition{Field: "battery_percent", Operator: "<", Threshold: 10}},
+	},
+	{
+		ID:          "gps-satellites",
+		Description: "Fewer than 6 GNSS satellites in view, position unreliable",
+		Rule: Rule{Name: "Few GPS Satellites", Type: AlertTypeCustom, Severity: SeverityWarning, CooldownMs: 60000,
+			Condition: Condition{Field: "satellites_visible", Operator: "<", Threshold: 6}},
+	},
+	{
+		ID:          "geofence-radius",
+		Description: "More than 500 m from home, a circular geofence for sites without drawn geofences",
+		Rule: Rule{Name: "Outside Home Radius", Type: AlertTypeGeofenceBreach, Severity: SeverityWarning, CooldownMs: 60000,
+			Condition: Condition{Field: "distance_from_home", Operator: ">", Threshold: 500}},
+	},
+	{
+		ID:          "altitude-ceiling",
+		Description: "Above 120 m over the takeoff point, the usual regulatory ceiling",
+		Rule: Rule{Name: "Altitude Ceiling", Type: AlertTypeCustom, Severity: SeverityCritical, CooldownMs: 30000,
+			Condition: Condition{Field: "altitude_baro", Operator: ">", Threshold: 120}},
+	},
+	{
+		ID:          "link-quality",
+		Description: "Link signal quality below 30%",
+		Rule: Rule{Name: "Weak Signal", Type: AlertTypeSignalWeak, Severity: SeverityWarning, CooldownMs: 30000,
+			Condition: Condition{Field: "signal_quality", Operator: "<", Threshold: 30}},
+	},
+	{
+		ID:          "position-stale",
+		Description: "No position for 10 seconds, the link or GNSS is failing",
+		Rule: Rule{Name: "Position Stale", Type: AlertTypeConnectionLost, Severity: SeverityWarning, CooldownMs: 60000,
+			Condition: Condition{Field: "age_of_last_fix", Operator: ">", Threshold: 10}},
+	},
+}
+
+// Templates returns the built-in rule templates
+func Templates() []Template {
+	return append([]Template(nil), templates...)
+}
+
+// RuleFromTemplate returns a new, enabled rule built from a template
+func RuleFromTemplate(id string) (*Rule, error) {
+	for _, t := range templates {
+		if t.ID == id {
+			rule := t.Rule
+			rule.Enabled = true
+			return &rule, nil
+		}
+	}
+	return nil, ErrTemplateNotFound
+}
+
+// RuleSet is the YAML document rules are exported to and imported from
+type RuleSet struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// ExportRules returns all rules as YAML. Rules are ordered by ID, so
+// exports of the same rules compare equal.
+func (a *Alerter) ExportRules() ([]byte, error) {
+	return yaml.Marshal(RuleSet{Rules: a.GetRules()})
+}
+
+// ParseRules reads rules exported by ExportRules
+func ParseRules(data []byte) ([]*Rule, error) {
+	var set RuleSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing rules: %w", err)
+	}
+	for i, rule := range set.Rules {
+		if rule == nil {
+			return nil, fmt.Errorf("rule %d is empty", i)
+		}
+	}
+	return set.Rules, nil
+}
+
+// ImportResult counts the rules changed by an import
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// ImportRules creates the rules with new IDs and replaces those with known
+// ones, in one step. With replace, rules missing from the import are
+// deleted, so every gateway ends up with the same rule set.
+func (a *Alerter) ImportRules(rules []*Rule, replace bool) ImportResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var res ImportResult
+	now := time.Now().UnixMilli()
+	imported := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.ID == "" {
+			rule.ID = uuid.New().String()
+		}
+		if old, ok := a.rules[rule.ID]; ok {
+			rule.CreatedAt = old.CreatedAt
+			res.Updated++
+		} else {
+			rule.CreatedAt = now
+			res.Created++
+		}
+		rule.UpdatedAt = now
+		a.rules[rule.ID] = rule
+		imported[rule.ID] = true
+	}
+	if replace {
+		for id := range a.rules {
+			if !imported[id] {
+				delete(a.rules, id)
+				res.Deleted++
+			}
+		}
+	}
+	a.changed()
+	return res
+}
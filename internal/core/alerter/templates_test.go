@@ -0,0 +1,67 @@
+package alerter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRuleFromTemplate(t *testing.T) {
+	a := New(Config{})
+	for _, tmpl := range Templates() {
+		rule, err := RuleFromTemplate(tmpl.ID)
+		if err != nil {
+			t.Fatalf("RuleFromTemplate(%s) error = %v", tmpl.ID, err)
+		}
+		if !rule.Enabled || rule.Name == "" || !a.HasField(rule.Condition.Field) {
+			t.Errorf("Template %s rule = %+v", tmpl.ID, rule)
+		}
+	}
+
+	// Rules are copies
+	rule, _ := RuleFromTemplate("battery-low")
+	rule.Condition.Threshold = 50
+	if again, _ := RuleFromTemplate("battery-low"); again.Condition.Threshold != 25 {
+		t.Errorf("Template threshold changed to %v", again.Condition.Threshold)
+	}
+
+	if _, err := RuleFromTemplate("battery-empty"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("RuleFromTemplate(unknown) error = %v", err)
+	}
+}
+
+func TestAlerter_ExportImportRules(t *testing.T) {
+	src := New(Config{})
+	rule, _ := RuleFromTemplate("gps-satellites")
+	rule.ID, rule.Channels = "sats", []string{"ops"}
+	src.CreateRule(rule)
+
+	data, err := src.ExportRules()
+	if err != nil {
+		t.Fatalf("ExportRules() error = %v", err)
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	if len(rules) != 4 || rules[3].ID != "sats" || rules[3].Condition != rule.Condition || rules[3].Channels[0] != "ops" || rules[3].CreatedAt != 0 {
+		t.Fatalf("Parsed rules = %+v", rules)
+	}
+
+	// Merged into the defaults, then replacing them
+	dst := New(Config{})
+	dst.DeleteRule("default-weak-signal")
+	if res := dst.ImportRules(rules, false); res != (ImportResult{Created: 2, Updated: 2}) {
+		t.Errorf("ImportRules() = %+v", res)
+	}
+	dst.CreateRule(&Rule{ID: "local", Name: "Local"})
+	if res := dst.ImportRules(rules[3:], true); res != (ImportResult{Updated: 1, Deleted: 4}) {
+		t.Errorf("ImportRules(replace) = %+v", res)
+	}
+	if got := dst.GetRules(); len(got) != 1 || got[0].ID != "sats" || got[0].CreatedAt == 0 {
+		t.Errorf("Rules after replace = %+v", got)
+	}
+
+	if _, err := ParseRules([]byte("rules:\n  - \n")); err == nil {
+		t.Error("ParseRules() accepted an empty rule")
+	}
+}
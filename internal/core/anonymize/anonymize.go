@@ -0,0 +1,78 @@
+// Package anonymize pseudonymizes device and operator identities in data
+// exports, so datasets can be shared without exposing fleet identities.
+// Pseudonyms are stable for a given key, so the same drone has the same
+// pseudonym across track, alert and report exports.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Anonymizer maps real identities to pseudonyms
+type Anonymizer interface {
+	DeviceID(id string) string
+	Operator(name string) string
+}
+
+// HMAC derives pseudonyms with HMAC-SHA256 under a secret key. Without the
+// key, pseudonyms cannot be linked back to the original identities.
+type HMAC struct {
+	key []byte
+}
+
+// NewHMAC creates an anonymizer with the given key
+func NewHMAC(key []byte) *HMAC {
+	return &HMAC{key: key}
+}
+
+// NewRandomHMAC creates an anonymizer with a random key. Pseudonyms are only
+// consistent for the lifetime of the anonymizer.
+func NewRandomHMAC() (*HMAC, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating anonymization key: %w", err)
+	}
+	return NewHMAC(key), nil
+}
+
+// DeviceID returns the pseudonym of a device ID ("" stays "")
+func (h *HMAC) DeviceID(id string) string {
+	if id == "" {
+		return ""
+	}
+	return "dev-" + h.sum("device", id)
+}
+
+// Operator returns the pseudonym of an operator name ("" stays "")
+func (h *HMAC) Operator(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "op-" + h.sum("operator", name)
+}
+
+// sum returns a truncated HMAC of a value, separated by kind so a device and
+// an operator with the same name get unrelated pseudonyms
+func (h *HMAC) sum(kind, value string) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// Text replaces each device ID occurring in free text, such as an alert
+// message, with its pseudonym
+func Text(a Anonymizer, s string, deviceIDs ...string) string {
+	for _, id := range deviceIDs {
+		if id != "" {
+			s = strings.ReplaceAll(s, id, a.DeviceID(id))
+		}
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package anonymize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHMAC_Consistent(t *testing.T) {
+	a := NewHMAC([]byte("secret"))
+	b := NewHMAC([]byte("secret"))
+	other := NewHMAC([]byte("other"))
+
+	id := a.DeviceID("uav-001")
+	if !strings.HasPrefix(id, "dev-") || strings.Contains(id, "uav-001") {
+		t.Errorf("DeviceID = %q, want dev- pseudonym", id)
+	}
+	if b.DeviceID("uav-001") != id {
+		t.Error("Same key should give the same pseudonym")
+	}
+	if other.DeviceID("uav-001") == id {
+		t.Error("Different keys should give different pseudonyms")
+	}
+	if a.DeviceID("uav-002") == id {
+		t.Error("Different devices should give different pseudonyms")
+	}
+	if a.Operator("uav-001") == id || !strings.HasPrefix(a.Operator("alice"), "op-") {
+		t.Errorf("Operator pseudonyms should be separate from device pseudonyms")
+	}
+	if a.DeviceID("") != "" || a.Operator("") != "" {
+		t.Error("Empty identities should stay empty")
+	}
+}
+
+func TestText(t *testing.T) {
+	a := NewHMAC([]byte("secret"))
+	got := Text(a, "Drone uav-001 entered geofence Airport", "uav-001", "")
+	want := "Drone " + a.DeviceID("uav-001") + " entered geofence Airport"
+	if got != want {
+		t.Errorf("Text = %q, want %q", got, want)
+	}
+}
+
+func TestNewRandomHMAC(t *testing.T) {
+	a, err := NewRandomHMAC()
+	if err != nil {
+		t.Fatalf("NewRandomHMAC failed: %v", err)
+	}
+	b, _ := NewRandomHMAC()
+	if a.DeviceID("uav-001") == b.DeviceID("uav-001") {
+		t.Error("Random keys should differ")
+	}
+}
@@ -0,0 +1,167 @@
+// Package archiver writes published telemetry to rotating CSV or Parquet
+// files, for offline analysis pipelines that do not consume the live
+// streams. Files are stored through a backup target, so they can go to a
+// local directory or S3-compatible object storage.
+package archiver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// File formats
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Defaults applied when Config fields are zero
+const (
+	DefaultRotate  = time.Hour
+	DefaultMaxRows = 100000
+)
+
+// maxBufferedFiles is how many files' worth of rows are kept while the
+// store fails; newer rows are dropped beyond that
+const maxBufferedFiles = 10
+
+// Store receives the archive files. backup.Target implementations satisfy
+// it.
+type Store interface {
+	Put(name string, data []byte) error
+	String() string
+}
+
+// Config holds archiver settings
+type Config struct {
+	Format  string        // csv or parquet (default csv)
+	Rotate  time.Duration // Time covered by each file (0 = DefaultRotate)
+	MaxRows int           // Rows that start a new file early (0 = DefaultMaxRows)
+}
+
+// Archiver buffers states and writes them to a new file every Rotate or
+// MaxRows rows
+type Archiver struct {
+	cfg   Config
+	store Store
+	now   func() time.Time
+
+	mu      sync.Mutex
+	rows    []models.DroneState
+	start   time.Time // Receipt of the first buffered row
+	dropped int       // Rows dropped while the store failed, not yet logged
+	full    chan struct{}
+
+	flushMu sync.Mutex // Serializes flushes
+}
+
+// New creates an archiver writing to store
+func New(cfg Config, store Store) (*Archiver, error) {
+	switch cfg.Format {
+	case "":
+		cfg.Format = FormatCSV
+	case FormatCSV, FormatParquet:
+	default:
+		return nil, fmt.Errorf("unknown format %q (want csv or parquet)", cfg.Format)
+	}
+	if cfg.Rotate <= 0 {
+		cfg.Rotate = DefaultRotate
+	}
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = DefaultMaxRows
+	}
+	return &Archiver{cfg: cfg, store: store, now: time.Now, full: make(chan struct{}, 1)}, nil
+}
+
+// Observe buffers a published state
+func (a *Archiver) Observe(state *models.DroneState) {
+	if state == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.rows) >= maxBufferedFiles*a.cfg.MaxRows {
+		a.dropped++
+		return
+	}
+	if len(a.rows) == 0 {
+		a.start = a.now()
+	}
+	a.rows = append(a.rows, *state)
+	if len(a.rows)%a.cfg.MaxRows == 0 {
+		select {
+		case a.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run writes a file every Rotate, or early once MaxRows rows are buffered,
+// until the context is cancelled. Call Flush afterwards to write the rest.
+func (a *Archiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Rotate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-a.full:
+		}
+		if _, err := a.Flush(); err != nil {
+			log.Printf("[Archive] %v", err)
+		}
+	}
+}
+
+// Flush writes the buffered rows to a new file and returns its name, ""
+// if there was nothing to write. On failure the rows stay buffered for the
+// next attempt.
+func (a *Archiver) Flush() (string, error) {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	a.mu.Lock()
+	rows, start, dropped := a.rows, a.start, a.dropped
+	a.rows, a.dropped = nil, 0
+	a.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("[Archive] Dropped %d row(s) while %s was failing", dropped, a.store)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	var data []byte
+	var err error
+	if a.cfg.Format == FormatParquet {
+		data = writeParquet(rows)
+	} else {
+		data, err = writeCSV(rows)
+	}
+	name := FileName(start, a.cfg.Format)
+	if err == nil {
+		err = a.store.Put(name, data)
+	}
+	if err != nil {
+		a.mu.Lock()
+		a.rows = append(rows, a.rows...)
+		a.start = start
+		a.mu.Unlock()
+		return "", fmt.Errorf("storing %s in %s: %w", name, a.store, err)
+	}
+	log.Printf("[Archive] Stored %s in %s (%d rows, %d bytes)", name, a.store, len(rows), len(data))
+	return name, nil
+}
+
+// FileName returns the name of the file whose first row was received at t
+func FileName(t time.Time, format string) string {
+	return "telemetry-" + t.UTC().Format("20060102T150405.000Z") + "." + format
+}
@@ -0,0 +1,315 @@
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// memStore keeps files in memory and fails while err is set
+type memStore struct {
+	files map[string][]byte
+	err   error
+}
+
+func (s *memStore) Put(name string, data []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.files[name] = data
+	return nil
+}
+
+func (s *memStore) String() string { return "memory" }
+
+func testStates() []*models.DroneState {
+	a := models.NewDroneState("uav-1", "mavlink")
+	a.Timestamp = 1700000000000
+	a.Location = models.Location{Lat: 22.5431, Lon: 113.9345, AltGNSS: 120.5}
+	a.Status.Armed = true
+	a.Status.FlightMode = models.FlightModeAuto
+	a.Status.BatteryPercent = 87
+
+	b := models.NewDroneState("dji-7", "dji")
+	b.Timestamp = 1700000001000
+	b.Tenant = "acme"
+	b.Location = models.Location{Lat: -33.86, Lon: 151.21}
+	return []*models.DroneState{a, b}
+}
+
+func TestArchiver_CSV(t *testing.T) {
+	store := &memStore{files: make(map[string][]byte)}
+	a, err := New(Config{}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.now = func() time.Time { return time.UnixMilli(1700000000123) }
+
+	if name, err := a.Flush(); name != "" || err != nil {
+		t.Errorf("Flush() without rows = %q, %v", name, err)
+	}
+	for _, s := range testStates() {
+		a.Observe(s)
+	}
+	name, err := a.Flush()
+	if err != nil || name != "telemetry-20231114T221320.123Z.csv" {
+		t.Fatalf("Flush() = %q, %v", name, err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(store.files[name])).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][0] != "device_id" || len(records[0]) != len(columns) {
+		t.Fatalf("CSV = %v", records)
+	}
+	want := "uav-1,1700000000000,mavlink,,22.5431,113.9345,0,120.5,0,0,0,0,0,0,87,AUTO,true,0"
+	if got := strings.Join(records[1], ","); got != want {
+		t.Errorf("Row = %s, want %s", got, want)
+	}
+
+	// Rows are written once
+	if name, _ := a.Flush(); name != "" {
+		t.Errorf("Second Flush() wrote %s", name)
+	}
+}
+
+func TestArchiver_StoreFailure(t *testing.T) {
+	store := &memStore{files: make(map[string][]byte), err: errors.New("bucket unreachable")}
+	a, _ := New(Config{MaxRows: 1}, store)
+	for _, s := range testStates() {
+		a.Observe(s)
+	}
+	if _, err := a.Flush(); err == nil {
+		t.Fatal("Flush() should report the store error")
+	}
+
+	// Rows are kept up to maxBufferedFiles files
+	for i := 0; i < 2*maxBufferedFiles; i++ {
+		a.Observe(testStates()[0])
+	}
+	if len(a.rows) != maxBufferedFiles || a.dropped != maxBufferedFiles+2 {
+		t.Errorf("Buffered %d rows, dropped %d", len(a.rows), a.dropped)
+	}
+
+	store.err = nil
+	name, err := a.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(store.files[name], []byte("\n")); lines != maxBufferedFiles+1 {
+		t.Errorf("File has %d lines, want the header and %d rows", lines, maxBufferedFiles)
+	}
+}
+
+func TestArchiver_RunRotatesOnMaxRows(t *testing.T) {
+	store := &memStore{files: make(map[string][]byte)}
+	a, _ := New(Config{Format: FormatParquet, MaxRows: 2, Rotate: time.Hour}, store)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(done)
+	}()
+
+	for _, s := range testStates() {
+		a.Observe(s)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		a.flushMu.Lock()
+		n := len(store.files)
+		a.flushMu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("No file written after MaxRows rows")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	for name := range store.files {
+		if !strings.HasSuffix(name, ".parquet") {
+			t.Errorf("File name = %s", name)
+		}
+	}
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	if _, err := New(Config{Format: "xlsx"}, &memStore{}); err == nil {
+		t.Error("New() should reject unknown formats")
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	var rows []models.DroneState
+	for i := 0; i < 20; i++ {
+		for _, s := range testStates() {
+			rows = append(rows, *s)
+		}
+	}
+	data := writeParquet(rows)
+
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatal("Missing PAR1 magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - footerLen
+	r := &thriftReader{data: data[footerStart : len(data)-8]}
+	meta := r.readStruct()
+	if r.err != nil || r.pos != footerLen {
+		t.Fatalf("Footer: %v after %d of %d bytes", r.err, r.pos, footerLen)
+	}
+
+	if meta[1] != int64(1) || meta[3] != int64(len(rows)) {
+		t.Errorf("Version %v, rows %v", meta[1], meta[3])
+	}
+	schema := meta[2].([]any)
+	if len(schema) != len(columns)+1 || schema[0].(map[int16]any)[5] != int64(len(columns)) {
+		t.Fatalf("Schema = %v", schema)
+	}
+	for i, c := range columns {
+		if el := schema[i+1].(map[int16]any); string(el[4].([]byte)) != c.name {
+			t.Errorf("Schema column %d = %s, want %s", i, el[4], c.name)
+		}
+	}
+
+	groups := meta[4].([]any)
+	chunks := groups[0].(map[int16]any)[1].([]any)
+	column := func(name string) []byte {
+		for i, c := range columns {
+			if c.name != name {
+				continue
+			}
+			md := chunks[i].(map[int16]any)[3].(map[int16]any)
+			offset := int(md[9].(int64))
+			page := &thriftReader{data: data[offset:]}
+			header := page.readStruct()
+			if page.err != nil || header[5].(map[int16]any)[1] != int64(len(rows)) {
+				t.Fatalf("Page header of %s = %v, %v", name, header, page.err)
+			}
+			size := int(header[3].(int64))
+			return data[offset+page.pos : offset+page.pos+size]
+		}
+		t.Fatalf("No column %s", name)
+		return nil
+	}
+
+	ids := column("device_id")
+	for i := range rows {
+		n := int(binary.LittleEndian.Uint32(ids))
+		if got := string(ids[4 : 4+n]); got != rows[i].DeviceID {
+			t.Fatalf("device_id[%d] = %s, want %s", i, got, rows[i].DeviceID)
+		}
+		ids = ids[4+n:]
+	}
+	lat := column("lat")
+	if got := math.Float64frombits(binary.LittleEndian.Uint64(lat[8:])); got != -33.86 {
+		t.Errorf("lat[1] = %v", got)
+	}
+	if ts := column("timestamp"); binary.LittleEndian.Uint64(ts) != 1700000000000 {
+		t.Errorf("timestamp[0] = %d", binary.LittleEndian.Uint64(ts))
+	}
+	if armed := column("armed"); len(armed) != 5 || armed[0] != 0x55 {
+		t.Errorf("armed = %x, want every other row set", armed)
+	}
+}
+
+// thriftReader decodes Thrift compact structs generically: fields by ID,
+// integers as int64, binaries as []byte, lists as []any
+type thriftReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.data) {
+		r.err = errors.New("unexpected end")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.err = errors.New("bad varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.err = errors.New("bad varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		if r.pos+n > len(r.data) {
+			r.err = errors.New("binary out of range")
+			return nil
+		}
+		b := r.data[r.pos : r.pos+n]
+		r.pos += n
+		return b
+	case thriftList:
+		header := r.byte()
+		n, elemType := int(header>>4), header&0x0f
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(elemType)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.err = errors.New("unsupported type")
+	return nil
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for r.err == nil {
+		header := r.byte()
+		if header == 0 {
+			break
+		}
+		typ := header & 0x0f
+		if delta := int16(header >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(r.varint())
+		}
+		fields[last] = r.value(typ)
+	}
+	return fields
+}
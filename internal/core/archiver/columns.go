@@ -0,0 +1,81 @@
+package archiver
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// kind is the value type of a column
+type kind int
+
+const (
+	kindString kind = iota
+	kindTimestamp
+	kindInt
+	kindDouble
+	kindBool
+)
+
+// column is one field of the archived rows
+type column struct {
+	name  string
+	kind  kind
+	value func(s *models.DroneState) any // string, int64, int, float64 or bool by kind
+}
+
+// columns are the archived fields, in file order
+var columns = []column{
+	{"device_id", kindString, func(s *models.DroneState) any { return s.DeviceID }},
+	{"timestamp", kindTimestamp, func(s *models.DroneState) any { return s.Timestamp }},
+	{"protocol_source", kindString, func(s *models.DroneState) any { return s.ProtocolSource }},
+	{"tenant", kindString, func(s *models.DroneState) any { return s.Tenant }},
+	{"lat", kindDouble, func(s *models.DroneState) any { return s.Location.Lat }},
+	{"lon", kindDouble, func(s *models.DroneState) any { return s.Location.Lon }},
+	{"alt_baro", kindDouble, func(s *models.DroneState) any { return s.Location.AltBaro }},
+	{"alt_gnss", kindDouble, func(s *models.DroneState) any { return s.Location.AltGNSS }},
+	{"roll", kindDouble, func(s *models.DroneState) any { return s.Attitude.Roll }},
+	{"pitch", kindDouble, func(s *models.DroneState) any { return s.Attitude.Pitch }},
+	{"yaw", kindDouble, func(s *models.DroneState) any { return s.Attitude.Yaw }},
+	{"vx", kindDouble, func(s *models.DroneState) any { return s.Velocity.Vx }},
+	{"vy", kindDouble, func(s *models.DroneState) any { return s.Velocity.Vy }},
+	{"vz", kindDouble, func(s *models.DroneState) any { return s.Velocity.Vz }},
+	{"battery_percent", kindInt, func(s *models.DroneState) any { return s.Status.BatteryPercent }},
+	{"flight_mode", kindString, func(s *models.DroneState) any { return string(s.Status.FlightMode) }},
+	{"armed", kindBool, func(s *models.DroneState) any { return s.Status.Armed }},
+	{"signal_quality", kindInt, func(s *models.DroneState) any { return s.Status.SignalQuality }},
+}
+
+// writeCSV encodes rows as CSV with a header line. Timestamps are Unix ms.
+func writeCSV(rows []models.DroneState) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.name
+	}
+	w.Write(record)
+
+	for i := range rows {
+		for j, c := range columns {
+			switch v := c.value(&rows[i]).(type) {
+			case string:
+				record[j] = v
+			case int64:
+				record[j] = strconv.FormatInt(v, 10)
+			case int:
+				record[j] = strconv.Itoa(v)
+			case float64:
+				record[j] = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				record[j] = strconv.FormatBool(v)
+			}
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,246 @@
+package archiver
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// The Parquet file is written by hand: one row group with one uncompressed,
+// PLAIN encoded data page per column. All columns are required, so pages
+// carry no repetition or definition levels.
+
+const parquetMagic = "PAR1"
+
+// Parquet physical types, converted types and other enums from
+// parquet-format's parquet.thrift
+const (
+	parquetBoolean   = 0
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+
+	parquetRequired     = 0
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+)
+
+// physicalType returns the Parquet type of a column kind
+func physicalType(k kind) int32 {
+	switch k {
+	case kindString:
+		return parquetByteArray
+	case kindTimestamp:
+		return parquetInt64
+	case kindInt:
+		return parquetInt32
+	case kindDouble:
+		return parquetDouble
+	default:
+		return parquetBoolean
+	}
+}
+
+// writeParquet encodes rows as a Parquet file
+func writeParquet(rows []models.DroneState) []byte {
+	buf := []byte(parquetMagic)
+
+	type chunk struct {
+		offset, size int64
+	}
+	chunks := make([]chunk, len(columns))
+	for i, c := range columns {
+		data := encodePlain(c, rows)
+
+		var h thriftWriter
+		h.i32(1, parquetDataPage)
+		h.i32(2, int32(len(data)))
+		h.i32(3, int32(len(data)))
+		h.beginStruct(5) // DataPageHeader
+		h.i32(1, int32(len(rows)))
+		h.i32(2, parquetPlain)
+		h.i32(3, parquetRLE)
+		h.i32(4, parquetRLE)
+		h.endStruct()
+		h.endStruct()
+
+		chunks[i] = chunk{offset: int64(len(buf)), size: int64(len(h.buf) + len(data))}
+		buf = append(buf, h.buf...)
+		buf = append(buf, data...)
+	}
+
+	var m thriftWriter
+	m.i32(1, 1) // Format version
+	m.list(2, thriftStruct, len(columns)+1)
+	m.beginElem()
+	m.binary(4, "schema")
+	m.i32(5, int32(len(columns)))
+	m.endStruct()
+	for _, c := range columns {
+		m.beginElem()
+		m.i32(1, physicalType(c.kind))
+		m.i32(3, parquetRequired)
+		m.binary(4, c.name)
+		switch c.kind {
+		case kindString:
+			m.i32(6, parquetUTF8)
+		case kindTimestamp:
+			m.i32(6, parquetTimestampMillis)
+		}
+		m.endStruct()
+	}
+	m.i64(3, int64(len(rows)))
+
+	var total int64
+	for _, ch := range chunks {
+		total += ch.size
+	}
+	m.list(4, thriftStruct, 1)
+	m.beginElem() // RowGroup
+	m.list(1, thriftStruct, len(columns))
+	for i, c := range columns {
+		m.beginElem() // ColumnChunk
+		m.i64(2, chunks[i].offset)
+		m.beginStruct(3) // ColumnMetaData
+		m.i32(1, physicalType(c.kind))
+		m.list(2, thriftI32, 1)
+		m.appendI32(parquetPlain)
+		m.list(3, thriftBinary, 1)
+		m.appendBinary(c.name)
+		m.i32(4, parquetUncompressed)
+		m.i64(5, int64(len(rows)))
+		m.i64(6, chunks[i].size)
+		m.i64(7, chunks[i].size)
+		m.i64(9, chunks[i].offset)
+		m.endStruct()
+		m.endStruct()
+	}
+	m.i64(2, total)
+	m.i64(3, int64(len(rows)))
+	m.endStruct()
+	m.binary(6, "open-uav-telemetry-bridge")
+	m.endStruct()
+
+	buf = append(buf, m.buf...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(m.buf)))
+	return append(buf, parquetMagic...)
+}
+
+// encodePlain encodes the values of a column in the PLAIN encoding
+func encodePlain(c column, rows []models.DroneState) []byte {
+	var data []byte
+	if c.kind == kindBool {
+		data = make([]byte, (len(rows)+7)/8)
+	}
+	for i := range rows {
+		switch v := c.value(&rows[i]).(type) {
+		case string:
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(v)))
+			data = append(data, v...)
+		case int64:
+			data = binary.LittleEndian.AppendUint64(data, uint64(v))
+		case int:
+			data = binary.LittleEndian.AppendUint32(data, uint32(int32(v)))
+		case float64:
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+		case bool:
+			if v {
+				data[i/8] |= 1 << (i % 8)
+			}
+		}
+	}
+	return data
+}
+
+// Thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes a struct in the Thrift compact protocol, which
+// Parquet uses for page headers and the file footer. The outermost struct
+// is implicit and closed by the final endStruct.
+type thriftWriter struct {
+	buf   []byte
+	stack []int16 // Last field ID of each open struct, innermost last
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	if len(w.stack) == 0 {
+		w.stack = []int16{0}
+	}
+	last := &w.stack[len(w.stack)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.buf = binary.AppendVarint(w.buf, int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.appendI32(v)
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.appendBinary(s)
+}
+
+// list starts a list field; the n elements are appended next
+func (w *thriftWriter) list(id int16, elemType byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xf0|elemType)
+		w.buf = binary.AppendUvarint(w.buf, uint64(n))
+	}
+}
+
+// beginStruct starts a struct field
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.stack = append(w.stack, 0)
+}
+
+// beginElem starts a struct element of a list
+func (w *thriftWriter) beginElem() {
+	if len(w.stack) == 0 {
+		w.stack = []int16{0}
+	}
+	w.stack = append(w.stack, 0)
+}
+
+// endStruct closes the innermost struct
+func (w *thriftWriter) endStruct() {
+	w.buf = append(w.buf, 0)
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+func (w *thriftWriter) appendI32(v int32) {
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *thriftWriter) appendBinary(s string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
@@ -0,0 +1,269 @@
+// Package audit keeps an append-only record of the changes made through the
+// API: who changed which resource, when, and a field-level diff of the
+// change. Entries are appended to a JSON Lines file that is never rewritten,
+// and the most recent ones are kept in memory for queries.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMaxEntries is the number of entries kept in memory when
+// Config.MaxEntries is 0
+const DefaultMaxEntries = 10000
+
+// Action is the kind of change
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionApply   Action = "apply"   // Pending configuration written and applied
+	ActionLockout Action = "lockout" // Logins refused after repeated failures
+)
+
+// Redacted replaces the values of secret fields in diffs
+const Redacted = "[redacted]"
+
+// secretFields are field names whose values are never recorded
+var secretFields = map[string]bool{
+	"key":           true,
+	"hash":          true,
+	"password":      true,
+	"password_hash": true,
+	"secret":        true,
+	"token":         true,
+}
+
+// Change is one changed field. Before is null for added fields and After
+// for removed ones.
+type Change struct {
+	Field  string `json:"field"` // Dotted path, e.g. mqtt.broker
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// Entry is one recorded change
+type Entry struct {
+	ID         string   `json:"id"`
+	Timestamp  int64    `json:"timestamp"` // Unix ms
+	Actor      string   `json:"actor"`     // Username or API key name, "anonymous" without authentication
+	Role       string   `json:"role,omitempty"`
+	Tenant     string   `json:"tenant,omitempty"`
+	RemoteAddr string   `json:"remote_addr,omitempty"`
+	Action     Action   `json:"action"`
+	Resource   string   `json:"resource"` // e.g. config, geofence, alert_rule
+	ResourceID string   `json:"resource_id,omitempty"`
+	Changes    []Change `json:"changes,omitempty"`
+}
+
+// Query selects entries
+type Query struct {
+	Actor      string
+	Resource   string
+	ResourceID string
+	Since      int64 // Unix ms, 0 = no lower bound
+	Until      int64 // Unix ms, 0 = no upper bound
+	Limit      int   // 0 = no limit
+}
+
+// Config holds audit log settings
+type Config struct {
+	Path       string // JSON Lines file entries are appended to ("" = memory only)
+	MaxEntries int    // Entries kept in memory for queries (0 = DefaultMaxEntries)
+}
+
+// Log records changes
+type Log struct {
+	max int
+	now func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File
+	entries []Entry // Oldest first
+}
+
+// Open opens the audit log, loading the most recent entries of an existing
+// file
+func Open(cfg Config) (*Log, error) {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultMaxEntries
+	}
+	l := &Log{max: cfg.MaxEntries, now: time.Now}
+	if cfg.Path == "" {
+		return l, nil
+	}
+
+	if err := l.load(cfg.Path); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+		return nil, fmt.Errorf("creating audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(cfg.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// load reads the entries of an existing file. Unreadable lines, such as a
+// line cut short by a crash, are skipped.
+func (l *Log) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		l.entries = append(l.entries, e)
+		if len(l.entries) > 2*l.max {
+			l.entries = append([]Entry(nil), l.entries[len(l.entries)-l.max:]...)
+		}
+	}
+	if len(l.entries) > l.max {
+		l.entries = append([]Entry(nil), l.entries[len(l.entries)-l.max:]...)
+	}
+	return scanner.Err()
+}
+
+// Record appends an entry, assigning its ID and timestamp. The entry is
+// kept in memory even if writing it to the file fails.
+func (l *Log) Record(e Entry) (Entry, error) {
+	e.ID = uuid.New().String()
+	if e.Timestamp == 0 {
+		e.Timestamp = l.now().UnixMilli()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, e)
+	if len(l.entries) > l.max {
+		l.entries = l.entries[len(l.entries)-l.max:]
+	}
+	if l.file == nil {
+		return e, nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return e, fmt.Errorf("writing audit log: %w", err)
+	}
+	return e, l.file.Sync()
+}
+
+// List returns the matching entries, newest first
+func (l *Log) List(q Query) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]Entry, 0)
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		e := l.entries[i]
+		if (q.Actor != "" && e.Actor != q.Actor) ||
+			(q.Resource != "" && e.Resource != q.Resource) ||
+			(q.ResourceID != "" && e.ResourceID != q.ResourceID) ||
+			(q.Since > 0 && e.Timestamp < q.Since) ||
+			(q.Until > 0 && e.Timestamp > q.Until) {
+			continue
+		}
+		result = append(result, e)
+		if q.Limit > 0 && len(result) >= q.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// Close closes the file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Diff compares two JSON-decoded values field by field and returns the
+// changes, sorted by field. Objects are compared recursively and other
+// values, including arrays, as a whole. Secret fields are redacted.
+func Diff(before, after any) []Change {
+	var changes []Change
+	diff("", before, after, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func diff(field string, before, after any, changes *[]Change) {
+	b, bIsObj := before.(map[string]any)
+	a, aIsObj := after.(map[string]any)
+	if bIsObj || aIsObj {
+		if (before != nil && !bIsObj) || (after != nil && !aIsObj) {
+			// An object replaced by a scalar or the reverse
+			*changes = append(*changes, Change{Field: field, Before: redact(field, before), After: redact(field, after)})
+			return
+		}
+		for k, v := range a {
+			diff(join(field, k), b[k], v, changes)
+		}
+		for k, v := range b {
+			if _, ok := a[k]; !ok {
+				diff(join(field, k), v, nil, changes)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, Change{Field: field, Before: redact(field, before), After: redact(field, after)})
+	}
+}
+
+// join appends a key to a dotted path
+func join(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
+
+// redact hides the value of a secret field, keeping null as null so it is
+// still visible that a secret was set or removed
+func redact(field string, v any) any {
+	if v == nil {
+		return nil
+	}
+	name := field[strings.LastIndexByte(field, '.')+1:]
+	if secretFields[name] {
+		return Redacted
+	}
+	return v
+}
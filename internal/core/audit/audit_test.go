@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLog_RecordAndList(t *testing.T) {
+	l, err := Open(Config{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	l.Record(Entry{Actor: "alice", Action: ActionCreate, Resource: "geofence", ResourceID: "g1", Timestamp: 1000})
+	l.Record(Entry{Actor: "bob", Action: ActionUpdate, Resource: "config", Timestamp: 2000})
+	e, _ := l.Record(Entry{Actor: "alice", Action: ActionDelete, Resource: "geofence", ResourceID: "g1", Timestamp: 3000})
+	if e.ID == "" {
+		t.Error("Record did not assign an ID")
+	}
+
+	all := l.List(Query{})
+	if len(all) != 3 || all[0].Timestamp != 3000 {
+		t.Fatalf("List = %+v, want 3 entries newest first", all)
+	}
+	if got := l.List(Query{Actor: "alice"}); len(got) != 2 {
+		t.Errorf("actor filter returned %d entries, want 2", len(got))
+	}
+	if got := l.List(Query{Resource: "geofence", Since: 2000}); len(got) != 1 || got[0].Action != ActionDelete {
+		t.Errorf("resource/since filter = %+v", got)
+	}
+	if got := l.List(Query{Until: 2000, Limit: 1}); len(got) != 1 || got[0].Actor != "bob" {
+		t.Errorf("until/limit filter = %+v", got)
+	}
+}
+
+func TestLog_PersistsAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	l, err := Open(Config{Path: path, MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for _, actor := range []string{"a", "b", "c"} {
+		if _, err := l.Record(Entry{Actor: actor, Action: ActionUpdate, Resource: "config"}); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	if got := len(l.List(Query{})); got != 2 {
+		t.Errorf("in-memory entries = %d, want 2", got)
+	}
+	l.Close()
+
+	// A truncated last line must not prevent loading
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"id":"broken`)
+	f.Close()
+
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("file has %d lines, want all 3 entries", n)
+	}
+
+	l, err = Open(Config{Path: path, MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer l.Close()
+	got := l.List(Query{})
+	if len(got) != 2 || got[0].Actor != "c" || got[1].Actor != "b" {
+		t.Errorf("reloaded entries = %+v, want c, b", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := map[string]any{
+		"mqtt":   map[string]any{"broker": "tcp://a:1883", "password": "old", "topics": []any{"x"}},
+		"region": "eu",
+		"gone":   1.0,
+	}
+	after := map[string]any{
+		"mqtt":   map[string]any{"broker": "tcp://b:1883", "password": "new", "topics": []any{"x"}},
+		"region": "eu",
+		"added":  true,
+	}
+
+	changes := Diff(before, after)
+	want := []Change{
+		{Field: "added", Before: nil, After: true},
+		{Field: "gone", Before: 1.0, After: nil},
+		{Field: "mqtt.broker", Before: "tcp://a:1883", After: "tcp://b:1883"},
+		{Field: "mqtt.password", Before: Redacted, After: Redacted},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Diff = %+v, want %+v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+
+	// A created resource lists every field as added
+	created := Diff(nil, map[string]any{"id": "g1", "name": "Zone"})
+	if len(created) != 2 || created[0].Field != "id" || created[0].Before != nil {
+		t.Errorf("Diff(nil, obj) = %+v", created)
+	}
+	if changes := Diff(before, before); len(changes) != 0 {
+		t.Errorf("Diff of equal values = %+v, want none", changes)
+	}
+}
@@ -0,0 +1,23 @@
+package core
+
+import (
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// AutopilotSource is implemented by adapters that capture the firmware and
+// hardware details drones report about themselves
+type AutopilotSource interface {
+	AutopilotInfo(deviceID string) (*models.AutopilotInfo, bool)
+}
+
+// AutopilotInfo returns the autopilot details an adapter captured for a device
+func (e *Engine) AutopilotInfo(deviceID string) (*models.AutopilotInfo, bool) {
+	for _, adapter := range e.adapters {
+		if source, ok := adapter.(AutopilotSource); ok {
+			if info, ok := source.AutopilotInfo(deviceID); ok {
+				return info, true
+			}
+		}
+	}
+	return nil, false
+}
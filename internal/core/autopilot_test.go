@@ -0,0 +1,34 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// autopilotAdapter reports firmware details for one device
+type autopilotAdapter struct {
+	fakeAdapter
+	deviceID string
+}
+
+func (a *autopilotAdapter) AutopilotInfo(deviceID string) (*models.AutopilotInfo, bool) {
+	if deviceID != a.deviceID {
+		return nil, false
+	}
+	return &models.AutopilotInfo{Autopilot: "px4", FirmwareVersion: "1.15.0"}, true
+}
+
+func TestEngine_AutopilotInfo(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	e.RegisterAdapter(&fakeAdapter{name: "dji"})
+	e.RegisterAdapter(&autopilotAdapter{fakeAdapter: fakeAdapter{name: "mavlink"}, deviceID: "mavlink-1"})
+
+	info, ok := e.AutopilotInfo("mavlink-1")
+	if !ok || info.FirmwareVersion != "1.15.0" {
+		t.Errorf("AutopilotInfo() = %+v, %v", info, ok)
+	}
+	if _, ok := e.AutopilotInfo("dji-1"); ok {
+		t.Error("AutopilotInfo() should report unknown devices as missing")
+	}
+}
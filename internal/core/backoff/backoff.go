@@ -0,0 +1,70 @@
+// Package backoff provides exponential retry delays for reconnect loops
+package backoff
+
+import "time"
+
+// Default delays
+const (
+	DefaultInitial = 1 * time.Second
+	DefaultMax     = 60 * time.Second
+)
+
+// Backoff produces exponentially growing delays capped at Max.
+// It is not safe for concurrent use.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+
+	attempts int
+	current  time.Duration
+}
+
+// New creates a backoff doubling from initial up to max.
+// Non-positive values fall back to the defaults.
+func New(initial, max time.Duration) *Backoff {
+	if initial <= 0 {
+		initial = DefaultInitial
+	}
+	if max <= 0 {
+		max = DefaultMax
+	}
+	if max < initial {
+		max = initial
+	}
+	return &Backoff{
+		Initial:    initial,
+		Max:        max,
+		Multiplier: 2,
+	}
+}
+
+// FromMs creates a backoff from millisecond settings
+func FromMs(initialMs, maxMs int) *Backoff {
+	return New(time.Duration(initialMs)*time.Millisecond, time.Duration(maxMs)*time.Millisecond)
+}
+
+// Next returns the delay before the next attempt and advances the backoff
+func (b *Backoff) Next() time.Duration {
+	if b.current == 0 {
+		b.current = b.Initial
+	} else {
+		b.current = time.Duration(float64(b.current) * b.Multiplier)
+		if b.current > b.Max {
+			b.current = b.Max
+		}
+	}
+	b.attempts++
+	return b.current
+}
+
+// Reset returns the backoff to its initial delay
+func (b *Backoff) Reset() {
+	b.attempts = 0
+	b.current = 0
+}
+
+// Attempts returns the number of delays handed out since the last reset
+func (b *Backoff) Attempts() int {
+	return b.attempts
+}
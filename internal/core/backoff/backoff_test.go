@@ -0,0 +1,60 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff_Next(t *testing.T) {
+	b := New(100*time.Millisecond, 500*time.Millisecond)
+
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		500 * time.Millisecond,
+		500 * time.Millisecond,
+	}
+	for i, w := range want {
+		if got := b.Next(); got != w {
+			t.Errorf("Next() #%d = %v, want %v", i+1, got, w)
+		}
+	}
+	if b.Attempts() != len(want) {
+		t.Errorf("Attempts() = %d, want %d", b.Attempts(), len(want))
+	}
+}
+
+func TestBackoff_Reset(t *testing.T) {
+	b := New(time.Second, time.Minute)
+	b.Next()
+	b.Next()
+	b.Reset()
+
+	if b.Attempts() != 0 {
+		t.Errorf("Attempts() after Reset = %d, want 0", b.Attempts())
+	}
+	if got := b.Next(); got != time.Second {
+		t.Errorf("Next() after Reset = %v, want 1s", got)
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	b := New(0, 0)
+	if b.Initial != DefaultInitial || b.Max != DefaultMax {
+		t.Errorf("defaults = %v/%v, want %v/%v", b.Initial, b.Max, DefaultInitial, DefaultMax)
+	}
+
+	// Max below initial is raised to initial
+	b = New(10*time.Second, time.Second)
+	if b.Max != 10*time.Second {
+		t.Errorf("Max = %v, want 10s", b.Max)
+	}
+}
+
+func TestFromMs(t *testing.T) {
+	b := FromMs(250, 1000)
+	if b.Initial != 250*time.Millisecond || b.Max != time.Second {
+		t.Errorf("FromMs = %v/%v, want 250ms/1s", b.Initial, b.Max)
+	}
+}
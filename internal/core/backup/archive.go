@@ -0,0 +1,245 @@
+// Package backup snapshots the gateway's configuration and state into
+// compressed archives on a cron schedule, stores them locally or in S3 with
+// count-based retention, and restores them on a rebuilt unit.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Entry kinds
+const (
+	KindFile     = "file"     // Restored to the configured path of its name
+	KindSnapshot = "snapshot" // Restored to the restore directory and imported on the next start
+)
+
+// Limits on the members of an archive, far above what a gateway backs up,
+// so a corrupt or tampered archive cannot exhaust memory
+const (
+	maxMemberSize  = 256 << 20
+	maxArchiveSize = 1 << 30
+)
+
+// manifestName is the first member of every archive
+const manifestName = "manifest.json"
+
+// archivePrefix and archiveSuffix frame archive names; the timestamp
+// between them sorts chronologically
+const (
+	archivePrefix = "outb-backup-"
+	archiveSuffix = ".tar.gz"
+)
+
+var (
+	// ErrInvalidArchive is returned for archives without a valid manifest
+	ErrInvalidArchive = errors.New("invalid backup archive")
+	// ErrFileExists is returned when a restore would overwrite files
+	ErrFileExists = errors.New("file exists")
+)
+
+// Entry is one file or snapshot in an archive
+type Entry struct {
+	Name string `json:"name"`           // Path within the archive
+	Kind string `json:"kind"`           // KindFile or KindSnapshot
+	Path string `json:"path,omitempty"` // Original path of file entries, informational only
+	Size int64  `json:"size"`
+	Data []byte `json:"-"`
+}
+
+// Manifest describes an archive
+type Manifest struct {
+	Version    string  `json:"version"`     // Gateway version that wrote the archive
+	CreatedAt  int64   `json:"created_at"`  // Unix ms
+	RestoreDir string  `json:"restore_dir"` // Restore directory of the gateway that wrote the archive
+	Entries    []Entry `json:"entries"`
+}
+
+// ArchiveName returns the archive name for a backup taken at t
+func ArchiveName(t time.Time) string {
+	return archivePrefix + t.UTC().Format("20060102T150405Z") + archiveSuffix
+}
+
+// isArchiveName reports whether name was produced by ArchiveName
+func isArchiveName(name string) bool {
+	return strings.HasPrefix(name, archivePrefix) && strings.HasSuffix(name, archiveSuffix)
+}
+
+// WriteArchive writes the manifest and its entries as a gzipped tarball
+func WriteArchive(w io.Writer, m *Manifest) error {
+	for i := range m.Entries {
+		m.Entries[i].Size = int64(len(m.Entries[i].Data))
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	modTime := time.UnixMilli(m.CreatedAt)
+	write := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := write(manifestName, manifest); err != nil {
+		return err
+	}
+	for _, e := range m.Entries {
+		if err := write(e.Name, e.Data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadArchive reads an archive written by WriteArchive
+func ReadArchive(r io.Reader) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+
+	var m *Manifest
+	var total int64
+	members := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		total += hdr.Size
+		if hdr.Size > maxMemberSize || total > maxArchiveSize {
+			return nil, fmt.Errorf("%w: %s too large", ErrInvalidArchive, hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		if hdr.Name == manifestName {
+			m = &Manifest{}
+			if err := json.Unmarshal(data, m); err != nil {
+				return nil, fmt.Errorf("%w: manifest: %v", ErrInvalidArchive, err)
+			}
+			continue
+		}
+		members[hdr.Name] = data
+	}
+	if m == nil {
+		return nil, fmt.Errorf("%w: no manifest", ErrInvalidArchive)
+	}
+
+	for i, e := range m.Entries {
+		data, ok := members[e.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, e.Name)
+		}
+		m.Entries[i].Data = data
+	}
+	return m, nil
+}
+
+// Targets are the paths a restore writes to, taken from the current
+// configuration rather than the archive
+type Targets struct {
+	Files       map[string]string // Path of each file entry, by entry name
+	SnapshotDir string            // Directory of snapshot entries
+}
+
+// Restore writes file entries to their target paths and snapshot entries
+// to the snapshot directory, returning the paths written. Unless force is
+// set, nothing is written if any file entry already exists.
+func Restore(m *Manifest, t Targets, force bool) ([]string, error) {
+	targets := make([]string, len(m.Entries))
+	for i, e := range m.Entries {
+		if !filepath.IsLocal(e.Name) {
+			return nil, fmt.Errorf("%w: invalid entry name %q", ErrInvalidArchive, e.Name)
+		}
+		switch e.Kind {
+		case KindFile:
+			targets[i] = t.Files[e.Name]
+		case KindSnapshot:
+			if t.SnapshotDir != "" {
+				targets[i] = filepath.Join(t.SnapshotDir, filepath.Base(e.Name))
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown kind %q for %s", ErrInvalidArchive, e.Kind, e.Name)
+		}
+		if targets[i] == "" {
+			return nil, fmt.Errorf("%w: no path configured for %s", ErrInvalidArchive, e.Name)
+		}
+		if e.Kind == KindFile && !force {
+			if _, err := os.Stat(targets[i]); err == nil {
+				return nil, fmt.Errorf("%w: %s", ErrFileExists, targets[i])
+			}
+		}
+	}
+
+	for i, e := range m.Entries {
+		if err := writeFile(targets[i], e.Data); err != nil {
+			return targets[:i], err
+		}
+	}
+	return targets, nil
+}
+
+// writeFile atomically replaces a file, creating its directory
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", path, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadSnapshots reads the snapshots restored to dir, keyed by file name.
+// A missing directory has no snapshots.
+func LoadSnapshots(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[string][]byte)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		snapshots[e.Name()] = data
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchive_RoundTripAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "configs", "config.yaml")
+	m := &Manifest{
+		Version:    "test",
+		CreatedAt:  1700000000000,
+		RestoreDir: filepath.Join(dir, "restore"),
+		Entries: []Entry{
+			{Name: "files/config.yaml", Kind: KindFile, Path: configPath, Data: []byte("mqtt:\n  enabled: true\n")},
+			{Name: "snapshots/geofences.json", Kind: KindSnapshot, Data: []byte(`[{"id":"gf-1"}]`)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, m); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+	got, err := ReadArchive(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadArchive() error = %v", err)
+	}
+	if got.Version != "test" || len(got.Entries) != 2 || got.Entries[0].Size != int64(len(m.Entries[0].Data)) {
+		t.Errorf("ReadArchive() manifest = %+v", got)
+	}
+
+	targets := Targets{
+		Files:       map[string]string{"files/config.yaml": configPath},
+		SnapshotDir: m.RestoreDir,
+	}
+	paths, err := Restore(got, targets, false)
+	if err != nil || len(paths) != 2 {
+		t.Fatalf("Restore() = %v, %v", paths, err)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != "mqtt:\n  enabled: true\n" {
+		t.Errorf("Restored config = %q", data)
+	}
+	snapshots, err := LoadSnapshots(m.RestoreDir)
+	if err != nil || string(snapshots["geofences.json"]) != `[{"id":"gf-1"}]` {
+		t.Errorf("LoadSnapshots() = %v, %v", snapshots, err)
+	}
+
+	if _, err := Restore(got, targets, false); !errors.Is(err, ErrFileExists) {
+		t.Errorf("Restore() over existing files error = %v, want ErrFileExists", err)
+	}
+	if _, err := Restore(got, targets, true); err != nil {
+		t.Errorf("Restore(force) error = %v", err)
+	}
+
+	if _, err := ReadArchive(bytes.NewReader([]byte("not a tarball"))); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("ReadArchive(garbage) error = %v, want ErrInvalidArchive", err)
+	}
+	if snapshots, err := LoadSnapshots(filepath.Join(dir, "missing")); err != nil || snapshots != nil {
+		t.Errorf("LoadSnapshots(missing) = %v, %v", snapshots, err)
+	}
+}
+
+func TestArchive_Tampered(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	targets := Targets{
+		Files:       map[string]string{"files/config.yaml": configPath},
+		SnapshotDir: filepath.Join(dir, "restore"),
+	}
+
+	// Paths recorded in the archive are ignored
+	outside := filepath.Join(t.TempDir(), "outside")
+	m := &Manifest{
+		RestoreDir: outside,
+		Entries:    []Entry{{Name: "files/config.yaml", Kind: KindFile, Path: outside, Data: []byte("x")}},
+	}
+	if paths, err := Restore(m, targets, false); err != nil || len(paths) != 1 || paths[0] != configPath {
+		t.Errorf("Restore() = %v, %v, want %s", paths, err, configPath)
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("Restore() wrote the archive's path %s", outside)
+	}
+
+	for _, e := range []Entry{
+		{Name: "files/cron", Kind: KindFile, Path: outside},
+		{Name: "../snapshots/geofences.json", Kind: KindSnapshot},
+		{Name: "/etc/geofences.json", Kind: KindSnapshot},
+	} {
+		m := &Manifest{Entries: []Entry{e}}
+		if _, err := Restore(m, targets, true); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("Restore(%s) error = %v, want ErrInvalidArchive", e.Name, err)
+		}
+	}
+
+	// Oversized members are refused from their header
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "snapshots/tracks.json", Mode: 0600, Size: maxMemberSize + 1})
+	gz.Close()
+	if _, err := ReadArchive(&buf); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("ReadArchive(oversized) error = %v, want ErrInvalidArchive", err)
+	}
+}
@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Collector gathers the entries of a backup
+type Collector func() (*Manifest, error)
+
+// Runner takes backups. The job scheduler runs it on the configured
+// schedule.
+type Runner struct {
+	target  Target
+	keep    int
+	collect Collector
+}
+
+// NewRunner creates a runner keeping the newest keep archives (0 = keep all)
+func NewRunner(target Target, keep int, collect Collector) *Runner {
+	return &Runner{target: target, keep: keep, collect: collect}
+}
+
+// Run takes a backup now, stores it and prunes old archives. Returns the
+// archive name.
+func (s *Runner) Run() (string, error) {
+	now := time.Now()
+	m, err := s.collect()
+	if err != nil {
+		return "", fmt.Errorf("collecting backup: %w", err)
+	}
+	m.CreatedAt = now.UnixMilli()
+
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, m); err != nil {
+		return "", fmt.Errorf("writing archive: %w", err)
+	}
+	name := ArchiveName(now)
+	if err := s.target.Put(name, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("storing %s: %w", name, err)
+	}
+	log.Printf("[Backup] Stored %s in %s (%d entries, %d bytes)", name, s.target, len(m.Entries), buf.Len())
+
+	deleted, err := prune(s.target, s.keep)
+	if len(deleted) > 0 {
+		log.Printf("[Backup] Removed %d old archive(s)", len(deleted))
+	}
+	if err != nil {
+		log.Printf("[Backup] Pruning %s failed: %v", s.target, err)
+	}
+	return name, nil
+}
@@ -0,0 +1,46 @@
+package backup
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRunner_RunAndPrune(t *testing.T) {
+	target := &LocalTarget{Dir: t.TempDir()}
+	for _, ts := range []string{"20260101T030000Z", "20260102T030000Z", "20260103T030000Z"} {
+		target.Put(archivePrefix+ts+archiveSuffix, []byte("old"))
+	}
+	target.Put("notes.txt", []byte("not an archive"))
+
+	s := NewRunner(target, 2, func() (*Manifest, error) {
+		return &Manifest{Version: "test", Entries: []Entry{{Name: "snapshots/rules.json", Kind: KindSnapshot, Data: []byte("[]")}}}, nil
+	})
+
+	name, err := s.Run()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	names, _ := target.List()
+	if len(names) != 2 || names[0] != archivePrefix+"20260103T030000Z"+archiveSuffix || names[1] != name {
+		t.Errorf("Archives after prune = %v, want the newest old one and %s", names, name)
+	}
+
+	data, err := target.Get(name)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	m, err := ReadArchive(bytes.NewReader(data))
+	if err != nil || m.CreatedAt == 0 || time.Since(time.UnixMilli(m.CreatedAt)) > time.Minute {
+		t.Errorf("Stored archive = %+v, %v", m, err)
+	}
+}
+
+func mustTime(t *testing.T, date string) time.Time {
+	t.Helper()
+	ts, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
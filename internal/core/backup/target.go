@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrArchiveNotFound is returned when a target has no archive by that name
+var ErrArchiveNotFound = errors.New("backup archive not found")
+
+// Target stores archives
+type Target interface {
+	Put(name string, data []byte) error
+	Get(name string) ([]byte, error)
+	List() ([]string, error) // Archive names, oldest first
+	Delete(name string) error
+	String() string
+}
+
+// LocalTarget stores archives in a directory
+type LocalTarget struct {
+	Dir string
+}
+
+// Put writes an archive
+func (t *LocalTarget) Put(name string, data []byte) error {
+	return writeFile(filepath.Join(t.Dir, name), data)
+}
+
+// Get reads an archive
+func (t *LocalTarget) Get(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(t.Dir, filepath.Base(name)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrArchiveNotFound, name)
+	}
+	return data, err
+}
+
+// List returns the archives in the directory, oldest first
+func (t *LocalTarget) List() ([]string, error) {
+	entries, err := os.ReadDir(t.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && isArchiveName(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete removes an archive
+func (t *LocalTarget) Delete(name string) error {
+	return os.Remove(filepath.Join(t.Dir, filepath.Base(name)))
+}
+
+func (t *LocalTarget) String() string {
+	return t.Dir
+}
+
+// prune deletes all but the newest keep archives and returns the names
+// deleted. keep <= 0 keeps everything.
+func prune(t Target, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	names, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) <= keep {
+		return nil, nil
+	}
+
+	var deleted []string
+	for _, name := range names[:len(names)-keep] {
+		if err := t.Delete(name); err != nil {
+			return deleted, fmt.Errorf("deleting %s: %w", name, err)
+		}
+		deleted = append(deleted, name)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Batching defaults
+const (
+	DefaultBatchMaxSize    = 100
+	DefaultBatchMaxLatency = 100 * time.Millisecond
+)
+
+// batcher collects the states routed to one BatchPublisher and sends them
+// once maxSize states are pending or the oldest has waited maxLatency
+type batcher struct {
+	maxSize    int
+	maxLatency time.Duration
+	send       func(states []*models.DroneState)
+
+	sendMu  sync.Mutex // Keeps batches in order
+	mu      sync.Mutex
+	pending []*models.DroneState
+	timer   *time.Timer
+
+	received  uint64
+	highWater int
+}
+
+// newBatcher creates a batcher, applying the defaults for zero limits
+func newBatcher(maxSize int, maxLatency time.Duration, send func(states []*models.DroneState)) *batcher {
+	if maxSize <= 0 {
+		maxSize = DefaultBatchMaxSize
+	}
+	if maxLatency <= 0 {
+		maxLatency = DefaultBatchMaxLatency
+	}
+	return &batcher{maxSize: maxSize, maxLatency: maxLatency, send: send}
+}
+
+// add queues a state. A full batch is sent by the caller, so a slow
+// publisher slows down the engine instead of piling up batches.
+func (b *batcher) add(state *models.DroneState) {
+	b.mu.Lock()
+	b.pending = append(b.pending, state)
+	b.received++
+	b.highWater = max(b.highWater, len(b.pending))
+	if len(b.pending) == 1 {
+		b.timer = time.AfterFunc(b.maxLatency, b.flush)
+	}
+	full := len(b.pending) >= b.maxSize
+	b.mu.Unlock()
+
+	if full {
+		b.flush()
+	}
+}
+
+// flush sends the pending states, if any
+func (b *batcher) flush() {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	b.mu.Lock()
+	states := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(states) > 0 {
+		b.send(states)
+	}
+}
+
+// stats returns the pending states of the batcher
+func (b *batcher) stats(stage string) QueueStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return QueueStats{
+		Stage:     stage,
+		Capacity:  b.maxSize,
+		Depth:     len(b.pending),
+		HighWater: b.highWater,
+		Received:  b.received,
+	}
+}
+
+// newPublisherBatcher creates the batcher of a publisher. Each batch counts
+// as one publish in the publisher's health, and a failed batch reports a
+// publisher error for each of its states.
+func (e *Engine) newPublisherBatcher(pub Publisher, bp BatchPublisher) *batcher {
+	return newBatcher(e.batchSize, e.batchLatency, func(states []*models.DroneState) {
+		e.injectPublisherDelay(pub.Name())
+		err := bp.PublishBatch(states)
+		e.health.record(pub.Name(), err, time.Now())
+		if err == nil {
+			return
+		}
+		for _, state := range states {
+			e.bus.Publish(events.Event{
+				Type:     events.PublisherError,
+				DeviceID: state.DeviceID,
+				Source:   pub.Name(),
+				Error:    err.Error(),
+			})
+		}
+	})
+}
+
+// flushBatches sends the states pending for all batch publishers
+func (e *Engine) flushBatches() {
+	for _, b := range e.batchers {
+		b.flush()
+	}
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// batchPublisher records the batches it receives
+type batchPublisher struct {
+	recordingPublisher
+	err error
+
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (p *batchPublisher) PublishBatch(states []*models.DroneState) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var ids []string
+	for _, s := range states {
+		ids = append(ids, s.DeviceID)
+	}
+	p.batches = append(p.batches, ids)
+	return p.err
+}
+
+func (p *batchPublisher) received() [][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]string(nil), p.batches...)
+}
+
+func TestEngine_BatchBySize(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1, Batching: true, BatchMaxSize: 3, BatchMaxLatency: time.Hour})
+	pub := &batchPublisher{recordingPublisher: recordingPublisher{name: "mqtt"}}
+	plain := &recordingPublisher{name: "plain"}
+	e.RegisterPublisher(pub)
+	e.RegisterPublisher(plain)
+
+	for i := 0; i < 4; i++ {
+		e.processState(models.NewDroneState(fmt.Sprintf("uav-%d", i), "sim"))
+	}
+
+	batches := pub.received()
+	if len(batches) != 1 || fmt.Sprint(batches[0]) != "[uav-0 uav-1 uav-2]" {
+		t.Fatalf("Batches = %v, want one batch of the first 3 states", batches)
+	}
+	if len(pub.states) != 0 {
+		t.Errorf("Publish called for batched publisher: %v", pub.states)
+	}
+	if len(plain.states) != 4 {
+		t.Errorf("Publisher without batch support received %v, want every state", plain.states)
+	}
+	if h := e.GetPublisherHealth()[0]; h.TotalPublished != 1 {
+		t.Errorf("TotalPublished = %d, want 1 batch", h.TotalPublished)
+	}
+
+	// Stopping sends the rest
+	e.Stop()
+	if batches := pub.received(); len(batches) != 2 || fmt.Sprint(batches[1]) != "[uav-3]" {
+		t.Errorf("Batches after Stop = %v, want the pending state sent", batches)
+	}
+}
+
+func TestEngine_BatchByLatency(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1, Batching: true, BatchMaxSize: 100, BatchMaxLatency: 20 * time.Millisecond})
+	pub := &batchPublisher{recordingPublisher: recordingPublisher{name: "redis"}}
+	e.RegisterPublisher(pub)
+
+	e.processState(models.NewDroneState("uav-1", "sim"))
+	e.processState(models.NewDroneState("uav-2", "sim"))
+	if batches := pub.received(); len(batches) != 0 {
+		t.Fatalf("Batch sent before the latency elapsed: %v", batches)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(pub.received()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if batches := pub.received(); len(batches) != 1 || len(batches[0]) != 2 {
+		t.Errorf("Batches = %v, want both states in one batch", batches)
+	}
+}
+
+func TestEngine_BatchErrorsAndTopics(t *testing.T) {
+	e := NewEngine(EngineConfig{
+		RateHz:       1,
+		Batching:     true,
+		BatchMaxSize: 2,
+		RoutingRules: []routing.Rule{
+			{Name: "fleet-a", Enabled: true, Publishers: []string{"mqtt"}, DevicePrefix: "fleet-a-", Topic: "fleet-a/{device_id}"},
+			{Name: "uavs", Enabled: true, Publishers: []string{"mqtt"}, DevicePrefix: "uav-"},
+		},
+	})
+	pub := &batchPublisher{recordingPublisher: recordingPublisher{name: "mqtt"}, err: errors.New("broker down")}
+	e.RegisterPublisher(pub)
+
+	var failed []string
+	e.Events().Subscribe("test", func(ev events.Event) {
+		if ev.Type == events.PublisherError {
+			failed = append(failed, ev.DeviceID)
+		}
+	}, events.PublisherError)
+
+	// A topic override is sent on its own
+	e.processState(models.NewDroneState("fleet-a-1", "sim"))
+	if len(pub.topics) != 1 || len(pub.received()) != 0 {
+		t.Errorf("Routed state: topics %v, batches %v", pub.topics, pub.received())
+	}
+
+	e.processState(models.NewDroneState("uav-1", "sim"))
+	e.processState(models.NewDroneState("uav-2", "sim"))
+	if fmt.Sprint(failed) != "[uav-1 uav-2]" {
+		t.Errorf("PublisherError events for %v, want each state of the failed batch", failed)
+	}
+	if h := e.GetPublisherHealth()[0]; h.LastError != "broker down" {
+		t.Errorf("Health = %+v, want the batch error recorded", h)
+	}
+}
+
+func TestEngine_BatchingDisabled(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	pub := &batchPublisher{recordingPublisher: recordingPublisher{name: "mqtt"}}
+	e.RegisterPublisher(pub)
+
+	e.processState(models.NewDroneState("uav-1", "sim"))
+	if len(pub.states) != 1 || len(pub.received()) != 0 {
+		t.Errorf("Without batching: Publish %v, batches %v", pub.states, pub.received())
+	}
+}
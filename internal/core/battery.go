@@ -0,0 +1,90 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// dischargeSmoothing is the weight of the newest discharge rate sample in
+// the per-device moving average
+const dischargeSmoothing = 0.3
+
+// batteryModel estimates how long each drone's battery lasts. Battery
+// levels are whole percents, so a discharge rate sample is taken each time
+// the level drops, over the time since the previous drop, and smoothed per
+// device. The first drop only marks the start of the next sample. A rising
+// level or another battery pack starts over.
+type batteryModel struct {
+	mu      sync.Mutex
+	devices map[string]*deviceBattery
+}
+
+// deviceBattery is the discharge state of one device
+type deviceBattery struct {
+	serial    string
+	percent   int
+	changedAt int64   // Unix ms the level last dropped, 0 before the first drop
+	seenAt    int64   // Unix ms of the last battery sample
+	rate      float64 // Smoothed discharge rate in percent per minute, 0 until the first drop
+}
+
+func newBatteryModel() *batteryModel {
+	return &batteryModel{devices: make(map[string]*deviceBattery)}
+}
+
+// apply updates the device's discharge rate from a state and sets the
+// state's estimated endurance
+func (m *batteryModel) apply(state *models.DroneState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state.Status.EstimatedEnduranceMin = nil
+	at := state.Freshness.BatteryAt
+	if state.Freshness.IsZero() {
+		at = state.Timestamp
+	}
+	if at == 0 {
+		return
+	}
+
+	d, ok := m.devices[state.DeviceID]
+	percent := state.Status.BatteryPercent
+	switch {
+	case !ok || percent > d.percent || state.Status.BatterySerial != d.serial:
+		d = &deviceBattery{serial: state.Status.BatterySerial, percent: percent, seenAt: at}
+		m.devices[state.DeviceID] = d
+	case at > d.seenAt:
+		d.seenAt = at
+		if percent < d.percent {
+			if d.changedAt != 0 {
+				sample := float64(d.percent-percent) / (float64(at-d.changedAt) / 60000)
+				if d.rate == 0 {
+					d.rate = sample
+				} else {
+					d.rate += dischargeSmoothing * (sample - d.rate)
+				}
+			}
+			d.percent, d.changedAt = percent, at
+		}
+	}
+
+	if d.rate == 0 {
+		return
+	}
+	// Without a drop for longer than one percent takes at the average rate,
+	// the rate is at most one percent over that time
+	rate := d.rate
+	if elapsed := float64(d.seenAt-d.changedAt) / 60000; elapsed*rate > 1 {
+		rate = 1 / elapsed
+	}
+	endurance := float64(percent) / rate
+	state.Status.EstimatedEnduranceMin = &endurance
+}
+
+// forget drops the discharge state of a device
+func (m *batteryModel) forget(deviceID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.devices, deviceID)
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestBatteryModel_Endurance(t *testing.T) {
+	m := newBatteryModel()
+	state := &models.DroneState{DeviceID: "drone-1"}
+	sample := func(minutes float64, percent int) *float64 {
+		state.Timestamp = 1700000000000 + int64(minutes*60000)
+		state.Status.BatteryPercent = percent
+		m.apply(state)
+		return state.Status.EstimatedEnduranceMin
+	}
+
+	// The first drop only starts the first sample
+	if got := sample(0, 80); got != nil {
+		t.Fatalf("Endurance = %v, want none before any drop", *got)
+	}
+	if got := sample(0.5, 79); got != nil {
+		t.Fatalf("Endurance = %v, want none after one drop", *got)
+	}
+
+	// 1% per minute
+	if got := sample(1.5, 78); got == nil || math.Abs(*got-78) > 1e-9 {
+		t.Fatalf("Endurance = %v, want 78 min", got)
+	}
+	// 2% per minute is smoothed in
+	if got := sample(2, 77); got == nil || math.Abs(*got-77/1.3) > 1e-9 {
+		t.Errorf("Endurance = %v, want %v min", *got, 77/1.3)
+	}
+
+	// No drop for 4 minutes caps the rate at 1% per 4 minutes
+	if got := sample(6, 77); got == nil || math.Abs(*got-77*4) > 1e-9 {
+		t.Errorf("Endurance = %v, want %v min while the level holds", *got, 77*4)
+	}
+
+	// A fresh battery starts over
+	if got := sample(7, 100); got != nil {
+		t.Errorf("Endurance = %v, want none after a battery swap", *got)
+	}
+
+	m.forget("drone-1")
+	if len(m.devices) != 0 {
+		t.Error("forget should drop the discharge state")
+	}
+}
+
+func TestBatteryModel_PartialStates(t *testing.T) {
+	m := newBatteryModel()
+	state := &models.DroneState{DeviceID: "drone-1"}
+	for i, percent := range []int{90, 89, 88} {
+		state.Timestamp = 1700000000000 + int64(i)*60000
+		state.Freshness.BatteryAt = state.Timestamp
+		state.Status.BatteryPercent = percent
+		m.apply(state)
+	}
+	if state.Status.EstimatedEnduranceMin == nil || *state.Status.EstimatedEnduranceMin != 88 {
+		t.Fatalf("Endurance = %v, want 88 min", state.Status.EstimatedEnduranceMin)
+	}
+
+	// A position update 30 s later does not carry a battery sample
+	state.Timestamp += 30000
+	state.Freshness.PositionAt = state.Timestamp
+	m.apply(state)
+	if got := state.Status.EstimatedEnduranceMin; got == nil || *got != 88 {
+		t.Errorf("Endurance = %v, want 88 min", got)
+	}
+}
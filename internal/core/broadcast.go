@@ -0,0 +1,36 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/broadcast"
+)
+
+var (
+	// ErrPublisherNotFound is returned for an unknown publisher name
+	ErrPublisherNotFound = errors.New("publisher not found")
+	// ErrBroadcastUnsupported is returned for publishers that cannot forward broadcasts
+	ErrBroadcastUnsupported = errors.New("publisher does not support broadcasts")
+)
+
+// BroadcastPublisher is optionally implemented by publishers that can
+// forward operator broadcast messages northbound
+type BroadcastPublisher interface {
+	PublishBroadcast(msg *broadcast.Message) error
+}
+
+// PublishBroadcast forwards an operator broadcast through the named publisher
+func (e *Engine) PublishBroadcast(publisher string, msg *broadcast.Message) error {
+	for _, pub := range e.publishers {
+		if pub.Name() != publisher {
+			continue
+		}
+		bp, ok := pub.(BroadcastPublisher)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrBroadcastUnsupported, publisher)
+		}
+		return bp.PublishBroadcast(msg)
+	}
+	return fmt.Errorf("%w: %s", ErrPublisherNotFound, publisher)
+}
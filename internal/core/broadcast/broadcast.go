@@ -0,0 +1,145 @@
+// Package broadcast keeps operator messages that are pushed to every
+// connected UI, e.g. "Range closing in 10 minutes", until they expire
+package broadcast
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+)
+
+// Limits
+const (
+	DefaultTTL       = time.Hour
+	MaxTTL           = 7 * 24 * time.Hour
+	MaxMessageLength = 500
+	MaxActive        = 100
+)
+
+var (
+	// ErrInvalidMessage is returned for an empty, oversized or badly formed message
+	ErrInvalidMessage = errors.New("invalid broadcast message")
+	// ErrNotFound is returned for unknown message IDs
+	ErrNotFound = errors.New("broadcast message not found")
+)
+
+// Message is an operator broadcast
+type Message struct {
+	ID        string                `json:"id"`
+	Text      string                `json:"message"`
+	Severity  alerter.AlertSeverity `json:"severity"`
+	Author    string                `json:"author,omitempty"`
+	CreatedAt int64                 `json:"created_at"` // Unix ms
+	ExpiresAt int64                 `json:"expires_at"` // Unix ms
+}
+
+// Expired reports whether the message has expired at the given time
+func (m *Message) Expired(now time.Time) bool {
+	return now.UnixMilli() >= m.ExpiresAt
+}
+
+// Store holds the active broadcasts
+type Store struct {
+	mu       sync.Mutex
+	messages map[string]*Message
+	now      func() time.Time
+}
+
+// NewStore creates an empty broadcast store
+func NewStore() *Store {
+	return &Store{
+		messages: make(map[string]*Message),
+		now:      time.Now,
+	}
+}
+
+// Add validates and stores a message. An empty severity defaults to info and
+// a zero ttl to DefaultTTL.
+func (s *Store) Add(text string, severity alerter.AlertSeverity, author string, ttl time.Duration) (*Message, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return nil, fmt.Errorf("%w: message is required", ErrInvalidMessage)
+	case len(text) > MaxMessageLength:
+		return nil, fmt.Errorf("%w: message must be at most %d characters", ErrInvalidMessage, MaxMessageLength)
+	case ttl < 0 || ttl > MaxTTL:
+		return nil, fmt.Errorf("%w: ttl must be between 0 and %s", ErrInvalidMessage, MaxTTL)
+	}
+	if severity == "" {
+		severity = alerter.SeverityInfo
+	}
+	if severity != alerter.SeverityInfo && severity != alerter.SeverityWarning && severity != alerter.SeverityCritical {
+		return nil, fmt.Errorf("%w: severity must be info, warning or critical", ErrInvalidMessage)
+	}
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.pruneLocked(now)
+	if len(s.messages) >= MaxActive {
+		return nil, fmt.Errorf("%w: at most %d active broadcasts", ErrInvalidMessage, MaxActive)
+	}
+
+	msg := &Message{
+		ID:        uuid.New().String(),
+		Text:      text,
+		Severity:  severity,
+		Author:    author,
+		CreatedAt: now.UnixMilli(),
+		ExpiresAt: now.Add(ttl).UnixMilli(),
+	}
+	s.messages[msg.ID] = msg
+	return msg, nil
+}
+
+// Active returns the unexpired messages, oldest first
+func (s *Store) Active() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(s.now())
+	result := make([]*Message, 0, len(s.messages))
+	for _, msg := range s.messages {
+		result = append(result, msg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt != result[j].CreatedAt {
+			return result[i].CreatedAt < result[j].CreatedAt
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Delete withdraws a message before it expires
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(s.now())
+	if _, ok := s.messages[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.messages, id)
+	return nil
+}
+
+// pruneLocked removes expired messages. Caller must hold the lock.
+func (s *Store) pruneLocked(now time.Time) {
+	for id, msg := range s.messages {
+		if msg.Expired(now) {
+			delete(s.messages, id)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package broadcast
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+)
+
+func TestStore_AddDefaults(t *testing.T) {
+	s := NewStore()
+	msg, err := s.Add("  Range closing in 10 minutes ", "", "ops", 0)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if msg.Text != "Range closing in 10 minutes" {
+		t.Errorf("Text = %q, want trimmed", msg.Text)
+	}
+	if msg.Severity != alerter.SeverityInfo {
+		t.Errorf("Severity = %q, want info", msg.Severity)
+	}
+	if got := time.Duration(msg.ExpiresAt-msg.CreatedAt) * time.Millisecond; got != DefaultTTL {
+		t.Errorf("TTL = %v, want %v", got, DefaultTTL)
+	}
+}
+
+func TestStore_AddValidation(t *testing.T) {
+	s := NewStore()
+	tests := []struct {
+		name     string
+		text     string
+		severity alerter.AlertSeverity
+		ttl      time.Duration
+	}{
+		{"empty", " ", "", 0},
+		{"too long", strings.Repeat("x", MaxMessageLength+1), "", 0},
+		{"bad severity", "hello", "urgent", 0},
+		{"negative ttl", "hello", "", -time.Second},
+		{"ttl too long", "hello", "", MaxTTL + time.Second},
+	}
+	for _, tt := range tests {
+		if _, err := s.Add(tt.text, tt.severity, "", tt.ttl); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("%s: error = %v, want ErrInvalidMessage", tt.name, err)
+		}
+	}
+}
+
+func TestStore_ExpiryAndDelete(t *testing.T) {
+	s := NewStore()
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+
+	short, _ := s.Add("short", alerter.SeverityWarning, "", time.Minute)
+	long, _ := s.Add("long", alerter.SeverityCritical, "", time.Hour)
+	if got := len(s.Active()); got != 2 {
+		t.Fatalf("Active() = %d messages, want 2", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	active := s.Active()
+	if len(active) != 1 || active[0].ID != long.ID {
+		t.Errorf("Active() after expiry = %v, want only %s", active, long.ID)
+	}
+	if err := s.Delete(short.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete(expired) error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Delete(long.ID); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if got := len(s.Active()); got != 0 {
+		t.Errorf("Active() after delete = %d messages, want 0", got)
+	}
+}
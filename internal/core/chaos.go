@@ -0,0 +1,57 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/chaos"
+)
+
+// ErrAdapterNotFound is returned for an unknown adapter name
+var ErrAdapterNotFound = errors.New("adapter not found")
+
+// Chaos returns the engine's fault injector. It is nil unless the binary
+// was built with the "chaos" build tag.
+func (e *Engine) Chaos() *chaos.Injector {
+	return e.chaos
+}
+
+// ReconnectAdapter stops and restarts an adapter, as if its connection had
+// dropped. Only available with fault injection compiled in.
+func (e *Engine) ReconnectAdapter(name string) error {
+	if e.chaos == nil {
+		return errors.New("fault injection not enabled")
+	}
+	if e.ctx == nil {
+		return errors.New("engine not started")
+	}
+
+	for _, adapter := range e.adapters {
+		if adapter.Name() != name {
+			continue
+		}
+
+		e.reconnectMu.Lock()
+		defer e.reconnectMu.Unlock()
+
+		log.Printf("[Chaos] Forcing reconnect of adapter %s", name)
+		if err := adapter.Stop(); err != nil {
+			log.Printf("[Chaos] Error stopping adapter %s: %v", name, err)
+		}
+		if err := adapter.Start(e.ctx, e.startIntake(e.ctx, name)); err != nil {
+			return fmt.Errorf("restarting adapter %s: %w", name, err)
+		}
+		e.chaos.RecordReconnect()
+		return nil
+	}
+	return ErrAdapterNotFound
+}
+
+// injectPublisherDelay sleeps for the configured publisher delay, if any
+func (e *Engine) injectPublisherDelay(publisher string) {
+	if d := e.chaos.PublisherDelay(publisher); d > 0 {
+		time.Sleep(d)
+	}
+}
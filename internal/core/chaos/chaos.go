@@ -0,0 +1,138 @@
+// Package chaos provides fault injection for verifying HA and failover
+// behavior in staging. It is only active in binaries built with the "chaos"
+// build tag (make build-chaos); in normal builds New returns nil and every
+// hook is a no-op.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// MaxDelay is the longest publisher delay that can be injected
+const MaxDelay = 60 * time.Second
+
+// ErrInvalidSettings is returned for out-of-range settings
+var ErrInvalidSettings = errors.New("invalid chaos settings")
+
+// Settings are the active faults
+type Settings struct {
+	DropPercent      float64        `json:"drop_percent"`                 // Percent of adapter events dropped (0-100)
+	PublisherDelayMs map[string]int `json:"publisher_delay_ms,omitempty"` // Delay before publishing, per publisher name ("*" = all)
+}
+
+// Stats counts injected faults
+type Stats struct {
+	EventsDropped     uint64 `json:"events_dropped"`
+	PublishesDelayed  uint64 `json:"publishes_delayed"`
+	AdapterReconnects uint64 `json:"adapter_reconnects"`
+}
+
+// Injector holds the fault settings. A nil Injector injects nothing.
+type Injector struct {
+	mu       sync.Mutex
+	settings Settings
+	stats    Stats
+	rng      *rand.Rand
+}
+
+// New returns an injector, or nil if fault injection is not compiled in
+func New() *Injector {
+	if !Enabled {
+		return nil
+	}
+	return newInjector(time.Now().UnixNano())
+}
+
+func newInjector(seed int64) *Injector {
+	return &Injector{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Settings returns the active faults
+func (i *Injector) Settings() Settings {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	s := i.settings
+	if s.PublisherDelayMs != nil {
+		s.PublisherDelayMs = make(map[string]int, len(i.settings.PublisherDelayMs))
+		for k, v := range i.settings.PublisherDelayMs {
+			s.PublisherDelayMs[k] = v
+		}
+	}
+	return s
+}
+
+// SetSettings replaces the active faults. The zero Settings clears them.
+func (i *Injector) SetSettings(s Settings) error {
+	if s.DropPercent < 0 || s.DropPercent > 100 {
+		return fmt.Errorf("%w: drop_percent must be between 0 and 100", ErrInvalidSettings)
+	}
+	delays := make(map[string]int, len(s.PublisherDelayMs))
+	for name, ms := range s.PublisherDelayMs {
+		if ms < 0 || time.Duration(ms)*time.Millisecond > MaxDelay {
+			return fmt.Errorf("%w: publisher_delay_ms must be between 0 and %d", ErrInvalidSettings, MaxDelay.Milliseconds())
+		}
+		delays[name] = ms
+	}
+	s.PublisherDelayMs = delays
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.settings = s
+	return nil
+}
+
+// Stats returns the injected fault counters
+func (i *Injector) Stats() Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stats
+}
+
+// DropEvent reports whether an adapter event should be dropped
+func (i *Injector) DropEvent() bool {
+	if i == nil {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.settings.DropPercent <= 0 || i.rng.Float64()*100 >= i.settings.DropPercent {
+		return false
+	}
+	i.stats.EventsDropped++
+	return true
+}
+
+// PublisherDelay returns how long to delay a publish to the named publisher
+func (i *Injector) PublisherDelay(publisher string) time.Duration {
+	if i == nil {
+		return 0
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ms, ok := i.settings.PublisherDelayMs[publisher]
+	if !ok {
+		ms = i.settings.PublisherDelayMs["*"]
+	}
+	if ms <= 0 {
+		return 0
+	}
+	i.stats.PublishesDelayed++
+	return time.Duration(ms) * time.Millisecond
+}
+
+// RecordReconnect counts a forced adapter reconnect
+func (i *Injector) RecordReconnect() {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.stats.AdapterReconnects++
+}
@@ -0,0 +1,109 @@
+package chaos
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	if !Enabled && New() != nil {
+		t.Error("New() should return nil without the chaos build tag")
+	}
+	if Enabled && New() == nil {
+		t.Error("New() should return an injector with the chaos build tag")
+	}
+}
+
+func TestInjector_NilIsNoop(t *testing.T) {
+	var i *Injector
+	if i.DropEvent() {
+		t.Error("nil injector dropped an event")
+	}
+	if d := i.PublisherDelay("mqtt"); d != 0 {
+		t.Errorf("nil injector delay = %v, want 0", d)
+	}
+	i.RecordReconnect()
+}
+
+func TestInjector_DropEvent(t *testing.T) {
+	i := newInjector(1)
+	for n := 0; n < 100; n++ {
+		if i.DropEvent() {
+			t.Fatal("DropEvent() = true with no faults configured")
+		}
+	}
+
+	if err := i.SetSettings(Settings{DropPercent: 100}); err != nil {
+		t.Fatalf("SetSettings() error = %v", err)
+	}
+	for n := 0; n < 100; n++ {
+		if !i.DropEvent() {
+			t.Fatal("DropEvent() = false with drop_percent 100")
+		}
+	}
+
+	if err := i.SetSettings(Settings{DropPercent: 50}); err != nil {
+		t.Fatalf("SetSettings() error = %v", err)
+	}
+	dropped := 0
+	for n := 0; n < 1000; n++ {
+		if i.DropEvent() {
+			dropped++
+		}
+	}
+	if dropped < 400 || dropped > 600 {
+		t.Errorf("Dropped %d of 1000 events at 50%%", dropped)
+	}
+
+	if got := i.Stats().EventsDropped; got != uint64(100+dropped) {
+		t.Errorf("EventsDropped = %d, want %d", got, 100+dropped)
+	}
+}
+
+func TestInjector_PublisherDelay(t *testing.T) {
+	i := newInjector(1)
+	err := i.SetSettings(Settings{PublisherDelayMs: map[string]int{"mqtt": 250, "*": 10, "gb28181": 0}})
+	if err != nil {
+		t.Fatalf("SetSettings() error = %v", err)
+	}
+
+	tests := []struct {
+		publisher string
+		want      time.Duration
+	}{
+		{"mqtt", 250 * time.Millisecond},
+		{"other", 10 * time.Millisecond},
+		{"gb28181", 0},
+	}
+	for _, tt := range tests {
+		if got := i.PublisherDelay(tt.publisher); got != tt.want {
+			t.Errorf("PublisherDelay(%q) = %v, want %v", tt.publisher, got, tt.want)
+		}
+	}
+	if got := i.Stats().PublishesDelayed; got != 2 {
+		t.Errorf("PublishesDelayed = %d, want 2", got)
+	}
+}
+
+func TestInjector_SetSettingsValidation(t *testing.T) {
+	i := newInjector(1)
+	invalid := []Settings{
+		{DropPercent: -1},
+		{DropPercent: 101},
+		{PublisherDelayMs: map[string]int{"mqtt": -5}},
+		{PublisherDelayMs: map[string]int{"mqtt": int(MaxDelay.Milliseconds()) + 1}},
+	}
+	for _, s := range invalid {
+		if err := i.SetSettings(s); !errors.Is(err, ErrInvalidSettings) {
+			t.Errorf("SetSettings(%+v) error = %v, want ErrInvalidSettings", s, err)
+		}
+	}
+
+	// Settings returns a copy
+	i.SetSettings(Settings{PublisherDelayMs: map[string]int{"mqtt": 5}})
+	i.Settings().PublisherDelayMs["mqtt"] = 500
+	if got := i.Settings().PublisherDelayMs["mqtt"]; got != 5 {
+		t.Errorf("Settings() not copied, delay = %d", got)
+	}
+}
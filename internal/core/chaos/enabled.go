@@ -0,0 +1,6 @@
+//go:build chaos
+
+package chaos
+
+// Enabled reports whether fault injection is compiled in (build tag "chaos")
+const Enabled = true
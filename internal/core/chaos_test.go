@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/chaos"
+)
+
+func TestEngine_ChaosDisabledByDefault(t *testing.T) {
+	if chaos.Enabled {
+		t.Skip("built with the chaos tag")
+	}
+
+	e := NewEngine(EngineConfig{RateHz: 1})
+	if e.Chaos() != nil {
+		t.Error("Chaos() should be nil without the chaos build tag")
+	}
+	if err := e.ReconnectAdapter("mavlink"); err == nil {
+		t.Error("ReconnectAdapter() should fail without the chaos build tag")
+	}
+}
+
+func TestEngine_ReconnectAdapter(t *testing.T) {
+	if !chaos.Enabled {
+		t.Skip("requires the chaos build tag")
+	}
+
+	e := NewEngine(EngineConfig{RateHz: 1})
+	e.RegisterAdapter(&fakeAdapter{name: "mavlink"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := e.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() {
+		cancel()
+		e.Stop()
+	}()
+
+	if err := e.ReconnectAdapter("mavlink"); err != nil {
+		t.Fatalf("ReconnectAdapter() error = %v", err)
+	}
+	if err := e.ReconnectAdapter("missing"); !errors.Is(err, ErrAdapterNotFound) {
+		t.Errorf("ReconnectAdapter(missing) error = %v, want ErrAdapterNotFound", err)
+	}
+	if got := e.Chaos().Stats().AdapterReconnects; got != 1 {
+		t.Errorf("AdapterReconnects = %d, want 1", got)
+	}
+}
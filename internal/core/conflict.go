@@ -0,0 +1,31 @@
+package core
+
+import (
+	"log"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/conflict"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Conflicts returns the duplicate device ID detector
+func (e *Engine) Conflicts() *conflict.Detector {
+	return e.conflicts
+}
+
+// checkConflict records the state's protocol source, publishes a
+// DeviceConflict event for new conflicts and applies the device's
+// resolution. It reports whether the state should be processed.
+func (e *Engine) checkConflict(state *models.DroneState) bool {
+	keep, detected := e.conflicts.Check(state, time.Now())
+	if detected != nil {
+		log.Printf("[Engine] Device ID %s claimed by multiple sources: %v", detected.DeviceID, detected.Sources)
+		e.bus.Publish(events.Event{
+			Type:     events.DeviceConflict,
+			DeviceID: detected.DeviceID,
+			Conflict: detected,
+		})
+	}
+	return keep
+}
@@ -0,0 +1,222 @@
+// Package conflict detects device IDs that are claimed by more than one
+// protocol source at the same time (e.g. a MAVLink sysid and a DJI forwarder
+// both reporting "drone-001") and applies operator-chosen resolutions.
+package conflict
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Resolution actions
+const (
+	ActionSuffix = "suffix" // Append "-<source>" to the device ID for every source
+	ActionRename = "rename" // Rename the states of one source to NewID
+	ActionPrefer = "prefer" // Keep the states of one source and drop the others
+)
+
+var (
+	// ErrInvalidResolution is returned for an incomplete or unknown resolution
+	ErrInvalidResolution = errors.New("invalid conflict resolution")
+	// ErrNotFound is returned for device IDs without a conflict or resolution
+	ErrNotFound = errors.New("conflict not found")
+)
+
+// Resolution tells the detector how to handle states for a conflicting ID
+type Resolution struct {
+	Action    string `json:"action"`
+	Source    string `json:"source,omitempty"` // rename: source to rename; prefer: source to keep
+	NewID     string `json:"new_id,omitempty"` // rename: new device ID
+	UpdatedAt int64  `json:"updated_at"`       // Unix ms
+}
+
+// Validate checks that the resolution is complete
+func (r Resolution) Validate(deviceID string) error {
+	switch r.Action {
+	case ActionSuffix:
+		return nil
+	case ActionRename:
+		if r.Source == "" || r.NewID == "" {
+			return fmt.Errorf("%w: rename needs source and new_id", ErrInvalidResolution)
+		}
+		if r.NewID == deviceID {
+			return fmt.Errorf("%w: new_id must differ from the device ID", ErrInvalidResolution)
+		}
+		return nil
+	case ActionPrefer:
+		if r.Source == "" {
+			return fmt.Errorf("%w: prefer needs source", ErrInvalidResolution)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: action must be suffix, rename or prefer", ErrInvalidResolution)
+	}
+}
+
+// Conflict is a device ID seen from more than one protocol source
+type Conflict struct {
+	DeviceID   string      `json:"device_id"`
+	Sources    []string    `json:"sources"`
+	DetectedAt int64       `json:"detected_at"`  // Unix ms
+	LastSeenAt int64       `json:"last_seen_at"` // Unix ms of the last conflicting state
+	Resolution *Resolution `json:"resolution,omitempty"`
+}
+
+// Detector tracks the protocol sources of every device ID. Two sources
+// conflict when both send a state for the same ID within the window.
+type Detector struct {
+	window time.Duration
+
+	mu          sync.Mutex
+	lastSeen    map[string]map[string]time.Time // Device ID -> source -> last state
+	conflicts   map[string]*Conflict
+	resolutions map[string]Resolution
+}
+
+// New creates a detector with the given conflict window
+func New(window time.Duration) *Detector {
+	return &Detector{
+		window:      window,
+		lastSeen:    make(map[string]map[string]time.Time),
+		conflicts:   make(map[string]*Conflict),
+		resolutions: make(map[string]Resolution),
+	}
+}
+
+// Check records a state and applies the resolution for its device ID, which
+// may rename the state. It reports whether the state should be kept and
+// returns the conflict if this state revealed a new one.
+func (d *Detector) Check(state *models.DroneState, now time.Time) (bool, *Conflict) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id, source := state.DeviceID, state.ProtocolSource
+	seen := d.lastSeen[id]
+	if seen == nil {
+		seen = make(map[string]time.Time)
+		d.lastSeen[id] = seen
+	}
+	seen[source] = now
+
+	var detected *Conflict
+	if active := d.activeSources(seen, now); len(active) > 1 {
+		c, ok := d.conflicts[id]
+		if !ok {
+			c = &Conflict{DeviceID: id, DetectedAt: now.UnixMilli()}
+			d.conflicts[id] = c
+		}
+		c.Sources = mergeSources(c.Sources, active)
+		c.LastSeenAt = now.UnixMilli()
+		if !ok {
+			detected = d.copyLocked(c)
+		}
+	}
+
+	res, ok := d.resolutions[id]
+	if !ok {
+		return true, detected
+	}
+	switch res.Action {
+	case ActionSuffix:
+		state.DeviceID = id + "-" + source
+	case ActionRename:
+		if source == res.Source {
+			state.DeviceID = res.NewID
+		}
+	case ActionPrefer:
+		if source != res.Source {
+			return false, detected
+		}
+	}
+	return true, detected
+}
+
+// activeSources returns the sources seen within the window, sorted
+func (d *Detector) activeSources(seen map[string]time.Time, now time.Time) []string {
+	var active []string
+	for source, t := range seen {
+		if now.Sub(t) <= d.window {
+			active = append(active, source)
+		}
+	}
+	sort.Strings(active)
+	return active
+}
+
+// List returns all conflicts sorted by device ID
+func (d *Detector) List() []*Conflict {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := make([]*Conflict, 0, len(d.conflicts))
+	for _, c := range d.conflicts {
+		result = append(result, d.copyLocked(c))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].DeviceID < result[j].DeviceID })
+	return result
+}
+
+// SetResolution sets how states for a device ID are handled. A resolution
+// may be set before a conflict is detected; it is listed once the conflict
+// is.
+func (d *Detector) SetResolution(deviceID string, res Resolution) (Resolution, error) {
+	if err := res.Validate(deviceID); err != nil {
+		return Resolution{}, err
+	}
+	res.UpdatedAt = time.Now().UnixMilli()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resolutions[deviceID] = res
+	return res, nil
+}
+
+// Dismiss removes a conflict and its resolution. The conflict is reported
+// again if both sources keep sending.
+func (d *Detector) Dismiss(deviceID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, hasConflict := d.conflicts[deviceID]
+	_, hasResolution := d.resolutions[deviceID]
+	if !hasConflict && !hasResolution {
+		return ErrNotFound
+	}
+	delete(d.conflicts, deviceID)
+	delete(d.resolutions, deviceID)
+	delete(d.lastSeen, deviceID)
+	return nil
+}
+
+// copyLocked returns a copy of a conflict with its resolution. Caller must
+// hold the lock.
+func (d *Detector) copyLocked(c *Conflict) *Conflict {
+	cp := *c
+	cp.Sources = append([]string(nil), c.Sources...)
+	if res, ok := d.resolutions[c.DeviceID]; ok {
+		cp.Resolution = &res
+	}
+	return &cp
+}
+
+// mergeSources returns the sorted union of two source lists
+func mergeSources(a, b []string) []string {
+	set := make(map[string]bool, len(a)+len(b))
+	for _, s := range a {
+		set[s] = true
+	}
+	for _, s := range b {
+		set[s] = true
+	}
+	result := make([]string, 0, len(set))
+	for s := range set {
+		result = append(result, s)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,97 @@
+package conflict
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestDetector_Detect(t *testing.T) {
+	d := New(30 * time.Second)
+	now := time.Unix(1700000000, 0)
+
+	if _, c := d.Check(models.NewDroneState("drone-001", "mavlink"), now); c != nil {
+		t.Fatal("A single source should not conflict")
+	}
+	keep, c := d.Check(models.NewDroneState("drone-001", "dji"), now.Add(time.Second))
+	if !keep || c == nil {
+		t.Fatalf("Check() = %v, %v, want kept state and a new conflict", keep, c)
+	}
+	if len(c.Sources) != 2 || c.Sources[0] != "dji" || c.Sources[1] != "mavlink" {
+		t.Errorf("Sources = %v, want [dji mavlink]", c.Sources)
+	}
+
+	// Already reported
+	if _, c := d.Check(models.NewDroneState("drone-001", "mavlink"), now.Add(2*time.Second)); c != nil {
+		t.Error("An existing conflict should not be reported again")
+	}
+
+	// Sources outside the window do not conflict
+	if _, c := d.Check(models.NewDroneState("drone-002", "mavlink"), now); c != nil {
+		t.Error("Unexpected conflict")
+	}
+	if _, c := d.Check(models.NewDroneState("drone-002", "dji"), now.Add(time.Minute)); c != nil {
+		t.Error("Sources a minute apart should not conflict")
+	}
+
+	if got := d.List(); len(got) != 1 || got[0].DeviceID != "drone-001" {
+		t.Errorf("List() = %v, want drone-001", got)
+	}
+}
+
+func TestDetector_Resolutions(t *testing.T) {
+	tests := []struct {
+		name   string
+		res    Resolution
+		source string
+		keep   bool
+		wantID string
+	}{
+		{"suffix", Resolution{Action: ActionSuffix}, "dji", true, "drone-001-dji"},
+		{"rename matching source", Resolution{Action: ActionRename, Source: "dji", NewID: "dji-1"}, "dji", true, "dji-1"},
+		{"rename other source", Resolution{Action: ActionRename, Source: "dji", NewID: "dji-1"}, "mavlink", true, "drone-001"},
+		{"prefer keeps source", Resolution{Action: ActionPrefer, Source: "mavlink"}, "mavlink", true, "drone-001"},
+		{"prefer drops others", Resolution{Action: ActionPrefer, Source: "mavlink"}, "dji", false, "drone-001"},
+	}
+	for _, tt := range tests {
+		d := New(30 * time.Second)
+		if _, err := d.SetResolution("drone-001", tt.res); err != nil {
+			t.Fatalf("%s: SetResolution() error = %v", tt.name, err)
+		}
+		state := models.NewDroneState("drone-001", tt.source)
+		keep, _ := d.Check(state, time.Now())
+		if keep != tt.keep || state.DeviceID != tt.wantID {
+			t.Errorf("%s: Check() = %v, %s; want %v, %s", tt.name, keep, state.DeviceID, tt.keep, tt.wantID)
+		}
+	}
+}
+
+func TestDetector_ValidationAndDismiss(t *testing.T) {
+	d := New(30 * time.Second)
+	invalid := []Resolution{
+		{Action: "merge"},
+		{Action: ActionRename, Source: "dji"},
+		{Action: ActionRename, Source: "dji", NewID: "drone-001"},
+		{Action: ActionPrefer},
+	}
+	for _, res := range invalid {
+		if _, err := d.SetResolution("drone-001", res); !errors.Is(err, ErrInvalidResolution) {
+			t.Errorf("SetResolution(%+v) error = %v, want ErrInvalidResolution", res, err)
+		}
+	}
+
+	if err := d.Dismiss("drone-001"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Dismiss(unknown) error = %v, want ErrNotFound", err)
+	}
+	d.SetResolution("drone-001", Resolution{Action: ActionSuffix})
+	if err := d.Dismiss("drone-001"); err != nil {
+		t.Errorf("Dismiss() error = %v", err)
+	}
+	state := models.NewDroneState("drone-001", "dji")
+	d.Check(state, time.Now())
+	if state.DeviceID != "drone-001" {
+		t.Errorf("Resolution still applied after Dismiss: %s", state.DeviceID)
+	}
+}
@@ -0,0 +1,27 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/conflict"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestEngine_DeviceConflict(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 100})
+	var conflicts []events.Event
+	e.Events().Subscribe("test", func(ev events.Event) { conflicts = append(conflicts, ev) }, events.DeviceConflict)
+
+	e.processState(models.NewDroneState("drone-001", "mavlink"))
+	e.processState(models.NewDroneState("drone-001", "dji"))
+	if len(conflicts) != 1 || conflicts[0].Conflict == nil || len(conflicts[0].Conflict.Sources) != 2 {
+		t.Fatalf("Conflict events = %+v, want one conflict with two sources", conflicts)
+	}
+
+	e.Conflicts().SetResolution("drone-001", conflict.Resolution{Action: conflict.ActionSuffix})
+	e.processState(models.NewDroneState("drone-001", "dji"))
+	if e.GetState("drone-001-dji") == nil {
+		t.Error("Suffix resolution should store the state as drone-001-dji")
+	}
+}
@@ -10,17 +10,103 @@ const (
 	ee = 0.00669342162296594323 // Eccentricity squared
 )
 
+// Accuracy modes for GCJ02 conversion
+const (
+	AccuracyAnalytic = "analytic" // Closed-form transform only
+	AccuracyGrid     = "grid"     // Analytic transform plus correction grid
+)
+
+// DefaultReverseIterations is the iteration count used for GCJ02 -> WGS84
+const DefaultReverseIterations = 3
+
+// Datums positions can be expressed in
+const (
+	DatumWGS84 = "wgs84" // GPS
+	DatumGCJ02 = "gcj02" // Amap, Tencent, Google China
+	DatumBD09  = "bd09"  // Baidu Maps
+)
+
+// ValidDatum reports whether d is a known datum
+func ValidDatum(d string) bool {
+	return d == DatumWGS84 || d == DatumGCJ02 || d == DatumBD09
+}
+
 // Converter handles coordinate system conversions
 type Converter struct {
 	EnableGCJ02 bool
 	EnableBD09  bool
+
+	grid              *Grid
+	reverseIterations int
 }
 
 // New creates a new Converter with the specified options
 func New(enableGCJ02, enableBD09 bool) *Converter {
 	return &Converter{
-		EnableGCJ02: enableGCJ02,
-		EnableBD09:  enableBD09,
+		EnableGCJ02:       enableGCJ02,
+		EnableBD09:        enableBD09,
+		reverseIterations: DefaultReverseIterations,
+	}
+}
+
+// SetGrid enables the differential correction grid (nil disables it)
+func (c *Converter) SetGrid(g *Grid) {
+	c.grid = g
+}
+
+// SetReverseIterations sets the iteration count for GCJ02 -> WGS84.
+// Values <= 0 restore the default.
+func (c *Converter) SetReverseIterations(n int) {
+	if n <= 0 {
+		n = DefaultReverseIterations
+	}
+	c.reverseIterations = n
+}
+
+// AccuracyMode returns the active accuracy mode
+func (c *Converter) AccuracyMode() string {
+	if c.grid != nil {
+		return AccuracyGrid
+	}
+	return AccuracyAnalytic
+}
+
+// ToGCJ02 converts WGS84 to GCJ02 using the analytic transform and,
+// when loaded, the correction grid
+func (c *Converter) ToGCJ02(lat, lon float64) (float64, float64) {
+	gcjLat, gcjLon := WGS84ToGCJ02(lat, lon)
+	if dLat, dLon, ok := c.grid.Offset(lat, lon); ok {
+		gcjLat += dLat
+		gcjLon += dLon
+	}
+	return gcjLat, gcjLon
+}
+
+// FromGCJ02 converts GCJ02 back to WGS84 by iteratively inverting ToGCJ02
+func (c *Converter) FromGCJ02(lat, lon float64) (float64, float64) {
+	if outOfChina(lat, lon) {
+		return lat, lon
+	}
+
+	wgsLat, wgsLon := lat, lon
+	for i := 0; i < c.reverseIterations; i++ {
+		gcjLat, gcjLon := c.ToGCJ02(wgsLat, wgsLon)
+		wgsLat -= gcjLat - lat
+		wgsLon -= gcjLon - lon
+	}
+	return wgsLat, wgsLon
+}
+
+// ToDatum converts WGS84 to the given datum. Empty or unknown datums
+// return the WGS84 position unchanged.
+func (c *Converter) ToDatum(lat, lon float64, datum string) (float64, float64) {
+	switch datum {
+	case DatumGCJ02:
+		return c.ToGCJ02(lat, lon)
+	case DatumBD09:
+		return GCJ02ToBD09(c.ToGCJ02(lat, lon))
+	default:
+		return lat, lon
 	}
 }
 
@@ -47,7 +133,7 @@ func (c *Converter) Convert(lat, lon float64) ConvertResult {
 	}
 
 	if c.EnableGCJ02 || c.EnableBD09 {
-		gcjLat, gcjLon := WGS84ToGCJ02(lat, lon)
+		gcjLat, gcjLon := c.ToGCJ02(lat, lon)
 		if c.EnableGCJ02 {
 			result.LatGCJ02 = &gcjLat
 			result.LonGCJ02 = &gcjLon
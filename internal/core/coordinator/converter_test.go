@@ -233,3 +233,62 @@ func BenchmarkWGS84ToBD09(b *testing.B) {
 		WGS84ToBD09(39.908722, 116.397499)
 	}
 }
+
+func TestConverterReverseIterations(t *testing.T) {
+	c := New(true, false)
+	c.SetReverseIterations(10)
+
+	for _, tc := range testCases {
+		if !tc.inChina {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			gcjLat, gcjLon := c.ToGCJ02(tc.wgs84Lat, tc.wgs84Lon)
+			gotLat, gotLon := c.FromGCJ02(gcjLat, gcjLon)
+
+			// Round trip should be well below a centimeter (~1e-7 deg)
+			if !almostEqual(gotLat, tc.wgs84Lat, 1e-7) {
+				t.Errorf("Latitude: got %.9f, want %.9f", gotLat, tc.wgs84Lat)
+			}
+			if !almostEqual(gotLon, tc.wgs84Lon, 1e-7) {
+				t.Errorf("Longitude: got %.9f, want %.9f", gotLon, tc.wgs84Lon)
+			}
+		})
+	}
+}
+
+func TestConverterSetReverseIterationsDefault(t *testing.T) {
+	c := New(true, false)
+	c.SetReverseIterations(0)
+
+	if c.reverseIterations != DefaultReverseIterations {
+		t.Errorf("reverseIterations = %d, want %d", c.reverseIterations, DefaultReverseIterations)
+	}
+}
+
+func TestConverterToDatum(t *testing.T) {
+	c := New(false, false)
+	lat, lon := 39.9042, 116.4074
+
+	gcjLat, gcjLon := WGS84ToGCJ02(lat, lon)
+	bdLat, bdLon := WGS84ToBD09(lat, lon)
+	tests := []struct {
+		datum            string
+		wantLat, wantLon float64
+	}{
+		{DatumWGS84, lat, lon},
+		{"", lat, lon},
+		{DatumGCJ02, gcjLat, gcjLon},
+		{DatumBD09, bdLat, bdLon},
+	}
+	for _, tt := range tests {
+		gotLat, gotLon := c.ToDatum(lat, lon, tt.datum)
+		if gotLat != tt.wantLat || gotLon != tt.wantLon {
+			t.Errorf("ToDatum(%q) = (%f, %f), want (%f, %f)", tt.datum, gotLat, gotLon, tt.wantLat, tt.wantLon)
+		}
+	}
+
+	if !ValidDatum(DatumBD09) || ValidDatum("cgcs2000") || ValidDatum("") {
+		t.Error("ValidDatum accepted or rejected the wrong datums")
+	}
+}
@@ -0,0 +1,24 @@
+package coordinator
+
+import "math"
+
+// EarthRadiusM is the mean Earth radius in meters
+const EarthRadiusM = 6371000.0
+
+// MetersPerDegLat is the length of one degree of latitude in meters, for
+// local flat-earth offsets
+const MetersPerDegLat = 111320.0
+
+// HaversineDistance returns the great-circle distance between two points in
+// meters
+func HaversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLat := (lat2 - lat1) * math.Pi / 180
+	deltaLon := (lon2 - lon1) * math.Pi / 180
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return EarthRadiusM * 2 * math.Atan2(math.Sqrt(h), math.Sqrt(1-h))
+}
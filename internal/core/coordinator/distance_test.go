@@ -0,0 +1,28 @@
+package coordinator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversineDistance(t *testing.T) {
+	// Beijing to Shanghai approximate distance: ~1068 km
+	beijing := []float64{39.9042, 116.4074}
+	shanghai := []float64{31.2304, 121.4737}
+
+	distance := HaversineDistance(beijing[0], beijing[1], shanghai[0], shanghai[1])
+
+	// Should be approximately 1068 km (1068000 meters) with some tolerance
+	expectedMeters := 1068000.0
+	tolerance := 50000.0 // 50km tolerance
+
+	if math.Abs(distance-expectedMeters) > tolerance {
+		t.Errorf("Distance between Beijing and Shanghai should be ~%v meters, got %v", expectedMeters, distance)
+	}
+
+	// Same point should have 0 distance
+	samePoint := HaversineDistance(beijing[0], beijing[1], beijing[0], beijing[1])
+	if samePoint != 0 {
+		t.Errorf("Distance to same point should be 0, got %v", samePoint)
+	}
+}
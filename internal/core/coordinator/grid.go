@@ -0,0 +1,159 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GridTile is a regular lat/lon grid of residual GCJ02 offsets.
+// Offsets are expressed in degrees and are added on top of the analytic
+// WGS84 -> GCJ02 transform to correct its regional error.
+type GridTile struct {
+	MinLat float64   `json:"min_lat"` // Latitude of the first row
+	MinLon float64   `json:"min_lon"` // Longitude of the first column
+	Step   float64   `json:"step"`    // Grid spacing in degrees
+	Rows   int       `json:"rows"`    // Number of latitude rows
+	Cols   int       `json:"cols"`    // Number of longitude columns
+	DLat   []float64 `json:"dlat"`    // Row-major latitude offsets (rows*cols)
+	DLon   []float64 `json:"dlon"`    // Row-major longitude offsets (rows*cols)
+}
+
+// validate checks that the tile dimensions match its data
+func (t *GridTile) validate() error {
+	if t.Step <= 0 {
+		return fmt.Errorf("step must be positive")
+	}
+	if t.Rows < 2 || t.Cols < 2 {
+		return fmt.Errorf("tile must have at least 2 rows and 2 columns")
+	}
+	n := t.Rows * t.Cols
+	if len(t.DLat) != n || len(t.DLon) != n {
+		return fmt.Errorf("expected %d offsets, got dlat=%d dlon=%d", n, len(t.DLat), len(t.DLon))
+	}
+	return nil
+}
+
+// contains reports whether the point lies inside the tile extent
+func (t *GridTile) contains(lat, lon float64) bool {
+	maxLat := t.MinLat + t.Step*float64(t.Rows-1)
+	maxLon := t.MinLon + t.Step*float64(t.Cols-1)
+	return lat >= t.MinLat && lat <= maxLat && lon >= t.MinLon && lon <= maxLon
+}
+
+// offset returns the bilinearly interpolated offset at the given point
+func (t *GridTile) offset(lat, lon float64) (float64, float64) {
+	fy := (lat - t.MinLat) / t.Step
+	fx := (lon - t.MinLon) / t.Step
+
+	r0 := int(fy)
+	c0 := int(fx)
+	if r0 >= t.Rows-1 {
+		r0 = t.Rows - 2
+	}
+	if c0 >= t.Cols-1 {
+		c0 = t.Cols - 2
+	}
+	wy := fy - float64(r0)
+	wx := fx - float64(c0)
+
+	i00 := r0*t.Cols + c0
+	i01 := i00 + 1
+	i10 := i00 + t.Cols
+	i11 := i10 + 1
+
+	bilinear := func(v []float64) float64 {
+		top := v[i00]*(1-wx) + v[i01]*wx
+		bottom := v[i10]*(1-wx) + v[i11]*wx
+		return top*(1-wy) + bottom*wy
+	}
+
+	return bilinear(t.DLat), bilinear(t.DLon)
+}
+
+// Grid is a set of correction tiles used by the "grid" accuracy mode
+type Grid struct {
+	tiles []*GridTile
+}
+
+// NewGrid creates a grid from already loaded tiles
+func NewGrid(tiles ...*GridTile) (*Grid, error) {
+	for i, t := range tiles {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("tile %d: %w", i, err)
+		}
+	}
+	return &Grid{tiles: tiles}, nil
+}
+
+// LoadGrid loads correction tiles from a JSON file or a directory of JSON files
+func LoadGrid(path string) (*Grid, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading grid path: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading grid directory: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+				continue
+			}
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+		sort.Strings(files)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no grid tiles found in %s", path)
+	}
+
+	tiles := make([]*GridTile, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading grid tile %s: %w", file, err)
+		}
+		var tile GridTile
+		if err := json.Unmarshal(data, &tile); err != nil {
+			return nil, fmt.Errorf("parsing grid tile %s: %w", file, err)
+		}
+		if err := tile.validate(); err != nil {
+			return nil, fmt.Errorf("invalid grid tile %s: %w", file, err)
+		}
+		tiles = append(tiles, &tile)
+	}
+
+	return &Grid{tiles: tiles}, nil
+}
+
+// Offset returns the residual correction for a WGS84 point.
+// The boolean is false if no tile covers the point.
+func (g *Grid) Offset(lat, lon float64) (float64, float64, bool) {
+	if g == nil {
+		return 0, 0, false
+	}
+	for _, t := range g.tiles {
+		if t.contains(lat, lon) {
+			dLat, dLon := t.offset(lat, lon)
+			return dLat, dLon, true
+		}
+	}
+	return 0, 0, false
+}
+
+// TileCount returns the number of loaded tiles
+func (g *Grid) TileCount() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.tiles)
+}
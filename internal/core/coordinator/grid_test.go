@@ -0,0 +1,139 @@
+package coordinator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestTile() *GridTile {
+	// 2x2 tile covering Tiananmen with a linear gradient in longitude
+	return &GridTile{
+		MinLat: 39.9,
+		MinLon: 116.3,
+		Step:   0.2,
+		Rows:   2,
+		Cols:   2,
+		DLat:   []float64{0.00001, 0.00001, 0.00001, 0.00001},
+		DLon:   []float64{0.0, 0.00002, 0.0, 0.00002},
+	}
+}
+
+func TestGridOffset(t *testing.T) {
+	g, err := NewGrid(newTestTile())
+	if err != nil {
+		t.Fatalf("NewGrid failed: %v", err)
+	}
+
+	dLat, dLon, ok := g.Offset(39.95, 116.4)
+	if !ok {
+		t.Fatal("Point should be covered by the tile")
+	}
+	if !almostEqual(dLat, 0.00001, 1e-12) {
+		t.Errorf("dLat = %g, want 0.00001", dLat)
+	}
+	// Halfway across the tile in longitude
+	if !almostEqual(dLon, 0.00001, 1e-12) {
+		t.Errorf("dLon = %g, want 0.00001", dLon)
+	}
+
+	if _, _, ok := g.Offset(31.2, 121.5); ok {
+		t.Error("Shanghai should not be covered by the tile")
+	}
+}
+
+func TestGridOffsetNil(t *testing.T) {
+	var g *Grid
+	if _, _, ok := g.Offset(39.9, 116.4); ok {
+		t.Error("nil grid should not return an offset")
+	}
+	if g.TileCount() != 0 {
+		t.Error("nil grid should have no tiles")
+	}
+}
+
+func TestNewGridInvalidTile(t *testing.T) {
+	tile := newTestTile()
+	tile.DLat = tile.DLat[:3]
+
+	if _, err := NewGrid(tile); err == nil {
+		t.Error("Expected error for mismatched offset count")
+	}
+}
+
+func TestConverterWithGrid(t *testing.T) {
+	g, _ := NewGrid(newTestTile())
+	c := New(true, false)
+
+	baseLat, baseLon := c.ToGCJ02(39.908722, 116.397499)
+	if c.AccuracyMode() != AccuracyAnalytic {
+		t.Errorf("AccuracyMode = %s, want %s", c.AccuracyMode(), AccuracyAnalytic)
+	}
+
+	c.SetGrid(g)
+	if c.AccuracyMode() != AccuracyGrid {
+		t.Errorf("AccuracyMode = %s, want %s", c.AccuracyMode(), AccuracyGrid)
+	}
+
+	result := c.Convert(39.908722, 116.397499)
+	if result.LatGCJ02 == nil {
+		t.Fatal("GCJ02 should be set")
+	}
+	if !almostEqual(*result.LatGCJ02-baseLat, 0.00001, 1e-9) {
+		t.Errorf("Grid correction not applied to latitude: diff %g", *result.LatGCJ02-baseLat)
+	}
+	if *result.LonGCJ02 <= baseLon {
+		t.Error("Grid correction not applied to longitude")
+	}
+
+	// Reverse conversion should undo the corrected transform
+	lat, lon := c.FromGCJ02(*result.LatGCJ02, *result.LonGCJ02)
+	if !almostEqual(lat, 39.908722, 1e-7) || !almostEqual(lon, 116.397499, 1e-7) {
+		t.Errorf("FromGCJ02 = (%.9f, %.9f), want (39.908722, 116.397499)", lat, lon)
+	}
+}
+
+func TestLoadGrid(t *testing.T) {
+	dir := t.TempDir()
+
+	tile := `{"min_lat":39.9,"min_lon":116.3,"step":0.2,"rows":2,"cols":2,
+		"dlat":[0,0,0,0],"dlon":[0,0,0,0]}`
+	if err := os.WriteFile(filepath.Join(dir, "beijing.json"), []byte(tile), 0644); err != nil {
+		t.Fatalf("Failed to write tile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("ignored"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	t.Run("Directory", func(t *testing.T) {
+		g, err := LoadGrid(dir)
+		if err != nil {
+			t.Fatalf("LoadGrid failed: %v", err)
+		}
+		if g.TileCount() != 1 {
+			t.Errorf("TileCount = %d, want 1", g.TileCount())
+		}
+	})
+
+	t.Run("Single file", func(t *testing.T) {
+		g, err := LoadGrid(filepath.Join(dir, "beijing.json"))
+		if err != nil {
+			t.Fatalf("LoadGrid failed: %v", err)
+		}
+		if g.TileCount() != 1 {
+			t.Errorf("TileCount = %d, want 1", g.TileCount())
+		}
+	})
+
+	t.Run("Missing path", func(t *testing.T) {
+		if _, err := LoadGrid(filepath.Join(dir, "missing")); err == nil {
+			t.Error("Expected error for missing path")
+		}
+	})
+
+	t.Run("Empty directory", func(t *testing.T) {
+		if _, err := LoadGrid(t.TempDir()); err == nil {
+			t.Error("Expected error for directory without tiles")
+		}
+	})
+}
@@ -0,0 +1,18 @@
+package core
+
+import (
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coverage"
+)
+
+// Coverage returns the grid of link quality reported by devices
+func (e *Engine) Coverage() *coverage.Map {
+	return e.coverage
+}
+
+// PruneCoverage removes coverage periods that ended before the given time.
+// Returns the number of cell periods removed.
+func (e *Engine) PruneCoverage(before time.Time) int {
+	return e.coverage.Prune(before)
+}
@@ -0,0 +1,256 @@
+// Package coverage aggregates the link quality reported by drones into a
+// geographic grid over time, so operators can find coverage dead zones at
+// their sites before planning beyond-visual-line-of-sight routes.
+package coverage
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// DefaultCellSizeM is the grid cell edge length when Config.CellSizeM is 0
+const DefaultCellSizeM = 50.0
+
+// DefaultBucket is the aggregation period when Config.Bucket is 0
+const DefaultBucket = time.Hour
+
+// Config holds grid settings
+type Config struct {
+	CellSizeM float64       // Cell edge length in meters (0 = DefaultCellSizeM)
+	Bucket    time.Duration // Samples are aggregated per cell and period (0 = DefaultBucket)
+}
+
+// Query selects the samples to aggregate
+type Query struct {
+	Since  int64   // Unix ms, 0 = no lower bound
+	Until  int64   // Unix ms, 0 = no upper bound
+	Tenant string  // Only samples from this tenant's devices ("" = all)
+	Bounds *Bounds // Only cells whose center lies within the bounds (nil = all)
+}
+
+// Bounds is a latitude/longitude bounding box
+type Bounds struct {
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+// contains reports whether a point lies within the bounds
+func (b *Bounds) contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// Cell is the aggregated link quality of one grid cell
+type Cell struct {
+	Lat        float64 `json:"lat"` // Cell center
+	Lon        float64 `json:"lon"`
+	Bounds     Bounds  `json:"bounds"`
+	Samples    int     `json:"samples"`
+	AvgQuality float64 `json:"avg_quality"` // Mean signal quality 0-100
+	MinQuality int     `json:"min_quality"`
+	MaxQuality int     `json:"max_quality"`
+	FirstSeen  int64   `json:"first_seen"` // Unix ms
+	LastSeen   int64   `json:"last_seen"`  // Unix ms
+}
+
+// cellKey identifies a grid cell within a period for one tenant
+type cellKey struct {
+	tenant string
+	row    int64
+	col    int64
+	bucket int64
+}
+
+// stats accumulates the samples of a cell
+type stats struct {
+	samples   int
+	sum       int64
+	min       int
+	max       int
+	firstSeen int64
+	lastSeen  int64
+}
+
+// Map aggregates signal quality samples by grid cell
+type Map struct {
+	grid
+	bucketMs int64
+
+	mu    sync.RWMutex
+	cells map[cellKey]*stats
+}
+
+// New creates a coverage map
+func New(cfg Config) *Map {
+	if cfg.CellSizeM <= 0 {
+		cfg.CellSizeM = DefaultCellSizeM
+	}
+	if cfg.Bucket <= 0 {
+		cfg.Bucket = DefaultBucket
+	}
+	return &Map{
+		grid:     grid{cellSize: cfg.CellSizeM},
+		bucketMs: cfg.Bucket.Milliseconds(),
+		cells:    make(map[cellKey]*stats),
+	}
+}
+
+// CellSize returns the cell edge length in meters
+func (m *Map) CellSize() float64 {
+	return m.cellSize
+}
+
+// Observe records the signal quality of a state. States without a position
+// or a reported signal quality are ignored; it returns whether the state was
+// recorded.
+func (m *Map) Observe(state *models.DroneState) bool {
+	q := state.Status.SignalQuality
+	lat, lon := state.Location.Lat, state.Location.Lon
+	if q <= 0 || (lat == 0 && lon == 0) {
+		return false
+	}
+	if q > 100 {
+		q = 100
+	}
+
+	row, col := m.cellOf(lat, lon)
+	key := cellKey{tenant: state.Tenant, row: row, col: col, bucket: state.Timestamp / m.bucketMs}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.cells[key]
+	if !ok {
+		s = &stats{min: q, max: q, firstSeen: state.Timestamp}
+		m.cells[key] = s
+	}
+	s.samples++
+	s.sum += int64(q)
+	s.min = min(s.min, q)
+	s.max = max(s.max, q)
+	s.firstSeen = min(s.firstSeen, state.Timestamp)
+	s.lastSeen = max(s.lastSeen, state.Timestamp)
+	return true
+}
+
+// Cells aggregates the matching periods of every cell, weakest signal first
+func (m *Map) Cells(q Query) []Cell {
+	type gridKey struct{ row, col int64 }
+	merged := make(map[gridKey]*stats)
+
+	m.mu.RLock()
+	for key, s := range m.cells {
+		if q.Tenant != "" && key.tenant != q.Tenant {
+			continue
+		}
+		// Periods overlapping the query window are included
+		start, end := key.bucket*m.bucketMs, (key.bucket+1)*m.bucketMs
+		if (q.Since > 0 && end <= q.Since) || (q.Until > 0 && start > q.Until) {
+			continue
+		}
+		gk := gridKey{key.row, key.col}
+		acc, ok := merged[gk]
+		if !ok {
+			c := *s
+			merged[gk] = &c
+			continue
+		}
+		acc.samples += s.samples
+		acc.sum += s.sum
+		acc.min = min(acc.min, s.min)
+		acc.max = max(acc.max, s.max)
+		acc.firstSeen = min(acc.firstSeen, s.firstSeen)
+		acc.lastSeen = max(acc.lastSeen, s.lastSeen)
+	}
+	m.mu.RUnlock()
+
+	result := make([]Cell, 0, len(merged))
+	for gk, s := range merged {
+		b := m.bounds(gk.row, gk.col)
+		lat, lon := (b.MinLat+b.MaxLat)/2, (b.MinLon+b.MaxLon)/2
+		if q.Bounds != nil && !q.Bounds.contains(lat, lon) {
+			continue
+		}
+		result = append(result, Cell{
+			Lat:        lat,
+			Lon:        lon,
+			Bounds:     b,
+			Samples:    s.samples,
+			AvgQuality: math.Round(float64(s.sum)/float64(s.samples)*10) / 10,
+			MinQuality: s.min,
+			MaxQuality: s.max,
+			FirstSeen:  s.firstSeen,
+			LastSeen:   s.lastSeen,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].AvgQuality != result[j].AvgQuality {
+			return result[i].AvgQuality < result[j].AvgQuality
+		}
+		if result[i].Lat != result[j].Lat {
+			return result[i].Lat < result[j].Lat
+		}
+		return result[i].Lon < result[j].Lon
+	})
+	return result
+}
+
+// Prune removes periods that ended before the given time and returns how
+// many cell periods were removed
+func (m *Map) Prune(before time.Time) int {
+	cutoff := before.UnixMilli()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removed := 0
+	for key := range m.cells {
+		if (key.bucket+1)*m.bucketMs <= cutoff {
+			delete(m.cells, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// grid divides the map into cells of roughly cellSize meters square
+type grid struct {
+	cellSize float64
+}
+
+// cellOf returns the grid row and column of a position. Rows are a fixed
+// latitude step; the longitude step of each row is widened by the cosine of
+// its center latitude so cells stay roughly square.
+func (g grid) cellOf(lat, lon float64) (int64, int64) {
+	latStep := g.cellSize / coordinator.MetersPerDegLat
+	row := int64(math.Floor(lat / latStep))
+	col := int64(math.Floor(lon / g.lonStep(row)))
+	return row, col
+}
+
+// lonStep returns the longitude width of the cells in a row
+func (g grid) lonStep(row int64) float64 {
+	latStep := g.cellSize / coordinator.MetersPerDegLat
+	center := (float64(row) + 0.5) * latStep
+	cos := math.Cos(center * math.Pi / 180)
+	if cos < 0.01 {
+		cos = 0.01 // Avoid degenerate cells at the poles
+	}
+	return latStep / cos
+}
+
+// bounds returns the bounding box of a cell
+func (g grid) bounds(row, col int64) Bounds {
+	latStep := g.cellSize / coordinator.MetersPerDegLat
+	lonStep := g.lonStep(row)
+	return Bounds{
+		MinLat: float64(row) * latStep,
+		MinLon: float64(col) * lonStep,
+		MaxLat: float64(row+1) * latStep,
+		MaxLon: float64(col+1) * lonStep,
+	}
+}
@@ -0,0 +1,88 @@
+package coverage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func sample(lat, lon float64, quality int, ts int64, tenant string) *models.DroneState {
+	s := models.NewDroneState("drone-1", "mavlink")
+	s.Location.Lat, s.Location.Lon = lat, lon
+	s.Status.SignalQuality = quality
+	s.Timestamp = ts
+	s.Tenant = tenant
+	return s
+}
+
+func TestMap_Aggregate(t *testing.T) {
+	m := New(Config{CellSizeM: 100, Bucket: time.Minute})
+	hour := time.Hour.Milliseconds()
+
+	// Two samples in one cell, one in a cell ~1 km north
+	m.Observe(sample(22.5000, 114.0000, 80, hour, ""))
+	m.Observe(sample(22.5002, 114.0002, 40, hour+120000, ""))
+	m.Observe(sample(22.5100, 114.0000, 10, hour, "acme"))
+
+	if m.Observe(sample(22.5, 114, 0, hour, "")) || m.Observe(sample(0, 0, 50, hour, "")) {
+		t.Error("States without signal quality or position should be ignored")
+	}
+
+	cells := m.Cells(Query{})
+	if len(cells) != 2 {
+		t.Fatalf("Cells() returned %d cells, want 2", len(cells))
+	}
+	weak, strong := cells[0], cells[1]
+	if weak.AvgQuality != 10 {
+		t.Errorf("Weakest cell avg = %.1f, want 10", weak.AvgQuality)
+	}
+	if strong.Samples != 2 || strong.AvgQuality != 60 || strong.MinQuality != 40 || strong.MaxQuality != 80 {
+		t.Errorf("Merged cell = %+v", strong)
+	}
+	if strong.Bounds.MaxLat-strong.Bounds.MinLat > 0.001 || strong.Lat < strong.Bounds.MinLat || strong.Lat > strong.Bounds.MaxLat {
+		t.Errorf("Cell bounds %+v do not match a 100 m cell at %.5f", strong.Bounds, strong.Lat)
+	}
+
+	// Time window only keeps the second minute's sample
+	if cells := m.Cells(Query{Since: hour + 60000}); len(cells) != 1 || cells[0].Samples != 1 || cells[0].AvgQuality != 40 {
+		t.Errorf("Cells(since) = %+v", cells)
+	}
+	if cells := m.Cells(Query{Tenant: "acme"}); len(cells) != 1 || cells[0].AvgQuality != 10 {
+		t.Errorf("Cells(tenant) = %+v", cells)
+	}
+	if cells := m.Cells(Query{Bounds: &Bounds{MinLat: 22.505, MinLon: 113.9, MaxLat: 22.52, MaxLon: 114.1}}); len(cells) != 1 {
+		t.Errorf("Cells(bounds) = %+v", cells)
+	}
+
+	if removed := m.Prune(time.UnixMilli(hour + 60000)); removed != 2 {
+		t.Errorf("Prune() removed %d, want 2", removed)
+	}
+	if cells := m.Cells(Query{}); len(cells) != 1 {
+		t.Errorf("Cells() after prune = %+v", cells)
+	}
+}
+
+func TestOccupancy(t *testing.T) {
+	o := NewOccupancy(100, Query{Since: 1000, Bounds: &Bounds{MinLat: 22, MinLon: 113, MaxLat: 23, MaxLon: 115}})
+	o.Add("drone-1", 22.5000, 114.0000, 1000)
+	o.Add("drone-1", 22.5002, 114.0002, 3000)
+	o.Add("drone-2", 22.5001, 114.0001, 2000)
+	o.Add("drone-2", 22.5100, 114.0000, 2000)
+
+	// Outside the window or bounds, or without a position
+	o.Add("drone-1", 22.5, 114, 500)
+	o.Add("drone-1", 24, 114, 2000)
+	o.Add("drone-1", 0, 0, 2000)
+
+	cells := o.Cells()
+	if len(cells) != 2 {
+		t.Fatalf("Cells() returned %d cells, want 2", len(cells))
+	}
+	if c := cells[0]; c.Points != 3 || c.Devices != 2 || c.FirstSeen != 1000 || c.LastSeen != 3000 {
+		t.Errorf("Busiest cell = %+v", c)
+	}
+	if c := cells[1]; c.Points != 1 || c.Devices != 1 || c.Lat < c.Bounds.MinLat || c.Lat > c.Bounds.MaxLat {
+		t.Errorf("Second cell = %+v", c)
+	}
+}
@@ -0,0 +1,90 @@
+package coverage
+
+import "sort"
+
+// OccupancyCell counts the track points within one grid cell
+type OccupancyCell struct {
+	Lat       float64 `json:"lat"` // Cell center
+	Lon       float64 `json:"lon"`
+	Bounds    Bounds  `json:"bounds"`
+	Points    int     `json:"points"`
+	Devices   int     `json:"devices"`    // Distinct devices with points in the cell
+	FirstSeen int64   `json:"first_seen"` // Unix ms
+	LastSeen  int64   `json:"last_seen"`  // Unix ms
+}
+
+// Occupancy aggregates track points into per-cell counts, so traffic
+// heatmaps can be drawn without sending every point to the client. It
+// is built for one query and not safe for concurrent use.
+type Occupancy struct {
+	grid
+	q     Query
+	cells map[[2]int64]*occupancy
+}
+
+// occupancy accumulates the points of a cell
+type occupancy struct {
+	points    int
+	devices   map[string]bool
+	firstSeen int64
+	lastSeen  int64
+}
+
+// NewOccupancy creates an aggregation with cells of cellSizeM meters
+// (0 = DefaultCellSizeM), counting the points within the query's time
+// window and bounds. The query's tenant is not used; callers filter devices.
+func NewOccupancy(cellSizeM float64, q Query) *Occupancy {
+	if cellSizeM <= 0 {
+		cellSizeM = DefaultCellSizeM
+	}
+	return &Occupancy{grid: grid{cellSize: cellSizeM}, q: q, cells: make(map[[2]int64]*occupancy)}
+}
+
+// Add counts a track point of a device. Points without a position or
+// outside the query are ignored.
+func (o *Occupancy) Add(deviceID string, lat, lon float64, timestamp int64) {
+	if (lat == 0 && lon == 0) || (o.q.Since > 0 && timestamp < o.q.Since) || (o.q.Until > 0 && timestamp > o.q.Until) {
+		return
+	}
+	if o.q.Bounds != nil && !o.q.Bounds.contains(lat, lon) {
+		return
+	}
+
+	row, col := o.cellOf(lat, lon)
+	c, ok := o.cells[[2]int64{row, col}]
+	if !ok {
+		c = &occupancy{devices: make(map[string]bool), firstSeen: timestamp}
+		o.cells[[2]int64{row, col}] = c
+	}
+	c.points++
+	c.devices[deviceID] = true
+	c.firstSeen = min(c.firstSeen, timestamp)
+	c.lastSeen = max(c.lastSeen, timestamp)
+}
+
+// Cells returns the counted cells, busiest first
+func (o *Occupancy) Cells() []OccupancyCell {
+	result := make([]OccupancyCell, 0, len(o.cells))
+	for key, c := range o.cells {
+		b := o.bounds(key[0], key[1])
+		result = append(result, OccupancyCell{
+			Lat:       (b.MinLat + b.MaxLat) / 2,
+			Lon:       (b.MinLon + b.MaxLon) / 2,
+			Bounds:    b,
+			Points:    c.points,
+			Devices:   len(c.devices),
+			FirstSeen: c.firstSeen,
+			LastSeen:  c.lastSeen,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Points != result[j].Points {
+			return result[i].Points > result[j].Points
+		}
+		if result[i].Lat != result[j].Lat {
+			return result[i].Lat < result[j].Lat
+		}
+		return result[i].Lon < result[j].Lon
+	})
+	return result
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coverage"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestEngine_Coverage(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 100, CoverageCellSizeM: 100})
+
+	now := time.Now().UnixMilli()
+	for i, q := range []int{70, 30, 0} {
+		s := models.NewDroneState("mavlink-1", "mavlink")
+		s.Location.Lat, s.Location.Lon = 22.5, 114.0
+		s.Status.SignalQuality = q
+		s.Timestamp = now + int64(i)
+		e.processState(s)
+	}
+
+	cells := e.Coverage().Cells(coverage.Query{})
+	if len(cells) != 1 || cells[0].Samples != 2 || cells[0].AvgQuality != 50 {
+		t.Errorf("Coverage cells = %+v, want one cell averaging 50 over 2 samples", cells)
+	}
+	if e.Coverage().CellSize() != 100 {
+		t.Errorf("CellSize() = %v, want 100", e.Coverage().CellSize())
+	}
+	if removed := e.PruneCoverage(time.Now().Add(48 * time.Hour)); removed != 1 {
+		t.Errorf("PruneCoverage() removed %d, want 1", removed)
+	}
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// DefaultDedupPositionM is the position change below which a state with
+// the same timestamp is a repeat
+const DefaultDedupPositionM = 0.5
+
+// DedupStats counts the states of a protocol source checked for repeats
+type DedupStats struct {
+	Source  string `json:"source"`
+	Checked uint64 `json:"checked"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// dedupKey holds the fields of a state compared with the next one
+type dedupKey struct {
+	timestamp int64
+	lat, lon  float64
+	altGNSS   float64
+	altBaro   float64
+	battery   int
+	armed     bool
+	mode      models.FlightMode
+}
+
+func newDedupKey(state *models.DroneState) dedupKey {
+	return dedupKey{
+		timestamp: state.Timestamp,
+		lat:       state.Location.Lat,
+		lon:       state.Location.Lon,
+		altGNSS:   state.Location.AltGNSS,
+		altBaro:   state.Location.AltBaro,
+		battery:   state.Status.BatteryPercent,
+		armed:     state.Status.Armed,
+		mode:      state.Status.FlightMode,
+	}
+}
+
+// deduplicator drops states repeating the last state of their device, as
+// sent by forwarders that resend a sample until they have a new one. A
+// state is a repeat if it has the same timestamp, its position and
+// altitudes moved less than positionM, and it is armed, in the flight
+// mode and at the battery level of the last one.
+type deduplicator struct {
+	sources   []string // Protocol sources checked (empty = all)
+	positionM float64
+
+	mu    sync.Mutex
+	last  map[string]dedupKey    // device_id -> last state kept
+	stats map[string]*DedupStats // protocol source -> counts
+}
+
+func newDeduplicator(sources []string, positionM float64) *deduplicator {
+	if positionM <= 0 {
+		positionM = DefaultDedupPositionM
+	}
+	return &deduplicator{
+		sources:   sources,
+		positionM: positionM,
+		last:      make(map[string]dedupKey),
+		stats:     make(map[string]*DedupStats),
+	}
+}
+
+// repeated reports whether a state repeats the last one of its device.
+// Other states become the device's last state.
+func (d *deduplicator) repeated(state *models.DroneState) bool {
+	if len(d.sources) > 0 && !slices.Contains(d.sources, state.ProtocolSource) {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats, ok := d.stats[state.ProtocolSource]
+	if !ok {
+		stats = &DedupStats{Source: state.ProtocolSource}
+		d.stats[state.ProtocolSource] = stats
+	}
+	stats.Checked++
+
+	key := newDedupKey(state)
+	last, ok := d.last[state.DeviceID]
+	if ok && d.same(last, key) {
+		stats.Dropped++
+		return true
+	}
+	d.last[state.DeviceID] = key
+	return false
+}
+
+// same compares two states within the position threshold
+func (d *deduplicator) same(a, b dedupKey) bool {
+	if a.timestamp != b.timestamp || a.battery != b.battery || a.armed != b.armed || a.mode != b.mode {
+		return false
+	}
+	return math.Abs(a.altGNSS-b.altGNSS) < d.positionM &&
+		math.Abs(a.altBaro-b.altBaro) < d.positionM &&
+		coordinator.HaversineDistance(a.lat, a.lon, b.lat, b.lon) < d.positionM
+}
+
+// forget drops the last state of a device
+func (d *deduplicator) forget(deviceID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.last, deviceID)
+}
+
+// GetDedupStats returns the deduplication counts per protocol source,
+// nil when deduplication is off
+func (e *Engine) GetDedupStats() []DedupStats {
+	if e.dedup == nil {
+		return nil
+	}
+	e.dedup.mu.Lock()
+	defer e.dedup.mu.Unlock()
+
+	stats := make([]DedupStats, 0, len(e.dedup.stats))
+	for _, s := range e.dedup.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Source < stats[j].Source })
+	return stats
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestEngine_Dedup(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1e6, Dedup: true, DedupSources: []string{"dji"}})
+	pub := &recordingPublisher{name: "mqtt"}
+	e.RegisterPublisher(pub)
+
+	state := func(id, source string, ts int64, lat float64) *models.DroneState {
+		s := models.NewDroneState(id, source)
+		s.Timestamp = ts
+		s.Location.Lat, s.Location.Lon = lat, 114.0579
+		s.Status.BatteryPercent = 80
+		return s
+	}
+	e.processState(state("dji-1", "dji", 1000, 22.5431))
+	e.processState(state("dji-1", "dji", 1000, 22.5431))
+	e.processState(state("dji-1", "dji", 1000, 22.54310001)) // About 1 mm away
+	e.processState(state("dji-1", "dji", 1000, 22.5432))     // About 11 m away
+	e.processState(state("dji-1", "dji", 2000, 22.5432))     // A new sample at the same place
+	changed := state("dji-1", "dji", 2000, 22.5432)
+	changed.Status.BatteryPercent = 79
+	e.processState(changed)
+
+	// Other sources are not checked
+	e.processState(state("mavlink-1", "mavlink", 1000, 22.5431))
+	e.processState(state("mavlink-1", "mavlink", 1000, 22.5431))
+
+	if len(pub.states) != 6 {
+		t.Errorf("Published %v, want 4 dji-1 and 2 mavlink-1 states", pub.states)
+	}
+	stats := e.GetDedupStats()
+	if len(stats) != 1 || stats[0] != (DedupStats{Source: "dji", Checked: 6, Dropped: 2}) {
+		t.Errorf("GetDedupStats() = %+v", stats)
+	}
+
+	e.dedup.forget("dji-1")
+	e.processState(state("dji-1", "dji", 2000, 22.5432))
+	if len(pub.states) != 7 {
+		t.Error("A forgotten device's state should not be dropped")
+	}
+
+	if NewEngine(EngineConfig{}).GetDedupStats() != nil {
+		t.Error("GetDedupStats() should be nil when deduplication is off")
+	}
+}
@@ -0,0 +1,16 @@
+package core
+
+import (
+	"github.com/open-uav/telemetry-bridge/internal/core/identity"
+	"github.com/open-uav/telemetry-bridge/internal/core/registry"
+)
+
+// Devices returns the registry of device names, airframes and operators
+func (e *Engine) Devices() *registry.Registry {
+	return e.devices
+}
+
+// Identity returns the device aliases merging sources into canonical devices
+func (e *Engine) Identity() *identity.Mapper {
+	return e.identity
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/enrich"
+	"github.com/open-uav/telemetry-bridge/internal/core/identity"
+	"github.com/open-uav/telemetry-bridge/internal/core/registry"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestEngine_DeviceMetadata(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 100})
+	if err := e.Devices().Create(&registry.Device{ID: "mavlink-1", Name: "Survey 1", Tags: []string{"survey"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"mavlink-1", "mavlink-2"} {
+		e.processState(models.NewDroneState(id, "mavlink"))
+	}
+	if got := e.GetState("mavlink-1"); got == nil || got.Metadata == nil || got.Metadata.Name != "Survey 1" {
+		t.Errorf("mavlink-1 state = %+v, want registered metadata", got)
+	}
+	if got := e.GetState("mavlink-2"); got == nil || got.Metadata != nil {
+		t.Errorf("mavlink-2 state = %+v, want no metadata", got)
+	}
+}
+
+func TestEngine_DeviceAliases(t *testing.T) {
+	ids, err := identity.New([]identity.Alias{{ID: "rid-1", Canonical: "mavlink-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(EngineConfig{RateHz: 100, Identity: ids})
+
+	e.processState(models.NewDroneState("mavlink-1", "mavlink"))
+	e.processState(models.NewDroneState("rid-1", "poll"))
+
+	if e.GetDeviceCount() != 1 || e.GetState("rid-1") != nil {
+		t.Fatalf("Devices = %d, want rid-1 merged into mavlink-1", e.GetDeviceCount())
+	}
+	got := e.GetState("mavlink-1")
+	if got == nil || got.ProtocolSource != "poll" || len(got.Sources) != 2 {
+		t.Errorf("mavlink-1 state = %+v, want the poll state with both sources", got)
+	}
+	if len(e.Conflicts().List()) != 0 {
+		t.Error("Aliased sources should not be reported as conflicting")
+	}
+}
+
+func TestEngine_Enrichment(t *testing.T) {
+	ids, err := identity.New([]identity.Alias{{ID: "rid-1", Canonical: "mavlink-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := enrich.New([]enrich.Spec{
+		{Type: enrich.TypeUnits, Sources: []string{"poll"}, Convert: map[string]string{"location.alt_gnss": "ft_to_m"}},
+		{Type: enrich.TypeTags, Tags: map[string]string{"site": "north"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(EngineConfig{RateHz: 100, Identity: ids, Enrichment: chain})
+
+	state := models.NewDroneState("rid-1", "poll")
+	state.Location.AltGNSS = 100
+	state.Freshness = models.FreshnessAt(2000)
+	e.processState(state)
+
+	// The fused state keeps the converted position of the poll source
+	later := models.NewDroneState("mavlink-1", "mavlink")
+	later.Freshness = models.Freshness{AttitudeAt: 3000}
+	e.processState(later)
+
+	got := e.GetState("mavlink-1")
+	if got == nil {
+		t.Fatal("mavlink-1 state missing")
+	}
+	if got.Extra["site"] != "north" || math.Abs(got.Location.AltGNSS-30.48) > 1e-9 {
+		t.Errorf("mavlink-1 alt_gnss = %v, extra %v", got.Location.AltGNSS, got.Extra)
+	}
+}
@@ -5,17 +5,28 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
+	"github.com/open-uav/telemetry-bridge/internal/core/chaos"
+	"github.com/open-uav/telemetry-bridge/internal/core/conflict"
 	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/internal/core/coverage"
+	"github.com/open-uav/telemetry-bridge/internal/core/enrich"
+	"github.com/open-uav/telemetry-bridge/internal/core/equipment"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/identity"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/internal/core/registry"
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
 	"github.com/open-uav/telemetry-bridge/internal/core/statestore"
+	"github.com/open-uav/telemetry-bridge/internal/core/tenant"
 	"github.com/open-uav/telemetry-bridge/internal/core/throttler"
+	"github.com/open-uav/telemetry-bridge/internal/core/timeline"
 	"github.com/open-uav/telemetry-bridge/internal/core/trackstore"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/internal/core/tracing"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
-// StateCallback is a function that receives state updates
-type StateCallback func(state *models.DroneState)
-
 // Engine is the core message routing engine
 type Engine struct {
 	adapters      []Adapter
@@ -24,20 +35,111 @@ type Engine struct {
 	trackStore    *trackstore.Store
 	throttler     *throttler.Throttler
 	coordinator   *coordinator.Converter
-	stateCallback StateCallback
-	events        chan *models.DroneState
+	health        *healthMonitor
+	quarantine    *quarantine.Store
+	presence      *presenceTracker
+	conflicts     *conflict.Detector
+	equipment     *equipment.Tracker
+	timeline      *timeline.Tracker
+	router        *routing.Router
+	tenants       *tenant.Registry
+	devices       *registry.Registry
+	identity      *identity.Mapper
+	enrichment    *enrich.Chain // nil when no processors are configured
+	coverage      *coverage.Map
+	chaos         *chaos.Injector
+	tracer        *tracing.Tracer
+	batchers      map[string]*batcher // Per batch publisher, nil when batching is off
+	batchSize     int
+	batchLatency  time.Duration
+	ctx           context.Context
+	reconnectMu   sync.Mutex
+	bus           *events.Bus
 	wg            sync.WaitGroup
-	mu            sync.RWMutex
+
+	queue   *eventQueue   // Between the adapters and the routing goroutine
+	home    *homeTracker  // Launch point of each drone
+	battery *batteryModel // Discharge rate of each drone
+	dedup   *deduplicator // nil when deduplication is off
 }
 
 // EngineConfig holds configuration for the engine
 type EngineConfig struct {
 	RateHz            float64
+	MaxRateHz         float64 // Upper bound for per-device rate boosts (0 = unlimited)
 	ConvertGCJ02      bool
 	ConvertBD09       bool
 	TrackEnabled      bool
 	TrackMaxPoints    int
 	TrackSampleIntervalMs int64
+
+	// Flight segmentation of the track store (0 = defaults)
+	TrackMaxFlights   int
+	TrackFlightIdleMs int64
+
+	// Memory budget of the track store (0 = unlimited)
+	TrackMaxBytes          int64
+	TrackDownsampleAfterMs int64
+	TrackDownsampleEvery   int
+
+	// Optional GCJ02 accuracy settings
+	CoordinateGrid         *coordinator.Grid
+	CoordinateReverseIters int
+
+	// Publisher health thresholds (0 = defaults)
+	PublisherDegradedErrors int
+	PublisherStaleAfterMs   int64
+
+	// Parse error quarantine (0 = defaults)
+	QuarantineMaxEntries int
+
+	// Silence before a device is reported offline (0 = default)
+	DeviceOfflineAfterMs int64
+
+	// Latest-state cache limits (0 = no expiry, no device limit)
+	StateTTL   time.Duration
+	MaxDevices int
+
+	// Geohash length indexing states for spatial queries (0 = no index)
+	GeohashPrecision int
+
+	// Initial publisher routing rules (none = every publisher gets every state)
+	RoutingRules []routing.Rule
+
+	// Device ownership for multi-tenant gateways (nil = no tenants)
+	Tenants *tenant.Registry
+
+	// Registered device details merged into states (nil = in-memory registry)
+	Devices *registry.Registry
+
+	// Device aliases merging sources into canonical devices (nil = none)
+	Identity *identity.Mapper
+
+	// Processors shaping each state, in order (nil = none)
+	Enrichment *enrich.Chain
+
+	// Signal coverage grid (0 = defaults)
+	CoverageCellSizeM float64
+	CoverageBucket    time.Duration
+
+	// Pipeline span recording (nil = tracing disabled)
+	Tracer *tracing.Tracer
+
+	// Send states to publishers implementing BatchPublisher in batches of
+	// up to BatchMaxSize, waiting at most BatchMaxLatency (0 = defaults)
+	Batching        bool
+	BatchMaxSize    int
+	BatchMaxLatency time.Duration
+
+	// Event queue capacity and what to do when it is full (zero = defaults)
+	QueueSize  int
+	DropPolicy string
+
+	// Drop states repeating the last one of their device, for the listed
+	// protocol sources (none = all), within DedupPositionM (0 = default)
+	Dedup          bool
+	DedupSources   []string
+	DedupPositionM float64
 }
 
 // NewEngine creates a new core engine
@@ -47,32 +149,107 @@ func NewEngine(cfg EngineConfig) *Engine {
 		ts = trackstore.New(trackstore.Config{
 			MaxPointsPerDrone: cfg.TrackMaxPoints,
 			SampleIntervalMs:  cfg.TrackSampleIntervalMs,
+
+			MaxFlightsPerDrone: cfg.TrackMaxFlights,
+			FlightIdleMs:       cfg.TrackFlightIdleMs,
+
+			MaxBytes:          cfg.TrackMaxBytes,
+			DownsampleAfterMs: cfg.TrackDownsampleAfterMs,
+			DownsampleEvery:   cfg.TrackDownsampleEvery,
 		})
 	}
 
-	return &Engine{
+	conv := coordinator.New(cfg.ConvertGCJ02, cfg.ConvertBD09)
+	conv.SetGrid(cfg.CoordinateGrid)
+	conv.SetReverseIterations(cfg.CoordinateReverseIters)
+
+	th := throttler.New(cfg.RateHz)
+	th.SetMaxRate(cfg.MaxRateHz)
+
+	offlineAfterMs := cfg.DeviceOfflineAfterMs
+	if offlineAfterMs <= 0 {
+		offlineAfterMs = DefaultDeviceOfflineAfterMs
+	}
+
+	devices := cfg.Devices
+	if devices == nil {
+		devices, _ = registry.New("")
+	}
+	ids := cfg.Identity
+	if ids == nil {
+		ids, _ = identity.New(nil)
+	}
+
+	e := &Engine{
 		adapters:    make([]Adapter, 0),
 		publishers:  make([]Publisher, 0),
-		stateStore:  statestore.New(),
+		stateStore:  statestore.New(statestore.Config{TTL: cfg.StateTTL, MaxDevices: cfg.MaxDevices, GeohashPrecision: cfg.GeohashPrecision}),
 		trackStore:  ts,
-		throttler:   throttler.New(cfg.RateHz),
-		coordinator: coordinator.New(cfg.ConvertGCJ02, cfg.ConvertBD09),
-		events:      make(chan *models.DroneState, 100),
+		throttler:   th,
+		coordinator: conv,
+		health:      newHealthMonitor(cfg.PublisherDegradedErrors, cfg.PublisherStaleAfterMs),
+		quarantine:  quarantine.New(quarantine.Config{MaxEntries: cfg.QuarantineMaxEntries}),
+		presence:    newPresenceTracker(cfg.DeviceOfflineAfterMs),
+		conflicts:   conflict.New(time.Duration(offlineAfterMs) * time.Millisecond),
+		equipment:   equipment.New(equipment.Config{}),
+		timeline:    timeline.New(timeline.Config{}),
+		router:      routing.New(cfg.RoutingRules),
+		tenants:     cfg.Tenants,
+		devices:     devices,
+		identity:    ids,
+		enrichment:  cfg.Enrichment,
+		coverage:    coverage.New(coverage.Config{CellSizeM: cfg.CoverageCellSizeM, Bucket: cfg.CoverageBucket}),
+		chaos:       chaos.New(),
+		tracer:      cfg.Tracer,
+		bus:         events.NewBus(),
+		queue:       newEventQueue(cfg.QueueSize, cfg.DropPolicy),
+		home:        newHomeTracker(),
+		battery:     newBatteryModel(),
 	}
+	if cfg.Dedup {
+		e.dedup = newDeduplicator(cfg.DedupSources, cfg.DedupPositionM)
+	}
+	if cfg.Batching {
+		e.batchers = make(map[string]*batcher)
+		e.batchSize, e.batchLatency = cfg.BatchMaxSize, cfg.BatchMaxLatency
+	}
+	e.stateStore.SetEvictCallback(e.evictDevice)
+	return e
 }
 
 // RegisterAdapter adds an adapter to the engine
 func (e *Engine) RegisterAdapter(adapter Adapter) {
 	e.adapters = append(e.adapters, adapter)
+	if q, ok := adapter.(Quarantinable); ok {
+		q.SetQuarantine(e.quarantine)
+	}
+	if m, ok := adapter.(MissionSource); ok {
+		m.SetMissionCallback(e.missionChanged(adapter.Name()))
+	}
+	if r, ok := adapter.(RawSource); ok {
+		r.SetRawCallback(e.publishRaw)
+	}
+	if p, ok := adapter.(PresenceSource); ok {
+		p.SetOfflineCallback(e.deviceGone(adapter.Name()))
+	}
+	if l, ok := adapter.(LinkSource); ok {
+		l.SetLinkCallback(e.linkChanged(adapter.Name()))
+	}
 }
 
 // RegisterPublisher adds a publisher to the engine
 func (e *Engine) RegisterPublisher(publisher Publisher) {
 	e.publishers = append(e.publishers, publisher)
+	if bp, ok := publisher.(BatchPublisher); ok && e.batchers != nil {
+		e.batchers[publisher.Name()] = e.newPublisherBatcher(publisher, bp)
+	}
+	e.health.register(publisher.Name())
 }
 
 // Start begins the engine processing
 func (e *Engine) Start(ctx context.Context) error {
+	e.ctx = ctx
+
 	// Start all publishers first
 	for _, pub := range e.publishers {
 		if err := pub.Start(ctx); err != nil {
@@ -83,7 +260,7 @@ func (e *Engine) Start(ctx context.Context) error {
 
 	// Start all adapters
 	for _, adapter := range e.adapters {
-		if err := adapter.Start(ctx, e.events); err != nil {
+		if err := adapter.Start(ctx, e.startIntake(ctx, adapter.Name())); err != nil {
 			return fmt.Errorf("starting adapter %s: %w", adapter.Name(), err)
 		}
 		log.Printf("[Engine] Adapter started: %s", adapter.Name())
@@ -93,6 +270,18 @@ func (e *Engine) Start(ctx context.Context) error {
 	e.wg.Add(1)
 	go e.routeMessages(ctx)
 
+	// Start the publisher health monitor
+	e.wg.Add(1)
+	go e.monitorPublishers(ctx)
+
+	// Start the device presence monitor
+	e.wg.Add(1)
+	go e.monitorDevices(ctx)
+
+	// Start the dropped state warnings
+	e.wg.Add(1)
+	go e.monitorQueue(ctx)
+
 	log.Printf("[Engine] Started with %d adapters and %d publishers",
 		len(e.adapters), len(e.publishers))
 
@@ -107,7 +296,10 @@ func (e *Engine) routeMessages(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		case state := <-e.events:
+		case state := <-e.queue.ch:
+			if e.chaos.DropEvent() {
+				continue
+			}
 			e.processState(state)
 		}
 	}
@@ -115,38 +307,141 @@ func (e *Engine) routeMessages(ctx context.Context) {
 
 // processState handles a single state update
 func (e *Engine) processState(state *models.DroneState) {
+	span := e.startStateSpan(state)
+	defer span.End()
+
+	// Detect device IDs claimed by several sources and apply resolutions
+	if !e.checkConflict(state) {
+		span.SetAttribute("outcome", "conflict")
+		return
+	}
+
+	// Drop samples a source sent again, before any work is done on them
+	if e.dedup != nil && e.dedup.repeated(state) {
+		span.SetAttribute("outcome", "duplicate")
+		return
+	}
+
+	// Shape the state with the configured processors, before sources are
+	// fused, so that unit conversions only see the values of their source
+	if e.enrichment != nil {
+		e.enrichment.Apply(state)
+	}
+
+	// Merge sources seen under aliases into their canonical device
+	e.identity.Apply(state, time.Now())
+
+	// Stamp the owning tenant; adapters cannot choose it
+	state.Tenant = e.tenants.Resolve(state.DeviceID)
+	state.Metadata = e.devices.Metadata(state.DeviceID)
+
 	// Apply coordinate conversion
 	e.applyCoordinateConversion(state)
 
+	// Capture the home position and the distance and bearing to it
+	e.home.apply(state)
+
+	// Estimate the battery endurance from the recent discharge rate
+	e.battery.apply(state)
+
 	// Update state store
 	e.stateStore.Update(state)
 
+	if e.presence.seen(state.DeviceID, time.Now()) {
+		e.bus.Publish(events.Event{Type: events.DeviceOnline, DeviceID: state.DeviceID})
+	}
+
+	// Detect battery swaps and payload changes
+	e.observeEquipment(state)
+
+	// Derive flight events (takeoff, landing, mode changes...)
+	e.observeTimeline(state)
+
 	// Record to track store
 	if e.trackStore != nil {
 		e.trackStore.Record(state)
 	}
 
+	// Aggregate link quality into the coverage grid
+	e.coverage.Observe(state)
+
 	// Check throttle
 	if !e.throttler.ShouldPublish(state) {
+		span.SetAttribute("outcome", "throttled")
 		return
 	}
+	span.SetAttribute("outcome", "published")
 
-	// Publish to all publishers
+	// Publish to the publishers selected by the routing rules
 	for _, pub := range e.publishers {
-		if err := pub.Publish(state); err != nil {
-			log.Printf("[Engine] Publish error (%s): %v", pub.Name(), err)
+		sent, err := e.publish(pub, state, span.Context())
+		if !sent {
+			continue
+		}
+		e.health.record(pub.Name(), err, time.Now())
+		if err != nil {
+			e.bus.Publish(events.Event{
+				Type:     events.PublisherError,
+				DeviceID: state.DeviceID,
+				Source:   pub.Name(),
+				Error:    err.Error(),
+			})
+		}
+	}
+
+	dispatch := e.tracer.StartChild(span.Context(), "dispatch events", tracing.KindInternal, time.Now())
+	e.bus.Publish(events.Event{Type: events.StateUpdated, DeviceID: state.DeviceID, State: state})
+	dispatch.End()
+}
+
+// monitorPublishers periodically checks the connection state of publishers
+// that expose it, so a dead publisher is detected even without traffic
+func (e *Engine) monitorPublishers(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, pub := range e.publishers {
+				if c, ok := pub.(Connectable); ok {
+					e.health.check(pub.Name(), c.IsConnected(), now)
+				}
+			}
 		}
 	}
+}
+
+// monitorDevices reports devices that stopped sending state as offline
+func (e *Engine) monitorDevices(ctx context.Context) {
+	defer e.wg.Done()
 
-	// Call state callback (for WebSocket broadcast)
-	e.mu.RLock()
-	cb := e.stateCallback
-	e.mu.RUnlock()
-	if cb != nil {
-		cb(state)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, id := range e.presence.expire(now) {
+				e.bus.Publish(events.Event{Type: events.DeviceOffline, DeviceID: id})
+			}
+			e.stateStore.Expire(now)
+		}
 	}
 }
 
+// Converter returns the coordinate converter, including the correction grid
+// when one is loaded
+func (e *Engine) Converter() *coordinator.Converter {
+	return e.coordinator
+}
+
 // applyCoordinateConversion converts WGS84 coordinates to GCJ02/BD09 if configured
 func (e *Engine) applyCoordinateConversion(state *models.DroneState) {
 	if e.coordinator == nil {
@@ -173,6 +468,9 @@ func (e *Engine) Stop() error {
 	// Wait for routing to complete
 	e.wg.Wait()
 
+	// Send what is left in the batches before the publishers stop
+	e.flushBatches()
+
 	// Stop publishers
 	for _, pub := range e.publishers {
 		if err := pub.Stop(); err != nil {
@@ -194,6 +492,18 @@ func (e *Engine) GetAllStates() []*models.DroneState {
 	return e.stateStore.GetAll()
 }
 
+// GetStatesInBounds returns the device states positioned within a
+// latitude/longitude rectangle
+func (e *Engine) GetStatesInBounds(b statestore.Bounds) []*models.DroneState {
+	return e.stateStore.InBounds(b)
+}
+
+// GetStatesWithinRadius returns the device states within radiusM meters of
+// a point
+func (e *Engine) GetStatesWithinRadius(lat, lon, radiusM float64) []*models.DroneState {
+	return e.stateStore.WithinRadius(lat, lon, radiusM)
+}
+
 // GetDeviceCount returns the number of tracked devices
 func (e *Engine) GetDeviceCount() int {
 	return e.stateStore.Count()
@@ -204,12 +514,58 @@ func (e *Engine) SetThrottleRate(rateHz float64) {
 	e.throttler.SetRate(rateHz)
 }
 
-// SetStateCallback sets a callback function that will be called for each state update
-// This is used for WebSocket broadcasting
-func (e *Engine) SetStateCallback(cb StateCallback) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	e.stateCallback = cb
+// GetThrottleStatus returns the effective publish rates and per-device
+// throttle counters
+func (e *Engine) GetThrottleStatus() throttler.Status {
+	return e.throttler.Status()
+}
+
+// BoostThrottle temporarily raises the publish rate of one device.
+// Returns when the boost expires.
+func (e *Engine) BoostThrottle(deviceID string, rateHz float64, d time.Duration) (time.Time, error) {
+	expires, err := e.throttler.Boost(deviceID, rateHz, d)
+	if err != nil {
+		return time.Time{}, err
+	}
+	log.Printf("[Engine] Boosted %s to %.1f Hz until %s", deviceID, rateHz, expires.Format(time.RFC3339))
+	return expires, nil
+}
+
+// ClearThrottleBoost returns a device to the default publish rate
+func (e *Engine) ClearThrottleBoost(deviceID string) {
+	e.throttler.ClearBoost(deviceID)
+}
+
+// Events returns the engine's event bus. Subsystems subscribe to it for
+// state updates, device presence and publisher errors.
+func (e *Engine) Events() *events.Bus {
+	return e.bus
+}
+
+// SetPublisherHealthCallback sets a callback that is called when a
+// publisher becomes degraded or recovers
+func (e *Engine) SetPublisherHealthCallback(cb PublisherHealthCallback) {
+	e.health.setCallback(cb)
+}
+
+// GetPublisherHealth returns the health of all registered publishers,
+// with the protocol state of those implementing HealthReporter
+func (e *Engine) GetPublisherHealth() []PublisherHealth {
+	result := e.health.all()
+	for _, pub := range e.publishers {
+		hr, ok := pub.(HealthReporter)
+		if !ok {
+			continue
+		}
+		for i := range result {
+			if result[i].Name == pub.Name() {
+				detail := hr.Health()
+				result[i].Detail = &detail
+				break
+			}
+		}
+	}
+	return result
 }
 
 // GetTrack returns the trajectory for a device
@@ -220,6 +576,14 @@ func (e *Engine) GetTrack(deviceID string, limit int, since int64) []trackstore.
 	return e.trackStore.GetTrack(deviceID, limit, since)
 }
 
+// GetFlights returns the flights segmented from a device's states
+func (e *Engine) GetFlights(deviceID string) []trackstore.Flight {
+	if e.trackStore == nil {
+		return []trackstore.Flight{}
+	}
+	return e.trackStore.GetFlights(deviceID)
+}
+
 // ClearTrack removes all trajectory data for a device
 func (e *Engine) ClearTrack(deviceID string) {
 	if e.trackStore != nil {
@@ -227,6 +591,32 @@ func (e *Engine) ClearTrack(deviceID string) {
 	}
 }
 
+// PruneTracks removes track points recorded before the given time.
+// Returns the number of points removed.
+func (e *Engine) PruneTracks(before time.Time) int {
+	if e.trackStore == nil {
+		return 0
+	}
+	return e.trackStore.Prune(before)
+}
+
+// GetTrackDeviceIDs returns the devices with stored track points
+func (e *Engine) GetTrackDeviceIDs() []string {
+	if e.trackStore == nil {
+		return nil
+	}
+	return e.trackStore.GetDeviceIDs()
+}
+
+// ImportTrack merges track points restored from a backup into a device's
+// track. Returns the number of points added.
+func (e *Engine) ImportTrack(deviceID string, points []trackstore.TrackPoint) int {
+	if e.trackStore == nil {
+		return 0
+	}
+	return e.trackStore.Import(deviceID, points)
+}
+
 // GetTrackSize returns the number of track points for a device
 func (e *Engine) GetTrackSize(deviceID string) int {
 	if e.trackStore == nil {
@@ -235,16 +625,28 @@ func (e *Engine) GetTrackSize(deviceID string) int {
 	return e.trackStore.GetTrackSize(deviceID)
 }
 
+// GetTrackStats returns the memory used by the track store
+func (e *Engine) GetTrackStats() trackstore.Stats {
+	if e.trackStore == nil {
+		return trackstore.Stats{}
+	}
+	return e.trackStore.GetStats()
+}
+
 // IsTrackEnabled returns whether track storage is enabled
 func (e *Engine) IsTrackEnabled() bool {
 	return e.trackStore != nil
 }
 
-// GetAdapterNames returns the names of all registered adapters
+// GetAdapterNames returns the names of all registered adapters, each
+// followed by the adapters it currently hosts
 func (e *Engine) GetAdapterNames() []string {
-	names := make([]string, len(e.adapters))
-	for i, adapter := range e.adapters {
-		names[i] = adapter.Name()
+	names := make([]string, 0, len(e.adapters))
+	for _, adapter := range e.adapters {
+		names = append(names, adapter.Name())
+		if host, ok := adapter.(AdapterHost); ok {
+			names = append(names, host.HostedAdapters()...)
+		}
 	}
 	return names
 }
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// hostAdapter hosts adapters registered at runtime
+type hostAdapter struct {
+	fakeAdapter
+	hosted []string
+}
+
+func (a *hostAdapter) HostedAdapters() []string { return a.hosted }
+
+func TestEngine_GetAdapterNamesHosted(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	e.RegisterAdapter(&fakeAdapter{name: "mavlink"})
+	e.RegisterAdapter(&hostAdapter{
+		fakeAdapter: fakeAdapter{name: "external"},
+		hosted:      []string{"external:lidar", "external:radar"},
+	})
+	e.RegisterAdapter(&fakeAdapter{name: "dji"})
+
+	got := strings.Join(e.GetAdapterNames(), ",")
+	want := "mavlink,external,external:lidar,external:radar,dji"
+	if got != want {
+		t.Errorf("GetAdapterNames() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+// Package enrich shapes drone states with a chain of small processors
+// configured per deployment: converting units reported by a source, adding
+// static tags, renaming the added fields and looking up the place a drone
+// is at. Processors run in the configured order, each on the states of
+// the protocol sources it is limited to.
+package enrich
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Processor types
+const (
+	TypeUnits   = "units"   // Converts numeric fields, e.g. feet to meters
+	TypeTags    = "tags"    // Sets static fields in DroneState.Extra
+	TypeRename  = "rename"  // Renames fields in DroneState.Extra
+	TypeGeocode = "geocode" // Sets the name of the place containing the position
+)
+
+// ErrInvalidSpec is returned for a processor that cannot be built
+var ErrInvalidSpec = errors.New("invalid enrichment processor")
+
+// Spec configures one processor. Only the settings of its type are used.
+type Spec struct {
+	Type    string
+	Sources []string // Protocol sources processed (empty = all)
+
+	Convert map[string]string // units: field -> conversion, e.g. "location.alt_baro": "ft_to_m"
+	Tags    map[string]string // tags: Extra key -> value
+	Rename  map[string]string // rename: old Extra key -> new key
+	Field   string            // geocode: Extra key set to the place name (default "place")
+	Places  []Place           // geocode: places searched in order
+}
+
+// Place is a named circular area for reverse geocoding
+type Place struct {
+	Name    string
+	Lat     float64 // Center latitude in degrees (WGS84)
+	Lon     float64 // Center longitude in degrees (WGS84)
+	RadiusM float64
+}
+
+// Processor changes a state in place
+type Processor interface {
+	Process(state *models.DroneState)
+}
+
+// step is a processor limited to some protocol sources
+type step struct {
+	proc    Processor
+	sources []string
+}
+
+// Chain runs processors in order
+type Chain struct {
+	steps []step
+	types []string
+}
+
+// New builds a chain from processor specs
+func New(specs []Spec) (*Chain, error) {
+	c := &Chain{}
+	for i, spec := range specs {
+		if err := c.Add(spec); err != nil {
+			return nil, fmt.Errorf("processor %d: %w", i, err)
+		}
+	}
+	return c, nil
+}
+
+// Add appends a processor to the chain
+func (c *Chain) Add(spec Spec) error {
+	proc, err := newProcessor(spec)
+	if err != nil {
+		return err
+	}
+	c.steps = append(c.steps, step{proc: proc, sources: spec.Sources})
+	c.types = append(c.types, spec.Type)
+	return nil
+}
+
+// Len returns the number of processors
+func (c *Chain) Len() int {
+	return len(c.steps)
+}
+
+// newProcessor builds the processor of a spec
+func newProcessor(spec Spec) (Processor, error) {
+	switch spec.Type {
+	case TypeUnits:
+		return newUnits(spec.Convert)
+	case TypeTags:
+		if len(spec.Tags) == 0 {
+			return nil, fmt.Errorf("%w: tags is empty", ErrInvalidSpec)
+		}
+		return tags(spec.Tags), nil
+	case TypeRename:
+		if len(spec.Rename) == 0 {
+			return nil, fmt.Errorf("%w: rename is empty", ErrInvalidSpec)
+		}
+		return rename(spec.Rename), nil
+	case TypeGeocode:
+		return newGeocoder(spec.Field, spec.Places)
+	}
+	return nil, fmt.Errorf("%w: unknown type %q, want units, tags, rename or geocode", ErrInvalidSpec, spec.Type)
+}
+
+// Apply runs the chain on a state
+func (c *Chain) Apply(state *models.DroneState) {
+	for _, s := range c.steps {
+		if len(s.sources) > 0 && !slices.Contains(s.sources, state.ProtocolSource) {
+			continue
+		}
+		s.proc.Process(state)
+	}
+}
+
+// Types returns the processor types in order
+func (c *Chain) Types() []string {
+	return append([]string(nil), c.types...)
+}
+
+// setExtra sets a field in state.Extra, creating the map if needed
+func setExtra(state *models.DroneState, key, value string) {
+	if state.Extra == nil {
+		state.Extra = make(map[string]string)
+	}
+	state.Extra[key] = value
+}
+
+// tags sets static fields
+type tags map[string]string
+
+// Process implements Processor
+func (t tags) Process(state *models.DroneState) {
+	for k, v := range t {
+		setExtra(state, k, v)
+	}
+}
+
+// rename renames fields, keeping the value
+type rename map[string]string
+
+// Process implements Processor
+func (r rename) Process(state *models.DroneState) {
+	for from, to := range r {
+		if v, ok := state.Extra[from]; ok {
+			delete(state.Extra, from)
+			state.Extra[to] = v
+		}
+	}
+}
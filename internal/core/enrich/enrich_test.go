@@ -0,0 +1,75 @@
+package enrich
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestChain_Apply(t *testing.T) {
+	c, err := New([]Spec{
+		{Type: TypeUnits, Sources: []string{"udp"}, Convert: map[string]string{"location.alt_baro": "ft_to_m", "velocity.vx": "kn_to_ms"}},
+		{Type: TypeTags, Tags: map[string]string{"site": "north", "fleet": "survey"}},
+		{Type: TypeGeocode, Places: []Place{
+			{Name: "Airfield", Lat: 22.5431, Lon: 114.0579, RadiusM: 500},
+			{Name: "City", Lat: 22.5431, Lon: 114.0579, RadiusM: 20000},
+		}},
+		{Type: TypeRename, Rename: map[string]string{"site": "site_id", "missing": "x"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := strings.Join(c.Types(), ","); got != "units,tags,geocode,rename" {
+		t.Errorf("Types() = %s", got)
+	}
+
+	state := models.NewDroneState("udp-1", "udp")
+	state.Location.Lat, state.Location.Lon = 22.5440, 114.0579 // About 100 m north of the airfield center
+	state.Location.AltBaro = 1000
+	state.Velocity.Vx = 10
+	c.Apply(state)
+
+	if math.Abs(state.Location.AltBaro-304.8) > 1e-9 || math.Abs(state.Velocity.Vx-5.1444) > 1e-4 {
+		t.Errorf("Converted alt_baro = %v, vx = %v", state.Location.AltBaro, state.Velocity.Vx)
+	}
+	want := map[string]string{"site_id": "north", "fleet": "survey", "place": "Airfield"}
+	if len(state.Extra) != len(want) {
+		t.Errorf("Extra = %v, want %v", state.Extra, want)
+	}
+	for k, v := range want {
+		if state.Extra[k] != v {
+			t.Errorf("Extra[%s] = %q, want %q", k, state.Extra[k], v)
+		}
+	}
+
+	// Other sources are not converted; positions outside all places and
+	// without a fix are not named
+	other := models.NewDroneState("mavlink-1", "mavlink")
+	other.Location.AltBaro = 1000
+	c.Apply(other)
+	if other.Location.AltBaro != 1000 || other.Extra["place"] != "" || other.Extra["site_id"] != "north" {
+		t.Errorf("mavlink state = %+v, extra %v", other.Location, other.Extra)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		spec Spec
+		want string
+	}{
+		{Spec{Type: "lookup"}, "unknown type"},
+		{Spec{Type: TypeUnits, Convert: map[string]string{"location.lat": "ft_to_m"}}, `unknown field "location.lat"`},
+		{Spec{Type: TypeUnits, Convert: map[string]string{"location.alt_baro": "furlongs"}}, `unknown conversion "furlongs"`},
+		{Spec{Type: TypeTags}, "tags is empty"},
+		{Spec{Type: TypeRename}, "rename is empty"},
+		{Spec{Type: TypeGeocode, Places: []Place{{Name: "Nowhere"}}}, "positive radius_m"},
+	} {
+		_, err := New([]Spec{{Type: TypeTags, Tags: map[string]string{"a": "b"}}, tt.spec})
+		if !errors.Is(err, ErrInvalidSpec) || !strings.HasPrefix(err.Error(), "processor 1: ") || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("New(%+v) error = %v, want it to mention %s", tt.spec, err, tt.want)
+		}
+	}
+}
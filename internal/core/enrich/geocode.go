@@ -0,0 +1,51 @@
+package enrich
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// defaultPlaceField is the Extra key set by the geocoder
+const defaultPlaceField = "place"
+
+// geocoder is a reverse geocoding stub: it names the position from a
+// configured list of places instead of querying a geocoding service. The
+// first place containing the position wins; outside all places the field
+// is left unset.
+type geocoder struct {
+	field  string
+	places []Place
+}
+
+// newGeocoder checks the places
+func newGeocoder(field string, places []Place) (*geocoder, error) {
+	if field == "" {
+		field = defaultPlaceField
+	}
+	if len(places) == 0 {
+		return nil, fmt.Errorf("%w: places is empty", ErrInvalidSpec)
+	}
+	for _, p := range places {
+		if p.Name == "" || p.RadiusM <= 0 || math.Abs(p.Lat) > 90 || math.Abs(p.Lon) > 180 {
+			return nil, fmt.Errorf("%w: place %q needs a name, a valid center and a positive radius_m", ErrInvalidSpec, p.Name)
+		}
+	}
+	return &geocoder{field: field, places: places}, nil
+}
+
+// Process implements Processor
+func (g *geocoder) Process(state *models.DroneState) {
+	lat, lon := state.Location.Lat, state.Location.Lon
+	if lat == 0 && lon == 0 {
+		return // No fix
+	}
+	for _, p := range g.places {
+		if coordinator.HaversineDistance(lat, lon, p.Lat, p.Lon) <= p.RadiusM {
+			setExtra(state, g.field, p.Name)
+			return
+		}
+	}
+}
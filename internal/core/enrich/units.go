@@ -0,0 +1,82 @@
+package enrich
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// conversions are the unit conversions by name, as factors
+var conversions = map[string]float64{
+	"ft_to_m":    0.3048,
+	"m_to_ft":    1 / 0.3048,
+	"kn_to_ms":   1852.0 / 3600,
+	"ms_to_kn":   3600 / 1852.0,
+	"kmh_to_ms":  1 / 3.6,
+	"ms_to_kmh":  3.6,
+	"mph_to_ms":  0.44704,
+	"rad_to_deg": 180 / math.Pi,
+	"deg_to_rad": math.Pi / 180,
+}
+
+// numericFields are the state fields that can be converted, by JSON path
+var numericFields = map[string]func(s *models.DroneState) *float64{
+	"location.alt_baro": func(s *models.DroneState) *float64 { return &s.Location.AltBaro },
+	"location.alt_gnss": func(s *models.DroneState) *float64 { return &s.Location.AltGNSS },
+	"attitude.roll":     func(s *models.DroneState) *float64 { return &s.Attitude.Roll },
+	"attitude.pitch":    func(s *models.DroneState) *float64 { return &s.Attitude.Pitch },
+	"attitude.yaw":      func(s *models.DroneState) *float64 { return &s.Attitude.Yaw },
+	"velocity.vx":       func(s *models.DroneState) *float64 { return &s.Velocity.Vx },
+	"velocity.vy":       func(s *models.DroneState) *float64 { return &s.Velocity.Vy },
+	"velocity.vz":       func(s *models.DroneState) *float64 { return &s.Velocity.Vz },
+}
+
+// units converts numeric fields, typically of a source that does not
+// report SI units
+type units []unitConversion
+
+// unitConversion scales one field
+type unitConversion struct {
+	field  func(s *models.DroneState) *float64
+	factor float64
+}
+
+// newUnits builds the conversions, checking field and conversion names
+func newUnits(convert map[string]string) (units, error) {
+	if len(convert) == 0 {
+		return nil, fmt.Errorf("%w: convert is empty", ErrInvalidSpec)
+	}
+	var u units
+	for path, name := range convert {
+		field, ok := numericFields[path]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q, want one of %s", ErrInvalidSpec, path, names(numericFields))
+		}
+		factor, ok := conversions[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown conversion %q, want one of %s", ErrInvalidSpec, name, names(conversions))
+		}
+		u = append(u, unitConversion{field: field, factor: factor})
+	}
+	return u, nil
+}
+
+// Process implements Processor
+func (u units) Process(state *models.DroneState) {
+	for _, c := range u {
+		*c.field(state) *= c.factor
+	}
+}
+
+// names lists the keys of a map, sorted
+func names[V any](m map[string]V) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"log"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/equipment"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Equipment returns the battery and payload tracker
+func (e *Engine) Equipment() *equipment.Tracker {
+	return e.equipment
+}
+
+// observeEquipment records the state's battery and payload and publishes an
+// EquipmentChanged event for each swap
+func (e *Engine) observeEquipment(state *models.DroneState) {
+	for _, c := range e.equipment.Observe(state) {
+		log.Printf("[Engine] %s on %s: %s -> %s", c.Type, c.DeviceID, c.Previous, c.Current)
+		change := c
+		e.bus.Publish(events.Event{
+			Type:      events.EquipmentChanged,
+			DeviceID:  c.DeviceID,
+			Equipment: &change,
+		})
+	}
+}
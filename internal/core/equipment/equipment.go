@@ -0,0 +1,218 @@
+// Package equipment detects battery swaps and payload changes from the
+// battery serial and payload ID reported in telemetry, and keeps per-battery
+// usage statistics so maintenance analytics stay accurate when a drone
+// flies several packs.
+package equipment
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// ChangeType identifies what was changed on a device
+type ChangeType string
+
+const (
+	ChangeBatterySwap ChangeType = "battery_swap"
+	ChangePayload     ChangeType = "payload_change"
+)
+
+// DefaultMaxChanges is the number of changes kept when Config.MaxChanges is 0
+const DefaultMaxChanges = 500
+
+// maxFlightGapMs is the longest gap between two armed states still counted
+// as flight time, so a telemetry outage does not inflate it
+const maxFlightGapMs = 10000
+
+// Change is a battery swap or payload change
+type Change struct {
+	ID        string     `json:"id"`
+	DeviceID  string     `json:"device_id"`
+	Type      ChangeType `json:"type"`
+	Previous  string     `json:"previous"`            // Serial or payload ID before the change
+	Current   string     `json:"current"`             // Serial or payload ID after the change
+	InFlight  bool       `json:"in_flight,omitempty"` // Reported while armed, e.g. a misread serial
+	Timestamp int64      `json:"timestamp"`           // Unix ms
+}
+
+// Battery holds the usage statistics of a battery pack. The "since
+// installed" counters are reset each time the pack is swapped in.
+type Battery struct {
+	Serial       string `json:"serial"`
+	DeviceID     string `json:"device_id"`      // Device it is installed in or was last seen in
+	InstalledAt  int64  `json:"installed_at"`   // Unix ms of the last swap-in
+	Flights      int    `json:"flights"`        // Flights since installed
+	FlightTimeMs int64  `json:"flight_time_ms"` // Armed time since installed
+	MinPercent   int    `json:"min_percent"`    // Lowest charge since installed
+	LastSeen     int64  `json:"last_seen"`      // Unix ms
+
+	TotalFlights      int   `json:"total_flights"`
+	TotalFlightTimeMs int64 `json:"total_flight_time_ms"`
+}
+
+// Config holds tracker settings
+type Config struct {
+	MaxChanges int // Changes kept in history (0 = DefaultMaxChanges)
+}
+
+// device is the last known equipment of a device
+type device struct {
+	battery  string
+	payload  string
+	armed    bool
+	lastSeen int64
+}
+
+// Tracker records the equipment reported by each device
+type Tracker struct {
+	maxChanges int
+
+	mu        sync.RWMutex
+	devices   map[string]*device
+	batteries map[string]*Battery
+	changes   []Change
+}
+
+// New creates a tracker
+func New(cfg Config) *Tracker {
+	if cfg.MaxChanges <= 0 {
+		cfg.MaxChanges = DefaultMaxChanges
+	}
+	return &Tracker{
+		maxChanges: cfg.MaxChanges,
+		devices:    make(map[string]*device),
+		batteries:  make(map[string]*Battery),
+	}
+}
+
+// Observe updates the tracker from a state and returns the changes it
+// revealed. States without a battery serial or payload ID leave the last
+// known value in place.
+func (t *Tracker) Observe(state *models.DroneState) []Change {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := state.Timestamp
+	d, ok := t.devices[state.DeviceID]
+	if !ok {
+		d = &device{}
+		t.devices[state.DeviceID] = d
+	}
+	armed := state.Status.Armed
+
+	var changes []Change
+	if serial := state.Status.BatterySerial; serial != "" && serial != d.battery {
+		if d.battery != "" {
+			changes = append(changes, t.addChange(state.DeviceID, ChangeBatterySwap, d.battery, serial, armed, now))
+		}
+		t.install(serial, state, now)
+		d.battery = serial
+	}
+	if id := state.Status.PayloadID; id != "" && id != d.payload {
+		if d.payload != "" {
+			changes = append(changes, t.addChange(state.DeviceID, ChangePayload, d.payload, id, armed, now))
+		}
+		d.payload = id
+	}
+
+	if b := t.batteries[d.battery]; b != nil {
+		if armed && !d.armed {
+			b.Flights++
+			b.TotalFlights++
+		}
+		if armed && d.armed && now > d.lastSeen && now-d.lastSeen <= maxFlightGapMs {
+			b.FlightTimeMs += now - d.lastSeen
+			b.TotalFlightTimeMs += now - d.lastSeen
+		}
+		if state.Status.BatteryPercent < b.MinPercent {
+			b.MinPercent = state.Status.BatteryPercent
+		}
+		b.LastSeen = now
+	}
+
+	d.armed = armed
+	d.lastSeen = now
+	return changes
+}
+
+// install resets the "since installed" statistics of a pack that was just
+// put into a device. Caller must hold the lock.
+func (t *Tracker) install(serial string, state *models.DroneState, now int64) {
+	b, ok := t.batteries[serial]
+	if !ok {
+		b = &Battery{Serial: serial}
+		t.batteries[serial] = b
+	}
+	b.DeviceID = state.DeviceID
+	b.InstalledAt = now
+	b.Flights = 0
+	b.FlightTimeMs = 0
+	b.MinPercent = state.Status.BatteryPercent
+}
+
+// addChange appends a change to the history. Caller must hold the lock.
+func (t *Tracker) addChange(deviceID string, typ ChangeType, previous, current string, inFlight bool, now int64) Change {
+	c := Change{
+		ID:        uuid.New().String(),
+		DeviceID:  deviceID,
+		Type:      typ,
+		Previous:  previous,
+		Current:   current,
+		InFlight:  inFlight,
+		Timestamp: now,
+	}
+	t.changes = append(t.changes, c)
+	if len(t.changes) > t.maxChanges {
+		t.changes = t.changes[len(t.changes)-t.maxChanges:]
+	}
+	return c
+}
+
+// Changes returns the change history, newest first, optionally filtered by
+// device. A limit of 0 returns all changes.
+func (t *Tracker) Changes(deviceID string, limit int) []Change {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := []Change{}
+	for i := len(t.changes) - 1; i >= 0; i-- {
+		c := t.changes[i]
+		if deviceID != "" && c.DeviceID != deviceID {
+			continue
+		}
+		result = append(result, c)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// Batteries returns the statistics of every battery seen, sorted by serial
+func (t *Tracker) Batteries() []Battery {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]Battery, 0, len(t.batteries))
+	for _, b := range t.batteries {
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Serial < result[j].Serial })
+	return result
+}
+
+// Battery returns the statistics of one battery pack
+func (t *Tracker) Battery(serial string) (Battery, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	b, ok := t.batteries[serial]
+	if !ok {
+		return Battery{}, false
+	}
+	return *b, true
+}
@@ -0,0 +1,105 @@
+package equipment
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// observe feeds a state with the given equipment to the tracker
+func observe(t *Tracker, ts int64, serial, payload string, armed bool, percent int) []Change {
+	state := models.NewDroneState("drone-001", "dji")
+	state.Timestamp = ts
+	state.Status.BatterySerial = serial
+	state.Status.PayloadID = payload
+	state.Status.Armed = armed
+	state.Status.BatteryPercent = percent
+	return t.Observe(state)
+}
+
+func TestTracker_BatterySwap(t *testing.T) {
+	tr := New(Config{})
+
+	// First pack: two flights of 3 s and 2 s
+	if changes := observe(tr, 0, "PACK-A", "", false, 100); len(changes) != 0 {
+		t.Fatalf("first battery should not be a swap, got %+v", changes)
+	}
+	for ts := int64(1000); ts <= 4000; ts += 1000 {
+		observe(tr, ts, "PACK-A", "", true, 90)
+	}
+	observe(tr, 5000, "PACK-A", "", false, 60)
+	observe(tr, 6000, "", "", true, 55) // Serial not in every state
+	observe(tr, 8000, "", "", true, 40)
+	observe(tr, 9000, "PACK-A", "", false, 40)
+
+	a, _ := tr.Battery("PACK-A")
+	if a.Flights != 2 || a.FlightTimeMs != 5000 || a.MinPercent != 40 {
+		t.Errorf("PACK-A = %+v, want 2 flights, 5000 ms, min 40%%", a)
+	}
+
+	changes := observe(tr, 10000, "PACK-B", "", false, 100)
+	if len(changes) != 1 || changes[0].Type != ChangeBatterySwap || changes[0].Previous != "PACK-A" || changes[0].Current != "PACK-B" || changes[0].InFlight {
+		t.Fatalf("swap = %+v", changes)
+	}
+	observe(tr, 11000, "PACK-B", "", true, 95)
+	observe(tr, 12000, "PACK-B", "", true, 90)
+
+	// The new pack's statistics start from the swap
+	b, _ := tr.Battery("PACK-B")
+	if b.Flights != 1 || b.FlightTimeMs != 1000 || b.MinPercent != 90 || b.InstalledAt != 10000 {
+		t.Errorf("PACK-B = %+v", b)
+	}
+	// The removed pack is no longer charged for flights
+	if a2, _ := tr.Battery("PACK-A"); a2.Flights != 2 || a2.FlightTimeMs != 5000 {
+		t.Errorf("PACK-A after swap = %+v", a2)
+	}
+
+	// Swapping a pack back in resets its since-installed counters only
+	observe(tr, 13000, "PACK-A", "", false, 100)
+	a, _ = tr.Battery("PACK-A")
+	if a.Flights != 0 || a.FlightTimeMs != 0 || a.TotalFlights != 2 || a.TotalFlightTimeMs != 5000 {
+		t.Errorf("PACK-A reinstalled = %+v", a)
+	}
+}
+
+func TestTracker_PayloadChange(t *testing.T) {
+	tr := New(Config{})
+	observe(tr, 0, "", "Zenmuse H20", false, 100)
+	observe(tr, 1000, "", "", false, 100)
+
+	changes := observe(tr, 2000, "", "Zenmuse L1", true, 100)
+	if len(changes) != 1 || changes[0].Type != ChangePayload || !changes[0].InFlight {
+		t.Fatalf("payload change = %+v", changes)
+	}
+	if got := tr.Changes("drone-001", 0); len(got) != 1 || got[0].Previous != "Zenmuse H20" {
+		t.Errorf("Changes() = %+v", got)
+	}
+	if got := tr.Changes("other", 0); len(got) != 0 {
+		t.Errorf("Changes(other) = %+v", got)
+	}
+}
+
+func TestTracker_FlightGap(t *testing.T) {
+	tr := New(Config{})
+	observe(tr, 0, "PACK-A", "", true, 100)
+	observe(tr, 60000, "PACK-A", "", true, 90) // Telemetry outage
+	observe(tr, 61000, "PACK-A", "", true, 90)
+
+	if a, _ := tr.Battery("PACK-A"); a.FlightTimeMs != 1000 {
+		t.Errorf("FlightTimeMs = %d, want 1000 (gap not counted)", a.FlightTimeMs)
+	}
+}
+
+func TestTracker_MaxChanges(t *testing.T) {
+	tr := New(Config{MaxChanges: 2})
+	for i, serial := range []string{"A", "B", "C", "D"} {
+		observe(tr, int64(i), serial, "", false, 100)
+	}
+	changes := tr.Changes("", 0)
+	if len(changes) != 2 || changes[0].Current != "D" || changes[1].Current != "C" {
+		t.Errorf("Changes() = %+v, want the 2 newest", changes)
+	}
+	if limited := tr.Changes("", 1); len(limited) != 1 {
+		t.Errorf("Changes(limit 1) returned %d", len(limited))
+	}
+}
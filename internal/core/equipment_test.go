@@ -0,0 +1,27 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/equipment"
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestEngine_EquipmentChanged(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 100})
+	var changes []events.Event
+	e.Events().Subscribe("test", func(ev events.Event) { changes = append(changes, ev) }, events.EquipmentChanged)
+
+	for _, serial := range []string{"PACK-A", "PACK-A", "PACK-B"} {
+		state := models.NewDroneState("drone-001", "dji")
+		state.Status.BatterySerial = serial
+		e.processState(state)
+	}
+	if len(changes) != 1 || changes[0].Equipment == nil || changes[0].Equipment.Type != equipment.ChangeBatterySwap {
+		t.Fatalf("Equipment events = %+v, want one battery swap", changes)
+	}
+	if got := e.Equipment().Batteries(); len(got) != 2 {
+		t.Errorf("Batteries() = %+v, want both packs", got)
+	}
+}
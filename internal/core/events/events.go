@@ -0,0 +1,142 @@
+// Package events provides the gateway's internal event bus. The engine and
+// the API subsystems publish typed events; the WebSocket hub, alerter,
+// geofence engine and notifiers subscribe to the ones they need.
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/conflict"
+	"github.com/open-uav/telemetry-bridge/internal/core/equipment"
+	"github.com/open-uav/telemetry-bridge/internal/core/geofence"
+	"github.com/open-uav/telemetry-bridge/internal/core/timeline"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Type identifies an event
+type Type string
+
+const (
+	StateUpdated   Type = "state_updated"   // A state passed the throttle and was published
+	DeviceOnline   Type = "device_online"   // First state from a device, or first after going offline
+	DeviceOffline  Type = "device_offline"  // No state from a device within the offline timeout
+	AlertRaised    Type = "alert_raised"    // The alerter generated an alert
+	BreachDetected Type = "breach_detected" // A drone entered or left a geofence
+	PublisherError Type = "publisher_error" // A publisher failed to publish a state
+	DeviceConflict Type = "device_conflict" // A device ID is claimed by more than one protocol source
+
+	EquipmentChanged Type = "equipment_changed" // A device reported a different battery pack or payload
+	PredictedBreach  Type = "predicted_breach"  // A drone's velocity projects a geofence crossing within the prediction horizon
+	AlertEscalated   Type = "alert_escalated"   // An alert stayed unacknowledged past an escalation policy's delay
+	DeviceEvicted    Type = "device_evicted"    // A device was dropped from the state cache; Source is the reason
+	MissionChanged   Type = "mission_changed"   // An adapter captured a different mission plan for a device
+	AdapterStatus    Type = "adapter_status"    // An adapter's link to its drones opened or was lost; Source is the adapter
+	FlightEvent      Type = "flight_event"      // A state transition revealed a takeoff, landing, mode change or similar
+)
+
+// Event is a typed event. Only the fields relevant to the type are set.
+type Event struct {
+	Type      Type               `json:"type"`
+	DeviceID  string             `json:"device_id,omitempty"`
+	Source    string             `json:"source,omitempty"` // Emitting component, e.g. publisher name
+	Timestamp int64              `json:"timestamp"`        // Unix milliseconds
+	State     *models.DroneState `json:"state,omitempty"`  // StateUpdated
+	Alert     *alerter.Alert     `json:"alert,omitempty"`  // AlertRaised, AlertEscalated
+	Breach    *geofence.Breach   `json:"breach,omitempty"` // BreachDetected, PredictedBreach
+	Error     string             `json:"error,omitempty"`  // PublisherError, AdapterStatus
+
+	Conflict  *conflict.Conflict `json:"conflict,omitempty"`  // DeviceConflict
+	Equipment *equipment.Change  `json:"equipment,omitempty"` // EquipmentChanged
+	Mission   *models.Mission    `json:"mission,omitempty"`   // MissionChanged
+	Link      *models.LinkStatus `json:"link,omitempty"`      // AdapterStatus
+	Flight    *timeline.Event    `json:"flight,omitempty"`    // FlightEvent
+}
+
+// Handler receives events
+type Handler func(Event)
+
+// subscription is a registered handler
+type subscription struct {
+	id      int
+	name    string
+	types   map[Type]bool // Empty means all types
+	handler Handler
+}
+
+// Bus dispatches events to subscribers synchronously, in subscription
+// order, on the publisher's goroutine. Handlers may publish further events.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []*subscription
+	nextID int
+}
+
+// NewBus creates an event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for the given types (all types if none are
+// given) and returns a function that removes it
+func (b *Bus) Subscribe(name string, handler Handler, types ...Type) func() {
+	sub := &subscription{name: name, handler: handler, types: make(map[Type]bool)}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	sub.id = b.nextID
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == sub.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers an event to every matching subscriber. A panicking
+// subscriber is logged and does not affect the others.
+func (b *Bus) Publish(ev Event) {
+	if ev.Timestamp == 0 {
+		ev.Timestamp = time.Now().UnixMilli()
+	}
+
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if len(sub.types) > 0 && !sub.types[ev.Type] {
+			continue
+		}
+		b.deliver(sub, ev)
+	}
+}
+
+// deliver calls one subscriber, recovering from panics
+func (b *Bus) deliver(sub *subscription, ev Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Events] Subscriber %s panicked on %s: %v", sub.name, ev.Type, r)
+		}
+	}()
+	sub.handler(ev)
+}
+
+// Subscribers returns the number of subscribers
+func (b *Bus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
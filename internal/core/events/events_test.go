@@ -0,0 +1,68 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestBus_PublishOrderAndFilter(t *testing.T) {
+	bus := NewBus()
+	var got []string
+
+	bus.Subscribe("first", func(ev Event) { got = append(got, "first:"+string(ev.Type)) }, StateUpdated)
+	bus.Subscribe("all", func(ev Event) { got = append(got, "all:"+string(ev.Type)) })
+	bus.Subscribe("offline", func(ev Event) { got = append(got, "offline:"+string(ev.Type)) }, DeviceOffline)
+
+	bus.Publish(Event{Type: StateUpdated, State: models.NewDroneState("uav-1", "test")})
+	bus.Publish(Event{Type: DeviceOffline, DeviceID: "uav-1"})
+
+	want := []string{"first:state_updated", "all:state_updated", "all:device_offline", "offline:device_offline"}
+	if len(got) != len(want) {
+		t.Fatalf("Delivered %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Delivery %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	bus := NewBus()
+	calls := 0
+	unsubscribe := bus.Subscribe("counter", func(Event) { calls++ })
+
+	bus.Publish(Event{Type: DeviceOnline})
+	unsubscribe()
+	bus.Publish(Event{Type: DeviceOnline})
+
+	if calls != 1 {
+		t.Errorf("Calls = %d, want 1", calls)
+	}
+	if bus.Subscribers() != 0 {
+		t.Errorf("Subscribers = %d, want 0", bus.Subscribers())
+	}
+}
+
+func TestBus_NestedPublishAndPanic(t *testing.T) {
+	bus := NewBus()
+	var alerts int
+
+	bus.Subscribe("broken", func(Event) { panic("boom") }, BreachDetected)
+	bus.Subscribe("alerter", func(ev Event) {
+		bus.Publish(Event{Type: AlertRaised, DeviceID: ev.DeviceID})
+	}, BreachDetected)
+	bus.Subscribe("hub", func(ev Event) {
+		alerts++
+		if ev.Timestamp == 0 {
+			t.Error("Publish should stamp events")
+		}
+	}, AlertRaised)
+
+	bus.Publish(Event{Type: BreachDetected, DeviceID: "uav-1"})
+
+	if alerts != 1 {
+		t.Errorf("Nested AlertRaised deliveries = %d, want 1", alerts)
+	}
+}
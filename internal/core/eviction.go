@@ -0,0 +1,29 @@
+package core
+
+import (
+	"log"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/statestore"
+)
+
+// evictDevice cleans up after a device is dropped from the state store. A
+// device still online is reported offline first; subscribers of
+// DeviceEvicted drop their own per-device state.
+func (e *Engine) evictDevice(deviceID string, reason statestore.EvictReason) {
+	log.Printf("[Engine] Evicted device %s from the state cache (%s)", deviceID, reason)
+	if e.presence.forget(deviceID) {
+		e.bus.Publish(events.Event{Type: events.DeviceOffline, DeviceID: deviceID})
+	}
+	if e.trackStore != nil {
+		e.trackStore.ClearTrack(deviceID)
+	}
+	e.home.forget(deviceID)
+	e.identity.Forget(deviceID)
+	e.battery.forget(deviceID)
+	e.timeline.Forget(deviceID)
+	if e.dedup != nil {
+		e.dedup.forget(deviceID)
+	}
+	e.bus.Publish(events.Event{Type: events.DeviceEvicted, DeviceID: deviceID, Source: string(reason)})
+}
@@ -0,0 +1,197 @@
+// Package fanout relays messages between gateway instances over a Redis
+// pub/sub channel. Each instance publishes what happens locally and
+// receives what the other instances publish; its own messages are skipped.
+// Messages published while Redis is unreachable are dropped.
+package fanout
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/backoff"
+	"github.com/open-uav/telemetry-bridge/internal/core/resp"
+)
+
+// dialTimeout bounds connecting and each publish round trip
+const dialTimeout = 5 * time.Second
+
+// queueSize is the number of messages waiting to be published
+const queueSize = 256
+
+// envelope tags a message with the instance that published it
+type envelope struct {
+	Instance string          `json:"instance"`
+	Message  json.RawMessage `json:"message"`
+}
+
+// Bus relays messages between instances
+type Bus struct {
+	cfg      config.FanoutConfig
+	instance string
+	out      chan []byte
+	dropped  atomic.Uint64
+}
+
+// New creates a bus with a random instance ID
+func New(cfg config.FanoutConfig) *Bus {
+	return &Bus{
+		cfg:      cfg,
+		instance: uuid.New().String(),
+		out:      make(chan []byte, queueSize),
+	}
+}
+
+// Start publishes and receives in the background until ctx is done.
+// handle is called with each message of the other instances, one at a time.
+func (b *Bus) Start(ctx context.Context, handle func(message []byte)) {
+	go b.publishLoop(ctx)
+	go b.subscribeLoop(ctx, handle)
+}
+
+// Publish queues a JSON message for the other instances. It never blocks:
+// the message is dropped if the queue is full.
+func (b *Bus) Publish(message []byte) {
+	payload, err := json.Marshal(envelope{Instance: b.instance, Message: message})
+	if err != nil {
+		log.Printf("[Fanout] Failed to encode message: %v", err)
+		return
+	}
+	select {
+	case b.out <- payload:
+	default:
+		b.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of messages that were not published
+func (b *Bus) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+// connect dials the server and authenticates
+func (b *Bus) connect() (*resp.Conn, error) {
+	c, err := resp.Dial(b.cfg.Address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Handshake(b.cfg.Username, b.cfg.Password, 0); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// publishLoop publishes the queued messages, pipelining those that queued
+// up during a round trip. While disconnected, messages are dropped until
+// the next reconnect attempt is due.
+func (b *Bus) publishLoop(ctx context.Context) {
+	var c *resp.Conn
+	defer func() {
+		if c != nil {
+			c.Close()
+		}
+	}()
+	bo := backoff.New(0, 0)
+	var retryAt time.Time
+
+	for {
+		var payload []byte
+		select {
+		case <-ctx.Done():
+			return
+		case payload = <-b.out:
+		}
+
+		cmds := [][]string{{"PUBLISH", b.cfg.Channel, string(payload)}}
+	drain:
+		for len(cmds) < queueSize {
+			select {
+			case payload = <-b.out:
+				cmds = append(cmds, []string{"PUBLISH", b.cfg.Channel, string(payload)})
+			default:
+				break drain
+			}
+		}
+
+		if c == nil {
+			if time.Now().Before(retryAt) {
+				b.dropped.Add(uint64(len(cmds)))
+				continue
+			}
+			var err error
+			if c, err = b.connect(); err != nil {
+				retryAt = time.Now().Add(bo.Next())
+				b.dropped.Add(uint64(len(cmds)))
+				log.Printf("[Fanout] Redis %s not reachable, dropping messages: %v", b.cfg.Address, err)
+				continue
+			}
+			bo.Reset()
+		}
+
+		if _, err := c.Pipeline(cmds...); err != nil {
+			c.Close()
+			c = nil
+			b.dropped.Add(uint64(len(cmds)))
+			log.Printf("[Fanout] Publish failed, reconnecting: %v", err)
+		}
+	}
+}
+
+// subscribeLoop receives the messages of the other instances, resubscribing
+// with backoff when the connection is lost
+func (b *Bus) subscribeLoop(ctx context.Context, handle func(message []byte)) {
+	bo := backoff.New(0, 0)
+	for {
+		err := b.subscribe(ctx, handle, bo)
+		if ctx.Err() != nil {
+			return
+		}
+		delay := bo.Next()
+		log.Printf("[Fanout] Subscription to %s lost, retrying in %s: %v", b.cfg.Channel, delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// subscribe receives messages on one connection until it fails or ctx is
+// done
+func (b *Bus) subscribe(ctx context.Context, handle func(message []byte), bo *backoff.Backoff) error {
+	c, err := b.connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	stop := context.AfterFunc(ctx, func() { c.Close() })
+	defer stop()
+
+	if err := c.Subscribe(b.cfg.Channel); err != nil {
+		return err
+	}
+	bo.Reset()
+	log.Printf("[Fanout] Subscribed to %s on %s as instance %s", b.cfg.Channel, b.cfg.Address, b.instance)
+
+	for {
+		_, payload, err := c.Receive()
+		if err != nil {
+			return err
+		}
+		var env envelope
+		if err := json.Unmarshal([]byte(payload), &env); err != nil {
+			log.Printf("[Fanout] Ignoring malformed message: %v", err)
+			continue
+		}
+		if env.Instance == b.instance {
+			continue
+		}
+		handle(env.Message)
+	}
+}
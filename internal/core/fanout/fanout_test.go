@@ -0,0 +1,162 @@
+package fanout
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+)
+
+// pubsubServer is a fake Redis server that delivers PUBLISH to SUBSCRIBE
+type pubsubServer struct {
+	ln          net.Listener
+	mu          sync.Mutex
+	subscribers map[net.Conn]string // Connection -> channel
+}
+
+func newPubsubServer(t *testing.T) *pubsubServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &pubsubServer{ln: ln, subscribers: make(map[net.Conn]string)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *pubsubServer) serve(c net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+	r := bufio.NewReader(c)
+	for {
+		cmd, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		switch cmd[0] {
+		case "SUBSCRIBE":
+			s.subscribers[c] = cmd[1]
+			fmt.Fprintf(c, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(cmd[1]), cmd[1])
+		case "PUBLISH":
+			n := 0
+			for sub, channel := range s.subscribers {
+				if channel == cmd[1] {
+					fmt.Fprintf(sub, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(channel), channel, len(cmd[2]), cmd[2])
+					n++
+				}
+			}
+			fmt.Fprintf(c, ":%d\r\n", n)
+		default:
+			io.WriteString(c, "+OK\r\n")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *pubsubServer) subscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers)
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestBus(t *testing.T) {
+	srv := newPubsubServer(t)
+	cfg := config.FanoutConfig{Address: srv.ln.Addr().String(), Channel: "outb:ws"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := map[string]chan string{"a": make(chan string, 10), "b": make(chan string, 10)}
+	buses := map[string]*Bus{"a": New(cfg), "b": New(cfg)}
+	for name, bus := range buses {
+		bus.Start(ctx, func(message []byte) { received[name] <- string(message) })
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.subscriberCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	buses["a"].Publish([]byte(`{"type":"state_update"}`))
+	select {
+	case msg := <-received["b"]:
+		if msg != `{"type":"state_update"}` {
+			t.Errorf("b received %s", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("b did not receive the message of a")
+	}
+	select {
+	case msg := <-received["a"]:
+		t.Errorf("a received its own message %s", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if dropped := buses["a"].Dropped(); dropped != 0 {
+		t.Errorf("Dropped = %d, want 0", dropped)
+	}
+}
+
+func TestBus_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := New(config.FanoutConfig{Address: addr, Channel: "outb:ws"})
+	bus.Start(ctx, func([]byte) {})
+
+	bus.Publish([]byte(`{}`))
+	deadline := time.Now().Add(2 * time.Second)
+	for bus.Dropped() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if bus.Dropped() != 1 {
+		t.Errorf("Dropped = %d, want 1 while Redis is unreachable", bus.Dropped())
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // GeofenceType represents the type of geofence
@@ -34,6 +36,17 @@ type Geofence struct {
 	Enabled      bool         `json:"enabled"`
 	CreatedAt    int64        `json:"created_at"`
 	UpdatedAt    int64        `json:"updated_at"`
+
+	Severity alerter.AlertSeverity `json:"severity"`           // Severity of breach alerts (default warning)
+	Channels []string              `json:"channels,omitempty"` // Notification channels breach alerts are sent to
+	Tenant   string                `json:"tenant,omitempty"`   // Owning tenant; empty applies to every device
+	Datum    string                `json:"datum,omitempty"`    // Datum of Coordinates and Center: wgs84 (default), gcj02 or bd09
+	Group    string                `json:"group,omitempty"`    // ID of the group the fence belongs to
+
+	// Loitering detection: a dwell breach is reported once per stay longer
+	// than the limit on the same side of the fence (0 = off)
+	DwellInsideSec  int `json:"dwell_inside_sec,omitempty"`
+	DwellOutsideSec int `json:"dwell_outside_sec,omitempty"`
 }
 
 // BreachType represents the type of geofence breach
@@ -42,6 +55,7 @@ type BreachType string
 const (
 	BreachTypeEnter BreachType = "enter"
 	BreachTypeExit  BreachType = "exit"
+	BreachTypeDwell BreachType = "dwell" // Stayed inside or outside longer than the fence allows
 )
 
 // Breach represents a geofence breach event
@@ -54,21 +68,68 @@ type Breach struct {
 	Lon        float64    `json:"lon"`
 	Alt        float64    `json:"alt"`
 	Timestamp  int64      `json:"timestamp"`
+
+	// Copied from the geofence when the breach is detected
+	GeofenceName string                `json:"geofence_name"`
+	Severity     alerter.AlertSeverity `json:"severity"`
+	Channels     []string              `json:"channels,omitempty"`
+	GroupID      string                `json:"group_id,omitempty"`
+
+	// Set for breaches projected from the current velocity; Lat/Lon/Alt are
+	// then the projected crossing point
+	Predicted bool    `json:"predicted,omitempty"`
+	ETASec    float64 `json:"eta_sec,omitempty"` // Seconds until the projected crossing
+
+	// Set for dwell breaches
+	Inside   bool    `json:"inside,omitempty"`    // The drone stayed inside, not outside
+	DwellSec float64 `json:"dwell_sec,omitempty"` // Seconds spent on the same side of the fence
+}
+
+// dwellTimer tracks how long a device has been on one side of a fence
+type dwellTimer struct {
+	inside   bool
+	since    time.Time
+	reported bool
+}
+
+// predictStep is the interval at which a state is projected along its
+// velocity when predicting breaches
+const predictStep = 500 * time.Millisecond
+
+// DefaultSeverity is the breach alert severity for geofences without one
+const DefaultSeverity = alerter.SeverityWarning
+
+// ValidSeverity reports whether s is a valid breach alert severity
+func ValidSeverity(s alerter.AlertSeverity) bool {
+	return s == alerter.SeverityInfo || s == alerter.SeverityWarning || s == alerter.SeverityCritical
 }
 
 // Engine handles geofence management and breach detection
 type Engine struct {
 	geofences    map[string]*Geofence
+	groups       map[string]*Group
 	deviceStates map[string]map[string]bool // deviceID -> geofenceID -> inside
 	breaches     []Breach
 	maxBreaches  int
 	onBreach     func(*Breach)
 	mu           sync.RWMutex
+
+	predictHorizon time.Duration
+	predicted      map[string]map[string]BreachType // deviceID -> geofenceID -> last predicted breach
+
+	dwell map[string]map[string]*dwellTimer // deviceID -> geofenceID -> time on the current side
+	now   func() time.Time
+
+	converter    *coordinator.Converter // Converts WGS84 states into fence datums
+	defaultDatum string
+
+	index *fenceIndex // Bounding boxes of the geofences, nil until rebuilt after a change
 }
 
 // Config holds geofence engine configuration
 type Config struct {
-	MaxBreaches int // Maximum number of breaches to keep in memory
+	MaxBreaches    int           // Maximum number of breaches to keep in memory
+	PredictHorizon time.Duration // Predict breaches this far ahead from the current velocity (0 = off)
 }
 
 // NewEngine creates a new geofence engine
@@ -80,12 +141,32 @@ func NewEngine(cfg Config) *Engine {
 
 	return &Engine{
 		geofences:    make(map[string]*Geofence),
+		groups:       make(map[string]*Group),
 		deviceStates: make(map[string]map[string]bool),
 		breaches:     make([]Breach, 0),
 		maxBreaches:  maxBreaches,
+
+		predictHorizon: cfg.PredictHorizon,
+		predicted:      make(map[string]map[string]BreachType),
+
+		dwell: make(map[string]map[string]*dwellTimer),
+		now:   time.Now,
+
+		converter:    coordinator.New(false, false),
+		defaultDatum: coordinator.DatumWGS84,
 	}
 }
 
+// ForgetDevice drops the inside/outside and prediction state of a device, so
+// its next state is evaluated as if first seen
+func (e *Engine) ForgetDevice(deviceID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.deviceStates, deviceID)
+	delete(e.predicted, deviceID)
+	delete(e.dwell, deviceID)
+}
+
 // SetBreachCallback sets a callback function to be called when a breach occurs
 func (e *Engine) SetBreachCallback(cb func(*Breach)) {
 	e.mu.Lock()
@@ -93,11 +174,43 @@ func (e *Engine) SetBreachCallback(cb func(*Breach)) {
 	e.onBreach = cb
 }
 
+// SetPredictHorizon sets how far ahead breaches are predicted (0 = off)
+func (e *Engine) SetPredictHorizon(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.predictHorizon = d
+}
+
+// SetConverter sets the converter used to evaluate states against fences
+// drawn in GCJ02 or BD09, so the correction grid applies to them too
+func (e *Engine) SetConverter(c *coordinator.Converter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.converter = c
+}
+
+// SetDefaultDatum sets the datum of geofences created without one
+func (e *Engine) SetDefaultDatum(datum string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.defaultDatum = datum
+}
+
+// DefaultDatum returns the datum of geofences created without one
+func (e *Engine) DefaultDatum() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.defaultDatum
+}
+
 // AddGeofence adds a new geofence
 func (e *Engine) AddGeofence(gf *Geofence) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if gf.Group != "" && e.groups[gf.Group] == nil {
+		return ErrGroupNotFound
+	}
 	if gf.ID == "" {
 		gf.ID = uuid.New().String()
 	}
@@ -105,8 +218,12 @@ func (e *Engine) AddGeofence(gf *Geofence) error {
 	now := time.Now().UnixMilli()
 	gf.CreatedAt = now
 	gf.UpdatedAt = now
+	if gf.Severity == "" {
+		gf.Severity = DefaultSeverity
+	}
 
 	e.geofences[gf.ID] = gf
+	e.index = nil
 	return nil
 }
 
@@ -118,9 +235,16 @@ func (e *Engine) UpdateGeofence(gf *Geofence) error {
 	if _, ok := e.geofences[gf.ID]; !ok {
 		return ErrGeofenceNotFound
 	}
+	if gf.Group != "" && e.groups[gf.Group] == nil {
+		return ErrGroupNotFound
+	}
 
 	gf.UpdatedAt = time.Now().UnixMilli()
+	if gf.Severity == "" {
+		gf.Severity = DefaultSeverity
+	}
 	e.geofences[gf.ID] = gf
+	e.index = nil
 	return nil
 }
 
@@ -134,11 +258,18 @@ func (e *Engine) DeleteGeofence(id string) error {
 	}
 
 	delete(e.geofences, id)
+	e.index = nil
 
 	// Clean up device states for this geofence
 	for deviceID := range e.deviceStates {
 		delete(e.deviceStates[deviceID], id)
 	}
+	for deviceID := range e.predicted {
+		delete(e.predicted[deviceID], id)
+	}
+	for deviceID := range e.dwell {
+		delete(e.dwell[deviceID], id)
+	}
 
 	return nil
 }
@@ -167,7 +298,10 @@ func (e *Engine) GetGeofences() []*Geofence {
 	return result
 }
 
-// Evaluate checks if a drone state triggers any geofence breaches
+// Evaluate checks if a drone state triggers any geofence breaches. With a
+// prediction horizon set, it also returns predicted breaches (Predicted set)
+// the first time the state's velocity projects a crossing within the horizon.
+// Predicted breaches are not kept in the breach history.
 func (e *Engine) Evaluate(state *models.DroneState) []*Breach {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -179,9 +313,17 @@ func (e *Engine) Evaluate(state *models.DroneState) []*Breach {
 		e.deviceStates[state.DeviceID] = make(map[string]bool)
 	}
 	deviceState := e.deviceStates[state.DeviceID]
+	if e.predicted[state.DeviceID] == nil {
+		e.predicted[state.DeviceID] = make(map[string]BreachType)
+	}
+	predicted := e.predicted[state.DeviceID]
+	if e.dwell[state.DeviceID] == nil {
+		e.dwell[state.DeviceID] = make(map[string]*dwellTimer)
+	}
+	dwell := e.dwell[state.DeviceID]
 
-	for _, gf := range e.geofences {
-		if !gf.Enabled {
+	for _, gf := range e.candidates(state, deviceState, predicted) {
+		if !e.active(gf) || (gf.Tenant != "" && gf.Tenant != state.Tenant) {
 			continue
 		}
 
@@ -220,8 +362,15 @@ func (e *Engine) Evaluate(state *models.DroneState) []*Breach {
 
 		// Update state
 		deviceState[gf.ID] = inside
+		if breach == nil {
+			breach = e.checkDwell(state, gf, dwell, inside)
+		}
 
 		if breach != nil {
+			breach.GeofenceName = gf.Name
+			breach.Severity = gf.Severity
+			breach.Channels = gf.Channels
+			breach.GroupID = gf.Group
 			e.addBreach(breach)
 			breaches = append(breaches, breach)
 
@@ -229,18 +378,162 @@ func (e *Engine) Evaluate(state *models.DroneState) []*Breach {
 			if e.onBreach != nil {
 				go e.onBreach(breach)
 			}
+			delete(predicted, gf.ID)
+			continue
+		}
+
+		// Warn once per projected crossing, again only after the projection
+		// stopped crossing or the drone actually crossed
+		p := e.predict(state, gf, inside)
+		if p == nil {
+			delete(predicted, gf.ID)
+			continue
+		}
+		if predicted[gf.ID] != p.Type {
+			predicted[gf.ID] = p.Type
+			breaches = append(breaches, p)
 		}
 	}
 
 	return breaches
 }
 
+// candidates returns the geofences a state has to be evaluated against: the
+// fences whose bounding box holds the position, or the track projected over
+// the prediction horizon, plus those the device is inside of, has a
+// predicted breach for or may only stay outside of for a limited time. Other
+// fences cannot report a breach. Caller must hold the lock.
+func (e *Engine) candidates(state *models.DroneState, deviceState map[string]bool, predicted map[string]BreachType) []*Geofence {
+	if e.index == nil {
+		e.index = newFenceIndex(e.geofences)
+	}
+
+	var result []*Geofence
+	seen := make(map[string]bool)
+	add := func(gf *Geofence) {
+		if !seen[gf.ID] {
+			seen[gf.ID] = true
+			result = append(result, gf)
+		}
+	}
+
+	var reach float64
+	if e.predictHorizon > 0 {
+		reach = math.Hypot(state.Velocity.Vx, state.Velocity.Vy) * e.predictHorizon.Seconds()
+	}
+	for datum, tree := range e.index.trees {
+		lat, lon := e.converter.ToDatum(state.Location.Lat, state.Location.Lon, datum)
+		tree.search(pointBox(lat, lon).pad(reach), add)
+	}
+
+	for id, inside := range deviceState {
+		if gf := e.geofences[id]; inside && gf != nil {
+			add(gf)
+		}
+	}
+	for id := range predicted {
+		if gf := e.geofences[id]; gf != nil {
+			add(gf)
+		}
+	}
+	for _, gf := range e.index.dwellOutside {
+		add(gf)
+	}
+	return result
+}
+
+// checkDwell restarts the dwell timer when the device changed sides and
+// returns a dwell breach the first time the stay exceeds the fence's limit.
+// Caller must hold the lock.
+func (e *Engine) checkDwell(state *models.DroneState, gf *Geofence, dwell map[string]*dwellTimer, inside bool) *Breach {
+	now := e.now()
+	timer := dwell[gf.ID]
+	if timer == nil || timer.inside != inside {
+		timer = &dwellTimer{inside: inside, since: now}
+		dwell[gf.ID] = timer
+	}
+
+	limit := gf.DwellOutsideSec
+	if inside {
+		limit = gf.DwellInsideSec
+	}
+	stayed := now.Sub(timer.since)
+	if limit <= 0 || timer.reported || stayed < time.Duration(limit)*time.Second {
+		return nil
+	}
+	timer.reported = true
+	return &Breach{
+		ID:         uuid.New().String(),
+		GeofenceID: gf.ID,
+		DeviceID:   state.DeviceID,
+		Type:       BreachTypeDwell,
+		Lat:        state.Location.Lat,
+		Lon:        state.Location.Lon,
+		Alt:        state.Location.AltGNSS,
+		Timestamp:  now.UnixMilli(),
+		Inside:     inside,
+		DwellSec:   stayed.Seconds(),
+	}
+}
+
+// predict projects a state along its velocity and returns the first breach
+// that would be reported within the prediction horizon, or nil. Caller must
+// hold the lock.
+func (e *Engine) predict(state *models.DroneState, gf *Geofence, inside bool) *Breach {
+	if e.predictHorizon <= 0 || (inside && !gf.AlertOnExit) || (!inside && !gf.AlertOnEnter) {
+		return nil
+	}
+	v := state.Velocity
+	if v.Vx == 0 && v.Vy == 0 && v.Vz == 0 {
+		return nil
+	}
+
+	lat, lon, alt := state.Location.Lat, state.Location.Lon, state.Location.AltGNSS
+	metersPerDegLon := coordinator.MetersPerDegLat * math.Cos(lat*math.Pi/180)
+	for t := predictStep; t <= e.predictHorizon; t += predictStep {
+		sec := t.Seconds()
+		pLat := lat + v.Vx*sec/coordinator.MetersPerDegLat
+		pLon := lon
+		if metersPerDegLon > 0 {
+			pLon += v.Vy * sec / metersPerDegLon
+		}
+		pAlt := alt - v.Vz*sec // Vz points down
+		if e.isInsideAt(pLat, pLon, pAlt, gf) == inside {
+			continue
+		}
+
+		breachType := BreachTypeEnter
+		if inside {
+			breachType = BreachTypeExit
+		}
+		return &Breach{
+			ID:           uuid.New().String(),
+			GeofenceID:   gf.ID,
+			DeviceID:     state.DeviceID,
+			Type:         breachType,
+			Lat:          pLat,
+			Lon:          pLon,
+			Alt:          pAlt,
+			Timestamp:    time.Now().UnixMilli(),
+			GeofenceName: gf.Name,
+			Severity:     gf.Severity,
+			Channels:     gf.Channels,
+			GroupID:      gf.Group,
+			Predicted:    true,
+			ETASec:       sec,
+		}
+	}
+	return nil
+}
+
 // isInside checks if a drone is inside a geofence
 func (e *Engine) isInside(state *models.DroneState, gf *Geofence) bool {
-	lat := state.Location.Lat
-	lon := state.Location.Lon
-	alt := state.Location.AltGNSS
+	return e.isInsideAt(state.Location.Lat, state.Location.Lon, state.Location.AltGNSS, gf)
+}
 
+// isInsideAt checks if a WGS84 position is inside a geofence, converting it
+// to the fence's datum first
+func (e *Engine) isInsideAt(lat, lon, alt float64, gf *Geofence) bool {
 	// Check altitude bounds
 	if gf.MinAltitude != nil && alt < *gf.MinAltitude {
 		return false
@@ -248,6 +541,7 @@ func (e *Engine) isInside(state *models.DroneState, gf *Geofence) bool {
 	if gf.MaxAltitude != nil && alt > *gf.MaxAltitude {
 		return false
 	}
+	lat, lon = e.converter.ToDatum(lat, lon, gf.Datum)
 
 	switch gf.Type {
 	case GeofenceTypeCircle:
@@ -269,7 +563,7 @@ func (e *Engine) isInsideCircle(lat, lon float64, gf *Geofence) bool {
 	centerLon := gf.Center[1]
 
 	// Calculate distance using Haversine formula
-	distance := haversineDistance(lat, lon, centerLat, centerLon)
+	distance := coordinator.HaversineDistance(lat, lon, centerLat, centerLon)
 
 	return distance <= gf.Radius
 }
@@ -311,8 +605,21 @@ func (e *Engine) addBreach(breach *Breach) {
 	}
 }
 
+// BreachQuery filters the breach history; empty fields match every breach
+type BreachQuery struct {
+	DeviceID   string
+	GeofenceID string
+	GroupID    string // Breaches of fences in the group or its subgroups
+	Limit      int    // 0 = no limit
+}
+
 // GetBreaches returns breach history
 func (e *Engine) GetBreaches(deviceID string, geofenceID string, limit int) []Breach {
+	return e.QueryBreaches(BreachQuery{DeviceID: deviceID, GeofenceID: geofenceID, Limit: limit})
+}
+
+// QueryBreaches returns the breaches matching a query, newest first
+func (e *Engine) QueryBreaches(q BreachQuery) []Breach {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
@@ -321,16 +628,19 @@ func (e *Engine) GetBreaches(deviceID string, geofenceID string, limit int) []Br
 	for i := len(e.breaches) - 1; i >= 0; i-- {
 		breach := e.breaches[i]
 
-		if deviceID != "" && breach.DeviceID != deviceID {
+		if q.DeviceID != "" && breach.DeviceID != q.DeviceID {
+			continue
+		}
+		if q.GeofenceID != "" && breach.GeofenceID != q.GeofenceID {
 			continue
 		}
-		if geofenceID != "" && breach.GeofenceID != geofenceID {
+		if q.GroupID != "" && !e.inGroup(breach.GroupID, q.GroupID) {
 			continue
 		}
 
 		result = append(result, breach)
 
-		if limit > 0 && len(result) >= limit {
+		if q.Limit > 0 && len(result) >= q.Limit {
 			break
 		}
 	}
@@ -361,30 +671,12 @@ func (e *Engine) GetStats() map[string]interface{} {
 	return map[string]interface{}{
 		"total_geofences":   len(e.geofences),
 		"enabled_geofences": enabled,
+		"total_groups":      len(e.groups),
 		"total_breaches":    len(e.breaches),
 		"tracked_devices":   len(e.deviceStates),
 	}
 }
 
-// haversineDistance calculates the distance between two points on Earth in meters
-func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadius = 6371000 // meters
-
-	// Convert to radians
-	lat1Rad := lat1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	deltaLat := (lat2 - lat1) * math.Pi / 180
-	deltaLon := (lon2 - lon1) * math.Pi / 180
-
-	// Haversine formula
-	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return earthRadius * c
-}
-
 // MarshalJSON for Geofence
 func (gf *Geofence) MarshalJSON() ([]byte, error) {
 	type alias Geofence
@@ -394,6 +686,10 @@ func (gf *Geofence) MarshalJSON() ([]byte, error) {
 // Errors
 var (
 	ErrGeofenceNotFound = &GeofenceError{"geofence not found"}
+	ErrGroupNotFound    = &GeofenceError{"geofence group not found"}
+	ErrParentNotFound   = &GeofenceError{"parent group not found"}
+	ErrGroupNotEmpty    = &GeofenceError{"geofence group still has geofences or subgroups"}
+	ErrGroupCycle       = &GeofenceError{"geofence group cannot be nested in itself"}
 )
 
 type GeofenceError struct {
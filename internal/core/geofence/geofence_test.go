@@ -1,12 +1,13 @@
 package geofence
 
 import (
-	"math"
 	"sync"
 	"testing"
 	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestNewEngine(t *testing.T) {
@@ -180,6 +181,32 @@ func TestEngine_Evaluate_CircleEnter(t *testing.T) {
 	if breaches[0].DeviceID != "drone-1" {
 		t.Errorf("Breach device should be 'drone-1', got '%s'", breaches[0].DeviceID)
 	}
+	if breaches[0].GeofenceName != "Beijing Zone" || breaches[0].Severity != DefaultSeverity {
+		t.Errorf("Breach should carry the geofence name and default severity, got %q/%q", breaches[0].GeofenceName, breaches[0].Severity)
+	}
+}
+
+func TestEngine_Severity(t *testing.T) {
+	e := NewEngine(Config{})
+
+	gf := &Geofence{Name: "No-fly", Type: GeofenceTypeCircle, Center: []float64{39.9, 116.4}, Radius: 1000, Enabled: true}
+	e.AddGeofence(gf)
+	if gf.Severity != alerter.SeverityWarning {
+		t.Errorf("Default severity = %q, want warning", gf.Severity)
+	}
+
+	gf.Severity = alerter.SeverityCritical
+	gf.AlertOnEnter = true
+	e.UpdateGeofence(gf)
+
+	breaches := e.Evaluate(&models.DroneState{DeviceID: "drone-1", Location: models.Location{Lat: 39.9, Lon: 116.4}})
+	if len(breaches) != 1 || breaches[0].Severity != alerter.SeverityCritical {
+		t.Errorf("Breaches = %+v, want one critical breach", breaches)
+	}
+
+	if ValidSeverity("urgent") || !ValidSeverity(alerter.SeverityInfo) {
+		t.Error("ValidSeverity accepted or rejected the wrong values")
+	}
 }
 
 func TestEngine_Evaluate_CircleExit(t *testing.T) {
@@ -523,28 +550,6 @@ func TestEngine_MaxBreaches(t *testing.T) {
 	}
 }
 
-func TestHaversineDistance(t *testing.T) {
-	// Beijing to Shanghai approximate distance: ~1068 km
-	beijing := []float64{39.9042, 116.4074}
-	shanghai := []float64{31.2304, 121.4737}
-
-	distance := haversineDistance(beijing[0], beijing[1], shanghai[0], shanghai[1])
-
-	// Should be approximately 1068 km (1068000 meters) with some tolerance
-	expectedMeters := 1068000.0
-	tolerance := 50000.0 // 50km tolerance
-
-	if math.Abs(distance-expectedMeters) > tolerance {
-		t.Errorf("Distance between Beijing and Shanghai should be ~%v meters, got %v", expectedMeters, distance)
-	}
-
-	// Same point should have 0 distance
-	samePoint := haversineDistance(beijing[0], beijing[1], beijing[0], beijing[1])
-	if samePoint != 0 {
-		t.Errorf("Distance to same point should be 0, got %v", samePoint)
-	}
-}
-
 func TestEngine_IsInsideCircle(t *testing.T) {
 	e := NewEngine(Config{})
 
@@ -642,3 +647,173 @@ func TestEngine_IsInsideCircle_InvalidCircle(t *testing.T) {
 		t.Error("Invalid circle (no center) should return false")
 	}
 }
+
+func TestEngine_Evaluate_TenantGeofence(t *testing.T) {
+	e := NewEngine(Config{})
+	e.AddGeofence(&Geofence{
+		Name:         "ACME Yard",
+		Type:         GeofenceTypeCircle,
+		Center:       []float64{39.9087, 116.3975},
+		Radius:       5000,
+		AlertOnEnter: true,
+		Enabled:      true,
+		Tenant:       "acme",
+	})
+
+	inside := models.Location{Lat: 39.9087, Lon: 116.3975}
+	if breaches := e.Evaluate(&models.DroneState{DeviceID: "gx-1", Tenant: "globex", Location: inside}); len(breaches) != 0 {
+		t.Error("Tenant geofence should not apply to other tenants' devices")
+	}
+	if breaches := e.Evaluate(&models.DroneState{DeviceID: "acme-1", Tenant: "acme", Location: inside}); len(breaches) != 1 {
+		t.Errorf("Expected 1 breach for the owning tenant, got %d", len(breaches))
+	}
+}
+
+func TestEngine_Evaluate_Datum(t *testing.T) {
+	e := NewEngine(Config{})
+
+	// A 100 m fence drawn on Amap around the drone: GCJ02 is offset by
+	// several hundred meters in Beijing, so it only matches after conversion
+	lat, lon := 39.9087, 116.3975
+	gcjLat, gcjLon := coordinator.WGS84ToGCJ02(lat, lon)
+	for _, datum := range []string{coordinator.DatumGCJ02, coordinator.DatumWGS84} {
+		e.AddGeofence(&Geofence{
+			ID:           datum,
+			Type:         GeofenceTypeCircle,
+			Center:       []float64{gcjLat, gcjLon},
+			Radius:       100,
+			AlertOnEnter: true,
+			Enabled:      true,
+			Datum:        datum,
+		})
+	}
+
+	breaches := e.Evaluate(&models.DroneState{DeviceID: "drone-1", Location: models.Location{Lat: lat, Lon: lon}})
+	if len(breaches) != 1 || breaches[0].GeofenceID != coordinator.DatumGCJ02 {
+		t.Fatalf("Expected only the GCJ02 fence to be entered, got %+v", breaches)
+	}
+	if breaches[0].Lat != lat || breaches[0].Lon != lon {
+		t.Errorf("Breach position should stay in WGS84, got %f, %f", breaches[0].Lat, breaches[0].Lon)
+	}
+}
+
+func TestEngine_Evaluate_Predicted(t *testing.T) {
+	e := NewEngine(Config{PredictHorizon: 30 * time.Second})
+	e.AddGeofence(&Geofence{
+		Name: "Airport", Type: GeofenceTypeCircle, Center: []float64{22.5, 114.0},
+		Radius: 500, AlertOnEnter: true, AlertOnExit: true, Enabled: true,
+	})
+
+	// 1 km south of the center, flying north at 20 m/s: 25 s to the edge
+	state := models.NewDroneState("drone-1", "mavlink")
+	state.Location.Lat, state.Location.Lon = 22.5-1000/coordinator.MetersPerDegLat, 114.0
+	state.Velocity.Vx = 20
+
+	breaches := e.Evaluate(state)
+	if len(breaches) != 1 || !breaches[0].Predicted || breaches[0].Type != BreachTypeEnter {
+		t.Fatalf("Evaluate() = %+v, want one predicted enter", breaches)
+	}
+	if eta := breaches[0].ETASec; eta < 24 || eta > 26 {
+		t.Errorf("ETASec = %.1f, want about 25", eta)
+	}
+	if len(e.Evaluate(state)) != 0 {
+		t.Error("The same projected crossing should only be predicted once")
+	}
+	if len(e.GetBreaches("", "", 0)) != 0 {
+		t.Error("Predicted breaches should not be kept in the history")
+	}
+
+	// Hovering clears the prediction, so resuming warns again
+	state.Velocity.Vx = 0
+	e.Evaluate(state)
+	state.Velocity.Vx = 20
+	if len(e.Evaluate(state)) != 1 {
+		t.Error("A new approach should be predicted again")
+	}
+
+	// The actual crossing, then heading for the far edge
+	state.Location.Lat = 22.5
+	breaches = e.Evaluate(state)
+	if len(breaches) != 1 || breaches[0].Predicted {
+		t.Fatalf("Evaluate() inside = %+v, want the actual enter", breaches)
+	}
+	breaches = e.Evaluate(state)
+	if len(breaches) != 1 || !breaches[0].Predicted || breaches[0].Type != BreachTypeExit {
+		t.Errorf("Evaluate() heading out = %+v, want a predicted exit", breaches)
+	}
+
+	// Out of range of a short horizon
+	short := NewEngine(Config{PredictHorizon: 10 * time.Second})
+	short.AddGeofence(&Geofence{Type: GeofenceTypeCircle, Center: []float64{22.5, 114.0}, Radius: 500, AlertOnEnter: true, Enabled: true})
+	state.Location.Lat = 22.5 - 1000/coordinator.MetersPerDegLat
+	if breaches := short.Evaluate(state); len(breaches) != 0 {
+		t.Errorf("Evaluate() beyond the horizon = %+v, want none", breaches)
+	}
+}
+
+func TestEngine_ForgetDevice(t *testing.T) {
+	engine := NewEngine(Config{})
+	engine.AddGeofence(&Geofence{
+		Name: "Zone", Type: GeofenceTypeCircle, Center: []float64{22.5, 114.0},
+		Radius: 500, AlertOnEnter: true, Enabled: true,
+	})
+	state := models.NewDroneState("uav-1", "mavlink")
+	state.Location.Lat, state.Location.Lon = 22.5, 114.0
+
+	if breaches := engine.Evaluate(state); len(breaches) != 1 {
+		t.Fatalf("Expected an enter breach, got %d", len(breaches))
+	}
+	if breaches := engine.Evaluate(state); len(breaches) != 0 {
+		t.Fatalf("Expected no repeated breach, got %d", len(breaches))
+	}
+	engine.ForgetDevice("uav-1")
+	if breaches := engine.Evaluate(state); len(breaches) != 1 {
+		t.Errorf("Expected an enter breach after forgetting the device, got %d", len(breaches))
+	}
+}
+
+func TestEngine_Evaluate_Dwell(t *testing.T) {
+	engine := NewEngine(Config{})
+	now := time.Unix(1700000000, 0)
+	engine.now = func() time.Time { return now }
+	engine.AddGeofence(&Geofence{
+		Name: "Pad", Type: GeofenceTypeCircle, Center: []float64{22.5, 114.0},
+		Radius: 500, Enabled: true, DwellInsideSec: 60, DwellOutsideSec: 300,
+	})
+	state := models.NewDroneState("uav-1", "mavlink")
+	state.Location.Lat, state.Location.Lon = 22.5, 114.0
+
+	if breaches := engine.Evaluate(state); len(breaches) != 0 {
+		t.Fatalf("Expected no breach on arrival, got %+v", breaches)
+	}
+	now = now.Add(59 * time.Second)
+	if breaches := engine.Evaluate(state); len(breaches) != 0 {
+		t.Fatalf("Expected no breach before the dwell limit, got %+v", breaches)
+	}
+
+	now = now.Add(2 * time.Second)
+	breaches := engine.Evaluate(state)
+	if len(breaches) != 1 || breaches[0].Type != BreachTypeDwell || !breaches[0].Inside || breaches[0].DwellSec != 61 {
+		t.Fatalf("Expected an inside dwell breach after 61 s, got %+v", breaches)
+	}
+	if breaches[0].GeofenceName != "Pad" || len(engine.GetBreaches("uav-1", "", 0)) != 1 {
+		t.Errorf("Dwell breach should carry the geofence and be kept in the history: %+v", breaches[0])
+	}
+
+	now = now.Add(time.Hour)
+	if breaches := engine.Evaluate(state); len(breaches) != 0 {
+		t.Errorf("Expected one dwell breach per stay, got %+v", breaches)
+	}
+
+	// Leaving restarts the timer for the outside limit
+	state.Location.Lat = 23.0
+	engine.Evaluate(state)
+	now = now.Add(299 * time.Second)
+	if breaches := engine.Evaluate(state); len(breaches) != 0 {
+		t.Fatalf("Expected no breach before the outside limit, got %+v", breaches)
+	}
+	now = now.Add(time.Second)
+	if breaches := engine.Evaluate(state); len(breaches) != 1 || breaches[0].Inside {
+		t.Errorf("Expected an outside dwell breach, got %+v", breaches)
+	}
+}
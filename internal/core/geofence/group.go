@@ -0,0 +1,215 @@
+package geofence
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group collects geofences, e.g. the zones of an airport or the fences of
+// one customer site, so they can be enabled and reported on together.
+// Groups nest: a geofence is active only while it, its group and every
+// enclosing group are enabled.
+type Group struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parent      string `json:"parent,omitempty"` // ID of the enclosing group
+	Enabled     bool   `json:"enabled"`
+	Tenant      string `json:"tenant,omitempty"` // Owning tenant; empty for groups of every tenant
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+// GroupStats summarizes the geofences and breach history of a group and
+// its subgroups
+type GroupStats struct {
+	GroupID         string             `json:"group_id"`
+	Subgroups       int                `json:"subgroups"`
+	TotalGeofences  int                `json:"total_geofences"`
+	ActiveGeofences int                `json:"active_geofences"` // Enabled, in enabled groups only
+	Breaches        int                `json:"breaches"`
+	BreachesByType  map[BreachType]int `json:"breaches_by_type"`
+	DevicesInside   int                `json:"devices_inside"`
+	LastBreachAt    int64              `json:"last_breach_at,omitempty"`
+}
+
+// AddGroup adds a new geofence group
+func (e *Engine) AddGroup(g *Group) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if g.Parent != "" && e.groups[g.Parent] == nil {
+		return ErrParentNotFound
+	}
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+
+	now := time.Now().UnixMilli()
+	g.CreatedAt = now
+	g.UpdatedAt = now
+	e.groups[g.ID] = g
+	return nil
+}
+
+// UpdateGroup updates an existing geofence group
+func (e *Engine) UpdateGroup(g *Group) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.groups[g.ID]; !ok {
+		return ErrGroupNotFound
+	}
+	if g.Parent != "" {
+		if e.groups[g.Parent] == nil {
+			return ErrParentNotFound
+		}
+		if e.inGroup(g.Parent, g.ID) {
+			return ErrGroupCycle
+		}
+	}
+
+	g.UpdatedAt = time.Now().UnixMilli()
+	e.groups[g.ID] = g
+	return nil
+}
+
+// DeleteGroup removes a geofence group. Groups still holding geofences or
+// subgroups cannot be deleted.
+func (e *Engine) DeleteGroup(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.groups[id]; !ok {
+		return ErrGroupNotFound
+	}
+	for _, gf := range e.geofences {
+		if gf.Group == id {
+			return ErrGroupNotEmpty
+		}
+	}
+	for _, g := range e.groups {
+		if g.Parent == id {
+			return ErrGroupNotEmpty
+		}
+	}
+
+	delete(e.groups, id)
+	return nil
+}
+
+// GetGroup returns a geofence group by ID
+func (e *Engine) GetGroup(id string) (*Group, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	g, ok := e.groups[id]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	return g, nil
+}
+
+// GetGroups returns all geofence groups
+func (e *Engine) GetGroups() []*Group {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := make([]*Group, 0, len(e.groups))
+	for _, g := range e.groups {
+		result = append(result, g)
+	}
+	return result
+}
+
+// InGroup reports whether a geofence belongs to the group or one of its
+// subgroups
+func (e *Engine) InGroup(gf *Geofence, groupID string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.inGroup(gf.Group, groupID)
+}
+
+// GroupStats returns the statistics of a group, including its subgroups
+func (e *Engine) GroupStats(id string) (*GroupStats, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if _, ok := e.groups[id]; !ok {
+		return nil, ErrGroupNotFound
+	}
+
+	stats := &GroupStats{GroupID: id, BreachesByType: make(map[BreachType]int)}
+	for _, g := range e.groups {
+		if g.ID != id && e.inGroup(g.ID, id) {
+			stats.Subgroups++
+		}
+	}
+
+	fences := make(map[string]bool)
+	for _, gf := range e.geofences {
+		if !e.inGroup(gf.Group, id) {
+			continue
+		}
+		fences[gf.ID] = true
+		stats.TotalGeofences++
+		if e.active(gf) {
+			stats.ActiveGeofences++
+		}
+	}
+	for _, inside := range e.deviceStates {
+		for gfID, in := range inside {
+			if in && fences[gfID] {
+				stats.DevicesInside++
+				break
+			}
+		}
+	}
+
+	for _, b := range e.breaches {
+		if !e.inGroup(b.GroupID, id) {
+			continue
+		}
+		stats.Breaches++
+		stats.BreachesByType[b.Type]++
+		stats.LastBreachAt = max(stats.LastBreachAt, b.Timestamp)
+	}
+	return stats, nil
+}
+
+// active reports whether a geofence and all its enclosing groups are
+// enabled. Caller must hold the lock.
+func (e *Engine) active(gf *Geofence) bool {
+	if !gf.Enabled {
+		return false
+	}
+	id := gf.Group
+	for i := 0; id != "" && i <= len(e.groups); i++ {
+		g, ok := e.groups[id]
+		if !ok {
+			break
+		}
+		if !g.Enabled {
+			return false
+		}
+		id = g.Parent
+	}
+	return true
+}
+
+// inGroup reports whether groupID is the target group or one of its
+// subgroups. Caller must hold the lock.
+func (e *Engine) inGroup(groupID, target string) bool {
+	for i := 0; groupID != "" && i <= len(e.groups); i++ {
+		if groupID == target {
+			return true
+		}
+		g, ok := e.groups[groupID]
+		if !ok {
+			break
+		}
+		groupID = g.Parent
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package geofence
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestEngine_Groups(t *testing.T) {
+	e := NewEngine(Config{})
+
+	airport := &Group{ID: "airport", Name: "Airport zones", Enabled: true}
+	if err := e.AddGroup(airport); err != nil {
+		t.Fatal(err)
+	}
+	runway := &Group{ID: "runway", Name: "Runways", Parent: "airport", Enabled: true}
+	if err := e.AddGroup(runway); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddGroup(&Group{Name: "Orphan", Parent: "missing"}); err != ErrParentNotFound {
+		t.Errorf("AddGroup with unknown parent = %v", err)
+	}
+
+	gf := &Geofence{ID: "rwy-09", Name: "Runway 09", Type: GeofenceTypeCircle, Group: "runway",
+		Center: []float64{39.9087, 116.3975}, Radius: 1000, AlertOnEnter: true, Enabled: true}
+	if err := e.AddGeofence(gf); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddGeofence(&Geofence{Name: "Lost", Group: "missing"}); err != ErrGroupNotFound {
+		t.Errorf("AddGeofence with unknown group = %v", err)
+	}
+
+	// A group cannot be nested in itself or its subgroups
+	cycle := *airport
+	cycle.Parent = "runway"
+	if err := e.UpdateGroup(&cycle); err != ErrGroupCycle {
+		t.Errorf("UpdateGroup into a subgroup = %v", err)
+	}
+
+	state := models.NewDroneState("uav-1", "mavlink")
+	state.Location = models.Location{Lat: 39.9087, Lon: 116.3975}
+	outside := models.NewDroneState("uav-1", "mavlink")
+	outside.Location = models.Location{Lat: 40.5, Lon: 117}
+
+	// Disabling the enclosing group deactivates the fence
+	disabled := *airport
+	disabled.Enabled = false
+	e.UpdateGroup(&disabled)
+	if breaches := e.Evaluate(state); len(breaches) != 0 {
+		t.Errorf("Fence in a disabled group reported %d breaches", len(breaches))
+	}
+	e.UpdateGroup(airport)
+	e.Evaluate(outside)
+	breaches := e.Evaluate(state)
+	if len(breaches) != 1 || breaches[0].GroupID != "runway" {
+		t.Fatalf("Breaches = %+v", breaches)
+	}
+
+	// The breaches of subgroups count towards the enclosing group
+	if got := e.QueryBreaches(BreachQuery{GroupID: "airport"}); len(got) != 1 {
+		t.Errorf("Airport breaches = %d, want 1", len(got))
+	}
+	if got := e.QueryBreaches(BreachQuery{GroupID: "other"}); len(got) != 0 {
+		t.Errorf("Other group breaches = %d", len(got))
+	}
+	stats, err := e.GroupStats("airport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Subgroups != 1 || stats.TotalGeofences != 1 || stats.ActiveGeofences != 1 ||
+		stats.Breaches != 1 || stats.BreachesByType[BreachTypeEnter] != 1 || stats.DevicesInside != 1 {
+		t.Errorf("Stats = %+v", stats)
+	}
+
+	// Groups are deleted once empty
+	if err := e.DeleteGroup("airport"); err != ErrGroupNotEmpty {
+		t.Errorf("DeleteGroup with subgroups = %v", err)
+	}
+	e.DeleteGeofence("rwy-09")
+	if err := e.DeleteGroup("runway"); err != nil {
+		t.Errorf("DeleteGroup = %v", err)
+	}
+	if _, err := e.GroupStats("runway"); err != ErrGroupNotFound {
+		t.Errorf("GroupStats of a deleted group = %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package geofence
+
+import (
+	"math"
+	"sort"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+)
+
+// rtreeFanout is the number of children of an index node
+const rtreeFanout = 16
+
+// bbox is a latitude/longitude rectangle, edges included
+type bbox struct {
+	minLat, minLon, maxLat, maxLon float64
+}
+
+// pointBox returns the bounding box of a single point
+func pointBox(lat, lon float64) bbox {
+	return bbox{minLat: lat, minLon: lon, maxLat: lat, maxLon: lon}
+}
+
+// intersects reports whether two boxes overlap
+func (b bbox) intersects(o bbox) bool {
+	return b.minLat <= o.maxLat && o.minLat <= b.maxLat && b.minLon <= o.maxLon && o.minLon <= b.maxLon
+}
+
+// union returns the smallest box holding both boxes
+func (b bbox) union(o bbox) bbox {
+	return bbox{
+		minLat: math.Min(b.minLat, o.minLat),
+		minLon: math.Min(b.minLon, o.minLon),
+		maxLat: math.Max(b.maxLat, o.maxLat),
+		maxLon: math.Max(b.maxLon, o.maxLon),
+	}
+}
+
+// pad grows the box by a distance in meters on every side. Boxes reaching
+// a pole or the antimeridian span every longitude.
+func (b bbox) pad(meters float64) bbox {
+	dLat := meters / coordinator.MetersPerDegLat
+	b.minLat, b.maxLat = b.minLat-dLat, b.maxLat+dLat
+	if b.minLat <= -90 || b.maxLat >= 90 {
+		b.minLat, b.maxLat = math.Max(b.minLat, -90), math.Min(b.maxLat, 90)
+		b.minLon, b.maxLon = -180, 180
+		return b
+	}
+	// Degrees of longitude are shortest at the latitude farthest from the equator
+	maxAbsLat := math.Max(math.Abs(b.minLat), math.Abs(b.maxLat))
+	dLon := meters / (coordinator.MetersPerDegLat * math.Cos(maxAbsLat*math.Pi/180))
+	b.minLon, b.maxLon = b.minLon-dLon, b.maxLon+dLon
+	if b.minLon < -180 || b.maxLon > 180 {
+		b.minLon, b.maxLon = -180, 180
+	}
+	return b
+}
+
+// fenceBox returns the bounding box of a geofence in its own datum, or false
+// if the geofence has no usable shape and can never hold a drone
+func fenceBox(gf *Geofence) (bbox, bool) {
+	switch gf.Type {
+	case GeofenceTypeCircle:
+		if len(gf.Center) < 2 {
+			return bbox{}, false
+		}
+		// Slightly larger than the radius, so haversine rounding never
+		// leaves a point on the edge out of the box
+		return pointBox(gf.Center[0], gf.Center[1]).pad(gf.Radius*1.01 + 1), true
+	case GeofenceTypePolygon:
+		if len(gf.Coordinates) < 3 {
+			return bbox{}, false
+		}
+		b := bbox{minLat: math.Inf(1), minLon: math.Inf(1), maxLat: math.Inf(-1), maxLon: math.Inf(-1)}
+		for _, c := range gf.Coordinates {
+			if len(c) < 2 {
+				return bbox{}, false
+			}
+			b = b.union(pointBox(c[0], c[1]))
+		}
+		return b, true
+	default:
+		return bbox{}, false
+	}
+}
+
+// rtreeNode is a node of a packed R-tree; leaves hold one geofence
+type rtreeNode struct {
+	box      bbox
+	children []*rtreeNode
+	fence    *Geofence
+}
+
+// search calls fn for every geofence whose box overlaps b
+func (n *rtreeNode) search(b bbox, fn func(*Geofence)) {
+	if !n.box.intersects(b) {
+		return
+	}
+	if n.fence != nil {
+		fn(n.fence)
+		return
+	}
+	for _, child := range n.children {
+		child.search(b, fn)
+	}
+}
+
+// newRtree bulk-loads leaves into an R-tree with Sort-Tile-Recursive
+// packing, or returns nil for no leaves
+func newRtree(nodes []*rtreeNode) *rtreeNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	for len(nodes) > 1 {
+		nodes = packLevel(nodes)
+	}
+	return nodes[0]
+}
+
+// packLevel groups nodes into parents of up to rtreeFanout children: nodes
+// are cut into vertical slices by longitude, then each slice into runs by
+// latitude, so parents cover small, compact boxes
+func packLevel(nodes []*rtreeNode) []*rtreeNode {
+	parents := (len(nodes) + rtreeFanout - 1) / rtreeFanout
+	sliceSize := int(math.Ceil(math.Sqrt(float64(parents)))) * rtreeFanout
+
+	centerLon := func(n *rtreeNode) float64 { return n.box.minLon + n.box.maxLon }
+	centerLat := func(n *rtreeNode) float64 { return n.box.minLat + n.box.maxLat }
+	sort.Slice(nodes, func(i, j int) bool { return centerLon(nodes[i]) < centerLon(nodes[j]) })
+
+	result := make([]*rtreeNode, 0, parents)
+	for start := 0; start < len(nodes); start += sliceSize {
+		slice := nodes[start:min(start+sliceSize, len(nodes))]
+		sort.Slice(slice, func(i, j int) bool { return centerLat(slice[i]) < centerLat(slice[j]) })
+		for i := 0; i < len(slice); i += rtreeFanout {
+			children := slice[i:min(i+rtreeFanout, len(slice))]
+			parent := &rtreeNode{box: children[0].box, children: children}
+			for _, child := range children[1:] {
+				parent.box = parent.box.union(child.box)
+			}
+			result = append(result, parent)
+		}
+	}
+	return result
+}
+
+// fenceIndex finds the geofences near a position without testing every
+// fence. It is rebuilt from scratch when geofences change.
+type fenceIndex struct {
+	trees map[string]*rtreeNode // By fence datum, as boxes are in the fence's datum
+
+	// Fences with an outside dwell limit must be evaluated wherever the
+	// drone is
+	dwellOutside []*Geofence
+}
+
+// newFenceIndex indexes the bounding boxes of geofences
+func newFenceIndex(geofences map[string]*Geofence) *fenceIndex {
+	leaves := make(map[string][]*rtreeNode)
+	idx := &fenceIndex{trees: make(map[string]*rtreeNode)}
+	for _, gf := range geofences {
+		if gf.DwellOutsideSec > 0 {
+			idx.dwellOutside = append(idx.dwellOutside, gf)
+		}
+		if box, ok := fenceBox(gf); ok {
+			leaves[gf.Datum] = append(leaves[gf.Datum], &rtreeNode{box: box, fence: gf})
+		}
+	}
+	for datum, nodes := range leaves {
+		idx.trees[datum] = newRtree(nodes)
+	}
+	return idx
+}
@@ -0,0 +1,149 @@
+package geofence
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// randomFences returns n circles and squares of 50 m to 5 km scattered over
+// about 1 x 1 degrees around Beijing, a quarter of them in GCJ02
+func randomFences(rng *rand.Rand, n int) []*Geofence {
+	fences := make([]*Geofence, 0, n)
+	for i := 0; i < n; i++ {
+		lat, lon := 39.5+rng.Float64(), 116+rng.Float64()
+		size := 50 + rng.Float64()*5000
+		gf := &Geofence{
+			Name:         fmt.Sprintf("fence-%d", i),
+			AlertOnEnter: true,
+			AlertOnExit:  true,
+			Enabled:      true,
+		}
+		if i%2 == 0 {
+			gf.Type = GeofenceTypeCircle
+			gf.Center = []float64{lat, lon}
+			gf.Radius = size
+		} else {
+			d := size / coordinator.MetersPerDegLat
+			gf.Type = GeofenceTypePolygon
+			gf.Coordinates = [][]float64{{lat, lon}, {lat + d, lon}, {lat + d, lon + d}, {lat, lon + d}}
+		}
+		if i%4 == 1 {
+			gf.Datum = coordinator.DatumGCJ02
+		}
+		fences = append(fences, gf)
+	}
+	return fences
+}
+
+func TestEngine_Evaluate_Index(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	e := NewEngine(Config{MaxBreaches: 100000})
+	e.SetConverter(coordinator.New(true, false))
+	for _, gf := range randomFences(rng, 500) {
+		e.AddGeofence(gf)
+	}
+
+	// A drone wandering across the area enters and leaves exactly the
+	// fences a check against every fence finds
+	lat, lon := 40.0, 116.5
+	inside := make(map[string]bool)
+	for step := 0; step < 2000; step++ {
+		lat += (rng.Float64() - 0.5) * 0.01
+		lon += (rng.Float64() - 0.5) * 0.01
+		state := &models.DroneState{DeviceID: "drone-1", Location: models.Location{Lat: lat, Lon: lon}}
+
+		want := make(map[string]BreachType)
+		for _, gf := range e.GetGeofences() {
+			now := e.isInside(state, gf)
+			if now && !inside[gf.ID] {
+				want[gf.ID] = BreachTypeEnter
+			} else if !now && inside[gf.ID] {
+				want[gf.ID] = BreachTypeExit
+			}
+			inside[gf.ID] = now
+		}
+
+		breaches := e.Evaluate(state)
+		if len(breaches) != len(want) {
+			t.Fatalf("Step %d: %d breaches, want %d", step, len(breaches), len(want))
+		}
+		for _, b := range breaches {
+			if want[b.GeofenceID] != b.Type {
+				t.Fatalf("Step %d: unexpected %s breach of %s", step, b.Type, b.GeofenceName)
+			}
+		}
+	}
+	if len(e.GetBreaches("", "", 0)) == 0 {
+		t.Fatal("Expected the drone to cross some fences")
+	}
+}
+
+func TestEngine_Evaluate_IndexRebuilt(t *testing.T) {
+	e := NewEngine(Config{})
+	gf := &Geofence{
+		Name:         "Moved",
+		Type:         GeofenceTypeCircle,
+		Center:       []float64{10, 10},
+		Radius:       100,
+		AlertOnEnter: true,
+		Enabled:      true,
+	}
+	e.AddGeofence(gf)
+
+	state := &models.DroneState{DeviceID: "drone-1", Location: models.Location{Lat: 20, Lon: 20}}
+	if len(e.Evaluate(state)) != 0 {
+		t.Fatal("Drone far from the fence should not breach it")
+	}
+
+	gf.Center = []float64{20, 20}
+	e.UpdateGeofence(gf)
+	if b := e.Evaluate(state); len(b) != 1 || b[0].Type != BreachTypeEnter {
+		t.Fatalf("After moving the fence onto the drone: breaches %+v, want one enter", b)
+	}
+
+	e.DeleteGeofence(gf.ID)
+	if len(e.Evaluate(state)) != 0 {
+		t.Error("Deleted fence should not be evaluated")
+	}
+}
+
+func TestBboxPad(t *testing.T) {
+	tests := []struct {
+		name string
+		box  bbox
+		want bbox
+	}{
+		{"equator", pointBox(0, 0), bbox{-0.01, -0.01, 0.01, 0.01}},
+		{"pole", pointBox(89.995, 0), bbox{89.985, -180, 90, 180}},
+		{"antimeridian", pointBox(0, 179.995), bbox{-0.01, -180, 0.01, 180}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.box.pad(0.01 * coordinator.MetersPerDegLat)
+			near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+			if !near(got.minLat, tt.want.minLat) || !near(got.minLon, tt.want.minLon) ||
+				!near(got.maxLat, tt.want.maxLat) || !near(got.maxLon, tt.want.maxLon) {
+				t.Errorf("pad() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkEngine_Evaluate(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	e := NewEngine(Config{})
+	for _, gf := range randomFences(rng, 500) {
+		e.AddGeofence(gf)
+	}
+	state := &models.DroneState{DeviceID: "drone-1", Location: models.Location{Lat: 40, Lon: 116.5}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.Evaluate(state)
+	}
+}
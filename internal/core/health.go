@@ -0,0 +1,209 @@
+package core
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// Publisher health defaults
+const (
+	DefaultPublisherDegradedErrors = 5
+	DefaultPublisherStaleAfterMs   = 30000
+)
+
+// PublisherStatus is the health status of a publisher
+type PublisherStatus string
+
+const (
+	PublisherStatusUnknown  PublisherStatus = "unknown"  // Nothing published yet
+	PublisherStatusHealthy  PublisherStatus = "healthy"  // Last publish succeeded
+	PublisherStatusDegraded PublisherStatus = "degraded" // Repeated errors or disconnected too long
+)
+
+// Connectable is implemented by publishers that expose their connection state
+type Connectable = sdk.Connectable
+
+// PublisherHealth is a snapshot of a publisher's health
+type PublisherHealth struct {
+	Name              string           `json:"name"`
+	Status            PublisherStatus  `json:"status"`
+	Connected         *bool            `json:"connected,omitempty"`
+	LastSuccess       int64            `json:"last_success,omitempty"`  // Unix ms of last successful publish
+	LastError         string           `json:"last_error,omitempty"`    // Most recent error message
+	LastErrorAt       int64            `json:"last_error_at,omitempty"` // Unix ms of most recent error
+	ConsecutiveErrors int              `json:"consecutive_errors"`
+	TotalPublished    uint64           `json:"total_published"`
+	TotalErrors       uint64           `json:"total_errors"`
+	Reason            string           `json:"reason,omitempty"` // Why the publisher is degraded
+	Detail            *PublisherDetail `json:"detail,omitempty"` // Protocol state of publishers implementing HealthReporter
+}
+
+// PublisherHealthCallback is called when a publisher changes status
+type PublisherHealthCallback func(health PublisherHealth)
+
+// publisherHealthEntry is the mutable health state of one publisher
+type publisherHealthEntry struct {
+	PublisherHealth
+	disconnectedSince time.Time
+}
+
+// healthMonitor tracks per-publisher publish results and connectivity
+type healthMonitor struct {
+	degradedErrors int
+	staleAfter     time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]*publisherHealthEntry
+	order    []string
+	callback PublisherHealthCallback
+}
+
+// newHealthMonitor creates a monitor with the given thresholds
+func newHealthMonitor(degradedErrors int, staleAfterMs int64) *healthMonitor {
+	if degradedErrors <= 0 {
+		degradedErrors = DefaultPublisherDegradedErrors
+	}
+	if staleAfterMs <= 0 {
+		staleAfterMs = DefaultPublisherStaleAfterMs
+	}
+	return &healthMonitor{
+		degradedErrors: degradedErrors,
+		staleAfter:     time.Duration(staleAfterMs) * time.Millisecond,
+		entries:        make(map[string]*publisherHealthEntry),
+	}
+}
+
+// register adds a publisher to the monitor
+func (m *healthMonitor) register(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[name]; ok {
+		return
+	}
+	m.entries[name] = &publisherHealthEntry{
+		PublisherHealth: PublisherHealth{Name: name, Status: PublisherStatusUnknown},
+	}
+	m.order = append(m.order, name)
+}
+
+// setCallback sets the status change callback
+func (m *healthMonitor) setCallback(cb PublisherHealthCallback) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callback = cb
+}
+
+// record updates health after a publish attempt.
+// Only the first error and status changes are logged.
+func (m *healthMonitor) record(name string, err error, now time.Time) {
+	m.mu.Lock()
+	entry, ok := m.entries[name]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+
+	prev := entry.Status
+	if err == nil {
+		entry.TotalPublished++
+		entry.LastSuccess = now.UnixMilli()
+		if entry.ConsecutiveErrors > 0 && prev == PublisherStatusDegraded {
+			log.Printf("[Engine] Publisher %s recovered after %d errors", name, entry.ConsecutiveErrors)
+		}
+		entry.ConsecutiveErrors = 0
+		if entry.disconnectedSince.IsZero() {
+			entry.Status = PublisherStatusHealthy
+			entry.Reason = ""
+		}
+	} else {
+		entry.TotalErrors++
+		entry.ConsecutiveErrors++
+		entry.LastError = err.Error()
+		entry.LastErrorAt = now.UnixMilli()
+		if entry.ConsecutiveErrors == 1 {
+			log.Printf("[Engine] Publish error (%s): %v", name, err)
+		}
+		if entry.ConsecutiveErrors >= m.degradedErrors && prev != PublisherStatusDegraded {
+			entry.Status = PublisherStatusDegraded
+			entry.Reason = "consecutive publish errors"
+			log.Printf("[Engine] Publisher %s degraded after %d consecutive errors: %v", name, entry.ConsecutiveErrors, err)
+		}
+	}
+
+	m.notify(entry, prev)
+}
+
+// check updates connectivity for publishers that expose it.
+// A publisher disconnected longer than staleAfter is marked degraded.
+func (m *healthMonitor) check(name string, connected bool, now time.Time) {
+	m.mu.Lock()
+	entry, ok := m.entries[name]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+
+	prev := entry.Status
+	c := connected
+	entry.Connected = &c
+
+	if connected {
+		if !entry.disconnectedSince.IsZero() {
+			entry.disconnectedSince = time.Time{}
+			if prev == PublisherStatusDegraded && entry.ConsecutiveErrors < m.degradedErrors {
+				entry.Status = PublisherStatusHealthy
+				entry.Reason = ""
+				log.Printf("[Engine] Publisher %s reconnected", name)
+			}
+		}
+	} else {
+		if entry.disconnectedSince.IsZero() {
+			entry.disconnectedSince = now
+		}
+		if prev != PublisherStatusDegraded && now.Sub(entry.disconnectedSince) >= m.staleAfter {
+			entry.Status = PublisherStatusDegraded
+			entry.Reason = "disconnected"
+			log.Printf("[Engine] Publisher %s degraded: disconnected for %v", name, now.Sub(entry.disconnectedSince).Round(time.Second))
+		}
+	}
+
+	m.notify(entry, prev)
+}
+
+// notify releases the lock and fires the callback if the status changed.
+// Must be called with m.mu held.
+func (m *healthMonitor) notify(entry *publisherHealthEntry, prev PublisherStatus) {
+	changed := entry.Status != prev
+	snapshot := entry.snapshot()
+	cb := m.callback
+	m.mu.Unlock()
+
+	// Transitions from unknown to healthy are not interesting
+	if changed && cb != nil && !(prev == PublisherStatusUnknown && snapshot.Status == PublisherStatusHealthy) {
+		cb(snapshot)
+	}
+}
+
+// snapshot returns a copy of the public health fields
+func (e *publisherHealthEntry) snapshot() PublisherHealth {
+	h := e.PublisherHealth
+	if e.Connected != nil {
+		c := *e.Connected
+		h.Connected = &c
+	}
+	return h
+}
+
+// all returns health snapshots in registration order
+func (m *healthMonitor) all() []PublisherHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]PublisherHealth, 0, len(m.order))
+	for _, name := range m.order {
+		result = append(result, m.entries[name].snapshot())
+	}
+	return result
+}
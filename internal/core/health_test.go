@@ -0,0 +1,109 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor_DegradedAfterErrors(t *testing.T) {
+	m := newHealthMonitor(3, 0)
+	m.register("mqtt")
+
+	var changes []PublisherHealth
+	m.setCallback(func(h PublisherHealth) { changes = append(changes, h) })
+
+	now := time.Now()
+	m.record("mqtt", nil, now)
+	for i := 0; i < 3; i++ {
+		m.record("mqtt", errors.New("not connected"), now)
+	}
+
+	h := m.all()[0]
+	if h.Status != PublisherStatusDegraded {
+		t.Errorf("Status = %s, want degraded", h.Status)
+	}
+	if h.ConsecutiveErrors != 3 || h.TotalErrors != 3 || h.TotalPublished != 1 {
+		t.Errorf("counters = %d/%d/%d, want 3/3/1", h.ConsecutiveErrors, h.TotalErrors, h.TotalPublished)
+	}
+	if h.LastError != "not connected" {
+		t.Errorf("LastError = %q, want 'not connected'", h.LastError)
+	}
+	if len(changes) != 1 || changes[0].Status != PublisherStatusDegraded {
+		t.Fatalf("callback changes = %+v, want one degraded", changes)
+	}
+
+	// Recovery
+	m.record("mqtt", nil, now)
+	if got := m.all()[0].Status; got != PublisherStatusHealthy {
+		t.Errorf("Status after success = %s, want healthy", got)
+	}
+	if len(changes) != 2 || changes[1].Status != PublisherStatusHealthy {
+		t.Errorf("callback changes = %+v, want recovery", changes)
+	}
+}
+
+func TestHealthMonitor_Disconnected(t *testing.T) {
+	m := newHealthMonitor(0, 1000)
+	m.register("gb28181")
+
+	start := time.Now()
+	m.check("gb28181", false, start)
+	if got := m.all()[0].Status; got != PublisherStatusUnknown {
+		t.Errorf("Status right after disconnect = %s, want unknown", got)
+	}
+
+	m.check("gb28181", false, start.Add(2*time.Second))
+	h := m.all()[0]
+	if h.Status != PublisherStatusDegraded || h.Reason != "disconnected" {
+		t.Errorf("Status = %s (%s), want degraded (disconnected)", h.Status, h.Reason)
+	}
+	if h.Connected == nil || *h.Connected {
+		t.Error("Connected should be false")
+	}
+
+	m.check("gb28181", true, start.Add(3*time.Second))
+	if got := m.all()[0].Status; got != PublisherStatusHealthy {
+		t.Errorf("Status after reconnect = %s, want healthy", got)
+	}
+}
+
+func TestHealthMonitor_UnknownPublisher(t *testing.T) {
+	m := newHealthMonitor(0, 0)
+
+	// Must not panic
+	m.record("missing", errors.New("x"), time.Now())
+	m.check("missing", false, time.Now())
+
+	if len(m.all()) != 0 {
+		t.Error("Unregistered publishers should not be reported")
+	}
+}
+
+// reportingPublisher reports a fixed protocol state
+type reportingPublisher struct {
+	recordingPublisher
+	detail PublisherDetail
+}
+
+func (p *reportingPublisher) Health() PublisherDetail { return p.detail }
+
+func TestEngine_PublisherHealthDetail(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	e.RegisterPublisher(&recordingPublisher{name: "plain"})
+	e.RegisterPublisher(&reportingPublisher{
+		recordingPublisher: recordingPublisher{name: "gb28181"},
+		detail:             PublisherDetail{State: "unregistered", LastError: "REGISTER failed with status 403: Forbidden"},
+	})
+
+	health := e.GetPublisherHealth()
+	if len(health) != 2 {
+		t.Fatalf("GetPublisherHealth() returned %d entries, want 2", len(health))
+	}
+	if health[0].Detail != nil {
+		t.Errorf("Detail of plain publisher = %+v, want none", health[0].Detail)
+	}
+	if d := health[1].Detail; d == nil || d.State != "unregistered" || d.LastError == "" {
+		t.Errorf("Detail of gb28181 = %+v, want the reported registration state", d)
+	}
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"math"
+	"sync"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// homeTracker captures each drone's home position and stamps states with
+// the distance and bearing to it. A home reported by the flight controller
+// is kept until it reports another; otherwise home is the first fix after
+// the drone arms, and is reset when it arms again.
+type homeTracker struct {
+	mu      sync.Mutex
+	devices map[string]*deviceHome
+}
+
+// deviceHome is the home state of one device
+type deviceHome struct {
+	armed bool
+	home  *models.HomePosition // nil until known
+}
+
+func newHomeTracker() *homeTracker {
+	return &homeTracker{devices: make(map[string]*deviceHome)}
+}
+
+// apply updates the device's home from a state and replaces state.Home with
+// a copy carrying the distance and bearing from the state's position
+func (t *homeTracker) apply(state *models.DroneState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	d, ok := t.devices[state.DeviceID]
+	if !ok {
+		d = &deviceHome{}
+		t.devices[state.DeviceID] = d
+	}
+
+	hasFix := state.Location.Lat != 0 || state.Location.Lon != 0
+	switch {
+	case state.Home != nil && state.Home.Source == models.HomeSourceAutopilot:
+		home := *state.Home
+		d.home = &home
+	case state.Status.Armed && !d.armed && (d.home == nil || d.home.Source == models.HomeSourceArmed):
+		// Armed again, possibly at another launch site
+		d.home = nil
+	}
+	d.armed = state.Status.Armed
+
+	if d.home == nil && state.Status.Armed && hasFix {
+		d.home = &models.HomePosition{
+			Lat:    state.Location.Lat,
+			Lon:    state.Location.Lon,
+			Alt:    state.Location.AltGNSS,
+			Source: models.HomeSourceArmed,
+		}
+	}
+
+	if d.home == nil {
+		state.Home = nil
+		return
+	}
+	home := *d.home
+	if hasFix {
+		home.DistanceM = coordinator.HaversineDistance(state.Location.Lat, state.Location.Lon, home.Lat, home.Lon)
+		home.BearingDeg = initialBearing(state.Location.Lat, state.Location.Lon, home.Lat, home.Lon)
+	}
+	state.Home = &home
+}
+
+// forget drops the home of a device
+func (t *homeTracker) forget(deviceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.devices, deviceID)
+}
+
+// initialBearing returns the direction from the first point to the second
+// in degrees from north (0-360)
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLon := (lon2 - lon1) * math.Pi / 180
+
+	y := math.Sin(deltaLon) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLon)
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+	return math.Mod(bearing+360, 360)
+}
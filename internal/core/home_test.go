@@ -0,0 +1,68 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestHomeTracker_FirstArmedFix(t *testing.T) {
+	tr := newHomeTracker()
+	state := &models.DroneState{DeviceID: "drone-1", Location: models.Location{Lat: 22.5, Lon: 113.9, AltGNSS: 40}}
+
+	tr.apply(state)
+	if state.Home != nil {
+		t.Fatalf("Home = %+v, want none before arming", state.Home)
+	}
+
+	state.Status.Armed = true
+	tr.apply(state)
+	if state.Home == nil || state.Home.Source != models.HomeSourceArmed || state.Home.Alt != 40 || state.Home.DistanceM != 0 {
+		t.Fatalf("Home = %+v, want the first armed fix", state.Home)
+	}
+
+	// Roughly 111 m north of home, so home is due south
+	state.Location.Lat = 22.501
+	tr.apply(state)
+	if math.Abs(state.Home.DistanceM-111.2) > 0.5 || math.Abs(state.Home.BearingDeg-180) > 0.01 {
+		t.Errorf("Home = %+v, want about 111.2 m at 180 deg", state.Home)
+	}
+
+	// Disarmed and armed again at another site
+	state.Status.Armed = false
+	tr.apply(state)
+	state.Status.Armed = true
+	tr.apply(state)
+	if state.Home.Lat != 22.501 || state.Home.DistanceM != 0 {
+		t.Errorf("Home = %+v, want reset on arming", state.Home)
+	}
+
+	tr.forget("drone-1")
+	state.Home = nil
+	state.Status.Armed = false
+	tr.apply(state)
+	if state.Home != nil {
+		t.Error("forget should drop the home position")
+	}
+}
+
+func TestHomeTracker_Autopilot(t *testing.T) {
+	tr := newHomeTracker()
+	state := &models.DroneState{
+		DeviceID: "mavlink-1",
+		Location: models.Location{Lat: 22.5, Lon: 113.901},
+		Home:     &models.HomePosition{Lat: 22.5, Lon: 113.9, Alt: 35, Source: models.HomeSourceAutopilot},
+	}
+	tr.apply(state)
+	if math.Abs(state.Home.DistanceM-102.8) > 0.5 || math.Abs(state.Home.BearingDeg-270) > 0.01 {
+		t.Errorf("Home = %+v, want about 102.8 m at 270 deg", state.Home)
+	}
+
+	// Kept across arming and for states that do not carry it
+	next := &models.DroneState{DeviceID: "mavlink-1", Location: models.Location{Lat: 22.6, Lon: 113.9}, Status: models.Status{Armed: true}}
+	tr.apply(next)
+	if next.Home == nil || next.Home.Source != models.HomeSourceAutopilot || next.Home.Lat != 22.5 {
+		t.Errorf("Home = %+v, want the autopilot home", next.Home)
+	}
+}
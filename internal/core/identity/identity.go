@@ -0,0 +1,196 @@
+// Package identity merges the device IDs under which one aircraft is seen
+// by several sources, e.g. a MAVLink system ID and its Remote ID or ADS-B
+// address, into one canonical device. Aliases map the IDs of the other
+// sources to the canonical ID; the states of all of them are fused into one
+// state that lists where each part came from.
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+var (
+	// ErrInvalidAlias is returned for an incomplete or circular alias
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrNotFound is returned for device IDs without an alias
+	ErrNotFound = errors.New("alias not found")
+)
+
+// Alias maps the device ID reported by a source to a canonical device ID
+type Alias struct {
+	ID        string `json:"id"`               // Device ID as reported by the adapter
+	Canonical string `json:"canonical"`        // Device ID the states are merged into
+	Source    string `json:"source,omitempty"` // Only map states of this protocol source (empty = any)
+	UpdatedAt int64  `json:"updated_at"`       // Unix ms
+}
+
+// Mapper applies aliases to states and fuses the states of each canonical
+// device
+type Mapper struct {
+	mu      sync.Mutex
+	aliases map[string]Alias   // Alias ID -> alias
+	devices map[string]*device // Canonical ID -> fused state
+}
+
+// device is the fused state of a canonical device and its sources
+type device struct {
+	state   *models.DroneState
+	sources map[[2]string]int64 // {device ID, protocol source} -> last state, Unix ms
+}
+
+// New creates a mapper with the given aliases
+func New(aliases []Alias) (*Mapper, error) {
+	m := &Mapper{
+		aliases: make(map[string]Alias),
+		devices: make(map[string]*device),
+	}
+	for _, a := range aliases {
+		if _, err := m.Set(a); err != nil {
+			return nil, fmt.Errorf("alias %s: %w", a.ID, err)
+		}
+	}
+	return m, nil
+}
+
+// Set adds or replaces the alias for a device ID. Aliases do not chain: the
+// canonical ID must not be an alias itself, and an ID that other aliases
+// point to cannot become an alias.
+func (m *Mapper) Set(a Alias) (Alias, error) {
+	if a.ID == "" || a.Canonical == "" {
+		return Alias{}, fmt.Errorf("%w: id and canonical are required", ErrInvalidAlias)
+	}
+	if a.ID == a.Canonical {
+		return Alias{}, fmt.Errorf("%w: canonical must differ from the id", ErrInvalidAlias)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.aliases[a.Canonical]; ok {
+		return Alias{}, fmt.Errorf("%w: %s is an alias of %s", ErrInvalidAlias, a.Canonical, m.aliases[a.Canonical].Canonical)
+	}
+	for _, other := range m.aliases {
+		if other.Canonical == a.ID {
+			return Alias{}, fmt.Errorf("%w: %s is the canonical id of %s", ErrInvalidAlias, a.ID, other.ID)
+		}
+	}
+
+	if old, ok := m.aliases[a.ID]; ok {
+		delete(m.devices, old.Canonical)
+	}
+	a.UpdatedAt = time.Now().UnixMilli()
+	m.aliases[a.ID] = a
+	delete(m.devices, a.Canonical)
+	return a, nil
+}
+
+// Delete removes the alias for a device ID. Its states are reported under
+// their own ID again.
+func (m *Mapper) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.aliases[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(m.aliases, id)
+	delete(m.devices, a.Canonical)
+	return nil
+}
+
+// List returns all aliases sorted by ID
+func (m *Mapper) List() []Alias {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]Alias, 0, len(m.aliases))
+	for _, a := range m.aliases {
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Apply renames a state reported under an alias to its canonical ID. States
+// of canonical devices are fused with the previous state: parts the state
+// carries older data for than another source sent (position and velocity,
+// attitude, battery level, going by Freshness) are taken from the previous
+// state, and Sources lists every source seen for the device. States of
+// devices without aliases are left unchanged.
+func (m *Mapper) Apply(state *models.DroneState, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reported := state.DeviceID
+	if a, ok := m.aliases[reported]; ok && (a.Source == "" || a.Source == state.ProtocolSource) {
+		state.DeviceID = a.Canonical
+	}
+	if !m.isCanonical(state.DeviceID) {
+		return
+	}
+
+	d, ok := m.devices[state.DeviceID]
+	if !ok {
+		d = &device{sources: make(map[[2]string]int64)}
+		m.devices[state.DeviceID] = d
+	}
+	d.sources[[2]string{reported, state.ProtocolSource}] = now.UnixMilli()
+	if d.state != nil {
+		fuse(state, d.state)
+	}
+
+	state.Sources = make([]models.SourceInfo, 0, len(d.sources))
+	for key, lastSeen := range d.sources {
+		state.Sources = append(state.Sources, models.SourceInfo{DeviceID: key[0], ProtocolSource: key[1], LastSeen: lastSeen})
+	}
+	sort.Slice(state.Sources, func(i, j int) bool {
+		if state.Sources[i].DeviceID != state.Sources[j].DeviceID {
+			return state.Sources[i].DeviceID < state.Sources[j].DeviceID
+		}
+		return state.Sources[i].ProtocolSource < state.Sources[j].ProtocolSource
+	})
+
+	fused := *state
+	d.state = &fused
+}
+
+// Forget drops the fused state of a device, e.g. after it was evicted
+func (m *Mapper) Forget(deviceID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.devices, deviceID)
+}
+
+// isCanonical reports whether an alias points to the device ID. Caller must
+// hold the lock.
+func (m *Mapper) isCanonical(id string) bool {
+	for _, a := range m.aliases {
+		if a.Canonical == id {
+			return true
+		}
+	}
+	return false
+}
+
+// fuse copies the parts of prev that are fresher than those of state
+func fuse(state, prev *models.DroneState) {
+	if state.Freshness.PositionAt < prev.Freshness.PositionAt {
+		state.Location = prev.Location
+		state.Velocity = prev.Velocity
+		state.Freshness.PositionAt = prev.Freshness.PositionAt
+	}
+	if state.Freshness.AttitudeAt < prev.Freshness.AttitudeAt {
+		state.Attitude = prev.Attitude
+		state.Freshness.AttitudeAt = prev.Freshness.AttitudeAt
+	}
+	if state.Freshness.BatteryAt < prev.Freshness.BatteryAt {
+		state.Status.BatteryPercent = prev.Status.BatteryPercent
+		state.Freshness.BatteryAt = prev.Freshness.BatteryAt
+	}
+}
@@ -0,0 +1,109 @@
+package identity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestMapper_Set(t *testing.T) {
+	m, err := New([]Alias{{ID: "rid-1", Canonical: "mavlink-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, a := range []Alias{
+		{ID: "rid-2"},                         // No canonical
+		{ID: "rid-2", Canonical: "rid-2"},     // Maps to itself
+		{ID: "adsb-1", Canonical: "rid-1"},    // Canonical is an alias
+		{ID: "mavlink-1", Canonical: "dji-1"}, // ID is a canonical
+	} {
+		if _, err := m.Set(a); !errors.Is(err, ErrInvalidAlias) {
+			t.Errorf("Set(%+v) error = %v, want ErrInvalidAlias", a, err)
+		}
+	}
+
+	a, err := m.Set(Alias{ID: "adsb-1", Canonical: "mavlink-1", Source: "poll"})
+	if err != nil || a.UpdatedAt == 0 {
+		t.Fatalf("Set() = %+v, %v", a, err)
+	}
+	if got := m.List(); len(got) != 2 || got[0].ID != "adsb-1" || got[1].ID != "rid-1" {
+		t.Errorf("List() = %+v, want adsb-1 and rid-1", got)
+	}
+
+	if err := m.Delete("rid-1"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if err := m.Delete("rid-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() again error = %v, want ErrNotFound", err)
+	}
+
+	if _, err := New([]Alias{{ID: "a", Canonical: "b"}, {ID: "b", Canonical: "c"}}); err == nil {
+		t.Error("New() should reject chained aliases")
+	}
+}
+
+func TestMapper_Apply(t *testing.T) {
+	m, _ := New([]Alias{
+		{ID: "rid-1", Canonical: "mavlink-1"},
+		{ID: "drone-1", Canonical: "mavlink-1", Source: "dji"},
+	})
+	now := time.Unix(1700000000, 0)
+
+	// The autopilot link reports everything
+	full := models.NewDroneState("mavlink-1", "mavlink")
+	full.Location = models.Location{Lat: 22.54, Lon: 113.94}
+	full.Attitude.Yaw = 90
+	full.Status.BatteryPercent = 80
+	full.Freshness = models.FreshnessAt(1000)
+	m.Apply(full, now)
+	if len(full.Sources) != 1 || full.Sources[0].ProtocolSource != "mavlink" {
+		t.Errorf("Sources = %+v, want the mavlink link", full.Sources)
+	}
+
+	// Remote ID only reports a newer position
+	rid := models.NewDroneState("rid-1", "poll")
+	rid.Location = models.Location{Lat: 22.55, Lon: 113.95}
+	rid.Freshness = models.Freshness{PositionAt: 2000}
+	m.Apply(rid, now.Add(time.Second))
+
+	if rid.DeviceID != "mavlink-1" {
+		t.Fatalf("DeviceID = %s, want the canonical mavlink-1", rid.DeviceID)
+	}
+	if rid.Location.Lat != 22.55 || rid.Attitude.Yaw != 90 || rid.Status.BatteryPercent != 80 {
+		t.Errorf("Fused state = %+v / %+v / %+v, want the RID position with the mavlink attitude and battery",
+			rid.Location, rid.Attitude, rid.Status)
+	}
+	if rid.Freshness != (models.Freshness{PositionAt: 2000, AttitudeAt: 1000, BatteryAt: 1000}) {
+		t.Errorf("Freshness = %+v", rid.Freshness)
+	}
+	if len(rid.Sources) != 2 || rid.Sources[0].DeviceID != "mavlink-1" || rid.Sources[1].DeviceID != "rid-1" ||
+		rid.Sources[1].LastSeen != now.Add(time.Second).UnixMilli() {
+		t.Errorf("Sources = %+v, want mavlink-1 and rid-1", rid.Sources)
+	}
+
+	// An older mavlink position does not replace the RID one
+	stale := models.NewDroneState("mavlink-1", "mavlink")
+	stale.Location = models.Location{Lat: 22.54, Lon: 113.94}
+	stale.Freshness = models.FreshnessAt(1500)
+	m.Apply(stale, now.Add(2*time.Second))
+	if stale.Location.Lat != 22.55 || stale.Freshness.AttitudeAt != 1500 {
+		t.Errorf("Fused state = %+v, want the RID position and the new attitude", stale)
+	}
+
+	// Source-specific aliases only map their source
+	other := models.NewDroneState("drone-1", "mavlink")
+	m.Apply(other, now)
+	if other.DeviceID != "drone-1" || other.Sources != nil {
+		t.Errorf("State of another source = %+v, want it unchanged", other)
+	}
+
+	// Devices without aliases are untouched
+	plain := models.NewDroneState("mavlink-2", "mavlink")
+	m.Apply(plain, now)
+	if plain.Sources != nil {
+		t.Errorf("Sources = %+v, want none", plain.Sources)
+	}
+}
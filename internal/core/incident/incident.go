@@ -0,0 +1,360 @@
+// Package incident groups alerts and link events for the same device that
+// occur close together into a single incident, so an emergency such as a
+// link loss followed by a geofence exit and a low battery shows up as one
+// incident instead of a burst of unrelated alerts.
+package incident
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+)
+
+// DefaultWindow is the correlation window when Config.Window is 0
+const DefaultWindow = 5 * time.Minute
+
+// DefaultMaxIncidents is the number of incidents kept when Config.MaxIncidents is 0
+const DefaultMaxIncidents = 500
+
+// ErrIncidentNotFound is returned for an unknown incident ID
+var ErrIncidentNotFound = errors.New("incident not found")
+
+// Link event kinds. Alerts use their alert type as the kind.
+const (
+	KindLinkLost     = "link_lost"     // The device went offline
+	KindLinkRestored = "link_restored" // The device reported again after going offline
+)
+
+// Status is the state of an incident
+type Status string
+
+const (
+	StatusOpen     Status = "open"
+	StatusResolved Status = "resolved"
+)
+
+// Config holds correlation settings
+type Config struct {
+	Window       time.Duration // Events this close to the previous one join its incident (0 = DefaultWindow)
+	MaxIncidents int           // Oldest incidents are dropped beyond this (0 = DefaultMaxIncidents)
+}
+
+// Event is one alert or link event of an incident
+type Event struct {
+	Kind      string                `json:"kind"` // Alert type, link_lost or link_restored
+	AlertID   string                `json:"alert_id,omitempty"`
+	Severity  alerter.AlertSeverity `json:"severity"`
+	Message   string                `json:"message"`
+	Timestamp int64                 `json:"timestamp"` // Unix ms
+}
+
+// Incident is a group of related events for one device
+type Incident struct {
+	ID         string                `json:"id"`
+	DeviceID   string                `json:"device_id"`
+	Status     Status                `json:"status"`
+	Severity   alerter.AlertSeverity `json:"severity"`   // Highest event severity
+	RootCause  string                `json:"root_cause"` // Kind of the first event
+	Summary    string                `json:"summary"`    // Distinct kinds in order, e.g. "link_lost → geofence_breach → battery_low"
+	StartedAt  int64                 `json:"started_at"` // Unix ms
+	UpdatedAt  int64                 `json:"updated_at"` // Unix ms of the last event
+	ResolvedAt int64                 `json:"resolved_at,omitempty"`
+	Events     []Event               `json:"events"`
+
+	linkDown bool // The device is offline; the incident stays open until it reports again
+}
+
+// Query selects incidents
+type Query struct {
+	DeviceID string
+	Status   Status // "" = all
+	Limit    int    // 0 = no limit
+}
+
+// Correlator builds incidents from alerts and link events
+type Correlator struct {
+	window int64
+	max    int
+	now    func() time.Time
+
+	mu        sync.Mutex
+	incidents []*Incident          // Oldest first
+	current   map[string]*Incident // Latest incident per device
+	pending   map[string][]Event   // Link loss and restore not yet part of an incident
+}
+
+// New creates a correlator
+func New(cfg Config) *Correlator {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.MaxIncidents <= 0 {
+		cfg.MaxIncidents = DefaultMaxIncidents
+	}
+	return &Correlator{
+		window:  cfg.Window.Milliseconds(),
+		max:     cfg.MaxIncidents,
+		now:     time.Now,
+		current: make(map[string]*Incident),
+		pending: make(map[string][]Event),
+	}
+}
+
+// SetWindow changes the correlation window (0 = DefaultWindow)
+func (c *Correlator) SetWindow(window time.Duration) {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = window.Milliseconds()
+}
+
+// AddAlert correlates an alert. Alerts without a device, such as a degraded
+// publisher, are ignored. Returns the incident the alert joined or opened.
+func (c *Correlator) AddAlert(a *alerter.Alert) *Incident {
+	if a == nil || a.DeviceID == "" {
+		return nil
+	}
+	ev := Event{
+		Kind:      string(a.Type),
+		AlertID:   a.ID,
+		Severity:  a.Severity,
+		Message:   a.Message,
+		Timestamp: a.Timestamp,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inc := c.active(a.DeviceID, ev.Timestamp)
+	if inc == nil {
+		inc = c.open(a.DeviceID)
+		// A recent link loss is the likely cause of what followed
+		if pending := c.pending[a.DeviceID]; len(pending) > 0 && ev.Timestamp-pending[len(pending)-1].Timestamp < c.window {
+			for _, p := range pending {
+				c.append(inc, p)
+			}
+			inc.linkDown = pending[len(pending)-1].Kind == KindLinkLost
+		}
+	}
+	delete(c.pending, a.DeviceID)
+	c.append(inc, ev)
+	return c.snapshot(inc)
+}
+
+// LinkLost records that a device went offline. It joins the device's open
+// incident, which then stays open until the link is restored. Otherwise it
+// is held, together with a following restore, and becomes the start of an
+// incident if an alert follows within a window, so a drone that is simply
+// switched off opens no incident.
+func (c *Correlator) LinkLost(deviceID string, ts int64) *Incident {
+	ev := Event{
+		Kind:      KindLinkLost,
+		Severity:  alerter.SeverityWarning,
+		Message:   "Lost link to drone " + deviceID,
+		Timestamp: ts,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inc := c.active(deviceID, ts)
+	if inc == nil {
+		c.pending[deviceID] = []Event{ev}
+		return nil
+	}
+	c.append(inc, ev)
+	inc.linkDown = true
+	return c.snapshot(inc)
+}
+
+// LinkRestored records that a device reported again after going offline.
+// It joins the incident or the held link loss of the device, if any.
+func (c *Correlator) LinkRestored(deviceID string, ts int64) *Incident {
+	ev := Event{
+		Kind:      KindLinkRestored,
+		Severity:  alerter.SeverityInfo,
+		Message:   "Link to drone " + deviceID + " restored",
+		Timestamp: ts,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inc := c.active(deviceID, ts)
+	if inc == nil || !inc.linkDown {
+		if pending := c.pending[deviceID]; len(pending) == 1 {
+			c.pending[deviceID] = append(pending, ev)
+		}
+		return nil
+	}
+	c.append(inc, ev)
+	inc.linkDown = false
+	return c.snapshot(inc)
+}
+
+// List returns the matching incidents, most recently updated first
+func (c *Correlator) List(q Query) []Incident {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now().UnixMilli()
+	result := make([]Incident, 0)
+	for _, inc := range c.incidents {
+		if q.DeviceID != "" && inc.DeviceID != q.DeviceID {
+			continue
+		}
+		c.resolve(inc, now)
+		if q.Status != "" && inc.Status != q.Status {
+			continue
+		}
+		result = append(result, *c.snapshot(inc))
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].UpdatedAt > result[j].UpdatedAt })
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result
+}
+
+// Get returns an incident
+func (c *Correlator) Get(id string) (Incident, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, inc := range c.incidents {
+		if inc.ID == id {
+			c.resolve(inc, c.now().UnixMilli())
+			return *c.snapshot(inc), nil
+		}
+	}
+	return Incident{}, ErrIncidentNotFound
+}
+
+// Prune removes resolved incidents whose last event is before the given
+// time and returns how many were removed
+func (c *Correlator) Prune(before time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now, cutoff := c.now().UnixMilli(), before.UnixMilli()
+	kept := c.incidents[:0]
+	for _, inc := range c.incidents {
+		c.resolve(inc, now)
+		if inc.Status == StatusResolved && inc.UpdatedAt < cutoff {
+			c.forget(inc)
+			continue
+		}
+		kept = append(kept, inc)
+	}
+	removed := len(c.incidents) - len(kept)
+	clear(c.incidents[len(kept):])
+	c.incidents = kept
+
+	// Link events no alert followed within a window will never be used
+	for id, pending := range c.pending {
+		if now-pending[len(pending)-1].Timestamp >= c.window {
+			delete(c.pending, id)
+		}
+	}
+	return removed
+}
+
+// active returns the device's incident if an event at ts still joins it.
+// Caller must hold the lock.
+func (c *Correlator) active(deviceID string, ts int64) *Incident {
+	inc, ok := c.current[deviceID]
+	if !ok {
+		return nil
+	}
+	c.resolve(inc, ts)
+	if inc.Status == StatusResolved {
+		return nil
+	}
+	return inc
+}
+
+// open starts a new incident for a device. Caller must hold the lock.
+func (c *Correlator) open(deviceID string) *Incident {
+	inc := &Incident{
+		ID:       uuid.New().String(),
+		DeviceID: deviceID,
+		Status:   StatusOpen,
+	}
+	c.incidents = append(c.incidents, inc)
+	c.current[deviceID] = inc
+	if len(c.incidents) > c.max {
+		c.forget(c.incidents[0])
+		c.incidents[0] = nil
+		c.incidents = c.incidents[1:]
+	}
+	return inc
+}
+
+// forget removes an incident from the per-device index. Caller must hold
+// the lock.
+func (c *Correlator) forget(inc *Incident) {
+	if c.current[inc.DeviceID] == inc {
+		delete(c.current, inc.DeviceID)
+	}
+}
+
+// append adds an event to an incident. Caller must hold the lock.
+func (c *Correlator) append(inc *Incident, ev Event) {
+	inc.Events = append(inc.Events, ev)
+	if len(inc.Events) == 1 {
+		inc.StartedAt = ev.Timestamp
+		inc.RootCause = ev.Kind
+	}
+	inc.UpdatedAt = max(inc.UpdatedAt, ev.Timestamp)
+	if severityRank(ev.Severity) > severityRank(inc.Severity) {
+		inc.Severity = ev.Severity
+	}
+
+	var kinds []string
+	for _, e := range inc.Events {
+		if !slices.Contains(kinds, e.Kind) {
+			kinds = append(kinds, e.Kind)
+		}
+	}
+	inc.Summary = strings.Join(kinds, " → ")
+}
+
+// resolve marks an incident resolved once its link is up and no event
+// followed for a full window. Caller must hold the lock.
+func (c *Correlator) resolve(inc *Incident, now int64) {
+	if inc.Status == StatusResolved || inc.linkDown || now-inc.UpdatedAt < c.window {
+		return
+	}
+	inc.Status = StatusResolved
+	inc.ResolvedAt = inc.UpdatedAt + c.window
+}
+
+// snapshot returns a copy of an incident that is safe to hand out. Caller
+// must hold the lock.
+func (c *Correlator) snapshot(inc *Incident) *Incident {
+	cp := *inc
+	cp.Events = append([]Event(nil), inc.Events...)
+	return &cp
+}
+
+// severityRank orders severities from least to most severe
+func severityRank(s alerter.AlertSeverity) int {
+	switch s {
+	case alerter.SeverityInfo:
+		return 1
+	case alerter.SeverityWarning:
+		return 2
+	case alerter.SeverityCritical:
+		return 3
+	}
+	return 0
+}
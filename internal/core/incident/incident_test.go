@@ -0,0 +1,124 @@
+package incident
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+)
+
+func alert(deviceID string, alertType alerter.AlertType, severity alerter.AlertSeverity, ts int64) *alerter.Alert {
+	return &alerter.Alert{ID: string(alertType) + "-alert", Type: alertType, Severity: severity, DeviceID: deviceID, Timestamp: ts}
+}
+
+func TestCorrelator_GroupsRelatedEvents(t *testing.T) {
+	c := New(Config{Window: time.Minute})
+	now := int64(10 * 60000)
+	c.now = func() time.Time { return time.UnixMilli(now) }
+
+	// A drone switched off opens nothing
+	if c.LinkLost("drone-2", now) != nil {
+		t.Error("LinkLost() without a following alert should not open an incident")
+	}
+
+	// The drone reports again outside its geofence
+	c.LinkLost("drone-1", now)
+	if c.LinkRestored("drone-1", now+15000) != nil {
+		t.Error("LinkRestored() without an incident should not open one")
+	}
+	inc := c.AddAlert(alert("drone-1", alerter.AlertTypeGeofenceBreach, alerter.SeverityWarning, now+20000))
+	if inc == nil || inc.RootCause != KindLinkLost || len(inc.Events) != 3 {
+		t.Fatalf("AddAlert() after link loss = %+v, want an incident rooted in the link loss", inc)
+	}
+	c.AddAlert(alert("drone-1", alerter.AlertTypeBatteryLow, alerter.SeverityCritical, now+50000))
+	c.AddAlert(alert("drone-1", alerter.AlertTypeBatteryLow, alerter.SeverityCritical, now+70000))
+
+	if c.AddAlert(alert("", alerter.AlertTypePublisherDegraded, alerter.SeverityCritical, now)) != nil {
+		t.Error("System alerts should be ignored")
+	}
+
+	incidents := c.List(Query{})
+	if len(incidents) != 1 {
+		t.Fatalf("List() returned %d incidents, want 1", len(incidents))
+	}
+	got := incidents[0]
+	if got.Status != StatusOpen || got.Severity != alerter.SeverityCritical || len(got.Events) != 5 {
+		t.Errorf("Incident = %+v", got)
+	}
+	if want := "link_lost → link_restored → geofence_breach → battery_low"; got.Summary != want {
+		t.Errorf("Summary = %q, want %q", got.Summary, want)
+	}
+
+	// Quiet for a full window resolves it; the next alert opens a new one
+	now += 70000 + 60000
+	if incidents := c.List(Query{Status: StatusResolved}); len(incidents) != 1 || incidents[0].ResolvedAt != 10*60000+130000 {
+		t.Errorf("List(resolved) = %+v", incidents)
+	}
+	next := c.AddAlert(alert("drone-1", alerter.AlertTypeSignalWeak, alerter.SeverityWarning, now))
+	if next.ID == got.ID || next.RootCause != string(alerter.AlertTypeSignalWeak) {
+		t.Errorf("Alert after the window joined the resolved incident: %+v", next)
+	}
+
+	if _, err := c.Get("missing"); !errors.Is(err, ErrIncidentNotFound) {
+		t.Errorf("Get() unknown error = %v, want ErrIncidentNotFound", err)
+	}
+	if inc, err := c.Get(got.ID); err != nil || inc.DeviceID != "drone-1" {
+		t.Errorf("Get() = %+v, %v", inc, err)
+	}
+}
+
+func TestCorrelator_OpenWhileLinkDown(t *testing.T) {
+	c := New(Config{Window: time.Minute})
+	now := int64(60000)
+	c.now = func() time.Time { return time.UnixMilli(now) }
+
+	c.AddAlert(alert("drone-1", alerter.AlertTypeSignalWeak, alerter.SeverityWarning, now))
+	c.LinkLost("drone-1", now+10000)
+
+	now += 10 * 60000
+	if incidents := c.List(Query{Status: StatusOpen}); len(incidents) != 1 {
+		t.Fatalf("Incident should stay open while the link is down, got %+v", incidents)
+	}
+	if removed := c.Prune(time.UnixMilli(now)); removed != 0 {
+		t.Errorf("Prune() removed %d open incidents", removed)
+	}
+
+	inc := c.LinkRestored("drone-1", now)
+	if inc == nil || inc.Events[len(inc.Events)-1].Kind != KindLinkRestored {
+		t.Fatalf("LinkRestored() = %+v, want the restore appended", inc)
+	}
+
+	now += 60000
+	if removed := c.Prune(time.UnixMilli(now)); removed != 1 {
+		t.Errorf("Prune() removed %d, want the resolved incident", removed)
+	}
+	if len(c.List(Query{})) != 0 {
+		t.Error("List() should be empty after pruning")
+	}
+}
+
+func TestCorrelator_SetWindow(t *testing.T) {
+	c := New(Config{})
+	c.SetWindow(10 * time.Second)
+	c.AddAlert(alert("drone-1", alerter.AlertTypeSignalWeak, alerter.SeverityWarning, 0))
+	c.AddAlert(alert("drone-1", alerter.AlertTypeBatteryLow, alerter.SeverityWarning, 15000))
+	if incidents := c.List(Query{}); len(incidents) != 2 {
+		t.Errorf("Alerts 15 s apart with a 10 s window gave %d incidents, want 2", len(incidents))
+	}
+}
+
+func TestCorrelator_MaxIncidents(t *testing.T) {
+	c := New(Config{Window: time.Minute, MaxIncidents: 2})
+	for i, id := range []string{"a", "b", "c"} {
+		c.AddAlert(alert(id, alerter.AlertTypeBatteryLow, alerter.SeverityWarning, int64(i)))
+	}
+
+	incidents := c.List(Query{Limit: 5})
+	if len(incidents) != 2 || incidents[0].DeviceID != "c" || incidents[1].DeviceID != "b" {
+		t.Errorf("List() = %+v, want the two newest incidents", incidents)
+	}
+	if len(c.List(Query{DeviceID: "c", Limit: 1})) != 1 {
+		t.Error("List(device) should return the device's incident")
+	}
+}
@@ -1,36 +1,39 @@
 package core
 
 import (
-	"context"
-
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
 )
 
-// Adapter is the interface that all southbound protocol adapters must implement
-type Adapter interface {
-	// Name returns the adapter name (e.g., "mavlink", "dji", "gb28181")
-	Name() string
+// Adapter is the interface that all southbound protocol adapters must
+// implement. It is defined in pkg/sdk so external adapters can implement it.
+type Adapter = sdk.Adapter
 
-	// Start begins receiving data and sends DroneState events to the channel
-	// The adapter should respect context cancellation for graceful shutdown
-	Start(ctx context.Context, events chan<- *models.DroneState) error
+// Publisher is the interface that all northbound publishers must implement.
+// It is defined in pkg/sdk so external publishers can implement it.
+type Publisher = sdk.Publisher
 
-	// Stop gracefully stops the adapter
-	Stop() error
-}
+// BatchPublisher is implemented by publishers that accept several states
+// per call. It is defined in pkg/sdk so external publishers can implement it.
+type BatchPublisher = sdk.BatchPublisher
+
+// StatsReporter is implemented by adapters that count the traffic they
+// receive. It is defined in pkg/sdk so external adapters can implement it.
+type StatsReporter = sdk.StatsReporter
 
-// Publisher is the interface that all northbound publishers must implement
-type Publisher interface {
-	// Name returns the publisher name (e.g., "mqtt", "websocket", "http")
-	Name() string
+// AdapterStats are the traffic counters of an adapter
+type AdapterStats = sdk.AdapterStats
 
-	// Start initializes the publisher and prepares for publishing
-	// The publisher should respect context cancellation for graceful shutdown
-	Start(ctx context.Context) error
+// HealthReporter is implemented by publishers that report the state of
+// their protocol session. It is defined in pkg/sdk so external publishers
+// can implement it.
+type HealthReporter = sdk.HealthReporter
 
-	// Publish sends a DroneState to the destination
-	Publish(state *models.DroneState) error
+// PublisherDetail is the protocol-level health of a publisher
+type PublisherDetail = sdk.PublisherDetail
 
-	// Stop gracefully stops the publisher
-	Stop() error
+// AdapterHost is implemented by adapters that accept other adapters at
+// runtime, such as out-of-process adapters connecting over a socket. The
+// hosted adapters are listed alongside the registered ones.
+type AdapterHost interface {
+	HostedAdapters() []string
 }
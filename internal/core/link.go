@@ -0,0 +1,27 @@
+package core
+
+import (
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// LinkSource is implemented by adapters whose link to the drones can be
+// lost and come back without a restart, such as a hot-plugged serial port.
+// The engine sets the callback on registration and publishes an
+// AdapterStatus event for each change.
+type LinkSource interface {
+	SetLinkCallback(fn func(status *models.LinkStatus))
+}
+
+// linkChanged returns the callback that publishes an adapter's link
+// changes on the event bus
+func (e *Engine) linkChanged(source string) func(status *models.LinkStatus) {
+	return func(status *models.LinkStatus) {
+		e.bus.Publish(events.Event{
+			Type:   events.AdapterStatus,
+			Source: source,
+			Error:  status.Error,
+			Link:   status,
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// linkAdapter reports link changes through its callback
+type linkAdapter struct {
+	fakeAdapter
+	notify func(status *models.LinkStatus)
+}
+
+func (a *linkAdapter) SetLinkCallback(fn func(status *models.LinkStatus)) {
+	a.notify = fn
+}
+
+func TestEngine_LinkStatus(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	adapter := &linkAdapter{fakeAdapter: fakeAdapter{name: "mavlink"}}
+	e.RegisterAdapter(adapter)
+
+	var received []events.Event
+	e.Events().Subscribe("test", func(ev events.Event) {
+		received = append(received, ev)
+	}, events.AdapterStatus)
+
+	adapter.notify(&models.LinkStatus{Connected: true, Endpoint: "/dev/ttyUSB0@57600"})
+	adapter.notify(&models.LinkStatus{Endpoint: "/dev/ttyUSB0@57600", Error: "EOF"})
+
+	if len(received) != 2 || received[0].Source != "mavlink" || !received[0].Link.Connected {
+		t.Fatalf("AdapterStatus events = %+v", received)
+	}
+	if received[1].Link.Connected || received[1].Error != "EOF" {
+		t.Errorf("Lost link event = %+v", received[1])
+	}
+}
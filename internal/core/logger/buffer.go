@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,6 +22,58 @@ const (
 	LevelError Level = "error"
 )
 
+// levelPriority orders levels from least to most severe
+var levelPriority = map[Level]int{
+	LevelDebug: 0,
+	LevelInfo:  1,
+	LevelWarn:  2,
+	LevelError: 3,
+}
+
+// ParseLevel parses a level name (debug, info, warn, error)
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return "", fmt.Errorf("unknown log level %q", s)
+}
+
+// ParseSourceLevels parses per-source level names, such as the
+// server.log_levels setting
+func ParseSourceLevels(names map[string]string) (map[string]Level, error) {
+	sources := make([]string, 0, len(names))
+	for source := range names {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	levels := make(map[string]Level, len(names))
+	for _, source := range sources {
+		if strings.TrimSpace(source) == "" {
+			return nil, fmt.Errorf("missing source name")
+		}
+		if strings.TrimSpace(names[source]) == "" {
+			return nil, fmt.Errorf("%s: missing log level", source)
+		}
+		level, err := ParseLevel(names[source])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		levels[source] = level
+	}
+	return levels, nil
+}
+
+// Fields holds structured key/value data attached to a log entry
+type Fields map[string]interface{}
+
 // Entry represents a single log entry
 type Entry struct {
 	ID        int64  `json:"id"`
@@ -27,6 +81,7 @@ type Entry struct {
 	Level     Level  `json:"level"`
 	Source    string `json:"source"`
 	Message   string `json:"message"`
+	Fields    Fields `json:"fields,omitempty"`
 }
 
 // Subscriber receives log entries via a channel
@@ -36,15 +91,32 @@ type Subscriber struct {
 	Ch     chan *Entry
 }
 
+// Counts are the entries a source logged per level, and how many more its
+// level filter suppressed
+type Counts struct {
+	Levels     map[Level]int64 `json:"levels"`
+	Suppressed int64           `json:"suppressed"`
+}
+
+// maxCountedSources bounds the sources with their own counters; entries of
+// further sources are counted under OtherSource
+const maxCountedSources = 256
+
+// OtherSource collects the counts of sources beyond maxCountedSources
+const OtherSource = "other"
+
 // Buffer is a ring buffer for log entries with subscriber support
 type Buffer struct {
-	entries     []Entry
-	head        int
-	size        int
-	cap         int
-	nextID      int64
-	subscribers map[string]*Subscriber
-	mu          sync.RWMutex
+	entries      []Entry
+	head         int
+	size         int
+	cap          int
+	nextID       int64
+	minLevel     Level
+	sourceLevels map[string]Level // Per-source overrides of minLevel
+	counts       map[string]*Counts
+	subscribers  map[string]*Subscriber
+	mu           sync.RWMutex
 }
 
 // New creates a new log buffer with the specified capacity
@@ -56,37 +128,120 @@ func New(capacity int) *Buffer {
 		entries:     make([]Entry, capacity),
 		cap:         capacity,
 		nextID:      1,
+		minLevel:    LevelDebug,
 		subscribers: make(map[string]*Subscriber),
+
+		sourceLevels: make(map[string]Level),
+		counts:       make(map[string]*Counts),
 	}
 }
 
+// SetLevel sets the minimum level accepted by the buffer
+func (b *Buffer) SetLevel(level Level) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.minLevel = level
+}
+
+// Level returns the minimum level accepted by the buffer
+func (b *Buffer) Level() Level {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.minLevel
+}
+
+// Enabled reports whether entries of the given level pass the level filter
+func (b *Buffer) Enabled(level Level) bool {
+	return shouldSend(level, b.Level())
+}
+
+// SetSourceLevels replaces the per-source minimum levels, which override
+// the buffer's level for entries from those sources
+func (b *Buffer) SetSourceLevels(levels map[string]Level) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sourceLevels = make(map[string]Level, len(levels))
+	for source, level := range levels {
+		b.sourceLevels[source] = level
+	}
+}
+
+// SourceLevels returns the per-source level overrides
+func (b *Buffer) SourceLevels() map[string]Level {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	levels := make(map[string]Level, len(b.sourceLevels))
+	for source, level := range b.sourceLevels {
+		levels[source] = level
+	}
+	return levels
+}
+
+// Counts returns the entries logged and suppressed per source since the
+// buffer was created. Clear does not reset them.
+func (b *Buffer) Counts() map[string]Counts {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	counts := make(map[string]Counts, len(b.counts))
+	for source, c := range b.counts {
+		levels := make(map[Level]int64, len(c.Levels))
+		for level, n := range c.Levels {
+			levels[level] = n
+		}
+		counts[source] = Counts{Levels: levels, Suppressed: c.Suppressed}
+	}
+	return counts
+}
+
+// countsFor returns the counters of a source. Must be called with b.mu held.
+func (b *Buffer) countsFor(source string) *Counts {
+	c, ok := b.counts[source]
+	if ok {
+		return c
+	}
+	if len(b.counts) >= maxCountedSources {
+		source = OtherSource
+		if c, ok := b.counts[source]; ok {
+			return c
+		}
+	}
+	c = &Counts{Levels: make(map[Level]int64)}
+	b.counts[source] = c
+	return c
+}
+
 // Write implements io.Writer interface for use with log.SetOutput
 func (b *Buffer) Write(p []byte) (n int, err error) {
-	// Parse the log message and add to buffer
+	level, source, msg := parseLine(p)
+	b.Add(level, source, msg)
+	return len(p), nil
+}
+
+// parseLine extracts level, source and message from a standard log line
+func parseLine(p []byte) (Level, string, string) {
 	msg := string(p)
 	if len(msg) > 0 && msg[len(msg)-1] == '\n' {
 		msg = msg[:len(msg)-1]
 	}
 
-	// Try to detect level from message prefix
-	level := LevelInfo
+	msg = stripLogPrefix(msg)
+
 	source := "system"
 
-	// Parse source from [Source] prefix
-	if len(msg) > 2 && msg[0] == '[' {
-		for i := 1; i < len(msg); i++ {
-			if msg[i] == ']' {
-				source = msg[1:i]
-				msg = msg[i+1:]
-				if len(msg) > 0 && msg[0] == ' ' {
-					msg = msg[1:]
-				}
-				break
-			}
-		}
+	// Parse source from [Source] prefix. An explicit [LEVEL] tag, first or
+	// after the source, sets the level.
+	tag, rest, ok := cutTag(msg)
+	if ok && !isLevelTag(tag) {
+		source, msg = tag, rest
+		tag, rest, ok = cutTag(msg)
+	}
+	if ok && isLevelTag(tag) {
+		level, _ := ParseLevel(tag)
+		return level, source, rest
 	}
 
-	// Detect level from keywords
+	// Untagged lines fall back to keywords
+	level := LevelInfo
 	switch {
 	case containsIgnoreCase(msg, "error"):
 		level = LevelError
@@ -96,8 +251,42 @@ func (b *Buffer) Write(p []byte) (n int, err error) {
 		level = LevelDebug
 	}
 
-	b.Add(level, source, msg)
-	return len(p), nil
+	return level, source, msg
+}
+
+// cutTag splits a leading "[tag] " off a message
+func cutTag(msg string) (tag, rest string, ok bool) {
+	if len(msg) < 3 || msg[0] != '[' {
+		return "", msg, false
+	}
+	end := strings.IndexByte(msg, ']')
+	if end < 2 {
+		return "", msg, false
+	}
+	return msg[1:end], strings.TrimPrefix(msg[end+1:], " "), true
+}
+
+// isLevelTag reports whether a tag names a log level, e.g. [ERROR]
+func isLevelTag(tag string) bool {
+	_, err := ParseLevel(tag)
+	return err == nil && tag != ""
+}
+
+// stripLogPrefix removes a standard log package date/time prefix
+// ("2006/01/02 15:04:05[.000000] ") so the [Source] tag can be parsed
+func stripLogPrefix(msg string) string {
+	if len(msg) < 20 || msg[4] != '/' || msg[7] != '/' || msg[10] != ' ' || msg[13] != ':' || msg[16] != ':' {
+		return msg
+	}
+	i := 19
+	if msg[i] == '.' {
+		for i++; i < len(msg) && msg[i] >= '0' && msg[i] <= '9'; i++ {
+		}
+	}
+	if i < len(msg) && msg[i] == ' ' {
+		return msg[i+1:]
+	}
+	return msg
 }
 
 // containsIgnoreCase checks if s contains substr (case-insensitive)
@@ -128,16 +317,36 @@ func containsIgnoreCase(s, substr string) bool {
 	return false
 }
 
-// Add adds a new log entry to the buffer
+// Add adds a new log entry to the buffer.
+// Returns nil if the level is below the minimum level for its source.
 func (b *Buffer) Add(level Level, source, message string) *Entry {
+	return b.AddFields(level, source, message, nil)
+}
+
+// AddFields adds a new log entry with structured fields to the buffer.
+// Returns nil if the level is below the minimum level for its source.
+func (b *Buffer) AddFields(level Level, source, message string, fields Fields) *Entry {
 	b.mu.Lock()
 
+	minLevel, ok := b.sourceLevels[source]
+	if !ok {
+		minLevel = b.minLevel
+	}
+	counts := b.countsFor(source)
+	if !shouldSend(level, minLevel) {
+		counts.Suppressed++
+		b.mu.Unlock()
+		return nil
+	}
+	counts.Levels[level]++
+
 	entry := Entry{
 		ID:        b.nextID,
 		Timestamp: time.Now().UnixMilli(),
 		Level:     level,
 		Source:    source,
 		Message:   message,
+		Fields:    fields,
 	}
 	b.nextID++
 
@@ -172,15 +381,8 @@ func (b *Buffer) Add(level Level, source, message string) *Entry {
 
 // shouldSend checks if an entry with given level should be sent to a subscriber with the given filter
 func shouldSend(entryLevel, filterLevel Level) bool {
-	levels := map[Level]int{
-		LevelDebug: 0,
-		LevelInfo:  1,
-		LevelWarn:  2,
-		LevelError: 3,
-	}
-
-	entryPriority, ok1 := levels[entryLevel]
-	filterPriority, ok2 := levels[filterLevel]
+	entryPriority, ok1 := levelPriority[entryLevel]
+	filterPriority, ok2 := levelPriority[filterLevel]
 
 	if !ok1 || !ok2 {
 		return true // Unknown levels, send anyway
@@ -222,10 +424,7 @@ func (b *Buffer) GetSince(sinceID int64) []Entry {
 	}
 
 	var result []Entry
-	start := 0
-	if b.size == b.cap {
-		start = b.head
-	}
+	start := b.oldest()
 
 	for i := 0; i < b.size; i++ {
 		idx := (start + i) % b.cap
@@ -247,10 +446,7 @@ func (b *Buffer) GetFiltered(level Level, source string, limit int) []Entry {
 	}
 
 	var result []Entry
-	start := 0
-	if b.size == b.cap {
-		start = b.head
-	}
+	start := b.oldest()
 
 	for i := 0; i < b.size; i++ {
 		idx := (start + i) % b.cap
@@ -300,6 +496,27 @@ func (b *Buffer) Unsubscribe(id string) {
 	}
 }
 
+// Prune removes entries older than before.
+// Returns the number of entries removed.
+func (b *Buffer) Prune(before time.Time) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cutoff := before.UnixMilli()
+	removed := 0
+	start := b.oldest()
+	for removed < b.size && b.entries[(start+removed)%b.cap].Timestamp < cutoff {
+		removed++
+	}
+	b.size -= removed
+	return removed
+}
+
+// oldest returns the index of the oldest entry. Must be called with b.mu held.
+func (b *Buffer) oldest() int {
+	return (b.head - b.size + b.cap) % b.cap
+}
+
 // Size returns the current number of entries
 func (b *Buffer) Size() int {
 	b.mu.RLock()
@@ -337,10 +554,14 @@ func (b *Buffer) Error(source, format string, args ...interface{}) {
 	b.Add(LevelError, source, fmt.Sprintf(format, args...))
 }
 
-// MultiWriter combines the buffer with stdout for dual output
+// MultiWriter combines the buffer with stdout and an optional log file.
+// Entries below the buffer's level are dropped from every output.
 type MultiWriter struct {
 	buffer *Buffer
 	stdout io.Writer
+	file   io.Writer
+	json   bool
+	mu     sync.Mutex
 }
 
 // NewMultiWriter creates a writer that outputs to both buffer and stdout
@@ -351,21 +572,111 @@ func NewMultiWriter(buffer *Buffer, stdout io.Writer) *MultiWriter {
 	}
 }
 
-// Write implements io.Writer
+// SetFile sets an additional output that receives JSON lines (nil disables)
+func (mw *MultiWriter) SetFile(w io.Writer) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.file = w
+}
+
+// SetJSON switches stdout between text and JSON lines
+func (mw *MultiWriter) SetJSON(enabled bool) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.json = enabled
+}
+
+// Write implements io.Writer. In text mode the line is passed to stdout
+// unchanged.
 func (mw *MultiWriter) Write(p []byte) (n int, err error) {
-	// Write to stdout first
+	level, source, msg := parseLine(p)
+	mw.emit(level, source, msg, nil, p)
+	return len(p), nil
+}
+
+// Log records a structured entry in the buffer and writes it to the outputs
+func (mw *MultiWriter) Log(level Level, source, message string, fields Fields) {
+	mw.emit(level, source, message, fields, nil)
+}
+
+// emit adds the entry to the buffer and writes it to stdout and the file.
+// raw, if set, is the original text line used for text-mode stdout.
+func (mw *MultiWriter) emit(level Level, source, message string, fields Fields, raw []byte) {
+	entry := mw.buffer.AddFields(level, source, message, fields)
+	if entry == nil {
+		return
+	}
+
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
 	if mw.stdout != nil {
-		mw.stdout.Write(p)
+		switch {
+		case mw.json:
+			mw.stdout.Write(formatJSON(entry))
+		case raw != nil:
+			mw.stdout.Write(raw)
+		default:
+			mw.stdout.Write(formatText(entry))
+		}
+	}
+	if mw.file != nil {
+		mw.file.Write(formatJSON(entry))
+	}
+}
+
+// jsonLine is the on-disk/stdout representation of an entry
+type jsonLine struct {
+	Time    string `json:"time"`
+	Level   Level  `json:"level"`
+	Source  string `json:"source"`
+	Message string `json:"message"`
+	Fields  Fields `json:"fields,omitempty"`
+}
+
+// formatJSON renders an entry as a single JSON line
+func formatJSON(e *Entry) []byte {
+	data, err := json.Marshal(jsonLine{
+		Time:    time.UnixMilli(e.Timestamp).Format("2006-01-02T15:04:05.000Z07:00"),
+		Level:   e.Level,
+		Source:  e.Source,
+		Message: e.Message,
+		Fields:  e.Fields,
+	})
+	if err != nil {
+		data, _ = json.Marshal(jsonLine{Level: e.Level, Source: e.Source, Message: e.Message})
 	}
-	// Then write to buffer
-	return mw.buffer.Write(p)
+	return append(data, '\n')
+}
+
+// formatText renders an entry as a human-readable line
+func formatText(e *Entry) []byte {
+	var sb strings.Builder
+	sb.WriteString(time.UnixMilli(e.Timestamp).Format("2006/01/02 15:04:05.000 "))
+	if e.Source != "system" {
+		sb.WriteString("[" + e.Source + "] ")
+	}
+	sb.WriteString(e.Message)
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " %s=%v", k, e.Fields[k])
+	}
+
+	sb.WriteByte('\n')
+	return []byte(sb.String())
 }
 
 // SetupGlobalLogger configures the standard log package to use the buffer
-func SetupGlobalLogger(buffer *Buffer, stdout io.Writer) {
+func SetupGlobalLogger(buffer *Buffer, stdout io.Writer) *MultiWriter {
 	mw := NewMultiWriter(buffer, stdout)
 	log.SetOutput(mw)
 	log.SetFlags(log.Ldate | log.Ltime)
+	return mw
 }
 
 // MarshalJSON returns JSON representation of entries
@@ -2,6 +2,9 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -221,6 +224,27 @@ func TestBuffer_Write_LevelDetection(t *testing.T) {
 	}
 }
 
+func TestBuffer_Write_LevelTag(t *testing.T) {
+	tests := []struct {
+		line   string
+		level  Level
+		source string
+		msg    string
+	}{
+		{"[Engine] failover complete", LevelInfo, "Engine", "failover complete"},
+		{"[MQTT] published 10 states, 0 failures", LevelInfo, "MQTT", "published 10 states, 0 failures"},
+		{"[MQTT] [ERROR] connection lost", LevelError, "MQTT", "connection lost"},
+		{"[WARN] disk almost full", LevelWarn, "system", "disk almost full"},
+		{"[Test] [debug] reported errors: 0", LevelDebug, "Test", "reported errors: 0"},
+	}
+	for _, tt := range tests {
+		level, source, msg := parseLine([]byte(tt.line + "\n"))
+		if level != tt.level || source != tt.source || msg != tt.msg {
+			t.Errorf("parseLine(%q) = %s, %q, %q, want %s, %q, %q", tt.line, level, source, msg, tt.level, tt.source, tt.msg)
+		}
+	}
+}
+
 func TestBuffer_Subscribe(t *testing.T) {
 	buf := New(10)
 
@@ -316,6 +340,29 @@ func TestBuffer_Clear(t *testing.T) {
 	}
 }
 
+func TestBuffer_Prune(t *testing.T) {
+	b := New(3)
+	for i := 0; i < 4; i++ {
+		b.Add(LevelInfo, "test", "message")
+	}
+
+	b.mu.Lock()
+	start := b.oldest()
+	b.entries[start].Timestamp = 1000
+	b.entries[(start+1)%b.cap].Timestamp = 2000
+	b.mu.Unlock()
+
+	if removed := b.Prune(time.UnixMilli(3000)); removed != 2 {
+		t.Errorf("Prune() = %d, want 2", removed)
+	}
+	if b.Size() != 1 {
+		t.Errorf("Size() after Prune = %d, want 1", b.Size())
+	}
+	if entries := b.GetSince(0); len(entries) != 1 || entries[0].ID != 4 {
+		t.Errorf("GetSince(0) after Prune = %+v, want entry 4", entries)
+	}
+}
+
 func TestBuffer_LogHelpers(t *testing.T) {
 	buf := New(10)
 
@@ -416,3 +463,201 @@ func TestShouldSend(t *testing.T) {
 		}
 	}
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Level
+	}{
+		{"debug", LevelDebug},
+		{"", LevelInfo},
+		{"INFO", LevelInfo},
+		{"warning", LevelWarn},
+		{"error", LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) error: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel should fail for unknown level")
+	}
+}
+
+func TestParseSourceLevels(t *testing.T) {
+	levels, err := ParseSourceLevels(map[string]string{"MAVLink": "WARNING", "DJI": "debug"})
+	if err != nil {
+		t.Fatalf("ParseSourceLevels failed: %v", err)
+	}
+	if levels["MAVLink"] != LevelWarn || levels["DJI"] != LevelDebug {
+		t.Errorf("ParseSourceLevels = %v", levels)
+	}
+
+	for _, names := range []map[string]string{{"MAVLink": "trace"}, {"MAVLink": ""}, {"": "info"}} {
+		if _, err := ParseSourceLevels(names); err == nil {
+			t.Errorf("ParseSourceLevels(%v) should fail", names)
+		}
+	}
+}
+
+func TestBuffer_SetLevel(t *testing.T) {
+	buf := New(10)
+	buf.SetLevel(LevelWarn)
+
+	if e := buf.Add(LevelInfo, "test", "info"); e != nil {
+		t.Error("Info entry should be filtered at warn level")
+	}
+	if e := buf.Add(LevelError, "test", "error"); e == nil {
+		t.Error("Error entry should pass warn level")
+	}
+	if buf.Size() != 1 {
+		t.Errorf("Size = %d, want 1", buf.Size())
+	}
+	if buf.Enabled(LevelDebug) {
+		t.Error("Debug should not be enabled at warn level")
+	}
+}
+
+func TestBuffer_SetSourceLevel(t *testing.T) {
+	buf := New(10)
+	buf.SetLevel(LevelInfo)
+	buf.SetSourceLevels(map[string]Level{"MAVLink": LevelError, "DJI": LevelDebug})
+
+	if e := buf.Add(LevelWarn, "MAVLink", "noisy"); e != nil {
+		t.Error("Warn entry should be filtered for a source at error level")
+	}
+	if e := buf.Add(LevelDebug, "DJI", "details"); e == nil {
+		t.Error("Debug entry should pass for a source at debug level")
+	}
+	if e := buf.Add(LevelDebug, "Engine", "details"); e != nil {
+		t.Error("Other sources should keep the buffer level")
+	}
+
+	buf.SetSourceLevels(map[string]Level{"DJI": LevelDebug})
+	if e := buf.Add(LevelWarn, "MAVLink", "back"); e == nil {
+		t.Error("Removing the override should restore the buffer level")
+	}
+	if levels := buf.SourceLevels(); len(levels) != 1 || levels["DJI"] != LevelDebug {
+		t.Errorf("SourceLevels = %v", levels)
+	}
+}
+
+func TestBuffer_Counts(t *testing.T) {
+	buf := New(2)
+	buf.SetSourceLevels(map[string]Level{"MAVLink": LevelWarn})
+	buf.Add(LevelInfo, "MAVLink", "suppressed")
+	buf.Add(LevelError, "MAVLink", "kept")
+	buf.Add(LevelInfo, "Engine", "kept")
+	buf.Add(LevelInfo, "Engine", "kept, evicting the first")
+	buf.Clear()
+
+	counts := buf.Counts()
+	if c := counts["MAVLink"]; c.Levels[LevelError] != 1 || c.Levels[LevelInfo] != 0 || c.Suppressed != 1 {
+		t.Errorf("MAVLink counts = %+v", c)
+	}
+	if c := counts["Engine"]; c.Levels[LevelInfo] != 2 {
+		t.Errorf("Engine counts = %+v", c)
+	}
+
+	for i := 0; i < maxCountedSources; i++ {
+		buf.Add(LevelInfo, fmt.Sprintf("source-%d", i), "message")
+	}
+	counts = buf.Counts()
+	if len(counts) != maxCountedSources+1 || counts[OtherSource].Levels[LevelInfo] != 2 {
+		t.Errorf("Sources beyond the limit should be counted as %q: %d sources, %+v", OtherSource, len(counts), counts[OtherSource])
+	}
+}
+
+func TestBuffer_AddFields(t *testing.T) {
+	buf := New(10)
+
+	buf.AddFields(LevelInfo, "Engine", "state processed", Fields{"device_id": "drone-1"})
+
+	entries := buf.GetLast(1)
+	if len(entries) != 1 {
+		t.Fatal("AddFields should add one entry")
+	}
+	if entries[0].Fields["device_id"] != "drone-1" {
+		t.Errorf("Fields = %v, want device_id=drone-1", entries[0].Fields)
+	}
+}
+
+func TestStripLogPrefix(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"2024/01/02 03:04:05 [HTTP] ready", "[HTTP] ready"},
+		{"2024/01/02 03:04:05.123456 [HTTP] ready", "[HTTP] ready"},
+		{"[HTTP] ready", "[HTTP] ready"},
+	}
+	for _, tt := range tests {
+		if got := stripLogPrefix(tt.in); got != tt.want {
+			t.Errorf("stripLogPrefix(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMultiWriter_LevelFilter(t *testing.T) {
+	buf := New(10)
+	buf.SetLevel(LevelWarn)
+	var stdout bytes.Buffer
+
+	mw := NewMultiWriter(buf, &stdout)
+	mw.Write([]byte("[Test] Hello world\n"))
+
+	if stdout.Len() != 0 {
+		t.Errorf("Filtered entry should not reach stdout, got %q", stdout.String())
+	}
+	if buf.Size() != 0 {
+		t.Errorf("Filtered entry should not reach buffer, size %d", buf.Size())
+	}
+
+	// Fatal messages are tagged so they survive the filter
+	mw.Write([]byte("2024/01/01 12:00:00 [ERROR] Failed to start HTTP server: address in use\n"))
+	if !strings.Contains(stdout.String(), "Failed to start HTTP server") {
+		t.Errorf("Error entry should reach stdout, got %q", stdout.String())
+	}
+}
+
+func TestMultiWriter_JSON(t *testing.T) {
+	buf := New(10)
+	var stdout, file bytes.Buffer
+
+	mw := NewMultiWriter(buf, &stdout)
+	mw.SetJSON(true)
+	mw.SetFile(&file)
+	mw.Log(LevelWarn, "MQTT", "reconnecting", Fields{"attempt": 3})
+
+	for name, out := range map[string]*bytes.Buffer{"stdout": &stdout, "file": &file} {
+		var line map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+			t.Fatalf("%s: invalid JSON line %q: %v", name, out.String(), err)
+		}
+		if line["level"] != "warn" || line["source"] != "MQTT" || line["message"] != "reconnecting" {
+			t.Errorf("%s: unexpected line %v", name, line)
+		}
+		fields, _ := line["fields"].(map[string]interface{})
+		if fields["attempt"] != float64(3) {
+			t.Errorf("%s: fields = %v, want attempt=3", name, fields)
+		}
+	}
+}
+
+func TestMultiWriter_TextFields(t *testing.T) {
+	buf := New(10)
+	var stdout bytes.Buffer
+
+	mw := NewMultiWriter(buf, &stdout)
+	mw.Log(LevelInfo, "Engine", "started", Fields{"b": 2, "a": 1})
+
+	if !strings.HasSuffix(stdout.String(), "[Engine] started a=1 b=2\n") {
+		t.Errorf("Unexpected text line %q", stdout.String())
+	}
+}
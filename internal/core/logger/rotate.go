@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp embedded in rotated file names
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateConfig contains rotating log file settings
+type RotateConfig struct {
+	Path       string // Active log file path
+	MaxSizeMB  int    // Rotate when the file exceeds this size (0 = never)
+	MaxAgeDays int    // Delete rotated files older than this (0 = keep)
+	MaxBackups int    // Maximum number of rotated files to keep (0 = keep all)
+}
+
+// RotatingFile is an io.Writer that rotates the underlying file by size
+// and prunes old backups by age and count
+type RotatingFile struct {
+	cfg      RotateConfig
+	maxBytes int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens (or creates) the log file for appending
+func NewRotatingFile(cfg RotateConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("log file path is required")
+	}
+	r := &RotatingFile{
+		cfg:      cfg,
+		maxBytes: int64(cfg.MaxSizeMB) * 1024 * 1024,
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+		return nil, fmt.Errorf("creating log directory: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.prune()
+	return r, nil
+}
+
+// open opens the active file and records its current size
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write implements io.Writer
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, fmt.Errorf("log file closed")
+	}
+
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the active file, renames it to a timestamped backup and
+// starts a new file
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate()
+}
+
+func (r *RotatingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return fmt.Errorf("closing log file: %w", err)
+		}
+		r.file = nil
+	}
+
+	if err := os.Rename(r.cfg.Path, r.backupName(time.Now())); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("renaming log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+// backupName returns an unused backup path for the given time
+func (r *RotatingFile) backupName(t time.Time) string {
+	dir := filepath.Dir(r.cfg.Path)
+	ext := filepath.Ext(r.cfg.Path)
+	base := strings.TrimSuffix(filepath.Base(r.cfg.Path), ext)
+	stamp := t.Format(backupTimeFormat)
+
+	name := filepath.Join(dir, fmt.Sprintf("%s-%s%s", base, stamp, ext))
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name
+		}
+		name = filepath.Join(dir, fmt.Sprintf("%s-%s-%d%s", base, stamp, i, ext))
+	}
+}
+
+// backup is a rotated log file
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups lists rotated files, newest first
+func (r *RotatingFile) backups() []backup {
+	dir := filepath.Dir(r.cfg.Path)
+	ext := filepath.Ext(r.cfg.Path)
+	prefix := strings.TrimSuffix(filepath.Base(r.cfg.Path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var result []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		result = append(result, backup{path: filepath.Join(dir, name), time: t})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].time.Equal(result[j].time) {
+			return result[i].path > result[j].path
+		}
+		return result[i].time.After(result[j].time)
+	})
+	return result
+}
+
+// prune removes backups beyond MaxBackups or older than MaxAgeDays
+func (r *RotatingFile) prune() {
+	if r.cfg.MaxBackups <= 0 && r.cfg.MaxAgeDays <= 0 {
+		return
+	}
+
+	cutoff := time.Now().Add(-time.Duration(r.cfg.MaxAgeDays) * 24 * time.Hour)
+	for i, b := range r.backups() {
+		if (r.cfg.MaxBackups > 0 && i >= r.cfg.MaxBackups) ||
+			(r.cfg.MaxAgeDays > 0 && b.time.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+// PruneBefore removes rotated files older than before.
+// Returns the number of files removed.
+func (r *RotatingFile) PruneBefore(before time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for _, b := range r.backups() {
+		if b.time.Before(before) && os.Remove(b.path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// Close closes the active file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
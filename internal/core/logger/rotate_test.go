@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRotatingFile_EmptyPath(t *testing.T) {
+	if _, err := NewRotatingFile(RotateConfig{}); err == nil {
+		t.Error("NewRotatingFile should fail without a path")
+	}
+}
+
+func TestRotatingFile_RotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "outb.log")
+
+	r, err := NewRotatingFile(RotateConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewRotatingFile error: %v", err)
+	}
+	defer r.Close()
+	r.maxBytes = 32
+
+	line := []byte(strings.Repeat("x", 19) + "\n")
+	for i := 0; i < 3; i++ {
+		if _, err := r.Write(line); err != nil {
+			t.Fatalf("Write error: %v", err)
+		}
+	}
+
+	if got := len(r.backups()); got != 2 {
+		t.Errorf("backups = %d, want 2", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+	if string(data) != string(line) {
+		t.Errorf("active file = %q, want one line", data)
+	}
+}
+
+func TestRotatingFile_MaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "outb.log")
+
+	r, err := NewRotatingFile(RotateConfig{Path: path, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewRotatingFile error: %v", err)
+	}
+	defer r.Close()
+
+	for i := 0; i < 4; i++ {
+		r.Write([]byte("entry\n"))
+		if err := r.Rotate(); err != nil {
+			t.Fatalf("Rotate error: %v", err)
+		}
+	}
+
+	if got := len(r.backups()); got != 2 {
+		t.Errorf("backups = %d, want 2", got)
+	}
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "outb.log")
+
+	// An old backup from 10 days ago
+	old := filepath.Join(dir, "outb-"+time.Now().AddDate(0, 0, -10).Format(backupTimeFormat)+".log")
+	if err := os.WriteFile(old, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	r, err := NewRotatingFile(RotateConfig{Path: path, MaxAgeDays: 7})
+	if err != nil {
+		t.Fatalf("NewRotatingFile error: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Backup older than max age should be removed")
+	}
+}
+
+func TestRotatingFile_PruneBefore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "outb.log")
+
+	old := filepath.Join(dir, "outb-"+time.Now().AddDate(-2, 0, 0).Format(backupTimeFormat)+".log")
+	recent := filepath.Join(dir, "outb-"+time.Now().AddDate(0, 0, -1).Format(backupTimeFormat)+".log")
+	for _, f := range []string{old, recent} {
+		if err := os.WriteFile(f, []byte("x\n"), 0644); err != nil {
+			t.Fatalf("WriteFile error: %v", err)
+		}
+	}
+
+	r, err := NewRotatingFile(RotateConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewRotatingFile error: %v", err)
+	}
+	defer r.Close()
+
+	if removed := r.PruneBefore(time.Now().AddDate(-1, 0, 0)); removed != 1 {
+		t.Errorf("PruneBefore() = %d, want 1", removed)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Backup older than cutoff should be removed")
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Error("Recent backup should be kept")
+	}
+}
+
+func TestRotatingFile_WriteAfterClose(t *testing.T) {
+	r, err := NewRotatingFile(RotateConfig{Path: filepath.Join(t.TempDir(), "outb.log")})
+	if err != nil {
+		t.Fatalf("NewRotatingFile error: %v", err)
+	}
+	r.Close()
+
+	if _, err := r.Write([]byte("x\n")); err == nil {
+		t.Error("Write after Close should fail")
+	}
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// MissionSource is implemented by adapters that capture the mission plans
+// loaded on drones. The engine sets the callback on registration and
+// publishes a MissionChanged event for each change.
+type MissionSource interface {
+	Mission(deviceID string) (*models.Mission, bool)
+	SetMissionCallback(fn func(deviceID string, mission *models.Mission))
+}
+
+// Mission returns the mission an adapter captured for a device
+func (e *Engine) Mission(deviceID string) (*models.Mission, bool) {
+	for _, adapter := range e.adapters {
+		if source, ok := adapter.(MissionSource); ok {
+			if mission, ok := source.Mission(deviceID); ok {
+				return mission, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// missionChanged returns the callback that publishes an adapter's mission
+// changes on the event bus
+func (e *Engine) missionChanged(source string) func(deviceID string, mission *models.Mission) {
+	return func(deviceID string, mission *models.Mission) {
+		e.bus.Publish(events.Event{
+			Type:     events.MissionChanged,
+			DeviceID: deviceID,
+			Source:   source,
+			Mission:  mission,
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// missionAdapter holds missions set through its callback
+type missionAdapter struct {
+	fakeAdapter
+	missions map[string]*models.Mission
+	notify   func(deviceID string, mission *models.Mission)
+}
+
+func (a *missionAdapter) Mission(deviceID string) (*models.Mission, bool) {
+	m, ok := a.missions[deviceID]
+	return m, ok
+}
+
+func (a *missionAdapter) SetMissionCallback(fn func(deviceID string, mission *models.Mission)) {
+	a.notify = fn
+}
+
+func (a *missionAdapter) capture(deviceID string, mission *models.Mission) {
+	a.missions[deviceID] = mission
+	a.notify(deviceID, mission)
+}
+
+func TestEngine_Mission(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	adapter := &missionAdapter{fakeAdapter: fakeAdapter{name: "mavlink"}, missions: make(map[string]*models.Mission)}
+	e.RegisterAdapter(&fakeAdapter{name: "dji"})
+	e.RegisterAdapter(adapter)
+
+	var received []events.Event
+	e.Events().Subscribe("test", func(ev events.Event) {
+		received = append(received, ev)
+	}, events.MissionChanged)
+
+	adapter.capture("mavlink-1", &models.Mission{Items: []models.MissionItem{{Seq: 0, Command: "NAV_TAKEOFF"}}})
+
+	if len(received) != 1 || received[0].DeviceID != "mavlink-1" || received[0].Source != "mavlink" || received[0].Mission == nil {
+		t.Fatalf("MissionChanged events = %+v", received)
+	}
+	if m, ok := e.Mission("mavlink-1"); !ok || len(m.Items) != 1 {
+		t.Errorf("Mission() = %+v, %v", m, ok)
+	}
+	if _, ok := e.Mission("dji-1"); ok {
+		t.Error("Mission() should report unknown devices as missing")
+	}
+}
@@ -0,0 +1,301 @@
+// Package notify delivers operator notifications, such as raised and
+// escalated alerts, to external channels: HTTP webhooks, email and SMS.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownChannel is returned when sending to a channel that is not configured
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	// ErrRateLimited is returned when a channel has sent its maximum number
+	// of messages for the current minute
+	ErrRateLimited = errors.New("notification rate limit exceeded")
+)
+
+// Message is a notification
+type Message struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	Data    any    `json:"data,omitempty"` // Structured payload for webhooks, e.g. the alert
+}
+
+// Channel delivers messages to one destination
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Webhook posts messages as JSON to a URL
+type Webhook struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhook creates a webhook channel. Headers are added to every request,
+// e.g. an Authorization token.
+func NewWebhook(name, url string, headers map[string]string) *Webhook {
+	return &Webhook{
+		name:    name,
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the channel name
+func (w *Webhook) Name() string {
+	return w.name
+}
+
+// Send posts the message. Any non-2xx response is an error.
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encoding webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", w.name, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: unexpected status %s", w.name, resp.Status)
+	}
+	return nil
+}
+
+// EmailConfig contains SMTP settings
+type EmailConfig struct {
+	Name     string
+	Address  string // SMTP server host:port; STARTTLS is used when offered
+	Username string // Empty = no authentication
+	Password string
+	From     string
+	To       []string
+}
+
+// Email sends messages as plain-text mail
+type Email struct {
+	cfg      EmailConfig
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	now      func() time.Time
+}
+
+// NewEmail creates an email channel
+func NewEmail(cfg EmailConfig) *Email {
+	return &Email{cfg: cfg, sendMail: smtp.SendMail, now: time.Now}
+}
+
+// Name returns the channel name
+func (e *Email) Name() string {
+	return e.cfg.Name
+}
+
+// Send mails the message to every recipient
+func (e *Email) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(e.cfg.Address)
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, host)
+	}
+	if err := e.sendMail(e.cfg.Address, auth, e.cfg.From, e.cfg.To, e.compose(msg)); err != nil {
+		return fmt.Errorf("email %s: %w", e.cfg.Name, err)
+	}
+	return nil
+}
+
+// compose builds the RFC 5322 message
+func (e *Email) compose(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerSafe(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// headerSafe strips line breaks that would inject further headers
+func headerSafe(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// DefaultSMSURL is the API base URL of SMS channels when none is configured
+const DefaultSMSURL = "https://api.twilio.com"
+
+// SMSConfig contains the settings of a Twilio-compatible SMS gateway
+type SMSConfig struct {
+	Name       string
+	URL        string // API base URL (default DefaultSMSURL)
+	AccountSID string
+	AuthToken  string
+	From       string // Sender number
+	To         []string
+}
+
+// SMS sends the message subject as a text message through a
+// Twilio-compatible HTTP API
+type SMS struct {
+	cfg    SMSConfig
+	client *http.Client
+}
+
+// NewSMS creates an SMS channel
+func NewSMS(cfg SMSConfig) *SMS {
+	if cfg.URL == "" {
+		cfg.URL = DefaultSMSURL
+	}
+	return &SMS{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name returns the channel name
+func (s *SMS) Name() string {
+	return s.cfg.Name
+}
+
+// Send texts the subject, or the text if there is no subject, to every
+// recipient
+func (s *SMS) Send(ctx context.Context, msg Message) error {
+	body := msg.Subject
+	if body == "" {
+		body = msg.Text
+	}
+	endpoint := strings.TrimSuffix(s.cfg.URL, "/") + "/2010-04-01/Accounts/" + url.PathEscape(s.cfg.AccountSID) + "/Messages.json"
+
+	var errs []error
+	for _, to := range s.cfg.To {
+		form := url.Values{"To": {to}, "From": {s.cfg.From}, "Body": {body}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(s.cfg.AccountSID, s.cfg.AuthToken)
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sms %s to %s: %w", s.cfg.Name, to, err))
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			errs = append(errs, fmt.Errorf("sms %s to %s: unexpected status %s", s.cfg.Name, to, resp.Status))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// limited is a channel sending at most max messages per minute
+type limited struct {
+	Channel
+	max int
+	now func() time.Time
+
+	mu   sync.Mutex
+	sent []time.Time // Send times within the last minute, oldest first
+}
+
+// WithRateLimit limits a channel to perMinute messages in any minute.
+// Messages over the limit are dropped with ErrRateLimited. A limit of 0 or
+// less returns the channel unchanged.
+func WithRateLimit(c Channel, perMinute int) Channel {
+	if perMinute <= 0 {
+		return c
+	}
+	return &limited{Channel: c, max: perMinute, now: time.Now}
+}
+
+// Send sends the message unless the limit is reached
+func (l *limited) Send(ctx context.Context, msg Message) error {
+	l.mu.Lock()
+	now := l.now()
+	i := 0
+	for i < len(l.sent) && now.Sub(l.sent[i]) >= time.Minute {
+		i++
+	}
+	l.sent = l.sent[i:]
+	if len(l.sent) >= l.max {
+		l.mu.Unlock()
+		return fmt.Errorf("%s: %w", l.Name(), ErrRateLimited)
+	}
+	l.sent = append(l.sent, now)
+	l.mu.Unlock()
+
+	return l.Channel.Send(ctx, msg)
+}
+
+// Dispatcher sends messages to channels by name
+type Dispatcher struct {
+	channels map[string]Channel
+}
+
+// NewDispatcher creates a dispatcher for the given channels
+func NewDispatcher(channels ...Channel) *Dispatcher {
+	d := &Dispatcher{channels: make(map[string]Channel)}
+	for _, c := range channels {
+		d.channels[c.Name()] = c
+	}
+	return d
+}
+
+// Names returns the configured channel names, sorted. A nil Dispatcher has
+// no channels.
+func (d *Dispatcher) Names() []string {
+	if d == nil {
+		return nil
+	}
+	names := make([]string, 0, len(d.channels))
+	for name := range d.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send delivers a message to the named channels and returns the joined
+// errors of the channels that failed
+func (d *Dispatcher) Send(ctx context.Context, names []string, msg Message) error {
+	var errs []error
+	for _, name := range names {
+		var c Channel
+		if d != nil {
+			c = d.channels[name]
+		}
+		if c == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownChannel, name))
+			continue
+		}
+		if err := c.Send(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhook_Send(t *testing.T) {
+	var got Message
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		if got.Subject == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	hook := NewWebhook("ops", srv.URL, map[string]string{"Authorization": "Bearer token"})
+	if err := hook.Send(context.Background(), Message{Subject: "Alert", Text: "Battery low", Data: map[string]int{"value": 9}}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.Subject != "Alert" || got.Text != "Battery low" || auth != "Bearer token" {
+		t.Errorf("Received %+v with Authorization %q", got, auth)
+	}
+	if err := hook.Send(context.Background(), Message{Subject: "fail"}); err == nil {
+		t.Error("Send() should fail on a non-2xx response")
+	}
+}
+
+func TestEmail_Send(t *testing.T) {
+	mail := NewEmail(EmailConfig{Name: "oncall", Address: "smtp.example.com:587", Username: "outb", Password: "secret",
+		From: "outb@example.com", To: []string{"a@example.com", "b@example.com"}})
+	mail.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	var addr string
+	var to []string
+	var body []byte
+	var auth smtp.Auth
+	mail.sendMail = func(a string, au smtp.Auth, from string, rcpt []string, msg []byte) error {
+		addr, auth, to, body = a, au, rcpt, msg
+		return nil
+	}
+
+	if err := mail.Send(context.Background(), Message{Subject: "Escalated\r\nBcc: x@evil", Text: "line 1\nline 2"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	msg := string(body)
+	if addr != "smtp.example.com:587" || auth == nil || len(to) != 2 {
+		t.Errorf("SendMail(%s, %v, %v)", addr, auth, to)
+	}
+	if !strings.Contains(msg, "Subject: Escalated  Bcc: x@evil\r\n") || strings.Contains(msg, "\r\nBcc:") {
+		t.Errorf("Subject header not sanitized:\n%s", msg)
+	}
+	if !strings.Contains(msg, "To: a@example.com, b@example.com\r\n") || !strings.HasSuffix(msg, "\r\n\r\nline 1\r\nline 2\r\n") {
+		t.Errorf("Message =\n%s", msg)
+	}
+}
+
+// recorder is a channel recording the messages it receives
+type recorder struct {
+	name string
+	sent []Message
+	err  error
+}
+
+func (r *recorder) Name() string { return r.name }
+
+func (r *recorder) Send(_ context.Context, msg Message) error {
+	r.sent = append(r.sent, msg)
+	return r.err
+}
+
+func TestDispatcher_Send(t *testing.T) {
+	ok := &recorder{name: "ok"}
+	broken := &recorder{name: "broken", err: errors.New("down")}
+	d := NewDispatcher(ok, broken)
+
+	if names := d.Names(); len(names) != 2 || names[0] != "broken" || names[1] != "ok" {
+		t.Errorf("Names() = %v", names)
+	}
+
+	err := d.Send(context.Background(), []string{"ok", "broken", "missing"}, Message{Subject: "x"})
+	if len(ok.sent) != 1 || len(broken.sent) != 1 {
+		t.Errorf("Delivered to ok %d, broken %d, want 1 each", len(ok.sent), len(broken.sent))
+	}
+	if !errors.Is(err, ErrUnknownChannel) || !strings.Contains(err.Error(), "down") {
+		t.Errorf("Send() error = %v, want the failed and unknown channels", err)
+	}
+
+	var none *Dispatcher
+	if none.Names() != nil || !errors.Is(none.Send(context.Background(), []string{"ok"}, Message{}), ErrUnknownChannel) {
+		t.Error("nil Dispatcher should have no channels")
+	}
+}
+
+func TestSMS_Send(t *testing.T) {
+	var bodies []string
+	var path, user, pass string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		r.ParseForm()
+		bodies = append(bodies, r.PostForm.Get("To")+"|"+r.PostForm.Get("From")+"|"+r.PostForm.Get("Body"))
+		if r.PostForm.Get("To") == "+15550000" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	sms := NewSMS(SMSConfig{Name: "oncall-sms", URL: srv.URL + "/", AccountSID: "AC123", AuthToken: "token",
+		From: "+15551234", To: []string{"+15559876", "+15550000"}})
+	err := sms.Send(context.Background(), Message{Subject: "[CRITICAL] Drone d1 battery 8%", Text: "details"})
+	if err == nil || !strings.Contains(err.Error(), "+15550000") || strings.Contains(err.Error(), "+15559876") {
+		t.Errorf("Send() error = %v, want only the rejected recipient", err)
+	}
+	if path != "/2010-04-01/Accounts/AC123/Messages.json" || user != "AC123" || pass != "token" {
+		t.Errorf("Request to %s as %s:%s", path, user, pass)
+	}
+	if len(bodies) != 2 || bodies[0] != "+15559876|+15551234|[CRITICAL] Drone d1 battery 8%" {
+		t.Errorf("Sent %v", bodies)
+	}
+
+	if NewSMS(SMSConfig{}).cfg.URL != DefaultSMSURL {
+		t.Error("NewSMS() should default to the Twilio API")
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	rec := &recorder{name: "sms"}
+	if WithRateLimit(rec, 0) != Channel(rec) {
+		t.Error("WithRateLimit(0) should not limit")
+	}
+
+	c := WithRateLimit(rec, 2).(*limited)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	for i := range 3 {
+		err := c.Send(context.Background(), Message{Subject: "x"})
+		if i < 2 && err != nil {
+			t.Fatalf("Send() %d error = %v", i, err)
+		}
+		if i == 2 && !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Send() over the limit error = %v, want ErrRateLimited", err)
+		}
+		now = now.Add(20 * time.Second)
+	}
+	if len(rec.sent) != 2 {
+		t.Errorf("Delivered %d messages, want 2", len(rec.sent))
+	}
+	// The first message is a minute old now
+	if err := c.Send(context.Background(), Message{Subject: "x"}); err != nil {
+		t.Errorf("Send() after the window error = %v", err)
+	}
+	if c.Name() != "sms" {
+		t.Errorf("Name() = %q", c.Name())
+	}
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+)
+
+// DefaultDeviceOfflineAfterMs is how long a device may stay silent before it
+// is reported offline
+const DefaultDeviceOfflineAfterMs = 30000
+
+// PresenceSource is implemented by adapters that notice a device is gone
+// before the offline timeout, e.g. when its connection goes silent. The
+// engine sets the callback on registration and reports the device offline
+// at once.
+type PresenceSource interface {
+	SetOfflineCallback(fn func(deviceID string))
+}
+
+// deviceGone returns the offline callback for an adapter
+func (e *Engine) deviceGone(adapter string) func(deviceID string) {
+	return func(deviceID string) {
+		if e.presence.leave(deviceID) {
+			e.bus.Publish(events.Event{Type: events.DeviceOffline, DeviceID: deviceID, Source: adapter})
+		}
+	}
+}
+
+// presenceTracker tracks which devices are online based on their last state
+type presenceTracker struct {
+	timeout time.Duration
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+	online   map[string]bool
+}
+
+// newPresenceTracker creates a tracker (timeoutMs <= 0 uses the default)
+func newPresenceTracker(timeoutMs int64) *presenceTracker {
+	if timeoutMs <= 0 {
+		timeoutMs = DefaultDeviceOfflineAfterMs
+	}
+	return &presenceTracker{
+		timeout:  time.Duration(timeoutMs) * time.Millisecond,
+		lastSeen: make(map[string]time.Time),
+		online:   make(map[string]bool),
+	}
+}
+
+// seen records a state from a device and reports whether it just came online
+func (p *presenceTracker) seen(deviceID string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastSeen[deviceID] = now
+	if p.online[deviceID] {
+		return false
+	}
+	p.online[deviceID] = true
+	return true
+}
+
+// expire marks silent devices offline and returns their IDs
+func (p *presenceTracker) expire(now time.Time) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var offline []string
+	for id, isOnline := range p.online {
+		if isOnline && now.Sub(p.lastSeen[id]) > p.timeout {
+			p.online[id] = false
+			offline = append(offline, id)
+		}
+	}
+	return offline
+}
+
+// leave marks a device offline before its timeout and reports whether it
+// was online
+func (p *presenceTracker) leave(deviceID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.online[deviceID] {
+		return false
+	}
+	p.online[deviceID] = false
+	return true
+}
+
+// forget removes a device and reports whether it was online
+func (p *presenceTracker) forget(deviceID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	online := p.online[deviceID]
+	delete(p.online, deviceID)
+	delete(p.lastSeen, deviceID)
+	return online
+}
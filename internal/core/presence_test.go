@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestPresenceTracker(t *testing.T) {
+	p := newPresenceTracker(1000)
+	now := time.Now()
+
+	if !p.seen("uav-1", now) {
+		t.Error("First state should bring the device online")
+	}
+	if p.seen("uav-1", now.Add(500*time.Millisecond)) {
+		t.Error("Repeated state should not report online again")
+	}
+	if offline := p.expire(now.Add(1200 * time.Millisecond)); len(offline) != 0 {
+		t.Errorf("Offline = %v, want none within the timeout", offline)
+	}
+	if offline := p.expire(now.Add(2 * time.Second)); len(offline) != 1 || offline[0] != "uav-1" {
+		t.Errorf("Offline = %v, want [uav-1]", offline)
+	}
+	if offline := p.expire(now.Add(3 * time.Second)); len(offline) != 0 {
+		t.Errorf("Offline = %v, want none after already reported", offline)
+	}
+	if !p.seen("uav-1", now.Add(4*time.Second)) {
+		t.Error("State after going offline should bring the device online again")
+	}
+}
+
+// failingPublisher always fails to publish
+type failingPublisher struct{}
+
+func (failingPublisher) Name() string                           { return "failing" }
+func (failingPublisher) Start(ctx context.Context) error        { return nil }
+func (failingPublisher) Publish(state *models.DroneState) error { return errors.New("broker down") }
+func (failingPublisher) Stop() error                            { return nil }
+
+func TestEngine_Events(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	e.RegisterPublisher(failingPublisher{})
+
+	var got []events.Event
+	e.Events().Subscribe("test", func(ev events.Event) { got = append(got, ev) })
+
+	e.processState(models.NewDroneState("uav-1", "mavlink"))
+
+	want := []events.Type{events.DeviceOnline, events.PublisherError, events.StateUpdated}
+	if len(got) != len(want) {
+		t.Fatalf("Events = %d, want %d", len(got), len(want))
+	}
+	for i, typ := range want {
+		if got[i].Type != typ || got[i].DeviceID != "uav-1" {
+			t.Errorf("Event %d = %s/%s, want %s/uav-1", i, got[i].Type, got[i].DeviceID, typ)
+		}
+	}
+	if got[1].Source != "failing" || got[1].Error != "broker down" {
+		t.Errorf("PublisherError = %+v", got[1])
+	}
+	if got[2].State == nil {
+		t.Error("StateUpdated should carry the state")
+	}
+}
+
+func TestEngine_EvictDevice(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1, TrackEnabled: true, TrackMaxPoints: 10, MaxDevices: 1})
+
+	var got []events.Event
+	e.Events().Subscribe("test", func(ev events.Event) { got = append(got, ev) }, events.DeviceOffline, events.DeviceEvicted)
+
+	e.processState(models.NewDroneState("uav-1", "mavlink"))
+	e.processState(models.NewDroneState("uav-2", "mavlink"))
+
+	if len(got) != 2 || got[0].Type != events.DeviceOffline || got[1].Type != events.DeviceEvicted || got[1].Source != "capacity" {
+		t.Fatalf("Events = %+v, want uav-1 offline and evicted", got)
+	}
+	if got[0].DeviceID != "uav-1" || got[1].DeviceID != "uav-1" {
+		t.Errorf("Evicted %s, want uav-1", got[1].DeviceID)
+	}
+	if e.GetState("uav-1") != nil || e.GetTrackSize("uav-1") != 0 || e.GetDeviceCount() != 1 {
+		t.Error("Evicted device should have no state or track")
+	}
+	if offline := e.presence.expire(time.Now().Add(time.Hour)); len(offline) != 1 || offline[0] != "uav-2" {
+		t.Errorf("Offline = %v, want only uav-2", offline)
+	}
+}
+
+// presenceAdapter reports devices gone through the offline callback
+type presenceAdapter struct {
+	fakeAdapter
+	offline func(deviceID string)
+}
+
+func (a *presenceAdapter) SetOfflineCallback(fn func(deviceID string)) { a.offline = fn }
+
+func TestEngine_PresenceSource(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	adapter := &presenceAdapter{fakeAdapter: fakeAdapter{name: "dji"}}
+	e.RegisterAdapter(adapter)
+	if adapter.offline == nil {
+		t.Fatal("RegisterAdapter should set the offline callback")
+	}
+
+	var got []events.Event
+	e.Events().Subscribe("test", func(ev events.Event) { got = append(got, ev) }, events.DeviceOnline, events.DeviceOffline)
+
+	adapter.offline("uav-1")
+	if len(got) != 0 {
+		t.Fatalf("Events = %+v, want none for a device that was never online", got)
+	}
+
+	e.processState(models.NewDroneState("uav-1", "dji"))
+	adapter.offline("uav-1")
+	adapter.offline("uav-1")
+	if len(got) != 2 || got[1].Type != events.DeviceOffline || got[1].DeviceID != "uav-1" || got[1].Source != "dji" {
+		t.Fatalf("Events = %+v, want uav-1 online then offline once", got)
+	}
+	if offline := e.presence.expire(time.Now().Add(time.Hour)); len(offline) != 0 {
+		t.Errorf("Offline = %v, want none after the adapter reported it", offline)
+	}
+
+	e.processState(models.NewDroneState("uav-1", "dji"))
+	if len(got) != 3 || got[2].Type != events.DeviceOnline {
+		t.Errorf("Events = %+v, want uav-1 online again", got)
+	}
+}
@@ -0,0 +1,101 @@
+// Package protowire encodes and decodes the Protobuf wire format by hand,
+// for the few messages the gateway exchanges (Sparkplug B payloads, the DJI
+// forwarder protocol) without generated code.
+package protowire
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Wire types
+const (
+	Varint  = 0
+	Fixed64 = 1
+	Bytes   = 2
+	Fixed32 = 5
+)
+
+// ErrMalformed is returned by Walk for truncated or invalid messages
+var ErrMalformed = errors.New("malformed protobuf message")
+
+// Walk calls fn for each field in a message. v holds varint and fixed
+// values, b holds length-delimited values.
+func Walk(data []byte, fn func(field, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrMalformed
+		}
+		data = data[n:]
+		field, wire := int(tag>>3), int(tag&7)
+
+		var v uint64
+		var b []byte
+		switch wire {
+		case Varint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return ErrMalformed
+			}
+			data = data[n:]
+		case Fixed64:
+			if len(data) < 8 {
+				return ErrMalformed
+			}
+			v = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case Fixed32:
+			if len(data) < 4 {
+				return ErrMalformed
+			}
+			v = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case Bytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || l > uint64(len(data)-n) {
+				return ErrMalformed
+			}
+			b = data[n : n+int(l)]
+			data = data[n+int(l):]
+		default:
+			return ErrMalformed
+		}
+
+		if err := fn(field, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendTag appends a field tag
+func AppendTag(buf []byte, field, wire int) []byte {
+	return binary.AppendUvarint(buf, uint64(field)<<3|uint64(wire))
+}
+
+// AppendVarint appends a varint field
+func AppendVarint(buf []byte, field int, v uint64) []byte {
+	buf = AppendTag(buf, field, Varint)
+	return binary.AppendUvarint(buf, v)
+}
+
+// AppendBytes appends a length-delimited field
+func AppendBytes(buf []byte, field int, b []byte) []byte {
+	buf = AppendTag(buf, field, Bytes)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// AppendFloat appends a float field
+func AppendFloat(buf []byte, field int, v float32) []byte {
+	buf = AppendTag(buf, field, Fixed32)
+	return binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+}
+
+// AppendDouble appends a double field
+func AppendDouble(buf []byte, field int, v float64) []byte {
+	buf = AppendTag(buf, field, Fixed64)
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+}
@@ -0,0 +1,156 @@
+// Package publicfeed keeps a delayed, coarsened copy of drone positions for
+// publication to the public, e.g. for community transparency programs.
+// Device IDs are pseudonymized, positions are snapped to a coarse grid and
+// nothing is released until the configured delay has passed.
+package publicfeed
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/anonymize"
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Defaults applied when Config fields are zero
+const (
+	DefaultPrecisionM = 1000.0
+	DefaultStaleAfter = 5 * time.Minute
+	DefaultInterval   = time.Second
+)
+
+// Config holds feed settings
+type Config struct {
+	Delay      time.Duration        // Age a position must reach before it is published
+	PrecisionM float64              // Grid size positions are snapped to (0 = DefaultPrecisionM)
+	StaleAfter time.Duration        // Hide drones whose delayed position is older (0 = DefaultStaleAfter)
+	Interval   time.Duration        // Minimum spacing of buffered positions per drone (0 = DefaultInterval)
+	Anonymizer anonymize.Anonymizer // Replaces device IDs (required)
+}
+
+// Position is a published drone position
+type Position struct {
+	ID        string  `json:"id"` // Pseudonymized device ID
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	Alt       float64 `json:"alt"`       // GNSS altitude in meters, rounded to 10 m
+	Timestamp int64   `json:"timestamp"` // Unix ms the position was received, rounded down to the second
+}
+
+// Feed buffers positions until they are old enough to publish
+type Feed struct {
+	cfg Config
+
+	mu      sync.Mutex
+	pending map[string][]Position // Per pseudonym, oldest first
+	current map[string]Position   // Latest released position per pseudonym
+}
+
+// New creates a feed
+func New(cfg Config) *Feed {
+	if cfg.PrecisionM <= 0 {
+		cfg.PrecisionM = DefaultPrecisionM
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = DefaultStaleAfter
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	return &Feed{
+		cfg:     cfg,
+		pending: make(map[string][]Position),
+		current: make(map[string]Position),
+	}
+}
+
+// Delay returns how long positions are held back
+func (f *Feed) Delay() time.Duration {
+	return f.cfg.Delay
+}
+
+// PrecisionM returns the grid size positions are snapped to
+func (f *Feed) PrecisionM() float64 {
+	return f.cfg.PrecisionM
+}
+
+// Observe buffers the coarsened position of a state received at the given
+// time. States without a position are ignored.
+func (f *Feed) Observe(state *models.DroneState, at time.Time) {
+	if state.Location.Lat == 0 && state.Location.Lon == 0 {
+		return
+	}
+
+	lat, lon := f.snap(state.Location.Lat, state.Location.Lon)
+	p := Position{
+		ID:        f.cfg.Anonymizer.DeviceID(state.DeviceID),
+		Lat:       lat,
+		Lon:       lon,
+		Alt:       math.Round(state.Location.AltGNSS/10) * 10,
+		Timestamp: at.Truncate(time.Second).UnixMilli(),
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	queue := f.pending[p.ID]
+	if n := len(queue); n > 0 && p.Timestamp-queue[n-1].Timestamp < f.cfg.Interval.Milliseconds() {
+		return
+	}
+	f.pending[p.ID] = append(queue, p)
+}
+
+// Positions returns the latest position of each drone that is at least the
+// configured delay old, sorted by ID. Drones whose delayed position is
+// older than the stale period are left out.
+func (f *Feed) Positions(now time.Time) []Position {
+	cutoff := now.Add(-f.cfg.Delay).UnixMilli()
+	staleBefore := cutoff - f.cfg.StaleAfter.Milliseconds()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Release buffered positions that have aged past the delay
+	for id, queue := range f.pending {
+		released := 0
+		for released < len(queue) && queue[released].Timestamp <= cutoff {
+			released++
+		}
+		if released > 0 {
+			f.current[id] = queue[released-1]
+		}
+		if released == len(queue) {
+			delete(f.pending, id)
+		} else {
+			f.pending[id] = queue[released:]
+		}
+	}
+
+	result := make([]Position, 0, len(f.current))
+	for id, p := range f.current {
+		if p.Timestamp < staleBefore {
+			delete(f.current, id)
+			continue
+		}
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// snap moves a position to the center of its grid cell. The longitude step
+// is widened by the cosine of the latitude so cells stay roughly square.
+func (f *Feed) snap(lat, lon float64) (float64, float64) {
+	latStep := f.cfg.PrecisionM / coordinator.MetersPerDegLat
+	lat = (math.Floor(lat/latStep) + 0.5) * latStep
+
+	cos := math.Cos(lat * math.Pi / 180)
+	if cos < 0.01 {
+		cos = 0.01 // Avoid degenerate cells at the poles
+	}
+	lonStep := latStep / cos
+	lon = (math.Floor(lon/lonStep) + 0.5) * lonStep
+	return lat, lon
+}
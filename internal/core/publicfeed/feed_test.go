@@ -0,0 +1,53 @@
+package publicfeed
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/anonymize"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func state(id string, lat, lon, alt float64) *models.DroneState {
+	s := models.NewDroneState(id, "mavlink")
+	s.Location.Lat, s.Location.Lon, s.Location.AltGNSS = lat, lon, alt
+	return s
+}
+
+func TestFeed_DelayAndCoarsen(t *testing.T) {
+	f := New(Config{Delay: 5 * time.Minute, Anonymizer: anonymize.NewHMAC([]byte("key"))})
+	t0 := time.Unix(1700000000, 0)
+
+	f.Observe(state("mavlink-1", 22.54321, 114.05678, 123.4), t0)
+	f.Observe(state("mavlink-1", 22.54400, 114.05700, 126), t0.Add(time.Minute))
+	f.Observe(state("mavlink-1", 22.54500, 114.05800, 130), t0.Add(time.Minute+100*time.Millisecond)) // Within interval
+	f.Observe(state("mavlink-2", 0, 0, 0), t0)
+
+	if got := f.Positions(t0.Add(4 * time.Minute)); len(got) != 0 {
+		t.Fatalf("Positions() before delay = %+v, want none", got)
+	}
+
+	got := f.Positions(t0.Add(5 * time.Minute))
+	if len(got) != 1 {
+		t.Fatalf("Positions() after delay = %+v, want 1", got)
+	}
+	p := got[0]
+	if p.ID == "mavlink-1" || p.ID == "" {
+		t.Errorf("ID = %q, want a pseudonym", p.ID)
+	}
+	if p.Timestamp != t0.UnixMilli() || p.Alt != 120 {
+		t.Errorf("Position = %+v, want the first sample with altitude rounded to 120", p)
+	}
+	// Snapped to the center of a ~1 km cell
+	if math.Abs(p.Lat-22.54321) > 0.0045 || p.Lat == 22.54321 {
+		t.Errorf("Lat = %.6f, want coarsened near 22.54321", p.Lat)
+	}
+
+	if got := f.Positions(t0.Add(6 * time.Minute)); len(got) != 1 || got[0].Timestamp != t0.Add(time.Minute).UnixMilli() {
+		t.Errorf("Positions() later = %+v, want the second sample", got)
+	}
+	if got := f.Positions(t0.Add(12 * time.Minute)); len(got) != 0 {
+		t.Errorf("Positions() after stale period = %+v, want none", got)
+	}
+}
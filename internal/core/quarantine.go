@@ -0,0 +1,69 @@
+package core
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Quarantinable is implemented by adapters that store payloads they fail
+// to parse in the engine's quarantine
+type Quarantinable interface {
+	SetQuarantine(q *quarantine.Store)
+}
+
+// Replayer is implemented by adapters that can re-parse a quarantined payload
+type Replayer interface {
+	Replay(entry quarantine.Entry) (*models.DroneState, error)
+}
+
+// Quarantine returns the engine's parse error quarantine
+func (e *Engine) Quarantine() *quarantine.Store {
+	return e.quarantine
+}
+
+// ReplayQuarantined re-parses a quarantined payload with its adapter's
+// current code and, on success, feeds the state into the pipeline and
+// removes the entry. Failed replays stay quarantined with the new error.
+func (e *Engine) ReplayQuarantined(id string) (*models.DroneState, error) {
+	entry, err := e.quarantine.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Truncated {
+		err := fmt.Errorf("payload was truncated (%d of %d bytes)", len(entry.Payload), entry.Size)
+		e.quarantine.MarkReplayed(id, err)
+		return nil, err
+	}
+
+	var replayer Replayer
+	for _, adapter := range e.adapters {
+		if adapter.Name() == entry.Adapter {
+			replayer, _ = adapter.(Replayer)
+			break
+		}
+	}
+	if replayer == nil {
+		return nil, fmt.Errorf("adapter %s does not support replay", entry.Adapter)
+	}
+
+	state, err := replayer.Replay(entry)
+	if err != nil {
+		e.quarantine.MarkReplayed(id, err)
+		return nil, err
+	}
+
+	select {
+	case e.queue.ch <- state:
+	default:
+		err := fmt.Errorf("engine event queue full")
+		e.quarantine.MarkReplayed(id, err)
+		return nil, err
+	}
+
+	e.quarantine.Delete(id)
+	log.Printf("[Engine] Replayed quarantined %s payload for %s", entry.Adapter, state.DeviceID)
+	return state, nil
+}
@@ -0,0 +1,210 @@
+// Package quarantine keeps raw payloads that adapters failed to parse so
+// they can be inspected and replayed after a fix
+package quarantine
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Defaults
+const (
+	DefaultMaxEntries      = 100       // Per adapter
+	DefaultMaxPayloadBytes = 64 * 1024 // Matches the DJI frame limit
+)
+
+// ErrNotFound is returned for unknown entry IDs
+var ErrNotFound = errors.New("quarantine entry not found")
+
+// Entry is a payload an adapter failed to parse
+type Entry struct {
+	ID          string `json:"id"`
+	Adapter     string `json:"adapter"`
+	DeviceID    string `json:"device_id,omitempty"` // Known device, if any
+	Source      string `json:"source,omitempty"`    // Remote address or channel
+	Error       string `json:"error"`
+	Payload     []byte `json:"payload,omitempty"` // Raw bytes (base64 in JSON)
+	Size        int    `json:"size"`              // Original payload size
+	Truncated   bool   `json:"truncated,omitempty"`
+	ReceivedAt  int64  `json:"received_at"`            // Unix ms
+	ReplayedAt  int64  `json:"replayed_at,omitempty"`  // Unix ms of last replay attempt
+	ReplayError string `json:"replay_error,omitempty"` // Error from last replay attempt
+}
+
+// Config contains quarantine settings
+type Config struct {
+	MaxEntries      int // Maximum entries kept per adapter
+	MaxPayloadBytes int // Payloads are truncated beyond this size
+}
+
+// Stats contains quarantine counters
+type Stats struct {
+	Total     int            `json:"total"`
+	ByAdapter map[string]int `json:"by_adapter"`
+	Dropped   uint64         `json:"dropped"` // Entries evicted because the quarantine was full
+}
+
+// Store is a bounded, per-adapter quarantine
+type Store struct {
+	maxEntries      int
+	maxPayloadBytes int
+
+	mu      sync.RWMutex
+	entries map[string][]*Entry // adapter -> entries, oldest first
+	byID    map[string]*Entry
+	dropped uint64
+}
+
+// New creates a quarantine store
+func New(cfg Config) *Store {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultMaxEntries
+	}
+	if cfg.MaxPayloadBytes <= 0 {
+		cfg.MaxPayloadBytes = DefaultMaxPayloadBytes
+	}
+	return &Store{
+		maxEntries:      cfg.MaxEntries,
+		maxPayloadBytes: cfg.MaxPayloadBytes,
+		entries:         make(map[string][]*Entry),
+		byID:            make(map[string]*Entry),
+	}
+}
+
+// Add quarantines a payload. The payload is copied.
+func (s *Store) Add(adapter, deviceID, source string, payload []byte, parseErr error) *Entry {
+	entry := &Entry{
+		ID:         uuid.New().String(),
+		Adapter:    adapter,
+		DeviceID:   deviceID,
+		Source:     source,
+		Size:       len(payload),
+		ReceivedAt: time.Now().UnixMilli(),
+	}
+	if parseErr != nil {
+		entry.Error = parseErr.Error()
+	}
+	if len(payload) > s.maxPayloadBytes {
+		payload = payload[:s.maxPayloadBytes]
+		entry.Truncated = true
+	}
+	entry.Payload = append([]byte(nil), payload...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := append(s.entries[adapter], entry)
+	if len(list) > s.maxEntries {
+		for _, old := range list[:len(list)-s.maxEntries] {
+			delete(s.byID, old.ID)
+			s.dropped++
+		}
+		list = append([]*Entry(nil), list[len(list)-s.maxEntries:]...)
+	}
+	s.entries[adapter] = list
+	s.byID[entry.ID] = entry
+
+	copied := *entry
+	return &copied
+}
+
+// List returns entries, newest first, optionally filtered by adapter.
+// A non-positive limit returns all entries.
+func (s *Store) List(adapter string, limit int) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Entry
+	for name, list := range s.entries {
+		if adapter != "" && name != adapter {
+			continue
+		}
+		for _, e := range list {
+			result = append(result, *e)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ReceivedAt > result[j].ReceivedAt
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// Get returns an entry by ID
+func (s *Store) Get(id string) (Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.byID[id]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	return *e, nil
+}
+
+// MarkReplayed records the result of a replay attempt
+func (s *Store) MarkReplayed(id string, replayErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.byID[id]
+	if !ok {
+		return
+	}
+	e.ReplayedAt = time.Now().UnixMilli()
+	e.ReplayError = ""
+	if replayErr != nil {
+		e.ReplayError = replayErr.Error()
+	}
+}
+
+// Delete removes an entry
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.byID, id)
+	list := s.entries[e.Adapter]
+	for i, item := range list {
+		if item.ID == id {
+			s.entries[e.Adapter] = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Clear removes all entries, or only those of one adapter
+func (s *Store) Clear(adapter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, list := range s.entries {
+		if adapter != "" && name != adapter {
+			continue
+		}
+		for _, e := range list {
+			delete(s.byID, e.ID)
+		}
+		delete(s.entries, name)
+	}
+}
+
+// Stats returns quarantine counters
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := Stats{ByAdapter: make(map[string]int), Dropped: s.dropped}
+	for name, list := range s.entries {
+		stats.ByAdapter[name] = len(list)
+		stats.Total += len(list)
+	}
+	return stats
+}
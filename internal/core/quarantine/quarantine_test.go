@@ -0,0 +1,104 @@
+package quarantine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStore_AddAndGet(t *testing.T) {
+	s := New(Config{})
+	payload := []byte(`{"broken"`)
+
+	added := s.Add("dji", "drone-1", "10.0.0.1:5000", payload, errors.New("unexpected EOF"))
+	payload[0] = 'X' // Store must keep its own copy
+
+	got, err := s.Get(added.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if string(got.Payload) != `{"broken"` {
+		t.Errorf("Payload = %s, want original bytes", got.Payload)
+	}
+	if got.Adapter != "dji" || got.DeviceID != "drone-1" || got.Error != "unexpected EOF" || got.Size != 9 {
+		t.Errorf("Unexpected entry: %+v", got)
+	}
+
+	if _, err := s.Get("missing"); err != ErrNotFound {
+		t.Errorf("Get(missing) error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestStore_BoundedPerAdapter(t *testing.T) {
+	s := New(Config{MaxEntries: 2})
+
+	first := s.Add("dji", "", "", []byte("1"), nil)
+	s.Add("dji", "", "", []byte("2"), nil)
+	s.Add("dji", "", "", []byte("3"), nil)
+	s.Add("mavlink", "", "", nil, errors.New("bad crc"))
+
+	if got := len(s.List("dji", 0)); got != 2 {
+		t.Errorf("dji entries = %d, want 2", got)
+	}
+	if got := len(s.List("mavlink", 0)); got != 1 {
+		t.Errorf("mavlink entries = %d, want 1", got)
+	}
+	if _, err := s.Get(first.ID); err != ErrNotFound {
+		t.Error("Oldest entry should be evicted")
+	}
+
+	stats := s.Stats()
+	if stats.Total != 3 || stats.Dropped != 1 || stats.ByAdapter["dji"] != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestStore_TruncatesPayload(t *testing.T) {
+	s := New(Config{MaxPayloadBytes: 4})
+
+	e := s.Add("dji", "", "", []byte("123456"), nil)
+
+	if !e.Truncated || string(e.Payload) != "1234" || e.Size != 6 {
+		t.Errorf("Unexpected truncated entry: %+v", e)
+	}
+}
+
+func TestStore_ListLimit(t *testing.T) {
+	s := New(Config{})
+	for i := 0; i < 5; i++ {
+		s.Add("dji", "", "", nil, nil)
+	}
+
+	if got := len(s.List("", 3)); got != 3 {
+		t.Errorf("List limit: got %d, want 3", got)
+	}
+	if got := len(s.List("", 0)); got != 5 {
+		t.Errorf("List all: got %d, want 5", got)
+	}
+}
+
+func TestStore_MarkReplayedDeleteClear(t *testing.T) {
+	s := New(Config{})
+	a := s.Add("dji", "", "", nil, nil)
+	b := s.Add("mavlink", "", "", nil, nil)
+
+	s.MarkReplayed(a.ID, errors.New("still broken"))
+	got, _ := s.Get(a.ID)
+	if got.ReplayedAt == 0 || got.ReplayError != "still broken" {
+		t.Errorf("Replay result not recorded: %+v", got)
+	}
+
+	if err := s.Delete(a.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := s.Delete(a.ID); err != ErrNotFound {
+		t.Errorf("Delete twice error = %v, want %v", err, ErrNotFound)
+	}
+	if len(s.List("dji", 0)) != 0 {
+		t.Error("Deleted entry should not be listed")
+	}
+
+	s.Clear("")
+	if _, err := s.Get(b.ID); err != ErrNotFound {
+		t.Error("Clear should remove all entries")
+	}
+}
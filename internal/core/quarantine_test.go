@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// replayAdapter quarantines payloads and replays them as JSON states
+type replayAdapter struct {
+	q *quarantine.Store
+}
+
+func (a *replayAdapter) Name() string { return "replay" }
+func (a *replayAdapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	return nil
+}
+func (a *replayAdapter) Stop() error                       { return nil }
+func (a *replayAdapter) SetQuarantine(q *quarantine.Store) { a.q = q }
+func (a *replayAdapter) Replay(entry quarantine.Entry) (*models.DroneState, error) {
+	var state models.DroneState
+	if err := json.Unmarshal(entry.Payload, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func TestEngine_ReplayQuarantined(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	a := &replayAdapter{}
+	e.RegisterAdapter(a)
+
+	if a.q != e.Quarantine() {
+		t.Fatal("RegisterAdapter should hand the quarantine to the adapter")
+	}
+
+	bad := a.q.Add("replay", "", "", []byte(`{"device_id":`), errors.New("unexpected EOF"))
+	if _, err := e.ReplayQuarantined(bad.ID); err == nil {
+		t.Error("Replay of malformed payload should fail")
+	}
+	if entry, _ := e.Quarantine().Get(bad.ID); entry.ReplayError == "" {
+		t.Error("Failed replay should be recorded on the entry")
+	}
+
+	good := a.q.Add("replay", "", "", []byte(`{"device_id":"uav-1"}`), errors.New("old bug"))
+	state, err := e.ReplayQuarantined(good.ID)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if state.DeviceID != "uav-1" {
+		t.Errorf("DeviceID = %s, want uav-1", state.DeviceID)
+	}
+	if _, err := e.Quarantine().Get(good.ID); err != quarantine.ErrNotFound {
+		t.Error("Replayed entry should be removed")
+	}
+
+	select {
+	case got := <-e.queue.ch:
+		if got != state {
+			t.Error("Replayed state should be queued for processing")
+		}
+	default:
+		t.Error("Replayed state should be queued for processing")
+	}
+}
+
+func TestEngine_ReplayQuarantined_Unsupported(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	e.RegisterAdapter(&fakeAdapter{name: "fake"})
+
+	entry := e.Quarantine().Add("fake", "", "", []byte("x"), nil)
+	if _, err := e.ReplayQuarantined(entry.ID); err == nil {
+		t.Error("Replay should fail for adapters without Replayer")
+	}
+	if _, err := e.ReplayQuarantined("missing"); err != quarantine.ErrNotFound {
+		t.Errorf("Replay(missing) error = %v, want %v", err, quarantine.ErrNotFound)
+	}
+}
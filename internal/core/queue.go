@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Drop policies of the event queue, applied when it is full
+const (
+	DropNewest = "drop_newest" // Discard the incoming state
+	DropOldest = "drop_oldest" // Discard the oldest queued state to make room
+	DropBlock  = "block"       // Make the adapter wait, pushing back on its source
+)
+
+// Event queue defaults
+const (
+	DefaultQueueSize  = 100
+	DefaultDropPolicy = DropNewest
+	queueWarnInterval = 10 * time.Second
+	queueStageEvents  = "events"
+	queueStageAdapter = "adapter:"
+	queueStagePublish = "batch:"
+)
+
+// ValidDropPolicy checks a drop policy name; empty selects the default
+func ValidDropPolicy(policy string) error {
+	switch policy {
+	case "", DropNewest, DropOldest, DropBlock:
+		return nil
+	}
+	return fmt.Errorf("unknown drop policy %q (want %s, %s or %s)", policy, DropNewest, DropOldest, DropBlock)
+}
+
+// QueueStats is a snapshot of one stage of the pipeline: the intake of an
+// adapter, the shared event queue, or the batch of a publisher
+type QueueStats struct {
+	Stage     string `json:"stage"`            // adapter:<name>, events or batch:<publisher>
+	Policy    string `json:"policy,omitempty"` // Drop policy of the event queue
+	Capacity  int    `json:"capacity,omitempty"`
+	Depth     int    `json:"depth"`
+	HighWater int    `json:"high_water"` // Largest depth seen
+	Received  uint64 `json:"received"`
+	Dropped   uint64 `json:"dropped"`
+	Blocked   uint64 `json:"blocked"` // Sends that waited for room
+}
+
+// intake is the channel one adapter sends its states to
+type intake struct {
+	name     string
+	ch       chan *models.DroneState
+	received atomic.Uint64
+	dropped  atomic.Uint64
+	blocked  atomic.Uint64
+
+	warned uint64 // Drops already reported, owned by warnDrops
+}
+
+// eventQueue is the bounded queue between the adapters and the routing
+// goroutine. Each adapter sends into its own unbuffered intake, and a pump
+// moves its states into the queue according to the drop policy, so drops
+// are counted per adapter and the block policy reaches the adapter.
+type eventQueue struct {
+	ch     chan *models.DroneState
+	policy string
+
+	highWater atomic.Int64
+	received  atomic.Uint64
+	dropped   atomic.Uint64
+	blocked   atomic.Uint64
+
+	mu      sync.Mutex
+	intakes []*intake
+}
+
+// newEventQueue creates a queue, applying the defaults for zero values
+func newEventQueue(size int, policy string) *eventQueue {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	if policy == "" {
+		policy = DefaultDropPolicy
+	}
+	return &eventQueue{
+		ch:     make(chan *models.DroneState, size),
+		policy: policy,
+	}
+}
+
+// intake returns the intake of an adapter, creating it on first use
+func (q *eventQueue) intake(name string) (*intake, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, in := range q.intakes {
+		if in.name == name {
+			return in, false
+		}
+	}
+	in := &intake{name: name, ch: make(chan *models.DroneState)}
+	q.intakes = append(q.intakes, in)
+	return in, true
+}
+
+// pump moves the states of an intake into the queue until ctx is done
+func (q *eventQueue) pump(ctx context.Context, in *intake) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case state := <-in.ch:
+			in.received.Add(1)
+			q.push(ctx, in, state)
+		}
+	}
+}
+
+// push adds a state to the queue, applying the drop policy when it is full
+func (q *eventQueue) push(ctx context.Context, in *intake, state *models.DroneState) {
+	q.received.Add(1)
+	for {
+		select {
+		case q.ch <- state:
+			q.observeDepth()
+			return
+		default:
+		}
+
+		switch q.policy {
+		case DropBlock:
+			in.blocked.Add(1)
+			q.blocked.Add(1)
+			select {
+			case q.ch <- state:
+				q.observeDepth()
+			case <-ctx.Done():
+			}
+			return
+		case DropOldest:
+			select {
+			case <-q.ch:
+				in.dropped.Add(1)
+				q.dropped.Add(1)
+			default:
+			}
+			// Retry; the routing goroutine may have made room as well
+		default:
+			in.dropped.Add(1)
+			q.dropped.Add(1)
+			return
+		}
+	}
+}
+
+// observeDepth records the high water mark of the queue
+func (q *eventQueue) observeDepth() {
+	depth := int64(len(q.ch))
+	for {
+		hw := q.highWater.Load()
+		if depth <= hw || q.highWater.CompareAndSwap(hw, depth) {
+			return
+		}
+	}
+}
+
+// stats returns the adapter intakes followed by the queue itself. Drops
+// under drop_oldest are counted against the adapter whose state made room.
+func (q *eventQueue) stats() []QueueStats {
+	q.mu.Lock()
+	intakes := append([]*intake(nil), q.intakes...)
+	q.mu.Unlock()
+
+	result := make([]QueueStats, 0, len(intakes)+1)
+	for _, in := range intakes {
+		result = append(result, QueueStats{
+			Stage:    queueStageAdapter + in.name,
+			Received: in.received.Load(),
+			Dropped:  in.dropped.Load(),
+			Blocked:  in.blocked.Load(),
+		})
+	}
+	return append(result, QueueStats{
+		Stage:     queueStageEvents,
+		Policy:    q.policy,
+		Capacity:  cap(q.ch),
+		Depth:     len(q.ch),
+		HighWater: int(q.highWater.Load()),
+		Received:  q.received.Load(),
+		Dropped:   q.dropped.Load(),
+		Blocked:   q.blocked.Load(),
+	})
+}
+
+// warnDrops logs the adapters whose states were dropped since the last call
+func (q *eventQueue) warnDrops() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, in := range q.intakes {
+		dropped := in.dropped.Load()
+		if n := dropped - in.warned; n > 0 {
+			log.Printf("[Engine] Event queue full: dropped %d states from adapter %s (policy %s, capacity %d)",
+				n, in.name, q.policy, cap(q.ch))
+		}
+		in.warned = dropped
+	}
+}
+
+// monitorQueue periodically warns about dropped states
+func (e *Engine) monitorQueue(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(queueWarnInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.queue.warnDrops()
+		}
+	}
+}
+
+// startIntake starts the pump of an adapter's intake on first use and
+// returns the channel the adapter sends to
+func (e *Engine) startIntake(ctx context.Context, adapter string) chan<- *models.DroneState {
+	in, created := e.queue.intake(adapter)
+	if created {
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.queue.pump(ctx, in)
+		}()
+	}
+	return in.ch
+}
+
+// GetQueueStats returns per-stage queue statistics: each adapter's intake,
+// the event queue, and the batch of each batching publisher
+func (e *Engine) GetQueueStats() []QueueStats {
+	stats := e.queue.stats()
+	for _, pub := range e.publishers {
+		if b := e.batchers[pub.Name()]; b != nil {
+			stats = append(stats, b.stats(queueStagePublish+pub.Name()))
+		}
+	}
+	return stats
+}
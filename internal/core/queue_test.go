@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestValidDropPolicy(t *testing.T) {
+	for _, policy := range []string{"", DropNewest, DropOldest, DropBlock} {
+		if err := ValidDropPolicy(policy); err != nil {
+			t.Errorf("ValidDropPolicy(%q) = %v", policy, err)
+		}
+	}
+	if err := ValidDropPolicy("drop_all"); err == nil {
+		t.Error("Unknown policy should be rejected")
+	}
+}
+
+// fill pushes states named after ids through the intake of a queue
+func fill(ctx context.Context, q *eventQueue, in *intake, ids ...string) {
+	for _, id := range ids {
+		in.received.Add(1)
+		q.push(ctx, in, models.NewDroneState(id, "test"))
+	}
+}
+
+func queued(q *eventQueue) []string {
+	var ids []string
+	for len(q.ch) > 0 {
+		ids = append(ids, (<-q.ch).DeviceID)
+	}
+	return ids
+}
+
+func TestEventQueue_DropPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{DropNewest, []string{"a", "b"}},
+		{DropOldest, []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			q := newEventQueue(2, tt.policy)
+			in, _ := q.intake("sim")
+			fill(context.Background(), q, in, "a", "b", "c", "d")
+
+			stats := q.stats()
+			if len(stats) != 2 || stats[0].Stage != "adapter:sim" || stats[1].Stage != "events" {
+				t.Fatalf("stats() = %+v", stats)
+			}
+			if stats[0].Received != 4 || stats[0].Dropped != 2 {
+				t.Errorf("Adapter stats = %+v, want 4 received and 2 dropped", stats[0])
+			}
+			if stats[1].Policy != tt.policy || stats[1].Capacity != 2 || stats[1].Depth != 2 || stats[1].HighWater != 2 {
+				t.Errorf("Queue stats = %+v", stats[1])
+			}
+			if got := queued(q); len(got) != 2 || got[0] != tt.want[0] || got[1] != tt.want[1] {
+				t.Errorf("Queued = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventQueue_Block(t *testing.T) {
+	q := newEventQueue(1, DropBlock)
+	in, _ := q.intake("sim")
+	fill(context.Background(), q, in, "a")
+
+	done := make(chan struct{})
+	go func() {
+		fill(context.Background(), q, in, "b")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("push should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-q.ch
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("push should resume once there is room")
+	}
+	if got := queued(q); len(got) != 1 || got[0] != "b" {
+		t.Errorf("Queued = %v, want [b]", got)
+	}
+	if stats := q.stats(); stats[0].Blocked != 1 || stats[0].Dropped != 0 {
+		t.Errorf("Adapter stats = %+v, want 1 blocked and none dropped", stats[0])
+	}
+
+	// A cancelled context releases a blocked push
+	fill(context.Background(), q, in, "c")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fill(ctx, q, in, "d")
+}
+
+func TestEngine_StartIntake(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1, QueueSize: 4})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		e.wg.Wait()
+	}()
+
+	events := e.startIntake(ctx, "sim")
+	if again := e.startIntake(ctx, "sim"); again != events {
+		t.Error("startIntake should reuse the intake of an adapter")
+	}
+
+	events <- models.NewDroneState("uav-1", "test")
+	select {
+	case state := <-e.queue.ch:
+		if state.DeviceID != "uav-1" {
+			t.Errorf("DeviceID = %s, want uav-1", state.DeviceID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the pump")
+	}
+
+	stats := e.GetQueueStats()
+	if len(stats) != 2 || stats[0].Received != 1 || stats[1].Policy != DefaultDropPolicy || stats[1].Capacity != 4 {
+		t.Errorf("GetQueueStats() = %+v", stats)
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"log"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// RawSource is implemented by adapters that can pass selected protocol
+// messages through without converting them. The engine sets the callback on
+// registration and forwards the messages to each RawPublisher.
+type RawSource interface {
+	SetRawCallback(fn func(msg *models.RawMessage))
+}
+
+// RawPublisher is optionally implemented by publishers that can forward raw
+// protocol messages northbound
+type RawPublisher interface {
+	PublishRaw(msg *models.RawMessage) error
+}
+
+// StatusTextSource is implemented by adapters that keep the recent text
+// messages of drone autopilots
+type StatusTextSource interface {
+	StatusTexts(deviceID string) ([]models.StatusText, bool)
+}
+
+// StatusTexts returns the recent autopilot text messages of a device
+func (e *Engine) StatusTexts(deviceID string) ([]models.StatusText, bool) {
+	for _, adapter := range e.adapters {
+		if source, ok := adapter.(StatusTextSource); ok {
+			if texts, ok := source.StatusTexts(deviceID); ok {
+				return texts, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// publishRaw forwards a raw message to the publishers that support them
+func (e *Engine) publishRaw(msg *models.RawMessage) {
+	msg.Tenant = e.tenants.Resolve(msg.DeviceID)
+	for _, pub := range e.publishers {
+		if rp, ok := pub.(RawPublisher); ok {
+			if err := rp.PublishRaw(msg); err != nil {
+				log.Printf("[Engine] Error publishing raw %s to %s: %v", msg.Name, pub.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/tenant"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// rawAdapter passes raw messages through its callback and keeps status texts
+type rawAdapter struct {
+	fakeAdapter
+	notify func(msg *models.RawMessage)
+	texts  map[string][]models.StatusText
+}
+
+func (a *rawAdapter) SetRawCallback(fn func(msg *models.RawMessage)) {
+	a.notify = fn
+}
+
+func (a *rawAdapter) StatusTexts(deviceID string) ([]models.StatusText, bool) {
+	texts, ok := a.texts[deviceID]
+	return texts, ok
+}
+
+// rawPublisher records raw messages
+type rawPublisher struct {
+	fakePublisher
+	raw []*models.RawMessage
+}
+
+func (p *rawPublisher) PublishRaw(msg *models.RawMessage) error {
+	p.raw = append(p.raw, msg)
+	return nil
+}
+
+func TestEngine_Raw(t *testing.T) {
+	registry, err := tenant.New([]tenant.Tenant{{ID: "acme", DevicePrefixes: []string{"mavlink-"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(EngineConfig{RateHz: 1, Tenants: registry})
+	adapter := &rawAdapter{
+		fakeAdapter: fakeAdapter{name: "mavlink"},
+		texts:       map[string][]models.StatusText{"mavlink-1": {{Severity: "warning", Text: "EKF variance"}}},
+	}
+	pub := &rawPublisher{fakePublisher: fakePublisher{name: "mqtt"}}
+	e.RegisterAdapter(&fakeAdapter{name: "dji"})
+	e.RegisterAdapter(adapter)
+	e.RegisterPublisher(&fakePublisher{name: "http"})
+	e.RegisterPublisher(pub)
+
+	adapter.notify(&models.RawMessage{DeviceID: "mavlink-1", Name: "STATUSTEXT"})
+	if len(pub.raw) != 1 || pub.raw[0].Name != "STATUSTEXT" || pub.raw[0].Tenant != "acme" {
+		t.Fatalf("Published raw messages = %+v", pub.raw)
+	}
+
+	if texts, ok := e.StatusTexts("mavlink-1"); !ok || len(texts) != 1 {
+		t.Errorf("StatusTexts() = %+v, %v", texts, ok)
+	}
+	if _, ok := e.StatusTexts("dji-1"); ok {
+		t.Error("StatusTexts() should report unknown devices as missing")
+	}
+}
@@ -0,0 +1,218 @@
+// Package registry stores operator-maintained details about devices, such
+// as a friendly name, airframe type and serial number, so they can be merged
+// into telemetry keyed only by the device ID seen on the wire.
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+var (
+	// ErrDeviceNotFound is returned for an unregistered device ID
+	ErrDeviceNotFound = errors.New("device not registered")
+	// ErrDeviceExists is returned when registering a device twice
+	ErrDeviceExists = errors.New("device already registered")
+	// ErrInvalidDevice is returned for a device without an ID
+	ErrInvalidDevice = errors.New("device id is required")
+)
+
+// Device is a registered device
+type Device struct {
+	ID        string   `json:"id"` // Device ID as reported by the adapter
+	Name      string   `json:"name,omitempty"`
+	Airframe  string   `json:"airframe,omitempty"`
+	Serial    string   `json:"serial,omitempty"`
+	Operator  string   `json:"operator,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	CreatedAt int64    `json:"created_at"` // Unix ms
+	UpdatedAt int64    `json:"updated_at"` // Unix ms
+}
+
+// Metadata returns the details merged into the device's states
+func (d *Device) Metadata() *models.DeviceMetadata {
+	return &models.DeviceMetadata{
+		Name:     d.Name,
+		Airframe: d.Airframe,
+		Serial:   d.Serial,
+		Operator: d.Operator,
+		Tags:     append([]string(nil), d.Tags...),
+	}
+}
+
+// Registry holds registered devices in memory and optionally persists them
+// to a JSON file
+type Registry struct {
+	path string
+
+	mu      sync.RWMutex
+	devices map[string]*Device
+}
+
+// New creates a registry, loading existing devices from path.
+// An empty path keeps devices in memory only.
+func New(path string) (*Registry, error) {
+	r := &Registry{
+		path:    path,
+		devices: make(map[string]*Device),
+	}
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("reading device registry: %w", err)
+	}
+
+	var devices []*Device
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("parsing device registry: %w", err)
+	}
+	for _, d := range devices {
+		r.devices[d.ID] = d
+	}
+	return r, nil
+}
+
+// List returns all registered devices sorted by ID
+func (r *Registry) List() []Device {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]Device, 0, len(r.devices))
+	for _, d := range r.devices {
+		result = append(result, *d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Get returns a registered device
+func (r *Registry) Get(id string) (Device, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.devices[id]
+	if !ok {
+		return Device{}, ErrDeviceNotFound
+	}
+	return *d, nil
+}
+
+// Create registers a device
+func (r *Registry) Create(d *Device) error {
+	if d.ID == "" {
+		return ErrInvalidDevice
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.devices[d.ID]; ok {
+		return fmt.Errorf("%w: %s", ErrDeviceExists, d.ID)
+	}
+
+	d.CreatedAt = time.Now().UnixMilli()
+	d.UpdatedAt = d.CreatedAt
+	stored := *d
+	r.devices[d.ID] = &stored
+	if err := r.save(); err != nil {
+		delete(r.devices, d.ID)
+		return err
+	}
+	return nil
+}
+
+// Update replaces the details of a registered device
+func (r *Registry) Update(d *Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.devices[d.ID]
+	if !ok {
+		return ErrDeviceNotFound
+	}
+
+	d.CreatedAt = existing.CreatedAt
+	d.UpdatedAt = time.Now().UnixMilli()
+	stored := *d
+	r.devices[d.ID] = &stored
+	if err := r.save(); err != nil {
+		r.devices[d.ID] = existing
+		return err
+	}
+	return nil
+}
+
+// Delete unregisters a device
+func (r *Registry) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.devices[id]
+	if !ok {
+		return ErrDeviceNotFound
+	}
+	delete(r.devices, id)
+	if err := r.save(); err != nil {
+		r.devices[id] = existing
+		return err
+	}
+	return nil
+}
+
+// Metadata returns the details of a device, or nil if it is not
+// registered. A nil Registry has no devices.
+func (r *Registry) Metadata(id string) *models.DeviceMetadata {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.devices[id]
+	if !ok {
+		return nil
+	}
+	return d.Metadata()
+}
+
+// save writes the registry to disk. Caller must hold the lock.
+func (r *Registry) save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	devices := make([]*Device, 0, len(r.devices))
+	for _, d := range r.devices {
+		devices = append(devices, d)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+
+	data, err := json.MarshalIndent(devices, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding device registry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("creating device registry directory: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing device registry: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("writing device registry: %w", err)
+	}
+	return nil
+}
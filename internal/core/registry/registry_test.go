@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry_CRUD(t *testing.T) {
+	r, _ := New("")
+
+	d := &Device{ID: "mavlink-1", Name: "Survey 1", Airframe: "quadrotor", Tags: []string{"survey"}}
+	if err := r.Create(d); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if d.CreatedAt == 0 {
+		t.Error("Create() should set CreatedAt")
+	}
+	if err := r.Create(&Device{ID: "mavlink-1"}); !errors.Is(err, ErrDeviceExists) {
+		t.Errorf("Create() duplicate error = %v, want ErrDeviceExists", err)
+	}
+	if err := r.Create(&Device{}); !errors.Is(err, ErrInvalidDevice) {
+		t.Errorf("Create() without ID error = %v, want ErrInvalidDevice", err)
+	}
+
+	if err := r.Update(&Device{ID: "mavlink-1", Name: "Survey One"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if m := r.Metadata("mavlink-1"); m == nil || m.Name != "Survey One" || m.Airframe != "" {
+		t.Errorf("Metadata() = %+v, want the updated details", m)
+	}
+	if err := r.Update(&Device{ID: "dji-1"}); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("Update() unknown error = %v, want ErrDeviceNotFound", err)
+	}
+
+	if err := r.Delete("mavlink-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if r.Metadata("mavlink-1") != nil || len(r.List()) != 0 {
+		t.Error("Deleted device should have no metadata")
+	}
+
+	var nilRegistry *Registry
+	if nilRegistry.Metadata("mavlink-1") != nil {
+		t.Error("nil registry should have no devices")
+	}
+}
+
+func TestRegistry_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	r1, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	r1.Create(&Device{ID: "dji-1", Serial: "1ZNBJ7R0010", Operator: "alice"})
+
+	r2, err := New(path)
+	if err != nil {
+		t.Fatalf("New() reload error = %v", err)
+	}
+	d, err := r2.Get("dji-1")
+	if err != nil || d.Serial != "1ZNBJ7R0010" || d.Operator != "alice" {
+		t.Errorf("Get() after reload = %+v, %v", d, err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package resp is a minimal Redis client speaking RESP2. It only supports
+// what the gateway needs: pipelined commands with simple replies for the
+// Redis publisher, and pub/sub for the WebSocket fan-out.
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Conn is a connection to a Redis server
+type Conn struct {
+	nc      net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+	timeout time.Duration
+}
+
+// Dial connects to a Redis server. The timeout also bounds each pipelined
+// round trip.
+func Dial(address string, timeout time.Duration) (*Conn, error) {
+	nc, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{nc: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc), timeout: timeout}, nil
+}
+
+// Handshake authenticates, if a password is given, and selects the
+// database, if not 0
+func (c *Conn) Handshake(username, password string, db int) error {
+	var cmds [][]string
+	if password != "" {
+		if username != "" {
+			cmds = append(cmds, []string{"AUTH", username, password})
+		} else {
+			cmds = append(cmds, []string{"AUTH", password})
+		}
+	}
+	if db != 0 {
+		cmds = append(cmds, []string{"SELECT", strconv.Itoa(db)})
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	_, err := c.Pipeline(cmds...)
+	return err
+}
+
+// Pipeline sends the commands in one write and reads one reply per command.
+// The first error reply is returned as an error.
+func (c *Conn) Pipeline(cmds ...[]string) ([]string, error) {
+	c.nc.SetDeadline(time.Now().Add(c.timeout))
+	if err := c.write(cmds...); err != nil {
+		return nil, err
+	}
+
+	replies := make([]string, len(cmds))
+	var replyErr error
+	for i := range cmds {
+		reply, err := c.readReply()
+		var re Error
+		if errors.As(err, &re) {
+			if replyErr == nil {
+				replyErr = fmt.Errorf("%s: %w", cmds[i][0], err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, replyErr
+}
+
+// Subscribe subscribes to a pub/sub channel. The connection can then only
+// be used to Receive.
+func (c *Conn) Subscribe(channel string) error {
+	c.nc.SetDeadline(time.Now().Add(c.timeout))
+	if err := c.write([]string{"SUBSCRIBE", channel}); err != nil {
+		return err
+	}
+	reply, err := c.readArray()
+	if err != nil {
+		return err
+	}
+	if len(reply) < 2 || reply[0] != "subscribe" {
+		return fmt.Errorf("unexpected SUBSCRIBE reply %q", reply)
+	}
+	return nil
+}
+
+// Receive waits for the next message on a subscribed connection and returns
+// its channel and payload. It blocks until a message arrives or the
+// connection is closed.
+func (c *Conn) Receive() (channel, payload string, err error) {
+	c.nc.SetDeadline(time.Time{})
+	for {
+		reply, err := c.readArray()
+		if err != nil {
+			return "", "", err
+		}
+		if len(reply) == 3 && reply[0] == "message" {
+			return reply[1], reply[2], nil
+		}
+	}
+}
+
+// write sends commands as arrays of bulk strings
+func (c *Conn) write(cmds ...[]string) error {
+	for _, args := range cmds {
+		fmt.Fprintf(c.w, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	return c.w.Flush()
+}
+
+// Error is an error reply from the server
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// readReply reads a simple string, error, integer or bulk string reply
+func (c *Conn) readReply() (string, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return "", err
+	}
+	if len(line) == 0 {
+		return "", fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", Error(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return "", nil // Null bulk string
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	default:
+		return "", fmt.Errorf("unsupported reply type %q", line[0])
+	}
+}
+
+// readArray reads an array reply whose elements are simple replies, as
+// pub/sub confirmations and messages are
+func (c *Conn) readArray() ([]string, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("empty reply")
+	}
+	if line[0] == '-' {
+		return nil, Error(line[1:])
+	}
+	if line[0] != '*' {
+		return nil, fmt.Errorf("expected array reply, got %q", line)
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid array length %q", line[1:])
+	}
+	elems := make([]string, n)
+	for i := range elems {
+		if elems[i], err = c.readReply(); err != nil {
+			return nil, err
+		}
+	}
+	return elems, nil
+}
+
+// readLine reads a CRLF-terminated line without the terminator
+func (c *Conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("malformed reply line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.nc.Close()
+}
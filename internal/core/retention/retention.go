@@ -0,0 +1,110 @@
+// Package retention enforces time-based data retention policies across
+// the gateway's stores with a janitor run by the job scheduler
+package retention
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PruneFunc removes data older than before and returns how much was removed
+type PruneFunc func(before time.Time) int
+
+// Policy is the retention policy for one store
+type Policy struct {
+	Name   string
+	MaxAge time.Duration // 0 = keep forever
+	prune  PruneFunc
+}
+
+// Janitor prunes every registered store
+type Janitor struct {
+	mu       sync.Mutex
+	policies []Policy
+}
+
+// NewJanitor creates a janitor
+func NewJanitor() *Janitor {
+	return &Janitor{}
+}
+
+// Add registers a store. Policies with a zero max age are kept for
+// reporting but never pruned.
+func (j *Janitor) Add(name string, maxAge time.Duration, prune PruneFunc) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.policies = append(j.policies, Policy{Name: name, MaxAge: maxAge, prune: prune})
+}
+
+// Policies returns the registered policies
+func (j *Janitor) Policies() []Policy {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Policy(nil), j.policies...)
+}
+
+// RunOnce prunes every store and returns the number of items removed per store
+func (j *Janitor) RunOnce(now time.Time) map[string]int {
+	result := make(map[string]int)
+	for _, p := range j.Policies() {
+		if p.MaxAge <= 0 || p.prune == nil {
+			continue
+		}
+		removed := p.prune(now.Add(-p.MaxAge))
+		result[p.Name] = removed
+		if removed > 0 {
+			log.Printf("[Retention] Pruned %d %s older than %s", removed, p.Name, FormatAge(p.MaxAge))
+		}
+	}
+	return result
+}
+
+// ParseAge parses a retention period. In addition to Go durations
+// ("12h", "90m") it accepts days ("30d"), weeks ("2w") and years ("1y",
+// 365 days). An empty string or "0" means keep forever.
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+		'y': 365 * 24 * time.Hour,
+	}
+	if unit, ok := units[s[len(s)-1]]; ok {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid retention period %q", s)
+		}
+		return time.Duration(n) * unit, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid retention period %q", s)
+	}
+	return d, nil
+}
+
+// FormatAge formats a retention period using the largest whole unit
+func FormatAge(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d <= 0:
+		return "forever"
+	case d%(365*day) == 0:
+		return fmt.Sprintf("%dy", d/(365*day))
+	case d%(7*day) == 0:
+		return fmt.Sprintf("%dw", d/(7*day))
+	case d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,85 @@
+package retention
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"30d", 30 * day, false},
+		{"2w", 14 * day, false},
+		{"1y", 365 * day, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"xd", 0, true},
+		{"-1d", 0, true},
+		{"forever", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAge(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAge(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	day := 24 * time.Hour
+	tests := map[time.Duration]string{
+		0:                "forever",
+		365 * day:        "1y",
+		14 * day:         "2w",
+		30 * day:         "30d",
+		90 * time.Minute: "1h30m0s",
+	}
+	for in, want := range tests {
+		if got := FormatAge(in); got != want {
+			t.Errorf("FormatAge(%v) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestJanitor_RunOnce(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	j := NewJanitor()
+
+	var trackCutoff time.Time
+	j.Add("tracks", 30*24*time.Hour, func(before time.Time) int {
+		trackCutoff = before
+		return 5
+	})
+	called := false
+	j.Add("alerts", 0, func(before time.Time) int {
+		called = true
+		return 1
+	})
+
+	result := j.RunOnce(now)
+
+	if want := now.Add(-30 * 24 * time.Hour); !trackCutoff.Equal(want) {
+		t.Errorf("tracks cutoff = %v, want %v", trackCutoff, want)
+	}
+	if result["tracks"] != 5 {
+		t.Errorf("tracks removed = %d, want 5", result["tracks"])
+	}
+	if called {
+		t.Error("Policies with zero max age must not be pruned")
+	}
+	if len(j.Policies()) != 2 {
+		t.Errorf("Policies = %d, want 2", len(j.Policies()))
+	}
+}
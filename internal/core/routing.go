@@ -0,0 +1,55 @@
+package core
+
+import (
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
+	"github.com/open-uav/telemetry-bridge/internal/core/tracing"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// TopicPublisher is implemented by publishers that can publish to a topic
+// chosen by a routing rule instead of their default topic
+type TopicPublisher interface {
+	PublishTo(state *models.DroneState, topic string) error
+}
+
+// Routing returns the engine's publisher routing rules
+func (e *Engine) Routing() *routing.Router {
+	return e.router
+}
+
+// publish sends a state to one publisher according to the routing rules,
+// recording the send as a child span of trace. Returns false if the rules
+// exclude the publisher or the state was queued for a batch, whose result
+// is recorded when the batch is sent. States routed to a topic override are
+// sent on their own.
+func (e *Engine) publish(pub Publisher, state *models.DroneState, trace tracing.SpanContext) (bool, error) {
+	decision := e.router.Route(pub.Name(), state)
+	if !decision.Publish {
+		return false, nil
+	}
+
+	span := e.tracer.StartChild(trace, "publish "+pub.Name(), tracing.KindProducer, time.Now())
+	defer span.End()
+	span.SetAttribute("publisher", pub.Name())
+
+	tp, routedTopic := pub.(TopicPublisher)
+	routedTopic = routedTopic && decision.Topic != ""
+	if b := e.batchers[pub.Name()]; b != nil && !routedTopic {
+		span.SetAttribute("batched", true)
+		b.add(state)
+		return false, nil
+	}
+
+	e.injectPublisherDelay(pub.Name())
+	var err error
+	if routedTopic {
+		span.SetAttribute("topic", decision.Topic)
+		err = tp.PublishTo(state, decision.Topic)
+	} else {
+		err = pub.Publish(state)
+	}
+	span.RecordError(err)
+	return true, err
+}
@@ -0,0 +1,196 @@
+// Package routing decides which publishers receive which drone states.
+//
+// A publisher with no enabled rules receives every state. Once any enabled
+// rule names a publisher, that publisher only receives states matched by one
+// of its rules. Rules are evaluated in order; the first match supplies the
+// topic override, if any.
+package routing
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+var (
+	// ErrRuleNotFound is returned for an unknown rule ID
+	ErrRuleNotFound = errors.New("routing rule not found")
+	// ErrInvalidRule is returned for a rule that names no publishers
+	ErrInvalidRule = errors.New("routing rule must name at least one publisher")
+)
+
+// Rule routes matching states to a set of publishers. Empty match fields
+// match everything.
+type Rule struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Enabled      bool     `json:"enabled"`
+	Publishers   []string `json:"publishers"`              // Publisher names, e.g. "mqtt", "gb28181"
+	DeviceIDs    []string `json:"device_ids,omitempty"`    // Exact device IDs
+	DevicePrefix string   `json:"device_prefix,omitempty"` // Device ID prefix
+	Sources      []string `json:"sources,omitempty"`       // Protocol sources, e.g. "mavlink", "dji"
+	Topic        string   `json:"topic,omitempty"`         // Topic override for topic-based publishers; {device_id} and {tenant} are substituted
+	CreatedAt    int64    `json:"created_at"`
+	UpdatedAt    int64    `json:"updated_at"`
+}
+
+// Matches reports whether the rule's filters match a state
+func (r *Rule) Matches(state *models.DroneState) bool {
+	if len(r.DeviceIDs) > 0 && !contains(r.DeviceIDs, state.DeviceID) {
+		return false
+	}
+	if r.DevicePrefix != "" && !strings.HasPrefix(state.DeviceID, r.DevicePrefix) {
+		return false
+	}
+	if len(r.Sources) > 0 && !contains(r.Sources, state.ProtocolSource) {
+		return false
+	}
+	return true
+}
+
+// TopicFor returns the rule's topic for a state, or "" if none is set
+func (r *Rule) TopicFor(state *models.DroneState) string {
+	return strings.NewReplacer("{device_id}", state.DeviceID, "{tenant}", state.Tenant).Replace(r.Topic)
+}
+
+// Decision is the routing result for one publisher and state
+type Decision struct {
+	Publish bool
+	Topic   string // Topic override, "" for the publisher's default
+	RuleID  string // Matching rule, "" if the publisher has no rules
+}
+
+// Router holds the routing rules
+type Router struct {
+	mu    sync.RWMutex
+	rules []*Rule
+}
+
+// New creates a router with the given rules. Rules without an ID get one.
+func New(rules []Rule) *Router {
+	r := &Router{}
+	for i := range rules {
+		rule := rules[i]
+		r.Create(&rule)
+	}
+	return r
+}
+
+// Route decides whether a publisher should receive a state
+func (r *Router) Route(publisher string, state *models.DroneState) Decision {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	restricted := false
+	for _, rule := range r.rules {
+		if !rule.Enabled || !contains(rule.Publishers, publisher) {
+			continue
+		}
+		restricted = true
+		if rule.Matches(state) {
+			return Decision{Publish: true, Topic: rule.TopicFor(state), RuleID: rule.ID}
+		}
+	}
+	return Decision{Publish: !restricted}
+}
+
+// List returns a copy of all rules in evaluation order
+func (r *Router) List() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]Rule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, *rule)
+	}
+	return rules
+}
+
+// Get returns a copy of a rule
+func (r *Router) Get(id string) (Rule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.index(id); i >= 0 {
+		return *r.rules[i], nil
+	}
+	return Rule{}, ErrRuleNotFound
+}
+
+// Create appends a rule, assigning an ID if it has none
+func (r *Router) Create(rule *Rule) error {
+	if len(rule.Publishers) == 0 {
+		return ErrInvalidRule
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rule.ID == "" || r.index(rule.ID) >= 0 {
+		rule.ID = uuid.New().String()
+	}
+	now := time.Now().UnixMilli()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	stored := *rule
+	r.rules = append(r.rules, &stored)
+	return nil
+}
+
+// Update replaces a rule, keeping its position and creation time
+func (r *Router) Update(rule *Rule) error {
+	if len(rule.Publishers) == 0 {
+		return ErrInvalidRule
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(rule.ID)
+	if i < 0 {
+		return ErrRuleNotFound
+	}
+	rule.CreatedAt = r.rules[i].CreatedAt
+	rule.UpdatedAt = time.Now().UnixMilli()
+
+	stored := *rule
+	r.rules[i] = &stored
+	return nil
+}
+
+// Delete removes a rule
+func (r *Router) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return ErrRuleNotFound
+	}
+	r.rules = append(r.rules[:i], r.rules[i+1:]...)
+	return nil
+}
+
+// index returns the position of a rule or -1. Caller must hold the lock.
+func (r *Router) index(id string) int {
+	for i, rule := range r.rules {
+		if rule.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
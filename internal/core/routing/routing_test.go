@@ -0,0 +1,81 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestRouter_Route(t *testing.T) {
+	r := New([]Rule{
+		{Name: "mavlink-to-gb", Enabled: true, Publishers: []string{"gb28181"}, Sources: []string{"mavlink"}},
+		{Name: "fleet-a", Enabled: true, Publishers: []string{"mqtt"}, DevicePrefix: "fleet-a-", Topic: "fleet-a/{device_id}"},
+		{Name: "vip", Enabled: true, Publishers: []string{"mqtt"}, DeviceIDs: []string{"vip-1"}},
+		{Name: "disabled", Enabled: false, Publishers: []string{"plugin"}},
+	})
+
+	tests := []struct {
+		publisher string
+		deviceID  string
+		source    string
+		publish   bool
+		topic     string
+	}{
+		{"gb28181", "uav-1", "mavlink", true, ""},
+		{"gb28181", "uav-1", "dji", false, ""},
+		{"mqtt", "fleet-a-7", "dji", true, "fleet-a/fleet-a-7"},
+		{"mqtt", "vip-1", "mavlink", true, ""},
+		{"mqtt", "uav-1", "mavlink", false, ""},
+		{"plugin", "uav-1", "mavlink", true, ""}, // Only disabled rules: unrestricted
+		{"other", "uav-1", "dji", true, ""},      // No rules: unrestricted
+	}
+	for _, tt := range tests {
+		d := r.Route(tt.publisher, models.NewDroneState(tt.deviceID, tt.source))
+		if d.Publish != tt.publish || d.Topic != tt.topic {
+			t.Errorf("Route(%s, %s/%s) = %+v, want publish=%v topic=%q",
+				tt.publisher, tt.deviceID, tt.source, d, tt.publish, tt.topic)
+		}
+	}
+}
+
+func TestRouter_CRUD(t *testing.T) {
+	r := New(nil)
+
+	if err := r.Create(&Rule{Name: "empty"}); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("Create without publishers error = %v, want ErrInvalidRule", err)
+	}
+
+	rule := &Rule{Name: "mqtt-only", Enabled: true, Publishers: []string{"mqtt"}, DevicePrefix: "a-"}
+	if err := r.Create(rule); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if rule.ID == "" || rule.CreatedAt == 0 {
+		t.Errorf("Create should assign ID and timestamps: %+v", rule)
+	}
+
+	rule.DevicePrefix = "b-"
+	if err := r.Update(rule); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, err := r.Get(rule.ID)
+	if err != nil || got.DevicePrefix != "b-" {
+		t.Errorf("Get after update = %+v, %v", got, err)
+	}
+	if !r.Route("mqtt", models.NewDroneState("b-1", "dji")).Publish {
+		t.Error("Updated rule should route b-1 to mqtt")
+	}
+
+	if err := r.Update(&Rule{ID: "missing", Publishers: []string{"mqtt"}}); !errors.Is(err, ErrRuleNotFound) {
+		t.Errorf("Update missing error = %v, want ErrRuleNotFound", err)
+	}
+	if err := r.Delete(rule.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(r.List()) != 0 {
+		t.Errorf("Rules after delete = %d, want 0", len(r.List()))
+	}
+	if err := r.Delete(rule.ID); !errors.Is(err, ErrRuleNotFound) {
+		t.Errorf("Delete twice error = %v, want ErrRuleNotFound", err)
+	}
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/routing"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// recordingPublisher records published device IDs and topics
+type recordingPublisher struct {
+	name   string
+	states []string
+	topics []string
+}
+
+func (p *recordingPublisher) Name() string                    { return p.name }
+func (p *recordingPublisher) Start(ctx context.Context) error { return nil }
+func (p *recordingPublisher) Stop() error                     { return nil }
+
+func (p *recordingPublisher) Publish(state *models.DroneState) error {
+	p.states = append(p.states, state.DeviceID)
+	return nil
+}
+
+func (p *recordingPublisher) PublishTo(state *models.DroneState, topic string) error {
+	p.topics = append(p.topics, topic)
+	return p.Publish(state)
+}
+
+func TestEngine_Routing(t *testing.T) {
+	e := NewEngine(EngineConfig{
+		RateHz: 1,
+		RoutingRules: []routing.Rule{
+			{Name: "mavlink-only", Enabled: true, Publishers: []string{"gb28181"}, Sources: []string{"mavlink"}},
+			{Name: "fleet-a", Enabled: true, Publishers: []string{"mqtt"}, DevicePrefix: "fleet-a-", Topic: "fleet-a/{device_id}"},
+		},
+	})
+	gb := &recordingPublisher{name: "gb28181"}
+	mqtt := &recordingPublisher{name: "mqtt"}
+	other := &recordingPublisher{name: "other"}
+	e.RegisterPublisher(gb)
+	e.RegisterPublisher(mqtt)
+	e.RegisterPublisher(other)
+
+	e.processState(models.NewDroneState("uav-1", "mavlink"))
+	e.processState(models.NewDroneState("fleet-a-1", "dji"))
+
+	if len(gb.states) != 1 || gb.states[0] != "uav-1" {
+		t.Errorf("gb28181 received %v, want [uav-1]", gb.states)
+	}
+	if len(mqtt.states) != 1 || mqtt.states[0] != "fleet-a-1" {
+		t.Errorf("mqtt received %v, want [fleet-a-1]", mqtt.states)
+	}
+	if len(mqtt.topics) != 1 || mqtt.topics[0] != "fleet-a/fleet-a-1" {
+		t.Errorf("mqtt topics %v, want [fleet-a/fleet-a-1]", mqtt.topics)
+	}
+	if len(other.states) != 2 {
+		t.Errorf("Unrouted publisher received %v, want both states", other.states)
+	}
+
+	// Rules changed at runtime apply to the next state
+	for _, rule := range e.Routing().List() {
+		e.Routing().Delete(rule.ID)
+	}
+	e.processState(models.NewDroneState("uav-2", "dji"))
+	if len(gb.states) != 2 || len(mqtt.states) != 2 {
+		t.Errorf("After clearing rules: gb28181 %v, mqtt %v", gb.states, mqtt.states)
+	}
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Next(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	base := time.Date(2026, 10, 15, 10, 30, 0, 0, shanghai) // Thursday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 3 * * *", time.Date(2026, 10, 16, 3, 0, 0, 0, shanghai)},
+		{"@daily", time.Date(2026, 10, 16, 0, 0, 0, 0, shanghai)},
+		{"*/15 * * * *", time.Date(2026, 10, 15, 10, 45, 0, 0, shanghai)},
+		{"30 10 * * *", time.Date(2026, 10, 16, 10, 30, 0, 0, shanghai)}, // Strictly after
+		{"0 2 * * 0", time.Date(2026, 10, 18, 2, 0, 0, 0, shanghai)},     // Sunday
+		{"0 2 * * 7", time.Date(2026, 10, 18, 2, 0, 0, 0, shanghai)},
+		{"0 0 1 1-3 *", time.Date(2027, 1, 1, 0, 0, 0, 0, shanghai)},
+		{"0 0 20 * 1", time.Date(2026, 10, 19, 0, 0, 0, 0, shanghai)}, // Monday before the 20th
+		{"5,50 9-11 * * 1-5", time.Date(2026, 10, 15, 10, 50, 0, 0, shanghai)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr, shanghai)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(base); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	s, _ := Parse("0 0 30 2 *", nil)
+	if got := s.Next(base); !got.IsZero() {
+		t.Errorf("February 30th Next() = %v, want zero", got)
+	}
+}
+
+func TestParse_Every(t *testing.T) {
+	s, err := Parse("@every 90s", nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	base := time.Date(2026, 10, 15, 10, 30, 10, 0, time.UTC)
+	if got, want := s.Next(base), base.Add(90*time.Second); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *", "@every", "@every 10ms", "@every soon"} {
+		if _, err := Parse(expr, nil); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Package scheduler runs the gateway's recurring tasks, such as retention,
+// backups and alert escalation, on cron or interval schedules and keeps a
+// history of their runs
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultHistory is the number of runs kept per job
+const DefaultHistory = 20
+
+var (
+	// ErrJobNotFound is returned for an unknown job name
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when a job is triggered while it runs
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Task is the work of a job. It returns a short summary of what it did.
+type Task func(ctx context.Context) (string, error)
+
+// Trigger is what started a run
+type Trigger string
+
+const (
+	TriggerSchedule Trigger = "schedule"
+	TriggerManual   Trigger = "manual"
+)
+
+// Definition describes a job to add
+type Definition struct {
+	Name        string
+	Description string
+	Schedule    string // See Parse
+	Disabled    bool   // Not run on schedule, but can still be triggered
+	RunAtStart  bool   // Also run once when the scheduler starts
+	Task        Task
+}
+
+// Run is one execution of a job
+type Run struct {
+	Job        string  `json:"job"`
+	Trigger    Trigger `json:"trigger"`
+	StartedAt  int64   `json:"started_at"` // Unix ms
+	DurationMs int64   `json:"duration_ms"`
+	Result     string  `json:"result,omitempty"` // Summary returned by the task
+	Error      string  `json:"error,omitempty"`
+}
+
+// Job is the state of a job
+type Job struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Schedule    string `json:"schedule"`
+	Enabled     bool   `json:"enabled"`
+	Running     bool   `json:"running"`
+	NextRun     int64  `json:"next_run,omitempty"` // Unix ms, 0 if not scheduled
+	LastRun     *Run   `json:"last_run,omitempty"`
+}
+
+// job is a registered job
+type job struct {
+	def      Definition
+	schedule Schedule
+	running  bool
+	next     time.Time
+	history  []Run // Oldest first
+}
+
+// Scheduler runs jobs on their schedules
+type Scheduler struct {
+	loc *time.Location
+	now func() time.Time
+
+	mu      sync.Mutex
+	ctx     context.Context
+	jobs    []*job
+	started bool
+}
+
+// New creates a scheduler evaluating cron schedules in the given location
+// (nil = UTC)
+func New(loc *time.Location) *Scheduler {
+	return &Scheduler{loc: loc, now: time.Now, ctx: context.Background()}
+}
+
+// Add registers a job. Jobs added after Start are not scheduled.
+func (s *Scheduler) Add(def Definition) error {
+	schedule, err := Parse(def.Schedule, s.loc)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", def.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.find(def.Name); err == nil {
+		return fmt.Errorf("job %s: already registered", def.Name)
+	}
+	s.jobs = append(s.jobs, &job{def: def, schedule: schedule})
+	return nil
+}
+
+// Start runs every enabled job on its schedule until the context is done
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	s.ctx = ctx
+	for _, j := range s.jobs {
+		if !j.def.Disabled {
+			go s.loop(ctx, j)
+		}
+	}
+}
+
+// Jobs returns the state of every job in registration order
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j.state())
+	}
+	return jobs
+}
+
+// Get returns the state of a job and its runs, newest first
+func (s *Scheduler) Get(name string) (Job, []Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, err := s.find(name)
+	if err != nil {
+		return Job{}, nil, err
+	}
+	runs := make([]Run, 0, len(j.history))
+	for i := len(j.history) - 1; i >= 0; i-- {
+		runs = append(runs, j.history[i])
+	}
+	return j.state(), runs, nil
+}
+
+// Trigger runs a job now, even if it is disabled, and waits for it to finish
+func (s *Scheduler) Trigger(name string) (Run, error) {
+	s.mu.Lock()
+	j, err := s.find(name)
+	if err != nil {
+		s.mu.Unlock()
+		return Run{}, err
+	}
+	if j.running {
+		s.mu.Unlock()
+		return Run{}, ErrJobRunning
+	}
+	j.running = true
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	return s.run(ctx, j, TriggerManual), nil
+}
+
+// loop runs a job at its scheduled times
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	if j.def.RunAtStart && s.claim(j) {
+		s.run(ctx, j, TriggerSchedule)
+	}
+	for {
+		next := j.schedule.Next(s.now())
+		s.mu.Lock()
+		j.next = next
+		s.mu.Unlock()
+		if next.IsZero() {
+			log.Printf("[Scheduler] Schedule of job %s never matches, job stopped", j.def.Name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if !s.claim(j) {
+			log.Printf("[Scheduler] Job %s is still running, skipping scheduled run", j.def.Name)
+			continue
+		}
+		s.run(ctx, j, TriggerSchedule)
+	}
+}
+
+// claim marks a job running. Returns false if it already runs.
+func (s *Scheduler) claim(j *job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.running {
+		return false
+	}
+	j.running = true
+	return true
+}
+
+// run executes a claimed job and records the run
+func (s *Scheduler) run(ctx context.Context, j *job, trigger Trigger) Run {
+	start := s.now()
+	result, err := j.def.Task(ctx)
+	run := Run{
+		Job:        j.def.Name,
+		Trigger:    trigger,
+		StartedAt:  start.UnixMilli(),
+		DurationMs: s.now().Sub(start).Milliseconds(),
+		Result:     result,
+	}
+	if err != nil {
+		run.Error = err.Error()
+		log.Printf("[Scheduler] Job %s failed: %v", j.def.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j.running = false
+	j.history = append(j.history, run)
+	if len(j.history) > DefaultHistory {
+		j.history = j.history[len(j.history)-DefaultHistory:]
+	}
+	return run
+}
+
+// find returns a job by name. Caller must hold the lock.
+func (s *Scheduler) find(name string) (*job, error) {
+	for _, j := range s.jobs {
+		if j.def.Name == name {
+			return j, nil
+		}
+	}
+	return nil, ErrJobNotFound
+}
+
+// state returns the exported state of a job. Caller must hold the lock.
+func (j *job) state() Job {
+	state := Job{
+		Name:        j.def.Name,
+		Description: j.def.Description,
+		Schedule:    j.def.Schedule,
+		Enabled:     !j.def.Disabled,
+		Running:     j.running,
+	}
+	if !j.next.IsZero() {
+		state.NextRun = j.next.UnixMilli()
+	}
+	if n := len(j.history); n > 0 {
+		last := j.history[n-1]
+		state.LastRun = &last
+	}
+	return state
+}
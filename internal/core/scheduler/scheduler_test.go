@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler_TriggerAndHistory(t *testing.T) {
+	s := New(nil)
+	calls := 0
+	err := s.Add(Definition{Name: "prune", Description: "Prune old data", Schedule: "@daily", Task: func(ctx context.Context) (string, error) {
+		calls++
+		if calls == 2 {
+			return "", errors.New("disk full")
+		}
+		return "pruned 3 items", nil
+	}})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add(Definition{Name: "prune", Schedule: "@daily"}); err == nil {
+		t.Error("Add() duplicate name should fail")
+	}
+	if err := s.Add(Definition{Name: "bad", Schedule: "every day"}); err == nil {
+		t.Error("Add() invalid schedule should fail")
+	}
+
+	run, err := s.Trigger("prune")
+	if err != nil || run.Trigger != TriggerManual || run.Result != "pruned 3 items" || run.Error != "" {
+		t.Fatalf("Trigger() = %+v, %v", run, err)
+	}
+	if run, _ := s.Trigger("prune"); run.Error != "disk full" {
+		t.Errorf("Failed run = %+v, want the task error", run)
+	}
+	if _, err := s.Trigger("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Trigger() unknown error = %v, want ErrJobNotFound", err)
+	}
+
+	job, runs, err := s.Get("prune")
+	if err != nil || len(runs) != 2 || runs[0].Error != "disk full" {
+		t.Fatalf("Get() = %+v, %+v, %v", job, runs, err)
+	}
+	if !job.Enabled || job.Running || job.LastRun == nil || job.LastRun.Error != "disk full" {
+		t.Errorf("Job = %+v", job)
+	}
+
+	for range DefaultHistory {
+		s.Trigger("prune")
+	}
+	if _, runs, _ := s.Get("prune"); len(runs) != DefaultHistory {
+		t.Errorf("History has %d runs, want %d", len(runs), DefaultHistory)
+	}
+}
+
+func TestScheduler_Start(t *testing.T) {
+	s := New(nil)
+	ran := make(chan struct{}, 1)
+	release := make(chan struct{})
+	s.Add(Definition{Name: "backup", Schedule: "@every 1h", RunAtStart: true, Task: func(ctx context.Context) (string, error) {
+		ran <- struct{}{}
+		<-release
+		return "", nil
+	}})
+	s.Add(Definition{Name: "off", Schedule: "@every 1s", Disabled: true, RunAtStart: true, Task: func(ctx context.Context) (string, error) {
+		t.Error("Disabled job ran")
+		return "", nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Start(ctx)
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("RunAtStart job did not run")
+	}
+	if _, err := s.Trigger("backup"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Trigger() while running error = %v, want ErrJobRunning", err)
+	}
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		jobs := s.Jobs()
+		if jobs[0].LastRun != nil && jobs[0].NextRun > 0 {
+			if jobs[0].LastRun.Trigger != TriggerSchedule || jobs[1].Enabled || jobs[1].NextRun != 0 {
+				t.Errorf("Jobs() = %+v", jobs)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Jobs() = %+v, want a recorded run and a next run", jobs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
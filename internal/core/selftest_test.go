@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// fakeAdapter is a minimal adapter with optional self-test support
+type fakeAdapter struct {
+	name string
+	err  error
+}
+
+func (a *fakeAdapter) Name() string { return a.name }
+func (a *fakeAdapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	return nil
+}
+func (a *fakeAdapter) Stop() error { return nil }
+func (a *fakeAdapter) SelfTest() (*models.DroneState, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return syntheticState(), nil
+}
+
+// fakePublisher records self-test calls
+type fakePublisher struct {
+	name string
+	err  error
+	got  *models.DroneState
+}
+
+func (p *fakePublisher) Name() string                           { return p.name }
+func (p *fakePublisher) Start(ctx context.Context) error        { return nil }
+func (p *fakePublisher) Publish(state *models.DroneState) error { return nil }
+func (p *fakePublisher) Stop() error                            { return nil }
+func (p *fakePublisher) SelfTest(state *models.DroneState) error {
+	p.got = state
+	return p.err
+}
+
+// plainPublisher has no self-test or connection support
+type plainPublisher struct{}
+
+func (plainPublisher) Name() string                           { return "plain" }
+func (plainPublisher) Start(ctx context.Context) error        { return nil }
+func (plainPublisher) Publish(state *models.DroneState) error { return nil }
+func (plainPublisher) Stop() error                            { return nil }
+
+func TestEngine_RunSelfTest_Pass(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1, ConvertGCJ02: true})
+	e.RegisterAdapter(&fakeAdapter{name: "fake"})
+	pub := &fakePublisher{name: "out"}
+	e.RegisterPublisher(pub)
+	e.RegisterPublisher(plainPublisher{})
+
+	report := e.RunSelfTest()
+
+	if !report.Passed {
+		t.Fatalf("report should pass: %+v", report.Stages)
+	}
+	if len(report.Stages) != 4 {
+		t.Fatalf("stages = %d, want 4", len(report.Stages))
+	}
+	if report.Stages[3].Result != SelfTestSkip {
+		t.Errorf("plain publisher result = %s, want skip", report.Stages[3].Result)
+	}
+	if pub.got == nil || pub.got.Location.LatGCJ02 == nil {
+		t.Error("publisher should receive a GCJ02-converted state")
+	}
+	if e.GetDeviceCount() != 0 {
+		t.Error("self-test must not touch the state store")
+	}
+}
+
+func TestEngine_RunSelfTest_Fail(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	e.RegisterAdapter(&fakeAdapter{name: "broken", err: errors.New("decode failed")})
+	e.RegisterPublisher(&fakePublisher{name: "down", err: errors.New("not connected")})
+
+	report := e.RunSelfTest()
+
+	if report.Passed {
+		t.Fatal("report should fail")
+	}
+	if report.Stages[0].Result != SelfTestFail || report.Stages[0].Error != "decode failed" {
+		t.Errorf("adapter stage = %+v", report.Stages[0])
+	}
+	// Engine stage falls back to the synthetic state
+	if report.Stages[1].Result != SelfTestPass {
+		t.Errorf("engine stage = %+v", report.Stages[1])
+	}
+	if report.Stages[2].Result != SelfTestFail {
+		t.Errorf("publisher stage = %+v", report.Stages[2])
+	}
+}
@@ -0,0 +1,197 @@
+package statestore
+
+import (
+	"math"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// MaxGeohashPrecision is the longest geohash the index supports (~4 cm cells)
+const MaxGeohashPrecision = 12
+
+// maxCoverCells caps the cells a query looks up in the index. Queries
+// spanning more cells than this scan every state instead.
+const maxCoverCells = 1024
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Bounds is a latitude/longitude rectangle (WGS84), edges included
+type Bounds struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// Contains reports whether a point lies in the bounds
+func (b Bounds) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// geohash encodes a position as a geohash of the given length
+func geohash(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	hash := make([]byte, 0, precision)
+	even := true // Bits alternate longitude, latitude
+	bits, ch := 0, 0
+	for len(hash) < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				minLon = mid
+			} else {
+				ch <<= 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch <<= 1
+				maxLat = mid
+			}
+		}
+		even = !even
+		if bits++; bits == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// coverCells returns the geohash cells of the given length overlapping the
+// bounds, or false if there are more than maxCoverCells
+func coverCells(b Bounds, precision int) ([]string, bool) {
+	latBits := 5 * precision / 2
+	lonBits := 5*precision - latBits
+	latCells, lonCells := 1<<latBits, 1<<lonBits
+	cellLat := 180.0 / float64(latCells)
+	cellLon := 360.0 / float64(lonCells)
+
+	index := func(v, origin, size float64, cells int) int {
+		i := int(math.Floor((v - origin) / size))
+		return min(max(i, 0), cells-1)
+	}
+	i0, i1 := index(b.MinLat, -90, cellLat, latCells), index(b.MaxLat, -90, cellLat, latCells)
+	j0, j1 := index(b.MinLon, -180, cellLon, lonCells), index(b.MaxLon, -180, cellLon, lonCells)
+	if (i1-i0+1)*(j1-j0+1) > maxCoverCells {
+		return nil, false
+	}
+
+	cells := make([]string, 0, (i1-i0+1)*(j1-j0+1))
+	for i := i0; i <= i1; i++ {
+		for j := j0; j <= j1; j++ {
+			lat := -90 + (float64(i)+0.5)*cellLat
+			lon := -180 + (float64(j)+0.5)*cellLon
+			cells = append(cells, geohash(lat, lon, precision))
+		}
+	}
+	return cells, true
+}
+
+// index files a state under its geohash cell. Caller must hold the lock.
+func (s *StateStore) index(state *models.DroneState) {
+	if s.cfg.GeohashPrecision <= 0 {
+		return
+	}
+	cell := geohash(state.Location.Lat, state.Location.Lon, s.cfg.GeohashPrecision)
+	old, ok := s.cells[state.DeviceID]
+	if ok && old == cell {
+		return
+	}
+	if ok {
+		s.unindex(state.DeviceID)
+	}
+	devices := s.grid[cell]
+	if devices == nil {
+		devices = make(map[string]struct{})
+		s.grid[cell] = devices
+	}
+	devices[state.DeviceID] = struct{}{}
+	s.cells[state.DeviceID] = cell
+}
+
+// unindex removes a device from the geohash index. Caller must hold the
+// lock.
+func (s *StateStore) unindex(deviceID string) {
+	cell, ok := s.cells[deviceID]
+	if !ok {
+		return
+	}
+	delete(s.grid[cell], deviceID)
+	if len(s.grid[cell]) == 0 {
+		delete(s.grid, cell)
+	}
+	delete(s.cells, deviceID)
+}
+
+// InBounds returns the states positioned within the bounds. With a geohash
+// index only the cells overlapping the bounds are looked at.
+func (s *StateStore) InBounds(b Bounds) []*models.DroneState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.inBounds(b)
+}
+
+// inBounds implements InBounds. Caller must hold the read lock.
+func (s *StateStore) inBounds(b Bounds) []*models.DroneState {
+	result := make([]*models.DroneState, 0)
+	if s.cfg.GeohashPrecision > 0 {
+		if cells, ok := coverCells(b, s.cfg.GeohashPrecision); ok {
+			for _, cell := range cells {
+				for id := range s.grid[cell] {
+					state := s.states[id]
+					if b.Contains(state.Location.Lat, state.Location.Lon) {
+						result = append(result, state)
+					}
+				}
+			}
+			return result
+		}
+	}
+	for _, state := range s.states {
+		if b.Contains(state.Location.Lat, state.Location.Lon) {
+			result = append(result, state)
+		}
+	}
+	return result
+}
+
+// WithinRadius returns the states within radiusM meters of a point
+func (s *StateStore) WithinRadius(lat, lon, radiusM float64) []*models.DroneState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*models.DroneState, 0)
+	for _, state := range s.inBounds(radiusBounds(lat, lon, radiusM)) {
+		if coordinator.HaversineDistance(lat, lon, state.Location.Lat, state.Location.Lon) <= radiusM {
+			result = append(result, state)
+		}
+	}
+	return result
+}
+
+// radiusBounds returns bounds enclosing a circle. Circles reaching a pole
+// or the antimeridian span all longitudes.
+func radiusBounds(lat, lon, radiusM float64) Bounds {
+	angle := radiusM / coordinator.EarthRadiusM // Angular radius in radians
+	dLat := angle * 180 / math.Pi
+	b := Bounds{MinLat: lat - dLat, MaxLat: lat + dLat, MinLon: -180, MaxLon: 180}
+	if b.MinLat <= -90 || b.MaxLat >= 90 {
+		b.MinLat, b.MaxLat = max(b.MinLat, -90), min(b.MaxLat, 90)
+		return b
+	}
+	// Widest longitude span of the circle, at the parallels it touches
+	sinLon := math.Sin(angle) / math.Cos(lat*math.Pi/180)
+	if sinLon >= 1 {
+		return b
+	}
+	dLon := math.Asin(sinLon) * 180 / math.Pi
+	if lon-dLon > -180 && lon+dLon < 180 {
+		b.MinLon, b.MaxLon = lon-dLon, lon+dLon
+	}
+	return b
+}
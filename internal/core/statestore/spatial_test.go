@@ -0,0 +1,99 @@
+package statestore
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestGeohash(t *testing.T) {
+	// Reference values from geohash.org
+	if got := geohash(57.64911, 10.40744, 11); got != "u4pruydqqvj" {
+		t.Errorf("geohash() = %s, want u4pruydqqvj", got)
+	}
+	if got := geohash(-25.382708, -49.265506, 6); got != "6gkzwg" {
+		t.Errorf("geohash() = %s, want 6gkzwg", got)
+	}
+}
+
+// ids returns the sorted device IDs of states
+func ids(states []*models.DroneState) []string {
+	result := make([]string, 0, len(states))
+	for _, s := range states {
+		result = append(result, s.DeviceID)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestStateStore_SpatialQueries(t *testing.T) {
+	indexed := New(Config{GeohashPrecision: 6})
+	scanned := New(Config{})
+
+	// Drones scattered around Shenzhen, some moving between cells
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		state := models.NewDroneState(fmt.Sprintf("uav-%03d", i%300), "mavlink")
+		state.Location.Lat = 22.5 + rng.Float64()*0.2
+		state.Location.Lon = 114.0 + rng.Float64()*0.2
+		indexed.Update(state)
+		scanned.Update(state)
+	}
+	indexed.Delete("uav-007")
+	scanned.Delete("uav-007")
+
+	b := Bounds{MinLat: 22.55, MinLon: 114.05, MaxLat: 22.6, MaxLon: 114.12}
+	want := ids(scanned.InBounds(b))
+	if len(want) == 0 {
+		t.Fatal("InBounds() found nothing to compare")
+	}
+	if got := ids(indexed.InBounds(b)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("indexed InBounds() = %v, want %v", got, want)
+	}
+
+	want = ids(scanned.WithinRadius(22.6, 114.1, 3000))
+	if got := ids(indexed.WithinRadius(22.6, 114.1, 3000)); len(want) == 0 || fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("indexed WithinRadius() = %v, want %v", got, want)
+	}
+	for _, s := range indexed.WithinRadius(22.6, 114.1, 3000) {
+		if d := coordinator.HaversineDistance(22.6, 114.1, s.Location.Lat, s.Location.Lon); d > 3000 {
+			t.Errorf("%s is %.0f m away", s.DeviceID, d)
+		}
+	}
+
+	// Too many cells for the index: falls back to a scan
+	if got := len(indexed.InBounds(Bounds{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180})); got != 299 {
+		t.Errorf("InBounds(world) = %d states, want 299", got)
+	}
+}
+
+func TestStateStore_WithinRadiusEdges(t *testing.T) {
+	store := New(Config{GeohashPrecision: 5})
+	add := func(id string, lat, lon float64) {
+		state := models.NewDroneState(id, "mavlink")
+		state.Location.Lat, state.Location.Lon = lat, lon
+		store.Update(state)
+	}
+	add("north", 60.009, 10)  // ~1000 m north
+	add("east", 60, 10.0179)  // ~995 m east, wider than 1000 m in degrees of latitude
+	add("far", 60, 10.05)     // ~2780 m east
+	add("fiji-w", -17, 179.9) // Across the antimeridian
+	add("fiji-e", -17, -179.99)
+
+	if got := ids(store.WithinRadius(60, 10, 1005)); fmt.Sprint(got) != "[east north]" {
+		t.Errorf("WithinRadius() = %v, want east and north", got)
+	}
+	if got := ids(store.WithinRadius(-17, 179.99, 20000)); fmt.Sprint(got) != "[fiji-e fiji-w]" {
+		t.Errorf("WithinRadius(antimeridian) = %v, want both sides", got)
+	}
+
+	// Moved away: the index follows
+	add("north", 61, 10)
+	if got := ids(store.WithinRadius(60, 10, 1005)); fmt.Sprint(got) != "[east]" {
+		t.Errorf("WithinRadius() after move = %v, want east", got)
+	}
+}
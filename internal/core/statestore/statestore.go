@@ -2,28 +2,108 @@ package statestore
 
 import (
 	"sync"
+	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
+// EvictReason is why a device was removed from the store
+type EvictReason string
+
+const (
+	EvictExpired  EvictReason = "expired"  // No update within the TTL
+	EvictCapacity EvictReason = "capacity" // Least recently updated device beyond MaxDevices
+)
+
+// Config holds state store limits
+type Config struct {
+	TTL        time.Duration // Remove devices without an update for this long (0 = never)
+	MaxDevices int           // Evict the least recently updated device beyond this many (0 = unlimited)
+
+	// GeohashPrecision indexes states by geohash cells of this length, so
+	// spatial queries only look at nearby devices (0 = no index, scan all)
+	GeohashPrecision int
+}
+
 // StateStore provides thread-safe in-memory caching of drone states
 type StateStore struct {
-	mu     sync.RWMutex
-	states map[string]*models.DroneState
+	cfg Config
+	now func() time.Time
+
+	mu      sync.RWMutex
+	states  map[string]*models.DroneState
+	updated map[string]time.Time
+	onEvict func(deviceID string, reason EvictReason)
+
+	grid  map[string]map[string]struct{} // Geohash cell -> device IDs
+	cells map[string]string              // Device ID -> geohash cell
 }
 
 // New creates a new StateStore
-func New() *StateStore {
+func New(cfg Config) *StateStore {
+	cfg.GeohashPrecision = min(cfg.GeohashPrecision, MaxGeohashPrecision)
 	return &StateStore{
-		states: make(map[string]*models.DroneState),
+		cfg:     cfg,
+		now:     time.Now,
+		states:  make(map[string]*models.DroneState),
+		updated: make(map[string]time.Time),
+		grid:    make(map[string]map[string]struct{}),
+		cells:   make(map[string]string),
 	}
 }
 
-// Update stores or updates the state for a device
-func (s *StateStore) Update(state *models.DroneState) {
+// SetEvictCallback sets a function called for every device removed by
+// expiry or the device limit. It is not called for Delete.
+func (s *StateStore) SetEvictCallback(cb func(deviceID string, reason EvictReason)) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.onEvict = cb
+}
+
+// Update stores or updates the state for a device. A new device beyond
+// MaxDevices evicts the least recently updated one.
+func (s *StateStore) Update(state *models.DroneState) {
+	s.mu.Lock()
+	var evicted string
+	if _, ok := s.states[state.DeviceID]; !ok && s.cfg.MaxDevices > 0 && len(s.states) >= s.cfg.MaxDevices {
+		evicted = s.oldest()
+		s.remove(evicted)
+	}
 	s.states[state.DeviceID] = state
+	s.updated[state.DeviceID] = s.now()
+	s.index(state)
+	cb := s.onEvict
+	s.mu.Unlock()
+
+	if evicted != "" && cb != nil {
+		cb(evicted, EvictCapacity)
+	}
+}
+
+// Expire removes devices without an update within the TTL and returns
+// their IDs
+func (s *StateStore) Expire(now time.Time) []string {
+	if s.cfg.TTL <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	var expired []string
+	for id, t := range s.updated {
+		if now.Sub(t) > s.cfg.TTL {
+			s.remove(id)
+			expired = append(expired, id)
+		}
+	}
+	cb := s.onEvict
+	s.mu.Unlock()
+
+	if cb != nil {
+		for _, id := range expired {
+			cb(id, EvictExpired)
+		}
+	}
+	return expired
 }
 
 // Get retrieves the current state for a device
@@ -50,7 +130,7 @@ func (s *StateStore) GetAll() []*models.DroneState {
 func (s *StateStore) Delete(deviceID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	delete(s.states, deviceID)
+	s.remove(deviceID)
 }
 
 // Count returns the number of devices in the store
@@ -59,3 +139,23 @@ func (s *StateStore) Count() int {
 	defer s.mu.RUnlock()
 	return len(s.states)
 }
+
+// remove deletes a device. Caller must hold the lock.
+func (s *StateStore) remove(deviceID string) {
+	delete(s.states, deviceID)
+	delete(s.updated, deviceID)
+	s.unindex(deviceID)
+}
+
+// oldest returns the least recently updated device. Caller must hold the
+// lock.
+func (s *StateStore) oldest() string {
+	var id string
+	var oldest time.Time
+	for d, t := range s.updated {
+		if id == "" || t.Before(oldest) {
+			id, oldest = d, t
+		}
+	}
+	return id
+}
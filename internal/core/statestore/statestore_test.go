@@ -2,12 +2,13 @@ package statestore
 
 import (
 	"testing"
+	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestStateStore(t *testing.T) {
-	store := New()
+	store := New(Config{})
 
 	// Test empty store
 	if store.Count() != 0 {
@@ -67,7 +68,7 @@ func TestStateStore(t *testing.T) {
 }
 
 func TestStateStoreConcurrency(t *testing.T) {
-	store := New()
+	store := New(Config{})
 	done := make(chan bool)
 
 	// Concurrent writes
@@ -100,3 +101,39 @@ func TestStateStoreConcurrency(t *testing.T) {
 		t.Errorf("Expected 1 device, got %d", store.Count())
 	}
 }
+
+func TestStateStoreEviction(t *testing.T) {
+	store := New(Config{TTL: time.Minute, MaxDevices: 2})
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	var evicted []string
+	store.SetEvictCallback(func(deviceID string, reason EvictReason) {
+		evicted = append(evicted, deviceID+":"+string(reason))
+	})
+
+	store.Update(models.NewDroneState("uav-001", "mavlink"))
+	now = now.Add(10 * time.Second)
+	store.Update(models.NewDroneState("uav-002", "mavlink"))
+	now = now.Add(10 * time.Second)
+	store.Update(models.NewDroneState("uav-001", "mavlink"))
+	store.Update(models.NewDroneState("uav-003", "dji"))
+
+	if store.Count() != 2 || store.Get("uav-002") != nil {
+		t.Errorf("Expected uav-002 evicted at capacity, have %d devices", store.Count())
+	}
+
+	if expired := store.Expire(now.Add(30 * time.Second)); len(expired) != 0 {
+		t.Errorf("Expire() = %v, want none within the TTL", expired)
+	}
+	now = now.Add(30 * time.Second)
+	store.Update(models.NewDroneState("uav-003", "dji"))
+	if expired := store.Expire(now.Add(45 * time.Second)); len(expired) != 1 || expired[0] != "uav-001" {
+		t.Errorf("Expire() = %v, want [uav-001]", expired)
+	}
+
+	want := []string{"uav-002:capacity", "uav-001:expired"}
+	if len(evicted) != len(want) || evicted[0] != want[0] || evicted[1] != want[1] {
+		t.Errorf("Evicted %v, want %v", evicted, want)
+	}
+}
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrStatsUnsupported is returned for adapters that do not count their traffic
+var ErrStatsUnsupported = errors.New("adapter does not report statistics")
+
+// AdapterStats returns the traffic counters of the named adapter
+func (e *Engine) AdapterStats(name string) (AdapterStats, error) {
+	for _, adapter := range e.adapters {
+		if adapter.Name() == name {
+			reporter, ok := adapter.(StatsReporter)
+			if !ok {
+				return AdapterStats{}, fmt.Errorf("%w: %s", ErrStatsUnsupported, name)
+			}
+			return reporter.Stats(), nil
+		}
+		// Hosted adapters are counted by their host
+		if host, ok := adapter.(AdapterHost); ok && slices.Contains(host.HostedAdapters(), name) {
+			return AdapterStats{}, fmt.Errorf("%w: %s (counted under %s)", ErrStatsUnsupported, name, adapter.Name())
+		}
+	}
+	return AdapterStats{}, ErrAdapterNotFound
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+// statsAdapter reports fixed traffic counters
+type statsAdapter struct {
+	fakeAdapter
+}
+
+func (a *statsAdapter) Stats() AdapterStats {
+	return AdapterStats{MessagesReceived: 42, ConnectedPeers: 2}
+}
+
+func TestEngine_AdapterStats(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 1})
+	e.RegisterAdapter(&fakeAdapter{name: "dji"})
+	e.RegisterAdapter(&statsAdapter{fakeAdapter: fakeAdapter{name: "mavlink"}})
+
+	stats, err := e.AdapterStats("mavlink")
+	if err != nil || stats.MessagesReceived != 42 || stats.ConnectedPeers != 2 {
+		t.Errorf("AdapterStats(mavlink) = %+v, %v", stats, err)
+	}
+	if _, err := e.AdapterStats("dji"); !errors.Is(err, ErrStatsUnsupported) {
+		t.Errorf("AdapterStats(dji) error = %v, want ErrStatsUnsupported", err)
+	}
+	if _, err := e.AdapterStats("missing"); !errors.Is(err, ErrAdapterNotFound) {
+		t.Errorf("AdapterStats(missing) error = %v, want ErrAdapterNotFound", err)
+	}
+}
@@ -0,0 +1,8 @@
+package core
+
+import "github.com/open-uav/telemetry-bridge/internal/core/tenant"
+
+// Tenants returns the device ownership registry, nil without tenants
+func (e *Engine) Tenants() *tenant.Registry {
+	return e.tenants
+}
@@ -0,0 +1,127 @@
+// Package tenant assigns devices to organizations so one gateway can serve
+// several customers. A device belongs to the tenant that lists its ID, or
+// else to the tenant with the longest matching device ID prefix; devices
+// matched by no tenant are only visible to global users.
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidTenant is returned for tenants with a bad or duplicate ID or an
+// ambiguous device assignment
+var ErrInvalidTenant = errors.New("invalid tenant")
+
+// validID matches tenant IDs, which appear in MQTT topics
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Tenant is an organization owning a set of devices
+type Tenant struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Devices        []string `json:"devices,omitempty"`         // Exact device IDs
+	DevicePrefixes []string `json:"device_prefixes,omitempty"` // Device ID prefixes
+}
+
+// prefix is a device ID prefix and its tenant
+type prefix struct {
+	prefix string
+	tenant string
+}
+
+// Registry resolves the tenant of a device. A nil Registry has no tenants
+// and assigns no device.
+type Registry struct {
+	tenants  []Tenant
+	byID     map[string]int
+	devices  map[string]string
+	prefixes []prefix // Longest first
+}
+
+// New validates the tenants and builds a registry
+func New(tenants []Tenant) (*Registry, error) {
+	r := &Registry{
+		byID:    make(map[string]int),
+		devices: make(map[string]string),
+	}
+	seenPrefix := make(map[string]string)
+
+	for i, t := range tenants {
+		if !validID.MatchString(t.ID) {
+			return nil, fmt.Errorf("%w: id %q must be lowercase letters, digits, '-' or '_'", ErrInvalidTenant, t.ID)
+		}
+		if _, dup := r.byID[t.ID]; dup {
+			return nil, fmt.Errorf("%w: duplicate id %q", ErrInvalidTenant, t.ID)
+		}
+		r.byID[t.ID] = i
+
+		for _, id := range t.Devices {
+			if owner, taken := r.devices[id]; taken {
+				return nil, fmt.Errorf("%w: device %q assigned to %s and %s", ErrInvalidTenant, id, owner, t.ID)
+			}
+			r.devices[id] = t.ID
+		}
+		for _, p := range t.DevicePrefixes {
+			if p == "" {
+				return nil, fmt.Errorf("%w: %s has an empty device prefix", ErrInvalidTenant, t.ID)
+			}
+			if owner, taken := seenPrefix[p]; taken {
+				return nil, fmt.Errorf("%w: prefix %q assigned to %s and %s", ErrInvalidTenant, p, owner, t.ID)
+			}
+			seenPrefix[p] = t.ID
+			r.prefixes = append(r.prefixes, prefix{prefix: p, tenant: t.ID})
+		}
+		r.tenants = append(r.tenants, t)
+	}
+
+	sort.SliceStable(r.prefixes, func(i, j int) bool {
+		return len(r.prefixes[i].prefix) > len(r.prefixes[j].prefix)
+	})
+	return r, nil
+}
+
+// Resolve returns the tenant ID of a device, or "" if no tenant owns it
+func (r *Registry) Resolve(deviceID string) string {
+	if r == nil {
+		return ""
+	}
+	if t, ok := r.devices[deviceID]; ok {
+		return t
+	}
+	for _, p := range r.prefixes {
+		if strings.HasPrefix(deviceID, p.prefix) {
+			return p.tenant
+		}
+	}
+	return ""
+}
+
+// Visible reports whether a user of the given tenant may see a device.
+// Global users (tenant "") see every device.
+func (r *Registry) Visible(tenant, deviceID string) bool {
+	return tenant == "" || r.Resolve(deviceID) == tenant
+}
+
+// Get returns a tenant by ID
+func (r *Registry) Get(id string) (Tenant, bool) {
+	if r == nil {
+		return Tenant{}, false
+	}
+	i, ok := r.byID[id]
+	if !ok {
+		return Tenant{}, false
+	}
+	return r.tenants[i], true
+}
+
+// List returns all tenants in configuration order
+func (r *Registry) List() []Tenant {
+	if r == nil {
+		return []Tenant{}
+	}
+	return append([]Tenant(nil), r.tenants...)
+}
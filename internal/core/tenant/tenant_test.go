@@ -0,0 +1,58 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistry_Resolve(t *testing.T) {
+	r, err := New([]Tenant{
+		{ID: "acme", DevicePrefixes: []string{"acme-"}, Devices: []string{"mavlink-7"}},
+		{ID: "acme-labs", DevicePrefixes: []string{"acme-labs-"}},
+		{ID: "globex", DevicePrefixes: []string{"gx-"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := map[string]string{
+		"acme-001":      "acme",
+		"acme-labs-001": "acme-labs", // Longest prefix wins
+		"mavlink-7":     "acme",
+		"gx-9":          "globex",
+		"mavlink-1":     "",
+	}
+	for device, want := range tests {
+		if got := r.Resolve(device); got != want {
+			t.Errorf("Resolve(%s) = %q, want %q", device, got, want)
+		}
+	}
+
+	if !r.Visible("", "gx-9") || !r.Visible("globex", "gx-9") || r.Visible("acme", "gx-9") {
+		t.Error("Visible() should allow global users and the owning tenant only")
+	}
+	if r.Visible("acme", "mavlink-1") {
+		t.Error("Unassigned devices should only be visible to global users")
+	}
+}
+
+func TestRegistry_Invalid(t *testing.T) {
+	for name, tenants := range map[string][]Tenant{
+		"bad id":        {{ID: "Acme Corp"}},
+		"duplicate id":  {{ID: "acme"}, {ID: "acme"}},
+		"shared device": {{ID: "a", Devices: []string{"d1"}}, {ID: "b", Devices: []string{"d1"}}},
+		"shared prefix": {{ID: "a", DevicePrefixes: []string{"x-"}}, {ID: "b", DevicePrefixes: []string{"x-"}}},
+		"empty prefix":  {{ID: "a", DevicePrefixes: []string{""}}},
+	} {
+		if _, err := New(tenants); !errors.Is(err, ErrInvalidTenant) {
+			t.Errorf("%s: New() error = %v, want ErrInvalidTenant", name, err)
+		}
+	}
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	var r *Registry
+	if r.Resolve("d1") != "" || !r.Visible("", "d1") || r.Visible("acme", "d1") || len(r.List()) != 0 {
+		t.Error("nil registry should assign no devices")
+	}
+}
@@ -0,0 +1,26 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/tenant"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestEngine_TenantStamp(t *testing.T) {
+	registry, err := tenant.New([]tenant.Tenant{{ID: "acme", DevicePrefixes: []string{"acme-"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(EngineConfig{RateHz: 100, Tenants: registry})
+
+	for _, id := range []string{"acme-001", "gx-001"} {
+		e.processState(models.NewDroneState(id, "mavlink"))
+	}
+	if got := e.GetState("acme-001"); got == nil || got.Tenant != "acme" {
+		t.Errorf("acme-001 state = %+v, want tenant acme", got)
+	}
+	if got := e.GetState("gx-001"); got == nil || got.Tenant != "" {
+		t.Errorf("gx-001 state = %+v, want no tenant", got)
+	}
+}
@@ -1,10 +1,30 @@
 package throttler
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Boost limits
+const (
+	DefaultBoostDuration = time.Minute
+	MaxBoostDuration     = 10 * time.Minute
+)
+
+// DropReasonRateLimit is recorded when a state arrives before the device's
+// publish interval has elapsed
+const DropReasonRateLimit = "rate_limit"
+
+var (
+	// ErrInvalidRate is returned for a non-positive or too high boost rate
+	ErrInvalidRate = errors.New("invalid rate")
+	// ErrInvalidDuration is returned for a boost longer than MaxBoostDuration
+	ErrInvalidDuration = errors.New("invalid duration")
 )
 
 // Throttler controls the rate of state updates per device
@@ -12,7 +32,49 @@ type Throttler struct {
 	mu          sync.RWMutex
 	rateHz      float64
 	interval    time.Duration
+	maxRateHz   float64 // Upper bound for boosts, 0 = unlimited
 	lastPublish map[string]time.Time
+	stats       map[string]*deviceStats
+	boosts      map[string]boost
+}
+
+// deviceStats holds the runtime counters of one device
+type deviceStats struct {
+	received       uint64
+	published      uint64
+	dropped        uint64
+	lastDrop       time.Time
+	lastDropReason string
+	avgInterval    time.Duration // Moving average of the publish interval
+}
+
+// boost temporarily overrides the rate of one device
+type boost struct {
+	rateHz   float64
+	interval time.Duration
+	expires  time.Time
+}
+
+// DeviceStatus is the runtime throttle state of one device
+type DeviceStatus struct {
+	DeviceID        string  `json:"device_id"`
+	EffectiveRateHz float64 `json:"effective_rate_hz"` // Configured rate, including any boost
+	ObservedRateHz  float64 `json:"observed_rate_hz"`  // Measured publish rate
+	Received        uint64  `json:"received"`
+	Published       uint64  `json:"published"`
+	Dropped         uint64  `json:"dropped"`
+	LastPublishAt   int64   `json:"last_publish_at,omitempty"` // Unix milliseconds
+	LastDropAt      int64   `json:"last_drop_at,omitempty"`    // Unix milliseconds
+	LastDropReason  string  `json:"last_drop_reason,omitempty"`
+	Boosted         bool    `json:"boosted"`
+	BoostExpiresAt  int64   `json:"boost_expires_at,omitempty"` // Unix milliseconds
+}
+
+// Status is a snapshot of the throttler
+type Status struct {
+	RateHz    float64        `json:"rate_hz"`
+	MaxRateHz float64        `json:"max_rate_hz,omitempty"`
+	Devices   []DeviceStatus `json:"devices"`
 }
 
 // New creates a new Throttler with the specified rate in Hz
@@ -24,6 +86,8 @@ func New(rateHz float64) *Throttler {
 		rateHz:      rateHz,
 		interval:    time.Duration(float64(time.Second) / rateHz),
 		lastPublish: make(map[string]time.Time),
+		stats:       make(map[string]*deviceStats),
+		boosts:      make(map[string]boost),
 	}
 }
 
@@ -34,15 +98,140 @@ func (t *Throttler) ShouldPublish(state *models.DroneState) bool {
 	defer t.mu.Unlock()
 
 	now := time.Now()
-	lastTime, exists := t.lastPublish[state.DeviceID]
+	st := t.deviceStats(state.DeviceID)
+	st.received++
 
-	if !exists || now.Sub(lastTime) >= t.interval {
+	interval, _ := t.intervalFor(state.DeviceID, now)
+	lastTime, exists := t.lastPublish[state.DeviceID]
+	if !exists || now.Sub(lastTime) >= interval {
+		if exists {
+			elapsed := now.Sub(lastTime)
+			if st.avgInterval == 0 {
+				st.avgInterval = elapsed
+			} else {
+				st.avgInterval = (st.avgInterval*4 + elapsed) / 5
+			}
+		}
 		t.lastPublish[state.DeviceID] = now
+		st.published++
 		return true
 	}
+
+	st.dropped++
+	st.lastDrop = now
+	st.lastDropReason = fmt.Sprintf("%s: %s since last publish, interval %s",
+		DropReasonRateLimit, now.Sub(lastTime).Round(time.Millisecond), interval)
 	return false
 }
 
+// deviceStats returns the stats of a device, creating them if needed.
+// Caller must hold the write lock.
+func (t *Throttler) deviceStats(deviceID string) *deviceStats {
+	st, ok := t.stats[deviceID]
+	if !ok {
+		st = &deviceStats{}
+		t.stats[deviceID] = st
+	}
+	return st
+}
+
+// intervalFor returns the publish interval of a device and its active boost,
+// dropping the boost once it has expired. Caller must hold the write lock.
+func (t *Throttler) intervalFor(deviceID string, now time.Time) (time.Duration, *boost) {
+	b, ok := t.boosts[deviceID]
+	if !ok {
+		return t.interval, nil
+	}
+	if !now.Before(b.expires) {
+		delete(t.boosts, deviceID)
+		return t.interval, nil
+	}
+	return b.interval, &b
+}
+
+// SetMaxRate sets the upper bound for boosted rates (0 = unlimited)
+func (t *Throttler) SetMaxRate(rateHz float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.maxRateHz = rateHz
+}
+
+// Boost temporarily publishes a device at rateHz instead of the default
+// rate. A zero duration uses DefaultBoostDuration. Boosting a device again
+// replaces the previous boost. Returns when the boost expires.
+func (t *Throttler) Boost(deviceID string, rateHz float64, d time.Duration) (time.Time, error) {
+	if d == 0 {
+		d = DefaultBoostDuration
+	}
+	if d < 0 || d > MaxBoostDuration {
+		return time.Time{}, fmt.Errorf("%w: must be at most %s", ErrInvalidDuration, MaxBoostDuration)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if rateHz <= 0 || (t.maxRateHz > 0 && rateHz > t.maxRateHz) {
+		return time.Time{}, fmt.Errorf("%w: must be positive and at most %g Hz", ErrInvalidRate, t.maxRateHz)
+	}
+
+	expires := time.Now().Add(d)
+	t.boosts[deviceID] = boost{
+		rateHz:   rateHz,
+		interval: time.Duration(float64(time.Second) / rateHz),
+		expires:  expires,
+	}
+	return expires, nil
+}
+
+// ClearBoost returns a device to the default rate
+func (t *Throttler) ClearBoost(deviceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.boosts, deviceID)
+}
+
+// Status returns the current rates and per-device counters, sorted by device ID
+func (t *Throttler) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	status := Status{
+		RateHz:    t.rateHz,
+		MaxRateHz: t.maxRateHz,
+		Devices:   make([]DeviceStatus, 0, len(t.stats)),
+	}
+	for id, st := range t.stats {
+		ds := DeviceStatus{
+			DeviceID:        id,
+			EffectiveRateHz: t.rateHz,
+			Received:        st.received,
+			Published:       st.published,
+			Dropped:         st.dropped,
+			LastDropReason:  st.lastDropReason,
+		}
+		if _, b := t.intervalFor(id, now); b != nil {
+			ds.EffectiveRateHz = b.rateHz
+			ds.Boosted = true
+			ds.BoostExpiresAt = b.expires.UnixMilli()
+		}
+		if st.avgInterval > 0 {
+			ds.ObservedRateHz = float64(time.Second) / float64(st.avgInterval)
+		}
+		if last, ok := t.lastPublish[id]; ok {
+			ds.LastPublishAt = last.UnixMilli()
+		}
+		if !st.lastDrop.IsZero() {
+			ds.LastDropAt = st.lastDrop.UnixMilli()
+		}
+		status.Devices = append(status.Devices, ds)
+	}
+	sort.Slice(status.Devices, func(i, j int) bool {
+		return status.Devices[i].DeviceID < status.Devices[j].DeviceID
+	})
+	return status
+}
+
 // SetRate updates the throttle rate
 func (t *Throttler) SetRate(rateHz float64) {
 	t.mu.Lock()
@@ -75,3 +264,4 @@ func (t *Throttler) ResetAll() {
 	defer t.mu.Unlock()
 	t.lastPublish = make(map[string]time.Time)
 }
+
@@ -1,10 +1,12 @@
 package throttler
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestThrottler(t *testing.T) {
@@ -117,3 +119,69 @@ func TestThrottlerResetAll(t *testing.T) {
 		t.Error("Device 2 should publish after ResetAll")
 	}
 }
+
+func TestThrottlerBoost(t *testing.T) {
+	throttler := New(1.0)
+	throttler.SetMaxRate(10)
+	state := models.NewDroneState("uav-001", "mavlink")
+
+	if _, err := throttler.Boost("uav-001", 20, time.Minute); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("Boost above max rate error = %v, want ErrInvalidRate", err)
+	}
+	if _, err := throttler.Boost("uav-001", 10, time.Hour); !errors.Is(err, ErrInvalidDuration) {
+		t.Errorf("Boost too long error = %v, want ErrInvalidDuration", err)
+	}
+
+	expires, err := throttler.Boost("uav-001", 10, 0)
+	if err != nil {
+		t.Fatalf("Boost failed: %v", err)
+	}
+	if d := time.Until(expires); d <= 0 || d > DefaultBoostDuration {
+		t.Errorf("Boost expires in %s, want within the default duration", d)
+	}
+
+	throttler.ShouldPublish(state)
+	time.Sleep(110 * time.Millisecond)
+	if !throttler.ShouldPublish(state) {
+		t.Error("Boosted device should publish after the boosted interval")
+	}
+
+	throttler.ClearBoost("uav-001")
+	if throttler.ShouldPublish(state) {
+		t.Error("Device should return to the default rate after ClearBoost")
+	}
+}
+
+func TestThrottlerStatus(t *testing.T) {
+	throttler := New(1.0)
+	state1 := models.NewDroneState("uav-002", "mavlink")
+	state2 := models.NewDroneState("uav-001", "mavlink")
+
+	throttler.ShouldPublish(state1)
+	throttler.ShouldPublish(state1)
+	throttler.ShouldPublish(state1)
+	throttler.ShouldPublish(state2)
+	if _, err := throttler.Boost("uav-001", 5, time.Minute); err != nil {
+		t.Fatalf("Boost failed: %v", err)
+	}
+
+	status := throttler.Status()
+	if status.RateHz != 1.0 || len(status.Devices) != 2 {
+		t.Fatalf("Status = %+v, want rate 1.0 with 2 devices", status)
+	}
+
+	boosted, normal := status.Devices[0], status.Devices[1]
+	if boosted.DeviceID != "uav-001" || !boosted.Boosted || boosted.EffectiveRateHz != 5 || boosted.BoostExpiresAt == 0 {
+		t.Errorf("Boosted device = %+v", boosted)
+	}
+	if normal.Received != 3 || normal.Published != 1 || normal.Dropped != 2 {
+		t.Errorf("Counters = %d/%d/%d, want 3 received, 1 published, 2 dropped",
+			normal.Received, normal.Published, normal.Dropped)
+	}
+	if !strings.HasPrefix(normal.LastDropReason, DropReasonRateLimit) || normal.LastDropAt == 0 {
+		t.Errorf("Last drop = %q at %d, want rate limit", normal.LastDropReason, normal.LastDropAt)
+	}
+	if normal.EffectiveRateHz != 1.0 || normal.Boosted {
+		t.Errorf("Normal device = %+v, want default rate", normal)
+	}
+}
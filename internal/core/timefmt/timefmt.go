@@ -0,0 +1,136 @@
+// Package timefmt provides gateway-wide timezone and timestamp formatting
+package timefmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Named timestamp layouts
+const (
+	FormatRFC3339      = "rfc3339"   // 2006-01-02T15:04:05Z07:00
+	FormatRFC3339Milli = "rfc3339ms" // 2006-01-02T15:04:05.000Z07:00
+	FormatGB28181      = "gb28181"   // 2006-01-02T15:04:05 (no zone, per GB/T 28181)
+	FormatDateTime     = "datetime"  // 2006-01-02 15:04:05
+	FormatUnixMs       = "unix_ms"   // Milliseconds since epoch
+)
+
+var layouts = map[string]string{
+	FormatRFC3339:      time.RFC3339,
+	FormatRFC3339Milli: "2006-01-02T15:04:05.000Z07:00",
+	FormatGB28181:      "2006-01-02T15:04:05",
+	FormatDateTime:     "2006-01-02 15:04:05",
+}
+
+// LoadLocation resolves a timezone name.
+// Empty or "Local" selects the server's local zone; otherwise an IANA name
+// (e.g. "Asia/Shanghai") or "UTC" is expected.
+func LoadLocation(name string) (*time.Location, error) {
+	switch strings.TrimSpace(name) {
+	case "", "Local", "local":
+		return time.Local, nil
+	case "UTC", "utc":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// ValidateFormat checks a format name or Go reference layout. Layouts must
+// include the year ("2006" or "06"), which also catches misspelled names.
+func ValidateFormat(format string) error {
+	if format == "" || format == FormatUnixMs {
+		return nil
+	}
+	if _, ok := layouts[format]; ok {
+		return nil
+	}
+	if !strings.Contains(format, "06") {
+		return fmt.Errorf("unknown time format %q: want rfc3339, rfc3339ms, gb28181, datetime, unix_ms or a Go layout with the year", format)
+	}
+	return nil
+}
+
+// Formatter formats Unix millisecond timestamps in a fixed zone and layout
+type Formatter struct {
+	loc    *time.Location
+	format string
+}
+
+// New creates a formatter. An empty format defaults to RFC 3339.
+// Unknown format names are treated as Go reference layouts.
+func New(loc *time.Location, format string) *Formatter {
+	if loc == nil {
+		loc = time.Local
+	}
+	if format == "" {
+		format = FormatRFC3339
+	}
+	return &Formatter{loc: loc, format: format}
+}
+
+// Parse creates a formatter from a timezone name and format
+func Parse(timezone, format string) (*Formatter, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	return New(loc, format), nil
+}
+
+// Location returns the formatter's timezone
+func (f *Formatter) Location() *time.Location {
+	return f.loc
+}
+
+// FormatName returns the configured format name or layout
+func (f *Formatter) FormatName() string {
+	return f.format
+}
+
+// Format formats a Unix millisecond timestamp
+func (f *Formatter) Format(ms int64) string {
+	if f.format == FormatUnixMs {
+		return strconv.FormatInt(ms, 10)
+	}
+	return f.FormatTime(time.UnixMilli(ms))
+}
+
+// FormatTime formats a time value in the formatter's zone
+func (f *Formatter) FormatTime(t time.Time) string {
+	if f.format == FormatUnixMs {
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	layout, ok := layouts[f.format]
+	if !ok {
+		layout = f.format
+	}
+	return t.In(f.loc).Format(layout)
+}
+
+// WithOverrides returns a formatter with the given timezone and/or format
+// replaced. Empty values keep the current setting.
+func (f *Formatter) WithOverrides(timezone, format string) (*Formatter, error) {
+	loc := f.loc
+	if timezone != "" {
+		var err error
+		loc, err = LoadLocation(timezone)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if format == "" {
+		format = f.format
+	} else if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	return New(loc, format), nil
+}
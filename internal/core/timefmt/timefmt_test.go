@@ -0,0 +1,130 @@
+package timefmt
+
+import (
+	"testing"
+	"time"
+)
+
+// 2024-01-02T03:04:05.678Z
+const testMs int64 = 1704164645678
+
+func TestLoadLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		want *time.Location
+	}{
+		{"", time.Local},
+		{"Local", time.Local},
+		{"UTC", time.UTC},
+	}
+	for _, tt := range tests {
+		loc, err := LoadLocation(tt.name)
+		if err != nil {
+			t.Fatalf("LoadLocation(%q) error: %v", tt.name, err)
+		}
+		if loc != tt.want {
+			t.Errorf("LoadLocation(%q) = %v, want %v", tt.name, loc, tt.want)
+		}
+	}
+
+	if _, err := LoadLocation("Not/AZone"); err == nil {
+		t.Error("LoadLocation should fail for unknown zone")
+	}
+}
+
+func TestFormatter_Format(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+
+	tests := []struct {
+		name   string
+		loc    *time.Location
+		format string
+		want   string
+	}{
+		{"rfc3339 utc", time.UTC, FormatRFC3339, "2024-01-02T03:04:05Z"},
+		{"rfc3339 +8", shanghai, FormatRFC3339, "2024-01-02T11:04:05+08:00"},
+		{"rfc3339ms", time.UTC, FormatRFC3339Milli, "2024-01-02T03:04:05.678Z"},
+		{"gb28181", shanghai, FormatGB28181, "2024-01-02T11:04:05"},
+		{"datetime", shanghai, FormatDateTime, "2024-01-02 11:04:05"},
+		{"unix_ms", shanghai, FormatUnixMs, "1704164645678"},
+		{"custom layout", time.UTC, "02/01/2006 15:04", "02/01/2024 03:04"},
+		{"default", time.UTC, "", "2024-01-02T03:04:05Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.loc, tt.format).Format(testMs)
+			if got != tt.want {
+				t.Errorf("Format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse("UTC", FormatDateTime)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if f.Location() != time.UTC {
+		t.Errorf("Location = %v, want UTC", f.Location())
+	}
+	if f.FormatName() != FormatDateTime {
+		t.Errorf("FormatName = %s, want %s", f.FormatName(), FormatDateTime)
+	}
+
+	if _, err := Parse("Invalid/Zone", ""); err == nil {
+		t.Error("Parse should fail for unknown zone")
+	}
+	if _, err := Parse("UTC", "rfc3339_ms"); err == nil {
+		t.Error("Parse should fail for unknown format")
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{"", FormatRFC3339, FormatUnixMs, FormatGB28181, "02/01/2006 15:04", "060102150405"} {
+		if err := ValidateFormat(format); err != nil {
+			t.Errorf("ValidateFormat(%q) error = %v", format, err)
+		}
+	}
+	for _, format := range []string{"RFC3339", "unixms", "15:04:05", "iso"} {
+		if err := ValidateFormat(format); err == nil {
+			t.Errorf("ValidateFormat(%q) should fail", format)
+		}
+	}
+}
+
+func TestFormatter_WithOverrides(t *testing.T) {
+	base := New(time.UTC, FormatRFC3339)
+
+	// No overrides keeps base settings
+	f, err := base.WithOverrides("", "")
+	if err != nil {
+		t.Fatalf("WithOverrides error: %v", err)
+	}
+	if f.Location() != time.UTC || f.FormatName() != FormatRFC3339 {
+		t.Errorf("WithOverrides(\"\", \"\") = %v/%s, want UTC/%s", f.Location(), f.FormatName(), FormatRFC3339)
+	}
+
+	// Format override only
+	f, err = base.WithOverrides("", FormatUnixMs)
+	if err != nil {
+		t.Fatalf("WithOverrides error: %v", err)
+	}
+	if got := f.Format(testMs); got != "1704164645678" {
+		t.Errorf("Format() = %s, want 1704164645678", got)
+	}
+
+	// Invalid timezone or format
+	if _, err := base.WithOverrides("Bad/Zone", ""); err == nil {
+		t.Error("WithOverrides should fail for unknown zone")
+	}
+	if _, err := base.WithOverrides("", "unixms"); err == nil {
+		t.Error("WithOverrides should fail for unknown format")
+	}
+
+	// Base formatter is unchanged
+	if base.FormatName() != FormatRFC3339 {
+		t.Errorf("base FormatName = %s, want %s", base.FormatName(), FormatRFC3339)
+	}
+}
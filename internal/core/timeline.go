@@ -0,0 +1,28 @@
+package core
+
+import (
+	"log"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/timeline"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Timeline returns the flight event tracker
+func (e *Engine) Timeline() *timeline.Tracker {
+	return e.timeline
+}
+
+// observeTimeline derives the flight events revealed by the state and
+// publishes a FlightEvent event for each
+func (e *Engine) observeTimeline(state *models.DroneState) {
+	for _, ev := range e.timeline.Observe(state) {
+		log.Printf("[Engine] Flight event on %s: %s", ev.DeviceID, ev.Message)
+		flight := ev
+		e.bus.Publish(events.Event{
+			Type:     events.FlightEvent,
+			DeviceID: ev.DeviceID,
+			Flight:   &flight,
+		})
+	}
+}
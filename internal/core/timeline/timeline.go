@@ -0,0 +1,221 @@
+// Package timeline derives discrete flight events (arming, takeoff,
+// landing, flight mode changes, RTL, GPS loss) from the state transitions
+// of each drone and keeps the recent ones, giving operators a timeline
+// instead of raw state diffs.
+package timeline
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// EventType identifies a flight event
+type EventType string
+
+const (
+	EventArmed       EventType = "armed"
+	EventDisarmed    EventType = "disarmed"
+	EventTakeoff     EventType = "takeoff"
+	EventLanding     EventType = "landing"
+	EventModeChange  EventType = "mode_change"
+	EventRTL         EventType = "rtl" // Switched to return-to-launch, by the pilot or a failsafe
+	EventGPSLost     EventType = "gps_lost"
+	EventGPSRestored EventType = "gps_restored"
+)
+
+// Detection thresholds
+const (
+	// TakeoffAltM is the height above home at which an armed drone is
+	// airborne, above ground effect and bounces on the skids
+	TakeoffAltM = 2.0
+	// LandingAltM is the height above home below which a drone has landed
+	LandingAltM = 1.0
+	// MinSatellites is the fewest satellites in view for a usable fix,
+	// when the source reports them
+	MinSatellites = 4
+)
+
+// DefaultMaxEvents is the number of events kept per device when
+// Config.MaxEvents is 0
+const DefaultMaxEvents = 200
+
+// Event is a flight event of a device
+type Event struct {
+	ID        string    `json:"id"`
+	DeviceID  string    `json:"device_id"`
+	Type      EventType `json:"type"`
+	Message   string    `json:"message"`
+	Previous  string    `json:"previous,omitempty"` // Flight mode before a mode change or RTL
+	Current   string    `json:"current,omitempty"`  // Flight mode after it
+	Lat       float64   `json:"lat,omitempty"`      // Last known position (WGS84)
+	Lon       float64   `json:"lon,omitempty"`
+	AltM      float64   `json:"alt_m,omitempty"` // Height above home
+	Timestamp int64     `json:"timestamp"`       // Unix ms of the state revealing it
+}
+
+// Config holds tracker settings
+type Config struct {
+	MaxEvents int // Events kept per device (0 = DefaultMaxEvents)
+}
+
+// device is the last known flight state of a device
+type device struct {
+	armed    bool
+	airborne bool
+	mode     models.FlightMode
+	fix      bool
+	lostFix  bool // A gps_lost event awaits its gps_restored
+	lat, lon float64
+	events   []Event
+}
+
+// Tracker derives and keeps the flight events of each device
+type Tracker struct {
+	maxEvents int
+
+	mu      sync.RWMutex
+	devices map[string]*device
+}
+
+// New creates a tracker
+func New(cfg Config) *Tracker {
+	if cfg.MaxEvents <= 0 {
+		cfg.MaxEvents = DefaultMaxEvents
+	}
+	return &Tracker{
+		maxEvents: cfg.MaxEvents,
+		devices:   make(map[string]*device),
+	}
+}
+
+// hasFix reports whether a state carries a usable position
+func hasFix(state *models.DroneState) bool {
+	if state.Location.Lat == 0 && state.Location.Lon == 0 {
+		return false
+	}
+	sats := state.Status.SatellitesVisible
+	return sats == nil || *sats >= MinSatellites
+}
+
+// Observe updates the tracker from a state and returns the events it
+// revealed. The first state of a device only sets its initial flight
+// state, so a gateway restart does not report drones already flying as
+// taking off.
+func (t *Tracker) Observe(state *models.DroneState) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	armed := state.Status.Armed
+	alt := state.Location.AltBaro
+	fix := hasFix(state)
+	mode := state.Status.FlightMode
+
+	d, ok := t.devices[state.DeviceID]
+	if !ok {
+		d = &device{
+			armed:    armed,
+			airborne: armed && alt >= TakeoffAltM,
+			mode:     mode,
+			fix:      fix,
+		}
+		if fix {
+			d.lat, d.lon = state.Location.Lat, state.Location.Lon
+		}
+		t.devices[state.DeviceID] = d
+		return nil
+	}
+	if fix {
+		d.lat, d.lon = state.Location.Lat, state.Location.Lon
+	}
+
+	var added []Event
+	add := func(typ EventType, msg, previous, current string) {
+		ev := Event{
+			ID:        uuid.New().String(),
+			DeviceID:  state.DeviceID,
+			Type:      typ,
+			Message:   msg,
+			Previous:  previous,
+			Current:   current,
+			Lat:       d.lat,
+			Lon:       d.lon,
+			AltM:      alt,
+			Timestamp: state.Timestamp,
+		}
+		d.events = append(d.events, ev)
+		if len(d.events) > t.maxEvents {
+			d.events = d.events[len(d.events)-t.maxEvents:]
+		}
+		added = append(added, ev)
+	}
+
+	if armed && !d.armed {
+		add(EventArmed, "Motors armed", "", "")
+	}
+	switch {
+	case !d.airborne && armed && alt >= TakeoffAltM:
+		d.airborne = true
+		add(EventTakeoff, "Takeoff detected", "", "")
+	case d.airborne && (!armed || alt < LandingAltM):
+		d.airborne = false
+		add(EventLanding, "Landing detected", "", "")
+	}
+	if !armed && d.armed {
+		add(EventDisarmed, "Motors disarmed", "", "")
+	}
+
+	if mode != d.mode && mode != "" && mode != models.FlightModeUnknown && d.mode != "" {
+		typ, msg := EventModeChange, fmt.Sprintf("Flight mode %s -> %s", d.mode, mode)
+		if mode == models.FlightModeRTL {
+			typ, msg = EventRTL, fmt.Sprintf("Return to launch from %s", d.mode)
+		}
+		add(typ, msg, string(d.mode), string(mode))
+	}
+	if mode != "" && mode != models.FlightModeUnknown {
+		d.mode = mode
+	}
+
+	switch {
+	case d.fix && !fix:
+		d.lostFix = true
+		add(EventGPSLost, "GPS fix lost", "", "")
+	case !d.fix && fix && d.lostFix:
+		d.lostFix = false
+		add(EventGPSRestored, "GPS fix restored", "", "")
+	}
+
+	d.armed = armed
+	d.fix = fix
+	return added
+}
+
+// Events returns the events of a device, newest first. A limit of 0
+// returns all events.
+func (t *Tracker) Events(deviceID string, limit int) []Event {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := []Event{}
+	d, ok := t.devices[deviceID]
+	if !ok {
+		return result
+	}
+	for i := len(d.events) - 1; i >= 0; i-- {
+		result = append(result, d.events[i])
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// Forget drops the flight state and events of a device
+func (t *Tracker) Forget(deviceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.devices, deviceID)
+}
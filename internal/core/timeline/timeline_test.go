@@ -0,0 +1,138 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// state builds a state of drone-001 at a fixed position
+func state(ts int64, armed bool, alt float64, mode models.FlightMode) *models.DroneState {
+	s := models.NewDroneState("drone-001", "mavlink")
+	s.Timestamp = ts
+	s.Location.Lat, s.Location.Lon = 22.5, 114.0
+	s.Location.AltBaro = alt
+	s.Status.Armed = armed
+	s.Status.FlightMode = mode
+	return s
+}
+
+// types returns the types of events
+func types(evs []Event) []EventType {
+	var result []EventType
+	for _, ev := range evs {
+		result = append(result, ev.Type)
+	}
+	return result
+}
+
+func equal(a, b []EventType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTracker_Flight(t *testing.T) {
+	tr := New(Config{})
+
+	steps := []struct {
+		state *models.DroneState
+		want  []EventType
+	}{
+		{state(0, false, 0, models.FlightModeStabilize), nil},
+		{state(1000, true, 0, models.FlightModeStabilize), []EventType{EventArmed}},
+		{state(2000, true, 1.5, models.FlightModeStabilize), nil}, // Not yet clear of the ground
+		{state(3000, true, 5, models.FlightModeAuto), []EventType{EventTakeoff, EventModeChange}},
+		{state(4000, true, 1.5, models.FlightModeAuto), nil}, // Low pass, not landed
+		{state(5000, true, 30, models.FlightModeRTL), []EventType{EventRTL}},
+		{state(6000, true, 30, models.FlightModeUnknown), nil},
+		{state(7000, true, 0.2, models.FlightModeLand), []EventType{EventLanding, EventModeChange}},
+		{state(8000, false, 0.2, models.FlightModeLand), []EventType{EventDisarmed}},
+	}
+	for i, step := range steps {
+		if got := types(tr.Observe(step.state)); !equal(got, step.want) {
+			t.Errorf("step %d: events = %v, want %v", i, got, step.want)
+		}
+	}
+
+	evs := tr.Events("drone-001", 0)
+	if len(evs) != 7 || evs[0].Type != EventDisarmed || evs[6].Type != EventArmed {
+		t.Fatalf("Events() = %v, want 7 newest first", types(evs))
+	}
+	rtl := evs[3]
+	if rtl.Type != EventRTL || rtl.Previous != "AUTO" || rtl.Current != "RTL" || rtl.Timestamp != 5000 || rtl.AltM != 30 {
+		t.Errorf("RTL event = %+v", rtl)
+	}
+	if got := tr.Events("drone-001", 2); len(got) != 2 || got[1].Type != EventModeChange {
+		t.Errorf("Events(limit 2) = %v", types(got))
+	}
+	if got := tr.Events("drone-002", 0); got == nil || len(got) != 0 {
+		t.Errorf("Events(unknown) = %v, want empty", got)
+	}
+}
+
+func TestTracker_FirstStateAirborne(t *testing.T) {
+	tr := New(Config{})
+
+	// A drone first seen in flight has not just taken off, but lands later
+	if evs := tr.Observe(state(0, true, 50, models.FlightModeAuto)); len(evs) != 0 {
+		t.Errorf("first state: events = %v, want none", types(evs))
+	}
+	if got := types(tr.Observe(state(1000, false, 0, models.FlightModeAuto))); !equal(got, []EventType{EventLanding, EventDisarmed}) {
+		t.Errorf("events = %v, want landing and disarmed", got)
+	}
+}
+
+func TestTracker_GPSLoss(t *testing.T) {
+	tr := New(Config{})
+	few, many := 3, 12
+
+	s := state(0, true, 10, models.FlightModeGuided)
+	s.Status.SatellitesVisible = &many
+	tr.Observe(s)
+
+	s = state(1000, true, 10, models.FlightModeGuided)
+	s.Status.SatellitesVisible = &few
+	evs := tr.Observe(s)
+	if !equal(types(evs), []EventType{EventGPSLost}) || evs[0].Lat != 22.5 {
+		t.Errorf("few satellites: events = %+v, want gps_lost at the last fix", evs)
+	}
+
+	s = state(2000, true, 10, models.FlightModeGuided)
+	s.Location.Lat, s.Location.Lon = 0, 0
+	if evs := tr.Observe(s); len(evs) != 0 {
+		t.Errorf("still lost: events = %v, want none", types(evs))
+	}
+
+	// Sources without a satellite count only lose the fix with the position
+	if got := types(tr.Observe(state(3000, true, 10, models.FlightModeGuided))); !equal(got, []EventType{EventGPSRestored}) {
+		t.Errorf("fix back: events = %v, want gps_restored", got)
+	}
+}
+
+func TestTracker_MaxEventsAndForget(t *testing.T) {
+	tr := New(Config{MaxEvents: 3})
+	tr.Observe(state(0, false, 0, models.FlightModeStabilize))
+	for i := int64(1); i <= 4; i++ {
+		tr.Observe(state(i*1000, i%2 == 1, 0, models.FlightModeStabilize))
+	}
+	evs := tr.Events("drone-001", 0)
+	if len(evs) != 3 || evs[0].Timestamp != 4000 || evs[2].Timestamp != 2000 {
+		t.Errorf("Events() = %+v, want the 3 newest", evs)
+	}
+
+	tr.Forget("drone-001")
+	if evs := tr.Events("drone-001", 0); len(evs) != 0 {
+		t.Errorf("after Forget: %d events", len(evs))
+	}
+	// Forgotten devices start over without events
+	if evs := tr.Observe(state(5000, true, 0, models.FlightModeStabilize)); len(evs) != 0 {
+		t.Errorf("after Forget: events = %v, want none", types(evs))
+	}
+}
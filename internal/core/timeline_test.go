@@ -0,0 +1,27 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/events"
+	"github.com/open-uav/telemetry-bridge/internal/core/timeline"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestEngine_FlightEvents(t *testing.T) {
+	e := NewEngine(EngineConfig{RateHz: 100})
+	var flights []events.Event
+	e.Events().Subscribe("test", func(ev events.Event) { flights = append(flights, ev) }, events.FlightEvent)
+
+	for _, armed := range []bool{false, true, true} {
+		state := models.NewDroneState("drone-001", "mavlink")
+		state.Status.Armed = armed
+		e.processState(state)
+	}
+	if len(flights) != 1 || flights[0].Flight == nil || flights[0].Flight.Type != timeline.EventArmed {
+		t.Fatalf("Flight events = %+v, want one armed", flights)
+	}
+	if got := e.Timeline().Events("drone-001", 0); len(got) != 1 {
+		t.Errorf("Events() = %+v, want the armed event", got)
+	}
+}
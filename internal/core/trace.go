@@ -0,0 +1,28 @@
+package core
+
+import (
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/tracing"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// startStateSpan starts the root span of a state's trip through the
+// pipeline. The span begins when the adapter received the message, and the
+// time spent waiting in the engine queue is recorded as a child span.
+// Returns nil when tracing is off or the trace is not sampled.
+func (e *Engine) startStateSpan(state *models.DroneState) *tracing.Span {
+	now := time.Now()
+	received := state.ReceivedAt
+	if received.IsZero() || received.After(now) {
+		received = now
+	}
+
+	span := e.tracer.StartSpan("process "+state.ProtocolSource, tracing.KindConsumer, received)
+	span.SetAttribute("device.id", state.DeviceID)
+	span.SetAttribute("protocol", state.ProtocolSource)
+
+	queued := e.tracer.StartChild(span.Context(), "engine queue", tracing.KindInternal, received)
+	queued.End()
+	return span
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/tracing"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// spanRecorder collects exported spans
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (r *spanRecorder) Export(ctx context.Context, spans []tracing.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestEngine_Tracing(t *testing.T) {
+	rec := &spanRecorder{}
+	tracer := tracing.New(tracing.Config{SampleRatio: 1}, rec)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer.Start(ctx)
+
+	e := NewEngine(EngineConfig{RateHz: 1, Tracer: tracer})
+	e.RegisterPublisher(plainPublisher{})
+	e.RegisterPublisher(failingPublisher{})
+
+	state := models.NewDroneState("uav-1", "mavlink")
+	state.ReceivedAt = time.Now().Add(-50 * time.Millisecond)
+	e.processState(state)
+	tracer.Flush(context.Background())
+
+	spans := make(map[string]tracing.SpanData)
+	for _, s := range rec.spans {
+		spans[s.Name] = s
+	}
+	root, ok := spans["process mavlink"]
+	if !ok || len(rec.spans) != 5 {
+		t.Fatalf("Spans = %+v, want root, queue, 2 publishes and dispatch", rec.spans)
+	}
+	if !root.Start.Equal(state.ReceivedAt) {
+		t.Errorf("Root starts at %v, want adapter receive time %v", root.Start, state.ReceivedAt)
+	}
+	for _, name := range []string{"engine queue", "publish plain", "publish failing", "dispatch events"} {
+		s, ok := spans[name]
+		if !ok {
+			t.Errorf("Missing span %q", name)
+			continue
+		}
+		if s.Context.TraceID != root.Context.TraceID || s.Parent != root.Context.SpanID {
+			t.Errorf("Span %q is not a child of the root span", name)
+		}
+	}
+	if spans["engine queue"].End.Sub(spans["engine queue"].Start) < 50*time.Millisecond {
+		t.Error("Queue span should cover the time since the adapter received the message")
+	}
+	if spans["publish failing"].Error != "broker down" || spans["publish plain"].Error != "" {
+		t.Errorf("Publish errors = %q, %q", spans["publish failing"].Error, spans["publish plain"].Error)
+	}
+}
@@ -0,0 +1,342 @@
+// Package tracing records OpenTelemetry-compatible spans for the telemetry
+// pipeline and exports them in batches, by default as OTLP/HTTP JSON. Trace
+// context travels between pipeline stages as a W3C traceparent string.
+//
+// A nil *Tracer and a nil *Span are valid and record nothing, so callers do
+// not need to check whether tracing is enabled.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for the export pipeline
+const (
+	DefaultQueueSize     = 4096
+	DefaultBatchSize     = 512
+	DefaultFlushInterval = 5 * time.Second
+)
+
+// ErrInvalidTraceparent is returned for a malformed traceparent
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// SpanContext identifies a span and is what propagates to child spans
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// IsValid reports whether the context identifies a span
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats the context as a W3C traceparent header value, or ""
+// for an invalid context
+func (sc SpanContext) Traceparent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-01"
+}
+
+// ParseTraceparent parses a W3C traceparent header value
+func ParseTraceparent(s string) (SpanContext, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// SpanKind describes the relationship of a span to its surroundings
+type SpanKind int
+
+// Span kinds, numbered as in OTLP
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+	KindProducer SpanKind = 4
+	KindConsumer SpanKind = 5
+)
+
+// Attribute is a span attribute. Value is a string, bool, int, int64 or
+// float64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// SpanData is a finished span as handed to the exporter
+type SpanData struct {
+	Context    SpanContext
+	Parent     SpanID // Zero for root spans
+	Name       string
+	Kind       SpanKind
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Error      string // Set when the span failed
+}
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Config holds tracer settings
+type Config struct {
+	SampleRatio   float64       // Fraction of root spans recorded, 0-1
+	QueueSize     int           // Finished spans buffered for export; more are dropped (0 = default)
+	BatchSize     int           // Spans per export request (0 = default)
+	FlushInterval time.Duration // Maximum time a span waits for export (0 = default)
+}
+
+// Stats counts exported and lost spans
+type Stats struct {
+	Exported uint64 `json:"exported"`
+	Dropped  uint64 `json:"dropped"` // Queue full
+	Failed   uint64 `json:"failed"`  // Export errors
+}
+
+// Tracer creates spans and exports them in the background
+type Tracer struct {
+	cfg      Config
+	exporter Exporter
+	queue    chan SpanData
+	flush    chan chan struct{}
+
+	exported atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+
+	startOnce sync.Once
+	done      chan struct{}
+}
+
+// New creates a tracer exporting to the given exporter
+func New(cfg Config, exporter Exporter) *Tracer {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	cfg.SampleRatio = min(max(cfg.SampleRatio, 0), 1)
+	return &Tracer{
+		cfg:      cfg,
+		exporter: exporter,
+		queue:    make(chan SpanData, cfg.QueueSize),
+		flush:    make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start exports finished spans until the context is done
+func (t *Tracer) Start(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.startOnce.Do(func() { go t.run(ctx) })
+}
+
+// Flush exports every queued span and waits until done or the context
+// expires
+func (t *Tracer) Flush(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case t.flush <- ack:
+	case <-t.done:
+		return
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-ack:
+	case <-ctx.Done():
+	}
+}
+
+// Stats returns the export counters
+func (t *Tracer) Stats() Stats {
+	if t == nil {
+		return Stats{}
+	}
+	return Stats{Exported: t.exported.Load(), Dropped: t.dropped.Load(), Failed: t.failed.Load()}
+}
+
+// StartSpan starts a root span, subject to sampling. Returns nil for an
+// unsampled trace.
+func (t *Tracer) StartSpan(name string, kind SpanKind, start time.Time) *Span {
+	if t == nil || t.cfg.SampleRatio == 0 {
+		return nil
+	}
+	var sc SpanContext
+	rand.Read(sc.TraceID[:])
+	rand.Read(sc.SpanID[:])
+	// Sample on the random low half of the trace ID
+	if t.cfg.SampleRatio < 1 && float64(binary.BigEndian.Uint64(sc.TraceID[8:]))/(1<<64) >= t.cfg.SampleRatio {
+		return nil
+	}
+	return &Span{tracer: t, data: SpanData{Context: sc, Name: name, Kind: kind, Start: start}}
+}
+
+// StartChild starts a span within the trace of parent. Returns nil if
+// parent is invalid, i.e. the trace is not sampled.
+func (t *Tracer) StartChild(parent SpanContext, name string, kind SpanKind, start time.Time) *Span {
+	if t == nil || !parent.IsValid() {
+		return nil
+	}
+	sc := SpanContext{TraceID: parent.TraceID}
+	rand.Read(sc.SpanID[:])
+	return &Span{tracer: t, data: SpanData{Context: sc, Parent: parent.SpanID, Name: name, Kind: kind, Start: start}}
+}
+
+// run batches finished spans and exports them
+func (t *Tracer) run(ctx context.Context) {
+	defer close(t.done)
+	ticker := time.NewTicker(t.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, t.cfg.BatchSize)
+	failing := false
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		exportCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := t.exporter.Export(exportCtx, batch)
+		cancel()
+		switch {
+		case err != nil:
+			t.failed.Add(uint64(len(batch)))
+			if !failing {
+				log.Printf("[Tracing] Failed to export %d span(s): %v", len(batch), err)
+			}
+			failing = true
+		default:
+			t.exported.Add(uint64(len(batch)))
+			if failing {
+				log.Printf("[Tracing] Span export recovered")
+			}
+			failing = false
+		}
+		batch = batch[:0]
+	}
+	drain := func() {
+		for {
+			select {
+			case span := <-t.queue:
+				batch = append(batch, span)
+				if len(batch) >= t.cfg.BatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			drain()
+			return
+		case ack := <-t.flush:
+			drain()
+			close(ack)
+		case <-ticker.C:
+			export()
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= t.cfg.BatchSize {
+				export()
+			}
+		}
+	}
+}
+
+// enqueue hands a finished span to the export loop, dropping it if the
+// queue is full
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case t.queue <- data:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+// Span is an operation being timed. Spans are not safe for concurrent use.
+type Span struct {
+	tracer *Tracer
+	data   SpanData
+	ended  bool
+}
+
+// Context returns the span's context for starting children, or the zero
+// context for a nil span
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.Context
+}
+
+// SetAttribute adds an attribute
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.data.Attributes = append(s.data.Attributes, Attribute{Key: key, Value: value})
+}
+
+// RecordError marks the span failed. A nil error is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.data.Error = err.Error()
+}
+
+// End finishes the span now and queues it for export
+func (s *Span) End() {
+	if s == nil || s.ended {
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	s.tracer.enqueue(s.data)
+}
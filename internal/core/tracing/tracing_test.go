@@ -0,0 +1,221 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memExporter collects exported spans
+type memExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+	err   error
+}
+
+func (m *memExporter) Export(ctx context.Context, spans []SpanData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.spans = append(m.spans, spans...)
+	return nil
+}
+
+func TestTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatalf("ParseTraceparent: %v", err)
+	}
+	if got := sc.Traceparent(); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("Traceparent = %s", got)
+	}
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(s); !errors.Is(err, ErrInvalidTraceparent) {
+			t.Errorf("ParseTraceparent(%q) error = %v, want ErrInvalidTraceparent", s, err)
+		}
+	}
+	if (SpanContext{}).Traceparent() != "" {
+		t.Error("Invalid context should format as empty")
+	}
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	if New(Config{SampleRatio: 0}, &memExporter{}).StartSpan("x", KindInternal, time.Now()) != nil {
+		t.Error("Ratio 0 should sample nothing")
+	}
+	all := New(Config{SampleRatio: 1}, &memExporter{})
+	for range 100 {
+		if !all.StartSpan("x", KindInternal, time.Now()).Context().IsValid() {
+			t.Fatal("Ratio 1 should sample everything")
+		}
+	}
+	half := New(Config{SampleRatio: 0.5}, &memExporter{})
+	sampled := 0
+	for range 1000 {
+		if half.StartSpan("x", KindInternal, time.Now()) != nil {
+			sampled++
+		}
+	}
+	if sampled < 400 || sampled > 600 {
+		t.Errorf("Sampled %d of 1000 at ratio 0.5", sampled)
+	}
+}
+
+func TestTracer_NilSafe(t *testing.T) {
+	var tracer *Tracer
+	tracer.Start(context.Background())
+	span := tracer.StartSpan("x", KindInternal, time.Now())
+	span.SetAttribute("k", "v")
+	span.RecordError(errors.New("boom"))
+	span.End()
+	tracer.StartChild(span.Context(), "child", KindInternal, time.Now()).End()
+	tracer.Flush(context.Background())
+	if tracer.Stats() != (Stats{}) {
+		t.Error("Nil tracer should have no stats")
+	}
+}
+
+func TestTracer_Export(t *testing.T) {
+	exp := &memExporter{}
+	tracer := New(Config{SampleRatio: 1, BatchSize: 2}, exp)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer.Start(ctx)
+
+	root := tracer.StartSpan("root", KindConsumer, time.Now())
+	child := tracer.StartChild(root.Context(), "child", KindProducer, time.Now())
+	child.RecordError(errors.New("refused"))
+	child.End()
+	child.End()
+	root.End()
+	tracer.Flush(context.Background())
+
+	if len(exp.spans) != 2 {
+		t.Fatalf("Exported %d spans, want 2", len(exp.spans))
+	}
+	c, r := exp.spans[0], exp.spans[1]
+	if c.Context.TraceID != r.Context.TraceID || c.Parent != r.Context.SpanID || r.Parent != (SpanID{}) {
+		t.Errorf("Child %+v is not a child of root %+v", c.Context, r.Context)
+	}
+	if c.Error != "refused" || r.Error != "" {
+		t.Errorf("Errors = %q, %q", c.Error, r.Error)
+	}
+	if s := tracer.Stats(); s.Exported != 2 || s.Failed != 0 {
+		t.Errorf("Stats = %+v", s)
+	}
+
+	exp.err = errors.New("down")
+	tracer.StartSpan("lost", KindInternal, time.Now()).End()
+	tracer.Flush(context.Background())
+	if s := tracer.Stats(); s.Failed != 1 {
+		t.Errorf("Stats = %+v, want 1 failed", s)
+	}
+}
+
+func TestTracer_QueueFull(t *testing.T) {
+	tracer := New(Config{SampleRatio: 1, QueueSize: 1}, &memExporter{})
+	tracer.StartSpan("a", KindInternal, time.Now()).End()
+	tracer.StartSpan("b", KindInternal, time.Now()).End()
+	if s := tracer.Stats(); s.Dropped != 1 {
+		t.Errorf("Stats = %+v, want 1 dropped", s)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer srv.Close()
+
+	exp, err := NewOTLPExporter(OTLPConfig{
+		Endpoint:    srv.URL + "/",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		ServiceName: "outb",
+	})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter: %v", err)
+	}
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	start := time.UnixMilli(1700000000000)
+	err = exp.Export(context.Background(), []SpanData{{
+		Context:    sc,
+		Parent:     SpanID{1},
+		Name:       "publish mqtt",
+		Kind:       KindProducer,
+		Start:      start,
+		End:        start.Add(time.Millisecond),
+		Attributes: []Attribute{{Key: "device.id", Value: "uav-1"}, {Key: "retry", Value: 2}},
+		Error:      "timeout",
+	}})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	rs := body["resourceSpans"].([]any)[0].(map[string]any)
+	service := rs["resource"].(map[string]any)["attributes"].([]any)[0].(map[string]any)
+	if service["key"] != "service.name" || service["value"].(map[string]any)["stringValue"] != "outb" {
+		t.Errorf("Resource attribute = %v", service)
+	}
+	span := rs["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	want := map[string]any{
+		"traceId":           "4bf92f3577b34da6a3ce929d0e0e4736",
+		"spanId":            "00f067aa0ba902b7",
+		"parentSpanId":      "0100000000000000",
+		"name":              "publish mqtt",
+		"kind":              float64(4),
+		"startTimeUnixNano": "1700000000000000000",
+		"endTimeUnixNano":   "1700000000001000000",
+	}
+	for k, v := range want {
+		if span[k] != v {
+			t.Errorf("Span %s = %v, want %v", k, span[k], v)
+		}
+	}
+	retry := span["attributes"].([]any)[1].(map[string]any)["value"].(map[string]any)
+	if retry["intValue"] != "2" {
+		t.Errorf("Int attribute = %v", retry)
+	}
+	if status := span["status"].(map[string]any); status["code"] != float64(2) || status["message"] != "timeout" {
+		t.Errorf("Status = %v", status)
+	}
+
+	if _, err := NewOTLPExporter(OTLPConfig{Endpoint: "localhost:4318"}); err == nil {
+		t.Error("Endpoint without scheme should be rejected")
+	}
+}
+
+func TestOTLPExporter_CollectorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	exp, _ := NewOTLPExporter(OTLPConfig{Endpoint: srv.URL})
+	if err := exp.Export(context.Background(), nil); err == nil {
+		t.Error("Export should fail on a 429 response")
+	}
+}
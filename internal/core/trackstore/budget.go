@@ -0,0 +1,86 @@
+package trackstore
+
+import (
+	"math"
+	"slices"
+)
+
+// maxDownsampleLevel is the coarsest thinning tried before old points are
+// dropped. Each level doubles the time between kept points.
+const maxDownsampleLevel = 10
+
+// enforceBudget brings the tracks back under the memory budget, leaving a
+// quarter of it free so that it does not run on every recorded point.
+// Points older than DownsampleAfterMs are thinned to the first point in
+// each time bucket, doubling the bucket until the tracks fit. If thinning
+// is not enough, the oldest points across all tracks are dropped. Must be
+// called with s.mu held.
+func (s *Store) enforceBudget(now int64) {
+	target := s.cfg.MaxBytes / 4 * 3
+
+	if s.cfg.DownsampleEvery > 1 && s.cfg.DownsampleAfterMs >= 0 {
+		interval := max(s.cfg.SampleIntervalMs, 1)
+		cutoff := now - s.cfg.DownsampleAfterMs
+		for level := 1; level <= maxDownsampleLevel; level++ {
+			bucket := int64(s.cfg.DownsampleEvery) * interval << (level - 1)
+			for _, rb := range s.tracks {
+				last := int64(-1)
+				rb.Retain(func(p TrackPoint) bool {
+					if p.Timestamp >= cutoff {
+						return true
+					}
+					b := p.Timestamp / bucket
+					if b == last {
+						return false
+					}
+					last = b
+					return true
+				})
+			}
+			s.level = max(s.level, level)
+			if s.recount() <= target {
+				return
+			}
+		}
+	}
+
+	// Still over budget: drop the oldest points of all tracks
+	var timestamps []int64
+	for _, rb := range s.tracks {
+		for _, p := range rb.GetAll() {
+			timestamps = append(timestamps, p.Timestamp)
+		}
+	}
+	cutoff := int64(math.MinInt64) // Only shrink storage to fit
+	if excess := len(timestamps) - int(target/pointSize); excess > 0 {
+		slices.Sort(timestamps)
+		cutoff = timestamps[min(excess, len(timestamps)-1)]
+	}
+	for _, rb := range s.tracks {
+		rb.Retain(func(p TrackPoint) bool { return p.Timestamp >= cutoff })
+	}
+	s.dropEmpty()
+	s.recount()
+}
+
+// recount recomputes the memory held by all tracks. Must be called with
+// s.mu held.
+func (s *Store) recount() int64 {
+	s.bytes = 0
+	for _, rb := range s.tracks {
+		s.bytes += rb.Bytes()
+	}
+	return s.bytes
+}
+
+// dropEmpty forgets devices whose tracks are empty. Must be called with
+// s.mu held.
+func (s *Store) dropEmpty() {
+	for id, rb := range s.tracks {
+		if rb.Size() == 0 {
+			s.bytes -= rb.Bytes()
+			delete(s.tracks, id)
+			delete(s.lastSample, id)
+		}
+	}
+}
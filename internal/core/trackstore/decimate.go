@@ -0,0 +1,137 @@
+package trackstore
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+)
+
+// Decimate reduces a track to at most maxPoints points while keeping its
+// shape. It uses Douglas-Peucker refinement: starting from the first and last
+// point, it repeatedly keeps the point that deviates most from the simplified
+// track until maxPoints points are kept. Deviation is measured in meters and
+// includes altitude. The first and last points are always kept. Points are
+// returned in their original order; tracks that already fit are returned as
+// is.
+func Decimate(points []TrackPoint, maxPoints int) []TrackPoint {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+	if maxPoints == 1 {
+		return []TrackPoint{points[len(points)-1]}
+	}
+
+	xyz := project(points)
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	kept := 2
+
+	h := &segmentHeap{}
+	if s, ok := farthest(xyz, 0, len(points)-1); ok {
+		heap.Push(h, s)
+	}
+	for kept < maxPoints && h.Len() > 0 {
+		s := heap.Pop(h).(segment)
+		keep[s.split] = true
+		kept++
+		if left, ok := farthest(xyz, s.start, s.split); ok {
+			heap.Push(h, left)
+		}
+		if right, ok := farthest(xyz, s.split, s.end); ok {
+			heap.Push(h, right)
+		}
+	}
+
+	result := make([]TrackPoint, 0, kept)
+	for i, k := range keep {
+		if k {
+			result = append(result, points[i])
+		}
+	}
+	return result
+}
+
+// project converts points to local Cartesian coordinates in meters using an
+// equirectangular projection centred on the first point
+func project(points []TrackPoint) [][3]float64 {
+	lat0 := points[0].Lat * math.Pi / 180
+	cosLat0 := math.Cos(lat0)
+
+	xyz := make([][3]float64, len(points))
+	for i, p := range points {
+		xyz[i] = [3]float64{
+			(p.Lon - points[0].Lon) * math.Pi / 180 * cosLat0 * coordinator.EarthRadiusM,
+			(p.Lat - points[0].Lat) * math.Pi / 180 * coordinator.EarthRadiusM,
+			p.Alt - points[0].Alt,
+		}
+	}
+	return xyz
+}
+
+// segment is a span of the track whose interior has not been kept yet.
+// split is the interior point farthest from the line start-end.
+type segment struct {
+	start, end int
+	split      int
+	dist       float64
+}
+
+// farthest returns the interior point of start-end farthest from the line
+// between them. ok is false when the segment has no interior points.
+func farthest(xyz [][3]float64, start, end int) (segment, bool) {
+	if end-start < 2 {
+		return segment{}, false
+	}
+	s := segment{start: start, end: end, split: start + 1, dist: -1}
+	for i := start + 1; i < end; i++ {
+		if d := distToSegment(xyz[i], xyz[start], xyz[end]); d > s.dist {
+			s.split, s.dist = i, d
+		}
+	}
+	return s, true
+}
+
+// distToSegment returns the distance from p to the segment a-b
+func distToSegment(p, a, b [3]float64) float64 {
+	var ab, ap [3]float64
+	var abLen2, dot float64
+	for i := range ab {
+		ab[i] = b[i] - a[i]
+		ap[i] = p[i] - a[i]
+		abLen2 += ab[i] * ab[i]
+		dot += ab[i] * ap[i]
+	}
+
+	t := 0.0
+	if abLen2 > 0 {
+		t = math.Max(0, math.Min(1, dot/abLen2))
+	}
+	var d2 float64
+	for i := range ab {
+		d := ap[i] - t*ab[i]
+		d2 += d * d
+	}
+	return math.Sqrt(d2)
+}
+
+// segmentHeap is a max-heap of segments by deviation
+type segmentHeap []segment
+
+func (h segmentHeap) Len() int { return len(h) }
+func (h segmentHeap) Less(i, j int) bool {
+	if h[i].dist != h[j].dist {
+		return h[i].dist > h[j].dist
+	}
+	return h[i].split < h[j].split
+}
+func (h segmentHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *segmentHeap) Push(x interface{}) { *h = append(*h, x.(segment)) }
+
+func (h *segmentHeap) Pop() interface{} {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
@@ -0,0 +1,139 @@
+package trackstore
+
+import (
+	"math"
+	"testing"
+)
+
+// circleTrack returns a 1 Hz track flying n points around a circle of
+// about 1 km radius while climbing
+func circleTrack(n int) []TrackPoint {
+	points := make([]TrackPoint, n)
+	for i := range points {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = TrackPoint{
+			Timestamp: int64(i) * 1000,
+			Lat:       39.9 + 0.009*math.Sin(a),
+			Lon:       116.4 + 0.0117*math.Cos(a),
+			Alt:       100 + float64(i)*0.01,
+		}
+	}
+	return points
+}
+
+// maxDeviation returns the largest distance in meters from an original
+// point to the decimated track segment spanning its timestamp
+func maxDeviation(original, decimated []TrackPoint) float64 {
+	xyz := project(append(append([]TrackPoint{}, original...), decimated...))
+	orig, dec := xyz[:len(original)], xyz[len(original):]
+
+	worst, seg := 0.0, 0
+	for i, p := range original {
+		for seg < len(decimated)-2 && decimated[seg+1].Timestamp <= p.Timestamp {
+			seg++
+		}
+		if d := distToSegment(orig[i], dec[seg], dec[seg+1]); d > worst {
+			worst = d
+		}
+	}
+	return worst
+}
+
+func TestDecimate_NoOp(t *testing.T) {
+	points := circleTrack(10)
+
+	if got := Decimate(points, 0); len(got) != 10 {
+		t.Errorf("Decimate(max=0) returned %d points, want 10", len(got))
+	}
+	if got := Decimate(points, 10); len(got) != 10 {
+		t.Errorf("Decimate(max=10) returned %d points, want 10", len(got))
+	}
+	if got := Decimate(nil, 5); len(got) != 0 {
+		t.Errorf("Decimate(nil) returned %d points, want 0", len(got))
+	}
+}
+
+func TestDecimate_StraightLine(t *testing.T) {
+	points := make([]TrackPoint, 100)
+	for i := range points {
+		points[i] = TrackPoint{Timestamp: int64(i), Lat: 39.9 + float64(i)*0.0001, Lon: 116.4, Alt: 100}
+	}
+
+	got := Decimate(points, 2)
+	if len(got) != 2 {
+		t.Fatalf("Decimate() returned %d points, want 2", len(got))
+	}
+	if got[0] != points[0] || got[1] != points[99] {
+		t.Errorf("Decimate() = %v, want first and last point", got)
+	}
+
+	if got := Decimate(points, 1); len(got) != 1 || got[0] != points[99] {
+		t.Errorf("Decimate(max=1) = %v, want last point", got)
+	}
+}
+
+func TestDecimate_KeepsCorners(t *testing.T) {
+	// An L-shaped track: north for 50 points, then east for 50
+	var points []TrackPoint
+	for i := 0; i < 50; i++ {
+		points = append(points, TrackPoint{Timestamp: int64(i), Lat: 39.9 + float64(i)*0.0001, Lon: 116.4})
+	}
+	corner := points[49]
+	for i := 1; i <= 50; i++ {
+		points = append(points, TrackPoint{Timestamp: int64(49 + i), Lat: corner.Lat, Lon: 116.4 + float64(i)*0.0001})
+	}
+
+	got := Decimate(points, 3)
+	if len(got) != 3 {
+		t.Fatalf("Decimate() returned %d points, want 3", len(got))
+	}
+	if got[1] != corner {
+		t.Errorf("Decimate() middle point = %+v, want corner %+v", got[1], corner)
+	}
+}
+
+func TestDecimate_AltitudeChange(t *testing.T) {
+	// A vertical climb and descent over one spot
+	points := make([]TrackPoint, 21)
+	for i := range points {
+		alt := float64(i) * 10
+		if i > 10 {
+			alt = float64(20-i) * 10
+		}
+		points[i] = TrackPoint{Timestamp: int64(i), Lat: 39.9, Lon: 116.4, Alt: alt}
+	}
+
+	got := Decimate(points, 3)
+	if len(got) != 3 || got[1].Alt != 100 {
+		t.Errorf("Decimate() = %+v, want the 100 m apex kept", got)
+	}
+}
+
+func TestDecimate_Accuracy(t *testing.T) {
+	// Two hours at 1 Hz
+	points := circleTrack(7200)
+
+	got := Decimate(points, 500)
+	if len(got) != 500 {
+		t.Fatalf("Decimate() returned %d points, want 500", len(got))
+	}
+	if got[0] != points[0] || got[len(got)-1] != points[len(points)-1] {
+		t.Error("Decimate() should keep the first and last point")
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Timestamp <= got[i-1].Timestamp {
+			t.Fatalf("Decimate() points out of order at %d", i)
+		}
+	}
+
+	// A 1 km circle drawn with 500 chords deviates by r(1-cos(π/500)) ≈ 2 cm
+	if dev := maxDeviation(points, got); dev > 0.5 {
+		t.Errorf("max deviation = %.2f m, want <= 0.5 m", dev)
+	}
+
+	// Fewer points trade accuracy, but stay close
+	coarse := Decimate(points, 50)
+	if dev := maxDeviation(points, coarse); dev > 5 {
+		t.Errorf("max deviation (50 points) = %.2f m, want <= 5 m", dev)
+	}
+}
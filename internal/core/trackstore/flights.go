@@ -0,0 +1,207 @@
+package trackstore
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/coordinator"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Flight segmentation modes
+const (
+	SegmentArmed  = "armed"  // From arming to disarming
+	SegmentMotion = "motion" // From moving to standing still, for sources that don't report arming
+)
+
+const (
+	// minFlightSpeed is the ground speed in m/s above which a drone that
+	// doesn't report arming counts as flying
+	minFlightSpeed = 1.0
+
+	// armedGapMs ends an armed flight when no state arrived for this long
+	armedGapMs = 5 * 60 * 1000
+)
+
+// Flight is one flight of a drone and its statistics
+type Flight struct {
+	ID           string  `json:"id"` // Device ID and start time
+	DeviceID     string  `json:"device_id"`
+	Segmentation string  `json:"segmentation"` // armed | motion
+	StartTime    int64   `json:"start_time"`   // Unix ms
+	EndTime      int64   `json:"end_time"`     // Unix ms, the latest state while in progress
+	InProgress   bool    `json:"in_progress"`
+	DurationSec  float64 `json:"duration_sec"`
+	DistanceM    float64 `json:"distance_m"`    // Horizontal distance flown
+	MaxAltM      float64 `json:"max_alt_m"`     // Highest GNSS altitude
+	MaxSpeedMs   float64 `json:"max_speed_ms"`  // Highest ground speed
+	BatteryStart int     `json:"battery_start"` // Percent
+	BatteryEnd   int     `json:"battery_end"`   // Percent
+	BatteryUsed  int     `json:"battery_used"`  // Percentage points, 0 if unknown or the battery was swapped
+}
+
+// flightLog segments the states of one device into flights
+type flightLog struct {
+	armAware   bool // The device has reported being armed
+	current    *Flight
+	lastTime   int64 // Timestamp of the previous state
+	stillSince int64 // When a motion flight dropped below minFlightSpeed, 0 while moving
+	lastLat    float64
+	lastLon    float64
+	hasFix     bool
+	flights    []Flight // Completed flights, oldest first
+}
+
+// observe updates the flights of a device with a state at time t
+func (l *flightLog) observe(state *models.DroneState, t int64, idleMs int64, maxFlights int) {
+	// A long silence ends the flight at the last state
+	if l.current != nil {
+		gap := int64(armedGapMs)
+		if l.current.Segmentation == SegmentMotion {
+			gap = idleMs
+		}
+		if t-l.lastTime > gap {
+			l.finish(l.endTime(l.lastTime), maxFlights)
+		}
+	}
+
+	if state.Status.Armed {
+		l.armAware = true
+	}
+	speed := math.Hypot(state.Velocity.Vx, state.Velocity.Vy)
+
+	var flying bool
+	switch {
+	case l.armAware:
+		flying = state.Status.Armed
+	case speed >= minFlightSpeed:
+		flying = true
+		l.stillSince = 0
+	case l.current != nil:
+		if l.stillSince == 0 {
+			l.stillSince = t
+		}
+		flying = t-l.stillSince < idleMs
+	}
+
+	if flying && l.current == nil {
+		mode := SegmentMotion
+		if l.armAware {
+			mode = SegmentArmed
+		}
+		l.current = &Flight{
+			ID:           fmt.Sprintf("%s-%d", state.DeviceID, t),
+			DeviceID:     state.DeviceID,
+			Segmentation: mode,
+			StartTime:    t,
+			MaxAltM:      state.Location.AltGNSS,
+			BatteryStart: state.Status.BatteryPercent,
+		}
+		l.hasFix = false
+		l.stillSince = 0
+	}
+
+	fix := state.Location.Lat != 0 || state.Location.Lon != 0
+	if l.current != nil {
+		f := l.current
+		if flying {
+			if fix && l.hasFix {
+				f.DistanceM += coordinator.HaversineDistance(l.lastLat, l.lastLon, state.Location.Lat, state.Location.Lon)
+			}
+			f.MaxAltM = math.Max(f.MaxAltM, state.Location.AltGNSS)
+			f.MaxSpeedMs = math.Max(f.MaxSpeedMs, speed)
+			f.BatteryEnd = state.Status.BatteryPercent
+			f.EndTime = t
+		} else {
+			l.finish(l.endTime(t), maxFlights)
+		}
+	}
+
+	if fix {
+		l.lastLat, l.lastLon, l.hasFix = state.Location.Lat, state.Location.Lon, true
+	}
+	l.lastTime = t
+}
+
+// endTime returns when the current flight ended: when a motion flight came
+// to a standstill, otherwise at the given time
+func (l *flightLog) endTime(t int64) int64 {
+	if l.current.Segmentation == SegmentMotion && l.stillSince > 0 {
+		return l.stillSince
+	}
+	return t
+}
+
+// finish completes the current flight at the given time
+func (l *flightLog) finish(end int64, maxFlights int) {
+	f := *l.current
+	l.current = nil
+	l.stillSince = 0
+
+	f.EndTime = end
+	f.InProgress = false
+	f.summarize()
+
+	l.flights = append(l.flights, f)
+	if maxFlights > 0 && len(l.flights) > maxFlights {
+		l.flights = append([]Flight(nil), l.flights[len(l.flights)-maxFlights:]...)
+	}
+}
+
+// summarize fills in the fields derived from the start and end
+func (f *Flight) summarize() {
+	f.DurationSec = float64(f.EndTime-f.StartTime) / 1000
+	f.BatteryUsed = 0
+	if f.BatteryStart > 0 && f.BatteryEnd > 0 && f.BatteryStart > f.BatteryEnd {
+		f.BatteryUsed = f.BatteryStart - f.BatteryEnd
+	}
+}
+
+// snapshot returns the completed flights and the current one, oldest first.
+// A current flight whose device went silent for longer than the gap is
+// reported as ended.
+func (l *flightLog) snapshot(now int64, idleMs int64) []Flight {
+	result := append([]Flight(nil), l.flights...)
+	if l.current != nil {
+		f := *l.current
+		gap := int64(armedGapMs)
+		if f.Segmentation == SegmentMotion {
+			gap = idleMs
+		}
+		f.InProgress = now-l.lastTime <= gap
+		f.summarize()
+		result = append(result, f)
+	}
+	return result
+}
+
+// observeFlight feeds a state into its device's flight segmentation
+func (s *Store) observeFlight(state *models.DroneState, now int64) {
+	log, ok := s.flights[state.DeviceID]
+	if !ok {
+		log = &flightLog{}
+		s.flights[state.DeviceID] = log
+	}
+	log.observe(state, now, s.flightIdleMs(), s.cfg.MaxFlightsPerDrone)
+}
+
+// GetFlights returns the flights of a device, oldest first, including the
+// one in progress
+func (s *Store) GetFlights(deviceID string) []Flight {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	log, ok := s.flights[deviceID]
+	if !ok {
+		return []Flight{}
+	}
+	return log.snapshot(s.now().UnixMilli(), s.flightIdleMs())
+}
+
+// flightIdleMs returns the configured idle time or the default
+func (s *Store) flightIdleMs() int64 {
+	if s.cfg.FlightIdleMs > 0 {
+		return s.cfg.FlightIdleMs
+	}
+	return DefaultConfig().FlightIdleMs
+}
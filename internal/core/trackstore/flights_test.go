@@ -0,0 +1,104 @@
+package trackstore
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func flightState(deviceID string, ts int64, lat, alt, vx float64, armed bool, battery int) *models.DroneState {
+	state := models.NewDroneState(deviceID, "test")
+	state.Timestamp = ts
+	state.Location.Lat = lat
+	state.Location.Lon = 113.9
+	state.Location.AltGNSS = alt
+	state.Velocity.Vx = vx
+	state.Status.Armed = armed
+	state.Status.BatteryPercent = battery
+	return state
+}
+
+func TestStore_FlightsArmed(t *testing.T) {
+	s := New(DefaultConfig())
+	s.now = func() time.Time { return time.UnixMilli(130000) }
+
+	s.Record(flightState("drone-1", 1000, 22.500, 10, 0, false, 100))
+	s.Record(flightState("drone-1", 2000, 22.500, 10, 0, true, 98))
+	s.Record(flightState("drone-1", 62000, 22.501, 80, 12, true, 90))
+	s.Record(flightState("drone-1", 122000, 22.502, 40, 5, true, 75))
+
+	flights := s.GetFlights("drone-1")
+	if len(flights) != 1 || !flights[0].InProgress {
+		t.Fatalf("Flights = %+v, want one in progress", flights)
+	}
+
+	s.Record(flightState("drone-1", 182000, 22.502, 10, 0, false, 74))
+	s.Record(flightState("drone-1", 190000, 22.502, 10, 0, false, 74))
+	s.now = func() time.Time { return time.UnixMilli(200000) }
+
+	flights = s.GetFlights("drone-1")
+	if len(flights) != 1 {
+		t.Fatalf("Got %d flights, want 1", len(flights))
+	}
+	f := flights[0]
+	if f.Segmentation != SegmentArmed || f.InProgress || f.StartTime != 2000 || f.EndTime != 182000 || f.DurationSec != 180 {
+		t.Errorf("Flight = %+v", f)
+	}
+	if math.Abs(f.DistanceM-222.4) > 1 || f.MaxAltM != 80 || f.MaxSpeedMs != 12 {
+		t.Errorf("Distance %.1f, max alt %.0f, max speed %.0f", f.DistanceM, f.MaxAltM, f.MaxSpeedMs)
+	}
+	if f.BatteryStart != 98 || f.BatteryEnd != 75 || f.BatteryUsed != 23 {
+		t.Errorf("Battery %d -> %d, used %d", f.BatteryStart, f.BatteryEnd, f.BatteryUsed)
+	}
+}
+
+func TestStore_FlightsMotion(t *testing.T) {
+	s := New(Config{MaxPointsPerDrone: 100, FlightIdleMs: 10000})
+
+	// A source that never reports arming: moving, then standing still
+	s.Record(flightState("poll-1", 0, 22.5, 0, 0, false, 0))
+	s.Record(flightState("poll-1", 5000, 22.5, 50, 8, false, 0))
+	s.Record(flightState("poll-1", 15000, 22.5, 60, 8, false, 0))
+	s.Record(flightState("poll-1", 20000, 22.5, 0, 0.2, false, 0))
+	s.Record(flightState("poll-1", 25000, 22.5, 0, 0, false, 0))
+	if flights := s.GetFlights("poll-1"); len(flights) != 1 || flights[0].EndTime != 25000 {
+		t.Fatalf("Still within the idle time: %+v", flights)
+	}
+	s.Record(flightState("poll-1", 31000, 22.5, 0, 0, false, 0))
+
+	// Second flight ended by a silence longer than the idle time
+	s.Record(flightState("poll-1", 40000, 22.5, 30, 6, false, 0))
+	s.Record(flightState("poll-1", 45000, 22.5, 30, 6, false, 0))
+	s.Record(flightState("poll-1", 90000, 22.5, 0, 0, false, 0))
+
+	flights := s.GetFlights("poll-1")
+	if len(flights) != 2 {
+		t.Fatalf("Got %d flights, want 2: %+v", len(flights), flights)
+	}
+	if f := flights[0]; f.Segmentation != SegmentMotion || f.StartTime != 5000 || f.EndTime != 20000 || f.MaxAltM != 60 || f.BatteryUsed != 0 {
+		t.Errorf("First flight = %+v", f)
+	}
+	if f := flights[1]; f.StartTime != 40000 || f.EndTime != 45000 || f.InProgress {
+		t.Errorf("Second flight = %+v", f)
+	}
+}
+
+func TestStore_FlightsLimitAndClear(t *testing.T) {
+	s := New(Config{MaxPointsPerDrone: 100, MaxFlightsPerDrone: 2})
+
+	for i := int64(0); i < 3; i++ {
+		s.Record(flightState("drone-1", i*10000, 22.5, 10, 0, true, 90))
+		s.Record(flightState("drone-1", i*10000+5000, 22.5, 10, 0, false, 90))
+	}
+	flights := s.GetFlights("drone-1")
+	if len(flights) != 2 || flights[0].StartTime != 10000 {
+		t.Errorf("Flights = %+v, want the last two", flights)
+	}
+
+	s.ClearTrack("drone-1")
+	if flights := s.GetFlights("drone-1"); len(flights) != 0 {
+		t.Errorf("ClearTrack should drop flights, got %d", len(flights))
+	}
+}
@@ -3,6 +3,7 @@ package trackstore
 
 import (
 	"sync"
+	"unsafe"
 )
 
 // TrackPoint represents a single point in a drone's trajectory
@@ -17,7 +18,14 @@ type TrackPoint struct {
 	Speed     float64 `json:"speed"`
 }
 
-// RingBuffer is a generic circular buffer
+// pointSize is the memory one stored TrackPoint takes
+const pointSize = int64(unsafe.Sizeof(TrackPoint{}))
+
+// minGrowth is the smallest number of slots a buffer grows by
+const minGrowth = 64
+
+// RingBuffer is a generic circular buffer. Its storage grows on demand up
+// to the capacity, so short tracks don't hold a full buffer.
 type RingBuffer struct {
 	data  []TrackPoint
 	head  int // Next write position
@@ -29,8 +37,7 @@ type RingBuffer struct {
 // NewRingBuffer creates a new ring buffer with the specified capacity
 func NewRingBuffer(capacity int) *RingBuffer {
 	return &RingBuffer{
-		data: make([]TrackPoint, capacity),
-		cap:  capacity,
+		cap: capacity,
 	}
 }
 
@@ -39,35 +46,45 @@ func (rb *RingBuffer) Push(point TrackPoint) {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
 
+	if rb.size == len(rb.data) && len(rb.data) < rb.cap {
+		rb.resize(min(rb.cap, len(rb.data)+max(minGrowth, len(rb.data)/4)))
+	}
+
 	rb.data[rb.head] = point
-	rb.head = (rb.head + 1) % rb.cap
+	rb.head = (rb.head + 1) % len(rb.data)
 
-	if rb.size < rb.cap {
+	if rb.size < len(rb.data) {
 		rb.size++
 	}
 }
 
+// resize moves the points into new storage of n slots in chronological
+// order. Must be called with rb.mu held.
+func (rb *RingBuffer) resize(n int) {
+	data := make([]TrackPoint, n)
+	rb.copyTo(data)
+	rb.data = data
+	rb.head = rb.size % max(n, 1)
+}
+
+// copyTo copies the points to dst in chronological order. Must be called
+// with rb.mu held.
+func (rb *RingBuffer) copyTo(dst []TrackPoint) {
+	if rb.size == 0 {
+		return
+	}
+	start := rb.oldest()
+	n := copy(dst, rb.data[start:min(start+rb.size, len(rb.data))])
+	copy(dst[n:rb.size], rb.data)
+}
+
 // GetAll returns all points in chronological order
 func (rb *RingBuffer) GetAll() []TrackPoint {
 	rb.mu.RLock()
 	defer rb.mu.RUnlock()
 
 	result := make([]TrackPoint, rb.size)
-	if rb.size == 0 {
-		return result
-	}
-
-	// Calculate start position (oldest element)
-	start := 0
-	if rb.size == rb.cap {
-		start = rb.head // Buffer is full, oldest is at head
-	}
-
-	for i := 0; i < rb.size; i++ {
-		idx := (start + i) % rb.cap
-		result[i] = rb.data[idx]
-	}
-
+	rb.copyTo(result)
 	return result
 }
 
@@ -86,10 +103,10 @@ func (rb *RingBuffer) GetLast(n int) []TrackPoint {
 	result := make([]TrackPoint, n)
 
 	// Calculate start position for the last n elements
-	start := (rb.head - n + rb.cap) % rb.cap
+	start := (rb.head - n + len(rb.data)) % len(rb.data)
 
 	for i := 0; i < n; i++ {
-		idx := (start + i) % rb.cap
+		idx := (start + i) % len(rb.data)
 		result[i] = rb.data[idx]
 	}
 
@@ -107,14 +124,10 @@ func (rb *RingBuffer) GetSince(timestamp int64) []TrackPoint {
 
 	var result []TrackPoint
 
-	// Calculate start position (oldest element)
-	start := 0
-	if rb.size == rb.cap {
-		start = rb.head
-	}
+	start := rb.oldest()
 
 	for i := 0; i < rb.size; i++ {
-		idx := (start + i) % rb.cap
+		idx := (start + i) % len(rb.data)
 		if rb.data[idx].Timestamp >= timestamp {
 			result = append(result, rb.data[idx])
 		}
@@ -123,6 +136,54 @@ func (rb *RingBuffer) GetSince(timestamp int64) []TrackPoint {
 	return result
 }
 
+// DropBefore removes points older than the given timestamp.
+// Returns the number of points removed.
+func (rb *RingBuffer) DropBefore(timestamp int64) int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if rb.size == 0 {
+		return 0
+	}
+	dropped := 0
+	start := rb.oldest()
+	for dropped < rb.size && rb.data[(start+dropped)%len(rb.data)].Timestamp < timestamp {
+		dropped++
+	}
+	rb.size -= dropped
+	return dropped
+}
+
+// Retain keeps the points for which keep returns true, visiting them in
+// chronological order, and shrinks the storage to fit.
+// Returns the number of points removed.
+func (rb *RingBuffer) Retain(keep func(p TrackPoint) bool) int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	all := make([]TrackPoint, rb.size)
+	rb.copyTo(all)
+	kept := all[:0]
+	for _, p := range all {
+		if keep(p) {
+			kept = append(kept, p)
+		}
+	}
+	removed := rb.size - len(kept)
+	if len(kept) != len(rb.data) {
+		rb.data = append([]TrackPoint(nil), kept...)
+		rb.size = len(kept)
+		rb.head = 0
+	}
+	return removed
+}
+
+// oldest returns the index of the oldest element. Must be called with rb.mu
+// held and a non-empty buffer.
+func (rb *RingBuffer) oldest() int {
+	return (rb.head - rb.size + len(rb.data)) % len(rb.data)
+}
+
 // Size returns the current number of elements
 func (rb *RingBuffer) Size() int {
 	rb.mu.RLock()
@@ -134,6 +195,14 @@ func (rb *RingBuffer) Size() int {
 func (rb *RingBuffer) Clear() {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
+	rb.data = nil
 	rb.head = 0
 	rb.size = 0
 }
+
+// Bytes returns the memory held by the buffer's storage
+func (rb *RingBuffer) Bytes() int64 {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return int64(len(rb.data)) * pointSize
+}
@@ -2,31 +2,61 @@ package trackstore
 
 import (
 	"math"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // Config holds configuration for the track store
 type Config struct {
 	MaxPointsPerDrone  int   // Maximum points to store per drone
 	SampleIntervalMs   int64 // Minimum interval between samples in milliseconds
+
+	MaxFlightsPerDrone int   // Completed flights kept per drone (0 = unlimited)
+	FlightIdleMs       int64 // Standing still this long ends a motion-segmented flight
+
+	// Memory budget shared by all tracks (0 = unlimited). Beyond it, points
+	// older than DownsampleAfterMs are thinned to about one in every
+	// DownsampleEvery samples, then more coarsely, and as a last resort the
+	// oldest points of all tracks are dropped.
+	MaxBytes          int64
+	DownsampleAfterMs int64
+	DownsampleEvery   int
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() Config {
 	return Config{
-		MaxPointsPerDrone: 10000,
-		SampleIntervalMs:  1000, // 1 second
+		MaxPointsPerDrone:  10000,
+		SampleIntervalMs:   1000, // 1 second
+		MaxFlightsPerDrone: 100,
+		FlightIdleMs:       30000,
+		MaxBytes:           256 << 20,
+		DownsampleAfterMs:  600000, // 10 minutes
+		DownsampleEvery:    10,
 	}
 }
 
+// Stats describes the memory used by the track store
+type Stats struct {
+	Devices         int   `json:"devices"`
+	Points          int   `json:"points"`
+	MemoryBytes     int64 `json:"memory_bytes"`
+	MaxBytes        int64 `json:"max_bytes,omitempty"`        // 0 = unlimited
+	DownsampleLevel int   `json:"downsample_level,omitempty"` // Coarsest thinning applied so far (0 = none)
+}
+
 // Store manages trajectory data for multiple drones
 type Store struct {
 	tracks     map[string]*RingBuffer
 	lastSample map[string]int64 // Last sample timestamp per device
+	flights    map[string]*flightLog
+	bytes      int64 // Memory held by all ring buffers
+	level      int   // Coarsest downsampling level applied so far
 	cfg        Config
+	now        func() time.Time
 	mu         sync.RWMutex
 }
 
@@ -35,7 +65,9 @@ func New(cfg Config) *Store {
 	return &Store{
 		tracks:     make(map[string]*RingBuffer),
 		lastSample: make(map[string]int64),
+		flights:    make(map[string]*flightLog),
 		cfg:        cfg,
+		now:        time.Now,
 	}
 }
 
@@ -52,6 +84,9 @@ func (s *Store) Record(state *models.DroneState) bool {
 		now = time.Now().UnixMilli()
 	}
 
+	// Flights see every state, not just the sampled ones
+	s.observeFlight(state, now)
+
 	if now-lastTime < s.cfg.SampleIntervalMs {
 		return false // Skip this sample
 	}
@@ -88,9 +123,15 @@ func (s *Store) Record(state *models.DroneState) bool {
 		point.LonGCJ02 = *state.Location.LonGCJ02
 	}
 
+	before := rb.Bytes()
 	rb.Push(point)
+	s.bytes += rb.Bytes() - before
 	s.lastSample[state.DeviceID] = now
 
+	if s.cfg.MaxBytes > 0 && s.bytes > s.cfg.MaxBytes {
+		s.enforceBudget(now)
+	}
+
 	return true
 }
 
@@ -125,9 +166,83 @@ func (s *Store) ClearTrack(deviceID string) {
 	defer s.mu.Unlock()
 
 	if rb, exists := s.tracks[deviceID]; exists {
+		s.bytes -= rb.Bytes()
 		rb.Clear()
 	}
 	delete(s.lastSample, deviceID)
+	delete(s.flights, deviceID)
+}
+
+// Prune removes points older than before from all tracks and forgets
+// devices whose tracks become empty. Returns the number of points removed.
+func (s *Store) Prune(before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := before.UnixMilli()
+	removed := 0
+	for _, rb := range s.tracks {
+		removed += rb.DropBefore(cutoff)
+	}
+	s.dropEmpty()
+	for _, log := range s.flights {
+		kept := log.flights[:0]
+		for _, f := range log.flights {
+			if f.EndTime >= cutoff {
+				kept = append(kept, f)
+			}
+		}
+		log.flights = kept
+	}
+	return removed
+}
+
+// Import merges points restored from a backup into a device's track in
+// timestamp order. Points at an already stored timestamp are skipped, and
+// the oldest points are dropped if the track exceeds its capacity.
+// Returns the number of points added.
+func (s *Store) Import(deviceID string, points []TrackPoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var existing []TrackPoint
+	if rb, ok := s.tracks[deviceID]; ok {
+		existing = rb.GetAll()
+	}
+	seen := make(map[int64]bool, len(existing))
+	for _, p := range existing {
+		seen[p.Timestamp] = true
+	}
+
+	merged := append([]TrackPoint(nil), existing...)
+	for _, p := range points {
+		if !seen[p.Timestamp] {
+			seen[p.Timestamp] = true
+			merged = append(merged, p)
+		}
+	}
+	added := len(merged) - len(existing)
+	if added == 0 {
+		return 0
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp < merged[j].Timestamp })
+
+	if old, ok := s.tracks[deviceID]; ok {
+		s.bytes -= old.Bytes()
+	}
+	rb := NewRingBuffer(s.cfg.MaxPointsPerDrone)
+	for _, p := range merged {
+		rb.Push(p)
+	}
+	s.tracks[deviceID] = rb
+	s.bytes += rb.Bytes()
+	if last := merged[len(merged)-1].Timestamp; last > s.lastSample[deviceID] {
+		s.lastSample[deviceID] = last
+	}
+	if s.cfg.MaxBytes > 0 && s.bytes > s.cfg.MaxBytes {
+		s.enforceBudget(s.now().UnixMilli())
+	}
+	return added
 }
 
 // GetTrackSize returns the number of points stored for a device
@@ -164,3 +279,20 @@ func (s *Store) GetTotalPoints() int {
 	}
 	return total
 }
+
+// GetStats returns the memory used by all tracks
+func (s *Store) GetStats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := Stats{
+		Devices:         len(s.tracks),
+		MemoryBytes:     s.bytes,
+		MaxBytes:        s.cfg.MaxBytes,
+		DownsampleLevel: s.level,
+	}
+	for _, rb := range s.tracks {
+		stats.Points += rb.Size()
+	}
+	return stats
+}
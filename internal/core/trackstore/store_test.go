@@ -2,8 +2,9 @@ package trackstore
 
 import (
 	"testing"
+	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestRingBuffer_Push(t *testing.T) {
@@ -107,6 +108,27 @@ func TestRingBuffer_Clear(t *testing.T) {
 	}
 }
 
+func TestRingBuffer_DropBefore(t *testing.T) {
+	rb := NewRingBuffer(3)
+	for i := int64(1); i <= 4; i++ {
+		rb.Push(TrackPoint{Timestamp: i})
+	}
+
+	// Buffer holds 2,3,4 with the oldest in the middle of the array
+	if dropped := rb.DropBefore(4); dropped != 2 {
+		t.Errorf("DropBefore() = %d, want 2", dropped)
+	}
+	points := rb.GetAll()
+	if len(points) != 1 || points[0].Timestamp != 4 {
+		t.Errorf("GetAll() after DropBefore = %+v, want [4]", points)
+	}
+
+	rb.Push(TrackPoint{Timestamp: 5})
+	if last := rb.GetLast(2); len(last) != 2 || last[0].Timestamp != 4 || last[1].Timestamp != 5 {
+		t.Errorf("GetLast(2) = %+v, want [4 5]", last)
+	}
+}
+
 func TestStore_Record(t *testing.T) {
 	cfg := Config{
 		MaxPointsPerDrone: 100,
@@ -215,6 +237,43 @@ func TestStore_ClearTrack(t *testing.T) {
 	}
 }
 
+func TestStore_Prune(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SampleIntervalMs = 0
+	store := New(cfg)
+
+	store.Record(&models.DroneState{DeviceID: "old", Timestamp: 1000})
+	store.Record(&models.DroneState{DeviceID: "mixed", Timestamp: 1000})
+	store.Record(&models.DroneState{DeviceID: "mixed", Timestamp: 5000})
+
+	if removed := store.Prune(time.UnixMilli(2000)); removed != 2 {
+		t.Errorf("Prune() = %d, want 2", removed)
+	}
+	if store.GetTrackSize("mixed") != 1 {
+		t.Errorf("GetTrackSize(mixed) = %d, want 1", store.GetTrackSize("mixed"))
+	}
+	if len(store.GetDeviceIDs()) != 1 {
+		t.Error("Devices with empty tracks should be forgotten")
+	}
+}
+
+func TestStore_Import(t *testing.T) {
+	store := New(Config{MaxPointsPerDrone: 3, SampleIntervalMs: 0})
+	store.Record(&models.DroneState{DeviceID: "drone-1", Timestamp: 5000})
+
+	added := store.Import("drone-1", []TrackPoint{{Timestamp: 3000}, {Timestamp: 5000}, {Timestamp: 1000}, {Timestamp: 4000}})
+	if added != 3 {
+		t.Errorf("Import() = %d, want 3 (5000 is already stored)", added)
+	}
+	track := store.GetTrack("drone-1", 0, 0)
+	if len(track) != 3 || track[0].Timestamp != 3000 || track[2].Timestamp != 5000 {
+		t.Errorf("Track after import = %+v, want the newest 3 points in order", track)
+	}
+	if store.Record(&models.DroneState{DeviceID: "drone-1", Timestamp: 4500}) {
+		t.Error("Record() older than the imported points should be skipped")
+	}
+}
+
 func TestStore_SpeedCalculation(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.SampleIntervalMs = 0
@@ -242,3 +301,105 @@ func TestStore_SpeedCalculation(t *testing.T) {
 		t.Errorf("Speed = %f, want %f", points[0].Speed, expectedSpeed)
 	}
 }
+
+func TestRingBuffer_Grow(t *testing.T) {
+	rb := NewRingBuffer(100)
+	if rb.Bytes() != 0 {
+		t.Errorf("Bytes() = %d, want 0 before the first point", rb.Bytes())
+	}
+
+	for i := 1; i <= 250; i++ {
+		rb.Push(TrackPoint{Timestamp: int64(i)})
+		if i == 10 && rb.Bytes() >= 100*pointSize {
+			t.Errorf("Bytes() = %d after 10 points, want less than the full capacity", rb.Bytes())
+		}
+	}
+	if rb.Bytes() != 100*pointSize {
+		t.Errorf("Bytes() = %d, want %d at capacity", rb.Bytes(), 100*pointSize)
+	}
+	points := rb.GetAll()
+	if len(points) != 100 || points[0].Timestamp != 151 || points[99].Timestamp != 250 {
+		t.Errorf("GetAll() = %d points from %d, want 100 from 151", len(points), points[0].Timestamp)
+	}
+}
+
+func TestRingBuffer_Retain(t *testing.T) {
+	rb := NewRingBuffer(5)
+	for i := 1; i <= 7; i++ {
+		rb.Push(TrackPoint{Timestamp: int64(i)})
+	}
+
+	if removed := rb.Retain(func(p TrackPoint) bool { return p.Timestamp%2 == 1 }); removed != 2 {
+		t.Errorf("Retain() = %d, want 2", removed)
+	}
+	points := rb.GetAll()
+	if len(points) != 3 || points[0].Timestamp != 3 || points[2].Timestamp != 7 {
+		t.Errorf("GetAll() = %+v, want 3, 5, 7", points)
+	}
+	if rb.Bytes() != 3*pointSize {
+		t.Errorf("Bytes() = %d, want storage shrunk to 3 points", rb.Bytes())
+	}
+
+	rb.Push(TrackPoint{Timestamp: 8})
+	if last := rb.GetLast(1); last[0].Timestamp != 8 || rb.Size() != 4 {
+		t.Errorf("Push() after Retain: last = %d, size = %d", last[0].Timestamp, rb.Size())
+	}
+}
+
+func TestStore_MemoryBudget(t *testing.T) {
+	cfg := Config{
+		MaxPointsPerDrone: 100000,
+		SampleIntervalMs:  1000,
+		MaxBytes:          1000 * pointSize,
+		DownsampleAfterMs: 60000,
+		DownsampleEvery:   10,
+	}
+	store := New(cfg)
+
+	// 3 drones for 20 minutes at one sample per second
+	for sec := int64(1); sec <= 1200; sec++ {
+		for _, id := range []string{"a", "b", "c"} {
+			store.Record(&models.DroneState{DeviceID: id, Timestamp: sec * 1000})
+		}
+		if stats := store.GetStats(); stats.MemoryBytes > cfg.MaxBytes {
+			t.Fatalf("MemoryBytes = %d at %ds, want at most %d", stats.MemoryBytes, sec, cfg.MaxBytes)
+		}
+	}
+
+	stats := store.GetStats()
+	if stats.DownsampleLevel < 1 || stats.Devices != 3 {
+		t.Errorf("Stats = %+v, want 3 devices downsampled", stats)
+	}
+	track := store.GetTrack("a", 0, 0)
+	if track[0].Timestamp > 60000 {
+		t.Errorf("Oldest point = %d, want the start of the shift kept after thinning", track[0].Timestamp)
+	}
+	if recent := store.GetTrack("a", 0, 1200000-60000); len(recent) != 61 {
+		t.Errorf("Recent points = %d, want all 61 of the last minute", len(recent))
+	}
+	if old := len(store.GetTrack("a", 0, 0)) - len(store.GetTrack("a", 0, 600001)); old > 61 {
+		t.Errorf("Points in the first 10 minutes = %d, want at most one per 10s bucket", old)
+	}
+}
+
+func TestStore_MemoryBudgetDropsOldest(t *testing.T) {
+	cfg := Config{MaxPointsPerDrone: 1000, SampleIntervalMs: 0, MaxBytes: 100 * pointSize}
+	store := New(cfg)
+
+	for ts := int64(1); ts <= 300; ts++ {
+		store.Record(&models.DroneState{DeviceID: "old", Timestamp: ts})
+	}
+	for ts := int64(301); ts <= 320; ts++ {
+		store.Record(&models.DroneState{DeviceID: "new", Timestamp: ts})
+	}
+
+	if stats := store.GetStats(); stats.MemoryBytes > cfg.MaxBytes || stats.DownsampleLevel != 0 {
+		t.Errorf("Stats = %+v, want under budget without downsampling", stats)
+	}
+	if store.GetTrackSize("new") != 20 {
+		t.Errorf("GetTrackSize(new) = %d, want the newest track untouched", store.GetTrackSize("new"))
+	}
+	if last := store.GetTrack("old", 1, 0); len(last) != 1 || last[0].Timestamp != 300 {
+		t.Errorf("Newest point of old = %+v, want 300", last)
+	}
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Adapter implements the core.Adapter interface for subprocess plugins
+type Adapter struct {
+	cfg        config.PluginConfig
+	proc       *process
+	quarantine *quarantine.Store
+}
+
+// NewAdapter creates a new plugin adapter
+func NewAdapter(cfg config.PluginConfig) *Adapter {
+	return &Adapter{cfg: cfg}
+}
+
+// Name returns the adapter name
+func (a *Adapter) Name() string {
+	return "plugin:" + a.cfg.Name
+}
+
+// Start launches the plugin and forwards its states to the events channel
+func (a *Adapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	a.proc = newProcess(a.cfg, func(msg *Message, raw []byte) {
+		if msg.Type != MessageTypeState {
+			return
+		}
+
+		state, err := a.decodeState(msg)
+		if err != nil {
+			log.Printf("[Plugin] %v", err)
+			a.quarantinePayload(raw, err)
+			return
+		}
+
+		// Blocks while the engine applies back-pressure
+		select {
+		case events <- state:
+		case <-ctx.Done():
+		}
+	})
+
+	a.proc.onInvalid = a.quarantinePayload
+
+	return a.proc.start(ctx)
+}
+
+// SetQuarantine sets the store for lines that fail to parse
+func (a *Adapter) SetQuarantine(q *quarantine.Store) {
+	a.quarantine = q
+}
+
+// quarantinePayload stores a protocol line that failed to parse
+func (a *Adapter) quarantinePayload(raw []byte, err error) {
+	if a.quarantine != nil {
+		a.quarantine.Add(a.Name(), "", a.cfg.Command, raw, err)
+	}
+}
+
+// Replay re-parses a quarantined state line
+func (a *Adapter) Replay(entry quarantine.Entry) (*models.DroneState, error) {
+	var msg Message
+	if err := json.Unmarshal(entry.Payload, &msg); err != nil {
+		return nil, fmt.Errorf("decoding message: %w", err)
+	}
+	if msg.Type != MessageTypeState {
+		return nil, fmt.Errorf("not a state message: %q", msg.Type)
+	}
+	return a.decodeState(&msg)
+}
+
+// decodeState parses a state message from the plugin
+func (a *Adapter) decodeState(msg *Message) (*models.DroneState, error) {
+	var state models.DroneState
+	if err := json.Unmarshal(msg.Data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state from %s: %w", a.cfg.Name, err)
+	}
+	if state.DeviceID == "" {
+		return nil, fmt.Errorf("state without device_id from %s", a.cfg.Name)
+	}
+	if state.ProtocolSource == "" {
+		state.ProtocolSource = a.cfg.Name
+	}
+	return &state, nil
+}
+
+// SelfTest checks that the plugin is running and decodes a synthetic state
+// message in loopback without emitting it
+func (a *Adapter) SelfTest() (*models.DroneState, error) {
+	if !a.IsRunning() {
+		return nil, fmt.Errorf("plugin %s not running", a.cfg.Name)
+	}
+
+	synthetic := models.NewDroneState(a.cfg.Name+"-selftest", "")
+	synthetic.Timestamp = time.Now().UnixMilli()
+	synthetic.Location.Lat = 39.9087
+	synthetic.Location.Lon = 116.3975
+	data, err := json.Marshal(synthetic)
+	if err != nil {
+		return nil, fmt.Errorf("encoding state: %w", err)
+	}
+	return a.decodeState(&Message{Type: MessageTypeState, Data: data})
+}
+
+// Stop gracefully stops the plugin
+func (a *Adapter) Stop() error {
+	if a.proc != nil {
+		a.proc.stop()
+	}
+	return nil
+}
+
+// IsRunning returns whether the plugin process is alive
+func (a *Adapter) IsRunning() bool {
+	return a.proc != nil && a.proc.isRunning()
+}
@@ -0,0 +1,300 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/quarantine"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// TestHelperProcess is not a real test; it acts as a plugin subprocess
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("OUTB_PLUGIN_HELPER")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	fmt.Println(`{"type":"hello","name":"helper","version":"0.0.1"}`)
+
+	switch mode {
+	case "adapter":
+		fmt.Println(`{"type":"log","message":"emitting state"}`)
+		fmt.Println(`{"type":"state","data":{"device_id":"plugin-001","location":{"lat":22.5,"lon":114.0}}}`)
+	case "malformed":
+		fmt.Println(`not json`)
+		fmt.Println(`{"type":"state","data":{"location":{"lat":22.5,"lon":114.0}}}`)
+	case "exit":
+		return
+	case "longline":
+		fmt.Println(strings.Repeat("x", maxLineSize+1))
+	case "stuck":
+		time.Sleep(time.Minute)
+	}
+
+	out := os.Getenv("OUTB_PLUGIN_OUT")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Type == MessageTypeShutdown {
+			return
+		}
+		if out != "" {
+			f, _ := os.OpenFile(out, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			f.Write(append(scanner.Bytes(), '\n'))
+			f.Close()
+		}
+	}
+}
+
+func helperConfig(name, mode string, env ...string) config.PluginConfig {
+	return config.PluginConfig{
+		Name:           name,
+		Command:        os.Args[0],
+		Args:           []string{"-test.run=TestHelperProcess"},
+		Env:            append([]string{"OUTB_PLUGIN_HELPER=" + mode}, env...),
+		RestartDelayMs: 10,
+	}
+}
+
+func TestAdapter_Name(t *testing.T) {
+	a := NewAdapter(config.PluginConfig{Name: "foo"})
+
+	if a.Name() != "plugin:foo" {
+		t.Errorf("Name() = %s, want 'plugin:foo'", a.Name())
+	}
+}
+
+func TestAdapter_StartMissingCommand(t *testing.T) {
+	a := NewAdapter(config.PluginConfig{Name: "foo"})
+	events := make(chan *models.DroneState, 1)
+
+	if err := a.Start(context.Background(), events); err == nil {
+		t.Error("Expected error for plugin without command")
+	}
+}
+
+func TestAdapter_ReceivesState(t *testing.T) {
+	a := NewAdapter(helperConfig("helper", "adapter"))
+	events := make(chan *models.DroneState, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer a.Stop()
+
+	select {
+	case state := <-events:
+		if state.DeviceID != "plugin-001" {
+			t.Errorf("DeviceID = %s, want plugin-001", state.DeviceID)
+		}
+		if state.ProtocolSource != "helper" {
+			t.Errorf("ProtocolSource = %s, want helper", state.ProtocolSource)
+		}
+		if state.Location.Lat != 22.5 {
+			t.Errorf("Lat = %f, want 22.5", state.Location.Lat)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for plugin state")
+	}
+}
+
+func TestAdapter_QuarantinesMalformed(t *testing.T) {
+	a := NewAdapter(helperConfig("helper", "malformed"))
+	q := quarantine.New(quarantine.Config{})
+	a.SetQuarantine(q)
+	events := make(chan *models.DroneState, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := a.Start(ctx, events); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer a.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Stats().Total < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries := q.List("plugin:helper", 0)
+	if len(entries) != 2 {
+		t.Fatalf("Quarantined entries = %d, want 2", len(entries))
+	}
+
+	for _, e := range entries {
+		if _, err := a.Replay(e); err == nil {
+			t.Errorf("Replay of %q should fail", e.Payload)
+		}
+	}
+
+	fixed := entries[0]
+	fixed.Payload = []byte(`{"type":"state","data":{"device_id":"plugin-002","location":{"lat":22.5,"lon":114.0}}}`)
+	state, err := a.Replay(fixed)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if state.DeviceID != "plugin-002" || state.ProtocolSource != "helper" {
+		t.Errorf("Unexpected replayed state: %+v", state)
+	}
+}
+
+func TestAdapter_Restart(t *testing.T) {
+	cfg := helperConfig("helper", "exit")
+	cfg.Restart = true
+	a := NewAdapter(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := a.Start(ctx, make(chan *models.DroneState, 1)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer a.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for a.proc.restartCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Plugin was not restarted after exiting")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAdapter_RestartRetried(t *testing.T) {
+	// The plugin command disappears after the first start, so restarts
+	// fail until it is back
+	command := filepath.Join(t.TempDir(), "plugin")
+	if err := os.Symlink(os.Args[0], command); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	cfg := helperConfig("helper", "exit")
+	cfg.Command = command
+	cfg.Restart = true
+	a := NewAdapter(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := a.Start(ctx, make(chan *models.DroneState, 1)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer a.Stop()
+	os.Remove(command)
+	time.Sleep(100 * time.Millisecond)
+	restarts := a.proc.restartCount()
+
+	os.Symlink(os.Args[0], command)
+	deadline := time.Now().Add(5 * time.Second)
+	for a.proc.restartCount() == restarts {
+		if time.Now().After(deadline) {
+			t.Fatal("Plugin was not restarted after a failed restart")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAdapter_LineTooLong(t *testing.T) {
+	a := NewAdapter(helperConfig("helper", "longline"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := a.Start(ctx, make(chan *models.DroneState, 1)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer a.Stop()
+
+	// The plugin is killed rather than left blocked on its stdout
+	deadline := time.Now().Add(5 * time.Second)
+	for a.proc.isRunning() {
+		if time.Now().After(deadline) {
+			t.Fatal("Plugin sending an oversized line is still running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPublisher_PublishStuck(t *testing.T) {
+	p := NewPublisher(helperConfig("helper", "stuck"))
+
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer p.Stop()
+
+	// A plugin not reading its stdin makes Publish fail, not block
+	done := make(chan error)
+	go func() {
+		var err error
+		for i := 0; i < 10000 && err == nil; i++ {
+			err = p.Publish(models.NewDroneState("uav-001", "mavlink"))
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Publish to a stuck plugin should fail once its queue is full")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish blocked on a stuck plugin")
+	}
+}
+
+func TestPublisher_PublishNotStarted(t *testing.T) {
+	p := NewPublisher(config.PluginConfig{Name: "foo"})
+
+	if err := p.Publish(models.NewDroneState("uav-001", "mavlink")); err == nil {
+		t.Error("Expected error when publishing before Start")
+	}
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.jsonl")
+	p := NewPublisher(helperConfig("helper", "publisher", "OUTB_PLUGIN_OUT="+out))
+
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if !p.IsRunning() {
+		t.Error("Plugin should be running after Start")
+	}
+
+	if err := p.Publish(models.NewDroneState("uav-001", "mavlink")); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(out)
+		if strings.Contains(string(data), `"device_id":"uav-001"`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Plugin did not receive state, got %q", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	p.Stop()
+	if p.IsRunning() {
+		t.Error("Plugin should not be running after Stop")
+	}
+}
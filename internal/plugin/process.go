@@ -0,0 +1,288 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+)
+
+// maxLineSize bounds a single protocol line (matches the DJI frame limit)
+const maxLineSize = 64 * 1024
+
+// inputQueueSize is the number of messages waiting for the plugin's stdin
+// before sends fail, so a stuck plugin never blocks its caller
+const inputQueueSize = 256
+
+// maxRestartDelay caps the backoff between failed restarts
+const maxRestartDelay = time.Minute
+
+// process supervises a plugin subprocess and restarts it if configured
+type process struct {
+	cfg       config.PluginConfig
+	onMsg     func(msg *Message, raw []byte)
+	onInvalid func(raw []byte, err error) // Lines that are not valid protocol messages
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	mu       sync.Mutex
+	input    chan []byte // Messages for the running instance's stdin
+	running  bool
+	stopping bool
+	restarts int
+}
+
+func newProcess(cfg config.PluginConfig, onMsg func(msg *Message, raw []byte)) *process {
+	return &process{
+		cfg:   cfg,
+		onMsg: onMsg,
+	}
+}
+
+// start launches the subprocess and its supervision loop.
+// The first launch is synchronous so configuration errors surface immediately.
+func (p *process) start(ctx context.Context) error {
+	if p.cfg.Command == "" {
+		return fmt.Errorf("plugin %s: command is required", p.cfg.Name)
+	}
+
+	procCtx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	cmd, stdout, err := p.spawn(procCtx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	p.wg.Add(1)
+	go p.supervise(procCtx, cmd, stdout)
+	return nil
+}
+
+// spawn starts a single instance of the subprocess
+func (p *process) spawn(ctx context.Context) (*exec.Cmd, io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, p.cfg.Command, p.cfg.Args...)
+	cmd.Env = append(os.Environ(), p.cfg.Env...)
+	cmd.Stderr = &logWriter{prefix: fmt.Sprintf("[Plugin:%s] ", p.cfg.Name)}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("plugin %s: stdin pipe: %w", p.cfg.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("plugin %s: stdout pipe: %w", p.cfg.Name, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("plugin %s: start %s: %w", p.cfg.Name, p.cfg.Command, err)
+	}
+
+	input := make(chan []byte, inputQueueSize)
+	go writeLoop(stdin, input)
+
+	p.mu.Lock()
+	p.input = input
+	p.running = true
+	p.mu.Unlock()
+
+	log.Printf("[Plugin] Started %s (pid %d)", p.cfg.Name, cmd.Process.Pid)
+	return cmd, stdout, nil
+}
+
+// supervise reads plugin output and restarts the subprocess when it exits
+func (p *process) supervise(ctx context.Context, cmd *exec.Cmd, stdout io.ReadCloser) {
+	defer p.wg.Done()
+
+	for {
+		if err := p.readLoop(stdout); err != nil {
+			// Nothing reads stdout any more, so Wait would never return
+			// for a plugin blocked writing to it
+			log.Printf("[Plugin] Reading from %s failed, killing it: %v", p.cfg.Name, err)
+			cmd.Process.Kill()
+		}
+		err := cmd.Wait()
+
+		p.mu.Lock()
+		p.running = false
+		p.closeInput()
+		stopping := p.stopping
+		p.mu.Unlock()
+
+		if stopping || ctx.Err() != nil {
+			return
+		}
+		log.Printf("[Plugin] %s exited: %v", p.cfg.Name, err)
+
+		if !p.cfg.Restart {
+			return
+		}
+
+		// Failed restarts are retried with a doubling delay
+		delay := time.Duration(p.cfg.RestartDelayMs) * time.Millisecond
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			var spawnErr error
+			cmd, stdout, spawnErr = p.spawn(ctx)
+			if spawnErr == nil {
+				break
+			}
+			delay = min(max(2*delay, time.Second), maxRestartDelay)
+			log.Printf("[Plugin] Restart failed, retrying in %s: %v", delay, spawnErr)
+		}
+		p.mu.Lock()
+		p.restarts++
+		p.mu.Unlock()
+	}
+}
+
+// readLoop decodes protocol lines until the stream closes. It returns an
+// error if the stream cannot be read, e.g. for a line over maxLineSize.
+func (p *process) readLoop(stdout io.Reader) error {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 4096), maxLineSize)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			log.Printf("[Plugin] Invalid message from %s: %v", p.cfg.Name, err)
+			if p.onInvalid != nil {
+				p.onInvalid(line, err)
+			}
+			continue
+		}
+
+		switch msg.Type {
+		case MessageTypeHello:
+			log.Printf("[Plugin] %s registered (name: %s, version: %s)", p.cfg.Name, msg.Name, msg.Version)
+		case MessageTypeLog:
+			log.Printf("[Plugin:%s] %s", p.cfg.Name, msg.Message)
+		default:
+			if p.onMsg != nil {
+				p.onMsg(&msg, line)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// writeLoop writes queued messages to a plugin's stdin until the queue is
+// closed, then closes stdin. Writes fail once the plugin exits.
+func writeLoop(stdin io.WriteCloser, input <-chan []byte) {
+	defer stdin.Close()
+	for data := range input {
+		stdin.Write(data)
+	}
+}
+
+// closeInput ends the running instance's write loop. Caller must hold mu.
+func (p *process) closeInput() {
+	if p.input != nil {
+		close(p.input)
+		p.input = nil
+	}
+}
+
+// send queues a message for the plugin's stdin. It fails rather than
+// blocks when the plugin does not keep up.
+func (p *process) send(msg *Message) error {
+	data, err := encodeMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.input == nil {
+		return fmt.Errorf("plugin %s not running", p.cfg.Name)
+	}
+	select {
+	case p.input <- data:
+		return nil
+	default:
+		return fmt.Errorf("plugin %s input queue full", p.cfg.Name)
+	}
+}
+
+// stop asks the plugin to exit and kills it if it does not within the grace period
+func (p *process) stop() {
+	if p.cancel == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.stopping = true
+	p.mu.Unlock()
+
+	// stdin is closed once the queued messages are written
+	p.send(&Message{Type: MessageTypeShutdown})
+	p.mu.Lock()
+	p.closeInput()
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	// Give the plugin a moment to exit cleanly before the context kill applies
+	grace := time.NewTimer(2 * time.Second)
+	defer grace.Stop()
+
+	select {
+	case <-done:
+		p.cancel()
+	case <-grace.C:
+		p.cancel()
+		<-done
+	}
+}
+
+// isRunning returns whether the subprocess is currently alive
+func (p *process) isRunning() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running
+}
+
+// restartCount returns how many times the subprocess has been restarted
+func (p *process) restartCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.restarts
+}
+
+// logWriter copies plugin stderr to the standard logger line by line
+type logWriter struct {
+	prefix string
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		log.Print(w.prefix + scanner.Text())
+	}
+	return len(b), nil
+}
@@ -0,0 +1,55 @@
+// Package plugin runs third-party adapters and publishers as subprocesses.
+//
+// A plugin is any executable that speaks newline-delimited JSON over its
+// standard streams. Each line is a single Message:
+//
+//	plugin -> gateway (stdout):
+//	  {"type":"hello","name":"my-plugin","version":"1.0"}
+//	  {"type":"state","data":{...DroneState...}}   (adapter plugins only)
+//	  {"type":"log","message":"..."}
+//
+//	gateway -> plugin (stdin):
+//	  {"type":"state","data":{...DroneState...}}   (publisher plugins only)
+//	  {"type":"shutdown"}
+//
+// Anything a plugin writes to stderr is copied to the gateway log.
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MessageType defines the type of a plugin protocol message
+type MessageType string
+
+const (
+	MessageTypeHello    MessageType = "hello"
+	MessageTypeState    MessageType = "state"
+	MessageTypeLog      MessageType = "log"
+	MessageTypeShutdown MessageType = "shutdown"
+)
+
+// Kinds of plugins
+const (
+	KindAdapter   = "adapter"
+	KindPublisher = "publisher"
+)
+
+// Message is a single line of the plugin protocol
+type Message struct {
+	Type    MessageType     `json:"type"`
+	Name    string          `json:"name,omitempty"`
+	Version string          `json:"version,omitempty"`
+	Message string          `json:"message,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// encodeMessage serializes a message as a single protocol line
+func encodeMessage(msg *Message) ([]byte, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal plugin message: %w", err)
+	}
+	return append(data, '\n'), nil
+}
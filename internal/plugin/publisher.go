@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Publisher implements the core.Publisher interface for subprocess plugins
+type Publisher struct {
+	cfg  config.PluginConfig
+	proc *process
+}
+
+// NewPublisher creates a new plugin publisher
+func NewPublisher(cfg config.PluginConfig) *Publisher {
+	return &Publisher{cfg: cfg}
+}
+
+// Name returns the publisher name
+func (p *Publisher) Name() string {
+	return "plugin:" + p.cfg.Name
+}
+
+// Start launches the plugin process
+func (p *Publisher) Start(ctx context.Context) error {
+	p.proc = newProcess(p.cfg, nil)
+	return p.proc.start(ctx)
+}
+
+// Publish writes a DroneState to the plugin's stdin
+func (p *Publisher) Publish(state *models.DroneState) error {
+	if p.proc == nil {
+		return fmt.Errorf("plugin %s not started", p.cfg.Name)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("json marshal failed: %w", err)
+	}
+
+	return p.proc.send(&Message{Type: MessageTypeState, Data: data})
+}
+
+// SelfTest encodes the state without sending it and checks that the
+// plugin process is running
+func (p *Publisher) SelfTest(state *models.DroneState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("json marshal failed: %w", err)
+	}
+	if _, err := encodeMessage(&Message{Type: MessageTypeState, Data: data}); err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	if !p.IsRunning() {
+		return fmt.Errorf("plugin %s not running", p.cfg.Name)
+	}
+	return nil
+}
+
+// Stop gracefully stops the plugin
+func (p *Publisher) Stop() error {
+	if p.proc != nil {
+		p.proc.stop()
+	}
+	return nil
+}
+
+// IsRunning returns whether the plugin process is alive
+func (p *Publisher) IsRunning() bool {
+	return p.proc != nil && p.proc.isRunning()
+}
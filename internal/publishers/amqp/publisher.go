@@ -0,0 +1,307 @@
+// Package amqp publishes states to an AMQP 0.9.1 exchange, such as a
+// RabbitMQ topic exchange, with routing keys built from the device ID and
+// protocol source. Publishing uses confirm mode, so a state is only
+// reported as sent once the broker has taken responsibility for it.
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/backoff"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// dialTimeout bounds connecting and the protocol handshake
+const dialTimeout = 5 * time.Second
+
+// exchangeTypes are the exchange types that can be declared
+var exchangeTypes = map[string]bool{"direct": true, "fanout": true, "topic": true, "headers": true}
+
+// Publisher implements the core.Publisher interface for AMQP brokers
+type Publisher struct {
+	cfg      config.AMQPConfig
+	endpoint endpoint
+	ctx      context.Context
+
+	mu           sync.Mutex
+	conn         *conn
+	reconnecting bool
+	stopped      bool
+	connectedAt  time.Time
+	lastError    string // Why the connection was last lost or refused
+	lastErrorAt  time.Time
+}
+
+// New creates a new AMQP publisher, checking the URL, exchange type and
+// routing key template
+func New(cfg config.AMQPConfig) (*Publisher, error) {
+	ep, err := parseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if cfg.ExchangeType != "" && !exchangeTypes[cfg.ExchangeType] {
+		return nil, fmt.Errorf("unknown exchange type %q (want direct, fanout, topic or headers)", cfg.ExchangeType)
+	}
+	if len(cfg.Exchange) > 255 {
+		return nil, fmt.Errorf("exchange name is longer than 255 bytes")
+	}
+	if cfg.ExchangeType != "" && strings.HasPrefix(cfg.Exchange, "amq.") {
+		return nil, fmt.Errorf("exchange %s is predeclared by the broker, leave exchange_type empty", cfg.Exchange)
+	}
+	if err := checkTemplate(cfg.RoutingKey); err != nil {
+		return nil, fmt.Errorf("routing key: %w", err)
+	}
+	return &Publisher{cfg: cfg, endpoint: ep, ctx: context.Background()}, nil
+}
+
+// checkTemplate rejects unknown placeholders
+func checkTemplate(tmpl string) error {
+	rest := routingKeyReplacer("", "", "").Replace(tmpl)
+	if i := strings.IndexByte(rest, '{'); i >= 0 {
+		end := strings.IndexByte(rest[i:], '}')
+		if end < 0 {
+			return fmt.Errorf("unterminated placeholder in %q", tmpl)
+		}
+		return fmt.Errorf("unknown placeholder %s (want {device_id}, {protocol_source} or {tenant})", rest[i:i+end+1])
+	}
+	return nil
+}
+
+func routingKeyReplacer(deviceID, source, tenant string) *strings.Replacer {
+	return strings.NewReplacer("{device_id}", deviceID, "{protocol_source}", source, "{tenant}", tenant)
+}
+
+// Name returns the publisher name
+func (p *Publisher) Name() string {
+	return "amqp"
+}
+
+// Start connects to the broker. If it is unreachable the publisher keeps
+// retrying in the background.
+func (p *Publisher) Start(ctx context.Context) error {
+	p.ctx = ctx
+	if err := p.connect(); err != nil {
+		log.Printf("[AMQP] Broker %s not reachable, retrying in background: %v", p.endpoint.address, err)
+		p.scheduleReconnect()
+	}
+	return nil
+}
+
+// connect opens the connection and its confirm-mode channel
+func (p *Publisher) connect() error {
+	heartbeat := time.Duration(p.cfg.HeartbeatSec) * time.Second
+	if heartbeat < 0 {
+		heartbeat = 0
+	}
+	c, err := dial(p.endpoint, dialOptions{
+		timeout:      dialTimeout,
+		heartbeat:    heartbeat,
+		exchange:     p.cfg.Exchange,
+		exchangeType: p.cfg.ExchangeType,
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.setError(err)
+		return err
+	}
+	if p.stopped {
+		c.close()
+		return fmt.Errorf("publisher stopped")
+	}
+	p.conn = c
+	p.connectedAt = time.Now()
+	go p.watch(c)
+	return nil
+}
+
+// watch reconnects when the broker closes the connection or heartbeats stop
+func (p *Publisher) watch(c *conn) {
+	<-c.done
+	p.mu.Lock()
+	if p.conn != c || p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.conn = nil
+	if err := c.failure(); err != nil {
+		p.setError(err)
+	}
+	p.mu.Unlock()
+
+	log.Printf("[AMQP] Connection lost, reconnecting with backoff: %v", c.failure())
+	p.scheduleReconnect()
+}
+
+// scheduleReconnect starts a background reconnect loop unless one is
+// already running
+func (p *Publisher) scheduleReconnect() {
+	p.mu.Lock()
+	if p.reconnecting || p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.reconnecting = true
+	p.mu.Unlock()
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			p.reconnecting = false
+			p.mu.Unlock()
+		}()
+
+		b := backoff.FromMs(p.cfg.ReconnectInitialMs, p.cfg.ReconnectMaxMs)
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-time.After(b.Next()):
+			}
+			if err := p.connect(); err != nil {
+				log.Printf("[AMQP] Reconnect to %s failed: %v", p.endpoint.address, err)
+				continue
+			}
+			log.Printf("[AMQP] Connected to %s", p.endpoint.address)
+			return
+		}
+	}()
+}
+
+// Publish sends the state and waits for the broker to confirm it
+func (p *Publisher) Publish(state *models.DroneState) error {
+	msg, err := p.message(state)
+	if err != nil {
+		return err
+	}
+	return p.send(msg)
+}
+
+// PublishBatch sends several states and waits for all their confirms
+func (p *Publisher) PublishBatch(states []*models.DroneState) error {
+	msgs := make([]message, 0, len(states))
+	for _, state := range states {
+		msg, err := p.message(state)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return p.send(msgs...)
+}
+
+// message encodes a state with its routing key. Device and source are also
+// sent as headers, for headers exchanges.
+func (p *Publisher) message(state *models.DroneState) (message, error) {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return message{}, fmt.Errorf("json marshal failed: %w", err)
+	}
+	key := p.routingKey(state)
+	if len(key) > 255 {
+		return message{}, fmt.Errorf("routing key for %s is longer than 255 bytes", state.DeviceID)
+	}
+	headers := map[string]any{
+		"device_id":       state.DeviceID,
+		"protocol_source": state.ProtocolSource,
+	}
+	if state.Tenant != "" {
+		headers["tenant"] = state.Tenant
+	}
+	return message{routingKey: key, headers: headers, body: body}, nil
+}
+
+// routingKey fills the routing key template for a state
+func (p *Publisher) routingKey(state *models.DroneState) string {
+	return routingKeyReplacer(state.DeviceID, state.ProtocolSource, state.Tenant).Replace(p.cfg.RoutingKey)
+}
+
+// send publishes the messages. A connection failure closes the connection,
+// which watch then replaces; broker nacks leave it open.
+func (p *Publisher) send(msgs ...message) error {
+	p.mu.Lock()
+	c := p.conn
+	p.mu.Unlock()
+	if c == nil {
+		return fmt.Errorf("amqp not connected")
+	}
+
+	timeout := time.Duration(p.cfg.ConfirmTimeoutMs) * time.Millisecond
+	if err := c.publish(p.cfg.Exchange, p.cfg.Persistent, msgs, timeout); err != nil {
+		return fmt.Errorf("amqp publish failed: %w", err)
+	}
+	return nil
+}
+
+// SelfTest encodes the state and builds its routing key without publishing,
+// and checks the broker connection
+func (p *Publisher) SelfTest(state *models.DroneState) error {
+	if _, err := p.message(state); err != nil {
+		return err
+	}
+	if !p.IsConnected() {
+		return fmt.Errorf("amqp not connected to %s", p.endpoint.address)
+	}
+	return nil
+}
+
+// Stop closes the connection
+func (p *Publisher) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	if p.conn != nil {
+		err := p.conn.close()
+		p.conn = nil
+		return err
+	}
+	return nil
+}
+
+// IsConnected returns true if the publisher holds a broker connection
+func (p *Publisher) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.conn != nil
+}
+
+// Health reports the broker connection
+func (p *Publisher) Health() sdk.PublisherDetail {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := sdk.PublisherDetail{
+		State:    "disconnected",
+		Endpoint: p.endpoint.address,
+		Details:  map[string]string{"exchange": p.cfg.Exchange, "vhost": p.endpoint.vhost},
+	}
+	switch {
+	case p.stopped:
+		d.State = "stopped"
+	case p.conn != nil:
+		d.State = "connected"
+		d.Details["connected_since"] = p.connectedAt.UTC().Format(time.RFC3339)
+	case p.reconnecting:
+		d.State = "reconnecting"
+	}
+	if p.lastError != "" {
+		d.LastError = p.lastError
+		d.LastErrorAt = p.lastErrorAt.UnixMilli()
+	}
+	return d
+}
+
+// setError records a connection error. Callers hold p.mu.
+func (p *Publisher) setError(err error) {
+	p.lastError = err.Error()
+	p.lastErrorAt = time.Now()
+}
@@ -0,0 +1,101 @@
+package gb28181
+
+import (
+	"fmt"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+)
+
+// PublishAlert sends an alert to the platform as an Alarm notification. The
+// alarm is raised on the drone's channel with its last reported position;
+// alerts without a known drone are raised on the gateway. While the platform
+// holds alarm subscriptions, only alarms matching one of them are sent.
+func (p *Publisher) PublishAlert(alert *alerter.Alert) error {
+	p.mu.RLock()
+	running := p.running
+	loc := p.loc
+	p.mu.RUnlock()
+	if !running {
+		return fmt.Errorf("publisher not running")
+	}
+	if !p.sipClient.IsRegistered() {
+		return fmt.Errorf("not registered with SIP server")
+	}
+
+	notify := p.buildAlarm(alert)
+	if !p.alarmSubscribed(notify.AlarmPriority, notify.AlarmMethod) {
+		return nil
+	}
+	notify.SN = p.sipClient.NextSN()
+	notify.AlarmTime = gbxml.FormatTime(alert.Timestamp, loc)
+
+	body, err := notify.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := p.sipClient.SendMessage(p.ctx, "Application/MANSCDP+xml", body); err != nil {
+		return fmt.Errorf("send alarm notify: %w", err)
+	}
+	return nil
+}
+
+// buildAlarm maps an alert onto an Alarm notification without SN and time
+func (p *Publisher) buildAlarm(alert *alerter.Alert) *gbxml.AlarmNotify {
+	notify := &gbxml.AlarmNotify{
+		CmdType:          gbxml.CmdTypeAlarm,
+		DeviceID:         p.deviceMgr.GatewayID(),
+		AlarmPriority:    alarmPriority(alert.Severity),
+		AlarmMethod:      alarmMethod(alert),
+		AlarmDescription: alert.Message,
+	}
+	if ch := p.deviceMgr.GetChannel(alert.DeviceID); ch != nil {
+		notify.DeviceID = ch.DeviceID
+		if state := ch.LastState; state != nil && (state.Location.Lat != 0 || state.Location.Lon != 0) {
+			lon, lat := state.Location.Lon, state.Location.Lat
+			notify.Longitude, notify.Latitude = &lon, &lat
+		}
+	}
+	return notify
+}
+
+// alarmSubscribed reports whether an alarm should be sent: always without
+// alarm subscriptions, otherwise if any subscription accepts it
+func (p *Publisher) alarmSubscribed(priority, method int) bool {
+	subs := p.subMgr.GetAlarmSubscriptions()
+	if len(subs) == 0 {
+		return true
+	}
+	for _, sub := range subs {
+		if sub.Alarm.Accepts(priority, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// alarmPriority maps an alert severity onto an alarm priority
+func alarmPriority(severity alerter.AlertSeverity) int {
+	switch severity {
+	case alerter.SeverityCritical:
+		return gbxml.AlarmPriorityFirst
+	case alerter.SeverityWarning:
+		return gbxml.AlarmPrioritySecond
+	default:
+		return gbxml.AlarmPriorityThird
+	}
+}
+
+// alarmMethod maps an alert type onto an alarm method: geofence alerts are
+// GPS alarms, other drone alerts device alarms and gateway alerts faults
+func alarmMethod(alert *alerter.Alert) int {
+	switch {
+	case alert.Type == alerter.AlertTypeGeofenceBreach || alert.Type == alerter.AlertTypeGeofencePredicted ||
+		alert.Type == alerter.AlertTypeGeofenceDwell:
+		return gbxml.AlarmMethodGPS
+	case alert.DeviceID == "":
+		return gbxml.AlarmMethodDeviceFault
+	default:
+		return gbxml.AlarmMethodDevice
+	}
+}
@@ -0,0 +1,100 @@
+package gb28181
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/alerter"
+	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestPublisher_BuildAlarm(t *testing.T) {
+	p := New(config.GB28181Config{DeviceID: "34020000001110000001"})
+	p.deviceMgr = NewDeviceManager(p.cfg.DeviceID)
+	state := models.NewDroneState("uav-001", "mavlink")
+	state.Location.Lat, state.Location.Lon = 22.5431, 114.0579
+	ch := p.deviceMgr.UpdateDrone(state)
+
+	notify := p.buildAlarm(&alerter.Alert{
+		Type: alerter.AlertTypeGeofenceBreach, Severity: alerter.SeverityCritical,
+		DeviceID: "uav-001", Message: "Drone uav-001 entered geofence Airport",
+	})
+	if notify.DeviceID != ch.DeviceID || notify.AlarmPriority != gbxml.AlarmPriorityFirst || notify.AlarmMethod != gbxml.AlarmMethodGPS {
+		t.Errorf("Breach alarm = %+v", notify)
+	}
+	if notify.Latitude == nil || *notify.Latitude != 22.5431 || *notify.Longitude != 114.0579 {
+		t.Errorf("Breach alarm position = %v, %v", notify.Latitude, notify.Longitude)
+	}
+
+	notify = p.buildAlarm(&alerter.Alert{Type: alerter.AlertTypeBatteryLow, Severity: alerter.SeverityWarning, DeviceID: "uav-001"})
+	if notify.AlarmPriority != gbxml.AlarmPrioritySecond || notify.AlarmMethod != gbxml.AlarmMethodDevice {
+		t.Errorf("Battery alarm = %+v", notify)
+	}
+
+	notify = p.buildAlarm(&alerter.Alert{Type: "publisher_degraded", Severity: alerter.SeverityInfo, Message: "MQTT degraded"})
+	if notify.DeviceID != p.cfg.DeviceID || notify.AlarmPriority != gbxml.AlarmPriorityThird ||
+		notify.AlarmMethod != gbxml.AlarmMethodDeviceFault || notify.Latitude != nil {
+		t.Errorf("Gateway alarm = %+v", notify)
+	}
+
+	notify.SN, notify.AlarmTime = 7, "2026-01-02T03:04:05"
+	body, err := notify.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, want := range []string{"<CmdType>Alarm</CmdType>", "<AlarmPriority>3</AlarmPriority>", "<AlarmMethod>6</AlarmMethod>",
+		"<AlarmTime>2026-01-02T03:04:05</AlarmTime>", "<AlarmDescription>MQTT degraded</AlarmDescription>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Marshal() missing %s:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Longitude") {
+		t.Errorf("Marshal() should omit an unknown position:\n%s", body)
+	}
+}
+
+func TestRequestHandler_AlarmSubscribe(t *testing.T) {
+	subs := NewSubscriptionManager()
+	h := NewRequestHandler(NewDeviceManager("34020000001110000001"), subs, nil)
+	p := &Publisher{subMgr: subs}
+	if !p.alarmSubscribed(gbxml.AlarmPriorityThird, gbxml.AlarmMethodDevice) {
+		t.Error("Alarms should be sent without alarm subscriptions")
+	}
+
+	body := []byte(gbxml.XMLDeclaration + `
+<Query>
+  <CmdType>Alarm</CmdType>
+  <SN>17</SN>
+  <DeviceID>34020000001110000001</DeviceID>
+  <StartAlarmPriority>1</StartAlarmPriority>
+  <EndAlarmPriority>2</EndAlarmPriority>
+  <AlarmMethod>4</AlarmMethod>
+</Query>`)
+	req := newSIPRequest(sip.SUBSCRIBE, "34020000001110000001", "alarm-1", body)
+	req.AppendHeader(sip.NewHeader("Expires", "600"))
+	if resp := h.HandleRequest(req); resp.StatusCode != 200 {
+		t.Fatalf("SUBSCRIBE status = %d, want 200", resp.StatusCode)
+	}
+
+	alarmSubs := subs.GetAlarmSubscriptions()
+	if len(alarmSubs) != 1 || alarmSubs[0].ID != "alarm-1" || time.Until(alarmSubs[0].Expires) > 10*time.Minute {
+		t.Fatalf("Alarm subscriptions = %+v", alarmSubs)
+	}
+	for _, tt := range []struct {
+		priority, method int
+		want             bool
+	}{
+		{gbxml.AlarmPriorityFirst, gbxml.AlarmMethodGPS, true},
+		{gbxml.AlarmPriorityThird, gbxml.AlarmMethodGPS, false},
+		{gbxml.AlarmPrioritySecond, gbxml.AlarmMethodDevice, false},
+	} {
+		if got := p.alarmSubscribed(tt.priority, tt.method); got != tt.want {
+			t.Errorf("alarmSubscribed(%d, %d) = %v, want %v", tt.priority, tt.method, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package gb28181
 
 import (
 	"crypto/md5"
+	"crypto/subtle"
 	"fmt"
 	"math/rand"
 	"regexp"
@@ -94,6 +95,45 @@ func (d *DigestAuth) GenerateResponse(method, uri string) string {
 	return authHeader.String()
 }
 
+// digestParamRe matches the key=value pairs of a Digest header
+var digestParamRe = regexp.MustCompile(`(\w+)=(?:"([^"]*)"|([^\s,]+))`)
+
+// ParseDigestParams parses the parameters of an Authorization or
+// WWW-Authenticate header
+func ParseDigestParams(header string) map[string]string {
+	params := make(map[string]string)
+	header = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(header), "Digest"))
+	for _, m := range digestParamRe.FindAllStringSubmatch(header, -1) {
+		value := m[2]
+		if value == "" {
+			value = m[3]
+		}
+		params[strings.ToLower(m[1])] = value
+	}
+	return params
+}
+
+// VerifyDigest checks the Authorization header of a request against the
+// password, for a challenge issued with the given realm and nonce
+func VerifyDigest(params map[string]string, method, realm, nonce, password string) bool {
+	if params["realm"] != realm || params["nonce"] != nonce || params["response"] == "" {
+		return false
+	}
+
+	ha1 := md5Hash(fmt.Sprintf("%s:%s:%s", params["username"], realm, password))
+	ha2 := md5Hash(fmt.Sprintf("%s:%s", method, params["uri"]))
+
+	var expected string
+	if qop := params["qop"]; qop != "" {
+		expected = md5Hash(fmt.Sprintf("%s:%s:%s:%s:%s:%s",
+			ha1, nonce, params["nc"], params["cnonce"], qop, ha2))
+	} else {
+		expected = md5Hash(fmt.Sprintf("%s:%s:%s", ha1, nonce, ha2))
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(params["response"]))) == 1
+}
+
 // md5Hash computes MD5 hash and returns hex string
 func md5Hash(s string) string {
 	hash := md5.Sum([]byte(s))
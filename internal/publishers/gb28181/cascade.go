@@ -0,0 +1,453 @@
+package gb28181
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+)
+
+// cascadeNonceTTL is how long a REGISTER challenge stays valid
+const cascadeNonceTTL = 5 * time.Minute
+
+// Downstream is a GB28181 device or gateway registered with this gateway
+type Downstream struct {
+	DeviceID     string
+	Addr         string              // host:port queries are sent to
+	RegisteredAt time.Time           // Last successful REGISTER
+	Expires      time.Time           // Registration expiry
+	LastSeen     time.Time           // Last REGISTER or keepalive
+	Channels     []gbxml.CatalogItem // Last complete catalog reported by the device
+
+	catalogSN    int                 // SN of the catalog being received
+	catalogParts []gbxml.CatalogItem // Items received so far for catalogSN
+}
+
+// CascadeManager lets this gateway act as the superior platform of
+// downstream devices and gateways: it accepts their REGISTER, collects
+// their catalogs and re-publishes channels and notifications upstream
+type CascadeManager struct {
+	cfg   config.GB28181CascadeConfig
+	realm string
+
+	mu          sync.RWMutex
+	downstreams map[string]*Downstream // Keyed by device ID
+	nonces      map[string]time.Time   // Issued challenges and when
+
+	sn  atomic.Int32
+	now func() time.Time
+	loc *time.Location // Zone of the Date header
+
+	// sendTo sends a MESSAGE to a downstream device and forward re-publishes
+	// a downstream notification upstream
+	sendTo  func(ctx context.Context, d Downstream, body string) error
+	forward func(ctx context.Context, method sip.RequestMethod, body string) error
+}
+
+// NewCascadeManager creates a cascade manager that talks to downstream
+// devices and the upstream platform through sipClient
+func NewCascadeManager(cfg config.GB28181Config, sipClient *SIPClient) *CascadeManager {
+	realm := cfg.Cascade.Realm
+	if realm == "" && len(cfg.DeviceID) >= 10 {
+		realm = cfg.DeviceID[:10]
+	}
+
+	m := &CascadeManager{
+		cfg:         cfg.Cascade,
+		realm:       realm,
+		downstreams: make(map[string]*Downstream),
+		nonces:      make(map[string]time.Time),
+		now:         time.Now,
+		loc:         time.Local,
+	}
+	m.sendTo = func(ctx context.Context, d Downstream, body string) error {
+		return sipClient.SendMessageTo(ctx, d.DeviceID, d.Addr, "Application/MANSCDP+xml", body)
+	}
+	m.forward = func(ctx context.Context, method sip.RequestMethod, body string) error {
+		if !sipClient.IsRegistered() {
+			return fmt.Errorf("not registered with SIP server")
+		}
+		if method == sip.NOTIFY {
+			return sipClient.SendNotify(ctx, "Application/MANSCDP+xml", body)
+		}
+		return sipClient.SendMessage(ctx, "Application/MANSCDP+xml", body)
+	}
+	return m
+}
+
+// SetLocation sets the timezone of the Date header sent to downstream
+// devices
+func (m *CascadeManager) SetLocation(loc *time.Location) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if loc == nil {
+		loc = time.Local
+	}
+	m.loc = loc
+}
+
+// HandleRegister answers a REGISTER from a downstream device, challenging
+// it first when a password is configured
+func (m *CascadeManager) HandleRegister(req *sip.Request) *sip.Response {
+	from := req.From()
+	if from == nil || from.Address.User == "" {
+		return sip.NewResponseFromRequest(req, 400, "Bad Request", nil)
+	}
+	deviceID := from.Address.User
+
+	if !m.allowed(deviceID) {
+		log.Printf("[GB28181] Rejecting REGISTER from %s: not in cascade.allowed_ids", deviceID)
+		return sip.NewResponseFromRequest(req, 403, "Forbidden", nil)
+	}
+
+	if m.cfg.Password != "" {
+		authz := req.GetHeader("Authorization")
+		if authz == nil || !m.authorized(authz.Value()) {
+			if authz != nil {
+				log.Printf("[GB28181] REGISTER from %s failed authentication", deviceID)
+			}
+			return m.challenge(req)
+		}
+	}
+
+	expires := 3600
+	if h := req.GetHeader("Expires"); h != nil {
+		if val, err := strconv.Atoi(h.Value()); err == nil {
+			expires = val
+		}
+	}
+
+	now := m.now()
+	if expires == 0 {
+		m.mu.Lock()
+		delete(m.downstreams, deviceID)
+		m.mu.Unlock()
+		log.Printf("[GB28181] Downstream %s unregistered", deviceID)
+		return sip.NewResponseFromRequest(req, 200, "OK", nil)
+	}
+
+	addr := registerAddr(req)
+
+	m.mu.Lock()
+	d, ok := m.downstreams[deviceID]
+	if !ok {
+		d = &Downstream{DeviceID: deviceID}
+		m.downstreams[deviceID] = d
+	}
+	query := !ok || d.Addr != addr || len(d.Channels) == 0
+	d.Addr = addr
+	d.RegisteredAt = now
+	d.Expires = now.Add(time.Duration(expires) * time.Second)
+	d.LastSeen = now
+	loc := m.loc
+	m.mu.Unlock()
+
+	if !ok {
+		log.Printf("[GB28181] Downstream %s registered from %s (expires %ds)", deviceID, addr, expires)
+	}
+	if query {
+		go m.queryCatalog(deviceID)
+	}
+
+	resp := sip.NewResponseFromRequest(req, 200, "OK", nil)
+	resp.AppendHeader(sip.NewHeader("Expires", strconv.Itoa(expires)))
+	resp.AppendHeader(sip.NewHeader("Date", now.In(loc).Format("2006-01-02T15:04:05.000")))
+	return resp
+}
+
+// HandleMessage handles a MESSAGE or NOTIFY from a downstream device:
+// keepalives, catalog responses, and position and alarm notifications,
+// which are forwarded upstream. It returns nil for requests that are not
+// downstream notifications, such as queries from the upstream platform.
+func (m *CascadeManager) HandleMessage(req *sip.Request, body []byte) *sip.Response {
+	var msg struct {
+		XMLName  xml.Name
+		CmdType  gbxml.CmdType `xml:"CmdType"`
+		DeviceID string        `xml:"DeviceID"`
+	}
+	if err := gbxml.Unmarshal(body, &msg); err != nil {
+		return nil
+	}
+	if msg.XMLName.Local != "Notify" && msg.XMLName.Local != "Response" {
+		return nil
+	}
+
+	from := req.From()
+	if from == nil || !m.touch(from.Address.User) {
+		// Unknown sender, e.g. after a restart: make it register again
+		return sip.NewResponseFromRequest(req, 403, "Forbidden", nil)
+	}
+	deviceID := from.Address.User
+
+	switch {
+	case msg.XMLName.Local == "Response" && msg.CmdType == gbxml.CmdTypeCatalog:
+		var catalog gbxml.CatalogResponse
+		if err := gbxml.Unmarshal(body, &catalog); err != nil {
+			log.Printf("[GB28181] Failed to parse catalog from downstream %s: %v", deviceID, err)
+			return sip.NewResponseFromRequest(req, 400, "Bad Request", nil)
+		}
+		m.addCatalog(deviceID, &catalog)
+	case msg.CmdType == gbxml.CmdTypeMobilePosition || msg.CmdType == gbxml.CmdTypeAlarm:
+		method := req.Method
+		if msg.CmdType == gbxml.CmdTypeMobilePosition {
+			method = sip.NOTIFY
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := m.forward(ctx, method, string(body)); err != nil {
+				log.Printf("[GB28181] Failed to forward %s from downstream %s: %v", msg.CmdType, deviceID, err)
+			}
+		}()
+	}
+
+	return sip.NewResponseFromRequest(req, 200, "OK", nil)
+}
+
+// Channels returns the catalog items of all downstream devices, each device
+// followed by its channels. Channels of offline devices are reported OFF.
+func (m *CascadeManager) Channels(parentID, civilCode string) []gbxml.CatalogItem {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.downstreams))
+	for id := range m.downstreams {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	now := m.now()
+	var items []gbxml.CatalogItem
+	for _, id := range ids {
+		d := m.downstreams[id]
+		online := m.online(d, now)
+
+		device := gbxml.NewCatalogItem(d.DeviceID, d.DeviceID, parentID, civilCode, online)
+		device.Parental = 1
+		items = append(items, device)
+
+		for _, item := range d.Channels {
+			if item.ParentID == "" {
+				item.ParentID = d.DeviceID
+			}
+			if !online {
+				item.Status = "OFF"
+			}
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Downstreams returns the registered downstream devices sorted by ID
+func (m *CascadeManager) Downstreams() []Downstream {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Downstream, 0, len(m.downstreams))
+	for _, d := range m.downstreams {
+		result = append(result, m.copyDownstream(d))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].DeviceID < result[j].DeviceID })
+	return result
+}
+
+// Run refreshes the downstream catalogs and drops expired registrations
+// until done is closed
+func (m *CascadeManager) Run(done <-chan struct{}) {
+	interval := time.Duration(m.cfg.CatalogInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for _, id := range m.prune() {
+				m.queryCatalog(id)
+			}
+		}
+	}
+}
+
+// prune drops expired registrations and challenges and returns the IDs of
+// the devices still online
+func (m *CascadeManager) prune() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var online []string
+	for id, d := range m.downstreams {
+		if now.After(d.Expires) {
+			log.Printf("[GB28181] Downstream %s registration expired", id)
+			delete(m.downstreams, id)
+			continue
+		}
+		if m.online(d, now) {
+			online = append(online, id)
+		}
+	}
+	for nonce, issued := range m.nonces {
+		if now.Sub(issued) > cascadeNonceTTL {
+			delete(m.nonces, nonce)
+		}
+	}
+	sort.Strings(online)
+	return online
+}
+
+// queryCatalog asks a downstream device for its catalog
+func (m *CascadeManager) queryCatalog(deviceID string) {
+	sn := int(m.sn.Add(1))
+
+	m.mu.Lock()
+	d, ok := m.downstreams[deviceID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	d.catalogSN = sn
+	d.catalogParts = nil
+	target := m.copyDownstream(d)
+	m.mu.Unlock()
+
+	body, err := gbxml.NewCatalogQuery(deviceID, sn).Marshal()
+	if err != nil {
+		log.Printf("[GB28181] Failed to marshal catalog query: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.sendTo(ctx, target, body); err != nil {
+		log.Printf("[GB28181] Failed to query catalog of downstream %s: %v", deviceID, err)
+	}
+}
+
+// addCatalog collects a catalog response, which may be split over several
+// messages, and replaces the device's channels once it is complete
+func (m *CascadeManager) addCatalog(deviceID string, resp *gbxml.CatalogResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.downstreams[deviceID]
+	if !ok {
+		return
+	}
+	if resp.SN != d.catalogSN {
+		d.catalogSN = resp.SN
+		d.catalogParts = nil
+	}
+	d.catalogParts = append(d.catalogParts, resp.DeviceList.Items...)
+
+	if len(d.catalogParts) >= resp.SumNum {
+		d.Channels = d.catalogParts
+		d.catalogParts = nil
+		log.Printf("[GB28181] Downstream %s catalog: %d channels", deviceID, len(d.Channels))
+	}
+}
+
+// touch records that a registered downstream device was heard from and
+// reports whether it is registered
+func (m *CascadeManager) touch(deviceID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.downstreams[deviceID]
+	if !ok {
+		return false
+	}
+	d.LastSeen = m.now()
+	return true
+}
+
+// online reports whether a downstream device is registered and sent a
+// keepalive recently
+func (m *CascadeManager) online(d *Downstream, now time.Time) bool {
+	timeout := time.Duration(m.cfg.KeepaliveTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 3 * time.Minute
+	}
+	return now.Before(d.Expires) && now.Sub(d.LastSeen) <= timeout
+}
+
+// allowed reports whether a device may register
+func (m *CascadeManager) allowed(deviceID string) bool {
+	if len(m.cfg.AllowedIDs) == 0 {
+		return true
+	}
+	for _, id := range m.cfg.AllowedIDs {
+		if id == deviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// challenge answers 401 with a new digest challenge
+func (m *CascadeManager) challenge(req *sip.Request) *sip.Response {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	nonce := hex.EncodeToString(b)
+
+	m.mu.Lock()
+	m.nonces[nonce] = m.now()
+	m.mu.Unlock()
+
+	resp := sip.NewResponseFromRequest(req, 401, "Unauthorized", nil)
+	resp.AppendHeader(sip.NewHeader("WWW-Authenticate",
+		fmt.Sprintf(`Digest realm="%s", nonce="%s", algorithm=MD5`, m.realm, nonce)))
+	return resp
+}
+
+// authorized verifies an Authorization header against an issued challenge
+func (m *CascadeManager) authorized(header string) bool {
+	params := ParseDigestParams(header)
+
+	m.mu.RLock()
+	issued, ok := m.nonces[params["nonce"]]
+	m.mu.RUnlock()
+	if !ok || m.now().Sub(issued) > cascadeNonceTTL {
+		return false
+	}
+	return VerifyDigest(params, string(sip.REGISTER), m.realm, params["nonce"], m.cfg.Password)
+}
+
+// copyDownstream returns a copy of d that is safe to use without the lock
+func (m *CascadeManager) copyDownstream(d *Downstream) Downstream {
+	c := *d
+	c.Channels = append([]gbxml.CatalogItem(nil), d.Channels...)
+	c.catalogParts = nil
+	return c
+}
+
+// registerAddr returns where a registering device receives requests: its
+// Contact address, else the source of the request
+func registerAddr(req *sip.Request) string {
+	if contact := req.Contact(); contact != nil && contact.Address.Host != "" {
+		port := contact.Address.Port
+		if port == 0 {
+			port = 5060
+		}
+		return net.JoinHostPort(contact.Address.Host, strconv.Itoa(port))
+	}
+	return req.Source()
+}
@@ -0,0 +1,222 @@
+package gb28181
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+)
+
+const downstreamID = "34020100001180000001"
+
+func newTestCascade(t *testing.T, cascade config.GB28181CascadeConfig) (*CascadeManager, chan string, chan string) {
+	t.Helper()
+	cascade.Enabled = true
+	m := NewCascadeManager(config.GB28181Config{DeviceID: "34020000001320000001", Cascade: cascade}, nil)
+
+	queries := make(chan string, 4)
+	forwarded := make(chan string, 4)
+	m.sendTo = func(ctx context.Context, d Downstream, body string) error {
+		queries <- d.Addr + " " + body
+		return nil
+	}
+	m.forward = func(ctx context.Context, method sip.RequestMethod, body string) error {
+		forwarded <- string(method) + " " + body
+		return nil
+	}
+	return m, queries, forwarded
+}
+
+// newDownstreamRequest builds a request from the downstream device
+func newDownstreamRequest(method sip.RequestMethod, body string) *sip.Request {
+	req := sip.NewRequest(method, sip.Uri{User: "34020000001320000001", Host: "3402000000"})
+	req.AppendHeader(&sip.FromHeader{Address: sip.Uri{User: downstreamID, Host: "3402010000"}, Params: sip.NewParams()})
+	req.AppendHeader(&sip.ToHeader{Address: sip.Uri{User: downstreamID, Host: "3402010000"}, Params: sip.NewParams()})
+	id := sip.CallIDHeader("register-1")
+	req.AppendHeader(&id)
+	req.AppendHeader(&sip.CSeqHeader{SeqNo: 1, MethodName: method})
+	if method == sip.REGISTER {
+		req.AppendHeader(&sip.ContactHeader{Address: sip.Uri{User: downstreamID, Host: "10.0.0.2", Port: 5061}})
+		req.AppendHeader(sip.NewHeader("Expires", "3600"))
+	}
+	if body != "" {
+		req.AppendHeader(sip.NewHeader("Content-Type", "Application/MANSCDP+xml"))
+		req.SetBody([]byte(body))
+	}
+	return req
+}
+
+func receive(t *testing.T, ch chan string) string {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out")
+		return ""
+	}
+}
+
+func TestCascade_RegisterWithDigest(t *testing.T) {
+	m, queries, _ := newTestCascade(t, config.GB28181CascadeConfig{Password: "secret"})
+	h := NewRequestHandler(NewDeviceManager("34020000001320000001"), NewSubscriptionManager(), nil)
+	h.SetCascadeManager(m)
+
+	resp := h.HandleRequest(newDownstreamRequest(sip.REGISTER, ""))
+	if resp.StatusCode != 401 {
+		t.Fatalf("REGISTER without credentials = %d, want 401", resp.StatusCode)
+	}
+	challenge := resp.GetHeader("WWW-Authenticate").Value()
+	if !strings.Contains(challenge, `realm="3402000000"`) {
+		t.Errorf("Challenge = %s", challenge)
+	}
+
+	wrong := NewDigestAuth(downstreamID, "wrong")
+	if err := wrong.ParseChallenge(challenge); err != nil {
+		t.Fatal(err)
+	}
+	req := newDownstreamRequest(sip.REGISTER, "")
+	req.AppendHeader(sip.NewHeader("Authorization", wrong.GenerateResponse("REGISTER", "sip:34020000001320000001@3402000000")))
+	if resp := h.HandleRequest(req); resp.StatusCode != 401 {
+		t.Errorf("REGISTER with a wrong password = %d, want 401", resp.StatusCode)
+	}
+
+	auth := NewDigestAuth(downstreamID, "secret")
+	if err := auth.ParseChallenge(challenge + `, qop="auth"`); err != nil {
+		t.Fatal(err)
+	}
+	req = newDownstreamRequest(sip.REGISTER, "")
+	req.AppendHeader(sip.NewHeader("Authorization", auth.GenerateResponse("REGISTER", "sip:34020000001320000001@3402000000")))
+	resp = h.HandleRequest(req)
+	if resp.StatusCode != 200 || resp.GetHeader("Expires") == nil || resp.GetHeader("Date") == nil {
+		t.Fatalf("Authenticated REGISTER = %d, want 200 with Expires and Date", resp.StatusCode)
+	}
+
+	query := receive(t, queries)
+	if !strings.HasPrefix(query, "10.0.0.2:5061 ") || !strings.Contains(query, "<CmdType>Catalog</CmdType>") {
+		t.Errorf("Catalog query = %s", query)
+	}
+	if d := m.Downstreams(); len(d) != 1 || d[0].DeviceID != downstreamID {
+		t.Errorf("Downstreams = %+v", d)
+	}
+}
+
+func TestCascade_RegisterRejected(t *testing.T) {
+	h := NewRequestHandler(NewDeviceManager("34020000001320000001"), NewSubscriptionManager(), nil)
+	if resp := h.HandleRequest(newDownstreamRequest(sip.REGISTER, "")); resp.StatusCode != 405 {
+		t.Errorf("REGISTER without cascading = %d, want 405", resp.StatusCode)
+	}
+
+	m, _, _ := newTestCascade(t, config.GB28181CascadeConfig{AllowedIDs: []string{"34020100001180000002"}})
+	h.SetCascadeManager(m)
+	if resp := h.HandleRequest(newDownstreamRequest(sip.REGISTER, "")); resp.StatusCode != 403 {
+		t.Errorf("REGISTER from a device not allowed = %d, want 403", resp.StatusCode)
+	}
+
+	keepalive, _ := gbxml.NewKeepaliveNotify(downstreamID, 1).Marshal()
+	if resp := h.HandleRequest(newDownstreamRequest(sip.MESSAGE, keepalive)); resp.StatusCode != 403 {
+		t.Errorf("Keepalive from an unregistered device = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestCascade_CatalogAndForwarding(t *testing.T) {
+	m, queries, forwarded := newTestCascade(t, config.GB28181CascadeConfig{KeepaliveTimeout: 60})
+	now := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	m.SetLocation(time.FixedZone("CST", 8*3600))
+	h := NewRequestHandler(NewDeviceManager("34020000001320000001"), NewSubscriptionManager(), nil)
+	h.SetCascadeManager(m)
+
+	resp := h.HandleRequest(newDownstreamRequest(sip.REGISTER, ""))
+	if resp.StatusCode != 200 {
+		t.Fatalf("REGISTER = %d, want 200", resp.StatusCode)
+	}
+	if date := resp.GetHeader("Date"); date == nil || date.Value() != "2026-01-01T16:00:00.000" {
+		t.Errorf("Date = %v, want the gateway's local time", date)
+	}
+	receive(t, queries)
+	sn := m.Downstreams()[0].catalogSN
+
+	// A catalog of two channels split over two messages
+	for i, id := range []string{"34020100001310000001", "34020100001310000002"} {
+		part := gbxml.NewCatalogResponse(downstreamID, sn, []gbxml.CatalogItem{
+			gbxml.NewCatalogItem(id, "Camera", "", "340201", true),
+		})
+		part.SumNum = 2
+		body, _ := part.Marshal()
+		if resp := h.HandleRequest(newDownstreamRequest(sip.MESSAGE, body)); resp.StatusCode != 200 {
+			t.Fatalf("Catalog part %d = %d, want 200", i, resp.StatusCode)
+		}
+		if got := len(m.Channels("34020000001320000001", "340200")); i == 0 && got != 1 {
+			t.Errorf("Incomplete catalog should not be published, got %d items", got)
+		}
+	}
+
+	items := m.Channels("34020000001320000001", "340200")
+	if len(items) != 3 {
+		t.Fatalf("Channels = %+v, want the device and 2 channels", items)
+	}
+	if items[0].DeviceID != downstreamID || items[0].Parental != 1 || items[0].ParentID != "34020000001320000001" {
+		t.Errorf("Downstream item = %+v", items[0])
+	}
+	if items[1].ParentID != downstreamID || items[1].Status != "ON" {
+		t.Errorf("Channel item = %+v", items[1])
+	}
+
+	// Positions are re-published upstream unchanged
+	position := `<?xml version="1.0"?><Notify><CmdType>MobilePosition</CmdType><SN>3</SN>` +
+		`<DeviceID>34020100001310000001</DeviceID><Longitude>113.9</Longitude></Notify>`
+	if resp := h.HandleRequest(newDownstreamRequest(sip.MESSAGE, position)); resp.StatusCode != 200 {
+		t.Fatalf("MobilePosition = %d, want 200", resp.StatusCode)
+	}
+	if got := receive(t, forwarded); got != "NOTIFY "+position {
+		t.Errorf("Forwarded = %s", got)
+	}
+
+	// Without keepalives the device goes offline
+	now = now.Add(2 * time.Minute)
+	items = m.Channels("34020000001320000001", "340200")
+	if items[0].Status != "OFF" || items[2].Status != "OFF" {
+		t.Errorf("Channels of a silent device should be OFF: %+v", items)
+	}
+	keepalive, _ := gbxml.NewKeepaliveNotify(downstreamID, 2).Marshal()
+	if resp := h.HandleRequest(newDownstreamRequest(sip.MESSAGE, keepalive)); resp.StatusCode != 200 {
+		t.Fatalf("Keepalive = %d, want 200", resp.StatusCode)
+	}
+	if items = m.Channels("34020000001320000001", "340200"); items[0].Status != "ON" {
+		t.Errorf("Keepalive should bring the device online: %+v", items[0])
+	}
+
+	// Unregistering removes the device
+	req := newDownstreamRequest(sip.REGISTER, "")
+	req.ReplaceHeader(sip.NewHeader("Expires", "0"))
+	h.HandleRequest(req)
+	if len(m.Downstreams()) != 0 {
+		t.Error("REGISTER with Expires: 0 should remove the device")
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	auth := NewDigestAuth("user", "password")
+	if err := auth.ParseChallenge(`Digest realm="3402000000", nonce="abc123"`); err != nil {
+		t.Fatal(err)
+	}
+	params := ParseDigestParams(auth.GenerateResponse("REGISTER", "sip:34020000002000000001@3402000000"))
+	if params["username"] != "user" || params["uri"] != "sip:34020000002000000001@3402000000" {
+		t.Errorf("ParseDigestParams() = %v", params)
+	}
+	if !VerifyDigest(params, "REGISTER", "3402000000", "abc123", "password") {
+		t.Error("VerifyDigest() rejected a valid response")
+	}
+	if VerifyDigest(params, "REGISTER", "3402000000", "abc123", "other") {
+		t.Error("VerifyDigest() accepted a wrong password")
+	}
+	if VerifyDigest(params, "REGISTER", "3402000000", "def456", "password") {
+		t.Error("VerifyDigest() accepted a different nonce")
+	}
+}
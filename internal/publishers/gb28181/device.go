@@ -5,7 +5,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // Channel represents a GB28181 channel (corresponds to a drone)
@@ -25,6 +26,8 @@ type DeviceManager struct {
 	channels  map[string]*Channel // Map of drone ID to channel
 	mu        sync.RWMutex
 	nextSeq   int // Next channel sequence number
+
+	onChange func(ch Channel, event string) // Called with a catalog event when a channel is added or changes status
 }
 
 // NewDeviceManager creates a new device manager
@@ -41,14 +44,22 @@ func NewDeviceManager(gatewayID string) *DeviceManager {
 	}
 }
 
-// UpdateDrone updates or creates a channel for the given drone state
-func (dm *DeviceManager) UpdateDrone(state *models.DroneState) *Channel {
+// SetChangeCallback sets the function called when a channel is added
+// (event ADD), comes back online (ON) or goes offline (OFF)
+func (dm *DeviceManager) SetChangeCallback(fn func(ch Channel, event string)) {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
+	dm.onChange = fn
+}
 
+// UpdateDrone updates or creates a channel for the given drone state
+func (dm *DeviceManager) UpdateDrone(state *models.DroneState) *Channel {
+	dm.mu.Lock()
 	ch, exists := dm.channels[state.DeviceID]
+	event := ""
 	if !exists {
 		// Create new channel with generated ID
+		event = gbxml.CatalogEventAdd
 		channelID := dm.generateChannelID()
 		ch = &Channel{
 			DeviceID: channelID,
@@ -57,12 +68,19 @@ func (dm *DeviceManager) UpdateDrone(state *models.DroneState) *Channel {
 			Online:   true,
 		}
 		dm.channels[state.DeviceID] = ch
+	} else if !ch.Online {
+		event = gbxml.CatalogEventOn
 	}
 
 	ch.LastUpdate = time.Now()
 	ch.LastState = state
 	ch.Online = true
+	changed, onChange := *ch, dm.onChange
+	dm.mu.Unlock()
 
+	if event != "" && onChange != nil {
+		onChange(changed, event)
+	}
 	return ch
 }
 
@@ -73,6 +91,18 @@ func (dm *DeviceManager) GetChannel(droneID string) *Channel {
 	return dm.channels[droneID]
 }
 
+// GetChannelByID returns the channel with the given 20-digit channel ID
+func (dm *DeviceManager) GetChannelByID(channelID string) *Channel {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+	for _, ch := range dm.channels {
+		if ch.DeviceID == channelID {
+			return ch
+		}
+	}
+	return nil
+}
+
 // GetAllChannels returns all channels
 func (dm *DeviceManager) GetAllChannels() []*Channel {
 	dm.mu.RLock()
@@ -102,12 +132,20 @@ func (dm *DeviceManager) GetOnlineChannels() []*Channel {
 // MarkOffline marks a channel as offline based on timeout
 func (dm *DeviceManager) MarkOffline(timeout time.Duration) {
 	dm.mu.Lock()
-	defer dm.mu.Unlock()
-
 	now := time.Now()
+	var offline []Channel
 	for _, ch := range dm.channels {
 		if ch.Online && now.Sub(ch.LastUpdate) > timeout {
 			ch.Online = false
+			offline = append(offline, *ch)
+		}
+	}
+	onChange := dm.onChange
+	dm.mu.Unlock()
+
+	if onChange != nil {
+		for _, ch := range offline {
+			onChange(ch, gbxml.CatalogEventOff)
 		}
 	}
 }
@@ -2,7 +2,9 @@ package gb28181
 
 import (
 	"context"
-	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
 	"log"
 	"regexp"
 	"strconv"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/emiago/sipgo/sip"
 
+	"github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/media"
 	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
 )
 
@@ -18,6 +21,15 @@ type RequestHandler struct {
 	deviceMgr *DeviceManager
 	subMgr    *SubscriptionManager
 	sipClient *SIPClient
+	streams   *StreamManager  // nil when video is disabled
+	cascade   *CascadeManager // nil when cascading is disabled
+	params    *DeviceParams   // nil when configuration is not exposed
+
+	// reply sends a MESSAGE to the platform, notify a NOTIFY for the given
+	// event package, and reboot handles a TeleBoot command
+	reply  func(ctx context.Context, body string) error
+	notify func(ctx context.Context, event, body string) error
+	reboot func()
 }
 
 // NewRequestHandler creates a new request handler
@@ -26,11 +38,52 @@ func NewRequestHandler(deviceMgr *DeviceManager, subMgr *SubscriptionManager, si
 		deviceMgr: deviceMgr,
 		subMgr:    subMgr,
 		sipClient: sipClient,
+		reply: func(ctx context.Context, body string) error {
+			return sipClient.SendMessage(ctx, "Application/MANSCDP+xml", body)
+		},
+		notify: func(ctx context.Context, event, body string) error {
+			return sipClient.SendNotifyEvent(ctx, event, "Application/MANSCDP+xml", body)
+		},
+		// The gateway cannot restart its host; it restarts its session
+		// with the platform by registering again
+		reboot: func() {
+			sipClient.MarkUnregistered(errors.New("TeleBoot requested by platform"))
+		},
 	}
 }
 
+// SetStreamManager enables answering INVITEs for live video
+func (h *RequestHandler) SetStreamManager(streams *StreamManager) {
+	h.streams = streams
+}
+
+// SetCascadeManager enables accepting downstream devices
+func (h *RequestHandler) SetCascadeManager(cascade *CascadeManager) {
+	h.cascade = cascade
+}
+
+// SetDeviceParams enables ConfigDownload and DeviceConfig
+func (h *RequestHandler) SetDeviceParams(params *DeviceParams) {
+	h.params = params
+}
+
 // HandleRequest processes incoming SIP requests
 func (h *RequestHandler) HandleRequest(req *sip.Request) *sip.Response {
+	switch req.Method {
+	case sip.REGISTER:
+		if h.cascade == nil {
+			return sip.NewResponseFromRequest(req, 405, "Method Not Allowed", nil)
+		}
+		return h.cascade.HandleRegister(req)
+	case sip.INVITE:
+		return h.handleInvite(req)
+	case sip.ACK:
+		h.handleAck(req)
+		return nil
+	case sip.BYE:
+		return h.handleBye(req)
+	}
+
 	contentType := req.GetHeader("Content-Type")
 	if contentType == nil {
 		return sip.NewResponseFromRequest(req, 200, "OK", nil)
@@ -42,6 +95,13 @@ func (h *RequestHandler) HandleRequest(req *sip.Request) *sip.Response {
 		return sip.NewResponseFromRequest(req, 200, "OK", nil)
 	}
 
+	// Keepalives, catalogs and notifications from downstream devices
+	if h.cascade != nil && (req.Method == sip.MESSAGE || req.Method == sip.NOTIFY) {
+		if resp := h.cascade.HandleMessage(req, body); resp != nil {
+			return resp
+		}
+	}
+
 	// Detect message type from XML
 	if req.Method == sip.MESSAGE {
 		return h.handleMessage(req, body)
@@ -52,63 +112,102 @@ func (h *RequestHandler) HandleRequest(req *sip.Request) *sip.Response {
 	return sip.NewResponseFromRequest(req, 200, "OK", nil)
 }
 
-// handleMessage handles MESSAGE requests (queries from platform)
+// handleMessage handles MESSAGE requests (queries and controls from platform)
 func (h *RequestHandler) handleMessage(req *sip.Request, body []byte) *sip.Response {
 	// Try to detect query type
-	var query gbxml.Query
-	if err := xml.Unmarshal(body, &query); err != nil {
+	var query gbxml.Header
+	if err := gbxml.Unmarshal(body, &query); err != nil {
 		log.Printf("[GB28181] Failed to parse query XML: %v", err)
 		return sip.NewResponseFromRequest(req, 400, "Bad Request", nil)
 	}
 
-	switch query.CmdType {
-	case gbxml.CmdTypeCatalog:
+	switch root := query.XMLName.Local; {
+	case root == "Query" && query.CmdType == gbxml.CmdTypeCatalog:
 		return h.handleCatalogQuery(req, query.SN, query.DeviceID)
-	case gbxml.CmdTypeDeviceInfo:
+	case root == "Query" && query.CmdType == gbxml.CmdTypeDeviceInfo:
 		return h.handleDeviceInfoQuery(req, query.SN, query.DeviceID)
-	case gbxml.CmdTypeDeviceStatus:
+	case root == "Query" && query.CmdType == gbxml.CmdTypeDeviceStatus:
 		return h.handleDeviceStatusQuery(req, query.SN, query.DeviceID)
+	case root == "Query" && query.CmdType == gbxml.CmdTypeConfigDownload:
+		return h.handleConfigDownload(req, body)
+	case root == "Control" && query.CmdType == gbxml.CmdTypeDeviceConfig:
+		return h.handleDeviceConfig(req, body)
+	case root == "Control" && query.CmdType == gbxml.CmdTypeDeviceControl:
+		return h.handleDeviceControl(req, body)
+	case root == "Query" || root == "Control":
+		// Answer so the platform does not wait for a response that never comes
+		log.Printf("[GB28181] Unsupported %s type: %s", root, query.CmdType)
+		h.respondResult(query.CmdType, query.SN, query.DeviceID, gbxml.ResultError)
 	default:
-		log.Printf("[GB28181] Unknown query type: %s", query.CmdType)
+		log.Printf("[GB28181] Ignoring %s %s", root, query.CmdType)
 	}
 
 	return sip.NewResponseFromRequest(req, 200, "OK", nil)
 }
 
-// handleCatalogQuery responds to Catalog queries
-func (h *RequestHandler) handleCatalogQuery(req *sip.Request, sn int, deviceID string) *sip.Response {
-	log.Printf("[GB28181] Received Catalog query (SN=%d, DeviceID=%s)", sn, deviceID)
+// respond sends a response body to the platform in the background, so
+// that the SIP transaction of the request is answered first
+func (h *RequestHandler) respond(what, body string, then func()) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.reply(ctx, body); err != nil {
+			log.Printf("[GB28181] Failed to send %s response: %v", what, err)
+		}
+		if then != nil {
+			then()
+		}
+	}()
+}
 
-	// Build catalog items from registered channels
+// respondResult sends a response carrying only a result
+func (h *RequestHandler) respondResult(cmdType gbxml.CmdType, sn int, deviceID, result string) {
+	body, err := gbxml.NewResponse(cmdType, sn, deviceID, result).Marshal()
+	if err != nil {
+		log.Printf("[GB28181] Failed to marshal %s response: %v", cmdType, err)
+		return
+	}
+	h.respond(string(cmdType), body, nil)
+}
+
+// catalogItems returns the catalog of the gateway: its channels followed
+// by those of downstream devices
+func (h *RequestHandler) catalogItems() []gbxml.CatalogItem {
 	channels := h.deviceMgr.GetAllChannels()
 	items := make([]gbxml.CatalogItem, len(channels))
 	for i, ch := range channels {
-		items[i] = gbxml.NewCatalogItem(
-			ch.DeviceID,
-			ch.Name,
-			h.deviceMgr.GatewayID(),
-			h.deviceMgr.CivilCode(),
-			ch.Online,
-		)
+		items[i] = h.catalogItem(*ch)
+	}
+	if h.cascade != nil {
+		items = append(items, h.cascade.Channels(h.deviceMgr.GatewayID(), h.deviceMgr.CivilCode())...)
 	}
+	return items
+}
+
+// catalogItem describes a channel of the gateway
+func (h *RequestHandler) catalogItem(ch Channel) gbxml.CatalogItem {
+	return gbxml.NewCatalogItem(
+		ch.DeviceID,
+		ch.Name,
+		h.deviceMgr.GatewayID(),
+		h.deviceMgr.CivilCode(),
+		ch.Online,
+	)
+}
 
-	// Create response
-	catalogResp := gbxml.NewCatalogResponse(h.deviceMgr.GatewayID(), sn, items)
+// handleCatalogQuery responds to Catalog queries
+func (h *RequestHandler) handleCatalogQuery(req *sip.Request, sn int, deviceID string) *sip.Response {
+	log.Printf("[GB28181] Received Catalog query (SN=%d, DeviceID=%s)", sn, deviceID)
+
+	// Create response from registered channels
+	catalogResp := gbxml.NewCatalogResponse(h.deviceMgr.GatewayID(), sn, h.catalogItems())
 	respBody, err := catalogResp.Marshal()
 	if err != nil {
 		log.Printf("[GB28181] Failed to marshal catalog response: %v", err)
 		return sip.NewResponseFromRequest(req, 500, "Internal Server Error", nil)
 	}
 
-	// Send response via MESSAGE (async)
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := h.sipClient.SendMessage(ctx, "Application/MANSCDP+xml", respBody); err != nil {
-			log.Printf("[GB28181] Failed to send catalog response: %v", err)
-		}
-	}()
-
+	h.respond("catalog", respBody, nil)
 	return sip.NewResponseFromRequest(req, 200, "OK", nil)
 }
 
@@ -116,18 +215,11 @@ func (h *RequestHandler) handleCatalogQuery(req *sip.Request, sn int, deviceID s
 func (h *RequestHandler) handleDeviceInfoQuery(req *sip.Request, sn int, deviceID string) *sip.Response {
 	log.Printf("[GB28181] Received DeviceInfo query (SN=%d, DeviceID=%s)", sn, deviceID)
 
-	// Build device info response
-	respBody := buildDeviceInfoResponse(h.deviceMgr.GatewayID(), sn)
-
-	// Send response via MESSAGE (async)
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := h.sipClient.SendMessage(ctx, "Application/MANSCDP+xml", respBody); err != nil {
-			log.Printf("[GB28181] Failed to send device info response: %v", err)
-		}
-	}()
-
+	name := defaultDeviceName
+	if h.params != nil {
+		name = h.params.Name()
+	}
+	h.respond("device info", buildDeviceInfoResponse(h.deviceMgr.GatewayID(), name, sn), nil)
 	return sip.NewResponseFromRequest(req, 200, "OK", nil)
 }
 
@@ -135,22 +227,94 @@ func (h *RequestHandler) handleDeviceInfoQuery(req *sip.Request, sn int, deviceI
 func (h *RequestHandler) handleDeviceStatusQuery(req *sip.Request, sn int, deviceID string) *sip.Response {
 	log.Printf("[GB28181] Received DeviceStatus query (SN=%d, DeviceID=%s)", sn, deviceID)
 
-	// Build device status response
-	respBody := buildDeviceStatusResponse(h.deviceMgr.GatewayID(), sn)
+	h.respond("device status", buildDeviceStatusResponse(h.deviceMgr.GatewayID(), sn), nil)
+	return sip.NewResponseFromRequest(req, 200, "OK", nil)
+}
 
-	// Send response via MESSAGE (async)
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := h.sipClient.SendMessage(ctx, "Application/MANSCDP+xml", respBody); err != nil {
-			log.Printf("[GB28181] Failed to send device status response: %v", err)
+// handleConfigDownload reports the basic parameters. Other configuration
+// types, such as video parameters, are answered with an error.
+func (h *RequestHandler) handleConfigDownload(req *sip.Request, body []byte) *sip.Response {
+	var query gbxml.ConfigDownloadQuery
+	if err := gbxml.Unmarshal(body, &query); err != nil {
+		log.Printf("[GB28181] Failed to parse ConfigDownload query: %v", err)
+		return sip.NewResponseFromRequest(req, 400, "Bad Request", nil)
+	}
+	log.Printf("[GB28181] Received ConfigDownload query (SN=%d, ConfigType=%s)", query.SN, query.ConfigType)
+
+	resp := &gbxml.ConfigDownloadResponse{
+		CmdType:  gbxml.CmdTypeConfigDownload,
+		SN:       query.SN,
+		DeviceID: h.deviceMgr.GatewayID(),
+		Result:   gbxml.ResultError,
+	}
+	if h.params != nil && query.Wants(gbxml.ConfigTypeBasicParam) {
+		resp.Result = gbxml.ResultOK
+		resp.BasicParam = h.params.BasicParam()
+	}
+	respBody, err := resp.Marshal()
+	if err != nil {
+		log.Printf("[GB28181] Failed to marshal ConfigDownload response: %v", err)
+		return sip.NewResponseFromRequest(req, 500, "Internal Server Error", nil)
+	}
+
+	h.respond("ConfigDownload", respBody, nil)
+	return sip.NewResponseFromRequest(req, 200, "OK", nil)
+}
+
+// handleDeviceConfig applies basic parameters set by the platform
+func (h *RequestHandler) handleDeviceConfig(req *sip.Request, body []byte) *sip.Response {
+	var control gbxml.DeviceConfigControl
+	if err := gbxml.Unmarshal(body, &control); err != nil {
+		log.Printf("[GB28181] Failed to parse DeviceConfig: %v", err)
+		return sip.NewResponseFromRequest(req, 400, "Bad Request", nil)
+	}
+
+	result := gbxml.ResultOK
+	switch {
+	case h.params == nil || control.BasicParam == nil:
+		log.Printf("[GB28181] Rejecting DeviceConfig (SN=%d): only BasicParam is supported", control.SN)
+		result = gbxml.ResultError
+	default:
+		if err := h.params.Apply(control.BasicParam); err != nil {
+			log.Printf("[GB28181] Rejecting DeviceConfig (SN=%d): %v", control.SN, err)
+			result = gbxml.ResultError
+		} else {
+			log.Printf("[GB28181] Applied DeviceConfig (SN=%d): %+v", control.SN, *h.params.BasicParam())
 		}
-	}()
+	}
 
+	h.respondResult(gbxml.CmdTypeDeviceConfig, control.SN, h.deviceMgr.GatewayID(), result)
 	return sip.NewResponseFromRequest(req, 200, "OK", nil)
 }
 
-// handleSubscribe handles SUBSCRIBE requests for position updates
+// handleDeviceControl executes a remote control command. Only TeleBoot is
+// supported: the gateway has no camera to steer or record.
+func (h *RequestHandler) handleDeviceControl(req *sip.Request, body []byte) *sip.Response {
+	var control gbxml.DeviceControl
+	if err := gbxml.Unmarshal(body, &control); err != nil {
+		log.Printf("[GB28181] Failed to parse DeviceControl: %v", err)
+		return sip.NewResponseFromRequest(req, 400, "Bad Request", nil)
+	}
+
+	if control.TeleBoot != "Boot" {
+		log.Printf("[GB28181] Rejecting unsupported DeviceControl %q (SN=%d)", control.Command(), control.SN)
+		h.respondResult(gbxml.CmdTypeDeviceControl, control.SN, control.DeviceID, gbxml.ResultError)
+		return sip.NewResponseFromRequest(req, 200, "OK", nil)
+	}
+
+	respBody, err := gbxml.NewResponse(gbxml.CmdTypeDeviceControl, control.SN, control.DeviceID, gbxml.ResultOK).Marshal()
+	if err != nil {
+		log.Printf("[GB28181] Failed to marshal DeviceControl response: %v", err)
+		return sip.NewResponseFromRequest(req, 500, "Internal Server Error", nil)
+	}
+
+	// Re-register once the platform has the response
+	log.Printf("[GB28181] Received TeleBoot (SN=%d), re-registering", control.SN)
+	h.respond("DeviceControl", respBody, h.reboot)
+	return sip.NewResponseFromRequest(req, 200, "OK", nil)
+}
+
+// handleSubscribe handles SUBSCRIBE requests for position updates and alarms
 func (h *RequestHandler) handleSubscribe(req *sip.Request) *sip.Response {
 	log.Printf("[GB28181] Received SUBSCRIBE request")
 
@@ -199,9 +363,34 @@ func (h *RequestHandler) handleSubscribe(req *sip.Request) *sip.Response {
 		Expires:   time.Now().Add(time.Duration(expires) * time.Second),
 		EventType: eventType,
 	}
+
+	// Alarm and catalog subscriptions carry a Query body
+	var query gbxml.Query
+	if len(body) > 0 && gbxml.Unmarshal(body, &query) != nil {
+		query = gbxml.Query{} // Not a MANSCDP body
+	}
+	switch query.CmdType {
+	case gbxml.CmdTypeAlarm:
+		var alarm gbxml.AlarmQuery
+		if err := gbxml.Unmarshal(body, &alarm); err != nil {
+			log.Printf("[GB28181] Failed to parse alarm subscription: %v", err)
+			return sip.NewResponseFromRequest(req, 400, "Bad Request", nil)
+		}
+		sub.Alarm = &alarm
+		log.Printf("[GB28181] Alarm subscription created: ID=%s, Priority=%d-%d, Method=%q, Expires=%v",
+			sub.ID, alarm.StartAlarmPriority, alarm.EndAlarmPriority, alarm.AlarmMethod, sub.Expires)
+	case gbxml.CmdTypeCatalog:
+		sub.Catalog = true
+		log.Printf("[GB28181] Catalog subscription created: ID=%s, Expires=%v", sub.ID, sub.Expires)
+	default:
+		log.Printf("[GB28181] Subscription created: ID=%s, Interval=%ds, Expires=%v", sub.ID, sub.Interval, sub.Expires)
+	}
 	h.subMgr.Add(sub)
 
-	log.Printf("[GB28181] Subscription created: ID=%s, Interval=%ds, Expires=%v", sub.ID, sub.Interval, sub.Expires)
+	// A new catalog subscription first receives the whole catalog
+	if sub.Catalog && expires > 0 {
+		h.sendCatalogNotify(query.SN, h.catalogItems())
+	}
 
 	// Create 200 OK response with Expires header
 	resp := sip.NewResponseFromRequest(req, 200, "OK", nil)
@@ -210,15 +399,105 @@ func (h *RequestHandler) handleSubscribe(req *sip.Request) *sip.Response {
 	return resp
 }
 
+// NotifyCatalogChange reports a channel that was added, came online or
+// went offline to the catalog subscriptions. It is the DeviceManager
+// change callback.
+func (h *RequestHandler) NotifyCatalogChange(ch Channel, event string) {
+	if len(h.subMgr.GetCatalogSubscriptions()) == 0 {
+		return
+	}
+	item := h.catalogItem(ch)
+	item.Event = event
+	h.sendCatalogNotify(h.sipClient.NextSN(), []gbxml.CatalogItem{item})
+}
+
+// sendCatalogNotify sends a catalog notification in the background
+func (h *RequestHandler) sendCatalogNotify(sn int, items []gbxml.CatalogItem) {
+	body, err := gbxml.NewCatalogNotify(h.deviceMgr.GatewayID(), sn, items).Marshal()
+	if err != nil {
+		log.Printf("[GB28181] Failed to marshal catalog notify: %v", err)
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.notify(ctx, "Catalog", body); err != nil {
+			log.Printf("[GB28181] Failed to send catalog notify: %v", err)
+		}
+	}()
+}
+
+// handleInvite answers a live video INVITE with an SDP offer of PS over RTP
+func (h *RequestHandler) handleInvite(req *sip.Request) *sip.Response {
+	if h.streams == nil {
+		log.Printf("[GB28181] Rejecting INVITE: video is disabled")
+		return sip.NewResponseFromRequest(req, 488, "Not Acceptable Here", nil)
+	}
+
+	channelID := req.Recipient.User
+	ch := h.deviceMgr.GetChannelByID(channelID)
+	if ch == nil {
+		log.Printf("[GB28181] Rejecting INVITE: unknown channel %s", channelID)
+		return sip.NewResponseFromRequest(req, 404, "Not Found", nil)
+	}
+
+	offer, err := media.ParseSDP(req.Body())
+	if err == nil && req.CallID() == nil {
+		err = fmt.Errorf("missing Call-ID")
+	}
+	if err != nil {
+		log.Printf("[GB28181] Rejecting INVITE for %s: %v", channelID, err)
+		return sip.NewResponseFromRequest(req, 400, "Bad Request", nil)
+	}
+
+	answer, err := h.streams.Invite(req.CallID().Value(), ch, offer)
+	if err != nil {
+		log.Printf("[GB28181] Rejecting INVITE for %s: %v", channelID, err)
+		switch {
+		case errors.Is(err, ErrNoMediaPort):
+			return sip.NewResponseFromRequest(req, 486, "Busy Here", nil)
+		case errors.Is(err, ErrNoVideoSource):
+			return sip.NewResponseFromRequest(req, 404, "Not Found", nil)
+		default:
+			return sip.NewResponseFromRequest(req, 488, "Not Acceptable Here", nil)
+		}
+	}
+
+	resp := sip.NewResponseFromRequest(req, 200, "OK", answer.Marshal())
+	resp.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+	if h.sipClient != nil {
+		resp.AppendHeader(sip.NewHeader("Contact", fmt.Sprintf("<sip:%s@%s>", channelID, h.sipClient.localAddr)))
+	}
+	return resp
+}
+
+// handleAck starts streaming once the platform acknowledges the INVITE answer
+func (h *RequestHandler) handleAck(req *sip.Request) {
+	if h.streams == nil || req.CallID() == nil {
+		return
+	}
+	if err := h.streams.Play(req.CallID().Value()); err != nil {
+		log.Printf("[GB28181] ACK for %s: %v", req.CallID().Value(), err)
+	}
+}
+
+// handleBye stops a live video session
+func (h *RequestHandler) handleBye(req *sip.Request) *sip.Response {
+	if h.streams == nil || req.CallID() == nil || !h.streams.Bye(req.CallID().Value()) {
+		return sip.NewResponseFromRequest(req, 481, "Call/Transaction Does Not Exist", nil)
+	}
+	return sip.NewResponseFromRequest(req, 200, "OK", nil)
+}
+
 // buildDeviceInfoResponse builds a DeviceInfo response XML
-func buildDeviceInfoResponse(deviceID string, sn int) string {
+func buildDeviceInfoResponse(deviceID, name string, sn int) string {
 	return gbxml.XMLDeclaration + "\r\n" +
 		`<Response>
   <CmdType>DeviceInfo</CmdType>
   <SN>` + strconv.Itoa(sn) + `</SN>
   <DeviceID>` + deviceID + `</DeviceID>
   <Result>OK</Result>
-  <DeviceName>UAV-Gateway</DeviceName>
+  <DeviceName>` + html.EscapeString(name) + `</DeviceName>
   <Manufacturer>OUTB</Manufacturer>
   <Model>v1.0</Model>
   <Firmware>1.0.0</Firmware>
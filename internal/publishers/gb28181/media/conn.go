@@ -0,0 +1,143 @@
+package media
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Media transports negotiated in SDP
+const (
+	TransportUDP        = "udp"         // RTP/AVP
+	TransportTCPActive  = "tcp-active"  // TCP/RTP/AVP, we connect to the platform
+	TransportTCPPassive = "tcp-passive" // TCP/RTP/AVP, the platform connects to us
+)
+
+// acceptTimeout bounds how long a passive TCP session waits for the platform
+const acceptTimeout = 10 * time.Second
+
+// Conn is the local media port of one session. RTP over TCP uses the
+// RFC 4571 two-byte length framing expected by GB28181 platforms.
+type Conn struct {
+	transport string
+	port      int
+	remote    string
+
+	udp      *net.UDPConn
+	udpDest  *net.UDPAddr
+	listener net.Listener
+
+	mu  sync.Mutex
+	tcp net.Conn
+}
+
+// Bind reserves the local port for a session. UDP and passive TCP bind
+// immediately so the port announced in SDP is known to be usable.
+func Bind(transport string, port int, remote string) (*Conn, error) {
+	c := &Conn{transport: transport, port: port, remote: remote}
+	local := fmt.Sprintf(":%d", port)
+
+	switch transport {
+	case TransportUDP:
+		dest, err := net.ResolveUDPAddr("udp", remote)
+		if err != nil {
+			return nil, fmt.Errorf("resolve media destination: %w", err)
+		}
+		addr, _ := net.ResolveUDPAddr("udp", local)
+		conn, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("bind media port %d: %w", port, err)
+		}
+		c.udp, c.udpDest = conn, dest
+	case TransportTCPPassive:
+		ln, err := net.Listen("tcp", local)
+		if err != nil {
+			return nil, fmt.Errorf("bind media port %d: %w", port, err)
+		}
+		c.listener = ln
+	case TransportTCPActive:
+		// Dialed from the reserved port on Connect
+	default:
+		return nil, fmt.Errorf("unsupported media transport %q", transport)
+	}
+	return c, nil
+}
+
+// Connect establishes TCP sessions; it is a no-op for UDP
+func (c *Conn) Connect(ctx context.Context) error {
+	switch c.transport {
+	case TransportTCPActive:
+		dialer := net.Dialer{
+			Timeout:   acceptTimeout,
+			LocalAddr: &net.TCPAddr{Port: c.port},
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", c.remote)
+		if err != nil {
+			return fmt.Errorf("connect media to %s: %w", c.remote, err)
+		}
+		c.setTCP(conn)
+	case TransportTCPPassive:
+		if tl, ok := c.listener.(*net.TCPListener); ok {
+			tl.SetDeadline(time.Now().Add(acceptTimeout))
+		}
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return fmt.Errorf("accept media connection on port %d: %w", c.port, err)
+		}
+		c.setTCP(conn)
+	}
+	return nil
+}
+
+func (c *Conn) setTCP(conn net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tcp = conn
+}
+
+// WritePacket sends one RTP packet
+func (c *Conn) WritePacket(pkt []byte) error {
+	if c.udp != nil {
+		_, err := c.udp.WriteToUDP(pkt, c.udpDest)
+		return err
+	}
+
+	c.mu.Lock()
+	conn := c.tcp
+	c.mu.Unlock()
+	if conn == nil {
+		return fmt.Errorf("media connection not established")
+	}
+	framed := binary.BigEndian.AppendUint16(make([]byte, 0, len(pkt)+2), uint16(len(pkt)))
+	_, err := conn.Write(append(framed, pkt...))
+	return err
+}
+
+// Port returns the local media port
+func (c *Conn) Port() int {
+	return c.port
+}
+
+// Transport returns the negotiated transport
+func (c *Conn) Transport() string {
+	return c.transport
+}
+
+// Close releases the socket(s)
+func (c *Conn) Close() error {
+	if c.udp != nil {
+		c.udp.Close()
+	}
+	if c.listener != nil {
+		c.listener.Close()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tcp != nil {
+		c.tcp.Close()
+	}
+	return nil
+}
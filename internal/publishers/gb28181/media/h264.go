@@ -0,0 +1,146 @@
+package media
+
+import "fmt"
+
+// H.264 NAL unit types (ITU-T H.264 Table 7-1, RFC 6184)
+const (
+	naluTypeIDR  = 5
+	naluTypeSPS  = 7
+	naluTypePPS  = 8
+	naluTypeAUD  = 9
+	naluTypeSTAP = 24
+	naluTypeFUA  = 28
+)
+
+// Frame is one H.264 access unit
+type Frame struct {
+	NALUs    [][]byte // NAL units without start codes
+	PTS      uint32   // Presentation time on the 90 kHz clock
+	Keyframe bool
+}
+
+// H264Depacketizer reassembles RFC 6184 RTP packets into access units.
+// Frames end on the RTP marker bit or when the timestamp changes.
+type H264Depacketizer struct {
+	emit func(Frame)
+
+	sps, pps []byte // Out-of-band or last seen parameter sets
+
+	nalus   [][]byte
+	ts      uint32
+	fu      []byte
+	lastSeq uint16
+	started bool
+}
+
+// NewH264Depacketizer creates a depacketizer that calls emit for every frame
+func NewH264Depacketizer(emit func(Frame)) *H264Depacketizer {
+	return &H264Depacketizer{emit: emit}
+}
+
+// SetParameterSets sets SPS/PPS announced out of band (sprop-parameter-sets).
+// They are inserted before keyframes that do not carry their own.
+func (d *H264Depacketizer) SetParameterSets(sps, pps []byte) {
+	d.sps = sps
+	d.pps = pps
+}
+
+// Push adds one RTP packet
+func (d *H264Depacketizer) Push(pkt *RTPPacket) error {
+	if d.started {
+		if pkt.Sequence != d.lastSeq+1 {
+			d.fu = nil // Lost fragments; drop the partial NAL unit
+		}
+		if pkt.Timestamp != d.ts {
+			d.flush()
+		}
+	}
+	d.started = true
+	d.lastSeq = pkt.Sequence
+	d.ts = pkt.Timestamp
+
+	if err := d.depacketize(pkt.Payload); err != nil {
+		return err
+	}
+	if pkt.Marker {
+		d.flush()
+	}
+	return nil
+}
+
+// depacketize extracts NAL units from one RTP payload
+func (d *H264Depacketizer) depacketize(payload []byte) error {
+	if len(payload) < 1 {
+		return fmt.Errorf("empty H.264 RTP payload")
+	}
+
+	switch typ := payload[0] & 0x1F; {
+	case typ >= 1 && typ <= 23:
+		d.add(payload)
+
+	case typ == naluTypeSTAP:
+		buf := payload[1:]
+		for len(buf) > 2 {
+			size := int(buf[0])<<8 | int(buf[1])
+			if size == 0 || len(buf) < 2+size {
+				return fmt.Errorf("invalid STAP-A NAL unit size %d", size)
+			}
+			d.add(buf[2 : 2+size])
+			buf = buf[2+size:]
+		}
+
+	case typ == naluTypeFUA:
+		if len(payload) < 2 {
+			return fmt.Errorf("FU-A packet too short")
+		}
+		header := payload[1]
+		if header&0x80 != 0 {
+			d.fu = append([]byte{payload[0]&0xE0 | header&0x1F}, payload[2:]...)
+		} else if d.fu != nil {
+			d.fu = append(d.fu, payload[2:]...)
+		}
+		if header&0x40 != 0 && d.fu != nil {
+			d.add(d.fu)
+			d.fu = nil
+		}
+
+	default:
+		return fmt.Errorf("unsupported H.264 packetization type %d", typ)
+	}
+	return nil
+}
+
+// add queues a NAL unit for the current access unit
+func (d *H264Depacketizer) add(nalu []byte) {
+	switch nalu[0] & 0x1F {
+	case naluTypeSPS:
+		d.sps = append([]byte(nil), nalu...)
+	case naluTypePPS:
+		d.pps = append([]byte(nil), nalu...)
+	case naluTypeAUD:
+		return // The PS muxer delimits access units itself
+	}
+	d.nalus = append(d.nalus, append([]byte(nil), nalu...))
+}
+
+// flush emits the current access unit
+func (d *H264Depacketizer) flush() {
+	if len(d.nalus) == 0 {
+		return
+	}
+	f := Frame{NALUs: d.nalus, PTS: d.ts}
+	hasSPS := false
+	for _, n := range d.nalus {
+		switch n[0] & 0x1F {
+		case naluTypeIDR:
+			f.Keyframe = true
+		case naluTypeSPS:
+			hasSPS = true
+		}
+	}
+	if f.Keyframe && !hasSPS && d.sps != nil && d.pps != nil {
+		f.NALUs = append([][]byte{d.sps, d.pps}, f.NALUs...)
+	}
+	d.nalus = nil
+	d.emit(f)
+}
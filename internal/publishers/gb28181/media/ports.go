@@ -0,0 +1,63 @@
+package media
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PortPool hands out local media ports from a fixed range. Ports are
+// allocated round-robin so a just-released port is not reused immediately.
+type PortPool struct {
+	min, max int
+
+	mu   sync.Mutex
+	next int
+	used map[int]bool
+}
+
+// NewPortPool creates a pool for the inclusive range [min, max]
+func NewPortPool(min, max int) *PortPool {
+	if max < min {
+		max = min
+	}
+	return &PortPool{min: min, max: max, next: min, used: make(map[int]bool)}
+}
+
+// Acquire reserves a free port
+func (p *PortPool) Acquire() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	size := p.max - p.min + 1
+	for i := 0; i < size; i++ {
+		port := p.next
+		p.next++
+		if p.next > p.max {
+			p.next = p.min
+		}
+		if !p.used[port] {
+			p.used[port] = true
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free media port in %d-%d", p.min, p.max)
+}
+
+// Release returns a port to the pool
+func (p *PortPool) Release(port int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.used, port)
+}
+
+// Size returns the number of ports in the pool
+func (p *PortPool) Size() int {
+	return p.max - p.min + 1
+}
+
+// InUse returns the number of reserved ports
+func (p *PortPool) InUse() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.used)
+}
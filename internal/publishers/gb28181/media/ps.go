@@ -0,0 +1,130 @@
+package media
+
+import "encoding/binary"
+
+const (
+	// StreamTypeH264 is the PSM stream_type for H.264 video
+	StreamTypeH264 = 0x1B
+
+	psVideoStreamID = 0xE0
+	psMuxRate       = 6106 // In units of 50 bytes/s, as used by most GB28181 devices
+
+	// maxPESPayload keeps PES_packet_length within 16 bits
+	// (3 bytes of flags + 5 bytes of PTS precede the payload)
+	maxPESPayload = 0xFFFF - 8
+)
+
+// PSMuxer wraps H.264 access units into MPEG-2 program stream packs as
+// required by GB/T 28181. Keyframes carry a system header and program
+// stream map so the platform can start decoding from them.
+type PSMuxer struct{}
+
+// NewPSMuxer creates a program stream muxer
+func NewPSMuxer() *PSMuxer {
+	return &PSMuxer{}
+}
+
+// Mux encodes one access unit as a PS pack, using the frame PTS as SCR
+func (m *PSMuxer) Mux(f Frame) []byte {
+	var es []byte
+	for _, nalu := range f.NALUs {
+		es = append(es, 0x00, 0x00, 0x00, 0x01)
+		es = append(es, nalu...)
+	}
+
+	pts := uint64(f.PTS)
+	buf := packHeader(pts)
+	if f.Keyframe {
+		buf = append(buf, systemHeader()...)
+		buf = append(buf, programStreamMap()...)
+	}
+
+	first := true
+	for len(es) > 0 {
+		n := min(len(es), maxPESPayload)
+		buf = append(buf, pesHeader(n, pts, first)...)
+		buf = append(buf, es[:n]...)
+		es = es[n:]
+		first = false
+	}
+	return buf
+}
+
+// packHeader builds the 14-byte pack header with SCR = pts
+func packHeader(scr uint64) []byte {
+	b := []byte{0x00, 0x00, 0x01, 0xBA, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xF8}
+	b[4] = 0x44 | byte((scr>>30)&0x07)<<3 | byte((scr>>28)&0x03)
+	b[5] = byte(scr >> 20)
+	b[6] = byte((scr>>15)&0x1F)<<3 | 0x04 | byte((scr>>13)&0x03)
+	b[7] = byte(scr >> 5)
+	b[8] = byte(scr&0x1F)<<3 | 0x04 // SCR extension = 0
+	b[9] = 0x01
+	b[10] = byte(psMuxRate >> 14)
+	b[11] = byte(psMuxRate >> 6)
+	b[12] = byte(psMuxRate&0x3F)<<2 | 0x03
+	return b
+}
+
+// systemHeader builds a system header declaring one video stream
+func systemHeader() []byte {
+	rateBound := psMuxRate
+	return []byte{
+		0x00, 0x00, 0x01, 0xBB,
+		0x00, 0x09, // header_length
+		0x80 | byte(rateBound>>15), byte(rateBound >> 7), byte(rateBound&0x7F)<<1 | 0x01,
+		0x04,                     // audio_bound=1, fixed=0, CSPS=0
+		0xE1,                     // audio/video lock, marker, video_bound=1
+		0xFF,                     // packet_rate_restriction + reserved
+		psVideoStreamID,          // stream_id
+		0xE0 | 0x20 | 0x04, 0x00, // P-STD buffer scale 1, size bound 1024 KB
+	}
+}
+
+// programStreamMap builds a PSM mapping stream 0xE0 to H.264
+func programStreamMap() []byte {
+	b := []byte{
+		0x00, 0x00, 0x01, 0xBC,
+		0x00, 0x0E, // program_stream_map_length
+		0xE0,       // current_next_indicator + version 0
+		0xFF,       // reserved + marker
+		0x00, 0x00, // program_stream_info_length
+		0x00, 0x04, // elementary_stream_map_length
+		StreamTypeH264, psVideoStreamID, 0x00, 0x00,
+	}
+	return binary.BigEndian.AppendUint32(b, crc32MPEG(b))
+}
+
+// pesHeader builds a video PES header. Only the first PES of an access
+// unit carries the PTS.
+func pesHeader(payloadLen int, pts uint64, withPTS bool) []byte {
+	if !withPTS {
+		length := payloadLen + 3
+		return []byte{0x00, 0x00, 0x01, psVideoStreamID, byte(length >> 8), byte(length), 0x80, 0x00, 0x00}
+	}
+	length := payloadLen + 8
+	return []byte{
+		0x00, 0x00, 0x01, psVideoStreamID, byte(length >> 8), byte(length),
+		0x80, 0x80, 0x05,
+		0x21 | byte((pts>>29)&0x0E),
+		byte(pts >> 22),
+		byte((pts>>14)&0xFE) | 0x01,
+		byte(pts >> 7),
+		byte((pts<<1)&0xFE) | 0x01,
+	}
+}
+
+// crc32MPEG computes the CRC-32/MPEG-2 used by program stream maps
+func crc32MPEG(data []byte) uint32 {
+	crc := uint32(0xFFFFFFFF)
+	for _, b := range data {
+		crc ^= uint32(b) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,100 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+)
+
+const (
+	rtpHeaderSize = 12
+
+	// DefaultMaxPayload keeps RTP packets below a typical 1500 byte MTU
+	DefaultMaxPayload = 1400
+)
+
+// RTPPacket is a parsed RTP packet
+type RTPPacket struct {
+	Marker      bool
+	PayloadType uint8
+	Sequence    uint16
+	Timestamp   uint32
+	SSRC        uint32
+	Payload     []byte
+}
+
+// ParseRTP parses an RTP packet, skipping CSRCs, header extensions and padding
+func ParseRTP(b []byte) (*RTPPacket, error) {
+	if len(b) < rtpHeaderSize {
+		return nil, fmt.Errorf("RTP packet too short: %d bytes", len(b))
+	}
+	if b[0]>>6 != 2 {
+		return nil, fmt.Errorf("unsupported RTP version %d", b[0]>>6)
+	}
+
+	offset := rtpHeaderSize + int(b[0]&0x0F)*4
+	if b[0]&0x10 != 0 {
+		if len(b) < offset+4 {
+			return nil, fmt.Errorf("RTP extension header truncated")
+		}
+		offset += 4 + int(binary.BigEndian.Uint16(b[offset+2:]))*4
+	}
+	end := len(b)
+	if b[0]&0x20 != 0 && end > 0 {
+		end -= int(b[end-1])
+	}
+	if offset > end {
+		return nil, fmt.Errorf("RTP header exceeds packet length")
+	}
+
+	return &RTPPacket{
+		Marker:      b[1]&0x80 != 0,
+		PayloadType: b[1] & 0x7F,
+		Sequence:    binary.BigEndian.Uint16(b[2:]),
+		Timestamp:   binary.BigEndian.Uint32(b[4:]),
+		SSRC:        binary.BigEndian.Uint32(b[8:]),
+		Payload:     b[offset:end],
+	}, nil
+}
+
+// Packetizer splits PS frames into RTP packets. The marker bit is set on
+// the last packet of every frame.
+type Packetizer struct {
+	PayloadType uint8
+	SSRC        uint32
+	MaxPayload  int
+	seq         uint16
+}
+
+// NewPacketizer creates a packetizer with a random initial sequence number
+func NewPacketizer(payloadType uint8, ssrc uint32) *Packetizer {
+	return &Packetizer{
+		PayloadType: payloadType,
+		SSRC:        ssrc,
+		MaxPayload:  DefaultMaxPayload,
+		seq:         uint16(rand.Intn(1 << 16)),
+	}
+}
+
+// Packetize returns the RTP packets carrying one frame
+func (p *Packetizer) Packetize(frame []byte, timestamp uint32) [][]byte {
+	var packets [][]byte
+	for len(frame) > 0 {
+		n := min(len(frame), p.MaxPayload)
+		pkt := make([]byte, rtpHeaderSize+n)
+		pkt[0] = 0x80
+		pkt[1] = p.PayloadType & 0x7F
+		if n == len(frame) {
+			pkt[1] |= 0x80
+		}
+		binary.BigEndian.PutUint16(pkt[2:], p.seq)
+		binary.BigEndian.PutUint32(pkt[4:], timestamp)
+		binary.BigEndian.PutUint32(pkt[8:], p.SSRC)
+		copy(pkt[rtpHeaderSize:], frame[:n])
+
+		packets = append(packets, pkt)
+		frame = frame[n:]
+		p.seq++
+	}
+	return packets
+}
@@ -0,0 +1,335 @@
+package media
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rtspDialTimeout  = 10 * time.Second
+	rtspKeepalive    = 30 * time.Second
+	rtspUserAgent    = "OUTB-GB28181/1.0"
+	rtspDefaultPort  = "554"
+	rtspMaxBodyBytes = 64 * 1024
+)
+
+// rtspSource pulls the first H.264 track of an RTSP stream using RTP
+// interleaved over the RTSP TCP connection
+type rtspSource struct {
+	url      *url.URL
+	username string
+	password string
+
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+	cseq    int
+	session string
+	auth    string // WWW-Authenticate challenge
+}
+
+// newRTSPSource creates an RTSP source. Credentials in the URL are used
+// for Basic or Digest authentication and stripped from request URIs.
+func newRTSPSource(u *url.URL) *rtspSource {
+	s := &rtspSource{url: u}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+		stripped := *u
+		stripped.User = nil
+		s.url = &stripped
+	}
+	return s
+}
+
+// rtspResponse is a parsed RTSP response
+type rtspResponse struct {
+	status  int
+	reason  string
+	headers map[string]string // Canonical (lower-case) header names
+	body    []byte
+}
+
+// Run implements Source
+func (s *rtspSource) Run(ctx context.Context, emit func(Frame)) error {
+	host := s.url.Host
+	if s.url.Port() == "" {
+		host = net.JoinHostPort(s.url.Hostname(), rtspDefaultPort)
+	}
+	dialer := net.Dialer{Timeout: rtspDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return fmt.Errorf("connect to RTSP server %s: %w", host, err)
+	}
+	s.conn = conn
+	s.br = bufio.NewReader(conn)
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	depkt := NewH264Depacketizer(emit)
+	if err := s.setup(depkt); err != nil {
+		return err
+	}
+	log.Printf("[GB28181] Pulling RTSP stream %s", s.url.Redacted())
+
+	go s.keepalive(ctx)
+
+	err = s.readInterleaved(depkt)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// setup runs DESCRIBE, SETUP and PLAY for the first H.264 video track
+func (s *rtspSource) setup(depkt *H264Depacketizer) error {
+	base := s.url.String()
+	resp, err := s.request("DESCRIBE", base, map[string]string{"Accept": "application/sdp"})
+	if err != nil {
+		return err
+	}
+	if cb := resp.headers["content-base"]; cb != "" {
+		base = cb
+	}
+
+	sd, err := ParseSDP(resp.body)
+	if err != nil {
+		return fmt.Errorf("parse RTSP SDP: %w", err)
+	}
+	var track *MediaDescription
+	var pt int
+	for i := range sd.Media {
+		if p, ok := sd.Media[i].PayloadType("H264"); ok && sd.Media[i].Type == "video" {
+			track, pt = &sd.Media[i], p
+			break
+		}
+	}
+	if track == nil {
+		return fmt.Errorf("RTSP stream has no H.264 video track")
+	}
+
+	if sprop := track.FormatParams(pt)["sprop-parameter-sets"]; sprop != "" {
+		sets := strings.Split(sprop, ",")
+		if len(sets) >= 2 {
+			sps, err1 := base64.StdEncoding.DecodeString(sets[0])
+			pps, err2 := base64.StdEncoding.DecodeString(sets[1])
+			if err1 == nil && err2 == nil && len(sps) > 0 && len(pps) > 0 {
+				depkt.SetParameterSets(sps, pps)
+			}
+		}
+	}
+
+	control, _ := track.Attr("control")
+	resp, err = s.request("SETUP", trackURL(base, control), map[string]string{
+		"Transport": "RTP/AVP/TCP;unicast;interleaved=0-1",
+	})
+	if err != nil {
+		return err
+	}
+	session, _, _ := strings.Cut(resp.headers["session"], ";")
+	s.session = strings.TrimSpace(session)
+
+	if _, err := s.request("PLAY", base, map[string]string{"Range": "npt=0.000-"}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// trackURL resolves an SDP control attribute against the content base
+func trackURL(base, control string) string {
+	switch {
+	case control == "" || control == "*":
+		return base
+	case strings.HasPrefix(control, "rtsp://"):
+		return control
+	case strings.HasSuffix(base, "/"):
+		return base + control
+	default:
+		return base + "/" + control
+	}
+}
+
+// request sends an RTSP request and waits for a 2xx response, retrying
+// once with credentials when challenged
+func (s *rtspSource) request(method, uri string, headers map[string]string) (*rtspResponse, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := s.write(method, uri, headers); err != nil {
+			return nil, err
+		}
+		resp, err := readRTSPResponse(s.br)
+		if err != nil {
+			return nil, fmt.Errorf("RTSP %s: %w", method, err)
+		}
+		if resp.status == 401 && attempt == 0 && s.username != "" && s.auth == "" {
+			s.auth = resp.headers["www-authenticate"]
+			continue
+		}
+		if resp.status < 200 || resp.status >= 300 {
+			return nil, fmt.Errorf("RTSP %s failed: %d %s", method, resp.status, resp.reason)
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("RTSP %s failed: authentication rejected", method)
+}
+
+// write sends an RTSP request without waiting for the response
+func (s *rtspSource) write(method, uri string, headers map[string]string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.cseq++
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s RTSP/1.0\r\n", method, uri)
+	fmt.Fprintf(&b, "CSeq: %d\r\n", s.cseq)
+	fmt.Fprintf(&b, "User-Agent: %s\r\n", rtspUserAgent)
+	if s.session != "" {
+		fmt.Fprintf(&b, "Session: %s\r\n", s.session)
+	}
+	if s.auth != "" {
+		fmt.Fprintf(&b, "Authorization: %s\r\n", s.authorization(method, uri))
+	}
+	for k, v := range headers {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	b.WriteString("\r\n")
+
+	_, err := io.WriteString(s.conn, b.String())
+	return err
+}
+
+var rtspAuthParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authorization builds a Basic or Digest Authorization header value
+func (s *rtspSource) authorization(method, uri string) string {
+	if !strings.HasPrefix(strings.ToLower(s.auth), "digest") {
+		creds := base64.StdEncoding.EncodeToString([]byte(s.username + ":" + s.password))
+		return "Basic " + creds
+	}
+
+	params := make(map[string]string)
+	for _, m := range rtspAuthParam.FindAllStringSubmatch(s.auth, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	ha1 := md5Hex(s.username + ":" + params["realm"] + ":" + s.password)
+	ha2 := md5Hex(method + ":" + uri)
+	response := md5Hex(ha1 + ":" + params["nonce"] + ":" + ha2)
+	return fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s"`,
+		s.username, params["realm"], params["nonce"], uri, response)
+}
+
+func md5Hex(s string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(s)))
+}
+
+// keepalive refreshes the RTSP session until ctx is done. Responses are
+// consumed by readInterleaved.
+func (s *rtspSource) keepalive(ctx context.Context) {
+	ticker := time.NewTicker(rtspKeepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write("OPTIONS", s.url.String(), nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readInterleaved reads $-framed RTP packets from the RTSP connection
+func (s *rtspSource) readInterleaved(depkt *H264Depacketizer) error {
+	header := make([]byte, 4)
+	buf := make([]byte, 65536)
+	for {
+		first, err := s.br.Peek(1)
+		if err != nil {
+			return fmt.Errorf("read RTSP stream: %w", err)
+		}
+		if first[0] != '$' {
+			// Keepalive response or server request; skip it
+			if _, err := readRTSPResponse(s.br); err != nil {
+				return fmt.Errorf("read RTSP stream: %w", err)
+			}
+			continue
+		}
+
+		if _, err := io.ReadFull(s.br, header); err != nil {
+			return fmt.Errorf("read RTSP stream: %w", err)
+		}
+		size := int(binary.BigEndian.Uint16(header[2:]))
+		if _, err := io.ReadFull(s.br, buf[:size]); err != nil {
+			return fmt.Errorf("read RTSP stream: %w", err)
+		}
+		if header[1] != 0 {
+			continue // RTCP
+		}
+		pkt, err := ParseRTP(buf[:size])
+		if err != nil {
+			continue
+		}
+		if err := depkt.Push(pkt); err != nil {
+			log.Printf("[GB28181] Dropping RTP packet from %s: %v", s.url.Redacted(), err)
+		}
+	}
+}
+
+// readRTSPResponse reads one RTSP message (status line, headers and body)
+func readRTSPResponse(br *bufio.Reader) (*rtspResponse, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(strings.TrimSpace(line), " ", 3)
+	resp := &rtspResponse{headers: make(map[string]string)}
+	if len(parts) >= 2 && strings.HasPrefix(parts[0], "RTSP/") {
+		resp.status, _ = strconv.Atoi(parts[1])
+		if len(parts) == 3 {
+			resp.reason = parts[2]
+		}
+	}
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			resp.headers[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+	}
+
+	if cl := resp.headers["content-length"]; cl != "" {
+		n, err := strconv.Atoi(cl)
+		if err != nil || n < 0 || n > rtspMaxBodyBytes {
+			return nil, fmt.Errorf("invalid RTSP Content-Length %q", cl)
+		}
+		resp.body = make([]byte, n)
+		if _, err := io.ReadFull(br, resp.body); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
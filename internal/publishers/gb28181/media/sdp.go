@@ -0,0 +1,191 @@
+// Package media implements the GB28181 live video path: SDP negotiation,
+// H.264 ingest from RTSP/RTP cameras, MPEG-PS muxing and RTP delivery
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Attribute is an SDP a= line. Flag attributes (sendonly) have an empty value.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// MediaDescription is an SDP m= section
+type MediaDescription struct {
+	Type       string // video | audio
+	Port       int
+	Protocol   string // RTP/AVP | TCP/RTP/AVP
+	Formats    []int  // Payload types
+	Attributes []Attribute
+}
+
+// SessionDescription is the subset of SDP used by GB28181 and RTSP cameras
+type SessionDescription struct {
+	Origin       string // o= username (device or platform ID)
+	SessionName  string // s= Play | Playback | Download
+	ConnectionIP string // c= address
+	SSRC         string // y= 10-digit GB28181 SSRC
+	Media        []MediaDescription
+}
+
+// ParseSDP parses an SDP body
+func ParseSDP(data []byte) (*SessionDescription, error) {
+	sd := &SessionDescription{}
+	var cur *MediaDescription
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) < 2 || line[1] != '=' {
+			continue
+		}
+		value := line[2:]
+
+		switch line[0] {
+		case 'o':
+			if fields := strings.Fields(value); len(fields) > 0 {
+				sd.Origin = fields[0]
+			}
+		case 's':
+			sd.SessionName = value
+		case 'c':
+			// c=IN IP4 192.168.1.1 (a media-level line only fills a missing session address)
+			fields := strings.Fields(value)
+			if len(fields) == 3 && (cur == nil || sd.ConnectionIP == "") {
+				sd.ConnectionIP = fields[2]
+			}
+		case 'y':
+			sd.SSRC = value
+		case 'm':
+			md, err := parseMediaLine(value)
+			if err != nil {
+				return nil, err
+			}
+			sd.Media = append(sd.Media, md)
+			cur = &sd.Media[len(sd.Media)-1]
+		case 'a':
+			if cur == nil {
+				continue
+			}
+			key, val, _ := strings.Cut(value, ":")
+			cur.Attributes = append(cur.Attributes, Attribute{Key: key, Value: val})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read SDP: %w", err)
+	}
+	if len(sd.Media) == 0 {
+		return nil, fmt.Errorf("SDP has no media description")
+	}
+	return sd, nil
+}
+
+// parseMediaLine parses "video 30000 RTP/AVP 96 98"
+func parseMediaLine(value string) (MediaDescription, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 3 {
+		return MediaDescription{}, fmt.Errorf("invalid SDP media line %q", value)
+	}
+	port, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return MediaDescription{}, fmt.Errorf("invalid SDP media port %q", fields[1])
+	}
+	md := MediaDescription{Type: fields[0], Port: port, Protocol: fields[2]}
+	for _, f := range fields[3:] {
+		if pt, err := strconv.Atoi(f); err == nil {
+			md.Formats = append(md.Formats, pt)
+		}
+	}
+	return md, nil
+}
+
+// Video returns the first video media description
+func (sd *SessionDescription) Video() *MediaDescription {
+	for i := range sd.Media {
+		if sd.Media[i].Type == "video" {
+			return &sd.Media[i]
+		}
+	}
+	return nil
+}
+
+// Marshal encodes the session description
+func (sd *SessionDescription) Marshal() []byte {
+	var b strings.Builder
+	b.WriteString("v=0\r\n")
+	fmt.Fprintf(&b, "o=%s 0 0 IN IP4 %s\r\n", sd.Origin, sd.ConnectionIP)
+	fmt.Fprintf(&b, "s=%s\r\n", sd.SessionName)
+	fmt.Fprintf(&b, "c=IN IP4 %s\r\n", sd.ConnectionIP)
+	b.WriteString("t=0 0\r\n")
+	for _, md := range sd.Media {
+		fmt.Fprintf(&b, "m=%s %d %s", md.Type, md.Port, md.Protocol)
+		for _, pt := range md.Formats {
+			fmt.Fprintf(&b, " %d", pt)
+		}
+		b.WriteString("\r\n")
+		for _, a := range md.Attributes {
+			if a.Value == "" {
+				fmt.Fprintf(&b, "a=%s\r\n", a.Key)
+			} else {
+				fmt.Fprintf(&b, "a=%s:%s\r\n", a.Key, a.Value)
+			}
+		}
+	}
+	if sd.SSRC != "" {
+		fmt.Fprintf(&b, "y=%s\r\n", sd.SSRC)
+	}
+	return []byte(b.String())
+}
+
+// Attr returns the value of the first attribute with the given key
+func (md *MediaDescription) Attr(key string) (string, bool) {
+	for _, a := range md.Attributes {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// PayloadType returns the payload type mapped to an encoding ("PS", "H264")
+func (md *MediaDescription) PayloadType(encoding string) (int, bool) {
+	for _, a := range md.Attributes {
+		if a.Key != "rtpmap" {
+			continue
+		}
+		// rtpmap:96 PS/90000
+		ptStr, enc, _ := strings.Cut(a.Value, " ")
+		name, _, _ := strings.Cut(enc, "/")
+		if !strings.EqualFold(name, encoding) {
+			continue
+		}
+		if pt, err := strconv.Atoi(ptStr); err == nil {
+			return pt, true
+		}
+	}
+	return 0, false
+}
+
+// FormatParams returns the fmtp parameters for a payload type
+func (md *MediaDescription) FormatParams(pt int) map[string]string {
+	params := make(map[string]string)
+	prefix := strconv.Itoa(pt) + " "
+	for _, a := range md.Attributes {
+		if a.Key != "fmtp" || !strings.HasPrefix(a.Value, prefix) {
+			continue
+		}
+		for _, p := range strings.Split(strings.TrimPrefix(a.Value, prefix), ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if k != "" {
+				params[k] = v
+			}
+		}
+	}
+	return params
+}
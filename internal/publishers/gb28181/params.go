@@ -0,0 +1,109 @@
+package gb28181
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+)
+
+// defaultDeviceName is reported when device_name is not configured
+const defaultDeviceName = "UAV-Gateway"
+
+// DeviceParams holds the basic parameters the platform reads with
+// ConfigDownload and changes with DeviceConfig. Changes last until the
+// gateway restarts.
+type DeviceParams struct {
+	cfg config.GB28181Config
+
+	mu                sync.RWMutex
+	name              string
+	heartbeatInterval int // Seconds
+	heartbeatCount    int
+}
+
+// NewDeviceParams creates the parameters from the configuration
+func NewDeviceParams(cfg config.GB28181Config) *DeviceParams {
+	name := cfg.DeviceName
+	if name == "" {
+		name = defaultDeviceName
+	}
+	return &DeviceParams{
+		cfg:               cfg,
+		name:              name,
+		heartbeatInterval: cfg.HeartbeatInterval,
+		heartbeatCount:    maxKeepaliveFailures,
+	}
+}
+
+// Name returns the device name
+func (d *DeviceParams) Name() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.name
+}
+
+// HeartbeatInterval returns the time between keepalives
+func (d *DeviceParams) HeartbeatInterval() time.Duration {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return time.Duration(d.heartbeatInterval) * time.Second
+}
+
+// HeartbeatCount returns the number of consecutive failed keepalives after
+// which the registration is considered lost
+func (d *DeviceParams) HeartbeatCount() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.heartbeatCount
+}
+
+// BasicParam returns the parameters as reported by ConfigDownload
+func (d *DeviceParams) BasicParam() *gbxml.BasicParam {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return &gbxml.BasicParam{
+		Name:              d.name,
+		DeviceID:          d.cfg.DeviceID,
+		SIPServerID:       d.cfg.ServerID,
+		SIPServerIP:       d.cfg.ServerIP,
+		SIPServerPort:     d.cfg.ServerPort,
+		DomainName:        d.cfg.ServerDomain,
+		Expiration:        d.cfg.RegisterExpires,
+		HeartBeatInterval: d.heartbeatInterval,
+		HeartBeatCount:    d.heartbeatCount,
+	}
+}
+
+// Apply changes the parameters set in u. Nothing is changed if any value
+// is invalid. The registration expiry is fixed by register_expires and
+// cannot be changed.
+func (d *DeviceParams) Apply(u *gbxml.BasicParamUpdate) error {
+	if u.Name != nil && *u.Name == "" {
+		return fmt.Errorf("empty name")
+	}
+	if u.Expiration != nil && *u.Expiration != d.cfg.RegisterExpires {
+		return fmt.Errorf("expiration cannot be changed from %d", d.cfg.RegisterExpires)
+	}
+	if u.HeartBeatInterval != nil && *u.HeartBeatInterval <= 0 {
+		return fmt.Errorf("invalid heartbeat interval %d", *u.HeartBeatInterval)
+	}
+	if u.HeartBeatCount != nil && *u.HeartBeatCount <= 0 {
+		return fmt.Errorf("invalid heartbeat count %d", *u.HeartBeatCount)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if u.Name != nil {
+		d.name = *u.Name
+	}
+	if u.HeartBeatInterval != nil {
+		d.heartbeatInterval = *u.HeartBeatInterval
+	}
+	if u.HeartBeatCount != nil {
+		d.heartbeatCount = *u.HeartBeatCount
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package gb28181
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+const gatewayID = "34020000001110000001"
+
+// newTestHandler returns a handler whose replies and notifications are
+// captured instead of sent, and a channel receiving its reboots
+func newTestHandler(t *testing.T) (*RequestHandler, chan string, chan string, chan struct{}) {
+	t.Helper()
+	cfg := config.GB28181Config{
+		DeviceID: gatewayID, DeviceName: "Airfield", ServerID: "34020000002000000001",
+		ServerIP: "10.0.0.1", ServerPort: 5060, ServerDomain: "3402000000",
+		RegisterExpires: 3600, HeartbeatInterval: 60,
+	}
+	h := NewRequestHandler(NewDeviceManager(gatewayID), NewSubscriptionManager(), NewSIPClient(cfg))
+	h.SetDeviceParams(NewDeviceParams(cfg))
+
+	replies := make(chan string, 4)
+	notifies := make(chan string, 4)
+	reboots := make(chan struct{}, 1)
+	h.reply = func(ctx context.Context, body string) error {
+		replies <- body
+		return nil
+	}
+	h.notify = func(ctx context.Context, event, body string) error {
+		notifies <- event + " " + body
+		return nil
+	}
+	h.reboot = func() { reboots <- struct{}{} }
+	return h, replies, notifies, reboots
+}
+
+// newManscdpRequest builds a MESSAGE or SUBSCRIBE from the platform
+func newManscdpRequest(method sip.RequestMethod, body string) *sip.Request {
+	req := newSIPRequest(method, gatewayID, "call-1", nil)
+	req.AppendHeader(sip.NewHeader("Content-Type", "Application/MANSCDP+xml"))
+	req.SetBody([]byte(gbxml.XMLDeclaration + "\r\n" + body))
+	return req
+}
+
+func assertContains(t *testing.T, body string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(body, w) {
+			t.Errorf("Missing %s in:\n%s", w, body)
+		}
+	}
+}
+
+func TestRequestHandler_ConfigDownload(t *testing.T) {
+	h, replies, _, _ := newTestHandler(t)
+
+	req := newManscdpRequest(sip.MESSAGE, `<Query><CmdType>ConfigDownload</CmdType><SN>11</SN><DeviceID>`+gatewayID+`</DeviceID><ConfigType>BasicParam/VideoParamOpt</ConfigType></Query>`)
+	if resp := h.HandleRequest(req); resp.StatusCode != 200 {
+		t.Fatalf("MESSAGE status = %d, want 200", resp.StatusCode)
+	}
+	body := receive(t, replies)
+	assertContains(t, body, "<CmdType>ConfigDownload</CmdType>", "<SN>11</SN>", "<Result>OK</Result>",
+		"<Name>Airfield</Name>", "<SIPServerId>34020000002000000001</SIPServerId>", "<SIPServerIp>10.0.0.1</SIPServerIp>",
+		"<DomainName>3402000000</DomainName>", "<Expiration>3600</Expiration>",
+		"<HeartBeatInterval>60</HeartBeatInterval>", "<HeartBeatCount>3</HeartBeatCount>")
+	if strings.Contains(body, "Password") {
+		t.Errorf("ConfigDownload must not report the password:\n%s", body)
+	}
+
+	req = newManscdpRequest(sip.MESSAGE, `<Query><CmdType>ConfigDownload</CmdType><SN>12</SN><DeviceID>`+gatewayID+`</DeviceID><ConfigType>VideoParamOpt</ConfigType></Query>`)
+	h.HandleRequest(req)
+	body = receive(t, replies)
+	assertContains(t, body, "<SN>12</SN>", "<Result>ERROR</Result>")
+	if strings.Contains(body, "BasicParam") {
+		t.Errorf("Unsupported config type should not report BasicParam:\n%s", body)
+	}
+}
+
+func TestRequestHandler_DeviceConfig(t *testing.T) {
+	h, replies, _, _ := newTestHandler(t)
+
+	req := newManscdpRequest(sip.MESSAGE, `<Control><CmdType>DeviceConfig</CmdType><SN>21</SN><DeviceID>`+gatewayID+`</DeviceID>
+<BasicParam><Name>Field 2</Name><HeartBeatInterval>30</HeartBeatInterval><HeartBeatCount>5</HeartBeatCount></BasicParam></Control>`)
+	if resp := h.HandleRequest(req); resp.StatusCode != 200 {
+		t.Fatalf("MESSAGE status = %d, want 200", resp.StatusCode)
+	}
+	assertContains(t, receive(t, replies), "<CmdType>DeviceConfig</CmdType>", "<SN>21</SN>", "<Result>OK</Result>")
+	if h.params.Name() != "Field 2" || h.params.HeartbeatInterval().Seconds() != 30 || h.params.HeartbeatCount() != 5 {
+		t.Errorf("Params after DeviceConfig = %+v", *h.params.BasicParam())
+	}
+
+	// An invalid value rejects the whole change
+	req = newManscdpRequest(sip.MESSAGE, `<Control><CmdType>DeviceConfig</CmdType><SN>22</SN><DeviceID>`+gatewayID+`</DeviceID>
+<BasicParam><Name>Field 3</Name><Expiration>60</Expiration></BasicParam></Control>`)
+	h.HandleRequest(req)
+	assertContains(t, receive(t, replies), "<SN>22</SN>", "<Result>ERROR</Result>")
+	if h.params.Name() != "Field 2" {
+		t.Errorf("Name = %s, want it unchanged", h.params.Name())
+	}
+
+	h.HandleRequest(newManscdpRequest(sip.MESSAGE, `<Query><CmdType>DeviceInfo</CmdType><SN>23</SN><DeviceID>`+gatewayID+`</DeviceID></Query>`))
+	assertContains(t, receive(t, replies), "<DeviceName>Field 2</DeviceName>")
+}
+
+func TestRequestHandler_DeviceControl(t *testing.T) {
+	h, replies, _, reboots := newTestHandler(t)
+
+	req := newManscdpRequest(sip.MESSAGE, `<Control><CmdType>DeviceControl</CmdType><SN>31</SN><DeviceID>`+gatewayID+`</DeviceID><TeleBoot>Boot</TeleBoot></Control>`)
+	if resp := h.HandleRequest(req); resp.StatusCode != 200 {
+		t.Fatalf("MESSAGE status = %d, want 200", resp.StatusCode)
+	}
+	assertContains(t, receive(t, replies), "<CmdType>DeviceControl</CmdType>", "<SN>31</SN>", "<Result>OK</Result>")
+	select {
+	case <-reboots:
+	case <-time.After(2 * time.Second):
+		t.Error("TeleBoot should reboot after the response is sent")
+	}
+
+	req = newManscdpRequest(sip.MESSAGE, `<Control><CmdType>DeviceControl</CmdType><SN>32</SN><DeviceID>`+gatewayID+`</DeviceID><PTZCmd>A50F01</PTZCmd></Control>`)
+	h.HandleRequest(req)
+	assertContains(t, receive(t, replies), "<SN>32</SN>", "<Result>ERROR</Result>")
+
+	// Unknown commands are answered instead of ignored
+	req = newManscdpRequest(sip.MESSAGE, `<Query><CmdType>PresetQuery</CmdType><SN>33</SN><DeviceID>`+gatewayID+`</DeviceID></Query>`)
+	h.HandleRequest(req)
+	assertContains(t, receive(t, replies), "<CmdType>PresetQuery</CmdType>", "<SN>33</SN>", "<Result>ERROR</Result>")
+	if len(reboots) != 0 {
+		t.Error("Only TeleBoot should reboot")
+	}
+}
+
+func TestRequestHandler_CatalogSubscribe(t *testing.T) {
+	h, _, notifies, _ := newTestHandler(t)
+	h.deviceMgr.SetChangeCallback(h.NotifyCatalogChange)
+	h.deviceMgr.UpdateDrone(models.NewDroneState("uav-001", "mavlink"))
+
+	req := newManscdpRequest(sip.SUBSCRIBE, `<Query><CmdType>Catalog</CmdType><SN>41</SN><DeviceID>`+gatewayID+`</DeviceID></Query>`)
+	req.AppendHeader(sip.NewHeader("Expires", "600"))
+	if resp := h.HandleRequest(req); resp.StatusCode != 200 {
+		t.Fatalf("SUBSCRIBE status = %d, want 200", resp.StatusCode)
+	}
+	if subs := h.subMgr.GetCatalogSubscriptions(); len(subs) != 1 || subs[0].Alarm != nil {
+		t.Fatalf("Catalog subscriptions = %+v", subs)
+	}
+	notify := receive(t, notifies)
+	assertContains(t, notify, "Catalog <?xml", "<Notify>", "<SN>41</SN>", "<SumNum>1</SumNum>", "<Name>UAV-uav-001</Name>")
+	if strings.Contains(notify, "<Event>") {
+		t.Errorf("The initial catalog should carry no events:\n%s", notify)
+	}
+
+	ch := h.deviceMgr.UpdateDrone(models.NewDroneState("uav-002", "mavlink"))
+	assertContains(t, receive(t, notifies), "<DeviceID>"+ch.DeviceID+"</DeviceID>", "<Event>ADD</Event>", "<SumNum>1</SumNum>")
+
+	h.deviceMgr.MarkOffline(-1)
+	for range 2 {
+		assertContains(t, receive(t, notifies), "<Event>OFF</Event>", "<Status>OFF</Status>")
+	}
+	h.deviceMgr.UpdateDrone(models.NewDroneState("uav-002", "mavlink"))
+	assertContains(t, receive(t, notifies), "<DeviceID>"+ch.DeviceID+"</DeviceID>", "<Event>ON</Event>")
+
+	// Updates of online channels are not notified
+	h.deviceMgr.UpdateDrone(models.NewDroneState("uav-002", "mavlink"))
+	if len(notifies) != 0 {
+		t.Errorf("Unexpected notification: %s", <-notifies)
+	}
+}
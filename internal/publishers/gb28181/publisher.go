@@ -4,14 +4,21 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
-	"github.com/open-uav/telemetry-bridge/internal/models"
 	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
 )
 
+// maxKeepaliveFailures is the default number of consecutive failed
+// keepalives after which the registration is considered lost (GB/T 28181
+// default timeout count)
+const maxKeepaliveFailures = 3
+
 // Publisher implements the core.Publisher interface for GB/T 28181
 type Publisher struct {
 	cfg       config.GB28181Config
@@ -19,11 +26,17 @@ type Publisher struct {
 	deviceMgr *DeviceManager
 	subMgr    *SubscriptionManager
 	handler   *RequestHandler
+	params    *DeviceParams
+	streams   *StreamManager  // nil when video is disabled
+	cascade   *CascadeManager // nil when cascading is disabled
 
 	mu            sync.RWMutex
 	running       bool
 	lastStates    map[string]*models.DroneState
 	lastSentTimes map[string]time.Time
+	loc           *time.Location
+
+	keepaliveFailures int // Consecutive failed keepalives
 
 	ctx        context.Context
 	cancel     context.CancelFunc
@@ -37,10 +50,24 @@ func New(cfg config.GB28181Config) *Publisher {
 		cfg:           cfg,
 		lastStates:    make(map[string]*models.DroneState),
 		lastSentTimes: make(map[string]time.Time),
+		loc:           time.Local,
 		done:          make(chan struct{}),
 	}
 }
 
+// SetLocation sets the timezone used for GB28181 Time fields
+func (p *Publisher) SetLocation(loc *time.Location) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if loc == nil {
+		loc = time.Local
+	}
+	p.loc = loc
+	if p.cascade != nil {
+		p.cascade.SetLocation(loc)
+	}
+}
+
 // Name returns the publisher name
 func (p *Publisher) Name() string {
 	return "gb28181"
@@ -64,6 +91,20 @@ func (p *Publisher) Start(ctx context.Context) error {
 	p.deviceMgr = NewDeviceManager(p.cfg.DeviceID)
 	p.subMgr = NewSubscriptionManager()
 	p.handler = NewRequestHandler(p.deviceMgr, p.subMgr, p.sipClient)
+	p.params = NewDeviceParams(p.cfg)
+	p.handler.SetDeviceParams(p.params)
+	p.deviceMgr.SetChangeCallback(p.handler.NotifyCatalogChange)
+	if p.cfg.Video.Enabled {
+		p.streams = NewStreamManager(p.ctx, p.cfg)
+		p.handler.SetStreamManager(p.streams)
+	}
+	if p.cfg.Cascade.Enabled {
+		p.cascade = NewCascadeManager(p.cfg, p.sipClient)
+		p.mu.RLock()
+		p.cascade.SetLocation(p.loc)
+		p.mu.RUnlock()
+		p.handler.SetCascadeManager(p.cascade)
+	}
 
 	// Start SIP client
 	if err := p.sipClient.Start(p.ctx); err != nil {
@@ -73,9 +114,13 @@ func (p *Publisher) Start(ctx context.Context) error {
 	// Set request handler
 	p.sipClient.SetRequestHandler(p.handler.HandleRequest)
 
-	// Initial registration
-	if err := p.sipClient.Register(p.ctx); err != nil {
-		return fmt.Errorf("initial registration: %w", err)
+	// Initial registration; on failure the registration loop keeps retrying
+	regCtx, regCancel := context.WithTimeout(p.ctx, 10*time.Second)
+	err := p.sipClient.Register(regCtx)
+	regCancel()
+	if err != nil {
+		log.Printf("[GB28181] Initial registration failed, retrying in background: %v", err)
+		p.sipClient.MarkUnregistered(err)
 	}
 
 	// Start background tasks
@@ -99,6 +144,15 @@ func (p *Publisher) Start(ctx context.Context) error {
 		p.subMgr.StartCleanupLoop(p.done)
 	}()
 
+	// Downstream catalog refresh loop
+	if p.cascade != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.cascade.Run(p.done)
+		}()
+	}
+
 	log.Printf("[GB28181] Publisher started (device: %s, server: %s:%d)",
 		p.cfg.DeviceID, p.cfg.ServerIP, p.cfg.ServerPort)
 
@@ -152,8 +206,12 @@ func (p *Publisher) shouldSendPosition(deviceID string) bool {
 
 // sendPositionNotify sends a MobilePosition notification
 func (p *Publisher) sendPositionNotify(state *models.DroneState) error {
-	// Create MobilePosition XML
-	notify := gbxml.NewMobilePositionNotify(state, p.sipClient.NextSN())
+	p.mu.RLock()
+	loc := p.loc
+	p.mu.RUnlock()
+
+	// Create MobilePosition XML in the gateway timezone
+	notify := gbxml.NewMobilePositionNotifyIn(state, p.sipClient.NextSN(), loc)
 	body, err := notify.Marshal()
 	if err != nil {
 		return fmt.Errorf("marshal position notify: %w", err)
@@ -172,17 +230,37 @@ func (p *Publisher) sendPositionNotify(state *models.DroneState) error {
 	return nil
 }
 
-// heartbeatLoop sends periodic keepalive messages
-func (p *Publisher) heartbeatLoop() {
-	interval := time.Duration(p.cfg.HeartbeatInterval) * time.Second
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// SelfTest builds the MobilePosition notification without sending it and
+// checks the SIP registration
+func (p *Publisher) SelfTest(state *models.DroneState) error {
+	p.mu.RLock()
+	running := p.running
+	loc := p.loc
+	p.mu.RUnlock()
+
+	if _, err := gbxml.NewMobilePositionNotifyIn(state, 0, loc).Marshal(); err != nil {
+		return fmt.Errorf("marshal position notify: %w", err)
+	}
+
+	if !running || p.sipClient == nil {
+		return fmt.Errorf("publisher not running")
+	}
+	if !p.sipClient.IsRegistered() {
+		return fmt.Errorf("not registered with SIP server %s:%d", p.cfg.ServerIP, p.cfg.ServerPort)
+	}
+	return nil
+}
 
+// heartbeatLoop sends periodic keepalive messages. The interval is read
+// before each keepalive, as the platform can change it with DeviceConfig.
+func (p *Publisher) heartbeatLoop() {
 	for {
+		timer := time.NewTimer(p.params.HeartbeatInterval())
 		select {
 		case <-p.ctx.Done():
+			timer.Stop()
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			p.sendHeartbeat()
 		}
 	}
@@ -202,8 +280,18 @@ func (p *Publisher) sendHeartbeat() {
 	}
 
 	if err := p.sipClient.SendMessage(p.ctx, "Application/MANSCDP+xml", body); err != nil {
-		log.Printf("[GB28181] Failed to send keepalive: %v", err)
+		p.keepaliveFailures++
+		count := p.params.HeartbeatCount()
+		log.Printf("[GB28181] Failed to send keepalive (%d/%d): %v", p.keepaliveFailures, count, err)
+		if p.keepaliveFailures >= count {
+			// Platform considers the device offline; re-register
+			log.Printf("[GB28181] Keepalive lost, re-registering")
+			p.keepaliveFailures = 0
+			p.sipClient.MarkUnregistered(fmt.Errorf("keepalive lost: %w", err))
+		}
+		return
 	}
+	p.keepaliveFailures = 0
 }
 
 // Stop gracefully stops the publisher
@@ -225,6 +313,11 @@ func (p *Publisher) Stop() error {
 	// Wait for background tasks
 	p.wg.Wait()
 
+	// Stop live video sessions
+	if p.streams != nil {
+		p.streams.Close()
+	}
+
 	// Stop SIP client
 	if p.sipClient != nil {
 		if err := p.sipClient.Stop(); err != nil {
@@ -244,11 +337,59 @@ func (p *Publisher) IsConnected() bool {
 	return p.sipClient.IsRegistered()
 }
 
+// Health reports the registration with the SIP server
+func (p *Publisher) Health() sdk.PublisherDetail {
+	d := sdk.PublisherDetail{
+		State:    "stopped",
+		Endpoint: fmt.Sprintf("%s:%d", p.cfg.ServerIP, p.cfg.ServerPort),
+		Details: map[string]string{
+			"device_id": p.cfg.DeviceID,
+			"server_id": p.cfg.ServerID,
+		},
+	}
+	if p.sipClient == nil {
+		return d
+	}
+
+	reg := p.sipClient.Registration()
+	d.State = "unregistered"
+	if reg.Registered {
+		d.State = "registered"
+		d.Details["registered_at"] = reg.RegisteredAt.UTC().Format(time.RFC3339)
+		d.Details["expires_at"] = reg.RegisteredAt.Add(time.Duration(p.cfg.RegisterExpires) * time.Second).UTC().Format(time.RFC3339)
+	}
+	if reg.LastError != "" {
+		d.LastError = reg.LastError
+		d.LastErrorAt = reg.LastErrorAt.UnixMilli()
+	}
+	if p.cascade != nil {
+		d.Details["downstreams"] = strconv.Itoa(len(p.cascade.Downstreams()))
+	}
+	return d
+}
+
 // GetOnlineDevices returns the count of online devices
 func (p *Publisher) GetOnlineDevices() int {
 	return len(p.deviceMgr.GetOnlineChannels())
 }
 
+// GetActiveStreams returns the number of live video sessions
+func (p *Publisher) GetActiveStreams() int {
+	if p.streams == nil {
+		return 0
+	}
+	return p.streams.Count()
+}
+
+// GetDownstreams returns the devices and gateways registered with this
+// gateway in cascade mode
+func (p *Publisher) GetDownstreams() []Downstream {
+	if p.cascade == nil {
+		return nil
+	}
+	return p.cascade.Downstreams()
+}
+
 // GetActiveSubscriptions returns the count of active subscriptions
 func (p *Publisher) GetActiveSubscriptions() int {
 	return len(p.subMgr.GetActive())
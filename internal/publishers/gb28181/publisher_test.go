@@ -2,12 +2,13 @@ package gb28181
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
-	"github.com/open-uav/telemetry-bridge/internal/models"
 	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestDigestAuth_ParseChallenge(t *testing.T) {
@@ -449,3 +450,80 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestMobilePositionNotify_Timezone(t *testing.T) {
+	state := &models.DroneState{
+		DeviceID:  "34020000001320000001",
+		Timestamp: 1705639200000, // 2024-01-19T04:40:00Z
+	}
+
+	shanghai := time.FixedZone("CST", 8*3600)
+	notify := gbxml.NewMobilePositionNotifyIn(state, 1, shanghai)
+	if notify.Time != "2024-01-19T12:40:00" {
+		t.Errorf("Time = %s, want 2024-01-19T12:40:00", notify.Time)
+	}
+
+	notify = gbxml.NewMobilePositionNotifyIn(state, 1, time.UTC)
+	if notify.Time != "2024-01-19T04:40:00" {
+		t.Errorf("Time = %s, want 2024-01-19T04:40:00", notify.Time)
+	}
+}
+
+func TestPublisher_SetLocation(t *testing.T) {
+	p := New(config.GB28181Config{})
+
+	p.SetLocation(time.UTC)
+	if p.loc != time.UTC {
+		t.Error("SetLocation should update the timezone")
+	}
+
+	p.SetLocation(nil)
+	if p.loc != time.Local {
+		t.Error("SetLocation(nil) should fall back to local time")
+	}
+}
+
+func TestPublisher_SelfTestNotRunning(t *testing.T) {
+	pub := New(config.GB28181Config{DeviceID: "34020000001320000001"})
+
+	state := &models.DroneState{DeviceID: "drone-1", Location: models.Location{Lat: 39.9, Lon: 116.4}}
+	if err := pub.SelfTest(state); err == nil {
+		t.Error("SelfTest should fail when the publisher is not running")
+	}
+}
+
+func TestPublisher_Health(t *testing.T) {
+	cfg := config.GB28181Config{
+		DeviceID:        "34020000001320000001",
+		ServerID:        "34020000002000000001",
+		ServerIP:        "192.168.1.100",
+		ServerPort:      5060,
+		RegisterExpires: 3600,
+	}
+	pub := New(cfg)
+
+	h := pub.Health()
+	if h.State != "stopped" || h.Endpoint != "192.168.1.100:5060" || h.Details["device_id"] != cfg.DeviceID {
+		t.Errorf("Health when not running = %+v", h)
+	}
+
+	// Simulate a failed registration, then a successful one
+	pub.sipClient = NewSIPClient(cfg)
+	pub.sipClient.MarkUnregistered(errors.New("REGISTER failed with status 403: Forbidden"))
+	h = pub.Health()
+	if h.State != "unregistered" || h.LastError != "REGISTER failed with status 403: Forbidden" || h.LastErrorAt == 0 {
+		t.Errorf("Health after failed registration = %+v", h)
+	}
+
+	pub.sipClient.mu.Lock()
+	pub.sipClient.registered = true
+	pub.sipClient.registeredAt = time.Now()
+	pub.sipClient.mu.Unlock()
+	h = pub.Health()
+	if h.State != "registered" || h.Details["registered_at"] == "" || h.Details["expires_at"] == "" {
+		t.Errorf("Health when registered = %+v", h)
+	}
+	if h.LastError == "" {
+		t.Error("Health should keep the last registration error")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,6 +15,7 @@ import (
 	"github.com/emiago/sipgo/sip"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/backoff"
 )
 
 // SIPClient handles SIP protocol operations for GB28181
@@ -30,7 +33,10 @@ type SIPClient struct {
 
 	registered     bool
 	registeredAt   time.Time
+	lastError      string // Why the registration last failed or was lost
+	lastErrorAt    time.Time
 	registerCancel context.CancelFunc
+	lost           chan struct{} // Signals the registration loop to re-register
 
 	// Handler for incoming requests
 	requestHandler func(req *sip.Request) *sip.Response
@@ -42,6 +48,7 @@ func NewSIPClient(cfg config.GB28181Config) *SIPClient {
 		cfg:       cfg,
 		auth:      NewDigestAuth(cfg.Username, cfg.Password),
 		localAddr: fmt.Sprintf("%s:%d", cfg.LocalIP, cfg.LocalPort),
+		lost:      make(chan struct{}, 1),
 	}
 }
 
@@ -123,6 +130,25 @@ func (c *SIPClient) registerHandlers() {
 	c.server.OnInfo(func(req *sip.Request, tx sip.ServerTransaction) {
 		c.handleRequest(req, tx)
 	})
+
+	// Handle live video sessions (INVITE / ACK / BYE)
+	c.server.OnInvite(func(req *sip.Request, tx sip.ServerTransaction) {
+		c.handleRequest(req, tx)
+	})
+	c.server.OnAck(func(req *sip.Request, tx sip.ServerTransaction) {
+		c.handleRequest(req, tx)
+	})
+	c.server.OnBye(func(req *sip.Request, tx sip.ServerTransaction) {
+		c.handleRequest(req, tx)
+	})
+
+	// Handle downstream devices in cascade mode (REGISTER / NOTIFY)
+	c.server.OnRegister(func(req *sip.Request, tx sip.ServerTransaction) {
+		c.handleRequest(req, tx)
+	})
+	c.server.OnNotify(func(req *sip.Request, tx sip.ServerTransaction) {
+		c.handleRequest(req, tx)
+	})
 }
 
 // handleRequest handles incoming SIP requests
@@ -136,6 +162,11 @@ func (c *SIPClient) handleRequest(req *sip.Request, tx sip.ServerTransaction) {
 		resp = handler(req)
 	}
 
+	// ACK is never answered
+	if req.Method == sip.ACK || tx == nil {
+		return
+	}
+
 	if resp == nil {
 		// Default 200 OK response
 		resp = sip.NewResponseFromRequest(req, 200, "OK", nil)
@@ -258,7 +289,9 @@ func (c *SIPClient) Register(ctx context.Context) error {
 	return nil
 }
 
-// StartRegistrationLoop starts the periodic registration refresh
+// StartRegistrationLoop keeps the client registered. It refreshes the
+// registration before it expires and, after a failure or a lost
+// registration, retries with exponential backoff.
 func (c *SIPClient) StartRegistrationLoop(ctx context.Context) {
 	regCtx, cancel := context.WithCancel(ctx)
 	c.registerCancel = cancel
@@ -269,18 +302,71 @@ func (c *SIPClient) StartRegistrationLoop(ctx context.Context) {
 		refreshInterval = time.Minute
 	}
 
-	ticker := time.NewTicker(refreshInterval)
-	defer ticker.Stop()
+	bo := backoff.FromMs(c.cfg.ReconnectInitialMs, c.cfg.ReconnectMaxMs)
 
 	for {
+		wait := refreshInterval
+		if !c.IsRegistered() {
+			wait = bo.Next()
+		}
+
+		timer := time.NewTimer(wait)
 		select {
 		case <-regCtx.Done():
+			timer.Stop()
 			return
-		case <-ticker.C:
-			if err := c.Register(regCtx); err != nil {
-				log.Printf("[GB28181] Registration refresh failed: %v", err)
-			}
+		case <-c.lost:
+			// Registration lost, retry with backoff
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		if err := c.Register(regCtx); err != nil {
+			c.setUnregistered(err)
+			log.Printf("[GB28181] Registration failed (attempt %d), retrying with backoff: %v", bo.Attempts(), err)
+			continue
 		}
+		bo.Reset()
+	}
+}
+
+// MarkUnregistered marks the registration as lost because of err and
+// wakes the registration loop to re-register
+func (c *SIPClient) MarkUnregistered(err error) {
+	c.setUnregistered(err)
+	select {
+	case c.lost <- struct{}{}:
+	default:
+	}
+}
+
+// setUnregistered marks the registration as lost, recording why
+func (c *SIPClient) setUnregistered(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.registered = false
+	c.lastError = err.Error()
+	c.lastErrorAt = time.Now()
+}
+
+// RegistrationStatus is the state of the registration with the SIP server
+type RegistrationStatus struct {
+	Registered   bool
+	RegisteredAt time.Time // Last successful REGISTER
+	LastError    string
+	LastErrorAt  time.Time
+}
+
+// Registration returns the registration state
+func (c *SIPClient) Registration() RegistrationStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return RegistrationStatus{
+		Registered:   c.registered,
+		RegisteredAt: c.registeredAt,
+		LastError:    c.lastError,
+		LastErrorAt:  c.lastErrorAt,
 	}
 }
 
@@ -299,15 +385,48 @@ func (c *SIPClient) SendMessage(ctx context.Context, contentType, body string) e
 		Host:   c.cfg.ServerDomain,
 	}
 
-	fromAddr := sip.Uri{
+	toAddr := sip.Uri{
 		Scheme: "sip",
-		User:   c.cfg.DeviceID,
+		User:   c.cfg.ServerID,
 		Host:   c.cfg.ServerDomain,
 	}
 
+	return c.sendMessage(ctx, requestURI, toAddr, contentType, body)
+}
+
+// SendMessageTo sends a SIP MESSAGE request to a downstream device
+// registered at addr (host:port)
+func (c *SIPClient) SendMessageTo(ctx context.Context, deviceID, addr, contentType, body string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+
+	requestURI := sip.Uri{
+		Scheme: "sip",
+		User:   deviceID,
+		Host:   host,
+		Port:   port,
+	}
+
 	toAddr := sip.Uri{
 		Scheme: "sip",
-		User:   c.cfg.ServerID,
+		User:   deviceID,
+		Host:   c.cfg.ServerDomain,
+	}
+
+	return c.sendMessage(ctx, requestURI, toAddr, contentType, body)
+}
+
+// sendMessage sends a MESSAGE from this device to the given recipient
+func (c *SIPClient) sendMessage(ctx context.Context, requestURI, toAddr sip.Uri, contentType, body string) error {
+	fromAddr := sip.Uri{
+		Scheme: "sip",
+		User:   c.cfg.DeviceID,
 		Host:   c.cfg.ServerDomain,
 	}
 
@@ -343,8 +462,14 @@ func (c *SIPClient) SendMessage(ctx context.Context, contentType, body string) e
 	return nil
 }
 
-// SendNotify sends a SIP NOTIFY request
+// SendNotify sends a SIP NOTIFY request for the presence event
 func (c *SIPClient) SendNotify(ctx context.Context, contentType, body string) error {
+	return c.SendNotifyEvent(ctx, "presence", contentType, body)
+}
+
+// SendNotifyEvent sends a SIP NOTIFY request for the given event package,
+// e.g. "Catalog"
+func (c *SIPClient) SendNotifyEvent(ctx context.Context, event, contentType, body string) error {
 	requestURI := sip.Uri{
 		Scheme: "sip",
 		User:   c.cfg.ServerID,
@@ -380,7 +505,7 @@ func (c *SIPClient) SendNotify(ctx context.Context, contentType, body string) er
 
 	req.AppendHeader(&sip.CSeqHeader{SeqNo: uint32(c.cseq.Add(1)), MethodName: sip.NOTIFY})
 	req.AppendHeader(sip.NewHeader("Max-Forwards", "70"))
-	req.AppendHeader(sip.NewHeader("Event", "presence"))
+	req.AppendHeader(sip.NewHeader("Event", event))
 	req.AppendHeader(sip.NewHeader("Subscription-State", "active"))
 	req.AppendHeader(sip.NewHeader("Content-Type", contentType))
 	req.SetBody([]byte(body))
@@ -0,0 +1,372 @@
+package gb28181
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/media"
+)
+
+// ackTimeout releases sessions whose INVITE was never acknowledged
+const ackTimeout = 30 * time.Second
+
+// Stream negotiation errors, mapped to SIP status codes by the request handler
+var (
+	ErrUnsupportedSession = errors.New("unsupported media session")
+	ErrNoVideoSource      = errors.New("no video source for channel")
+	ErrNoMediaPort        = errors.New("no free media port")
+	ErrSessionNotFound    = errors.New("media session not found")
+)
+
+// StreamSession is one live video session negotiated by INVITE
+type StreamSession struct {
+	CallID      string
+	ChannelID   string
+	DroneID     string
+	SourceURL   string
+	SSRC        string
+	PayloadType uint8
+	RemoteAddr  string
+	LocalPort   int
+	Transport   string
+	CreatedAt   time.Time
+	StartedAt   time.Time
+
+	conn    *media.Conn
+	cancel  context.CancelFunc
+	done    chan struct{}
+	timeout *time.Timer
+}
+
+// StreamManager answers INVITEs by relaying a camera stream as PS over RTP
+type StreamManager struct {
+	ctx     context.Context
+	cfg     config.GB28181VideoConfig
+	mediaIP string
+	domain  string
+	ports   *media.PortPool
+
+	// newSource opens the camera stream; replaced in tests
+	newSource func(url string) (media.Source, error)
+
+	mu       sync.Mutex
+	sessions map[string]*StreamSession
+	ssrcSeq  int
+}
+
+// NewStreamManager creates a stream manager. Sessions stop when ctx is done.
+func NewStreamManager(ctx context.Context, cfg config.GB28181Config) *StreamManager {
+	mediaIP := cfg.Video.MediaIP
+	if mediaIP == "" {
+		mediaIP = cfg.LocalIP
+	}
+	domain := cfg.ServerDomain
+	if domain == "" && len(cfg.DeviceID) >= 10 {
+		domain = cfg.DeviceID[:10]
+	}
+	return &StreamManager{
+		ctx:       ctx,
+		cfg:       cfg.Video,
+		mediaIP:   mediaIP,
+		domain:    domain,
+		ports:     media.NewPortPool(cfg.Video.MediaPortMin, cfg.Video.MediaPortMax),
+		newSource: media.NewSource,
+		sessions:  make(map[string]*StreamSession),
+	}
+}
+
+// sourceURL returns the camera stream for a drone
+func (m *StreamManager) sourceURL(droneID string) string {
+	if u, ok := m.cfg.Sources[droneID]; ok && u != "" {
+		return u
+	}
+	return m.cfg.SourceURL
+}
+
+// nextSSRC generates a GB28181 real-time SSRC: "0" + domain digits 4-8 + sequence
+func (m *StreamManager) nextSSRC() string {
+	m.ssrcSeq = m.ssrcSeq%9999 + 1
+	prefix := "00000"
+	if len(m.domain) >= 8 {
+		prefix = m.domain[3:8]
+	}
+	return fmt.Sprintf("0%s%04d", prefix, m.ssrcSeq)
+}
+
+// Invite negotiates a live session for a channel and returns the SDP answer.
+// Streaming starts on Play, once the platform acknowledges the answer.
+func (m *StreamManager) Invite(callID string, ch *Channel, offer *media.SessionDescription) (*media.SessionDescription, error) {
+	if !strings.EqualFold(offer.SessionName, "Play") {
+		return nil, fmt.Errorf("%w: session %q (only Play is supported)", ErrUnsupportedSession, offer.SessionName)
+	}
+	video := offer.Video()
+	if video == nil || video.Port == 0 || net.ParseIP(offer.ConnectionIP) == nil {
+		return nil, fmt.Errorf("%w: offer has no video destination", ErrUnsupportedSession)
+	}
+	pt, ok := video.PayloadType("PS")
+	if !ok {
+		return nil, fmt.Errorf("%w: offer does not accept PS", ErrUnsupportedSession)
+	}
+
+	transport := media.TransportUDP
+	if strings.HasPrefix(strings.ToUpper(video.Protocol), "TCP") {
+		// The platform's setup role is the opposite of ours
+		transport = media.TransportTCPActive
+		if setup, _ := video.Attr("setup"); setup == "active" {
+			transport = media.TransportTCPPassive
+		}
+	}
+
+	sourceURL := m.sourceURL(ch.DroneID)
+	if sourceURL == "" {
+		return nil, fmt.Errorf("%w %s", ErrNoVideoSource, ch.DeviceID)
+	}
+
+	// Replace an existing session on re-INVITE
+	m.Bye(callID)
+
+	remote := net.JoinHostPort(offer.ConnectionIP, strconv.Itoa(video.Port))
+	conn, err := m.bind(transport, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	ssrc := offer.SSRC
+	if ssrc == "" {
+		ssrc = m.nextSSRC()
+	}
+	s := &StreamSession{
+		CallID:      callID,
+		ChannelID:   ch.DeviceID,
+		DroneID:     ch.DroneID,
+		SourceURL:   sourceURL,
+		SSRC:        ssrc,
+		PayloadType: uint8(pt),
+		RemoteAddr:  remote,
+		LocalPort:   conn.Port(),
+		Transport:   transport,
+		CreatedAt:   time.Now(),
+		conn:        conn,
+		done:        make(chan struct{}),
+	}
+	s.timeout = time.AfterFunc(ackTimeout, func() {
+		if m.pending(callID) {
+			log.Printf("[GB28181] INVITE %s not acknowledged, releasing media port %d", callID, s.LocalPort)
+			m.Bye(callID)
+		}
+	})
+	m.sessions[callID] = s
+	m.mu.Unlock()
+
+	answerMedia := media.MediaDescription{
+		Type:     "video",
+		Port:     conn.Port(),
+		Protocol: video.Protocol,
+		Formats:  []int{pt},
+		Attributes: []media.Attribute{
+			{Key: "sendonly"},
+			{Key: "rtpmap", Value: fmt.Sprintf("%d PS/90000", pt)},
+		},
+	}
+	switch transport {
+	case media.TransportTCPActive:
+		answerMedia.Attributes = append(answerMedia.Attributes,
+			media.Attribute{Key: "setup", Value: "active"}, media.Attribute{Key: "connection", Value: "new"})
+	case media.TransportTCPPassive:
+		answerMedia.Attributes = append(answerMedia.Attributes,
+			media.Attribute{Key: "setup", Value: "passive"}, media.Attribute{Key: "connection", Value: "new"})
+	}
+
+	log.Printf("[GB28181] INVITE %s: channel %s -> %s (%s, local port %d, SSRC %s)",
+		callID, ch.DeviceID, remote, transport, conn.Port(), ssrc)
+
+	return &media.SessionDescription{
+		Origin:       ch.DeviceID,
+		SessionName:  "Play",
+		ConnectionIP: m.mediaIP,
+		SSRC:         ssrc,
+		Media:        []media.MediaDescription{answerMedia},
+	}, nil
+}
+
+// bind reserves a media port, skipping ports that are taken by other processes
+func (m *StreamManager) bind(transport, remote string) (*media.Conn, error) {
+	var lastErr error
+	for i := 0; i < m.ports.Size(); i++ {
+		port, err := m.ports.Acquire()
+		if err != nil {
+			break
+		}
+		conn, err := media.Bind(transport, port, remote)
+		if err == nil {
+			return conn, nil
+		}
+		m.ports.Release(port)
+		lastErr = err
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoMediaPort, lastErr)
+	}
+	return nil, ErrNoMediaPort
+}
+
+// pending reports whether a session exists and has not started streaming
+func (m *StreamManager) pending(callID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[callID]
+	return ok && s.cancel == nil
+}
+
+// Play starts streaming a negotiated session (on ACK)
+func (m *StreamManager) Play(callID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[callID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if s.cancel != nil {
+		return nil // Retransmitted ACK
+	}
+	s.timeout.Stop()
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	s.cancel = cancel
+	s.StartedAt = time.Now()
+	go m.stream(ctx, s)
+	return nil
+}
+
+// stream relays the camera to the platform until the session ends
+func (m *StreamManager) stream(ctx context.Context, s *StreamSession) {
+	defer close(s.done)
+	defer m.remove(s)
+
+	if err := s.conn.Connect(ctx); err != nil {
+		log.Printf("[GB28181] Stream %s: %v", s.CallID, err)
+		return
+	}
+	src, err := m.newSource(s.SourceURL)
+	if err != nil {
+		log.Printf("[GB28181] Stream %s: %v", s.CallID, err)
+		return
+	}
+
+	ssrc, _ := strconv.ParseUint(s.SSRC, 10, 32)
+	muxer := media.NewPSMuxer()
+	packetizer := media.NewPacketizer(s.PayloadType, uint32(ssrc))
+	streamCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	keyframeSeen := false
+	err = src.Run(streamCtx, func(f media.Frame) {
+		// The platform cannot decode anything before the first keyframe
+		if !keyframeSeen && !f.Keyframe {
+			return
+		}
+		keyframeSeen = true
+		for _, pkt := range packetizer.Packetize(muxer.Mux(f), f.PTS) {
+			if err := s.conn.WritePacket(pkt); err != nil {
+				log.Printf("[GB28181] Stream %s: send to %s failed: %v", s.CallID, s.RemoteAddr, err)
+				stop()
+				return
+			}
+		}
+	})
+	if err != nil {
+		log.Printf("[GB28181] Stream %s: source %s: %v", s.CallID, s.SourceURL, err)
+		return
+	}
+	log.Printf("[GB28181] Stream %s ended", s.CallID)
+}
+
+// remove forgets a session and releases its port
+func (m *StreamManager) remove(s *StreamSession) {
+	m.mu.Lock()
+	if cur, ok := m.sessions[s.CallID]; ok && cur == s {
+		delete(m.sessions, s.CallID)
+	}
+	m.mu.Unlock()
+
+	s.conn.Close()
+	m.ports.Release(s.LocalPort)
+}
+
+// Bye stops a session (on BYE or CANCEL). It returns false if the call is unknown.
+func (m *StreamManager) Bye(callID string) bool {
+	m.mu.Lock()
+	s, ok := m.sessions[callID]
+	if ok {
+		delete(m.sessions, callID)
+	}
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	s.timeout.Stop()
+	if s.cancel != nil {
+		s.cancel()
+		s.conn.Close()
+		<-s.done
+	} else {
+		m.remove(s)
+	}
+	log.Printf("[GB28181] Stream %s stopped (channel %s)", callID, s.ChannelID)
+	return true
+}
+
+// Sessions returns a snapshot of the active sessions
+func (m *StreamManager) Sessions() []StreamSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := make([]StreamSession, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, StreamSession{
+			CallID:      s.CallID,
+			ChannelID:   s.ChannelID,
+			DroneID:     s.DroneID,
+			SourceURL:   s.SourceURL,
+			SSRC:        s.SSRC,
+			PayloadType: s.PayloadType,
+			RemoteAddr:  s.RemoteAddr,
+			LocalPort:   s.LocalPort,
+			Transport:   s.Transport,
+			CreatedAt:   s.CreatedAt,
+			StartedAt:   s.StartedAt,
+		})
+	}
+	return sessions
+}
+
+// Count returns the number of sessions
+func (m *StreamManager) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions)
+}
+
+// Close stops every session
+func (m *StreamManager) Close() {
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.sessions))
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+
+	for _, id := range ids {
+		m.Bye(id)
+	}
+}
@@ -0,0 +1,193 @@
+package gb28181
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emiago/sipgo/sip"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/media"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// fakeSource emits a delta frame followed by a keyframe and waits for cancel
+type fakeSource struct{}
+
+func (fakeSource) Run(ctx context.Context, emit func(media.Frame)) error {
+	emit(media.Frame{NALUs: [][]byte{{0x41, 0x01}}, PTS: 1000})
+	emit(media.Frame{NALUs: [][]byte{{0x65, 0x88}}, PTS: 4000, Keyframe: true})
+	<-ctx.Done()
+	return nil
+}
+
+func newTestStreamManager(t *testing.T) (*StreamManager, *DeviceManager) {
+	t.Helper()
+	cfg := config.GB28181Config{
+		DeviceID:     "34020000001320000001",
+		LocalIP:      "127.0.0.1",
+		ServerDomain: "3402000000",
+		Video: config.GB28181VideoConfig{
+			Enabled:      true,
+			SourceURL:    "rtsp://camera/stream1",
+			MediaPortMin: 39400,
+			MediaPortMax: 39409,
+		},
+	}
+	m := NewStreamManager(context.Background(), cfg)
+	m.newSource = func(string) (media.Source, error) { return fakeSource{}, nil }
+	t.Cleanup(m.Close)
+
+	dm := NewDeviceManager(cfg.DeviceID)
+	dm.UpdateDrone(&models.DroneState{DeviceID: "drone-001"})
+	return m, dm
+}
+
+func testOffer(port int, session string) *media.SessionDescription {
+	offer, _ := media.ParseSDP([]byte(fmt.Sprintf("v=0\r\n"+
+		"o=34020000002000000001 0 0 IN IP4 127.0.0.1\r\n"+
+		"s=%s\r\nc=IN IP4 127.0.0.1\r\nt=0 0\r\n"+
+		"m=video %d RTP/AVP 96 98\r\na=recvonly\r\na=rtpmap:96 PS/90000\r\na=rtpmap:98 H264/90000\r\n"+
+		"y=0100000001\r\n", session, port)))
+	return offer
+}
+
+func TestStreamManager_InviteAndPlay(t *testing.T) {
+	m, dm := newTestStreamManager(t)
+	ch := dm.GetChannel("drone-001")
+
+	platform, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer platform.Close()
+
+	answer, err := m.Invite("call-1", ch, testOffer(platform.LocalAddr().(*net.UDPAddr).Port, "Play"))
+	if err != nil {
+		t.Fatalf("Invite failed: %v", err)
+	}
+	video := answer.Video()
+	if video.Port < 39400 || video.Port > 39409 {
+		t.Errorf("Answer port = %d, want within 39400-39409", video.Port)
+	}
+	if _, ok := video.Attr("sendonly"); !ok {
+		t.Error("Answer should be sendonly")
+	}
+	if answer.SSRC != "0100000001" || answer.Origin != ch.DeviceID {
+		t.Errorf("Answer SSRC = %s, origin = %s", answer.SSRC, answer.Origin)
+	}
+
+	if err := m.Play("call-1"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	platform.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := platform.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("No RTP received: %v", err)
+	}
+	pkt, err := media.ParseRTP(buf[:n])
+	if err != nil {
+		t.Fatalf("ParseRTP failed: %v", err)
+	}
+	if pkt.SSRC != 100000001 || pkt.PayloadType != 96 {
+		t.Errorf("RTP SSRC = %d, PT = %d", pkt.SSRC, pkt.PayloadType)
+	}
+	if pkt.Timestamp != 4000 || !bytes.HasPrefix(pkt.Payload, []byte{0x00, 0x00, 0x01, 0xBA}) {
+		t.Error("First packet should carry the keyframe PS pack")
+	}
+
+	if !m.Bye("call-1") {
+		t.Error("Bye should find the session")
+	}
+	if m.Count() != 0 || m.ports.InUse() != 0 {
+		t.Errorf("After Bye: sessions = %d, ports in use = %d", m.Count(), m.ports.InUse())
+	}
+	if m.Bye("call-1") {
+		t.Error("Second Bye should report an unknown session")
+	}
+}
+
+func TestStreamManager_InviteRejected(t *testing.T) {
+	m, dm := newTestStreamManager(t)
+	ch := dm.GetChannel("drone-001")
+
+	if _, err := m.Invite("call-1", ch, testOffer(30000, "Playback")); !errors.Is(err, ErrUnsupportedSession) {
+		t.Errorf("Playback error = %v, want %v", err, ErrUnsupportedSession)
+	}
+
+	m.cfg.SourceURL = ""
+	if _, err := m.Invite("call-2", ch, testOffer(30000, "Play")); !errors.Is(err, ErrNoVideoSource) {
+		t.Errorf("No source error = %v, want %v", err, ErrNoVideoSource)
+	}
+
+	m.cfg.Sources = map[string]string{"drone-001": "rtp://0.0.0.0:5004"}
+	if _, err := m.Invite("call-3", ch, testOffer(30000, "Play")); err != nil {
+		t.Errorf("Per-drone source should be used: %v", err)
+	}
+	if m.Count() != 1 {
+		t.Errorf("Sessions = %d, want 1", m.Count())
+	}
+}
+
+// newSIPRequest builds a minimal in-dialog request from the platform
+func newSIPRequest(method sip.RequestMethod, channelID, callID string, body []byte) *sip.Request {
+	req := sip.NewRequest(method, sip.Uri{User: channelID, Host: "127.0.0.1", Port: 5060})
+	req.AppendHeader(&sip.FromHeader{Address: sip.Uri{User: "34020000002000000001", Host: "3402000000"}, Params: sip.NewParams()})
+	req.AppendHeader(&sip.ToHeader{Address: sip.Uri{User: channelID, Host: "3402000000"}, Params: sip.NewParams()})
+	id := sip.CallIDHeader(callID)
+	req.AppendHeader(&id)
+	req.AppendHeader(&sip.CSeqHeader{SeqNo: 1, MethodName: method})
+	if body != nil {
+		req.AppendHeader(sip.NewHeader("Content-Type", "application/sdp"))
+		req.SetBody(body)
+	}
+	return req
+}
+
+func TestRequestHandler_InviteBye(t *testing.T) {
+	m, dm := newTestStreamManager(t)
+	ch := dm.GetChannel("drone-001")
+	h := NewRequestHandler(dm, NewSubscriptionManager(), NewSIPClient(config.GB28181Config{LocalIP: "127.0.0.1", LocalPort: 5060}))
+
+	offer := testOffer(30000, "Play").Marshal()
+	if resp := h.HandleRequest(newSIPRequest(sip.INVITE, ch.DeviceID, "call-1", offer)); resp.StatusCode != 488 {
+		t.Errorf("INVITE without video = %d, want 488", resp.StatusCode)
+	}
+
+	h.SetStreamManager(m)
+	if resp := h.HandleRequest(newSIPRequest(sip.INVITE, "34020000001329999999", "call-1", offer)); resp.StatusCode != 404 {
+		t.Errorf("INVITE for unknown channel = %d, want 404", resp.StatusCode)
+	}
+
+	resp := h.HandleRequest(newSIPRequest(sip.INVITE, ch.DeviceID, "call-1", offer))
+	if resp.StatusCode != 200 {
+		t.Fatalf("INVITE = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.GetHeader("Content-Type"); ct == nil || ct.Value() != "application/sdp" {
+		t.Error("INVITE answer should be application/sdp")
+	}
+	if !strings.Contains(string(resp.Body()), "a=sendonly") {
+		t.Errorf("INVITE answer body:\n%s", resp.Body())
+	}
+	if resp.GetHeader("Contact") == nil {
+		t.Error("INVITE answer should carry a Contact header")
+	}
+
+	if resp := h.HandleRequest(newSIPRequest(sip.ACK, ch.DeviceID, "call-1", nil)); resp != nil {
+		t.Error("ACK must not be answered")
+	}
+	if resp := h.HandleRequest(newSIPRequest(sip.BYE, ch.DeviceID, "call-1", nil)); resp.StatusCode != 200 {
+		t.Errorf("BYE = %d, want 200", resp.StatusCode)
+	}
+	if resp := h.HandleRequest(newSIPRequest(sip.BYE, ch.DeviceID, "call-1", nil)); resp.StatusCode != 481 {
+		t.Errorf("Second BYE = %d, want 481", resp.StatusCode)
+	}
+}
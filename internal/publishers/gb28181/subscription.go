@@ -3,15 +3,21 @@ package gb28181
 import (
 	"sync"
 	"time"
+
+	gbxml "github.com/open-uav/telemetry-bridge/internal/publishers/gb28181/xml"
 )
 
-// Subscription represents a position subscription from the platform
+// Subscription represents a position, alarm or catalog subscription from
+// the platform
 type Subscription struct {
 	ID        string    // Subscription ID (from SUBSCRIBE dialog)
 	DeviceID  string    // Target device ID (or "*" for all)
 	Interval  int       // Report interval in seconds
 	Expires   time.Time // Subscription expiry time
 	EventType string    // Event type (e.g., "presence")
+
+	Alarm   *gbxml.AlarmQuery // Conditions of an alarm subscription, nil for position subscriptions
+	Catalog bool              // Catalog subscription: channel changes are notified
 }
 
 // SubscriptionManager manages active subscriptions
@@ -80,6 +86,36 @@ func (sm *SubscriptionManager) GetForDevice(deviceID string) []*Subscription {
 	return subs
 }
 
+// GetAlarmSubscriptions returns the active alarm subscriptions
+func (sm *SubscriptionManager) GetAlarmSubscriptions() []*Subscription {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	now := time.Now()
+	var subs []*Subscription
+	for _, sub := range sm.subscriptions {
+		if sub.Alarm != nil && sub.Expires.After(now) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// GetCatalogSubscriptions returns the active catalog subscriptions
+func (sm *SubscriptionManager) GetCatalogSubscriptions() []*Subscription {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	now := time.Now()
+	var subs []*Subscription
+	for _, sub := range sm.subscriptions {
+		if sub.Catalog && sub.Expires.After(now) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
 // HasActiveSubscriptions returns true if there are any active subscriptions
 func (sm *SubscriptionManager) HasActiveSubscriptions() bool {
 	sm.mu.RLock()
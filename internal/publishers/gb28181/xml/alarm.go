@@ -0,0 +1,77 @@
+package xml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Alarm priorities (GB/T 28181-2016 A.2.5); 1 is the most severe
+const (
+	AlarmPriorityFirst  = 1
+	AlarmPrioritySecond = 2
+	AlarmPriorityThird  = 3
+	AlarmPriorityFourth = 4
+)
+
+// Alarm methods (GB/T 28181-2016 A.2.5)
+const (
+	AlarmMethodPhone       = 1
+	AlarmMethodDevice      = 2
+	AlarmMethodSMS         = 3
+	AlarmMethodGPS         = 4
+	AlarmMethodVideo       = 5
+	AlarmMethodDeviceFault = 6
+	AlarmMethodOther       = 7
+)
+
+// AlarmNotify represents an alarm notification
+type AlarmNotify struct {
+	XMLName          xml.Name `xml:"Notify"`
+	CmdType          CmdType  `xml:"CmdType"`
+	SN               int      `xml:"SN"`
+	DeviceID         string   `xml:"DeviceID"`
+	AlarmPriority    int      `xml:"AlarmPriority"`
+	AlarmMethod      int      `xml:"AlarmMethod"`
+	AlarmTime        string   `xml:"AlarmTime"`
+	AlarmDescription string   `xml:"AlarmDescription,omitempty"`
+	Longitude        *float64 `xml:"Longitude,omitempty"` // Omitted when the position is unknown
+	Latitude         *float64 `xml:"Latitude,omitempty"`
+}
+
+// Marshal serializes the alarm notification to XML with declaration
+func (n *AlarmNotify) Marshal() (string, error) {
+	data, err := xml.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal alarm: %w", err)
+	}
+	return XMLDeclaration + "\r\n" + string(data), nil
+}
+
+// AlarmQuery is the body of an alarm SUBSCRIBE. Zero priorities and an
+// empty or "0" method accept every alarm.
+type AlarmQuery struct {
+	XMLName            xml.Name `xml:"Query"`
+	CmdType            CmdType  `xml:"CmdType"`
+	SN                 int      `xml:"SN"`
+	DeviceID           string   `xml:"DeviceID"`
+	StartAlarmPriority int      `xml:"StartAlarmPriority"`
+	EndAlarmPriority   int      `xml:"EndAlarmPriority"`
+	AlarmMethod        string   `xml:"AlarmMethod"` // Combination of method digits, e.g. "24" = device or GPS
+}
+
+// Accepts reports whether an alarm with the given priority and method
+// matches the query conditions
+func (q *AlarmQuery) Accepts(priority, method int) bool {
+	if q.StartAlarmPriority > 0 && priority < q.StartAlarmPriority {
+		return false
+	}
+	if q.EndAlarmPriority > 0 && priority > q.EndAlarmPriority {
+		return false
+	}
+	if q.AlarmMethod == "" || q.AlarmMethod == "0" {
+		return true
+	}
+	return strings.Contains(q.AlarmMethod, strconv.Itoa(method))
+}
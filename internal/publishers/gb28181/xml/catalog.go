@@ -22,6 +22,24 @@ func ParseCatalogQuery(data []byte) (*CatalogQuery, error) {
 	return &q, nil
 }
 
+// NewCatalogQuery creates a Catalog query for a downstream device
+func NewCatalogQuery(deviceID string, sn int) *CatalogQuery {
+	return &CatalogQuery{
+		CmdType:  CmdTypeCatalog,
+		SN:       sn,
+		DeviceID: deviceID,
+	}
+}
+
+// Marshal serializes the catalog query to XML with declaration
+func (q *CatalogQuery) Marshal() (string, error) {
+	data, err := xml.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal catalog query: %w", err)
+	}
+	return XMLDeclaration + "\r\n" + string(data), nil
+}
+
 // CatalogResponse represents a Catalog query response
 type CatalogResponse struct {
 	XMLName    xml.Name        `xml:"Response"`
@@ -55,8 +73,18 @@ type CatalogItem struct {
 	Status       string `xml:"Status"`
 	Longitude    string `xml:"Longitude,omitempty"`
 	Latitude     string `xml:"Latitude,omitempty"`
+	Event        string `xml:"Event,omitempty"` // Change reported by a catalog notification
 }
 
+// Catalog change events (GB/T 28181-2016 A.2.6.3)
+const (
+	CatalogEventOn     = "ON"
+	CatalogEventOff    = "OFF"
+	CatalogEventAdd    = "ADD"
+	CatalogEventDelete = "DEL"
+	CatalogEventUpdate = "UPDATE"
+)
+
 // NewCatalogResponse creates a Catalog response
 func NewCatalogResponse(deviceID string, sn int, items []CatalogItem) *CatalogResponse {
 	return &CatalogResponse{
@@ -102,3 +130,38 @@ func (r *CatalogResponse) Marshal() (string, error) {
 	}
 	return XMLDeclaration + "\r\n" + string(data), nil
 }
+
+// CatalogNotify reports catalog changes to a catalog subscription. A
+// notification sent when the subscription is created carries the whole
+// catalog without events.
+type CatalogNotify struct {
+	XMLName    xml.Name        `xml:"Notify"`
+	CmdType    CmdType         `xml:"CmdType"`
+	SN         int             `xml:"SN"`
+	DeviceID   string          `xml:"DeviceID"`
+	SumNum     int             `xml:"SumNum"`
+	DeviceList CatalogItemList `xml:"DeviceList"`
+}
+
+// NewCatalogNotify creates a catalog notification
+func NewCatalogNotify(deviceID string, sn int, items []CatalogItem) *CatalogNotify {
+	return &CatalogNotify{
+		CmdType:  CmdTypeCatalog,
+		SN:       sn,
+		DeviceID: deviceID,
+		SumNum:   len(items),
+		DeviceList: CatalogItemList{
+			Num:   len(items),
+			Items: items,
+		},
+	}
+}
+
+// Marshal serializes the catalog notification to XML with declaration
+func (n *CatalogNotify) Marshal() (string, error) {
+	data, err := xml.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal catalog notify: %w", err)
+	}
+	return XMLDeclaration + "\r\n" + string(data), nil
+}
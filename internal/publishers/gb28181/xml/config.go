@@ -0,0 +1,116 @@
+package xml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// ConfigTypeBasicParam is the only configuration type the gateway reports
+const ConfigTypeBasicParam = "BasicParam"
+
+// ConfigDownloadQuery asks for device configuration (GB/T 28181-2016
+// A.2.4.7). ConfigType lists the requested types separated by "/".
+type ConfigDownloadQuery struct {
+	XMLName    xml.Name `xml:"Query"`
+	CmdType    CmdType  `xml:"CmdType"`
+	SN         int      `xml:"SN"`
+	DeviceID   string   `xml:"DeviceID"`
+	ConfigType string   `xml:"ConfigType"`
+}
+
+// Wants reports whether the query asks for the given configuration type
+func (q *ConfigDownloadQuery) Wants(configType string) bool {
+	for _, t := range strings.Split(q.ConfigType, "/") {
+		if strings.TrimSpace(t) == configType {
+			return true
+		}
+	}
+	return false
+}
+
+// BasicParam is the basic device configuration. The password is never
+// reported.
+type BasicParam struct {
+	Name              string `xml:"Name"`
+	DeviceID          string `xml:"DeviceID"`
+	SIPServerID       string `xml:"SIPServerId"`
+	SIPServerIP       string `xml:"SIPServerIp"`
+	SIPServerPort     int    `xml:"SIPServerPort"`
+	DomainName        string `xml:"DomainName"`
+	Expiration        int    `xml:"Expiration"`        // Registration expiry in seconds
+	HeartBeatInterval int    `xml:"HeartBeatInterval"` // Seconds between keepalives
+	HeartBeatCount    int    `xml:"HeartBeatCount"`    // Missed keepalives before the device is offline
+}
+
+// ConfigDownloadResponse answers a ConfigDownload query
+type ConfigDownloadResponse struct {
+	XMLName    xml.Name    `xml:"Response"`
+	CmdType    CmdType     `xml:"CmdType"`
+	SN         int         `xml:"SN"`
+	DeviceID   string      `xml:"DeviceID"`
+	Result     string      `xml:"Result"`
+	BasicParam *BasicParam `xml:"BasicParam,omitempty"`
+}
+
+// Marshal serializes the configuration response to XML with declaration
+func (r *ConfigDownloadResponse) Marshal() (string, error) {
+	data, err := xml.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal config download response: %w", err)
+	}
+	return XMLDeclaration + "\r\n" + string(data), nil
+}
+
+// DeviceConfigControl changes device configuration (GB/T 28181-2016
+// A.2.3.2). Omitted BasicParam fields are left unchanged.
+type DeviceConfigControl struct {
+	XMLName    xml.Name          `xml:"Control"`
+	CmdType    CmdType           `xml:"CmdType"`
+	SN         int               `xml:"SN"`
+	DeviceID   string            `xml:"DeviceID"`
+	BasicParam *BasicParamUpdate `xml:"BasicParam"`
+}
+
+// BasicParamUpdate holds the BasicParam fields set by a DeviceConfig, nil
+// for those left unchanged
+type BasicParamUpdate struct {
+	Name              *string `xml:"Name"`
+	Expiration        *int    `xml:"Expiration"`
+	HeartBeatInterval *int    `xml:"HeartBeatInterval"`
+	HeartBeatCount    *int    `xml:"HeartBeatCount"`
+}
+
+// DeviceControl is a remote control command (GB/T 28181-2016 A.2.3.1).
+// Only one command is set per message.
+type DeviceControl struct {
+	XMLName   xml.Name `xml:"Control"`
+	CmdType   CmdType  `xml:"CmdType"`
+	SN        int      `xml:"SN"`
+	DeviceID  string   `xml:"DeviceID"`
+	TeleBoot  string   `xml:"TeleBoot"` // "Boot" restarts the device
+	PTZCmd    string   `xml:"PTZCmd"`
+	RecordCmd string   `xml:"RecordCmd"`
+	GuardCmd  string   `xml:"GuardCmd"`
+	AlarmCmd  string   `xml:"AlarmCmd"`
+	IFameCmd  string   `xml:"IFameCmd"`
+}
+
+// Command names the command carried by the message, "" if none
+func (c *DeviceControl) Command() string {
+	switch {
+	case c.TeleBoot != "":
+		return "TeleBoot"
+	case c.PTZCmd != "":
+		return "PTZCmd"
+	case c.RecordCmd != "":
+		return "RecordCmd"
+	case c.GuardCmd != "":
+		return "GuardCmd"
+	case c.AlarmCmd != "":
+		return "AlarmCmd"
+	case c.IFameCmd != "":
+		return "IFameCmd"
+	}
+	return ""
+}
@@ -1,12 +1,14 @@
 package xml
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"math"
 	"time"
 
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 // XMLDeclaration is the standard XML declaration for GB28181 messages
@@ -22,8 +24,28 @@ const (
 	CmdTypeMobilePosition CmdType = "MobilePosition"
 	CmdTypeKeepalive      CmdType = "Keepalive"
 	CmdTypeRecordInfo     CmdType = "RecordInfo"
+	CmdTypeAlarm          CmdType = "Alarm"
+	CmdTypeConfigDownload CmdType = "ConfigDownload"
+	CmdTypeDeviceConfig   CmdType = "DeviceConfig"
+	CmdTypeDeviceControl  CmdType = "DeviceControl"
 )
 
+// Result values of a Response
+const (
+	ResultOK    = "OK"
+	ResultError = "ERROR"
+)
+
+// Unmarshal parses a MANSCDP body. Bodies declaring GB2312 are accepted;
+// the fields read by the gateway are ASCII.
+func Unmarshal(data []byte, v any) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return d.Decode(v)
+}
+
 // Query represents a GB28181 query message
 type Query struct {
 	XMLName  xml.Name `xml:"Query"`
@@ -32,6 +54,15 @@ type Query struct {
 	DeviceID string   `xml:"DeviceID"`
 }
 
+// Header is the part common to all MANSCDP messages. XMLName tells a
+// Query, Control, Notify or Response apart.
+type Header struct {
+	XMLName  xml.Name
+	CmdType  CmdType `xml:"CmdType"`
+	SN       int     `xml:"SN"`
+	DeviceID string  `xml:"DeviceID"`
+}
+
 // Response represents a GB28181 response message
 type Response struct {
 	XMLName  xml.Name `xml:"Response"`
@@ -41,6 +72,25 @@ type Response struct {
 	Result   string   `xml:"Result,omitempty"`
 }
 
+// NewResponse creates a response carrying only a result
+func NewResponse(cmdType CmdType, sn int, deviceID, result string) *Response {
+	return &Response{
+		CmdType:  cmdType,
+		SN:       sn,
+		DeviceID: deviceID,
+		Result:   result,
+	}
+}
+
+// Marshal serializes the response to XML with declaration
+func (r *Response) Marshal() (string, error) {
+	data, err := xml.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal response: %w", err)
+	}
+	return XMLDeclaration + "\r\n" + string(data), nil
+}
+
 // Notify represents a GB28181 notification message
 type Notify struct {
 	XMLName  xml.Name `xml:"Notify"`
@@ -72,11 +122,28 @@ type MobilePositionNotify struct {
 	Altitude  float64  `xml:"Altitude"`
 }
 
+// TimeLayout is the GB28181 timestamp layout (local time without zone offset)
+const TimeLayout = "2006-01-02T15:04:05"
+
+// FormatTime converts a Unix millisecond timestamp to a GB28181 time string in loc
+func FormatTime(ms int64, loc *time.Location) string {
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.UnixMilli(ms).In(loc).Format(TimeLayout)
+}
+
 // NewMobilePositionNotify creates a MobilePosition notification from DroneState
+// using the server's local timezone
 func NewMobilePositionNotify(state *models.DroneState, sn int) *MobilePositionNotify {
+	return NewMobilePositionNotifyIn(state, sn, time.Local)
+}
+
+// NewMobilePositionNotifyIn creates a MobilePosition notification with the
+// Time field expressed in the given timezone
+func NewMobilePositionNotifyIn(state *models.DroneState, sn int, loc *time.Location) *MobilePositionNotify {
 	// Convert timestamp (Unix milliseconds) to ISO8601
-	t := time.UnixMilli(state.Timestamp)
-	timeStr := t.Format("2006-01-02T15:04:05")
+	timeStr := FormatTime(state.Timestamp, loc)
 
 	// Calculate ground speed from Vx, Vy
 	speed := math.Sqrt(state.Velocity.Vx*state.Velocity.Vx + state.Velocity.Vy*state.Velocity.Vy)
@@ -0,0 +1,131 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// maxShadowName is the longest named shadow AWS IoT accepts
+const maxShadowName = 64
+
+// awsIoT publishes to AWS IoT Core, keeping a named shadow per drone under
+// the bridge's thing: $aws/things/{thing}/shadow/name/{device_id}
+type awsIoT struct {
+	tls      *tls.Config
+	shadow   *reportThrottle // nil when shadow updates are off
+	topicPre string          // $aws/things/{thing}/shadow/name/
+}
+
+// NewAWSIoT creates a publisher for AWS IoT Core authenticating with the
+// thing certificate
+func NewAWSIoT(cfg config.AWSIoTConfig) (*Publisher, error) {
+	if cfg.Endpoint == "" || cfg.ThingName == "" {
+		return nil, fmt.Errorf("endpoint and thing_name are required")
+	}
+	if cfg.QoS < 0 || cfg.QoS > 1 {
+		return nil, fmt.Errorf("qos must be 0 or 1")
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load thing certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ServerName:   cfg.Endpoint,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca_file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_file %s contains no certificates", cfg.CAFile)
+		}
+	}
+	// MQTT on port 443 is selected by ALPN
+	if cfg.Port == 443 {
+		tlsConfig.NextProtos = []string{"x-amzn-mqtt-ca"}
+	}
+
+	a := &awsIoT{
+		tls:      tlsConfig,
+		topicPre: "$aws/things/" + cfg.ThingName + "/shadow/name/",
+	}
+	if cfg.Shadow.Enabled {
+		a.shadow = newReportThrottle(cfg.Shadow.IntervalSec)
+	}
+
+	p := New(config.MQTTConfig{
+		Broker:             fmt.Sprintf("ssl://%s:%d", cfg.Endpoint, cfg.Port),
+		ClientID:           cfg.ThingName,
+		TopicPrefix:        cfg.TopicPrefix,
+		QoS:                cfg.QoS,
+		ReconnectInitialMs: 1000,
+		ReconnectMaxMs:     60000,
+		PayloadFormat:      PayloadFormatJSON,
+	})
+	p.cloud = a
+	return p, nil
+}
+
+func (a *awsIoT) name() string {
+	return "aws_iot"
+}
+
+func (a *awsIoT) configure(opts *pahomqtt.ClientOptions) {
+	opts.SetTLSConfig(a.tls)
+}
+
+// onConnect logs shadow updates the service rejects, e.g. for documents
+// over the size limit or missing policy permissions
+func (a *awsIoT) onConnect(c pahomqtt.Client) {
+	if a.shadow == nil {
+		return
+	}
+	c.Subscribe(a.topicPre+"+/update/rejected", 0, func(_ pahomqtt.Client, m pahomqtt.Message) {
+		log.Printf("[AWS IoT] Shadow update rejected on %s: %s", m.Topic(), m.Payload())
+	})
+}
+
+func (a *awsIoT) topic(topicData, string) string {
+	return ""
+}
+
+func (a *awsIoT) routed(_ topicData, topic string) string {
+	return topic
+}
+
+// report returns the update of the drone's named shadow
+func (a *awsIoT) report(state *models.DroneState, now time.Time) (message, bool) {
+	if a.shadow == nil || !a.shadow.due(state.DeviceID, now) {
+		return message{}, false
+	}
+
+	doc := map[string]any{"state": map[string]any{"reported": newReportedState(state)}}
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return message{}, false
+	}
+	return message{topic: a.topicPre + shadowName(state.DeviceID) + "/update", payload: payload}, true
+}
+
+// shadowName maps a device ID to a valid shadow name: letters, digits,
+// colons, underscores and hyphens, at most 64 bytes
+func shadowName(deviceID string) string {
+	return sanitizeName(deviceID, func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == ':' || r == '_' || r == '-'
+	}, maxShadowName)
+}
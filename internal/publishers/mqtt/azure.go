@@ -0,0 +1,188 @@
+package mqtt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// azureAPIVersion is the IoT Hub API version announced in the username
+const azureAPIVersion = "2021-04-12"
+
+// maxTwinKey is the longest twin property name IoT Hub accepts
+const maxTwinKey = 128
+
+// azureIoT publishes to Azure IoT Hub as one device. IoT Hub only accepts
+// its own topics, so all messages go to the device's events topic with the
+// drone in message properties, and drones are kept as reported properties
+// of the device twin under "drones".
+type azureIoT struct {
+	host     string
+	deviceID string
+	key      []byte // Decoded shared access key
+	tokenTTL time.Duration
+	twin     *reportThrottle // nil when twin updates are off
+	rid      atomic.Uint64   // Request ID of twin updates
+}
+
+// NewAzureIoT creates a publisher for Azure IoT Hub authenticating with
+// SAS tokens derived from the device connection string
+func NewAzureIoT(cfg config.AzureIoTConfig) (*Publisher, error) {
+	if cfg.QoS < 0 || cfg.QoS > 1 {
+		return nil, fmt.Errorf("qos must be 0 or 1")
+	}
+	a, err := parseConnectionString(cfg.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	a.tokenTTL = time.Duration(cfg.TokenTTLSec) * time.Second
+	if cfg.Twin.Enabled {
+		a.twin = newReportThrottle(cfg.Twin.IntervalSec)
+	}
+
+	p := New(config.MQTTConfig{
+		Broker:             fmt.Sprintf("ssl://%s:8883", a.host),
+		ClientID:           a.deviceID,
+		QoS:                cfg.QoS,
+		ReconnectInitialMs: 1000,
+		ReconnectMaxMs:     60000,
+		PayloadFormat:      PayloadFormatJSON,
+	})
+	p.cloud = a
+	return p, nil
+}
+
+// parseConnectionString reads a device connection string
+func parseConnectionString(s string) (*azureIoT, error) {
+	fields := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			fields[k] = v
+		}
+	}
+	a := &azureIoT{host: fields["HostName"], deviceID: fields["DeviceId"]}
+	if a.host == "" || a.deviceID == "" || fields["SharedAccessKey"] == "" {
+		return nil, fmt.Errorf("connection string needs HostName, DeviceId and SharedAccessKey")
+	}
+	if fields["ModuleId"] != "" {
+		return nil, fmt.Errorf("module connection strings are not supported")
+	}
+	key, err := base64.StdEncoding.DecodeString(fields["SharedAccessKey"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SharedAccessKey: %w", err)
+	}
+	a.key = key
+	return a, nil
+}
+
+func (a *azureIoT) name() string {
+	return "azure_iot"
+}
+
+// configure sets the credentials. The SAS token is created on every
+// connect, so a reconnect after the token expired gets a fresh one.
+func (a *azureIoT) configure(opts *pahomqtt.ClientOptions) {
+	opts.SetProtocolVersion(4) // IoT Hub speaks MQTT 3.1.1 only
+	opts.SetTLSConfig(&tls.Config{ServerName: a.host, MinVersion: tls.VersionTLS12})
+	opts.SetCredentialsProvider(func() (string, string) {
+		username := fmt.Sprintf("%s/%s/?api-version=%s", a.host, a.deviceID, azureAPIVersion)
+		return username, a.sasToken(time.Now().Add(a.tokenTTL))
+	})
+}
+
+// sasToken signs the device's resource URI until expiry
+func (a *azureIoT) sasToken(expiry time.Time) string {
+	uri := url.QueryEscape(a.host + "/devices/" + a.deviceID)
+	se := strconv.FormatInt(expiry.Unix(), 10)
+
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(uri + "\n" + se))
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return fmt.Sprintf("SharedAccessSignature sr=%s&sig=%s&se=%s", uri, url.QueryEscape(sig), se)
+}
+
+// onConnect logs twin updates the service rejects, e.g. for a twin over
+// the size limit
+func (a *azureIoT) onConnect(c pahomqtt.Client) {
+	if a.twin == nil {
+		return
+	}
+	c.Subscribe("$iothub/twin/res/#", 0, func(_ pahomqtt.Client, m pahomqtt.Message) {
+		// $iothub/twin/res/{status}/?$rid={rid}
+		status := strings.SplitN(strings.TrimPrefix(m.Topic(), "$iothub/twin/res/"), "/", 2)[0]
+		if !strings.HasPrefix(status, "2") {
+			log.Printf("[Azure IoT] Twin update failed with status %s: %s", status, m.Payload())
+		}
+	})
+}
+
+// topic returns the events topic with the drone and message kind as
+// properties, which IoT Hub message routing can filter on
+func (a *azureIoT) topic(d topicData, suffix string) string {
+	props := url.Values{"message": {suffix}}
+	if d.DeviceID != "" {
+		props.Set("device_id", d.DeviceID)
+	}
+	if d.Tenant != "" {
+		props.Set("tenant", d.Tenant)
+	}
+	if d.ProtocolSource != "" {
+		props.Set("protocol_source", d.ProtocolSource)
+	}
+	return a.eventsTopic(props)
+}
+
+// routed keeps the topic of a routing rule as the route property
+func (a *azureIoT) routed(d topicData, topic string) string {
+	props := url.Values{"message": {"state"}, "device_id": {d.DeviceID}, "route": {topic}}
+	if d.Tenant != "" {
+		props.Set("tenant", d.Tenant)
+	}
+	if d.ProtocolSource != "" {
+		props.Set("protocol_source", d.ProtocolSource)
+	}
+	return a.eventsTopic(props)
+}
+
+// eventsTopic builds devices/{device_id}/messages/events/{properties}. The
+// JSON content type lets message routing query the body.
+func (a *azureIoT) eventsTopic(props url.Values) string {
+	return fmt.Sprintf("devices/%s/messages/events/$.ct=application%%2Fjson&$.ce=utf-8&%s", a.deviceID, props.Encode())
+}
+
+// report returns the patch of the drone's reported properties
+func (a *azureIoT) report(state *models.DroneState, now time.Time) (message, bool) {
+	if a.twin == nil || !a.twin.due(state.DeviceID, now) {
+		return message{}, false
+	}
+
+	patch := map[string]any{"drones": map[string]any{twinKey(state.DeviceID): newReportedState(state)}}
+	payload, err := json.Marshal(patch)
+	if err != nil {
+		return message{}, false
+	}
+	topic := fmt.Sprintf("$iothub/twin/PATCH/properties/reported/?$rid=%d", a.rid.Add(1))
+	return message{topic: topic, payload: payload}, true
+}
+
+// twinKey maps a device ID to a valid twin property name, which must not
+// contain '.', '$' or spaces
+func twinKey(deviceID string) string {
+	return sanitizeName(deviceID, func(r rune) bool {
+		return r != '.' && r != '$' && r != ' ' && r > 0x1f
+	}, maxTwinKey)
+}
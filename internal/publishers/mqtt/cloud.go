@@ -0,0 +1,92 @@
+package mqtt
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// cloudPreset adapts the publisher to a cloud IoT service: authentication,
+// the topics the service accepts and the per-drone state document it keeps
+// (AWS IoT device shadow, Azure IoT Hub device twin)
+type cloudPreset interface {
+	name() string
+	// configure sets TLS and credentials
+	configure(opts *pahomqtt.ClientOptions)
+	// onConnect subscribes to the service's responses
+	onConnect(c pahomqtt.Client)
+	// topic returns the topic of a device message, or "" for the default
+	// {prefix}/{device_id}/{suffix} layout
+	topic(d topicData, suffix string) string
+	// routed returns the topic for a state a routing rule sent to topic
+	routed(d topicData, topic string) string
+	// report returns the state document update for a state, if one is due
+	report(state *models.DroneState, now time.Time) (message, bool)
+}
+
+// reportedState is the summary of a drone kept in its shadow or twin
+type reportedState struct {
+	ProtocolSource string            `json:"protocol_source"`
+	Lat            float64           `json:"lat"`
+	Lon            float64           `json:"lon"`
+	AltGNSS        float64           `json:"alt_gnss"`
+	BatteryPercent int               `json:"battery_percent"`
+	FlightMode     models.FlightMode `json:"flight_mode"`
+	Armed          bool              `json:"armed"`
+	Timestamp      int64             `json:"timestamp"` // Unix ms
+}
+
+func newReportedState(state *models.DroneState) reportedState {
+	return reportedState{
+		ProtocolSource: state.ProtocolSource,
+		Lat:            state.Location.Lat,
+		Lon:            state.Location.Lon,
+		AltGNSS:        state.Location.AltGNSS,
+		BatteryPercent: state.Status.BatteryPercent,
+		FlightMode:     state.Status.FlightMode,
+		Armed:          state.Status.Armed,
+		Timestamp:      state.Timestamp,
+	}
+}
+
+// reportThrottle limits state document updates to one per device and
+// interval, since cloud services bill and rate-limit them
+type reportThrottle struct {
+	interval time.Duration
+	mu       sync.Mutex
+	last     map[string]time.Time
+}
+
+func newReportThrottle(intervalSec int) *reportThrottle {
+	return &reportThrottle{interval: time.Duration(intervalSec) * time.Second, last: make(map[string]time.Time)}
+}
+
+// due reports whether an update of the device is due and, if so, records it
+func (t *reportThrottle) due(deviceID string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if last, ok := t.last[deviceID]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+	t.last[deviceID] = now
+	return true
+}
+
+// sanitizeName replaces the characters a cloud service does not accept in
+// a name with underscores and truncates it to max bytes
+func sanitizeName(s string, valid func(r rune) bool, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if valid(r) {
+			return r
+		}
+		return '_'
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
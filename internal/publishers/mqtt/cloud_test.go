@@ -0,0 +1,191 @@
+package mqtt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// writeThingCert writes a self-signed certificate and key to dir
+func writeThingCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "outb"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "thing.crt"), filepath.Join(dir, "thing.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func TestNewAWSIoT(t *testing.T) {
+	certFile, keyFile := writeThingCert(t, t.TempDir())
+	cfg := config.AWSIoTConfig{
+		Endpoint:    "abc123-ats.iot.eu-west-1.amazonaws.com",
+		Port:        8883,
+		ThingName:   "outb-gateway",
+		CertFile:    certFile,
+		KeyFile:     keyFile,
+		TopicPrefix: "uav/telemetry",
+		QoS:         1,
+		Shadow:      config.ReportedStateConfig{Enabled: true, IntervalSec: 10},
+	}
+
+	for name, mutate := range map[string]func(c *config.AWSIoTConfig){
+		"no endpoint":  func(c *config.AWSIoTConfig) { c.Endpoint = "" },
+		"qos 2":        func(c *config.AWSIoTConfig) { c.QoS = 2 },
+		"missing cert": func(c *config.AWSIoTConfig) { c.CertFile = "missing.crt" },
+		"empty ca":     func(c *config.AWSIoTConfig) { c.CAFile = keyFile },
+	} {
+		bad := cfg
+		mutate(&bad)
+		if _, err := NewAWSIoT(bad); err == nil {
+			t.Errorf("NewAWSIoT() with %s should fail", name)
+		}
+	}
+
+	p, err := NewAWSIoT(cfg)
+	if err != nil {
+		t.Fatalf("NewAWSIoT() error = %v", err)
+	}
+	if p.Name() != "aws_iot" || p.cfg.Broker != "ssl://abc123-ats.iot.eu-west-1.amazonaws.com:8883" || p.cfg.ClientID != "outb-gateway" {
+		t.Errorf("Publisher = %s %s %s", p.Name(), p.cfg.Broker, p.cfg.ClientID)
+	}
+
+	state := models.NewDroneState("mavlink.1", "mavlink")
+	state.Status.BatteryPercent = 60
+	now := time.Now()
+	msgs, err := p.stateMessages(state, p.deviceTopic(state, "state"))
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("stateMessages() = %d messages, %v, want the state and the shadow update", len(msgs), err)
+	}
+	if msgs[0].topic != "uav/telemetry/mavlink.1/state" {
+		t.Errorf("State topic = %s", msgs[0].topic)
+	}
+	if msgs[1].topic != "$aws/things/outb-gateway/shadow/name/mavlink_1/update" {
+		t.Errorf("Shadow topic = %s", msgs[1].topic)
+	}
+	var doc struct {
+		State struct {
+			Reported reportedState `json:"reported"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(msgs[1].payload, &doc); err != nil || doc.State.Reported.BatteryPercent != 60 {
+		t.Errorf("Shadow update = %s", msgs[1].payload)
+	}
+
+	// Shadow updates are throttled per drone
+	if _, ok := p.cloud.report(state, now.Add(time.Second)); ok {
+		t.Error("Shadow update within the interval should be skipped")
+	}
+	if _, ok := p.cloud.report(state, now.Add(11*time.Second)); !ok {
+		t.Error("Shadow update after the interval should be sent")
+	}
+}
+
+func TestNewAzureIoT(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	cfg := config.AzureIoTConfig{
+		ConnectionString: "HostName=fleet.azure-devices.net;DeviceId=outb-gateway;SharedAccessKey=" + key,
+		TokenTTLSec:      3600,
+		Twin:             config.ReportedStateConfig{Enabled: true, IntervalSec: 10},
+	}
+
+	for _, cs := range []string{
+		"HostName=fleet.azure-devices.net;DeviceId=outb-gateway",
+		"HostName=fleet.azure-devices.net;DeviceId=outb-gateway;SharedAccessKey=not base64!",
+		cfg.ConnectionString + ";ModuleId=bridge",
+	} {
+		if _, err := NewAzureIoT(config.AzureIoTConfig{ConnectionString: cs}); err == nil {
+			t.Errorf("NewAzureIoT(%q) should fail", cs)
+		}
+	}
+
+	p, err := NewAzureIoT(cfg)
+	if err != nil {
+		t.Fatalf("NewAzureIoT() error = %v", err)
+	}
+	if p.Name() != "azure_iot" || p.cfg.Broker != "ssl://fleet.azure-devices.net:8883" || p.cfg.ClientID != "outb-gateway" {
+		t.Errorf("Publisher = %s %s %s", p.Name(), p.cfg.Broker, p.cfg.ClientID)
+	}
+
+	// All messages go to the device's events topic
+	state := models.NewDroneState("dji.1", "dji")
+	state.Tenant = "acme"
+	want := "devices/outb-gateway/messages/events/$.ct=application%2Fjson&$.ce=utf-8&device_id=dji.1&message=state&protocol_source=dji&tenant=acme"
+	if got := p.deviceTopic(state, "state"); got != want {
+		t.Errorf("deviceTopic() = %s, want %s", got, want)
+	}
+	if got := p.tenantTopic("", "dji.1", "raw/STATUSTEXT"); !strings.HasSuffix(got, "&device_id=dji.1&message=raw%2FSTATUSTEXT") {
+		t.Errorf("tenantTopic() = %s", got)
+	}
+	if got := p.cloud.routed(stateTopicData("", state), "fleet/priority"); !strings.Contains(got, "route=fleet%2Fpriority") {
+		t.Errorf("routed() = %s", got)
+	}
+
+	m, ok := p.cloud.report(state, time.Now())
+	if !ok || m.topic != "$iothub/twin/PATCH/properties/reported/?$rid=1" {
+		t.Fatalf("report() = %s, %v", m.topic, ok)
+	}
+	var patch struct {
+		Drones map[string]reportedState `json:"drones"`
+	}
+	if err := json.Unmarshal(m.payload, &patch); err != nil || patch.Drones["dji_1"].ProtocolSource != "dji" {
+		t.Errorf("Twin patch = %s, want drone dji_1", m.payload)
+	}
+}
+
+func TestAzureIoT_sasToken(t *testing.T) {
+	a, err := parseConnectionString("HostName=fleet.azure-devices.net;DeviceId=outb-gateway;SharedAccessKey=" +
+		base64.StdEncoding.EncodeToString([]byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := a.sasToken(time.Unix(1700000000, 0))
+	fields, err := url.ParseQuery(strings.TrimPrefix(token, "SharedAccessSignature "))
+	if err != nil {
+		t.Fatalf("Token %s does not parse: %v", token, err)
+	}
+	if fields.Get("sr") != "fleet.azure-devices.net/devices/outb-gateway" || fields.Get("se") != "1700000000" {
+		t.Errorf("Token = %s", token)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(url.QueryEscape("fleet.azure-devices.net/devices/outb-gateway") + "\n1700000000"))
+	if fields.Get("sig") != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("sig = %s, want the HMAC of the resource URI and expiry", fields.Get("sig"))
+	}
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/internal/core/broadcast"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/mqtt/sparkplug"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
 )
 
 // Publisher implements the core.Publisher interface for MQTT
@@ -19,28 +25,62 @@ type Publisher struct {
 	client pahomqtt.Client
 	mu     sync.RWMutex
 	ready  bool
+	lost   bool // Connection was lost and is being re-established
+
+	connectedAt time.Time
+	lastError   string // Why the connection was last lost or refused
+	lastErrorAt time.Time
+
+	sparkplug *sparkplugSession // Non-nil when publishing Sparkplug B
+
+	topicTmpl *template.Template // Parsed topic_template, nil for the default layout
+	topicErr  error              // Why topic_template did not parse, reported by Start
+
+	cloud cloudPreset // Non-nil for the AWS IoT and Azure IoT Hub presets
 }
 
 // New creates a new MQTT publisher
 func New(cfg config.MQTTConfig) *Publisher {
-	return &Publisher{
+	p := &Publisher{
 		cfg: cfg,
 	}
+	if cfg.PayloadFormat == PayloadFormatSparkplug {
+		node := cfg.Sparkplug.EdgeNodeID
+		if node == "" {
+			node = cfg.ClientID
+		}
+		p.sparkplug = newSparkplugSession(cfg.Sparkplug.GroupID, node)
+	}
+	p.topicTmpl, p.topicErr = ParseTopicTemplate(cfg.TopicTemplate)
+	return p
 }
 
 // Name returns the publisher name
 func (p *Publisher) Name() string {
+	if p.cloud != nil {
+		return p.cloud.name()
+	}
 	return "mqtt"
 }
 
 // Start initializes the MQTT client and connects to the broker
 func (p *Publisher) Start(ctx context.Context) error {
+	if err := p.validateFormat(); err != nil {
+		return err
+	}
+
 	opts := pahomqtt.NewClientOptions()
 	opts.AddBroker(p.cfg.Broker)
 	opts.SetClientID(p.cfg.ClientID)
+	// Automatic reconnect with exponential backoff (capped at ReconnectMaxMs)
 	opts.SetAutoReconnect(true)
 	opts.SetConnectRetry(true)
-	opts.SetConnectRetryInterval(5 * time.Second)
+	opts.SetConnectRetryInterval(time.Duration(p.cfg.ReconnectInitialMs) * time.Millisecond)
+	opts.SetMaxReconnectInterval(time.Duration(p.cfg.ReconnectMaxMs) * time.Millisecond)
+
+	if p.cloud != nil {
+		p.cloud.configure(opts)
+	}
 
 	// Set credentials if provided
 	if p.cfg.Username != "" {
@@ -48,8 +88,13 @@ func (p *Publisher) Start(ctx context.Context) error {
 		opts.SetPassword(p.cfg.Password)
 	}
 
-	// Set Last Will and Testament (LWT)
-	if p.cfg.LWT.Enabled {
+	// Set Last Will and Testament (LWT). Sparkplug B uses NDEATH as the will.
+	if p.sparkplug != nil {
+		p.configureSparkplug(opts)
+		if p.cfg.LWT.Enabled {
+			log.Printf("[MQTT] LWT status topic disabled: Sparkplug B uses NDEATH")
+		}
+	} else if p.cfg.LWT.Enabled {
 		lwtTopic := fmt.Sprintf("%s/%s", p.cfg.LWT.Topic, p.cfg.ClientID)
 		opts.SetWill(lwtTopic, p.cfg.LWT.Message, byte(p.cfg.QoS), true)
 	}
@@ -58,10 +103,23 @@ func (p *Publisher) Start(ctx context.Context) error {
 	opts.SetOnConnectHandler(func(c pahomqtt.Client) {
 		p.mu.Lock()
 		p.ready = true
+		p.connectedAt = time.Now()
+		reconnected := p.lost
+		p.lost = false
 		p.mu.Unlock()
 
+		if reconnected {
+			log.Printf("[MQTT] Reconnected to broker %s", p.cfg.Broker)
+		}
+
+		if p.cloud != nil {
+			p.cloud.onConnect(c)
+		}
+
 		// Publish online status
-		if p.cfg.LWT.Enabled {
+		if p.sparkplug != nil {
+			p.sparkplugOnConnect(c)
+		} else if p.cfg.LWT.Enabled {
 			statusTopic := fmt.Sprintf("%s/%s", p.cfg.LWT.Topic, p.cfg.ClientID)
 			c.Publish(statusTopic, byte(p.cfg.QoS), true, "online")
 		}
@@ -70,25 +128,34 @@ func (p *Publisher) Start(ctx context.Context) error {
 	opts.SetConnectionLostHandler(func(c pahomqtt.Client, err error) {
 		p.mu.Lock()
 		p.ready = false
+		p.lost = true
+		p.setError(err)
 		p.mu.Unlock()
+		log.Printf("[MQTT] Connection lost, reconnecting with backoff: %v", err)
 	})
 
 	// Create and connect client
 	p.client = pahomqtt.NewClient(opts)
 	token := p.client.Connect()
 
-	// Wait for connection with timeout
+	// Wait for the first connection; if the broker is unreachable the
+	// client keeps retrying in the background
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-time.After(10 * time.Second):
-		if !token.WaitTimeout(0) {
-			return fmt.Errorf("mqtt connection timeout")
+	case <-token.Done():
+		if token.Error() != nil {
+			p.mu.Lock()
+			p.setError(token.Error())
+			p.mu.Unlock()
+			return fmt.Errorf("mqtt connection failed: %w", token.Error())
 		}
-	}
-
-	if token.Wait() && token.Error() != nil {
-		return fmt.Errorf("mqtt connection failed: %w", token.Error())
+	case <-time.After(10 * time.Second):
+		p.mu.Lock()
+		p.lost = true
+		p.setError(fmt.Errorf("broker %s not reachable", p.cfg.Broker))
+		p.mu.Unlock()
+		log.Printf("[MQTT] Broker %s not reachable, retrying in background", p.cfg.Broker)
 	}
 
 	return nil
@@ -96,6 +163,45 @@ func (p *Publisher) Start(ctx context.Context) error {
 
 // Publish sends a DroneState to the MQTT broker
 func (p *Publisher) Publish(state *models.DroneState) error {
+	return p.publishState(state, p.deviceTopic(state, "state"))
+}
+
+// deviceTopic builds {prefix}/{device_id}/{suffix}, or
+// {prefix}/{tenant}/{device_id}/{suffix} for devices owned by a tenant.
+// With a topic_template, the template replaces {prefix}/{device_id}.
+func (p *Publisher) deviceTopic(state *models.DroneState, suffix string) string {
+	return p.topic(stateTopicData(p.cfg.TopicPrefix, state), suffix)
+}
+
+// tenantTopic is deviceTopic for messages that are not states
+func (p *Publisher) tenantTopic(tenant, deviceID, suffix string) string {
+	return p.topic(topicData{Prefix: p.cfg.TopicPrefix, Tenant: tenant, DeviceID: deviceID}, suffix)
+}
+
+// topic builds the topic of a device message, in the layout of the cloud
+// preset if it has one
+func (p *Publisher) topic(d topicData, suffix string) string {
+	if p.cloud != nil {
+		if t := p.cloud.topic(d, suffix); t != "" {
+			return t
+		}
+	}
+	return p.baseTopic(d) + "/" + suffix
+}
+
+// PublishTo sends a DroneState to the given topic instead of the default
+// state topic. Used by routing rules with a topic override. Sparkplug B
+// topics are fixed, so the override is ignored in that format; cloud
+// presets may map it onto a topic the service accepts.
+func (p *Publisher) PublishTo(state *models.DroneState, topic string) error {
+	if p.cloud != nil {
+		topic = p.cloud.routed(stateTopicData(p.cfg.TopicPrefix, state), topic)
+	}
+	return p.publishState(state, topic)
+}
+
+// publishState sends a DroneState to a topic
+func (p *Publisher) publishState(state *models.DroneState, topic string) error {
 	p.mu.RLock()
 	ready := p.ready
 	p.mu.RUnlock()
@@ -104,29 +210,76 @@ func (p *Publisher) Publish(state *models.DroneState) error {
 		return fmt.Errorf("mqtt client not connected")
 	}
 
-	// Serialize state to JSON
-	payload, err := json.Marshal(state)
-	if err != nil {
-		return fmt.Errorf("json marshal failed: %w", err)
+	if p.sparkplug != nil {
+		return p.publishSparkplug(state)
 	}
 
-	// Build topic: {prefix}/{device_id}/state
-	topic := fmt.Sprintf("%s/%s/state", p.cfg.TopicPrefix, state.DeviceID)
+	msgs, err := p.stateMessages(state, topic)
+	if err != nil {
+		return err
+	}
 
-	// Publish message
-	token := p.client.Publish(topic, byte(p.cfg.QoS), false, payload)
+	// Publish messages
+	tokens := make([]pahomqtt.Token, 0, len(msgs))
+	for _, m := range msgs {
+		tokens = append(tokens, p.client.Publish(m.topic, byte(p.cfg.QoS), p.cfg.Retain, m.payload))
+	}
 
 	// Non-blocking publish - don't wait for confirmation
 	go func() {
-		if token.WaitTimeout(5 * time.Second) && token.Error() != nil {
-			// Log error but don't block
-			_ = token.Error()
+		for _, token := range tokens {
+			token.WaitTimeout(5 * time.Second)
 		}
 	}()
 
 	return nil
 }
 
+// PublishBatch sends several states to their default topics, waiting for
+// the deliveries in one goroutine instead of one per message. A state that
+// cannot be encoded is skipped and reported after the others are sent.
+func (p *Publisher) PublishBatch(states []*models.DroneState) error {
+	p.mu.RLock()
+	ready := p.ready
+	p.mu.RUnlock()
+
+	if !ready {
+		return fmt.Errorf("mqtt client not connected")
+	}
+
+	var firstErr error
+	if p.sparkplug != nil {
+		for _, state := range states {
+			if err := p.publishSparkplug(state); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	tokens := make([]pahomqtt.Token, 0, len(states))
+	for _, state := range states {
+		msgs, err := p.stateMessages(state, p.deviceTopic(state, "state"))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, m := range msgs {
+			tokens = append(tokens, p.client.Publish(m.topic, byte(p.cfg.QoS), p.cfg.Retain, m.payload))
+		}
+	}
+
+	go func() {
+		for _, token := range tokens {
+			token.WaitTimeout(5 * time.Second)
+		}
+	}()
+
+	return firstErr
+}
+
 // PublishLocation sends only location data (lighter payload)
 func (p *Publisher) PublishLocation(state *models.DroneState) error {
 	p.mu.RLock()
@@ -153,7 +306,56 @@ func (p *Publisher) PublishLocation(state *models.DroneState) error {
 		return fmt.Errorf("json marshal failed: %w", err)
 	}
 
-	topic := fmt.Sprintf("%s/%s/location", p.cfg.TopicPrefix, state.DeviceID)
+	topic := p.deviceTopic(state, "location")
+	token := p.client.Publish(topic, byte(p.cfg.QoS), false, payload)
+
+	go func() {
+		token.WaitTimeout(5 * time.Second)
+	}()
+
+	return nil
+}
+
+// PublishBroadcast sends an operator broadcast to {prefix}/broadcast. The
+// message is not retained since it expires.
+func (p *Publisher) PublishBroadcast(msg *broadcast.Message) error {
+	if !p.IsConnected() {
+		return fmt.Errorf("mqtt client not connected")
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("json marshal failed: %w", err)
+	}
+
+	topic := fmt.Sprintf("%s/broadcast", p.cfg.TopicPrefix)
+	if p.cloud != nil {
+		if t := p.cloud.topic(topicData{Prefix: p.cfg.TopicPrefix}, "broadcast"); t != "" {
+			topic = t
+		}
+	}
+	token := p.client.Publish(topic, byte(p.cfg.QoS), false, payload)
+
+	go func() {
+		token.WaitTimeout(5 * time.Second)
+	}()
+
+	return nil
+}
+
+// PublishRaw sends a raw protocol message as JSON to
+// {prefix}/{device_id}/raw/{name}, also in the Sparkplug B format
+func (p *Publisher) PublishRaw(msg *models.RawMessage) error {
+	if !p.IsConnected() {
+		return fmt.Errorf("mqtt client not connected")
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("json marshal failed: %w", err)
+	}
+
+	topic := p.tenantTopic(msg.Tenant, msg.DeviceID, "raw/"+msg.Name)
 	token := p.client.Publish(topic, byte(p.cfg.QoS), false, payload)
 
 	go func() {
@@ -163,11 +365,40 @@ func (p *Publisher) PublishLocation(state *models.DroneState) error {
 	return nil
 }
 
+// SelfTest encodes the state and builds its topic without publishing, and
+// checks the broker connection
+func (p *Publisher) SelfTest(state *models.DroneState) error {
+	if p.sparkplug != nil {
+		if err := p.validateFormat(); err != nil {
+			return err
+		}
+		if _, _, err := newSparkplugSession(p.sparkplug.group, p.sparkplug.node).deviceMessages(state); err != nil {
+			return err
+		}
+	} else {
+		if _, err := json.Marshal(state); err != nil {
+			return fmt.Errorf("json marshal failed: %w", err)
+		}
+
+		topic := p.deviceTopic(state, "state")
+		if strings.ContainsAny(topic, "+#") {
+			return fmt.Errorf("invalid topic %q: wildcards are not allowed", topic)
+		}
+	}
+
+	if !p.IsConnected() {
+		return fmt.Errorf("mqtt client not connected to %s", p.cfg.Broker)
+	}
+	return nil
+}
+
 // Stop gracefully stops the publisher
 func (p *Publisher) Stop() error {
 	if p.client != nil && p.client.IsConnected() {
 		// Publish offline status before disconnecting
-		if p.cfg.LWT.Enabled {
+		if p.sparkplug != nil {
+			p.publishNodeDeath()
+		} else if p.cfg.LWT.Enabled {
 			statusTopic := fmt.Sprintf("%s/%s", p.cfg.LWT.Topic, p.cfg.ClientID)
 			token := p.client.Publish(statusTopic, byte(p.cfg.QoS), true, "offline")
 			token.WaitTimeout(2 * time.Second)
@@ -178,9 +409,57 @@ func (p *Publisher) Stop() error {
 	return nil
 }
 
+// validateFormat checks the payload format settings
+func (p *Publisher) validateFormat() error {
+	switch p.cfg.PayloadFormat {
+	case "", PayloadFormatJSON:
+		if p.topicErr != nil {
+			return fmt.Errorf("invalid mqtt topic template: %w", p.topicErr)
+		}
+		return nil
+	case PayloadFormatSparkplug:
+		if !sparkplug.ValidID(p.sparkplug.group) || !sparkplug.ValidID(p.sparkplug.node) {
+			return fmt.Errorf("invalid sparkplug group %q or edge node %q", p.sparkplug.group, p.sparkplug.node)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown mqtt payload format %q", p.cfg.PayloadFormat)
+	}
+}
+
 // IsConnected returns true if the client is connected
 func (p *Publisher) IsConnected() bool {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	return p.ready
 }
+
+// Health reports the broker connection
+func (p *Publisher) Health() sdk.PublisherDetail {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	d := sdk.PublisherDetail{
+		State:    "connecting",
+		Endpoint: p.cfg.Broker,
+		Details:  map[string]string{"client_id": p.cfg.ClientID},
+	}
+	switch {
+	case p.ready:
+		d.State = "connected"
+		d.Details["connected_since"] = p.connectedAt.UTC().Format(time.RFC3339)
+	case p.lost:
+		d.State = "reconnecting"
+	}
+	if p.lastError != "" {
+		d.LastError = p.lastError
+		d.LastErrorAt = p.lastErrorAt.UnixMilli()
+	}
+	return d
+}
+
+// setError records a connection error. Callers hold p.mu.
+func (p *Publisher) setError(err error) {
+	p.lastError = err.Error()
+	p.lastErrorAt = time.Now()
+}
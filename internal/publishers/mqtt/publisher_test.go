@@ -1,10 +1,12 @@
 package mqtt
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/open-uav/telemetry-bridge/internal/config"
-	"github.com/open-uav/telemetry-bridge/internal/models"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
 )
 
 func TestNew(t *testing.T) {
@@ -77,6 +79,16 @@ func TestPublisher_Publish_NotConnected(t *testing.T) {
 	}
 }
 
+func TestPublisher_PublishBatch_NotConnected(t *testing.T) {
+	p := New(config.MQTTConfig{})
+
+	err := p.PublishBatch([]*models.DroneState{{DeviceID: "drone-1"}, {DeviceID: "drone-2"}})
+
+	if err == nil || err.Error() != "mqtt client not connected" {
+		t.Errorf("Error = '%v', want 'mqtt client not connected'", err)
+	}
+}
+
 func TestPublisher_PublishLocation_NotConnected(t *testing.T) {
 	p := New(config.MQTTConfig{})
 
@@ -183,3 +195,76 @@ func TestPublisher_ReadyState(t *testing.T) {
 		t.Error("IsConnected should return false when not ready")
 	}
 }
+
+func TestPublisher_Health(t *testing.T) {
+	p := New(config.MQTTConfig{Broker: "tcp://localhost:1883", ClientID: "test-client"})
+
+	h := p.Health()
+	if h.State != "connecting" || h.Endpoint != "tcp://localhost:1883" || h.Details["client_id"] != "test-client" {
+		t.Errorf("Health before connecting = %+v", h)
+	}
+
+	// Simulate the connection handlers
+	p.mu.Lock()
+	p.ready = true
+	p.connectedAt = time.Now()
+	p.mu.Unlock()
+	if h := p.Health(); h.State != "connected" || h.Details["connected_since"] == "" || h.LastError != "" {
+		t.Errorf("Health when connected = %+v", h)
+	}
+
+	p.mu.Lock()
+	p.ready = false
+	p.lost = true
+	p.setError(errors.New("EOF"))
+	p.mu.Unlock()
+	if h := p.Health(); h.State != "reconnecting" || h.LastError != "EOF" || h.LastErrorAt == 0 {
+		t.Errorf("Health after connection lost = %+v", h)
+	}
+}
+
+func TestPublisher_SelfTest(t *testing.T) {
+	p := New(config.MQTTConfig{TopicPrefix: "uav/telemetry", Broker: "tcp://localhost:1883"})
+	state := &models.DroneState{DeviceID: "drone-1"}
+
+	if err := p.SelfTest(state); err == nil {
+		t.Error("SelfTest should fail when not connected")
+	}
+
+	p.mu.Lock()
+	p.ready = true
+	p.mu.Unlock()
+
+	if err := p.SelfTest(state); err != nil {
+		t.Errorf("SelfTest failed: %v", err)
+	}
+
+	// Wildcards in the device ID produce an invalid topic
+	if err := p.SelfTest(&models.DroneState{DeviceID: "drone/+"}); err == nil {
+		t.Error("SelfTest should reject wildcard topics")
+	}
+}
+
+func TestPublisher_deviceTopic(t *testing.T) {
+	p := New(config.MQTTConfig{TopicPrefix: "uav/telemetry"})
+
+	state := models.NewDroneState("drone-001", "mavlink")
+	if got := p.deviceTopic(state, "state"); got != "uav/telemetry/drone-001/state" {
+		t.Errorf("deviceTopic() = %s", got)
+	}
+
+	state.Tenant = "acme"
+	if got := p.deviceTopic(state, "location"); got != "uav/telemetry/acme/drone-001/location" {
+		t.Errorf("deviceTopic() with tenant = %s", got)
+	}
+}
+
+func TestPublisher_PublishRawNotConnected(t *testing.T) {
+	p := New(config.MQTTConfig{TopicPrefix: "uav/telemetry"})
+	if err := p.PublishRaw(&models.RawMessage{DeviceID: "mavlink-1", Name: "STATUSTEXT"}); err == nil {
+		t.Error("PublishRaw should fail when not connected")
+	}
+	if got := p.tenantTopic("acme", "mavlink-1", "raw/STATUSTEXT"); got != "uav/telemetry/acme/mavlink-1/raw/STATUSTEXT" {
+		t.Errorf("tenantTopic() = %s", got)
+	}
+}
@@ -0,0 +1,269 @@
+package mqtt
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/open-uav/telemetry-bridge/internal/publishers/mqtt/sparkplug"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Payload formats
+const (
+	PayloadFormatJSON      = "json"
+	PayloadFormatSparkplug = "sparkplug_b"
+)
+
+// rebirthMetric is the node control metric a host application sets to
+// request new birth certificates
+const rebirthMetric = "Node Control/Rebirth"
+
+// Node metric aliases. Device metrics use aliases from deviceAliasStride up.
+const (
+	aliasRebirth      = 1
+	deviceAliasStride = 64
+)
+
+// deviceMetric maps a DroneState field to a Sparkplug metric
+type deviceMetric struct {
+	name     string
+	dataType sparkplug.DataType
+	value    func(s *models.DroneState) interface{}
+}
+
+// deviceMetrics are published for every drone, in alias order
+var deviceMetrics = []deviceMetric{
+	{"Location/Latitude", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Location.Lat }},
+	{"Location/Longitude", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Location.Lon }},
+	{"Location/AltBaro", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Location.AltBaro }},
+	{"Location/AltGNSS", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Location.AltGNSS }},
+	{"Attitude/Roll", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Attitude.Roll }},
+	{"Attitude/Pitch", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Attitude.Pitch }},
+	{"Attitude/Yaw", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Attitude.Yaw }},
+	{"Velocity/Vx", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Velocity.Vx }},
+	{"Velocity/Vy", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Velocity.Vy }},
+	{"Velocity/Vz", sparkplug.Double, func(s *models.DroneState) interface{} { return s.Velocity.Vz }},
+	{"Status/BatteryPercent", sparkplug.Int32, func(s *models.DroneState) interface{} { return int64(s.Status.BatteryPercent) }},
+	{"Status/FlightMode", sparkplug.String, func(s *models.DroneState) interface{} { return string(s.Status.FlightMode) }},
+	{"Status/Armed", sparkplug.Boolean, func(s *models.DroneState) interface{} { return s.Status.Armed }},
+	{"Status/SignalQuality", sparkplug.Int32, func(s *models.DroneState) interface{} { return int64(s.Status.SignalQuality) }},
+	{"Properties/ProtocolSource", sparkplug.String, func(s *models.DroneState) interface{} { return s.ProtocolSource }},
+}
+
+// sparkplugSession holds the Sparkplug B state of the edge node: the birth/
+// death sequence, the message sequence and the devices that have been born
+// in the current session. Each drone is a Sparkplug device.
+type sparkplugSession struct {
+	group string
+	node  string
+
+	// send serializes encoding and publishing so sequence numbers reach
+	// the broker in order
+	send sync.Mutex
+
+	mu        sync.Mutex
+	bdSeq     uint64
+	seq       uint64
+	devices   map[string]uint64 // Device ID -> alias base
+	nextAlias uint64
+}
+
+func newSparkplugSession(group, node string) *sparkplugSession {
+	return &sparkplugSession{
+		group:   group,
+		node:    node,
+		devices: make(map[string]uint64),
+	}
+}
+
+// nextSeq returns the next message sequence number (0-255). Caller must
+// hold the lock.
+func (s *sparkplugSession) nextSeq() *uint64 {
+	seq := s.seq
+	s.seq = (s.seq + 1) % 256
+	return &seq
+}
+
+// newConnection starts a new birth/death sequence for a (re)connect
+func (s *sparkplugSession) newConnection() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bdSeq = (s.bdSeq + 1) % 256
+}
+
+// death returns the NDEATH topic and payload for the current connection
+func (s *sparkplugSession) death() (string, []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := sparkplug.Payload{
+		Timestamp: uint64(time.Now().UnixMilli()),
+		Metrics:   []sparkplug.Metric{{Name: "bdSeq", DataType: sparkplug.UInt64, Value: s.bdSeq}},
+	}
+	payload, _ := p.Encode()
+	return sparkplug.Topic(s.group, sparkplug.NDEATH, s.node, ""), payload
+}
+
+// birth returns the NBIRTH topic and payload and resets the session so
+// every device is born again
+func (s *sparkplugSession) birth() (string, []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq = 0
+	s.devices = make(map[string]uint64)
+	s.nextAlias = deviceAliasStride
+
+	p := sparkplug.Payload{
+		Timestamp: uint64(time.Now().UnixMilli()),
+		Metrics: []sparkplug.Metric{
+			{Name: "bdSeq", DataType: sparkplug.UInt64, Value: s.bdSeq},
+			{Name: rebirthMetric, Alias: aliasRebirth, DataType: sparkplug.Boolean, Value: false},
+		},
+		Seq: s.nextSeq(),
+	}
+	payload, _ := p.Encode()
+	return sparkplug.Topic(s.group, sparkplug.NBIRTH, s.node, ""), payload
+}
+
+// deviceMessages returns the messages to publish for a state: a DBIRTH with
+// metric names and aliases the first time a device is seen, then DDATA
+// with aliases only
+func (s *sparkplugSession) deviceMessages(state *models.DroneState) ([]string, [][]byte, error) {
+	if !sparkplug.ValidID(state.DeviceID) {
+		return nil, nil, fmt.Errorf("invalid sparkplug device id %q", state.DeviceID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var topics []string
+	var payloads [][]byte
+
+	base, born := s.devices[state.DeviceID]
+	if !born {
+		base = s.nextAlias
+		s.nextAlias += deviceAliasStride
+		s.devices[state.DeviceID] = base
+
+		payload, err := s.devicePayload(state, base, true)
+		if err != nil {
+			delete(s.devices, state.DeviceID)
+			return nil, nil, err
+		}
+		topics = append(topics, sparkplug.Topic(s.group, sparkplug.DBIRTH, s.node, state.DeviceID))
+		payloads = append(payloads, payload)
+	}
+
+	payload, err := s.devicePayload(state, base, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	topics = append(topics, sparkplug.Topic(s.group, sparkplug.DDATA, s.node, state.DeviceID))
+	payloads = append(payloads, payload)
+	return topics, payloads, nil
+}
+
+// devicePayload encodes a state. Births carry metric names; data messages
+// only aliases. Caller must hold the lock.
+func (s *sparkplugSession) devicePayload(state *models.DroneState, base uint64, birth bool) ([]byte, error) {
+	ts := uint64(state.Timestamp)
+	if ts == 0 {
+		ts = uint64(time.Now().UnixMilli())
+	}
+
+	metrics := make([]sparkplug.Metric, len(deviceMetrics))
+	for i, dm := range deviceMetrics {
+		metrics[i] = sparkplug.Metric{
+			Alias:     base + uint64(i),
+			Timestamp: ts,
+			DataType:  dm.dataType,
+			Value:     dm.value(state),
+		}
+		if birth {
+			metrics[i].Name = dm.name
+		}
+	}
+
+	p := sparkplug.Payload{Timestamp: ts, Metrics: metrics, Seq: s.nextSeq()}
+	return p.Encode()
+}
+
+// isRebirthRequest reports whether an NCMD payload asks for a rebirth
+func isRebirthRequest(payload []byte) bool {
+	p, err := sparkplug.Decode(payload)
+	if err != nil {
+		return false
+	}
+	for _, m := range p.Metrics {
+		if m.Name == rebirthMetric || m.Alias == aliasRebirth {
+			if v, ok := m.Value.(bool); ok && v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// configureSparkplug sets the NDEATH will and the handlers that keep the
+// will's bdSeq in step with reconnects
+func (p *Publisher) configureSparkplug(opts *pahomqtt.ClientOptions) {
+	topic, payload := p.sparkplug.death()
+	opts.SetBinaryWill(topic, payload, 1, false)
+
+	opts.SetReconnectingHandler(func(c pahomqtt.Client, o *pahomqtt.ClientOptions) {
+		p.sparkplug.newConnection()
+		topic, payload := p.sparkplug.death()
+		o.SetBinaryWill(topic, payload, 1, false)
+	})
+}
+
+// sparkplugOnConnect subscribes to node commands and publishes NBIRTH
+func (p *Publisher) sparkplugOnConnect(c pahomqtt.Client) {
+	cmdTopic := sparkplug.Topic(p.sparkplug.group, sparkplug.NCMD, p.sparkplug.node, "")
+	c.Subscribe(cmdTopic, 1, func(c pahomqtt.Client, m pahomqtt.Message) {
+		if isRebirthRequest(m.Payload()) {
+			log.Printf("[MQTT] Sparkplug rebirth requested")
+			p.publishNodeBirth(c)
+		}
+	})
+	p.publishNodeBirth(c)
+}
+
+// publishNodeBirth publishes NBIRTH; devices are reborn on their next state
+func (p *Publisher) publishNodeBirth(c pahomqtt.Client) {
+	p.sparkplug.send.Lock()
+	defer p.sparkplug.send.Unlock()
+
+	topic, payload := p.sparkplug.birth()
+	c.Publish(topic, 1, false, payload)
+}
+
+// publishSparkplug publishes a state as DBIRTH/DDATA
+func (p *Publisher) publishSparkplug(state *models.DroneState) error {
+	p.sparkplug.send.Lock()
+	defer p.sparkplug.send.Unlock()
+
+	topics, payloads, err := p.sparkplug.deviceMessages(state)
+	if err != nil {
+		return err
+	}
+	for i := range topics {
+		token := p.client.Publish(topics[i], byte(p.cfg.QoS), false, payloads[i])
+		go func() {
+			token.WaitTimeout(5 * time.Second)
+		}()
+	}
+	return nil
+}
+
+// publishNodeDeath publishes NDEATH before a graceful disconnect
+func (p *Publisher) publishNodeDeath() {
+	topic, payload := p.sparkplug.death()
+	token := p.client.Publish(topic, 1, false, payload)
+	token.WaitTimeout(2 * time.Second)
+}
@@ -0,0 +1,243 @@
+// Package sparkplug implements the parts of Eclipse Sparkplug B needed by
+// the MQTT publisher: topic names and the Protobuf payload encoding. Only
+// scalar metric types are supported; DataSet, Template and property sets
+// are not.
+package sparkplug
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/open-uav/telemetry-bridge/internal/core/protowire"
+)
+
+// Namespace is the Sparkplug B topic namespace
+const Namespace = "spBv1.0"
+
+// MessageType is a Sparkplug message type
+type MessageType string
+
+const (
+	NBIRTH MessageType = "NBIRTH"
+	NDEATH MessageType = "NDEATH"
+	NDATA  MessageType = "NDATA"
+	NCMD   MessageType = "NCMD"
+	DBIRTH MessageType = "DBIRTH"
+	DDEATH MessageType = "DDEATH"
+	DDATA  MessageType = "DDATA"
+	DCMD   MessageType = "DCMD"
+)
+
+// Topic returns spBv1.0/{group}/{type}/{node}[/{device}]
+func Topic(group string, mt MessageType, node, device string) string {
+	topic := fmt.Sprintf("%s/%s/%s/%s", Namespace, group, mt, node)
+	if device != "" {
+		topic += "/" + device
+	}
+	return topic
+}
+
+// ValidID reports whether s can be used as a group, node or device ID
+func ValidID(s string) bool {
+	return s != "" && !strings.ContainsAny(s, "/+#")
+}
+
+// DataType is a Sparkplug metric data type
+type DataType uint32
+
+const (
+	Int32   DataType = 3
+	Int64   DataType = 4
+	UInt64  DataType = 8
+	Float   DataType = 9
+	Double  DataType = 10
+	Boolean DataType = 11
+	String  DataType = 12
+)
+
+// Metric is a Sparkplug metric. Value must match the data type: int64 for
+// Int32/Int64, uint64 for UInt64, float64 for Float/Double, bool or string.
+// A zero Alias means the metric has no alias.
+type Metric struct {
+	Name      string
+	Alias     uint64
+	Timestamp uint64 // Unix ms
+	DataType  DataType
+	Value     interface{}
+}
+
+// Payload is a Sparkplug B payload. Seq is nil for NDEATH.
+type Payload struct {
+	Timestamp uint64 // Unix ms
+	Metrics   []Metric
+	Seq       *uint64
+}
+
+// ErrMalformed is returned when a payload cannot be decoded
+var ErrMalformed = protowire.ErrMalformed
+
+// Protobuf field numbers from sparkplug_b.proto
+const (
+	fieldPayloadTimestamp = 1
+	fieldPayloadMetrics   = 2
+	fieldPayloadSeq       = 3
+
+	fieldMetricName      = 1
+	fieldMetricAlias     = 2
+	fieldMetricTimestamp = 3
+	fieldMetricDatatype  = 4
+	fieldMetricIntValue  = 10
+	fieldMetricLongValue = 11
+	fieldMetricFloat     = 12
+	fieldMetricDouble    = 13
+	fieldMetricBoolean   = 14
+	fieldMetricString    = 15
+)
+
+// Encode serializes the payload in Protobuf wire format
+func (p *Payload) Encode() ([]byte, error) {
+	var buf []byte
+	buf = protowire.AppendVarint(buf, fieldPayloadTimestamp, p.Timestamp)
+	for i := range p.Metrics {
+		m, err := p.Metrics[i].encode()
+		if err != nil {
+			return nil, err
+		}
+		buf = protowire.AppendBytes(buf, fieldPayloadMetrics, m)
+	}
+	if p.Seq != nil {
+		buf = protowire.AppendVarint(buf, fieldPayloadSeq, *p.Seq)
+	}
+	return buf, nil
+}
+
+// encode serializes one metric
+func (m *Metric) encode() ([]byte, error) {
+	var buf []byte
+	if m.Name != "" {
+		buf = protowire.AppendBytes(buf, fieldMetricName, []byte(m.Name))
+	}
+	if m.Alias != 0 {
+		buf = protowire.AppendVarint(buf, fieldMetricAlias, m.Alias)
+	}
+	if m.Timestamp != 0 {
+		buf = protowire.AppendVarint(buf, fieldMetricTimestamp, m.Timestamp)
+	}
+	buf = protowire.AppendVarint(buf, fieldMetricDatatype, uint64(m.DataType))
+
+	bad := fmt.Errorf("metric %q: value %T does not match data type %d", m.Name, m.Value, m.DataType)
+	switch m.DataType {
+	case Int32:
+		v, ok := m.Value.(int64)
+		if !ok {
+			return nil, bad
+		}
+		buf = protowire.AppendVarint(buf, fieldMetricIntValue, uint64(uint32(int32(v))))
+	case Int64:
+		v, ok := m.Value.(int64)
+		if !ok {
+			return nil, bad
+		}
+		buf = protowire.AppendVarint(buf, fieldMetricLongValue, uint64(v))
+	case UInt64:
+		v, ok := m.Value.(uint64)
+		if !ok {
+			return nil, bad
+		}
+		buf = protowire.AppendVarint(buf, fieldMetricLongValue, v)
+	case Float:
+		v, ok := m.Value.(float64)
+		if !ok {
+			return nil, bad
+		}
+		buf = protowire.AppendFloat(buf, fieldMetricFloat, float32(v))
+	case Double:
+		v, ok := m.Value.(float64)
+		if !ok {
+			return nil, bad
+		}
+		buf = protowire.AppendDouble(buf, fieldMetricDouble, v)
+	case Boolean:
+		v, ok := m.Value.(bool)
+		if !ok {
+			return nil, bad
+		}
+		var b uint64
+		if v {
+			b = 1
+		}
+		buf = protowire.AppendVarint(buf, fieldMetricBoolean, b)
+	case String:
+		v, ok := m.Value.(string)
+		if !ok {
+			return nil, bad
+		}
+		buf = protowire.AppendBytes(buf, fieldMetricString, []byte(v))
+	default:
+		return nil, fmt.Errorf("metric %q: unsupported data type %d", m.Name, m.DataType)
+	}
+	return buf, nil
+}
+
+// Decode parses a payload. Metrics with unsupported value types are kept
+// with a nil Value.
+func Decode(data []byte) (*Payload, error) {
+	p := &Payload{}
+	err := protowire.Walk(data, func(field, wire int, v uint64, b []byte) error {
+		switch {
+		case field == fieldPayloadTimestamp && wire == protowire.Varint:
+			p.Timestamp = v
+		case field == fieldPayloadSeq && wire == protowire.Varint:
+			seq := v
+			p.Seq = &seq
+		case field == fieldPayloadMetrics && wire == protowire.Bytes:
+			m, err := decodeMetric(b)
+			if err != nil {
+				return err
+			}
+			p.Metrics = append(p.Metrics, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// decodeMetric parses one metric
+func decodeMetric(data []byte) (Metric, error) {
+	var m Metric
+	err := protowire.Walk(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case fieldMetricName:
+			m.Name = string(b)
+		case fieldMetricAlias:
+			m.Alias = v
+		case fieldMetricTimestamp:
+			m.Timestamp = v
+		case fieldMetricDatatype:
+			m.DataType = DataType(v)
+		case fieldMetricIntValue:
+			m.Value = int64(int32(uint32(v)))
+		case fieldMetricLongValue:
+			m.Value = v
+		case fieldMetricFloat:
+			m.Value = float64(math.Float32frombits(uint32(v)))
+		case fieldMetricDouble:
+			m.Value = math.Float64frombits(v)
+		case fieldMetricBoolean:
+			m.Value = v != 0
+		case fieldMetricString:
+			m.Value = string(b)
+		}
+		return nil
+	})
+	if m.DataType == Int64 {
+		if v, ok := m.Value.(uint64); ok {
+			m.Value = int64(v)
+		}
+	}
+	return m, err
+}
@@ -0,0 +1,91 @@
+package sparkplug
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTopic(t *testing.T) {
+	if got := Topic("UAV", NBIRTH, "outb-001", ""); got != "spBv1.0/UAV/NBIRTH/outb-001" {
+		t.Errorf("Topic() = %s", got)
+	}
+	if got := Topic("UAV", DDATA, "outb-001", "uav-1"); got != "spBv1.0/UAV/DDATA/outb-001/uav-1" {
+		t.Errorf("Topic() = %s", got)
+	}
+	for _, id := range []string{"", "a/b", "a+", "#"} {
+		if ValidID(id) {
+			t.Errorf("ValidID(%q) = true", id)
+		}
+	}
+}
+
+func TestPayload_EncodeKnownBytes(t *testing.T) {
+	seq := uint64(0)
+	p := Payload{
+		Timestamp: 1,
+		Metrics:   []Metric{{Name: "a", Alias: 2, DataType: Boolean, Value: true}},
+		Seq:       &seq,
+	}
+	got, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	// timestamp=1, metrics={name="a", alias=2, datatype=11, boolean_value=true}, seq=0
+	want := []byte{0x08, 0x01, 0x12, 0x09, 0x0a, 0x01, 'a', 0x10, 0x02, 0x20, 0x0b, 0x70, 0x01, 0x18, 0x00}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Encode() = % x, want % x", got, want)
+	}
+}
+
+func TestPayload_RoundTrip(t *testing.T) {
+	seq := uint64(42)
+	p := Payload{
+		Timestamp: 1709882231000,
+		Metrics: []Metric{
+			{Name: "Location/Latitude", Alias: 64, DataType: Double, Value: 22.5431},
+			{Name: "Status/BatteryPercent", Alias: 65, DataType: Int32, Value: int64(-5)},
+			{Name: "Counter", DataType: Int64, Value: int64(-7)},
+			{Name: "bdSeq", DataType: UInt64, Value: uint64(3)},
+			{Name: "Speed", DataType: Float, Value: float64(1.5)},
+			{Name: "Status/FlightMode", DataType: String, Value: "AUTO"},
+			{Name: "Status/Armed", DataType: Boolean, Value: false},
+		},
+		Seq: &seq,
+	}
+	data, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.Timestamp != p.Timestamp || got.Seq == nil || *got.Seq != 42 {
+		t.Errorf("Decoded header = %d/%v", got.Timestamp, got.Seq)
+	}
+	if len(got.Metrics) != len(p.Metrics) {
+		t.Fatalf("Decoded %d metrics, want %d", len(got.Metrics), len(p.Metrics))
+	}
+	for i, m := range p.Metrics {
+		g := got.Metrics[i]
+		if g.Name != m.Name || g.Alias != m.Alias || g.DataType != m.DataType || g.Value != m.Value {
+			t.Errorf("Metric %d = %+v, want %+v", i, g, m)
+		}
+	}
+}
+
+func TestPayload_EncodeTypeMismatch(t *testing.T) {
+	p := Payload{Metrics: []Metric{{Name: "x", DataType: Double, Value: "not a number"}}}
+	if _, err := p.Encode(); err == nil {
+		t.Error("Encode() should reject a value that does not match the data type")
+	}
+}
+
+func TestDecode_Malformed(t *testing.T) {
+	for _, data := range [][]byte{{0x12, 0x09, 0x0a}, {0x08}, {0x0b}} {
+		if _, err := Decode(data); err == nil {
+			t.Errorf("Decode(% x) should fail", data)
+		}
+	}
+}
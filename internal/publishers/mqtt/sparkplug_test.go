@@ -0,0 +1,103 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/publishers/mqtt/sparkplug"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestNew_SparkplugDefaults(t *testing.T) {
+	p := New(config.MQTTConfig{
+		ClientID:      "outb-001",
+		PayloadFormat: PayloadFormatSparkplug,
+		Sparkplug:     config.SparkplugConfig{GroupID: "UAV"},
+	})
+	if p.sparkplug == nil || p.sparkplug.node != "outb-001" {
+		t.Fatal("Edge node ID should default to the client ID")
+	}
+	if err := p.validateFormat(); err != nil {
+		t.Errorf("validateFormat() error = %v", err)
+	}
+
+	if err := New(config.MQTTConfig{PayloadFormat: "xml"}).validateFormat(); err == nil {
+		t.Error("validateFormat() should reject unknown formats")
+	}
+}
+
+func TestSparkplugSession_BirthThenData(t *testing.T) {
+	s := newSparkplugSession("UAV", "outb-001")
+
+	topic, payload := s.birth()
+	if topic != "spBv1.0/UAV/NBIRTH/outb-001" {
+		t.Errorf("NBIRTH topic = %s", topic)
+	}
+	birth, _ := sparkplug.Decode(payload)
+	if *birth.Seq != 0 || birth.Metrics[0].Name != "bdSeq" {
+		t.Errorf("NBIRTH = %+v", birth)
+	}
+
+	state := models.NewDroneState("uav-1", "mavlink")
+	state.Timestamp = 1709882231000
+	state.Location.Lat = 22.5
+	state.Status.BatteryPercent = 80
+
+	topics, payloads, err := s.deviceMessages(state)
+	if err != nil {
+		t.Fatalf("deviceMessages() error = %v", err)
+	}
+	if len(topics) != 2 || topics[0] != "spBv1.0/UAV/DBIRTH/outb-001/uav-1" || topics[1] != "spBv1.0/UAV/DDATA/outb-001/uav-1" {
+		t.Fatalf("First state topics = %v, want DBIRTH then DDATA", topics)
+	}
+
+	dbirth, _ := sparkplug.Decode(payloads[0])
+	ddata, _ := sparkplug.Decode(payloads[1])
+	if *dbirth.Seq != 1 || *ddata.Seq != 2 {
+		t.Errorf("Seq = %d/%d, want 1/2", *dbirth.Seq, *ddata.Seq)
+	}
+	if dbirth.Metrics[0].Name != "Location/Latitude" || dbirth.Metrics[0].Value != 22.5 {
+		t.Errorf("DBIRTH metric = %+v", dbirth.Metrics[0])
+	}
+	for i, m := range ddata.Metrics {
+		if m.Name != "" || m.Alias != dbirth.Metrics[i].Alias {
+			t.Errorf("DDATA metric %d = %+v, want alias %d without name", i, m, dbirth.Metrics[i].Alias)
+		}
+	}
+
+	// Known devices only get DDATA; a second device gets its own aliases
+	topics, _, _ = s.deviceMessages(state)
+	if len(topics) != 1 {
+		t.Errorf("Second state topics = %v, want DDATA only", topics)
+	}
+	_, payloads, _ = s.deviceMessages(models.NewDroneState("uav-2", "dji"))
+	other, _ := sparkplug.Decode(payloads[0])
+	if other.Metrics[0].Alias == dbirth.Metrics[0].Alias {
+		t.Error("Devices should not share metric aliases")
+	}
+
+	// A rebirth resets the sequence and re-births devices
+	s.birth()
+	topics, _, _ = s.deviceMessages(state)
+	if len(topics) != 2 {
+		t.Errorf("Topics after rebirth = %v, want DBIRTH then DDATA", topics)
+	}
+
+	if _, _, err := s.deviceMessages(models.NewDroneState("uav/+", "dji")); err == nil {
+		t.Error("deviceMessages() should reject device IDs with topic separators")
+	}
+}
+
+func TestIsRebirthRequest(t *testing.T) {
+	cmd := sparkplug.Payload{Metrics: []sparkplug.Metric{{Name: rebirthMetric, DataType: sparkplug.Boolean, Value: true}}}
+	payload, _ := cmd.Encode()
+	if !isRebirthRequest(payload) {
+		t.Error("Rebirth command not recognized")
+	}
+
+	cmd.Metrics[0].Value = false
+	payload, _ = cmd.Encode()
+	if isRebirthRequest(payload) {
+		t.Error("Rebirth=false should not trigger a rebirth")
+	}
+}
@@ -0,0 +1,123 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// topicData is the data a topic template is executed with
+type topicData struct {
+	Prefix         string // topic_prefix
+	Tenant         string // Empty for devices without a tenant
+	DeviceID       string
+	ProtocolSource string // Empty for raw messages
+}
+
+// stateTopicData returns the topic data of a state
+func stateTopicData(prefix string, state *models.DroneState) topicData {
+	return topicData{Prefix: prefix, Tenant: state.Tenant, DeviceID: state.DeviceID, ProtocolSource: state.ProtocolSource}
+}
+
+// ParseTopicTemplate parses a topic_template. The template builds the base
+// topic of a device, which suffixes like state or raw/{name} are appended
+// to, from {{.Prefix}}, {{.Tenant}}, {{.DeviceID}} and {{.ProtocolSource}}.
+// An empty template returns nil, for the default {prefix}/{device_id} layout.
+func ParseTopicTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("topic").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// Catch unknown fields and wildcards before the first state arrives
+	var b strings.Builder
+	if err := tmpl.Execute(&b, topicData{Prefix: "uav", Tenant: "tenant", DeviceID: "device", ProtocolSource: "mavlink"}); err != nil {
+		return nil, err
+	}
+	if b.Len() == 0 {
+		return nil, fmt.Errorf("template renders an empty topic")
+	}
+	if strings.ContainsAny(b.String(), "+#") {
+		return nil, fmt.Errorf("invalid topic %q: wildcards are not allowed", b.String())
+	}
+	return tmpl, nil
+}
+
+// baseTopic builds the topic messages of a device are published under. A
+// template that fails for this device falls back to the default layout.
+func (p *Publisher) baseTopic(d topicData) string {
+	if p.topicTmpl != nil {
+		var b strings.Builder
+		if err := p.topicTmpl.Execute(&b, d); err == nil {
+			return b.String()
+		}
+	}
+	if d.Tenant != "" {
+		return fmt.Sprintf("%s/%s/%s", d.Prefix, d.Tenant, d.DeviceID)
+	}
+	return fmt.Sprintf("%s/%s", d.Prefix, d.DeviceID)
+}
+
+// message is a payload to publish
+type message struct {
+	topic   string
+	payload []byte
+}
+
+// stateMessages encodes a state for the given topic and, with split topics
+// enabled, its position, battery and status parts for the device's
+// sub-topics, so lightweight subscribers need not decode whole states. A
+// cloud preset adds the shadow or twin update when one is due.
+func (p *Publisher) stateMessages(state *models.DroneState, topic string) ([]message, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal failed: %w", err)
+	}
+	msgs := []message{{topic: topic, payload: payload}}
+	if p.cloud != nil {
+		if m, ok := p.cloud.report(state, time.Now()); ok {
+			msgs = append(msgs, m)
+		}
+	}
+	if !p.cfg.SplitTopics {
+		return msgs, nil
+	}
+
+	parts := []struct {
+		suffix string
+		v      any
+	}{
+		{"position", struct {
+			DeviceID  string          `json:"device_id"`
+			Timestamp int64           `json:"timestamp"`
+			Location  models.Location `json:"location"`
+			Velocity  models.Velocity `json:"velocity"`
+		}{state.DeviceID, state.Timestamp, state.Location, state.Velocity}},
+		{"battery", struct {
+			DeviceID              string   `json:"device_id"`
+			Timestamp             int64    `json:"timestamp"`
+			BatteryPercent        int      `json:"battery_percent"`
+			EstimatedEnduranceMin *float64 `json:"estimated_endurance_min,omitempty"`
+		}{state.DeviceID, state.Timestamp, state.Status.BatteryPercent, state.Status.EstimatedEnduranceMin}},
+		{"status", struct {
+			DeviceID  string        `json:"device_id"`
+			Timestamp int64         `json:"timestamp"`
+			Status    models.Status `json:"status"`
+		}{state.DeviceID, state.Timestamp, state.Status}},
+	}
+	for _, part := range parts {
+		payload, err := json.Marshal(part.v)
+		if err != nil {
+			return nil, fmt.Errorf("json marshal failed: %w", err)
+		}
+		msgs = append(msgs, message{topic: p.deviceTopic(state, part.suffix), payload: payload})
+	}
+	return msgs, nil
+}
@@ -0,0 +1,101 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestParseTopicTemplate(t *testing.T) {
+	if tmpl, err := ParseTopicTemplate(""); tmpl != nil || err != nil {
+		t.Errorf("ParseTopicTemplate(\"\") = %v, %v, want the default layout", tmpl, err)
+	}
+
+	for _, text := range []string{
+		"{{.DeviceID",          // Syntax error
+		"uav/{{.Serial}}",      // Unknown field
+		"uav/+/{{.DeviceID}}",  // Wildcard
+		"{{if false}}x{{end}}", // Empty topic
+	} {
+		if _, err := ParseTopicTemplate(text); err == nil {
+			t.Errorf("ParseTopicTemplate(%q) should fail", text)
+		}
+	}
+}
+
+func TestPublisher_topicTemplate(t *testing.T) {
+	p := New(config.MQTTConfig{
+		TopicPrefix:   "uav/telemetry",
+		TopicTemplate: "fleet/{{with .Tenant}}{{.}}/{{end}}{{.DeviceID}}/{{.ProtocolSource}}",
+	})
+	if err := p.validateFormat(); err != nil {
+		t.Fatalf("validateFormat() error = %v", err)
+	}
+
+	state := models.NewDroneState("drone-001", "mavlink")
+	if got := p.deviceTopic(state, "state"); got != "fleet/drone-001/mavlink/state" {
+		t.Errorf("deviceTopic() = %s", got)
+	}
+	state.Tenant = "acme"
+	if got := p.deviceTopic(state, "state"); got != "fleet/acme/drone-001/mavlink/state" {
+		t.Errorf("deviceTopic() with tenant = %s", got)
+	}
+
+	// Raw messages carry no protocol source
+	if got := p.tenantTopic("", "drone-001", "raw/STATUSTEXT"); got != "fleet/drone-001//raw/STATUSTEXT" {
+		t.Errorf("tenantTopic() = %s", got)
+	}
+
+	bad := New(config.MQTTConfig{TopicTemplate: "{{.Serial}}"})
+	if err := bad.validateFormat(); err == nil {
+		t.Error("validateFormat() should reject an invalid topic template")
+	}
+}
+
+func TestPublisher_stateMessages(t *testing.T) {
+	state := models.NewDroneState("drone-001", "mavlink")
+	state.Location = models.Location{Lat: 22.54, Lon: 113.94}
+	state.Status.BatteryPercent = 75
+
+	p := New(config.MQTTConfig{TopicPrefix: "uav/telemetry"})
+	msgs, err := p.stateMessages(state, "uav/telemetry/drone-001/state")
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("stateMessages() = %d messages, %v, want the state only", len(msgs), err)
+	}
+
+	p = New(config.MQTTConfig{TopicPrefix: "uav/telemetry", SplitTopics: true})
+	msgs, err = p.stateMessages(state, "routed/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"routed/state",
+		"uav/telemetry/drone-001/position",
+		"uav/telemetry/drone-001/battery",
+		"uav/telemetry/drone-001/status",
+	}
+	if len(msgs) != len(want) {
+		t.Fatalf("stateMessages() = %d messages, want %d", len(msgs), len(want))
+	}
+	for i, m := range msgs {
+		if m.topic != want[i] {
+			t.Errorf("Topic %d = %s, want %s", i, m.topic, want[i])
+		}
+	}
+
+	var battery struct {
+		DeviceID       string `json:"device_id"`
+		BatteryPercent int    `json:"battery_percent"`
+	}
+	if err := json.Unmarshal(msgs[2].payload, &battery); err != nil || battery.DeviceID != "drone-001" || battery.BatteryPercent != 75 {
+		t.Errorf("Battery payload = %s", msgs[2].payload)
+	}
+	var position struct {
+		Location models.Location `json:"location"`
+	}
+	if err := json.Unmarshal(msgs[1].payload, &position); err != nil || position.Location.Lat != 22.54 {
+		t.Errorf("Position payload = %s", msgs[1].payload)
+	}
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"embed"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles are the schema migrations, named {version}_{name}.sql and
+// applied in version order
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock serializing the migrations of
+// gateways sharing a database
+const migrationLockKey = 0x6f757462 // "outb"
+
+// migration is one embedded schema migration
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations reads the embedded migrations in version order
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	var result []migration
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: name must start with a version number", entry.Name())
+		}
+		sql, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, migration{version: version, name: name, sql: string(sql)})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].version < result[j].version })
+	return result, nil
+}
+
+// migrate selects the schema, creating it if missing, and applies the
+// migrations not yet recorded in outb_schema_migrations, each in its own
+// transaction
+func migrate(c *conn, schema string) error {
+	rows, err := c.query("SELECT 1 FROM pg_namespace WHERE nspname = " + quoteLiteral(schema))
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		if _, err := c.query("CREATE SCHEMA IF NOT EXISTS " + quoteIdent(schema)); err != nil {
+			return fmt.Errorf("creating schema %s: %w", schema, err)
+		}
+	}
+	if _, err := c.query("SET search_path TO " + quoteIdent(schema)); err != nil {
+		return err
+	}
+
+	if _, err := c.query(fmt.Sprintf("SELECT pg_advisory_lock(%d)", migrationLockKey)); err != nil {
+		return err
+	}
+	defer c.query(fmt.Sprintf("SELECT pg_advisory_unlock(%d)", migrationLockKey))
+
+	if _, err := c.query(`CREATE TABLE IF NOT EXISTS outb_schema_migrations (
+    version    integer PRIMARY KEY,
+    name       text NOT NULL,
+    applied_at timestamptz NOT NULL DEFAULT now()
+)`); err != nil {
+		return err
+	}
+	rows, err = c.query("SELECT version FROM outb_schema_migrations")
+	if err != nil {
+		return err
+	}
+	applied := make(map[int]bool, len(rows))
+	for _, row := range rows {
+		v, _ := strconv.Atoi(row[0])
+		applied[v] = true
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		record := fmt.Sprintf("INSERT INTO outb_schema_migrations (version, name) VALUES (%d, %s)", m.version, quoteLiteral(m.name))
+		if _, err := c.query(m.sql + ";\n" + record); err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		log.Printf("[Postgres] Applied migration %s", m.name)
+	}
+	return nil
+}
+
+// quoteIdent quotes an SQL identifier
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes an SQL string literal
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,53 @@
+-- Latest state of each device, upserted as states arrive
+CREATE TABLE IF NOT EXISTS drone_states (
+    device_id          text PRIMARY KEY,
+    tenant             text NOT NULL DEFAULT '',
+    protocol_source    text NOT NULL,
+    ts                 timestamptz NOT NULL,
+    lat                double precision NOT NULL,
+    lon                double precision NOT NULL,
+    alt_baro           double precision NOT NULL,
+    alt_gnss           double precision NOT NULL,
+    roll               double precision NOT NULL,
+    pitch              double precision NOT NULL,
+    yaw                double precision NOT NULL,
+    vx                 double precision NOT NULL,
+    vy                 double precision NOT NULL,
+    vz                 double precision NOT NULL,
+    battery_percent    integer NOT NULL,
+    flight_mode        text NOT NULL,
+    armed              boolean NOT NULL,
+    signal_quality     integer NOT NULL,
+    satellites_visible integer,
+    state              jsonb NOT NULL,
+    updated_at         timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS drone_states_tenant ON drone_states (tenant);
+
+-- Every published state, partitioned by time so old data is dropped a
+-- partition at a time. Partitions are created by the publisher.
+CREATE TABLE IF NOT EXISTS drone_state_history (
+    device_id          text NOT NULL,
+    tenant             text NOT NULL DEFAULT '',
+    protocol_source    text NOT NULL,
+    ts                 timestamptz NOT NULL,
+    lat                double precision NOT NULL,
+    lon                double precision NOT NULL,
+    alt_baro           double precision NOT NULL,
+    alt_gnss           double precision NOT NULL,
+    roll               double precision NOT NULL,
+    pitch              double precision NOT NULL,
+    yaw                double precision NOT NULL,
+    vx                 double precision NOT NULL,
+    vy                 double precision NOT NULL,
+    vz                 double precision NOT NULL,
+    battery_percent    integer NOT NULL,
+    flight_mode        text NOT NULL,
+    armed              boolean NOT NULL,
+    signal_quality     integer NOT NULL,
+    satellites_visible integer,
+    state              jsonb NOT NULL
+) PARTITION BY RANGE (ts);
+
+CREATE INDEX IF NOT EXISTS drone_state_history_device_ts ON drone_state_history (device_id, ts);
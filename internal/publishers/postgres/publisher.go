@@ -0,0 +1,445 @@
+// Package postgres publishes states to PostgreSQL, so standard BI tools can
+// query fleet data without custom pipelines. The latest state of each
+// device is upserted into drone_states and every state is appended to
+// drone_state_history, partitioned by day or month so old data is dropped
+// a partition at a time. The tables are created by embedded migrations
+// when the publisher connects.
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/backoff"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// historyTable is the parent table of the history partitions, named
+// {historyTable}_p{YYYYMMDD} or _p{YYYYMM}
+const historyTable = "drone_state_history"
+
+// maxRowsPerInsert keeps inserts below the 65535 parameters of a statement
+const maxRowsPerInsert = 1000
+
+// columns are the columns of both tables set from a state
+var columns = []string{
+	"device_id", "tenant", "protocol_source", "ts",
+	"lat", "lon", "alt_baro", "alt_gnss",
+	"roll", "pitch", "yaw", "vx", "vy", "vz",
+	"battery_percent", "flight_mode", "armed", "signal_quality", "satellites_visible",
+	"state",
+}
+
+// identifierPattern matches the schema names accepted without quoting
+// surprises
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Publisher implements the core.Publisher interface for PostgreSQL
+type Publisher struct {
+	cfg config.PostgresConfig
+	ctx context.Context
+
+	mu           sync.Mutex
+	conn         *conn
+	partitions   map[string]bool // History partitions known to exist
+	reconnecting bool
+	stopped      bool
+	connectedAt  time.Time
+	lastError    string // Why the connection was last lost or refused
+	lastErrorAt  time.Time
+}
+
+// New creates a new PostgreSQL publisher, checking the SSL mode, schema and
+// partition size
+func New(cfg config.PostgresConfig) (*Publisher, error) {
+	switch cfg.SSLMode {
+	case "", "disable", "require", "verify-full":
+	default:
+		return nil, fmt.Errorf("unknown ssl_mode %q (want disable, require or verify-full)", cfg.SSLMode)
+	}
+	if cfg.Schema != "" && !identifierPattern.MatchString(cfg.Schema) {
+		return nil, fmt.Errorf("schema %q must be letters, digits and underscores", cfg.Schema)
+	}
+	switch cfg.HistoryPartition {
+	case "", "day", "month":
+	default:
+		return nil, fmt.Errorf("unknown history_partition %q (want day or month)", cfg.HistoryPartition)
+	}
+	if cfg.RetentionDays < 0 {
+		return nil, fmt.Errorf("retention_days must not be negative")
+	}
+	return &Publisher{cfg: cfg, ctx: context.Background(), partitions: make(map[string]bool)}, nil
+}
+
+// Name returns the publisher name
+func (p *Publisher) Name() string {
+	return "postgres"
+}
+
+// Start connects to the server and migrates the schema. If it is
+// unreachable the publisher keeps retrying in the background.
+func (p *Publisher) Start(ctx context.Context) error {
+	p.ctx = ctx
+	if err := p.connect(); err != nil {
+		log.Printf("[Postgres] Server %s not reachable, retrying in background: %v", p.cfg.Address, err)
+		p.scheduleReconnect()
+	}
+	return nil
+}
+
+// connect dials the server, applies pending migrations and drops expired
+// history partitions
+func (p *Publisher) connect() error {
+	c, err := dial(dialOptions{
+		address:  p.cfg.Address,
+		database: p.cfg.Database,
+		username: p.cfg.Username,
+		password: p.cfg.Password,
+		sslMode:  p.cfg.SSLMode,
+		timeout:  p.timeout(),
+	})
+	if err == nil {
+		if err = migrate(c, p.schema()); err != nil {
+			c.close()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.setError(err)
+		return err
+	}
+	if p.stopped {
+		c.close()
+		return fmt.Errorf("publisher stopped")
+	}
+	p.conn = c
+	p.connectedAt = time.Now()
+	p.partitions = make(map[string]bool)
+	p.prune()
+	return nil
+}
+
+// scheduleReconnect starts a background reconnect loop unless one is
+// already running
+func (p *Publisher) scheduleReconnect() {
+	p.mu.Lock()
+	if p.reconnecting || p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.reconnecting = true
+	p.mu.Unlock()
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			p.reconnecting = false
+			p.mu.Unlock()
+		}()
+
+		b := backoff.FromMs(p.cfg.ReconnectInitialMs, p.cfg.ReconnectMaxMs)
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-time.After(b.Next()):
+			}
+			if err := p.connect(); err != nil {
+				log.Printf("[Postgres] Reconnect to %s failed: %v", p.cfg.Address, err)
+				continue
+			}
+			log.Printf("[Postgres] Connected to %s", p.cfg.Address)
+			return
+		}
+	}()
+}
+
+// Publish stores the state
+func (p *Publisher) Publish(state *models.DroneState) error {
+	return p.PublishBatch([]*models.DroneState{state})
+}
+
+// PublishBatch appends the states to the history and upserts the latest
+// state of each device, in one transaction and round trip
+func (p *Publisher) PublishBatch(states []*models.DroneState) error {
+	rows := make([][]any, 0, len(states))
+	latest := make(map[string]int) // Row of the newest state per device
+	var order []string
+	for _, state := range states {
+		row, err := stateRow(state)
+		if err != nil {
+			return err
+		}
+		if i, ok := latest[state.DeviceID]; !ok {
+			order = append(order, state.DeviceID)
+			latest[state.DeviceID] = len(rows)
+		} else if state.Timestamp >= states[i].Timestamp {
+			latest[state.DeviceID] = len(rows)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	c := p.conn
+	if c == nil {
+		p.mu.Unlock()
+		return fmt.Errorf("postgres not connected")
+	}
+
+	// Partitions the states fall into, created before inserting
+	var stmts []statement
+	var created []string
+	for _, state := range states {
+		name, from, to := p.partition(stateTime(state))
+		if p.partitions[name] || slices.Contains(created, name) {
+			continue
+		}
+		created = append(created, name)
+		stmts = append(stmts, statement{sql: fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%s) TO (%s)",
+			quoteIdent(name), historyTable, quoteLiteral(from.Format(time.RFC3339)), quoteLiteral(to.Format(time.RFC3339)))})
+	}
+	for start := 0; start < len(rows); start += maxRowsPerInsert {
+		stmts = append(stmts, insert(historyTable, rows[start:min(start+maxRowsPerInsert, len(rows))], ""))
+	}
+	upserts := make([][]any, 0, len(order))
+	for _, id := range order {
+		upserts = append(upserts, rows[latest[id]])
+	}
+	for start := 0; start < len(upserts); start += maxRowsPerInsert {
+		stmts = append(stmts, insert("drone_states", upserts[start:min(start+maxRowsPerInsert, len(upserts))], upsertClause))
+	}
+
+	err := c.exec(stmts...)
+	var pgErr *Error
+	if err != nil && !errors.As(err, &pgErr) {
+		c.nc.Close()
+		p.conn = nil
+		p.setError(err)
+		p.mu.Unlock()
+		log.Printf("[Postgres] Connection lost, reconnecting with backoff: %v", err)
+		p.scheduleReconnect()
+		return fmt.Errorf("postgres publish failed: %w", err)
+	}
+	if err == nil && len(created) > 0 {
+		for _, name := range created {
+			p.partitions[name] = true
+		}
+		p.prune()
+	}
+	p.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("postgres publish failed: %w", err)
+	}
+	return nil
+}
+
+// upsertClause updates the latest state of a device unless the stored one
+// is newer, so states arriving out of order do not move it back
+var upsertClause = func() string {
+	var sets []string
+	for _, col := range columns[1:] {
+		sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+	}
+	return " ON CONFLICT (device_id) DO UPDATE SET " + strings.Join(sets, ", ") +
+		", updated_at = now() WHERE drone_states.ts <= EXCLUDED.ts"
+}()
+
+// insert builds a multi-row INSERT of the rows into a table
+func insert(table string, rows [][]any, suffix string) statement {
+	var sql strings.Builder
+	fmt.Fprintf(&sql, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+	args := make([]any, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteByte('(')
+		for j := range row {
+			if j > 0 {
+				sql.WriteString(", ")
+			}
+			fmt.Fprintf(&sql, "$%d", len(args)+j+1)
+		}
+		sql.WriteByte(')')
+		args = append(args, row...)
+	}
+	sql.WriteString(suffix)
+	return statement{sql: sql.String(), args: args}
+}
+
+// stateRow returns the column values of a state
+func stateRow(state *models.DroneState) ([]any, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal failed: %w", err)
+	}
+	return []any{
+		state.DeviceID, state.Tenant, state.ProtocolSource, stateTime(state),
+		state.Location.Lat, state.Location.Lon, state.Location.AltBaro, state.Location.AltGNSS,
+		state.Attitude.Roll, state.Attitude.Pitch, state.Attitude.Yaw,
+		state.Velocity.Vx, state.Velocity.Vy, state.Velocity.Vz,
+		state.Status.BatteryPercent, string(state.Status.FlightMode), state.Status.Armed,
+		state.Status.SignalQuality, state.Status.SatellitesVisible,
+		payload,
+	}, nil
+}
+
+// stateTime returns the time of a state, now for states without one
+func stateTime(state *models.DroneState) time.Time {
+	if state.Timestamp == 0 {
+		return time.Now().UTC()
+	}
+	return time.UnixMilli(state.Timestamp).UTC()
+}
+
+// partition returns the name and UTC time range of the history partition
+// holding t
+func (p *Publisher) partition(t time.Time) (string, time.Time, time.Time) {
+	t = t.UTC()
+	if p.cfg.HistoryPartition == "month" {
+		from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return historyTable + "_p" + from.Format("200601"), from, from.AddDate(0, 1, 0)
+	}
+	from := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return historyTable + "_p" + from.Format("20060102"), from, from.AddDate(0, 0, 1)
+}
+
+// partitionEnd returns the end of a history partition's range from its
+// name, whichever partition size created it
+func partitionEnd(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, historyTable+"_p")
+	if !ok {
+		return time.Time{}, false
+	}
+	if from, err := time.Parse("20060102", suffix); err == nil && len(suffix) == 8 {
+		return from.AddDate(0, 0, 1), true
+	}
+	if from, err := time.Parse("200601", suffix); err == nil && len(suffix) == 6 {
+		return from.AddDate(0, 1, 0), true
+	}
+	return time.Time{}, false
+}
+
+// prune drops the history partitions entirely older than the retention.
+// Failures are logged and retried with the next partition. Callers hold
+// p.mu.
+func (p *Publisher) prune() {
+	if p.cfg.RetentionDays <= 0 || p.conn == nil {
+		return
+	}
+	rows, err := p.conn.query(`SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = '` + historyTable + `'::regclass`)
+	if err != nil {
+		log.Printf("[Postgres] Failed to list history partitions: %v", err)
+		return
+	}
+	cutoff := time.Now().UTC().AddDate(0, 0, -p.cfg.RetentionDays)
+	for _, row := range rows {
+		end, ok := partitionEnd(row[0])
+		if !ok || end.After(cutoff) {
+			continue
+		}
+		if _, err := p.conn.query("DROP TABLE IF EXISTS " + quoteIdent(row[0])); err != nil {
+			log.Printf("[Postgres] Failed to drop history partition %s: %v", row[0], err)
+			continue
+		}
+		delete(p.partitions, row[0])
+		log.Printf("[Postgres] Dropped history partition %s (retention %d days)", row[0], p.cfg.RetentionDays)
+	}
+}
+
+// schema returns the schema of the tables
+func (p *Publisher) schema() string {
+	if p.cfg.Schema == "" {
+		return "public"
+	}
+	return p.cfg.Schema
+}
+
+// timeout bounds connecting and each round trip
+func (p *Publisher) timeout() time.Duration {
+	if p.cfg.TimeoutMs > 0 {
+		return time.Duration(p.cfg.TimeoutMs) * time.Millisecond
+	}
+	return 5 * time.Second
+}
+
+// SelfTest encodes the state without writing it, and checks the server
+// connection
+func (p *Publisher) SelfTest(state *models.DroneState) error {
+	if _, err := stateRow(state); err != nil {
+		return err
+	}
+	if !p.IsConnected() {
+		return fmt.Errorf("postgres not connected to %s", p.cfg.Address)
+	}
+	return nil
+}
+
+// Stop closes the connection
+func (p *Publisher) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	if p.conn != nil {
+		err := p.conn.close()
+		p.conn = nil
+		return err
+	}
+	return nil
+}
+
+// IsConnected returns true if the publisher holds a server connection
+func (p *Publisher) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.conn != nil
+}
+
+// Health reports the server connection
+func (p *Publisher) Health() sdk.PublisherDetail {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := sdk.PublisherDetail{
+		State:    "disconnected",
+		Endpoint: p.cfg.Address,
+		Details:  map[string]string{"database": p.cfg.Database, "schema": p.schema()},
+	}
+	switch {
+	case p.stopped:
+		d.State = "stopped"
+	case p.conn != nil:
+		d.State = "connected"
+		d.Details["connected_since"] = p.connectedAt.UTC().Format(time.RFC3339)
+	case p.reconnecting:
+		d.State = "reconnecting"
+	}
+	if p.lastError != "" {
+		d.LastError = p.lastError
+		d.LastErrorAt = p.lastErrorAt.UnixMilli()
+	}
+	return d
+}
+
+// setError records a connection error. Callers hold p.mu.
+func (p *Publisher) setError(err error) {
+	p.lastError = err.Error()
+	p.lastErrorAt = time.Now()
+}
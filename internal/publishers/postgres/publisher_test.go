@@ -0,0 +1,431 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// executed is a statement committed by the fake server
+type executed struct {
+	sql  string
+	args []*string
+}
+
+// fakeServer speaks enough of the PostgreSQL protocol to accept the
+// publisher: cleartext passwords, simple queries and pipelines of
+// statements, which are rolled back if one contains failOn
+type fakeServer struct {
+	ln       net.Listener
+	password string
+
+	mu         sync.Mutex
+	queries    []string
+	statements []executed
+	versions   []string // Applied migration versions
+	partitions []string // History partitions
+	failOn     string
+}
+
+func newFakeServer(t *testing.T, password string) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln, password: password}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+// readMessage reads a frontend message. The startup message has no type.
+func readMessage(r *bufio.Reader, typed bool) (byte, *decoder, error) {
+	var typ byte
+	if typed {
+		var err error
+		if typ, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(size[:])-4)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return typ, &decoder{b: payload}, nil
+}
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+	r, w := bufio.NewReader(c), bufio.NewWriter(c)
+	var out buffer
+	send := func() {
+		w.Write(out.b)
+		w.Flush()
+		out = buffer{}
+	}
+	ready := func() {
+		out.begin('Z')
+		out.byte('I')
+		out.end()
+		send()
+	}
+	fail := func(msg string) {
+		out.begin('E')
+		out.byte('S')
+		out.cstring("ERROR")
+		out.byte('C')
+		out.cstring("42P01")
+		out.byte('M')
+		out.cstring(msg)
+		out.byte(0)
+		out.end()
+	}
+
+	if _, d, err := readMessage(r, false); err != nil || d.int32() != protocolVersion {
+		return
+	}
+	out.begin('R')
+	out.int32(authCleartext)
+	out.end()
+	send()
+	s.mu.Lock()
+	password := s.password
+	s.mu.Unlock()
+	if _, d, err := readMessage(r, true); err != nil || d.cstring() != password {
+		fail("password authentication failed")
+		send()
+		return
+	}
+	out.begin('R')
+	out.int32(authOK)
+	out.end()
+	ready()
+
+	var pending []executed
+	var sql string
+	var args []*string
+	failed := false
+	for {
+		typ, d, err := readMessage(r, true)
+		if err != nil {
+			return
+		}
+		switch typ {
+		case 'Q':
+			q := d.cstring()
+			s.mu.Lock()
+			s.queries = append(s.queries, q)
+			var rows []string
+			switch {
+			case strings.Contains(q, "FROM pg_namespace"):
+				rows = []string{"1"}
+			case strings.HasPrefix(q, "SELECT version FROM outb_schema_migrations"):
+				rows = slices.Clone(s.versions)
+			case strings.Contains(q, "pg_inherits"):
+				rows = slices.Clone(s.partitions)
+			case strings.Contains(q, "INSERT INTO outb_schema_migrations"):
+				s.versions = append(s.versions, "1")
+			case strings.HasPrefix(q, "DROP TABLE IF EXISTS "):
+				name := strings.Trim(strings.TrimPrefix(q, "DROP TABLE IF EXISTS "), `"`)
+				s.partitions = slices.DeleteFunc(s.partitions, func(p string) bool { return p == name })
+			}
+			s.mu.Unlock()
+			for _, row := range rows {
+				out.begin('D')
+				out.int16(1)
+				out.int32(int32(len(row)))
+				out.bytes([]byte(row))
+				out.end()
+			}
+			out.begin('C')
+			out.cstring("OK")
+			out.end()
+			ready()
+		case 'P':
+			d.cstring()
+			sql = d.cstring()
+		case 'B':
+			d.cstring()
+			d.cstring()
+			d.int16()
+			args = make([]*string, d.int16())
+			for i := range args {
+				if n := d.int32(); n >= 0 {
+					v := string(d.bytes(int(n)))
+					args[i] = &v
+				}
+			}
+		case 'E':
+			if failed {
+				continue
+			}
+			s.mu.Lock()
+			failOn := s.failOn
+			s.mu.Unlock()
+			if failOn != "" && strings.Contains(sql, failOn) {
+				failed = true
+				fail("relation does not exist")
+				continue
+			}
+			pending = append(pending, executed{sql: sql, args: args})
+			out.begin('C')
+			out.cstring("INSERT 0 1")
+			out.end()
+		case 'S':
+			s.mu.Lock()
+			if !failed {
+				s.statements = append(s.statements, pending...)
+				for _, stmt := range pending {
+					if name, ok := strings.CutPrefix(stmt.sql, "CREATE TABLE IF NOT EXISTS "); ok {
+						s.partitions = append(s.partitions, strings.Trim(strings.Fields(name)[0], `"`))
+					}
+				}
+			}
+			s.mu.Unlock()
+			pending, failed = nil, false
+			ready()
+		case 'X':
+			return
+		}
+	}
+}
+
+// takeStatements returns and clears the committed statements
+func (s *fakeServer) takeStatements() []executed {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stmts := s.statements
+	s.statements = nil
+	return stmts
+}
+
+func (s *fakeServer) countQueries(substr string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, q := range s.queries {
+		if strings.Contains(q, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func testConfig(s *fakeServer) config.PostgresConfig {
+	return config.PostgresConfig{
+		Enabled:            true,
+		Address:            s.ln.Addr().String(),
+		Database:           "fleet",
+		Username:           "outb",
+		Password:           "secret",
+		TimeoutMs:          2000,
+		ReconnectInitialMs: 10,
+		ReconnectMaxMs:     50,
+	}
+}
+
+func state(id string, ts int64) *models.DroneState {
+	s := models.NewDroneState(id, "mavlink")
+	s.Timestamp = ts
+	s.Location.Lat, s.Location.Lon = 22.5, 114.0
+	return s
+}
+
+func TestPublisher(t *testing.T) {
+	server := newFakeServer(t, "secret")
+	p, err := New(testConfig(server))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop()
+	if !p.IsConnected() {
+		t.Fatalf("Not connected: %+v", p.Health())
+	}
+	if server.countQueries("CREATE TABLE IF NOT EXISTS drone_states") != 1 || server.countQueries(`SET search_path TO "public"`) != 1 {
+		t.Error("Migrations not applied")
+	}
+
+	day := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC).UnixMilli()
+	if err := p.PublishBatch([]*models.DroneState{state("uav-1", day+2000), state("uav-2", day+1000), state("uav-1", day)}); err != nil {
+		t.Fatalf("PublishBatch failed: %v", err)
+	}
+	stmts := server.takeStatements()
+	if len(stmts) != 3 {
+		t.Fatalf("Statements = %d, want partition, history and upsert", len(stmts))
+	}
+	if want := `CREATE TABLE IF NOT EXISTS "drone_state_history_p20261015" PARTITION OF drone_state_history FOR VALUES FROM ('2026-10-15T00:00:00Z') TO ('2026-10-16T00:00:00Z')`; stmts[0].sql != want {
+		t.Errorf("Partition = %s", stmts[0].sql)
+	}
+	if !strings.HasPrefix(stmts[1].sql, "INSERT INTO drone_state_history (device_id,") || len(stmts[1].args) != 3*len(columns) {
+		t.Errorf("History = %s with %d args", stmts[1].sql, len(stmts[1].args))
+	}
+	upsert := stmts[2]
+	if !strings.Contains(upsert.sql, "ON CONFLICT (device_id) DO UPDATE") || len(upsert.args) != 2*len(columns) {
+		t.Fatalf("Upsert = %s with %d args", upsert.sql, len(upsert.args))
+	}
+	// The newest state of uav-1 wins; NULL for the unknown satellite count
+	if *upsert.args[0] != "uav-1" || *upsert.args[3] != "2026-10-15 08:00:02Z" || upsert.args[18] != nil {
+		t.Errorf("Upsert row = %s %s %v", *upsert.args[0], *upsert.args[3], upsert.args[18])
+	}
+	if !strings.Contains(*upsert.args[19], `"device_id":"uav-1"`) {
+		t.Errorf("State column = %s", *upsert.args[19])
+	}
+
+	// Known partitions are not created again
+	if err := p.Publish(state("uav-1", day+3000)); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if stmts := server.takeStatements(); len(stmts) != 2 {
+		t.Errorf("Statements = %d, want history and upsert", len(stmts))
+	}
+
+	// Server errors roll back the batch but keep the connection
+	server.mu.Lock()
+	server.failOn = "drone_states"
+	server.mu.Unlock()
+	if err := p.Publish(state("uav-1", day+4000)); err == nil || !strings.Contains(err.Error(), "SQLSTATE 42P01") {
+		t.Errorf("Publish error = %v, want server error", err)
+	}
+	if stmts := server.takeStatements(); len(stmts) != 0 || !p.IsConnected() {
+		t.Errorf("After error: %d statements committed, connected = %v", len(stmts), p.IsConnected())
+	}
+
+	// A restarted gateway does not migrate again
+	p.Stop()
+	p2, _ := New(testConfig(server))
+	p2.Start(context.Background())
+	defer p2.Stop()
+	if !p2.IsConnected() || server.countQueries("CREATE TABLE IF NOT EXISTS drone_states") != 1 {
+		t.Errorf("Second start: connected = %v, migrations run %d times", p2.IsConnected(), server.countQueries("CREATE TABLE IF NOT EXISTS drone_states"))
+	}
+}
+
+func TestPublisher_Retention(t *testing.T) {
+	server := newFakeServer(t, "secret")
+	today := time.Now().UTC()
+	current := historyTable + "_p" + today.Format("20060102")
+	server.mu.Lock()
+	server.partitions = []string{
+		historyTable + "_p20200101",
+		historyTable + "_p202001",
+		historyTable + "_p" + today.AddDate(0, 0, -3).Format("20060102"),
+		current,
+	}
+	server.mu.Unlock()
+	cfg := testConfig(server)
+	cfg.RetentionDays = 7
+	p, _ := New(cfg)
+	p.Start(context.Background())
+	defer p.Stop()
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.partitions) != 2 || server.partitions[1] != current {
+		t.Errorf("Partitions after pruning = %v, want the last 7 days", server.partitions)
+	}
+}
+
+func TestPublisher_Reconnect(t *testing.T) {
+	server := newFakeServer(t, "secret")
+	cfg := testConfig(server)
+	cfg.Password = "wrong"
+	p, _ := New(cfg)
+	p.Start(context.Background())
+	defer p.Stop()
+	if p.IsConnected() {
+		t.Fatal("Connected with a wrong password")
+	}
+	if h := p.Health(); !strings.Contains(h.LastError, "password authentication failed") {
+		t.Errorf("Health = %+v, want the server error", h)
+	}
+
+	server.mu.Lock()
+	server.password = "wrong"
+	server.mu.Unlock()
+	for deadline := time.Now().Add(2 * time.Second); !p.IsConnected() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !p.IsConnected() {
+		t.Error("Did not reconnect")
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	for _, cfg := range []config.PostgresConfig{
+		{SSLMode: "prefer"},
+		{Schema: "fleet; DROP"},
+		{HistoryPartition: "week"},
+		{RetentionDays: -1},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded, want error", cfg)
+		}
+	}
+	if _, err := New(config.PostgresConfig{SSLMode: "verify-full", Schema: "uav_data", HistoryPartition: "month"}); err != nil {
+		t.Errorf("New() failed: %v", err)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	p, _ := New(config.PostgresConfig{HistoryPartition: "month"})
+	name, from, to := p.partition(time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC))
+	if name != "drone_state_history_p202612" || from.Day() != 1 || to.Year() != 2027 || to.Month() != time.January {
+		t.Errorf("partition() = %s %v %v", name, from, to)
+	}
+	if end, ok := partitionEnd(name); !ok || !end.Equal(to) {
+		t.Errorf("partitionEnd(%s) = %v, want %v", name, end, to)
+	}
+	if end, ok := partitionEnd("drone_state_history_p20261231"); !ok || !end.Equal(to) {
+		t.Errorf("partitionEnd(daily) = %v, want %v", end, to)
+	}
+	if _, ok := partitionEnd("drone_state_history_default"); ok {
+		t.Error("partitionEnd() parsed a foreign partition")
+	}
+}
+
+func TestScramFinal(t *testing.T) {
+	// Example exchange of RFC 7677
+	final, serverSignature, err := scramFinal("pencil",
+		"n=user,r=rOprNGfwEbeRWgbNEkqO",
+		"r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+		"rOprNGfwEbeRWgbNEkqO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="; final != want {
+		t.Errorf("client-final-message = %s, want %s", final, want)
+	}
+	if got := "v=" + base64.StdEncoding.EncodeToString(serverSignature); got != "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=" {
+		t.Errorf("server signature = %s", got)
+	}
+
+	if _, _, err := scramFinal("pencil", "n=,r=abc", "r=xyz,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096", "abc"); err == nil {
+		t.Error("scramFinal() accepted a nonce not extending the client's")
+	}
+}
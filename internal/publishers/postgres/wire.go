@@ -0,0 +1,603 @@
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// protocolVersion is protocol 3.0, spoken by PostgreSQL 7.4 and later
+const protocolVersion = 3 << 16
+
+// sslRequestCode asks the server to switch to TLS before the startup
+const sslRequestCode = 80877103
+
+// maxMessageSize bounds the backend messages the client accepts
+const maxMessageSize = 16 << 20
+
+// Authentication request codes
+const (
+	authOK           = 0
+	authCleartext    = 3
+	authMD5          = 5
+	authSASL         = 10
+	authSASLContinue = 11
+	authSASLFinal    = 12
+)
+
+// Error is an error reported by the server. The connection stays usable.
+type Error struct {
+	Severity string
+	Code     string // SQLSTATE
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s (SQLSTATE %s)", e.Severity, e.Message, e.Code)
+}
+
+// parseError decodes the fields of an ErrorResponse
+func parseError(payload []byte) *Error {
+	e := &Error{}
+	d := decoder{b: payload}
+	for {
+		field := d.byte()
+		if field == 0 || d.err != nil {
+			return e
+		}
+		value := d.cstring()
+		switch field {
+		case 'S':
+			e.Severity = value
+		case 'C':
+			e.Code = value
+		case 'M':
+			e.Message = value
+		}
+	}
+}
+
+// dialOptions are the connection settings
+type dialOptions struct {
+	address  string // host:port
+	database string
+	username string
+	password string
+	sslMode  string        // disable, require or verify-full
+	timeout  time.Duration // Bounds connecting and each round trip
+}
+
+// statement is a statement run with the extended query protocol. Arguments
+// are sent as text and typed by the server from their use.
+type statement struct {
+	sql  string
+	args []any
+}
+
+// conn is a minimal PostgreSQL client connection. It runs simple queries
+// and pipelines of parameterized statements, and does not support COPY,
+// cancellation or binary results.
+type conn struct {
+	nc      net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+	timeout time.Duration
+}
+
+// dial connects, switches to TLS if requested and authenticates
+func dial(opts dialOptions) (*conn, error) {
+	nc, err := net.DialTimeout("tcp", opts.address, opts.timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{nc: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc), timeout: opts.timeout}
+	nc.SetDeadline(time.Now().Add(opts.timeout))
+	if opts.sslMode == "require" || opts.sslMode == "verify-full" {
+		if err := c.startTLS(opts); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	if err := c.startup(opts); err != nil {
+		c.nc.Close()
+		return nil, err
+	}
+	c.nc.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// startTLS asks the server for TLS. require encrypts without verifying the
+// certificate, like libpq; verify-full also checks it against the host.
+func (c *conn) startTLS(opts dialOptions) error {
+	var b buffer
+	b.begin(0)
+	b.int32(sslRequestCode)
+	b.end()
+	if err := c.write(b.b); err != nil {
+		return err
+	}
+	reply, err := c.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if reply != 'S' {
+		return fmt.Errorf("server does not support TLS")
+	}
+	host, _, _ := net.SplitHostPort(opts.address)
+	cfg := &tls.Config{ServerName: host, InsecureSkipVerify: opts.sslMode == "require"}
+	tc := tls.Client(c.nc, cfg)
+	if err := tc.Handshake(); err != nil {
+		return err
+	}
+	c.nc = tc
+	c.r = bufio.NewReader(tc)
+	c.w = bufio.NewWriter(tc)
+	return nil
+}
+
+// startup sends the startup message and answers the authentication
+// requests until the server is ready for queries
+func (c *conn) startup(opts dialOptions) error {
+	var b buffer
+	b.begin(0)
+	b.int32(protocolVersion)
+	for _, kv := range [][2]string{
+		{"user", opts.username},
+		{"database", opts.database},
+		{"application_name", "outb"},
+		{"client_encoding", "UTF8"},
+		{"TimeZone", "UTC"},
+	} {
+		b.cstring(kv[0])
+		b.cstring(kv[1])
+	}
+	b.byte(0)
+	b.end()
+	if err := c.write(b.b); err != nil {
+		return err
+	}
+
+	for {
+		typ, payload, err := c.receive()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'R':
+			if err := c.authenticate(payload, opts); err != nil {
+				return err
+			}
+		case 'E':
+			return parseError(payload)
+		case 'Z':
+			return nil
+		}
+		// ParameterStatus, BackendKeyData and notices are ignored
+	}
+}
+
+// authenticate answers an authentication request
+func (c *conn) authenticate(payload []byte, opts dialOptions) error {
+	d := decoder{b: payload}
+	code := d.int32()
+	if code != authOK && opts.password == "" {
+		return fmt.Errorf("server requires a password")
+	}
+	var b buffer
+	switch code {
+	case authOK:
+		return nil
+	case authCleartext:
+		b.begin('p')
+		b.cstring(opts.password)
+	case authMD5:
+		salt := d.bytes(4)
+		inner := md5.Sum([]byte(opts.password + opts.username))
+		outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+		b.begin('p')
+		b.cstring("md5" + hex.EncodeToString(outer[:]))
+	case authSASL:
+		var mechanisms []string
+		for m := d.cstring(); m != "" && d.err == nil; m = d.cstring() {
+			mechanisms = append(mechanisms, m)
+		}
+		for _, m := range mechanisms {
+			if m == "SCRAM-SHA-256" {
+				return c.scram(opts.password)
+			}
+		}
+		return fmt.Errorf("server offers no supported SASL mechanism (%s)", strings.Join(mechanisms, ", "))
+	default:
+		return fmt.Errorf("unsupported authentication method %d", code)
+	}
+	b.end()
+	return c.write(b.b)
+}
+
+// scram authenticates with SCRAM-SHA-256 (RFC 7677). The server takes the
+// user from the startup message, so the SCRAM user name is left empty.
+func (c *conn) scram(password string) error {
+	nonce := make([]byte, 18)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	clientNonce := base64.StdEncoding.EncodeToString(nonce)
+	clientFirstBare := "n=,r=" + clientNonce
+
+	var b buffer
+	b.begin('p')
+	b.cstring("SCRAM-SHA-256")
+	b.int32(int32(len(clientFirstBare) + 3))
+	b.bytes([]byte("n,," + clientFirstBare))
+	b.end()
+	if err := c.write(b.b); err != nil {
+		return err
+	}
+
+	serverFirst, err := c.saslMessage(authSASLContinue)
+	if err != nil {
+		return err
+	}
+	final, serverSignature, err := scramFinal(password, clientFirstBare, serverFirst, clientNonce)
+	if err != nil {
+		return err
+	}
+	b = buffer{}
+	b.begin('p')
+	b.bytes([]byte(final))
+	b.end()
+	if err := c.write(b.b); err != nil {
+		return err
+	}
+
+	serverFinal, err := c.saslMessage(authSASLFinal)
+	if err != nil {
+		return err
+	}
+	if got, ok := strings.CutPrefix(serverFinal, "v="); !ok || got != base64.StdEncoding.EncodeToString(serverSignature) {
+		return fmt.Errorf("server failed SCRAM verification")
+	}
+	return nil
+}
+
+// saslMessage reads the SASL data of the next authentication request,
+// which must have the given code
+func (c *conn) saslMessage(want int32) (string, error) {
+	typ, payload, err := c.receive()
+	if err != nil {
+		return "", err
+	}
+	if typ == 'E' {
+		return "", parseError(payload)
+	}
+	d := decoder{b: payload}
+	if typ != 'R' || d.int32() != want {
+		return "", fmt.Errorf("unexpected message %q during SCRAM authentication", typ)
+	}
+	return string(d.b), nil
+}
+
+// scramFinal computes the client-final-message for the server-first-message
+// and the signature the server must prove
+func scramFinal(password, clientFirstBare, serverFirst, clientNonce string) (string, []byte, error) {
+	var nonce, salt string
+	iterations := 0
+	for _, attr := range strings.Split(serverFirst, ",") {
+		key, value, _ := strings.Cut(attr, "=")
+		switch key {
+		case "r":
+			nonce = value
+		case "s":
+			salt = value
+		case "i":
+			iterations, _ = strconv.Atoi(value)
+		}
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil || !strings.HasPrefix(nonce, clientNonce) || len(nonce) == len(clientNonce) || iterations <= 0 {
+		return "", nil, fmt.Errorf("invalid SCRAM server-first-message")
+	}
+
+	salted, err := pbkdf2.Key(sha256.New, password, saltBytes, iterations, sha256.Size)
+	if err != nil {
+		return "", nil, err
+	}
+	clientKey := hmacSHA256(salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + nonce
+	authMessage := clientFirstBare + "," + serverFirst + "," + withoutProof
+	proof := hmacSHA256(storedKey[:], authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	serverSignature := hmacSHA256(hmacSHA256(salted, "Server Key"), authMessage)
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), serverSignature, nil
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
+
+// query runs SQL with the simple query protocol and returns the rows of
+// its results as text, NULL as "". Several statements separated by
+// semicolons run in one transaction.
+func (c *conn) query(sql string) ([][]string, error) {
+	var b buffer
+	b.begin('Q')
+	b.cstring(sql)
+	b.end()
+	c.nc.SetDeadline(time.Now().Add(c.timeout))
+	if err := c.write(b.b); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	var queryErr error
+	for {
+		typ, payload, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case 'D':
+			d := decoder{b: payload}
+			row := make([]string, d.int16())
+			for i := range row {
+				if n := d.int32(); n >= 0 {
+					row[i] = string(d.bytes(int(n)))
+				}
+			}
+			if d.err != nil {
+				return nil, d.err
+			}
+			rows = append(rows, row)
+		case 'E':
+			if queryErr == nil {
+				queryErr = parseError(payload)
+			}
+		case 'Z':
+			return rows, queryErr
+		}
+	}
+}
+
+// exec pipelines the statements with one round trip. They run in one
+// implicit transaction: the first error rolls all of them back and is
+// returned.
+func (c *conn) exec(stmts ...statement) error {
+	var b buffer
+	for _, stmt := range stmts {
+		if len(stmt.args) > math.MaxUint16 {
+			return fmt.Errorf("statement has %d parameters, at most %d are supported", len(stmt.args), math.MaxUint16)
+		}
+		b.begin('P') // Parse into the unnamed statement
+		b.cstring("")
+		b.cstring(stmt.sql)
+		b.int16(0) // Parameter types are inferred
+		b.end()
+
+		b.begin('B') // Bind to the unnamed portal
+		b.cstring("")
+		b.cstring("")
+		b.int16(0) // All parameters in text format
+		b.int16(int16(uint16(len(stmt.args))))
+		for _, arg := range stmt.args {
+			text, ok := encodeText(arg)
+			if !ok {
+				b.int32(-1)
+				continue
+			}
+			b.int32(int32(len(text)))
+			b.bytes([]byte(text))
+		}
+		b.int16(0) // Results in text format
+		b.end()
+
+		b.begin('E')
+		b.cstring("")
+		b.int32(0) // No row limit
+		b.end()
+	}
+	b.begin('S')
+	b.end()
+
+	c.nc.SetDeadline(time.Now().Add(c.timeout))
+	if err := c.write(b.b); err != nil {
+		return err
+	}
+	var execErr error
+	for {
+		typ, payload, err := c.receive()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'E':
+			if execErr == nil {
+				execErr = parseError(payload)
+			}
+		case 'Z':
+			return execErr
+		}
+	}
+}
+
+// encodeText formats a statement argument in the text format, false for
+// NULL
+func encodeText(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN", true
+		case math.IsInf(v, 1):
+			return "Infinity", true
+		case math.IsInf(v, -1):
+			return "-Infinity", true
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case *int:
+		if v == nil {
+			return "", false
+		}
+		return strconv.Itoa(*v), true
+	case *float64:
+		if v == nil {
+			return "", false
+		}
+		return encodeText(*v)
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05.999999Z07:00"), true
+	}
+	return fmt.Sprint(v), true
+}
+
+// close says goodbye to the server and closes the connection
+func (c *conn) close() error {
+	var b buffer
+	b.begin('X')
+	b.end()
+	c.nc.SetDeadline(time.Now().Add(c.timeout))
+	c.write(b.b)
+	return c.nc.Close()
+}
+
+// write sends messages and flushes them
+func (c *conn) write(p []byte) error {
+	if _, err := c.w.Write(p); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+// receive reads the next backend message
+func (c *conn) receive() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	size := int(binary.BigEndian.Uint32(header[1:])) - 4
+	if size < 0 || size > maxMessageSize {
+		return 0, nil, fmt.Errorf("invalid message length %d", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+// buffer builds frontend messages, several of which may be written at once
+type buffer struct {
+	b      []byte
+	length int // Offset of the length of the message being built
+}
+
+// begin starts a message. The startup and SSL request messages have no
+// type byte, given as 0.
+func (b *buffer) begin(typ byte) {
+	if typ != 0 {
+		b.b = append(b.b, typ)
+	}
+	b.length = len(b.b)
+	b.b = append(b.b, 0, 0, 0, 0)
+}
+
+// end fills in the length of the message
+func (b *buffer) end() {
+	binary.BigEndian.PutUint32(b.b[b.length:], uint32(len(b.b)-b.length))
+}
+
+func (b *buffer) byte(v byte)      { b.b = append(b.b, v) }
+func (b *buffer) int16(v int16)    { b.b = binary.BigEndian.AppendUint16(b.b, uint16(v)) }
+func (b *buffer) int32(v int32)    { b.b = binary.BigEndian.AppendUint32(b.b, uint32(v)) }
+func (b *buffer) bytes(p []byte)   { b.b = append(b.b, p...) }
+func (b *buffer) cstring(s string) { b.b = append(append(b.b, s...), 0) }
+
+// decoder reads the fields of a backend message. The first error is kept
+// and later reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	p := d.b[:n]
+	d.b = d.b[n:]
+	return p
+}
+
+func (d *decoder) byte() byte {
+	if p := d.take(1); p != nil {
+		return p[0]
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if p := d.take(2); p != nil {
+		return int16(binary.BigEndian.Uint16(p))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if p := d.take(4); p != nil {
+		return int32(binary.BigEndian.Uint32(p))
+	}
+	return 0
+}
+
+func (d *decoder) bytes(n int) []byte {
+	return d.take(n)
+}
+
+func (d *decoder) cstring() string {
+	if d.err != nil {
+		return ""
+	}
+	i := bytes.IndexByte(d.b, 0)
+	if i < 0 {
+		d.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	s := string(d.b[:i])
+	d.b = d.b[i+1:]
+	return s
+}
@@ -0,0 +1,253 @@
+// Package redis publishes the latest state of each device to Redis, so
+// horizontally scaled web backends can read current positions without
+// querying the gateway API. Each state is stored with SET and a TTL, so
+// devices that stop reporting disappear, and is optionally announced on a
+// pub/sub channel.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/internal/core/backoff"
+	"github.com/open-uav/telemetry-bridge/internal/core/resp"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// dialTimeout bounds connecting and each pipelined round trip
+const dialTimeout = 5 * time.Second
+
+// Publisher implements the core.Publisher interface for Redis
+type Publisher struct {
+	cfg config.RedisConfig
+	ctx context.Context
+
+	mu           sync.Mutex
+	conn         *resp.Conn
+	reconnecting bool
+	stopped      bool
+	connectedAt  time.Time
+	lastError    string // Why the connection was last lost or refused
+	lastErrorAt  time.Time
+}
+
+// New creates a new Redis publisher
+func New(cfg config.RedisConfig) *Publisher {
+	return &Publisher{cfg: cfg, ctx: context.Background()}
+}
+
+// Name returns the publisher name
+func (p *Publisher) Name() string {
+	return "redis"
+}
+
+// Start connects to the server. If it is unreachable the publisher keeps
+// retrying in the background.
+func (p *Publisher) Start(ctx context.Context) error {
+	p.ctx = ctx
+	if err := p.connect(); err != nil {
+		log.Printf("[Redis] Server %s not reachable, retrying in background: %v", p.cfg.Address, err)
+		p.scheduleReconnect()
+	}
+	return nil
+}
+
+// connect dials the server, authenticates and selects the database
+func (p *Publisher) connect() error {
+	c, err := resp.Dial(p.cfg.Address, dialTimeout)
+	if err == nil {
+		if err = c.Handshake(p.cfg.Username, p.cfg.Password, p.cfg.DB); err != nil {
+			c.Close()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.setError(err)
+		return err
+	}
+	if p.stopped {
+		c.Close()
+		return fmt.Errorf("publisher stopped")
+	}
+	p.conn = c
+	p.connectedAt = time.Now()
+	return nil
+}
+
+// scheduleReconnect starts a background reconnect loop unless one is
+// already running
+func (p *Publisher) scheduleReconnect() {
+	p.mu.Lock()
+	if p.reconnecting || p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.reconnecting = true
+	p.mu.Unlock()
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			p.reconnecting = false
+			p.mu.Unlock()
+		}()
+
+		b := backoff.FromMs(p.cfg.ReconnectInitialMs, p.cfg.ReconnectMaxMs)
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-time.After(b.Next()):
+			}
+			if err := p.connect(); err != nil {
+				log.Printf("[Redis] Reconnect to %s failed: %v", p.cfg.Address, err)
+				continue
+			}
+			log.Printf("[Redis] Connected to %s", p.cfg.Address)
+			return
+		}
+	}()
+}
+
+// Publish stores the state under its device key and announces it on the
+// channel, if configured
+func (p *Publisher) Publish(state *models.DroneState) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("json marshal failed: %w", err)
+	}
+	return p.send(p.commands(state, string(payload))...)
+}
+
+// PublishBatch stores several states in one pipelined round trip
+func (p *Publisher) PublishBatch(states []*models.DroneState) error {
+	var cmds [][]string
+	for _, state := range states {
+		payload, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("json marshal failed: %w", err)
+		}
+		cmds = append(cmds, p.commands(state, string(payload))...)
+	}
+	return p.send(cmds...)
+}
+
+// commands builds the SET (and PUBLISH) commands for a state
+func (p *Publisher) commands(state *models.DroneState, payload string) [][]string {
+	set := []string{"SET", p.deviceKey(state), payload}
+	if p.cfg.TTLSec > 0 {
+		set = append(set, "EX", strconv.Itoa(p.cfg.TTLSec))
+	}
+	cmds := [][]string{set}
+	if p.cfg.Channel != "" {
+		cmds = append(cmds, []string{"PUBLISH", p.cfg.Channel, payload})
+	}
+	return cmds
+}
+
+// deviceKey builds {prefix}:{device_id}, or {prefix}:{tenant}:{device_id}
+// for devices owned by a tenant
+func (p *Publisher) deviceKey(state *models.DroneState) string {
+	if state.Tenant != "" {
+		return fmt.Sprintf("%s:%s:%s", p.cfg.KeyPrefix, state.Tenant, state.DeviceID)
+	}
+	return fmt.Sprintf("%s:%s", p.cfg.KeyPrefix, state.DeviceID)
+}
+
+// send pipelines the commands. A connection failure drops the connection
+// and starts reconnecting; error replies leave it open.
+func (p *Publisher) send(cmds ...[]string) error {
+	p.mu.Lock()
+	c := p.conn
+	if c == nil {
+		p.mu.Unlock()
+		return fmt.Errorf("redis not connected")
+	}
+	_, err := c.Pipeline(cmds...)
+	var re resp.Error
+	if err != nil && !errors.As(err, &re) {
+		c.Close()
+		p.conn = nil
+		p.setError(err)
+		p.mu.Unlock()
+		log.Printf("[Redis] Connection lost, reconnecting with backoff: %v", err)
+		p.scheduleReconnect()
+		return fmt.Errorf("redis publish failed: %w", err)
+	}
+	p.mu.Unlock()
+	return err
+}
+
+// SelfTest encodes the state and builds its key without writing, and checks
+// the server connection
+func (p *Publisher) SelfTest(state *models.DroneState) error {
+	if _, err := json.Marshal(state); err != nil {
+		return fmt.Errorf("json marshal failed: %w", err)
+	}
+	if !p.IsConnected() {
+		return fmt.Errorf("redis not connected to %s", p.cfg.Address)
+	}
+	return nil
+}
+
+// Stop closes the connection
+func (p *Publisher) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	if p.conn != nil {
+		err := p.conn.Close()
+		p.conn = nil
+		return err
+	}
+	return nil
+}
+
+// IsConnected returns true if the publisher holds a server connection
+func (p *Publisher) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.conn != nil
+}
+
+// Health reports the server connection
+func (p *Publisher) Health() sdk.PublisherDetail {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := sdk.PublisherDetail{
+		State:    "disconnected",
+		Endpoint: p.cfg.Address,
+		Details:  map[string]string{"db": strconv.Itoa(p.cfg.DB)},
+	}
+	switch {
+	case p.stopped:
+		d.State = "stopped"
+	case p.conn != nil:
+		d.State = "connected"
+		d.Details["connected_since"] = p.connectedAt.UTC().Format(time.RFC3339)
+	case p.reconnecting:
+		d.State = "reconnecting"
+	}
+	if p.lastError != "" {
+		d.LastError = p.lastError
+		d.LastErrorAt = p.lastErrorAt.UnixMilli()
+	}
+	return d
+}
+
+// setError records a connection error. Callers hold p.mu.
+func (p *Publisher) setError(err error) {
+	p.lastError = err.Error()
+	p.lastErrorAt = time.Now()
+}
@@ -0,0 +1,224 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// fakeServer records RESP commands and replies +OK, or :1 to PUBLISH
+type fakeServer struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	cmds [][]string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		cmd, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.cmds = append(s.cmds, cmd)
+		s.mu.Unlock()
+		switch cmd[0] {
+		case "PUBLISH":
+			io.WriteString(c, ":1\r\n")
+		case "AUTH":
+			if cmd[len(cmd)-1] != "secret" {
+				io.WriteString(c, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			io.WriteString(c, "+OK\r\n")
+		default:
+			io.WriteString(c, "+OK\r\n")
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (s *fakeServer) commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, len(s.cmds))
+	for i, cmd := range s.cmds {
+		out[i] = fmt.Sprintf("%s %s", cmd[0], cmd[1])
+	}
+	return out
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	srv := newFakeServer(t)
+	p := New(config.RedisConfig{
+		Address:   srv.ln.Addr().String(),
+		Password:  "secret",
+		DB:        2,
+		KeyPrefix: "uav:state",
+		TTLSec:    30,
+		Channel:   "uav:updates",
+	})
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer p.Stop()
+	if !p.IsConnected() {
+		t.Fatal("Publisher should be connected")
+	}
+
+	state := models.NewDroneState("mavlink-1", "mavlink")
+	if err := p.Publish(state); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	state.Tenant = "acme"
+	if err := p.Publish(state); err != nil {
+		t.Fatalf("Publish() tenant error = %v", err)
+	}
+
+	want := []string{
+		"AUTH secret", "SELECT 2",
+		"SET uav:state:mavlink-1", "PUBLISH uav:updates",
+		"SET uav:state:acme:mavlink-1", "PUBLISH uav:updates",
+	}
+	if got := srv.commands(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Commands = %v, want %v", got, want)
+	}
+	srv.mu.Lock()
+	set := srv.cmds[2]
+	srv.mu.Unlock()
+	if len(set) != 5 || set[3] != "EX" || set[4] != "30" || !strings.Contains(set[2], `"device_id":"mavlink-1"`) {
+		t.Errorf("SET = %v, want JSON state with EX 30", set)
+	}
+}
+
+func TestPublisher_PublishBatch(t *testing.T) {
+	srv := newFakeServer(t)
+	p := New(config.RedisConfig{Address: srv.ln.Addr().String(), KeyPrefix: "uav:state", Channel: "uav:updates"})
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer p.Stop()
+
+	states := []*models.DroneState{models.NewDroneState("uav-1", "sim"), models.NewDroneState("uav-2", "sim")}
+	if err := p.PublishBatch(states); err != nil {
+		t.Fatalf("PublishBatch() error = %v", err)
+	}
+
+	want := []string{"SET uav:state:uav-1", "PUBLISH uav:updates", "SET uav:state:uav-2", "PUBLISH uav:updates"}
+	if got := srv.commands(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Commands = %v, want %v", got, want)
+	}
+}
+
+func TestPublisher_AuthFailure(t *testing.T) {
+	srv := newFakeServer(t)
+	p := New(config.RedisConfig{Address: srv.ln.Addr().String(), Password: "wrong", ReconnectInitialMs: 60000})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+
+	if p.IsConnected() {
+		t.Error("Publisher should not be connected with a wrong password")
+	}
+	if err := p.Publish(models.NewDroneState("mavlink-1", "mavlink")); err == nil {
+		t.Error("Publish() should fail while disconnected")
+	}
+	if err := p.SelfTest(models.NewDroneState("mavlink-1", "mavlink")); err == nil {
+		t.Error("SelfTest() should fail while disconnected")
+	}
+	if h := p.Health(); h.State != "reconnecting" || h.LastError == "" || h.LastErrorAt == 0 {
+		t.Errorf("Health() = %+v, want reconnecting with the auth error", h)
+	}
+}
+
+func TestPublisher_Reconnect(t *testing.T) {
+	srv := newFakeServer(t)
+	addr := srv.ln.Addr().String()
+	srv.ln.Close()
+
+	p := New(config.RedisConfig{Address: addr, KeyPrefix: "uav:state", ReconnectInitialMs: 10, ReconnectMaxMs: 10})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("Cannot rebind %s: %v", addr, err)
+	}
+	srv2 := &fakeServer{ln: ln}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv2.serve(c)
+		}
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !p.IsConnected() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.Publish(models.NewDroneState("mavlink-1", "mavlink")); err != nil {
+		t.Errorf("Publish() after reconnect error = %v", err)
+	}
+	if h := p.Health(); h.State != "connected" || h.Endpoint != addr || h.LastError == "" {
+		t.Errorf("Health() = %+v, want connected with the dial error kept", h)
+	}
+}
@@ -0,0 +1,154 @@
+package stanag4586
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Message types sent by the publisher
+const (
+	msgVehicleOperatingModeReport = 3001
+	msgVehicleOperatingStates     = 3002
+	msgInertialStates             = 4000
+)
+
+// wrapperSize is the length of the DLI message wrapper: sequence number,
+// data length, source ID, destination ID, message type and properties
+const wrapperSize = 16
+
+// iddEdition is the interface edition put in the message properties
+const iddEdition = 3
+
+// altitudeWGS84 is the DLI altitude type of GNSS altitudes
+const altitudeWGS84 = 3
+
+// Flight path control modes reported in message #3001
+const (
+	modeNone           = 0
+	modeFlightDirector = 1
+	modeReturnHome     = 2
+	modeManual         = 3
+	modeLaunch         = 4
+	modeRecovery       = 5
+	modeWaypoint       = 6
+	modeLoiter         = 7
+)
+
+// flightPathModes maps unified flight modes to DLI control modes
+var flightPathModes = map[models.FlightMode]byte{
+	models.FlightModeManual:    modeManual,
+	models.FlightModeStabilize: modeManual,
+	models.FlightModeAltHold:   modeManual,
+	models.FlightModeLoiter:    modeLoiter,
+	models.FlightModeAuto:      modeWaypoint,
+	models.FlightModeGuided:    modeFlightDirector,
+	models.FlightModeRTL:       modeReturnHome,
+	models.FlightModeLand:      modeRecovery,
+	models.FlightModeTakeoff:   modeLaunch,
+}
+
+// body builds a message body: a presence vector of pvBytes, followed by
+// the fields that are set. Fields are numbered from 1 after the presence
+// vector, matching bit 0 of the vector.
+type body struct {
+	pv     uint32
+	fields []byte
+}
+
+func (b *body) field(n int, value []byte) {
+	b.pv |= 1 << (n - 1)
+	b.fields = append(b.fields, value...)
+}
+
+func (b *body) u8(n int, v byte)    { b.field(n, []byte{v}) }
+func (b *body) u32(n int, v uint32) { b.field(n, binary.BigEndian.AppendUint32(nil, v)) }
+func (b *body) f32(n int, v float64) {
+	b.field(n, binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(v))))
+}
+func (b *body) f64(n int, v float64) {
+	b.field(n, binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+}
+func (b *body) bytes(pvBytes int) []byte {
+	out := make([]byte, pvBytes, pvBytes+len(b.fields))
+	for i := range pvBytes {
+		out[i] = byte(b.pv >> (8 * (pvBytes - 1 - i)))
+	}
+	return append(out, b.fields...)
+}
+
+// header starts a message body with the fields every vehicle message
+// begins with: time stamp, vehicle ID and CUCS ID
+func header(state *models.DroneState, vehicleID, cucsID uint32) *body {
+	b := &body{}
+	b.f64(1, float64(state.Timestamp)/1000) // Seconds since 1970-01-01 UTC
+	b.u32(2, vehicleID)
+	b.u32(3, cucsID)
+	return b
+}
+
+// inertialStates encodes message #4000 with position, altitude, velocity
+// and attitude. Accelerations, rates and magnetic variation are not sent.
+func inertialStates(state *models.DroneState, vehicleID, cucsID uint32) []byte {
+	b := header(state, vehicleID, cucsID)
+	b.f64(4, radians(state.Location.Lat))
+	b.f64(5, radians(state.Location.Lon))
+	b.f32(6, state.Location.AltGNSS)
+	b.u8(7, altitudeWGS84)
+	b.f32(8, state.Velocity.Vx)  // North
+	b.f32(9, state.Velocity.Vy)  // East
+	b.f32(10, state.Velocity.Vz) // Down
+	b.f32(14, state.Attitude.Roll)
+	b.f32(15, state.Attitude.Pitch)
+	b.f32(16, radians(state.Attitude.Yaw))
+	return b.bytes(3)
+}
+
+// operatingModeReport encodes message #3001 with the flight path control mode
+func operatingModeReport(state *models.DroneState, vehicleID, cucsID uint32) []byte {
+	b := header(state, vehicleID, cucsID)
+	mode, ok := flightPathModes[state.Status.FlightMode]
+	if !ok {
+		mode = modeNone
+	}
+	b.u8(4, mode)
+	return b.bytes(1)
+}
+
+// operatingStates encodes message #3002 with the propulsion energy level,
+// which carries the battery percentage
+func operatingStates(state *models.DroneState, vehicleID, cucsID uint32) []byte {
+	b := header(state, vehicleID, cucsID)
+	b.u8(14, byte(min(max(state.Status.BatteryPercent, 0), 100)))
+	return b.bytes(3)
+}
+
+// wrap puts a message body in the DLI message wrapper, optionally followed
+// by a 4-byte checksum: the sum of all preceding bytes
+func wrap(seq uint16, sourceID, destID uint32, msgType uint16, data []byte, checksum bool) []byte {
+	props := uint16(iddEdition) << 10
+	if checksum {
+		props |= 2 // Checksum length: 4 bytes
+	}
+	out := make([]byte, 0, wrapperSize+len(data)+4)
+	out = binary.BigEndian.AppendUint16(out, seq)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(data)))
+	out = binary.BigEndian.AppendUint32(out, sourceID)
+	out = binary.BigEndian.AppendUint32(out, destID)
+	out = binary.BigEndian.AppendUint16(out, msgType)
+	out = binary.BigEndian.AppendUint16(out, props)
+	out = append(out, data...)
+	if checksum {
+		var sum uint32
+		for _, c := range out {
+			sum += uint32(c)
+		}
+		out = binary.BigEndian.AppendUint32(out, sum)
+	}
+	return out
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
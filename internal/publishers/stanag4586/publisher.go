@@ -0,0 +1,177 @@
+// Package stanag4586 publishes states as STANAG 4586 Data Link Interface
+// (DLI) messages over UDP, acting as the Vehicle Specific Module (VSM) for
+// every drone, so ground control systems built on the NATO UAV
+// interoperability standard can display them. Each state is sent as
+// Inertial States (#4000), Vehicle Operating Mode Report (#3001) and
+// Vehicle Operating States (#3002) messages.
+package stanag4586
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+// broadcastID addresses every CUCS or vehicle
+const broadcastID = 0xFFFFFFFF
+
+// Publisher implements the core.Publisher interface for STANAG 4586
+type Publisher struct {
+	cfg config.STANAG4586Config
+
+	mu       sync.Mutex
+	conn     *net.UDPConn
+	seq      uint16
+	vehicles map[string]uint32 // Vehicle ID per device ID
+}
+
+// New creates a new STANAG 4586 publisher, checking the address and the
+// configured vehicle IDs
+func New(cfg config.STANAG4586Config) (*Publisher, error) {
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+	vehicles := make(map[string]uint32, len(cfg.VehicleIDs))
+	owners := make(map[uint32]string, len(cfg.VehicleIDs))
+	for device, id := range cfg.VehicleIDs {
+		if id == 0 || id == broadcastID {
+			return nil, fmt.Errorf("vehicle ID of %s must not be 0 or 0xFFFFFFFF", device)
+		}
+		if other, ok := owners[id]; ok {
+			return nil, fmt.Errorf("vehicle ID %d is used by both %s and %s", id, other, device)
+		}
+		owners[id] = device
+		vehicles[device] = id
+	}
+	return &Publisher{cfg: cfg, vehicles: vehicles}, nil
+}
+
+// Name returns the publisher name
+func (p *Publisher) Name() string {
+	return "stanag4586"
+}
+
+// Start opens the UDP socket
+func (p *Publisher) Start(ctx context.Context) error {
+	addr, err := net.ResolveUDPAddr("udp", p.cfg.Address)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", p.cfg.Address, err)
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", p.cfg.Address, err)
+	}
+
+	p.mu.Lock()
+	p.conn = conn
+	p.mu.Unlock()
+	log.Printf("[STANAG4586] Sending to %s as VSM %d", p.cfg.Address, p.cfg.VSMID)
+	return nil
+}
+
+// Publish sends the messages for a state
+func (p *Publisher) Publish(state *models.DroneState) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return fmt.Errorf("stanag4586 not started")
+	}
+	for _, msg := range p.messages(state) {
+		if _, err := p.conn.Write(msg); err != nil {
+			return fmt.Errorf("stanag4586 send failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// messages encodes the wrapped messages for a state. Callers hold p.mu.
+func (p *Publisher) messages(state *models.DroneState) [][]byte {
+	vehicleID := p.vehicleID(state.DeviceID)
+	bodies := []struct {
+		msgType uint16
+		data    []byte
+	}{
+		{msgInertialStates, inertialStates(state, vehicleID, p.cfg.CUCSID)},
+		{msgVehicleOperatingModeReport, operatingModeReport(state, vehicleID, p.cfg.CUCSID)},
+		{msgVehicleOperatingStates, operatingStates(state, vehicleID, p.cfg.CUCSID)},
+	}
+	msgs := make([][]byte, len(bodies))
+	for i, b := range bodies {
+		p.seq++
+		msgs[i] = wrap(p.seq, p.cfg.VSMID, p.cfg.CUCSID, b.msgType, b.data, p.cfg.Checksum)
+	}
+	return msgs
+}
+
+// vehicleID returns the configured vehicle ID of a device, or derives one
+// from a hash of the device ID. Derived IDs are logged once so they can be
+// entered in the CUCS. Callers hold p.mu.
+func (p *Publisher) vehicleID(deviceID string) uint32 {
+	if id, ok := p.vehicles[deviceID]; ok {
+		return id
+	}
+	h := fnv.New32a()
+	h.Write([]byte(deviceID))
+	id := h.Sum32()
+	if id == 0 || id == broadcastID {
+		id = 1
+	}
+	p.vehicles[deviceID] = id
+	log.Printf("[STANAG4586] Device %s sent as vehicle ID %d", deviceID, id)
+	return id
+}
+
+// SelfTest checks the socket is open. Encoding a state cannot fail.
+func (p *Publisher) SelfTest(state *models.DroneState) error {
+	if !p.IsConnected() {
+		return fmt.Errorf("stanag4586 not started")
+	}
+	return nil
+}
+
+// Stop closes the socket
+func (p *Publisher) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// IsConnected returns true while the socket is open
+func (p *Publisher) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.conn != nil
+}
+
+// Health reports the socket state. UDP has no session, so "open" only
+// means datagrams are being sent.
+func (p *Publisher) Health() sdk.PublisherDetail {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d := sdk.PublisherDetail{
+		State:    "closed",
+		Endpoint: p.cfg.Address,
+		Details: map[string]string{
+			"vsm_id":   strconv.FormatUint(uint64(p.cfg.VSMID), 10),
+			"vehicles": strconv.Itoa(len(p.vehicles)),
+		},
+	}
+	if p.conn != nil {
+		d.State = "open"
+	}
+	return d
+}
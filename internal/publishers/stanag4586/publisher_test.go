@@ -0,0 +1,144 @@
+package stanag4586
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/internal/config"
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.STANAG4586Config
+		want string
+	}{
+		{"address", config.STANAG4586Config{Address: "localhost"}, "invalid address"},
+		{"broadcast vehicle", config.STANAG4586Config{Address: "localhost:4586", VehicleIDs: map[string]uint32{"uav-1": broadcastID}}, "must not be 0"},
+		{"duplicate vehicle", config.STANAG4586Config{Address: "localhost:4586", VehicleIDs: map[string]uint32{"uav-1": 7, "uav-2": 7}}, "is used by both"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	ln, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	p, err := New(config.STANAG4586Config{
+		Address:    ln.LocalAddr().String(),
+		VSMID:      1,
+		CUCSID:     broadcastID,
+		VehicleIDs: map[string]uint32{"uav-1": 42},
+		Checksum:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SelfTest(models.NewDroneState("uav-1", "mavlink")); err == nil {
+		t.Error("SelfTest should fail before Start")
+	}
+	if h := p.Health(); h.State != "closed" {
+		t.Errorf("Health before Start = %+v, want closed", h)
+	}
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop()
+	if h := p.Health(); h.State != "open" || h.Endpoint != ln.LocalAddr().String() || h.Details["vehicles"] != "1" {
+		t.Errorf("Health after Start = %+v", h)
+	}
+
+	state := models.NewDroneState("uav-1", "mavlink")
+	state.Timestamp = 1709882231500
+	state.Location.Lat, state.Location.Lon, state.Location.AltGNSS = 39.9, 116.4, 120.5
+	state.Attitude.Yaw = 90
+	state.Status.FlightMode = models.FlightModeRTL
+	state.Status.BatteryPercent = 64
+	if err := p.Publish(state); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	ln.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1500)
+	var msgs [][]byte
+	for range 3 {
+		n, err := ln.Read(buf)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		msgs = append(msgs, append([]byte(nil), buf[:n]...))
+	}
+
+	for i, want := range []uint16{msgInertialStates, msgVehicleOperatingModeReport, msgVehicleOperatingStates} {
+		msg := msgs[i]
+		length := int(binary.BigEndian.Uint16(msg[2:]))
+		if len(msg) != wrapperSize+length+4 {
+			t.Fatalf("Message %d is %d bytes, want wrapper + %d + checksum", i, len(msg), length)
+		}
+		if seq := binary.BigEndian.Uint16(msg); seq != uint16(i+1) {
+			t.Errorf("Sequence = %d, want %d", seq, i+1)
+		}
+		if got := binary.BigEndian.Uint16(msg[12:]); got != want {
+			t.Errorf("Message type = %d, want %d", got, want)
+		}
+		if src, dst := binary.BigEndian.Uint32(msg[4:]), binary.BigEndian.Uint32(msg[8:]); src != 1 || dst != broadcastID {
+			t.Errorf("Source = %d, destination = %#x", src, dst)
+		}
+		var sum uint32
+		for _, c := range msg[:len(msg)-4] {
+			sum += uint32(c)
+		}
+		if got := binary.BigEndian.Uint32(msg[len(msg)-4:]); got != sum {
+			t.Errorf("Checksum = %d, want %d", got, sum)
+		}
+	}
+
+	// #4000: presence vector, time stamp, vehicle ID, CUCS ID, lat, lon
+	data := msgs[0][wrapperSize:]
+	if pv := uint32(data[0])<<16 | uint32(data[1])<<8 | uint32(data[2]); pv != 0b1110001111111111 {
+		t.Errorf("Presence vector = %b", pv)
+	}
+	if ts := math.Float64frombits(binary.BigEndian.Uint64(data[3:])); ts != 1709882231.5 {
+		t.Errorf("Time stamp = %v", ts)
+	}
+	if id := binary.BigEndian.Uint32(data[11:]); id != 42 {
+		t.Errorf("Vehicle ID = %d, want 42", id)
+	}
+	if lat := math.Float64frombits(binary.BigEndian.Uint64(data[19:])); math.Abs(lat-39.9*math.Pi/180) > 1e-12 {
+		t.Errorf("Latitude = %v rad", lat)
+	}
+
+	// #3001 ends with the control mode, #3002 with the energy level
+	if mode := msgs[1][len(msgs[1])-5]; mode != modeReturnHome {
+		t.Errorf("Flight path control mode = %d, want %d", mode, modeReturnHome)
+	}
+	if level := msgs[2][len(msgs[2])-5]; level != 64 {
+		t.Errorf("Energy level = %d, want 64", level)
+	}
+}
+
+func TestPublisher_VehicleID(t *testing.T) {
+	p, _ := New(config.STANAG4586Config{Address: "localhost:4586"})
+	id := p.vehicleID("uav-7")
+	if id == 0 || id == broadcastID || p.vehicleID("uav-7") != id {
+		t.Errorf("Derived vehicle ID = %d, should be stable and valid", id)
+	}
+	if p.vehicleID("uav-8") == id {
+		t.Error("Different devices should get different vehicle IDs")
+	}
+}
@@ -0,0 +1,239 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SchemaVersion is the version of the DroneState JSON Schema, sent as
+// schema_version with every serialized state. The minor version is bumped
+// when fields are added, the major version when fields change meaning or
+// are removed.
+const SchemaVersion = "1.0"
+
+// DroneState represents the unified telemetry data model
+// This is the core data structure that all protocol adapters convert to
+type DroneState struct {
+	DeviceID       string   `json:"device_id"`        // Unique device identifier
+	Timestamp      int64    `json:"timestamp"`        // Unix timestamp in milliseconds
+	ProtocolSource string   `json:"protocol_source"`  // Data source: mavlink, dji, gb28181
+	Tenant         string   `json:"tenant,omitempty"` // Owning organization, set by the gateway
+	Location       Location `json:"location"`         // Position data
+	Attitude       Attitude `json:"attitude"`         // Orientation data
+	Status         Status   `json:"status"`           // System status
+	Velocity       Velocity `json:"velocity"`         // Velocity data
+
+	Freshness Freshness `json:"freshness"`        // When each part was last updated
+	AgeMs     *int64    `json:"age_ms,omitempty"` // Milliseconds since Timestamp, set in API responses
+
+	Metadata *DeviceMetadata `json:"metadata,omitempty"` // Registered device details, if any
+	Home     *HomePosition   `json:"home,omitempty"`     // Launch point, once known
+	Sources  []SourceInfo    `json:"sources,omitempty"`  // Sources merged into this device by device aliases
+
+	Extra map[string]string `json:"extra,omitempty"` // Deployment-specific fields set by enrichment processors, e.g. static tags
+
+	ReceivedAt time.Time `json:"-"` // When the adapter received the message, for tracing
+}
+
+// MarshalJSON encodes the state with its schema_version
+func (s DroneState) MarshalJSON() ([]byte, error) {
+	type alias DroneState
+	return json.Marshal(struct {
+		SchemaVersion string `json:"schema_version"`
+		alias
+	}{SchemaVersion, alias(s)})
+}
+
+// Freshness records when the source last updated each part of the state, so
+// a value that stopped changing can be told apart from one that is still
+// being reported. Times are Unix ms, 0 if the part was never reported.
+type Freshness struct {
+	PositionAt int64 `json:"position_at,omitempty"` // Last Location update
+	AttitudeAt int64 `json:"attitude_at,omitempty"` // Last Attitude update
+	BatteryAt  int64 `json:"battery_at,omitempty"`  // Last Status.BatteryPercent update
+}
+
+// FreshnessAt returns a Freshness with every part updated at ms, for sources
+// that always send complete states
+func FreshnessAt(ms int64) Freshness {
+	return Freshness{PositionAt: ms, AttitudeAt: ms, BatteryAt: ms}
+}
+
+// IsZero reports whether no part has an update time
+func (f Freshness) IsZero() bool {
+	return f == Freshness{}
+}
+
+// DeviceMetadata holds the operator-maintained details of a registered device
+type DeviceMetadata struct {
+	Name     string   `json:"name,omitempty"`     // Friendly name
+	Airframe string   `json:"airframe,omitempty"` // Airframe type, e.g. quadrotor, fixed_wing, vtol
+	Serial   string   `json:"serial,omitempty"`   // Airframe serial number
+	Operator string   `json:"operator,omitempty"` // Responsible operator or pilot
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// SourceInfo describes one source whose states are merged into a device,
+// e.g. the MAVLink link and the Remote ID broadcast of the same aircraft
+type SourceInfo struct {
+	DeviceID       string `json:"device_id"` // Device ID reported by the source
+	ProtocolSource string `json:"protocol_source"`
+	LastSeen       int64  `json:"last_seen"` // Unix ms of the source's last state
+}
+
+// Sources of a home position
+const (
+	HomeSourceAutopilot = "autopilot" // Reported by the flight controller, e.g. MAVLink HOME_POSITION
+	HomeSourceArmed     = "armed"     // First fix after the drone armed
+)
+
+// HomePosition is where a drone launched and returns to, with the drone's
+// current distance and bearing to it. Adapters that receive a home from the
+// flight controller set Lat, Lon, Alt and Source; the gateway fills in the
+// rest.
+type HomePosition struct {
+	Lat        float64 `json:"lat"`         // Latitude in degrees (WGS84)
+	Lon        float64 `json:"lon"`         // Longitude in degrees (WGS84)
+	Alt        float64 `json:"alt"`         // Altitude above mean sea level in meters, 0 if unknown
+	Source     string  `json:"source"`      // HomeSourceAutopilot or HomeSourceArmed
+	DistanceM  float64 `json:"distance_m"`  // Horizontal distance from the drone in meters
+	BearingDeg float64 `json:"bearing_deg"` // Direction from the drone to home in degrees from north (0-360)
+}
+
+// AutopilotInfo holds the firmware and hardware details a drone's flight
+// controller reports about itself
+type AutopilotInfo struct {
+	Autopilot         string             `json:"autopilot,omitempty"`          // Flight stack, e.g. ardupilot, px4
+	FirmwareVersion   string             `json:"firmware_version,omitempty"`   // e.g. "4.5.1" or "1.15.0-rc"
+	FirmwareGitHash   string             `json:"firmware_git_hash,omitempty"`  // Commit the firmware was built from
+	MiddlewareVersion string             `json:"middleware_version,omitempty"` // Version of the middleware, if any
+	OSVersion         string             `json:"os_version,omitempty"`         // Version of the flight controller OS, if any
+	BoardVersion      uint32             `json:"board_version,omitempty"`      // Board type and revision
+	VendorID          uint16             `json:"vendor_id,omitempty"`          // Board vendor (USB vendor ID)
+	ProductID         uint16             `json:"product_id,omitempty"`         // Board product (USB product ID)
+	HardwareUID       string             `json:"hardware_uid,omitempty"`       // Unique ID of the flight controller (hex)
+	Params            map[string]float64 `json:"params,omitempty"`             // Captured parameter values
+	UpdatedAt         int64              `json:"updated_at"`                   // Unix ms
+}
+
+// Mission is the mission plan loaded on a drone's flight controller
+type Mission struct {
+	Items     []MissionItem `json:"items"`                // Waypoints and commands in sequence order
+	Current   int           `json:"current"`              // Sequence number of the item being executed, -1 if unknown
+	MissionID uint32        `json:"mission_id,omitempty"` // Plan ID reported by the autopilot, if supported
+	UpdatedAt int64         `json:"updated_at"`           // Unix ms the plan was captured
+}
+
+// MissionItem is one waypoint or command of a mission
+type MissionItem struct {
+	Seq          int        `json:"seq"`
+	Command      string     `json:"command"`      // e.g. NAV_WAYPOINT, NAV_TAKEOFF
+	CommandID    int        `json:"command_id"`   // MAV_CMD value
+	Frame        string     `json:"frame"`        // e.g. GLOBAL_RELATIVE_ALT_INT
+	Lat          float64    `json:"lat"`          // Degrees in global frames, x in meters in local frames
+	Lon          float64    `json:"lon"`          // Degrees in global frames, y in meters in local frames
+	Alt          float64    `json:"alt"`          // Meters, reference depends on the frame
+	Params       [4]float64 `json:"params"`       // Command parameters 1-4, unset ones are 0
+	Autocontinue bool       `json:"autocontinue"` // Continues to the next item when done
+}
+
+// StatusText is a text message from a drone's autopilot, e.g. a pre-arm
+// check failure or an EKF warning
+type StatusText struct {
+	Severity  string `json:"severity"` // emergency, alert, critical, error, warning, notice, info or debug
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp"` // Unix ms
+}
+
+// LinkStatus is the state of an adapter's link to its drones, such as a
+// serial port, reported when it opens or is lost
+type LinkStatus struct {
+	Connected bool   `json:"connected"`
+	Endpoint  string `json:"endpoint"`        // e.g. serial device and baud rate
+	Error     string `json:"error,omitempty"` // Why the link was lost
+	Timestamp int64  `json:"timestamp"`       // Unix ms
+}
+
+// RawMessage is a protocol message passed through without conversion to
+// a DroneState
+type RawMessage struct {
+	DeviceID  string         `json:"device_id"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Name      string         `json:"name"`      // Protocol message name, e.g. STATUSTEXT
+	Timestamp int64          `json:"timestamp"` // Unix ms
+	Fields    map[string]any `json:"fields"`    // Message fields by protocol field name
+}
+
+// Location contains position information
+type Location struct {
+	Lat              float64 `json:"lat"`               // Latitude in degrees (WGS84)
+	Lon              float64 `json:"lon"`               // Longitude in degrees (WGS84)
+	AltBaro          float64 `json:"alt_baro"`          // Barometric altitude in meters
+	AltGNSS          float64 `json:"alt_gnss"`          // GNSS altitude in meters
+	CoordinateSystem string  `json:"coordinate_system"` // Primary coordinate system: WGS84
+
+	// Converted coordinates for China map services (optional)
+	LatGCJ02 *float64 `json:"lat_gcj02,omitempty"` // GCJ02 latitude (Amap, Tencent)
+	LonGCJ02 *float64 `json:"lon_gcj02,omitempty"` // GCJ02 longitude
+	LatBD09  *float64 `json:"lat_bd09,omitempty"`  // BD09 latitude (Baidu Maps)
+	LonBD09  *float64 `json:"lon_bd09,omitempty"`  // BD09 longitude
+}
+
+// Attitude contains orientation information
+type Attitude struct {
+	Roll  float64 `json:"roll"`  // Roll angle in radians
+	Pitch float64 `json:"pitch"` // Pitch angle in radians
+	Yaw   float64 `json:"yaw"`   // Yaw angle in degrees (0-360)
+}
+
+// Status contains system status information
+type Status struct {
+	BatteryPercent int        `json:"battery_percent"` // Battery level 0-100
+	FlightMode     FlightMode `json:"flight_mode"`     // Unified flight mode
+	Armed          bool       `json:"armed"`           // Whether motors are armed
+	SignalQuality  int        `json:"signal_quality"`  // Signal strength 0-100
+	BatterySerial  string     `json:"battery_serial,omitempty"` // Serial of the installed battery pack, if reported
+	PayloadID      string     `json:"payload_id,omitempty"`     // Identifier of the mounted payload, if reported
+
+	EstimatedEnduranceMin *float64 `json:"estimated_endurance_min,omitempty"` // Minutes until the battery is empty at the recent discharge rate, set by the gateway once known
+	SatellitesVisible     *int     `json:"satellites_visible,omitempty"`      // GNSS satellites in view, if reported
+}
+
+// Velocity contains velocity information
+type Velocity struct {
+	Vx float64 `json:"vx"` // Velocity in X (North) direction, m/s
+	Vy float64 `json:"vy"` // Velocity in Y (East) direction, m/s
+	Vz float64 `json:"vz"` // Velocity in Z (Down) direction, m/s
+}
+
+// FlightMode represents unified flight modes across different protocols
+type FlightMode string
+
+const (
+	FlightModeUnknown    FlightMode = "UNKNOWN"
+	FlightModeManual     FlightMode = "MANUAL"
+	FlightModeStabilize  FlightMode = "STABILIZE"
+	FlightModeAltHold    FlightMode = "ALT_HOLD"
+	FlightModeLoiter     FlightMode = "LOITER"  // Position hold
+	FlightModeAuto       FlightMode = "AUTO"    // Autonomous mission
+	FlightModeGuided     FlightMode = "GUIDED"  // External control
+	FlightModeRTL        FlightMode = "RTL"     // Return to launch
+	FlightModeLand       FlightMode = "LAND"    // Landing
+	FlightModeTakeoff    FlightMode = "TAKEOFF" // Taking off
+	FlightModeEmergency  FlightMode = "EMERGENCY"
+)
+
+// NewDroneState creates a new DroneState with default values
+func NewDroneState(deviceID string, protocolSource string) *DroneState {
+	return &DroneState{
+		DeviceID:       deviceID,
+		ProtocolSource: protocolSource,
+		Location: Location{
+			CoordinateSystem: "WGS84",
+		},
+		Status: Status{
+			FlightMode: FlightModeUnknown,
+		},
+		ReceivedAt: time.Now(),
+	}
+}
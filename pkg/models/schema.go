@@ -0,0 +1,12 @@
+package models
+
+import _ "embed"
+
+//go:embed drone_state.schema.json
+var droneStateSchema []byte
+
+// DroneStateSchema returns the JSON Schema of serialized DroneStates, at
+// version SchemaVersion
+func DroneStateSchema() []byte {
+	return droneStateSchema
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// schemaNode is the part of a JSON Schema the tests check
+type schemaNode struct {
+	ID                   string                 `json:"$id"`
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Pattern              string                 `json:"pattern"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	Items                *schemaNode            `json:"items"`
+	AdditionalProperties *schemaNode            `json:"additionalProperties"`
+	Defs                 map[string]*schemaNode `json:"$defs"`
+}
+
+func TestDroneStateSchema(t *testing.T) {
+	var root schemaNode
+	if err := json.Unmarshal(DroneStateSchema(), &root); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+	if !strings.HasSuffix(root.ID, ":"+SchemaVersion) {
+		t.Errorf("$id = %q, want version %s", root.ID, SchemaVersion)
+	}
+	if !regexp.MustCompile(root.Properties["schema_version"].Pattern).MatchString(SchemaVersion) {
+		t.Errorf("schema_version pattern does not accept %s", SchemaVersion)
+	}
+
+	resolve := func(n *schemaNode) *schemaNode {
+		if name, ok := strings.CutPrefix(n.Ref, "#/$defs/"); ok {
+			return root.Defs[name]
+		}
+		return n
+	}
+
+	// Every field of the model is described, and nothing else
+	var check func(path string, typ reflect.Type, node *schemaNode)
+	check = func(path string, typ reflect.Type, node *schemaNode) {
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		node = resolve(node)
+		switch typ.Kind() {
+		case reflect.Struct:
+			fields := map[string]reflect.Type{}
+			for i := 0; i < typ.NumField(); i++ {
+				name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+				if name != "-" {
+					fields[name] = typ.Field(i).Type
+				}
+			}
+			if typ == reflect.TypeOf(DroneState{}) {
+				fields["schema_version"] = reflect.TypeOf("")
+			}
+			var want, got []string
+			for name := range fields {
+				want = append(want, name)
+			}
+			for name := range node.Properties {
+				got = append(got, name)
+			}
+			sort.Strings(want)
+			sort.Strings(got)
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("%s: schema properties %v, model fields %v", path, got, want)
+				return
+			}
+			for name, field := range fields {
+				check(path+"."+name, field, node.Properties[name])
+			}
+			for _, name := range node.Required {
+				if _, ok := fields[name]; !ok {
+					t.Errorf("%s: required property %s is not a field", path, name)
+				}
+			}
+		case reflect.Slice:
+			check(path+"[]", typ.Elem(), node.Items)
+		case reflect.Map:
+			check(path+"{}", typ.Elem(), node.AdditionalProperties)
+		}
+	}
+	check("DroneState", reflect.TypeOf(DroneState{}), &root)
+}
+
+func TestDroneStateSchemaVersion(t *testing.T) {
+	for _, v := range []any{DroneState{DeviceID: "uav-001"}, &DroneState{DeviceID: "uav-001"}} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]any
+		json.Unmarshal(data, &fields)
+		if fields["schema_version"] != SchemaVersion || fields["device_id"] != "uav-001" {
+			t.Errorf("Marshal(%T) = %s, want schema_version %s and the state's fields", v, data, SchemaVersion)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package harness
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+	"github.com/open-uav/telemetry-bridge/pkg/sdk"
+)
+
+var (
+	_ sdk.Adapter   = (*Feed)(nil)
+	_ sdk.Publisher = (*Recorder)(nil)
+)
+
+// Feed is an adapter that emits the states passed to Send. Use it to drive
+// a publisher under test.
+type Feed struct {
+	name string
+
+	mu     sync.Mutex
+	ctx    context.Context
+	events chan<- *models.DroneState
+}
+
+// NewFeed creates a feed adapter
+func NewFeed(name string) *Feed {
+	return &Feed{name: name}
+}
+
+// Name returns the adapter name
+func (f *Feed) Name() string {
+	return f.name
+}
+
+// Start keeps the events channel for Send
+func (f *Feed) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ctx = ctx
+	f.events = events
+	return nil
+}
+
+// Stop is a no-op; Send fails once the harness context is cancelled
+func (f *Feed) Stop() error {
+	return nil
+}
+
+// Send passes a state to the engine. It blocks until the engine accepts the
+// state or the harness stops.
+func (f *Feed) Send(state *models.DroneState) error {
+	f.mu.Lock()
+	ctx, events := f.ctx, f.events
+	f.mu.Unlock()
+
+	if events == nil {
+		return ErrNotStarted
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case events <- state:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Recorder is a publisher that keeps a copy of every state it is given. Use
+// it to capture what an adapter under test produces.
+type Recorder struct {
+	name string
+
+	mu      sync.Mutex
+	states  []*models.DroneState
+	changed chan struct{} // Closed and replaced on every publish
+}
+
+// NewRecorder creates a recording publisher
+func NewRecorder(name string) *Recorder {
+	return &Recorder{name: name, changed: make(chan struct{})}
+}
+
+// Name returns the publisher name
+func (r *Recorder) Name() string {
+	return r.name
+}
+
+// Start is a no-op
+func (r *Recorder) Start(ctx context.Context) error {
+	return nil
+}
+
+// Publish records a copy of the state
+func (r *Recorder) Publish(state *models.DroneState) error {
+	cp := *state
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, &cp)
+	close(r.changed)
+	r.changed = make(chan struct{})
+	return nil
+}
+
+// Stop is a no-op
+func (r *Recorder) Stop() error {
+	return nil
+}
+
+// States returns the recorded states in publish order
+func (r *Recorder) States() []*models.DroneState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*models.DroneState(nil), r.states...)
+}
+
+// Wait blocks until at least n states have been recorded and returns them,
+// or returns ErrTimeout with the states recorded so far
+func (r *Recorder) Wait(n int, timeout time.Duration) ([]*models.DroneState, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		r.mu.Lock()
+		if len(r.states) >= n {
+			states := append([]*models.DroneState(nil), r.states...)
+			r.mu.Unlock()
+			return states, nil
+		}
+		changed := r.changed
+		r.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return r.States(), ErrTimeout
+		}
+	}
+}
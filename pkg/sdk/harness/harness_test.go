@@ -0,0 +1,146 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// pulseAdapter is an adapter as a third party would write it: it emits
+// one state per device when started
+type pulseAdapter struct {
+	devices []string
+}
+
+func (a *pulseAdapter) Name() string { return "pulse" }
+func (a *pulseAdapter) Stop() error  { return nil }
+
+func (a *pulseAdapter) Start(ctx context.Context, events chan<- *models.DroneState) error {
+	go func() {
+		for _, id := range a.devices {
+			state := models.NewDroneState(id, "pulse")
+			state.Location.Lat = 39.9
+			state.Location.Lon = 116.4
+			select {
+			case events <- state:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func TestHarness_AdapterToRecorder(t *testing.T) {
+	h := New(Config{ConvertGCJ02: true})
+	rec := NewRecorder("recorder")
+	if err := h.AddAdapter(&pulseAdapter{devices: []string{"a", "b", "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AddPublisher(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer h.Stop()
+
+	states, err := rec.Wait(3, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Wait() error = %v (got %d states)", err, len(states))
+	}
+	for _, s := range states {
+		if s.ProtocolSource != "pulse" {
+			t.Errorf("ProtocolSource = %q, want pulse", s.ProtocolSource)
+		}
+		if s.Location.LatGCJ02 == nil || s.Location.LonGCJ02 == nil {
+			t.Errorf("device %s: GCJ02 coordinates not filled in", s.DeviceID)
+		}
+	}
+	if got := len(h.States()); got != 3 {
+		t.Errorf("States() returned %d devices, want 3", got)
+	}
+	if h.State("b") == nil {
+		t.Error("State(b) = nil")
+	}
+}
+
+func TestHarness_FeedToPublisher(t *testing.T) {
+	h := New(Config{})
+	feed := NewFeed("feed")
+	rec := NewRecorder("publisher-under-test")
+	h.AddAdapter(feed)
+	h.AddPublisher(rec)
+
+	if err := feed.Send(models.NewDroneState("early", "feed")); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Send() before Start error = %v, want ErrNotStarted", err)
+	}
+
+	if err := h.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	state := models.NewDroneState("drone-001", "feed")
+	state.Status.BatteryPercent = 80
+	if err := feed.Send(state); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	states, err := rec.Wait(1, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if states[0].DeviceID != "drone-001" || states[0].Status.BatteryPercent != 80 {
+		t.Errorf("recorded state = %+v", states[0])
+	}
+
+	if err := h.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := feed.Send(state); err == nil {
+		t.Error("Send() after Stop should fail")
+	}
+	if err := h.Stop(); err != nil {
+		t.Errorf("second Stop() error = %v", err)
+	}
+}
+
+func TestHarness_Throttle(t *testing.T) {
+	h := New(Config{RateHz: 1})
+	feed := NewFeed("feed")
+	rec := NewRecorder("recorder")
+	h.AddAdapter(feed)
+	h.AddPublisher(rec)
+	if err := h.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer h.Stop()
+
+	for i := 0; i < 5; i++ {
+		feed.Send(models.NewDroneState("drone-001", "feed"))
+	}
+	if states, err := rec.Wait(2, 200*time.Millisecond); !errors.Is(err, ErrTimeout) || len(states) != 1 {
+		t.Errorf("Wait() = %d states, %v; want 1 state and ErrTimeout", len(states), err)
+	}
+}
+
+func TestHarness_AddAfterStart(t *testing.T) {
+	h := New(Config{})
+	if err := h.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer h.Stop()
+
+	if err := h.AddAdapter(NewFeed("late")); !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("AddAdapter() error = %v, want ErrAlreadyStarted", err)
+	}
+	if err := h.AddPublisher(NewRecorder("late")); !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("AddPublisher() error = %v, want ErrAlreadyStarted", err)
+	}
+	if err := h.Start(context.Background()); !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("second Start() error = %v, want ErrAlreadyStarted", err)
+	}
+}
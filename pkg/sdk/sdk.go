@@ -0,0 +1,97 @@
+// Package sdk is the public extension API of the bridge. Third-party
+// southbound adapters and northbound publishers implement Adapter and
+// Publisher against the types in pkg/models and can be compiled without
+// importing any internal package; pkg/sdk/harness runs them through the
+// bridge engine for testing.
+//
+// The packages under pkg/ follow semantic versioning independently of the
+// bridge release: Version is bumped on every change, and breaking changes
+// to pkg/ only happen with a new major version.
+package sdk
+
+import (
+	"context"
+
+	"github.com/open-uav/telemetry-bridge/pkg/models"
+)
+
+// Version is the semantic version of the public API under pkg/
+const Version = "1.13.0"
+
+// Adapter is the interface that all southbound protocol adapters must implement
+type Adapter interface {
+	// Name returns the adapter name (e.g., "mavlink", "dji", "gb28181")
+	Name() string
+
+	// Start begins receiving data and sends DroneState events to the channel
+	// The adapter should respect context cancellation for graceful shutdown
+	Start(ctx context.Context, events chan<- *models.DroneState) error
+
+	// Stop gracefully stops the adapter
+	Stop() error
+}
+
+// Publisher is the interface that all northbound publishers must implement
+type Publisher interface {
+	// Name returns the publisher name (e.g., "mqtt", "websocket", "http")
+	Name() string
+
+	// Start initializes the publisher and prepares for publishing
+	// The publisher should respect context cancellation for graceful shutdown
+	Start(ctx context.Context) error
+
+	// Publish sends a DroneState to the destination
+	Publish(state *models.DroneState) error
+
+	// Stop gracefully stops the publisher
+	Stop() error
+}
+
+// Connectable is implemented by publishers that expose their connection
+// state. The engine uses it to report a publisher as degraded when it stays
+// disconnected.
+type Connectable interface {
+	IsConnected() bool
+}
+
+// HealthReporter is implemented by publishers that report the state of
+// their protocol session, such as an MQTT broker connection or a GB28181
+// registration. The bridge adds it to the publisher health in
+// /api/v1/status.
+type HealthReporter interface {
+	Health() PublisherDetail
+}
+
+// PublisherDetail is the protocol-level health of a publisher
+type PublisherDetail struct {
+	State       string            `json:"state"`                   // Protocol state, e.g. "connected", "reconnecting" or "registered"
+	Endpoint    string            `json:"endpoint,omitempty"`      // Broker or server the publisher talks to
+	LastError   string            `json:"last_error,omitempty"`    // Most recent connection or registration error
+	LastErrorAt int64             `json:"last_error_at,omitempty"` // Unix ms of LastError
+	Details     map[string]string `json:"details,omitempty"`       // Protocol-specific values
+}
+
+// BatchPublisher is implemented by publishers that send several states more
+// efficiently in one call than one at a time. When batching is enabled the
+// engine collects states for such publishers and calls PublishBatch instead
+// of Publish.
+type BatchPublisher interface {
+	PublishBatch(states []*models.DroneState) error
+}
+
+// StatsReporter is implemented by adapters that count the traffic they
+// receive. The bridge serves the counters at
+// /api/v1/adapters/{name}/stats.
+type StatsReporter interface {
+	Stats() AdapterStats
+}
+
+// AdapterStats are the traffic counters of an adapter since it was created
+type AdapterStats struct {
+	MessagesReceived uint64  `json:"messages_received"`         // Including those that failed to parse
+	ParseErrors      uint64  `json:"parse_errors"`              // Messages that could not be decoded
+	ConnectedPeers   int     `json:"connected_peers"`           // Open connections or links, 0 for connectionless sources
+	BytesReceived    uint64  `json:"bytes_received"`            // Total bytes received
+	BytesPerSec      float64 `json:"bytes_per_sec"`             // Receive rate over the last few seconds
+	LastMessageAt    int64   `json:"last_message_at,omitempty"` // Unix ms of the last message, 0 if none
+}
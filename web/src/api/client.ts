@@ -2,9 +2,12 @@
 
 import type {
   StatusResponse,
+  AdapterStats,
   DronesResponse,
   DroneState,
   TrackResponse,
+  FlightsResponse,
+  DroneEventsResponse,
   LoginRequest,
   LoginResponse,
   AuthStatusResponse,
@@ -16,9 +19,17 @@ import type {
   TrackConfig,
   LogsResponse,
   LogLevel,
+  LogLevels,
+  LogStats,
   AlertsResponse,
   AlertRulesResponse,
+  BulkAckRequest,
+  BulkAckResponse,
   AlertRule,
+  AlertFieldsResponse,
+  AlertRuleTemplatesResponse,
+  TemplateRuleRequest,
+  ImportRulesResponse,
   Geofence,
   GeofencesResponse,
   BreachesResponse,
@@ -33,7 +44,37 @@ function getAuthToken(): string | null {
   return useAuthStore.getState().token;
 }
 
-async function fetchAPI<T>(endpoint: string, options?: RequestInit): Promise<T> {
+// Pending refresh, shared by requests failing at the same time
+let refreshing: Promise<boolean> | null = null;
+
+// Exchange the refresh token for a new token pair. Refresh tokens are
+// single-use, so concurrent callers wait for one refresh.
+function refreshSession(): Promise<boolean> {
+  const { refreshToken } = useAuthStore.getState();
+  if (!refreshToken) return Promise.resolve(false);
+  if (!refreshing) {
+    refreshing = fetch(`${API_BASE}/auth/refresh`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: refreshToken }),
+    })
+      .then(async (response) => {
+        if (!response.ok) return false;
+        const data: LoginResponse = await response.json();
+        useAuthStore
+          .getState()
+          .setAuth(data.token, data.user, data.expires_at, data.refresh_token, data.refresh_expires_at);
+        return true;
+      })
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+}
+
+async function fetchAPI<T>(endpoint: string, options?: RequestInit, retry = true): Promise<T> {
   const token = getAuthToken();
   const headers: Record<string, string> = {
     'Content-Type': 'application/json',
@@ -50,7 +91,10 @@ async function fetchAPI<T>(endpoint: string, options?: RequestInit): Promise<T>
     headers,
   });
 
-  // Handle 401 Unauthorized - logout user
+  // Handle 401 Unauthorized - renew the access token once, then logout user
+  if (response.status === 401 && retry && !endpoint.startsWith('/auth/') && (await refreshSession())) {
+    return fetchAPI<T>(endpoint, options, false);
+  }
   if (response.status === 401) {
     const authEnabled = useAuthStore.getState().authEnabled;
     if (authEnabled) {
@@ -75,9 +119,12 @@ export const api = {
     });
   },
 
+  // Revokes the session on the server
   logout: (): Promise<{ message: string }> => {
+    const refreshToken = useAuthStore.getState().refreshToken;
     return fetchAPI<{ message: string }>('/auth/logout', {
       method: 'POST',
+      body: JSON.stringify({ refresh_token: refreshToken ?? '' }),
     });
   },
 
@@ -90,6 +137,11 @@ export const api = {
     return fetchAPI<StatusResponse>('/status');
   },
 
+  // Get traffic counters of an adapter
+  getAdapterStats: (name: string): Promise<AdapterStats> => {
+    return fetchAPI<AdapterStats>(`/adapters/${encodeURIComponent(name)}/stats`);
+  },
+
   // Get all drones
   getDrones: (): Promise<DronesResponse> => {
     return fetchAPI<DronesResponse>('/drones');
@@ -101,14 +153,26 @@ export const api = {
   },
 
   // Get drone track history
-  getTrack: (deviceId: string, limit?: number, since?: number): Promise<TrackResponse> => {
+  getTrack: (deviceId: string, limit?: number, since?: number, maxPoints?: number): Promise<TrackResponse> => {
     const params = new URLSearchParams();
     if (limit !== undefined) params.set('limit', String(limit));
     if (since !== undefined) params.set('since', String(since));
+    if (maxPoints !== undefined) params.set('max_points', String(maxPoints));
     const query = params.toString();
     return fetchAPI<TrackResponse>(`/drones/${encodeURIComponent(deviceId)}/track${query ? `?${query}` : ''}`);
   },
 
+  // Get flights segmented from the drone's track, with statistics
+  getFlights: (deviceId: string): Promise<FlightsResponse> => {
+    return fetchAPI<FlightsResponse>(`/drones/${encodeURIComponent(deviceId)}/flights`);
+  },
+
+  // Get flight events (takeoff, landing, mode changes...), newest first
+  getDroneEvents: (deviceId: string, limit?: number): Promise<DroneEventsResponse> => {
+    const query = limit !== undefined ? `?limit=${limit}` : '';
+    return fetchAPI<DroneEventsResponse>(`/drones/${encodeURIComponent(deviceId)}/events${query}`);
+  },
+
   // Clear drone track
   clearTrack: (deviceId: string): Promise<void> => {
     return fetchAPI<void>(`/drones/${encodeURIComponent(deviceId)}/track`, {
@@ -222,6 +286,22 @@ export const api = {
     });
   },
 
+  getLogStats: (): Promise<LogStats> => {
+    return fetchAPI<LogStats>('/logs/stats');
+  },
+
+  getLogLevels: (): Promise<LogLevels> => {
+    return fetchAPI<LogLevels>('/logs/levels');
+  },
+
+  // Sources, when given, replace all per-source overrides
+  updateLogLevels: (levels: Partial<LogLevels>): Promise<LogLevels> => {
+    return fetchAPI<LogLevels>('/logs/levels', {
+      method: 'PUT',
+      body: JSON.stringify(levels),
+    });
+  },
+
   // Get log stream URL with auth token
   getLogStreamUrl: (level?: LogLevel): string => {
     const token = getAuthToken();
@@ -248,6 +328,13 @@ export const api = {
     });
   },
 
+  acknowledgeAlerts: (req: BulkAckRequest): Promise<BulkAckResponse> => {
+    return fetchAPI<BulkAckResponse>('/alerts/ack', {
+      method: 'POST',
+      body: JSON.stringify(req),
+    });
+  },
+
   clearAlerts: (): Promise<{ message: string }> => {
     return fetchAPI<{ message: string }>('/alerts', {
       method: 'DELETE',
@@ -258,6 +345,10 @@ export const api = {
     return fetchAPI<AlertRulesResponse>('/alerts/rules');
   },
 
+  getAlertFields: (): Promise<AlertFieldsResponse> => {
+    return fetchAPI<AlertFieldsResponse>('/alerts/fields');
+  },
+
   createAlertRule: (rule: Partial<AlertRule>): Promise<AlertRule> => {
     return fetchAPI<AlertRule>('/alerts/rules', {
       method: 'POST',
@@ -278,6 +369,41 @@ export const api = {
     });
   },
 
+  getAlertRuleTemplates: (): Promise<AlertRuleTemplatesResponse> => {
+    return fetchAPI<AlertRuleTemplatesResponse>('/alerts/rules/templates');
+  },
+
+  createAlertRuleFromTemplate: (req: TemplateRuleRequest): Promise<AlertRule> => {
+    return fetchAPI<AlertRule>('/alerts/rules/from-template', {
+      method: 'POST',
+      body: JSON.stringify(req),
+    });
+  },
+
+  exportAlertRules: async (): Promise<string> => {
+    const token = getAuthToken();
+    const headers: Record<string, string> = {};
+    if (token) {
+      headers['Authorization'] = `Bearer ${token}`;
+    }
+
+    const response = await fetch(`${API_BASE}/alerts/rules/export`, { headers });
+
+    if (!response.ok) {
+      throw new Error('Failed to export alert rules');
+    }
+
+    return response.text();
+  },
+
+  importAlertRules: (yaml: string, replace = false): Promise<ImportRulesResponse> => {
+    return fetchAPI<ImportRulesResponse>(`/alerts/rules/import${replace ? '?replace=true' : ''}`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/x-yaml' },
+      body: yaml,
+    });
+  },
+
   // Geofences
   getGeofences: (): Promise<GeofencesResponse> => {
     return fetchAPI<GeofencesResponse>('/geofences');
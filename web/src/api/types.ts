@@ -4,6 +4,7 @@
 export interface User {
   username: string;
   role: string;
+  tenant?: string;
 }
 
 export interface LoginRequest {
@@ -14,9 +15,16 @@ export interface LoginRequest {
 export interface LoginResponse {
   token: string;
   expires_at: number;
+  refresh_token: string;
+  refresh_expires_at: number; // End of the session
+  session_id: string;
   user: User;
 }
 
+export interface RefreshRequest {
+  refresh_token: string;
+}
+
 export interface AuthStatusResponse {
   auth_enabled: boolean;
   user?: User;
@@ -58,16 +66,163 @@ export interface Status {
   battery_voltage: number;
   gps_fix_type: number;
   satellites_visible: number;
+  battery_serial?: string;
+  payload_id?: string;
+  estimated_endurance_min?: number;
+  satellites_visible?: number;
 }
 
 export interface DroneState {
+  schema_version?: string; // Version of the JSON Schema the state conforms to
   device_id: string;
   timestamp: number;
   protocol_source: string;
+  tenant?: string;
   location: Location;
   attitude: Attitude;
   velocity: Velocity;
   status: Status;
+  freshness: Freshness;
+  age_ms?: number; // Set in REST and GraphQL responses
+  metadata?: DeviceMetadata;
+  home?: HomePosition;
+  sources?: SourceInfo[]; // Set for devices merged by aliases
+  extra?: Record<string, string>; // Fields set by enrichment processors
+}
+
+// Device ID and protocol a merged device was reported under
+export interface SourceInfo {
+  device_id: string;
+  protocol_source: string;
+  last_seen: number;
+}
+
+// Maps a device ID reported by a source to a canonical device
+export interface DeviceAlias {
+  id: string;
+  canonical: string;
+  source?: string;
+  updated_at: number;
+}
+
+export interface AliasesResponse {
+  count: number;
+  aliases: DeviceAlias[];
+}
+
+// When the source last updated each part of a state (Unix ms)
+export interface Freshness {
+  position_at?: number;
+  attitude_at?: number;
+  battery_at?: number;
+}
+
+// Launch point reported by the autopilot, or the first fix after arming,
+// with the drone's distance and bearing to it
+export interface HomePosition {
+  lat: number;
+  lon: number;
+  alt: number;
+  source: 'autopilot' | 'armed';
+  distance_m: number;
+  bearing_deg: number;
+}
+
+export interface DeviceMetadata {
+  name?: string;
+  airframe?: string;
+  serial?: string;
+  operator?: string;
+  tags?: string[];
+}
+
+// Reported by the flight controller (MAVLink AUTOPILOT_VERSION, PARAM_VALUE)
+export interface AutopilotInfo {
+  autopilot?: string; // ardupilot, px4, ...
+  firmware_version?: string;
+  firmware_git_hash?: string;
+  middleware_version?: string;
+  os_version?: string;
+  board_version?: number;
+  vendor_id?: number;
+  product_id?: number;
+  hardware_uid?: string;
+  params?: Record<string, number>;
+  updated_at: number;
+}
+
+export interface DroneMetadataResponse {
+  device_id: string;
+  registered?: DeviceMetadata;
+  autopilot?: AutopilotInfo;
+}
+
+// Mission plan captured from the flight controller (MAVLink mission protocol)
+export interface MissionItem {
+  seq: number;
+  command: string; // NAV_WAYPOINT, NAV_TAKEOFF, ...
+  command_id: number;
+  frame: string; // GLOBAL_RELATIVE_ALT_INT, ...
+  lat: number;
+  lon: number;
+  alt: number;
+  params: [number, number, number, number];
+  autocontinue: boolean;
+}
+
+export interface Mission {
+  items: MissionItem[];
+  current: number; // -1 if unknown
+  mission_id?: number;
+  updated_at: number;
+}
+
+export interface DroneMissionResponse {
+  device_id: string;
+  mission: Mission;
+}
+
+// Text message from the autopilot (MAVLink STATUSTEXT)
+export interface StatusText {
+  severity: 'emergency' | 'alert' | 'critical' | 'error' | 'warning' | 'notice' | 'info' | 'debug';
+  text: string;
+  timestamp: number;
+}
+
+export interface DroneStatusTextResponse {
+  device_id: string;
+  count: number;
+  messages: StatusText[];
+}
+
+// Flight event derived from state transitions
+export type FlightEventType =
+  | 'armed'
+  | 'disarmed'
+  | 'takeoff'
+  | 'landing'
+  | 'mode_change'
+  | 'rtl'
+  | 'gps_lost'
+  | 'gps_restored';
+
+export interface FlightEvent {
+  id: string;
+  device_id: string;
+  type: FlightEventType;
+  message: string;
+  previous?: string; // Flight mode before a mode change or RTL
+  current?: string;
+  lat?: number; // Last known position
+  lon?: number;
+  alt_m?: number; // Height above home
+  timestamp: number;
+}
+
+export interface DroneEventsResponse {
+  device_id: string;
+  count: number;
+  events: FlightEvent[]; // Newest first
 }
 
 export interface TrackPoint {
@@ -87,11 +242,78 @@ export interface AdapterStatus {
   enabled: boolean;
 }
 
+// Traffic counters of an adapter, from GET /adapters/{name}/stats
+export interface AdapterStats {
+  adapter: string;
+  messages_received: number;
+  parse_errors: number;
+  connected_peers: number;
+  bytes_received: number;
+  bytes_per_sec: number;
+  last_message_at?: number; // Unix ms
+}
+
+// One stage of the pipeline: an adapter's intake, the shared event queue
+// or a publisher's batch
+export interface TrackStats {
+  devices: number;
+  points: number;
+  memory_bytes: number;
+  max_bytes?: number;
+  downsample_level?: number;
+}
+
+export interface QueueStats {
+  stage: string;
+  policy?: 'drop_newest' | 'drop_oldest' | 'block';
+  capacity?: number;
+  depth: number;
+  high_water: number;
+  received: number;
+  dropped: number;
+  blocked: number;
+}
+
+// Repeated states dropped for a protocol source (dedup config)
+export interface DedupStats {
+  source: string;
+  checked: number;
+  dropped: number;
+}
+
+// Protocol state of a publisher, e.g. its broker connection or SIP
+// registration
+export interface PublisherDetail {
+  state: string;
+  endpoint?: string;
+  last_error?: string;
+  last_error_at?: number;
+  details?: Record<string, string>;
+}
+
+export interface PublisherHealth {
+  name: string;
+  status: 'unknown' | 'healthy' | 'degraded';
+  connected?: boolean;
+  last_success?: number;
+  last_error?: string;
+  last_error_at?: number;
+  consecutive_errors: number;
+  total_published: number;
+  total_errors: number;
+  reason?: string;
+  detail?: PublisherDetail;
+}
+
 export interface StatusResponse {
   version: string;
   uptime_seconds: number;
   adapters: AdapterStatus[];
   publishers: string[];
+  publisher_health?: PublisherHealth[];
+  queues?: QueueStats[];
+  dedup?: DedupStats[];
+  tracks?: TrackStats;
   stats: Stats;
 }
 
@@ -105,6 +327,32 @@ export interface TrackResponse {
   count: number;
   points: TrackPoint[];
   total_size: number;
+  decimated?: boolean;
+  datum: Datum;              // Datum of the point positions (?datum=)
+}
+
+// Flight segmented from a drone's states: from arming to disarming, or
+// from moving to standing still for sources that don't report arming
+export interface Flight {
+  id: string;
+  device_id: string;
+  segmentation: 'armed' | 'motion';
+  start_time: number;
+  end_time: number;
+  in_progress: boolean;
+  duration_sec: number;
+  distance_m: number;
+  max_alt_m: number;
+  max_speed_ms: number;
+  battery_start: number;
+  battery_end: number;
+  battery_used: number;
+}
+
+export interface FlightsResponse {
+  device_id: string;
+  count: number;
+  flights: Flight[];
 }
 
 export interface ErrorResponse {
@@ -113,12 +361,45 @@ export interface ErrorResponse {
 }
 
 // WebSocket message types
-export type WSMessageType = 'state_update' | 'drone_online' | 'drone_offline';
+export type WSMessageType =
+  | 'state_update'
+  | 'state_delta'
+  | 'drone_online'
+  | 'drone_offline'
+  | 'subscribe_ack'
+  | 'mission_changed'
+  | 'alerts_acknowledged'
+  | 'adapter_status'
+  | 'flight_event'
+  | 'auth_ack'
+  | 'error';
 
 export interface WSMessage {
   type: WSMessageType;
   device_id?: string;
-  data?: DroneState;
+  data?: DroneState | Mission | AlertsAcked | AdapterLink | FlightEvent | WSAuthAck | WSError;
+}
+
+// Credentials of an auth message, sent first when the server requires it
+export interface WSAuth {
+  token?: string;
+  api_key?: string;
+}
+
+export interface WSAuthAck {
+  username: string;
+  role: string;
+  tenant?: string;
+}
+
+export interface WSError {
+  message: string;
+}
+
+// Optional encoding settings of a subscribe message
+export interface WSSubscribeOptions {
+  delta?: boolean;
+  compress?: boolean;
 }
 
 // Configuration Types
@@ -181,6 +462,7 @@ export interface AuthConfig {
   has_password_hash: boolean;
   has_jwt_secret: boolean;
   token_expiry_hours: number;
+  access_token_minutes: number;
 }
 
 export interface HTTPConfig {
@@ -221,6 +503,19 @@ export interface AppConfig {
   track: TrackConfig;
 }
 
+// A problem with one config key, e.g. mqtt.topic_template
+export interface ConfigFieldError {
+  field: string;
+  message: string;
+}
+
+// Response of POST /api/v1/config/validate
+export interface ConfigValidationResponse {
+  valid: boolean;
+  errors: ConfigFieldError[];
+  warnings?: ConfigFieldError[]; // Unreachable endpoints, with probe=true
+}
+
 // Log Types
 export type LogLevel = 'debug' | 'info' | 'warn' | 'error';
 
@@ -238,13 +533,31 @@ export interface LogsResponse {
   total: number;
 }
 
+// Default log level and per-source overrides (GET/PUT /logs/levels)
+export interface LogLevels {
+  default: LogLevel;
+  sources: Record<string, LogLevel>;
+}
+
+// Entries a source logged per level, and how many its level suppressed
+export interface LogCounts {
+  levels: Partial<Record<LogLevel, number>>;
+  suppressed: number;
+}
+
+export interface LogStats {
+  levels: Partial<Record<LogLevel, number>>;
+  suppressed: number;
+  sources: Record<string, LogCounts>;
+}
+
 export interface LogStreamEvent {
   type: 'connected' | 'log' | 'ping';
   data: LogEntry | { subscriber_id: string } | { time: number };
 }
 
 // Alert Types
-export type AlertType = 'battery_low' | 'connection_lost' | 'signal_weak' | 'geofence_breach' | 'custom';
+export type AlertType = 'battery_low' | 'connection_lost' | 'signal_weak' | 'geofence_breach' | 'geofence_predicted' | 'geofence_dwell' | 'custom';
 export type AlertSeverity = 'info' | 'warning' | 'critical';
 
 export interface Alert {
@@ -260,6 +573,9 @@ export interface Alert {
   acknowledged: boolean;
   acked_at?: number;
   acked_by?: string;
+  escalations?: string[]; // IDs of the escalation policies that fired
+  escalated_at?: number;
+  channels?: string[]; // Notification channels the alert was sent to
 }
 
 export interface AlertCondition {
@@ -276,8 +592,13 @@ export interface AlertRule {
   enabled: boolean;
   condition: AlertCondition;
   cooldown_ms: number;
+  channels?: string[]; // Notification channels alerts are sent to
   created_at: number;
   updated_at: number;
+  // Scope; a rule without one applies to every device
+  device_ids?: string[];
+  device_pattern?: string; // Glob of device IDs, e.g. "fw-*"
+  protocol_source?: string;
 }
 
 export interface AlertsResponse {
@@ -291,14 +612,208 @@ export interface AlertsResponse {
   };
 }
 
+// Alerts acknowledged by any dashboard, sent over WebSocket
+export interface AlertsAcked {
+  ids: string[];
+  acked_by: string;
+  acked_at: number;
+}
+
+// An adapter's link to its drones opened or was lost, sent over WebSocket
+export interface AdapterLink {
+  adapter: string;
+  connected: boolean;
+  endpoint: string; // e.g. serial device and baud rate
+  error?: string;
+  timestamp: number;
+}
+
+// Either ids or a device_id/before filter
+export interface BulkAckRequest {
+  ids?: string[];
+  device_id?: string;
+  before?: number; // Alerts raised before this Unix ms
+}
+
+export interface BulkAckResponse {
+  acknowledged: number;
+  ids: string[];
+  not_found?: string[];
+}
+
 export interface AlertRulesResponse {
   rules: AlertRule[];
   count: number;
 }
 
+// Field a rule condition can compare, e.g. ground_speed in m/s
+export interface AlertField {
+  name: string;
+  unit?: string;
+  description: string;
+}
+
+export interface AlertFieldsResponse {
+  fields: AlertField[];
+  count: number;
+}
+
+// Preset rule for a common check, e.g. battery or altitude ceiling
+export interface AlertRuleTemplate {
+  id: string;
+  description: string;
+  rule: AlertRule;
+}
+
+export interface AlertRuleTemplatesResponse {
+  templates: AlertRuleTemplate[];
+  count: number;
+}
+
+// Creates a rule from a template; set fields override the template's
+export interface TemplateRuleRequest {
+  template: string;
+  name?: string;
+  severity?: AlertSeverity;
+  threshold?: number;
+  cooldown_ms?: number;
+  channels?: string[];
+  device_ids?: string[];
+  device_pattern?: string;
+  protocol_source?: string;
+}
+
+export interface ImportRulesResponse {
+  created: number;
+  updated: number;
+  deleted: number;
+  count: number;
+}
+
+export interface EscalationPolicy {
+  id: string;
+  name: string;
+  enabled: boolean;
+  min_severity: AlertSeverity;
+  types?: AlertType[];
+  after_ms: number;
+  channels: string[];
+  bump_severity: boolean;
+  created_at: number;
+  updated_at: number;
+}
+
+export interface EscalationPoliciesResponse {
+  policies: EscalationPolicy[];
+  channels: string[]; // Configured notification channels
+  count: number;
+}
+
+export interface AlertSilence {
+  id: string;
+  device_pattern: string; // Glob of device IDs, empty for all devices
+  rule_ids?: string[]; // Empty silences all alerts of the devices
+  starts_at: number;
+  ends_at: number;
+  comment?: string;
+  created_by?: string;
+  created_at: number;
+}
+
+export interface AlertSilencesResponse {
+  silences: AlertSilence[];
+  count: number;
+}
+
+// Scheduled Job Types
+export interface JobRun {
+  job: string;
+  trigger: 'schedule' | 'manual';
+  started_at: number;
+  duration_ms: number;
+  result?: string;
+  error?: string;
+}
+
+export interface Job {
+  name: string;
+  description: string;
+  schedule: string; // Cron expression or "@every <duration>"
+  enabled: boolean;
+  running: boolean;
+  next_run?: number;
+  last_run?: JobRun;
+}
+
+export interface JobsResponse {
+  count: number;
+  jobs: Job[];
+}
+
+export interface JobResponse extends Job {
+  history: JobRun[]; // Newest first
+}
+
+// Incident Types (correlated alerts and link events per device)
+export type IncidentStatus = 'open' | 'resolved';
+
+export interface IncidentEvent {
+  kind: string; // Alert type, link_lost or link_restored
+  alert_id?: string;
+  severity: AlertSeverity;
+  message: string;
+  timestamp: number;
+}
+
+export interface Incident {
+  id: string;
+  device_id: string;
+  status: IncidentStatus;
+  severity: AlertSeverity;
+  root_cause: string;
+  summary: string;
+  started_at: number;
+  updated_at: number;
+  resolved_at?: number;
+  events: IncidentEvent[];
+}
+
+export interface IncidentsResponse {
+  count: number;
+  incidents: Incident[];
+}
+
+// Audit Types
+export type AuditAction = 'create' | 'update' | 'delete' | 'apply';
+
+export interface AuditChange {
+  field: string;
+  before: unknown;
+  after: unknown;
+}
+
+export interface AuditEntry {
+  id: string;
+  timestamp: number;
+  actor: string;
+  role?: string;
+  tenant?: string;
+  remote_addr?: string;
+  action: AuditAction;
+  resource: string;
+  resource_id?: string;
+  changes?: AuditChange[];
+}
+
+export interface AuditResponse {
+  count: number;
+  entries: AuditEntry[];
+}
+
 // Geofence Types
 export type GeofenceType = 'polygon' | 'circle';
-export type BreachType = 'enter' | 'exit';
+export type Datum = 'wgs84' | 'gcj02' | 'bd09';
+export type BreachType = 'enter' | 'exit' | 'dwell';
 
 export interface Geofence {
   id: string;
@@ -314,6 +829,39 @@ export interface Geofence {
   enabled: boolean;
   created_at: number;
   updated_at: number;
+  severity: AlertSeverity;   // Severity of breach alerts
+  channels?: string[];       // Notification channels breach alerts are sent to
+  dwell_inside_sec?: number; // Alert when a drone stays inside longer (0 = off)
+  dwell_outside_sec?: number; // Alert when a drone stays outside longer (0 = off)
+  datum?: Datum;             // Datum of coordinates and center (default wgs84)
+  group?: string;            // ID of the group the fence belongs to
+}
+
+export interface GeofenceGroup {
+  id: string;
+  name: string;
+  description?: string;
+  parent?: string;   // ID of the enclosing group
+  enabled: boolean;  // Disabling deactivates the fences of the group and its subgroups
+  tenant?: string;
+  created_at: number;
+  updated_at: number;
+}
+
+export interface GeofenceGroupsResponse {
+  groups: GeofenceGroup[];
+  count: number;
+}
+
+export interface GeofenceGroupStats {
+  group_id: string;
+  subgroups: number;
+  total_geofences: number;
+  active_geofences: number; // Enabled, in enabled groups only
+  breaches: number;
+  breaches_by_type: Partial<Record<BreachType, number>>;
+  devices_inside: number;
+  last_breach_at?: number;
 }
 
 export interface GeofenceBreach {
@@ -325,6 +873,14 @@ export interface GeofenceBreach {
   lon: number;
   alt: number;
   timestamp: number;
+  geofence_name: string;
+  severity: AlertSeverity;
+  channels?: string[];
+  group_id?: string;
+  predicted?: boolean; // Projected from the current velocity
+  eta_sec?: number;
+  inside?: boolean; // Dwell breaches: stayed inside rather than outside
+  dwell_sec?: number;
 }
 
 export interface GeofencesResponse {
@@ -340,6 +896,42 @@ export interface BreachesResponse {
 export interface GeofenceStats {
   total_geofences: number;
   enabled_geofences: number;
+  total_groups: number;
   total_breaches: number;
   tracked_devices: number;
 }
+
+export interface CoverageCell {
+  lat: number;
+  lon: number;
+  bounds: { min_lat: number; min_lon: number; max_lat: number; max_lon: number };
+  samples: number;
+  avg_quality: number;
+  min_quality: number;
+  max_quality: number;
+  first_seen: number;
+  last_seen: number;
+}
+
+export interface CoverageResponse {
+  cell_size_m: number;
+  count: number;
+  cells: CoverageCell[];
+}
+
+export interface OccupancyCell {
+  lat: number;
+  lon: number;
+  bounds: { min_lat: number; min_lon: number; max_lat: number; max_lon: number };
+  points: number;
+  devices: number; // Distinct drones with points in the cell
+  first_seen: number;
+  last_seen: number;
+}
+
+export interface HeatmapResponse {
+  cell_size_m: number;
+  points: number;
+  count: number;
+  cells: OccupancyCell[]; // Busiest first
+}
@@ -84,6 +84,28 @@ export function DroneDetail({ drone, onClose }: DroneDetailProps) {
         )}
       </section>
 
+      {/* Home Section */}
+      {drone.home && (
+        <section className="mb-6">
+          <h3 className="text-sm font-semibold text-gray-400 uppercase tracking-wider mb-3">
+            Home
+          </h3>
+          <div className="grid grid-cols-2 gap-4">
+            <div className="bg-gray-900 rounded-lg p-3">
+              <p className="text-xs text-gray-500">Distance</p>
+              <p className="text-lg font-mono text-white">{drone.home.distance_m.toFixed(0)} m</p>
+            </div>
+            <div className="bg-gray-900 rounded-lg p-3">
+              <p className="text-xs text-gray-500">Bearing to Home</p>
+              <p className="text-lg font-mono text-white">{drone.home.bearing_deg.toFixed(0)}°</p>
+            </div>
+          </div>
+          <div className="mt-3 text-xs text-gray-500">
+            {drone.home.lat.toFixed(6)}, {drone.home.lon.toFixed(6)} ({drone.home.source === 'autopilot' ? 'set by autopilot' : 'where armed'})
+          </div>
+        </section>
+      )}
+
       {/* Attitude Section */}
       <section className="mb-6">
         <h3 className="text-sm font-semibold text-gray-400 uppercase tracking-wider mb-3">
@@ -2,7 +2,9 @@
 
 import { useEffect, useRef, useCallback } from 'react';
 import { useDroneStore } from '../store/droneStore';
-import type { WSMessage, DroneState } from '../api/types';
+import { useAlertStore } from '../store/alertStore';
+import { useAuthStore } from '../store/authStore';
+import type { WSMessage, DroneState, AlertsAcked, AdapterLink, WSAuthAck, WSError } from '../api/types';
 
 const RECONNECT_INTERVAL = 3000;
 const MAX_RECONNECT_ATTEMPTS = 10;
@@ -20,6 +22,7 @@ export function useWebSocket(options: UseWebSocketOptions = {}) {
   const setDrone = useDroneStore((state) => state.setDrone);
   const removeDrone = useDroneStore((state) => state.removeDrone);
   const setConnected = useDroneStore((state) => state.setConnected);
+  const markAcknowledged = useAlertStore((state) => state.markAcknowledged);
 
   const getWebSocketUrl = useCallback(() => {
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
@@ -49,6 +52,27 @@ export function useWebSocket(options: UseWebSocketOptions = {}) {
               removeDrone(message.device_id);
             }
             break;
+          case 'alerts_acknowledged':
+            if (message.data) {
+              markAcknowledged(message.data as AlertsAcked);
+            }
+            break;
+          case 'adapter_status':
+            if (message.data) {
+              const link = message.data as AdapterLink;
+              console.info(`[WebSocket] ${link.adapter} link ${link.connected ? 'open' : 'lost'} on ${link.endpoint}`);
+            }
+            break;
+          case 'auth_ack':
+            if (message.data) {
+              console.info('[WebSocket] Authenticated as', (message.data as WSAuthAck).username);
+            }
+            break;
+          case 'error':
+            if (message.data) {
+              console.warn('[WebSocket] Server error:', (message.data as WSError).message);
+            }
+            break;
           default:
             console.warn('Unknown WebSocket message type:', message.type);
         }
@@ -56,7 +80,7 @@ export function useWebSocket(options: UseWebSocketOptions = {}) {
         console.error('Failed to parse WebSocket message:', error);
       }
     },
-    [setDrone, removeDrone]
+    [setDrone, removeDrone, markAcknowledged]
   );
 
   const connect = useCallback(() => {
@@ -71,6 +95,11 @@ export function useWebSocket(options: UseWebSocketOptions = {}) {
 
     ws.onopen = () => {
       console.log('[WebSocket] Connected');
+      // Authenticate first, as required when the server runs in strict mode
+      const token = useAuthStore.getState().token;
+      if (token) {
+        ws.send(JSON.stringify({ type: 'auth', data: { token } }));
+      }
       setConnected(true);
       reconnectAttemptsRef.current = 0;
     };
@@ -10,7 +10,8 @@ import {
   useAlertsError,
   useAlertFilter,
 } from '../../store/alertStore';
-import type { AlertRule, AlertSeverity, AlertType } from '../../api/types';
+import { api } from '../../api/client';
+import type { AlertField, AlertRule, AlertSeverity, AlertType } from '../../api/types';
 
 // Severity badge colors
 const severityColors: Record<AlertSeverity, string> = {
@@ -25,6 +26,8 @@ const alertTypeNames: Record<AlertType, string> = {
   connection_lost: 'Connection Lost',
   signal_weak: 'Signal Weak',
   geofence_breach: 'Geofence Breach',
+  geofence_predicted: 'Predicted Geofence Breach',
+  geofence_dwell: 'Geofence Dwell',
   custom: 'Custom',
 };
 
@@ -38,6 +41,12 @@ const operatorNames: Record<string, string> = {
   '!=': 'Not equal to',
 };
 
+// Devices a rule is limited to, e.g. "fw-* · mavlink"; empty for all devices
+function ruleScope(rule: AlertRule): string {
+  const devices = [...(rule.device_ids ?? []), rule.device_pattern].filter(Boolean).join(', ');
+  return [devices, rule.protocol_source].filter(Boolean).join(' · ');
+}
+
 type TabType = 'alerts' | 'rules';
 
 export default function AlertsPage() {
@@ -323,6 +332,9 @@ export default function AlertsPage() {
                       </td>
                       <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500 dark:text-gray-400">
                         {rule.condition.field} {rule.condition.operator} {rule.condition.threshold}
+                        {ruleScope(rule) && (
+                          <div className="text-xs text-gray-400 dark:text-gray-500">{ruleScope(rule)}</div>
+                        )}
                       </td>
                       <td className="px-6 py-4 whitespace-nowrap">
                         <span className={`px-2 py-1 text-xs font-medium rounded ${severityColors[rule.severity]}`}>
@@ -395,6 +407,22 @@ function RuleModal({ rule, onSave, onClose }: RuleModalProps) {
     cooldown_ms: rule?.cooldown_ms || 60000,
   });
 
+  const [fields, setFields] = useState<AlertField[]>([
+    { name: 'battery_percent', unit: '%', description: 'Battery level' },
+    { name: 'signal_quality', unit: '%', description: 'Link signal quality' },
+    { name: 'altitude', unit: 'm', description: 'GNSS altitude' },
+    { name: 'ground_speed', unit: 'm/s', description: 'Horizontal speed' },
+  ]);
+
+  useEffect(() => {
+    api
+      .getAlertFields()
+      .then((response) => setFields(response.fields))
+      .catch(() => {
+        // Keep the built-in list
+      });
+  }, []);
+
   const handleSubmit = (e: React.FormEvent) => {
     e.preventDefault();
     onSave(formData);
@@ -471,10 +499,12 @@ function RuleModal({ rule, onSave, onClose }: RuleModalProps) {
                 onChange={(e) => handleConditionChange('field', e.target.value)}
                 className="px-3 py-2 border rounded-lg dark:bg-gray-700 dark:border-gray-600 dark:text-white"
               >
-                <option value="battery_percent">Battery %</option>
-                <option value="signal_quality">Signal Quality</option>
-                <option value="altitude">Altitude</option>
-                <option value="speed">Speed</option>
+                {fields.map((field) => (
+                  <option key={field.name} value={field.name} title={field.description}>
+                    {field.name}
+                    {field.unit ? ` (${field.unit})` : ''}
+                  </option>
+                ))}
               </select>
               <select
                 value={formData.condition?.operator}
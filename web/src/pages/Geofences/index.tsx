@@ -315,7 +315,9 @@ export default function GeofencesPage() {
                             : 'bg-blue-100 text-blue-800 dark:bg-blue-900 dark:text-blue-300'
                         }`}
                       >
-                        {breach.type === 'enter' ? 'ENTERED' : 'EXITED'}
+                        {breach.type === 'dwell'
+                          ? (breach.inside ? 'LOITERING' : 'STAYED OUT')
+                          : (breach.type === 'enter' ? 'ENTERED' : 'EXITED')}
                       </span>
                       <span className="text-sm text-gray-900 dark:text-white font-medium">
                         {gf?.name || breach.geofence_id}
@@ -6,8 +6,13 @@ import {
   useLogsError,
   useLogsStreaming,
   useLogFilter,
+  useLogStats,
+  useLogLevels,
 } from '../../store/logStore';
-import type { LogLevel, LogEntry } from '../../api/types';
+import type { LogLevel, LogEntry, LogCounts } from '../../api/types';
+
+const LEVELS: LogLevel[] = ['debug', 'info', 'warn', 'error'];
+const STATS_REFRESH_MS = 10000;
 
 const levelColors: Record<LogLevel, string> = {
   debug: 'text-gray-400',
@@ -46,6 +51,72 @@ function LogEntryRow({ log }: { log: LogEntry }) {
   );
 }
 
+function totalCount(counts: LogCounts): number {
+  return LEVELS.reduce((sum, level) => sum + (counts.levels[level] ?? 0), 0);
+}
+
+// Per-source counts by level; clicking a source filters the log, and the
+// select overrides the source's log level at runtime
+function SourceFacets({ selected, onSelect }: { selected: string; onSelect: (source: string) => void }) {
+  const stats = useLogStats();
+  const levels = useLogLevels();
+  const setSourceLevel = useLogStore((state) => state.setSourceLevel);
+
+  if (!stats) {
+    return null;
+  }
+  const sources = Object.entries(stats.sources).sort(([, a], [, b]) => totalCount(b) - totalCount(a));
+
+  return (
+    <div className="w-72 shrink-0 bg-slate-900 rounded-lg border border-slate-700 overflow-auto">
+      <div className="px-3 py-2 border-b border-slate-700 flex items-center justify-between text-xs text-slate-400">
+        <span>Sources (default level: {levels?.default ?? '-'})</span>
+        {stats.suppressed > 0 && <span title="Entries dropped by level filters">{stats.suppressed} suppressed</span>}
+      </div>
+      {sources.map(([source, counts]) => (
+        <div
+          key={source}
+          className={`px-3 py-2 border-b border-slate-800 text-sm ${selected === source ? 'bg-slate-700/50' : ''}`}
+        >
+          <div className="flex items-center justify-between gap-2">
+            <button
+              onClick={() => onSelect(selected === source ? '' : source)}
+              className="text-cyan-400 hover:underline truncate text-left"
+            >
+              {source}
+            </button>
+            <select
+              value={levels?.sources[source] ?? ''}
+              onChange={(e) => setSourceLevel(source, e.target.value as LogLevel | '')}
+              className="px-1 py-0.5 bg-slate-700 border border-slate-600 rounded text-white text-xs"
+              title="Log level for this source"
+            >
+              <option value="">Default</option>
+              {LEVELS.map((level) => (
+                <option key={level} value={level}>
+                  {level}
+                </option>
+              ))}
+            </select>
+          </div>
+          <div className="mt-1 flex gap-2 font-mono text-xs">
+            {LEVELS.map((level) => (
+              <span key={level} className={levelColors[level]} title={level}>
+                {counts.levels[level] ?? 0}
+              </span>
+            ))}
+            {counts.suppressed > 0 && (
+              <span className="text-slate-500" title="Suppressed by the level filter">
+                -{counts.suppressed}
+              </span>
+            )}
+          </div>
+        </div>
+      ))}
+    </div>
+  );
+}
+
 export function LogViewer() {
   const logs = useLogs();
   const isLoading = useLogsLoading();
@@ -58,6 +129,7 @@ export function LogViewer() {
   const setFilter = useLogStore((state) => state.setFilter);
   const startStreaming = useLogStore((state) => state.startStreaming);
   const stopStreaming = useLogStore((state) => state.stopStreaming);
+  const fetchStats = useLogStore((state) => state.fetchStats);
 
   const logContainerRef = useRef<HTMLDivElement>(null);
   const autoScrollRef = useRef(true);
@@ -70,6 +142,13 @@ export function LogViewer() {
     };
   }, [fetchLogs, stopStreaming]);
 
+  // Refresh the source facets periodically
+  useEffect(() => {
+    fetchStats();
+    const timer = setInterval(fetchStats, STATS_REFRESH_MS);
+    return () => clearInterval(timer);
+  }, [fetchStats]);
+
   // Auto-scroll to bottom when new logs arrive
   useEffect(() => {
     if (autoScrollRef.current && logContainerRef.current) {
@@ -96,6 +175,13 @@ export function LogViewer() {
     }
   };
 
+  const handleSourceSelect = (source: string) => {
+    setFilter({ source });
+    if (!isStreaming) {
+      fetchLogs();
+    }
+  };
+
   const handleToggleStreaming = () => {
     if (isStreaming) {
       stopStreaming();
@@ -187,23 +273,27 @@ export function LogViewer() {
         </div>
       )}
 
-      {/* Log Container */}
-      <div
-        ref={logContainerRef}
-        onScroll={handleScroll}
-        className="flex-1 bg-slate-900 rounded-lg border border-slate-700 overflow-auto"
-      >
-        {logs.length === 0 ? (
-          <div className="flex items-center justify-center h-full text-slate-500">
-            {isLoading ? 'Loading logs...' : 'No logs available'}
-          </div>
-        ) : (
-          <div className="py-2">
-            {logs.map((log) => (
-              <LogEntryRow key={log.id} log={log} />
-            ))}
-          </div>
-        )}
+      <div className="flex-1 flex gap-4 min-h-0">
+        <SourceFacets selected={filter.source} onSelect={handleSourceSelect} />
+
+        {/* Log Container */}
+        <div
+          ref={logContainerRef}
+          onScroll={handleScroll}
+          className="flex-1 bg-slate-900 rounded-lg border border-slate-700 overflow-auto"
+        >
+          {logs.length === 0 ? (
+            <div className="flex items-center justify-center h-full text-slate-500">
+              {isLoading ? 'Loading logs...' : 'No logs available'}
+            </div>
+          ) : (
+            <div className="py-2">
+              {logs.map((log) => (
+                <LogEntryRow key={log.id} log={log} />
+              ))}
+            </div>
+          )}
+        </div>
       </div>
 
       {/* Status Bar */}
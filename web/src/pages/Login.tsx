@@ -62,7 +62,7 @@ export function Login() {
 
     try {
       const response = await api.login({ username, password });
-      setAuth(response.token, response.user, response.expires_at);
+      setAuth(response.token, response.user, response.expires_at, response.refresh_token, response.refresh_expires_at);
       navigate(from, { replace: true });
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Login failed');
@@ -8,6 +8,9 @@ import { api } from '../api/client';
 import type { TrackPoint } from '../api/types';
 import dayjs from 'dayjs';
 
+// Long tracks are decimated server-side to keep the map responsive
+const MAX_MAP_POINTS = 500;
+
 export function TrackHistory() {
   const [searchParams, setSearchParams] = useSearchParams();
   const drones = useDroneStore((state) => Array.from(state.drones.values()));
@@ -32,7 +35,7 @@ export function TrackHistory() {
     setError(null);
 
     try {
-      const response = await api.getTrack(selectedDroneId, limit);
+      const response = await api.getTrack(selectedDroneId, limit, undefined, MAX_MAP_POINTS);
       setTrackPoints(response.points);
       setTotalSize(response.total_size);
     } catch (err) {
@@ -1,7 +1,7 @@
 // Zustand store for alert state management
 
 import { create } from 'zustand';
-import type { Alert, AlertRule } from '../api/types';
+import type { Alert, AlertRule, AlertsAcked } from '../api/types';
 import { api } from '../api/client';
 
 interface AlertStats {
@@ -27,6 +27,7 @@ interface AlertStore {
   fetchAlerts: () => Promise<void>;
   fetchRules: () => Promise<void>;
   acknowledgeAlert: (alertId: string) => Promise<void>;
+  markAcknowledged: (acked: AlertsAcked) => void;
   clearAlerts: () => Promise<void>;
   createRule: (rule: Partial<AlertRule>) => Promise<void>;
   updateRule: (ruleId: string, rule: Partial<AlertRule>) => Promise<void>;
@@ -100,6 +101,24 @@ export const useAlertStore = create<AlertStore>((set, get) => ({
     }
   },
 
+  // Applies an acknowledgement made on any dashboard, received over WebSocket
+  markAcknowledged: (acked: AlertsAcked) => {
+    const ids = new Set(acked.ids);
+    set((state) => {
+      const cleared = state.alerts.filter((a) => ids.has(a.id) && !a.acknowledged).length;
+      return {
+        alerts: state.alerts.map((a) =>
+          ids.has(a.id) && !a.acknowledged
+            ? { ...a, acknowledged: true, acked_at: acked.acked_at, acked_by: acked.acked_by }
+            : a
+        ),
+        stats: state.stats
+          ? { ...state.stats, unacknowledged: Math.max(0, state.stats.unacknowledged - cleared) }
+          : null,
+      };
+    });
+  },
+
   clearAlerts: async () => {
     try {
       await api.clearAlerts();
@@ -13,11 +13,13 @@ interface AuthStore {
   token: string | null;
   user: User | null;
   expiresAt: number | null;
+  refreshToken: string | null;
+  refreshExpiresAt: number | null; // End of the session
   authEnabled: boolean | null; // null = unknown, fetching
   isAuthenticated: boolean;
 
   // Actions
-  setAuth: (token: string, user: User, expiresAt: number) => void;
+  setAuth: (token: string, user: User, expiresAt: number, refreshToken?: string, refreshExpiresAt?: number) => void;
   setAuthEnabled: (enabled: boolean) => void;
   logout: () => void;
   isTokenExpired: () => boolean;
@@ -30,15 +32,19 @@ export const useAuthStore = create<AuthStore>()(
       token: null,
       user: null,
       expiresAt: null,
+      refreshToken: null,
+      refreshExpiresAt: null,
       authEnabled: null,
       isAuthenticated: false,
 
       // Actions
-      setAuth: (token: string, user: User, expiresAt: number) =>
+      setAuth: (token: string, user: User, expiresAt: number, refreshToken?: string, refreshExpiresAt?: number) =>
         set({
           token,
           user,
           expiresAt,
+          refreshToken: refreshToken ?? null,
+          refreshExpiresAt: refreshExpiresAt ?? null,
           isAuthenticated: true,
         }),
 
@@ -50,13 +56,18 @@ export const useAuthStore = create<AuthStore>()(
           token: null,
           user: null,
           expiresAt: null,
+          refreshToken: null,
+          refreshExpiresAt: null,
           isAuthenticated: false,
         }),
 
+      // The session is over once the access token has expired and it
+      // cannot be refreshed any more
       isTokenExpired: () => {
-        const { expiresAt } = get();
-        if (!expiresAt) return true;
-        return Date.now() / 1000 > expiresAt;
+        const { expiresAt, refreshToken, refreshExpiresAt } = get();
+        const end = refreshToken ? refreshExpiresAt : expiresAt;
+        if (!end) return true;
+        return Date.now() / 1000 > end;
       },
     }),
     {
@@ -65,6 +76,8 @@ export const useAuthStore = create<AuthStore>()(
         token: state.token,
         user: state.user,
         expiresAt: state.expiresAt,
+        refreshToken: state.refreshToken,
+        refreshExpiresAt: state.refreshExpiresAt,
         isAuthenticated: state.isAuthenticated,
       }),
     }
@@ -1,7 +1,7 @@
 // Zustand store for log state management
 
 import { create } from 'zustand';
-import type { LogEntry, LogLevel } from '../api/types';
+import type { LogEntry, LogLevel, LogLevels, LogStats } from '../api/types';
 import { api } from '../api/client';
 
 interface LogStore {
@@ -14,6 +14,8 @@ interface LogStore {
     level: LogLevel;
     source: string;
   };
+  stats: LogStats | null;
+  levels: LogLevels | null;
 
   // SSE connection
   eventSource: EventSource | null;
@@ -23,6 +25,8 @@ interface LogStore {
   clearLogs: () => Promise<void>;
   addLog: (log: LogEntry) => void;
   setFilter: (filter: Partial<{ level: LogLevel; source: string }>) => void;
+  fetchStats: () => Promise<void>;
+  setSourceLevel: (source: string, level: LogLevel | '') => Promise<void>;
   startStreaming: () => void;
   stopStreaming: () => void;
   clear: () => void;
@@ -40,6 +44,8 @@ export const useLogStore = create<LogStore>((set, get) => ({
     level: 'info',
     source: '',
   },
+  stats: null,
+  levels: null,
   eventSource: null,
 
   // Actions
@@ -89,6 +95,35 @@ export const useLogStore = create<LogStore>((set, get) => ({
     }));
   },
 
+  fetchStats: async () => {
+    try {
+      const [stats, levels] = await Promise.all([api.getLogStats(), api.getLogLevels()]);
+      set({ stats, levels });
+    } catch (err) {
+      set({
+        error: err instanceof Error ? err.message : 'Failed to load log stats',
+      });
+    }
+  },
+
+  // Sets or, with an empty level, removes the level override of a source
+  setSourceLevel: async (source, level) => {
+    const { levels } = get();
+    const sources = { ...(levels?.sources ?? {}) };
+    if (level) {
+      sources[source] = level;
+    } else {
+      delete sources[source];
+    }
+    try {
+      set({ levels: await api.updateLogLevels({ sources }) });
+    } catch (err) {
+      set({
+        error: err instanceof Error ? err.message : 'Failed to update log levels',
+      });
+    }
+  },
+
   startStreaming: () => {
     const { eventSource, filter } = get();
 
@@ -162,3 +197,5 @@ export const useLogsLoading = () => useLogStore((state) => state.isLoading);
 export const useLogsError = () => useLogStore((state) => state.error);
 export const useLogsStreaming = () => useLogStore((state) => state.isStreaming);
 export const useLogFilter = () => useLogStore((state) => state.filter);
+export const useLogStats = () => useLogStore((state) => state.stats);
+export const useLogLevels = () => useLogStore((state) => state.levels);
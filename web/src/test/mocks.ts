@@ -22,6 +22,7 @@ export const mockDroneState: DroneState = {
     vy: 3.0,
     vz: -1.0,
   },
+  freshness: {},
   status: {
     armed: true,
     flight_mode: 'LOITER',
@@ -53,6 +54,7 @@ export const mockDroneState2: DroneState = {
     vy: 0,
     vz: 0,
   },
+  freshness: {},
   status: {
     armed: false,
     flight_mode: 'LANDING',